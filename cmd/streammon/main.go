@@ -13,9 +13,14 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	// Embedded so IANA timezone preferences resolve on images without tzdata.
+	_ "time/tzdata"
 
 	"streammon/internal/auth"
 	"streammon/internal/crypto"
+	"streammon/internal/demo"
+	"streammon/internal/digest"
+	"streammon/internal/diskcache"
 	"streammon/internal/geoip"
 	"streammon/internal/media"
 	"streammon/internal/models"
@@ -27,6 +32,7 @@ import (
 	"streammon/internal/store"
 	"streammon/internal/tmdb"
 	"streammon/internal/version"
+	"streammon/internal/webhooks"
 )
 
 var Version = "dev"
@@ -63,6 +69,12 @@ func main() {
 		log.Println("WARNING: TOKEN_ENCRYPTION_KEY not set — secrets will be stored unencrypted. Generate one with: openssl rand -base64 32")
 	}
 
+	// Migrate backs up an existing database here before changing its
+	// schema, so a bad upgrade can be rolled back along with the image.
+	if dbPath != ":memory:" {
+		storeOpts = append(storeOpts, store.WithBackupDir(envOr("BACKUP_DIR", filepath.Join(filepath.Dir(dbPath), "backups"))))
+	}
+
 	s, err := store.New(dbPath, storeOpts...)
 	if err != nil {
 		log.Fatalf("opening database: %v", err)
@@ -86,13 +98,24 @@ func main() {
 		}
 	}
 
-	if err := s.CleanupZombieSessions(); err != nil {
-		log.Printf("zombie session cleanup: %v", err)
+	// DEMO_MODE fills an empty database with made-up data for demos and
+	// UI development.
+	if os.Getenv("DEMO_MODE") == "true" {
+		seedDemoData(s)
 	}
 
+	geoProvider, err := geoip.ParseProvider(os.Getenv("GEOIP_PROVIDER"))
+	if err != nil {
+		log.Fatalf("GEOIP_PROVIDER: %v", err)
+	}
 	geoDBPath := envOr("GEOIP_DB", "./geoip/GeoLite2-City.mmdb")
-	geoResolver := geoip.NewResolver(geoDBPath)
+	geoResolver := geoip.NewProviderResolver(geoProvider, geoDBPath)
 	defer geoResolver.Close()
+	if overrides, err := s.ListGeoOverrides(); err != nil {
+		log.Printf("loading geo overrides: %v", err)
+	} else {
+		geoResolver.SetOverrides(overrides)
+	}
 
 	geoUpdater := geoip.NewUpdater(s, geoResolver, geoDBPath)
 
@@ -128,8 +151,40 @@ func main() {
 	log.Printf("Auth providers: %v", authMgr.GetEnabledProviders())
 
 	rulesGeo := &geoAdapter{resolver: geoResolver}
+	// Posters and import uploads can outgrow a small data volume, so they
+	// live under CACHE_DIR, which can point somewhere roomier than DB_PATH.
+	cacheDir := envOr("CACHE_DIR", filepath.Join(filepath.Dir(dbPath), "cache"))
+	posterCacheMB := 512
+	if v := os.Getenv("POSTER_CACHE_MAX_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			posterCacheMB = n
+		} else {
+			log.Printf("WARNING: invalid POSTER_CACHE_MAX_MB %q, using default %d", v, posterCacheMB)
+		}
+	}
+	var posterCache *diskcache.Cache
+	if posterCacheMB > 0 {
+		posterCache, err = diskcache.Open(filepath.Join(cacheDir, "posters"), int64(posterCacheMB)<<20)
+		if err != nil {
+			log.Printf("WARNING: poster cache disabled: %v", err)
+		} else {
+			log.Printf("Poster cache: %s (max %d MB)", filepath.Join(cacheDir, "posters"), posterCacheMB)
+		}
+	}
+
 	rulesEngine := rules.NewEngine(s, rulesGeo, rules.DefaultEngineConfig())
-	rulesEngine.SetNotifier(notifier.New())
+	notifierOpts := []notifier.Option{
+		notifier.WithPosterFetcher(server.NewPosterFetcher(s, posterCache)),
+		notifier.WithHistory(s),
+	}
+	// Push services may use this contact to reach whoever runs the server.
+	if v := os.Getenv("WEB_PUSH_SUBJECT"); v != "" {
+		notifierOpts = append(notifierOpts, notifier.WithPushSubject(v))
+	}
+	notify := notifier.New(notifierOpts...)
+	rulesEngine.SetNotifier(notify)
+	outboundWebhooks := webhooks.New(s)
+	rulesEngine.SetWebhooks(outboundWebhooks)
 	// ServerResolver is set after poller creation below
 
 	pollInterval := 5 * time.Second
@@ -155,6 +210,7 @@ func main() {
 		poller.WithRulesEngine(rulesEngine),
 		poller.WithHouseholdAutoLearn(autoLearnMinSessions),
 		poller.WithGeoResolver(geoResolver),
+		poller.WithSessionObserver(outboundWebhooks),
 	)
 	rulesEngine.SetServerResolver(p)
 
@@ -173,6 +229,7 @@ func main() {
 			continue
 		}
 		p.AddServer(srv.ID, ms)
+		p.SetServerPolling(srv.ID, time.Duration(srv.PollIntervalSeconds)*time.Second, srv.Paused)
 		enabledCount++
 	}
 	log.Printf("Servers: %d enabled, %d total", enabledCount, len(servers))
@@ -185,6 +242,8 @@ func main() {
 			schOpts = append(schOpts, scheduler.WithSyncTimeout(d))
 		}
 	}
+	digests := digest.New(s, notify)
+	schOpts = append(schOpts, scheduler.WithDigests(digests), scheduler.WithWebhooks(outboundWebhooks))
 	sch := scheduler.New(s, p, tmdbClient, schOpts...)
 
 	vc := version.NewChecker(Version)
@@ -200,12 +259,26 @@ func main() {
 		server.WithRulesEngine(rulesEngine),
 		server.WithVersion(vc),
 		server.WithTMDBClient(tmdbClient),
+		server.WithWebhooks(outboundWebhooks),
+		server.WithDigests(digests),
 		server.WithAppContext(ctx),
+		server.WithImportDir(filepath.Join(cacheDir, "imports")),
+	}
+	if posterCache != nil {
+		opts = append(opts, server.WithPosterCache(posterCache))
 	}
 	if corsOrigin != "" {
 		opts = append(opts, server.WithCORSOrigin(corsOrigin))
 	}
+	if os.Getenv("METRICS_ENABLED") == "true" {
+		metricsToken := optionalSecret("METRICS_TOKEN")
+		if metricsToken == "" {
+			log.Println("WARNING: /metrics is enabled without METRICS_TOKEN; anyone who can reach StreamMon can read it")
+		}
+		opts = append(opts, server.WithMetrics(metricsToken))
+	}
 	srv := server.NewServer(s, opts...)
+	srv.ResumeImportJobs()
 
 	httpServer := &http.Server{
 		Addr:              listenAddr,
@@ -257,9 +330,10 @@ func main() {
 		srv.WaitEnrichment()
 		srv.WaitAutoSync()
 		srv.WaitLibrarySync()
+		srv.WaitImportJobs()
 		rulesEngine.WaitForNotifications()
-		server.StopRateLimiter()
-		server.StopAuthRateLimiter()
+		outboundWebhooks.Wait()
+		srv.StopRateLimiters()
 	})
 }
 
@@ -284,6 +358,26 @@ func runBoundedCleanup(ctx context.Context, fn func()) {
 	}
 }
 
+// seedDemoData generates demo data unless the database already has servers,
+// so DEMO_MODE never mixes made-up plays into a real household's history.
+func seedDemoData(s *store.Store) {
+	servers, err := s.ListAllServers()
+	if err != nil {
+		log.Printf("demo mode: listing servers: %v", err)
+		return
+	}
+	if len(servers) > 0 {
+		log.Println("demo mode: database already has servers, not generating demo data")
+		return
+	}
+	result, err := demo.Generate(context.Background(), s, demo.Options{})
+	if err != nil {
+		log.Printf("demo mode: %v", err)
+		return
+	}
+	log.Printf("demo mode: generated %d servers, %d users and %d plays", result.Servers, result.Users, result.Plays)
+}
+
 func envOr(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -34,6 +34,11 @@ func New(name, baseURL, apiKey string) (*Client, error) {
 	}, nil
 }
 
+// Configure applies per-integration proxy, TLS, and timeout settings.
+func (c *Client) Configure(opts httputil.ClientOptions) error {
+	return opts.Apply(c.HTTP)
+}
+
 func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader) (json.RawMessage, error) {
 	u := c.BaseURL + "/api/v3" + path
 	if len(query) > 0 {
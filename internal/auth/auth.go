@@ -59,9 +59,11 @@ const stateCookieName = "oidc_state"
 const nonceCookieName = "oidc_nonce"
 
 type oidcProvider struct {
-	provider *gooidc.Provider
-	oauth2   oauth2.Config
-	verifier *gooidc.IDTokenVerifier
+	provider   *gooidc.Provider
+	oauth2     oauth2.Config
+	verifier   *gooidc.IDTokenVerifier
+	logout     *logoutTokenVerifier
+	endSession string // end_session_endpoint, when the IdP advertises one
 }
 
 func buildProvider(ctx context.Context, cfg Config) (*oidcProvider, error) {
@@ -72,8 +74,15 @@ func buildProvider(ctx context.Context, cfg Config) (*oidcProvider, error) {
 	if err != nil {
 		return nil, err
 	}
+	var meta struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := provider.Claims(&meta); err != nil {
+		return nil, err
+	}
 	return &oidcProvider{
-		provider: provider,
+		provider:   provider,
+		endSession: meta.EndSessionEndpoint,
 		oauth2: oauth2.Config{
 			ClientID:     cfg.ClientID,
 			ClientSecret: cfg.ClientSecret,
@@ -82,6 +91,7 @@ func buildProvider(ctx context.Context, cfg Config) (*oidcProvider, error) {
 			Scopes:       parseScopes(cfg.Scopes),
 		},
 		verifier: provider.Verifier(&gooidc.Config{ClientID: cfg.ClientID}),
+		logout:   newLogoutTokenVerifier(provider, cfg.ClientID),
 	}, nil
 }
 
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+)
+
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// logoutTokenVerifier checks OpenID Connect Back-Channel Logout tokens. The
+// signature, issuer, audience and expiry are checked as for an ID token; on
+// top of that a logout token must carry the logout event, a jti and an iat,
+// must name a sub or sid, and must not carry a nonce, so an ID token can't
+// be passed off as one. A jti is remembered until its token expires, so a
+// captured logout token can't be replayed.
+type logoutTokenVerifier struct {
+	verifier *gooidc.IDTokenVerifier
+	now      func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // jti -> expiry of the token that used it
+}
+
+func newLogoutTokenVerifier(provider *gooidc.Provider, clientID string) *logoutTokenVerifier {
+	return &logoutTokenVerifier{
+		verifier: provider.Verifier(&gooidc.Config{ClientID: clientID}),
+		now:      time.Now,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// logoutToken is what a verified logout token asks to end: every session
+// of Subject, the IdP session Sid, or both.
+type logoutToken struct {
+	Subject string
+	Sid     string
+}
+
+var (
+	errNotLogoutToken   = errors.New("not a logout token")
+	errLogoutNoSubject  = errors.New("logout_token names no sub or sid")
+	errLogoutReplayed   = errors.New("logout_token already used")
+	errLogoutBadClaims  = errors.New("invalid logout_token claims")
+	errLogoutMissingJTI = errors.New("logout_token has no jti")
+)
+
+func (v *logoutTokenVerifier) Verify(ctx context.Context, raw string) (*logoutToken, error) {
+	token, err := v.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	var claims struct {
+		Sid    string                     `json:"sid"`
+		JTI    string                     `json:"jti"`
+		Nonce  json.RawMessage            `json:"nonce"`
+		Events map[string]json.RawMessage `json:"events"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return nil, errLogoutBadClaims
+	}
+	event, ok := claims.Events[backchannelLogoutEvent]
+	if !ok || len(event) == 0 || event[0] != '{' || claims.Nonce != nil {
+		return nil, errNotLogoutToken
+	}
+	if token.IssuedAt.IsZero() {
+		return nil, errLogoutBadClaims
+	}
+	if claims.JTI == "" {
+		return nil, errLogoutMissingJTI
+	}
+	if token.Subject == "" && claims.Sid == "" {
+		return nil, errLogoutNoSubject
+	}
+	if !v.remember(claims.JTI, token.Expiry) {
+		return nil, errLogoutReplayed
+	}
+	return &logoutToken{Subject: token.Subject, Sid: claims.Sid}, nil
+}
+
+// remember records jti until expiry, reporting false if it was already
+// recorded and hasn't expired. Expired entries are dropped as it goes.
+func (v *logoutTokenVerifier) remember(jti string, expiry time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	for id, exp := range v.seen {
+		if now.After(exp) {
+			delete(v.seen, id)
+		}
+	}
+	if _, ok := v.seen[jti]; ok {
+		return false
+	}
+	v.seen[jti] = expiry
+	return true
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -64,7 +65,7 @@ func (m *Manager) Reload() error {
 
 
 func (m *Manager) CreateSession(w http.ResponseWriter, r *http.Request, userID int64) error {
-	token, err := m.store.CreateSession(userID, time.Now().UTC().Add(SessionDuration))
+	token, err := m.store.CreateLoginSession(userID, time.Now().UTC().Add(SessionDuration), sessionClient(r), store.OIDCSession{})
 	if err != nil {
 		return err
 	}
@@ -73,6 +74,28 @@ func (m *Manager) CreateSession(w http.ResponseWriter, r *http.Request, userID i
 	return nil
 }
 
+// CreateOIDCSession is CreateSession for an OIDC login, remembering the IdP
+// login so logging out at either end can find the session.
+func (m *Manager) CreateOIDCSession(w http.ResponseWriter, r *http.Request, userID int64, login store.OIDCSession) error {
+	token, err := m.store.CreateLoginSession(userID, time.Now().UTC().Add(SessionDuration), sessionClient(r), login)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, makeCookie(CookieName, token, "/", int(SessionDuration.Seconds()), r))
+	return nil
+}
+
+// sessionClient describes the client signing in with r, for the session
+// list.
+func sessionClient(r *http.Request) store.SessionClient {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return store.SessionClient{IPAddress: ip, UserAgent: r.UserAgent()}
+}
+
 // CreateSessionAndRespond creates session and writes user JSON.
 // statusCode: http.StatusOK for login, http.StatusCreated for setup.
 func (m *Manager) CreateSessionAndRespond(w http.ResponseWriter, r *http.Request, user *models.User, statusCode int) error {
@@ -127,11 +150,41 @@ func (m *Manager) HandleGetStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// logoutRedirector is implemented by providers that can also end the login
+// at the identity provider.
+type logoutRedirector interface {
+	LogoutURL(idTokenHint string) string
+}
+
+// HandleLogout deletes the caller's session. For an OIDC session whose IdP
+// supports RP-initiated logout, the response carries logout_url for the
+// browser to visit so the IdP session ends too.
 func (m *Manager) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	resp := struct {
+		Status    string `json:"status"`
+		LogoutURL string `json:"logout_url,omitempty"`
+	}{Status: "ok"}
 	if cookie, err := r.Cookie(CookieName); err == nil {
+		resp.LogoutURL = m.idpLogoutURL(cookie.Value)
 		m.store.DeleteSession(cookie.Value)
 	}
 	http.SetCookie(w, clearCookie(CookieName, "/", r))
 	w.Header().Set("Content-Type", "application/json")
-	_, _ = w.Write([]byte(`{"status":"ok"}`))
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (m *Manager) idpLogoutURL(token string) string {
+	login, err := m.store.GetSessionOIDC(token)
+	if err != nil || login.Subject == "" {
+		return ""
+	}
+	p, ok := m.GetProvider(ProviderOIDC)
+	if !ok {
+		return ""
+	}
+	lr, ok := p.(logoutRedirector)
+	if !ok {
+		return ""
+	}
+	return lr.LogoutURL(login.IDToken)
 }
@@ -2,8 +2,10 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
+	"net/url"
 	"sync"
 
 	gooidc "github.com/coreos/go-oidc/v3/oidc"
@@ -21,7 +23,10 @@ type OIDCProvider struct {
 	provider   *gooidc.Provider
 	oauth2     oauth2.Config
 	verifier   *gooidc.IDTokenVerifier
+	logout     *logoutTokenVerifier
 	adminGroup string
+	endSession string
+	postLogout string
 }
 
 func NewOIDCProvider(cfg Config, st *store.Store, mgr *Manager) (*OIDCProvider, error) {
@@ -56,7 +61,10 @@ func (p *OIDCProvider) Reload(ctx context.Context, cfg Config) error {
 		p.provider = nil
 		p.oauth2 = oauth2.Config{}
 		p.verifier = nil
+		p.logout = nil
 		p.adminGroup = ""
+		p.endSession = ""
+		p.postLogout = ""
 		p.mu.Unlock()
 		return nil
 	}
@@ -75,12 +83,25 @@ func (p *OIDCProvider) Reload(ctx context.Context, cfg Config) error {
 	p.provider = op.provider
 	p.oauth2 = op.oauth2
 	p.verifier = op.verifier
+	p.logout = op.logout
 	p.adminGroup = cfg.AdminGroup
+	p.endSession = op.endSession
+	p.postLogout = postLogoutRedirect(cfg.RedirectURL)
 	p.mu.Unlock()
 
 	return nil
 }
 
+// postLogoutRedirect is where the IdP sends the browser after an
+// RP-initiated logout: the login page on the host the callback lives on.
+func postLogoutRedirect(redirectURL string) string {
+	u, err := url.Parse(redirectURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/login"}).String()
+}
+
 func (p *OIDCProvider) getConfig() (bool, oauth2.Config, *gooidc.IDTokenVerifier, string) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -160,6 +181,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		EmailVerified bool     `json:"email_verified"`
 		Name          string   `json:"name"`
 		Sub           string   `json:"sub"`
+		Sid           string   `json:"sid"`
 		Groups        []string `json:"groups"`
 	}
 	if err := idToken.Claims(&claims); err != nil {
@@ -211,6 +233,9 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 			desiredRole := models.RoleViewer
 			if groupAdmin {
 				desiredRole = models.RoleAdmin
+			} else if user.Role == models.RoleCoAdmin {
+				// Co-admin is granted by hand; only the admin group is synced.
+				desiredRole = models.RoleCoAdmin
 			}
 			if user.Role != desiredRole {
 				if err := p.store.UpdateUserRoleByIDSafe(user.ID, desiredRole); err != nil {
@@ -223,7 +248,8 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := p.manager.CreateSession(w, r, user.ID); err != nil {
+	login := store.OIDCSession{Subject: claims.Sub, SessionID: claims.Sid, IDToken: rawIDToken}
+	if err := p.manager.CreateOIDCSession(w, r, user.ID, login); err != nil {
 		log.Printf("session creation error: %v", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
@@ -235,6 +261,74 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
+// LogoutURL returns the IdP's end_session_endpoint for an RP-initiated
+// logout of the login idTokenHint came from, or "" when the IdP doesn't
+// advertise one.
+func (p *OIDCProvider) LogoutURL(idTokenHint string) string {
+	p.mu.RLock()
+	enabled, endpoint, clientID, postLogout := p.enabled, p.endSession, p.oauth2.ClientID, p.postLogout
+	p.mu.RUnlock()
+	if !enabled || endpoint == "" {
+		return ""
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	if idTokenHint != "" {
+		q.Set("id_token_hint", idTokenHint)
+	}
+	q.Set("client_id", clientID)
+	if postLogout != "" {
+		q.Set("post_logout_redirect_uri", postLogout)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// HandleBackchannelLogout implements OpenID Connect Back-Channel Logout. The
+// IdP POSTs a signed logout token naming a user (sub), one of their IdP
+// sessions (sid), or both, and every streamMon session that login created is
+// deleted -- signing out at the IdP signs out here too.
+func (p *OIDCProvider) HandleBackchannelLogout(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	enabled, verifier := p.enabled, p.logout
+	p.mu.RUnlock()
+	if !enabled {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	raw := r.PostFormValue("logout_token")
+	if raw == "" {
+		backchannelLogoutError(w, "missing logout_token")
+		return
+	}
+	token, err := verifier.Verify(r.Context(), raw)
+	if err != nil {
+		log.Printf("oidc back-channel logout: token verify error: %v", err)
+		backchannelLogoutError(w, "invalid logout_token")
+		return
+	}
+
+	n, err := p.store.DeleteOIDCSessions(token.Subject, token.Sid)
+	if err != nil {
+		log.Printf("oidc back-channel logout: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("oidc back-channel logout: sub=%q sid=%q ended %d session(s)", token.Subject, token.Sid, n)
+	w.WriteHeader(http.StatusOK)
+}
+
+func backchannelLogoutError(w http.ResponseWriter, desc string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request", "error_description": desc})
+}
+
 func (p *OIDCProvider) isExistingAdmin(sub, email string) bool {
 	if existing, err := p.store.GetUserByProvider(string(ProviderOIDC), sub); err == nil {
 		return existing.Role == models.RoleAdmin
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -88,6 +89,20 @@ func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
 			"id_token":     env.nextIDToken,
 		})
 	})
+	// oidctest's discovery document has no end_session_endpoint; add one so
+	// RP-initiated logout can be exercised.
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		discovery.ServeHTTP(rec, r)
+		var doc map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		doc["end_session_endpoint"] = env.issuer + "/logout"
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(doc)
+	})
 	mux.Handle("/", discovery)
 
 	srv := httptest.NewTLSServer(mux)
@@ -108,6 +123,7 @@ func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
 	}); err != nil {
 		t.Fatalf("Reload: %v", err)
 	}
+	mgr.RegisterProvider(p)
 	env.provider = p
 
 	return env
@@ -132,6 +148,7 @@ func (e *oidcTestEnv) signIDToken(t *testing.T, nonce string) string {
 		"iss": %q,
 		"aud": %q,
 		"sub": "user-123",
+		"sid": "idp-session-1",
 		"exp": %d,
 		"iat": %d,
 		"email": "user@example.com",
@@ -305,3 +322,145 @@ func TestHandleCallback_StateMismatch_StillRejected(t *testing.T) {
 		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
 	}
 }
+
+// loginSession completes an OIDC login and returns the session cookie.
+func (e *oidcTestEnv) loginSession(t *testing.T) *http.Cookie {
+	t.Helper()
+	if err := e.store.SetGuestAccess(true); err != nil {
+		t.Fatalf("SetGuestAccess: %v", err)
+	}
+	_, stateCookie, nonceCookie := e.startLogin(t)
+	e.nextIDToken = e.signIDToken(t, nonceCookie.Value)
+	w := e.callback(t, stateCookie.Value, []*http.Cookie{stateCookie, nonceCookie})
+	for _, c := range w.Result().Cookies() {
+		if c.Name == CookieName && c.Value != "" {
+			return c
+		}
+	}
+	t.Fatalf("login did not set a session cookie: %d %s", w.Code, w.Body.String())
+	return nil
+}
+
+// signLogoutToken mints a logout token with the given jti (omitted when
+// empty) and extra claims, each written as `, "name": value`.
+func (e *oidcTestEnv) signLogoutToken(t *testing.T, jti, extra string) string {
+	t.Helper()
+	now := time.Now().UTC()
+	if jti != "" {
+		extra = fmt.Sprintf(`, "jti": %q`, jti) + extra
+	}
+	claims := fmt.Sprintf(`{
+		"iss": %q,
+		"aud": %q,
+		"iat": %d,
+		"exp": %d%s
+	}`, e.issuer, e.clientID, now.Unix(), now.Add(2*time.Minute).Unix(), extra)
+	return oidctest.SignIDToken(e.priv, e.keyID, gooidc.RS256, claims)
+}
+
+func (e *oidcTestEnv) backchannelLogout(token string) *httptest.ResponseRecorder {
+	form := url.Values{"logout_token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/backchannel-logout", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(e.clientCtx())
+	w := httptest.NewRecorder()
+	e.provider.HandleBackchannelLogout(w, req)
+	return w
+}
+
+func TestHandleLogout_RedirectsToIdP(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	session := env.loginSession(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(session)
+	w := httptest.NewRecorder()
+	env.provider.manager.HandleLogout(w, req)
+
+	var resp struct {
+		Status    string `json:"status"`
+		LogoutURL string `json:"logout_url"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(resp.LogoutURL)
+	if err != nil || resp.LogoutURL == "" {
+		t.Fatalf("logout_url = %q", resp.LogoutURL)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != env.issuer+"/logout" {
+		t.Errorf("logout endpoint = %q", got)
+	}
+	q := u.Query()
+	if q.Get("id_token_hint") == "" || q.Get("client_id") != env.clientID {
+		t.Errorf("missing id_token_hint or client_id: %v", q)
+	}
+	if got := q.Get("post_logout_redirect_uri"); got != "https://app.example.com/login" {
+		t.Errorf("post_logout_redirect_uri = %q", got)
+	}
+	if _, err := env.store.GetSessionUser(session.Value); err == nil {
+		t.Error("expected the local session to be deleted")
+	}
+}
+
+func TestHandleBackchannelLogout(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	session := env.loginSession(t)
+	const event = `, "events": {"http://schemas.openid.net/event/backchannel-logout": {}}`
+
+	// An ID token is not a logout token.
+	if w := env.backchannelLogout(env.signIDToken(t, "n")); w.Code != http.StatusBadRequest {
+		t.Errorf("ID token: expected 400, got %d", w.Code)
+	}
+	for _, tc := range []struct {
+		name, jti, extra string
+	}{
+		{"no sub or sid", "logout-1", event},
+		{"no jti", "", `, "sid": "idp-session-1"` + event},
+		{"nonce", "logout-1", `, "sid": "idp-session-1", "nonce": "n"` + event},
+		{"event not an object", "logout-1", `, "sid": "idp-session-1", "events": {"http://schemas.openid.net/event/backchannel-logout": true}`},
+		{"no events", "logout-1", `, "sid": "idp-session-1"`},
+	} {
+		if w := env.backchannelLogout(env.signLogoutToken(t, tc.jti, tc.extra)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, w.Code)
+		}
+	}
+	if _, err := env.store.GetSessionUser(session.Value); err != nil {
+		t.Fatal("rejected logout tokens must not end the session")
+	}
+
+	logout := env.signLogoutToken(t, "logout-1", `, "sid": "idp-session-1"`+event)
+	w := env.backchannelLogout(logout)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected Cache-Control: no-store")
+	}
+	if _, err := env.store.GetSessionUser(session.Value); err == nil {
+		t.Error("expected the session to be ended by back-channel logout")
+	}
+
+	// The same token, or another with its jti, is a replay.
+	if w := env.backchannelLogout(logout); w.Code != http.StatusBadRequest {
+		t.Errorf("replayed token: expected 400, got %d", w.Code)
+	}
+	if w := env.backchannelLogout(env.signLogoutToken(t, "logout-1", `, "sub": "user-123"`+event)); w.Code != http.StatusBadRequest {
+		t.Errorf("reused jti: expected 400, got %d", w.Code)
+	}
+}
+
+func TestLogoutTokenVerifierForgetsExpiredJTIs(t *testing.T) {
+	now := time.Now()
+	v := &logoutTokenVerifier{now: func() time.Time { return now }, seen: make(map[string]time.Time)}
+	if !v.remember("a", now.Add(time.Minute)) {
+		t.Fatal("first use of a jti must be accepted")
+	}
+	if v.remember("a", now.Add(time.Minute)) {
+		t.Error("reuse within the token lifetime must be rejected")
+	}
+	now = now.Add(2 * time.Minute)
+	if !v.remember("a", now.Add(time.Minute)) {
+		t.Error("a jti whose token has expired should be forgotten")
+	}
+}
@@ -13,6 +13,11 @@ const (
 	ProviderOIDC     ProviderType = "oidc"
 	ProviderEmby     ProviderType = "emby"
 	ProviderJellyfin ProviderType = "jellyfin"
+
+	// ProviderProxy marks accounts provisioned by trusted header
+	// authentication. It isn't a login provider: the reverse proxy in front
+	// does the login.
+	ProviderProxy ProviderType = "proxy"
 )
 
 // Provider defines the interface for authentication providers
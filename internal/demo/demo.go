@@ -0,0 +1,407 @@
+// Package demo generates realistic but entirely made-up servers, users,
+// watch history and geo data, so dashboards can be shown off and UI and API
+// integrations developed without a real household's viewing data.
+package demo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// Host is the URL host of every demo server. It's what Clear and Exists
+// recognize demo data by, and being under .invalid it can never resolve.
+const Host = "demo.streammon.invalid"
+
+const (
+	DefaultDays  = 90
+	DefaultUsers = 8
+	MaxDays      = 730
+	MaxUsers     = 20
+)
+
+// ErrExists is returned by Generate when demo data is already present.
+var ErrExists = errors.New("demo data already exists")
+
+// Store is what the generator needs from storage.
+type Store interface {
+	ListAllServers() ([]models.Server, error)
+	CreateServer(srv *models.Server) error
+	DeleteServer(id int64) error
+	GetOrCreateUser(name string) (*models.User, error)
+	SetCachedGeo(geo *models.GeoResult) error
+	InsertHistoryBatch(ctx context.Context, entries []*models.WatchHistoryEntry) (inserted, skipped, consolidated int, err error)
+}
+
+// Options sizes the generated data. Zero values take the defaults, and
+// a zero Seed picks one from the clock.
+type Options struct {
+	Days  int   `json:"days"`
+	Users int   `json:"users"`
+	Seed  int64 `json:"seed"`
+}
+
+func (o *Options) Validate() error {
+	if o.Days == 0 {
+		o.Days = DefaultDays
+	}
+	if o.Users == 0 {
+		o.Users = DefaultUsers
+	}
+	if o.Days < 1 || o.Days > MaxDays {
+		return fmt.Errorf("days must be between 1 and %d", MaxDays)
+	}
+	if o.Users < 1 || o.Users > MaxUsers {
+		return fmt.Errorf("users must be between 1 and %d", MaxUsers)
+	}
+	return nil
+}
+
+// Result counts what Generate created.
+type Result struct {
+	Servers int `json:"servers"`
+	Users   int `json:"users"`
+	Plays   int `json:"plays"`
+}
+
+type city struct {
+	name, country string
+	lat, lng      float64
+	// prefix is a documentation range (RFC 5737) block the city's
+	// addresses are drawn from, so no real address is ever attributed.
+	prefix string
+}
+
+var cities = []city{
+	{"Seattle", "US", 47.6062, -122.3321, "203.0.113."},
+	{"Denver", "US", 39.7392, -104.9903, "198.51.100."},
+	{"Chicago", "US", 41.8781, -87.6298, "192.0.2."},
+	{"Toronto", "CA", 43.6532, -79.3832, "203.0.113."},
+	{"London", "GB", 51.5074, -0.1278, "198.51.100."},
+	{"Berlin", "DE", 52.5200, 13.4050, "192.0.2."},
+}
+
+type isp struct {
+	name string
+	asn  uint
+}
+
+// ISPs use private-use ASNs (RFC 6996).
+var (
+	homeISPs  = []isp{{"Example Fiber", 64512}, {"Sample Cable", 64513}, {"Placeholder Broadband", 64514}}
+	mobileISP = isp{"Demo Mobile", 64520}
+)
+
+var userNames = []string{
+	"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi", "ivan", "judy",
+	"mallory", "niaj", "olivia", "peggy", "rupert", "sybil", "trent", "uma", "victor", "wendy",
+}
+
+type device struct{ player, platform string }
+
+var devices = []device{
+	{"Plex Web", "Chrome"},
+	{"Plex for Android (TV)", "Android"},
+	{"Plex for Roku", "Roku"},
+	{"Infuse", "tvOS"},
+	{"Jellyfin Web", "Firefox"},
+	{"Jellyfin Media Player", "Windows"},
+	{"Swiftfin", "iOS"},
+	{"Kodi", "Linux"},
+}
+
+type movie struct {
+	title  string
+	year   int
+	mins   int
+	rating string
+}
+
+var movies = []movie{
+	{"The Lighthouse Keeper", 2019, 118, "PG-13"},
+	{"Paper Moons", 2021, 96, "PG"},
+	{"Northbound", 2017, 132, "R"},
+	{"A Quiet Orchard", 2022, 104, "PG"},
+	{"Static Bloom", 2020, 111, "PG-13"},
+	{"The Cartographer's Daughter", 2016, 127, "PG-13"},
+	{"Glasswork", 2023, 99, "R"},
+	{"Ember & Tide", 2018, 121, "PG-13"},
+	{"Small Hours", 2015, 88, "R"},
+	{"The Last Ferry", 2024, 115, "PG-13"},
+	{"Copper Sky", 2019, 102, "PG"},
+	{"Understory", 2021, 137, "R"},
+}
+
+type show struct {
+	title    string
+	year     int
+	seasons  int
+	episodes int
+	mins     int
+	rating   string
+}
+
+var shows = []show{
+	{"Harbor Lights", 2018, 4, 10, 48, "TV-14"},
+	{"The Night Shift Bakery", 2020, 3, 8, 24, "TV-PG"},
+	{"Signal Lost", 2019, 2, 10, 52, "TV-MA"},
+	{"Field Notes", 2021, 2, 6, 45, "TV-PG"},
+	{"Parallel Lines", 2017, 5, 12, 42, "TV-14"},
+	{"Tiny Kingdoms", 2022, 1, 8, 30, "TV-G"},
+}
+
+type album struct {
+	artist, title string
+	tracks        []string
+}
+
+var albums = []album{
+	{"The Paper Boats", "Low Tide", []string{"Undertow", "Saltwater", "Driftwood", "Harbour Song"}},
+	{"Mira Vale", "Neon Gardens", []string{"Bloom", "After Hours", "Glow", "Static"}},
+	{"Northern Static", "Frequencies", []string{"Carrier", "Interference", "Long Wave"}},
+}
+
+type member struct {
+	name     string
+	serverID int64
+	home     city
+	homeIP   string
+	mobileIP string
+	devices  []device
+	// plays is the member's average plays per day.
+	plays float64
+}
+
+// Exists reports whether demo data has been generated and not cleared.
+func Exists(st Store) (bool, error) {
+	servers, err := demoServers(st)
+	return len(servers) > 0, err
+}
+
+// Generate creates two demo servers, their users, geo cache entries for the
+// users' addresses and opts.Days of watch history. Demo servers are created
+// disabled so nothing tries to poll them. It refuses with ErrExists if demo
+// data is already present.
+func Generate(ctx context.Context, st Store, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	exists, err := Exists(st)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrExists
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	servers := []*models.Server{
+		{Name: "Demo Plex", Type: models.ServerTypePlex, URL: "http://" + Host + ":32400", MachineID: "demo"},
+		{Name: "Demo Jellyfin", Type: models.ServerTypeJellyfin, URL: "http://" + Host + ":8096"},
+	}
+	for _, srv := range servers {
+		srv.APIKey = "demo"
+		if err := st.CreateServer(srv); err != nil {
+			return nil, fmt.Errorf("creating demo server: %w", err)
+		}
+	}
+
+	members := make([]member, opts.Users)
+	for i := range members {
+		home := cities[rng.Intn(3)] // households cluster in the first few cities
+		m := member{
+			name:     userNames[i],
+			serverID: servers[i%len(servers)].ID,
+			home:     home,
+			homeIP:   fmt.Sprintf("%s%d", home.prefix, 10+i),
+			mobileIP: fmt.Sprintf("%s%d", home.prefix, 100+i),
+			plays:    0.5 + rng.Float64()*2.5,
+		}
+		for _, j := range rng.Perm(len(devices))[:2] {
+			m.devices = append(m.devices, devices[j])
+		}
+		if _, err := st.GetOrCreateUser(m.name); err != nil {
+			return nil, fmt.Errorf("creating demo user: %w", err)
+		}
+		homeISP := homeISPs[rng.Intn(len(homeISPs))]
+		if err := setGeo(st, m.homeIP, home, homeISP); err != nil {
+			return nil, err
+		}
+		if err := setGeo(st, m.mobileIP, home, mobileISP); err != nil {
+			return nil, err
+		}
+		members[i] = m
+	}
+
+	// A handful of away-from-home addresses for travel plays.
+	var travelIPs []string
+	for i, c := range cities {
+		ip := fmt.Sprintf("%s%d", c.prefix, 200+i)
+		if err := setGeo(st, ip, c, homeISPs[i%len(homeISPs)]); err != nil {
+			return nil, err
+		}
+		travelIPs = append(travelIPs, ip)
+	}
+
+	now := time.Now().UTC()
+	var entries []*models.WatchHistoryEntry
+	for _, m := range members {
+		for d := opts.Days; d >= 1; d-- {
+			day := now.AddDate(0, 0, -d).Truncate(24 * time.Hour)
+			n := playsOn(rng, m.plays, day)
+			// Plays start in the evening, mostly, and follow on from each other.
+			start := day.Add(time.Duration(17+rng.Intn(5))*time.Hour + time.Duration(rng.Intn(60))*time.Minute)
+			for range n {
+				e := play(rng, m, travelIPs, start)
+				entries = append(entries, e)
+				start = e.StoppedAt.Add(time.Duration(5+rng.Intn(40)) * time.Minute)
+			}
+		}
+	}
+	inserted, _, _, err := st.InsertHistoryBatch(ctx, entries)
+	if err != nil {
+		return nil, fmt.Errorf("inserting demo history: %w", err)
+	}
+	return &Result{Servers: len(servers), Users: len(members), Plays: inserted}, nil
+}
+
+// Clear deletes the demo servers and, with them, all demo history. Demo
+// users and geo cache entries are left behind: they're harmless and may be
+// shared with real data.
+func Clear(st Store) (int, error) {
+	servers, err := demoServers(st)
+	if err != nil {
+		return 0, err
+	}
+	for _, srv := range servers {
+		if err := st.DeleteServer(srv.ID); err != nil {
+			return 0, fmt.Errorf("deleting demo server %d: %w", srv.ID, err)
+		}
+	}
+	return len(servers), nil
+}
+
+func demoServers(st Store) ([]models.Server, error) {
+	all, err := st.ListAllServers()
+	if err != nil {
+		return nil, fmt.Errorf("listing servers: %w", err)
+	}
+	var servers []models.Server
+	for _, srv := range all {
+		if strings.Contains(srv.URL, "://"+Host) {
+			servers = append(servers, srv)
+		}
+	}
+	return servers, nil
+}
+
+func setGeo(st Store, ip string, c city, i isp) error {
+	geo := &models.GeoResult{IP: ip, Lat: c.lat, Lng: c.lng, City: c.name, Country: c.country, ISP: i.name, ASN: i.asn}
+	if err := st.SetCachedGeo(geo); err != nil {
+		return fmt.Errorf("caching demo geo for %s: %w", ip, err)
+	}
+	return nil
+}
+
+// playsOn draws how many plays a member makes on day, busier at weekends.
+func playsOn(rng *rand.Rand, avg float64, day time.Time) int {
+	if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		avg *= 1.5
+	}
+	n := 0
+	for i := 0; i < 6; i++ {
+		if rng.Float64() < avg/6 {
+			n++
+		}
+	}
+	return n
+}
+
+// play makes one history entry for m starting at start.
+func play(rng *rand.Rand, m member, travelIPs []string, start time.Time) *models.WatchHistoryEntry {
+	dev := m.devices[rng.Intn(len(m.devices))]
+	e := &models.WatchHistoryEntry{
+		ServerID:  m.serverID,
+		UserName:  m.name,
+		Player:    dev.player,
+		Platform:  dev.platform,
+		IPAddress: m.homeIP,
+		StartedAt: start,
+	}
+	switch r := rng.Float64(); {
+	case r < 0.03:
+		e.IPAddress = travelIPs[rng.Intn(len(travelIPs))]
+	case r < 0.15:
+		e.IPAddress = m.mobileIP
+	}
+
+	switch r := rng.Float64(); {
+	case r < 0.35:
+		i := rng.Intn(len(movies))
+		mv := movies[i]
+		e.MediaType = models.MediaTypeMovie
+		e.ItemID = fmt.Sprintf("demo-movie-%d", i)
+		e.Title, e.Year, e.ContentRating = mv.title, mv.year, mv.rating
+		e.DurationMs = int64(mv.mins) * 60_000
+	case r < 0.9:
+		i := rng.Intn(len(shows))
+		sh := shows[i]
+		season, episode := 1+rng.Intn(sh.seasons), 1+rng.Intn(sh.episodes)
+		e.MediaType = models.MediaTypeTV
+		e.GrandparentItemID = fmt.Sprintf("demo-show-%d", i)
+		e.ItemID = fmt.Sprintf("%s-s%02de%02d", e.GrandparentItemID, season, episode)
+		e.Title = fmt.Sprintf("Episode %d", episode)
+		e.ParentTitle = fmt.Sprintf("Season %d", season)
+		e.GrandparentTitle = sh.title
+		e.Year, e.ContentRating = sh.year, sh.rating
+		e.SeasonNumber, e.EpisodeNumber = season, episode
+		e.DurationMs = int64(sh.mins) * 60_000
+	default:
+		i := rng.Intn(len(albums))
+		al := albums[i]
+		track := rng.Intn(len(al.tracks))
+		e.MediaType = models.MediaTypeMusic
+		e.ItemID = fmt.Sprintf("demo-album-%d-%d", i, track)
+		e.Title, e.ParentTitle, e.GrandparentTitle = al.tracks[track], al.title, al.artist
+		e.DurationMs = int64(180+rng.Intn(120)) * 1000
+		e.AudioCodec, e.AudioChannels = "flac", 2
+		e.TranscodeDecision, e.AudioDecision = models.TranscodeDecisionDirectPlay, models.TranscodeDecisionDirectPlay
+		e.Bandwidth = 1_000_000
+		e.WatchedMs = e.DurationMs
+		e.StoppedAt = start.Add(time.Duration(e.WatchedMs) * time.Millisecond)
+		return e
+	}
+
+	// Most plays finish, the rest are abandoned part way.
+	e.WatchedMs = e.DurationMs
+	if rng.Float64() < 0.25 {
+		e.WatchedMs = e.DurationMs * int64(10+rng.Intn(70)) / 100
+	}
+	if rng.Float64() < 0.3 {
+		e.PausedMs = int64(1+rng.Intn(10)) * 60_000
+	}
+	e.StoppedAt = start.Add(time.Duration(e.WatchedMs+e.PausedMs) * time.Millisecond)
+
+	e.VideoResolution, e.VideoCodec, e.Bandwidth = "1080", "h264", 8_000_000
+	if rng.Float64() < 0.3 {
+		e.VideoResolution, e.VideoCodec, e.DynamicRange, e.Bandwidth = "4k", "hevc", "HDR10", 25_000_000
+	}
+	e.AudioCodec, e.AudioChannels = "eac3", 6
+	e.TranscodeDecision = models.TranscodeDecisionDirectPlay
+	e.VideoDecision, e.AudioDecision = models.TranscodeDecisionDirectPlay, models.TranscodeDecisionDirectPlay
+	if e.IPAddress != m.homeIP || rng.Float64() < 0.15 {
+		e.TranscodeDecision, e.VideoDecision = models.TranscodeDecisionTranscode, models.TranscodeDecisionTranscode
+		e.VideoResolution, e.VideoCodec, e.Bandwidth = "720", "h264", 4_000_000
+		e.TranscodeHWDecode, e.TranscodeHWEncode = true, true
+	}
+	return e
+}
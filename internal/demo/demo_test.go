@@ -0,0 +1,103 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.Migrate("../../migrations"); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestGenerate(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if err := (&Options{Users: MaxUsers + 1}).Validate(); err == nil {
+		t.Error("expected error for too many users")
+	}
+
+	result, err := Generate(ctx, s, Options{Days: 30, Users: 4, Seed: 42})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Servers != 2 || result.Users != 4 || result.Plays == 0 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	servers, err := s.ListAllServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, srv := range servers {
+		if srv.Enabled {
+			t.Errorf("demo server %q is enabled", srv.Name)
+		}
+	}
+
+	page, err := s.QueryHistory(1, 100, store.HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != result.Plays {
+		t.Errorf("history total = %d, want %d", page.Total, result.Plays)
+	}
+	docRanges := []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+	}
+	for _, e := range page.Items {
+		addr := netip.MustParseAddr(e.IPAddress)
+		documented := false
+		for _, p := range docRanges {
+			documented = documented || p.Contains(addr)
+		}
+		if !documented {
+			t.Errorf("play from %s, outside the documentation ranges", e.IPAddress)
+		}
+		if e.City == "" {
+			t.Errorf("play from %s has no cached geo", e.IPAddress)
+		}
+		if e.MediaType != models.MediaTypeMovie && e.MediaType != models.MediaTypeTV && e.MediaType != models.MediaTypeMusic {
+			t.Errorf("unexpected media type %q", e.MediaType)
+		}
+	}
+
+	if _, err := Generate(ctx, s, Options{Seed: 1}); !errors.Is(err, ErrExists) {
+		t.Errorf("second Generate err = %v, want ErrExists", err)
+	}
+
+	n, err := Clear(s)
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("cleared %d servers, want 2", n)
+	}
+	if exists, err := Exists(s); err != nil || exists {
+		t.Errorf("Exists after Clear = %v, %v", exists, err)
+	}
+	page, err = s.QueryHistory(1, 1, store.HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 0 {
+		t.Errorf("history total after Clear = %d, want 0", page.Total)
+	}
+}
@@ -0,0 +1,172 @@
+// Package digest compiles weekly and monthly activity digests and sends
+// them through notification channels.
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// Store is what the sender needs from storage.
+type Store interface {
+	ListDigestSchedules() ([]models.DigestSchedule, error)
+	ClaimDigestPeriod(ctx context.Context, id int64, periodKey string) (bool, error)
+	CompileDigest(ctx context.Context, period models.DigestPeriod, start, end time.Time) (*models.Digest, error)
+	ListDigestRecipients(ctx context.Context, start, end time.Time) ([]models.DigestRecipient, error)
+	PersonalDigest(ctx context.Context, userName string, start, end time.Time) (*models.DigestPersonal, error)
+	GetNotificationChannel(id int64) (*models.NotificationChannel, error)
+}
+
+// Notifier delivers a compiled digest.
+type Notifier interface {
+	SendDigest(ctx context.Context, d *models.Digest, channels []models.NotificationChannel) error
+	SendPersonalDigest(ctx context.Context, d *models.Digest, smtp models.EmailConfig, email string) error
+}
+
+type Sender struct {
+	store    Store
+	notifier Notifier
+	now      func() time.Time
+}
+
+func New(s Store, n Notifier) *Sender {
+	return &Sender{store: s, notifier: n, now: time.Now}
+}
+
+// SendDue sends each enabled schedule's digest of its last complete period,
+// unless that period already went out. Periods follow the server's local
+// calendar.
+func (s *Sender) SendDue(ctx context.Context) {
+	schedules, err := s.store.ListDigestSchedules()
+	if err != nil {
+		log.Printf("digest: list schedules: %v", err)
+		return
+	}
+	now := s.now()
+	for _, sched := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		if !sched.Enabled {
+			continue
+		}
+		start, end, key := sched.Period.LastComplete(now)
+		if sched.LastSentPeriod == key {
+			continue
+		}
+		// Claim first: a digest that fails to send is skipped rather than
+		// retried every run, like any other notification.
+		claimed, err := s.store.ClaimDigestPeriod(ctx, sched.ID, key)
+		if err != nil {
+			log.Printf("digest %d (%s): %v", sched.ID, sched.Name, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.Send(ctx, &sched, start, end); err != nil {
+			log.Printf("digest %d (%s): %v", sched.ID, sched.Name, err)
+			continue
+		}
+		log.Printf("digest: sent %s digest %q for %s", sched.Period, sched.Name, key)
+	}
+}
+
+// Compile builds sched's digest of the period from start up to end.
+func (s *Sender) Compile(ctx context.Context, sched *models.DigestSchedule, start, end time.Time) (*models.Digest, error) {
+	d, err := s.store.CompileDigest(ctx, sched.Period, start, end)
+	if err != nil {
+		return nil, err
+	}
+	d.Name = sched.Name
+	return d, nil
+}
+
+// Send compiles sched's digest of the period from start up to end and sends
+// it to the schedule's channels, then to each user when it's personalized.
+func (s *Sender) Send(ctx context.Context, sched *models.DigestSchedule, start, end time.Time) error {
+	d, err := s.Compile(ctx, sched, start, end)
+	if err != nil {
+		return err
+	}
+	channels, smtp := s.channels(sched)
+
+	var errs []error
+	if len(channels) > 0 {
+		if err := s.notifier.SendDigest(ctx, d, channels); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if sched.Personalized {
+		if smtp == nil {
+			errs = append(errs, errors.New("personalized digest needs an enabled email channel"))
+		} else if err := s.sendPersonal(ctx, d, *smtp); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// channels loads sched's enabled channels that take digests, along with the
+// SMTP settings of the first email channel among them for personal copies.
+// Channels that no longer exist are skipped.
+func (s *Sender) channels(sched *models.DigestSchedule) ([]models.NotificationChannel, *models.EmailConfig) {
+	var channels []models.NotificationChannel
+	var smtp *models.EmailConfig
+	for _, id := range sched.ChannelIDs {
+		ch, err := s.store.GetNotificationChannel(id)
+		if err != nil {
+			if !errors.Is(err, models.ErrNotFound) {
+				log.Printf("digest %d: loading channel %d: %v", sched.ID, id, err)
+			}
+			continue
+		}
+		if !ch.Enabled || !ch.Events.Allows(models.NotificationEventDigest, "", 0) {
+			continue
+		}
+		channels = append(channels, *ch)
+		if smtp == nil && ch.ChannelType == models.ChannelTypeEmail {
+			var config models.EmailConfig
+			if err := json.Unmarshal(ch.Config, &config); err == nil {
+				smtp = &config
+			}
+		}
+	}
+	return channels, smtp
+}
+
+// sendPersonal emails each user who watched something in the period their
+// own copy. Other users' watching is left out of personal copies.
+func (s *Sender) sendPersonal(ctx context.Context, d *models.Digest, smtp models.EmailConfig) error {
+	recipients, err := s.store.ListDigestRecipients(ctx, d.Start, d.End)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, r := range recipients {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		personal, err := s.store.PersonalDigest(ctx, r.UserName, d.Start, d.End)
+		if err != nil {
+			return err
+		}
+		own := *d
+		own.TopUsers = nil
+		own.Personal = personal
+		if err := s.notifier.SendPersonalDigest(ctx, &own, smtp, r.Email); err != nil {
+			log.Printf("digest: personal copy for %s: %v", r.UserName, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d personal digests failed", failed, len(recipients))
+	}
+	return nil
+}
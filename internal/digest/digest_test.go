@@ -0,0 +1,133 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type fakeStore struct {
+	schedules []models.DigestSchedule
+	channels  map[int64]*models.NotificationChannel
+	claims    []string
+}
+
+func (f *fakeStore) ListDigestSchedules() ([]models.DigestSchedule, error) {
+	return f.schedules, nil
+}
+
+func (f *fakeStore) ClaimDigestPeriod(_ context.Context, id int64, key string) (bool, error) {
+	for i := range f.schedules {
+		if f.schedules[i].ID == id {
+			if f.schedules[i].LastSentPeriod == key {
+				return false, nil
+			}
+			f.schedules[i].LastSentPeriod = key
+		}
+	}
+	f.claims = append(f.claims, key)
+	return true, nil
+}
+
+func (f *fakeStore) CompileDigest(_ context.Context, period models.DigestPeriod, start, end time.Time) (*models.Digest, error) {
+	return &models.Digest{Period: period, Start: start, End: end, Plays: 3,
+		TopUsers: []models.UserStat{{UserName: "alice"}, {UserName: "bob"}}}, nil
+}
+
+func (f *fakeStore) ListDigestRecipients(context.Context, time.Time, time.Time) ([]models.DigestRecipient, error) {
+	return []models.DigestRecipient{{UserName: "alice", Email: "alice@example.com"}}, nil
+}
+
+func (f *fakeStore) PersonalDigest(_ context.Context, userName string, _, _ time.Time) (*models.DigestPersonal, error) {
+	return &models.DigestPersonal{UserName: userName, Plays: 1}, nil
+}
+
+func (f *fakeStore) GetNotificationChannel(id int64) (*models.NotificationChannel, error) {
+	ch, ok := f.channels[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return ch, nil
+}
+
+type sentPersonal struct {
+	digest *models.Digest
+	email  string
+}
+
+type fakeNotifier struct {
+	sent     []*models.Digest
+	channels [][]models.NotificationChannel
+	personal []sentPersonal
+}
+
+func (f *fakeNotifier) SendDigest(_ context.Context, d *models.Digest, channels []models.NotificationChannel) error {
+	f.sent = append(f.sent, d)
+	f.channels = append(f.channels, channels)
+	return nil
+}
+
+func (f *fakeNotifier) SendPersonalDigest(_ context.Context, d *models.Digest, _ models.EmailConfig, email string) error {
+	f.personal = append(f.personal, sentPersonal{d, email})
+	return nil
+}
+
+func TestSendDue(t *testing.T) {
+	email, _ := json.Marshal(models.EmailConfig{Host: "smtp.example.com", Port: 25, From: "streammon@example.com"})
+	st := &fakeStore{
+		schedules: []models.DigestSchedule{
+			{ID: 1, Name: "Weekly", Period: models.DigestWeekly, ChannelIDs: []int64{1, 2, 3, 99}, Personalized: true, Enabled: true},
+			{ID: 2, Name: "Off", Period: models.DigestMonthly, ChannelIDs: []int64{1}},
+		},
+		channels: map[int64]*models.NotificationChannel{
+			1: {ID: 1, Name: "discord", ChannelType: models.ChannelTypeDiscord, Enabled: true},
+			2: {ID: 2, Name: "mail", ChannelType: models.ChannelTypeEmail, Enabled: true, Config: email},
+			3: {ID: 3, Name: "muted", ChannelType: models.ChannelTypeDiscord, Enabled: true,
+				Events: models.NotificationEventMatrix{models.NotificationEventDigest: {Enabled: false}}},
+		},
+	}
+	n := &fakeNotifier{}
+	s := New(st, n)
+	s.now = func() time.Time { return time.Date(2026, 3, 11, 3, 0, 0, 0, time.UTC) }
+
+	s.SendDue(context.Background())
+	s.SendDue(context.Background())
+
+	if len(n.sent) != 1 || len(st.claims) != 1 || st.claims[0] != "2026-03-02" {
+		t.Fatalf("sent %d digests, claims %v", len(n.sent), st.claims)
+	}
+	if d := n.sent[0]; d.Name != "Weekly" || !d.Start.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("digest = %+v", d)
+	}
+	if chs := n.channels[0]; len(chs) != 2 || chs[0].ID != 1 || chs[1].ID != 2 {
+		t.Errorf("channels = %+v", chs)
+	}
+	if len(n.personal) != 1 {
+		t.Fatalf("personal copies = %d", len(n.personal))
+	}
+	p := n.personal[0]
+	if p.email != "alice@example.com" || p.digest.Personal == nil || p.digest.Personal.UserName != "alice" || p.digest.TopUsers != nil {
+		t.Errorf("personal copy = %+v", p.digest)
+	}
+	if n.sent[0].TopUsers == nil || n.sent[0].Personal != nil {
+		t.Errorf("shared digest changed by personal copy: %+v", n.sent[0])
+	}
+}
+
+func TestSend_PersonalizedWithoutEmailChannel(t *testing.T) {
+	st := &fakeStore{channels: map[int64]*models.NotificationChannel{
+		1: {ID: 1, ChannelType: models.ChannelTypeDiscord, Enabled: true},
+	}}
+	n := &fakeNotifier{}
+	sched := &models.DigestSchedule{ID: 1, Period: models.DigestWeekly, ChannelIDs: []int64{1}, Personalized: true}
+	start, end, _ := sched.Period.LastComplete(time.Now())
+	if err := New(st, n).Send(context.Background(), sched, start, end); err == nil {
+		t.Error("expected an error without an email channel")
+	}
+	if len(n.sent) != 1 {
+		t.Errorf("shared digest not sent: %d", len(n.sent))
+	}
+}
@@ -0,0 +1,199 @@
+// Package diskcache keeps blobs such as proxied posters in a directory capped
+// at a maximum size, evicting the least recently used files when full.
+package diskcache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is safe for concurrent use. Recency survives restarts through file
+// modification times, which Get refreshes.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+	size    int64
+}
+
+type entry struct {
+	name string
+	size int64
+}
+
+// Open indexes the files already in dir, creating it if needed, and evicts
+// down to maxBytes. Files left half-written by a crash are removed.
+func Open(dir string, maxBytes int64) (*Cache, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("cache size must be positive")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating cache dir: %w", err)
+	}
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading cache dir: %w", err)
+	}
+
+	type found struct {
+		entry
+		modTime time.Time
+	}
+	var files []found
+	for _, de := range des {
+		if !de.Type().IsRegular() {
+			continue
+		}
+		if strings.HasSuffix(de.Name(), ".tmp") {
+			os.Remove(filepath.Join(dir, de.Name()))
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, found{entry{de.Name(), info.Size()}, info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	c := &Cache{dir: dir, maxBytes: maxBytes, entries: make(map[string]*list.Element), lru: list.New()}
+	for _, f := range files {
+		c.entries[f.name] = c.lru.PushBack(&entry{f.name, f.size})
+		c.size += f.size
+	}
+	c.mu.Lock()
+	c.evictLocked()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Get returns the data and content type stored under key.
+func (c *Cache) Get(key string) (data []byte, contentType string, ok bool) {
+	name := fileName(key)
+	c.mu.Lock()
+	el, ok := c.entries[name]
+	if ok {
+		c.lru.MoveToFront(el)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, "", false
+	}
+
+	raw, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		c.remove(name)
+		return nil, "", false
+	}
+	ct, data, ok := bytes.Cut(raw, []byte("\n"))
+	if !ok {
+		c.remove(name)
+		return nil, "", false
+	}
+	now := time.Now()
+	os.Chtimes(filepath.Join(c.dir, name), now, now)
+	return data, string(ct), true
+}
+
+// Put stores data under key, evicting older entries to make room. Data
+// larger than the whole cache isn't stored.
+func (c *Cache) Put(key, contentType string, data []byte) error {
+	if strings.ContainsAny(contentType, "\r\n") {
+		return fmt.Errorf("invalid content type")
+	}
+	size := int64(len(contentType) + 1 + len(data))
+	if size > c.maxBytes {
+		return nil
+	}
+	name := fileName(key)
+
+	f, err := os.CreateTemp(c.dir, name+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating cache file: %w", err)
+	}
+	_, err = f.WriteString(contentType + "\n")
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, name))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[name]; ok {
+		e := el.Value.(*entry)
+		c.size += size - e.size
+		e.size = size
+		c.lru.MoveToFront(el)
+	} else {
+		c.entries[name] = c.lru.PushFront(&entry{name, size})
+		c.size += size
+	}
+	c.evictLocked()
+	return nil
+}
+
+// Size returns the bytes the cache holds on disk.
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Len returns the number of cached entries.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[name]; ok {
+		c.size -= el.Value.(*entry).size
+		c.lru.Remove(el)
+		delete(c.entries, name)
+	}
+	os.Remove(filepath.Join(c.dir, name))
+}
+
+func (c *Cache) evictLocked() {
+	for c.size > c.maxBytes {
+		el := c.lru.Back()
+		if el == nil {
+			return
+		}
+		e := el.Value.(*entry)
+		c.lru.Remove(el)
+		delete(c.entries, e.name)
+		c.size -= e.size
+		os.Remove(filepath.Join(c.dir, e.name))
+	}
+}
+
+// fileName keeps keys, which may hold URL paths, out of the file system.
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package diskcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache_LRUEviction(t *testing.T) {
+	dir := t.TempDir()
+	// Each entry is "image/png\n" (10 bytes) plus 90 bytes of data.
+	c, err := Open(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("x"), 90)
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(key, "image/png", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading a makes b the least recently used.
+	if got, ct, ok := c.Get("a"); !ok || ct != "image/png" || !bytes.Equal(got, data) {
+		t.Fatalf("Get(a) = %q, %q, %v", got, ct, ok)
+	}
+	if err := c.Put("c", "image/png", data); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := c.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, _, ok := c.Get(key); !ok {
+			t.Errorf("%s should still be cached", key)
+		}
+	}
+	if c.Size() != 200 || c.Len() != 2 {
+		t.Errorf("size=%d len=%d, want 200 and 2", c.Size(), c.Len())
+	}
+
+	// Too big for the whole cache: skipped, nothing evicted.
+	if err := c.Put("huge", "image/png", bytes.Repeat([]byte("x"), 300)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := c.Get("huge"); ok || c.Len() != 2 {
+		t.Error("oversized entry should not be cached")
+	}
+}
+
+func TestCache_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("poster", "image/jpeg", []byte("jpeg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "leftover-123.tmp"), []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = Open(dir, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ct, ok := c.Get("poster"); !ok || ct != "image/jpeg" || string(got) != "jpeg" {
+		t.Fatalf("Get after reopen = %q, %q, %v", got, ct, ok)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "leftover-123.tmp")); !os.IsNotExist(err) {
+		t.Error("half-written file should be removed on open")
+	}
+
+	// Reopening with a smaller cap trims to fit.
+	c, err = Open(dir, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("len = %d after reopening with a smaller cap", c.Len())
+	}
+}
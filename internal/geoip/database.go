@@ -0,0 +1,94 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"streammon/internal/models"
+)
+
+// Provider names the vendor and format of the city database.
+type Provider string
+
+const (
+	ProviderMaxMind     Provider = "maxmind"
+	ProviderDBIP        Provider = "dbip"
+	ProviderIP2Location Provider = "ip2location"
+)
+
+// ParseProvider returns the provider named s; empty means MaxMind.
+func ParseProvider(s string) (Provider, error) {
+	switch p := Provider(s); p {
+	case "":
+		return ProviderMaxMind, nil
+	case ProviderMaxMind, ProviderDBIP, ProviderIP2Location:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown geoip provider %q (want maxmind, dbip or ip2location)", s)
+}
+
+// Downloads reports whether StreamMon fetches p's databases itself. Other
+// providers' files are placed by the admin and only ever read.
+func (p Provider) Downloads() bool {
+	return p == ProviderMaxMind
+}
+
+// Database is an open city database. Lookup returns nil, nil for an address
+// the database has no location for.
+type Database interface {
+	Lookup(ip net.IP) (*models.GeoResult, error)
+	Close() error
+}
+
+// OpenDatabase opens the city database at path in p's format.
+func OpenDatabase(p Provider, path string) (Database, error) {
+	switch p {
+	case ProviderMaxMind, ProviderDBIP:
+		// DB-IP publishes its databases in the MaxMind format, with the same
+		// city, country and location fields.
+		db, err := maxminddb.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return &mmdbDatabase{db: db}, nil
+	case ProviderIP2Location:
+		return openIP2Location(path)
+	}
+	return nil, fmt.Errorf("unknown geoip provider %q", p)
+}
+
+type mmdbDatabase struct {
+	db *maxminddb.Reader
+}
+
+type mmdbRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func (d *mmdbDatabase) Lookup(ip net.IP) (*models.GeoResult, error) {
+	var record mmdbRecord
+	if err := d.db.Lookup(ip, &record); err != nil {
+		return nil, err
+	}
+	return &models.GeoResult{
+		Lat:     record.Location.Latitude,
+		Lng:     record.Location.Longitude,
+		City:    record.City.Names["en"],
+		Country: record.Country.ISOCode,
+	}, nil
+}
+
+func (d *mmdbDatabase) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,63 @@
+package geoip
+
+import "strings"
+
+// hostingASNs are autonomous systems run by cloud, VPS, and VPN providers.
+// Residential users almost never stream from these, so a session from one
+// usually means a VPN or proxy. The list favours precision: networks that
+// also serve consumers (e.g. Cloudflare WARP) are left out.
+var hostingASNs = map[uint]string{
+	16509:  "Amazon AWS",
+	14618:  "Amazon AWS",
+	8075:   "Microsoft Azure",
+	396982: "Google Cloud",
+	31898:  "Oracle Cloud",
+	45102:  "Alibaba Cloud",
+	132203: "Tencent Cloud",
+	14061:  "DigitalOcean",
+	63949:  "Linode",
+	20473:  "Vultr",
+	16276:  "OVH",
+	24940:  "Hetzner",
+	51167:  "Contabo",
+	12876:  "Scaleway",
+	60781:  "Leaseweb",
+	28753:  "Leaseweb",
+	9009:   "M247",
+	60068:  "Datacamp",
+	212238: "Datacamp",
+	136787: "PacketHub",
+	62240:  "Clouvider",
+	21859:  "Zenlayer",
+	203020: "HostRoyale",
+	11878:  "tzulo",
+	8100:   "QuadraNet",
+	40676:  "Psychz",
+	36352:  "ColoCrossing",
+	46562:  "Performive",
+	63023:  "GTHost",
+	3258:   "xTom",
+	47583:  "Hostinger",
+	8560:   "IONOS",
+}
+
+// hostingOrgKeywords catch smaller providers missing from hostingASNs by
+// their registered organisation name.
+var hostingOrgKeywords = []string{
+	"hosting", "datacenter", "data center", "vpn", "vps", "colocation", "dedicated server",
+}
+
+// IsHostingASN reports whether an IP announced by asn with organisation org
+// belongs to a hosting provider or VPN rather than a consumer ISP.
+func IsHostingASN(asn uint, org string) bool {
+	if _, ok := hostingASNs[asn]; ok {
+		return true
+	}
+	org = strings.ToLower(org)
+	for _, kw := range hostingOrgKeywords {
+		if strings.Contains(org, kw) {
+			return true
+		}
+	}
+	return false
+}
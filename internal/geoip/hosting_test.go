@@ -0,0 +1,24 @@
+package geoip
+
+import "testing"
+
+func TestIsHostingASN(t *testing.T) {
+	tests := []struct {
+		asn  uint
+		org  string
+		want bool
+	}{
+		{16509, "AMAZON-02", true},
+		{9009, "M247 Europe SRL", true},
+		{99999, "Example Hosting Ltd", true},
+		{99999, "Acme VPN Services", true},
+		{7922, "COMCAST-7922", false},
+		{3320, "Deutsche Telekom AG", false},
+		{0, "", false},
+	}
+	for _, tt := range tests {
+		if got := IsHostingASN(tt.asn, tt.org); got != tt.want {
+			t.Errorf("IsHostingASN(%d, %q) = %v, want %v", tt.asn, tt.org, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+
+	"streammon/internal/models"
+)
+
+// IP2Location BIN column positions by database type (DB1 to DB26), counted
+// from 1 with the range start as column 1. Zero means the type doesn't carry
+// the field.
+var (
+	ip2lCountryColumn   = [27]uint8{0, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+	ip2lCityColumn      = [27]uint8{0, 0, 0, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4}
+	ip2lISPColumn       = [27]uint8{0, 0, 3, 0, 5, 0, 7, 5, 7, 0, 8, 0, 9, 0, 9, 0, 9, 0, 9, 7, 9, 0, 9, 7, 9, 9, 9}
+	ip2lLatitudeColumn  = [27]uint8{0, 0, 0, 0, 0, 5, 5, 0, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+	ip2lLongitudeColumn = [27]uint8{0, 0, 0, 0, 0, 6, 6, 0, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6}
+)
+
+// ip2lHeaderSize covers the fields of the BIN header this reader uses.
+const ip2lHeaderSize = 29
+
+// ip2locationDatabase reads an IP2Location BIN file in place. Rows are
+// fixed-size and sorted by range start, so a lookup binary-searches the
+// rows between the bounds the optional /16 index gives.
+type ip2locationDatabase struct {
+	f       *os.File
+	dbType  uint8
+	columns uint32
+
+	ipv4Count, ipv4Base, ipv4Index uint32
+	ipv6Count, ipv6Base, ipv6Index uint32
+}
+
+func openIP2Location(path string) (*ip2locationDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	var h [ip2lHeaderSize]byte
+	if _, err := f.ReadAt(h[:], 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading ip2location header: %w", err)
+	}
+	d := &ip2locationDatabase{
+		f:         f,
+		dbType:    h[0],
+		columns:   uint32(h[1]),
+		ipv4Count: binary.LittleEndian.Uint32(h[5:]),
+		ipv4Base:  binary.LittleEndian.Uint32(h[9:]),
+		ipv6Count: binary.LittleEndian.Uint32(h[13:]),
+		ipv6Base:  binary.LittleEndian.Uint32(h[17:]),
+		ipv4Index: binary.LittleEndian.Uint32(h[21:]),
+		ipv6Index: binary.LittleEndian.Uint32(h[25:]),
+	}
+	if d.dbType == 0 || int(d.dbType) >= len(ip2lCountryColumn) || d.columns < 2 {
+		f.Close()
+		return nil, errors.New("not an IP2Location BIN database")
+	}
+	return d, nil
+}
+
+func (d *ip2locationDatabase) Close() error {
+	return d.f.Close()
+}
+
+// ip2lAddr is an address as the 128-bit number the BIN rows are keyed by.
+type ip2lAddr struct{ hi, lo uint64 }
+
+func (a ip2lAddr) less(b ip2lAddr) bool {
+	return a.hi < b.hi || (a.hi == b.hi && a.lo < b.lo)
+}
+
+func (d *ip2locationDatabase) Lookup(ip net.IP) (*models.GeoResult, error) {
+	var (
+		addr            ip2lAddr
+		count, base     uint32
+		rowSize, keyLen uint32
+		indexAddr       uint32
+	)
+	if v4 := ip.To4(); v4 != nil {
+		n := binary.BigEndian.Uint32(v4)
+		// Range ends are exclusive, so the very last address is looked up
+		// as the one before it.
+		if n == math.MaxUint32 {
+			n--
+		}
+		addr = ip2lAddr{lo: uint64(n)}
+		count, base, keyLen = d.ipv4Count, d.ipv4Base, 4
+		rowSize = d.columns * 4
+		if d.ipv4Index > 0 {
+			indexAddr = d.ipv4Index + (n>>16)<<3
+		}
+	} else if v6 := ip.To16(); v6 != nil {
+		if d.ipv6Count == 0 {
+			return nil, nil
+		}
+		addr = ip2lAddr{hi: binary.BigEndian.Uint64(v6), lo: binary.BigEndian.Uint64(v6[8:])}
+		count, base, keyLen = d.ipv6Count, d.ipv6Base, 16
+		rowSize = 16 + (d.columns-1)*4
+		if d.ipv6Index > 0 {
+			indexAddr = d.ipv6Index + uint32(addr.hi>>48)<<3
+		}
+	} else {
+		return nil, nil
+	}
+
+	low, high := uint32(0), count
+	if indexAddr > 0 {
+		var err error
+		if low, err = d.uint32At(indexAddr); err != nil {
+			return nil, err
+		}
+		if high, err = d.uint32At(indexAddr + 4); err != nil {
+			return nil, err
+		}
+	}
+
+	// Each row plus the next one's start gives its range.
+	row := make([]byte, rowSize+keyLen)
+	for low <= high {
+		mid := low + (high-low)/2
+		offset := base + mid*rowSize
+		if _, err := d.f.ReadAt(row, int64(offset)-1); err != nil {
+			return nil, fmt.Errorf("reading ip2location row: %w", err)
+		}
+		from, to := d.rowKey(row, keyLen), d.rowKey(row[rowSize:], keyLen)
+		switch {
+		case addr.less(from):
+			if mid == 0 {
+				return nil, nil
+			}
+			high = mid - 1
+		case !addr.less(to):
+			low = mid + 1
+		default:
+			return d.result(row[keyLen:rowSize])
+		}
+	}
+	return nil, nil
+}
+
+func (d *ip2locationDatabase) rowKey(b []byte, keyLen uint32) ip2lAddr {
+	if keyLen == 4 {
+		return ip2lAddr{lo: uint64(binary.LittleEndian.Uint32(b))}
+	}
+	return ip2lAddr{hi: binary.LittleEndian.Uint64(b[8:]), lo: binary.LittleEndian.Uint64(b)}
+}
+
+// result reads the fields of a matched row; fields holds its columns after
+// the range start.
+func (d *ip2locationDatabase) result(fields []byte) (*models.GeoResult, error) {
+	column := func(positions *[27]uint8) (uint32, bool) {
+		pos := positions[d.dbType]
+		if pos == 0 {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint32(fields[(uint32(pos)-2)*4:]), true
+	}
+	str := func(positions *[27]uint8) (string, error) {
+		ptr, ok := column(positions)
+		if !ok {
+			return "", nil
+		}
+		return d.stringAt(ptr)
+	}
+
+	country, err := str(&ip2lCountryColumn)
+	if err != nil {
+		return nil, err
+	}
+	if country == "" {
+		// Reserved and unallocated ranges carry no location.
+		return nil, nil
+	}
+	r := &models.GeoResult{Country: country}
+	if r.City, err = str(&ip2lCityColumn); err != nil {
+		return nil, err
+	}
+	if r.ISP, err = str(&ip2lISPColumn); err != nil {
+		return nil, err
+	}
+	if bits, ok := column(&ip2lLatitudeColumn); ok {
+		r.Lat = float64(math.Float32frombits(bits))
+	}
+	if bits, ok := column(&ip2lLongitudeColumn); ok {
+		r.Lng = float64(math.Float32frombits(bits))
+	}
+	return r, nil
+}
+
+// uint32At reads the little-endian number at the 1-based offset pos, as
+// the BIN header's addresses count them.
+func (d *ip2locationDatabase) uint32At(pos uint32) (uint32, error) {
+	var b [4]byte
+	if _, err := d.f.ReadAt(b[:], int64(pos)-1); err != nil {
+		return 0, fmt.Errorf("reading ip2location index: %w", err)
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// stringAt reads the length-prefixed string at the 0-based offset ptr. The
+// LITE databases fill fields they don't have with "-".
+func (d *ip2locationDatabase) stringAt(ptr uint32) (string, error) {
+	var n [1]byte
+	if _, err := d.f.ReadAt(n[:], int64(ptr)); err != nil {
+		return "", fmt.Errorf("reading ip2location string: %w", err)
+	}
+	b := make([]byte, n[0])
+	if _, err := d.f.ReadAt(b, int64(ptr)+1); err != nil {
+		return "", fmt.Errorf("reading ip2location string: %w", err)
+	}
+	if s := string(b); s != "-" {
+		return s, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,121 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeIP2LocationBIN builds a DB5 (country, region, city, latitude,
+// longitude) IPv4 database with a single located range, 1.0.0.0/24.
+func writeIP2LocationBIN(t *testing.T) string {
+	t.Helper()
+	const columns = 6
+	type row struct {
+		from     uint32
+		country  string
+		city     string
+		lat, lng float32
+	}
+	rows := []row{
+		{from: 0, country: "-", city: "-"},
+		{from: 16777216, country: "AU", city: "Brisbane", lat: -27.46794, lng: 153.02809},
+		{from: 16777472, country: "-", city: "-"},
+		{from: math.MaxUint32},
+	}
+
+	const headerSize = 64
+	rowsEnd := headerSize + len(rows)*columns*4
+	var strs bytes.Buffer
+	addString := func(s string) uint32 {
+		ptr := uint32(rowsEnd + strs.Len())
+		strs.WriteByte(byte(len(s)))
+		strs.WriteString(s)
+		return ptr
+	}
+	addCountry := func(short string) uint32 {
+		// The long name follows the short code at ptr+3.
+		ptr := addString(short)
+		if len(short) < 2 {
+			strs.Write(make([]byte, 2-len(short)))
+		}
+		addString(short + " long name")
+		return ptr
+	}
+
+	var buf bytes.Buffer
+	header := make([]byte, headerSize)
+	header[0] = 5
+	header[1] = columns
+	binary.LittleEndian.PutUint32(header[5:], uint32(len(rows)))
+	binary.LittleEndian.PutUint32(header[9:], headerSize+1)
+	buf.Write(header)
+	for _, r := range rows {
+		fields := []uint32{r.from, 0, 0, 0, math.Float32bits(r.lat), math.Float32bits(r.lng)}
+		if r.from != math.MaxUint32 {
+			fields[1] = addCountry(r.country)
+			fields[2] = addString("-")
+			fields[3] = addString(r.city)
+		}
+		for _, f := range fields {
+			binary.Write(&buf, binary.LittleEndian, f)
+		}
+	}
+	buf.Write(strs.Bytes())
+
+	path := filepath.Join(t.TempDir(), "IP2LOCATION-LITE-DB5.BIN")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIP2LocationLookup(t *testing.T) {
+	r := NewProviderResolver(ProviderIP2Location, writeIP2LocationBIN(t))
+	defer r.Close()
+
+	got := r.Lookup(net.ParseIP("1.0.0.8"))
+	if got == nil {
+		t.Fatal("expected a result for 1.0.0.8")
+	}
+	if got.Country != "AU" || got.City != "Brisbane" || got.IP != "1.0.0.8" {
+		t.Errorf("result = %+v", got)
+	}
+	if math.Abs(got.Lat+27.46794) > 0.001 || math.Abs(got.Lng-153.02809) > 0.001 {
+		t.Errorf("location = %v, %v", got.Lat, got.Lng)
+	}
+
+	for _, ip := range []string{"0.0.0.1", "1.0.1.0", "255.255.255.255", "2001:db8::1"} {
+		if got := r.Lookup(net.ParseIP(ip)); got != nil {
+			t.Errorf("Lookup(%s) = %+v, want nil", ip, got)
+		}
+	}
+}
+
+func TestOpenDatabaseRejectsWrongFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bogus.BIN")
+	if err := os.WriteFile(path, make([]byte, 64), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenDatabase(ProviderIP2Location, path); err == nil {
+		t.Error("expected a zeroed header to be rejected")
+	}
+	if _, err := OpenDatabase(ProviderDBIP, path); err == nil {
+		t.Error("expected a non-mmdb file to be rejected")
+	}
+}
+
+func TestParseProvider(t *testing.T) {
+	for in, want := range map[string]Provider{"": ProviderMaxMind, "dbip": ProviderDBIP, "ip2location": ProviderIP2Location} {
+		if got, err := ParseProvider(in); err != nil || got != want {
+			t.Errorf("ParseProvider(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseProvider("geoplugin"); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+}
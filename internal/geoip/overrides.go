@@ -0,0 +1,48 @@
+package geoip
+
+import (
+	"cmp"
+	"net"
+	"net/netip"
+	"slices"
+
+	"streammon/internal/models"
+)
+
+type geoOverride struct {
+	prefix   netip.Prefix
+	override models.GeoOverride
+}
+
+// SetOverrides replaces the admin-managed locations Lookup consults before
+// the database. The most specific matching network wins.
+func (r *Resolver) SetOverrides(overrides []models.GeoOverride) {
+	parsed := make([]geoOverride, 0, len(overrides))
+	for _, o := range overrides {
+		if p := o.Prefix(); p.IsValid() {
+			parsed = append(parsed, geoOverride{prefix: p, override: o})
+		}
+	}
+	slices.SortStableFunc(parsed, func(a, b geoOverride) int {
+		return cmp.Compare(b.prefix.Bits(), a.prefix.Bits())
+	})
+	r.mu.Lock()
+	r.overrides = parsed
+	r.mu.Unlock()
+}
+
+// matchOverride returns the override covering ip, or nil. The caller holds
+// r.mu.
+func (r *Resolver) matchOverride(ip net.IP) *models.GeoOverride {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil
+	}
+	addr = addr.Unmap()
+	for i := range r.overrides {
+		if r.overrides[i].prefix.Contains(addr) {
+			return &r.overrides[i].override
+		}
+	}
+	return nil
+}
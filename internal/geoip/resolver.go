@@ -11,38 +11,50 @@ import (
 )
 
 type Resolver struct {
-	mu    sync.RWMutex
-	db    *maxminddb.Reader
-	asnDB *maxminddb.Reader
-}
+	mu       sync.RWMutex
+	provider Provider
+	db       Database
+	asnDB    *maxminddb.Reader
 
-type mmdbRecord struct {
-	City struct {
-		Names map[string]string `maxminddb:"names"`
-	} `maxminddb:"city"`
-	Country struct {
-		ISOCode string `maxminddb:"iso_code"`
-	} `maxminddb:"country"`
-	Location struct {
-		Latitude  float64 `maxminddb:"latitude"`
-		Longitude float64 `maxminddb:"longitude"`
-	} `maxminddb:"location"`
+	overrides []geoOverride
 }
 
 type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
 	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
 }
 
+// NewResolver returns a Resolver over the MaxMind city database at dbPath.
 func NewResolver(dbPath string) *Resolver {
+	return NewProviderResolver(ProviderMaxMind, dbPath)
+}
+
+// NewProviderResolver returns a Resolver over p's city database at dbPath.
+// A missing database leaves lookups empty until one is loaded.
+func NewProviderResolver(p Provider, dbPath string) *Resolver {
+	r := &Resolver{provider: p}
 	if dbPath == "" {
-		return &Resolver{}
+		return r
 	}
-	db, err := maxminddb.Open(dbPath)
+	db, err := OpenDatabase(p, dbPath)
 	if err != nil {
-		log.Printf("geoip: database not found at %s, will download when license key is configured", dbPath)
-		return &Resolver{}
+		if p.Downloads() {
+			log.Printf("geoip: database not found at %s, will download when license key is configured", dbPath)
+		} else {
+			log.Printf("geoip: %s database unavailable at %s: %v", p, dbPath, err)
+		}
+		return r
+	}
+	r.db = db
+	return r
+}
+
+// Provider is the vendor of the resolver's city database.
+func (r *Resolver) Provider() Provider {
+	if r.provider == "" {
+		return ProviderMaxMind
 	}
-	return &Resolver{db: db}
+	return r.provider
 }
 
 func (r *Resolver) Close() error {
@@ -60,27 +72,32 @@ func (r *Resolver) Close() error {
 func (r *Resolver) Lookup(ip net.IP) *models.GeoResult {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	if ip == nil || r.db == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() {
+	if ip == nil {
 		return nil
 	}
-	var record mmdbRecord
-	err := r.db.Lookup(ip, &record)
-	if err != nil {
-		return nil
-	}
-	city := record.City.Names["en"]
-	result := &models.GeoResult{
-		IP:      ip.String(),
-		Lat:     record.Location.Latitude,
-		Lng:     record.Location.Longitude,
-		City:    city,
-		Country: record.Country.ISOCode,
+	// An override can place any address, including private ones, and
+	// doesn't need a database.
+	var result *models.GeoResult
+	if o := r.matchOverride(ip); o != nil {
+		result = o.Result(ip.String())
+	} else {
+		if r.db == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() {
+			return nil
+		}
+		var err error
+		result, err = r.db.Lookup(ip)
+		if err != nil || result == nil {
+			return nil
+		}
+		result.IP = ip.String()
 	}
 
 	if r.asnDB != nil {
 		var asn asnRecord
 		if err := r.asnDB.Lookup(ip, &asn); err == nil {
 			result.ISP = asn.AutonomousSystemOrganization
+			result.ASN = asn.AutonomousSystemNumber
+			result.Hosting = IsHostingASN(asn.AutonomousSystemNumber, asn.AutonomousSystemOrganization)
 		}
 	}
 
@@ -88,7 +105,7 @@ func (r *Resolver) Lookup(ip net.IP) *models.GeoResult {
 }
 
 func (r *Resolver) Reload(dbPath string) error {
-	newDB, err := maxminddb.Open(dbPath)
+	newDB, err := OpenDatabase(r.Provider(), dbPath)
 	if err != nil {
 		return err
 	}
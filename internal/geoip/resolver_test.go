@@ -3,6 +3,8 @@ package geoip
 import (
 	"net"
 	"testing"
+
+	"streammon/internal/models"
 )
 
 func TestLookupNilWhenNoDB(t *testing.T) {
@@ -67,3 +69,31 @@ func TestCloseHandlesNilDatabases(t *testing.T) {
 		t.Fatalf("Close should not error with nil databases: %v", err)
 	}
 }
+
+func TestLookupOverrides(t *testing.T) {
+	r := NewResolver("")
+	r.SetOverrides([]models.GeoOverride{
+		{CIDR: "100.64.0.0/10", Label: "Mom & Dad", Country: "US", Lat: 40.7, Lng: -74},
+		{CIDR: "100.64.1.0/24", Label: "Uncle", City: "Boston", Country: "US"},
+		{CIDR: "192.168.1.0/24", Label: "Home"},
+	})
+
+	got := r.Lookup(net.ParseIP("100.70.0.9"))
+	if got == nil || got.City != "Mom & Dad" || got.Label != "Mom & Dad" || got.Lat != 40.7 || got.IP != "100.70.0.9" {
+		t.Errorf("CGNAT lookup = %+v", got)
+	}
+	if got := r.Lookup(net.ParseIP("100.64.1.20")); got == nil || got.Label != "Uncle" || got.City != "Boston" {
+		t.Errorf("expected the most specific override, got %+v", got)
+	}
+	if got := r.Lookup(net.ParseIP("192.168.1.5")); got == nil || got.Label != "Home" {
+		t.Errorf("expected an override to place a private address, got %+v", got)
+	}
+	if got := r.Lookup(net.ParseIP("8.8.8.8")); got != nil {
+		t.Errorf("expected no result outside the overrides without a database, got %+v", got)
+	}
+
+	r.SetOverrides(nil)
+	if got := r.Lookup(net.ParseIP("100.70.0.9")); got != nil {
+		t.Errorf("expected cleared overrides to stop matching, got %+v", got)
+	}
+}
@@ -42,7 +42,7 @@ type Updater struct {
 }
 
 func NewUpdater(store SettingsStore, resolver *Resolver, geoDBPath string) *Updater {
-	asnDBPath := strings.TrimSuffix(geoDBPath, ".mmdb") + "-ASN.mmdb"
+	asnDBPath := strings.TrimSuffix(strings.TrimSuffix(geoDBPath, ".mmdb"), ".BIN") + "-ASN.mmdb"
 	return &Updater{
 		store:           store,
 		resolver:        resolver,
@@ -61,6 +61,12 @@ func (u *Updater) ASNDBPath() string {
 	return u.asnDBPath
 }
 
+// ManagesDatabases reports whether the updater downloads the database
+// files itself, rather than only reading ones the admin provides.
+func (u *Updater) ManagesDatabases() bool {
+	return u.resolver.Provider().Downloads()
+}
+
 func (u *Updater) Download() error {
 	return u.download(false)
 }
@@ -74,6 +80,19 @@ func (u *Updater) download(force bool) error {
 	u.mu.Lock()
 	defer u.mu.Unlock()
 
+	if !u.resolver.Provider().Downloads() {
+		// The admin keeps these files current; pick up any replacement.
+		if fileExists(u.geoDBPath) {
+			if err := u.resolver.Reload(u.geoDBPath); err != nil {
+				return fmt.Errorf("reloading resolver: %w", err)
+			}
+		}
+		if fileExists(u.asnDBPath) {
+			_ = u.resolver.ReloadASN(u.asnDBPath)
+		}
+		return nil
+	}
+
 	key, err := u.store.GetMaxMindLicenseKey()
 	if err != nil {
 		return fmt.Errorf("getting license key: %w", err)
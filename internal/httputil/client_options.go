@@ -0,0 +1,113 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MaxClientTimeoutSeconds caps a per-client timeout override.
+const MaxClientTimeoutSeconds = 600
+
+// ClientOptions are the outbound HTTP settings configured for one media
+// server or integration. The zero value keeps the process-wide defaults.
+type ClientOptions struct {
+	// ProxyURL routes requests through an http, https, or socks5 proxy.
+	ProxyURL string `json:"proxy_url,omitempty"`
+	// CACert is a PEM bundle trusted in addition to the system roots, for
+	// services behind a self-signed or private CA.
+	CACert string `json:"ca_cert,omitempty"`
+	// InsecureSkipVerify disables TLS certificate verification entirely.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+	// TimeoutSeconds overrides the client's default timeout when non-zero.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// IsZero reports whether o leaves every default in place.
+func (o ClientOptions) IsZero() bool {
+	return o == ClientOptions{}
+}
+
+func (o ClientOptions) Validate() error {
+	if o.ProxyURL != "" {
+		u, err := url.Parse(o.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return errors.New("proxy_url must use http, https, or socks5 scheme")
+		}
+		if u.Host == "" {
+			return errors.New("proxy_url must have a host")
+		}
+	}
+	if o.CACert != "" {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(o.CACert)) {
+			return errors.New("ca_cert must contain at least one PEM certificate")
+		}
+	}
+	if o.TimeoutSeconds < 0 || o.TimeoutSeconds > MaxClientTimeoutSeconds {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", MaxClientTimeoutSeconds)
+	}
+	return nil
+}
+
+// Apply configures c with o, replacing its transport when a proxy or TLS
+// setting is present. Options that are unset leave c unchanged.
+func (o ClientOptions) Apply(c *http.Client) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.TimeoutSeconds > 0 {
+		c.Timeout = time.Duration(o.TimeoutSeconds) * time.Second
+	}
+	if o.ProxyURL == "" && o.CACert == "" && !o.InsecureSkipVerify {
+		return nil
+	}
+
+	base, ok := c.Transport.(*http.Transport)
+	if !ok || base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+
+	if o.ProxyURL != "" {
+		proxy, _ := url.Parse(o.ProxyURL)
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if o.CACert != "" || o.InsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+		if o.CACert != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			pool.AppendCertsFromPEM([]byte(o.CACert))
+			tlsConfig.RootCAs = pool
+		}
+		tlsConfig.InsecureSkipVerify = o.InsecureSkipVerify
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	c.Transport = transport
+	return nil
+}
+
+// NewClientWithOptions returns a client with the given default timeout and
+// o applied on top.
+func NewClientWithOptions(timeout time.Duration, o ClientOptions) (*http.Client, error) {
+	c := NewClientWithTimeout(timeout)
+	if err := o.Apply(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
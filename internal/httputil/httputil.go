@@ -118,11 +118,7 @@ func safeDialControl(_, address string, _ syscall.RawConn) error {
 // notification receivers are a legitimate, common setup.
 func NewSafeClient(timeout time.Duration) *http.Client {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
-	transport.DialContext = (&net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
-		Control:   safeDialControl,
-	}).DialContext
+	transport.DialContext = NewSafeDialer().DialContext
 
 	return &http.Client{
 		Timeout:   timeout,
@@ -133,6 +129,16 @@ func NewSafeClient(timeout time.Duration) *http.Client {
 	}
 }
 
+// NewSafeDialer returns a net.Dialer that applies NewSafeClient's
+// resolved-address checks, for integrations that don't speak HTTP.
+func NewSafeDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   safeDialControl,
+	}
+}
+
 // sensitiveURLParams lists query-string parameter names that carry secrets
 // (API keys, tokens, license keys) and must never reach logs or error
 // messages.
@@ -77,6 +77,11 @@ func NewClient(baseURL, apiKey string) (*Client, error) {
 	}, nil
 }
 
+// Configure applies per-integration proxy, TLS, and timeout settings.
+func (c *Client) Configure(opts httputil.ClientOptions) error {
+	return opts.Apply(c.http)
+}
+
 func ValidateURL(rawURL string) error {
 	return httputil.ValidateIntegrationURL(rawURL)
 }
@@ -44,10 +44,10 @@ func (cd *CascadeDeleter) DeleteExternalReferences(ctx context.Context, item *mo
 
 	var tasks []func() CascadeResult
 	if item.MediaType == models.MediaTypeMovie && item.TMDBID != "" {
-		tasks = append(tasks, func() CascadeResult { return cd.deleteFromRadarr(ctx, item.TMDBID, item.Title) })
+		tasks = append(tasks, func() CascadeResult { return cd.deleteFromRadarr(ctx, item) })
 	}
 	if item.MediaType == models.MediaTypeTV && item.TVDBID != "" {
-		tasks = append(tasks, func() CascadeResult { return cd.deleteFromSonarr(ctx, item.TVDBID, item.Title) })
+		tasks = append(tasks, func() CascadeResult { return cd.deleteFromSonarr(ctx, item) })
 	}
 	if item.TMDBID != "" {
 		mediaType := "movie"
@@ -104,10 +104,25 @@ func (cd *CascadeDeleter) runCascade(ctx context.Context, service, title string,
 	return result
 }
 
-func (cd *CascadeDeleter) deleteFromRadarr(ctx context.Context, tmdbID, title string) CascadeResult {
+// deleteFromRadarr applies the Radarr delete policy to the movie: removing it
+// with its files (the default), unmonitoring it, or leaving it alone, and
+// optionally excluding it from import lists.
+func (cd *CascadeDeleter) deleteFromRadarr(ctx context.Context, item *models.LibraryItemCache) CascadeResult {
+	tmdbID, title := item.TMDBID, item.Title
 	cfg, err := cd.store.GetRadarrConfig()
 	return cd.runCascade(ctx, "radarr", title, cfg, err, func(opCtx context.Context) (bool, string) {
+		policy, err := cd.store.GetRadarrDeletePolicy()
+		if err != nil {
+			return false, fmt.Sprintf("get delete policy: %v", err)
+		}
+		if policy.Action == models.ArrDeleteActionNone {
+			return false, ""
+		}
+
 		client, err := radarr.NewClient(cfg.URL, cfg.APIKey)
+		if err == nil {
+			err = client.Configure(cfg.HTTP)
+		}
 		if err != nil {
 			return false, fmt.Sprintf("create client: %v", err)
 		}
@@ -121,19 +136,50 @@ func (cd *CascadeDeleter) deleteFromRadarr(ctx context.Context, tmdbID, title st
 			return false, ""
 		}
 
-		if err := client.DeleteMovie(opCtx, movieID, true); err != nil {
+		if policy.Action == models.ArrDeleteActionUnmonitor {
+			if err := client.UnmonitorMovie(opCtx, movieID); err != nil {
+				return false, fmt.Sprintf("unmonitor movie %d: %v", movieID, err)
+			}
+			if policy.AddExclusion {
+				tmdbInt, err := strconv.Atoi(tmdbID)
+				if err != nil {
+					return false, fmt.Sprintf("invalid TMDB ID %q: %v", tmdbID, err)
+				}
+				if err := client.AddExclusion(opCtx, tmdbInt, title, item.Year); err != nil {
+					return false, fmt.Sprintf("exclude movie %d: %v", movieID, err)
+				}
+			}
+			log.Printf("cascade radarr %q: unmonitored (TMDB %s, Radarr ID %d, excluded: %v)", title, tmdbID, movieID, policy.AddExclusion)
+			return true, ""
+		}
+
+		if err := client.DeleteMovie(opCtx, movieID, true, policy.AddExclusion); err != nil {
 			return false, fmt.Sprintf("delete movie %d: %v", movieID, err)
 		}
 
-		log.Printf("cascade radarr %q: deleted (TMDB %s, Radarr ID %d)", title, tmdbID, movieID)
+		log.Printf("cascade radarr %q: deleted (TMDB %s, Radarr ID %d, excluded: %v)", title, tmdbID, movieID, policy.AddExclusion)
 		return true, ""
 	})
 }
 
-func (cd *CascadeDeleter) deleteFromSonarr(ctx context.Context, tvdbID, title string) CascadeResult {
+// deleteFromSonarr applies the Sonarr delete policy to the series, like
+// deleteFromRadarr.
+func (cd *CascadeDeleter) deleteFromSonarr(ctx context.Context, item *models.LibraryItemCache) CascadeResult {
+	tvdbID, title := item.TVDBID, item.Title
 	cfg, err := cd.store.GetSonarrConfig()
 	return cd.runCascade(ctx, "sonarr", title, cfg, err, func(opCtx context.Context) (bool, string) {
+		policy, err := cd.store.GetSonarrDeletePolicy()
+		if err != nil {
+			return false, fmt.Sprintf("get delete policy: %v", err)
+		}
+		if policy.Action == models.ArrDeleteActionNone {
+			return false, ""
+		}
+
 		client, err := sonarr.NewClient(cfg.URL, cfg.APIKey)
+		if err == nil {
+			err = client.Configure(cfg.HTTP)
+		}
 		if err != nil {
 			return false, fmt.Sprintf("create client: %v", err)
 		}
@@ -147,11 +193,28 @@ func (cd *CascadeDeleter) deleteFromSonarr(ctx context.Context, tvdbID, title st
 			return false, ""
 		}
 
-		if err := client.DeleteSeries(opCtx, seriesID, true); err != nil {
+		if policy.Action == models.ArrDeleteActionUnmonitor {
+			if err := client.UnmonitorSeries(opCtx, seriesID); err != nil {
+				return false, fmt.Sprintf("unmonitor series %d: %v", seriesID, err)
+			}
+			if policy.AddExclusion {
+				tvdbInt, err := strconv.Atoi(tvdbID)
+				if err != nil {
+					return false, fmt.Sprintf("invalid TVDB ID %q: %v", tvdbID, err)
+				}
+				if err := client.AddExclusion(opCtx, tvdbInt, title); err != nil {
+					return false, fmt.Sprintf("exclude series %d: %v", seriesID, err)
+				}
+			}
+			log.Printf("cascade sonarr %q: unmonitored (TVDB %s, Sonarr ID %d, excluded: %v)", title, tvdbID, seriesID, policy.AddExclusion)
+			return true, ""
+		}
+
+		if err := client.DeleteSeries(opCtx, seriesID, true, policy.AddExclusion); err != nil {
 			return false, fmt.Sprintf("delete series %d: %v", seriesID, err)
 		}
 
-		log.Printf("cascade sonarr %q: deleted (TVDB %s, Sonarr ID %d)", title, tvdbID, seriesID)
+		log.Printf("cascade sonarr %q: deleted (TVDB %s, Sonarr ID %d, excluded: %v)", title, tvdbID, seriesID, policy.AddExclusion)
 		return true, ""
 	})
 }
@@ -160,6 +223,9 @@ func (cd *CascadeDeleter) deleteFromOverseerr(ctx context.Context, tmdbID, media
 	cfg, err := cd.store.GetOverseerrConfig()
 	return cd.runCascade(ctx, "overseerr", title, cfg, err, func(opCtx context.Context) (bool, string) {
 		client, err := overseerr.NewClient(cfg.URL, cfg.APIKey)
+		if err == nil {
+			err = client.Configure(cfg.HTTP)
+		}
 		if err != nil {
 			return false, fmt.Sprintf("create client: %v", err)
 		}
@@ -207,6 +273,9 @@ func (cd *CascadeDeleter) UpdateSonarrMonitoring(ctx context.Context, item *mode
 	cfg, err := cd.store.GetSonarrConfig()
 	return cd.runCascade(ctx, "sonarr", item.Title, cfg, err, func(opCtx context.Context) (bool, string) {
 		client, err := sonarr.NewClient(cfg.URL, cfg.APIKey)
+		if err == nil {
+			err = client.Configure(cfg.HTTP)
+		}
 		if err != nil {
 			return false, fmt.Sprintf("create client: %v", err)
 		}
@@ -556,3 +556,115 @@ func findResult(results []CascadeResult, service string) *CascadeResult {
 	}
 	return nil
 }
+
+func TestDeleteExternalReferences_RadarrUnmonitorWithExclusion(t *testing.T) {
+	var unmonitored, excluded, deleted atomic.Bool
+	radarrSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v3/movie" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode([]map[string]any{{"id": 42}})
+		case r.URL.Path == "/api/v3/movie/editor" && r.Method == http.MethodPut:
+			var body struct {
+				MovieIDs  []int `json:"movieIds"`
+				Monitored bool  `json:"monitored"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.MovieIDs) == 1 && body.MovieIDs[0] == 42 && !body.Monitored {
+				unmonitored.Store(true)
+			}
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/v3/exclusions" && r.Method == http.MethodPost:
+			var body struct {
+				TMDBID int `json:"tmdbId"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.TMDBID == 27205 {
+				excluded.Store(true)
+			}
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete:
+			deleted.Store(true)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer radarrSrv.Close()
+
+	s := newTestStoreWithMigrations(t)
+	configureIntegration(t, s, "radarr", radarrSrv.URL)
+	if err := s.SetRadarrDeletePolicy(models.ArrDeletePolicy{Action: models.ArrDeleteActionUnmonitor, AddExclusion: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cd := NewCascadeDeleter(s)
+	item := &models.LibraryItemCache{Title: "Inception", MediaType: models.MediaTypeMovie, TMDBID: "27205", Year: 2010}
+	results := cd.DeleteExternalReferences(context.Background(), item)
+
+	if !unmonitored.Load() || !excluded.Load() {
+		t.Errorf("unmonitored=%v excluded=%v, want both", unmonitored.Load(), excluded.Load())
+	}
+	if deleted.Load() {
+		t.Error("expected movie to be kept in Radarr")
+	}
+	if r := findResult(results, "radarr"); r == nil || !r.Success {
+		t.Errorf("expected radarr success, got %+v", r)
+	}
+}
+
+func TestDeleteExternalReferences_SonarrDeleteWithExclusion(t *testing.T) {
+	var exclusionFlag atomic.Bool
+	sonarrSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v3/series" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode([]map[string]any{{"id": 77}})
+		case r.URL.Path == "/api/v3/series/77" && r.Method == http.MethodDelete:
+			exclusionFlag.Store(r.URL.Query().Get("addImportListExclusion") == "true")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer sonarrSrv.Close()
+
+	s := newTestStoreWithMigrations(t)
+	configureIntegration(t, s, "sonarr", sonarrSrv.URL)
+	if err := s.SetSonarrDeletePolicy(models.ArrDeletePolicy{Action: models.ArrDeleteActionDelete, AddExclusion: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cd := NewCascadeDeleter(s)
+	item := &models.LibraryItemCache{Title: "Breaking Bad", MediaType: models.MediaTypeTV, TVDBID: "67890"}
+	results := cd.DeleteExternalReferences(context.Background(), item)
+
+	if !exclusionFlag.Load() {
+		t.Error("expected delete with addImportListExclusion=true")
+	}
+	if r := findResult(results, "sonarr"); r == nil || !r.Success {
+		t.Errorf("expected sonarr success, got %+v", r)
+	}
+}
+
+func TestDeleteExternalReferences_ArrActionNone(t *testing.T) {
+	var called atomic.Bool
+	radarrSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer radarrSrv.Close()
+
+	s := newTestStoreWithMigrations(t)
+	configureIntegration(t, s, "radarr", radarrSrv.URL)
+	if err := s.SetRadarrDeletePolicy(models.ArrDeletePolicy{Action: models.ArrDeleteActionNone}); err != nil {
+		t.Fatal(err)
+	}
+
+	cd := NewCascadeDeleter(s)
+	item := &models.LibraryItemCache{Title: "Inception", MediaType: models.MediaTypeMovie, TMDBID: "27205"}
+	results := cd.DeleteExternalReferences(context.Background(), item)
+
+	if called.Load() {
+		t.Error("expected Radarr not to be contacted")
+	}
+	if r := findResult(results, "radarr"); r == nil || r.Success || r.Error != "" {
+		t.Errorf("expected skipped radarr result, got %+v", r)
+	}
+}
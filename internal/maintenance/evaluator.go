@@ -25,6 +25,7 @@ const (
 	DefaultMaxHeight   = 720
 	DefaultMinSizeGB   = 10.0
 	DefaultKeepSeasons = 3
+	DefaultMaxRating   = 6.0
 )
 
 type MediaServerResolver interface {
@@ -76,15 +77,89 @@ func (e *Evaluator) EvaluateRule(ctx context.Context, rule *models.MaintenanceRu
 		candidates, items, err = e.evaluateLargeFiles(ctx, rule)
 	case models.CriterionKeepLatestSeasons:
 		candidates, items, err = e.evaluateKeepLatestSeasons(ctx, rule)
+	case models.CriterionLowRated:
+		candidates, items, err = e.evaluateLowRated(ctx, rule)
 	default:
 		return nil, fmt.Errorf("unknown criterion type: %s", rule.CriterionType)
 	}
 	if err != nil {
 		return nil, err
 	}
+	candidates, err = e.dropCollectionProtected(candidates, items)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err = e.dropInProgress(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
 	return deduplicateCandidates(candidates, items), nil
 }
 
+// dropInProgress removes candidates someone has partially watched within the
+// continue-watching window, so a show being watched mid-season isn't flagged
+// by any criterion.
+func (e *Evaluator) dropInProgress(ctx context.Context, candidates []models.BatchCandidate) ([]models.BatchCandidate, error) {
+	days, err := e.store.GetMaintenanceContinueWatchingDays()
+	if err != nil {
+		return nil, fmt.Errorf("get continue watching window: %w", err)
+	}
+	if days == 0 || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	ids := make([]int64, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.LibraryItemID
+	}
+	inProgress, err := e.store.GetInProgressWatchTimes(ctx, ids, time.Now().UTC().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+	if len(inProgress) == 0 {
+		return candidates, nil
+	}
+
+	result := make([]models.BatchCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if _, ok := inProgress[c.LibraryItemID]; !ok {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// dropCollectionProtected removes candidates whose item belongs to a
+// protected collection. Membership comes from the last library sync, so
+// adding an item to a collection protects it from the next evaluation on.
+func (e *Evaluator) dropCollectionProtected(candidates []models.BatchCandidate, items []models.LibraryItemCache) ([]models.BatchCandidate, error) {
+	protection, err := e.store.GetMaintenanceCollectionProtection()
+	if err != nil {
+		return nil, fmt.Errorf("get collection protection: %w", err)
+	}
+	if !protection.Enabled() || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	protected := make(map[int64]bool)
+	for _, item := range items {
+		if protection.Protects(item.Collections) {
+			protected[item.ID] = true
+		}
+	}
+	if len(protected) == 0 {
+		return candidates, nil
+	}
+
+	result := make([]models.BatchCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if !protected[c.LibraryItemID] {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
 type unwatchedParams struct {
 	Days int `json:"days"`
 }
@@ -100,44 +175,11 @@ func (e *Evaluator) evaluateUnwatched(ctx context.Context, rule *models.Maintena
 
 	now := time.Now().UTC()
 	cutoff := now.AddDate(0, 0, -params.Days)
-	items, err := e.store.ListItemsForLibraries(ctx, rule.Libraries)
+	items, err := e.itemsWithWatchTimes(ctx, rule.Libraries)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	itemIDs := make([]int64, len(items))
-	for i, item := range items {
-		itemIDs[i] = item.ID
-	}
-
-	watchTimes, err := e.store.GetCrossServerWatchTimes(ctx, itemIDs)
-	if err != nil {
-		return nil, nil, fmt.Errorf("cross-server watch times: %w", err)
-	}
-
-	for i := range items {
-		if t, ok := watchTimes[items[i].ID]; ok && t != nil {
-			if items[i].LastWatchedAt == nil || t.After(*items[i].LastWatchedAt) {
-				items[i].LastWatchedAt = t
-			}
-		}
-	}
-
-	// Merge StreamMon's own watch_history which captures ALL users' sessions,
-	// not just the API user whose watch data the media server reports.
-	smTimes, err := e.store.GetStreamMonWatchTimes(ctx, itemIDs)
-	if err != nil {
-		return nil, nil, fmt.Errorf("streammon watch times: %w", err)
-	}
-
-	for i := range items {
-		if t, ok := smTimes[items[i].ID]; ok && t != nil {
-			if items[i].LastWatchedAt == nil || t.After(*items[i].LastWatchedAt) {
-				items[i].LastWatchedAt = t
-			}
-		}
-	}
-
 	total := countByMediaType(items, mediaType)
 
 	var results []models.BatchCandidate
@@ -179,6 +221,49 @@ func (e *Evaluator) evaluateUnwatched(ctx context.Context, rule *models.Maintena
 	return results, items, nil
 }
 
+// itemsWithWatchTimes lists the libraries' items with LastWatchedAt set to
+// the latest watch reported by any server or recorded by StreamMon.
+func (e *Evaluator) itemsWithWatchTimes(ctx context.Context, libraries []models.RuleLibrary) ([]models.LibraryItemCache, error) {
+	items, err := e.store.ListItemsForLibraries(ctx, libraries)
+	if err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]int64, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+
+	watchTimes, err := e.store.GetCrossServerWatchTimes(ctx, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("cross-server watch times: %w", err)
+	}
+
+	for i := range items {
+		if t, ok := watchTimes[items[i].ID]; ok && t != nil {
+			if items[i].LastWatchedAt == nil || t.After(*items[i].LastWatchedAt) {
+				items[i].LastWatchedAt = t
+			}
+		}
+	}
+
+	// Merge StreamMon's own watch_history which captures ALL users' sessions,
+	// not just the API user whose watch data the media server reports.
+	smTimes, err := e.store.GetStreamMonWatchTimes(ctx, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("streammon watch times: %w", err)
+	}
+
+	for i := range items {
+		if t, ok := smTimes[items[i].ID]; ok && t != nil {
+			if items[i].LastWatchedAt == nil || t.After(*items[i].LastWatchedAt) {
+				items[i].LastWatchedAt = t
+			}
+		}
+	}
+	return items, nil
+}
+
 func (e *Evaluator) evaluateLowResolution(ctx context.Context, rule *models.MaintenanceRule) ([]models.BatchCandidate, []models.LibraryItemCache, error) {
 	var params models.LowResolutionParams
 	if err := json.Unmarshal(rule.Parameters, &params); err != nil {
@@ -442,6 +527,146 @@ func (e *Evaluator) evaluateKeepLatestSeasonsItem(ctx context.Context, item mode
 	return nil
 }
 
+// lowRatedConcurrency bounds concurrent TMDB lookups in evaluateLowRated,
+// for the same reason as keepLatestSeasonsConcurrency.
+const lowRatedConcurrency = 6
+
+// tmdbRating is the part of a TMDB movie or TV response evaluateLowRated
+// compares against its thresholds.
+type tmdbRating struct {
+	VoteAverage float64 `json:"vote_average"`
+	VoteCount   int     `json:"vote_count"`
+	Popularity  float64 `json:"popularity"`
+}
+
+func (e *Evaluator) evaluateLowRated(ctx context.Context, rule *models.MaintenanceRule) ([]models.BatchCandidate, []models.LibraryItemCache, error) {
+	var params models.LowRatedParams
+	if err := json.Unmarshal(rule.Parameters, &params); err != nil {
+		return nil, nil, fmt.Errorf("parse params: %w", err)
+	}
+	if params.Days <= 0 {
+		params.Days = DefaultDays
+	}
+	if params.MaxRating <= 0 && params.MaxPopularity <= 0 {
+		params.MaxRating = DefaultMaxRating
+	}
+	if e.tmdb == nil {
+		return nil, nil, fmt.Errorf("%s requires TMDB", models.CriterionLowRated)
+	}
+
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -params.Days)
+	items, err := e.itemsWithWatchTimes(ctx, rule.Libraries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	total := countByMediaType(items, rule.MediaType)
+
+	// Only items past the watch cutoff need a TMDB lookup; the rest still
+	// count toward progress.
+	var staleIndexes []int
+	var processed int64
+	for i, item := range items {
+		if item.MediaType != rule.MediaType {
+			continue
+		}
+		if refTime, _ := getItemRefTime(item); refTime.After(cutoff) || item.TMDBID == "" {
+			processed++
+			continue
+		}
+		staleIndexes = append(staleIndexes, i)
+	}
+
+	candidateAt := make([]*models.BatchCandidate, len(items))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(lowRatedConcurrency)
+
+	for _, idx := range staleIndexes {
+		item := items[idx]
+		g.Go(func() error {
+			if gctx.Err() != nil {
+				return gctx.Err()
+			}
+
+			candidateAt[idx] = e.evaluateLowRatedItem(gctx, item, params, now)
+
+			n := atomic.AddInt64(&processed, 1)
+			mediautil.SendProgress(gctx, mediautil.SyncProgress{
+				Phase:   mediautil.PhaseEvaluating,
+				Current: int(n),
+				Total:   total,
+				Library: item.LibraryID,
+			})
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	var results []models.BatchCandidate
+	for _, idx := range staleIndexes {
+		if c := candidateAt[idx]; c != nil {
+			results = append(results, *c)
+		}
+	}
+	return results, items, nil
+}
+
+// evaluateLowRatedItem looks up an unwatched item on TMDB and returns a
+// candidate when it falls below every configured threshold. Items TMDB
+// can't rate are skipped, so a lookup failure never flags anything.
+func (e *Evaluator) evaluateLowRatedItem(ctx context.Context, item models.LibraryItemCache, params models.LowRatedParams, now time.Time) *models.BatchCandidate {
+	tmdbID, err := strconv.Atoi(item.TMDBID)
+	if err != nil {
+		return nil
+	}
+
+	var raw json.RawMessage
+	if item.MediaType == models.MediaTypeTV {
+		raw, err = e.tmdb.GetTV(ctx, tmdbID)
+	} else {
+		raw, err = e.tmdb.GetMovie(ctx, tmdbID)
+	}
+	if err != nil {
+		log.Printf("low_rated: tmdb lookup for %q (id=%d): %v", item.Title, tmdbID, err)
+		return nil
+	}
+	var rating tmdbRating
+	if err := json.Unmarshal(raw, &rating); err != nil {
+		log.Printf("low_rated: unmarshal rating for %q (tmdb=%d): %v", item.Title, tmdbID, err)
+		return nil
+	}
+
+	var parts []string
+	if params.MaxRating > 0 {
+		if rating.VoteCount == 0 || rating.VoteAverage >= params.MaxRating {
+			return nil
+		}
+		parts = append(parts, fmt.Sprintf("TMDB rating %.1f", rating.VoteAverage))
+	}
+	if params.MaxPopularity > 0 {
+		if rating.Popularity >= params.MaxPopularity {
+			return nil
+		}
+		parts = append(parts, fmt.Sprintf("TMDB popularity %.1f", rating.Popularity))
+	}
+
+	refTime, wasWatched := getItemRefTime(item)
+	days := int(now.Sub(refTime).Hours() / 24)
+	watched := fmt.Sprintf("never watched (added %d days ago)", days)
+	if wasWatched {
+		watched = fmt.Sprintf("not watched in %d days", days)
+	}
+	return &models.BatchCandidate{
+		LibraryItemID: item.ID,
+		Reason:        strings.Join(parts, ", ") + ", " + watched,
+	}
+}
+
 // Items sharing any key represent the same movie/show.
 func externalIDKeys(item *models.LibraryItemCache) []string {
 	var keys []string
@@ -9,8 +9,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1826,3 +1828,198 @@ func TestBenchTask16_KeepLatestSeasonsWallTime(t *testing.T) {
 	fmt.Printf("  measured actual (new):                          %v\n", elapsed)
 	fmt.Printf("  candidates=%d calls=%d\n", len(results), ms.callCount())
 }
+
+func TestEvaluateLowRated(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	srv := seedTestServer(t, s)
+
+	now := time.Now().UTC()
+	mk := func(itemID, title, tmdbID string, addedDaysAgo int) models.LibraryItemCache {
+		return models.LibraryItemCache{
+			ServerID: srv.ID, LibraryID: "lib1", ItemID: itemID,
+			MediaType: models.MediaTypeMovie, Title: title, TMDBID: tmdbID,
+			AddedAt: now.AddDate(0, 0, -addedDaysAgo), SyncedAt: now,
+		}
+	}
+	items := []models.LibraryItemCache{
+		mk("m1", "Filler", "1", 400),
+		mk("m2", "Classic", "2", 400),
+		mk("m3", "Recent Filler", "3", 10),
+		mk("m4", "Unrated", "4", 400),
+		mk("m5", "No TMDB", "", 400),
+		mk("m6", "Lookup Fails", "6", 400),
+	}
+	if _, err := s.UpsertLibraryItems(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+
+	var lookups atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		switch r.URL.Path {
+		case "/movie/1", "/movie/3":
+			fmt.Fprint(w, `{"vote_average":4.2,"vote_count":120,"popularity":1.5}`)
+		case "/movie/2":
+			fmt.Fprint(w, `{"vote_average":8.4,"vote_count":9000,"popularity":40}`)
+		case "/movie/4":
+			fmt.Fprint(w, `{"vote_average":0,"vote_count":0,"popularity":0.2}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	rule := &models.MaintenanceRule{
+		Libraries:     libs(srv.ID, "lib1"),
+		CriterionType: models.CriterionLowRated,
+		MediaType:     models.MediaTypeMovie,
+		Parameters:    json.RawMessage(`{"days": 365, "max_rating": 6}`),
+	}
+
+	e := NewEvaluator(s, tmdb.NewWithBaseURL("", nil, ts.URL), nil)
+	results, err := e.EvaluateRule(ctx, rule)
+	if err != nil {
+		t.Fatalf("EvaluateRule: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1 (only the stale low-rated movie): %+v", len(results), results)
+	}
+	if !strings.Contains(results[0].Reason, "TMDB rating 4.2") || !strings.Contains(results[0].Reason, "never watched") {
+		t.Errorf("unexpected reason %q", results[0].Reason)
+	}
+	// Recently added and TMDB-less items never reach TMDB.
+	if n := lookups.Load(); n != 4 {
+		t.Errorf("got %d TMDB lookups, want 4", n)
+	}
+
+	rule.Parameters = json.RawMessage(`{"days": 365, "max_rating": 6, "max_popularity": 1}`)
+	results, err = e.EvaluateRule(ctx, rule)
+	if err != nil {
+		t.Fatalf("EvaluateRule: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %d results, want 0 (popularity above threshold)", len(results))
+	}
+}
+
+func TestEvaluateLowRatedRequiresTMDB(t *testing.T) {
+	e, srv := newTestEvaluator(t)
+	rule := &models.MaintenanceRule{
+		Libraries:     libs(srv.ID, "lib1"),
+		CriterionType: models.CriterionLowRated,
+		MediaType:     models.MediaTypeMovie,
+		Parameters:    json.RawMessage(`{}`),
+	}
+	if _, err := e.EvaluateRule(context.Background(), rule); err == nil {
+		t.Fatal("expected error without a TMDB client")
+	}
+}
+
+func TestEvaluateRuleSkipsProtectedCollections(t *testing.T) {
+	e, srv := newTestEvaluator(t)
+	ctx := context.Background()
+
+	kept := mkLowResItem(srv.ID, "m1", "Family Favourite", "480", 640, 480)
+	kept.Collections = []string{"Keep Forever"}
+	boxed := mkLowResItem(srv.ID, "m2", "Trilogy Part 1", "480", 640, 480)
+	boxed.Collections = []string{"Trilogy"}
+	plain := mkLowResItem(srv.ID, "m3", "Plain Movie", "480", 640, 480)
+	if _, err := e.store.UpsertLibraryItems(ctx, []models.LibraryItemCache{kept, boxed, plain}); err != nil {
+		t.Fatal(err)
+	}
+
+	titles := func() []string {
+		t.Helper()
+		results, err := e.EvaluateRule(ctx, lowResRule(srv.ID, 720))
+		if err != nil {
+			t.Fatalf("EvaluateRule: %v", err)
+		}
+		var out []string
+		for _, c := range results {
+			item, err := e.store.GetLibraryItem(ctx, c.LibraryItemID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, item.Title)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	if got := titles(); len(got) != 3 {
+		t.Fatalf("without protection got %v, want all 3 items", got)
+	}
+
+	if err := e.store.SetMaintenanceCollectionProtection(models.CollectionProtection{Names: []string{"keep forever"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(); len(got) != 2 || got[0] != "Plain Movie" || got[1] != "Trilogy Part 1" {
+		t.Fatalf("with named protection got %v, want [Plain Movie Trilogy Part 1]", got)
+	}
+
+	if err := e.store.SetMaintenanceCollectionProtection(models.CollectionProtection{AllCollections: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(); len(got) != 1 || got[0] != "Plain Movie" {
+		t.Fatalf("with all collections protected got %v, want [Plain Movie]", got)
+	}
+}
+
+func TestEvaluateRuleSkipsInProgressItems(t *testing.T) {
+	e, srv := newTestEvaluator(t)
+	ctx := context.Background()
+
+	show := mkLowResItem(srv.ID, "show1", "Mid Season", "480", 640, 480)
+	show.MediaType = models.MediaTypeTV
+	other := mkLowResItem(srv.ID, "show2", "Abandoned", "480", 640, 480)
+	other.MediaType = models.MediaTypeTV
+	if _, err := e.store.UpsertLibraryItems(ctx, []models.LibraryItemCache{show, other}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for _, h := range []*models.WatchHistoryEntry{
+		// Partially watched episode of show1 last week.
+		{ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeTV, ItemID: "ep5", GrandparentItemID: "show1",
+			Title: "Episode 5", DurationMs: 3600000, WatchedMs: 600000,
+			StartedAt: now.AddDate(0, 0, -7), StoppedAt: now.AddDate(0, 0, -7).Add(10 * time.Minute)},
+		// show2 was only partially watched long ago.
+		{ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeTV, ItemID: "ep1", GrandparentItemID: "show2",
+			Title: "Episode 1", DurationMs: 3600000, WatchedMs: 600000,
+			StartedAt: now.AddDate(0, 0, -200), StoppedAt: now.AddDate(0, 0, -200).Add(10 * time.Minute)},
+	} {
+		if err := e.store.InsertHistory(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rule := lowResRule(srv.ID, 720)
+	rule.MediaType = models.MediaTypeTV
+
+	results, err := e.EvaluateRule(ctx, rule)
+	if err != nil {
+		t.Fatalf("EvaluateRule: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want only the abandoned show", len(results))
+	}
+	item, err := e.store.GetLibraryItem(ctx, results[0].LibraryItemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "Abandoned" {
+		t.Errorf("candidate = %q, want Abandoned", item.Title)
+	}
+
+	if err := e.store.SetMaintenanceContinueWatchingDays(0); err != nil {
+		t.Fatal(err)
+	}
+	results, err = e.EvaluateRule(ctx, rule)
+	if err != nil {
+		t.Fatalf("EvaluateRule: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("with protection disabled got %d results, want 2", len(results))
+	}
+}
@@ -13,6 +13,10 @@ var (
 	maxSizeGB  = 1000
 	minSeasons = 1
 	maxSeasons = 100
+	minRating     = 0
+	maxRating     = 10
+	minPopularity = 0
+	maxPopularity = 1000
 )
 
 func GetCriterionTypes() []models.CriterionTypeInfo {
@@ -63,5 +67,16 @@ func GetCriterionTypes() []models.CriterionTypeInfo {
 				{Name: "genre_ids", Type: "genre_multi_select", Label: "Filter by genres (empty = all)", Default: nil},
 			},
 		},
+		{
+			Type:        models.CriterionLowRated,
+			Name:        "Low Rated",
+			Description: "Unwatched items with a TMDB rating and popularity below thresholds",
+			MediaTypes:  []models.MediaType{models.MediaTypeMovie, models.MediaTypeTV},
+			Parameters: []models.ParamSpec{
+				{Name: "days", Type: "int", Label: "Days since last watched", Default: DefaultDays, Min: &minDays, Max: &maxDays},
+				{Name: "max_rating", Type: "int", Label: "TMDB rating below (0 = ignore)", Default: int(DefaultMaxRating), Min: &minRating, Max: &maxRating},
+				{Name: "max_popularity", Type: "int", Label: "TMDB popularity below (0 = ignore)", Default: 0, Min: &minPopularity, Max: &maxPopularity},
+			},
+		},
 	}
 }
@@ -9,9 +9,9 @@ import (
 func TestGetCriterionTypes(t *testing.T) {
 	types := GetCriterionTypes()
 
-	// Should have 5 criterion types
-	if len(types) != 5 {
-		t.Errorf("GetCriterionTypes() returned %d types, want 5", len(types))
+	// Should have 6 criterion types
+	if len(types) != 6 {
+		t.Errorf("GetCriterionTypes() returned %d types, want 6", len(types))
 	}
 
 	// Check each type exists
@@ -21,6 +21,7 @@ func TestGetCriterionTypes(t *testing.T) {
 		models.CriterionLowResolution:     false,
 		models.CriterionLargeFiles:        false,
 		models.CriterionKeepLatestSeasons: false,
+		models.CriterionLowRated:          false,
 	}
 
 	for _, ct := range types {
@@ -0,0 +1,78 @@
+package maintenance
+
+import (
+	"encoding/json"
+
+	"streammon/internal/models"
+)
+
+// GetRuleTemplates returns the built-in rule templates. Parameters stay
+// within the bounds GetCriterionTypes advertises.
+func GetRuleTemplates() []models.MaintenanceRuleTemplate {
+	return []models.MaintenanceRuleTemplate{
+		{
+			ID:            "unwatched-movies-1y",
+			Name:          "Unwatched Movies (1 year)",
+			Description:   "Movies nobody has watched in the last year",
+			CriterionType: models.CriterionUnwatchedMovie,
+			MediaType:     models.MediaTypeMovie,
+			Parameters:    json.RawMessage(`{"days":365}`),
+		},
+		{
+			ID:            "unwatched-movies-2y",
+			Name:          "Unwatched Movies (2 years)",
+			Description:   "Movies nobody has watched in the last two years",
+			CriterionType: models.CriterionUnwatchedMovie,
+			MediaType:     models.MediaTypeMovie,
+			Parameters:    json.RawMessage(`{"days":730}`),
+		},
+		{
+			ID:            "stale-tv-1y",
+			Name:          "Stale TV Shows (1 year)",
+			Description:   "TV shows with no watch activity in the last year",
+			CriterionType: models.CriterionUnwatchedTVNone,
+			MediaType:     models.MediaTypeTV,
+			Parameters:    json.RawMessage(`{"days":365}`),
+		},
+		{
+			ID:            "sd-movies",
+			Name:          "SD Movies",
+			Description:   "Movies at 480p or below, usually worth replacing",
+			CriterionType: models.CriterionLowResolution,
+			MediaType:     models.MediaTypeMovie,
+			Parameters:    json.RawMessage(`{"max_height":480}`),
+		},
+		{
+			ID:            "large-movies",
+			Name:          "Large Movies",
+			Description:   "Movies over 50 GB",
+			CriterionType: models.CriterionLargeFiles,
+			MediaType:     models.MediaTypeMovie,
+			Parameters:    json.RawMessage(`{"min_size_gb":50}`),
+		},
+		{
+			ID:            "large-shows",
+			Name:          "Large TV Shows",
+			Description:   "TV shows over 200 GB",
+			CriterionType: models.CriterionLargeFiles,
+			MediaType:     models.MediaTypeTV,
+			Parameters:    json.RawMessage(`{"min_size_gb":200}`),
+		},
+		{
+			ID:            "keep-latest-3-seasons",
+			Name:          "Keep Latest 3 Seasons",
+			Description:   "Older seasons of shows with more than three",
+			CriterionType: models.CriterionKeepLatestSeasons,
+			MediaType:     models.MediaTypeTV,
+			Parameters:    json.RawMessage(`{"keep_seasons":3}`),
+		},
+		{
+			ID:            "low-rated-movies",
+			Name:          "Low Rated Movies",
+			Description:   "Movies rated below 5 on TMDB that nobody has watched in a year",
+			CriterionType: models.CriterionLowRated,
+			MediaType:     models.MediaTypeMovie,
+			Parameters:    json.RawMessage(`{"days":365,"max_rating":5}`),
+		},
+	}
+}
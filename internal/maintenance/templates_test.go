@@ -0,0 +1,51 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestGetRuleTemplates(t *testing.T) {
+	criteria := make(map[models.CriterionType]models.CriterionTypeInfo)
+	for _, ct := range GetCriterionTypes() {
+		criteria[ct.Type] = ct
+	}
+
+	seen := make(map[string]bool)
+	for _, tpl := range GetRuleTemplates() {
+		if tpl.ID == "" || tpl.Name == "" || tpl.Description == "" {
+			t.Errorf("template %+v has empty id, name, or description", tpl)
+		}
+		if seen[tpl.ID] {
+			t.Errorf("duplicate template id %s", tpl.ID)
+		}
+		seen[tpl.ID] = true
+
+		ct, ok := criteria[tpl.CriterionType]
+		if !ok {
+			t.Errorf("template %s: unknown criterion type %s", tpl.ID, tpl.CriterionType)
+			continue
+		}
+		if !slices.Contains(ct.MediaTypes, tpl.MediaType) {
+			t.Errorf("template %s: media type %s not supported by %s", tpl.ID, tpl.MediaType, tpl.CriterionType)
+		}
+
+		var params map[string]any
+		if err := json.Unmarshal(tpl.Parameters, &params); err != nil {
+			t.Errorf("template %s: invalid parameters: %v", tpl.ID, err)
+			continue
+		}
+		for _, spec := range ct.Parameters {
+			v, ok := params[spec.Name].(float64)
+			if !ok {
+				continue
+			}
+			if (spec.Min != nil && v < float64(*spec.Min)) || (spec.Max != nil && v > float64(*spec.Max)) {
+				t.Errorf("template %s: %s = %v out of range", tpl.ID, spec.Name, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package embybase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"streammon/internal/models"
+)
+
+// fetchCollectionMembership maps item IDs to the names of the collections
+// (box sets) they belong to. Emby and Jellyfin don't report membership on the
+// items themselves, so each box set's children are listed instead.
+func (c *Client) fetchCollectionMembership(ctx context.Context) (map[string][]string, error) {
+	boxSets, err := c.fetchAllItems(ctx, url.Values{
+		"IncludeItemTypes": {"BoxSet"},
+		"Recursive":        {"true"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch collections: %w", err)
+	}
+
+	membership := make(map[string][]string)
+	for _, set := range boxSets {
+		children, err := c.fetchAllItems(ctx, url.Values{"ParentId": {set.ID}})
+		if err != nil {
+			return nil, fmt.Errorf("fetch collection %q items: %w", set.Name, err)
+		}
+		for _, child := range children {
+			membership[child.ID] = append(membership[child.ID], set.Name)
+		}
+	}
+	return membership, nil
+}
+
+func (c *Client) fetchAllItems(ctx context.Context, params url.Values) ([]embyLibraryItem, error) {
+	var all []embyLibraryItem
+	offset := 0
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		params.Set("StartIndex", strconv.Itoa(offset))
+		params.Set("Limit", strconv.Itoa(itemBatchSize))
+
+		var page libraryItemsCacheResponse
+		if err := c.fetchItemsPage(ctx, params, &page); err != nil {
+			return nil, err
+		}
+		if len(page.Items) == 0 {
+			break
+		}
+		all = append(all, page.Items...)
+		offset += len(page.Items)
+		if offset >= page.TotalRecordCount {
+			break
+		}
+	}
+	return all, nil
+}
+
+func applyCollectionMembership(items []models.LibraryItemCache, membership map[string][]string) {
+	for i := range items {
+		if names, ok := membership[items[i].ItemID]; ok {
+			items[i].Collections = names
+		}
+	}
+}
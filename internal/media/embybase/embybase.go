@@ -32,7 +32,7 @@ func New(srv models.Server, serverType models.ServerType) *Client {
 		serverType: serverType,
 		url:        strings.TrimRight(srv.URL, "/"),
 		apiKey:     srv.APIKey,
-		client:     httputil.NewClient(),
+		client:     newHTTPClient(srv),
 	}
 }
 
@@ -120,6 +120,7 @@ type embySession struct {
 	ID              string           `json:"Id"`
 	UserName        string           `json:"UserName"`
 	Client          string           `json:"Client"`
+	ClientVersion   string           `json:"ApplicationVersion"`
 	DeviceName      string           `json:"DeviceName"`
 	RemoteIP        string           `json:"RemoteEndPoint"`
 	NowPlaying      *nowPlaying      `json:"NowPlayingItem"`
@@ -129,6 +130,8 @@ type embySession struct {
 
 type nowPlaying struct {
 	Name                  string            `json:"Name"`
+	OriginalTitle         string            `json:"OriginalTitle"`
+	OfficialRating        string            `json:"OfficialRating"`
 	SeriesName            string            `json:"SeriesName"`
 	SeriesId              string            `json:"SeriesId"`
 	SeriesPrimaryImageTag string            `json:"SeriesPrimaryImageTag"`
@@ -172,11 +175,15 @@ type mediaStream struct {
 	VideoRange     string `json:"VideoRange"`     // SDR, HDR, etc.
 	VideoRangeType string `json:"VideoRangeType"` // SDR, HDR10, HDR10+, HLG, DOVI, DOVIWithHDR10, DOVIWithHLG, DOVIWithSDR
 	BitDepth       int    `json:"BitDepth"`
+	RealFrameRate  float64 `json:"RealFrameRate"`
+	Index          int    `json:"Index"`
+	Language       string `json:"Language"`
 }
 
 type playState struct {
-	PositionTicks int64 `json:"PositionTicks"`
-	IsPaused      bool  `json:"IsPaused"`
+	PositionTicks    int64 `json:"PositionTicks"`
+	IsPaused         bool  `json:"IsPaused"`
+	AudioStreamIndex *int  `json:"AudioStreamIndex"`
 }
 
 type transcodingInfo struct {
@@ -187,6 +194,7 @@ type transcodingInfo struct {
 	AudioCodec          string  `json:"AudioCodec"`
 	Bitrate             int64   `json:"Bitrate"`
 	CompletionPct       float64 `json:"CompletionPercentage"`
+	Framerate           float64 `json:"Framerate"`
 	Width               int     `json:"Width"`
 	Height              int     `json:"Height"`
 	AudioChannels       int     `json:"AudioChannels"`
@@ -194,6 +202,24 @@ type transcodingInfo struct {
 	TranscodeReasons    []string `json:"TranscodeReasons"`
 }
 
+// audioLanguage returns the language of the audio stream being played,
+// falling back to the first audio stream that reports one.
+func audioLanguage(streams []mediaStream, ps *playState) string {
+	var first string
+	for _, ms := range streams {
+		if ms.Type != "Audio" || ms.Language == "" {
+			continue
+		}
+		if ps != nil && ps.AudioStreamIndex != nil && *ps.AudioStreamIndex == ms.Index {
+			return ms.Language
+		}
+		if first == "" {
+			first = ms.Language
+		}
+	}
+	return first
+}
+
 func parseSessions(data []byte, serverID int64, serverName string, serverType models.ServerType) ([]models.ActiveStream, error) {
 	var sessions []embySession
 	if err := json.Unmarshal(data, &sessions); err != nil {
@@ -215,6 +241,8 @@ func parseSessions(data []byte, serverID int64, serverName string, serverType mo
 			UserName:          s.UserName,
 			MediaType:         embyMediaType(s.NowPlaying.Type),
 			Title:             s.NowPlaying.Name,
+			OriginalTitle:     s.NowPlaying.OriginalTitle,
+			ContentRating:     s.NowPlaying.OfficialRating,
 			ParentTitle:       s.NowPlaying.SeasonName,
 			GrandparentTitle:  s.NowPlaying.SeriesName,
 			SeasonNumber:      s.NowPlaying.ParentIndexNumber,
@@ -224,6 +252,7 @@ func parseSessions(data []byte, serverID int64, serverName string, serverType mo
 			ProgressMs:        ticksToMs(playPos(s.PlayState)),
 			Player:            s.DeviceName,
 			Platform:          s.Client,
+			PlayerVersion:     s.ClientVersion,
 			IPAddress:         s.RemoteIP,
 			StartedAt:         time.Now().UTC(),
 			State:             embyPlayerState(s.PlayState),
@@ -270,10 +299,13 @@ func parseSessions(data []byte, serverID int64, serverName string, serverType mo
 		}
 		as.Container = container
 		as.Bitrate = bitrate
+		as.Language = audioLanguage(mediaStreams, s.PlayState)
+		var sourceFPS float64
 		for _, ms := range mediaStreams {
 			switch ms.Type {
 			case "Video":
 				as.VideoCodec = ms.Codec
+				sourceFPS = ms.RealFrameRate
 				if ms.Height > 0 {
 					as.VideoResolution = fmt.Sprintf("%dp", ms.Height)
 				}
@@ -301,6 +333,12 @@ func parseSessions(data []byte, serverID int64, serverName string, serverType mo
 			if ti.Height > 0 {
 				as.TranscodeVideoResolution = fmt.Sprintf("%dp", ti.Height)
 			}
+			// Neither server reports a speed, so derive it from the
+			// transcoder's output frame rate against the source's.
+			if sourceFPS > 0 && ti.Framerate > 0 {
+				as.TranscodeSpeed = ti.Framerate / sourceFPS
+			}
+			as.EstimateTranscode(time.Now().UTC())
 		} else {
 			as.VideoDecision = models.TranscodeDecisionDirectPlay
 			as.AudioDecision = models.TranscodeDecisionDirectPlay
@@ -362,8 +400,12 @@ func ticksToMs(ticks int64) int64 {
 
 func embyMediaType(t string) models.MediaType {
 	switch t {
-	case "Movie", "MusicVideo", "Video", "Trailer":
+	case "Movie", "Video", "Trailer":
 		return models.MediaTypeMovie
+	case "MusicVideo":
+		return models.MediaTypeMusicVideo
+	case "Photo":
+		return models.MediaTypePhoto
 	case "Episode", "Series", "Season":
 		return models.MediaTypeTV
 	case "Audio":
@@ -924,3 +966,14 @@ func embyLevel(t string) string {
 		return ""
 	}
 }
+
+// newHTTPClient builds the client for srv from its HTTP options, falling
+// back to the defaults when they can't be applied.
+func newHTTPClient(srv models.Server) *http.Client {
+	client, err := httputil.NewClientWithOptions(httputil.DefaultTimeout, srv.HTTP)
+	if err != nil {
+		slog.Warn("emby/jellyfin: ignoring invalid http options", "server", srv.Name, "error", err)
+		return httputil.NewClient()
+	}
+	return client
+}
@@ -65,6 +65,9 @@ func TestGetSessions(t *testing.T) {
 	if s.Title != "Inception" {
 		t.Errorf("title = %q, want Inception", s.Title)
 	}
+	if s.PlayerVersion != "4.8.1.0" {
+		t.Errorf("player version = %q, want 4.8.1.0", s.PlayerVersion)
+	}
 	if s.DurationMs != 8880000 {
 		t.Errorf("duration = %d, want 8880000", s.DurationMs)
 	}
@@ -103,6 +106,15 @@ func TestGetSessions(t *testing.T) {
 	if s.TranscodeProgress != 55.2 {
 		t.Errorf("transcode progress = %f, want 55.2", s.TranscodeProgress)
 	}
+	if s.TranscodeSpeed != 2 {
+		t.Errorf("transcode speed = %f, want 2", s.TranscodeSpeed)
+	}
+	if s.TranscodeBufferMs != 1301760 {
+		t.Errorf("transcode buffer = %d, want 1301760", s.TranscodeBufferMs)
+	}
+	if s.TranscodeETA == nil {
+		t.Error("expected transcode ETA")
+	}
 	if s.VideoResolution != "1080p" {
 		t.Errorf("source resolution = %q, want 1080p", s.VideoResolution)
 	}
@@ -294,7 +306,8 @@ func TestMediaTypeMappings(t *testing.T) {
 		{"Book", models.MediaTypeBook},
 		{"Series", models.MediaTypeTV},
 		{"Season", models.MediaTypeTV},
-		{"MusicVideo", models.MediaTypeMovie},
+		{"MusicVideo", models.MediaTypeMusicVideo},
+		{"Photo", models.MediaTypePhoto},
 		{"Video", models.MediaTypeMovie},
 		{"BoxSet", models.MediaType("boxset")},
 	}
@@ -1154,6 +1167,14 @@ func TestGetLibraryItems(t *testing.T) {
 		if r.URL.Path != "/Items" {
 			t.Errorf("unexpected path: %s", r.URL.Path)
 		}
+		switch {
+		case r.URL.Query().Get("IncludeItemTypes") == "BoxSet":
+			w.Write([]byte(`{"Items": [{"Id": "set1", "Name": "Keep Forever", "Type": "BoxSet"}], "TotalRecordCount": 1}`))
+			return
+		case r.URL.Query().Get("ParentId") == "set1":
+			w.Write([]byte(`{"Items": [{"Id": "movie1", "Name": "Inception", "Type": "Movie"}], "TotalRecordCount": 1}`))
+			return
+		}
 		if r.URL.Query().Get("Recursive") != "true" {
 			t.Error("missing Recursive=true")
 		}
@@ -1227,6 +1248,9 @@ func TestGetLibraryItems(t *testing.T) {
 	if movie.LibraryID != "lib1" {
 		t.Errorf("movie library id = %q, want lib1", movie.LibraryID)
 	}
+	if len(movie.Collections) != 1 || movie.Collections[0] != "Keep Forever" {
+		t.Errorf("movie collections = %v, want [Keep Forever]", movie.Collections)
+	}
 
 	// Second item should be the series
 	series := items[1]
@@ -1239,6 +1263,9 @@ func TestGetLibraryItems(t *testing.T) {
 	if series.MediaType != models.MediaTypeTV {
 		t.Errorf("series media type = %q, want episode", series.MediaType)
 	}
+	if len(series.Collections) != 0 {
+		t.Errorf("series collections = %v, want none", series.Collections)
+	}
 	if series.EpisodeCount != 62 {
 		t.Errorf("series episode count = %d, want 62", series.EpisodeCount)
 	}
@@ -2069,3 +2096,36 @@ func TestParseSessionsLiveTVProgramIdWithoutImageTagsFallsBack(t *testing.T) {
 		t.Errorf("ThumbURL = %q, want %q (channel logo when program has Id but no ImageTags)", s.ThumbURL, "ch-abc")
 	}
 }
+
+func TestParseSessionsOriginalTitleAndAudioLanguage(t *testing.T) {
+	data := []byte(`[
+		{
+			"Id": "sess-4",
+			"UserName": "dave",
+			"NowPlayingItem": {
+				"Id": "movie-1",
+				"Name": "The Boat",
+				"OriginalTitle": "Das Boot",
+				"Type": "Movie",
+				"MediaStreams": [
+					{"Type": "Video", "Codec": "h264", "Index": 0},
+					{"Type": "Audio", "Codec": "ac3", "Language": "eng", "Index": 1},
+					{"Type": "Audio", "Codec": "ac3", "Language": "ger", "Index": 2}
+				]
+			},
+			"PlayState": {"PositionTicks": 0, "AudioStreamIndex": 2}
+		}
+	]`)
+
+	streams, err := parseSessions(data, 1, "test", models.ServerTypeEmby)
+	if err != nil {
+		t.Fatalf("parseSessions: %v", err)
+	}
+	s := streams[0]
+	if s.OriginalTitle != "Das Boot" {
+		t.Errorf("OriginalTitle = %q, want %q", s.OriginalTitle, "Das Boot")
+	}
+	if s.Language != "ger" {
+		t.Errorf("Language = %q, want the selected audio stream's %q", s.Language, "ger")
+	}
+}
@@ -40,6 +40,7 @@ type embyLibraryItem struct {
 	MediaSources       []embyMediaSource `json:"MediaSources,omitempty"`
 	UserData           *embyUserData     `json:"UserData,omitempty"`
 	ProviderIds        map[string]string `json:"ProviderIds,omitempty"`
+	OfficialRating     string            `json:"OfficialRating,omitempty"`
 }
 
 type embyUserData struct {
@@ -79,6 +80,15 @@ func (c *Client) GetLibraryItems(ctx context.Context, libraryID string) ([]model
 		mediautil.EnrichLastWatched(series, seriesHistory)
 	}
 
+	membership, err := c.fetchCollectionMembership(ctx)
+	if err != nil {
+		slog.Warn("failed to fetch collection membership",
+			"server_type", string(c.serverType), "error", err)
+	} else {
+		applyCollectionMembership(movies, membership)
+		applyCollectionMembership(series, membership)
+	}
+
 	result := slices.Concat(movies, series)
 	if result == nil {
 		return []models.LibraryItemCache{}, nil
@@ -204,7 +214,7 @@ func (c *Client) fetchLibraryBatch(ctx context.Context, libraryID, itemType stri
 		"ParentId":         {libraryID},
 		"Recursive":        {"true"},
 		"IncludeItemTypes": {itemType},
-		"Fields":           {"DateCreated,ProductionYear,MediaSources,RecursiveItemCount,ChildCount,UserData,ProviderIds"},
+		"Fields":           {"DateCreated,ProductionYear,MediaSources,RecursiveItemCount,ChildCount,UserData,ProviderIds,OfficialRating"},
 		"StartIndex":       {strconv.Itoa(offset)},
 		"Limit":            {strconv.Itoa(batchSize)},
 	}
@@ -270,6 +280,7 @@ func (c *Client) fetchLibraryBatch(ctx context.Context, libraryID, itemType stri
 			TMDBID:          item.ProviderIds["Tmdb"],
 			TVDBID:          item.ProviderIds["Tvdb"],
 			IMDBID:          item.ProviderIds["Imdb"],
+			ContentRating:   item.OfficialRating,
 		})
 	}
 
@@ -24,12 +24,23 @@ func (c *Client) TerminateSession(ctx context.Context, sessionID string, message
 	return c.stopSession(ctx, sessionID)
 }
 
+// SendSessionMessage shows message on the client playing sessionID without
+// interrupting playback. It stays up longer than the pre-stop notice in
+// TerminateSession since the viewer may be mid-scene.
+func (c *Client) SendSessionMessage(ctx context.Context, sessionID, message string) error {
+	return c.postSessionMessage(ctx, sessionID, message, 15000)
+}
+
 func (c *Client) sendSessionMessage(ctx context.Context, sessionID string, message string) error {
+	return c.postSessionMessage(ctx, sessionID, message, 5000)
+}
+
+func (c *Client) postSessionMessage(ctx context.Context, sessionID, message string, timeoutMs int) error {
 	msgURL := fmt.Sprintf("%s/Sessions/%s/Message", c.url, url.PathEscape(sessionID))
 	payload := struct {
 		Text      string `json:"Text"`
 		TimeoutMs int    `json:"TimeoutMs"`
-	}{Text: message, TimeoutMs: 5000}
+	}{Text: message, TimeoutMs: timeoutMs}
 	b, _ := json.Marshal(payload)
 	body := string(b)
 	return c.doPost(ctx, msgURL, body)
@@ -2,6 +2,7 @@ package embybase
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -115,3 +116,33 @@ func TestTerminateSession_StopFails(t *testing.T) {
 		t.Fatal("expected error when stop fails")
 	}
 }
+
+func TestSendSessionMessage(t *testing.T) {
+	var got struct {
+		Text      string
+		TimeoutMs int
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Sessions/sess-123/Message" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := New(models.Server{ID: 1, Name: "TestEmby", URL: ts.URL, APIKey: "test-key"}, models.ServerTypeEmby)
+	if err := c.SendSessionMessage(context.Background(), "sess-123", "heads up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Text != "heads up" {
+		t.Errorf("Text = %q, want %q", got.Text, "heads up")
+	}
+	if got.TimeoutMs <= 5000 {
+		t.Errorf("TimeoutMs = %d, want longer than the pre-stop notice", got.TimeoutMs)
+	}
+}
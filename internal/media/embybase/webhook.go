@@ -0,0 +1,115 @@
+package embybase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+// ErrWebhookUnsupported is returned for payloads that are neither an Emby
+// notification nor a Jellyfin Webhook plugin event.
+var ErrWebhookUnsupported = errors.New("unrecognized webhook payload")
+
+// WebhookEvent is a playback event delivered by an Emby notification webhook
+// or the Jellyfin Webhook plugin. Update.SessionKey is empty when the payload
+// carries no session ID (the Jellyfin plugin's default template omits it), in
+// which case the session is matched on item and user.
+type WebhookEvent struct {
+	Update   models.SessionUpdate
+	UserName string
+}
+
+type embyWebhook struct {
+	Event string `json:"Event"`
+	User  struct {
+		Name string `json:"Name"`
+	} `json:"User"`
+	Item struct {
+		ID string `json:"Id"`
+	} `json:"Item"`
+	Session struct {
+		ID string `json:"Id"`
+	} `json:"Session"`
+	PlaybackInfo struct {
+		PositionTicks int64 `json:"PositionTicks"`
+	} `json:"PlaybackInfo"`
+}
+
+type jellyfinWebhook struct {
+	NotificationType      string `json:"NotificationType"`
+	NotificationUsername  string `json:"NotificationUsername"`
+	ItemID                string `json:"ItemId"`
+	SessionID             string `json:"SessionId"`
+	PlaybackPositionTicks int64  `json:"PlaybackPositionTicks"`
+	IsPaused              bool   `json:"IsPaused"`
+}
+
+// ParseWebhook decodes a webhook body. ok is false for well-formed payloads
+// that aren't playback events (library or user notifications, for example).
+func ParseWebhook(body []byte) (ev WebhookEvent, ok bool, err error) {
+	var probe struct {
+		Event            string `json:"Event"`
+		NotificationType string `json:"NotificationType"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return WebhookEvent{}, false, fmt.Errorf("decoding webhook: %w", err)
+	}
+
+	switch {
+	case probe.Event != "":
+		var p embyWebhook
+		if err := json.Unmarshal(body, &p); err != nil {
+			return WebhookEvent{}, false, fmt.Errorf("decoding emby webhook: %w", err)
+		}
+		var state models.SessionState
+		switch p.Event {
+		case "playback.start", "playback.unpause":
+			state = models.SessionStatePlaying
+		case "playback.pause":
+			state = models.SessionStatePaused
+		case "playback.stop":
+			state = models.SessionStateStopped
+		default:
+			return WebhookEvent{}, false, nil
+		}
+		return WebhookEvent{
+			Update: models.SessionUpdate{
+				SessionKey: p.Session.ID,
+				RatingKey:  p.Item.ID,
+				State:      state,
+				ViewOffset: ticksToMs(p.PlaybackInfo.PositionTicks),
+			},
+			UserName: p.User.Name,
+		}, true, nil
+
+	case probe.NotificationType != "":
+		var p jellyfinWebhook
+		if err := json.Unmarshal(body, &p); err != nil {
+			return WebhookEvent{}, false, fmt.Errorf("decoding jellyfin webhook: %w", err)
+		}
+		var state models.SessionState
+		switch p.NotificationType {
+		case "PlaybackStart", "PlaybackProgress":
+			state = models.SessionStatePlaying
+			if p.IsPaused {
+				state = models.SessionStatePaused
+			}
+		case "PlaybackStop":
+			state = models.SessionStateStopped
+		default:
+			return WebhookEvent{}, false, nil
+		}
+		return WebhookEvent{
+			Update: models.SessionUpdate{
+				SessionKey: p.SessionID,
+				RatingKey:  p.ItemID,
+				State:      state,
+				ViewOffset: ticksToMs(p.PlaybackPositionTicks),
+			},
+			UserName: p.NotificationUsername,
+		}, true, nil
+	}
+	return WebhookEvent{}, false, ErrWebhookUnsupported
+}
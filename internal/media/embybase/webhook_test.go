@@ -0,0 +1,76 @@
+package embybase
+
+import (
+	"errors"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestParseWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantOK   bool
+		want     models.SessionUpdate
+		wantUser string
+	}{
+		{
+			name:     "emby pause",
+			body:     `{"Event":"playback.pause","User":{"Name":"alice"},"Item":{"Id":"42"},"Session":{"Id":"sess"},"PlaybackInfo":{"PositionTicks":600000000}}`,
+			wantOK:   true,
+			want:     models.SessionUpdate{SessionKey: "sess", RatingKey: "42", State: models.SessionStatePaused, ViewOffset: 60000},
+			wantUser: "alice",
+		},
+		{
+			name:   "emby stop",
+			body:   `{"Event":"playback.stop","Item":{"Id":"42"},"Session":{"Id":"sess"}}`,
+			wantOK: true,
+			want:   models.SessionUpdate{SessionKey: "sess", RatingKey: "42", State: models.SessionStateStopped},
+		},
+		{
+			name: "emby library event ignored",
+			body: `{"Event":"library.new","Item":{"Id":"42"}}`,
+		},
+		{
+			name:     "jellyfin progress paused",
+			body:     `{"NotificationType":"PlaybackProgress","ItemId":"abc","NotificationUsername":"bob","PlaybackPositionTicks":300000000,"IsPaused":true}`,
+			wantOK:   true,
+			want:     models.SessionUpdate{RatingKey: "abc", State: models.SessionStatePaused, ViewOffset: 30000},
+			wantUser: "bob",
+		},
+		{
+			name:   "jellyfin start with session id",
+			body:   `{"NotificationType":"PlaybackStart","ItemId":"abc","SessionId":"js"}`,
+			wantOK: true,
+			want:   models.SessionUpdate{SessionKey: "js", RatingKey: "abc", State: models.SessionStatePlaying},
+		},
+		{
+			name: "jellyfin item added ignored",
+			body: `{"NotificationType":"ItemAdded","ItemId":"abc"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, ok, err := ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ev.Update != tt.want || ev.UserName != tt.wantUser {
+				t.Errorf("got %+v user %q, want %+v user %q", ev.Update, ev.UserName, tt.want, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestParseWebhookUnsupported(t *testing.T) {
+	if _, _, err := ParseWebhook([]byte(`{"foo":1}`)); !errors.Is(err, ErrWebhookUnsupported) {
+		t.Errorf("expected ErrWebhookUnsupported, got %v", err)
+	}
+	if _, _, err := ParseWebhook([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
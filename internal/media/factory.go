@@ -5,6 +5,7 @@ import (
 
 	"streammon/internal/media/emby"
 	"streammon/internal/media/jellyfin"
+	"streammon/internal/media/kodi"
 	"streammon/internal/media/plex"
 	"streammon/internal/models"
 )
@@ -17,6 +18,8 @@ func NewMediaServer(srv models.Server) (MediaServer, error) {
 		return emby.New(srv), nil
 	case models.ServerTypeJellyfin:
 		return jellyfin.New(srv), nil
+	case models.ServerTypeKodi:
+		return kodi.New(srv), nil
 	default:
 		return nil, fmt.Errorf("unsupported server type: %s", srv.Type)
 	}
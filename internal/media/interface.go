@@ -28,3 +28,9 @@ type MediaServer interface {
 type RealtimeSubscriber interface {
 	Subscribe(ctx context.Context) (<-chan models.SessionUpdate, error)
 }
+
+// SessionMessenger is optionally implemented by adapters whose clients can
+// show an on-screen message during playback. Plex has no such API.
+type SessionMessenger interface {
+	SendSessionMessage(ctx context.Context, sessionID, message string) error
+}
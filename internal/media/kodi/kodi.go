@@ -0,0 +1,743 @@
+// Package kodi monitors a standalone Kodi box through its JSON-RPC API.
+//
+// Kodi has no user accounts, so sessions are attributed to the active
+// profile. The server's API key holds the web server credentials as
+// "username:password", or just the password for the default "kodi" user.
+// Library item IDs are Kodi's per-type IDs prefixed with their type, e.g.
+// "movie-12" or "tvshow-3".
+package kodi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"streammon/internal/httputil"
+	"streammon/internal/mediautil"
+	"streammon/internal/models"
+)
+
+const defaultUser = "kodi"
+
+// maxResponse bounds a JSON-RPC response; library listings are the largest.
+const maxResponse = 32 << 20
+
+type Server struct {
+	serverID   int64
+	serverName string
+	endpoint   string
+	host       string
+	user       string
+	password   string
+	client     *http.Client
+	nextID     atomic.Int64
+}
+
+func New(srv models.Server) *Server {
+	base := strings.TrimRight(srv.URL, "/")
+	endpoint := base
+	if !strings.HasSuffix(endpoint, "/jsonrpc") {
+		endpoint += "/jsonrpc"
+	}
+	var host string
+	if u, err := url.Parse(base); err == nil {
+		host = u.Hostname()
+	}
+	user, password, ok := strings.Cut(srv.APIKey, ":")
+	if !ok {
+		user, password = defaultUser, srv.APIKey
+	}
+	return &Server{
+		serverID:   srv.ID,
+		serverName: srv.Name,
+		endpoint:   endpoint,
+		host:       host,
+		user:       user,
+		password:   password,
+		client:     newHTTPClient(srv),
+	}
+}
+
+func newHTTPClient(srv models.Server) *http.Client {
+	client, err := httputil.NewClientWithOptions(httputil.DefaultTimeout, srv.HTTP)
+	if err != nil {
+		slog.Warn("kodi: ignoring invalid http options", "server", srv.Name, "error", err)
+		return httputil.NewClient()
+	}
+	return client
+}
+
+func (s *Server) Name() string            { return s.serverName }
+func (s *Server) Type() models.ServerType { return models.ServerTypeKodi }
+func (s *Server) ServerID() int64         { return s.serverID }
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("kodi: %s (code %d)", e.Message, e.Code)
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// call invokes method and decodes its result into result, if non-nil.
+func (s *Server) call(ctx context.Context, method string, params, result any) error {
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: s.nextID.Add(1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.password != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httputil.DrainBody(resp)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("kodi authentication failed (status %d) — check the web server username and password", resp.StatusCode)
+	default:
+		return fmt.Errorf("kodi returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return err
+	}
+	var rpc rpcResponse
+	if err := json.Unmarshal(data, &rpc); err != nil {
+		return fmt.Errorf("parsing %s response: %w", method, err)
+	}
+	if rpc.Error != nil {
+		return rpc.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpc.Result, result); err != nil {
+		return fmt.Errorf("parsing %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (s *Server) TestConnection(ctx context.Context) error {
+	var pong string
+	if err := s.call(ctx, "JSONRPC.Ping", nil, &pong); err != nil {
+		return err
+	}
+	if pong != "pong" {
+		return fmt.Errorf("unexpected ping response %q", pong)
+	}
+	return nil
+}
+
+// itemID joins a Kodi item type and its per-type ID.
+func itemID(kind string, id int) string {
+	if id <= 0 {
+		return ""
+	}
+	return kind + "-" + strconv.Itoa(id)
+}
+
+// parseItemID splits an ID made by itemID.
+func parseItemID(id string) (kind string, n int, err error) {
+	kind, num, ok := strings.Cut(id, "-")
+	if ok {
+		n, err = strconv.Atoi(num)
+	}
+	if !ok || err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("invalid kodi item id %q", id)
+	}
+	return kind, n, nil
+}
+
+func mediaType(kind string) models.MediaType {
+	switch kind {
+	case "episode", "tvshow", "season":
+		return models.MediaTypeTV
+	case "song":
+		return models.MediaTypeMusic
+	case "musicvideo":
+		return models.MediaTypeMusicVideo
+	case "channel":
+		return models.MediaTypeLiveTV
+	case "picture":
+		return models.MediaTypePhoto
+	default:
+		// Files played outside the library come through as "unknown".
+		return models.MediaTypeMovie
+	}
+}
+
+// kodiTime is the hours/minutes/seconds/milliseconds object Kodi uses for
+// playback positions.
+type kodiTime struct {
+	Hours        int64 `json:"hours"`
+	Minutes      int64 `json:"minutes"`
+	Seconds      int64 `json:"seconds"`
+	Milliseconds int64 `json:"milliseconds"`
+}
+
+func (t kodiTime) ms() int64 {
+	return ((t.Hours*60+t.Minutes)*60+t.Seconds)*1000 + t.Milliseconds
+}
+
+type streamDetails struct {
+	Video []struct {
+		Codec   string `json:"codec"`
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+		HDRType string `json:"hdrtype"`
+	} `json:"video"`
+	Audio []struct {
+		Codec    string `json:"codec"`
+		Channels int    `json:"channels"`
+		Language string `json:"language"`
+	} `json:"audio"`
+	Subtitle []struct {
+		Language string `json:"language"`
+	} `json:"subtitle"`
+}
+
+type uniqueIDs struct {
+	TMDB string `json:"tmdb"`
+	TVDB string `json:"tvdb"`
+	IMDB string `json:"imdb"`
+}
+
+// kodiItem holds the fields StreamMon asks for across the Player and
+// VideoLibrary methods; each method fills in the ones it was asked for.
+type kodiItem struct {
+	ID            int           `json:"id"`
+	Type          string        `json:"type"`
+	Label         string        `json:"label"`
+	Title         string        `json:"title"`
+	OriginalTitle string        `json:"originaltitle"`
+	ShowTitle     string        `json:"showtitle"`
+	Season        int           `json:"season"`
+	Episode       int           `json:"episode"` // episode number, or count for shows and seasons
+	Year          int           `json:"year"`
+	Duration      int64         `json:"duration"`
+	Runtime       int64         `json:"runtime"`
+	TVShowID      int           `json:"tvshowid"`
+	MovieID       int           `json:"movieid"`
+	EpisodeID     int           `json:"episodeid"`
+	SeasonID      int           `json:"seasonid"`
+	Album         string        `json:"album"`
+	Artist        []string      `json:"artist"`
+	MPAA          string        `json:"mpaa"`
+	Plot          string        `json:"plot"`
+	Genre         []string      `json:"genre"`
+	Director      []string      `json:"director"`
+	Studio        []string      `json:"studio"`
+	Rating        float64       `json:"rating"`
+	DateAdded     string        `json:"dateadded"`
+	FirstAired    string        `json:"firstaired"`
+	File          string        `json:"file"`
+	StreamDetails streamDetails `json:"streamdetails"`
+	UniqueID      uniqueIDs     `json:"uniqueid"`
+	Cast          []struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	} `json:"cast"`
+}
+
+func (it kodiItem) title() string {
+	if it.Title != "" {
+		return it.Title
+	}
+	return it.Label
+}
+
+// addedAt parses Kodi's "2006-01-02 15:04:05" local timestamps.
+func addedAt(s string) time.Time {
+	t, err := time.ParseInLocation(time.DateTime, s, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+var errUnsupported = errors.New("not supported by kodi")
+
+func (s *Server) DeleteItem(ctx context.Context, itemID string) error {
+	// VideoLibrary.Remove* only drops the library entry and leaves the
+	// file on disk, which isn't what maintenance deletes mean.
+	return fmt.Errorf("deleting media: %w", errUnsupported)
+}
+
+func (s *Server) GetUsers(ctx context.Context) ([]models.MediaUser, error) {
+	var result struct {
+		Profiles []struct {
+			Label string `json:"label"`
+		} `json:"profiles"`
+	}
+	if err := s.call(ctx, "Profiles.GetProfiles", nil, &result); err != nil {
+		return nil, err
+	}
+	users := make([]models.MediaUser, 0, len(result.Profiles))
+	for _, p := range result.Profiles {
+		users = append(users, models.MediaUser{Name: p.Label})
+	}
+	return users, nil
+}
+
+func (s *Server) currentProfile(ctx context.Context) string {
+	var profile struct {
+		Label string `json:"label"`
+	}
+	if err := s.call(ctx, "Profiles.GetCurrentProfile", nil, &profile); err != nil || profile.Label == "" {
+		return s.serverName
+	}
+	return profile.Label
+}
+
+type activePlayer struct {
+	PlayerID int    `json:"playerid"`
+	Type     string `json:"type"`
+}
+
+var playerItemProperties = []string{
+	"title", "originaltitle", "showtitle", "season", "episode", "year", "duration",
+	"tvshowid", "album", "artist", "mpaa", "file", "streamdetails",
+}
+
+func (s *Server) GetSessions(ctx context.Context) ([]models.ActiveStream, error) {
+	var players []activePlayer
+	if err := s.call(ctx, "Player.GetActivePlayers", nil, &players); err != nil {
+		return nil, err
+	}
+	if len(players) == 0 {
+		return nil, nil
+	}
+	user := s.currentProfile(ctx)
+
+	var streams []models.ActiveStream
+	for _, p := range players {
+		if p.Type == "picture" {
+			continue
+		}
+		var item struct {
+			Item kodiItem `json:"item"`
+		}
+		if err := s.call(ctx, "Player.GetItem", map[string]any{
+			"playerid": p.PlayerID, "properties": playerItemProperties,
+		}, &item); err != nil {
+			return nil, err
+		}
+		var props struct {
+			Time      kodiTime `json:"time"`
+			TotalTime kodiTime `json:"totaltime"`
+			Speed     float64  `json:"speed"`
+		}
+		if err := s.call(ctx, "Player.GetProperties", map[string]any{
+			"playerid": p.PlayerID, "properties": []string{"time", "totaltime", "speed"},
+		}, &props); err != nil {
+			return nil, err
+		}
+		streams = append(streams, s.buildStream(p, item.Item, props.Time.ms(), props.TotalTime.ms(), props.Speed, user))
+	}
+	return streams, nil
+}
+
+func (s *Server) buildStream(p activePlayer, it kodiItem, positionMs, totalMs int64, speed float64, user string) models.ActiveStream {
+	as := models.ActiveStream{
+		SessionID:     strconv.Itoa(p.PlayerID),
+		ServerID:      s.serverID,
+		ItemID:        itemID(it.Type, it.ID),
+		ServerName:    s.serverName,
+		ServerType:    models.ServerTypeKodi,
+		UserName:      user,
+		MediaType:     mediaType(it.Type),
+		Title:         it.title(),
+		OriginalTitle: it.OriginalTitle,
+		ContentRating: it.MPAA,
+		Year:          it.Year,
+		DurationMs:    totalMs,
+		ProgressMs:    positionMs,
+		Player:        s.serverName,
+		Platform:      "Kodi",
+		StartedAt:     time.Now().UTC(),
+		State:         models.SessionStatePlaying,
+		// Kodi plays files itself, so nothing is ever transcoded.
+		VideoDecision: models.TranscodeDecisionDirectPlay,
+		AudioDecision: models.TranscodeDecisionDirectPlay,
+	}
+	if as.DurationMs == 0 {
+		as.DurationMs = it.Duration * 1000
+	}
+	if speed == 0 {
+		as.State = models.SessionStatePaused
+	}
+	if net.ParseIP(s.host) != nil {
+		as.IPAddress = s.host
+	}
+	switch it.Type {
+	case "episode":
+		as.GrandparentItemID = itemID("tvshow", it.TVShowID)
+		as.GrandparentTitle = it.ShowTitle
+		as.SeasonNumber = it.Season
+		as.EpisodeNumber = it.Episode
+		if it.Season > 0 {
+			as.ParentTitle = fmt.Sprintf("Season %d", it.Season)
+		}
+	case "song":
+		as.ParentTitle = it.Album
+		if len(it.Artist) > 0 {
+			as.GrandparentTitle = it.Artist[0]
+		}
+		as.VideoDecision = ""
+	}
+	if p.Type == "audio" {
+		as.VideoDecision = ""
+	}
+	if len(it.StreamDetails.Video) > 0 {
+		v := it.StreamDetails.Video[0]
+		as.VideoCodec = v.Codec
+		as.VideoResolution = mediautil.HeightToResolution(v.Height)
+		if v.HDRType != "" {
+			as.DynamicRange = strings.ToUpper(v.HDRType)
+		}
+	}
+	if len(it.StreamDetails.Audio) > 0 {
+		a := it.StreamDetails.Audio[0]
+		as.AudioCodec = a.Codec
+		as.AudioChannels = a.Channels
+		as.Language = a.Language
+	}
+	if len(it.StreamDetails.Subtitle) > 0 {
+		as.SubtitleCodec = it.StreamDetails.Subtitle[0].Language
+	}
+	if i := strings.LastIndexByte(it.File, '.'); i >= 0 && i > strings.LastIndexAny(it.File, `/\`) {
+		as.Container = strings.ToLower(it.File[i+1:])
+	}
+	return as
+}
+
+func (s *Server) TerminateSession(ctx context.Context, sessionID string, message string) error {
+	playerID, err := strconv.Atoi(sessionID)
+	if err != nil {
+		return fmt.Errorf("invalid kodi player id %q", sessionID)
+	}
+	if message != "" {
+		if err := s.SendSessionMessage(ctx, sessionID, message); err != nil {
+			slog.Warn("kodi: showing stop message", "server", s.serverName, "error", err)
+		}
+	}
+	return s.call(ctx, "Player.Stop", map[string]any{"playerid": playerID}, nil)
+}
+
+// SendSessionMessage shows message as an on-screen notification. Kodi has
+// one screen, so the session doesn't matter.
+func (s *Server) SendSessionMessage(ctx context.Context, sessionID, message string) error {
+	return s.call(ctx, "GUI.ShowNotification", map[string]any{
+		"title": "StreamMon", "message": message, "displaytime": 10000,
+	}, nil)
+}
+
+// Kodi's video library is presented as two fixed libraries.
+const (
+	libraryMovies  = "movies"
+	libraryTVShows = "tvshows"
+)
+
+type listLimits struct {
+	Total int `json:"total"`
+}
+
+func (s *Server) GetLibraries(ctx context.Context) ([]models.Library, error) {
+	var movies struct {
+		Limits listLimits `json:"limits"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetMovies", map[string]any{"limits": map[string]int{"end": 1}}, &movies); err != nil {
+		return nil, err
+	}
+	var shows struct {
+		Limits listLimits `json:"limits"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetTVShows", map[string]any{"limits": map[string]int{"end": 1}}, &shows); err != nil {
+		return nil, err
+	}
+	var episodes struct {
+		Limits listLimits `json:"limits"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetEpisodes", map[string]any{"limits": map[string]int{"end": 1}}, &episodes); err != nil {
+		return nil, err
+	}
+	return []models.Library{
+		{ID: libraryMovies, ServerID: s.serverID, ServerName: s.serverName, ServerType: models.ServerTypeKodi,
+			Name: "Movies", Type: models.LibraryTypeMovie, ItemCount: movies.Limits.Total},
+		{ID: libraryTVShows, ServerID: s.serverID, ServerName: s.serverName, ServerType: models.ServerTypeKodi,
+			Name: "TV Shows", Type: models.LibraryTypeShow, ItemCount: shows.Limits.Total, GrandchildCount: episodes.Limits.Total},
+	}, nil
+}
+
+func (s *Server) GetLibraryItems(ctx context.Context, libraryID string) ([]models.LibraryItemCache, error) {
+	switch libraryID {
+	case libraryMovies:
+		var result struct {
+			Movies []kodiItem `json:"movies"`
+		}
+		if err := s.call(ctx, "VideoLibrary.GetMovies", map[string]any{
+			"properties": []string{"title", "year", "dateadded", "mpaa", "uniqueid", "streamdetails"},
+		}, &result); err != nil {
+			return nil, err
+		}
+		items := make([]models.LibraryItemCache, 0, len(result.Movies))
+		for _, m := range result.Movies {
+			item := s.cacheItem(libraryID, itemID("movie", m.MovieID), models.MediaTypeMovie, m)
+			if len(m.StreamDetails.Video) > 0 {
+				v := m.StreamDetails.Video[0]
+				item.VideoWidth, item.VideoHeight = v.Width, v.Height
+				item.VideoResolution = mediautil.HeightToResolution(v.Height)
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case libraryTVShows:
+		var result struct {
+			TVShows []kodiItem `json:"tvshows"`
+		}
+		if err := s.call(ctx, "VideoLibrary.GetTVShows", map[string]any{
+			"properties": []string{"title", "year", "dateadded", "mpaa", "uniqueid", "episode"},
+		}, &result); err != nil {
+			return nil, err
+		}
+		items := make([]models.LibraryItemCache, 0, len(result.TVShows))
+		for _, show := range result.TVShows {
+			item := s.cacheItem(libraryID, itemID("tvshow", show.TVShowID), models.MediaTypeTV, show)
+			item.EpisodeCount = show.Episode
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown kodi library %q", libraryID)
+	}
+}
+
+func (s *Server) cacheItem(libraryID, id string, mt models.MediaType, it kodiItem) models.LibraryItemCache {
+	return models.LibraryItemCache{
+		ServerID:      s.serverID,
+		LibraryID:     libraryID,
+		ItemID:        id,
+		MediaType:     mt,
+		Title:         it.title(),
+		Year:          it.Year,
+		AddedAt:       addedAt(it.DateAdded),
+		TMDBID:        it.UniqueID.TMDB,
+		TVDBID:        it.UniqueID.TVDB,
+		IMDBID:        it.UniqueID.IMDB,
+		ContentRating: it.MPAA,
+		SyncedAt:      time.Now().UTC(),
+	}
+}
+
+func (s *Server) GetRecentlyAdded(ctx context.Context, limit int) ([]models.LibraryItem, error) {
+	limits := map[string]int{"end": limit}
+	var movies struct {
+		Movies []kodiItem `json:"movies"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetRecentlyAddedMovies", map[string]any{
+		"properties": []string{"title", "year", "dateadded", "uniqueid"}, "limits": limits,
+	}, &movies); err != nil {
+		return nil, err
+	}
+	var episodes struct {
+		Episodes []kodiItem `json:"episodes"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetRecentlyAddedEpisodes", map[string]any{
+		"properties": []string{"title", "showtitle", "season", "episode", "dateadded", "firstaired"}, "limits": limits,
+	}, &episodes); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.LibraryItem, 0, len(movies.Movies)+len(episodes.Episodes))
+	for _, m := range movies.Movies {
+		items = append(items, models.LibraryItem{
+			ItemID:      itemID("movie", m.MovieID),
+			Title:       m.title(),
+			Year:        m.Year,
+			MediaType:   models.MediaTypeMovie,
+			AddedAt:     addedAt(m.DateAdded),
+			ServerID:    s.serverID,
+			ServerName:  s.serverName,
+			ServerType:  models.ServerTypeKodi,
+			ExternalIDs: models.ExternalIDs{TMDB: m.UniqueID.TMDB, TVDB: m.UniqueID.TVDB, IMDB: m.UniqueID.IMDB},
+		})
+	}
+	for _, e := range episodes.Episodes {
+		items = append(items, models.LibraryItem{
+			ItemID:        itemID("episode", e.EpisodeID),
+			Title:         e.title(),
+			SeriesTitle:   e.ShowTitle,
+			MediaType:     models.MediaTypeTV,
+			AddedAt:       addedAt(e.DateAdded),
+			ServerID:      s.serverID,
+			ServerName:    s.serverName,
+			ServerType:    models.ServerTypeKodi,
+			SeasonNumber:  e.Season,
+			EpisodeNumber: e.Episode,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].AddedAt.After(items[j].AddedAt) })
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+var detailProperties = map[string][]string{
+	"movie":   {"title", "year", "plot", "genre", "director", "cast", "rating", "mpaa", "runtime", "studio", "streamdetails"},
+	"episode": {"title", "showtitle", "season", "episode", "plot", "director", "cast", "rating", "runtime", "tvshowid", "streamdetails"},
+	"tvshow":  {"title", "year", "plot", "genre", "cast", "rating", "mpaa", "studio"},
+}
+
+func (s *Server) GetItemDetails(ctx context.Context, id string) (*models.ItemDetails, error) {
+	kind, n, err := parseItemID(id)
+	if err != nil {
+		return nil, err
+	}
+	props, ok := detailProperties[kind]
+	if !ok {
+		return nil, fmt.Errorf("item details for kodi %s: %w", kind, errUnsupported)
+	}
+	method := map[string]string{"movie": "VideoLibrary.GetMovieDetails", "episode": "VideoLibrary.GetEpisodeDetails", "tvshow": "VideoLibrary.GetTVShowDetails"}[kind]
+	var result map[string]kodiItem
+	if err := s.call(ctx, method, map[string]any{kind + "id": n, "properties": props}, &result); err != nil {
+		return nil, err
+	}
+	it, ok := result[kind+"details"]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	d := &models.ItemDetails{
+		ID:            id,
+		Title:         it.title(),
+		Year:          it.Year,
+		Summary:       it.Plot,
+		MediaType:     mediaType(kind),
+		Genres:        it.Genre,
+		Directors:     it.Director,
+		Rating:        it.Rating,
+		ContentRating: it.MPAA,
+		DurationMs:    it.Runtime * 1000,
+		ServerID:      s.serverID,
+		ServerName:    s.serverName,
+		ServerType:    models.ServerTypeKodi,
+		Level:         kind,
+	}
+	if kind == "tvshow" {
+		d.Level = "show"
+	}
+	if len(it.Studio) > 0 {
+		d.Studio = it.Studio[0]
+	}
+	for _, c := range it.Cast {
+		d.Cast = append(d.Cast, models.CastMember{Name: c.Name, Role: c.Role})
+	}
+	if kind == "episode" {
+		d.SeriesTitle = it.ShowTitle
+		d.SeasonNumber = it.Season
+		d.EpisodeNumber = it.Episode
+		d.SeriesID = itemID("tvshow", it.TVShowID)
+	}
+	if len(it.StreamDetails.Video) > 0 {
+		v := it.StreamDetails.Video[0]
+		d.VideoCodec = v.Codec
+		d.VideoResolution = mediautil.HeightToResolution(v.Height)
+	}
+	if len(it.StreamDetails.Audio) > 0 {
+		d.AudioCodec = it.StreamDetails.Audio[0].Codec
+		d.AudioChannels = it.StreamDetails.Audio[0].Channels
+	}
+	return d, nil
+}
+
+// Season IDs carry the show and season number, which is what
+// VideoLibrary.GetEpisodes filters on: "season-<tvshowid>-<number>".
+func seasonID(showID, number int) string {
+	return fmt.Sprintf("season-%d-%d", showID, number)
+}
+
+func (s *Server) GetSeasons(ctx context.Context, showID string) ([]models.Season, error) {
+	kind, n, err := parseItemID(showID)
+	if err != nil || kind != "tvshow" {
+		return nil, fmt.Errorf("invalid kodi show id %q", showID)
+	}
+	var result struct {
+		Seasons []kodiItem `json:"seasons"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetSeasons", map[string]any{
+		"tvshowid": n, "properties": []string{"season", "episode", "title"},
+	}, &result); err != nil {
+		return nil, err
+	}
+	seasons := make([]models.Season, 0, len(result.Seasons))
+	for _, se := range result.Seasons {
+		seasons = append(seasons, models.Season{
+			ID:           seasonID(n, se.Season),
+			Number:       se.Season,
+			Title:        se.title(),
+			EpisodeCount: se.Episode,
+		})
+	}
+	return seasons, nil
+}
+
+func (s *Server) GetEpisodes(ctx context.Context, id string) ([]models.Episode, error) {
+	var showID, number int
+	if _, err := fmt.Sscanf(id, "season-%d-%d", &showID, &number); err != nil {
+		return nil, fmt.Errorf("invalid kodi season id %q", id)
+	}
+	var result struct {
+		Episodes []kodiItem `json:"episodes"`
+	}
+	if err := s.call(ctx, "VideoLibrary.GetEpisodes", map[string]any{
+		"tvshowid": showID, "season": number, "properties": []string{"title", "episode", "plot", "runtime", "firstaired"},
+	}, &result); err != nil {
+		return nil, err
+	}
+	episodes := make([]models.Episode, 0, len(result.Episodes))
+	for _, e := range result.Episodes {
+		episodes = append(episodes, models.Episode{
+			ID:         itemID("episode", e.EpisodeID),
+			Number:     e.Episode,
+			Title:      e.title(),
+			Summary:    e.Plot,
+			DurationMs: e.Runtime * 1000,
+			AirDate:    e.FirstAired,
+		})
+	}
+	return episodes, nil
+}
@@ -0,0 +1,203 @@
+package kodi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+type mediaServer interface {
+	Name() string
+	Type() models.ServerType
+	GetSessions(ctx context.Context) ([]models.ActiveStream, error)
+	TestConnection(ctx context.Context) error
+	TerminateSession(ctx context.Context, sessionID string, message string) error
+	SendSessionMessage(ctx context.Context, sessionID, message string) error
+}
+
+func TestImplementsMediaServer(t *testing.T) {
+	var _ mediaServer = (*Server)(nil)
+}
+
+// fakeKodi answers JSON-RPC calls from results, keyed by method, and
+// records the methods called.
+type fakeKodi struct {
+	results map[string]string
+	calls   []string
+	params  map[string]json.RawMessage
+}
+
+func newFakeKodi(t *testing.T, results map[string]string) (*fakeKodi, *httptest.Server) {
+	t.Helper()
+	f := &fakeKodi{results: results, params: map[string]json.RawMessage{}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jsonrpc" {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "kodi" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+		f.calls = append(f.calls, req.Method)
+		f.params[req.Method] = req.Params
+		result, ok := f.results[req.Method]
+		if !ok {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found."}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(ts.Close)
+	return f, ts
+}
+
+func newTestKodi(url, apiKey string) *Server {
+	return New(models.Server{ID: 7, Name: "Living Room", Type: models.ServerTypeKodi, URL: url, APIKey: apiKey})
+}
+
+func TestTestConnection(t *testing.T) {
+	_, ts := newFakeKodi(t, map[string]string{"JSONRPC.Ping": `"pong"`})
+
+	if err := newTestKodi(ts.URL, "secret").TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection: %v", err)
+	}
+	if err := newTestKodi(ts.URL+"/jsonrpc", "kodi:secret").TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection with explicit endpoint and user: %v", err)
+	}
+	err := newTestKodi(ts.URL, "wrong").TestConnection(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("bad password error = %v", err)
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	_, ts := newFakeKodi(t, nil)
+	err := newTestKodi(ts.URL, "secret").TestConnection(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Method not found") {
+		t.Fatalf("error = %v, want rpc error", err)
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	_, ts := newFakeKodi(t, map[string]string{
+		"Player.GetActivePlayers":    `[{"playerid":1,"type":"video"},{"playerid":2,"type":"picture"}]`,
+		"Profiles.GetCurrentProfile": `{"label":"Alice"}`,
+		"Player.GetItem": `{"item":{"id":34,"type":"episode","label":"Pilot","title":"Pilot",
+			"showtitle":"The Show","season":1,"episode":2,"tvshowid":5,"file":"/mnt/tv/The Show/S01E02.MKV",
+			"streamdetails":{"video":[{"codec":"hevc","width":3840,"height":2160,"hdrtype":"hdr10"}],
+			"audio":[{"codec":"eac3","channels":6,"language":"eng"}],"subtitle":[]}}}`,
+		"Player.GetProperties": `{"time":{"hours":0,"minutes":1,"seconds":30,"milliseconds":0},
+			"totaltime":{"hours":0,"minutes":45,"seconds":0,"milliseconds":0},"speed":0}`,
+	})
+
+	streams, err := newTestKodi(ts.URL, "secret").GetSessions(context.Background())
+	if err != nil {
+		t.Fatalf("GetSessions: %v", err)
+	}
+	if len(streams) != 1 {
+		t.Fatalf("got %d streams, want 1 (pictures skipped)", len(streams))
+	}
+	s := streams[0]
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"SessionID", s.SessionID, "1"},
+		{"UserName", s.UserName, "Alice"},
+		{"ServerType", s.ServerType, models.ServerTypeKodi},
+		{"MediaType", s.MediaType, models.MediaTypeTV},
+		{"ItemID", s.ItemID, "episode-34"},
+		{"GrandparentItemID", s.GrandparentItemID, "tvshow-5"},
+		{"GrandparentTitle", s.GrandparentTitle, "The Show"},
+		{"SeasonNumber", s.SeasonNumber, 1},
+		{"EpisodeNumber", s.EpisodeNumber, 2},
+		{"ProgressMs", s.ProgressMs, int64(90000)},
+		{"DurationMs", s.DurationMs, int64(2700000)},
+		{"State", s.State, models.SessionStatePaused},
+		{"VideoResolution", s.VideoResolution, "4K"},
+		{"DynamicRange", s.DynamicRange, "HDR10"},
+		{"AudioChannels", s.AudioChannels, 6},
+		{"Container", s.Container, "mkv"},
+		{"VideoDecision", s.VideoDecision, models.TranscodeDecisionDirectPlay},
+		{"IPAddress", s.IPAddress, "127.0.0.1"},
+		{"Platform", s.Platform, "Kodi"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestGetSessionsIdle(t *testing.T) {
+	f, ts := newFakeKodi(t, map[string]string{"Player.GetActivePlayers": `[]`})
+	streams, err := newTestKodi(ts.URL, "secret").GetSessions(context.Background())
+	if err != nil {
+		t.Fatalf("GetSessions: %v", err)
+	}
+	if len(streams) != 0 || len(f.calls) != 1 {
+		t.Fatalf("streams = %v, calls = %v", streams, f.calls)
+	}
+}
+
+func TestTerminateSession(t *testing.T) {
+	f, ts := newFakeKodi(t, map[string]string{
+		"GUI.ShowNotification": `"OK"`,
+		"Player.Stop":          `"OK"`,
+	})
+	if err := newTestKodi(ts.URL, "secret").TerminateSession(context.Background(), "1", "Too many streams"); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+	if strings.Join(f.calls, ",") != "GUI.ShowNotification,Player.Stop" {
+		t.Fatalf("calls = %v", f.calls)
+	}
+	if got := string(f.params["Player.Stop"]); got != `{"playerid":1}` {
+		t.Errorf("Player.Stop params = %s", got)
+	}
+}
+
+func TestGetLibraryItems(t *testing.T) {
+	_, ts := newFakeKodi(t, map[string]string{
+		"VideoLibrary.GetMovies": `{"limits":{"total":1},"movies":[{"movieid":12,"label":"Heat","title":"Heat","year":1995,
+			"dateadded":"2024-03-01 20:15:00","uniqueid":{"tmdb":"949","imdb":"tt0113277"},
+			"streamdetails":{"video":[{"codec":"h264","width":1920,"height":1080}]}}]}`,
+	})
+	items, err := newTestKodi(ts.URL, "secret").GetLibraryItems(context.Background(), libraryMovies)
+	if err != nil {
+		t.Fatalf("GetLibraryItems: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items", len(items))
+	}
+	it := items[0]
+	if it.ItemID != "movie-12" || it.TMDBID != "949" || it.IMDBID != "tt0113277" || it.VideoResolution != "1080p" || it.AddedAt.IsZero() {
+		t.Errorf("item = %+v", it)
+	}
+}
+
+func TestParseItemID(t *testing.T) {
+	kind, n, err := parseItemID("tvshow-42")
+	if err != nil || kind != "tvshow" || n != 42 {
+		t.Fatalf("parseItemID = %q, %d, %v", kind, n, err)
+	}
+	for _, bad := range []string{"", "movie", "movie-", "movie-x", "movie-0"} {
+		if _, _, err := parseItemID(bad); err == nil {
+			t.Errorf("parseItemID(%q) succeeded", bad)
+		}
+	}
+}
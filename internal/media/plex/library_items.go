@@ -38,15 +38,21 @@ type libraryItemsContainer struct {
 }
 
 type libraryItemXML struct {
-	RatingKey    string         `xml:"ratingKey,attr"`
-	Type         string         `xml:"type,attr"`
-	Title        string         `xml:"title,attr"`
-	Year         string         `xml:"year,attr"`
-	AddedAt      string         `xml:"addedAt,attr"`
-	LastViewedAt string         `xml:"lastViewedAt,attr"`
-	LeafCount    string         `xml:"leafCount,attr"`
-	Guids        []plexGuid     `xml:"Guid"`
-	Media        []mediaInfoXML `xml:"Media"`
+	RatingKey     string         `xml:"ratingKey,attr"`
+	Type          string         `xml:"type,attr"`
+	Title         string         `xml:"title,attr"`
+	Year          string         `xml:"year,attr"`
+	AddedAt       string         `xml:"addedAt,attr"`
+	LastViewedAt  string         `xml:"lastViewedAt,attr"`
+	LeafCount     string         `xml:"leafCount,attr"`
+	ContentRating string         `xml:"contentRating,attr"`
+	Guids         []plexGuid     `xml:"Guid"`
+	Collections   []plexTag      `xml:"Collection"`
+	Media         []mediaInfoXML `xml:"Media"`
+}
+
+type plexTag struct {
+	Tag string `xml:"tag,attr"`
 }
 
 type mediaInfoXML struct {
@@ -262,6 +268,12 @@ func (s *Server) fetchLibraryBatch(ctx context.Context, libraryID, typeFilter st
 		}
 
 		externalIDs := parsePlexGuids(item.Guids)
+		var collections []string
+		for _, c := range item.Collections {
+			if c.Tag != "" {
+				collections = append(collections, c.Tag)
+			}
+		}
 		items = append(items, models.LibraryItemCache{
 			ServerID:        s.serverID,
 			LibraryID:       libraryID,
@@ -280,6 +292,8 @@ func (s *Server) fetchLibraryBatch(ctx context.Context, libraryID, typeFilter st
 			TMDBID:          externalIDs.TMDB,
 			TVDBID:          externalIDs.TVDB,
 			IMDBID:          externalIDs.IMDB,
+			ContentRating:   item.ContentRating,
+			Collections:     collections,
 		})
 	}
 
@@ -38,6 +38,8 @@ func TestGetLibraryItems(t *testing.T) {
 <MediaContainer totalSize="1">
   <Video ratingKey="100" type="movie" title="Inception" year="2010" addedAt="1700000000" lastViewedAt="1700100000">
     <Media videoResolution="1080"><Part size="5000000000"/></Media>
+    <Collection tag="Keep Forever"/>
+    <Collection tag="Nolan"/>
   </Video>
 </MediaContainer>`
 
@@ -93,6 +95,9 @@ func TestGetLibraryItems(t *testing.T) {
 	if movie.LastWatchedAt == nil {
 		t.Error("movie LastWatchedAt should not be nil")
 	}
+	if len(movie.Collections) != 2 || movie.Collections[0] != "Keep Forever" || movie.Collections[1] != "Nolan" {
+		t.Errorf("movie collections = %v, want [Keep Forever Nolan]", movie.Collections)
+	}
 
 	show := items[1]
 	if show.Title != "Breaking Bad" {
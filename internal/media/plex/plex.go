@@ -34,7 +34,7 @@ func New(srv models.Server) *Server {
 		serverName: srv.Name,
 		url:        strings.TrimRight(srv.URL, "/"),
 		token:      srv.APIKey,
-		client:     httputil.NewClient(),
+		client:     newHTTPClient(srv),
 	}
 }
 
@@ -252,6 +252,7 @@ type mediaContainer struct {
 	XMLName xml.Name   `xml:"MediaContainer"`
 	Videos  []plexItem `xml:"Video"`
 	Tracks  []plexItem `xml:"Track"`
+	Photos  []plexItem `xml:"Photo"`
 }
 
 type plexItem struct {
@@ -259,9 +260,12 @@ type plexItem struct {
 	RatingKey             string            `xml:"ratingKey,attr"`
 	ParentRatingKey       string            `xml:"parentRatingKey,attr"`
 	GrandparentRatingKey  string            `xml:"grandparentRatingKey,attr"`
+	LibrarySectionID      string            `xml:"librarySectionID,attr"`
 	Type                  string            `xml:"type,attr"`
 	Live                  string            `xml:"live,attr"`
 	Title                 string            `xml:"title,attr"`
+	OriginalTitle         string            `xml:"originalTitle,attr"`
+	ContentRating         string            `xml:"contentRating,attr"`
 	ParentTitle           string            `xml:"parentTitle,attr"`
 	GrandparentTitle      string            `xml:"grandparentTitle,attr"`
 	ParentIndex           string            `xml:"parentIndex,attr"`
@@ -282,6 +286,7 @@ type plexItem struct {
 type player struct {
 	Title   string `xml:"title,attr"`
 	Product string `xml:"product,attr"`
+	Version string `xml:"version,attr"`
 	Address string `xml:"address,attr"`
 	State   string `xml:"state,attr"`
 }
@@ -324,6 +329,7 @@ type plexStream struct {
 	DOVIPresent string `xml:"DOVIPresent,attr"`
 	DOVIProfile string `xml:"DOVIProfile,attr"`
 	BitDepth    string `xml:"bitDepth,attr"`
+	Language    string `xml:"languageCode,attr"`
 }
 
 type transcodeSession struct {
@@ -386,9 +392,10 @@ func (s *Server) parseSessions(ctx context.Context, data []byte) ([]models.Activ
 		return nil, fmt.Errorf("parsing plex XML: %w", err)
 	}
 
-	items := make([]plexItem, 0, len(mc.Videos)+len(mc.Tracks))
+	items := make([]plexItem, 0, len(mc.Videos)+len(mc.Tracks)+len(mc.Photos))
 	items = append(items, mc.Videos...)
 	items = append(items, mc.Tracks...)
+	items = append(items, mc.Photos...)
 
 	activeKeys := make(map[string]struct{}, len(items))
 
@@ -425,6 +432,7 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 		ServerID:          serverID,
 		ItemID:            item.RatingKey,
 		GrandparentItemID: item.GrandparentRatingKey,
+		LibraryID:         item.LibrarySectionID,
 		ServerName:        serverName,
 		ServerType:        models.ServerTypePlex,
 		UserName:          item.User.Title,
@@ -432,6 +440,7 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 		Title:             item.Title,
 		ParentTitle:       item.ParentTitle,
 		GrandparentTitle:  item.GrandparentTitle,
+		ContentRating:     item.ContentRating,
 		SeasonNumber:      atoi(item.ParentIndex),
 		EpisodeNumber:     atoi(item.Index),
 		Year:              atoi(item.Year),
@@ -439,6 +448,7 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 		ProgressMs:        atoi64(item.ViewOffset),
 		Player:            item.Player.Title,
 		Platform:          item.Player.Product,
+		PlayerVersion:     item.Player.Version,
 		IPAddress:         item.Player.Address,
 		Bandwidth:         atoi64(item.Session.Bandwidth) * 1000, // Plex reports kbps
 		StartedAt:         time.Now().UTC(),
@@ -447,6 +457,14 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 	if item.Live == "1" {
 		as.MediaType = models.MediaTypeLiveTV
 	}
+	// Plex reuses originalTitle for a track's artist, so it's only a title
+	// for video items.
+	if item.Type == "movie" || item.Type == "episode" {
+		as.OriginalTitle = item.OriginalTitle
+	}
+	if item.Type == "clip" && item.Subtype == "musicVideo" {
+		as.MediaType = models.MediaTypeMusicVideo
+	}
 	// ThumbURL stores a rating key (e.g., "55555"), a local path fragment
 	// (e.g., "library/metadata/12345/thumb/123"), or an external HTTP URL.
 	// The thumb proxy handler (api_thumb.go) handles keys and paths; external
@@ -458,7 +476,7 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 		as.ThumbURL = normalizeThumb(item.Thumb)
 	}
 
-	if item.Type == "clip" {
+	if item.Type == "clip" && as.MediaType != models.MediaTypeMusicVideo {
 		as.ExtraType = plexExtraType(item.Subtype)
 		if item.ParentRatingKey != "" {
 			as.ThumbURL = item.ParentRatingKey
@@ -494,6 +512,9 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 				if st.StreamType == "1" && as.DynamicRange == "" {
 					as.DynamicRange = deriveDynamicRange(st)
 				}
+				if st.StreamType == "2" && as.Language == "" {
+					as.Language = st.Language
+				}
 				if st.StreamType == "3" && st.Codec != "" {
 					as.SubtitleCodec = st.Codec
 				}
@@ -508,6 +529,8 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 		as.TranscodeHWDecode = isHWAccel(ts.HWDecoding)
 		as.TranscodeHWEncode = isHWAccel(ts.HWEncoding)
 		as.TranscodeProgress = atof(ts.Progress)
+		as.TranscodeSpeed = atof(ts.Speed)
+		as.TranscodeThrottled = ts.Throttled == "1"
 		as.TranscodeContainer = ts.Container
 		if ts.Protocol != "" {
 			as.TranscodeContainer = ts.Protocol
@@ -538,6 +561,7 @@ func buildStream(item plexItem, serverID int64, serverName string, srcInfo *sour
 				as.AudioCodec = ts.SourceAudioCodec
 			}
 		}
+		as.EstimateTranscode(time.Now().UTC())
 	} else {
 		as.VideoDecision = models.TranscodeDecisionDirectPlay
 		as.AudioDecision = models.TranscodeDecisionDirectPlay
@@ -581,6 +605,8 @@ func plexMediaType(t string) models.MediaType {
 		return models.MediaTypeTV
 	case "track":
 		return models.MediaTypeMusic
+	case "photo":
+		return models.MediaTypePhoto
 	default:
 		slog.Warn("unknown plex media type, using raw value", "type", t)
 		return models.MediaType(strings.ToLower(t))
@@ -693,3 +719,14 @@ func deriveDynamicRange(stream plexStream) string {
 
 	return "SDR"
 }
+
+// newHTTPClient builds the client for srv from its HTTP options, falling
+// back to the defaults when they can't be applied.
+func newHTTPClient(srv models.Server) *http.Client {
+	client, err := httputil.NewClientWithOptions(httputil.DefaultTimeout, srv.HTTP)
+	if err != nil {
+		slog.Warn("plex: ignoring invalid http options", "server", srv.Name, "error", err)
+		return httputil.NewClient()
+	}
+	return client
+}
@@ -74,6 +74,12 @@ func TestGetSessions(t *testing.T) {
 	if s.Title != "Inception" {
 		t.Errorf("title = %q, want Inception", s.Title)
 	}
+	if s.LibraryID != "1" {
+		t.Errorf("library id = %q, want 1", s.LibraryID)
+	}
+	if s.ContentRating != "PG-13" {
+		t.Errorf("content rating = %q, want PG-13", s.ContentRating)
+	}
 	if s.Year != 2010 {
 		t.Errorf("year = %d, want 2010", s.Year)
 	}
@@ -125,6 +131,12 @@ func TestGetSessions(t *testing.T) {
 	if s.TranscodeProgress != 40.5 {
 		t.Errorf("transcode progress = %f, want 40.5", s.TranscodeProgress)
 	}
+	if s.TranscodeSpeed != 2.5 || !s.TranscodeThrottled {
+		t.Errorf("transcode speed = %f throttled = %v, want 2.5 throttled", s.TranscodeSpeed, s.TranscodeThrottled)
+	}
+	if s.TranscodeETA == nil {
+		t.Error("expected transcode ETA")
+	}
 	if s.Bandwidth != 12000000 {
 		t.Errorf("bandwidth = %d, want 12000000", s.Bandwidth)
 	}
@@ -148,6 +160,9 @@ func TestGetSessions(t *testing.T) {
 	if s2.VideoDecision != models.TranscodeDecisionDirectPlay {
 		t.Errorf("s2 video decision = %q, want direct play (no TranscodeSession)", s2.VideoDecision)
 	}
+	if s2.PlayerVersion != "8.4.1" {
+		t.Errorf("s2 player version = %q, want 8.4.1", s2.PlayerVersion)
+	}
 
 	// Session 3: clip (trailer)
 	s3 := sessions[2]
@@ -684,3 +699,81 @@ func TestParseSessionsRegularEpisodeNotLiveTV(t *testing.T) {
 		t.Errorf("MediaType = %q, want %q (no live attr → regular TV)", streams[0].MediaType, models.MediaTypeTV)
 	}
 }
+
+func TestParseSessionsMusicVideoAndPhoto(t *testing.T) {
+	srv := &Server{serverID: 1, serverName: "plex-test"}
+	xmlBody := []byte(`<?xml version="1.0"?>
+<MediaContainer size="3">
+  <Video sessionKey="50" ratingKey="200" type="clip" subtype="musicVideo"
+         title="Bohemian Rhapsody" grandparentTitle="Queen">
+    <Player title="TV" product="Plex for Android" address="10.0.0.5" state="playing"/>
+    <Session id="sess-mv" bandwidth="4000"/>
+    <User title="alice"/>
+  </Video>
+  <Video sessionKey="51" ratingKey="201" type="clip" subtype="trailer" title="Dune Trailer">
+    <Player title="TV" product="Plex for Android" address="10.0.0.5" state="playing"/>
+    <Session id="sess-tr" bandwidth="4000"/>
+    <User title="bob"/>
+  </Video>
+  <Photo sessionKey="52" ratingKey="202" type="photo" title="Beach.jpg" parentTitle="Holiday 2025">
+    <Player title="iPad" product="Plex for iOS" address="10.0.0.9" state="playing"/>
+    <User title="carol"/>
+  </Photo>
+</MediaContainer>`)
+
+	streams, err := srv.parseSessions(context.Background(), xmlBody)
+	if err != nil {
+		t.Fatalf("parseSessions: %v", err)
+	}
+	if len(streams) != 3 {
+		t.Fatalf("got %d streams, want 3", len(streams))
+	}
+
+	byUser := make(map[string]models.ActiveStream, len(streams))
+	for _, s := range streams {
+		byUser[s.UserName] = s
+	}
+	if mv := byUser["alice"]; mv.MediaType != models.MediaTypeMusicVideo || mv.ExtraType != "" {
+		t.Errorf("music video: MediaType=%q ExtraType=%q, want %q and no extra type", mv.MediaType, mv.ExtraType, models.MediaTypeMusicVideo)
+	}
+	if tr := byUser["bob"]; tr.MediaType != models.MediaTypeMovie || tr.ExtraType != models.ExtraTypeTrailer {
+		t.Errorf("trailer: MediaType=%q ExtraType=%q, want movie/trailer", tr.MediaType, tr.ExtraType)
+	}
+	if ph := byUser["carol"]; ph.MediaType != models.MediaTypePhoto {
+		t.Errorf("photo: MediaType=%q, want %q", ph.MediaType, models.MediaTypePhoto)
+	}
+}
+
+func TestParseSessionsOriginalTitleAndLanguage(t *testing.T) {
+	srv := &Server{serverID: 1, serverName: "plex-test"}
+	xmlBody := []byte(`<?xml version="1.0"?>
+<MediaContainer size="2">
+  <Video sessionKey="60" ratingKey="300" type="movie" title="The Boat" originalTitle="Das Boot" year="1981">
+    <Media container="mkv">
+      <Part><Stream streamType="1" codec="h264"/><Stream streamType="2" codec="ac3" languageCode="ger"/></Part>
+    </Media>
+    <Player title="TV" product="Plex for Android" address="10.0.0.5" state="playing"/>
+    <Session id="sess-mv" bandwidth="4000"/>
+    <User title="alice"/>
+  </Video>
+  <Track sessionKey="61" ratingKey="301" type="track" title="Song" originalTitle="Guest Artist">
+    <Player title="Phone" product="Plexamp" address="10.0.0.6" state="playing"/>
+    <User title="bob"/>
+  </Track>
+</MediaContainer>`)
+
+	streams, err := srv.parseSessions(context.Background(), xmlBody)
+	if err != nil {
+		t.Fatalf("parseSessions: %v", err)
+	}
+	byUser := make(map[string]models.ActiveStream, len(streams))
+	for _, s := range streams {
+		byUser[s.UserName] = s
+	}
+	if movie := byUser["alice"]; movie.OriginalTitle != "Das Boot" || movie.Language != "ger" {
+		t.Errorf("movie OriginalTitle/Language = %q/%q, want Das Boot/ger", movie.OriginalTitle, movie.Language)
+	}
+	if track, ok := byUser["bob"]; !ok || track.OriginalTitle != "" {
+		t.Errorf("track OriginalTitle = %q, want empty (Plex uses it for the artist)", track.OriginalTitle)
+	}
+}
@@ -3,6 +3,7 @@ package mediautil
 import "context"
 
 const (
+	PhaseQueued     = "queued"
 	PhaseItems      = "items"
 	PhaseHistory    = "history"
 	PhaseEnriching  = "enriching"
@@ -19,6 +20,9 @@ type SyncProgress struct {
 	Error   string `json:"error,omitempty"`
 	Synced  int    `json:"synced,omitempty"`
 	Deleted int    `json:"deleted,omitempty"`
+	// QueuePosition is the 1-based place in the sync queue while Phase is
+	// PhaseQueued.
+	QueuePosition int `json:"queue_position,omitempty"`
 }
 
 type progressKeyType struct{}
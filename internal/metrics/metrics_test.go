@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesExpositionFormat(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_events_total", "Events.", "kind")
+	h := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+
+	c.Inc("a")
+	c.Add(2, `q"uote`)
+	h.Observe(0.05, "read")
+	h.Observe(0.5, "read")
+	h.Observe(5, "read")
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	WriteGauge(&buf, "test_streams", "Streams.", Sample{Value: 3}, Sample{Labels: []string{"server", "plex"}, Value: 2})
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE test_events_total counter\n",
+		`test_events_total{kind="a"} 1` + "\n",
+		`test_events_total{kind="q\"uote"} 2` + "\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{op="read",le="0.1"} 1` + "\n",
+		`test_latency_seconds_bucket{op="read",le="1"} 2` + "\n",
+		`test_latency_seconds_bucket{op="read",le="+Inf"} 3` + "\n",
+		`test_latency_seconds_sum{op="read"} 5.55` + "\n",
+		`test_latency_seconds_count{op="read"} 3` + "\n",
+		"# TYPE test_streams gauge\ntest_streams 3\n",
+		`test_streams{server="plex"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if c.Value("a") != 1 || h.Count("read") != 3 {
+		t.Errorf("Value = %v, Count = %d", c.Value("a"), h.Count("read"))
+	}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+// Default holds the process-wide metrics recorded by the packages below.
+var Default = NewRegistry()
+
+var (
+	PollDuration = Default.NewHistogramVec("streammon_poll_duration_seconds",
+		"Time taken to fetch sessions from a media server.", DurationBuckets, "server")
+	PollErrors = Default.NewCounterVec("streammon_poll_errors_total",
+		"Session polls that failed, by media server.", "server")
+	RuleEvaluations = Default.NewCounterVec("streammon_rule_evaluations_total",
+		"Rule evaluations, by rule type and result (pass, violation, error).", "rule_type", "result")
+	Notifications = Default.NewCounterVec("streammon_notifications_total",
+		"Notification deliveries, by channel type and result (sent, failed).", "channel_type", "result")
+	RateLimited = Default.NewCounterVec("streammon_rate_limited_total",
+		"Requests rejected by a rate limiter, by limiter (search, auth).", "limiter")
+	StatsShed = Default.NewCounterVec("streammon_stats_shed_total",
+		"Stats requests turned away while their endpoint class was at capacity, by class and outcome (stale, rejected).", "class", "outcome")
+	SessionsSuppressed = Default.NewCounterVec("streammon_sessions_suppressed_total",
+		"Ended sessions kept out of watch history, by reason (do_not_track, library_filter).", "reason")
+	DBQueryDuration = Default.NewHistogramVec("streammon_db_query_duration_seconds",
+		"Database statement latency, by operation (query, exec).", DurationBuckets, "op")
+)
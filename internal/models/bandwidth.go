@@ -0,0 +1,72 @@
+package models
+
+// BandwidthNetwork says whether a stream was served to a client on the
+// server's own network or over the internet.
+type BandwidthNetwork string
+
+const (
+	BandwidthLAN BandwidthNetwork = "lan"
+	BandwidthWAN BandwidthNetwork = "wan"
+)
+
+// BandwidthInterval is the bucket size of a bandwidth series.
+type BandwidthInterval string
+
+const (
+	BandwidthHourly BandwidthInterval = "hourly"
+	BandwidthDaily  BandwidthInterval = "daily"
+)
+
+func (i BandwidthInterval) Valid() bool {
+	return i == BandwidthHourly || i == BandwidthDaily
+}
+
+// BandwidthSample is one poll's traffic for a server, user and network:
+// Bytes estimated from the summed session bitrates over the time since the
+// previous poll, PeakBps that summed bitrate.
+type BandwidthSample struct {
+	ServerID int64
+	UserName string
+	Network  BandwidthNetwork
+	Bytes    int64
+	PeakBps  int64
+}
+
+// BandwidthPoint is one bucket of a bandwidth series. Bucket is the local
+// start time, "YYYY-MM-DD HH:00" hourly or "YYYY-MM-DD" daily, and AvgBps
+// spreads the bucket's bytes over its whole length.
+type BandwidthPoint struct {
+	Bucket   string `json:"bucket"`
+	Bytes    int64  `json:"bytes"`
+	LANBytes int64  `json:"lan_bytes"`
+	WANBytes int64  `json:"wan_bytes"`
+	AvgBps   int64  `json:"avg_bps"`
+}
+
+// BandwidthServerStat is a server's traffic over the whole range.
+type BandwidthServerStat struct {
+	ServerID   int64  `json:"server_id"`
+	ServerName string `json:"server_name"`
+	Bytes      int64  `json:"bytes"`
+	WANBytes   int64  `json:"wan_bytes"`
+}
+
+// BandwidthUserStat is a user's traffic over the whole range, with the
+// internet share broken out since that is what uses upload capacity.
+// PeakWANBps is the highest combined bitrate of the user's internet streams
+// on one server in a single poll.
+type BandwidthUserStat struct {
+	UserName   string `json:"user_name"`
+	Bytes      int64  `json:"bytes"`
+	WANBytes   int64  `json:"wan_bytes"`
+	PeakWANBps int64  `json:"peak_wan_bps"`
+}
+
+type BandwidthStats struct {
+	Interval BandwidthInterval     `json:"interval"`
+	LANBytes int64                 `json:"lan_bytes"`
+	WANBytes int64                 `json:"wan_bytes"`
+	Series   []BandwidthPoint      `json:"series"`
+	Servers  []BandwidthServerStat `json:"servers"`
+	Users    []BandwidthUserStat   `json:"users"`
+}
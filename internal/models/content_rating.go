@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// contentRatingAges maps well-known certification labels to the minimum
+// viewer age they imply. Labels are compared upper-cased.
+var contentRatingAges = map[string]int{
+	"G": 0, "PG": 10, "PG-13": 13, "R": 17, "NC-17": 18, "X": 18,
+	"TV-Y": 0, "TV-Y7": 7, "TV-Y7-FV": 7, "TV-G": 0, "TV-PG": 10, "TV-14": 14, "TV-MA": 17,
+	"U": 0, "UC": 0, "12A": 12, "R18": 18, "AL": 0, "TP": 0,
+}
+
+// ContentRatingAge returns the minimum viewer age for a content rating as
+// reported by Plex ("PG-13", "gb/15"), Emby or Jellyfin ("TV-MA", "DE-16").
+// It reports false for unrated or unrecognised labels.
+func ContentRatingAge(rating string) (int, bool) {
+	r := strings.ToUpper(strings.TrimSpace(rating))
+	if r == "" {
+		return 0, false
+	}
+	if age, ok := contentRatingAges[r]; ok {
+		return age, true
+	}
+	// Country-qualified labels: "gb/15", "DE-16", "FSK-12".
+	if i := strings.LastIndexAny(r, "/-"); i >= 0 {
+		r = r[i+1:]
+		if age, ok := contentRatingAges[r]; ok {
+			return age, true
+		}
+	}
+	end := 0
+	for end < len(r) && r[end] >= '0' && r[end] <= '9' {
+		end++
+	}
+	age, err := strconv.Atoi(r[:end])
+	if err != nil || age > 21 {
+		return 0, false
+	}
+	return age, true
+}
+
+// ContentRatingStat is the share of a user's plays at one content rating.
+type ContentRatingStat struct {
+	Rating       string  `json:"rating"`
+	SessionCount int     `json:"session_count"`
+	Percentage   float64 `json:"percentage"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DigestPeriod is how often a digest goes out and the span it covers.
+type DigestPeriod string
+
+const (
+	DigestWeekly  DigestPeriod = "weekly"
+	DigestMonthly DigestPeriod = "monthly"
+)
+
+func (p DigestPeriod) Valid() bool {
+	return p == DigestWeekly || p == DigestMonthly
+}
+
+// LastComplete returns the most recent whole period before now, in now's
+// location, and a key naming it: weeks run Monday to Monday, months from the
+// 1st.
+func (p DigestPeriod) LastComplete(now time.Time) (start, end time.Time, key string) {
+	if p == DigestMonthly {
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(0, -1, 0)
+		return start, end, start.Format("2006-01")
+	}
+	end = WeekStart(now)
+	start = end.AddDate(0, 0, -7)
+	return start, end, start.Format("2006-01-02")
+}
+
+// Title is the heading a digest of this period is sent under.
+func (p DigestPeriod) Title() string {
+	if p == DigestMonthly {
+		return "StreamMon monthly digest"
+	}
+	return "StreamMon weekly digest"
+}
+
+// MaxDigestNameLen bounds a digest schedule's name.
+const MaxDigestNameLen = 100
+
+// DigestSchedule sends a digest of the last period to ChannelIDs. With
+// Personalized set, media server users with an email address also get
+// their own copy, with their own watching added, through the first email
+// channel among ChannelIDs.
+type DigestSchedule struct {
+	ID             int64        `json:"id"`
+	Name           string       `json:"name"`
+	Period         DigestPeriod `json:"period"`
+	ChannelIDs     []int64      `json:"channel_ids"`
+	Personalized   bool         `json:"personalized"`
+	Enabled        bool         `json:"enabled"`
+	LastSentPeriod string       `json:"last_sent_period,omitempty"`
+	LastSentAt     *time.Time   `json:"last_sent_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func (d *DigestSchedule) Validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(d.Name) > MaxDigestNameLen {
+		return fmt.Errorf("name must be at most %d characters", MaxDigestNameLen)
+	}
+	if !d.Period.Valid() {
+		return errors.New("period must be weekly or monthly")
+	}
+	if len(d.ChannelIDs) == 0 {
+		return errors.New("at least one channel is required")
+	}
+	seen := make(map[int64]bool, len(d.ChannelIDs))
+	for _, id := range d.ChannelIDs {
+		if id <= 0 {
+			return errors.New("channel_ids must be positive")
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate channel id %d", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// Digest is a summary of one period's activity across all servers.
+// Personal is only set on a copy made for one user.
+type Digest struct {
+	Name            string          `json:"name"`
+	Period          DigestPeriod    `json:"period"`
+	Start           time.Time       `json:"start"`
+	End             time.Time       `json:"end"`
+	TotalHours      float64         `json:"total_hours"`
+	Plays           int             `json:"plays"`
+	ActiveUsers     int             `json:"active_users"`
+	NewContentCount int             `json:"new_content_count"`
+	NewContent      []DigestNewItem `json:"new_content"`
+	TopMovies       []MediaStat     `json:"top_movies"`
+	TopShows        []MediaStat     `json:"top_shows"`
+	TopUsers        []UserStat      `json:"top_users"`
+	Personal        *DigestPersonal `json:"personal,omitempty"`
+}
+
+// DigestNewItem is a title added to a library during the period.
+type DigestNewItem struct {
+	Title      string    `json:"title"`
+	Year       int       `json:"year,omitempty"`
+	MediaType  MediaType `json:"media_type"`
+	ServerName string    `json:"server_name"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+// DigestPersonal is one recipient's own watching over the period.
+type DigestPersonal struct {
+	UserName   string      `json:"user_name"`
+	TotalHours float64     `json:"total_hours"`
+	Plays      int         `json:"plays"`
+	TopTitles  []MediaStat `json:"top_titles"`
+}
+
+// DigestRecipient is a media server user a personalized digest goes to.
+type DigestRecipient struct {
+	UserName string
+	Email    string
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const MaxDoNotTrackNameLen = 50
+
+// DoNotTrackRule keeps matching sessions out of watch history, for household
+// members who'd rather their viewing wasn't recorded. Every condition that's
+// set must match: UserName and Device case-insensitively, Device against
+// either the player or the platform, and IPRange as a CIDR or bare address.
+// LibraryID needs ServerID since library IDs are per server.
+type DoNotTrackRule struct {
+	ID               int64      `json:"id"`
+	Name             string     `json:"name"`
+	Enabled          bool       `json:"enabled"`
+	ServerID         int64      `json:"server_id,omitempty"`
+	UserName         string     `json:"user_name,omitempty"`
+	Device           string     `json:"device,omitempty"`
+	LibraryID        string     `json:"library_id,omitempty"`
+	IPRange          string     `json:"ip_range,omitempty"`
+	SuppressedCount  int64      `json:"suppressed_count"`
+	LastSuppressedAt *time.Time `json:"last_suppressed_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Validate trims the rule's fields and normalizes IPRange, requiring at
+// least one condition so a rule can't silently stop all recording.
+func (r *DoNotTrackRule) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.UserName = strings.TrimSpace(r.UserName)
+	r.Device = strings.TrimSpace(r.Device)
+	r.LibraryID = strings.TrimSpace(r.LibraryID)
+	r.IPRange = strings.TrimSpace(r.IPRange)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > MaxDoNotTrackNameLen {
+		return fmt.Errorf("name must be at most %d characters", MaxDoNotTrackNameLen)
+	}
+	if r.ServerID < 0 {
+		return errors.New("server_id must not be negative")
+	}
+	if r.LibraryID != "" && r.ServerID == 0 {
+		return errors.New("library_id requires server_id")
+	}
+	if r.ServerID == 0 && r.UserName == "" && r.Device == "" && r.IPRange == "" {
+		return errors.New("at least one of server_id, user_name, device or ip_range is required")
+	}
+	if r.IPRange != "" {
+		p, err := parseNetworkPrefix(r.IPRange)
+		if err != nil {
+			return fmt.Errorf("invalid ip_range %q", r.IPRange)
+		}
+		r.IPRange = p.String()
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to s, apart from its library,
+// which the caller resolves. Disabled rules match nothing.
+func (r DoNotTrackRule) Matches(s *ActiveStream) bool {
+	if !r.Enabled {
+		return false
+	}
+	if r.ServerID != 0 && s.ServerID != r.ServerID {
+		return false
+	}
+	if r.UserName != "" && !strings.EqualFold(s.UserName, r.UserName) {
+		return false
+	}
+	if r.Device != "" && !strings.EqualFold(s.Player, r.Device) && !strings.EqualFold(s.Platform, r.Device) {
+		return false
+	}
+	if r.IPRange != "" {
+		p, err := netip.ParsePrefix(r.IPRange)
+		if err != nil {
+			return false
+		}
+		addr, err := netip.ParseAddr(s.IPAddress)
+		if err != nil || !p.Contains(addr.Unmap()) {
+			return false
+		}
+	}
+	return true
+}
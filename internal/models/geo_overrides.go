@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	MaxGeoOverrideLabelLen = 50
+	MaxGeoOverridePlaceLen = 100
+)
+
+// GeoOverride pins a network to a location the admin knows, such as a
+// relative's CGNAT range the geolocation database places in the wrong city.
+// It's consulted before the database, so history, stats and rules all see
+// it.
+type GeoOverride struct {
+	ID    int64  `json:"id"`
+	CIDR  string `json:"cidr"`
+	Label string `json:"label"`
+	City  string `json:"city"`
+	// Country is an ISO code, as the geolocation databases give.
+	Country   string    `json:"country"`
+	Lat       float64   `json:"lat"`
+	Lng       float64   `json:"lng"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate trims the text fields and normalizes the CIDR, accepting a bare
+// address as a single-host prefix.
+func (o *GeoOverride) Validate() error {
+	o.Label = strings.TrimSpace(o.Label)
+	o.City = strings.TrimSpace(o.City)
+	o.Country = strings.TrimSpace(o.Country)
+	if o.Label == "" {
+		return errors.New("label is required")
+	}
+	if len(o.Label) > MaxGeoOverrideLabelLen {
+		return fmt.Errorf("label must be at most %d characters", MaxGeoOverrideLabelLen)
+	}
+	if len(o.City) > MaxGeoOverridePlaceLen || len(o.Country) > MaxGeoOverridePlaceLen {
+		return fmt.Errorf("city and country must be at most %d characters", MaxGeoOverridePlaceLen)
+	}
+	p, err := parseNetworkPrefix(o.CIDR)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q", strings.TrimSpace(o.CIDR))
+	}
+	o.CIDR = p.String()
+	if o.Lat < -90 || o.Lat > 90 || o.Lng < -180 || o.Lng > 180 {
+		return errors.New("lat must be within ±90 and lng within ±180")
+	}
+	return nil
+}
+
+// Prefix is the override's network. It's only valid once Validate has
+// passed.
+func (o GeoOverride) Prefix() netip.Prefix {
+	p, _ := netip.ParsePrefix(o.CIDR)
+	return p
+}
+
+// Result is the location the override gives ip. Without a city, the label
+// shows in its place.
+func (o GeoOverride) Result(ip string) *GeoResult {
+	city := o.City
+	if city == "" {
+		city = o.Label
+	}
+	return &GeoResult{
+		IP:      ip,
+		Lat:     o.Lat,
+		Lng:     o.Lng,
+		City:    city,
+		Country: o.Country,
+		Label:   o.Label,
+	}
+}
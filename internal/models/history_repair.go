@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxTimeShiftMinutes bounds a history time shift to a day either way,
+// which covers every UTC offset.
+const MaxTimeShiftMinutes = 24 * 60
+
+// HistoryTimeShift selects one server's history rows started in [From, To)
+// and moves them by OffsetMinutes. CreatedFrom and CreatedTo narrow it to
+// the rows written during one import run, and ImportedOnly to rows that came
+// from Tautulli.
+type HistoryTimeShift struct {
+	ServerID      int64      `json:"server_id"`
+	From          time.Time  `json:"from"`
+	To            time.Time  `json:"to"`
+	CreatedFrom   *time.Time `json:"created_from,omitempty"`
+	CreatedTo     *time.Time `json:"created_to,omitempty"`
+	ImportedOnly  bool       `json:"imported_only"`
+	OffsetMinutes int        `json:"offset_minutes"`
+}
+
+func (s *HistoryTimeShift) Validate() error {
+	if s.ServerID <= 0 {
+		return errors.New("server_id is required")
+	}
+	if s.From.IsZero() || s.To.IsZero() || !s.From.Before(s.To) {
+		return errors.New("from must be before to")
+	}
+	if s.CreatedFrom != nil && s.CreatedTo != nil && !s.CreatedFrom.Before(*s.CreatedTo) {
+		return errors.New("created_from must be before created_to")
+	}
+	if s.OffsetMinutes == 0 || s.OffsetMinutes < -MaxTimeShiftMinutes || s.OffsetMinutes > MaxTimeShiftMinutes {
+		return fmt.Errorf("offset_minutes must be non-zero and between -%d and %d", MaxTimeShiftMinutes, MaxTimeShiftMinutes)
+	}
+	return nil
+}
+
+// Offset returns the shift as a duration.
+func (s *HistoryTimeShift) Offset() time.Duration {
+	return time.Duration(s.OffsetMinutes) * time.Minute
+}
+
+// HistoryTimeShiftSample is one row a shift would move, before and after.
+type HistoryTimeShiftSample struct {
+	HistoryID        int64     `json:"history_id"`
+	UserName         string    `json:"user_name"`
+	Title            string    `json:"title"`
+	StartedAt        time.Time `json:"started_at"`
+	ShiftedStartedAt time.Time `json:"shifted_started_at"`
+}
+
+// HistoryTimeShiftPreview reports what a shift would change without
+// changing anything.
+type HistoryTimeShiftPreview struct {
+	Matched int                      `json:"matched"`
+	Samples []HistoryTimeShiftSample `json:"samples"`
+}
+
+// HistoryTimeRepair is an applied shift. UndoneAt is set once it has been
+// reverted.
+type HistoryTimeRepair struct {
+	ID int64 `json:"id"`
+	HistoryTimeShift
+	RowCount  int        `json:"row_count"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UndoneAt  *time.Time `json:"undone_at,omitempty"`
+}
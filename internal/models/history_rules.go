@@ -0,0 +1,111 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// HistoryRuleAction is what a history rule does to a matching play.
+type HistoryRuleAction string
+
+const (
+	// HistoryRuleRenameUser records the play under Replacement instead of
+	// the media server's user name.
+	HistoryRuleRenameUser HistoryRuleAction = "rename_user"
+	// HistoryRuleRewriteTitle replaces Pattern matches in the title with
+	// Replacement, which may refer to capture groups as $1.
+	HistoryRuleRewriteTitle HistoryRuleAction = "rewrite_title"
+	// HistoryRuleSkip drops the play.
+	HistoryRuleSkip HistoryRuleAction = "skip"
+)
+
+// MaxHistoryRules caps the rules in HistoryRuleSettings.
+const MaxHistoryRules = 100
+
+// HistoryRule rewrites or drops plays before they're written to history, on
+// every write path: the poller, webhooks and imports. The match fields
+// narrow which plays it applies to; left empty they match every play.
+// UserName matches case-insensitively, and LibraryID needs ServerID since
+// library IDs are per server.
+type HistoryRule struct {
+	Name        string            `json:"name,omitempty"`
+	Enabled     bool              `json:"enabled"`
+	Action      HistoryRuleAction `json:"action"`
+	ServerID    int64             `json:"server_id,omitempty"`
+	LibraryID   string            `json:"library_id,omitempty"`
+	UserName    string            `json:"user_name,omitempty"`
+	MediaType   MediaType         `json:"media_type,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
+	Replacement string            `json:"replacement,omitempty"`
+}
+
+// HistoryRuleSettings is the ordered list of history rules. Each enabled
+// rule sees the play as rewritten by the rules before it.
+type HistoryRuleSettings struct {
+	Rules []HistoryRule `json:"rules"`
+}
+
+func (s *HistoryRuleSettings) Validate() error {
+	if len(s.Rules) > MaxHistoryRules {
+		return fmt.Errorf("at most %d history rules allowed", MaxHistoryRules)
+	}
+	if s.Rules == nil {
+		s.Rules = []HistoryRule{}
+	}
+	for i := range s.Rules {
+		if err := s.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *HistoryRule) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.LibraryID = strings.TrimSpace(r.LibraryID)
+	r.UserName = strings.TrimSpace(r.UserName)
+	if r.ServerID < 0 {
+		return errors.New("server_id must not be negative")
+	}
+	if r.LibraryID != "" && r.ServerID == 0 {
+		return errors.New("library_id requires server_id")
+	}
+	switch r.Action {
+	case HistoryRuleRenameUser:
+		r.Replacement = strings.TrimSpace(r.Replacement)
+		if r.UserName == "" || r.Replacement == "" {
+			return errors.New("rename_user requires user_name and replacement")
+		}
+	case HistoryRuleRewriteTitle:
+		if r.Pattern == "" {
+			return errors.New("rewrite_title requires pattern")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case HistoryRuleSkip:
+		if r.ServerID == 0 && r.UserName == "" && r.MediaType == "" {
+			return errors.New("skip requires server_id, user_name or media_type")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to entry, apart from its library,
+// which the caller resolves. Disabled rules match nothing.
+func (r HistoryRule) Matches(entry *WatchHistoryEntry) bool {
+	if !r.Enabled {
+		return false
+	}
+	if r.ServerID != 0 && entry.ServerID != r.ServerID {
+		return false
+	}
+	if r.UserName != "" && !strings.EqualFold(entry.UserName, r.UserName) {
+		return false
+	}
+	return r.MediaType == "" || entry.MediaType == r.MediaType
+}
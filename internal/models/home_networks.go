@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// MaxHomeNetworks caps the CIDRs in HomeNetworkSettings.
+const MaxHomeNetworks = 100
+
+// HomeNetworkSettings lists the networks the admin considers home. Sessions
+// from them, and from private, loopback and link-local addresses, are Local:
+// they're tagged as such in history, left out of new-location rules and
+// counted on their own in location stats rather than failing geo lookup.
+type HomeNetworkSettings struct {
+	CIDRs []string `json:"cidrs"`
+}
+
+// Validate normalizes the CIDRs, accepting bare addresses as single-host
+// prefixes and dropping duplicates.
+func (s *HomeNetworkSettings) Validate() error {
+	if len(s.CIDRs) > MaxHomeNetworks {
+		return fmt.Errorf("at most %d home networks", MaxHomeNetworks)
+	}
+	cidrs := make([]string, 0, len(s.CIDRs))
+	seen := make(map[string]bool, len(s.CIDRs))
+	for _, c := range s.CIDRs {
+		p, err := parseNetworkPrefix(c)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %q", strings.TrimSpace(c))
+		}
+		if seen[p.String()] {
+			continue
+		}
+		seen[p.String()] = true
+		cidrs = append(cidrs, p.String())
+	}
+	s.CIDRs = cidrs
+	return nil
+}
+
+// IsLocal reports whether ip is a private, loopback or link-local address
+// or falls in one of the home networks.
+func (s HomeNetworkSettings) IsLocal(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return true
+	}
+	for _, c := range s.CIDRs {
+		p, err := netip.ParsePrefix(c)
+		if err == nil && p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// ImportSource is where an import job reads history from.
+type ImportSource string
+
+const (
+	ImportSourceTautulli          ImportSource = "tautulli"
+	ImportSourceTautulliDatabase  ImportSource = "tautulli_db"
+	ImportSourceJellystat         ImportSource = "jellystat"
+	ImportSourcePlaybackReporting ImportSource = "playback_reporting"
+)
+
+type ImportJobStatus string
+
+const (
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+	ImportJobCancelled ImportJobStatus = "cancelled"
+)
+
+// ImportJob is one history import run and, once it finishes, its report.
+// The counters are InsertHistoryBatch's, summed over every batch; Errors
+// counts source records that couldn't be converted into history rows.
+// Resumes counts how often a restart picked the job back up, skipping the
+// Processed records already imported.
+type ImportJob struct {
+	ID           int64           `json:"id"`
+	Source       ImportSource    `json:"source"`
+	ServerID     int64           `json:"server_id"`
+	Status       ImportJobStatus `json:"status"`
+	FilePath     string          `json:"-"`
+	Processed    int             `json:"processed"`
+	Total        int             `json:"total"`
+	Inserted     int             `json:"inserted"`
+	Skipped      int             `json:"skipped"`
+	Consolidated int             `json:"consolidated"`
+	Errors       int             `json:"errors"`
+	Error        string          `json:"error,omitempty"`
+	Resumes      int             `json:"resumes"`
+	CreatedBy    string          `json:"created_by"`
+	StartedAt    time.Time       `json:"started_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
+}
+
+func (j *ImportJob) Finished() bool {
+	return j.Status != ImportJobRunning
+}
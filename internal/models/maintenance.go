@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -15,13 +16,14 @@ const (
 	CriterionLowResolution   CriterionType = "low_resolution"
 	CriterionLargeFiles      CriterionType = "large_files"
 	CriterionKeepLatestSeasons CriterionType = "keep_latest_seasons"
+	CriterionLowRated CriterionType = "low_rated"
 )
 
 func (ct CriterionType) Valid() bool {
 	switch ct {
 	case CriterionUnwatchedMovie, CriterionUnwatchedTVNone,
 		CriterionLowResolution, CriterionLargeFiles,
-		CriterionKeepLatestSeasons:
+		CriterionKeepLatestSeasons, CriterionLowRated:
 		return true
 	}
 	return false
@@ -44,6 +46,17 @@ type ParamSpec struct {
 	Max     *int        `json:"max,omitempty"`
 }
 
+// MaintenanceRuleTemplate is a built-in starting point for a rule. Creating a
+// rule from a template only needs a name and libraries added.
+type MaintenanceRuleTemplate struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	CriterionType CriterionType   `json:"criterion_type"`
+	MediaType     MediaType       `json:"media_type"`
+	Parameters    json.RawMessage `json:"parameters"`
+}
+
 type LibraryItemCache struct {
 	ID              int64     `json:"id"`
 	ServerID        int64     `json:"server_id"`
@@ -63,7 +76,9 @@ type LibraryItemCache struct {
 	TMDBID          string     `json:"tmdb_id,omitempty"`
 	TVDBID          string     `json:"tvdb_id,omitempty"`
 	IMDBID          string     `json:"imdb_id,omitempty"`
+	ContentRating   string     `json:"content_rating,omitempty"`
 	TMDBStatus      string     `json:"tmdb_status,omitempty"`
+	Collections     []string   `json:"collections,omitempty"`
 	SyncedAt        time.Time  `json:"synced_at"`
 }
 
@@ -109,6 +124,14 @@ func (in *MaintenanceRuleInput) Validate() error {
 	return nil
 }
 
+// MaintenanceRuleCloneInput overrides fields of a cloned rule. Empty fields
+// keep the source rule's values.
+type MaintenanceRuleCloneInput struct {
+	Name      string        `json:"name"`
+	Enabled   *bool         `json:"enabled"`
+	Libraries []RuleLibrary `json:"libraries"`
+}
+
 type MaintenanceRuleUpdateInput struct {
 	Name          string          `json:"name"`
 	CriterionType CriterionType   `json:"criterion_type"`
@@ -188,15 +211,43 @@ type LibraryMaintenance struct {
 	LibraryType  LibraryType                `json:"library_type"`
 	TotalItems   int                        `json:"total_items"`
 	LastSyncedAt *time.Time                 `json:"last_synced_at"`
+	SyncSchedule LibrarySyncSchedule        `json:"sync_schedule"`
 	Rules        []MaintenanceRuleWithCount `json:"rules"`
 }
 
+// LibrarySyncSchedule is how often the scheduler refreshes a library's cached
+// items. Daily runs at 3 AM server time; weekly libraries sync on the first
+// daily run at least a week after their last sync.
+type LibrarySyncSchedule string
+
+const (
+	LibrarySyncDaily  LibrarySyncSchedule = "daily"
+	LibrarySyncWeekly LibrarySyncSchedule = "weekly"
+	LibrarySyncOff    LibrarySyncSchedule = "off"
+)
+
+func (s LibrarySyncSchedule) Valid() bool {
+	switch s {
+	case LibrarySyncDaily, LibrarySyncWeekly, LibrarySyncOff:
+		return true
+	}
+	return false
+}
+
 type MaintenanceRuleWithCount struct {
 	MaintenanceRule
 	CandidateCount int `json:"candidate_count"`
 	ExclusionCount int `json:"exclusion_count"`
 }
 
+// MaintenancePending summarizes the candidates awaiting review across all
+// enabled rules. An item flagged by several rules is counted once.
+type MaintenancePending struct {
+	Items     int   `json:"items"`
+	TotalSize int64 `json:"total_size"`
+	Rules     int   `json:"rules"`
+}
+
 type LowResolutionParams struct {
 	MaxHeight int `json:"max_height"`
 }
@@ -210,6 +261,14 @@ type KeepLatestSeasonsParams struct {
 	GenreIDs    []int `json:"genre_ids"`
 }
 
+// LowRatedParams flags items not watched in Days whose TMDB rating and
+// popularity fall below the thresholds. A zero threshold isn't checked.
+type LowRatedParams struct {
+	Days          int     `json:"days"`
+	MaxRating     float64 `json:"max_rating"`
+	MaxPopularity float64 `json:"max_popularity"`
+}
+
 type Season struct {
 	ID           string `json:"id"`
 	Number       int    `json:"number"`
@@ -279,3 +338,91 @@ type CandidatesResponse struct {
 	PerPage        int                    `json:"per_page"`
 	Statuses       []string               `json:"statuses,omitempty"`
 }
+
+// ArrDeleteAction is what a maintenance delete does to the matching movie in
+// Radarr or series in Sonarr.
+type ArrDeleteAction string
+
+const (
+	ArrDeleteActionDelete    ArrDeleteAction = "delete"
+	ArrDeleteActionUnmonitor ArrDeleteAction = "unmonitor"
+	ArrDeleteActionNone      ArrDeleteAction = "none"
+)
+
+// ArrDeletePolicy configures the Radarr/Sonarr side of a maintenance delete.
+// AddExclusion adds the item to the import list exclusions so it isn't
+// grabbed again.
+type ArrDeletePolicy struct {
+	Action       ArrDeleteAction `json:"action"`
+	AddExclusion bool            `json:"add_exclusion"`
+}
+
+// Validate defaults an empty action to delete, which matches the behaviour
+// before the policy was configurable.
+func (p *ArrDeletePolicy) Validate() error {
+	switch p.Action {
+	case "":
+		p.Action = ArrDeleteActionDelete
+	case ArrDeleteActionDelete, ArrDeleteActionUnmonitor, ArrDeleteActionNone:
+	default:
+		return fmt.Errorf("invalid action %q", p.Action)
+	}
+	if p.Action == ArrDeleteActionNone && p.AddExclusion {
+		return errors.New("add_exclusion requires the delete or unmonitor action")
+	}
+	return nil
+}
+
+// MaxProtectedCollections caps how many collection names can be protected.
+const MaxProtectedCollections = 100
+
+// CollectionProtection keeps collection members out of maintenance
+// candidates. AllCollections protects an item in any collection; otherwise
+// only membership in one of Names (matched case-insensitively) counts.
+type CollectionProtection struct {
+	AllCollections bool     `json:"all_collections"`
+	Names          []string `json:"names"`
+}
+
+// Validate trims the names and drops blanks and duplicates.
+func (p *CollectionProtection) Validate() error {
+	if len(p.Names) > MaxProtectedCollections {
+		return fmt.Errorf("at most %d protected collections allowed", MaxProtectedCollections)
+	}
+	names := make([]string, 0, len(p.Names))
+	seen := make(map[string]bool, len(p.Names))
+	for _, n := range p.Names {
+		n = strings.TrimSpace(n)
+		key := strings.ToLower(n)
+		if n == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, n)
+	}
+	p.Names = names
+	return nil
+}
+
+// Enabled reports whether any item can be protected by p.
+func (p CollectionProtection) Enabled() bool {
+	return p.AllCollections || len(p.Names) > 0
+}
+
+// Protects reports whether an item in the given collections is protected.
+func (p CollectionProtection) Protects(collections []string) bool {
+	if len(collections) == 0 {
+		return false
+	}
+	if p.AllCollections {
+		return true
+	}
+	for _, c := range collections {
+		for _, n := range p.Names {
+			if strings.EqualFold(c, n) {
+				return true
+			}
+		}
+	}
+	return false
+}
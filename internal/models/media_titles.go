@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	ExternalIDTMDB = "tmdb"
+	ExternalIDTVDB = "tvdb"
+	ExternalIDIMDB = "imdb"
+)
+
+// ExternalID identifies a title by a metadata provider's ID, written as
+// "tmdb:603", "tvdb:81189" or "imdb:tt0133093". A bare IMDb ID ("tt...") or
+// number (taken as TMDB) is accepted too.
+type ExternalID struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+}
+
+func ParseExternalID(raw string) (ExternalID, error) {
+	raw = strings.TrimSpace(raw)
+	provider, id, ok := strings.Cut(raw, ":")
+	if !ok {
+		id = raw
+		provider = ExternalIDTMDB
+		if strings.HasPrefix(raw, "tt") {
+			provider = ExternalIDIMDB
+		}
+	}
+	provider = strings.ToLower(provider)
+	switch provider {
+	case ExternalIDTMDB, ExternalIDTVDB:
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return ExternalID{}, errors.New(provider + " id must be numeric")
+		}
+	case ExternalIDIMDB:
+		if !strings.HasPrefix(id, "tt") || len(id) < 3 || strings.Trim(id[2:], "0123456789") != "" {
+			return ExternalID{}, errors.New("imdb id must look like tt0133093")
+		}
+	default:
+		return ExternalID{}, errors.New("external id provider must be tmdb, tvdb or imdb")
+	}
+	return ExternalID{Provider: provider, ID: id}, nil
+}
+
+func (e ExternalID) String() string {
+	return e.Provider + ":" + e.ID
+}
+
+// MediaTitleCopy is one library's copy of a title.
+type MediaTitleCopy struct {
+	LibraryItemCache
+	ServerName string `json:"server_name"`
+}
+
+// MediaTitleRequest is a request for the title made through Overseerr.
+type MediaTitleRequest struct {
+	ID          int        `json:"id"`
+	Status      string     `json:"status"`
+	RequestedBy string     `json:"requested_by"`
+	Email       string     `json:"email,omitempty"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+}
+
+// MediaTitleCandidate is a maintenance rule flagging one of the title's
+// library copies.
+type MediaTitleCandidate struct {
+	ID            int64     `json:"id"`
+	RuleID        int64     `json:"rule_id"`
+	RuleName      string    `json:"rule_name"`
+	LibraryItemID int64     `json:"library_item_id"`
+	Reason        string    `json:"reason"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+// MediaTitle gathers everything known about one movie or show: its copies
+// across servers, who watched it, who asked for it, what maintenance thinks
+// of it, and its TMDB metadata when available.
+type MediaTitle struct {
+	ExternalID ExternalID             `json:"external_id"`
+	Title      string                 `json:"title"`
+	Year       int                    `json:"year,omitempty"`
+	MediaType  MediaType              `json:"media_type"`
+	TMDBID     string                 `json:"tmdb_id,omitempty"`
+	TVDBID     string                 `json:"tvdb_id,omitempty"`
+	IMDBID     string                 `json:"imdb_id,omitempty"`
+	TotalSize  int64                  `json:"total_size"`
+	Copies     []MediaTitleCopy       `json:"copies"`
+	History    []WatchHistoryEntry    `json:"history"`
+	Requests   []MediaTitleRequest    `json:"requests"`
+	Candidates []MediaTitleCandidate  `json:"candidates"`
+	Exclusions []MaintenanceExclusion `json:"exclusions"`
+	TMDB       json.RawMessage        `json:"tmdb,omitempty"`
+}
@@ -2,7 +2,12 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+
+	"streammon/internal/httputil"
 )
 
 var (
@@ -14,12 +19,14 @@ var (
 type MediaType string
 
 const (
-	MediaTypeMovie     MediaType = "movie"
-	MediaTypeTV        MediaType = "episode"
-	MediaTypeLiveTV    MediaType = "livetv"
-	MediaTypeMusic     MediaType = "track"
-	MediaTypeAudiobook MediaType = "audiobook"
-	MediaTypeBook      MediaType = "book"
+	MediaTypeMovie      MediaType = "movie"
+	MediaTypeTV         MediaType = "episode"
+	MediaTypeLiveTV     MediaType = "livetv"
+	MediaTypeMusic      MediaType = "track"
+	MediaTypeAudiobook  MediaType = "audiobook"
+	MediaTypeBook       MediaType = "book"
+	MediaTypeMusicVideo MediaType = "musicvideo"
+	MediaTypePhoto      MediaType = "photo"
 )
 
 type ExtraType string
@@ -38,14 +45,33 @@ type Role string
 const (
 	RoleAdmin  Role = "admin"
 	RoleViewer Role = "viewer"
+	// RoleCoAdmin can browse everything an admin can read, but with member
+	// IP addresses and precise locations masked. Its only write access is to
+	// the rules and notification channels of a workspace it administers.
+	RoleCoAdmin Role = "coadmin"
 )
 
+// CanReadAll reports whether the role may read every member's history and
+// stats rather than only its own.
+func (r Role) CanReadAll() bool {
+	return r == RoleAdmin || r == RoleCoAdmin
+}
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleViewer, RoleCoAdmin:
+		return true
+	}
+	return false
+}
+
 type ServerType string
 
 const (
 	ServerTypePlex     ServerType = "plex"
 	ServerTypeEmby     ServerType = "emby"
 	ServerTypeJellyfin ServerType = "jellyfin"
+	ServerTypeKodi     ServerType = "kodi"
 )
 
 // MediaUser represents a user from a media server with optional avatar.
@@ -64,7 +90,7 @@ type MediaUser struct {
 
 func (st ServerType) Valid() bool {
 	switch st {
-	case ServerTypePlex, ServerTypeEmby, ServerTypeJellyfin:
+	case ServerTypePlex, ServerTypeEmby, ServerTypeJellyfin, ServerTypeKodi:
 		return true
 	}
 	return false
@@ -79,9 +105,101 @@ type Server struct {
 	MachineID       string     `json:"machine_id,omitempty"`
 	Enabled         bool       `json:"enabled"`
 	ShowRecentMedia bool       `json:"show_recent_media"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
-	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	// OwnerUserName is the server owner's account. When ExcludeOwnerStats is
+	// set its plays are left out of shared stats but kept in history.
+	OwnerUserName     string `json:"owner_user_name"`
+	ExcludeOwnerStats bool   `json:"exclude_owner_stats"`
+	// PollIntervalSeconds overrides the global poll interval when non-zero.
+	// Paused servers stay configured but aren't polled.
+	PollIntervalSeconds int  `json:"poll_interval_seconds"`
+	Paused              bool `json:"paused"`
+	// LibraryFilter limits which libraries' plays are recorded to history.
+	LibraryFilter ServerLibraryFilter `json:"library_filter"`
+	// HTTP holds the proxy, TLS, and timeout settings used to reach the server.
+	HTTP      httputil.ClientOptions `json:"http"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+	DeletedAt *time.Time             `json:"deleted_at,omitempty"`
+}
+
+// MaxPollIntervalSeconds caps a server's poll interval override.
+const MaxPollIntervalSeconds = 3600
+
+// ServerPolling is the runtime-adjustable polling schedule of a server.
+type ServerPolling struct {
+	PollIntervalSeconds int  `json:"poll_interval_seconds"`
+	Paused              bool `json:"paused"`
+}
+
+func (p *ServerPolling) Validate() error {
+	if p.PollIntervalSeconds < 0 || p.PollIntervalSeconds > MaxPollIntervalSeconds {
+		return fmt.Errorf("poll_interval_seconds must be between 0 and %d", MaxPollIntervalSeconds)
+	}
+	return nil
+}
+
+// MaxFilteredLibraries caps each list in a ServerLibraryFilter.
+const MaxFilteredLibraries = 200
+
+// ServerLibraryFilter picks the libraries whose plays are recorded to
+// history. A non-empty IncludedLibraries records only those libraries,
+// ExcludedLibraries are never recorded. Plays whose library can't be
+// determined are recorded unless an include list is set.
+type ServerLibraryFilter struct {
+	IncludedLibraries []string `json:"included_libraries"`
+	ExcludedLibraries []string `json:"excluded_libraries"`
+}
+
+func (f *ServerLibraryFilter) Validate() error {
+	if len(f.IncludedLibraries) > MaxFilteredLibraries || len(f.ExcludedLibraries) > MaxFilteredLibraries {
+		return fmt.Errorf("at most %d libraries per list allowed", MaxFilteredLibraries)
+	}
+	f.IncludedLibraries = normalizeLibraryIDs(f.IncludedLibraries)
+	f.ExcludedLibraries = normalizeLibraryIDs(f.ExcludedLibraries)
+	for _, id := range f.ExcludedLibraries {
+		if slices.Contains(f.IncludedLibraries, id) {
+			return fmt.Errorf("library %q is both included and excluded", id)
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether f records every library.
+func (f ServerLibraryFilter) IsZero() bool {
+	return len(f.IncludedLibraries) == 0 && len(f.ExcludedLibraries) == 0
+}
+
+// Records reports whether plays from libraryID should be written to
+// history. An empty libraryID means the library is unknown.
+func (f ServerLibraryFilter) Records(libraryID string) bool {
+	if libraryID == "" {
+		return len(f.IncludedLibraries) == 0
+	}
+	if slices.Contains(f.ExcludedLibraries, libraryID) {
+		return false
+	}
+	return len(f.IncludedLibraries) == 0 || slices.Contains(f.IncludedLibraries, libraryID)
+}
+
+func normalizeLibraryIDs(ids []string) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// ServerWebhook describes the inbound playback webhook configured for an Emby
+// or Jellyfin server. The token itself is only returned when generated.
+type ServerWebhook struct {
+	ServerID       int64      `json:"server_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastReceivedAt *time.Time `json:"last_received_at,omitempty"`
+	// Signed reports whether deliveries must carry an HMAC signature.
+	Signed bool `json:"signed"`
 }
 
 func (s *Server) Validate() error {
@@ -89,7 +207,7 @@ func (s *Server) Validate() error {
 		return errors.New("name is required")
 	}
 	if !s.Type.Valid() {
-		return errors.New("type must be plex, emby, or jellyfin")
+		return errors.New("type must be plex, emby, jellyfin, or kodi")
 	}
 	if s.URL == "" {
 		return errors.New("url is required")
@@ -97,7 +215,10 @@ func (s *Server) Validate() error {
 	if s.APIKey == "" {
 		return errors.New("api_key is required")
 	}
-	return nil
+	if s.ExcludeOwnerStats && s.OwnerUserName == "" {
+		return errors.New("owner_user_name is required to exclude owner plays from stats")
+	}
+	return s.HTTP.Validate()
 }
 
 // ValidateForCreate performs validation for new server creation.
@@ -113,25 +234,35 @@ func (s *Server) ValidateForCreate() error {
 }
 
 type ServerInput struct {
-	Name            string     `json:"name"`
-	Type            ServerType `json:"type"`
-	URL             string     `json:"url"`
-	APIKey          string     `json:"api_key"`
-	MachineID       string     `json:"machine_id,omitempty"`
-	Enabled         bool       `json:"enabled"`
-	ShowRecentMedia bool       `json:"show_recent_media"`
+	Name              string     `json:"name"`
+	Type              ServerType `json:"type"`
+	URL               string     `json:"url"`
+	APIKey            string     `json:"api_key"`
+	MachineID         string     `json:"machine_id,omitempty"`
+	Enabled           bool       `json:"enabled"`
+	ShowRecentMedia   bool       `json:"show_recent_media"`
+	OwnerUserName     string     `json:"owner_user_name"`
+	ExcludeOwnerStats bool       `json:"exclude_owner_stats"`
+	// HTTP is left unchanged on update when omitted.
+	HTTP *httputil.ClientOptions `json:"http,omitempty"`
 }
 
 func (si *ServerInput) ToServer() *Server {
-	return &Server{
-		Name:            si.Name,
-		Type:            si.Type,
-		URL:             si.URL,
-		APIKey:          si.APIKey,
-		MachineID:       si.MachineID,
-		Enabled:         si.Enabled,
-		ShowRecentMedia: si.ShowRecentMedia,
+	srv := &Server{
+		Name:              si.Name,
+		Type:              si.Type,
+		URL:               si.URL,
+		APIKey:            si.APIKey,
+		MachineID:         si.MachineID,
+		Enabled:           si.Enabled,
+		ShowRecentMedia:   si.ShowRecentMedia,
+		OwnerUserName:     strings.TrimSpace(si.OwnerUserName),
+		ExcludeOwnerStats: si.ExcludeOwnerStats,
 	}
+	if si.HTTP != nil {
+		srv.HTTP = *si.HTTP
+	}
+	return srv
 }
 
 type User struct {
@@ -148,6 +279,54 @@ type User struct {
 	// must not be reachable by API-key callers (e.g. api-key rotate/revoke) check
 	// this flag.
 	APIKeyAuth bool `json:"-"`
+
+	// GuestTokenID is set when the request was authenticated with a guest
+	// token. Like APIKeyAuth it only exists on the in-memory context user.
+	GuestTokenID int64 `json:"-"`
+
+	// APITokenID is set when the request was authenticated with a personal
+	// API token. Like APIKeyAuth it only exists on the in-memory context user.
+	APITokenID int64 `json:"-"`
+}
+
+// UserPreferences are an account's defaults for stats and history requests,
+// applied server-side whenever a request leaves the matching parameter out.
+type UserPreferences struct {
+	// ServerIDs is the default server set. Empty means all servers.
+	ServerIDs []int64 `json:"server_ids"`
+	// Days is the default stats period, 0 meaning all time. Nil leaves the
+	// endpoint's own default in place.
+	Days *int `json:"days"`
+	// Timezone is an IANA name used for day and hour bucketing.
+	Timezone string `json:"timezone"`
+}
+
+// MaxPreferenceDays bounds UserPreferences.Days to ten years.
+const MaxPreferenceDays = 3650
+
+func (p *UserPreferences) Validate() error {
+	if p.ServerIDs == nil {
+		p.ServerIDs = []int64{}
+	}
+	seen := make(map[int64]bool, len(p.ServerIDs))
+	for _, id := range p.ServerIDs {
+		if id <= 0 {
+			return errors.New("server_ids must be positive")
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate server id %d", id)
+		}
+		seen[id] = true
+	}
+	if p.Days != nil && (*p.Days < 0 || *p.Days > MaxPreferenceDays) {
+		return fmt.Errorf("days must be between 0 and %d", MaxPreferenceDays)
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", p.Timezone)
+		}
+	}
+	return nil
 }
 
 // MaxUserNotesLen bounds a user's private admin note (in runes). Enforced by the
@@ -166,6 +345,11 @@ type WatchHistoryEntry struct {
 	Title               string            `json:"title"`
 	ParentTitle         string            `json:"parent_title"`
 	GrandparentTitle    string            `json:"grandparent_title"`
+	OriginalTitle       string            `json:"original_title,omitempty"`
+	Language            string            `json:"language,omitempty"`
+	ContentRating       string            `json:"content_rating,omitempty"`
+	NetworkLabel        string            `json:"network_label,omitempty"`
+	Local               bool              `json:"local,omitempty"`
 	Year                int               `json:"year"`
 	DurationMs          int64             `json:"duration_ms"`
 	WatchedMs           int64             `json:"watched_ms"`
@@ -192,12 +376,68 @@ type WatchHistoryEntry struct {
 	PausedMs            int64             `json:"paused_ms,omitempty"`
 	Watched             bool              `json:"watched"`
 	SessionCount        int               `json:"session_count"`
+	WatchPartyID        int64             `json:"watch_party_id,omitempty"`
 	TautulliReferenceID int64             `json:"-"`
 	City                string            `json:"city,omitempty"`
 	Country             string            `json:"country,omitempty"`
 	ISP                 string            `json:"isp,omitempty"`
 }
 
+// WatchParty is a group of history rows for the same item started within a
+// minute of each other by different users or devices.
+type WatchParty struct {
+	ID               int64     `json:"id"`
+	ServerID         int64     `json:"server_id"`
+	ItemID           string    `json:"item_id"`
+	MediaType        MediaType `json:"media_type"`
+	Title            string    `json:"title"`
+	GrandparentTitle string    `json:"grandparent_title,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	SessionCount     int       `json:"session_count"`
+	Users            []string  `json:"users"`
+}
+
+// HistoryDuplicateCluster is a group of history rows for the same server,
+// user, title, and grandparent title whose start times chain within the report window. They
+// survived insert-time dedup and consolidation, usually because an import and
+// the poller recorded the same play with clocks or boundaries further apart.
+type HistoryDuplicateCluster struct {
+	ServerID         int64                   `json:"server_id"`
+	UserName         string                  `json:"user_name"`
+	Title            string                  `json:"title"`
+	GrandparentTitle string                  `json:"grandparent_title,omitempty"`
+	Entries          []HistoryDuplicateEntry `json:"entries"`
+	// KeepID is the row a merge would keep: the one with the most watch time.
+	KeepID int64               `json:"keep_id"`
+	Merged HistoryMergePreview `json:"merged"`
+}
+
+type HistoryDuplicateEntry struct {
+	ID           int64     `json:"id"`
+	StartedAt    time.Time `json:"started_at"`
+	StoppedAt    time.Time `json:"stopped_at"`
+	DurationMs   int64     `json:"duration_ms"`
+	WatchedMs    int64     `json:"watched_ms"`
+	PausedMs     int64     `json:"paused_ms,omitempty"`
+	Player       string    `json:"player"`
+	Platform     string    `json:"platform"`
+	SessionCount int       `json:"session_count"`
+	FromTautulli bool      `json:"from_tautulli"`
+}
+
+// HistoryMergePreview is what the kept row would look like after merging its
+// cluster. Overlapping rows count once (the longest watch time wins) while
+// disjoint rows add up, as consolidation does.
+type HistoryMergePreview struct {
+	StartedAt    time.Time `json:"started_at"`
+	StoppedAt    time.Time `json:"stopped_at"`
+	DurationMs   int64     `json:"duration_ms"`
+	WatchedMs    int64     `json:"watched_ms"`
+	PausedMs     int64     `json:"paused_ms,omitempty"`
+	Watched      bool      `json:"watched"`
+	SessionCount int       `json:"session_count"`
+}
+
 type WatchSession struct {
 	ID         int64     `json:"id"`
 	HistoryID  int64     `json:"history_id"`
@@ -225,6 +465,7 @@ type ActiveStream struct {
 	ServerID           int64      `json:"server_id"`
 	ItemID             string     `json:"item_id,omitempty"`
 	GrandparentItemID  string     `json:"grandparent_item_id,omitempty"`
+	LibraryID          string     `json:"library_id,omitempty"`
 	ServerName         string     `json:"server_name"`
 	ServerType         ServerType `json:"server_type"`
 	UserName           string     `json:"user_name"`
@@ -233,11 +474,15 @@ type ActiveStream struct {
 	Title              string     `json:"title"`
 	ParentTitle        string     `json:"parent_title"`
 	GrandparentTitle   string     `json:"grandparent_title"`
+	OriginalTitle      string     `json:"original_title,omitempty"`
+	Language           string     `json:"language,omitempty"`
+	ContentRating      string     `json:"content_rating,omitempty"`
 	Year               int        `json:"year"`
 	DurationMs         int64      `json:"duration_ms"`
 	ProgressMs         int64      `json:"progress_ms"`
 	Player             string     `json:"player"`
 	Platform           string     `json:"platform"`
+	PlayerVersion      string     `json:"player_version,omitempty"`
 	IPAddress          string     `json:"ip_address"`
 	StartedAt          time.Time  `json:"started_at"`
 	LastPollSeen       time.Time  `json:"-"`
@@ -256,6 +501,10 @@ type ActiveStream struct {
 	TranscodeHWDecode        bool              `json:"transcode_hw_decode,omitempty"`
 	TranscodeHWEncode        bool              `json:"transcode_hw_encode,omitempty"`
 	TranscodeProgress        float64           `json:"transcode_progress,omitempty"`
+	TranscodeSpeed           float64           `json:"transcode_speed,omitempty"`
+	TranscodeThrottled       bool              `json:"transcode_throttled,omitempty"`
+	TranscodeBufferMs        int64             `json:"transcode_buffer_ms,omitempty"`
+	TranscodeETA             *time.Time        `json:"transcode_eta,omitempty"`
 	Bandwidth                int64             `json:"bandwidth,omitempty"`
 	ThumbURL                 string            `json:"thumb_url,omitempty"`
 	TranscodeContainer       string            `json:"transcode_container,omitempty"`
@@ -270,6 +519,30 @@ type ActiveStream struct {
 	PlexSessionUUID          string            `json:"plex_session_uuid,omitempty"`
 	LastPausedAt             time.Time         `json:"-"`
 	TranscodeKey             string            `json:"-"`
+	// ProgressSnapshots is the session's progress over time, oldest first,
+	// one per ProgressSnapshotEvery.
+	ProgressSnapshots     []ProgressSnapshot `json:"progress_snapshots,omitempty"`
+	ProgressSnapshotEvery time.Duration      `json:"-"`
+}
+
+// EstimateTranscode derives TranscodeBufferMs (how far the transcoder is
+// ahead of playback) and TranscodeETA from the reported progress and speed,
+// where speed is a multiple of realtime. It leaves both unset when the
+// server didn't report enough to estimate.
+func (s *ActiveStream) EstimateTranscode(now time.Time) {
+	if s.TranscodeProgress <= 0 || s.DurationMs <= 0 {
+		return
+	}
+	done := int64(s.TranscodeProgress / 100 * float64(s.DurationMs))
+	if buffer := done - s.ProgressMs; buffer > 0 {
+		s.TranscodeBufferMs = buffer
+	}
+	if s.TranscodeProgress >= 100 || s.TranscodeSpeed <= 0 {
+		return
+	}
+	remaining := time.Duration(float64(s.DurationMs-done)/s.TranscodeSpeed) * time.Millisecond
+	eta := now.Add(remaining)
+	s.TranscodeETA = &eta
 }
 
 type SessionState string
@@ -312,6 +585,9 @@ type GeoResult struct {
 	City     string   `json:"city"`
 	Country  string   `json:"country"`
 	ISP      string   `json:"isp,omitempty"`
+	ASN      uint     `json:"asn,omitempty"`
+	Hosting  bool     `json:"hosting,omitempty"` // ASN belongs to a hosting provider or VPN
+	Label    string   `json:"label,omitempty"`   // name of the geo override that placed the address
 	LastSeen *string  `json:"last_seen,omitempty"`
 	Users    []string `json:"users,omitempty"`
 }
@@ -397,27 +673,66 @@ type ItemDetails struct {
 }
 
 type MediaStat struct {
-	Title      string  `json:"title"`
-	Year       int     `json:"year,omitempty"`
-	PlayCount  int     `json:"play_count"`
-	TotalHours float64 `json:"total_hours"`
-	ThumbURL   string  `json:"thumb_url,omitempty"`
-	ServerID   int64   `json:"server_id,omitempty"`
-	ItemID     string  `json:"item_id,omitempty"`
+	Title      string          `json:"title"`
+	Artist     string          `json:"artist,omitempty"` // music albums and tracks
+	Year       int             `json:"year,omitempty"`
+	PlayCount  int             `json:"play_count"`
+	TotalHours float64         `json:"total_hours"`
+	ThumbURL   string          `json:"thumb_url,omitempty"`
+	ServerID   int64           `json:"server_id,omitempty"`
+	ItemID     string          `json:"item_id,omitempty"`
+	Comparison *PlayComparison `json:"comparison,omitempty"`
 }
 
 type UserStat struct {
-	UserName   string  `json:"user_name"`
-	PlayCount  int     `json:"play_count"`
-	TotalHours float64 `json:"total_hours"`
+	UserName   string          `json:"user_name"`
+	PlayCount  int             `json:"play_count"`
+	TotalHours float64         `json:"total_hours"`
+	Comparison *PlayComparison `json:"comparison,omitempty"`
+}
+
+// PlayComparison is an entry's totals over the previous period. The change
+// percentages are nil when the previous value was zero.
+type PlayComparison struct {
+	PreviousPlayCount   int      `json:"previous_play_count"`
+	PreviousTotalHours  float64  `json:"previous_total_hours"`
+	PlayCountChangePct  *float64 `json:"play_count_change_pct"`
+	TotalHoursChangePct *float64 `json:"total_hours_change_pct"`
 }
 
 type LibraryStat struct {
+	TotalPlays    int                `json:"total_plays"`
+	TotalHours    float64            `json:"total_hours"`
+	UniqueUsers   int                `json:"unique_users"`
+	UniqueMovies  int                `json:"unique_movies"`
+	UniqueTVShows int                `json:"unique_tv_shows"`
+	Music         MusicLibraryStat   `json:"music"`
+	Comparison    *LibraryComparison `json:"comparison,omitempty"`
+}
+
+// MusicLibraryStat is the listening behind a LibraryStat's totals: track
+// plays, with artists and albums taken from their grandparent and parent
+// titles.
+type MusicLibraryStat struct {
 	TotalPlays    int     `json:"total_plays"`
 	TotalHours    float64 `json:"total_hours"`
 	UniqueUsers   int     `json:"unique_users"`
-	UniqueMovies  int     `json:"unique_movies"`
-	UniqueTVShows int     `json:"unique_tv_shows"`
+	UniqueArtists int     `json:"unique_artists"`
+	UniqueAlbums  int     `json:"unique_albums"`
+	UniqueTracks  int     `json:"unique_tracks"`
+}
+
+// LibraryComparison is the library totals over the previous period, with
+// the change in each. Percentages are nil when the previous value was zero.
+type LibraryComparison struct {
+	Previous               LibraryStat `json:"previous"`
+	TotalPlaysChangePct    *float64    `json:"total_plays_change_pct"`
+	TotalHoursChangePct    *float64    `json:"total_hours_change_pct"`
+	UniqueUsersChangePct   *float64    `json:"unique_users_change_pct"`
+	UniqueMoviesChangePct  *float64    `json:"unique_movies_change_pct"`
+	UniqueTVShowsChangePct *float64    `json:"unique_tv_shows_change_pct"`
+	MusicPlaysChangePct    *float64    `json:"music_plays_change_pct"`
+	MusicHoursChangePct    *float64    `json:"music_hours_change_pct"`
 }
 
 type LibraryType string
@@ -442,6 +757,25 @@ type Library struct {
 	TotalSize       int64       `json:"total_size"`
 }
 
+// LibraryWatchStat is a library's watching over a stats range. Plays are
+// matched to the library through its cached items, so a library needs a
+// maintenance sync before it has any. CompletionRate is the share of plays
+// watched to the end; ItemsPlayed counts distinct cached items with a play.
+type LibraryWatchStat struct {
+	ServerID       int64       `json:"server_id"`
+	ServerName     string      `json:"server_name"`
+	LibraryID      string      `json:"library_id"`
+	Name           string      `json:"name"`
+	Type           LibraryType `json:"type,omitempty"`
+	ItemCount      int         `json:"item_count"`
+	TotalSize      int64       `json:"total_size"`
+	Plays          int         `json:"plays"`
+	TotalHours     float64     `json:"total_hours"`
+	UniqueUsers    int         `json:"unique_users"`
+	CompletionRate float64     `json:"completion_rate"`
+	ItemsPlayed    int         `json:"items_played"`
+}
+
 type LibraryItemDetail struct {
 	ID                 int64      `json:"id"`
 	ItemID             string     `json:"item_id"`
@@ -505,11 +839,28 @@ type ISPStat struct {
 }
 
 type UserDetailStats struct {
-	SessionCount int            `json:"session_count"`
-	TotalHours   float64        `json:"total_hours"`
-	Locations    []LocationStat `json:"locations"`
-	Devices      []DeviceStat   `json:"devices"`
-	ISPs         []ISPStat      `json:"isps"`
+	SessionCount int                `json:"session_count"`
+	TotalHours   float64            `json:"total_hours"`
+	Locations    []LocationStat     `json:"locations"`
+	Devices      []DeviceStat       `json:"devices"`
+	ISPs         []ISPStat          `json:"isps"`
+	Bandwidth    []MonthlyBandwidth `json:"bandwidth"`
+	// ContentRatings is the user's plays by content rating, unrated
+	// plays excluded.
+	ContentRatings []ContentRatingStat `json:"content_ratings"`
+	// NetworkLabels is the user's plays by network label, unlabelled plays
+	// excluded.
+	NetworkLabels []NetworkLabelStat `json:"network_labels"`
+	// LocalSessions counts plays from home networks and private addresses,
+	// which Locations leaves out.
+	LocalSessions int `json:"local_sessions"`
+}
+
+// MonthlyBandwidth is a user's estimated transfer for one calendar month
+// (UTC), derived from each session's bitrate multiplied by its play time.
+type MonthlyBandwidth struct {
+	Month string `json:"month"` // YYYY-MM
+	Bytes int64  `json:"bytes"`
 }
 
 type DayOfWeekStat struct {
@@ -523,6 +874,24 @@ type HourStat struct {
 	PlayCount int `json:"play_count"`
 }
 
+// HeatmapCell is one hour of one weekday in a WatchHeatmap. Plays count
+// where they started; HoursWatched spreads each play's watch time over the
+// hours it ran into.
+type HeatmapCell struct {
+	DayOfWeek    int     `json:"day_of_week"` // 0=Sun, 6=Sat
+	Hour         int     `json:"hour"`        // 0-23
+	PlayCount    int     `json:"play_count"`
+	HoursWatched float64 `json:"hours_watched"`
+}
+
+// WatchHeatmap is the 7×24 grid of watch activity in Timezone, Sunday
+// midnight first.
+type WatchHeatmap struct {
+	Timezone        string        `json:"timezone"`
+	Cells           []HeatmapCell `json:"cells"`
+	MaxHoursWatched float64       `json:"max_hours_watched"`
+}
+
 type DistributionStat struct {
 	Name       string  `json:"name"`
 	Count      int     `json:"count"`
@@ -544,3 +913,311 @@ type ConcurrentPeaks struct {
 	Transcode    int    `json:"transcode"`
 	PeakAt       string `json:"peak_at,omitempty"`
 }
+
+// ConcurrentRecord is the highest concurrent stream count seen in a window
+// and when it was reached (RFC3339, empty when no streams were ever seen).
+type ConcurrentRecord struct {
+	Count int    `json:"count"`
+	At    string `json:"at,omitempty"`
+}
+
+type ConcurrentRecords struct {
+	AllTime    ConcurrentRecord `json:"all_time"`
+	Last30Days ConcurrentRecord `json:"last_30_days"`
+	Notify     bool             `json:"notify"`
+}
+
+// ConcurrentRecordUpdate reports which records a live concurrent stream
+// count beat, alongside the records as they stood before it.
+type ConcurrentRecordUpdate struct {
+	AllTime       ConcurrentRecord
+	Last30Days    ConcurrentRecord
+	NewAllTime    bool
+	NewLast30Days bool
+}
+
+// PlaybackProgress is a user's last known position in an item, as recorded
+// when their most recent session for it ended.
+type PlaybackProgress struct {
+	ServerID          int64     `json:"server_id"`
+	UserName          string    `json:"user_name"`
+	ItemID            string    `json:"item_id"`
+	GrandparentItemID string    `json:"grandparent_item_id,omitempty"`
+	MediaType         MediaType `json:"media_type"`
+	Title             string    `json:"title"`
+	ParentTitle       string    `json:"parent_title,omitempty"`
+	GrandparentTitle  string    `json:"grandparent_title,omitempty"`
+	Year              int       `json:"year,omitempty"`
+	SeasonNumber      int       `json:"season_number,omitempty"`
+	EpisodeNumber     int       `json:"episode_number,omitempty"`
+	ThumbURL          string    `json:"thumb_url,omitempty"`
+	PositionMs        int64     `json:"position_ms"`
+	DurationMs        int64     `json:"duration_ms"`
+	Finished          bool      `json:"finished"`
+	FirstStartedAt    time.Time `json:"first_started_at"`
+	LastPlayedAt      time.Time `json:"last_played_at"`
+}
+
+// StaleProgressSummary counts a user's items that were started before a
+// cutoff and never finished.
+type StaleProgressSummary struct {
+	UserName       string    `json:"user_name"`
+	Count          int       `json:"count"`
+	OldestStarted  time.Time `json:"oldest_started_at"`
+	LatestPlayedAt time.Time `json:"latest_played_at"`
+}
+
+// ShareTargetType is the kind of record a share link exposes.
+type ShareTargetType string
+
+const (
+	ShareTargetHistory   ShareTargetType = "history"
+	ShareTargetViolation ShareTargetType = "violation"
+)
+
+func (t ShareTargetType) Valid() bool {
+	return t == ShareTargetHistory || t == ShareTargetViolation
+}
+
+const (
+	DefaultShareLinkHours = 24
+	MaxShareLinkHours     = 24 * 7
+)
+
+// ShareLink grants read-only access to a single record until it expires.
+// Token is only populated when the link is created.
+type ShareLink struct {
+	ID         int64           `json:"id"`
+	TargetType ShareTargetType `json:"target_type"`
+	TargetID   int64           `json:"target_id"`
+	CreatedBy  string          `json:"created_by"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Token      string          `json:"token,omitempty"`
+}
+
+type ShareLinkInput struct {
+	TargetType ShareTargetType `json:"target_type"`
+	TargetID   int64           `json:"target_id"`
+	// ExpiresInHours defaults to DefaultShareLinkHours when zero.
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+func (in *ShareLinkInput) Validate() error {
+	if !in.TargetType.Valid() {
+		return errors.New("target_type must be history or violation")
+	}
+	if in.TargetID <= 0 {
+		return errors.New("target_id is required")
+	}
+	if in.ExpiresInHours == 0 {
+		in.ExpiresInHours = DefaultShareLinkHours
+	}
+	if in.ExpiresInHours < 1 || in.ExpiresInHours > MaxShareLinkHours {
+		return fmt.Errorf("expires_in_hours must be between 1 and %d", MaxShareLinkHours)
+	}
+	return nil
+}
+
+// SharedRecord is what a share link resolves to. Exactly one of History or
+// Violation is set, matching TargetType.
+type SharedRecord struct {
+	TargetType ShareTargetType    `json:"target_type"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	History    *WatchHistoryEntry `json:"history,omitempty"`
+	Sessions   []WatchSession     `json:"sessions,omitempty"`
+	Location   *GeoResult         `json:"location,omitempty"`
+	Violation  *RuleViolation     `json:"violation,omitempty"`
+}
+
+// MaxIdentityNameLen bounds the name given to a merged user identity.
+const MaxIdentityNameLen = 100
+
+// UserAccount is one user on one media server. The same name on two servers
+// is two accounts unless both are linked to the same Identity.
+type UserAccount struct {
+	ServerID   int64      `json:"server_id"`
+	ServerName string     `json:"server_name,omitempty"`
+	UserName   string     `json:"user_name"`
+	Identity   string     `json:"identity,omitempty"`
+	LinkedAt   *time.Time `json:"linked_at,omitempty"`
+}
+
+// ValidateLink checks a as a request to link the account to an identity.
+func (a *UserAccount) ValidateLink() error {
+	a.UserName = strings.TrimSpace(a.UserName)
+	a.Identity = strings.TrimSpace(a.Identity)
+	if a.ServerID <= 0 {
+		return errors.New("server_id is required")
+	}
+	if a.UserName == "" {
+		return errors.New("user_name is required")
+	}
+	if a.Identity == "" {
+		return errors.New("identity is required")
+	}
+	if len(a.Identity) > MaxIdentityNameLen {
+		return fmt.Errorf("identity must be at most %d characters", MaxIdentityNameLen)
+	}
+	return nil
+}
+
+// DuplicateUserName is a user name seen on more than one server. Merged is
+// true when every account is linked to the same identity.
+type DuplicateUserName struct {
+	UserName string        `json:"user_name"`
+	Accounts []UserAccount `json:"accounts"`
+	Merged   bool          `json:"merged"`
+}
+
+// GuestTokenPrefix marks a guest token so it can't be mistaken for an API key.
+const GuestTokenPrefix = "smg_"
+
+const (
+	DefaultGuestTokenHours = 24 * 7
+	MaxGuestTokenHours     = 24 * 30
+	MaxGuestTokenUsers     = 20
+)
+
+// GuestToken grants read-only access to the stats and history of UserNames
+// until it expires. Token is only populated when the token is created.
+type GuestToken struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	UserNames  []string   `json:"user_names"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+// AllowsUser reports whether the token covers userName.
+func (t *GuestToken) AllowsUser(userName string) bool {
+	for _, n := range t.UserNames {
+		if n == userName {
+			return true
+		}
+	}
+	return false
+}
+
+type GuestTokenInput struct {
+	Name      string   `json:"name"`
+	UserNames []string `json:"user_names"`
+	// ExpiresInHours defaults to DefaultGuestTokenHours when zero.
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+func (in *GuestTokenInput) Validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(in.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+
+	seen := make(map[string]bool, len(in.UserNames))
+	names := make([]string, 0, len(in.UserNames))
+	for _, n := range in.UserNames {
+		n = strings.TrimSpace(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		names = append(names, n)
+	}
+	if len(names) == 0 {
+		return errors.New("user_names must name at least one user")
+	}
+	if len(names) > MaxGuestTokenUsers {
+		return fmt.Errorf("user_names may list at most %d users", MaxGuestTokenUsers)
+	}
+	in.UserNames = names
+
+	if in.ExpiresInHours == 0 {
+		in.ExpiresInHours = DefaultGuestTokenHours
+	}
+	if in.ExpiresInHours < 1 || in.ExpiresInHours > MaxGuestTokenHours {
+		return fmt.Errorf("expires_in_hours must be between 1 and %d", MaxGuestTokenHours)
+	}
+	return nil
+}
+
+// APITokenPrefix marks a personal API token sent as a Bearer credential.
+const APITokenPrefix = "smt_"
+
+// MaxAPITokensPerUser bounds how many tokens one account may hold.
+const MaxAPITokensPerUser = 25
+
+// APITokenScope caps what a personal API token can reach, on top of the
+// owning account's role.
+type APITokenScope string
+
+const (
+	// APITokenScopeReadOnly allows any GET the owner could make.
+	APITokenScopeReadOnly APITokenScope = "read_only"
+	// APITokenScopeStatsOnly allows GETs of the stats endpoints only.
+	APITokenScopeStatsOnly APITokenScope = "stats_only"
+	// APITokenScopeAdmin allows everything the owner can do except the
+	// endpoints that need an interactive session. Only admins may hold one.
+	APITokenScopeAdmin APITokenScope = "admin"
+)
+
+func (sc APITokenScope) Valid() bool {
+	switch sc {
+	case APITokenScopeReadOnly, APITokenScopeStatsOnly, APITokenScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIToken is a personal, revocable credential for scripts and integrations.
+// Token is only populated when the token is created.
+type APIToken struct {
+	ID         int64         `json:"id"`
+	UserID     int64         `json:"user_id"`
+	Name       string        `json:"name"`
+	Scope      APITokenScope `json:"scope"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Token      string        `json:"token,omitempty"`
+}
+
+// MaxSessionUserAgentLength bounds the user agent kept with a login session.
+const MaxSessionUserAgentLength = 512
+
+// LoginSession is a signed-in browser session. ID identifies the session
+// for revocation without exposing its cookie. Current marks the session
+// the request listing it was made with.
+type LoginSession struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+type APITokenInput struct {
+	Name  string        `json:"name"`
+	Scope APITokenScope `json:"scope"`
+}
+
+func (in *APITokenInput) Validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(in.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	if !in.Scope.Valid() {
+		return fmt.Errorf("scope must be %s, %s, or %s",
+			APITokenScopeReadOnly, APITokenScopeStatsOnly, APITokenScopeAdmin)
+	}
+	return nil
+}
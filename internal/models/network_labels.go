@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	MaxNetworkLabelNameLen = 50
+	MaxNetworkLabelEntries = 100
+)
+
+// NetworkLabel names a network a session can come from, such as "Home",
+// "Work VPN" or "Cellular", by CIDR and by ASN.
+type NetworkLabel struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CIDRs     []string  `json:"cidrs"`
+	ASNs      []uint    `json:"asns"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate trims the name and normalizes CIDRs, accepting bare addresses as
+// single-host prefixes.
+func (l *NetworkLabel) Validate() error {
+	l.Name = strings.TrimSpace(l.Name)
+	if l.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(l.Name) > MaxNetworkLabelNameLen {
+		return fmt.Errorf("name must be at most %d characters", MaxNetworkLabelNameLen)
+	}
+	if len(l.CIDRs) == 0 && len(l.ASNs) == 0 {
+		return errors.New("at least one CIDR or ASN is required")
+	}
+	if len(l.CIDRs) > MaxNetworkLabelEntries || len(l.ASNs) > MaxNetworkLabelEntries {
+		return fmt.Errorf("at most %d CIDRs and %d ASNs per label", MaxNetworkLabelEntries, MaxNetworkLabelEntries)
+	}
+
+	cidrs := make([]string, 0, len(l.CIDRs))
+	for _, c := range l.CIDRs {
+		p, err := parseNetworkPrefix(c)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %q", strings.TrimSpace(c))
+		}
+		cidrs = append(cidrs, p.String())
+	}
+	l.CIDRs = cidrs
+	for _, asn := range l.ASNs {
+		if asn == 0 {
+			return errors.New("ASNs must be positive")
+		}
+	}
+	if l.ASNs == nil {
+		l.ASNs = []uint{}
+	}
+	return nil
+}
+
+func parseNetworkPrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return p.Masked(), nil
+}
+
+// MatchNetworkLabel returns the name of the label covering ip, or "" when
+// none does. A CIDR match wins over an ASN match, and the most specific
+// CIDR wins among those. asn is 0 when it isn't known.
+func MatchNetworkLabel(labels []NetworkLabel, ip string, asn uint) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	best, bestBits := "", -1
+	for _, l := range labels {
+		for _, c := range l.CIDRs {
+			p, err := netip.ParsePrefix(c)
+			if err != nil || !p.Contains(addr) {
+				continue
+			}
+			if p.Bits() > bestBits {
+				best, bestBits = l.Name, p.Bits()
+			}
+		}
+	}
+	if best != "" || asn == 0 {
+		return best
+	}
+	for _, l := range labels {
+		for _, a := range l.ASNs {
+			if a == asn {
+				return l.Name
+			}
+		}
+	}
+	return ""
+}
+
+// NetworkLabelStat is a user's plays from one labelled network.
+type NetworkLabelStat struct {
+	Label        string  `json:"label"`
+	SessionCount int     `json:"session_count"`
+	Percentage   float64 `json:"percentage"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Language is the language a notification channel's messages are written
+// in. The empty language is English.
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageSpanish Language = "es"
+	LanguageFrench  Language = "fr"
+	LanguageGerman  Language = "de"
+)
+
+// Languages lists every language notifications can be sent in.
+var Languages = []Language{LanguageEnglish, LanguageSpanish, LanguageFrench, LanguageGerman}
+
+func (l Language) Valid() bool {
+	return l == "" || slices.Contains(Languages, l)
+}
+
+// IsEnglish reports whether l is the default language, which the rule's own
+// templates and the built-in text are written in.
+func (l Language) IsEnglish() bool {
+	return l == "" || l == LanguageEnglish
+}
+
+// NotificationTranslation replaces a rule's templates for channels set to
+// another language. An unset template falls back to the rule's own.
+type NotificationTranslation struct {
+	TitleTemplate   string `json:"title_template,omitempty"`
+	MessageTemplate string `json:"message_template,omitempty"`
+}
+
+func validateNotificationTranslations(translations map[Language]NotificationTranslation) error {
+	for lang, t := range translations {
+		if !lang.Valid() || lang.IsEnglish() {
+			return fmt.Errorf("invalid translation language %q", lang)
+		}
+		if err := validateNotificationTemplate("title", t.TitleTemplate); err != nil {
+			return fmt.Errorf("%s translation: %w", lang, err)
+		}
+		if err := validateNotificationTemplate("message", t.MessageTemplate); err != nil {
+			return fmt.Errorf("%s translation: %w", lang, err)
+		}
+	}
+	return nil
+}
+
+// Translated returns n with its templates replaced by the lang translation,
+// where the rule has one.
+func (n RuleNotification) Translated(lang Language) RuleNotification {
+	t, ok := n.Translations[lang]
+	if !ok {
+		return n
+	}
+	if t.TitleTemplate != "" {
+		n.TitleTemplate = t.TitleTemplate
+	}
+	if t.MessageTemplate != "" {
+		n.MessageTemplate = t.MessageTemplate
+	}
+	return n
+}
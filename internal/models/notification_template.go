@@ -0,0 +1,107 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MaxNotificationTemplateLen bounds a rule's title or message template.
+const MaxNotificationTemplateLen = 2000
+
+// NotificationTemplateData is what a rule's notification templates can use,
+// as in "{{.User}} is watching {{.Title}} from {{.City}}". Fields that don't
+// apply to a violation are empty.
+type NotificationTemplateData struct {
+	Rule              string
+	User              string
+	Severity          Severity
+	Message           string
+	Confidence        float64
+	Title             string
+	MediaType         MediaType
+	Year              int
+	Server            string
+	Player            string
+	Platform          string
+	IPAddress         string
+	City              string
+	Country           string
+	Location          string
+	TranscodeDecision TranscodeDecision
+	VideoResolution   string
+	OccurredAt        time.Time
+}
+
+// sampleNotificationTemplateData fills every field, so validating a template
+// against it catches references to fields that don't exist.
+var sampleNotificationTemplateData = NotificationTemplateData{
+	Rule:              "Sample Rule",
+	User:              "sample_user",
+	Severity:          SeverityWarning,
+	Message:           "sample message",
+	Confidence:        90,
+	Title:             "Sample Movie (2024)",
+	MediaType:         MediaTypeMovie,
+	Year:              2024,
+	Server:            "Plex",
+	Player:            "Living Room TV",
+	Platform:          "Android TV",
+	IPAddress:         "203.0.113.10",
+	City:              "Amsterdam",
+	Country:           "Netherlands",
+	Location:          "Amsterdam, Netherlands",
+	TranscodeDecision: TranscodeDecisionTranscode,
+	VideoResolution:   "4K",
+	OccurredAt:        time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC),
+}
+
+// HasTemplates reports whether the rule replaces the fixed notification text.
+func (n RuleNotification) HasTemplates() bool {
+	return n.TitleTemplate != "" || n.MessageTemplate != ""
+}
+
+// RenderTemplates fills the rule's templates with data. An unset template
+// renders as an empty string, which callers treat as "use the default".
+func (n RuleNotification) RenderTemplates(data NotificationTemplateData) (title, message string, err error) {
+	if title, err = renderNotificationTemplate("title", n.TitleTemplate, data); err != nil {
+		return "", "", err
+	}
+	if message, err = renderNotificationTemplate("message", n.MessageTemplate, data); err != nil {
+		return "", "", err
+	}
+	// A title is a single line on every provider.
+	title = strings.Join(strings.Fields(title), " ")
+	return title, message, nil
+}
+
+func validateNotificationTemplate(name, text string) error {
+	if len(text) > MaxNotificationTemplateLen {
+		return fmt.Errorf("%s_template must be %d characters or less", name, MaxNotificationTemplateLen)
+	}
+	if _, err := renderNotificationTemplate(name, text, sampleNotificationTemplateData); err != nil {
+		return err
+	}
+	return nil
+}
+
+func renderNotificationTemplate(name, text string, data NotificationTemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s_template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid %s_template: %w", name, err)
+	}
+	if strings.TrimSpace(buf.String()) == "" {
+		return "", errors.New(name + "_template renders empty text")
+	}
+	return buf.String(), nil
+}
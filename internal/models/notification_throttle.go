@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxNotificationCooldownMinutes bounds a rule's notification cooldown.
+const MaxNotificationCooldownMinutes = 7 * 24 * 60
+
+// QuietHours silences a channel for part of each day. Start and End are
+// "HH:MM" in Timezone, or the server's local time when it's empty; a window
+// ending before it starts runs past midnight. AllowCritical lets critical
+// violations through anyway.
+type QuietHours struct {
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	Timezone      string `json:"timezone,omitempty"`
+	AllowCritical bool   `json:"allow_critical"`
+}
+
+// IsZero reports whether q sets no window, which is how an update clears
+// a channel's quiet hours.
+func (q *QuietHours) IsZero() bool {
+	return q == nil || (q.Start == "" && q.End == "")
+}
+
+func (q *QuietHours) Validate() error {
+	if q.IsZero() {
+		return nil
+	}
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return fmt.Errorf("quiet_hours.start: %w", err)
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return fmt.Errorf("quiet_hours.end: %w", err)
+	}
+	if start == end {
+		return errors.New("quiet_hours.start and end must differ")
+	}
+	if q.Timezone != "" {
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			return fmt.Errorf("quiet_hours.timezone: unknown time zone %q", q.Timezone)
+		}
+	}
+	return nil
+}
+
+// Silences reports whether a notification of severity sent at t falls in
+// the quiet window.
+func (q *QuietHours) Silences(severity Severity, t time.Time) bool {
+	if q.IsZero() || (q.AllowCritical && severity == SeverityCritical) {
+		return false
+	}
+	if q.Timezone != "" {
+		if loc, err := time.LoadLocation(q.Timezone); err == nil {
+			t = t.In(loc)
+		}
+	}
+	start, err1 := parseClock(q.Start)
+	end, err2 := parseClock(q.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return inClockWindow(start, end, t.Hour()*60+t.Minute())
+}
+
+// inClockWindow reports whether minute, counted from midnight, falls in
+// [start, end), wrapping past midnight when end is before start.
+func inClockWindow(start, end, minute int) bool {
+	if start < end {
+		return minute >= start && minute < end
+	}
+	if start > end {
+		return minute >= start || minute < end
+	}
+	return false
+}
+
+// NotificationStatus is how a notification to one channel ended.
+type NotificationStatus string
+
+const (
+	NotificationSent       NotificationStatus = "sent"
+	NotificationFailed     NotificationStatus = "failed"
+	NotificationSuppressed NotificationStatus = "suppressed"
+)
+
+// Reasons a notification was suppressed rather than sent.
+const (
+	SuppressedQuietHours = "quiet_hours"
+	SuppressedCooldown   = "cooldown"
+)
+
+// NotificationRecord is one entry in the notification history: a
+// notification sent, failed, or held back from one channel. Payload is
+// left out of listings; RetryOf links a redelivery to the entry it re-sent.
+type NotificationRecord struct {
+	ID          int64              `json:"id"`
+	RuleID      int64              `json:"rule_id,omitempty"`
+	RuleName    string             `json:"rule_name"`
+	ChannelID   int64              `json:"channel_id"`
+	ChannelName string             `json:"channel_name"`
+	ChannelType ChannelType        `json:"channel_type"`
+	Event       NotificationEvent  `json:"event"`
+	UserName    string             `json:"user_name"`
+	Status      NotificationStatus `json:"status"`
+	Reason      string             `json:"reason,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Payload     json.RawMessage    `json:"payload,omitempty"`
+	RetryOf     int64              `json:"retry_of,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// NotificationPayload is what a notification was built from, kept in the
+// history so it can be sent again as it was.
+type NotificationPayload struct {
+	Violation       RuleViolation     `json:"violation"`
+	Stream          *ActiveStream     `json:"stream,omitempty"`
+	Geo             *GeoResult        `json:"geo,omitempty"`
+	Notification    RuleNotification  `json:"notification"`
+	Event           NotificationEvent `json:"event"`
+	RenderedTitle   string            `json:"rendered_title,omitempty"`
+	RenderedMessage string            `json:"rendered_message,omitempty"`
+}
+
+func NewNotificationPayload(v *RuleViolation) NotificationPayload {
+	return NotificationPayload{
+		Violation:       *v,
+		Stream:          v.Stream,
+		Geo:             v.Geo,
+		Notification:    v.Notification,
+		Event:           v.Event,
+		RenderedTitle:   v.RenderedTitle,
+		RenderedMessage: v.RenderedMessage,
+	}
+}
+
+// RuleViolation rebuilds the violation the payload was taken from.
+func (p NotificationPayload) RuleViolation() *RuleViolation {
+	v := p.Violation
+	v.Stream = p.Stream
+	v.Geo = p.Geo
+	v.Notification = p.Notification
+	v.Event = p.Event
+	v.RenderedTitle = p.RenderedTitle
+	v.RenderedMessage = p.RenderedMessage
+	return &v
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"streammon/internal/httputil"
+)
+
+// WebhookEvent is something an outbound webhook can be sent for.
+type WebhookEvent string
+
+const (
+	WebhookEventSessionStarted     WebhookEvent = "session.started"
+	WebhookEventSessionStopped     WebhookEvent = "session.stopped"
+	WebhookEventRuleTriggered      WebhookEvent = "rule.triggered"
+	WebhookEventMaintenanceDeleted WebhookEvent = "maintenance.deleted"
+	// WebhookEventMaintenanceEvaluated follows each maintenance rule
+	// evaluation, and WebhookEventMaintenanceThreshold one that found at
+	// least the configured number of candidates.
+	WebhookEventMaintenanceEvaluated    WebhookEvent = "maintenance.evaluated"
+	WebhookEventMaintenanceThreshold    WebhookEvent = "maintenance.threshold_exceeded"
+	WebhookEventMaintenanceBulkStarted  WebhookEvent = "maintenance.bulk_delete_started"
+	WebhookEventMaintenanceBulkFinished WebhookEvent = "maintenance.bulk_delete_finished"
+	// WebhookEventTest is sent by the test endpoint only; it can't be
+	// subscribed to.
+	WebhookEventTest WebhookEvent = "webhook.test"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to.
+var WebhookEvents = []WebhookEvent{
+	WebhookEventSessionStarted,
+	WebhookEventSessionStopped,
+	WebhookEventRuleTriggered,
+	WebhookEventMaintenanceDeleted,
+	WebhookEventMaintenanceEvaluated,
+	WebhookEventMaintenanceThreshold,
+	WebhookEventMaintenanceBulkStarted,
+	WebhookEventMaintenanceBulkFinished,
+}
+
+func (e WebhookEvent) Valid() bool {
+	return slices.Contains(WebhookEvents, e)
+}
+
+// OutboundWebhook POSTs a JSON payload to URL for each of Events, signed
+// with Secret. The secret is only returned when it's generated.
+type OutboundWebhook struct {
+	ID             int64          `json:"id"`
+	Name           string         `json:"name"`
+	URL            string         `json:"url"`
+	Secret         string         `json:"secret,omitempty"`
+	Events         []WebhookEvent `json:"events"`
+	Enabled        bool           `json:"enabled"`
+	LastDeliveryAt *time.Time     `json:"last_delivery_at,omitempty"`
+	// LastStatus is the HTTP status of the last delivery, 0 when it got no
+	// response.
+	LastStatus int       `json:"last_status,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (w *OutboundWebhook) Validate() error {
+	if w.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := httputil.ValidateIntegrationURL(w.URL); err != nil {
+		return err
+	}
+	if len(w.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for _, e := range w.Events {
+		if !e.Valid() {
+			return fmt.Errorf("unknown webhook event %q", e)
+		}
+	}
+	return nil
+}
+
+// WebhookDelivery is the body of every outbound webhook request. ID is
+// unique per delivery and doubles as the signature nonce.
+type WebhookDelivery struct {
+	ID         string       `json:"id"`
+	Event      WebhookEvent `json:"event"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Data       any          `json:"data"`
+}
+
+// RuleTriggeredWebhook is the data of a rule.triggered delivery.
+type RuleTriggeredWebhook struct {
+	Violation *RuleViolation `json:"violation"`
+	Session   *ActiveStream  `json:"session,omitempty"`
+	Geo       *GeoResult     `json:"geo,omitempty"`
+}
+
+// MaintenanceDeletedWebhook is the data of a maintenance.deleted delivery.
+type MaintenanceDeletedWebhook struct {
+	ServerID  int64     `json:"server_id"`
+	ItemID    string    `json:"item_id"`
+	Title     string    `json:"title"`
+	MediaType MediaType `json:"media_type"`
+	FileSize  int64     `json:"file_size"`
+	DeletedBy string    `json:"deleted_by"`
+}
+
+// MaintenanceEvaluatedWebhook is the data of maintenance.evaluated and
+// maintenance.threshold_exceeded deliveries. Threshold is only set on the
+// latter.
+type MaintenanceEvaluatedWebhook struct {
+	RuleID     int64         `json:"rule_id"`
+	RuleName   string        `json:"rule_name"`
+	Criterion  CriterionType `json:"criterion_type"`
+	MediaType  MediaType     `json:"media_type"`
+	Candidates int           `json:"candidates"`
+	Threshold  int           `json:"threshold,omitempty"`
+}
+
+// MaintenanceBulkDeleteWebhook is the data of maintenance.bulk_delete_started
+// and maintenance.bulk_delete_finished deliveries. The outcome counts are
+// zero when the delete starts.
+type MaintenanceBulkDeleteWebhook struct {
+	Requested  int    `json:"requested"`
+	Deleted    int    `json:"deleted"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+	BytesFreed int64  `json:"bytes_freed"`
+	DeletedBy  string `json:"deleted_by"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// ProgressSnapshotInterval is the starting spacing of a session's
+	// progress snapshots.
+	ProgressSnapshotInterval = time.Minute
+	// MaxProgressSnapshots caps a session's snapshots. A longer session
+	// doubles its spacing when it reaches the cap, keeping one snapshot per
+	// new interval, so the series always spans the whole session evenly.
+	MaxProgressSnapshots = 60
+)
+
+// ProgressSnapshot is how far into its item a session was at a moment, and
+// how it was being delivered, for drawing an activity card's timeline.
+type ProgressSnapshot struct {
+	At              time.Time         `json:"at"`
+	ProgressMs      int64             `json:"progress_ms"`
+	ProgressPercent float64           `json:"progress_percent"`
+	State           SessionState      `json:"state,omitempty"`
+	VideoDecision   TranscodeDecision `json:"video_decision,omitempty"`
+	Bandwidth       int64             `json:"bandwidth,omitempty"`
+}
+
+// RecordProgressSnapshot adds a snapshot of the session as of now if now
+// falls in a later interval than the last snapshot. Intervals are counted
+// from the first snapshot, so the series stays evenly spaced however often
+// the session is polled.
+func (s *ActiveStream) RecordProgressSnapshot(now time.Time) {
+	now = now.UTC()
+	if s.ProgressSnapshotEvery <= 0 {
+		s.ProgressSnapshotEvery = ProgressSnapshotInterval
+	}
+	if len(s.ProgressSnapshots) > 0 && !s.inNewSnapshotSlot(now) {
+		return
+	}
+	if len(s.ProgressSnapshots) >= MaxProgressSnapshots {
+		s.ProgressSnapshotEvery *= 2
+		first := s.ProgressSnapshots[0].At
+		thinned := make([]ProgressSnapshot, 0, MaxProgressSnapshots)
+		for _, snap := range s.ProgressSnapshots {
+			if len(thinned) == 0 || snapshotSlot(first, snap.At, s.ProgressSnapshotEvery) > snapshotSlot(first, thinned[len(thinned)-1].At, s.ProgressSnapshotEvery) {
+				thinned = append(thinned, snap)
+			}
+		}
+		s.ProgressSnapshots = thinned
+		if !s.inNewSnapshotSlot(now) {
+			return
+		}
+	}
+
+	snap := ProgressSnapshot{
+		At:            now,
+		ProgressMs:    s.ProgressMs,
+		State:         s.State,
+		VideoDecision: s.VideoDecision,
+		Bandwidth:     s.Bandwidth,
+	}
+	if s.DurationMs > 0 {
+		snap.ProgressPercent = math.Round(min(float64(s.ProgressMs)/float64(s.DurationMs)*100, 100)*10) / 10
+	}
+	// Clipped so appending copies: earlier copies of the session, such as
+	// published snapshots, share the backing array.
+	s.ProgressSnapshots = append(slices.Clip(s.ProgressSnapshots), snap)
+}
+
+func (s *ActiveStream) inNewSnapshotSlot(now time.Time) bool {
+	first := s.ProgressSnapshots[0].At
+	last := s.ProgressSnapshots[len(s.ProgressSnapshots)-1].At
+	return snapshotSlot(first, now, s.ProgressSnapshotEvery) > snapshotSlot(first, last, s.ProgressSnapshotEvery)
+}
+
+// snapshotSlot is which interval of length every, counted from first, t
+// falls in.
+func snapshotSlot(first, t time.Time, every time.Duration) int64 {
+	return int64(t.Sub(first) / every)
+}
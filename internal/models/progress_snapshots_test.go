@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordProgressSnapshot(t *testing.T) {
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	s := ActiveStream{DurationMs: 600000, ProgressMs: 60000, State: SessionStatePlaying, VideoDecision: TranscodeDecisionDirectPlay}
+
+	s.RecordProgressSnapshot(start)
+	s.ProgressMs = 70000
+	s.State = SessionStatePaused
+	s.RecordProgressSnapshot(start.Add(10 * time.Second))
+	if len(s.ProgressSnapshots) != 1 {
+		t.Fatalf("expected a snapshot within the interval to be skipped, got %d", len(s.ProgressSnapshots))
+	}
+	if got := s.ProgressSnapshots[0].ProgressPercent; got != 10 {
+		t.Errorf("percent = %v, want 10", got)
+	}
+
+	s.RecordProgressSnapshot(start.Add(70 * time.Second))
+	if len(s.ProgressSnapshots) != 2 || s.ProgressSnapshots[1].State != SessionStatePaused {
+		t.Fatalf("expected the next interval to be recorded, got %+v", s.ProgressSnapshots)
+	}
+
+	shared := s
+	s.RecordProgressSnapshot(start.Add(2 * time.Minute))
+	if len(shared.ProgressSnapshots) != 2 || len(s.ProgressSnapshots) != 3 {
+		t.Errorf("expected earlier copies to be unaffected, got %d and %d", len(shared.ProgressSnapshots), len(s.ProgressSnapshots))
+	}
+
+	for i := range MaxProgressSnapshots {
+		s.ProgressMs += 60000
+		s.RecordProgressSnapshot(start.Add(time.Duration(3+i) * time.Minute))
+	}
+	if n := len(s.ProgressSnapshots); n > MaxProgressSnapshots {
+		t.Fatalf("expected at most %d snapshots, got %d", MaxProgressSnapshots, n)
+	}
+	if !s.ProgressSnapshots[0].At.Equal(start) {
+		t.Errorf("expected thinning to keep the first snapshot, got %v", s.ProgressSnapshots[0].At)
+	}
+	if last := s.ProgressSnapshots[len(s.ProgressSnapshots)-1]; last.ProgressPercent != 100 {
+		t.Errorf("expected percent capped at 100, got %v", last.ProgressPercent)
+	}
+}
+
+func TestRecordProgressSnapshotEvenSpacing(t *testing.T) {
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	var s ActiveStream
+
+	// Polled every 25 seconds for three hours: past two thinnings.
+	for at := start; at.Before(start.Add(3 * time.Hour)); at = at.Add(25 * time.Second) {
+		s.RecordProgressSnapshot(at)
+	}
+	if s.ProgressSnapshotEvery != 4*ProgressSnapshotInterval {
+		t.Fatalf("every = %v, want %v", s.ProgressSnapshotEvery, 4*ProgressSnapshotInterval)
+	}
+	snaps := s.ProgressSnapshots
+	if len(snaps) > MaxProgressSnapshots || len(snaps) < MaxProgressSnapshots/2 {
+		t.Fatalf("got %d snapshots", len(snaps))
+	}
+	for i := 1; i < len(snaps); i++ {
+		slot := snaps[i].At.Sub(start) / s.ProgressSnapshotEvery
+		if slot != time.Duration(i) {
+			t.Fatalf("snapshot %d at %v is in interval %d, want one per interval", i, snaps[i].At, slot)
+		}
+		// Within an interval the snapshot is the first poll after its
+		// start, so spacing varies by less than one poll.
+		if gap := snaps[i].At.Sub(snaps[i-1].At); gap <= s.ProgressSnapshotEvery-25*time.Second || gap >= s.ProgressSnapshotEvery+25*time.Second {
+			t.Fatalf("gap %d = %v, want about %v", i, gap, s.ProgressSnapshotEvery)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	DefaultProxyAuthUserHeader = "Remote-User"
+	MaxProxyAuthTrustedProxies = 50
+)
+
+// ProxyAuthSettings configures trusted header authentication, for running
+// behind an authenticating reverse proxy such as Authelia or Authentik. A
+// request whose socket peer is one of TrustedProxies is signed in as the
+// user named in UserHeader; EmailHeader, when set, helps match an existing
+// account. With AutoProvision, unknown names get a new viewer account.
+type ProxyAuthSettings struct {
+	Enabled        bool     `json:"enabled"`
+	UserHeader     string   `json:"user_header"`
+	EmailHeader    string   `json:"email_header"`
+	TrustedProxies []string `json:"trusted_proxies"`
+	AutoProvision  bool     `json:"auto_provision"`
+}
+
+func DefaultProxyAuthSettings() ProxyAuthSettings {
+	return ProxyAuthSettings{UserHeader: DefaultProxyAuthUserHeader, TrustedProxies: []string{}}
+}
+
+// Normalize canonicalizes the header names, defaulting an empty user
+// header, and trims the proxy list.
+func (s *ProxyAuthSettings) Normalize() {
+	s.UserHeader = http.CanonicalHeaderKey(strings.TrimSpace(s.UserHeader))
+	if s.UserHeader == "" {
+		s.UserHeader = DefaultProxyAuthUserHeader
+	}
+	s.EmailHeader = http.CanonicalHeaderKey(strings.TrimSpace(s.EmailHeader))
+	proxies := make([]string, 0, len(s.TrustedProxies))
+	for _, p := range s.TrustedProxies {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	s.TrustedProxies = proxies
+}
+
+func (s ProxyAuthSettings) Validate() error {
+	if s.UserHeader == "" {
+		return errors.New("user_header is required")
+	}
+	if s.Enabled && len(s.TrustedProxies) == 0 {
+		return errors.New("at least one trusted proxy is required")
+	}
+	if len(s.TrustedProxies) > MaxProxyAuthTrustedProxies {
+		return fmt.Errorf("at most %d trusted proxies", MaxProxyAuthTrustedProxies)
+	}
+	if _, err := s.ParseTrustedProxies(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseTrustedProxies returns the trusted proxies as prefixes. A bare
+// address is a single-host prefix.
+func (s ProxyAuthSettings) ParseTrustedProxies() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(s.TrustedProxies))
+	for _, p := range s.TrustedProxies {
+		if strings.Contains(p, "/") {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", p)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", p)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import "fmt"
+
+const (
+	DefaultSearchRequestsPerMinute = 30
+	MaxSearchRequestsPerMinute     = 6000
+	MaxSearchBurst                 = 1000
+
+	DefaultAuthMaxFailures   = 10
+	DefaultAuthWindowMinutes = 15
+	MaxAuthMaxFailures       = 1000
+	MaxAuthWindowMinutes     = 24 * 60
+)
+
+// SearchRateLimit budgets search requests per client IP as a token bucket:
+// RequestsPerMinute is the sustained rate and Burst the extra requests a
+// client may make at once on top of it. Requests made with the API key or a
+// personal API token skip the limit when ExemptAPIKeys is set, so dashboards
+// polling with a token aren't throttled alongside browsers.
+type SearchRateLimit struct {
+	RequestsPerMinute int  `json:"requests_per_minute"`
+	Burst             int  `json:"burst"`
+	ExemptAPIKeys     bool `json:"exempt_api_keys"`
+}
+
+// AuthRateLimit locks a client out of the login and credential endpoints
+// after MaxFailures failed attempts within WindowMinutes. It has no API key
+// exemption: those endpoints are reached before authentication or only from
+// an interactive session, so an API key or token request never hits them.
+type AuthRateLimit struct {
+	MaxFailures   int `json:"max_failures"`
+	WindowMinutes int `json:"window_minutes"`
+}
+
+// RateLimitSettings holds the configurable request limits.
+type RateLimitSettings struct {
+	Search SearchRateLimit `json:"search"`
+	Auth   AuthRateLimit   `json:"auth"`
+}
+
+// DefaultRateLimitSettings matches the limits in place before they were
+// configurable.
+func DefaultRateLimitSettings() RateLimitSettings {
+	return RateLimitSettings{
+		Search: SearchRateLimit{RequestsPerMinute: DefaultSearchRequestsPerMinute},
+		Auth:   AuthRateLimit{MaxFailures: DefaultAuthMaxFailures, WindowMinutes: DefaultAuthWindowMinutes},
+	}
+}
+
+func (s RateLimitSettings) Validate() error {
+	if n := s.Search.RequestsPerMinute; n < 1 || n > MaxSearchRequestsPerMinute {
+		return fmt.Errorf("search.requests_per_minute must be between 1 and %d", MaxSearchRequestsPerMinute)
+	}
+	if n := s.Search.Burst; n < 0 || n > MaxSearchBurst {
+		return fmt.Errorf("search.burst must be between 0 and %d", MaxSearchBurst)
+	}
+	if n := s.Auth.MaxFailures; n < 1 || n > MaxAuthMaxFailures {
+		return fmt.Errorf("auth.max_failures must be between 1 and %d", MaxAuthMaxFailures)
+	}
+	if n := s.Auth.WindowMinutes; n < 1 || n > MaxAuthWindowMinutes {
+		return fmt.Errorf("auth.window_minutes must be between 1 and %d", MaxAuthWindowMinutes)
+	}
+	return nil
+}
+
+// ThrottledClient is a client a rate limiter is currently rejecting.
+type ThrottledClient struct {
+	Key               string `json:"key"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
+
+// RateLimiterStatus reports what one limiter is doing right now. Allowed
+// and Rejected count requests since the server started.
+type RateLimiterStatus struct {
+	Name           string            `json:"name"`
+	TrackedClients int               `json:"tracked_clients"`
+	Throttled      []ThrottledClient `json:"throttled"`
+	Allowed        int64             `json:"allowed"`
+	Rejected       int64             `json:"rejected"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RewatchStat is a title one user has watched to the end more than once.
+// For a series, a completion is a full pass: every episode the user has
+// finished, and every episode in the synced library when it's known,
+// finished once more. Episodes is how many episodes a pass covers.
+type RewatchStat struct {
+	UserName      string    `json:"user_name"`
+	MediaType     MediaType `json:"media_type"`
+	Title         string    `json:"title"`
+	Year          int       `json:"year,omitempty"`
+	Episodes      int       `json:"episodes,omitempty"`
+	Completions   int       `json:"completions"`
+	RewatchCount  int       `json:"rewatch_count"`
+	LastWatchedAt time.Time `json:"last_watched_at"`
+}
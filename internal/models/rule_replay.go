@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MaxRuleReplayWindow bounds how much history one replay covers.
+	MaxRuleReplayWindow = 31 * 24 * time.Hour
+	// MaxRuleReplaySessions bounds the sessions one replay evaluates.
+	MaxRuleReplaySessions = 2000
+)
+
+// RuleReplayOutcome is what a rule decided for one replayed session.
+type RuleReplayOutcome string
+
+const (
+	RuleReplayViolation RuleReplayOutcome = "violation"
+	RuleReplayPass      RuleReplayOutcome = "pass"
+	// RuleReplayExempt means the user is exempt from the rule.
+	RuleReplayExempt RuleReplayOutcome = "exempt"
+	// RuleReplaySkipped means the rule isn't checked per stream, or no
+	// evaluator handles its type.
+	RuleReplaySkipped RuleReplayOutcome = "skipped"
+	RuleReplayError   RuleReplayOutcome = "error"
+)
+
+// RuleReplayRequest picks the history to replay. RuleIDs limits the replay to
+// those rules, disabled ones included; empty replays every enabled rule.
+type RuleReplayRequest struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	UserName string    `json:"user_name,omitempty"`
+	RuleIDs  []int64   `json:"rule_ids,omitempty"`
+}
+
+func (r *RuleReplayRequest) Validate() error {
+	if r.From.IsZero() || r.To.IsZero() {
+		return errors.New("from and to are required")
+	}
+	if !r.To.After(r.From) {
+		return errors.New("to must be after from")
+	}
+	if r.To.Sub(r.From) > MaxRuleReplayWindow {
+		return errors.New("window must be 31 days or less")
+	}
+	return nil
+}
+
+// RuleReplayDecision is one rule's verdict on a replayed session. Message,
+// Signals and Details come from the violation the rule would have recorded.
+type RuleReplayDecision struct {
+	RuleID          int64                  `json:"rule_id"`
+	RuleName        string                 `json:"rule_name"`
+	RuleType        RuleType               `json:"rule_type"`
+	Outcome         RuleReplayOutcome      `json:"outcome"`
+	Reason          string                 `json:"reason,omitempty"`
+	Severity        Severity               `json:"severity,omitempty"`
+	Message         string                 `json:"message,omitempty"`
+	ConfidenceScore float64                `json:"confidence_score,omitempty"`
+	Signals         []ViolationSignal      `json:"signals,omitempty"`
+	Details         map[string]interface{} `json:"details,omitempty"`
+}
+
+// RuleReplaySession is one history row as the rules saw it when replayed:
+// the location, network and concurrency inputs the evaluators were given,
+// then each rule's decision.
+type RuleReplaySession struct {
+	HistoryID         int64                `json:"history_id"`
+	UserName          string               `json:"user_name"`
+	Title             string               `json:"title"`
+	Player            string               `json:"player"`
+	IPAddress         string               `json:"ip_address"`
+	StartedAt         time.Time            `json:"started_at"`
+	StoppedAt         time.Time            `json:"stopped_at"`
+	Geo               *GeoResult           `json:"geo,omitempty"`
+	NetworkLabel      string               `json:"network_label,omitempty"`
+	Household         bool                 `json:"household"`
+	ConcurrentStreams int                  `json:"concurrent_streams"`
+	Decisions         []RuleReplayDecision `json:"decisions"`
+}
+
+// RuleReplayResult is the outcome of a replay. Truncated is set when the
+// window held more than MaxRuleReplaySessions sessions; the earliest ones
+// are replayed.
+type RuleReplayResult struct {
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Rules      int                 `json:"rules"`
+	Violations int                 `json:"violations"`
+	Truncated  bool                `json:"truncated"`
+	Sessions   []RuleReplaySession `json:"sessions"`
+}
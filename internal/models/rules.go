@@ -3,7 +3,14 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"streammon/internal/httputil"
@@ -20,6 +27,14 @@ const (
 	RuleTypeNewDevice         RuleType = "new_device"
 	RuleTypeNewLocation       RuleType = "new_location"
 	RuleTypeISPVelocity       RuleType = "isp_velocity"
+	RuleTypeBandwidthQuota    RuleType = "bandwidth_quota"
+	RuleTypeClientMatch       RuleType = "client_match"
+	RuleTypeDistanceFromHome  RuleType = "distance_from_home"
+	RuleTypeHostingIP         RuleType = "hosting_ip"
+	RuleTypeContentRating     RuleType = "content_rating"
+	RuleTypeNetworkLabel      RuleType = "network_label"
+	RuleTypeTranscodeBudget   RuleType = "transcode_budget"
+	RuleTypeSlidingWindow     RuleType = "sliding_window"
 )
 
 func (rt RuleType) Valid() bool {
@@ -27,7 +42,9 @@ func (rt RuleType) Valid() bool {
 	case RuleTypeImpossibleTravel, RuleTypeConcurrentStreams,
 		RuleTypeSimultaneousLocs, RuleTypeDeviceVelocity,
 		RuleTypeGeoRestriction, RuleTypeNewDevice, RuleTypeNewLocation,
-		RuleTypeISPVelocity:
+		RuleTypeISPVelocity, RuleTypeBandwidthQuota, RuleTypeClientMatch,
+		RuleTypeDistanceFromHome, RuleTypeHostingIP, RuleTypeContentRating,
+		RuleTypeNetworkLabel, RuleTypeTranscodeBudget, RuleTypeSlidingWindow:
 		return true
 	}
 	return false
@@ -38,7 +55,10 @@ func (rt RuleType) IsRealTime() bool {
 	switch rt {
 	case RuleTypeConcurrentStreams, RuleTypeSimultaneousLocs,
 		RuleTypeGeoRestriction, RuleTypeNewDevice, RuleTypeNewLocation,
-		RuleTypeImpossibleTravel, RuleTypeDeviceVelocity, RuleTypeISPVelocity:
+		RuleTypeImpossibleTravel, RuleTypeDeviceVelocity, RuleTypeISPVelocity,
+		RuleTypeBandwidthQuota, RuleTypeClientMatch, RuleTypeDistanceFromHome,
+		RuleTypeHostingIP, RuleTypeContentRating, RuleTypeNetworkLabel,
+		RuleTypeTranscodeBudget, RuleTypeSlidingWindow:
 		return true
 	}
 	return false
@@ -67,24 +87,154 @@ const (
 	ChannelTypeWebhook  ChannelType = "webhook"
 	ChannelTypePushover ChannelType = "pushover"
 	ChannelTypeNtfy     ChannelType = "ntfy"
+	ChannelTypeApprise  ChannelType = "apprise"
+	ChannelTypeEmail    ChannelType = "email"
+	ChannelTypeGotify   ChannelType = "gotify"
 )
 
 func (ct ChannelType) Valid() bool {
 	switch ct {
-	case ChannelTypeDiscord, ChannelTypeWebhook, ChannelTypePushover, ChannelTypeNtfy:
+	case ChannelTypeDiscord, ChannelTypeWebhook, ChannelTypePushover, ChannelTypeNtfy, ChannelTypeApprise, ChannelTypeEmail, ChannelTypeGotify:
 		return true
 	}
 	return false
 }
 
+// RuleActionType is something the engine does to the offending session
+// after a rule records a violation.
+type RuleActionType string
+
+const (
+	// RuleActionTerminateStream stops the session on its media server,
+	// showing Message to the user where the server supports it.
+	RuleActionTerminateStream RuleActionType = "terminate_stream"
+	// RuleActionMessageUser shows Message on the offending session's screen
+	// without stopping it. Only Emby and Jellyfin clients support this.
+	RuleActionMessageUser RuleActionType = "message_user"
+)
+
+// MaxRuleActionMessageLen bounds the user-facing text an action sends.
+const MaxRuleActionMessageLen = 500
+
+func (t RuleActionType) Valid() bool {
+	switch t {
+	case RuleActionTerminateStream, RuleActionMessageUser:
+		return true
+	}
+	return false
+}
+
+type RuleAction struct {
+	Type    RuleActionType `json:"type"`
+	Message string         `json:"message,omitempty"`
+}
+
+func (a RuleAction) Validate() error {
+	if !a.Type.Valid() {
+		return fmt.Errorf("invalid action type %q", a.Type)
+	}
+	if a.Type == RuleActionMessageUser && strings.TrimSpace(a.Message) == "" {
+		return errors.New("message_user action requires a message")
+	}
+	if len(a.Message) > MaxRuleActionMessageLen {
+		return fmt.Errorf("action message must be %d characters or less", MaxRuleActionMessageLen)
+	}
+	return nil
+}
+
+// DiscordEmbedField is an optional section of a Discord violation embed.
+type DiscordEmbedField string
+
+const (
+	DiscordFieldPoster    DiscordEmbedField = "poster"
+	DiscordFieldMedia     DiscordEmbedField = "media"
+	DiscordFieldPlayer    DiscordEmbedField = "player"
+	DiscordFieldTranscode DiscordEmbedField = "transcode"
+	DiscordFieldLocation  DiscordEmbedField = "location"
+)
+
+// DefaultDiscordEmbedFields is used by rules that don't pick their own.
+var DefaultDiscordEmbedFields = []DiscordEmbedField{
+	DiscordFieldPoster, DiscordFieldMedia, DiscordFieldPlayer, DiscordFieldTranscode, DiscordFieldLocation,
+}
+
+func (f DiscordEmbedField) Valid() bool {
+	switch f {
+	case DiscordFieldPoster, DiscordFieldMedia, DiscordFieldPlayer, DiscordFieldTranscode, DiscordFieldLocation:
+		return true
+	}
+	return false
+}
+
+// RuleNotification holds per-rule notification options. A nil DiscordFields
+// means DefaultDiscordEmbedFields, an empty list sends the plain embed.
+// TitleTemplate and MessageTemplate, when set, replace the fixed notification
+// title and text; they are Go text/templates over NotificationTemplateData.
+// CooldownMinutes holds back repeat notifications for the same user within
+// that many minutes of the last one sent.
+type RuleNotification struct {
+	DiscordFields   []DiscordEmbedField `json:"discord_fields"`
+	TitleTemplate   string              `json:"title_template,omitempty"`
+	MessageTemplate string              `json:"message_template,omitempty"`
+	CooldownMinutes int                 `json:"cooldown_minutes,omitempty"`
+	// Translations holds the templates for channels set to other
+	// languages, keyed by language.
+	Translations map[Language]NotificationTranslation `json:"translations,omitempty"`
+}
+
+func (n RuleNotification) Validate() error {
+	for _, f := range n.DiscordFields {
+		if !f.Valid() {
+			return fmt.Errorf("invalid discord embed field %q", f)
+		}
+	}
+	if n.CooldownMinutes < 0 || n.CooldownMinutes > MaxNotificationCooldownMinutes {
+		return fmt.Errorf("cooldown_minutes must be between 0 and %d", MaxNotificationCooldownMinutes)
+	}
+	if err := validateNotificationTemplate("title", n.TitleTemplate); err != nil {
+		return err
+	}
+	if err := validateNotificationTemplate("message", n.MessageTemplate); err != nil {
+		return err
+	}
+	return validateNotificationTranslations(n.Translations)
+}
+
+// HasDiscordField reports whether the Discord embed should include f.
+func (n RuleNotification) HasDiscordField(f DiscordEmbedField) bool {
+	fields := n.DiscordFields
+	if fields == nil {
+		fields = DefaultDiscordEmbedFields
+	}
+	return slices.Contains(fields, f)
+}
+
 type Rule struct {
-	ID        int64           `json:"id"`
-	Name      string          `json:"name"`
-	Type      RuleType        `json:"type"`
-	Enabled   bool            `json:"enabled"`
-	Config    json.RawMessage `json:"config"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID           int64            `json:"id"`
+	Name         string           `json:"name"`
+	Type         RuleType         `json:"type"`
+	Enabled      bool             `json:"enabled"`
+	Config       json.RawMessage  `json:"config"`
+	Actions      []RuleAction     `json:"actions"`
+	Notification RuleNotification `json:"notification"`
+	// WorkspaceID confines the rule to the sessions on a workspace's
+	// servers. Nil applies it everywhere.
+	WorkspaceID *int64    `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// InWorkspace reports whether a workspace confined to workspaceID owns the
+// rule. Global rules belong to no workspace.
+func (r *Rule) InWorkspace(workspaceID int64) bool {
+	return r.WorkspaceID != nil && *r.WorkspaceID == workspaceID
+}
+
+// RuleWithCount is a rule with a summary of the violations it has raised.
+type RuleWithCount struct {
+	Rule
+	ViolationCount  int        `json:"violation_count"`
+	LastViolationAt *time.Time `json:"last_violation_at,omitempty"`
 }
 
 func (r *Rule) Validate() error {
@@ -100,7 +250,62 @@ func (r *Rule) Validate() error {
 	if len(r.Config) == 0 {
 		r.Config = json.RawMessage("{}")
 	}
-	return nil
+	if r.Type == RuleTypeClientMatch {
+		// Unlike the other types, this one has no useful defaults: an empty
+		// pattern list would silently never match.
+		var c ClientMatchConfig
+		if err := json.Unmarshal(r.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Type == RuleTypeContentRating {
+		var c ContentRatingConfig
+		if err := json.Unmarshal(r.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Type == RuleTypeNetworkLabel {
+		var c NetworkLabelConfig
+		if err := json.Unmarshal(r.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Type == RuleTypeTranscodeBudget {
+		var c TranscodeBudgetConfig
+		if err := json.Unmarshal(r.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Type == RuleTypeSlidingWindow {
+		var c SlidingWindowConfig
+		if err := json.Unmarshal(r.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Actions == nil {
+		r.Actions = []RuleAction{}
+	}
+	for _, a := range r.Actions {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
+	return r.Notification.Validate()
 }
 
 type ImpossibleTravelConfig struct {
@@ -154,6 +359,23 @@ func (c *ConcurrentStreamsConfig) Validate() error {
 	return nil
 }
 
+// RuleUserLimit overrides a concurrent streams rule's MaxStreams for one user.
+type RuleUserLimit struct {
+	UserName   string `json:"user_name"`
+	MaxStreams int    `json:"max_streams"`
+}
+
+func (l *RuleUserLimit) Validate() error {
+	l.UserName = strings.TrimSpace(l.UserName)
+	if l.UserName == "" {
+		return errors.New("user_name is required")
+	}
+	if l.MaxStreams < 1 {
+		return errors.New("max_streams must be at least 1")
+	}
+	return nil
+}
+
 type SimultaneousLocsConfig struct {
 	MinDistanceKm    float64 `json:"min_distance_km"`
 	ExemptHousehold  bool    `json:"exempt_household"`
@@ -219,6 +441,177 @@ func (c *ISPVelocityConfig) Validate() error {
 	return nil
 }
 
+// BandwidthQuotaConfig flags users whose estimated transfer for the current
+// calendar month (UTC) exceeds MonthlyQuotaGB (decimal gigabytes).
+type BandwidthQuotaConfig struct {
+	MonthlyQuotaGB   float64 `json:"monthly_quota_gb"`
+	AutoTerminate    bool    `json:"auto_terminate"`
+	TerminateMessage string  `json:"terminate_message"`
+}
+
+func (c *BandwidthQuotaConfig) Validate() error {
+	if c.MonthlyQuotaGB <= 0 {
+		c.MonthlyQuotaGB = 500
+	}
+	return nil
+}
+
+// DistanceFromHomeConfig flags sessions streamed farther than MaxDistanceKm
+// from the user's household home. Users without a home are never flagged.
+type DistanceFromHomeConfig struct {
+	MaxDistanceKm       float64 `json:"max_distance_km"`
+	SeverityThresholdKm float64 `json:"severity_threshold_km"`
+	ExemptHousehold     bool    `json:"exempt_household"`
+}
+
+func (c *DistanceFromHomeConfig) Validate() error {
+	if c.MaxDistanceKm <= 0 {
+		c.MaxDistanceKm = 100
+	}
+	if c.SeverityThresholdKm <= 0 {
+		c.SeverityThresholdKm = 1000
+	}
+	return nil
+}
+
+// ClientPattern describes a client by player name, platform (the app, e.g.
+// "Plex for Samsung"), and version. Player, Platform, and Version are
+// case-insensitive globs where * and ? are wildcards. VersionBelow matches
+// versions numerically lower than it, so "4" catches every 3.x release.
+// Empty fields match anything.
+type ClientPattern struct {
+	Player       string `json:"player,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+	Version      string `json:"version,omitempty"`
+	VersionBelow string `json:"version_below,omitempty"`
+}
+
+func (p ClientPattern) empty() bool {
+	return p.Player == "" && p.Platform == "" && p.Version == "" && p.VersionBelow == ""
+}
+
+// ClientMatchConfig flags sessions from clients matching any of Patterns.
+type ClientMatchConfig struct {
+	Patterns []ClientPattern `json:"patterns"`
+	Severity Severity        `json:"severity,omitempty"`
+}
+
+func (c *ClientMatchConfig) Validate() error {
+	if len(c.Patterns) == 0 {
+		return errors.New("at least one client pattern is required")
+	}
+	for _, p := range c.Patterns {
+		if p.empty() {
+			return errors.New("client patterns must set player, platform, version, or version_below")
+		}
+	}
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !c.Severity.Valid() {
+		return errors.New("invalid severity")
+	}
+	return nil
+}
+
+// ContentRatingConfig flags restricted profiles (UserNames) playing content
+// rated above MaxRating. FlagUnrated also flags content with no recognised
+// rating.
+type ContentRatingConfig struct {
+	UserNames        []string `json:"user_names"`
+	MaxRating        string   `json:"max_rating"`
+	FlagUnrated      bool     `json:"flag_unrated"`
+	Severity         Severity `json:"severity,omitempty"`
+	AutoTerminate    bool     `json:"auto_terminate"`
+	TerminateMessage string   `json:"terminate_message"`
+}
+
+func (c *ContentRatingConfig) Validate() error {
+	if len(c.UserNames) == 0 {
+		return errors.New("at least one restricted user is required")
+	}
+	if _, ok := ContentRatingAge(c.MaxRating); !ok {
+		return fmt.Errorf("unrecognised max_rating %q", c.MaxRating)
+	}
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !c.Severity.Valid() {
+		return errors.New("invalid severity")
+	}
+	return nil
+}
+
+// Restricts reports whether userName is one of the restricted profiles.
+func (c *ContentRatingConfig) Restricts(userName string) bool {
+	return slices.ContainsFunc(c.UserNames, func(n string) bool {
+		return strings.EqualFold(n, userName)
+	})
+}
+
+// HostingIPConfig flags sessions from VPN, proxy, and datacenter IPs, as
+// classified by the ASN database. ExtraASNs are treated as hosting networks
+// too, AllowedASNs never are (e.g. a household member's work VPN).
+type HostingIPConfig struct {
+	ExtraASNs   []uint   `json:"extra_asns,omitempty"`
+	AllowedASNs []uint   `json:"allowed_asns,omitempty"`
+	Severity    Severity `json:"severity,omitempty"`
+}
+
+func (c *HostingIPConfig) Validate() error {
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !c.Severity.Valid() {
+		return errors.New("invalid severity")
+	}
+	return nil
+}
+
+// NetworkLabelConfig flags sessions by their network label. Sessions from
+// BlockedLabels are flagged, and when AllowedLabels is set so is every
+// session from any other network, unlabelled ones included. UserNames limits
+// the rule to those users when set.
+type NetworkLabelConfig struct {
+	AllowedLabels    []string `json:"allowed_labels,omitempty"`
+	BlockedLabels    []string `json:"blocked_labels,omitempty"`
+	UserNames        []string `json:"user_names,omitempty"`
+	Severity         Severity `json:"severity,omitempty"`
+	AutoTerminate    bool     `json:"auto_terminate"`
+	TerminateMessage string   `json:"terminate_message"`
+}
+
+func (c *NetworkLabelConfig) Validate() error {
+	if len(c.AllowedLabels) == 0 && len(c.BlockedLabels) == 0 {
+		return errors.New("allowed_labels or blocked_labels is required")
+	}
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !c.Severity.Valid() {
+		return errors.New("invalid severity")
+	}
+	return nil
+}
+
+// Applies reports whether the rule covers userName.
+func (c *NetworkLabelConfig) Applies(userName string) bool {
+	if len(c.UserNames) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.UserNames, func(n string) bool { return strings.EqualFold(n, userName) })
+}
+
+// Flags reports whether a session from the network named label breaks the
+// rule. label is "" for unlabelled networks.
+func (c *NetworkLabelConfig) Flags(label string) bool {
+	match := func(n string) bool { return label != "" && strings.EqualFold(n, label) }
+	if slices.ContainsFunc(c.BlockedLabels, match) {
+		return true
+	}
+	return len(c.AllowedLabels) > 0 && !slices.ContainsFunc(c.AllowedLabels, match)
+}
+
 type RuleViolation struct {
 	ID              int64                  `json:"id"`
 	RuleID          int64                  `json:"rule_id"`
@@ -230,9 +623,24 @@ type RuleViolation struct {
 	Details         map[string]interface{} `json:"details,omitempty"`
 	ConfidenceScore float64                `json:"confidence_score"`
 	SessionKey      string                 `json:"session_key,omitempty"`
+	ServerID        int64                  `json:"server_id,omitempty"`
 	ActionTaken     string                 `json:"action_taken,omitempty"`
 	OccurredAt      time.Time              `json:"occurred_at"`
 	CreatedAt       time.Time              `json:"created_at"`
+
+	// Stream, Geo, Notification, and Event are attached by the rules engine
+	// for richer notifications and are never persisted.
+	Stream       *ActiveStream     `json:"-"`
+	Geo          *GeoResult        `json:"-"`
+	Notification RuleNotification  `json:"-"`
+	Event        NotificationEvent `json:"-"`
+	// RenderedTitle and RenderedMessage hold Notification's templates
+	// filled in for this violation; empty means the provider's default.
+	RenderedTitle   string `json:"-"`
+	RenderedMessage string `json:"-"`
+	// Language is the language the built-in notification text is written
+	// in for the channel being sent to.
+	Language Language `json:"-"`
 }
 
 func (v *RuleViolation) Validate() error {
@@ -295,6 +703,53 @@ func (h *HouseholdLocation) Validate() error {
 	return nil
 }
 
+// HouseholdHomeSource says where a member's home came from.
+type HouseholdHomeSource string
+
+const (
+	HouseholdHomeManual  HouseholdHomeSource = "manual"
+	HouseholdHomeLearned HouseholdHomeSource = "learned"
+)
+
+// DefaultHouseholdHomeRadiusKm is the smallest radius a home is given, so
+// geolocation jitter between a member's own IPs stays inside it.
+const DefaultHouseholdHomeRadiusKm = 25
+
+// HouseholdHome is a member's home on the household map. Confidence (0-100)
+// is the share of the member's household sessions that fall inside the home
+// radius, and always 100 for a manually assigned home.
+type HouseholdHome struct {
+	UserName   string              `json:"user_name"`
+	Latitude   float64             `json:"latitude"`
+	Longitude  float64             `json:"longitude"`
+	RadiusKm   float64             `json:"radius_km"`
+	Confidence float64             `json:"confidence"`
+	Source     HouseholdHomeSource `json:"source"`
+	UpdatedAt  *time.Time          `json:"updated_at,omitempty"`
+}
+
+func (h *HouseholdHome) Validate() error {
+	if h.UserName == "" {
+		return errors.New("user_name is required")
+	}
+	if h.Latitude < -90 || h.Latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if h.Longitude < -180 || h.Longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	if h.Latitude == 0 && h.Longitude == 0 {
+		return errors.New("latitude and longitude are required")
+	}
+	if h.RadiusKm < 0 || h.RadiusKm > 20000 {
+		return errors.New("radius_km must be between 0 and 20000")
+	}
+	if h.RadiusKm == 0 {
+		h.RadiusKm = DefaultHouseholdHomeRadiusKm
+	}
+	return nil
+}
+
 type UserTrustScore struct {
 	UserName        string     `json:"user_name"`
 	Score           int        `json:"score"`
@@ -309,8 +764,26 @@ type NotificationChannel struct {
 	ChannelType ChannelType     `json:"channel_type"`
 	Config      json.RawMessage `json:"config"`
 	Enabled     bool            `json:"enabled"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	// Language is what the channel's notifications are written in. Empty
+	// on update leaves the stored language unchanged.
+	Language Language `json:"language,omitempty"`
+	// Events filters which events the channel receives. Nil on update
+	// leaves the stored matrix unchanged.
+	Events NotificationEventMatrix `json:"events,omitempty"`
+	// QuietHours holds back notifications during part of the day. Nil on
+	// update leaves the stored window unchanged; an empty one clears it.
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// WorkspaceID limits the channel to events from a workspace's servers.
+	// Nil receives events from every server.
+	WorkspaceID *int64    `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// InWorkspace reports whether a workspace confined to workspaceID owns the
+// channel. Global channels belong to no workspace.
+func (n *NotificationChannel) InWorkspace(workspaceID int64) bool {
+	return n.WorkspaceID != nil && *n.WorkspaceID == workspaceID
 }
 
 func (n *NotificationChannel) Validate() error {
@@ -323,9 +796,120 @@ func (n *NotificationChannel) Validate() error {
 	if len(n.Config) == 0 {
 		return errors.New("config is required")
 	}
+	if !n.Language.Valid() {
+		return fmt.Errorf("invalid language %q", n.Language)
+	}
+	if err := n.QuietHours.Validate(); err != nil {
+		return err
+	}
+	return n.Events.Validate()
+}
+
+// NotificationAgent is a channel together with the rules routed to it and
+// its event matrix, so a complete notification setup can be read or written
+// in one request.
+type NotificationAgent struct {
+	NotificationChannel
+	RuleIDs []int64 `json:"rule_ids"`
+}
+
+type NotificationEvent string
+
+const (
+	NotificationEventRuleViolation    NotificationEvent = "rule_violation"
+	NotificationEventConcurrentRecord NotificationEvent = "concurrent_record"
+	NotificationEventWatchLimit       NotificationEvent = "watch_limit"
+	NotificationEventDigest           NotificationEvent = "digest"
+	NotificationEventMilestone        NotificationEvent = "milestone"
+)
+
+// NotificationEvents lists every event a channel can be filtered on.
+var NotificationEvents = []NotificationEvent{
+	NotificationEventRuleViolation,
+	NotificationEventConcurrentRecord,
+	NotificationEventWatchLimit,
+	NotificationEventDigest,
+	NotificationEventMilestone,
+}
+
+func (e NotificationEvent) Valid() bool {
+	switch e {
+	case NotificationEventRuleViolation, NotificationEventConcurrentRecord, NotificationEventWatchLimit,
+		NotificationEventDigest, NotificationEventMilestone:
+		return true
+	}
+	return false
+}
+
+// NotificationEventFilter is one row of a channel's event matrix. Empty
+// Users or ServerIDs match everyone.
+type NotificationEventFilter struct {
+	Enabled   bool     `json:"enabled"`
+	Users     []string `json:"users"`
+	ServerIDs []int64  `json:"server_ids"`
+}
+
+// NotificationEventMatrix maps each event to the filter a channel applies
+// to it. Events missing from the matrix are delivered unfiltered, so
+// channels created before the matrix existed keep receiving everything.
+type NotificationEventMatrix map[NotificationEvent]NotificationEventFilter
+
+func (m NotificationEventMatrix) Validate() error {
+	for event, f := range m {
+		if !event.Valid() {
+			return fmt.Errorf("unknown notification event %q", event)
+		}
+		for _, id := range f.ServerIDs {
+			if id <= 0 {
+				return fmt.Errorf("event %s: invalid server id %d", event, id)
+			}
+		}
+	}
 	return nil
 }
 
+// Allows reports whether the channel should receive event for userName on
+// serverID. An empty userName or zero serverID (events not tied to a user
+// or server) skip the corresponding filter.
+func (m NotificationEventMatrix) Allows(event NotificationEvent, userName string, serverID int64) bool {
+	f, ok := m[event]
+	if !ok {
+		return true
+	}
+	if !f.Enabled {
+		return false
+	}
+	if userName != "" && len(f.Users) > 0 && !slices.ContainsFunc(f.Users, func(u string) bool {
+		return strings.EqualFold(u, userName)
+	}) {
+		return false
+	}
+	if serverID != 0 && len(f.ServerIDs) > 0 && !slices.Contains(f.ServerIDs, serverID) {
+		return false
+	}
+	return true
+}
+
+// Complete returns a copy of m with every known event present, filling
+// missing ones with the enabled, unfiltered default.
+func (m NotificationEventMatrix) Complete() NotificationEventMatrix {
+	out := make(NotificationEventMatrix, len(NotificationEvents))
+	for _, event := range NotificationEvents {
+		f, ok := m[event]
+		if !ok {
+			f.Enabled = true
+		}
+		if f.Users == nil {
+			f.Users = []string{}
+		}
+		if f.ServerIDs == nil {
+			f.ServerIDs = []int64{}
+		}
+		out[event] = f
+	}
+	return out
+}
+
 type DiscordConfig struct {
 	WebhookURL string `json:"webhook_url"`
 }
@@ -353,9 +937,15 @@ func (c *WebhookConfig) Validate() error {
 	return nil
 }
 
+// PushoverConfig sends through Pushover. Priorities overrides the Pushover
+// priority (-2 to 1) sent for a severity; critical violations, the security
+// alerts, default to 1 and bypass the recipient's quiet hours.
 type PushoverConfig struct {
-	UserKey  string `json:"user_key"`
-	APIToken string `json:"api_token"`
+	UserKey    string           `json:"user_key"`
+	APIToken   string           `json:"api_token"`
+	Device     string           `json:"device,omitempty"`
+	Sound      string           `json:"sound,omitempty"`
+	Priorities map[Severity]int `json:"priorities,omitempty"`
 }
 
 func (c *PushoverConfig) Validate() error {
@@ -365,6 +955,70 @@ func (c *PushoverConfig) Validate() error {
 	if c.APIToken == "" {
 		return errors.New("api_token is required")
 	}
+	// Emergency priority (2) needs retry and expiry settings, so it isn't
+	// offered.
+	return validateSeverityPriorities(c.Priorities, -2, 1)
+}
+
+// Priority returns the Pushover priority for a severity.
+func (c *PushoverConfig) Priority(s Severity) int {
+	if p, ok := c.Priorities[s]; ok {
+		return p
+	}
+	switch s {
+	case SeverityCritical:
+		return 1
+	case SeverityInfo:
+		return -1
+	}
+	return 0
+}
+
+// GotifyConfig sends to a Gotify server using an application token.
+// Priorities overrides the Gotify priority (0 to 10) sent for a severity.
+type GotifyConfig struct {
+	ServerURL  string           `json:"server_url"`
+	Token      string           `json:"token"`
+	Priorities map[Severity]int `json:"priorities,omitempty"`
+}
+
+func (c *GotifyConfig) Validate() error {
+	if c.ServerURL == "" {
+		return errors.New("server_url is required")
+	}
+	if c.Token == "" {
+		return errors.New("token is required")
+	}
+	if err := httputil.ValidateIntegrationURL(c.ServerURL); err != nil {
+		return err
+	}
+	return validateSeverityPriorities(c.Priorities, 0, 10)
+}
+
+// Priority returns the Gotify priority for a severity. Gotify clients alert
+// loudly from 8, play a sound from 4 and only list the message below that.
+func (c *GotifyConfig) Priority(s Severity) int {
+	if p, ok := c.Priorities[s]; ok {
+		return p
+	}
+	switch s {
+	case SeverityCritical:
+		return 8
+	case SeverityWarning:
+		return 5
+	}
+	return 2
+}
+
+func validateSeverityPriorities(priorities map[Severity]int, lo, hi int) error {
+	for s, p := range priorities {
+		if !s.Valid() {
+			return fmt.Errorf("invalid severity %q in priorities", s)
+		}
+		if p < lo || p > hi {
+			return fmt.Errorf("priority for %s must be between %d and %d", s, lo, hi)
+		}
+	}
 	return nil
 }
 
@@ -384,6 +1038,168 @@ func (c *NtfyConfig) Validate() error {
 	return httputil.ValidateIntegrationURL(c.ServerURL)
 }
 
+// AppriseConfig dispatches through an Apprise API server. ServerURL is the
+// API's base URL, or an apprise:// (apprises:// for TLS) URL naming the host
+// and config key as in Apprise's own apprise:// plugin. Notifications go
+// either to the stored config under Key, or statelessly to URLs.
+type AppriseConfig struct {
+	ServerURL string   `json:"server_url"`
+	Key       string   `json:"key,omitempty"`
+	URLs      []string `json:"urls,omitempty"`
+	Tag       string   `json:"tag,omitempty"`
+}
+
+var appriseKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,128}$`)
+
+// Validate normalizes an apprise:// ServerURL into an http(s) base URL and
+// Key, then checks the result.
+func (c *AppriseConfig) Validate() error {
+	if u, err := url.Parse(c.ServerURL); err == nil && (u.Scheme == "apprise" || u.Scheme == "apprises") {
+		scheme := "http"
+		if u.Scheme == "apprises" {
+			scheme = "https"
+		}
+		if key := strings.Trim(u.Path, "/"); key != "" {
+			if c.Key != "" && c.Key != key {
+				return errors.New("key conflicts with the key in server_url")
+			}
+			c.Key = key
+		}
+		c.ServerURL = (&url.URL{Scheme: scheme, Host: u.Host, User: u.User}).String()
+	}
+	if c.ServerURL == "" {
+		return errors.New("server_url is required")
+	}
+	if err := httputil.ValidateIntegrationURL(c.ServerURL); err != nil {
+		return err
+	}
+	if c.Key == "" && len(c.URLs) == 0 {
+		return errors.New("key or urls is required")
+	}
+	if c.Key != "" && !appriseKeyPattern.MatchString(c.Key) {
+		return errors.New("key may only contain letters, digits, '-' and '_'")
+	}
+	for _, u := range c.URLs {
+		// Service URLs carry credentials, so don't echo them back.
+		if !strings.Contains(u, "://") {
+			return errors.New("urls must be apprise service urls (scheme://...)")
+		}
+	}
+	return nil
+}
+
+// NotifyURL is the Apprise API endpoint notifications are posted to.
+func (c *AppriseConfig) NotifyURL() string {
+	base := strings.TrimRight(c.ServerURL, "/") + "/notify"
+	if c.Key != "" {
+		return base + "/" + c.Key
+	}
+	return base
+}
+
+// EmailSecurity is how an SMTP connection is encrypted: "tls" connects over
+// TLS (usually port 465), "starttls" upgrades a plain connection (usually
+// 587), and "none" sends in the clear.
+type EmailSecurity string
+
+const (
+	EmailSecurityTLS      EmailSecurity = "tls"
+	EmailSecurityStartTLS EmailSecurity = "starttls"
+	EmailSecurityNone     EmailSecurity = "none"
+)
+
+// MaxEmailRecipients bounds each recipient list of an email channel.
+const MaxEmailRecipients = 50
+
+// EmailConfig sends notifications through an SMTP server. Notifications go
+// to To unless EventRecipients lists other addresses for their event.
+type EmailConfig struct {
+	Host            string                         `json:"host"`
+	Port            int                            `json:"port"`
+	Security        EmailSecurity                  `json:"security"`
+	Username        string                         `json:"username,omitempty"`
+	Password        string                         `json:"password,omitempty"`
+	From            string                         `json:"from"`
+	To              []string                       `json:"to"`
+	EventRecipients map[NotificationEvent][]string `json:"event_recipients,omitempty"`
+}
+
+func (c *EmailConfig) Validate() error {
+	c.Host = strings.TrimSpace(c.Host)
+	if c.Host == "" {
+		return errors.New("host is required")
+	}
+	if strings.ContainsAny(c.Host, ":/ ") && net.ParseIP(c.Host) == nil {
+		return errors.New("host must be a hostname or IP address")
+	}
+	if ip := net.ParseIP(c.Host); ip != nil {
+		if err := httputil.ValidateIP(ip); err != nil {
+			return err
+		}
+	}
+	switch c.Security {
+	case "":
+		c.Security = EmailSecurityStartTLS
+	case EmailSecurityTLS, EmailSecurityStartTLS, EmailSecurityNone:
+	default:
+		return fmt.Errorf("security must be %s, %s, or %s", EmailSecurityTLS, EmailSecurityStartTLS, EmailSecurityNone)
+	}
+	if c.Port == 0 {
+		switch c.Security {
+		case EmailSecurityTLS:
+			c.Port = 465
+		case EmailSecurityStartTLS:
+			c.Port = 587
+		default:
+			c.Port = 25
+		}
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	if c.Password != "" && c.Username == "" {
+		return errors.New("username is required with a password")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	if len(c.To) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	if err := validateEmailRecipients("to", c.To); err != nil {
+		return err
+	}
+	for event, to := range c.EventRecipients {
+		if !event.Valid() {
+			return fmt.Errorf("invalid event %q in event_recipients", event)
+		}
+		if err := validateEmailRecipients("event_recipients."+string(event), to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateEmailRecipients(field string, to []string) error {
+	if len(to) > MaxEmailRecipients {
+		return fmt.Errorf("%s may list at most %d recipients", field, MaxEmailRecipients)
+	}
+	for _, addr := range to {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid %s address %q", field, addr)
+		}
+	}
+	return nil
+}
+
+// RecipientsFor returns who receives notifications for event.
+func (c *EmailConfig) RecipientsFor(event NotificationEvent) []string {
+	if to := c.EventRecipients[event]; len(to) > 0 {
+		return to
+	}
+	return c.To
+}
+
 type MaintenanceTaskStatus string
 
 const (
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -121,6 +122,105 @@ func TestRuleValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "terminate_stream action",
+			rule: Rule{
+				Name:    "Test",
+				Type:    RuleTypeGeoRestriction,
+				Actions: []RuleAction{{Type: RuleActionTerminateStream, Message: "bye"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "message_user action",
+			rule: Rule{
+				Name:    "Test",
+				Type:    RuleTypeGeoRestriction,
+				Actions: []RuleAction{{Type: RuleActionMessageUser, Message: "Please stop sharing"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "message_user action without message",
+			rule: Rule{
+				Name:    "Test",
+				Type:    RuleTypeGeoRestriction,
+				Actions: []RuleAction{{Type: RuleActionMessageUser, Message: "  "}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown action type",
+			rule: Rule{
+				Name:    "Test",
+				Type:    RuleTypeGeoRestriction,
+				Actions: []RuleAction{{Type: "explode"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown discord field",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{DiscordFields: []DiscordEmbedField{DiscordFieldPoster, "weather"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "notification templates",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{TitleTemplate: "{{.User}} in {{.City}}", MessageTemplate: "{{.Title}} on {{.Player}} ({{.TranscodeDecision}})"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "template syntax error",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{MessageTemplate: "{{.User"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "template unknown variable",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{TitleTemplate: "{{.Username}}"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "translated templates",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{Translations: map[Language]NotificationTranslation{LanguageSpanish: {TitleTemplate: "{{.User}} en {{.City}}"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "translation for unsupported language",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{Translations: map[Language]NotificationTranslation{"xx": {TitleTemplate: "{{.User}}"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "translation template error",
+			rule: Rule{
+				Name:         "Test",
+				Type:         RuleTypeGeoRestriction,
+				Notification: RuleNotification{Translations: map[Language]NotificationTranslation{LanguageFrench: {MessageTemplate: "{{.Usuario}}"}}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty config gets default",
 			rule: Rule{
@@ -459,6 +559,46 @@ func TestConfigValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("AppriseConfig validation", func(t *testing.T) {
+		c := &AppriseConfig{ServerURL: "http://apprise.local:8000"}
+		if err := c.Validate(); err == nil {
+			t.Error("expected error without key or urls")
+		}
+		c.URLs = []string{"not a url"}
+		if err := c.Validate(); err == nil {
+			t.Error("expected error for url without scheme")
+		}
+		c.URLs = []string{"tgram://bot/chat"}
+		if err := c.Validate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if got := c.NotifyURL(); got != "http://apprise.local:8000/notify" {
+			t.Errorf("NotifyURL = %q", got)
+		}
+	})
+
+	t.Run("AppriseConfig apprise:// server URL", func(t *testing.T) {
+		c := &AppriseConfig{ServerURL: "apprises://apprise.example.com/my-alerts"}
+		if err := c.Validate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Key != "my-alerts" || c.ServerURL != "https://apprise.example.com" {
+			t.Errorf("normalized to %+v", c)
+		}
+		if got := c.NotifyURL(); got != "https://apprise.example.com/notify/my-alerts" {
+			t.Errorf("NotifyURL = %q", got)
+		}
+
+		c = &AppriseConfig{ServerURL: "apprise://apprise.example.com/a", Key: "b"}
+		if err := c.Validate(); err == nil {
+			t.Error("expected error for conflicting keys")
+		}
+		c = &AppriseConfig{ServerURL: "http://169.254.169.254", Key: "a"}
+		if err := c.Validate(); err == nil {
+			t.Error("expected link-local server to be rejected")
+		}
+	})
+
 	t.Run("WebhookConfig validation", func(t *testing.T) {
 		c := &WebhookConfig{}
 		if err := c.Validate(); err == nil {
@@ -650,3 +790,254 @@ func TestCalculateConfidence(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleValidate_ClientMatchConfig(t *testing.T) {
+	r := Rule{Name: "old clients", Type: RuleTypeClientMatch, Config: json.RawMessage(`{}`)}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for client_match rule without patterns")
+	}
+	r.Config = json.RawMessage(`{"patterns":[{}]}`)
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for empty client pattern")
+	}
+	r.Config = json.RawMessage(`{"patterns":[{"platform":"Plex for Samsung","version_below":"4"}]}`)
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNotificationEventMatrixAllows(t *testing.T) {
+	m := NotificationEventMatrix{
+		NotificationEventRuleViolation:    {Enabled: true, Users: []string{"Alice"}, ServerIDs: []int64{2}},
+		NotificationEventConcurrentRecord: {Enabled: false},
+	}
+	tests := []struct {
+		name   string
+		event  NotificationEvent
+		user   string
+		server int64
+		want   bool
+	}{
+		{"matching user and server", NotificationEventRuleViolation, "alice", 2, true},
+		{"other user", NotificationEventRuleViolation, "bob", 2, false},
+		{"other server", NotificationEventRuleViolation, "alice", 3, false},
+		{"no user or server", NotificationEventRuleViolation, "", 0, true},
+		{"disabled event", NotificationEventConcurrentRecord, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Allows(tt.event, tt.user, tt.server); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var empty NotificationEventMatrix
+	if !empty.Allows(NotificationEventRuleViolation, "bob", 1) {
+		t.Error("empty matrix should allow every event")
+	}
+	if complete := empty.Complete(); len(complete) != len(NotificationEvents) || !complete[NotificationEventConcurrentRecord].Enabled {
+		t.Errorf("Complete() = %+v, want every event enabled", complete)
+	}
+}
+
+func TestNotificationEventMatrixValidate(t *testing.T) {
+	if err := (NotificationEventMatrix{"bogus": {Enabled: true}}).Validate(); err == nil {
+		t.Error("expected error for unknown event")
+	}
+	if err := (NotificationEventMatrix{NotificationEventRuleViolation: {ServerIDs: []int64{0}}}).Validate(); err == nil {
+		t.Error("expected error for invalid server id")
+	}
+}
+
+func TestRuleValidate_ContentRatingConfig(t *testing.T) {
+	r := Rule{Name: "kids", Type: RuleTypeContentRating, Config: json.RawMessage(`{"max_rating":"PG"}`)}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for content_rating rule without users")
+	}
+	r.Config = json.RawMessage(`{"user_names":["kid"],"max_rating":"nonsense"}`)
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for unrecognised max_rating")
+	}
+	r.Config = json.RawMessage(`{"user_names":["kid"],"max_rating":"TV-PG"}`)
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestContentRatingAge(t *testing.T) {
+	tests := []struct {
+		rating string
+		age    int
+		ok     bool
+	}{
+		{"G", 0, true},
+		{"PG-13", 13, true},
+		{"tv-ma", 17, true},
+		{"TV-Y7", 7, true},
+		{"gb/15", 15, true},
+		{"gb/U", 0, true},
+		{"DE-16", 16, true},
+		{"FSK-12", 12, true},
+		{"12A", 12, true},
+		{"18+", 18, true},
+		{"", 0, false},
+		{"NR", 0, false},
+		{"Unrated", 0, false},
+		{"1080", 0, false},
+	}
+	for _, tt := range tests {
+		age, ok := ContentRatingAge(tt.rating)
+		if age != tt.age || ok != tt.ok {
+			t.Errorf("ContentRatingAge(%q) = %d, %v; want %d, %v", tt.rating, age, ok, tt.age, tt.ok)
+		}
+	}
+}
+
+func TestNetworkLabelValidate(t *testing.T) {
+	l := NetworkLabel{Name: "  Home ", CIDRs: []string{"192.168.1.7/24", "10.0.0.5", "2001:db8::/32"}}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "Home" {
+		t.Errorf("name = %q, want trimmed", l.Name)
+	}
+	want := []string{"192.168.1.0/24", "10.0.0.5/32", "2001:db8::/32"}
+	if !slices.Equal(l.CIDRs, want) {
+		t.Errorf("cidrs = %v, want %v", l.CIDRs, want)
+	}
+
+	for _, bad := range []NetworkLabel{
+		{Name: "", CIDRs: []string{"10.0.0.0/8"}},
+		{Name: "Empty"},
+		{Name: "Bad", CIDRs: []string{"10.0.0.0/33"}},
+		{Name: "Zero", ASNs: []uint{0}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", bad)
+		}
+	}
+}
+
+func TestMatchNetworkLabel(t *testing.T) {
+	labels := []NetworkLabel{
+		{Name: "Office", CIDRs: []string{"10.0.0.0/8"}},
+		{Name: "Lab", CIDRs: []string{"10.1.0.0/16"}},
+		{Name: "Cellular", ASNs: []uint{21928}},
+		{Name: "VPN", ASNs: []uint{9009}, CIDRs: []string{"198.51.100.0/24"}},
+	}
+	tests := []struct {
+		ip   string
+		asn  uint
+		want string
+	}{
+		{"10.2.3.4", 0, "Office"},
+		{"10.1.3.4", 0, "Lab"},
+		{"::ffff:10.1.3.4", 0, "Lab"},
+		{"172.56.1.1", 21928, "Cellular"},
+		{"198.51.100.9", 21928, "VPN"},
+		{"203.0.113.1", 0, ""},
+		{"not an ip", 21928, ""},
+	}
+	for _, tt := range tests {
+		if got := MatchNetworkLabel(labels, tt.ip, tt.asn); got != tt.want {
+			t.Errorf("MatchNetworkLabel(%q, %d) = %q, want %q", tt.ip, tt.asn, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkLabelConfig(t *testing.T) {
+	if err := (&NetworkLabelConfig{}).Validate(); err == nil {
+		t.Error("expected a config without labels to be invalid")
+	}
+	blocked := NetworkLabelConfig{BlockedLabels: []string{"VPN"}}
+	if err := blocked.Validate(); err != nil || blocked.Severity != SeverityWarning {
+		t.Fatalf("Validate = %v, severity %q", err, blocked.Severity)
+	}
+	if !blocked.Flags("vpn") || blocked.Flags("Home") || blocked.Flags("") {
+		t.Error("blocked list should flag only VPN")
+	}
+	allowed := NetworkLabelConfig{AllowedLabels: []string{"Home"}, UserNames: []string{"Kid"}}
+	if allowed.Flags("home") || !allowed.Flags("Cellular") || !allowed.Flags("") {
+		t.Error("allow list should flag everything but Home")
+	}
+	if !allowed.Applies("kid") || allowed.Applies("parent") {
+		t.Error("user names should limit the rule")
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	q := &QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Oslo", AllowCritical: true}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	oslo, _ := time.LoadLocation("Europe/Oslo")
+	tests := []struct {
+		at       time.Time
+		severity Severity
+		want     bool
+	}{
+		{time.Date(2026, 1, 10, 23, 30, 0, 0, oslo), SeverityWarning, true},
+		{time.Date(2026, 1, 10, 6, 59, 0, 0, oslo), SeverityInfo, true},
+		{time.Date(2026, 1, 10, 7, 0, 0, 0, oslo), SeverityWarning, false},
+		{time.Date(2026, 1, 10, 23, 30, 0, 0, oslo), SeverityCritical, false},
+		// 21:30 UTC is 22:30 in Oslo in winter.
+		{time.Date(2026, 1, 10, 21, 30, 0, 0, time.UTC), SeverityWarning, true},
+	}
+	for _, tt := range tests {
+		if got := q.Silences(tt.severity, tt.at); got != tt.want {
+			t.Errorf("Silences(%s, %s) = %v, want %v", tt.severity, tt.at, got, tt.want)
+		}
+	}
+	var none *QuietHours
+	if none.Silences(SeverityInfo, time.Now()) || (&QuietHours{}).Silences(SeverityInfo, time.Now()) {
+		t.Error("no window should silence nothing")
+	}
+
+	for _, bad := range []QuietHours{
+		{Start: "22:00"},
+		{Start: "22:00", End: "22:00"},
+		{Start: "25:00", End: "07:00"},
+		{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus"},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", bad)
+		}
+	}
+	if err := (RuleNotification{CooldownMinutes: -1}).Validate(); err == nil {
+		t.Error("expected a negative cooldown to be rejected")
+	}
+}
+
+func TestHomeNetworkSettings(t *testing.T) {
+	s := HomeNetworkSettings{CIDRs: []string{" 203.0.113.7/24 ", "198.51.100.9", "203.0.113.0/24"}}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if want := []string{"203.0.113.0/24", "198.51.100.9/32"}; !slices.Equal(s.CIDRs, want) {
+		t.Errorf("CIDRs = %v, want %v", s.CIDRs, want)
+	}
+	if err := (&HomeNetworkSettings{CIDRs: []string{"nope"}}).Validate(); err == nil {
+		t.Error("expected error for an invalid CIDR")
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"192.168.1.20", true},
+		{"10.0.0.5", true},
+		{"127.0.0.1", true},
+		{"fe80::1", true},
+		{"::ffff:203.0.113.40", true},
+		{"198.51.100.9", true},
+		{"198.51.100.10", false},
+		{"8.8.8.8", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := s.IsLocal(tt.ip); got != tt.want {
+			t.Errorf("IsLocal(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SharedIPStat is an address several users played from within the report
+// window. PeakConcurrent is the most streams from it at once.
+type SharedIPStat struct {
+	IPAddress      string     `json:"ip_address"`
+	Users          []string   `json:"users"`
+	Plays          int        `json:"plays"`
+	PeakConcurrent int        `json:"peak_concurrent"`
+	FirstSeen      time.Time  `json:"first_seen"`
+	LastSeen       time.Time  `json:"last_seen"`
+	Location       *GeoResult `json:"location,omitempty"`
+}
+
+// MultiIPUserStat is a user who played from many addresses within the
+// report window. IPs is capped at MaxReportedIPs, most played first;
+// IPCount is the full count.
+type MultiIPUserStat struct {
+	UserName string    `json:"user_name"`
+	IPCount  int       `json:"ip_count"`
+	IPs      []string  `json:"ips"`
+	Plays    int       `json:"plays"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+const MaxReportedIPs = 20
+
+type SharedIPReport struct {
+	SharedIPs    []SharedIPStat    `json:"shared_ips"`
+	MultiIPUsers []MultiIPUserStat `json:"multi_ip_users"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SlidingWindowMetric is what a sliding window rule totals over its window.
+type SlidingWindowMetric string
+
+const (
+	// SlidingWindowSessions counts the sessions a user started.
+	SlidingWindowSessions SlidingWindowMetric = "sessions"
+	// SlidingWindowCountries counts the distinct countries a user's
+	// sessions came from.
+	SlidingWindowCountries SlidingWindowMetric = "countries"
+	// SlidingWindowGigabytes totals a user's estimated transfer, in GB.
+	SlidingWindowGigabytes SlidingWindowMetric = "gigabytes"
+)
+
+func (m SlidingWindowMetric) Valid() bool {
+	switch m {
+	case SlidingWindowSessions, SlidingWindowCountries, SlidingWindowGigabytes:
+		return true
+	}
+	return false
+}
+
+// defaultWindowHours is each metric's window when the rule doesn't set one:
+// sessions per day, countries per week and transfer per 30 days.
+func (m SlidingWindowMetric) defaultWindowHours() int {
+	switch m {
+	case SlidingWindowCountries:
+		return 7 * 24
+	case SlidingWindowGigabytes:
+		return MaxSlidingWindowHours
+	}
+	return 24
+}
+
+// MaxSlidingWindowHours bounds a sliding window: 30 days.
+const MaxSlidingWindowHours = 30 * 24
+
+// SlidingWindowConfig flags a user whose Metric over the last WindowHours
+// goes over Threshold. Sessions count from when they started, and ones
+// still playing count too.
+type SlidingWindowConfig struct {
+	Metric      SlidingWindowMetric `json:"metric"`
+	WindowHours int                 `json:"window_hours,omitempty"`
+	Threshold   float64             `json:"threshold"`
+	Severity    Severity            `json:"severity,omitempty"`
+}
+
+func (c *SlidingWindowConfig) Validate() error {
+	if !c.Metric.Valid() {
+		return fmt.Errorf("invalid metric %q", c.Metric)
+	}
+	if c.WindowHours == 0 {
+		c.WindowHours = c.Metric.defaultWindowHours()
+	}
+	if c.WindowHours < 0 || c.WindowHours > MaxSlidingWindowHours {
+		return fmt.Errorf("window_hours must be between 1 and %d", MaxSlidingWindowHours)
+	}
+	if c.Threshold <= 0 {
+		return errors.New("threshold must be positive")
+	}
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !c.Severity.Valid() {
+		return errors.New("invalid severity")
+	}
+	return nil
+}
+
+func (c SlidingWindowConfig) Window() time.Duration {
+	return time.Duration(c.WindowHours) * time.Hour
+}
+
+// UserActivity is one of a user's sessions as the sliding window rules see
+// it: when it started, where from, and its estimated transfer.
+type UserActivity struct {
+	StartedAt time.Time
+	Country   string
+	Bytes     int64
+}
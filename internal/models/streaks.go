@@ -0,0 +1,213 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// HourMilestones are the lifetime watch hours worth announcing.
+var HourMilestones = []int{10, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// StreakMilestones are the consecutive watch days worth announcing.
+var StreakMilestones = []int{7, 30, 100, 365}
+
+type MilestoneKind string
+
+const (
+	MilestoneHours       MilestoneKind = "hours"
+	MilestoneStreak      MilestoneKind = "streak"
+	MilestoneAnniversary MilestoneKind = "anniversary"
+)
+
+// UserMilestone is a milestone a user reached: Value hours, a Value-day
+// streak begun on Since, or Value years since their first watch.
+type UserMilestone struct {
+	Kind  MilestoneKind `json:"kind"`
+	Value int           `json:"value"`
+	Since string        `json:"since,omitempty"`
+}
+
+// Key identifies the milestone for announcing it once. A streak is keyed by
+// its start, so a later streak of the same length is announced again.
+func (m UserMilestone) Key() string {
+	key := string(m.Kind) + ":" + strconv.Itoa(m.Value)
+	if m.Since != "" {
+		key += ":" + m.Since
+	}
+	return key
+}
+
+// Message describes the milestone for a notification, e.g. "alice hit
+// 1,000 hours watched".
+func (m UserMilestone) Message(userName string) string {
+	switch m.Kind {
+	case MilestoneHours:
+		return fmt.Sprintf("%s hit %s hours watched", userName, groupThousands(m.Value))
+	case MilestoneStreak:
+		return fmt.Sprintf("%s has watched something %d days in a row", userName, m.Value)
+	case MilestoneAnniversary:
+		if m.Value == 1 {
+			return fmt.Sprintf("It's a year since %s's first watch", userName)
+		}
+		return fmt.Sprintf("It's %d years since %s's first watch", m.Value, userName)
+	}
+	return userName + " reached a milestone"
+}
+
+func groupThousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// UserStreaks is a user's watch streaks and milestones, with days taken in
+// the location named by Timezone. The current streak is still alive when
+// the last watch was yesterday. MilestonesToday lists the milestones
+// reached today: hour marks crossed by today's watching, a streak reaching
+// a milestone length, or a first-watch anniversary.
+type UserStreaks struct {
+	UserName           string          `json:"user_name"`
+	Timezone           string          `json:"timezone"`
+	CurrentStreakDays  int             `json:"current_streak_days"`
+	CurrentStreakStart string          `json:"current_streak_start,omitempty"`
+	LongestStreakDays  int             `json:"longest_streak_days"`
+	LongestStreakStart string          `json:"longest_streak_start,omitempty"`
+	LongestStreakEnd   string          `json:"longest_streak_end,omitempty"`
+	LastWatchDate      string          `json:"last_watch_date,omitempty"`
+	TotalHours         float64         `json:"total_hours"`
+	TodayHours         float64         `json:"today_hours"`
+	HoursMilestone     int             `json:"hours_milestone"`
+	NextHoursMilestone int             `json:"next_hours_milestone,omitempty"`
+	FirstWatchAt       *time.Time      `json:"first_watch_at,omitempty"`
+	AnniversaryYears   int             `json:"anniversary_years"`
+	NextAnniversary    string          `json:"next_anniversary,omitempty"`
+	MilestonesToday    []UserMilestone `json:"milestones_today"`
+}
+
+// NewUserStreaks builds a user's streaks from the sorted, distinct dates
+// they watched on, their first watch, and their lifetime and today's
+// watched milliseconds, as of now.
+func NewUserStreaks(userName string, days []string, firstWatch *time.Time, totalMs, todayMs int64, now time.Time) *UserStreaks {
+	u := &UserStreaks{
+		UserName:     userName,
+		Timezone:     now.Location().String(),
+		TotalHours:   msToRoundedHours(totalMs),
+		TodayHours:   msToRoundedHours(todayMs),
+		FirstWatchAt: firstWatch,
+	}
+	var runStart string
+	for i, day := range days {
+		if i == 0 || !nextDay(days[i-1], day) {
+			runStart = day
+		}
+		run := daysBetween(runStart, day) + 1
+		if run > u.LongestStreakDays {
+			u.LongestStreakDays, u.LongestStreakStart, u.LongestStreakEnd = run, runStart, day
+		}
+	}
+	if len(days) > 0 {
+		u.LastWatchDate = days[len(days)-1]
+		today := now.Format(time.DateOnly)
+		if u.LastWatchDate == today || nextDay(u.LastWatchDate, today) {
+			u.CurrentStreakDays = daysBetween(runStart, u.LastWatchDate) + 1
+			u.CurrentStreakStart = runStart
+		}
+	}
+	u.refresh(now)
+	return u
+}
+
+// AddWatching counts ms of watching still in progress today, so milestones
+// are reached while the play that crosses them is still going.
+func (u *UserStreaks) AddWatching(ms int64, now time.Time) {
+	if ms <= 0 {
+		return
+	}
+	hours := float64(ms) / 3600000
+	u.TotalHours = roundHours(u.TotalHours + hours)
+	u.TodayHours = roundHours(u.TodayHours + hours)
+	today := now.Format(time.DateOnly)
+	if u.LastWatchDate != today {
+		if u.CurrentStreakDays == 0 {
+			u.CurrentStreakStart = today
+		}
+		u.CurrentStreakDays++
+		u.LastWatchDate = today
+		if u.CurrentStreakDays > u.LongestStreakDays {
+			u.LongestStreakDays = u.CurrentStreakDays
+			u.LongestStreakStart, u.LongestStreakEnd = u.CurrentStreakStart, today
+		}
+	}
+	if u.FirstWatchAt == nil {
+		first := now.Add(-time.Duration(ms) * time.Millisecond)
+		u.FirstWatchAt = &first
+	}
+	u.refresh(now)
+}
+
+func (u *UserStreaks) refresh(now time.Time) {
+	u.MilestonesToday = []UserMilestone{}
+	u.HoursMilestone, u.NextHoursMilestone = 0, 0
+	before := u.TotalHours - u.TodayHours
+	for _, m := range HourMilestones {
+		if u.TotalHours < float64(m) {
+			u.NextHoursMilestone = m
+			break
+		}
+		u.HoursMilestone = m
+		if before < float64(m) {
+			u.MilestonesToday = append(u.MilestonesToday, UserMilestone{Kind: MilestoneHours, Value: m})
+		}
+	}
+
+	today := now.Format(time.DateOnly)
+	if u.LastWatchDate == today && slices.Contains(StreakMilestones, u.CurrentStreakDays) {
+		u.MilestonesToday = append(u.MilestonesToday, UserMilestone{Kind: MilestoneStreak, Value: u.CurrentStreakDays, Since: u.CurrentStreakStart})
+	}
+
+	u.AnniversaryYears, u.NextAnniversary = 0, ""
+	if u.FirstWatchAt == nil {
+		return
+	}
+	first := u.FirstWatchAt.In(now.Location())
+	years := now.Year() - first.Year()
+	anniversary := time.Date(now.Year(), first.Month(), first.Day(), 0, 0, 0, 0, now.Location())
+	if anniversary.Format(time.DateOnly) > today {
+		years--
+		u.NextAnniversary = anniversary.Format(time.DateOnly)
+	} else {
+		u.NextAnniversary = anniversary.AddDate(1, 0, 0).Format(time.DateOnly)
+	}
+	u.AnniversaryYears = max(years, 0)
+	if u.AnniversaryYears > 0 && anniversary.Format(time.DateOnly) == today {
+		u.MilestonesToday = append(u.MilestonesToday, UserMilestone{Kind: MilestoneAnniversary, Value: u.AnniversaryYears})
+	}
+}
+
+func nextDay(day, next string) bool {
+	return daysBetween(day, next) == 1
+}
+
+// daysBetween counts the calendar days from one date to another, or -1 when
+// either doesn't parse.
+func daysBetween(from, to string) int {
+	a, errA := time.Parse(time.DateOnly, from)
+	b, errB := time.Parse(time.DateOnly, to)
+	if errA != nil || errB != nil {
+		return -1
+	}
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func msToRoundedHours(ms int64) float64 {
+	return roundHours(float64(ms) / 3600000)
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewUserStreaks(t *testing.T) {
+	now := time.Date(2024, 6, 10, 20, 0, 0, 0, time.UTC)
+	first := time.Date(2022, 6, 10, 9, 0, 0, 0, time.UTC)
+	days := []string{"2022-06-10", "2024-05-01", "2024-05-02", "2024-05-03", "2024-06-08", "2024-06-09"}
+	u := NewUserStreaks("alice", days, &first, 1002*3600000, 3*3600000, now)
+
+	if u.LongestStreakDays != 3 || u.LongestStreakStart != "2024-05-01" || u.LongestStreakEnd != "2024-05-03" {
+		t.Errorf("longest = %d %s..%s", u.LongestStreakDays, u.LongestStreakStart, u.LongestStreakEnd)
+	}
+	if u.CurrentStreakDays != 2 || u.CurrentStreakStart != "2024-06-08" {
+		t.Errorf("current = %d from %s, want 2 from 2024-06-08", u.CurrentStreakDays, u.CurrentStreakStart)
+	}
+	if u.HoursMilestone != 1000 || u.NextHoursMilestone != 2500 {
+		t.Errorf("hours milestones = %d, next %d", u.HoursMilestone, u.NextHoursMilestone)
+	}
+	if u.AnniversaryYears != 2 || u.NextAnniversary != "2025-06-10" {
+		t.Errorf("anniversary = %d, next %s", u.AnniversaryYears, u.NextAnniversary)
+	}
+	want := []UserMilestone{{Kind: MilestoneHours, Value: 1000}, {Kind: MilestoneAnniversary, Value: 2}}
+	if len(u.MilestonesToday) != len(want) || u.MilestonesToday[0] != want[0] || u.MilestonesToday[1] != want[1] {
+		t.Errorf("milestones today = %+v, want %+v", u.MilestonesToday, want)
+	}
+	if got := u.MilestonesToday[0].Message("alice"); got != "alice hit 1,000 hours watched" {
+		t.Errorf("message = %q", got)
+	}
+
+	stale := NewUserStreaks("bob", []string{"2024-06-01"}, &first, 3600000, 0, now)
+	if stale.CurrentStreakDays != 0 || stale.LongestStreakDays != 1 {
+		t.Errorf("broken streak = %d current, %d longest", stale.CurrentStreakDays, stale.LongestStreakDays)
+	}
+}
+
+func TestUserStreaksAddWatching(t *testing.T) {
+	now := time.Date(2024, 6, 10, 20, 0, 0, 0, time.UTC)
+	days := []string{"2024-06-04", "2024-06-05", "2024-06-06", "2024-06-07", "2024-06-08", "2024-06-09"}
+	first := time.Date(2024, 6, 4, 9, 0, 0, 0, time.UTC)
+	u := NewUserStreaks("alice", days, &first, 9*3600000, 0, now)
+	if len(u.MilestonesToday) != 0 {
+		t.Fatalf("milestones before watching today = %+v", u.MilestonesToday)
+	}
+
+	u.AddWatching(90*60000, now)
+	if u.CurrentStreakDays != 7 || u.LastWatchDate != "2024-06-10" || u.TotalHours != 10.5 {
+		t.Errorf("after watching: streak %d, last %s, hours %v", u.CurrentStreakDays, u.LastWatchDate, u.TotalHours)
+	}
+	want := []UserMilestone{{Kind: MilestoneHours, Value: 10}, {Kind: MilestoneStreak, Value: 7, Since: "2024-06-04"}}
+	if len(u.MilestonesToday) != 2 || u.MilestonesToday[0] != want[0] || u.MilestonesToday[1] != want[1] {
+		t.Errorf("milestones today = %+v, want %+v", u.MilestonesToday, want)
+	}
+	if key := u.MilestonesToday[1].Key(); key != "streak:7:2024-06-04" {
+		t.Errorf("streak key = %q", key)
+	}
+}
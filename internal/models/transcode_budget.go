@@ -0,0 +1,140 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TranscodeBudgetPolicy is what a transcode budget rule does when a server
+// runs more transcodes than its budget allows.
+type TranscodeBudgetPolicy string
+
+const (
+	// TranscodeBudgetNotify only records the violation and notifies.
+	TranscodeBudgetNotify TranscodeBudgetPolicy = "notify"
+	// TranscodeBudgetMessageLowest shows a message on the transcoding
+	// session with the lowest user priority.
+	TranscodeBudgetMessageLowest TranscodeBudgetPolicy = "message_lowest_priority"
+	// TranscodeBudgetTerminateNewestRemote stops the most recently started
+	// transcode coming from outside the local network.
+	TranscodeBudgetTerminateNewestRemote TranscodeBudgetPolicy = "terminate_newest_remote"
+)
+
+func (p TranscodeBudgetPolicy) Valid() bool {
+	switch p {
+	case TranscodeBudgetNotify, TranscodeBudgetMessageLowest, TranscodeBudgetTerminateNewestRemote:
+		return true
+	}
+	return false
+}
+
+const (
+	DefaultTranscodeBudget         = 2
+	DefaultTranscodeBudgetMessage  = "The server is short on transcoding capacity right now. Direct play or a lower quality will help."
+	DefaultTranscodeBudgetStopText = "This stream was stopped because the server's transcoding capacity is full."
+)
+
+// TranscodeBudgetWindow overrides the budget between Start and End, given as
+// "HH:MM" in the server's local time. A window whose End is before its Start
+// runs past midnight.
+type TranscodeBudgetWindow struct {
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	MaxTranscodes int    `json:"max_transcodes"`
+}
+
+// TranscodeBudgetConfig caps concurrent transcodes per media server. ServerID
+// limits the rule to one server; zero applies the budget to each server on
+// its own. Priorities ranks users for the message policy, higher meaning more
+// important; unlisted users rank 0.
+type TranscodeBudgetConfig struct {
+	ServerID      int64                   `json:"server_id,omitempty"`
+	MaxTranscodes int                     `json:"max_transcodes"`
+	Windows       []TranscodeBudgetWindow `json:"windows,omitempty"`
+	Policy        TranscodeBudgetPolicy   `json:"policy"`
+	Message       string                  `json:"message,omitempty"`
+	Priorities    map[string]int          `json:"priorities,omitempty"`
+	Severity      Severity                `json:"severity,omitempty"`
+}
+
+func (c *TranscodeBudgetConfig) Validate() error {
+	if c.MaxTranscodes < 0 {
+		return errors.New("max_transcodes must not be negative")
+	}
+	if c.MaxTranscodes == 0 {
+		c.MaxTranscodes = DefaultTranscodeBudget
+	}
+	for i, w := range c.Windows {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return fmt.Errorf("window %d: invalid start: %w", i+1, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return fmt.Errorf("window %d: invalid end: %w", i+1, err)
+		}
+		if start == end {
+			return fmt.Errorf("window %d: start and end must differ", i+1)
+		}
+		if w.MaxTranscodes < 0 {
+			return fmt.Errorf("window %d: max_transcodes must not be negative", i+1)
+		}
+	}
+	if c.Policy == "" {
+		c.Policy = TranscodeBudgetNotify
+	}
+	if !c.Policy.Valid() {
+		return errors.New("invalid policy")
+	}
+	if len(c.Message) > MaxRuleActionMessageLen {
+		return fmt.Errorf("message must be %d characters or less", MaxRuleActionMessageLen)
+	}
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !c.Severity.Valid() {
+		return errors.New("invalid severity")
+	}
+	return nil
+}
+
+// LimitAt returns the budget in force at t, read in t's location. The first
+// matching window wins.
+func (c *TranscodeBudgetConfig) LimitAt(t time.Time) int {
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range c.Windows {
+		start, err1 := parseClock(w.Start)
+		end, err2 := parseClock(w.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if inClockWindow(start, end, minute) {
+			return w.MaxTranscodes
+		}
+	}
+	return c.MaxTranscodes
+}
+
+// Priority returns userName's rank, matched case-insensitively.
+func (c *TranscodeBudgetConfig) Priority(userName string) int {
+	if p, ok := c.Priorities[userName]; ok {
+		return p
+	}
+	for name, p := range c.Priorities {
+		if strings.EqualFold(name, userName) {
+			return p
+		}
+	}
+	return 0
+}
+
+// parseClock returns the minutes after midnight of an "HH:MM" time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("want HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ArchivedUser is a user who dropped off a media server's user list, such
+// as when their share was revoked. Their history is kept; the final stats
+// are frozen at ArchivedAt.
+type ArchivedUser struct {
+	ServerID       int64      `json:"server_id"`
+	ServerName     string     `json:"server_name"`
+	UserName       string     `json:"user_name"`
+	FirstSeenAt    time.Time  `json:"first_seen_at"`
+	ArchivedAt     time.Time  `json:"archived_at"`
+	TotalPlays     int        `json:"total_plays"`
+	TotalWatchedMs int64      `json:"total_watched_ms"`
+	LastStreamedAt *time.Time `json:"last_streamed_at,omitempty"`
+}
+
+// MediaUserNames returns the names of users, for reconciling a server's
+// user list.
+func MediaUserNames(users []MediaUser) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names
+}
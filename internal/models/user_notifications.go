@@ -0,0 +1,169 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// UserAlertEvent is something a user can ask to hear about on their own
+// account, independent of the admin alert channels.
+type UserAlertEvent string
+
+const (
+	UserAlertNewDevice   UserAlertEvent = "new_device"
+	UserAlertNewLocation UserAlertEvent = "new_location"
+)
+
+func (e UserAlertEvent) Valid() bool {
+	switch e {
+	case UserAlertNewDevice, UserAlertNewLocation:
+		return true
+	}
+	return false
+}
+
+// UserAlertEventForRule maps a rule type to the user alert its violations
+// raise. User alerts ride on the admin's rules: with no enabled new device
+// rule, nobody hears about new devices.
+func UserAlertEventForRule(t RuleType) (UserAlertEvent, bool) {
+	switch t {
+	case RuleTypeNewDevice:
+		return UserAlertNewDevice, true
+	case RuleTypeNewLocation:
+		return UserAlertNewLocation, true
+	}
+	return "", false
+}
+
+// Title is the heading a user alert is sent under. The admin's rule name
+// isn't shown to the user.
+func (e UserAlertEvent) Title() string {
+	switch e {
+	case UserAlertNewDevice:
+		return "New device on your account"
+	case UserAlertNewLocation:
+		return "Your account was used from a new location"
+	}
+	return "StreamMon account activity"
+}
+
+// UserNotificationSubscription is which of their own account's events a
+// user wants to hear about and how.
+type UserNotificationSubscription struct {
+	Events []UserAlertEvent `json:"events"`
+	Email  bool             `json:"email"`
+	Push   bool             `json:"push"`
+}
+
+func (s *UserNotificationSubscription) Validate() error {
+	seen := make(map[UserAlertEvent]bool, len(s.Events))
+	for _, e := range s.Events {
+		if !e.Valid() {
+			return fmt.Errorf("invalid event %q", e)
+		}
+		if seen[e] {
+			return fmt.Errorf("duplicate event %q", e)
+		}
+		seen[e] = true
+	}
+	return nil
+}
+
+// Wants reports whether the subscription covers event on any delivery.
+func (s UserNotificationSubscription) Wants(event UserAlertEvent) bool {
+	if !s.Email && !s.Push {
+		return false
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// UserAlertSubscriber is a StreamMon user subscribed to an event.
+type UserAlertSubscriber struct {
+	UserID       int64
+	Name         string
+	Email        string
+	Subscription UserNotificationSubscription
+}
+
+// UserAlert is one user alert ready to deliver: where to email it, if
+// anywhere, and which browsers to push it to.
+type UserAlert struct {
+	Event     UserAlertEvent
+	UserName  string
+	Email     string
+	SMTP      *EmailConfig
+	Push      []PushSubscription
+	VAPIDKeys VAPIDKeys
+}
+
+// PushSubscription is a browser's Web Push endpoint, as returned by
+// PushManager.subscribe, with the keys its payloads are encrypted to.
+type PushSubscription struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"p256dh"`
+	Auth      string    `json:"auth"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaxPushSubscriptionsPerUser bounds the browsers one user can register.
+const MaxPushSubscriptionsPerUser = 10
+
+func (p *PushSubscription) Validate() error {
+	if len(p.Endpoint) > 2048 {
+		return errors.New("endpoint is too long")
+	}
+	u, err := url.Parse(p.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("endpoint must be an https URL")
+	}
+	if key, err := decodePushKey(p.P256dh); err != nil || len(key) != 65 || key[0] != 4 {
+		return errors.New("p256dh must be an uncompressed P-256 public key")
+	}
+	if secret, err := decodePushKey(p.Auth); err != nil || len(secret) != 16 {
+		return errors.New("auth must be a 16-byte secret")
+	}
+	if len(p.UserAgent) > 512 {
+		p.UserAgent = p.UserAgent[:512]
+	}
+	return nil
+}
+
+// decodePushKey accepts the unpadded base64url browsers produce, and the
+// padded form some libraries send.
+func decodePushKey(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
+
+// VAPIDKeys identify this server to push services. Both keys are base64url:
+// the public key as an uncompressed point, the private key as its scalar.
+type VAPIDKeys struct {
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+}
+
+// UserNotificationSettings is the admin's side of user alerts: which email
+// channel's SMTP server sends them. No channel means no email alerts.
+type UserNotificationSettings struct {
+	EmailChannelID int64 `json:"email_channel_id"`
+}
+
+func (s UserNotificationSettings) Validate() error {
+	if s.EmailChannelID < 0 {
+		return errors.New("email_channel_id must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxWatchGoalMinutes bounds a weekly target or limit: a whole week.
+const MaxWatchGoalMinutes = 7 * 24 * 60
+
+// WatchGoal is a user's weekly watch-time target and limit, in minutes. A
+// zero target or limit isn't set. Weeks start on Monday.
+type WatchGoal struct {
+	UserName            string    `json:"user_name"`
+	WeeklyTargetMinutes int       `json:"weekly_target_minutes"`
+	WeeklyLimitMinutes  int       `json:"weekly_limit_minutes"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (g *WatchGoal) Validate() error {
+	if g.UserName == "" {
+		return errors.New("user_name is required")
+	}
+	if g.WeeklyTargetMinutes < 0 || g.WeeklyTargetMinutes > MaxWatchGoalMinutes {
+		return fmt.Errorf("weekly_target_minutes must be between 0 and %d", MaxWatchGoalMinutes)
+	}
+	if g.WeeklyLimitMinutes < 0 || g.WeeklyLimitMinutes > MaxWatchGoalMinutes {
+		return fmt.Errorf("weekly_limit_minutes must be between 0 and %d", MaxWatchGoalMinutes)
+	}
+	if g.WeeklyTargetMinutes == 0 && g.WeeklyLimitMinutes == 0 {
+		return errors.New("set a weekly target, a weekly limit, or both")
+	}
+	if g.WeeklyLimitMinutes > 0 && g.WeeklyTargetMinutes > g.WeeklyLimitMinutes {
+		return errors.New("weekly_target_minutes must not exceed weekly_limit_minutes")
+	}
+	return nil
+}
+
+// WeekStart returns midnight on the Monday of t's week, in t's location.
+func WeekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+}
+
+// DailyWatchTime is one user's watching on one day.
+type DailyWatchTime struct {
+	Date      string `json:"date"`
+	WatchedMs int64  `json:"watched_ms"`
+	Plays     int    `json:"plays"`
+}
+
+// WatchGoalProgress is a user's week so far against their goal. Days holds
+// every day of the week, including ones still to come.
+type WatchGoalProgress struct {
+	WatchGoal
+	WeekStart      time.Time        `json:"week_start"`
+	WatchedMs      int64            `json:"watched_ms"`
+	WatchedMinutes int              `json:"watched_minutes"`
+	Days           []DailyWatchTime `json:"days"`
+	TargetMet      bool             `json:"target_met"`
+	LimitExceeded  bool             `json:"limit_exceeded"`
+	// RemainingMinutes is what's left under the limit, when one is set.
+	RemainingMinutes *int `json:"remaining_minutes,omitempty"`
+}
+
+// NewWatchGoalProgress totals days, as returned for the week starting at
+// weekStart, against goal.
+func NewWatchGoalProgress(goal WatchGoal, weekStart time.Time, days []DailyWatchTime) *WatchGoalProgress {
+	byDate := make(map[string]DailyWatchTime, len(days))
+	for _, d := range days {
+		byDate[d.Date] = d
+	}
+	p := &WatchGoalProgress{WatchGoal: goal, WeekStart: weekStart, Days: make([]DailyWatchTime, 7)}
+	var total int64
+	for i := range p.Days {
+		date := weekStart.AddDate(0, 0, i).Format("2006-01-02")
+		d := byDate[date]
+		d.Date = date
+		p.Days[i] = d
+		total += d.WatchedMs
+	}
+	p.AddWatched(total)
+	return p
+}
+
+// AddWatched counts ms more watching towards the week, such as sessions
+// still playing that aren't in history yet.
+func (p *WatchGoalProgress) AddWatched(ms int64) {
+	if ms > 0 {
+		p.WatchedMs += ms
+	}
+	p.WatchedMinutes = int(p.WatchedMs / 60000)
+	p.TargetMet = p.WeeklyTargetMinutes > 0 && p.WatchedMinutes >= p.WeeklyTargetMinutes
+	p.LimitExceeded = p.WeeklyLimitMinutes > 0 && p.WatchedMinutes > p.WeeklyLimitMinutes
+	p.RemainingMinutes = nil
+	if p.WeeklyLimitMinutes > 0 {
+		remaining := max(p.WeeklyLimitMinutes-p.WatchedMinutes, 0)
+		p.RemainingMinutes = &remaining
+	}
+}
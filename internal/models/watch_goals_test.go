@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchGoal_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		goal    WatchGoal
+		wantErr bool
+	}{
+		{"target only", WatchGoal{UserName: "alice", WeeklyTargetMinutes: 300}, false},
+		{"limit only", WatchGoal{UserName: "alice", WeeklyLimitMinutes: 600}, false},
+		{"both", WatchGoal{UserName: "alice", WeeklyTargetMinutes: 300, WeeklyLimitMinutes: 600}, false},
+		{"neither", WatchGoal{UserName: "alice"}, true},
+		{"no user", WatchGoal{WeeklyLimitMinutes: 600}, true},
+		{"negative", WatchGoal{UserName: "alice", WeeklyLimitMinutes: -1}, true},
+		{"over a week", WatchGoal{UserName: "alice", WeeklyTargetMinutes: MaxWatchGoalMinutes + 1}, true},
+		{"target above limit", WatchGoal{UserName: "alice", WeeklyTargetMinutes: 700, WeeklyLimitMinutes: 600}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.goal.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	loc := time.FixedZone("", -5*3600)
+	for _, tc := range []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 4, 15, 0, 0, 0, loc), "2026-03-02"},  // Wednesday
+		{time.Date(2026, 3, 2, 0, 0, 0, 0, loc), "2026-03-02"},   // Monday midnight
+		{time.Date(2026, 3, 8, 23, 59, 0, 0, loc), "2026-03-02"}, // Sunday
+		{time.Date(2026, 3, 1, 12, 0, 0, 0, loc), "2026-02-23"},  // across a month
+	} {
+		got := WeekStart(tc.at)
+		if got.Format("2006-01-02") != tc.want || got.Hour() != 0 || got.Location() != loc {
+			t.Errorf("WeekStart(%v) = %v, want %s", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestNewWatchGoalProgress(t *testing.T) {
+	week := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	goal := WatchGoal{UserName: "alice", WeeklyTargetMinutes: 60, WeeklyLimitMinutes: 120}
+	p := NewWatchGoalProgress(goal, week, []DailyWatchTime{
+		{Date: "2026-03-03", WatchedMs: 45 * 60000, Plays: 2},
+		{Date: "2026-03-05", WatchedMs: 30 * 60000, Plays: 1},
+	})
+	if len(p.Days) != 7 || p.Days[0].Date != "2026-03-02" || p.Days[1].Plays != 2 || p.Days[6].Date != "2026-03-08" {
+		t.Errorf("days = %+v", p.Days)
+	}
+	if p.WatchedMinutes != 75 || !p.TargetMet || p.LimitExceeded || p.RemainingMinutes == nil || *p.RemainingMinutes != 45 {
+		t.Errorf("progress = %+v", p)
+	}
+
+	p.AddWatched(50 * 60000)
+	if p.WatchedMinutes != 125 || !p.LimitExceeded || *p.RemainingMinutes != 0 {
+		t.Errorf("after adding = %+v", p)
+	}
+
+	noLimit := NewWatchGoalProgress(WatchGoal{UserName: "bob", WeeklyTargetMinutes: 60}, week, nil)
+	if noLimit.RemainingMinutes != nil || noLimit.TargetMet || noLimit.LimitExceeded {
+		t.Errorf("target only = %+v", noLimit)
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const MaxWorkspaceNameLen = 50
+
+// WorkspaceRole is a user's role within their workspace. Workspace admins
+// manage its members; everything else a member can do follows from their
+// StreamMon role, limited to the workspace's servers.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleMember WorkspaceRole = "member"
+)
+
+func (r WorkspaceRole) Valid() bool {
+	return r == WorkspaceRoleAdmin || r == WorkspaceRoleMember
+}
+
+// Workspace isolates a group of servers for a hoster managing several
+// friend groups. Members of a workspace only see its servers, and history
+// and stats from them; a user belongs to at most one workspace, a server to
+// at most one. StreamMon admins are unaffected; other users outside every
+// workspace see no servers at all once one exists.
+type Workspace struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w *Workspace) Validate() error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(w.Name) > MaxWorkspaceNameLen {
+		return fmt.Errorf("name must be at most %d characters", MaxWorkspaceNameLen)
+	}
+	return nil
+}
+
+type WorkspaceMember struct {
+	UserID int64         `json:"user_id"`
+	Name   string        `json:"name"`
+	Role   WorkspaceRole `json:"role"`
+}
+
+// WorkspaceOverview is a workspace with its servers, members and activity,
+// for the admin overview.
+type WorkspaceOverview struct {
+	Workspace
+	ServerIDs       []int64           `json:"server_ids"`
+	Members         []WorkspaceMember `json:"members"`
+	TotalPlays      int               `json:"total_plays"`
+	TotalWatchedMs  int64             `json:"total_watched_ms"`
+	PlaysLast30Days int               `json:"plays_last_30_days"`
+	LastPlayedAt    *time.Time        `json:"last_played_at,omitempty"`
+	ActiveStreams   int               `json:"active_streams"`
+}
+
+// WorkspaceMembership is the workspace a user belongs to and its servers.
+type WorkspaceMembership struct {
+	WorkspaceID int64         `json:"workspace_id"`
+	Name        string        `json:"name"`
+	Role        WorkspaceRole `json:"role"`
+	ServerIDs   []int64       `json:"server_ids"`
+}
+
+func (m *WorkspaceMembership) HasServer(id int64) bool {
+	return slices.Contains(m.ServerIDs, id)
+}
+
+// ScopeServerIDs limits requested server IDs to the workspace's servers,
+// with none requested meaning all of them.
+func (m *WorkspaceMembership) ScopeServerIDs(requested []int64) []int64 {
+	if len(requested) == 0 {
+		return slices.Clone(m.ServerIDs)
+	}
+	scoped := []int64{}
+	for _, id := range requested {
+		if m.HasServer(id) && !slices.Contains(scoped, id) {
+			scoped = append(scoped, id)
+		}
+	}
+	return scoped
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Wrapped is a user's recap of one calendar year of watching.
+type Wrapped struct {
+	UserName     string            `json:"user_name"`
+	Year         int               `json:"year"`
+	TotalHours   float64           `json:"total_hours"`
+	Plays        int               `json:"plays"`
+	DaysWatched  int               `json:"days_watched"`
+	TopShows     []MediaStat       `json:"top_shows"`
+	TopMovies    []MediaStat       `json:"top_movies"`
+	BusiestDay   *WrappedDay       `json:"busiest_day"`
+	LongestBinge *WrappedBinge     `json:"longest_binge"`
+	TopDevice    *DeviceStat       `json:"top_device"`
+	Locations    []WrappedLocation `json:"locations"`
+}
+
+// WrappedDay is the date, in the recap's time zone, with the most watching.
+type WrappedDay struct {
+	Date       string  `json:"date"`
+	TotalHours float64 `json:"total_hours"`
+	Plays      int     `json:"plays"`
+}
+
+// WrappedBinge is the longest run of one show's episodes watched back to
+// back.
+type WrappedBinge struct {
+	Show       string    `json:"show"`
+	Episodes   int       `json:"episodes"`
+	TotalHours float64   `json:"total_hours"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+}
+
+// WrappedLocation is a place watched from, for the recap's map.
+type WrappedLocation struct {
+	City    string  `json:"city"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+	Plays   int     `json:"plays"`
+}
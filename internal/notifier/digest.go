@@ -0,0 +1,133 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"streammon/internal/metrics"
+	"streammon/internal/models"
+)
+
+// SendDigest delivers d to each channel. Digests are scheduled, so they go
+// out regardless of quiet hours and cooldowns.
+func (n *Notifier) SendDigest(ctx context.Context, d *models.Digest, channels []models.NotificationChannel) error {
+	v := digestViolation(d, n.clock())
+	payload := n.payload(v)
+	var errs []error
+	for _, ch := range channels {
+		if err := n.send(ctx, ch, v); err != nil {
+			metrics.Notifications.Inc(string(ch.ChannelType), "failed")
+			n.record(ctx, v, ch, payload, models.NotificationFailed, "", err)
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name, err))
+			continue
+		}
+		metrics.Notifications.Inc(string(ch.ChannelType), "sent")
+		n.record(ctx, v, ch, payload, models.NotificationSent, "", nil)
+	}
+	return errors.Join(errs...)
+}
+
+// SendPersonalDigest emails d, with its Personal section, to one user
+// through smtp.
+func (n *Notifier) SendPersonalDigest(ctx context.Context, d *models.Digest, smtp models.EmailConfig, email string) error {
+	from, err := mail.ParseAddress(smtp.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := mail.ParseAddress(email)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q", email)
+	}
+	msg, err := buildEmail(from, []*mail.Address{to}, digestViolation(d, n.clock()), time.Now())
+	if err != nil {
+		return err
+	}
+	if err := n.sendSMTP(ctx, smtp, from, []*mail.Address{to}, msg); err != nil {
+		metrics.Notifications.Inc("user_email", "failed")
+		return err
+	}
+	metrics.Notifications.Inc("user_email", "sent")
+	return nil
+}
+
+// digestViolation wraps d in the violation every channel type knows how to
+// send, with the digest as its pre-rendered title and message.
+func digestViolation(d *models.Digest, now time.Time) *models.RuleViolation {
+	title := d.Period.Title()
+	if d.Name != "" {
+		title += ": " + d.Name
+	}
+	text := digestText(d)
+	return &models.RuleViolation{
+		RuleName:        d.Name,
+		Severity:        models.SeverityInfo,
+		Message:         text,
+		Details:         map[string]interface{}{"digest": d},
+		OccurredAt:      now.UTC(),
+		Event:           models.NotificationEventDigest,
+		RenderedTitle:   title,
+		RenderedMessage: text,
+	}
+}
+
+// digestText lays d out as plain text, which every provider can show.
+func digestText(d *models.Digest) string {
+	var b strings.Builder
+	last := d.End.AddDate(0, 0, -1)
+	fmt.Fprintf(&b, "%s to %s: %d plays, %.1f hours watched by %d users.\n",
+		d.Start.Format("Jan 2"), last.Format("Jan 2, 2006"), d.Plays, d.TotalHours, d.ActiveUsers)
+
+	if p := d.Personal; p != nil {
+		fmt.Fprintf(&b, "\nYou watched %.1f hours over %d plays.\n", p.TotalHours, p.Plays)
+		writeDigestTitles(&b, "Your top titles", p.TopTitles)
+	}
+
+	if d.NewContentCount > 0 {
+		fmt.Fprintf(&b, "\nNew in the libraries (%d):\n", d.NewContentCount)
+		for _, item := range d.NewContent {
+			fmt.Fprintf(&b, "- %s", digestTitle(item.Title, item.Year))
+			if item.ServerName != "" {
+				fmt.Fprintf(&b, " on %s", item.ServerName)
+			}
+			b.WriteString("\n")
+		}
+		if more := d.NewContentCount - len(d.NewContent); more > 0 {
+			fmt.Fprintf(&b, "...and %d more\n", more)
+		}
+	}
+
+	writeDigestTitles(&b, "Top movies", d.TopMovies)
+	writeDigestTitles(&b, "Top shows", d.TopShows)
+	if len(d.TopUsers) > 0 {
+		b.WriteString("\nTop users:\n")
+		for i, u := range d.TopUsers {
+			fmt.Fprintf(&b, "%d. %s - %.1f hours\n", i+1, u.UserName, u.TotalHours)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func writeDigestTitles(b *strings.Builder, heading string, stats []models.MediaStat) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", heading)
+	for i, m := range stats {
+		plays := "plays"
+		if m.PlayCount == 1 {
+			plays = "play"
+		}
+		fmt.Fprintf(b, "%d. %s - %d %s\n", i+1, digestTitle(m.Title, m.Year), m.PlayCount, plays)
+	}
+}
+
+func digestTitle(title string, year int) string {
+	if year > 0 {
+		return fmt.Sprintf("%s (%d)", title, year)
+	}
+	return title
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func testDigest() *models.Digest {
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	return &models.Digest{
+		Name: "Family", Period: models.DigestWeekly, Start: start, End: start.AddDate(0, 0, 7),
+		TotalHours: 12.5, Plays: 9, ActiveUsers: 3,
+		NewContentCount: 12,
+		NewContent:      []models.DigestNewItem{{Title: "Dune", Year: 2021, ServerName: "Plex"}},
+		TopMovies:       []models.MediaStat{{Title: "Heat", Year: 1995, PlayCount: 1}},
+		TopShows:        []models.MediaStat{{Title: "The Show", PlayCount: 4}},
+		TopUsers:        []models.UserStat{{UserName: "alice", TotalHours: 6}},
+	}
+}
+
+func TestDigestText(t *testing.T) {
+	text := digestText(testDigest())
+	for _, want := range []string{
+		"Mar 2 to Mar 8, 2026: 9 plays, 12.5 hours watched by 3 users.",
+		"New in the libraries (12):\n- Dune (2021) on Plex\n...and 11 more",
+		"Top movies:\n1. Heat (1995) - 1 play",
+		"Top shows:\n1. The Show - 4 plays",
+		"Top users:\n1. alice - 6.0 hours",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("digest text missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "You watched") {
+		t.Error("shared digest has a personal section")
+	}
+
+	d := testDigest()
+	d.TopUsers = nil
+	d.Personal = &models.DigestPersonal{UserName: "alice", TotalHours: 2, Plays: 1,
+		TopTitles: []models.MediaStat{{Title: "Heat", Year: 1995, PlayCount: 1}}}
+	text = digestText(d)
+	if !strings.Contains(text, "You watched 2.0 hours over 1 plays.\n\nYour top titles:\n1. Heat (1995)") {
+		t.Errorf("personal digest text:\n%s", text)
+	}
+	if strings.Contains(text, "Top users") {
+		t.Error("personal copy lists other users")
+	}
+}
+
+func TestSendDigest_Webhook(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+	}))
+	defer ts.Close()
+
+	n := newTestNotifier()
+	ch := models.NotificationChannel{
+		Name:        "hook",
+		ChannelType: models.ChannelTypeWebhook,
+		Config:      json.RawMessage(`{"url":"` + ts.URL + `","method":"POST"}`),
+		Enabled:     true,
+	}
+	if err := n.SendDigest(context.Background(), testDigest(), []models.NotificationChannel{ch}); err != nil {
+		t.Fatal(err)
+	}
+	if body["event"] != "digest" || body["title"] != "StreamMon weekly digest: Family" {
+		t.Errorf("payload = %v", body)
+	}
+	if text, _ := body["text"].(string); !strings.Contains(text, "Top shows") {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestEmailContent_Digest(t *testing.T) {
+	data := emailContent(digestViolation(testDigest(), time.Now()))
+	if data.Title != "StreamMon weekly digest: Family" || len(data.Fields) != 0 {
+		t.Errorf("email = %q with fields %+v", data.Title, data.Fields)
+	}
+}
@@ -0,0 +1,224 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// discordPosterName is the attachment filename the embed thumbnail points at.
+const discordPosterName = "poster.jpg"
+
+func (n *Notifier) sendDiscord(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
+	var config models.DiscordConfig
+	if err := json.Unmarshal(ch.Config, &config); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	embed := discordEmbed(v)
+
+	var poster []byte
+	var posterType string
+	if s := v.Stream; s != nil && s.ThumbURL != "" && n.posters != nil && v.Notification.HasDiscordField(models.DiscordFieldPoster) {
+		data, contentType, err := n.posters.FetchPoster(ctx, s.ServerID, s.ThumbURL)
+		if err != nil {
+			// A missing poster shouldn't cost the notification.
+			log.Printf("discord notification: poster for %q unavailable: %v", s.Title, err)
+		} else {
+			poster, posterType = data, contentType
+			embed["thumbnail"] = map[string]string{"url": "attachment://" + discordPosterName}
+		}
+	}
+
+	payload := map[string]interface{}{
+		"embeds": []map[string]interface{}{embed},
+	}
+	if poster == nil {
+		return n.postJSON(ctx, config.WebhookURL, payload)
+	}
+	return n.postDiscordWithAttachment(ctx, config.WebhookURL, payload, poster, posterType)
+}
+
+// discordEmbed builds the violation embed. Stream details are added per the
+// rule's notification options when the violation came from a live session.
+func discordEmbed(v *models.RuleViolation) map[string]interface{} {
+	color := 0x808080
+	switch v.Severity {
+	case models.SeverityCritical:
+		color = 0xFF0000
+	case models.SeverityWarning:
+		color = 0xFFA500
+	case models.SeverityInfo:
+		color = 0x0000FF
+	}
+
+	// Discord rejects embed fields with an empty value, and system events
+	// (e.g. concurrent stream records) aren't tied to a user.
+	var fields []map[string]interface{}
+	addField := func(name, value string, inline bool) {
+		if value != "" {
+			fields = append(fields, map[string]interface{}{"name": name, "value": value, "inline": inline})
+		}
+	}
+	lang := v.Language
+	addField(tr(lang, "User"), v.UserName, true)
+	if v.Event != models.NotificationEventDigest {
+		addField(tr(lang, "Severity"), tr(lang, string(v.Severity)), true)
+		addField(tr(lang, "Confidence"), fmt.Sprintf("%.0f%%", v.ConfidenceScore), true)
+	}
+
+	opts := v.Notification
+	if s := v.Stream; s != nil {
+		if opts.HasDiscordField(models.DiscordFieldMedia) {
+			addField(tr(lang, "Title"), streamTitle(s), false)
+		}
+		if opts.HasDiscordField(models.DiscordFieldPlayer) {
+			player := s.Player
+			if s.Platform != "" && s.Platform != s.Player {
+				player = strings.TrimSpace(player + " (" + s.Platform + ")")
+			}
+			addField(tr(lang, "Player"), player, true)
+		}
+		if opts.HasDiscordField(models.DiscordFieldTranscode) {
+			addField(tr(lang, "Stream"), streamDecision(s), true)
+		}
+	}
+	if opts.HasDiscordField(models.DiscordFieldLocation) {
+		addField(tr(lang, "Location"), violationLocation(v), true)
+	}
+
+	title := fmt.Sprintf(tr(lang, "Rule Violation: %s"), v.RuleName)
+	if v.RenderedTitle != "" {
+		title = v.RenderedTitle
+	}
+	description := v.Message
+	if v.RenderedMessage != "" {
+		description = v.RenderedMessage
+	}
+
+	return map[string]interface{}{
+		"title":       title,
+		"description": description,
+		"color":       color,
+		"fields":      fields,
+		"timestamp":   v.OccurredAt.Format(time.RFC3339),
+		"footer": map[string]string{
+			"text": tr(lang, "StreamMon Rules Engine"),
+		},
+	}
+}
+
+func streamTitle(s *models.ActiveStream) string {
+	switch {
+	case s.GrandparentTitle != "" && s.SeasonNumber > 0 && s.EpisodeNumber > 0:
+		return fmt.Sprintf("%s - S%02dE%02d - %s", s.GrandparentTitle, s.SeasonNumber, s.EpisodeNumber, s.Title)
+	case s.GrandparentTitle != "":
+		return s.GrandparentTitle + " - " + s.Title
+	case s.Year > 0:
+		return fmt.Sprintf("%s (%d)", s.Title, s.Year)
+	}
+	return s.Title
+}
+
+func streamDecision(s *models.ActiveStream) string {
+	decision := s.VideoDecision
+	if decision == "" {
+		decision = s.AudioDecision
+	}
+	if decision == "" {
+		return ""
+	}
+	parts := []string{string(decision)}
+	if decision == models.TranscodeDecisionTranscode {
+		if s.VideoResolution != "" && s.TranscodeVideoResolution != "" && s.VideoResolution != s.TranscodeVideoResolution {
+			parts = append(parts, s.VideoResolution+" → "+s.TranscodeVideoResolution)
+		}
+		if s.TranscodeHWEncode || s.TranscodeHWDecode {
+			parts = append(parts, "HW")
+		}
+	} else if s.VideoResolution != "" {
+		parts = append(parts, s.VideoResolution)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func violationLocation(v *models.RuleViolation) string {
+	var place []string
+	if g := v.Geo; g != nil {
+		for _, p := range []string{g.City, g.Country} {
+			if p != "" {
+				place = append(place, p)
+			}
+		}
+	}
+	loc := strings.Join(place, ", ")
+	if v.Stream != nil && v.Stream.IPAddress != "" {
+		if loc == "" {
+			return v.Stream.IPAddress
+		}
+		loc += " (" + v.Stream.IPAddress + ")"
+	}
+	return loc
+}
+
+// postDiscordWithAttachment sends payload as multipart form data with poster
+// as the first file, which embeds can reference via attachment://.
+func (n *Notifier) postDiscordWithAttachment(ctx context.Context, webhookURL string, payload interface{}, poster []byte, contentType string) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshaling payload: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("payload_json", string(payloadJSON)); err != nil {
+		return fmt.Errorf("writing payload: %w", err)
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[0]"; filename=%q`, discordPosterName))
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(poster)
+	}
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("writing poster: %w", err)
+	}
+	if _, err := part.Write(poster); err != nil {
+		return fmt.Errorf("writing poster: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("writing multipart body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, &body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type fakePosters struct {
+	data []byte
+	err  error
+	got  string
+}
+
+func (f *fakePosters) FetchPoster(_ context.Context, _ int64, thumb string) ([]byte, string, error) {
+	f.got = thumb
+	return f.data, "image/png", f.err
+}
+
+func richViolation(opts models.RuleNotification) *models.RuleViolation {
+	return &models.RuleViolation{
+		RuleName:        "Geo",
+		UserName:        "alice",
+		Severity:        models.SeverityWarning,
+		Message:         "impossible travel",
+		ConfidenceScore: 90,
+		OccurredAt:      time.Now().UTC(),
+		Stream: &models.ActiveStream{
+			ServerID:                 1,
+			Title:                    "Pilot",
+			GrandparentTitle:         "Show",
+			SeasonNumber:             1,
+			EpisodeNumber:            2,
+			Player:                   "Shield",
+			Platform:                 "Android",
+			IPAddress:                "203.0.113.5",
+			ThumbURL:                 "library/metadata/5/thumb/1",
+			VideoDecision:            models.TranscodeDecisionTranscode,
+			VideoResolution:          "4K",
+			TranscodeVideoResolution: "1080p",
+		},
+		Geo:          &models.GeoResult{City: "Berlin", Country: "Germany"},
+		Notification: opts,
+	}
+}
+
+func embedFieldValues(t *testing.T, embed map[string]interface{}) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	fields, _ := embed["fields"].([]interface{})
+	for _, f := range fields {
+		m := f.(map[string]interface{})
+		out[m["name"].(string)] = m["value"].(string)
+	}
+	return out
+}
+
+func TestDiscordEmbed_RichFields(t *testing.T) {
+	raw, _ := json.Marshal(discordEmbed(richViolation(models.RuleNotification{})))
+	var embed map[string]interface{}
+	json.Unmarshal(raw, &embed)
+	fields := embedFieldValues(t, embed)
+
+	want := map[string]string{
+		"User":     "alice",
+		"Title":    "Show - S01E02 - Pilot",
+		"Player":   "Shield (Android)",
+		"Stream":   "transcode · 4K → 1080p",
+		"Location": "Berlin, Germany (203.0.113.5)",
+	}
+	for name, v := range want {
+		if fields[name] != v {
+			t.Errorf("field %s = %q, want %q", name, fields[name], v)
+		}
+	}
+}
+
+func TestDiscordEmbed_FieldSelection(t *testing.T) {
+	opts := models.RuleNotification{DiscordFields: []models.DiscordEmbedField{models.DiscordFieldPlayer}}
+	raw, _ := json.Marshal(discordEmbed(richViolation(opts)))
+	var embed map[string]interface{}
+	json.Unmarshal(raw, &embed)
+	fields := embedFieldValues(t, embed)
+
+	if fields["Player"] == "" {
+		t.Error("expected Player field")
+	}
+	for _, name := range []string{"Title", "Stream", "Location"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("unexpected %s field", name)
+		}
+	}
+}
+
+func TestDiscordEmbed_Language(t *testing.T) {
+	v := richViolation(models.RuleNotification{})
+	v.Language = models.LanguageGerman
+	raw, _ := json.Marshal(discordEmbed(v))
+	var embed map[string]interface{}
+	json.Unmarshal(raw, &embed)
+
+	if embed["title"] != "Regelverstoß: Geo" {
+		t.Errorf("title = %v", embed["title"])
+	}
+	got := embedFieldValues(t, embed)
+	if got["Benutzer"] != "alice" || got["Schweregrad"] != "Warnung" || got["Standort"] != "Berlin, Germany (203.0.113.5)" {
+		t.Errorf("fields = %v", got)
+	}
+}
+
+func TestSendDiscord_PosterAttachment(t *testing.T) {
+	var payload map[string]interface{}
+	var fileType string
+	var fileData []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "multipart/form-data" {
+			t.Errorf("Content-Type = %q, want multipart/form-data", mediaType)
+			return
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			switch part.FormName() {
+			case "payload_json":
+				json.Unmarshal(data, &payload)
+			case "files[0]":
+				fileType = part.Header.Get("Content-Type")
+				fileData = data
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	posters := &fakePosters{data: []byte("png-bytes")}
+	n := newTestNotifier()
+	WithPosterFetcher(posters)(n)
+
+	channel := models.NotificationChannel{
+		ChannelType: models.ChannelTypeDiscord,
+		Config:      json.RawMessage(`{"webhook_url":"` + server.URL + `"}`),
+	}
+	if err := n.Notify(context.Background(), richViolation(models.RuleNotification{}), []models.NotificationChannel{channel}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if posters.got != "library/metadata/5/thumb/1" {
+		t.Errorf("poster thumb = %q", posters.got)
+	}
+	if string(fileData) != "png-bytes" || fileType != "image/png" {
+		t.Errorf("attachment = %q (%s)", fileData, fileType)
+	}
+	embed := payload["embeds"].([]interface{})[0].(map[string]interface{})
+	thumb, _ := embed["thumbnail"].(map[string]interface{})
+	if thumb["url"] != "attachment://"+discordPosterName {
+		t.Errorf("thumbnail = %v", embed["thumbnail"])
+	}
+}
+
+func TestSendDiscord_PosterFailureFallsBackToJSON(t *testing.T) {
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier()
+	WithPosterFetcher(&fakePosters{err: errors.New("offline")})(n)
+	channel := models.NotificationChannel{
+		ChannelType: models.ChannelTypeDiscord,
+		Config:      json.RawMessage(`{"webhook_url":"` + server.URL + `"}`),
+	}
+	if err := n.Notify(context.Background(), richViolation(models.RuleNotification{}), []models.NotificationChannel{channel}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("Content-Type = %q, want JSON fallback", contentType)
+	}
+}
@@ -0,0 +1,282 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// smtpTimeout bounds a whole SMTP exchange when ctx has no deadline.
+const smtpTimeout = 30 * time.Second
+
+type emailField struct {
+	Name  string
+	Value string
+}
+
+type emailData struct {
+	Title      string
+	Message    string
+	Severity   models.Severity
+	Color      string
+	Fields     []emailField
+	OccurredAt string
+}
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f4f5;font-family:-apple-system,Segoe UI,Roboto,Helvetica,Arial,sans-serif;color:#18181b">
+<table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width:600px;margin:0 auto;background:#ffffff;border-radius:8px;border-top:4px solid {{.Color}}">
+<tr><td style="padding:24px">
+<h2 style="margin:0 0 8px;font-size:18px">{{.Title}}</h2>
+<p style="margin:0 0 16px;font-size:14px;line-height:1.5;white-space:pre-line">{{.Message}}</p>
+<table role="presentation" cellspacing="0" cellpadding="0" style="font-size:14px">
+{{range .Fields}}<tr><td style="padding:4px 16px 4px 0;color:#71717a;vertical-align:top">{{.Name}}</td><td style="padding:4px 0">{{.Value}}</td></tr>
+{{end}}</table>
+<p style="margin:16px 0 0;font-size:12px;color:#a1a1aa">StreamMon &middot; {{.OccurredAt}}</p>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+var emailTextTemplate = template.Must(template.New("email").Parse(`{{.Title}}
+
+{{.Message}}
+{{range .Fields}}
+{{.Name}}: {{.Value}}{{end}}
+
+StreamMon - {{.OccurredAt}}
+`))
+
+func (n *Notifier) sendEmail(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
+	var config models.EmailConfig
+	if err := json.Unmarshal(ch.Config, &config); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	var to []*mail.Address
+	for _, raw := range config.RecipientsFor(v.Event) {
+		addr, err := mail.ParseAddress(raw)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q", raw)
+		}
+		to = append(to, addr)
+	}
+
+	msg, err := buildEmail(from, to, v, time.Now())
+	if err != nil {
+		return err
+	}
+	return n.sendSMTP(ctx, config, from, to, msg)
+}
+
+// sendSMTP delivers msg over one SMTP connection, encrypted as config asks.
+func (n *Notifier) sendSMTP(ctx context.Context, config models.EmailConfig, from *mail.Address, to []*mail.Address, msg []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+	conn, err := n.dial(ctx, "tcp", net.JoinHostPort(config.Host, strconv.Itoa(config.Port)))
+	if err != nil {
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	tlsConfig := &tls.Config{ServerName: config.Host, MinVersion: tls.VersionTLS12}
+	if config.Security == models.EmailSecurityTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer c.Close()
+
+	if config.Security == models.EmailSecurityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if config.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		if err := c.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp sender: %w", err)
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("smtp recipient %s: %w", addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return c.Quit()
+}
+
+// emailContent fills the templates for v. Email has room for every stream
+// detail, so it includes them all rather than following the Discord fields.
+func emailContent(v *models.RuleViolation) emailData {
+	color := "#808080"
+	switch v.Severity {
+	case models.SeverityCritical:
+		color = "#dc2626"
+	case models.SeverityWarning:
+		color = "#f59e0b"
+	case models.SeverityInfo:
+		color = "#2563eb"
+	}
+	d := emailData{
+		Title:      fmt.Sprintf(tr(v.Language, "Rule Violation: %s"), v.RuleName),
+		Message:    v.Message,
+		Severity:   v.Severity,
+		Color:      color,
+		OccurredAt: v.OccurredAt.UTC().Format("2006-01-02 15:04 MST"),
+	}
+	if v.Event == models.NotificationEventConcurrentRecord || v.Event == models.NotificationEventWatchLimit ||
+		v.Event == models.NotificationEventDigest || v.Event == models.NotificationEventMilestone {
+		d.Title = v.RuleName
+	}
+	if v.RenderedTitle != "" {
+		d.Title = v.RenderedTitle
+	}
+	if v.RenderedMessage != "" {
+		d.Message = v.RenderedMessage
+	}
+	add := func(name, value string) {
+		if value != "" {
+			d.Fields = append(d.Fields, emailField{Name: name, Value: value})
+		}
+	}
+	if v.Event == models.NotificationEventDigest {
+		// The message carries the whole digest.
+		return d
+	}
+	lang := v.Language
+	add(tr(lang, "User"), v.UserName)
+	add(tr(lang, "Severity"), tr(lang, string(v.Severity)))
+	add(tr(lang, "Confidence"), fmt.Sprintf("%.0f%%", v.ConfidenceScore))
+	if s := v.Stream; s != nil {
+		add(tr(lang, "Title"), streamTitle(s))
+		player := s.Player
+		if s.Platform != "" && s.Platform != s.Player {
+			player = strings.TrimSpace(player + " (" + s.Platform + ")")
+		}
+		add(tr(lang, "Player"), player)
+		add(tr(lang, "Stream"), streamDecision(s))
+	}
+	add(tr(lang, "Location"), violationLocation(v))
+	return d
+}
+
+// buildEmail renders v as a multipart/alternative message with plain text
+// and HTML bodies.
+func buildEmail(from *mail.Address, to []*mail.Address, v *models.RuleViolation, now time.Time) ([]byte, error) {
+	data := emailContent(v)
+	var text, html bytes.Buffer
+	if err := emailTextTemplate.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("rendering text body: %w", err)
+	}
+	if err := emailHTMLTemplate.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("rendering html body: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+	// Rule names and messages are admin- and user-controlled; keep them
+	// from starting new header lines.
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(pushTitle(v))
+
+	var msg bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&msg, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+messageID()+"@streammon>")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func messageID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
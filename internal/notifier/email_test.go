@@ -0,0 +1,207 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// smtpMessage is one message a fakeSMTPServer accepted.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accepts plain, unauthenticated SMTP and reports each
+// message it receives.
+func fakeSMTPServer(t *testing.T) (host string, port int, msgs <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	out := make(chan smtpMessage, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSMTP(conn, out)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, out
+}
+
+func serveFakeSMTP(conn net.Conn, out chan<- smtpMessage) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+	reply("220 fake ESMTP")
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			msg.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			reply("250 ok")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			reply("250 ok")
+		case upper == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.data = data.String()
+			out <- msg
+			msg = smtpMessage{}
+			reply("250 queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestNotifier_SendEmail(t *testing.T) {
+	host, port, msgs := fakeSMTPServer(t)
+	cfg, _ := json.Marshal(models.EmailConfig{
+		Host:     host,
+		Port:     port,
+		Security: models.EmailSecurityNone,
+		From:     "StreamMon <streammon@example.com>",
+		To:       []string{"admin@example.com"},
+		EventRecipients: map[models.NotificationEvent][]string{
+			models.NotificationEventConcurrentRecord: {"household@example.com", "other@example.com"},
+		},
+	})
+	ch := models.NotificationChannel{Name: "Email", ChannelType: models.ChannelTypeEmail, Config: cfg}
+
+	n := newTestNotifier()
+	violation := &models.RuleViolation{
+		RuleName:        "Geo <Restriction>",
+		UserName:        "alice",
+		Severity:        models.SeverityCritical,
+		Message:         "Streaming from a blocked country",
+		ConfidenceScore: 90,
+		OccurredAt:      time.Now().UTC(),
+		Event:           models.NotificationEventRuleViolation,
+		Stream:          &models.ActiveStream{Title: "Movie", Year: 2020, IPAddress: "203.0.113.10"},
+		Geo:             &models.GeoResult{City: "Paris", Country: "France"},
+	}
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{ch}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	got := <-msgs
+	if got.from != "streammon@example.com" || len(got.to) != 1 || got.to[0] != "admin@example.com" {
+		t.Errorf("envelope from %q to %v", got.from, got.to)
+	}
+	m, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject := m.Header.Get("Subject"); subject != "StreamMon: Geo <Restriction>" {
+		t.Errorf("subject = %q", subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q", m.Header.Get("Content-Type"))
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(p)
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+	if !strings.Contains(parts["text/plain"], "Location: Paris, France (203.0.113.10)") {
+		t.Errorf("text body = %q", parts["text/plain"])
+	}
+	html := parts["text/html"]
+	if !strings.Contains(html, "Geo &lt;Restriction&gt;") || !strings.Contains(html, "Movie (2020)") {
+		t.Errorf("html body = %q", html)
+	}
+
+	// Concurrent stream records go to their own recipients.
+	violation.Event = models.NotificationEventConcurrentRecord
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{ch}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	got = <-msgs
+	if len(got.to) != 2 || got.to[0] != "household@example.com" {
+		t.Errorf("record recipients = %v", got.to)
+	}
+}
+
+func TestEmailConfig_Validate(t *testing.T) {
+	valid := func() models.EmailConfig {
+		return models.EmailConfig{Host: "smtp.example.com", From: "me@example.com", To: []string{"me@example.com"}}
+	}
+	c := valid()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Security != models.EmailSecurityStartTLS || c.Port != 587 {
+		t.Errorf("defaults = %s:%d", c.Security, c.Port)
+	}
+
+	for name, mutate := range map[string]func(*models.EmailConfig){
+		"no host":         func(c *models.EmailConfig) { c.Host = "" },
+		"url host":        func(c *models.EmailConfig) { c.Host = "smtp://smtp.example.com" },
+		"link-local host": func(c *models.EmailConfig) { c.Host = "169.254.169.254" },
+		"bad security":    func(c *models.EmailConfig) { c.Security = "ssl" },
+		"bad from":        func(c *models.EmailConfig) { c.From = "not an address" },
+		"no recipients":   func(c *models.EmailConfig) { c.To = nil },
+		"bad recipient":   func(c *models.EmailConfig) { c.To = []string{"nope"} },
+		"unknown event": func(c *models.EmailConfig) {
+			c.EventRecipients = map[models.NotificationEvent][]string{"bogus": {"a@example.com"}}
+		},
+		"password alone":    func(c *models.EmailConfig) { c.Password = "secret" },
+		"port out of range": func(c *models.EmailConfig) { c.Port = 70000 },
+	} {
+		c := valid()
+		mutate(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
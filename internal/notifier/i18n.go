@@ -0,0 +1,75 @@
+package notifier
+
+import "streammon/internal/models"
+
+// translations holds the built-in notification text in each non-English
+// language, keyed by the English text. Rule messages and names come from
+// the rules themselves; rules translate those with per-language templates.
+var translations = map[models.Language]map[string]string{
+	models.LanguageSpanish: {
+		"User":                   "Usuario",
+		"Severity":               "Gravedad",
+		"Confidence":             "Confianza",
+		"Title":                  "Título",
+		"Player":                 "Reproductor",
+		"Stream":                 "Transmisión",
+		"Location":               "Ubicación",
+		"Rule Violation: %s":     "Infracción de regla: %s",
+		"StreamMon Rules Engine": "Motor de reglas de StreamMon",
+		"critical":               "crítica",
+		"warning":                "advertencia",
+		"info":                   "información",
+	},
+	models.LanguageFrench: {
+		"User":                   "Utilisateur",
+		"Severity":               "Gravité",
+		"Confidence":             "Confiance",
+		"Title":                  "Titre",
+		"Player":                 "Lecteur",
+		"Stream":                 "Flux",
+		"Location":               "Emplacement",
+		"Rule Violation: %s":     "Infraction à la règle : %s",
+		"StreamMon Rules Engine": "Moteur de règles StreamMon",
+		"critical":               "critique",
+		"warning":                "avertissement",
+		"info":                   "information",
+	},
+	models.LanguageGerman: {
+		"User":                   "Benutzer",
+		"Severity":               "Schweregrad",
+		"Confidence":             "Konfidenz",
+		"Title":                  "Titel",
+		"Player":                 "Player",
+		"Stream":                 "Stream",
+		"Location":               "Standort",
+		"Rule Violation: %s":     "Regelverstoß: %s",
+		"StreamMon Rules Engine": "StreamMon-Regel-Engine",
+		"critical":               "kritisch",
+		"warning":                "Warnung",
+		"info":                   "Info",
+	},
+}
+
+// tr returns s in lang, or s itself when there's no translation.
+func tr(lang models.Language, s string) string {
+	if t, ok := translations[lang][s]; ok {
+		return t
+	}
+	return s
+}
+
+// localize returns v as sent to a channel in lang: the rule's translated
+// templates rendered in place of its own, and the built-in text marked for
+// translation.
+func localize(v *models.RuleViolation, lang models.Language) *models.RuleViolation {
+	if lang.IsEnglish() {
+		return v
+	}
+	l := *v
+	l.Language = lang
+	if _, ok := v.Notification.Translations[lang]; ok {
+		l.Notification = v.Notification.Translated(lang)
+		return renderTemplates(&l)
+	}
+	return &l
+}
@@ -6,18 +6,63 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"streammon/internal/httputil"
+	"streammon/internal/metrics"
 	"streammon/internal/models"
 )
 
+// pushoverMessagesURL is Pushover's message API; tests point it elsewhere.
+var pushoverMessagesURL = "https://api.pushover.net/1/messages.json"
+
 type Notifier struct {
-	client *http.Client
+	client      *http.Client
+	dial        func(ctx context.Context, network, addr string) (net.Conn, error)
+	posters     PosterFetcher
+	pushSubject string
+	history     HistoryRecorder
+	now         func() time.Time
+
+	cooldownMu sync.Mutex
+	cooldowns  map[cooldownKey]time.Time // when each rule and user's cooldown ends
+}
+
+// PosterFetcher loads the poster for a stream's thumb so it can be attached
+// to a notification. Media server image URLs need credentials, so the bytes
+// are uploaded instead of linking them.
+type PosterFetcher interface {
+	FetchPoster(ctx context.Context, serverID int64, thumb string) (data []byte, contentType string, err error)
+}
+
+type Option func(*Notifier)
+
+// WithPosterFetcher enables poster thumbnails on providers that support them.
+func WithPosterFetcher(f PosterFetcher) Option {
+	return func(n *Notifier) {
+		n.posters = f
+	}
+}
+
+// WithHistory records every notification sent, failed, or held back.
+func WithHistory(h HistoryRecorder) Option {
+	return func(n *Notifier) {
+		n.history = h
+	}
+}
+
+// WithPushSubject sets the contact push services are given for this
+// server's web push requests, a mailto: or https: URL.
+func WithPushSubject(subject string) Option {
+	return func(n *Notifier) {
+		n.pushSubject = subject
+	}
 }
 
 // New returns a Notifier that sends over httputil.NewSafeClient, which
@@ -25,96 +70,103 @@ type Notifier struct {
 // not auto-follow redirects. This is SSRF defense-in-depth: admin-configured
 // notification URLs are validated at config time (models.DiscordConfig,
 // models.NtfyConfig, models.WebhookConfig), but a hostname can still resolve
-// to an internal address at send time.
-func New() *Notifier {
-	return &Notifier{
+// to an internal address at send time. SMTP connections go through the same
+// checks via httputil.NewSafeDialer.
+func New(opts ...Option) *Notifier {
+	n := &Notifier{
 		client: httputil.NewSafeClient(httputil.IntegrationTimeout),
+		dial:   httputil.NewSafeDialer().DialContext,
+
+		pushSubject: defaultPushSubject,
+	}
+	for _, opt := range opts {
+		opt(n)
 	}
+	return n
 }
 
 func (n *Notifier) Notify(ctx context.Context, violation *models.RuleViolation, channels []models.NotificationChannel) error {
 	if len(channels) == 0 {
 		return nil
 	}
+	violation = renderTemplates(violation)
+
+	now := n.clock()
+	payload := n.payload(violation)
+	key, prev, claimed := n.claimCooldown(violation, now)
+	if !claimed {
+		for _, ch := range channels {
+			n.record(ctx, violation, ch, payload, models.NotificationSuppressed, models.SuppressedCooldown, nil)
+		}
+		return nil
+	}
 
 	// Send to all channels in parallel
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var errs []string
+	sent := false
 
 	for _, ch := range channels {
+		if ch.QuietHours.Silences(violation.Severity, now) {
+			n.record(ctx, violation, ch, payload, models.NotificationSuppressed, models.SuppressedQuietHours, nil)
+			continue
+		}
 		wg.Add(1)
 		go func(ch models.NotificationChannel) {
 			defer wg.Done()
 
-			var err error
-			switch ch.ChannelType {
-			case models.ChannelTypeDiscord:
-				err = n.sendDiscord(ctx, ch, violation)
-			case models.ChannelTypeWebhook:
-				err = n.sendWebhook(ctx, ch, violation)
-			case models.ChannelTypePushover:
-				err = n.sendPushover(ctx, ch, violation)
-			case models.ChannelTypeNtfy:
-				err = n.sendNtfy(ctx, ch, violation)
-			default:
-				err = fmt.Errorf("unknown channel type: %s", ch.ChannelType)
-			}
+			err := n.send(ctx, ch, violation)
 			if err != nil {
+				metrics.Notifications.Inc(string(ch.ChannelType), "failed")
+				n.record(ctx, violation, ch, payload, models.NotificationFailed, "", err)
 				mu.Lock()
 				errs = append(errs, fmt.Sprintf("%s: %v", ch.Name, err))
 				mu.Unlock()
+				return
 			}
+			metrics.Notifications.Inc(string(ch.ChannelType), "sent")
+			n.record(ctx, violation, ch, payload, models.NotificationSent, "", nil)
+			mu.Lock()
+			sent = true
+			mu.Unlock()
 		}(ch)
 	}
 
 	wg.Wait()
 
+	// Nothing reached the user, so the next violation shouldn't be held back.
+	if !sent {
+		n.releaseCooldown(key, prev)
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("notification errors: %s", strings.Join(errs, "; "))
 	}
 	return nil
 }
 
-func (n *Notifier) sendDiscord(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
-	var config models.DiscordConfig
-	if err := json.Unmarshal(ch.Config, &config); err != nil {
-		return fmt.Errorf("parsing config: %w", err)
+// send delivers v to one channel, in the channel's language.
+func (n *Notifier) send(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
+	v = localize(v, ch.Language)
+	switch ch.ChannelType {
+	case models.ChannelTypeDiscord:
+		return n.sendDiscord(ctx, ch, v)
+	case models.ChannelTypeWebhook:
+		return n.sendWebhook(ctx, ch, v)
+	case models.ChannelTypePushover:
+		return n.sendPushover(ctx, ch, v)
+	case models.ChannelTypeNtfy:
+		return n.sendNtfy(ctx, ch, v)
+	case models.ChannelTypeApprise:
+		return n.sendApprise(ctx, ch, v)
+	case models.ChannelTypeEmail:
+		return n.sendEmail(ctx, ch, v)
+	case models.ChannelTypeGotify:
+		return n.sendGotify(ctx, ch, v)
+	default:
+		return fmt.Errorf("unknown channel type: %s", ch.ChannelType)
 	}
-	if err := config.Validate(); err != nil {
-		return err
-	}
-
-	color := 0x808080
-	switch v.Severity {
-	case models.SeverityCritical:
-		color = 0xFF0000
-	case models.SeverityWarning:
-		color = 0xFFA500
-	case models.SeverityInfo:
-		color = 0x0000FF
-	}
-
-	payload := map[string]interface{}{
-		"embeds": []map[string]interface{}{
-			{
-				"title":       fmt.Sprintf("Rule Violation: %s", v.RuleName),
-				"description": v.Message,
-				"color":       color,
-				"fields": []map[string]interface{}{
-					{"name": "User", "value": v.UserName, "inline": true},
-					{"name": "Severity", "value": string(v.Severity), "inline": true},
-					{"name": "Confidence", "value": fmt.Sprintf("%.0f%%", v.ConfidenceScore), "inline": true},
-				},
-				"timestamp": v.OccurredAt.Format(time.RFC3339),
-				"footer": map[string]string{
-					"text": "StreamMon Rules Engine",
-				},
-			},
-		},
-	}
-
-	return n.postJSON(ctx, config.WebhookURL, payload)
 }
 
 func (n *Notifier) sendWebhook(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
@@ -126,8 +178,12 @@ func (n *Notifier) sendWebhook(ctx context.Context, ch models.NotificationChanne
 		return err
 	}
 
+	event := models.NotificationEventRuleViolation
+	if v.Event == models.NotificationEventDigest {
+		event = v.Event
+	}
 	payload := map[string]interface{}{
-		"event":            "rule_violation",
+		"event":            event,
 		"rule_id":          v.RuleID,
 		"rule_name":        v.RuleName,
 		"user_name":        v.UserName,
@@ -137,6 +193,12 @@ func (n *Notifier) sendWebhook(ctx context.Context, ch models.NotificationChanne
 		"details":          v.Details,
 		"occurred_at":      v.OccurredAt.Format(time.RFC3339),
 	}
+	if v.RenderedTitle != "" {
+		payload["title"] = v.RenderedTitle
+	}
+	if v.RenderedMessage != "" {
+		payload["text"] = v.RenderedMessage
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -174,23 +236,21 @@ func (n *Notifier) sendPushover(ctx context.Context, ch models.NotificationChann
 		return err
 	}
 
-	priority := "0"
-	switch v.Severity {
-	case models.SeverityCritical:
-		priority = "1"
-	case models.SeverityInfo:
-		priority = "-1"
-	}
-
 	form := url.Values{}
 	form.Set("token", config.APIToken)
 	form.Set("user", config.UserKey)
-	form.Set("title", fmt.Sprintf("StreamMon: %s", v.RuleName))
-	form.Set("message", fmt.Sprintf("%s\n\nUser: %s\nConfidence: %.0f%%", v.Message, v.UserName, v.ConfidenceScore))
-	form.Set("priority", priority)
+	form.Set("title", pushTitle(v))
+	form.Set("message", pushMessage(v))
+	form.Set("priority", strconv.Itoa(config.Priority(v.Severity)))
 	form.Set("timestamp", fmt.Sprintf("%d", v.OccurredAt.Unix()))
+	if config.Device != "" {
+		form.Set("device", config.Device)
+	}
+	if config.Sound != "" {
+		form.Set("sound", config.Sound)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.pushover.net/1/messages.json",
+	req, err := http.NewRequestWithContext(ctx, "POST", pushoverMessagesURL,
 		strings.NewReader(form.Encode()))
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
@@ -231,13 +291,11 @@ func (n *Notifier) sendNtfy(ctx context.Context, ch models.NotificationChannel,
 		priority = "low"
 	}
 
-	message := fmt.Sprintf("%s\n\nUser: %s\nConfidence: %.0f%%", v.Message, v.UserName, v.ConfidenceScore)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", ntfyURL, strings.NewReader(message))
+	req, err := http.NewRequestWithContext(ctx, "POST", ntfyURL, strings.NewReader(pushMessage(v)))
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
-	req.Header.Set("Title", fmt.Sprintf("StreamMon: %s", v.RuleName))
+	req.Header.Set("Title", pushTitle(v))
 	req.Header.Set("Priority", priority)
 	req.Header.Set("Tags", string(v.Severity))
 
@@ -258,6 +316,81 @@ func (n *Notifier) sendNtfy(ctx context.Context, ch models.NotificationChannel,
 	return nil
 }
 
+func (n *Notifier) sendGotify(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
+	var config models.GotifyConfig
+	if err := json.Unmarshal(ch.Config, &config); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    pushTitle(v),
+		"message":  pushMessage(v),
+		"priority": config.Priority(v.Severity),
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(config.ServerURL, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", config.Token)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("gotify returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendApprise posts to an Apprise API server, which fans the notification
+// out to whatever services its URLs (or stored config) name.
+func (n *Notifier) sendApprise(ctx context.Context, ch models.NotificationChannel, v *models.RuleViolation) error {
+	var config models.AppriseConfig
+	if err := json.Unmarshal(ch.Config, &config); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	notifyType := "info"
+	switch v.Severity {
+	case models.SeverityCritical:
+		notifyType = "failure"
+	case models.SeverityWarning:
+		notifyType = "warning"
+	}
+
+	payload := map[string]string{
+		"title": pushTitle(v),
+		"body":  pushMessage(v),
+		"type":  notifyType,
+	}
+	if config.Key == "" {
+		payload["urls"] = strings.Join(config.URLs, ",")
+	}
+	if config.Tag != "" {
+		payload["tag"] = config.Tag
+	}
+
+	if err := n.postJSON(ctx, config.NotifyURL(), payload); err != nil {
+		return fmt.Errorf("apprise: %w", err)
+	}
+	return nil
+}
+
 func (n *Notifier) postJSON(ctx context.Context, url string, payload interface{}) error {
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -283,7 +416,9 @@ func (n *Notifier) postJSON(ctx context.Context, url string, payload interface{}
 	return nil
 }
 
-func (n *Notifier) TestChannel(ctx context.Context, ch *models.NotificationChannel) error {
+// TestChannel sends a sample violation to ch. The sample carries a stream and
+// location so rich providers show every field opts enables.
+func (n *Notifier) TestChannel(ctx context.Context, ch *models.NotificationChannel, opts models.RuleNotification) error {
 	testViolation := &models.RuleViolation{
 		RuleID:          0,
 		RuleName:        "Test Rule",
@@ -292,7 +427,21 @@ func (n *Notifier) TestChannel(ctx context.Context, ch *models.NotificationChann
 		Message:         "This is a test notification from StreamMon",
 		ConfidenceScore: 100,
 		OccurredAt:      time.Now().UTC(),
+		Stream: &models.ActiveStream{
+			Title:                    "Sample Movie",
+			Year:                     2024,
+			MediaType:                models.MediaTypeMovie,
+			Player:                   "Living Room TV",
+			Platform:                 "Android TV",
+			IPAddress:                "203.0.113.10",
+			VideoDecision:            models.TranscodeDecisionTranscode,
+			VideoResolution:          "4K",
+			TranscodeVideoResolution: "1080p",
+		},
+		Geo:          &models.GeoResult{City: "Amsterdam", Country: "Netherlands"},
+		Notification: opts,
 	}
 
-	return n.Notify(ctx, testViolation, []models.NotificationChannel{*ch})
+	// A test bypasses quiet hours and cooldowns, and isn't history.
+	return n.send(ctx, *ch, renderTemplates(testViolation))
 }
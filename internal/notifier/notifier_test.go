@@ -4,20 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"streammon/internal/models"
 )
 
-// newTestNotifier builds a Notifier with a plain (unguarded) HTTP client so
-// tests can talk to httptest servers on 127.0.0.1. Production code must use
+// newTestNotifier builds a Notifier with a plain (unguarded) HTTP client and
+// dialer so tests can talk to servers on 127.0.0.1. Production code must use
 // New(), whose client refuses connections to loopback/private/link-local
 // resolved IPs (see httputil.NewSafeClient) as SSRF defense-in-depth.
 func newTestNotifier() *Notifier {
-	return &Notifier{client: &http.Client{Timeout: 5 * time.Second}}
+	return &Notifier{
+		client: &http.Client{Timeout: 5 * time.Second},
+		dial:   (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+	}
 }
 
 func TestNotifier_SendDiscord(t *testing.T) {
@@ -168,6 +173,246 @@ func TestNotifier_SendNtfy(t *testing.T) {
 	}
 }
 
+func TestNotifier_SendGotify(t *testing.T) {
+	var receivedPath, receivedKey string
+	var receivedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedKey = r.Header.Get("X-Gotify-Key")
+		json.NewDecoder(r.Body).Decode(&receivedBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier()
+	channel := models.NotificationChannel{
+		Name:        "Test Gotify",
+		ChannelType: models.ChannelTypeGotify,
+		Config:      json.RawMessage(`{"server_url":"` + server.URL + `/gotify/","token":"apptoken","priorities":{"warning":6}}`),
+		Enabled:     true,
+	}
+	violation := &models.RuleViolation{
+		RuleName:   "Test Rule",
+		UserName:   "testuser",
+		Severity:   models.SeverityWarning,
+		Message:    "Warning violation",
+		OccurredAt: time.Now().UTC(),
+	}
+
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{channel}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if receivedPath != "/gotify/message" {
+		t.Errorf("path = %q, want /gotify/message", receivedPath)
+	}
+	if receivedKey != "apptoken" {
+		t.Errorf("X-Gotify-Key = %q, want apptoken", receivedKey)
+	}
+	if receivedBody["title"] != "StreamMon: Test Rule" {
+		t.Errorf("title = %v", receivedBody["title"])
+	}
+	if receivedBody["priority"] != float64(6) {
+		t.Errorf("priority = %v, want 6", receivedBody["priority"])
+	}
+}
+
+func TestNotifier_Templates(t *testing.T) {
+	var receivedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	channel := models.NotificationChannel{
+		Name:        "Test Gotify",
+		ChannelType: models.ChannelTypeGotify,
+		Config:      json.RawMessage(`{"server_url":"` + server.URL + `","token":"apptoken"}`),
+	}
+	violation := &models.RuleViolation{
+		RuleName: "Geo",
+		UserName: "alice",
+		Severity: models.SeverityWarning,
+		Message:  "Streaming from a blocked country",
+		Stream:   &models.ActiveStream{Title: "Movie", Year: 2020, Player: "TV", VideoDecision: models.TranscodeDecisionTranscode},
+		Geo:      &models.GeoResult{City: "Paris", Country: "France"},
+		Notification: models.RuleNotification{
+			TitleTemplate:   "{{.User}} in {{.City}}",
+			MessageTemplate: "{{.Title}} on {{.Player}} ({{.TranscodeDecision}})",
+		},
+	}
+
+	n := newTestNotifier()
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{channel}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if receivedBody["title"] != "alice in Paris" || receivedBody["message"] != "Movie (2020) on TV (transcode)" {
+		t.Errorf("body = %v", receivedBody)
+	}
+	if violation.RenderedTitle != "" {
+		t.Error("Notify mutated the caller's violation")
+	}
+
+	// A template that renders empty for this violation falls back to the
+	// default text.
+	violation.Geo = nil
+	violation.Notification = models.RuleNotification{TitleTemplate: "{{.City}}"}
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{channel}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if receivedBody["title"] != "StreamMon: Geo" {
+		t.Errorf("fallback title = %v", receivedBody["title"])
+	}
+}
+
+func TestNotifier_ChannelLanguage(t *testing.T) {
+	var receivedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	english := models.NotificationChannel{
+		Name:        "English",
+		ChannelType: models.ChannelTypeGotify,
+		Config:      json.RawMessage(`{"server_url":"` + server.URL + `","token":"apptoken"}`),
+	}
+	spanish := english
+	spanish.Name = "Spanish"
+	spanish.Language = models.LanguageSpanish
+	violation := &models.RuleViolation{
+		RuleName:        "Geo",
+		UserName:        "alice",
+		Severity:        models.SeverityWarning,
+		Message:         "Streaming from a blocked country",
+		ConfidenceScore: 80,
+		Geo:             &models.GeoResult{City: "Paris", Country: "France"},
+	}
+
+	n := newTestNotifier()
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{spanish}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if want := "Streaming from a blocked country\n\nUsuario: alice\nConfianza: 80%"; receivedBody["message"] != want {
+		t.Errorf("message = %q, want %q", receivedBody["message"], want)
+	}
+
+	// A rule's translation replaces its templates on channels in that
+	// language; an unset translated template keeps the rule's own.
+	violation.Notification = models.RuleNotification{
+		TitleTemplate:   "{{.User}} in {{.City}}",
+		MessageTemplate: "{{.Message}}",
+		Translations: map[models.Language]models.NotificationTranslation{
+			models.LanguageSpanish: {TitleTemplate: "{{.User}} en {{.City}}"},
+		},
+	}
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{spanish}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if receivedBody["title"] != "alice en Paris" || receivedBody["message"] != "Streaming from a blocked country" {
+		t.Errorf("spanish body = %v", receivedBody)
+	}
+	if err := n.send(context.Background(), english, renderTemplates(violation)); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if receivedBody["title"] != "alice in Paris" {
+		t.Errorf("english title = %v", receivedBody["title"])
+	}
+}
+
+func TestNotifier_SendPushover(t *testing.T) {
+	var received url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received = r.PostForm
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	orig := pushoverMessagesURL
+	pushoverMessagesURL = server.URL
+	t.Cleanup(func() { pushoverMessagesURL = orig })
+
+	n := newTestNotifier()
+	channel := models.NotificationChannel{
+		Name:        "Test Pushover",
+		ChannelType: models.ChannelTypePushover,
+		Config:      json.RawMessage(`{"user_key":"user","api_token":"token","sound":"siren"}`),
+		Enabled:     true,
+	}
+	for severity, want := range map[models.Severity]string{
+		models.SeverityCritical: "1",
+		models.SeverityWarning:  "0",
+		models.SeverityInfo:     "-1",
+	} {
+		violation := &models.RuleViolation{RuleName: "Test Rule", Severity: severity, OccurredAt: time.Now().UTC()}
+		if err := n.Notify(context.Background(), violation, []models.NotificationChannel{channel}); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+		if got := received.Get("priority"); got != want {
+			t.Errorf("%s priority = %q, want %q", severity, got, want)
+		}
+		if received.Get("sound") != "siren" || received.Get("token") != "token" {
+			t.Errorf("form = %v", received)
+		}
+	}
+}
+
+func TestNotifier_SendApprise(t *testing.T) {
+	var receivedPath string
+	var receivedBody map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &receivedBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier()
+	violation := &models.RuleViolation{
+		RuleName:   "Test Rule",
+		UserName:   "testuser",
+		Severity:   models.SeverityWarning,
+		Message:    "Warning violation",
+		OccurredAt: time.Now().UTC(),
+	}
+
+	stateless := models.NotificationChannel{
+		Name:        "Apprise",
+		ChannelType: models.ChannelTypeApprise,
+		Config:      json.RawMessage(`{"server_url":"` + server.URL + `","urls":["tgram://bot/chat","mailto://u:p@example.com"],"tag":"ops"}`),
+	}
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{stateless}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if receivedPath != "/notify" {
+		t.Errorf("path = %q, want /notify", receivedPath)
+	}
+	if receivedBody["urls"] != "tgram://bot/chat,mailto://u:p@example.com" || receivedBody["type"] != "warning" || receivedBody["tag"] != "ops" {
+		t.Errorf("body = %v", receivedBody)
+	}
+	if receivedBody["title"] != "StreamMon: Test Rule" {
+		t.Errorf("title = %q", receivedBody["title"])
+	}
+
+	stateful := models.NotificationChannel{
+		Name:        "Apprise key",
+		ChannelType: models.ChannelTypeApprise,
+		Config:      json.RawMessage(`{"server_url":"` + server.URL + `","key":"streammon"}`),
+	}
+	receivedBody = nil
+	if err := n.Notify(context.Background(), violation, []models.NotificationChannel{stateful}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if receivedPath != "/notify/streammon" {
+		t.Errorf("path = %q, want /notify/streammon", receivedPath)
+	}
+	if _, ok := receivedBody["urls"]; ok {
+		t.Error("stateful notify should not send urls")
+	}
+}
+
 func TestNotifier_MultipleChannels(t *testing.T) {
 	callCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -288,7 +533,7 @@ func TestNotifier_TestChannel(t *testing.T) {
 		Config:      json.RawMessage(`{"webhook_url":"` + server.URL + `"}`),
 	}
 
-	err := n.TestChannel(ctx, channel)
+	err := n.TestChannel(ctx, channel, models.RuleNotification{})
 	if err != nil {
 		t.Fatalf("TestChannel: %v", err)
 	}
@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+// renderTemplates returns v with its rule's notification templates filled
+// in, or v itself when the rule has none. A template that fails on this
+// violation's data falls back to the default text rather than dropping the
+// notification.
+func renderTemplates(v *models.RuleViolation) *models.RuleViolation {
+	if !v.Notification.HasTemplates() {
+		return v
+	}
+	title, message, err := v.Notification.RenderTemplates(templateData(v))
+	if err != nil {
+		log.Printf("notifier: rule %d templates: %v; using default text", v.RuleID, err)
+		return v
+	}
+	rendered := *v
+	rendered.RenderedTitle = title
+	rendered.RenderedMessage = message
+	return &rendered
+}
+
+func templateData(v *models.RuleViolation) models.NotificationTemplateData {
+	d := models.NotificationTemplateData{
+		Rule:       v.RuleName,
+		User:       v.UserName,
+		Severity:   v.Severity,
+		Message:    v.Message,
+		Confidence: v.ConfidenceScore,
+		OccurredAt: v.OccurredAt,
+	}
+	if s := v.Stream; s != nil {
+		d.Title = streamTitle(s)
+		d.MediaType = s.MediaType
+		d.Year = s.Year
+		d.Server = s.ServerName
+		d.Player = s.Player
+		d.Platform = s.Platform
+		d.IPAddress = s.IPAddress
+		d.TranscodeDecision = s.VideoDecision
+		if d.TranscodeDecision == "" {
+			d.TranscodeDecision = s.AudioDecision
+		}
+		d.VideoResolution = s.VideoResolution
+	}
+	if g := v.Geo; g != nil {
+		d.City = g.City
+		d.Country = g.Country
+		var place []string
+		for _, p := range []string{g.City, g.Country} {
+			if p != "" {
+				place = append(place, p)
+			}
+		}
+		d.Location = strings.Join(place, ", ")
+	}
+	return d
+}
+
+// pushTitle is the title text-only providers show.
+func pushTitle(v *models.RuleViolation) string {
+	if v.RenderedTitle != "" {
+		return v.RenderedTitle
+	}
+	return fmt.Sprintf("StreamMon: %s", v.RuleName)
+}
+
+// pushMessage is the body text-only providers show.
+func pushMessage(v *models.RuleViolation) string {
+	if v.RenderedMessage != "" {
+		return v.RenderedMessage
+	}
+	return fmt.Sprintf("%s\n\n%s: %s\n%s: %.0f%%", v.Message, tr(v.Language, "User"), v.UserName, tr(v.Language, "Confidence"), v.ConfidenceScore)
+}
@@ -0,0 +1,163 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"streammon/internal/metrics"
+	"streammon/internal/models"
+)
+
+// HistoryRecorder stores the outcome of each notification.
+type HistoryRecorder interface {
+	RecordNotification(ctx context.Context, rec *models.NotificationRecord) error
+}
+
+type cooldownKey struct {
+	ruleID   int64
+	userName string
+}
+
+// maxCooldowns is how many cooldowns are tracked before expired ones are
+// swept out.
+const maxCooldowns = 1024
+
+func (n *Notifier) clock() time.Time {
+	if n.now != nil {
+		return n.now()
+	}
+	return time.Now()
+}
+
+// claimCooldown starts v's rule and user cooldown at now, unless one is
+// already running, in which case claimed is false and v should be held
+// back. prev is the cooldown's earlier end, for releaseCooldown.
+func (n *Notifier) claimCooldown(v *models.RuleViolation, now time.Time) (key cooldownKey, prev time.Time, claimed bool) {
+	minutes := v.Notification.CooldownMinutes
+	if v.RuleID == 0 || minutes <= 0 {
+		return key, prev, true
+	}
+	key = cooldownKey{ruleID: v.RuleID, userName: strings.ToLower(v.UserName)}
+
+	n.cooldownMu.Lock()
+	defer n.cooldownMu.Unlock()
+	if n.cooldowns == nil {
+		n.cooldowns = make(map[cooldownKey]time.Time)
+	}
+	prev = n.cooldowns[key]
+	if now.Before(prev) {
+		return key, prev, false
+	}
+	if len(n.cooldowns) >= maxCooldowns {
+		for k, until := range n.cooldowns {
+			if !now.Before(until) {
+				delete(n.cooldowns, k)
+			}
+		}
+	}
+	n.cooldowns[key] = now.Add(time.Duration(minutes) * time.Minute)
+	return key, prev, true
+}
+
+// releaseCooldown undoes a claim when nothing was delivered, restoring the
+// cooldown's earlier end.
+func (n *Notifier) releaseCooldown(key cooldownKey, prev time.Time) {
+	if key.ruleID == 0 {
+		return
+	}
+	n.cooldownMu.Lock()
+	defer n.cooldownMu.Unlock()
+	if prev.IsZero() {
+		delete(n.cooldowns, key)
+	} else {
+		n.cooldowns[key] = prev
+	}
+}
+
+// payload snapshots v for the history, or nil when there's no history.
+func (n *Notifier) payload(v *models.RuleViolation) json.RawMessage {
+	if n.history == nil {
+		return nil
+	}
+	b, err := json.Marshal(models.NewNotificationPayload(v))
+	if err != nil {
+		log.Printf("notifier: encoding notification payload: %v", err)
+		return nil
+	}
+	return b
+}
+
+func (n *Notifier) record(ctx context.Context, v *models.RuleViolation, ch models.NotificationChannel, payload json.RawMessage, status models.NotificationStatus, reason string, sendErr error) {
+	if n.history == nil {
+		return
+	}
+	if err := n.save(ctx, newRecord(v, ch, payload, status, reason, sendErr)); err != nil {
+		log.Printf("notifier: recording notification history: %v", err)
+	}
+}
+
+func newRecord(v *models.RuleViolation, ch models.NotificationChannel, payload json.RawMessage, status models.NotificationStatus, reason string, sendErr error) *models.NotificationRecord {
+	rec := &models.NotificationRecord{
+		RuleID:      v.RuleID,
+		RuleName:    v.RuleName,
+		ChannelID:   ch.ID,
+		ChannelName: ch.Name,
+		ChannelType: ch.ChannelType,
+		Event:       v.Event,
+		UserName:    v.UserName,
+		Status:      status,
+		Reason:      reason,
+		Payload:     payload,
+	}
+	if rec.Event == "" {
+		rec.Event = models.NotificationEventRuleViolation
+	}
+	if sendErr != nil {
+		rec.Error = sendErr.Error()
+	}
+	return rec
+}
+
+func (n *Notifier) save(ctx context.Context, rec *models.NotificationRecord) error {
+	// A cancelled send is still worth recording.
+	return n.history.RecordNotification(context.WithoutCancel(ctx), rec)
+}
+
+// Redeliver sends a history entry's payload to ch again, as it is
+// configured now, and records the attempt as a retry of rec. Quiet hours
+// and cooldowns don't apply to a redelivery someone asked for.
+func (n *Notifier) Redeliver(ctx context.Context, rec *models.NotificationRecord, ch models.NotificationChannel) (*models.NotificationRecord, error) {
+	if n.history == nil {
+		return nil, errors.New("notification history is not enabled")
+	}
+	if len(rec.Payload) == 0 {
+		return nil, ErrNoPayload
+	}
+	var p models.NotificationPayload
+	if err := json.Unmarshal(rec.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+	v := p.RuleViolation()
+
+	status := models.NotificationSent
+	err := n.send(ctx, ch, v)
+	if err != nil {
+		status = models.NotificationFailed
+	}
+	metrics.Notifications.Inc(string(ch.ChannelType), string(status))
+	retry := newRecord(v, ch, rec.Payload, status, "", err)
+	retry.RetryOf = rec.ID
+	if err := n.save(ctx, retry); err != nil {
+		return nil, fmt.Errorf("recording redelivery: %w", err)
+	}
+	return retry, nil
+}
+
+// ErrNoPayload means a history entry was recorded without what it was built
+// from, so it can't be sent again.
+var ErrNoPayload = errors.New("notification has no stored payload")
@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type fakeHistory struct {
+	mu      sync.Mutex
+	records []models.NotificationRecord
+}
+
+func (f *fakeHistory) RecordNotification(ctx context.Context, rec *models.NotificationRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, *rec)
+	return nil
+}
+
+func (f *fakeHistory) statuses() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, r := range f.records {
+		s := string(r.Status)
+		if r.Reason != "" {
+			s += ":" + r.Reason
+		}
+		out = append(out, r.ChannelName+"="+s)
+	}
+	return out
+}
+
+func TestNotify_QuietHoursAndCooldown(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	history := &fakeHistory{}
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	n := newTestNotifier()
+	n.history = history
+	n.now = func() time.Time { return now }
+
+	config := json.RawMessage(`{"url":"` + server.URL + `"}`)
+	day := models.NotificationChannel{ID: 1, Name: "Day", ChannelType: models.ChannelTypeWebhook, Config: config,
+		QuietHours: &models.QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}}
+	always := models.NotificationChannel{ID: 2, Name: "Always", ChannelType: models.ChannelTypeWebhook, Config: config}
+	channels := []models.NotificationChannel{day, always}
+
+	violation := func(user string, severity models.Severity) *models.RuleViolation {
+		return &models.RuleViolation{
+			RuleID: 7, RuleName: "Rule", UserName: user, Severity: severity, Message: "m",
+			Notification: models.RuleNotification{CooldownMinutes: 30},
+		}
+	}
+
+	// Quiet hours hold back one channel; the other still delivers.
+	if err := n.Notify(context.Background(), violation("alice", models.SeverityWarning), channels); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("hits = %d, want 1", hits.Load())
+	}
+
+	// Within the cooldown, the same rule and user (in any case) is held back
+	// everywhere, while another user isn't.
+	now = now.Add(10 * time.Minute)
+	if err := n.Notify(context.Background(), violation("Alice", models.SeverityWarning), channels); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), violation("bob", models.SeverityWarning), channels); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("hits = %d, want 2", hits.Load())
+	}
+
+	// Once quiet hours and the cooldown are over, both channels deliver.
+	now = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	if err := n.Notify(context.Background(), violation("alice", models.SeverityWarning), channels); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 4 {
+		t.Fatalf("hits = %d, want 4", hits.Load())
+	}
+
+	got := history.statuses()
+	want := map[string]int{
+		"Day=suppressed:quiet_hours": 2,
+		"Always=sent":                3,
+		"Day=suppressed:cooldown":    1,
+		"Always=suppressed:cooldown": 1,
+		"Day=sent":                   1,
+	}
+	counts := map[string]int{}
+	for _, s := range got {
+		counts[s]++
+	}
+	for k, v := range want {
+		if counts[k] != v {
+			t.Errorf("%s recorded %d times, want %d (history %v)", k, counts[k], v, got)
+		}
+	}
+}
+
+func TestNotify_CooldownReleasedWhenNothingSent(t *testing.T) {
+	n := newTestNotifier()
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	quiet := models.NotificationChannel{Name: "Quiet", ChannelType: models.ChannelTypeWebhook,
+		Config:     json.RawMessage(`{"url":"http://127.0.0.1:1"}`),
+		QuietHours: &models.QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}}
+	v := &models.RuleViolation{RuleID: 1, UserName: "alice", Severity: models.SeverityInfo,
+		Notification: models.RuleNotification{CooldownMinutes: 60}}
+
+	if err := n.Notify(context.Background(), v, []models.NotificationChannel{quiet}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, claimed := n.claimCooldown(v, now.Add(time.Minute)); !claimed {
+		t.Error("a violation that reached no channel should not start the cooldown")
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	var bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body strings.Builder
+		io.Copy(&body, r.Body)
+		mu.Lock()
+		bodies = append(bodies, body.String())
+		mu.Unlock()
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	history := &fakeHistory{}
+	n := newTestNotifier()
+	n.history = history
+	ch := models.NotificationChannel{ID: 4, Name: "Hook", ChannelType: models.ChannelTypeWebhook,
+		Config: json.RawMessage(`{"url":"` + server.URL + `"}`)}
+	v := &models.RuleViolation{RuleID: 2, RuleName: "Rule", UserName: "alice", Severity: models.SeverityWarning,
+		Message: "too many streams", Notification: models.RuleNotification{CooldownMinutes: 60}}
+
+	n.Notify(context.Background(), v, []models.NotificationChannel{ch})
+	if len(history.records) != 1 || history.records[0].Status != models.NotificationFailed {
+		t.Fatalf("history = %+v", history.records)
+	}
+	failed := history.records[0]
+	failed.ID = 11
+	if len(failed.Payload) == 0 {
+		t.Fatal("no payload stored")
+	}
+
+	// The cooldown started by the first send doesn't hold back a retry.
+	fail.Store(false)
+	retry, err := n.Redeliver(context.Background(), &failed, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry.Status != models.NotificationSent || retry.RetryOf != 11 || retry.UserName != "alice" {
+		t.Errorf("retry = %+v", retry)
+	}
+	if len(history.records) != 2 || history.records[1].RetryOf != 11 {
+		t.Errorf("retry not recorded: %+v", history.records)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || !strings.Contains(bodies[1], "too many streams") {
+		t.Errorf("bodies = %q", bodies)
+	}
+
+	if _, err := n.Redeliver(context.Background(), &models.NotificationRecord{ID: 12}, ch); !errors.Is(err, ErrNoPayload) {
+		t.Errorf("no payload: err = %v", err)
+	}
+}
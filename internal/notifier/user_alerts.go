@@ -0,0 +1,128 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"streammon/internal/metrics"
+	"streammon/internal/models"
+	"streammon/internal/webpush"
+)
+
+// defaultPushSubject is used when no contact is configured. Push services
+// accept it, though some use the contact to reach operators of misbehaving
+// senders.
+const defaultPushSubject = "mailto:streammon@localhost"
+
+// pushTTL is how long a push service holds an alert for a browser that's
+// offline. Account activity older than a day is better read in the app.
+const pushTTL = 24 * time.Hour
+
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Tag   string `json:"tag"`
+	URL   string `json:"url"`
+}
+
+// NotifyUser tells a user about activity on their own account, by email
+// and on each browser they registered for push. It returns the push
+// subscriptions the push service reported gone, for the caller to forget.
+func (n *Notifier) NotifyUser(ctx context.Context, v *models.RuleViolation, alert models.UserAlert) ([]models.PushSubscription, error) {
+	var errs []error
+	if alert.SMTP != nil && alert.Email != "" {
+		if err := n.sendUserEmail(ctx, v, alert); err != nil {
+			metrics.Notifications.Inc("user_email", "failed")
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		} else {
+			metrics.Notifications.Inc("user_email", "sent")
+		}
+	}
+
+	var expired []models.PushSubscription
+	if len(alert.Push) > 0 {
+		payload, err := userPushPayload(v, alert.Event)
+		if err != nil {
+			return nil, err
+		}
+		msg := webpush.Message{Payload: payload, Subject: n.pushSubject, TTL: pushTTL}
+		for _, sub := range alert.Push {
+			err := webpush.Send(ctx, n.client, alert.VAPIDKeys, sub, msg)
+			switch {
+			case errors.Is(err, webpush.ErrGone):
+				expired = append(expired, sub)
+			case err != nil:
+				metrics.Notifications.Inc("web_push", "failed")
+				errs = append(errs, fmt.Errorf("push: %w", err))
+			default:
+				metrics.Notifications.Inc("web_push", "sent")
+			}
+		}
+	}
+	return expired, errors.Join(errs...)
+}
+
+func (n *Notifier) sendUserEmail(ctx context.Context, v *models.RuleViolation, alert models.UserAlert) error {
+	from, err := mail.ParseAddress(alert.SMTP.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := mail.ParseAddress(alert.Email)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q", alert.Email)
+	}
+	msg, err := buildEmail(from, []*mail.Address{to}, userAlertViolation(v, alert.Event), time.Now())
+	if err != nil {
+		return err
+	}
+	return n.sendSMTP(ctx, *alert.SMTP, from, []*mail.Address{to}, msg)
+}
+
+// userAlertViolation rewords v for the account's owner: the title names the
+// event rather than the admin's rule, and admin templates aren't applied.
+func userAlertViolation(v *models.RuleViolation, event models.UserAlertEvent) *models.RuleViolation {
+	out := *v
+	out.RenderedTitle = event.Title()
+	out.RenderedMessage = v.Message
+	return &out
+}
+
+// userPushPayload encodes the JSON the service worker shows, shortening the
+// body until it fits in one push message.
+func userPushPayload(v *models.RuleViolation, event models.UserAlertEvent) ([]byte, error) {
+	var body []string
+	if v.Message != "" {
+		body = append(body, v.Message)
+	}
+	if loc := violationLocation(v); loc != "" {
+		body = append(body, loc)
+	}
+	p := pushPayload{
+		Title: event.Title(),
+		Body:  strings.Join(body, "\n"),
+		Tag:   "streammon-" + string(event),
+		URL:   "/",
+	}
+	for {
+		payload, err := json.Marshal(p)
+		if err != nil || len(payload) <= webpush.MaxPayload {
+			return payload, err
+		}
+		p.Body = truncateUTF8(p.Body, len(p.Body)/2)
+	}
+}
+
+// truncateUTF8 cuts s to at most max bytes without splitting a character.
+func truncateUTF8(s string, max int) string {
+	for len(s) > max {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return s
+}
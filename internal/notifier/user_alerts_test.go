@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"streammon/internal/models"
+	"streammon/internal/webpush"
+)
+
+func TestNotifyUser_Push(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("Content-Encoding") != "aes128gcm" {
+			t.Errorf("content-encoding = %q", r.Header.Get("Content-Encoding"))
+		}
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	keys, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := func(id int64, path string) models.PushSubscription {
+		browser, err := ecdh.P256().GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return models.PushSubscription{
+			ID:       id,
+			Endpoint: ts.URL + path,
+			P256dh:   base64.RawURLEncoding.EncodeToString(browser.PublicKey().Bytes()),
+			Auth:     base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+		}
+	}
+
+	n := newTestNotifier()
+	n.client = ts.Client()
+	v := &models.RuleViolation{UserName: "alice", RuleName: "Admin-only rule name", Message: "New device: Chrome"}
+	expired, err := n.NotifyUser(context.Background(), v, models.UserAlert{
+		Event:     models.UserAlertNewDevice,
+		Push:      []models.PushSubscription{sub(1, "/ok"), sub(2, "/gone")},
+		VAPIDKeys: keys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("push requests = %d, want 2", hits.Load())
+	}
+	if len(expired) != 1 || expired[0].ID != 2 {
+		t.Errorf("expired = %+v", expired)
+	}
+}
+
+func TestUserPushPayload_FitsOneMessage(t *testing.T) {
+	v := &models.RuleViolation{RuleName: "Admin rule", Message: strings.Repeat("<é>", 4000)}
+	payload, err := userPushPayload(v, models.UserAlertNewLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) > webpush.MaxPayload {
+		t.Errorf("payload is %d bytes", len(payload))
+	}
+	if strings.Contains(string(payload), "Admin") {
+		t.Error("payload should not name the admin's rule")
+	}
+}
@@ -36,6 +36,11 @@ func NewClient(baseURL, apiKey string) (*Client, error) {
 	}, nil
 }
 
+// Configure applies per-integration proxy, TLS, and timeout settings.
+func (c *Client) Configure(opts httputil.ClientOptions) error {
+	return opts.Apply(c.http)
+}
+
 func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader) (json.RawMessage, error) {
 	return c.doWithOpts(ctx, method, path, query, body, true)
 }
@@ -228,8 +233,9 @@ func (c *Client) CreateRequestAsUser(ctx context.Context, plexToken string, reqB
 		return nil, fmt.Errorf("creating cookie jar: %w", err)
 	}
 	userClient := &http.Client{
-		Timeout: httputil.IntegrationTimeout,
-		Jar:     jar,
+		Timeout:   c.http.Timeout,
+		Transport: c.http.Transport,
+		Jar:       jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"streammon/internal/media"
+	"streammon/internal/metrics"
 	"streammon/internal/models"
 	"streammon/internal/store"
 )
@@ -26,6 +27,14 @@ type RuleEvaluator interface {
 	EvaluateSessions(ctx context.Context, streams []models.ActiveStream)
 }
 
+// SessionObserver hears about sessions as the poller starts and stops
+// tracking them. Calls are made from the polling goroutine and must not
+// block.
+type SessionObserver interface {
+	SessionStarted(s models.ActiveStream)
+	SessionEnded(s models.ActiveStream)
+}
+
 type Poller struct {
 	store    *store.Store
 	interval time.Duration
@@ -43,7 +52,7 @@ type Poller struct {
 	done      chan struct{}
 
 	wsCancel    map[int64]context.CancelFunc
-	triggerPoll chan struct{} // nil unless externally triggered; nil channel blocks forever in select
+	triggerPoll chan struct{} // buffered (size 1) so RequestPoll coalesces
 	pollNotify  chan struct{} // nil unless set by tests; guarded by nil check before send
 
 	rulesEngine RuleEvaluator
@@ -55,10 +64,34 @@ type Poller struct {
 	retryMu    sync.Mutex
 	retryQueue []retryEntry
 
+	// Servers delivering playback webhooks are only polled to reconcile
+	// (see pollDue). webhookSeen holds each server's last delivery,
+	// webhookDirty marks servers whose webhook named an untracked session.
+	webhookMu    sync.Mutex
+	webhookSeen  map[int64]time.Time
+	webhookDirty map[int64]bool
+	lastPolled   map[int64]time.Time
+
+	// Per-server schedules set by SetServerPolling. The ticker runs at the
+	// shortest active interval and pollDue skips servers that aren't due;
+	// paused servers aren't polled at all.
+	scheduleMu      sync.Mutex
+	serverIntervals map[int64]time.Duration
+	pausedServers   map[int64]bool
+	scheduleChanged chan struct{} // buffered (size 1), wakes run to reset the ticker
+
+	// pollHealth holds each server's latest poll outcome, for the dashboard.
+	healthMu   sync.Mutex
+	pollHealth map[int64]PollHealth
+
+	// lastBandwidthAt is when bandwidth was last sampled. Only poll touches it.
+	lastBandwidthAt time.Time
+
 	// DLNA sessions must be seen on two consecutive polls before being tracked
 	pendingDLNA map[string]models.ActiveStream
 
 	geoResolver          GeoResolver
+	sessionObserver      SessionObserver
 	autoLearnHousehold   bool
 	autoLearnMinSessions int
 
@@ -84,6 +117,15 @@ const (
 	retryInterval    = 30 * time.Second
 )
 
+const (
+	// webhookFreshness is how long after its last webhook a server keeps
+	// being treated as webhook-driven before regular polling resumes.
+	webhookFreshness = 10 * time.Minute
+	// webhookReconcileInterval is how often webhook-driven servers are still
+	// polled, to pick up stream details and any events that were missed.
+	webhookReconcileInterval = time.Minute
+)
+
 const DefaultAutoLearnMinSessions = 10
 
 type PollerOption func(*Poller)
@@ -116,6 +158,12 @@ func WithGeoResolver(r GeoResolver) PollerOption {
 	}
 }
 
+func WithSessionObserver(o SessionObserver) PollerOption {
+	return func(p *Poller) {
+		p.sessionObserver = o
+	}
+}
+
 func New(s *store.Store, interval time.Duration, opts ...PollerOption) *Poller {
 	p := &Poller{
 		store:       s,
@@ -125,6 +173,17 @@ func New(s *store.Store, interval time.Duration, opts ...PollerOption) *Poller {
 		subscribers: make(map[chan []models.ActiveStream]struct{}),
 		wsCancel:    make(map[int64]context.CancelFunc),
 		pendingDLNA: make(map[string]models.ActiveStream),
+		triggerPoll: make(chan struct{}, 1),
+
+		webhookSeen:  make(map[int64]time.Time),
+		webhookDirty: make(map[int64]bool),
+		lastPolled:   make(map[int64]time.Time),
+
+		serverIntervals: make(map[int64]time.Duration),
+		pausedServers:   make(map[int64]bool),
+		scheduleChanged: make(chan struct{}, 1),
+
+		pollHealth: make(map[int64]PollHealth),
 	}
 	p.insertHistoryFn = s.InsertHistoryContext
 	for _, opt := range opts {
@@ -180,6 +239,15 @@ func (p *Poller) RemoveServer(id int64) {
 		delete(p.wsCancel, id)
 	}
 	delete(p.servers, id)
+	p.webhookMu.Lock()
+	delete(p.webhookSeen, id)
+	delete(p.webhookDirty, id)
+	delete(p.lastPolled, id)
+	p.webhookMu.Unlock()
+	p.healthMu.Lock()
+	delete(p.pollHealth, id)
+	p.healthMu.Unlock()
+	p.SetServerPolling(id, 0, false)
 	var ended []models.ActiveStream
 	for key, s := range p.sessions {
 		if s.ServerID == id {
@@ -194,6 +262,40 @@ func (p *Poller) RemoveServer(id int64) {
 	}
 }
 
+// PollHealth is the outcome of a server's recent session polls.
+// ConsecutiveFailures resets on the next successful poll, which keeps the
+// last error around for display.
+type PollHealth struct {
+	LastPollAt          time.Time
+	LastSuccessAt       time.Time
+	LastError           error
+	ConsecutiveFailures int
+}
+
+func (p *Poller) recordPollHealth(serverID int64, at time.Time, err error) {
+	p.healthMu.Lock()
+	defer p.healthMu.Unlock()
+	h := p.pollHealth[serverID]
+	h.LastPollAt = at
+	if err != nil {
+		h.LastError = err
+		h.ConsecutiveFailures++
+	} else {
+		h.LastSuccessAt = at
+		h.ConsecutiveFailures = 0
+	}
+	p.pollHealth[serverID] = h
+}
+
+// PollHealth returns the server's latest poll outcome. The bool is false
+// until the server has been polled.
+func (p *Poller) PollHealth(serverID int64) (PollHealth, bool) {
+	p.healthMu.Lock()
+	defer p.healthMu.Unlock()
+	h, ok := p.pollHealth[serverID]
+	return h, ok
+}
+
 func (p *Poller) GetServer(id int64) (media.MediaServer, bool) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -379,13 +481,145 @@ func (p *Poller) applySessionChange(key string, session models.ActiveStream, u m
 	}
 	session.ProgressMs = u.ViewOffset
 	updatePauseState(&session, session.State, u.State)
+	session.RecordProgressSnapshot(time.Now())
 	p.sessions[key] = session
 	return nil
 }
 
+// ApplyWebhookUpdate applies a playback event pushed by a server webhook.
+// Events without a session ID are matched to the server's tracked session
+// for the same item and user. Events for sessions the poller hasn't seen yet
+// trigger an immediate poll of that server so new streams show up right away.
+func (p *Poller) ApplyWebhookUpdate(ctx context.Context, serverID int64, u models.SessionUpdate, userName string) {
+	p.webhookMu.Lock()
+	p.webhookSeen[serverID] = time.Now().UTC()
+	p.webhookMu.Unlock()
+
+	p.mu.RLock()
+	var tracked *models.ActiveStream
+	for _, s := range p.sessions {
+		if s.ServerID != serverID {
+			continue
+		}
+		if u.SessionKey != "" && s.SessionID == u.SessionKey ||
+			u.SessionKey == "" && s.ItemID == u.RatingKey && (userName == "" || strings.EqualFold(s.UserName, userName)) {
+			tracked = &s
+			break
+		}
+	}
+	p.mu.RUnlock()
+
+	if tracked == nil {
+		if u.State != models.SessionStateStopped {
+			p.RequestPoll(serverID)
+		}
+		return
+	}
+	u.SessionKey = tracked.SessionID
+	if u.ViewOffset <= 0 {
+		u.ViewOffset = tracked.ProgressMs
+	}
+	p.applyUpdate(ctx, serverID, u)
+	if u.RatingKey != "" && u.RatingKey != tracked.ItemID {
+		// Autoplay moved the session to a new item: fetch its details.
+		p.RequestPoll(serverID)
+	}
+}
+
+// ClearWebhook returns the server to regular polling, e.g. after its webhook
+// is removed.
+func (p *Poller) ClearWebhook(serverID int64) {
+	p.webhookMu.Lock()
+	delete(p.webhookSeen, serverID)
+	delete(p.webhookDirty, serverID)
+	p.webhookMu.Unlock()
+}
+
+// RequestPoll schedules a poll of serverID as soon as possible, even if it
+// is webhook-driven and not due for reconciliation.
+func (p *Poller) RequestPoll(serverID int64) {
+	p.webhookMu.Lock()
+	p.webhookDirty[serverID] = true
+	p.webhookMu.Unlock()
+	select {
+	case p.triggerPoll <- struct{}{}:
+	default:
+	}
+}
+
+// SetServerPolling sets how often serverID is polled, overriding the global
+// interval when interval is positive, and whether polling is paused. Pausing
+// ends the server's tracked sessions on the next poll.
+func (p *Poller) SetServerPolling(serverID int64, interval time.Duration, paused bool) {
+	p.scheduleMu.Lock()
+	if interval > 0 {
+		p.serverIntervals[serverID] = interval
+	} else {
+		delete(p.serverIntervals, serverID)
+	}
+	if paused {
+		p.pausedServers[serverID] = true
+	} else {
+		delete(p.pausedServers, serverID)
+	}
+	p.scheduleMu.Unlock()
+	select {
+	case p.scheduleChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Poller) isPaused(serverID int64) bool {
+	p.scheduleMu.Lock()
+	defer p.scheduleMu.Unlock()
+	return p.pausedServers[serverID]
+}
+
+// schedule returns serverID's poll interval and the current tick interval,
+// the shortest interval of any active server.
+func (p *Poller) schedule(serverID int64) (interval, tick time.Duration) {
+	p.scheduleMu.Lock()
+	defer p.scheduleMu.Unlock()
+	tick = p.interval
+	for id, d := range p.serverIntervals {
+		if d < tick && !p.pausedServers[id] {
+			tick = d
+		}
+	}
+	interval = p.interval
+	if d, ok := p.serverIntervals[serverID]; ok {
+		interval = d
+	}
+	return interval, tick
+}
+
+// pollDue reports whether serverID should be polled this tick, marking it
+// polled if so. Servers without recent webhooks are due once their poll
+// interval has elapsed, which is every tick unless another server polls
+// more often.
+func (p *Poller) pollDue(serverID int64, now time.Time) bool {
+	interval, tick := p.schedule(serverID)
+	p.webhookMu.Lock()
+	defer p.webhookMu.Unlock()
+	last, polled := p.lastPolled[serverID]
+	// Ticks don't land exactly on the interval, so allow half a tick early.
+	if polled && !p.webhookDirty[serverID] && interval > tick && now.Sub(last) < interval-tick/2 {
+		return false
+	}
+	seen, ok := p.webhookSeen[serverID]
+	due := !ok || now.Sub(seen) > webhookFreshness ||
+		p.webhookDirty[serverID] || now.Sub(last) >= webhookReconcileInterval
+	if due {
+		delete(p.webhookDirty, serverID)
+		p.lastPolled[serverID] = now
+	}
+	return due
+}
+
 func (p *Poller) run(ctx context.Context) {
 	defer close(p.done)
-	ticker := time.NewTicker(p.interval)
+	_, tick := p.schedule(0)
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 
 	p.poll(ctx)
@@ -393,6 +627,11 @@ func (p *Poller) run(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
+		case <-p.scheduleChanged:
+			if _, t := p.schedule(0); t != tick {
+				tick = t
+				ticker.Reset(tick)
+			}
 		case <-ticker.C:
 			p.poll(ctx)
 		case <-p.triggerPoll:
@@ -462,12 +701,31 @@ func (p *Poller) poll(ctx context.Context) {
 
 	failedServers := make(map[int64]struct{})
 	newSessions := make(map[string]models.ActiveStream)
+	var started []string
 
 	seenDLNA := make(map[string]struct{})
 	now := time.Now().UTC()
 	for _, entry := range servers {
+		if p.isPaused(entry.id) {
+			// Leaving its sessions out of newSessions ends them below.
+			continue
+		}
+		if !p.pollDue(entry.id, now) {
+			// Webhook-driven and reconciled recently: keep its sessions as
+			// the webhooks left them.
+			for key, prev := range oldSessions {
+				if prev.ServerID == entry.id {
+					newSessions[key] = prev
+				}
+			}
+			continue
+		}
+		pollStart := time.Now()
 		streams, err := entry.mediaServer.GetSessions(ctx)
+		metrics.PollDuration.ObserveDuration(time.Since(pollStart), entry.mediaServer.Name())
+		p.recordPollHealth(entry.id, now, err)
 		if err != nil {
+			metrics.PollErrors.Inc(entry.mediaServer.Name())
 			log.Printf("polling %s: %v", entry.mediaServer.Name(), err)
 			failedServers[entry.id] = struct{}{}
 			continue
@@ -520,6 +778,8 @@ func (p *Poller) poll(ctx context.Context) {
 				s.StartedAt = prev.StartedAt
 				s.PausedMs = prev.PausedMs
 				s.LastPausedAt = prev.LastPausedAt
+				s.ProgressSnapshots = prev.ProgressSnapshots
+				s.ProgressSnapshotEvery = prev.ProgressSnapshotEvery
 
 				if s.ProgressMs != prev.ProgressMs {
 					s.LastProgressChange = now
@@ -537,8 +797,10 @@ func (p *Poller) poll(ctx context.Context) {
 				updatePauseState(&s, "", s.State)
 				s.LastProgressChange = now
 				log.Printf("session start: user=%q title=%q server=%q", s.UserName, s.Title, s.ServerName)
+				started = append(started, key)
 			}
 			s.LastPollSeen = now
+			s.RecordProgressSnapshot(now)
 			newSessions[key] = s
 		}
 	}
@@ -567,11 +829,25 @@ func (p *Poller) poll(ctx context.Context) {
 		}
 	}
 
+	// Look the new sessions up before newSessions is shared.
+	startedSessions := make([]models.ActiveStream, 0, len(started))
+	for _, key := range started {
+		if s, ok := newSessions[key]; ok {
+			startedSessions = append(startedSessions, s)
+		}
+	}
+
 	p.mu.Lock()
 	p.sessions = newSessions
 	p.pendingDLNA = pendingDLNA
 	p.mu.Unlock()
 
+	if p.sessionObserver != nil {
+		for _, s := range startedSessions {
+			p.sessionObserver.SessionStarted(s)
+		}
+	}
+
 	for key, prev := range oldSessions {
 		if _, still := newSessions[key]; !still {
 			p.persistHistory(ctx, prev)
@@ -586,6 +862,7 @@ func (p *Poller) poll(ctx context.Context) {
 
 	snapshot := p.CurrentSessions()
 	p.publish(snapshot)
+	p.recordBandwidth(ctx, snapshot, now)
 
 	if p.rulesEngine != nil {
 		p.enqueueEval(snapshot)
@@ -599,6 +876,66 @@ func (p *Poller) poll(ctx context.Context) {
 	}
 }
 
+// maxBandwidthGap caps the time a bandwidth sample accounts for, so a stall
+// or a long pause in polling doesn't bill a stream for the whole gap.
+const maxBandwidthGap = 5 * time.Minute
+
+// recordBandwidth adds the traffic of the playing sessions since the last
+// sample, grouped by server, user and LAN/WAN. The first poll only starts
+// the clock.
+func (p *Poller) recordBandwidth(ctx context.Context, sessions []models.ActiveStream, now time.Time) {
+	last := p.lastBandwidthAt
+	p.lastBandwidthAt = now
+	if last.IsZero() || !now.After(last) {
+		return
+	}
+	elapsed := min(now.Sub(last), maxBandwidthGap)
+
+	type key struct {
+		serverID int64
+		user     string
+		network  models.BandwidthNetwork
+	}
+	bps := make(map[key]int64)
+	for _, s := range sessions {
+		if s.Bandwidth <= 0 || s.State == models.SessionStatePaused {
+			continue
+		}
+		network := models.BandwidthLAN
+		if isRemoteAddress(s.IPAddress) {
+			network = models.BandwidthWAN
+		}
+		bps[key{s.ServerID, s.UserName, network}] += s.Bandwidth
+	}
+	if len(bps) == 0 {
+		return
+	}
+
+	samples := make([]models.BandwidthSample, 0, len(bps))
+	for k, rate := range bps {
+		samples = append(samples, models.BandwidthSample{
+			ServerID: k.serverID,
+			UserName: k.user,
+			Network:  k.network,
+			Bytes:    int64(float64(rate) * elapsed.Seconds() / 8),
+			PeakBps:  rate,
+		})
+	}
+	if err := p.store.RecordBandwidth(ctx, now, samples); err != nil {
+		log.Printf("recording bandwidth: %v", err)
+	}
+}
+
+// isRemoteAddress reports whether ip is a public address. Sessions with no
+// parseable address are treated as local.
+func isRemoteAddress(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	return !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast()
+}
+
 // persistHistory writes s to watch history. It returns a non-nil error if
 // the write did not land: callers that need to know whether a session was
 // actually persisted (currently just PersistActiveSessions, for its
@@ -621,6 +958,24 @@ func (p *Poller) persistHistory(ctx context.Context, s models.ActiveStream) erro
 		}
 	}
 
+	if p.sessionObserver != nil {
+		p.sessionObserver.SessionEnded(s)
+	}
+
+	if rule := p.doNotTrackRule(ctx, s); rule != nil {
+		log.Printf("session end: user=%q server=%q not recorded (do-not-track rule %q)", s.UserName, s.ServerName, rule.Name)
+		metrics.SessionsSuppressed.Inc("do_not_track")
+		if err := p.store.RecordDoNotTrackSuppression(rule.ID, time.Now()); err != nil {
+			log.Printf("do-not-track rule %d: %v", rule.ID, err)
+		}
+		return nil
+	}
+	if !p.recordsLibrary(ctx, s) {
+		log.Printf("session end: user=%q title=%q server=%q not recorded (library filtered)", s.UserName, s.Title, s.ServerName)
+		metrics.SessionsSuppressed.Inc("library_filter")
+		return nil
+	}
+
 	// Read the watched threshold once and reuse it for both this entry's own
 	// Watched flag and the store's consolidation logic, instead of each
 	// re-reading it from the database independently.
@@ -638,6 +993,8 @@ func (p *Poller) persistHistory(ctx context.Context, s models.ActiveStream) erro
 
 	log.Printf("session end: user=%q title=%q server=%q watched=%v", s.UserName, s.Title, s.ServerName, watched)
 	entry := p.buildHistoryEntry(s, progressMs, watched)
+	geo := p.lookupGeo(s.IPAddress)
+	entry.NetworkLabel = p.networkLabel(s.IPAddress, geo)
 	if err := p.insertHistoryFn(ctx, entry, threshold); err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			// The write was in flight when its context was cancelled (e.g.
@@ -656,13 +1013,9 @@ func (p *Poller) persistHistory(ctx context.Context, s models.ActiveStream) erro
 		return err
 	}
 
-	if p.geoResolver != nil && s.IPAddress != "" {
-		if ip := net.ParseIP(s.IPAddress); ip != nil {
-			if geo := p.geoResolver.Lookup(ip); geo != nil {
-				if err := p.store.SetCachedGeo(geo); err != nil {
-					log.Printf("caching geo for %s: %v", s.IPAddress, err)
-				}
-			}
+	if geo != nil {
+		if err := p.store.SetCachedGeo(geo); err != nil {
+			log.Printf("caching geo for %s: %v", s.IPAddress, err)
 		}
 	}
 
@@ -674,6 +1027,95 @@ func (p *Poller) persistHistory(ctx context.Context, s models.ActiveStream) erro
 	return nil
 }
 
+func (p *Poller) lookupGeo(ipAddress string) *models.GeoResult {
+	if p.geoResolver == nil || ipAddress == "" {
+		return nil
+	}
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return nil
+	}
+	return p.geoResolver.Lookup(ip)
+}
+
+// networkLabel tags a finished session with the admin-defined network it
+// came from, matching the ASN from geo when no CIDR covers the address.
+func (p *Poller) networkLabel(ipAddress string, geo *models.GeoResult) string {
+	if p.store == nil || ipAddress == "" {
+		return ""
+	}
+	labels, err := p.store.ListNetworkLabels()
+	if err != nil {
+		log.Printf("listing network labels: %v", err)
+		return ""
+	}
+	var asn uint
+	if geo != nil {
+		asn = geo.ASN
+	}
+	return models.MatchNetworkLabel(labels, ipAddress, asn)
+}
+
+// recordsLibrary applies the server's library filter to a finished session.
+// Adapters that don't report the library are resolved through the synced
+// library cache by item, then by series. Lookup failures record the play.
+// doNotTrackRule returns the first enabled do-not-track rule matching s, or
+// nil when the session should be recorded. The library is looked up only for
+// rules that name one; a library that can't be resolved doesn't match.
+func (p *Poller) doNotTrackRule(ctx context.Context, s models.ActiveStream) *models.DoNotTrackRule {
+	if p.store == nil {
+		return nil
+	}
+	rules, err := p.store.ListDoNotTrackRules()
+	if err != nil {
+		log.Printf("listing do-not-track rules: %v", err)
+		return nil
+	}
+	libraryID, resolved := s.LibraryID, s.LibraryID != ""
+	for i := range rules {
+		if !rules[i].Matches(&s) {
+			continue
+		}
+		if rules[i].LibraryID != "" {
+			if !resolved {
+				libraryID, err = p.store.LibraryIDForItem(ctx, s.ServerID, s.ItemID, s.GrandparentItemID)
+				if err != nil {
+					log.Printf("do-not-track library lookup for %s: %v", s.Title, err)
+				}
+				resolved = true
+			}
+			if libraryID != rules[i].LibraryID {
+				continue
+			}
+		}
+		return &rules[i]
+	}
+	return nil
+}
+
+func (p *Poller) recordsLibrary(ctx context.Context, s models.ActiveStream) bool {
+	if p.store == nil {
+		return true
+	}
+	srv, err := p.store.GetServer(s.ServerID)
+	if err != nil {
+		log.Printf("library filter for server %d: %v", s.ServerID, err)
+		return true
+	}
+	if srv.LibraryFilter.IsZero() {
+		return true
+	}
+	libraryID := s.LibraryID
+	if libraryID == "" {
+		libraryID, err = p.store.LibraryIDForItem(ctx, s.ServerID, s.ItemID, s.GrandparentItemID)
+		if err != nil {
+			log.Printf("library filter for %s: %v", s.Title, err)
+			return true
+		}
+	}
+	return srv.LibraryFilter.Records(libraryID)
+}
+
 func (p *Poller) buildHistoryEntry(s models.ActiveStream, progressMs int64, watched bool) *models.WatchHistoryEntry {
 	videoDecision := s.VideoDecision
 	if videoDecision == "" {
@@ -697,6 +1139,9 @@ func (p *Poller) buildHistoryEntry(s models.ActiveStream, progressMs int64, watc
 		Title:             s.Title,
 		ParentTitle:       s.ParentTitle,
 		GrandparentTitle:  s.GrandparentTitle,
+		OriginalTitle:     s.OriginalTitle,
+		Language:          s.Language,
+		ContentRating:     s.ContentRating,
 		Year:              s.Year,
 		DurationMs:        s.DurationMs,
 		WatchedMs:         progressMs,
@@ -0,0 +1,61 @@
+package poller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type recordingObserver struct {
+	mu      sync.Mutex
+	started []string
+	ended   []string
+}
+
+func (o *recordingObserver) SessionStarted(s models.ActiveStream) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started = append(o.started, s.SessionID)
+}
+
+func (o *recordingObserver) SessionEnded(s models.ActiveStream) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ended = append(o.ended, s.SessionID)
+}
+
+func TestSessionObserver(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	p := newTestPoller(t, s)
+	obs := &recordingObserver{}
+	WithSessionObserver(obs)(p)
+
+	stream := models.ActiveStream{SessionID: "s1", ServerID: srv.ID, Title: "Movie", MediaType: models.MediaTypeMovie,
+		DurationMs: 100000, ProgressMs: 50000, UserName: "alice", StartedAt: time.Now().UTC()}
+	ms := &mockServer{name: "test", sessions: []models.ActiveStream{stream}}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitPoll(t, p)
+
+	// Still playing: no second start.
+	triggerAndWaitPoll(t, p)
+
+	ms.setSessions(nil)
+	triggerAndWaitPoll(t, p)
+	p.Stop()
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if len(obs.started) != 1 || obs.started[0] != "s1" {
+		t.Errorf("started = %v, want [s1]", obs.started)
+	}
+	if len(obs.ended) != 1 || obs.ended[0] != "s1" {
+		t.Errorf("ended = %v, want [s1]", obs.ended)
+	}
+}
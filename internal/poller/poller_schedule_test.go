@@ -0,0 +1,121 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestPollDueHonoursServerIntervals(t *testing.T) {
+	p := New(newTestStore(t), 10*time.Second)
+	p.SetServerPolling(1, 2*time.Second, false)
+	p.SetServerPolling(2, time.Minute, false)
+	now := time.Now().UTC()
+
+	if _, tick := p.schedule(0); tick != 2*time.Second {
+		t.Fatalf("tick = %v, want the shortest server interval", tick)
+	}
+
+	for id := int64(1); id <= 3; id++ {
+		if !p.pollDue(id, now) {
+			t.Errorf("server %d not due on first poll", id)
+		}
+	}
+
+	// One tick later only the fast server is due.
+	later := now.Add(2 * time.Second)
+	if !p.pollDue(1, later) {
+		t.Error("2s server should be due every tick")
+	}
+	if p.pollDue(2, later) || p.pollDue(3, later) {
+		t.Error("slower servers polled before their interval")
+	}
+
+	// Servers without an override follow the global interval.
+	if !p.pollDue(3, now.Add(10*time.Second)) {
+		t.Error("server without override not polled at the global interval")
+	}
+	if !p.pollDue(2, now.Add(time.Minute-time.Second)) {
+		t.Error("60s server not polled within half a tick of its interval")
+	}
+
+	// Pausing the fast server slows the tick back down.
+	p.SetServerPolling(1, 2*time.Second, true)
+	if _, tick := p.schedule(0); tick != 10*time.Second {
+		t.Errorf("tick = %v after pause, want global interval", tick)
+	}
+}
+
+func TestPausedServerSessionsEnd(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	p := newTestPoller(t, s)
+
+	ms := &mockServer{
+		name: "test",
+		sessions: []models.ActiveStream{
+			{SessionID: "s1", ServerID: srv.ID, Title: "Movie", MediaType: models.MediaTypeMovie,
+				DurationMs: 100000, ProgressMs: 50000, UserName: "alice", StartedAt: time.Now().UTC()},
+		},
+	}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+	waitPoll(t, p)
+	if n := len(p.CurrentSessions()); n != 1 {
+		t.Fatalf("expected 1 session, got %d", n)
+	}
+
+	p.SetServerPolling(srv.ID, 0, true)
+	triggerAndWaitPoll(t, p)
+	if n := len(p.CurrentSessions()); n != 0 {
+		t.Fatalf("expected paused server's session to end, got %d", n)
+	}
+
+	p.SetServerPolling(srv.ID, 0, false)
+	triggerAndWaitPoll(t, p)
+	if n := len(p.CurrentSessions()); n != 1 {
+		t.Errorf("expected session after resume, got %d", n)
+	}
+}
+
+func TestPollHealth(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	p := newTestPoller(t, s)
+
+	ms := &mockServer{name: "test", err: errors.New("connection refused")}
+	p.AddServer(srv.ID, ms)
+	if _, ok := p.PollHealth(srv.ID); ok {
+		t.Fatal("health reported before the first poll")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+	waitPoll(t, p)
+	triggerAndWaitPoll(t, p)
+	h, ok := p.PollHealth(srv.ID)
+	if !ok || h.ConsecutiveFailures != 2 || h.LastError == nil || !h.LastSuccessAt.IsZero() {
+		t.Fatalf("health after failures = %+v", h)
+	}
+
+	ms.mu.Lock()
+	ms.err = nil
+	ms.mu.Unlock()
+	triggerAndWaitPoll(t, p)
+	h, _ = p.PollHealth(srv.ID)
+	if h.ConsecutiveFailures != 0 || h.LastSuccessAt.IsZero() || h.LastError == nil {
+		t.Errorf("health after recovery = %+v", h)
+	}
+
+	p.RemoveServer(srv.ID)
+	if _, ok := p.PollHealth(srv.ID); ok {
+		t.Error("health kept after RemoveServer")
+	}
+}
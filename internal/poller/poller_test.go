@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -1035,6 +1036,50 @@ func TestPausedMsPersistedToHistory(t *testing.T) {
 	}
 }
 
+func TestProgressSnapshotsCarryAcrossPolls(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	p := newTestPoller(t, s)
+
+	session := models.ActiveStream{SessionID: "s1", ServerID: srv.ID, ItemID: "100", Title: "Movie",
+		MediaType: models.MediaTypeMovie, DurationMs: 120000, ProgressMs: 30000,
+		UserName: "alice", StartedAt: time.Now().UTC(), State: models.SessionStatePlaying}
+	ms := &mockServer{name: "test", sessions: []models.ActiveStream{session}}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+	waitPoll(t, p)
+
+	// Backdate the first snapshot so the next poll falls in a new interval.
+	p.mu.Lock()
+	for key, sess := range p.sessions {
+		snaps := slices.Clone(sess.ProgressSnapshots)
+		snaps[0].At = snaps[0].At.Add(-2 * models.ProgressSnapshotInterval)
+		sess.ProgressSnapshots = snaps
+		p.sessions[key] = sess
+	}
+	p.mu.Unlock()
+
+	session.ProgressMs = 60000
+	session.State = models.SessionStatePaused
+	ms.setSessions([]models.ActiveStream{session})
+	triggerAndWaitPoll(t, p)
+
+	sessions := p.CurrentSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+	snaps := sessions[0].ProgressSnapshots
+	if len(snaps) != 2 {
+		t.Fatalf("expected 2 snapshots, got %+v", snaps)
+	}
+	if snaps[0].ProgressPercent != 25 || snaps[1].ProgressPercent != 50 || snaps[1].State != models.SessionStatePaused {
+		t.Errorf("snapshots = %+v", snaps)
+	}
+}
+
 func TestSessionKeyFormat(t *testing.T) {
 	key := sessionKey(42, "abc", "100")
 	if key != "42:abc:100" {
@@ -1491,3 +1536,192 @@ func TestPersistActiveSessionsCountsInFlightCancelledWrite(t *testing.T) {
 		t.Fatalf("expected the cancelled in-flight write not to be silently queued for a retry that will never run, got %d queued", queued)
 	}
 }
+
+func TestLibraryFilterSkipsHistory(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	if err := s.SetServerLibraryFilter(srv.ID, models.ServerLibraryFilter{ExcludedLibraries: []string{"home", "fitness"}}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	if _, err := s.UpsertLibraryItems(context.Background(), []models.LibraryItemCache{{
+		ServerID: srv.ID, LibraryID: "fitness", ItemID: "series1",
+		MediaType: models.MediaTypeTV, Title: "Workout", AddedAt: now, SyncedAt: now,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestPoller(t, s)
+
+	ms := &mockServer{
+		name: "test",
+		sessions: []models.ActiveStream{
+			{SessionID: "s1", ServerID: srv.ID, LibraryID: "home", Title: "Birthday", MediaType: models.MediaTypeMovie,
+				DurationMs: 100000, ProgressMs: 50000, UserName: "alice", StartedAt: now},
+			{SessionID: "s2", ServerID: srv.ID, ItemID: "ep1", GrandparentItemID: "series1", Title: "Day 1", MediaType: models.MediaTypeTV,
+				DurationMs: 100000, ProgressMs: 50000, UserName: "alice", StartedAt: now},
+			{SessionID: "s3", ServerID: srv.ID, LibraryID: "movies", Title: "Movie", MediaType: models.MediaTypeMovie,
+				DurationMs: 100000, ProgressMs: 50000, UserName: "bob", StartedAt: now},
+		},
+	}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitPoll(t, p)
+
+	ms.setSessions(nil)
+	triggerAndWaitPoll(t, p)
+
+	p.Stop()
+
+	result, err := s.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Items[0].Title != "Movie" {
+		t.Fatalf("expected only Movie in history, got %+v", result.Items)
+	}
+}
+
+func TestDoNotTrackSkipsHistory(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	rules := []*models.DoNotTrackRule{
+		{Name: "Kids tablet", Enabled: true, UserName: "alice", Device: "iPad"},
+		{Name: "Guest room", Enabled: true, IPRange: "192.168.1.0/24"},
+		{Name: "Home videos", Enabled: true, ServerID: srv.ID, LibraryID: "home"},
+		{Name: "Disabled", Enabled: false, UserName: "bob"},
+	}
+	for _, r := range rules {
+		if err := s.CreateDoNotTrackRule(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestPoller(t, s)
+
+	now := time.Now().UTC()
+	ms := &mockServer{
+		name: "test",
+		sessions: []models.ActiveStream{
+			{SessionID: "s1", ServerID: srv.ID, Title: "Cartoon", MediaType: models.MediaTypeMovie, Player: "ipad",
+				DurationMs: 100000, ProgressMs: 50000, UserName: "Alice", StartedAt: now},
+			{SessionID: "s2", ServerID: srv.ID, Title: "Guest Movie", MediaType: models.MediaTypeMovie, IPAddress: "192.168.1.40",
+				DurationMs: 100000, ProgressMs: 50000, UserName: "carol", StartedAt: now},
+			{SessionID: "s3", ServerID: srv.ID, LibraryID: "home", Title: "Birthday", MediaType: models.MediaTypeMovie,
+				DurationMs: 100000, ProgressMs: 50000, UserName: "carol", StartedAt: now},
+			{SessionID: "s4", ServerID: srv.ID, Title: "Movie", MediaType: models.MediaTypeMovie, Player: "Chrome",
+				DurationMs: 100000, ProgressMs: 50000, UserName: "bob", StartedAt: now},
+		},
+	}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitPoll(t, p)
+
+	ms.setSessions(nil)
+	triggerAndWaitPoll(t, p)
+
+	p.Stop()
+
+	result, err := s.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Items[0].Title != "Movie" {
+		t.Fatalf("expected only Movie in history, got %+v", result.Items)
+	}
+
+	saved, err := s.ListDoNotTrackRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range saved {
+		want := int64(1)
+		if r.Name == "Disabled" {
+			want = 0
+		}
+		if r.SuppressedCount != want {
+			t.Errorf("rule %q suppressed %d sessions, want %d", r.Name, r.SuppressedCount, want)
+		}
+	}
+}
+
+func TestHistoryTaggedWithNetworkLabel(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	if err := s.CreateNetworkLabel(&models.NetworkLabel{Name: "Home", CIDRs: []string{"192.168.1.0/24"}}); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestPoller(t, s)
+
+	now := time.Now().UTC()
+	ms := &mockServer{
+		name: "test",
+		sessions: []models.ActiveStream{
+			{SessionID: "s1", ServerID: srv.ID, Title: "Home Movie", MediaType: models.MediaTypeMovie, IPAddress: "192.168.1.20",
+				DurationMs: 100000, ProgressMs: 50000, UserName: "alice", StartedAt: now},
+			{SessionID: "s2", ServerID: srv.ID, Title: "Away Movie", MediaType: models.MediaTypeMovie, IPAddress: "203.0.113.5",
+				DurationMs: 100000, ProgressMs: 50000, UserName: "alice", StartedAt: now},
+		},
+	}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitPoll(t, p)
+
+	ms.setSessions(nil)
+	triggerAndWaitPoll(t, p)
+
+	p.Stop()
+
+	result, err := s.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{}
+	for _, e := range result.Items {
+		labels[e.Title] = e.NetworkLabel
+	}
+	if labels["Home Movie"] != "Home" || labels["Away Movie"] != "" {
+		t.Fatalf("unexpected network labels %v", labels)
+	}
+}
+
+func TestRecordBandwidth(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	p := newTestPoller(t, s)
+	ctx := context.Background()
+
+	sessions := []models.ActiveStream{
+		{ServerID: srv.ID, UserName: "alice", IPAddress: "203.0.113.5", Bandwidth: 4_000_000},
+		{ServerID: srv.ID, UserName: "alice", IPAddress: "198.51.100.7", Bandwidth: 4_000_000},
+		{ServerID: srv.ID, UserName: "bob", IPAddress: "192.168.1.20", Bandwidth: 10_000_000},
+		{ServerID: srv.ID, UserName: "carol", IPAddress: "203.0.113.9", Bandwidth: 2_000_000, State: models.SessionStatePaused},
+	}
+	now := time.Now().UTC()
+	p.recordBandwidth(ctx, sessions, now.Add(-time.Minute))
+	p.recordBandwidth(ctx, sessions, now)
+	// A long gap only counts for maxBandwidthGap.
+	p.recordBandwidth(ctx, sessions[:1], now.Add(time.Hour))
+
+	stats, err := s.BandwidthStats(ctx, store.StatsFilter{Days: 1}, models.BandwidthDaily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := map[string]models.BandwidthUserStat{}
+	for _, u := range stats.Users {
+		users[u.UserName] = u
+	}
+	// 8 Mbps for a minute, then 4 Mbps for five.
+	if a := users["alice"]; a.WANBytes != 60_000_000+150_000_000 || a.PeakWANBps != 8_000_000 {
+		t.Errorf("alice = %+v", a)
+	}
+	if b := users["bob"]; b.Bytes != 75_000_000 || b.WANBytes != 0 {
+		t.Errorf("bob = %+v", b)
+	}
+	if _, ok := users["carol"]; ok {
+		t.Error("paused session recorded")
+	}
+}
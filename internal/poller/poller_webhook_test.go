@@ -0,0 +1,93 @@
+package poller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestWebhookUpdatesDriveSessions(t *testing.T) {
+	s, srv := newTestStoreWithServer(t)
+	p := newTestPoller(t, s)
+	ms := &mockServer{
+		name: "jellyfin",
+		sessions: []models.ActiveStream{
+			{SessionID: "s1", ServerID: srv.ID, ItemID: "i1", Title: "Movie", MediaType: models.MediaTypeMovie,
+				DurationMs: 100000, ProgressMs: 10000, UserName: "alice", StartedAt: time.Now().UTC()},
+		},
+	}
+	p.AddServer(srv.ID, ms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+	waitPoll(t, p)
+
+	// No session ID in the payload: matched on item and user.
+	p.ApplyWebhookUpdate(ctx, srv.ID, models.SessionUpdate{RatingKey: "i1", State: models.SessionStatePaused, ViewOffset: 60000}, "Alice")
+	sessions := p.CurrentSessions()
+	if len(sessions) != 1 || sessions[0].State != models.SessionStatePaused || sessions[0].ProgressMs != 60000 {
+		t.Fatalf("sessions after pause webhook = %+v", sessions)
+	}
+
+	// The server is webhook-driven now, so a regular poll within the
+	// reconcile interval leaves its sessions alone.
+	ms.mu.Lock()
+	ms.sessions = nil
+	ms.mu.Unlock()
+	triggerAndWaitPoll(t, p)
+	if len(p.CurrentSessions()) != 1 {
+		t.Fatal("expected webhook-driven session to survive a skipped poll")
+	}
+
+	p.ApplyWebhookUpdate(ctx, srv.ID, models.SessionUpdate{SessionKey: "s1", State: models.SessionStateStopped}, "")
+	if len(p.CurrentSessions()) != 0 {
+		t.Fatal("expected session removed after stop webhook")
+	}
+	result, err := s.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Items[0].WatchedMs != 60000 {
+		t.Errorf("history = %+v, want one entry at 60000ms", result.Items)
+	}
+
+	// A start for an unknown session polls the server straight away.
+	ms.mu.Lock()
+	ms.sessions = []models.ActiveStream{
+		{SessionID: "s2", ServerID: srv.ID, ItemID: "i2", Title: "Other", MediaType: models.MediaTypeMovie,
+			DurationMs: 100000, UserName: "bob", StartedAt: time.Now().UTC()},
+	}
+	ms.mu.Unlock()
+	p.ApplyWebhookUpdate(ctx, srv.ID, models.SessionUpdate{SessionKey: "s2", RatingKey: "i2", State: models.SessionStatePlaying}, "bob")
+	waitPoll(t, p)
+	sessions = p.CurrentSessions()
+	if len(sessions) != 1 || sessions[0].SessionID != "s2" {
+		t.Errorf("sessions after start webhook = %+v", sessions)
+	}
+}
+
+func TestPollDueFallsBackWithoutWebhooks(t *testing.T) {
+	p := newTestPoller(t, newTestStore(t))
+	now := time.Now().UTC()
+
+	if !p.pollDue(1, now) || !p.pollDue(1, now) {
+		t.Fatal("servers without webhooks must be polled every tick")
+	}
+
+	p.webhookSeen[1] = now
+	if p.pollDue(1, now.Add(time.Second)) {
+		t.Error("webhook-driven server polled before reconcile interval")
+	}
+	if !p.pollDue(1, now.Add(webhookReconcileInterval)) {
+		t.Error("webhook-driven server not reconciled")
+	}
+
+	p.webhookSeen[1] = now.Add(-webhookFreshness - time.Second)
+	if !p.pollDue(1, now.Add(webhookReconcileInterval+time.Second)) {
+		t.Error("server with stale webhooks should fall back to polling")
+	}
+}
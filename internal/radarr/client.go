@@ -47,11 +47,47 @@ func (c *Client) LookupMovieByTMDB(ctx context.Context, tmdbID string) (int, err
 	return movies[0].ID, nil
 }
 
-// DeleteMovie removes a movie from Radarr, optionally deleting files.
-func (c *Client) DeleteMovie(ctx context.Context, movieID int, deleteFiles bool) error {
+// DeleteMovie removes a movie from Radarr, optionally deleting files and
+// adding it to the import exclusion list so lists don't re-add it.
+func (c *Client) DeleteMovie(ctx context.Context, movieID int, deleteFiles, addExclusion bool) error {
 	q := url.Values{}
 	if deleteFiles {
 		q.Set("deleteFiles", "true")
 	}
+	if addExclusion {
+		q.Set("addImportExclusion", "true")
+	}
 	return c.DoDelete(ctx, fmt.Sprintf("/movie/%d", movieID), q)
 }
+
+type movieEditorRequest struct {
+	MovieIDs  []int `json:"movieIds"`
+	Monitored bool  `json:"monitored"`
+}
+
+// UnmonitorMovie keeps a movie in Radarr but stops it searching for or
+// grabbing new releases.
+func (c *Client) UnmonitorMovie(ctx context.Context, movieID int) error {
+	data, err := json.Marshal(movieEditorRequest{MovieIDs: []int{movieID}})
+	if err != nil {
+		return fmt.Errorf("marshal movie editor: %w", err)
+	}
+	_, err = c.DoPut(ctx, "/movie/editor", data)
+	return err
+}
+
+type movieExclusion struct {
+	TMDBID     int    `json:"tmdbId"`
+	MovieTitle string `json:"movieTitle"`
+	MovieYear  int    `json:"movieYear"`
+}
+
+// AddExclusion adds a movie to Radarr's import list exclusions.
+func (c *Client) AddExclusion(ctx context.Context, tmdbID int, title string, year int) error {
+	data, err := json.Marshal(movieExclusion{TMDBID: tmdbID, MovieTitle: title, MovieYear: year})
+	if err != nil {
+		return fmt.Errorf("marshal exclusion: %w", err)
+	}
+	_, err = c.DoPost(ctx, "/exclusions", data)
+	return err
+}
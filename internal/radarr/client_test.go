@@ -142,7 +142,7 @@ func TestDeleteMovie(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient(ts.URL, "test-key")
-	if err := c.DeleteMovie(context.Background(), 42, true); err != nil {
+	if err := c.DeleteMovie(context.Background(), 42, true, false); err != nil {
 		t.Fatalf("DeleteMovie: %v", err)
 	}
 }
@@ -155,7 +155,22 @@ func TestDeleteMovieFailure(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient(ts.URL, "test-key")
-	if err := c.DeleteMovie(context.Background(), 999, true); err == nil {
+	if err := c.DeleteMovie(context.Background(), 999, true, false); err == nil {
 		t.Fatal("expected error for 404 response")
 	}
 }
+
+func TestDeleteMovieAddsExclusion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("addImportExclusion") != "true" {
+			t.Errorf("expected addImportExclusion=true, got %q", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient(ts.URL, "test-key")
+	if err := c.DeleteMovie(context.Background(), 42, true, true); err != nil {
+		t.Fatalf("DeleteMovie: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+const bytesPerGB = 1e9
+
+// BandwidthQuerier reports a user's estimated transfer from watch history.
+type BandwidthQuerier interface {
+	GetUserBandwidthSince(userName string, since time.Time) (int64, error)
+}
+
+type BandwidthQuotaEvaluator struct {
+	store BandwidthQuerier
+	now   func() time.Time
+}
+
+func NewBandwidthQuotaEvaluator(store BandwidthQuerier) *BandwidthQuotaEvaluator {
+	return &BandwidthQuotaEvaluator{store: store, now: func() time.Time { return time.Now().UTC() }}
+}
+
+func (e *BandwidthQuotaEvaluator) Type() models.RuleType {
+	return models.RuleTypeBandwidthQuota
+}
+
+// Evaluate adds the user's completed sessions this month to an estimate for
+// every session they currently have open, so a quota is caught mid-stream
+// rather than only once the session lands in history.
+func (e *BandwidthQuotaEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil {
+		return nil, nil
+	}
+
+	var config models.BandwidthQuotaConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	stream := input.Stream
+	now := e.now()
+
+	used, err := e.store.GetUserBandwidthSince(stream.UserName, store.MonthStart(now))
+	if err != nil {
+		return nil, fmt.Errorf("getting user bandwidth: %w", err)
+	}
+
+	userStreams := filterStreamsByUser(input.AllStreams, stream.UserName)
+	if len(userStreams) == 0 {
+		userStreams = []models.ActiveStream{*stream}
+	}
+	for _, s := range userStreams {
+		used += activeStreamBytes(s, now)
+	}
+
+	quotaBytes := int64(config.MonthlyQuotaGB * bytesPerGB)
+	if used <= quotaBytes {
+		return nil, nil
+	}
+
+	usedGB := float64(used) / bytesPerGB
+	ratio := usedGB / config.MonthlyQuotaGB
+	severity := models.SeverityInfo
+	if ratio >= 1.5 {
+		severity = models.SeverityCritical
+	} else if ratio >= 1.2 {
+		severity = models.SeverityWarning
+	}
+
+	confidence := 50 + (ratio-1)*100
+	if confidence > 100 {
+		confidence = 100
+	}
+
+	violation := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: stream.UserName,
+		Severity: severity,
+		Message:  fmt.Sprintf("user has transferred an estimated %.1f GB this month (quota: %.1f GB)", usedGB, config.MonthlyQuotaGB),
+		Details: map[string]interface{}{
+			"used_bytes":       used,
+			"used_gb":          usedGB,
+			"monthly_quota_gb": config.MonthlyQuotaGB,
+			"month":            now.Format("2006-01"),
+		},
+		ConfidenceScore: confidence,
+		OccurredAt:      now,
+	}
+
+	return &EvaluationResult{
+		Violation: violation,
+		Signals: []models.ViolationSignal{
+			{Name: "used_gb", Weight: 0.7, Value: usedGB},
+			{Name: "quota_gb", Weight: 0.0, Value: config.MonthlyQuotaGB},
+			{Name: "excess_ratio", Weight: 0.3, Value: ratio - 1},
+		},
+	}, nil
+}
+
+// activeStreamBytes estimates what an in-progress session has transferred so
+// far: its current bitrate over the wall time it has been playing.
+func activeStreamBytes(s models.ActiveStream, now time.Time) int64 {
+	if s.Bandwidth <= 0 {
+		return 0
+	}
+	return s.Bandwidth * activePlayMs(s, now) / 8000
+}
+
+// activePlayMs is the wall time an in-progress session has spent playing,
+// not paused.
+func activePlayMs(s models.ActiveStream, now time.Time) int64 {
+	if s.StartedAt.IsZero() {
+		return 0
+	}
+	return max(now.Sub(s.StartedAt).Milliseconds()-s.PausedMs, 0)
+}
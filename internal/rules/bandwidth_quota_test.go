@@ -0,0 +1,87 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"streammon/internal/models"
+)
+
+type mockBandwidthQuerier struct {
+	bytes int64
+	since time.Time
+}
+
+func (m *mockBandwidthQuerier) GetUserBandwidthSince(userName string, since time.Time) (int64, error) {
+	m.since = since
+	return m.bytes, nil
+}
+
+func newBandwidthQuotaEvaluatorAt(q BandwidthQuerier, now time.Time) *BandwidthQuotaEvaluator {
+	e := NewBandwidthQuotaEvaluator(q)
+	e.now = func() time.Time { return now }
+	return e
+}
+
+func bandwidthQuotaRule(quotaGB float64) *models.Rule {
+	cfg, _ := json.Marshal(models.BandwidthQuotaConfig{MonthlyQuotaGB: quotaGB})
+	return &models.Rule{ID: 1, Name: "Quota", Type: models.RuleTypeBandwidthQuota, Config: cfg}
+}
+
+func TestBandwidthQuotaEvaluator_UnderQuota(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	q := &mockBandwidthQuerier{bytes: 50e9}
+	evaluator := newBandwidthQuotaEvaluatorAt(q, now)
+
+	stream := &models.ActiveStream{UserName: "alice", StartedAt: now.Add(-time.Hour), Bandwidth: 8_000_000}
+	result, err := evaluator.Evaluate(context.Background(), bandwidthQuotaRule(100), &EvaluationInput{
+		Stream: stream, AllStreams: []models.ActiveStream{*stream},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), q.since)
+}
+
+func TestBandwidthQuotaEvaluator_ActiveStreamsPushOverQuota(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	// 99 GB from history + two active 80 Mbps streams for an hour (36 GB each).
+	q := &mockBandwidthQuerier{bytes: 99e9}
+	evaluator := newBandwidthQuotaEvaluatorAt(q, now)
+
+	streams := []models.ActiveStream{
+		{SessionID: "a", UserName: "alice", StartedAt: now.Add(-time.Hour), Bandwidth: 80_000_000},
+		{SessionID: "b", UserName: "alice", StartedAt: now.Add(-time.Hour), Bandwidth: 80_000_000},
+		{SessionID: "c", UserName: "bob", StartedAt: now.Add(-time.Hour), Bandwidth: 80_000_000},
+	}
+	result, err := evaluator.Evaluate(context.Background(), bandwidthQuotaRule(100), &EvaluationInput{
+		Stream: &streams[0], AllStreams: streams,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.NotNil(t, result.Violation)
+	assert.Equal(t, "alice", result.Violation.UserName)
+	assert.Equal(t, models.SeverityCritical, result.Violation.Severity)
+	assert.Equal(t, int64(171e9), result.Violation.Details["used_bytes"])
+	assert.Contains(t, result.Violation.Message, "171.0 GB")
+}
+
+func TestBandwidthQuotaEvaluator_PausedTimeExcluded(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	stream := models.ActiveStream{
+		StartedAt: now.Add(-2 * time.Hour),
+		PausedMs:  int64(time.Hour / time.Millisecond),
+		Bandwidth: 8_000_000,
+	}
+	assert.Equal(t, int64(3.6e9), activeStreamBytes(stream, now))
+	assert.Zero(t, activeStreamBytes(models.ActiveStream{StartedAt: now.Add(-time.Hour)}, now))
+}
+
+func TestBandwidthQuotaEvaluator_Type(t *testing.T) {
+	evaluator := NewBandwidthQuotaEvaluator(&mockBandwidthQuerier{})
+	assert.Equal(t, models.RuleTypeBandwidthQuota, evaluator.Type())
+}
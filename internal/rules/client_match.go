@@ -0,0 +1,157 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type ClientMatchEvaluator struct{}
+
+func NewClientMatchEvaluator() *ClientMatchEvaluator {
+	return &ClientMatchEvaluator{}
+}
+
+func (e *ClientMatchEvaluator) Type() models.RuleType {
+	return models.RuleTypeClientMatch
+}
+
+func (e *ClientMatchEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil {
+		return nil, nil
+	}
+
+	var config models.ClientMatchConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	stream := input.Stream
+	var matched *models.ClientPattern
+	for i := range config.Patterns {
+		if clientMatches(config.Patterns[i], stream) {
+			matched = &config.Patterns[i]
+			break
+		}
+	}
+	if matched == nil {
+		return nil, nil
+	}
+
+	client := stream.Platform
+	if client == "" {
+		client = stream.Player
+	}
+	if stream.PlayerVersion != "" {
+		client += " " + stream.PlayerVersion
+	}
+
+	v := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: stream.UserName,
+		Severity: config.Severity,
+		Message:  fmt.Sprintf("streaming from flagged client: %s", strings.TrimSpace(client)),
+		Details: map[string]interface{}{
+			"player":          stream.Player,
+			"platform":        stream.Platform,
+			"player_version":  stream.PlayerVersion,
+			"matched_pattern": matched,
+		},
+		ConfidenceScore: 100,
+		OccurredAt:      time.Now().UTC(),
+	}
+
+	return &EvaluationResult{
+		Violation: v,
+		Signals: []models.ViolationSignal{
+			{Name: "client_match", Weight: 1.0, Value: true},
+		},
+	}, nil
+}
+
+// clientMatches reports whether every field p sets matches the stream. A
+// version constraint never matches a client that didn't report its version.
+func clientMatches(p models.ClientPattern, s *models.ActiveStream) bool {
+	if p.Player != "" && !globMatch(p.Player, s.Player) {
+		return false
+	}
+	if p.Platform != "" && !globMatch(p.Platform, s.Platform) {
+		return false
+	}
+	if p.Version != "" && !globMatch(p.Version, s.PlayerVersion) {
+		return false
+	}
+	if p.VersionBelow != "" && (s.PlayerVersion == "" || compareVersions(s.PlayerVersion, p.VersionBelow) >= 0) {
+		return false
+	}
+	return true
+}
+
+// globMatch matches s against a case-insensitive pattern in which * matches
+// any run of characters and ? any single one.
+func globMatch(pattern, s string) bool {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(s)
+}
+
+// compareVersions compares dotted versions segment by segment, numerically
+// where both segments start with digits ("10.2" > "9.15", "3.1-beta" is
+// 3.1), and treats missing segments as zero.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xok := leadingInt(x)
+		yn, yok := leadingInt(y)
+		if xok && yok || x == "" || y == "" {
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func leadingInt(s string) (int, bool) {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(s[:end])
+	return n, err == nil
+}
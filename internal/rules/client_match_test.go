@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestClientMatchEvaluator_Type(t *testing.T) {
+	e := NewClientMatchEvaluator()
+	if e.Type() != models.RuleTypeClientMatch {
+		t.Errorf("expected %s, got %s", models.RuleTypeClientMatch, e.Type())
+	}
+}
+
+func TestClientMatchEvaluator_Patterns(t *testing.T) {
+	e := NewClientMatchEvaluator()
+	ctx := context.Background()
+
+	config := models.ClientMatchConfig{
+		Patterns: []models.ClientPattern{
+			{Platform: "plex for samsung", VersionBelow: "4"},
+			{Player: "*Kodi*", Version: "18.?"},
+		},
+	}
+	configJSON, _ := json.Marshal(config)
+	rule := &models.Rule{ID: 1, Name: "Old clients", Type: models.RuleTypeClientMatch, Config: configJSON}
+
+	tests := []struct {
+		name     string
+		player   string
+		platform string
+		version  string
+		wantViol bool
+	}{
+		{"old samsung", "Living Room", "Plex for Samsung", "3.9.12", true},
+		{"current samsung", "Living Room", "Plex for Samsung", "4.1.0", false},
+		{"samsung without version", "Living Room", "Plex for Samsung", "", false},
+		{"other platform", "Living Room", "Plex for Roku", "3.0", false},
+		{"kodi 18", "Bedroom Kodi Box", "Kodi", "18.9", true},
+		{"kodi 19", "Bedroom Kodi Box", "Kodi", "19.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &EvaluationInput{
+				Stream: &models.ActiveStream{
+					UserName:      "testuser",
+					Player:        tt.player,
+					Platform:      tt.platform,
+					PlayerVersion: tt.version,
+				},
+			}
+			result, err := e.Evaluate(ctx, rule, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotViol := result != nil; gotViol != tt.wantViol {
+				t.Fatalf("violation = %v, want %v", gotViol, tt.wantViol)
+			}
+			if result != nil && result.Violation.Severity != models.SeverityWarning {
+				t.Errorf("severity = %s, want default warning", result.Violation.Severity)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.9.12", "4", -1},
+		{"10.2", "9.15", 1},
+		{"4.0.0", "4", 0},
+		{"3.1-beta", "3.1", 0},
+		{"2024.1", "2023.12.5", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
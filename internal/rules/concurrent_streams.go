@@ -10,7 +10,14 @@ import (
 	"streammon/internal/models"
 )
 
-type ConcurrentStreamsEvaluator struct{}
+// StreamLimitSource supplies per-user overrides of a rule's max_streams.
+type StreamLimitSource interface {
+	StreamLimit(ruleID int64, userName string) (int, bool)
+}
+
+type ConcurrentStreamsEvaluator struct {
+	limits StreamLimitSource
+}
 
 func NewConcurrentStreamsEvaluator() *ConcurrentStreamsEvaluator {
 	return &ConcurrentStreamsEvaluator{}
@@ -34,6 +41,12 @@ func (e *ConcurrentStreamsEvaluator) Evaluate(ctx context.Context, rule *models.
 	}
 
 	userName := input.Stream.UserName
+	var overridden bool
+	if e.limits != nil {
+		if maxStreams, ok := e.limits.StreamLimit(rule.ID, userName); ok {
+			config.MaxStreams, overridden = maxStreams, true
+		}
+	}
 	userStreams := filterStreamsByUser(input.AllStreams, userName)
 	if config.CountPausedAsOne {
 		userStreams = collapsePausedStreams(userStreams)
@@ -94,6 +107,7 @@ func (e *ConcurrentStreamsEvaluator) Evaluate(ctx context.Context, rule *models.
 		Details: map[string]interface{}{
 			"stream_count": streamCount,
 			"max_allowed":  config.MaxStreams,
+			"user_limit":   overridden,
 			"locations":    locations,
 			"devices":      devices,
 		},
@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type ContentRatingEvaluator struct{}
+
+func NewContentRatingEvaluator() *ContentRatingEvaluator {
+	return &ContentRatingEvaluator{}
+}
+
+func (e *ContentRatingEvaluator) Type() models.RuleType {
+	return models.RuleTypeContentRating
+}
+
+func (e *ContentRatingEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil {
+		return nil, nil
+	}
+
+	var config models.ContentRatingConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	stream := input.Stream
+	if !config.Restricts(stream.UserName) {
+		return nil, nil
+	}
+
+	maxAge, _ := models.ContentRatingAge(config.MaxRating)
+	age, rated := models.ContentRatingAge(stream.ContentRating)
+	var message string
+	switch {
+	case !rated && config.FlagUnrated:
+		message = fmt.Sprintf("restricted profile playing unrated content: %s", streamTitle(stream))
+	case rated && age > maxAge:
+		message = fmt.Sprintf("restricted profile playing %s content (limit %s): %s",
+			stream.ContentRating, config.MaxRating, streamTitle(stream))
+	default:
+		return nil, nil
+	}
+
+	v := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: stream.UserName,
+		Severity: config.Severity,
+		Message:  message,
+		Details: map[string]interface{}{
+			"content_rating": stream.ContentRating,
+			"max_rating":     config.MaxRating,
+			"title":          streamTitle(stream),
+		},
+		ConfidenceScore: 100,
+		OccurredAt:      time.Now().UTC(),
+	}
+
+	return &EvaluationResult{
+		Violation: v,
+		Signals: []models.ViolationSignal{
+			{Name: "content_rating", Weight: 1.0, Value: stream.ContentRating},
+		},
+	}, nil
+}
+
+func streamTitle(s *models.ActiveStream) string {
+	if s.GrandparentTitle != "" {
+		return s.GrandparentTitle + " - " + s.Title
+	}
+	return s.Title
+}
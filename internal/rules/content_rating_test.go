@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestContentRatingEvaluator_Type(t *testing.T) {
+	e := NewContentRatingEvaluator()
+	if e.Type() != models.RuleTypeContentRating {
+		t.Errorf("expected %s, got %s", models.RuleTypeContentRating, e.Type())
+	}
+}
+
+func TestContentRatingEvaluator_Ratings(t *testing.T) {
+	e := NewContentRatingEvaluator()
+	ctx := context.Background()
+
+	config := models.ContentRatingConfig{UserNames: []string{"Kid"}, MaxRating: "PG"}
+	configJSON, _ := json.Marshal(config)
+	rule := &models.Rule{ID: 1, Name: "Kids", Type: models.RuleTypeContentRating, Config: configJSON}
+
+	strict := models.ContentRatingConfig{UserNames: []string{"kid"}, MaxRating: "PG", FlagUnrated: true}
+	strictJSON, _ := json.Marshal(strict)
+	strictRule := &models.Rule{ID: 2, Name: "Kids strict", Type: models.RuleTypeContentRating, Config: strictJSON}
+
+	tests := []struct {
+		name     string
+		rule     *models.Rule
+		user     string
+		rating   string
+		wantViol bool
+	}{
+		{"within limit", rule, "kid", "PG", false},
+		{"above limit", rule, "kid", "R", true},
+		{"tv above limit", rule, "KID", "TV-14", true},
+		{"country rating above limit", rule, "kid", "gb/15", true},
+		{"unrestricted user", rule, "parent", "R", false},
+		{"unrated ignored", rule, "kid", "", false},
+		{"unrated flagged", strictRule, "kid", "NR", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &EvaluationInput{
+				Stream: &models.ActiveStream{UserName: tt.user, Title: "Movie", ContentRating: tt.rating},
+			}
+			result, err := e.Evaluate(ctx, tt.rule, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotViol := result != nil; gotViol != tt.wantViol {
+				t.Fatalf("violation = %v, want %v", gotViol, tt.wantViol)
+			}
+			if result != nil && result.Violation.Severity != models.SeverityWarning {
+				t.Errorf("severity = %q, want warning", result.Violation.Severity)
+			}
+		})
+	}
+}
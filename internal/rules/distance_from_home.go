@@ -0,0 +1,102 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+	"streammon/internal/units"
+)
+
+type DistanceFromHomeEvaluator struct {
+	geoResolver GeoResolver
+	store       HouseholdHomeQuerier
+}
+
+func NewDistanceFromHomeEvaluator(resolver GeoResolver, store HouseholdHomeQuerier) *DistanceFromHomeEvaluator {
+	return &DistanceFromHomeEvaluator{geoResolver: resolver, store: store}
+}
+
+func (e *DistanceFromHomeEvaluator) Type() models.RuleType {
+	return models.RuleTypeDistanceFromHome
+}
+
+func (e *DistanceFromHomeEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil || input.Stream.IPAddress == "" {
+		return nil, nil
+	}
+
+	var config models.DistanceFromHomeConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	stream := input.Stream
+	if config.ExemptHousehold && trustedHouseholdIPs(input.Households)[stream.IPAddress] {
+		return nil, nil
+	}
+
+	geo := input.GeoData
+	if geo == nil {
+		var err error
+		geo, err = e.geoResolver.Lookup(ctx, stream.IPAddress)
+		if err != nil || geo == nil {
+			return nil, nil
+		}
+	}
+	if geo.Lat == 0 && geo.Lng == 0 {
+		return nil, nil
+	}
+
+	home, err := ResolveHouseholdHome(e.store, stream.UserName)
+	if err != nil {
+		return nil, fmt.Errorf("resolving household home: %w", err)
+	}
+	if home == nil {
+		return nil, nil
+	}
+
+	distance := HaversineDistance(home.Latitude, home.Longitude, geo.Lat, geo.Lng)
+	// Anywhere inside the home radius is home, however far the limit is set.
+	if distance <= max(config.MaxDistanceKm, home.RadiusKm) {
+		return nil, nil
+	}
+
+	severity := models.SeverityWarning
+	if distance >= config.SeverityThresholdKm {
+		severity = models.SeverityCritical
+	}
+
+	signals := []models.ViolationSignal{
+		{Name: "distance_km", Weight: 0.6, Value: min(distance/config.MaxDistanceKm*50, 100)},
+		{Name: "home_confidence", Weight: 0.4, Value: home.Confidence},
+	}
+
+	v := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: stream.UserName,
+		Severity: severity,
+		Message: fmt.Sprintf("streaming %s from home: %s, %s",
+			units.FormatDistance(distance, input.UnitSystem), geo.City, geo.Country),
+		Details: map[string]interface{}{
+			"city":            geo.City,
+			"country":         geo.Country,
+			"ip":              stream.IPAddress,
+			"distance_km":     distance,
+			"max_distance_km": config.MaxDistanceKm,
+			"home_source":     home.Source,
+		},
+		ConfidenceScore: models.CalculateConfidence(signals),
+		OccurredAt:      time.Now().UTC(),
+	}
+
+	return &EvaluationResult{
+		Violation: v,
+		Signals:   signals,
+	}, nil
+}
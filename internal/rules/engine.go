@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"streammon/internal/media"
+	"streammon/internal/metrics"
 	"streammon/internal/models"
 	"streammon/internal/store"
 	"streammon/internal/units"
@@ -39,12 +42,22 @@ func getTerminateConfig(rule *models.Rule) terminateConfig {
 type EngineStore interface {
 	ListEnabledRules() ([]models.Rule, error)
 	ListAllRuleExemptions() (map[int64][]string, error)
+	ListAllRuleUserLimits() (map[int64]map[string]int, error)
 	GetUnitSystem() (string, error)
 	ListTrustedHouseholdLocations(userName string) ([]models.HouseholdLocation, error)
 	ViolationExistsRecent(ruleID int64, userName, sessionKey string, within time.Duration) (bool, error)
 	InsertViolationWithTx(ctx context.Context, v *models.RuleViolation, trustDecrement int) error
 	UpdateViolationAction(violationID int64, action string) error
 	GetChannelsForRule(ruleID int64) ([]models.NotificationChannel, error)
+	ObserveConcurrentStreams(ctx context.Context, count int, at time.Time) (models.ConcurrentRecordUpdate, error)
+	GetConcurrentRecordNotify() (bool, error)
+	ListEnabledNotificationChannels() ([]models.NotificationChannel, error)
+	ListNetworkLabels() ([]models.NetworkLabel, error)
+	GetHomeNetworkSettings() (models.HomeNetworkSettings, error)
+	ServerWorkspaces() (map[int64]int64, error)
+	UserAlertStore
+	WatchGoalStore
+	MilestoneStore
 }
 
 type Engine struct {
@@ -53,7 +66,11 @@ type Engine struct {
 	serverResolver ServerResolver
 	evaluators     map[models.RuleType]Evaluator
 	notifier       Notifier
-	exemptions     map[int64]map[string]bool // ruleID → set of exempt usernames
+	webhooks       WebhookDispatcher
+	windows        *slidingWindows
+	exemptions     map[int64]map[string]bool   // ruleID → set of exempt usernames
+	userLimits     map[int64]map[string]int    // ruleID → lowercased username → max streams
+	watchGoals     map[string]models.WatchGoal // username → goal, for users with a weekly limit
 
 	mu          sync.RWMutex
 	cachedRules []models.Rule
@@ -69,12 +86,23 @@ type Engine struct {
 
 	// Track in-flight notification goroutines for graceful shutdown
 	notifyWg sync.WaitGroup
+
+	watchLimitMu      sync.Mutex
+	watchLimitChecked map[string]time.Time
+
+	milestoneMu      sync.Mutex
+	milestoneChecked map[string]time.Time
 }
 
 type Notifier interface {
 	Notify(ctx context.Context, violation *models.RuleViolation, channels []models.NotificationChannel) error
 }
 
+// WebhookDispatcher sends outbound webhooks in the background.
+type WebhookDispatcher interface {
+	Dispatch(event models.WebhookEvent, data any)
+}
+
 type EngineConfig struct {
 	RuleCacheTTL      time.Duration
 	ViolationCooldown time.Duration
@@ -111,6 +139,7 @@ func NewEngine(s *store.Store, geo GeoResolver, config EngineConfig) *Engine {
 		store:                  s,
 		geoResolver:            geo,
 		evaluators:             make(map[models.RuleType]Evaluator),
+		windows:                newSlidingWindows(s, geo),
 		ruleCacheTTL:           config.RuleCacheTTL,
 		violationCooldown:      config.ViolationCooldown,
 		trustDecrementCritical: config.TrustDecrementCritical,
@@ -118,7 +147,7 @@ func NewEngine(s *store.Store, geo GeoResolver, config EngineConfig) *Engine {
 		trustDecrementInfo:     config.TrustDecrementInfo,
 	}
 
-	e.RegisterEvaluator(NewConcurrentStreamsEvaluator())
+	e.RegisterEvaluator(&ConcurrentStreamsEvaluator{limits: e})
 	e.RegisterEvaluator(NewGeoRestrictionEvaluator())
 	e.RegisterEvaluator(NewSimultaneousLocsEvaluator(geo))
 	e.RegisterEvaluator(NewImpossibleTravelEvaluator(geo, s))
@@ -126,6 +155,14 @@ func NewEngine(s *store.Store, geo GeoResolver, config EngineConfig) *Engine {
 	e.RegisterEvaluator(NewNewDeviceEvaluator(s))
 	e.RegisterEvaluator(NewNewLocationEvaluator(geo, s))
 	e.RegisterEvaluator(NewISPVelocityEvaluator(geo, s))
+	e.RegisterEvaluator(NewBandwidthQuotaEvaluator(s))
+	e.RegisterEvaluator(NewClientMatchEvaluator())
+	e.RegisterEvaluator(NewDistanceFromHomeEvaluator(geo, s))
+	e.RegisterEvaluator(NewHostingIPEvaluator())
+	e.RegisterEvaluator(NewContentRatingEvaluator())
+	e.RegisterEvaluator(NewNetworkLabelEvaluator())
+	e.RegisterEvaluator(NewTranscodeBudgetEvaluator())
+	e.RegisterEvaluator(&SlidingWindowEvaluator{windows: e.windows, now: time.Now})
 
 	return e
 }
@@ -138,6 +175,10 @@ func (e *Engine) SetNotifier(n Notifier) {
 	e.notifier = n
 }
 
+func (e *Engine) SetWebhooks(w WebhookDispatcher) {
+	e.webhooks = w
+}
+
 func (e *Engine) SetServerResolver(sr ServerResolver) {
 	e.serverResolver = sr
 }
@@ -150,6 +191,13 @@ type evalContext struct {
 	// households caches trusted household locations per user within a single
 	// tick so multiple sessions from the same user don't re-query the store.
 	households map[string][]models.HouseholdLocation
+	// networkLabels are the admin-defined networks sessions are tagged with.
+	networkLabels []models.NetworkLabel
+	// homeNetworks decides which sessions are Local.
+	homeNetworks models.HomeNetworkSettings
+	// serverWorkspaces maps servers to their workspace, so workspace rules
+	// only see that workspace's sessions.
+	serverWorkspaces map[int64]int64
 }
 
 // newEvalContext gathers the per-tick-constant reads (enabled rules + unit
@@ -165,10 +213,30 @@ func (e *Engine) newEvalContext() (*evalContext, error) {
 		unitSys = units.ParseSystem(sys)
 	}
 
+	labels, err := e.store.ListNetworkLabels()
+	if err != nil {
+		log.Printf("rules engine: listing network labels: %v", err)
+	}
+
+	home, err := e.store.GetHomeNetworkSettings()
+	if err != nil {
+		log.Printf("rules engine: reading home networks: %v", err)
+	}
+
+	workspaces, err := e.store.ServerWorkspaces()
+	if err != nil {
+		// Without the mapping no session can be placed in a workspace, so
+		// workspace rules are skipped rather than applied everywhere.
+		log.Printf("rules engine: listing workspace servers: %v", err)
+	}
+
 	return &evalContext{
-		rules:      rules,
-		unitSystem: unitSys,
-		households: make(map[string][]models.HouseholdLocation),
+		rules:            rules,
+		unitSystem:       unitSys,
+		households:       make(map[string][]models.HouseholdLocation),
+		networkLabels:    labels,
+		homeNetworks:     home,
+		serverWorkspaces: workspaces,
 	}, nil
 }
 
@@ -193,17 +261,91 @@ func (e *Engine) EvaluateSessions(ctx context.Context, streams []models.ActiveSt
 		return
 	}
 
+	e.checkConcurrentRecord(ctx, len(streams))
+
 	ec, err := e.newEvalContext()
 	if err != nil {
 		log.Printf("rules engine: failed to get rules: %v", err)
 		return
 	}
+	e.checkWatchLimits(ctx, streams)
+	e.checkMilestones(ctx, streams)
+	e.observeWindows(ctx, ec.rules, streams)
 
 	for i := range streams {
 		e.evaluateStream(ctx, &streams[i], streams, ec)
 	}
 }
 
+// checkConcurrentRecord records new all-time and rolling 30-day concurrent
+// stream peaks and, when enabled in settings, announces them on every enabled
+// notification channel. Beating both records sends a single all-time notice.
+func (e *Engine) checkConcurrentRecord(ctx context.Context, count int) {
+	now := time.Now().UTC()
+	upd, err := e.store.ObserveConcurrentStreams(ctx, count, now)
+	if err != nil {
+		log.Printf("rules engine: observe concurrent streams: %v", err)
+		return
+	}
+
+	var prev models.ConcurrentRecord
+	var window, label string
+	switch {
+	case upd.NewAllTime:
+		prev, window, label = upd.AllTime, "all_time", "all-time"
+	case upd.NewLast30Days:
+		prev, window, label = upd.Last30Days, "last_30_days", "30-day"
+	default:
+		return
+	}
+	log.Printf("rules engine: new %s concurrent stream record: %d (previous %d)", label, count, prev.Count)
+
+	if e.notifier == nil {
+		return
+	}
+	notify, err := e.store.GetConcurrentRecordNotify()
+	if err != nil || !notify {
+		return
+	}
+
+	violation := &models.RuleViolation{
+		RuleName:        "Concurrent Stream Record",
+		Severity:        models.SeverityInfo,
+		Message:         fmt.Sprintf("New %s record: %d concurrent streams (previous record %d)", label, count, prev.Count),
+		ConfidenceScore: 100,
+		Details: map[string]interface{}{
+			"window":         window,
+			"count":          count,
+			"previous_count": prev.Count,
+			"previous_at":    prev.At,
+		},
+		OccurredAt: now,
+	}
+
+	e.notifyWg.Add(1)
+	go func() {
+		defer e.notifyWg.Done()
+
+		channels, err := e.store.ListEnabledNotificationChannels()
+		if err != nil {
+			log.Printf("rules engine: error listing notification channels: %v", err)
+			return
+		}
+		violation.Event = models.NotificationEventConcurrentRecord
+		channels = channelsForEvent(channels, violation.Event, violation, 0)
+		if len(channels) == 0 {
+			return
+		}
+
+		notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := e.notifier.Notify(notifyCtx, violation, channels); err != nil {
+			log.Printf("rules engine: error sending record notification: %v", err)
+		}
+	}()
+}
+
 // EvaluateSession evaluates a single session, building a one-off tick context.
 // Retained for callers/tests that evaluate one stream at a time.
 func (e *Engine) EvaluateSession(ctx context.Context, stream *models.ActiveStream, allStreams []models.ActiveStream) {
@@ -225,6 +367,44 @@ func (e *Engine) evaluateStream(ctx context.Context, stream *models.ActiveStream
 		return
 	}
 
+	input := e.buildInput(ctx, stream, allStreams, ec)
+	workspaceID := ec.serverWorkspaces[stream.ServerID]
+	var workspaceInput *EvaluationInput
+
+	for _, rule := range ec.rules {
+		if !rule.Type.IsRealTime() {
+			continue
+		}
+
+		evaluator, ok := e.evaluators[rule.Type]
+		if !ok {
+			continue
+		}
+
+		if rule.WorkspaceID == nil {
+			e.evaluateRule(ctx, &rule, evaluator, input)
+			continue
+		}
+		if !rule.InWorkspace(workspaceID) {
+			continue
+		}
+		// A workspace rule only weighs the sessions on its own servers.
+		if workspaceInput == nil {
+			scoped := *input
+			scoped.AllStreams = nil
+			for _, s := range allStreams {
+				if ec.serverWorkspaces[s.ServerID] == workspaceID {
+					scoped.AllStreams = append(scoped.AllStreams, s)
+				}
+			}
+			workspaceInput = &scoped
+		}
+		e.evaluateRule(ctx, &rule, evaluator, workspaceInput)
+	}
+}
+
+// buildInput gathers what the evaluators need to know about stream.
+func (e *Engine) buildInput(ctx context.Context, stream *models.ActiveStream, allStreams []models.ActiveStream, ec *evalContext) *EvaluationInput {
 	input := &EvaluationInput{
 		Stream:     stream,
 		AllStreams: allStreams,
@@ -240,18 +420,15 @@ func (e *Engine) evaluateStream(ctx context.Context, stream *models.ActiveStream
 
 	input.Households = e.householdsFor(ec, stream.UserName)
 
-	for _, rule := range ec.rules {
-		if !rule.Type.IsRealTime() {
-			continue
-		}
-
-		evaluator, ok := e.evaluators[rule.Type]
-		if !ok {
-			continue
+	if len(ec.networkLabels) > 0 && stream.IPAddress != "" {
+		var asn uint
+		if input.GeoData != nil {
+			asn = input.GeoData.ASN
 		}
-
-		e.evaluateRule(ctx, &rule, evaluator, input)
+		input.NetworkLabel = models.MatchNetworkLabel(ec.networkLabels, stream.IPAddress, asn)
 	}
+	input.Local = ec.homeNetworks.IsLocal(stream.IPAddress)
+	return input
 }
 
 func (e *Engine) evaluateRule(ctx context.Context, rule *models.Rule, evaluator Evaluator, input *EvaluationInput) {
@@ -262,14 +439,20 @@ func (e *Engine) evaluateRule(ctx context.Context, rule *models.Rule, evaluator
 
 	result, err := evaluator.Evaluate(ctx, rule, input)
 	if err != nil {
+		metrics.RuleEvaluations.Inc(string(rule.Type), "error")
 		log.Printf("rules engine: error evaluating rule %d (%s): %v", rule.ID, rule.Name, err)
 		return
 	}
 
 	if result == nil || result.Violation == nil {
+		metrics.RuleEvaluations.Inc(string(rule.Type), "pass")
 		return
 	}
+	metrics.RuleEvaluations.Inc(string(rule.Type), "violation")
 
+	if input.Stream != nil {
+		result.Violation.ServerID = input.Stream.ServerID
+	}
 	if input.Stream != nil && input.Stream.SessionID != "" {
 		result.Violation.SessionKey = input.Stream.SessionID
 	} else {
@@ -295,55 +478,158 @@ func (e *Engine) evaluateRule(ctx context.Context, rule *models.Rule, evaluator
 	log.Printf("rules engine: violation detected - rule=%s user=%s severity=%s confidence=%.1f",
 		rule.Name, result.Violation.UserName, result.Violation.Severity, result.Violation.ConfidenceScore)
 
-	// Auto-terminate if configured
-	tc := getTerminateConfig(rule)
-	if tc.Enabled && e.serverResolver != nil {
-		msg := tc.Message
-		if msg == "" {
-			msg = defaultAutoTerminateMessage
+	// Messages go first: once a stream is terminated there's no screen
+	// left to show them on.
+	actions := ruleActions(rule)
+	for _, action := range actions {
+		if action.Type == models.RuleActionMessageUser {
+			e.messageForViolation(ctx, rule, input, result, action.Message)
 		}
-
-		var serverID int64
-		var sessionID, plexUUID string
-
-		switch rule.Type {
-		case models.RuleTypeConcurrentStreams:
-			// Target: newest stream, supplied out-of-band by the evaluator
-			// (not persisted on the violation).
-			if t := result.TerminateTarget; t != nil {
-				serverID = t.ServerID
-				sessionID = t.SessionID
-				plexUUID = t.PlexSessionUUID
-			}
-		default:
-			// Target: the stream being evaluated
-			if input.Stream != nil {
-				serverID = input.Stream.ServerID
-				sessionID = input.Stream.SessionID
-				plexUUID = input.Stream.PlexSessionUUID
-			}
+	}
+	for _, action := range actions {
+		if action.Type == models.RuleActionTerminateStream {
+			e.terminateForViolation(ctx, rule, input, result, action.Message)
 		}
+	}
 
-		if serverID > 0 && sessionID != "" {
-			if err := e.terminateStream(ctx, serverID, sessionID, plexUUID, msg); err != nil {
-				log.Printf("rules engine: auto-terminate failed for violation %d: %v", result.Violation.ID, err)
-				result.Violation.ActionTaken = "terminate_failed"
-			} else {
-				log.Printf("rules engine: auto-terminated stream for violation %d (rule=%s user=%s)", result.Violation.ID, rule.Name, result.Violation.UserName)
-				result.Violation.ActionTaken = "terminated"
-			}
-			if updateErr := e.store.UpdateViolationAction(result.Violation.ID, result.Violation.ActionTaken); updateErr != nil {
-				log.Printf("rules engine: failed to update violation action: %v", updateErr)
-			}
+	if e.webhooks != nil {
+		v := *result.Violation
+		v.RuleName = rule.Name
+		v.RuleType = rule.Type
+		data := models.RuleTriggeredWebhook{Violation: &v, Geo: input.GeoData}
+		if input.Stream != nil {
+			stream := *input.Stream
+			data.Session = &stream
 		}
+		e.webhooks.Dispatch(models.WebhookEventRuleTriggered, data)
 	}
 
 	if e.notifier != nil {
+		v := result.Violation
+		v.Notification = rule.Notification
+		v.Geo = input.GeoData
+		if input.Stream != nil {
+			stream := *input.Stream
+			v.Stream = &stream
+		}
 		e.notifyWg.Add(1)
-		go e.sendNotifications(rule.ID, result.Violation)
+		go e.sendNotifications(rule.ID, rule.Type, v)
+	}
+}
+
+// ruleActions returns the actions to run for a rule's violations. Rules
+// created before actions existed carry auto_terminate in their config; that
+// is folded in as a terminate_stream action unless one is already listed.
+// A transcode budget's overflow policy is folded in the same way.
+func ruleActions(rule *models.Rule) []models.RuleAction {
+	actions := rule.Actions
+	if rule.Type == models.RuleTypeTranscodeBudget {
+		return transcodeBudgetActions(rule, actions)
+	}
+	tc := getTerminateConfig(rule)
+	if !tc.Enabled {
+		return actions
+	}
+	for _, a := range actions {
+		if a.Type == models.RuleActionTerminateStream {
+			return actions
+		}
+	}
+	return append(slices.Clone(actions), models.RuleAction{
+		Type:    models.RuleActionTerminateStream,
+		Message: tc.Message,
+	})
+}
+
+// violationTarget returns the session a violation's actions apply to.
+func violationTarget(rule *models.Rule, input *EvaluationInput, result *EvaluationResult) (serverID int64, sessionID, plexUUID string) {
+	switch rule.Type {
+	case models.RuleTypeConcurrentStreams, models.RuleTypeTranscodeBudget:
+		// Target: the newest stream, or the session a transcode budget
+		// picked, supplied out-of-band by the evaluator (not persisted on
+		// the violation).
+		if t := result.TerminateTarget; t != nil {
+			return t.ServerID, t.SessionID, t.PlexSessionUUID
+		}
+	default:
+		// Target: the stream being evaluated
+		if input.Stream != nil {
+			return input.Stream.ServerID, input.Stream.SessionID, input.Stream.PlexSessionUUID
+		}
+	}
+	return 0, "", ""
+}
+
+// recordViolationAction appends action to the violation's action_taken, so
+// a message followed by a termination reads "messaged,terminated".
+func (e *Engine) recordViolationAction(result *EvaluationResult, action string) {
+	v := result.Violation
+	if v.ActionTaken != "" {
+		action = v.ActionTaken + "," + action
+	}
+	v.ActionTaken = action
+	if err := e.store.UpdateViolationAction(v.ID, v.ActionTaken); err != nil {
+		log.Printf("rules engine: failed to update violation action: %v", err)
 	}
 }
 
+// terminateForViolation stops the session that triggered a violation and
+// records the outcome on it.
+func (e *Engine) terminateForViolation(ctx context.Context, rule *models.Rule, input *EvaluationInput, result *EvaluationResult, message string) {
+	if e.serverResolver == nil {
+		return
+	}
+	msg := message
+	if msg == "" {
+		msg = defaultAutoTerminateMessage
+	}
+
+	serverID, sessionID, plexUUID := violationTarget(rule, input, result)
+	if serverID <= 0 || sessionID == "" {
+		return
+	}
+	if err := e.terminateStream(ctx, serverID, sessionID, plexUUID, msg); err != nil {
+		log.Printf("rules engine: auto-terminate failed for violation %d: %v", result.Violation.ID, err)
+		e.recordViolationAction(result, "terminate_failed")
+		return
+	}
+	log.Printf("rules engine: auto-terminated stream for violation %d (rule=%s user=%s)", result.Violation.ID, rule.Name, result.Violation.UserName)
+	e.recordViolationAction(result, "terminated")
+}
+
+// messageForViolation shows message on the offending session's screen.
+// Servers without on-screen messaging are recorded as message_unsupported
+// rather than failed, since retrying wouldn't help.
+func (e *Engine) messageForViolation(ctx context.Context, rule *models.Rule, input *EvaluationInput, result *EvaluationResult, message string) {
+	if e.serverResolver == nil {
+		return
+	}
+	serverID, sessionID, _ := violationTarget(rule, input, result)
+	if serverID <= 0 || sessionID == "" {
+		return
+	}
+	ms, ok := e.serverResolver.GetServer(serverID)
+	if !ok {
+		log.Printf("rules engine: message failed for violation %d: server %d not found", result.Violation.ID, serverID)
+		e.recordViolationAction(result, "message_failed")
+		return
+	}
+	messenger, ok := ms.(media.SessionMessenger)
+	if !ok {
+		e.recordViolationAction(result, "message_unsupported")
+		return
+	}
+
+	msgCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := messenger.SendSessionMessage(msgCtx, sessionID, message); err != nil {
+		log.Printf("rules engine: message failed for violation %d: %v", result.Violation.ID, err)
+		e.recordViolationAction(result, "message_failed")
+		return
+	}
+	e.recordViolationAction(result, "messaged")
+}
+
 func (e *Engine) terminateStream(ctx context.Context, serverID int64, sessionID, plexSessionUUID, message string) error {
 	if e.serverResolver == nil {
 		return fmt.Errorf("no server resolver configured")
@@ -365,28 +651,67 @@ func (e *Engine) terminateStream(ctx context.Context, serverID int64, sessionID,
 	return ms.TerminateSession(terminateCtx, terminateID, message)
 }
 
-func (e *Engine) sendNotifications(ruleID int64, violation *models.RuleViolation) {
+func (e *Engine) sendNotifications(ruleID int64, ruleType models.RuleType, violation *models.RuleViolation) {
 	defer e.notifyWg.Done()
 
+	// Use background context so notifications complete even during shutdown
+	notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	e.notifyUsers(notifyCtx, ruleType, violation)
+
 	channels, err := e.store.GetChannelsForRule(ruleID)
 	if err != nil {
 		log.Printf("rules engine: error getting channels for rule %d: %v", ruleID, err)
 		return
 	}
 
+	violation.Event = models.NotificationEventRuleViolation
+	channels = channelsForEvent(channels, violation.Event, violation, e.eventWorkspace(violation))
 	if len(channels) == 0 {
 		return
 	}
 
-	// Use background context so notifications complete even during shutdown
-	notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	if err := e.notifier.Notify(notifyCtx, violation, channels); err != nil {
 		log.Printf("rules engine: error sending notifications: %v", err)
 	}
 }
 
+// channelsForEvent keeps the channels whose event matrix accepts event for
+// the violation's user and server. Workspace channels are kept only when
+// the event happened in their workspace, workspaceID, which is 0 for events
+// outside every workspace.
+func channelsForEvent(channels []models.NotificationChannel, event models.NotificationEvent, v *models.RuleViolation, workspaceID int64) []models.NotificationChannel {
+	var serverID int64
+	if v.Stream != nil {
+		serverID = v.Stream.ServerID
+	}
+	out := channels[:0:0]
+	for _, ch := range channels {
+		if ch.WorkspaceID != nil && !ch.InWorkspace(workspaceID) {
+			continue
+		}
+		if ch.Events.Allows(event, v.UserName, serverID) {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// eventWorkspace returns the workspace of the server v's stream played on,
+// or 0 when it has no stream or the server is in no workspace.
+func (e *Engine) eventWorkspace(v *models.RuleViolation) int64 {
+	if v.Stream == nil {
+		return 0
+	}
+	workspaces, err := e.store.ServerWorkspaces()
+	if err != nil {
+		log.Printf("rules engine: listing workspace servers: %v", err)
+		return 0
+	}
+	return workspaces[v.Stream.ServerID]
+}
+
 // WaitForNotifications waits for all in-flight notification goroutines to complete.
 // Call this during graceful shutdown.
 func (e *Engine) WaitForNotifications() {
@@ -428,9 +753,13 @@ func (e *Engine) getEnabledRules() ([]models.Rule, error) {
 	}
 
 	exemptions := e.loadExemptions()
+	userLimits := e.loadUserLimits()
+	watchGoals := e.loadWatchGoals()
 
 	e.cachedRules = rules
 	e.exemptions = exemptions
+	e.userLimits = userLimits
+	e.watchGoals = watchGoals
 	e.lastRefresh = time.Now().UTC()
 
 	return rules, nil
@@ -443,10 +772,14 @@ func (e *Engine) RefreshRules() error {
 	}
 
 	exemptions := e.loadExemptions()
+	userLimits := e.loadUserLimits()
+	watchGoals := e.loadWatchGoals()
 
 	e.mu.Lock()
 	e.cachedRules = rules
 	e.exemptions = exemptions
+	e.userLimits = userLimits
+	e.watchGoals = watchGoals
 	e.lastRefresh = time.Now().UTC()
 	e.mu.Unlock()
 
@@ -470,11 +803,30 @@ func (e *Engine) loadExemptions() map[int64]map[string]bool {
 	return exemptions
 }
 
+func (e *Engine) loadUserLimits() map[int64]map[string]int {
+	limitMap, err := e.store.ListAllRuleUserLimits()
+	if err != nil {
+		log.Printf("rules engine: failed to load user limits: %v", err)
+		return nil
+	}
+	limits := make(map[int64]map[string]int, len(limitMap))
+	for ruleID, users := range limitMap {
+		byName := make(map[string]int, len(users))
+		for n, maxStreams := range users {
+			byName[strings.ToLower(n)] = maxStreams
+		}
+		limits[ruleID] = byName
+	}
+	return limits
+}
+
 // InvalidateCache clears the rules cache, forcing the next evaluation to fetch fresh rules.
 func (e *Engine) InvalidateCache() {
 	e.mu.Lock()
 	e.cachedRules = nil
 	e.exemptions = nil
+	e.userLimits = nil
+	e.watchGoals = nil
 	e.lastRefresh = time.Time{}
 	e.mu.Unlock()
 }
@@ -488,6 +840,14 @@ func (e *Engine) isExempt(ruleID int64, userName string) bool {
 	return e.exemptions[ruleID][strings.ToLower(userName)]
 }
 
+// StreamLimit returns the user's max_streams override for a rule, if any.
+func (e *Engine) StreamLimit(ruleID int64, userName string) (int, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	maxStreams, ok := e.userLimits[ruleID][strings.ToLower(userName)]
+	return maxStreams, ok
+}
+
 func (e *Engine) GetEvaluators() map[models.RuleType]Evaluator {
 	return e.evaluators
 }
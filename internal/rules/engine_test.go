@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -248,6 +249,62 @@ func TestEngine_EvaluateSession_ConcurrentStreams(t *testing.T) {
 	}
 }
 
+func TestEngine_EvaluateSession_WorkspaceRule(t *testing.T) {
+	e, s := setupTestEngine(t)
+	ctx := context.Background()
+
+	friends := &models.Server{Name: "Friends", Type: models.ServerTypePlex, URL: "http://friends", APIKey: "k", Enabled: true}
+	family := &models.Server{Name: "Family", Type: models.ServerTypePlex, URL: "http://family", APIKey: "k", Enabled: true}
+	for _, srv := range []*models.Server{friends, family} {
+		if err := s.CreateServer(srv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws := &models.Workspace{Name: "Friends"}
+	if err := s.CreateWorkspace(ws); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetWorkspaceServers(ctx, ws.ID, []int64{friends.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	configJSON, _ := json.Marshal(models.ConcurrentStreamsConfig{MaxStreams: 2})
+	rule := &models.Rule{
+		Name: "Friends max 2", Type: models.RuleTypeConcurrentStreams, Enabled: true,
+		Config: configJSON, WorkspaceID: &ws.ID,
+	}
+	if err := s.CreateRule(rule); err != nil {
+		t.Fatalf("CreateRule: %v", err)
+	}
+	e.RefreshRules()
+
+	now := time.Now().UTC()
+	stream := func(id string, serverID int64) models.ActiveStream {
+		return models.ActiveStream{SessionID: id, ServerID: serverID, UserName: "testuser", IPAddress: "192.168.1." + id, StartedAt: now}
+	}
+	violations := func() int {
+		t.Helper()
+		result, err := s.ListViolations(1, 10, store.ViolationFilters{UserName: "testuser"})
+		if err != nil {
+			t.Fatalf("ListViolations: %v", err)
+		}
+		return result.Total
+	}
+
+	// Three streams in all, but only one on the workspace's server.
+	streams := []models.ActiveStream{stream("1", friends.ID), stream("2", family.ID), stream("3", family.ID)}
+	e.EvaluateSessions(ctx, streams)
+	if n := violations(); n != 0 {
+		t.Fatalf("violations = %d, want 0 with one stream in the workspace", n)
+	}
+
+	streams = append(streams, stream("4", friends.ID), stream("5", friends.ID))
+	e.EvaluateSessions(ctx, streams)
+	if n := violations(); n == 0 {
+		t.Error("expected a violation with three streams in the workspace")
+	}
+}
+
 func TestEngine_EvaluateSession_ViolationCooldown(t *testing.T) {
 	e, s := setupTestEngine(t)
 	e.violationCooldown = 1 * time.Hour
@@ -476,6 +533,7 @@ type mockMediaServer struct {
 	id            int64
 	serverType    models.ServerType
 	terminatedIDs []string
+	messages      []string
 	terminateErr  error
 	mu            sync.Mutex
 }
@@ -513,6 +571,7 @@ func (m *mockMediaServer) TerminateSession(ctx context.Context, sessionID string
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.terminatedIDs = append(m.terminatedIDs, sessionID)
+	m.messages = append(m.messages, message)
 	return m.terminateErr
 }
 func (m *mockMediaServer) getTerminatedIDs() []string {
@@ -598,6 +657,46 @@ func TestEngine_ExemptUser_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestEngine_UserStreamLimitOverride(t *testing.T) {
+	e, s := setupTestEngine(t)
+	ctx := context.Background()
+
+	configJSON, _ := json.Marshal(models.ConcurrentStreamsConfig{MaxStreams: 1})
+	rule := &models.Rule{
+		Name:    "Max 1 Stream",
+		Type:    models.RuleTypeConcurrentStreams,
+		Enabled: true,
+		Config:  configJSON,
+	}
+	s.CreateRule(rule)
+
+	if err := s.SetRuleUserLimits(rule.ID, []models.RuleUserLimit{{UserName: "Family", MaxStreams: 3}}); err != nil {
+		t.Fatalf("SetRuleUserLimits: %v", err)
+	}
+	e.RefreshRules()
+
+	now := time.Now().UTC()
+	var streams []models.ActiveStream
+	for i, user := range []string{"family", "family", "family", "solo", "solo"} {
+		streams = append(streams, models.ActiveStream{
+			SessionID: fmt.Sprintf("s%d", i), UserName: user, IPAddress: "10.0.0.1", StartedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	e.EvaluateSessions(ctx, streams)
+
+	family, _ := s.ListViolations(1, 10, store.ViolationFilters{UserName: "family"})
+	if family.Total != 0 {
+		t.Errorf("expected the override to allow 3 streams, got %d violations", family.Total)
+	}
+	solo, _ := s.ListViolations(1, 10, store.ViolationFilters{UserName: "solo"})
+	if solo.Total == 0 {
+		t.Fatal("expected the rule's own limit to apply to users without an override")
+	}
+	if solo.Items[0].Details["user_limit"] != false {
+		t.Errorf("user_limit = %v, want false", solo.Items[0].Details["user_limit"])
+	}
+}
+
 func TestEngine_AutoTerminate_ConcurrentStreams(t *testing.T) {
 	e, s := setupTestEngine(t)
 	ctx := context.Background()
@@ -846,3 +945,293 @@ func TestEngine_AutoTerminate_GeoRestriction(t *testing.T) {
 		t.Errorf("ActionTaken = %q, want %q", result.Items[0].ActionTaken, "terminated")
 	}
 }
+
+func TestEngine_TerminateStreamAction(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := s.Migrate("../../migrations"); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	geo := &mockGeoResolver{
+		results: map[string]*models.GeoResult{
+			"1.2.3.4": {IP: "1.2.3.4", Country: "RU", City: "Moscow"},
+		},
+	}
+
+	e := NewEngine(s, geo, DefaultEngineConfig())
+	ms := &mockMediaServer{id: 1, serverType: models.ServerTypePlex}
+	e.SetServerResolver(&mockServerResolver{servers: map[int64]media.MediaServer{1: ms}})
+
+	configJSON, _ := json.Marshal(models.GeoRestrictionConfig{AllowedCountries: []string{"US"}})
+	rule := &models.Rule{
+		Name:    "US Only",
+		Type:    models.RuleTypeGeoRestriction,
+		Enabled: true,
+		Config:  configJSON,
+		Actions: []models.RuleAction{{Type: models.RuleActionTerminateStream, Message: "Not available in your region"}},
+	}
+	if err := s.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	e.RefreshRules()
+
+	stream := &models.ActiveStream{
+		SessionID:       "42",
+		PlexSessionUUID: "uuid-42",
+		ServerID:        1,
+		UserName:        "testuser",
+		IPAddress:       "1.2.3.4",
+	}
+	e.EvaluateSession(context.Background(), stream, []models.ActiveStream{*stream})
+
+	terminated := ms.getTerminatedIDs()
+	if len(terminated) != 1 || terminated[0] != "uuid-42" {
+		t.Fatalf("expected Plex session uuid-42 terminated, got %v", terminated)
+	}
+	if ms.messages[0] != "Not available in your region" {
+		t.Errorf("message = %q, want the action's message", ms.messages[0])
+	}
+
+	result, _ := s.ListViolations(1, 10, store.ViolationFilters{UserName: "testuser"})
+	if result.Total != 1 || result.Items[0].ActionTaken != "terminated" {
+		t.Fatalf("expected one terminated violation, got %+v", result.Items)
+	}
+}
+
+type mockMessengerServer struct {
+	*mockMediaServer
+	sent []string
+}
+
+func (m *mockMessengerServer) SendSessionMessage(ctx context.Context, sessionID, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.terminatedIDs) > 0 {
+		return fmt.Errorf("session %s already terminated", sessionID)
+	}
+	m.sent = append(m.sent, sessionID+":"+message)
+	return nil
+}
+
+func TestEngine_MessageUserAction(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := s.Migrate("../../migrations"); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	geo := &mockGeoResolver{
+		results: map[string]*models.GeoResult{
+			"1.2.3.4": {IP: "1.2.3.4", Country: "RU", City: "Moscow"},
+		},
+	}
+
+	e := NewEngine(s, geo, DefaultEngineConfig())
+	emby := &mockMessengerServer{mockMediaServer: &mockMediaServer{id: 1, serverType: models.ServerTypeEmby}}
+	plex := &mockMediaServer{id: 2, serverType: models.ServerTypePlex}
+	e.SetServerResolver(&mockServerResolver{servers: map[int64]media.MediaServer{1: emby, 2: plex}})
+
+	configJSON, _ := json.Marshal(models.GeoRestrictionConfig{AllowedCountries: []string{"US"}})
+	rule := &models.Rule{
+		Name:    "US Only",
+		Type:    models.RuleTypeGeoRestriction,
+		Enabled: true,
+		Config:  configJSON,
+		// Listed terminate-first to check messages still go out before the stop.
+		Actions: []models.RuleAction{
+			{Type: models.RuleActionTerminateStream},
+			{Type: models.RuleActionMessageUser, Message: "Stopping in 5 minutes"},
+		},
+	}
+	if err := s.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	e.RefreshRules()
+
+	ctx := context.Background()
+	embyStream := &models.ActiveStream{SessionID: "e1", ServerID: 1, UserName: "alice", IPAddress: "1.2.3.4"}
+	e.EvaluateSession(ctx, embyStream, []models.ActiveStream{*embyStream})
+	plexStream := &models.ActiveStream{SessionID: "p1", ServerID: 2, UserName: "bob", IPAddress: "1.2.3.4"}
+	e.EvaluateSession(ctx, plexStream, []models.ActiveStream{*plexStream})
+
+	if len(emby.sent) != 1 || emby.sent[0] != "e1:Stopping in 5 minutes" {
+		t.Fatalf("expected message sent before termination, got %v", emby.sent)
+	}
+
+	for user, want := range map[string]string{
+		"alice": "messaged,terminated",
+		"bob":   "message_unsupported,terminated",
+	} {
+		result, _ := s.ListViolations(1, 10, store.ViolationFilters{UserName: user})
+		if result.Total != 1 {
+			t.Fatalf("%s: expected 1 violation, got %d", user, result.Total)
+		}
+		if got := result.Items[0].ActionTaken; got != want {
+			t.Errorf("%s: ActionTaken = %q, want %q", user, got, want)
+		}
+	}
+}
+
+func TestRuleActions_LegacyAutoTerminate(t *testing.T) {
+	legacy := &models.Rule{Config: json.RawMessage(`{"auto_terminate":true,"terminate_message":"bye"}`)}
+	actions := ruleActions(legacy)
+	if len(actions) != 1 || actions[0].Type != models.RuleActionTerminateStream || actions[0].Message != "bye" {
+		t.Fatalf("legacy config not folded into actions: %+v", actions)
+	}
+
+	both := &models.Rule{
+		Config:  json.RawMessage(`{"auto_terminate":true,"terminate_message":"bye"}`),
+		Actions: []models.RuleAction{{Type: models.RuleActionTerminateStream, Message: "explicit"}},
+	}
+	actions = ruleActions(both)
+	if len(actions) != 1 || actions[0].Message != "explicit" {
+		t.Fatalf("explicit action should win over legacy config: %+v", actions)
+	}
+
+	if actions := ruleActions(&models.Rule{Config: json.RawMessage(`{}`)}); len(actions) != 0 {
+		t.Fatalf("expected no actions, got %+v", actions)
+	}
+}
+
+func TestEngine_EvaluateSessions_ConcurrentRecordNotification(t *testing.T) {
+	e, s := setupTestEngine(t)
+	ctx := context.Background()
+
+	notif := &mockNotifier{}
+	e.SetNotifier(notif)
+
+	channel := &models.NotificationChannel{
+		Name:        "Test Discord",
+		ChannelType: models.ChannelTypeDiscord,
+		Config:      json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/test"}`),
+		Enabled:     true,
+	}
+	if err := s.CreateNotificationChannel(channel); err != nil {
+		t.Fatalf("CreateNotificationChannel: %v", err)
+	}
+
+	now := time.Now().UTC()
+	one := []models.ActiveStream{{SessionID: "a", UserName: "alice", StartedAt: now}}
+	two := append(one, models.ActiveStream{SessionID: "b", UserName: "bob", StartedAt: now})
+
+	// Establishes the baseline record; never announced.
+	e.EvaluateSessions(ctx, one)
+	// New record while notifications are disabled: persisted, not sent.
+	e.EvaluateSessions(ctx, two)
+	e.WaitForNotifications()
+	if notif.count() != 0 {
+		t.Fatalf("expected no notifications while disabled, got %d", notif.count())
+	}
+
+	if err := s.SetConcurrentRecordNotify(true); err != nil {
+		t.Fatal(err)
+	}
+	e.EvaluateSessions(ctx, two)
+	e.WaitForNotifications()
+	if notif.count() != 0 {
+		t.Fatalf("tying the record should not notify, got %d", notif.count())
+	}
+
+	three := append(two, models.ActiveStream{SessionID: "c", UserName: "carol", StartedAt: now})
+	e.EvaluateSessions(ctx, three)
+	e.WaitForNotifications()
+	if notif.count() != 1 {
+		t.Fatalf("expected 1 record notification, got %d", notif.count())
+	}
+}
+
+func TestEngine_EvaluateSessions_RollingConcurrentRecordNotification(t *testing.T) {
+	e, s := setupTestEngine(t)
+	ctx := context.Background()
+
+	notif := &mockNotifier{}
+	e.SetNotifier(notif)
+
+	channel := &models.NotificationChannel{
+		Name:        "Test Discord",
+		ChannelType: models.ChannelTypeDiscord,
+		Config:      json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/test"}`),
+		Enabled:     true,
+	}
+	if err := s.CreateNotificationChannel(channel); err != nil {
+		t.Fatalf("CreateNotificationChannel: %v", err)
+	}
+	if err := s.SetConcurrentRecordNotify(true); err != nil {
+		t.Fatal(err)
+	}
+
+	// An all-time peak of three, from before the rolling window.
+	srv := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := s.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().AddDate(0, 0, -60)
+	for i, user := range []string{"alice", "bob", "carol"} {
+		if err := s.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: user, MediaType: models.MediaTypeMovie, Title: "Old",
+			StartedAt: old.Add(time.Duration(i) * time.Minute), StoppedAt: old.Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	one := []models.ActiveStream{{SessionID: "a", UserName: "alice", StartedAt: now}}
+	two := append(one, models.ActiveStream{SessionID: "b", UserName: "bob", StartedAt: now})
+
+	// Sets the first 30-day record; never announced.
+	e.EvaluateSessions(ctx, one)
+	e.WaitForNotifications()
+	if notif.count() != 0 {
+		t.Fatalf("expected no notification for the first 30-day record, got %d", notif.count())
+	}
+
+	e.EvaluateSessions(ctx, two)
+	e.WaitForNotifications()
+	if notif.count() != 1 {
+		t.Fatalf("expected 1 rolling record notification, got %d", notif.count())
+	}
+	v := notif.notifications[0]
+	if v.Details["window"] != "last_30_days" || !strings.Contains(v.Message, "30-day") {
+		t.Errorf("unexpected notification %q %+v", v.Message, v.Details)
+	}
+}
+
+func TestChannelsForEvent(t *testing.T) {
+	channels := []models.NotificationChannel{
+		{ID: 1},
+		{ID: 2, Events: models.NotificationEventMatrix{
+			models.NotificationEventRuleViolation: {Enabled: true, ServerIDs: []int64{2}},
+		}},
+		{ID: 3, Events: models.NotificationEventMatrix{
+			models.NotificationEventRuleViolation: {Enabled: false},
+		}},
+	}
+	v := &models.RuleViolation{UserName: "alice", Stream: &models.ActiveStream{ServerID: 1}}
+
+	got := channelsForEvent(channels, models.NotificationEventRuleViolation, v, 0)
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("got %+v, want only channel 1", got)
+	}
+	if got := channelsForEvent(channels, models.NotificationEventConcurrentRecord, v, 0); len(got) != 3 {
+		t.Fatalf("got %d channels, want all 3 for unfiltered event", len(got))
+	}
+
+	ws := int64(7)
+	channels = append(channels, models.NotificationChannel{ID: 4, WorkspaceID: &ws})
+	if got := channelsForEvent(channels, models.NotificationEventConcurrentRecord, v, 0); len(got) != 3 {
+		t.Fatalf("got %d channels, want the workspace channel left out of an unscoped event", len(got))
+	}
+	if got := channelsForEvent(channels, models.NotificationEventConcurrentRecord, v, ws); len(got) != 4 {
+		t.Fatalf("got %d channels, want the workspace channel for its own event", len(got))
+	}
+}
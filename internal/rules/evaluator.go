@@ -21,6 +21,12 @@ type EvaluationInput struct {
 	Households []models.HouseholdLocation
 	GeoData    *models.GeoResult
 	UnitSystem units.System
+	// NetworkLabel names the admin-defined network the stream comes from,
+	// "" when none matches.
+	NetworkLabel string
+	// Local is set when the stream comes from a home network or a private
+	// address.
+	Local bool
 }
 
 type EvaluationResult struct {
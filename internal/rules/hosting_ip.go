@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type HostingIPEvaluator struct{}
+
+func NewHostingIPEvaluator() *HostingIPEvaluator {
+	return &HostingIPEvaluator{}
+}
+
+func (e *HostingIPEvaluator) Type() models.RuleType {
+	return models.RuleTypeHostingIP
+}
+
+func (e *HostingIPEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	// Without the ASN database there's nothing to classify.
+	if input.Stream == nil || input.GeoData == nil || input.GeoData.ASN == 0 {
+		return nil, nil
+	}
+
+	var config models.HostingIPConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	geo := input.GeoData
+	if slices.Contains(config.AllowedASNs, geo.ASN) {
+		return nil, nil
+	}
+	if !geo.Hosting && !slices.Contains(config.ExtraASNs, geo.ASN) {
+		return nil, nil
+	}
+
+	network := geo.ISP
+	if network == "" {
+		network = fmt.Sprintf("AS%d", geo.ASN)
+	}
+
+	v := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: input.Stream.UserName,
+		Severity: config.Severity,
+		Message:  fmt.Sprintf("streaming from a VPN or datacenter IP: %s", network),
+		Details: map[string]interface{}{
+			"ip_address": input.Stream.IPAddress,
+			"asn":        geo.ASN,
+			"isp":        geo.ISP,
+			"country":    geo.Country,
+		},
+		ConfidenceScore: 100,
+		OccurredAt:      time.Now().UTC(),
+	}
+
+	return &EvaluationResult{
+		Violation: v,
+		Signals: []models.ViolationSignal{
+			{Name: "hosting_asn", Weight: 1.0, Value: geo.ASN},
+		},
+	}, nil
+}
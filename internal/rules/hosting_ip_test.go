@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestHostingIPEvaluator_Type(t *testing.T) {
+	e := NewHostingIPEvaluator()
+	if e.Type() != models.RuleTypeHostingIP {
+		t.Errorf("expected %s, got %s", models.RuleTypeHostingIP, e.Type())
+	}
+}
+
+func TestHostingIPEvaluator_Evaluate(t *testing.T) {
+	e := NewHostingIPEvaluator()
+	ctx := context.Background()
+
+	config := models.HostingIPConfig{ExtraASNs: []uint{64512}, AllowedASNs: []uint{9009}}
+	configJSON, _ := json.Marshal(config)
+	rule := &models.Rule{ID: 1, Name: "VPN", Type: models.RuleTypeHostingIP, Config: configJSON}
+
+	tests := []struct {
+		name     string
+		geo      *models.GeoResult
+		wantViol bool
+	}{
+		{"hosting asn", &models.GeoResult{ASN: 16509, ISP: "AMAZON-02", Hosting: true}, true},
+		{"residential", &models.GeoResult{ASN: 7922, ISP: "COMCAST-7922"}, false},
+		{"extra asn", &models.GeoResult{ASN: 64512, ISP: "Some Proxy"}, true},
+		{"allowed asn", &models.GeoResult{ASN: 9009, ISP: "M247 Europe SRL", Hosting: true}, false},
+		{"no asn data", &models.GeoResult{City: "Berlin"}, false},
+		{"no geo", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &EvaluationInput{
+				Stream:  &models.ActiveStream{UserName: "testuser", IPAddress: "203.0.113.5"},
+				GeoData: tt.geo,
+			}
+			result, err := e.Evaluate(ctx, rule, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotViol := result != nil; gotViol != tt.wantViol {
+				t.Fatalf("violation = %v, want %v", gotViol, tt.wantViol)
+			}
+			if result != nil && result.Violation.Severity != models.SeverityWarning {
+				t.Errorf("severity = %s, want default warning", result.Violation.Severity)
+			}
+		})
+	}
+}
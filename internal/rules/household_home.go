@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"errors"
+	"math"
+
+	"streammon/internal/models"
+)
+
+// homeClusterKm bounds how far from the anchor location a household location
+// can be and still count toward the learned home.
+const homeClusterKm = 100
+
+// HouseholdHomeQuerier reads what a member's home is derived from.
+type HouseholdHomeQuerier interface {
+	GetManualHouseholdHome(userName string) (*models.HouseholdHome, error)
+	ListHouseholdLocations(userName string) ([]models.HouseholdLocation, error)
+}
+
+// ResolveHouseholdHome returns the member's assigned home, falling back to
+// one learned from their household locations. It returns nil when neither
+// exists.
+func ResolveHouseholdHome(q HouseholdHomeQuerier, userName string) (*models.HouseholdHome, error) {
+	home, err := q.GetManualHouseholdHome(userName)
+	if err == nil {
+		return home, nil
+	}
+	if !errors.Is(err, models.ErrNotFound) {
+		return nil, err
+	}
+	locations, err := q.ListHouseholdLocations(userName)
+	if err != nil {
+		return nil, err
+	}
+	return LearnHouseholdHome(userName, locations), nil
+}
+
+// LearnHouseholdHome places a home at the session-weighted centre of the
+// household locations clustered around the member's busiest location,
+// preferring trusted ones. It returns nil when no location has coordinates.
+func LearnHouseholdHome(userName string, locations []models.HouseholdLocation) *models.HouseholdHome {
+	var located []models.HouseholdLocation
+	for _, l := range locations {
+		if l.Latitude != 0 || l.Longitude != 0 {
+			located = append(located, l)
+		}
+	}
+	if len(located) == 0 {
+		return nil
+	}
+
+	weight := func(l models.HouseholdLocation) float64 { return float64(max(l.SessionCount, 1)) }
+
+	anchor := located[0]
+	for _, l := range located[1:] {
+		if l.Trusted != anchor.Trusted {
+			if l.Trusted {
+				anchor = l
+			}
+			continue
+		}
+		if weight(l) > weight(anchor) {
+			anchor = l
+		}
+	}
+
+	var cluster []models.HouseholdLocation
+	var total, clustered, lat, lng float64
+	for _, l := range located {
+		w := weight(l)
+		total += w
+		if HaversineDistance(anchor.Latitude, anchor.Longitude, l.Latitude, l.Longitude) > homeClusterKm {
+			continue
+		}
+		cluster = append(cluster, l)
+		clustered += w
+		lat += l.Latitude * w
+		lng += l.Longitude * w
+	}
+
+	home := &models.HouseholdHome{
+		UserName:   userName,
+		Latitude:   lat / clustered,
+		Longitude:  lng / clustered,
+		RadiusKm:   models.DefaultHouseholdHomeRadiusKm,
+		Confidence: math.Round(clustered / total * 100),
+		Source:     models.HouseholdHomeLearned,
+	}
+	for _, l := range cluster {
+		d := HaversineDistance(home.Latitude, home.Longitude, l.Latitude, l.Longitude)
+		home.RadiusKm = max(home.RadiusKm, math.Ceil(d))
+	}
+	return home
+}
@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+type mockHomeQuerier struct {
+	manual    *models.HouseholdHome
+	locations []models.HouseholdLocation
+}
+
+func (m *mockHomeQuerier) GetManualHouseholdHome(string) (*models.HouseholdHome, error) {
+	if m.manual == nil {
+		return nil, models.ErrNotFound
+	}
+	return m.manual, nil
+}
+
+func (m *mockHomeQuerier) ListHouseholdLocations(string) ([]models.HouseholdLocation, error) {
+	return m.locations, nil
+}
+
+func TestLearnHouseholdHome(t *testing.T) {
+	if home := LearnHouseholdHome("alice", nil); home != nil {
+		t.Fatalf("expected no home without locations, got %+v", home)
+	}
+
+	locations := []models.HouseholdLocation{
+		// Two nearby Brooklyn/Manhattan IPs form the home cluster.
+		{IPAddress: "1.1.1.1", Latitude: 40.7128, Longitude: -74.0060, SessionCount: 30, Trusted: true},
+		{IPAddress: "1.1.1.2", Latitude: 40.6782, Longitude: -73.9442, SessionCount: 10, Trusted: true},
+		// A busier but untrusted location across the country stays out.
+		{IPAddress: "2.2.2.2", Latitude: 34.0522, Longitude: -118.2437, SessionCount: 40},
+		{IPAddress: "3.3.3.3"},
+	}
+	home := LearnHouseholdHome("alice", locations)
+	if home == nil {
+		t.Fatal("expected a learned home")
+	}
+	if home.Source != models.HouseholdHomeLearned {
+		t.Errorf("source = %q, want learned", home.Source)
+	}
+	if home.Latitude < 40.6 || home.Latitude > 40.8 || home.Longitude < -74.1 || home.Longitude > -73.9 {
+		t.Errorf("home at %f,%f, want New York", home.Latitude, home.Longitude)
+	}
+	if home.RadiusKm != models.DefaultHouseholdHomeRadiusKm {
+		t.Errorf("radius = %f, want default %d", home.RadiusKm, models.DefaultHouseholdHomeRadiusKm)
+	}
+	if home.Confidence != 50 {
+		t.Errorf("confidence = %f, want 50 (40 of 80 sessions)", home.Confidence)
+	}
+}
+
+func TestResolveHouseholdHome_PrefersManual(t *testing.T) {
+	q := &mockHomeQuerier{
+		manual:    &models.HouseholdHome{UserName: "alice", Latitude: 51.5, Longitude: -0.12, RadiusKm: 10, Source: models.HouseholdHomeManual},
+		locations: []models.HouseholdLocation{{Latitude: 40.7, Longitude: -74, SessionCount: 5}},
+	}
+	home, err := ResolveHouseholdHome(q, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if home.Source != models.HouseholdHomeManual || home.Latitude != 51.5 {
+		t.Errorf("home = %+v, want manual", home)
+	}
+}
+
+func TestDistanceFromHomeEvaluator(t *testing.T) {
+	q := &mockHomeQuerier{manual: &models.HouseholdHome{
+		UserName: "alice", Latitude: 40.7128, Longitude: -74.0060, RadiusKm: 25, Source: models.HouseholdHomeManual, Confidence: 100,
+	}}
+	e := NewDistanceFromHomeEvaluator(nil, q)
+	configJSON, _ := json.Marshal(models.DistanceFromHomeConfig{MaxDistanceKm: 200})
+	rule := &models.Rule{ID: 1, Name: "Far from home", Type: models.RuleTypeDistanceFromHome, Config: configJSON}
+
+	tests := []struct {
+		name     string
+		geo      *models.GeoResult
+		wantSev  models.Severity
+		wantViol bool
+	}{
+		{"at home", &models.GeoResult{City: "New York", Lat: 40.73, Lng: -73.99}, "", false},
+		{"philadelphia", &models.GeoResult{City: "Philadelphia", Lat: 39.95, Lng: -75.16}, "", false},
+		{"pittsburgh", &models.GeoResult{City: "Pittsburgh", Lat: 40.44, Lng: -79.99}, models.SeverityWarning, true},
+		{"london", &models.GeoResult{City: "London", Lat: 51.5, Lng: -0.12}, models.SeverityCritical, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &EvaluationInput{
+				Stream:  &models.ActiveStream{UserName: "alice", IPAddress: "9.9.9.9"},
+				GeoData: tt.geo,
+			}
+			result, err := e.Evaluate(context.Background(), rule, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (result != nil) != tt.wantViol {
+				t.Fatalf("violation = %v, want %v", result != nil, tt.wantViol)
+			}
+			if result != nil && result.Violation.Severity != tt.wantSev {
+				t.Errorf("severity = %s, want %s", result.Violation.Severity, tt.wantSev)
+			}
+		})
+	}
+
+	// Users with no home are never flagged.
+	e = NewDistanceFromHomeEvaluator(nil, &mockHomeQuerier{})
+	result, err := e.Evaluate(context.Background(), rule, &EvaluationInput{
+		Stream:  &models.ActiveStream{UserName: "bob", IPAddress: "9.9.9.9"},
+		GeoData: &models.GeoResult{Lat: 51.5, Lng: -0.12},
+	})
+	if err != nil || result != nil {
+		t.Errorf("expected no violation without a home, got %+v, %v", result, err)
+	}
+}
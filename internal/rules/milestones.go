@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// milestoneCheckInterval spaces out the history reads behind each watching
+// user's milestone check.
+const milestoneCheckInterval = 5 * time.Minute
+
+// MilestoneStore is what the engine reads and records for user milestones.
+type MilestoneStore interface {
+	GetDisplayTimezone() (string, error)
+	UserStreaks(ctx context.Context, userName string, now time.Time, serverIDs []int64) (*models.UserStreaks, error)
+	ClaimUserMilestone(ctx context.Context, userName string, m models.UserMilestone) (bool, error)
+}
+
+// checkMilestones announces the watch hour, streak and anniversary
+// milestones each watching user reaches today, counting the plays still in
+// progress. Each milestone is announced once, in the display timezone's day.
+func (e *Engine) checkMilestones(ctx context.Context, streams []models.ActiveStream) {
+	if e.notifier == nil {
+		return
+	}
+	now := time.Now()
+	if name, err := e.store.GetDisplayTimezone(); err == nil && name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			now = now.In(loc)
+		}
+	}
+
+	byUser := make(map[string][]models.ActiveStream)
+	for _, s := range streams {
+		byUser[s.UserName] = append(byUser[s.UserName], s)
+	}
+	for userName, userStreams := range byUser {
+		if !e.milestoneDue(userName, now) {
+			continue
+		}
+		streaks, err := e.store.UserStreaks(ctx, userName, now, nil)
+		if err != nil {
+			log.Printf("rules engine: streaks for %s: %v", userName, err)
+			continue
+		}
+		for _, s := range userStreams {
+			streaks.AddWatching(activePlayMs(s, now), now)
+		}
+		for _, m := range streaks.MilestonesToday {
+			claimed, err := e.store.ClaimUserMilestone(ctx, userName, m)
+			if err != nil {
+				log.Printf("rules engine: %v", err)
+				continue
+			}
+			if claimed {
+				e.notifyMilestone(userName, m, userStreams[0], now)
+			}
+		}
+	}
+}
+
+// milestoneDue reports whether userName's milestones are due a check at now,
+// starting the next interval when they are.
+func (e *Engine) milestoneDue(userName string, now time.Time) bool {
+	e.milestoneMu.Lock()
+	defer e.milestoneMu.Unlock()
+	if now.Before(e.milestoneChecked[userName].Add(milestoneCheckInterval)) {
+		return false
+	}
+	if e.milestoneChecked == nil {
+		e.milestoneChecked = make(map[string]time.Time)
+	}
+	e.milestoneChecked[userName] = now
+	return true
+}
+
+func (e *Engine) notifyMilestone(userName string, m models.UserMilestone, stream models.ActiveStream, now time.Time) {
+	details := map[string]interface{}{
+		"kind":  m.Kind,
+		"value": m.Value,
+	}
+	if m.Since != "" {
+		details["since"] = m.Since
+	}
+	violation := &models.RuleViolation{
+		RuleName:        "Milestone",
+		UserName:        userName,
+		Severity:        models.SeverityInfo,
+		Message:         m.Message(userName),
+		ConfidenceScore: 100,
+		Details:         details,
+		Stream:          &stream,
+		Event:           models.NotificationEventMilestone,
+		OccurredAt:      now.UTC(),
+	}
+
+	e.notifyWg.Add(1)
+	go func() {
+		defer e.notifyWg.Done()
+
+		channels, err := e.store.ListEnabledNotificationChannels()
+		if err != nil {
+			log.Printf("rules engine: error listing notification channels: %v", err)
+			return
+		}
+		channels = channelsForEvent(channels, violation.Event, violation, e.eventWorkspace(violation))
+		if len(channels) == 0 {
+			return
+		}
+
+		notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := e.notifier.Notify(notifyCtx, violation, channels); err != nil {
+			log.Printf("rules engine: error sending milestone notification: %v", err)
+		}
+	}()
+}
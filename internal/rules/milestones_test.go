@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestEngine_MilestoneNotification(t *testing.T) {
+	now := time.Now()
+	e, s := setupTestEngine(t)
+	ctx := context.Background()
+	notif := &mockNotifier{}
+	e.SetNotifier(notif)
+
+	channel := &models.NotificationChannel{
+		Name:        "Test Discord",
+		ChannelType: models.ChannelTypeDiscord,
+		Config:      json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/test"}`),
+		Enabled:     true,
+	}
+	if err := s.CreateNotificationChannel(channel); err != nil {
+		t.Fatal(err)
+	}
+	srv := &models.Server{Name: "Test", Type: models.ServerTypePlex, URL: "http://test", APIKey: "k", Enabled: true}
+	if err := s.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	// 9.5 hours, two days ago, so today's watching crosses 10.
+	started := now.AddDate(0, 0, -2).UTC()
+	if err := s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", Title: "Marathon", MediaType: models.MediaTypeMovie,
+		StartedAt: started, StoppedAt: started.Add(570 * time.Minute), WatchedMs: (570 * time.Minute).Milliseconds(),
+		DurationMs: (570 * time.Minute).Milliseconds(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	streams := func(playing time.Duration) []models.ActiveStream {
+		return []models.ActiveStream{{SessionID: "a", ServerID: srv.ID, UserName: "alice", StartedAt: now.Add(-playing).UTC()}}
+	}
+
+	e.EvaluateSessions(ctx, streams(20*time.Minute))
+	e.WaitForNotifications()
+	if notif.count() != 0 {
+		t.Fatalf("notified short of the milestone: %d", notif.count())
+	}
+
+	e.milestoneChecked = nil
+	e.EvaluateSessions(ctx, streams(40*time.Minute))
+	e.WaitForNotifications()
+	if notif.count() != 1 {
+		t.Fatalf("expected 1 milestone notification, got %d", notif.count())
+	}
+	v := notif.notifications[0]
+	if v.Event != models.NotificationEventMilestone || v.Message != "alice hit 10 hours watched" {
+		t.Errorf("violation = %+v", v)
+	}
+
+	// Once per milestone.
+	e.milestoneChecked = nil
+	e.EvaluateSessions(ctx, streams(50*time.Minute))
+	e.WaitForNotifications()
+	if notif.count() != 1 {
+		t.Fatalf("milestone announced again: %d notifications", notif.count())
+	}
+}
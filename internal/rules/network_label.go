@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type NetworkLabelEvaluator struct{}
+
+func NewNetworkLabelEvaluator() *NetworkLabelEvaluator {
+	return &NetworkLabelEvaluator{}
+}
+
+func (e *NetworkLabelEvaluator) Type() models.RuleType {
+	return models.RuleTypeNetworkLabel
+}
+
+func (e *NetworkLabelEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil || input.Stream.IPAddress == "" {
+		return nil, nil
+	}
+
+	var config models.NetworkLabelConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	stream := input.Stream
+	if !config.Applies(stream.UserName) || !config.Flags(input.NetworkLabel) {
+		return nil, nil
+	}
+
+	network := input.NetworkLabel
+	if network == "" {
+		network = "an unlabelled network"
+	}
+
+	v := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: stream.UserName,
+		Severity: config.Severity,
+		Message:  fmt.Sprintf("streaming from %s", network),
+		Details: map[string]interface{}{
+			"ip_address":    stream.IPAddress,
+			"network_label": input.NetworkLabel,
+		},
+		ConfidenceScore: 100,
+		OccurredAt:      time.Now().UTC(),
+	}
+
+	return &EvaluationResult{
+		Violation: v,
+		Signals: []models.ViolationSignal{
+			{Name: "network_label", Weight: 1.0, Value: input.NetworkLabel},
+		},
+	}, nil
+}
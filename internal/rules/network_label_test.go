@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestNetworkLabelEvaluator_Type(t *testing.T) {
+	e := NewNetworkLabelEvaluator()
+	if e.Type() != models.RuleTypeNetworkLabel {
+		t.Errorf("expected %s, got %s", models.RuleTypeNetworkLabel, e.Type())
+	}
+}
+
+func TestNetworkLabelEvaluator_Labels(t *testing.T) {
+	e := NewNetworkLabelEvaluator()
+	ctx := context.Background()
+
+	blockJSON, _ := json.Marshal(models.NetworkLabelConfig{BlockedLabels: []string{"VPN"}})
+	blockRule := &models.Rule{ID: 1, Name: "No VPN", Type: models.RuleTypeNetworkLabel, Config: blockJSON}
+	homeJSON, _ := json.Marshal(models.NetworkLabelConfig{AllowedLabels: []string{"Home"}, UserNames: []string{"kid"}, Severity: models.SeverityInfo})
+	homeRule := &models.Rule{ID: 2, Name: "Kid at home", Type: models.RuleTypeNetworkLabel, Config: homeJSON}
+
+	tests := []struct {
+		name     string
+		rule     *models.Rule
+		user     string
+		label    string
+		wantViol bool
+	}{
+		{"blocked label", blockRule, "alice", "VPN", true},
+		{"other label", blockRule, "alice", "Home", false},
+		{"unlabelled not blocked", blockRule, "alice", "", false},
+		{"allowed label", homeRule, "kid", "Home", false},
+		{"outside allowed", homeRule, "kid", "Cellular", true},
+		{"unlabelled outside allowed", homeRule, "kid", "", true},
+		{"user not covered", homeRule, "parent", "Cellular", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &EvaluationInput{
+				Stream:       &models.ActiveStream{UserName: tt.user, Title: "Movie", IPAddress: "203.0.113.9"},
+				NetworkLabel: tt.label,
+			}
+			result, err := e.Evaluate(ctx, tt.rule, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotViol := result != nil; gotViol != tt.wantViol {
+				t.Fatalf("violation = %v, want %v", gotViol, tt.wantViol)
+			}
+			if result != nil && result.Violation.Details["network_label"] != tt.label {
+				t.Errorf("details network_label = %v, want %q", result.Violation.Details["network_label"], tt.label)
+			}
+		})
+	}
+}
@@ -46,6 +46,11 @@ func (e *NewLocationEvaluator) Evaluate(ctx context.Context, rule *models.Rule,
 
 	stream := input.Stream
 
+	// Local sessions have no location to compare
+	if input.Local {
+		return nil, nil
+	}
+
 	// Check household exemption
 	if config.ExemptHousehold {
 		householdIPs := trustedHouseholdIPs(input.Households)
@@ -129,6 +129,39 @@ func TestNewLocationEvaluator_HouseholdExempt(t *testing.T) {
 	assert.Nil(t, result) // No violation - household exempt
 }
 
+func TestNewLocationEvaluator_LocalSkipped(t *testing.T) {
+	geoResolver := &mockGeoResolver{
+		results: map[string]*models.GeoResult{
+			"1.1.1.1": {IP: "1.1.1.1", Lat: 40.7128, Lng: -74.0060, City: "New York", Country: "US"},
+		},
+	}
+	mock := &mockHistoryQuerierWithIPs{
+		distinctIPs: []string{"1.1.1.1"},
+	}
+	evaluator := NewNewLocationEvaluator(geoResolver, mock)
+
+	rule := &models.Rule{
+		ID:     1,
+		Name:   "New Location Alert",
+		Type:   models.RuleTypeNewLocation,
+		Config: json.RawMessage(`{"notify_on_new": true, "min_distance_km": 50}`),
+	}
+
+	input := &EvaluationInput{
+		Stream: &models.ActiveStream{
+			UserName:  "alice",
+			IPAddress: "2.2.2.2",
+			StartedAt: time.Now().UTC(),
+		},
+		GeoData: &models.GeoResult{IP: "2.2.2.2", Lat: 34.0522, Lng: -118.2437, City: "Los Angeles", Country: "US"},
+		Local:   true,
+	}
+
+	result, err := evaluator.Evaluate(context.Background(), rule, input)
+	require.NoError(t, err)
+	assert.Nil(t, result) // No violation - home network
+}
+
 func TestNewLocationEvaluator_NoHistory(t *testing.T) {
 	geoResolver := &mockGeoResolver{
 		results: map[string]*models.GeoResult{
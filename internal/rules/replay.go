@@ -0,0 +1,149 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// Replay runs rules over stored history without side effects: nothing is
+// recorded, no action is taken and no notification is sent. Each row in
+// [from, to) is evaluated once, as of its start, alongside every row that
+// was playing at that moment; rows that started before from only provide
+// that context. Evaluators that look back through history see it as it is
+// now, so a replayed session's own later plays can already be there.
+func (e *Engine) Replay(ctx context.Context, rules []models.Rule, history []models.WatchHistoryEntry, from, to time.Time) []models.RuleReplaySession {
+	// Only the exemption and limit maps are used from the refresh; stale
+	// ones are better than none.
+	if err := e.RefreshRules(); err != nil {
+		log.Printf("rules replay: refreshing rules: %v", err)
+	}
+	ec, err := e.newEvalContext()
+	if err != nil {
+		ec = &evalContext{households: make(map[string][]models.HouseholdLocation)}
+	}
+	ec.rules = rules
+
+	streams := make([]models.ActiveStream, len(history))
+	for i := range history {
+		streams[i] = historyStream(&history[i])
+	}
+
+	sessions := []models.RuleReplaySession{}
+	for i := range history {
+		h := &history[i]
+		if h.StartedAt.Before(from) || !h.StartedAt.Before(to) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		var playing []models.ActiveStream
+		for j := range history {
+			if !history[j].StartedAt.After(h.StartedAt) && history[j].StoppedAt.After(h.StartedAt) {
+				playing = append(playing, streams[j])
+			}
+		}
+		sessions = append(sessions, e.replayStream(ctx, h, &streams[i], playing, ec))
+	}
+	return sessions
+}
+
+func (e *Engine) replayStream(ctx context.Context, h *models.WatchHistoryEntry, stream *models.ActiveStream, playing []models.ActiveStream, ec *evalContext) models.RuleReplaySession {
+	input := e.buildInput(ctx, stream, playing, ec)
+
+	rs := models.RuleReplaySession{
+		HistoryID:         h.ID,
+		UserName:          h.UserName,
+		Title:             streamTitle(stream),
+		Player:            h.Player,
+		IPAddress:         h.IPAddress,
+		StartedAt:         h.StartedAt,
+		StoppedAt:         h.StoppedAt,
+		Geo:               input.GeoData,
+		NetworkLabel:      input.NetworkLabel,
+		Household:         trustedHouseholdIPs(input.Households)[h.IPAddress],
+		ConcurrentStreams: len(filterStreamsByUser(playing, h.UserName)),
+		Decisions:         make([]models.RuleReplayDecision, 0, len(ec.rules)),
+	}
+
+	for i := range ec.rules {
+		rule := &ec.rules[i]
+		d := models.RuleReplayDecision{RuleID: rule.ID, RuleName: rule.Name, RuleType: rule.Type}
+		evaluator, ok := e.evaluators[rule.Type]
+		switch {
+		case !rule.Type.IsRealTime() || !ok:
+			d.Outcome = models.RuleReplaySkipped
+			d.Reason = "not evaluated per stream"
+		case e.isExempt(rule.ID, stream.UserName):
+			d.Outcome = models.RuleReplayExempt
+			d.Reason = "user is exempt from this rule"
+		default:
+			result, err := evaluator.Evaluate(ctx, rule, input)
+			switch {
+			case err != nil:
+				d.Outcome = models.RuleReplayError
+				d.Reason = err.Error()
+			case result == nil || result.Violation == nil:
+				d.Outcome = models.RuleReplayPass
+				d.Reason = "conditions not met"
+			default:
+				v := result.Violation
+				d.Outcome = models.RuleReplayViolation
+				d.Severity = v.Severity
+				d.Message = v.Message
+				d.ConfidenceScore = v.ConfidenceScore
+				d.Signals = result.Signals
+				d.Details = v.Details
+				if v.UserName != "" && v.UserName != stream.UserName {
+					d.Reason = fmt.Sprintf("raised against %s", v.UserName)
+				}
+			}
+		}
+		rs.Decisions = append(rs.Decisions, d)
+	}
+	return rs
+}
+
+// historyStream rebuilds the live session a history row was recorded from,
+// as far as history keeps it.
+func historyStream(h *models.WatchHistoryEntry) models.ActiveStream {
+	return models.ActiveStream{
+		SessionID:         fmt.Sprintf("history-%d", h.ID),
+		ServerID:          h.ServerID,
+		ItemID:            h.ItemID,
+		GrandparentItemID: h.GrandparentItemID,
+		UserName:          h.UserName,
+		MediaType:         h.MediaType,
+		ExtraType:         h.ExtraType,
+		Title:             h.Title,
+		ParentTitle:       h.ParentTitle,
+		GrandparentTitle:  h.GrandparentTitle,
+		OriginalTitle:     h.OriginalTitle,
+		Language:          h.Language,
+		ContentRating:     h.ContentRating,
+		Year:              h.Year,
+		DurationMs:        h.DurationMs,
+		ProgressMs:        h.WatchedMs,
+		Player:            h.Player,
+		Platform:          h.Platform,
+		IPAddress:         h.IPAddress,
+		StartedAt:         h.StartedAt,
+		VideoCodec:        h.VideoCodec,
+		AudioCodec:        h.AudioCodec,
+		VideoResolution:   h.VideoResolution,
+		AudioChannels:     h.AudioChannels,
+		VideoDecision:     h.VideoDecision,
+		AudioDecision:     h.AudioDecision,
+		TranscodeHWDecode: h.TranscodeHWDecode,
+		TranscodeHWEncode: h.TranscodeHWEncode,
+		Bandwidth:         h.Bandwidth,
+		DynamicRange:      h.DynamicRange,
+		SeasonNumber:      h.SeasonNumber,
+		EpisodeNumber:     h.EpisodeNumber,
+		PausedMs:          h.PausedMs,
+	}
+}
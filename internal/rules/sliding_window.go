@@ -0,0 +1,274 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// windowReloadInterval is how long a user's activity loaded from history is
+// trusted before it's loaded again, picking up imports and deletions.
+const windowReloadInterval = 6 * time.Hour
+
+// ActivityStore loads the history a user's sliding windows start from.
+type ActivityStore interface {
+	UserActivitySince(ctx context.Context, userName string, since time.Time) ([]models.UserActivity, error)
+}
+
+// slidingWindows keeps each user's recent sessions in memory so sliding
+// window rules don't query history on every poll. A user's sessions are
+// loaded from history the first time a rule needs them; after that, sessions
+// are added as the engine sees them end, and anything older than the longest
+// window is dropped.
+type slidingWindows struct {
+	store ActivityStore
+	geo   GeoResolver
+
+	mu    sync.Mutex
+	users map[string]*userWindow
+	live  map[string]liveActivity // session key → session still playing
+}
+
+type userWindow struct {
+	loadedAt time.Time
+	finished []models.UserActivity
+}
+
+type liveActivity struct {
+	userName string
+	activity models.UserActivity
+}
+
+func newSlidingWindows(store ActivityStore, geo GeoResolver) *slidingWindows {
+	return &slidingWindows{store: store, geo: geo}
+}
+
+func liveKey(s *models.ActiveStream) string {
+	return fmt.Sprintf("%d:%s", s.ServerID, s.SessionID)
+}
+
+// observe records the sessions playing this tick. Sessions that were playing
+// last tick and aren't now have ended, and move into their user's window
+// with the transfer they'd reached.
+func (w *slidingWindows) observe(ctx context.Context, streams []models.ActiveStream, now time.Time) {
+	w.mu.Lock()
+	known := make(map[string]string, len(w.live))
+	for key, la := range w.live {
+		known[key] = la.activity.Country
+	}
+	w.mu.Unlock()
+
+	next := make(map[string]liveActivity, len(streams))
+	for i := range streams {
+		s := &streams[i]
+		key := liveKey(s)
+		country, ok := known[key]
+		if !ok && w.geo != nil && s.IPAddress != "" {
+			if geo, err := w.geo.Lookup(ctx, s.IPAddress); err == nil && geo != nil {
+				country = geo.Country
+			}
+		}
+		next[key] = liveActivity{userName: s.UserName, activity: models.UserActivity{
+			StartedAt: s.StartedAt,
+			Country:   country,
+			Bytes:     activeStreamBytes(*s, now),
+		}}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, la := range w.live {
+		if _, ok := next[key]; ok {
+			continue
+		}
+		if uw := w.users[la.userName]; uw != nil {
+			uw.finished = append(uw.finished, la.activity)
+		}
+	}
+	w.live = next
+}
+
+// reset forgets everything, for when no rule uses the windows: sessions
+// that end unseen would otherwise go missing from them.
+func (w *slidingWindows) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.users, w.live = nil, nil
+}
+
+// windowTotals is a user's activity over one window.
+type windowTotals struct {
+	Sessions  int
+	Countries []string
+	Bytes     int64
+}
+
+// totals sums userName's sessions started since, finished and still
+// playing. stream, with its country, counts too when observe hasn't seen it.
+func (w *slidingWindows) totals(ctx context.Context, userName string, since, now time.Time, stream *models.ActiveStream, country string) (windowTotals, error) {
+	if err := w.load(ctx, userName, now); err != nil {
+		return windowTotals{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	activity := slices.Clone(w.users[userName].finished)
+	for _, la := range w.live {
+		if la.userName == userName {
+			activity = append(activity, la.activity)
+		}
+	}
+	if _, ok := w.live[liveKey(stream)]; !ok {
+		activity = append(activity, models.UserActivity{StartedAt: stream.StartedAt, Country: country, Bytes: activeStreamBytes(*stream, now)})
+	}
+
+	var t windowTotals
+	for _, a := range activity {
+		if a.StartedAt.Before(since) {
+			continue
+		}
+		t.Sessions++
+		t.Bytes += a.Bytes
+		if a.Country != "" && !slices.Contains(t.Countries, a.Country) {
+			t.Countries = append(t.Countries, a.Country)
+		}
+	}
+	slices.Sort(t.Countries)
+	return t, nil
+}
+
+// load reads userName's last MaxSlidingWindowHours of history when it hasn't
+// been read in windowReloadInterval, and drops what's aged out of every
+// window otherwise.
+func (w *slidingWindows) load(ctx context.Context, userName string, now time.Time) error {
+	horizon := now.Add(-models.MaxSlidingWindowHours * time.Hour)
+
+	w.mu.Lock()
+	uw := w.users[userName]
+	if uw != nil && now.Sub(uw.loadedAt) < windowReloadInterval {
+		uw.finished = slices.DeleteFunc(uw.finished, func(a models.UserActivity) bool {
+			return a.StartedAt.Before(horizon)
+		})
+		w.mu.Unlock()
+		return nil
+	}
+	w.mu.Unlock()
+
+	activity, err := w.store.UserActivitySince(ctx, userName, horizon)
+	if err != nil {
+		return fmt.Errorf("loading activity for %s: %w", userName, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.users == nil {
+		w.users = make(map[string]*userWindow)
+	}
+	w.users[userName] = &userWindow{loadedAt: now, finished: activity}
+	return nil
+}
+
+type SlidingWindowEvaluator struct {
+	windows *slidingWindows
+	now     func() time.Time
+}
+
+func (e *SlidingWindowEvaluator) Type() models.RuleType {
+	return models.RuleTypeSlidingWindow
+}
+
+// Evaluate compares the user's total over the rule's window with its
+// threshold, from the engine's in-memory windows rather than history.
+func (e *SlidingWindowEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil {
+		return nil, nil
+	}
+
+	var config models.SlidingWindowConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	stream := input.Stream
+	now := e.now()
+	var country string
+	if input.GeoData != nil {
+		country = input.GeoData.Country
+	}
+	t, err := e.windows.totals(ctx, stream.UserName, now.Add(-config.Window()), now, stream, country)
+	if err != nil {
+		return nil, err
+	}
+
+	window := formatWindow(config.WindowHours)
+	var value float64
+	var message string
+	details := map[string]interface{}{
+		"metric":       config.Metric,
+		"window_hours": config.WindowHours,
+		"threshold":    config.Threshold,
+	}
+	switch config.Metric {
+	case models.SlidingWindowSessions:
+		value = float64(t.Sessions)
+		message = fmt.Sprintf("user started %d sessions in the last %s (limit: %g)", t.Sessions, window, config.Threshold)
+	case models.SlidingWindowCountries:
+		value = float64(len(t.Countries))
+		message = fmt.Sprintf("user streamed from %d countries in the last %s (limit: %g): %s",
+			len(t.Countries), window, config.Threshold, strings.Join(t.Countries, ", "))
+		details["countries"] = t.Countries
+	case models.SlidingWindowGigabytes:
+		value = float64(t.Bytes) / bytesPerGB
+		message = fmt.Sprintf("user has transferred an estimated %.1f GB in the last %s (limit: %g GB)", value, window, config.Threshold)
+	}
+	details["value"] = value
+	if value <= config.Threshold {
+		return nil, nil
+	}
+
+	ratio := value / config.Threshold
+	confidence := min(50+(ratio-1)*100, 100)
+	return &EvaluationResult{
+		Violation: &models.RuleViolation{
+			RuleID:          rule.ID,
+			UserName:        stream.UserName,
+			Severity:        config.Severity,
+			Message:         message,
+			Details:         details,
+			ConfidenceScore: confidence,
+			OccurredAt:      now.UTC(),
+		},
+		Signals: []models.ViolationSignal{
+			{Name: string(config.Metric), Weight: 0.7, Value: value},
+			{Name: "threshold", Weight: 0.0, Value: config.Threshold},
+			{Name: "excess_ratio", Weight: 0.3, Value: ratio - 1},
+		},
+	}, nil
+}
+
+// formatWindow renders a window as days when it's whole days, e.g. "7d",
+// and as hours otherwise.
+func formatWindow(hours int) string {
+	if hours%24 == 0 {
+		return fmt.Sprintf("%dd", hours/24)
+	}
+	return fmt.Sprintf("%dh", hours)
+}
+
+// observeWindows keeps the sliding windows current when a rule uses them.
+func (e *Engine) observeWindows(ctx context.Context, rules []models.Rule, streams []models.ActiveStream) {
+	if !slices.ContainsFunc(rules, func(r models.Rule) bool { return r.Type == models.RuleTypeSlidingWindow }) {
+		e.windows.reset()
+		return
+	}
+	e.windows.observe(ctx, streams, time.Now())
+}
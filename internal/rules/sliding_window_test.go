@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"streammon/internal/models"
+)
+
+type mockActivityStore struct {
+	activity map[string][]models.UserActivity
+	loads    int
+}
+
+func (m *mockActivityStore) UserActivitySince(ctx context.Context, userName string, since time.Time) ([]models.UserActivity, error) {
+	m.loads++
+	var out []models.UserActivity
+	for _, a := range m.activity[userName] {
+		if !a.StartedAt.Before(since) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func slidingWindowRule(metric models.SlidingWindowMetric, threshold float64) *models.Rule {
+	cfg, _ := json.Marshal(models.SlidingWindowConfig{Metric: metric, Threshold: threshold})
+	return &models.Rule{ID: 1, Name: "Window", Type: models.RuleTypeSlidingWindow, Config: cfg}
+}
+
+func TestSlidingWindowEvaluator_Sessions(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := &mockActivityStore{activity: map[string][]models.UserActivity{
+		"alice": {
+			{StartedAt: now.Add(-30 * time.Hour)},
+			{StartedAt: now.Add(-5 * time.Hour)},
+			{StartedAt: now.Add(-3 * time.Hour)},
+		},
+	}}
+	windows := newSlidingWindows(store, nil)
+	evaluator := &SlidingWindowEvaluator{windows: windows, now: func() time.Time { return now }}
+	rule := slidingWindowRule(models.SlidingWindowSessions, 2)
+
+	stream := models.ActiveStream{SessionID: "a", UserName: "alice", StartedAt: now.Add(-time.Hour)}
+	windows.observe(context.Background(), []models.ActiveStream{stream}, now)
+	result, err := evaluator.Evaluate(context.Background(), rule, &EvaluationInput{Stream: &stream, AllStreams: []models.ActiveStream{stream}})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, "user started 3 sessions in the last 1d (limit: 2)", result.Violation.Message)
+	assert.Equal(t, models.SeverityWarning, result.Violation.Severity)
+
+	// Later ticks come from memory, with the ended session moved into the
+	// window rather than read back from history.
+	windows.observe(context.Background(), nil, now.Add(time.Minute))
+	next := models.ActiveStream{SessionID: "b", UserName: "alice", StartedAt: now}
+	windows.observe(context.Background(), []models.ActiveStream{next}, now.Add(time.Minute))
+	result, err = evaluator.Evaluate(context.Background(), rule, &EvaluationInput{Stream: &next, AllStreams: []models.ActiveStream{next}})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 4.0, result.Violation.Details["value"])
+	assert.Equal(t, 1, store.loads)
+
+	// Nearly a day on, only the last two sessions are left in the window.
+	evaluator.now = func() time.Time { return now.Add(23 * time.Hour) }
+	result, err = evaluator.Evaluate(context.Background(), rule, &EvaluationInput{Stream: &next, AllStreams: []models.ActiveStream{next}})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestSlidingWindowEvaluator_CountriesAndGigabytes(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := &mockActivityStore{activity: map[string][]models.UserActivity{
+		"alice": {
+			{StartedAt: now.Add(-6 * 24 * time.Hour), Country: "CA", Bytes: 20e9},
+			{StartedAt: now.Add(-2 * 24 * time.Hour), Country: "US", Bytes: 15e9},
+			{StartedAt: now.Add(-24 * time.Hour), Country: "US", Bytes: 10e9},
+		},
+	}}
+	geo := &mockGeoResolver{results: map[string]*models.GeoResult{"203.0.113.5": {Country: "MX"}}}
+	windows := newSlidingWindows(store, geo)
+	evaluator := &SlidingWindowEvaluator{windows: windows, now: func() time.Time { return now }}
+
+	// 8 Mbps for an hour is 3.6 GB.
+	stream := models.ActiveStream{SessionID: "a", UserName: "alice", IPAddress: "203.0.113.5", StartedAt: now.Add(-time.Hour), Bandwidth: 8_000_000}
+	windows.observe(context.Background(), []models.ActiveStream{stream}, now)
+	input := &EvaluationInput{Stream: &stream, AllStreams: []models.ActiveStream{stream}}
+
+	result, err := evaluator.Evaluate(context.Background(), slidingWindowRule(models.SlidingWindowCountries, 2), input)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"CA", "MX", "US"}, result.Violation.Details["countries"])
+
+	result, err = evaluator.Evaluate(context.Background(), slidingWindowRule(models.SlidingWindowGigabytes, 50), input)
+	require.NoError(t, err)
+	assert.Nil(t, result, "48.6 GB is under 50")
+
+	result, err = evaluator.Evaluate(context.Background(), slidingWindowRule(models.SlidingWindowGigabytes, 40), input)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.InDelta(t, 48.6, result.Violation.Details["value"], 0.01)
+}
+
+func TestSlidingWindowConfigValidate(t *testing.T) {
+	c := models.SlidingWindowConfig{Metric: models.SlidingWindowCountries, Threshold: 3}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, 168, c.WindowHours)
+
+	for _, bad := range []models.SlidingWindowConfig{
+		{Metric: "plays", Threshold: 1},
+		{Metric: models.SlidingWindowSessions},
+		{Metric: models.SlidingWindowSessions, Threshold: 1, WindowHours: models.MaxSlidingWindowHours + 1},
+	} {
+		assert.Error(t, bad.Validate(), "%+v", bad)
+	}
+}
@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"streammon/internal/models"
+)
+
+type TranscodeBudgetEvaluator struct {
+	now func() time.Time
+}
+
+func NewTranscodeBudgetEvaluator() *TranscodeBudgetEvaluator {
+	return &TranscodeBudgetEvaluator{now: time.Now}
+}
+
+func (e *TranscodeBudgetEvaluator) Type() models.RuleType {
+	return models.RuleTypeTranscodeBudget
+}
+
+// Evaluate counts the transcodes on the evaluated stream's server against
+// the budget in force now. Every stream on an over-budget server sees the
+// same overflow, so only the session the policy picks reports it; that keeps
+// one violation per overflow and lets the engine's cooldown key on the
+// picked session.
+func (e *TranscodeBudgetEvaluator) Evaluate(ctx context.Context, rule *models.Rule, input *EvaluationInput) (*EvaluationResult, error) {
+	if input.Stream == nil || !isTranscoding(input.Stream) {
+		return nil, nil
+	}
+
+	var config models.TranscodeBudgetConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	serverID := input.Stream.ServerID
+	if config.ServerID != 0 && config.ServerID != serverID {
+		return nil, nil
+	}
+
+	var transcodes []models.ActiveStream
+	for _, s := range input.AllStreams {
+		if s.ServerID == serverID && isTranscoding(&s) {
+			transcodes = append(transcodes, s)
+		}
+	}
+	limit := config.LimitAt(e.now())
+	if len(transcodes) <= limit {
+		return nil, nil
+	}
+
+	// Newest first, so ties below go to the most recent session.
+	slices.SortStableFunc(transcodes, func(a, b models.ActiveStream) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	anchor := &transcodes[0]
+	var target *models.ActiveStream
+	switch config.Policy {
+	case models.TranscodeBudgetMessageLowest:
+		target = anchor
+		for i := range transcodes {
+			if config.Priority(transcodes[i].UserName) < config.Priority(target.UserName) {
+				target = &transcodes[i]
+			}
+		}
+	case models.TranscodeBudgetTerminateNewestRemote:
+		for i := range transcodes {
+			if isRemoteAddress(transcodes[i].IPAddress) {
+				target = &transcodes[i]
+				break
+			}
+		}
+	}
+	if target != nil {
+		anchor = target
+	}
+	if anchor.SessionID != input.Stream.SessionID {
+		return nil, nil
+	}
+
+	v := &models.RuleViolation{
+		RuleID:   rule.ID,
+		UserName: anchor.UserName,
+		Severity: config.Severity,
+		Message:  fmt.Sprintf("%d transcodes running, budget is %d", len(transcodes), limit),
+		Details: map[string]interface{}{
+			"server_id":      serverID,
+			"transcodes":     len(transcodes),
+			"max_transcodes": limit,
+			"policy":         string(config.Policy),
+			"session_title":  anchor.Title,
+		},
+		ConfidenceScore: 100,
+		OccurredAt:      time.Now().UTC(),
+	}
+
+	result := &EvaluationResult{
+		Violation: v,
+		Signals: []models.ViolationSignal{
+			{Name: "transcodes", Weight: 1.0, Value: len(transcodes)},
+			{Name: "max_transcodes", Weight: 0, Value: limit},
+		},
+	}
+	if target != nil {
+		result.TerminateTarget = &TerminateTarget{
+			ServerID:        target.ServerID,
+			SessionID:       target.SessionID,
+			PlexSessionUUID: target.PlexSessionUUID,
+		}
+	}
+	return result, nil
+}
+
+// transcodeBudgetActions adds the action a transcode budget's policy calls
+// for, unless the rule already lists one of that type.
+func transcodeBudgetActions(rule *models.Rule, actions []models.RuleAction) []models.RuleAction {
+	var config models.TranscodeBudgetConfig
+	if err := json.Unmarshal(rule.Config, &config); err != nil || config.Validate() != nil {
+		return actions
+	}
+	var action models.RuleAction
+	switch config.Policy {
+	case models.TranscodeBudgetMessageLowest:
+		action = models.RuleAction{Type: models.RuleActionMessageUser, Message: config.Message}
+		if action.Message == "" {
+			action.Message = models.DefaultTranscodeBudgetMessage
+		}
+	case models.TranscodeBudgetTerminateNewestRemote:
+		action = models.RuleAction{Type: models.RuleActionTerminateStream, Message: config.Message}
+		if action.Message == "" {
+			action.Message = models.DefaultTranscodeBudgetStopText
+		}
+	default:
+		return actions
+	}
+	for _, a := range actions {
+		if a.Type == action.Type {
+			return actions
+		}
+	}
+	return append(slices.Clone(actions), action)
+}
+
+// isTranscoding reports whether a stream is converting video, or audio for
+// audio-only media. Audio transcodes alongside direct-played video are cheap
+// enough not to count against a budget.
+func isTranscoding(s *models.ActiveStream) bool {
+	if s.VideoDecision == models.TranscodeDecisionTranscode {
+		return true
+	}
+	return s.VideoDecision == "" && s.AudioDecision == models.TranscodeDecisionTranscode
+}
+
+// isRemoteAddress reports whether ip is a public address. Sessions with no
+// parseable address are treated as local.
+func isRemoteAddress(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	return !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast()
+}
@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestTranscodeBudgetEvaluator_Type(t *testing.T) {
+	e := NewTranscodeBudgetEvaluator()
+	if e.Type() != models.RuleTypeTranscodeBudget {
+		t.Errorf("expected %s, got %s", models.RuleTypeTranscodeBudget, e.Type())
+	}
+}
+
+func TestTranscodeBudgetEvaluator_Policies(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 2, 20, 30, 0, 0, time.UTC)
+	e := NewTranscodeBudgetEvaluator()
+	e.now = func() time.Time { return base }
+
+	transcode := func(id, user, ip string, startedMinsAgo int) models.ActiveStream {
+		return models.ActiveStream{
+			SessionID: id, ServerID: 1, UserName: user, IPAddress: ip,
+			VideoDecision: models.TranscodeDecisionTranscode,
+			StartedAt:     base.Add(-time.Duration(startedMinsAgo) * time.Minute),
+		}
+	}
+	streams := []models.ActiveStream{
+		transcode("a", "alice", "203.0.113.1", 30),
+		transcode("b", "bob", "192.168.1.20", 5),
+		transcode("c", "carol", "198.51.100.7", 10),
+		{SessionID: "d", ServerID: 1, UserName: "dave", VideoDecision: models.TranscodeDecisionDirectPlay},
+		transcode("e", "erin", "203.0.113.2", 1),
+	}
+	streams[4].ServerID = 2
+
+	tests := []struct {
+		name       string
+		config     models.TranscodeBudgetConfig
+		wantAnchor string
+		wantTarget string
+	}{
+		{"within budget", models.TranscodeBudgetConfig{MaxTranscodes: 3}, "", ""},
+		{"notify reports newest", models.TranscodeBudgetConfig{MaxTranscodes: 2}, "b", ""},
+		{"newest remote skips local", models.TranscodeBudgetConfig{MaxTranscodes: 2, Policy: models.TranscodeBudgetTerminateNewestRemote}, "c", "c"},
+		{"lowest priority", models.TranscodeBudgetConfig{
+			MaxTranscodes: 2,
+			Policy:        models.TranscodeBudgetMessageLowest,
+			Priorities:    map[string]int{"Bob": 5, "carol": 5},
+		}, "a", "a"},
+		{"evening window", models.TranscodeBudgetConfig{
+			MaxTranscodes: 5,
+			Windows:       []models.TranscodeBudgetWindow{{Start: "18:00", End: "23:00", MaxTranscodes: 1}},
+		}, "b", ""},
+		{"overnight window not active", models.TranscodeBudgetConfig{
+			MaxTranscodes: 5,
+			Windows:       []models.TranscodeBudgetWindow{{Start: "22:00", End: "06:00", MaxTranscodes: 0}},
+		}, "", ""},
+		{"other server", models.TranscodeBudgetConfig{ServerID: 2, MaxTranscodes: 1}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := json.Marshal(tt.config)
+			rule := &models.Rule{ID: 1, Name: "Budget", Type: models.RuleTypeTranscodeBudget, Config: cfg}
+			var anchors []string
+			var target *TerminateTarget
+			for i := range streams {
+				result, err := e.Evaluate(ctx, rule, &EvaluationInput{Stream: &streams[i], AllStreams: streams})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if result != nil {
+					anchors = append(anchors, streams[i].SessionID)
+					target = result.TerminateTarget
+				}
+			}
+			if tt.wantAnchor == "" {
+				if len(anchors) != 0 {
+					t.Fatalf("violations on %v, want none", anchors)
+				}
+				return
+			}
+			if len(anchors) != 1 || anchors[0] != tt.wantAnchor {
+				t.Fatalf("violations on %v, want [%s]", anchors, tt.wantAnchor)
+			}
+			gotTarget := ""
+			if target != nil {
+				gotTarget = target.SessionID
+			}
+			if gotTarget != tt.wantTarget {
+				t.Errorf("target = %q, want %q", gotTarget, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestTranscodeBudgetActions(t *testing.T) {
+	cfg, _ := json.Marshal(models.TranscodeBudgetConfig{Policy: models.TranscodeBudgetTerminateNewestRemote})
+	rule := &models.Rule{Type: models.RuleTypeTranscodeBudget, Config: cfg}
+	actions := ruleActions(rule)
+	if len(actions) != 1 || actions[0].Type != models.RuleActionTerminateStream || actions[0].Message != models.DefaultTranscodeBudgetStopText {
+		t.Fatalf("actions = %+v", actions)
+	}
+
+	rule.Actions = []models.RuleAction{{Type: models.RuleActionTerminateStream, Message: "custom"}}
+	if actions := ruleActions(rule); len(actions) != 1 || actions[0].Message != "custom" {
+		t.Errorf("listed action replaced: %+v", actions)
+	}
+
+	cfg, _ = json.Marshal(models.TranscodeBudgetConfig{Windows: []models.TranscodeBudgetWindow{{Start: "25:00", End: "06:00"}}})
+	bad := models.Rule{Name: "Budget", Type: models.RuleTypeTranscodeBudget, Config: cfg}
+	if err := bad.Validate(); err == nil {
+		t.Error("expected invalid window to fail validation")
+	}
+}
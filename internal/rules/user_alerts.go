@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// UserNotifier delivers alerts to users about their own accounts. Notifiers
+// that don't implement it only reach the admin channels.
+type UserNotifier interface {
+	NotifyUser(ctx context.Context, v *models.RuleViolation, alert models.UserAlert) ([]models.PushSubscription, error)
+}
+
+// UserAlertStore is what alerting users about their accounts reads.
+type UserAlertStore interface {
+	ListUserAlertSubscribers(ctx context.Context, event models.UserAlertEvent) ([]models.UserAlertSubscriber, error)
+	SelfScope(ctx context.Context, userID int64, userName string) (store.UserScope, error)
+	GetUserNotificationSettings() (models.UserNotificationSettings, error)
+	GetNotificationChannel(id int64) (*models.NotificationChannel, error)
+	ListPushSubscriptions(ctx context.Context, userID int64) ([]models.PushSubscription, error)
+	DeletePushSubscription(userID, id int64) error
+	GetVAPIDKeys() (models.VAPIDKeys, error)
+}
+
+// notifyUsers alerts the StreamMon users whose own media accounts the
+// violation is about, when they subscribed to its kind of event.
+func (e *Engine) notifyUsers(ctx context.Context, ruleType models.RuleType, v *models.RuleViolation) {
+	un, ok := e.notifier.(UserNotifier)
+	if !ok {
+		return
+	}
+	event, ok := models.UserAlertEventForRule(ruleType)
+	if !ok {
+		return
+	}
+	subscribers, err := e.store.ListUserAlertSubscribers(ctx, event)
+	if err != nil {
+		log.Printf("rules engine: listing %s subscribers: %v", event, err)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+	var serverID int64
+	if v.Stream != nil {
+		serverID = v.Stream.ServerID
+	}
+
+	var smtp *models.EmailConfig
+	var vapid models.VAPIDKeys
+	var vapidErr error
+	vapidLoaded := false
+	for _, sub := range subscribers {
+		scope, err := e.store.SelfScope(ctx, sub.UserID, sub.Name)
+		if err != nil {
+			log.Printf("rules engine: resolving accounts of user %d: %v", sub.UserID, err)
+			continue
+		}
+		if !scope.Matches(serverID, v.UserName) {
+			continue
+		}
+
+		alert := models.UserAlert{Event: event, UserName: sub.Name}
+		if sub.Subscription.Email && sub.Email != "" {
+			if smtp == nil {
+				smtp = e.userAlertSMTP()
+			}
+			if smtp != nil {
+				alert.Email = sub.Email
+				alert.SMTP = smtp
+			}
+		}
+		if sub.Subscription.Push {
+			if !vapidLoaded {
+				vapid, vapidErr = e.store.GetVAPIDKeys()
+				vapidLoaded = true
+			}
+			if vapidErr == nil {
+				alert.VAPIDKeys = vapid
+				if alert.Push, err = e.store.ListPushSubscriptions(ctx, sub.UserID); err != nil {
+					log.Printf("rules engine: listing push subscriptions of user %d: %v", sub.UserID, err)
+				}
+			}
+		}
+		if alert.SMTP == nil && len(alert.Push) == 0 {
+			continue
+		}
+
+		expired, err := un.NotifyUser(ctx, v, alert)
+		if err != nil {
+			log.Printf("rules engine: alerting user %d: %v", sub.UserID, err)
+		}
+		for _, p := range expired {
+			if err := e.store.DeletePushSubscription(sub.UserID, p.ID); err != nil && !errors.Is(err, models.ErrNotFound) {
+				log.Printf("rules engine: removing expired push subscription: %v", err)
+			}
+		}
+	}
+}
+
+// userAlertSMTP returns the SMTP settings of the email channel an admin
+// chose for user alerts, or nil when none is chosen or it's unusable.
+func (e *Engine) userAlertSMTP() *models.EmailConfig {
+	settings, err := e.store.GetUserNotificationSettings()
+	if err != nil || settings.EmailChannelID == 0 {
+		return nil
+	}
+	ch, err := e.store.GetNotificationChannel(settings.EmailChannelID)
+	if err != nil {
+		log.Printf("rules engine: loading user alert email channel: %v", err)
+		return nil
+	}
+	if ch.ChannelType != models.ChannelTypeEmail {
+		return nil
+	}
+	var config models.EmailConfig
+	if err := json.Unmarshal(ch.Config, &config); err != nil {
+		return nil
+	}
+	return &config
+}
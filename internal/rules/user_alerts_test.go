@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+type mockUserNotifier struct {
+	mockNotifier
+	mu      sync.Mutex
+	alerts  []models.UserAlert
+	expired bool
+}
+
+func (m *mockUserNotifier) NotifyUser(ctx context.Context, v *models.RuleViolation, alert models.UserAlert) ([]models.PushSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerts = append(m.alerts, alert)
+	if m.expired {
+		return alert.Push, nil
+	}
+	return nil, nil
+}
+
+func testPushSubscription(t *testing.T, userID int64, endpoint string) *models.PushSubscription {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+	}
+}
+
+func TestEngine_NotifyUsers(t *testing.T) {
+	e, s := setupTestEngine(t)
+	notif := &mockUserNotifier{}
+	e.SetNotifier(notif)
+	ctx := context.Background()
+
+	alice, err := s.CreateLocalUser("alice", "alice@example.com", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := s.CreateLocalUser("bob", "bob@example.com", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := map[int64]models.UserNotificationSubscription{
+		alice.ID: {Events: []models.UserAlertEvent{models.UserAlertNewDevice}, Email: true, Push: true},
+		bob.ID:   {Events: []models.UserAlertEvent{models.UserAlertNewDevice}, Email: true},
+	}
+	for id, sub := range subs {
+		if err := s.SetUserNotificationSubscription(id, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.SetVAPIDKeys(models.VAPIDKeys{PublicKey: "pub", PrivateKey: "priv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPushSubscription(testPushSubscription(t, alice.ID, "https://push.example.com/alice")); err != nil {
+		t.Fatal(err)
+	}
+	email := &models.NotificationChannel{
+		Name: "Email", ChannelType: models.ChannelTypeEmail, Enabled: true,
+		Config: json.RawMessage(`{"host":"smtp.example.com","from":"streammon@example.com","to":["admin@example.com"]}`),
+	}
+	if err := s.CreateNotificationChannel(email); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserNotificationSettings(models.UserNotificationSettings{EmailChannelID: email.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &models.RuleViolation{UserName: "alice", Message: "New device: Chrome"}
+	e.notifyUsers(ctx, models.RuleTypeNewDevice, v)
+	if len(notif.alerts) != 1 {
+		t.Fatalf("alerts = %+v, want one for alice", notif.alerts)
+	}
+	got := notif.alerts[0]
+	if got.UserName != "alice" || got.Email != "alice@example.com" || got.SMTP == nil || got.SMTP.Host != "smtp.example.com" {
+		t.Errorf("alert = %+v", got)
+	}
+	if len(got.Push) != 1 || got.VAPIDKeys.PrivateKey != "priv" {
+		t.Errorf("push = %+v, keys = %+v", got.Push, got.VAPIDKeys)
+	}
+
+	// Rules without a user alert event reach nobody.
+	e.notifyUsers(ctx, models.RuleTypeConcurrentStreams, v)
+	// Nor do events the user didn't subscribe to.
+	e.notifyUsers(ctx, models.RuleTypeNewLocation, v)
+	if len(notif.alerts) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(notif.alerts))
+	}
+
+	// Browsers the push service forgot are removed.
+	notif.expired = true
+	e.notifyUsers(ctx, models.RuleTypeNewDevice, v)
+	push, err := s.ListPushSubscriptions(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(push) != 0 {
+		t.Errorf("expired push subscription kept: %+v", push)
+	}
+}
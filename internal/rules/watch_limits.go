@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// watchLimitCheckInterval spaces out the history reads behind each user's
+// watch limit check.
+const watchLimitCheckInterval = time.Minute
+
+// WatchGoalStore is what the engine reads and records for watch limits.
+type WatchGoalStore interface {
+	ListWatchGoals() ([]models.WatchGoal, error)
+	WatchGoalProgress(ctx context.Context, goal models.WatchGoal, now time.Time, serverIDs []int64) (*models.WatchGoalProgress, error)
+	ClaimWatchLimitNotice(ctx context.Context, userName, week string) (bool, error)
+}
+
+func (e *Engine) loadWatchGoals() map[string]models.WatchGoal {
+	list, err := e.store.ListWatchGoals()
+	if err != nil {
+		log.Printf("rules engine: failed to load watch goals: %v", err)
+		return nil
+	}
+	goals := make(map[string]models.WatchGoal, len(list))
+	for _, g := range list {
+		if g.WeeklyLimitMinutes > 0 {
+			goals[g.UserName] = g
+		}
+	}
+	return goals
+}
+
+// checkWatchLimits announces, once a week, each user with a weekly limit
+// whose finished and still-playing sessions have gone over it. Enforcement
+// is left to the admin; this only reports.
+func (e *Engine) checkWatchLimits(ctx context.Context, streams []models.ActiveStream) {
+	if e.notifier == nil {
+		return
+	}
+	e.mu.RLock()
+	goals := e.watchGoals
+	e.mu.RUnlock()
+	if len(goals) == 0 {
+		return
+	}
+
+	byUser := make(map[string][]models.ActiveStream)
+	for _, s := range streams {
+		if _, ok := goals[s.UserName]; ok {
+			byUser[s.UserName] = append(byUser[s.UserName], s)
+		}
+	}
+
+	now := time.Now()
+	for userName, userStreams := range byUser {
+		if !e.watchLimitDue(userName, now) {
+			continue
+		}
+		goal := goals[userName]
+		progress, err := e.store.WatchGoalProgress(ctx, goal, now, nil)
+		if err != nil {
+			log.Printf("rules engine: watch goal progress for %s: %v", userName, err)
+			continue
+		}
+		sinceWeekStart := now.Sub(progress.WeekStart).Milliseconds()
+		for _, s := range userStreams {
+			progress.AddWatched(min(activePlayMs(s, now), sinceWeekStart))
+		}
+		if !progress.LimitExceeded {
+			continue
+		}
+		claimed, err := e.store.ClaimWatchLimitNotice(ctx, userName, progress.WeekStart.Format("2006-01-02"))
+		if err != nil {
+			log.Printf("rules engine: %v", err)
+			continue
+		}
+		if claimed {
+			e.notifyWatchLimit(progress, userStreams[0], now)
+		}
+	}
+}
+
+// watchLimitDue reports whether userName's limit is due a check at now,
+// starting the next interval when it is.
+func (e *Engine) watchLimitDue(userName string, now time.Time) bool {
+	e.watchLimitMu.Lock()
+	defer e.watchLimitMu.Unlock()
+	if now.Before(e.watchLimitChecked[userName].Add(watchLimitCheckInterval)) {
+		return false
+	}
+	if e.watchLimitChecked == nil {
+		e.watchLimitChecked = make(map[string]time.Time)
+	}
+	e.watchLimitChecked[userName] = now
+	return true
+}
+
+func (e *Engine) notifyWatchLimit(p *models.WatchGoalProgress, stream models.ActiveStream, now time.Time) {
+	violation := &models.RuleViolation{
+		RuleName:        "Weekly Watch Limit",
+		UserName:        p.UserName,
+		Severity:        models.SeverityWarning,
+		Message:         fmt.Sprintf("%s has watched %s this week, over their weekly limit of %s", p.UserName, formatMinutes(p.WatchedMinutes), formatMinutes(p.WeeklyLimitMinutes)),
+		ConfidenceScore: 100,
+		Details: map[string]interface{}{
+			"watched_minutes": p.WatchedMinutes,
+			"limit_minutes":   p.WeeklyLimitMinutes,
+			"week_start":      p.WeekStart.Format("2006-01-02"),
+		},
+		Stream:     &stream,
+		Event:      models.NotificationEventWatchLimit,
+		OccurredAt: now.UTC(),
+	}
+
+	e.notifyWg.Add(1)
+	go func() {
+		defer e.notifyWg.Done()
+
+		channels, err := e.store.ListEnabledNotificationChannels()
+		if err != nil {
+			log.Printf("rules engine: error listing notification channels: %v", err)
+			return
+		}
+		channels = channelsForEvent(channels, violation.Event, violation, e.eventWorkspace(violation))
+		if len(channels) == 0 {
+			return
+		}
+
+		notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := e.notifier.Notify(notifyCtx, violation, channels); err != nil {
+			log.Printf("rules engine: error sending watch limit notification: %v", err)
+		}
+	}()
+}
+
+// formatMinutes renders minutes as hours and minutes, e.g. "5h 20m".
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
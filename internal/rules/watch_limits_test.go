@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestEngine_WatchLimitNotification(t *testing.T) {
+	now := time.Now()
+	weekStart := models.WeekStart(now)
+	if now.Sub(weekStart) < time.Hour {
+		t.Skip("too close to the start of the week for an hour of watching")
+	}
+
+	e, s := setupTestEngine(t)
+	ctx := context.Background()
+	notif := &mockNotifier{}
+	e.SetNotifier(notif)
+
+	channel := &models.NotificationChannel{
+		Name:        "Test Discord",
+		ChannelType: models.ChannelTypeDiscord,
+		Config:      json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/test"}`),
+		Enabled:     true,
+	}
+	if err := s.CreateNotificationChannel(channel); err != nil {
+		t.Fatal(err)
+	}
+	srv := &models.Server{Name: "Test", Type: models.ServerTypePlex, URL: "http://test", APIKey: "k", Enabled: true}
+	if err := s.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	started := now.Add(-time.Hour).UTC()
+	if err := s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", Title: "Movie", MediaType: models.MediaTypeMovie,
+		StartedAt: started, StoppedAt: started.Add(40 * time.Minute), WatchedMs: (40 * time.Minute).Milliseconds(),
+		DurationMs: (2 * time.Hour).Milliseconds(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetWatchGoal(&models.WatchGoal{UserName: "alice", WeeklyLimitMinutes: 60}); err != nil {
+		t.Fatal(err)
+	}
+	e.RefreshRules()
+
+	streams := func(playing time.Duration) []models.ActiveStream {
+		return []models.ActiveStream{
+			{SessionID: "a", ServerID: srv.ID, UserName: "alice", StartedAt: now.Add(-playing).UTC()},
+			{SessionID: "b", ServerID: srv.ID, UserName: "bob", StartedAt: now.Add(-playing).UTC()},
+		}
+	}
+
+	// 40 minutes in history and 10 playing: under the limit.
+	e.EvaluateSessions(ctx, streams(10*time.Minute))
+	e.WaitForNotifications()
+	if notif.count() != 0 {
+		t.Fatalf("notified under the limit: %d", notif.count())
+	}
+
+	// Checks are spaced out, so forget the last one rather than wait.
+	e.watchLimitChecked = nil
+	e.EvaluateSessions(ctx, streams(25*time.Minute))
+	e.WaitForNotifications()
+	if notif.count() != 1 {
+		t.Fatalf("expected 1 watch limit notification, got %d", notif.count())
+	}
+	v := notif.notifications[0]
+	if v.Event != models.NotificationEventWatchLimit || v.UserName != "alice" || v.Details["watched_minutes"] != 65 {
+		t.Errorf("violation = %+v", v)
+	}
+
+	// Once a week.
+	e.watchLimitChecked = nil
+	e.EvaluateSessions(ctx, streams(30*time.Minute))
+	e.WaitForNotifications()
+	if notif.count() != 1 {
+		t.Errorf("announced the same week twice: %d", notif.count())
+	}
+}
+
+func TestFormatMinutes(t *testing.T) {
+	for minutes, want := range map[int]string{0: "0m", 45: "45m", 60: "1h", 320: "5h 20m"} {
+		if got := formatMinutes(minutes); got != want {
+			t.Errorf("formatMinutes(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"sync"
 	"time"
 
+	"streammon/internal/digest"
 	"streammon/internal/maintenance"
 	"streammon/internal/media"
 	"streammon/internal/models"
 	"streammon/internal/poller"
 	"streammon/internal/store"
 	"streammon/internal/tmdb"
+	"streammon/internal/webhooks"
 )
 
 const DefaultSyncTimeout = 6 * time.Hour
@@ -21,6 +23,8 @@ type Scheduler struct {
 	poller      *poller.Poller
 	tmdb        *tmdb.Client
 	syncTimeout time.Duration
+	digests     *digest.Sender
+	webhooks    *webhooks.Dispatcher
 
 	startOnce sync.Once
 	cancel    context.CancelFunc
@@ -35,6 +39,22 @@ func WithSyncTimeout(d time.Duration) Option {
 	}
 }
 
+// WithDigests sends due digests after each daily sync, so new content is
+// counted once the libraries are up to date.
+func WithDigests(d *digest.Sender) Option {
+	return func(s *Scheduler) {
+		s.digests = d
+	}
+}
+
+// WithWebhooks sends maintenance.evaluated webhooks after the daily rule
+// evaluation.
+func WithWebhooks(d *webhooks.Dispatcher) Option {
+	return func(s *Scheduler) {
+		s.webhooks = d
+	}
+}
+
 func New(s *store.Store, p *poller.Poller, tmdbClient *tmdb.Client, opts ...Option) *Scheduler {
 	sch := &Scheduler{
 		store:       s,
@@ -70,8 +90,10 @@ func (sch *Scheduler) run(ctx context.Context) {
 	if err := sch.SyncAll(ctx); err != nil {
 		log.Printf("scheduler: initial sync failed: %v", err)
 	}
+	sch.sendDigests(ctx)
 
 	sch.cleanupSessions()
+	sch.cleanupZombieSessions(ctx)
 
 	// The next daily sync fires once at a variable delay (time until 3 AM,
 	// which shifts across DST transitions) rather than on a fixed period, so
@@ -91,14 +113,25 @@ func (sch *Scheduler) run(ctx context.Context) {
 			if err := sch.SyncAll(ctx); err != nil {
 				log.Printf("scheduler: daily sync failed: %v", err)
 			}
+			sch.sendDigests(ctx)
 			// Recalculate to handle DST transitions
 			syncTimer.Reset(durationUntil3AM(time.Now()))
 		case <-sessionTicker.C:
 			sch.cleanupSessions()
+			sch.cleanupShareLinks()
+			sch.cleanupGuestTokens()
+			sch.cleanupZombieSessions(ctx)
 		}
 	}
 }
 
+func (sch *Scheduler) sendDigests(ctx context.Context) {
+	if sch.digests == nil {
+		return
+	}
+	sch.digests.SendDue(ctx)
+}
+
 func (sch *Scheduler) cleanupSessions() {
 	deleted, err := sch.store.DeleteExpiredSessions()
 	if err != nil {
@@ -110,6 +143,50 @@ func (sch *Scheduler) cleanupSessions() {
 	}
 }
 
+func (sch *Scheduler) cleanupShareLinks() {
+	deleted, err := sch.store.DeleteExpiredShareLinks()
+	if err != nil {
+		log.Printf("scheduler: share link cleanup failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("scheduler: cleaned up %d expired share links", deleted)
+	}
+}
+
+func (sch *Scheduler) cleanupGuestTokens() {
+	deleted, err := sch.store.DeleteExpiredGuestTokens()
+	if err != nil {
+		log.Printf("scheduler: guest token cleanup failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("scheduler: cleaned up %d expired guest tokens", deleted)
+	}
+}
+
+// cleanupZombieSessions runs the zombie cleanup if the admin turned it on.
+func (sch *Scheduler) cleanupZombieSessions(ctx context.Context) {
+	settings, err := sch.store.GetZombieSettings()
+	if err != nil {
+		log.Printf("scheduler: reading zombie settings: %v", err)
+		return
+	}
+	if !settings.Enabled {
+		return
+	}
+	report, err := sch.store.CleanupZombieSessions(ctx)
+	if err != nil {
+		log.Printf("scheduler: zombie session cleanup failed: %v", err)
+		return
+	}
+	if report.Closed == 0 {
+		return
+	}
+	log.Printf("scheduler: closed %d zombie sessions (%d max age, %d silence)",
+		report.Closed, report.ByReason[store.ZombieReasonMaxAge], report.ByReason[store.ZombieReasonSilence])
+}
+
 func (sch *Scheduler) SyncAll(ctx context.Context) error {
 	log.Println("scheduler: starting library sync")
 	startTime := time.Now().UTC()
@@ -120,6 +197,12 @@ func (sch *Scheduler) SyncAll(ctx context.Context) error {
 	// Phase 1.5: Fetch and persist TV series statuses from TMDB
 	sch.syncTVStatuses(ctx)
 
+	// Phase 1.6: Backfill library IDs on legacy history rows
+	sch.linkOrphanedHistory(ctx)
+
+	// Phase 1.7: Archive users who dropped off their server's user list
+	sch.syncServerUsers(ctx)
+
 	// Phase 2: Evaluate all rules now that all libraries are synced
 	totalCandidates, evalErrors := sch.evaluateAllRules(ctx)
 	totalErrors := syncErrors + evalErrors
@@ -143,6 +226,15 @@ func (sch *Scheduler) syncAllLibraries(ctx context.Context) (totalLibs, totalIte
 		return 0, 0, 1
 	}
 
+	schedules, err := sch.store.ListLibrarySyncSchedules(ctx)
+	if err != nil {
+		// Fall back to syncing everything daily rather than skipping the run.
+		log.Printf("scheduler: list library sync schedules: %v", err)
+		schedules = nil
+	}
+	now := time.Now().UTC()
+	var skipped int
+
 	for _, srv := range servers {
 		if ctx.Err() != nil {
 			return totalLibs, totalItems, totalErrors
@@ -173,6 +265,11 @@ func (sch *Scheduler) syncAllLibraries(ctx context.Context) (totalLibs, totalIte
 			if lib.Type != models.LibraryTypeMovie && lib.Type != models.LibraryTypeShow {
 				continue
 			}
+			schedule := schedules[models.RuleLibrary{ServerID: srv.ID, LibraryID: lib.ID}]
+			if !sch.libraryDue(ctx, srv.ID, lib.ID, schedule, now) {
+				skipped++
+				continue
+			}
 
 			itemCount, syncErr := sch.syncLibrary(ctx, srv.ID, srv.Name, lib.ID, lib.Name, ms, identity)
 			if syncErr != nil {
@@ -186,10 +283,33 @@ func (sch *Scheduler) syncAllLibraries(ctx context.Context) (totalLibs, totalIte
 		}
 	}
 
-	log.Printf("scheduler: phase 1 complete - synced %d libraries, %d items", totalLibs, totalItems)
+	log.Printf("scheduler: phase 1 complete - synced %d libraries, %d items (%d not due)", totalLibs, totalItems, skipped)
 	return totalLibs, totalItems, totalErrors
 }
 
+// weeklySyncMinAge is how old a weekly library's last sync must be before the
+// daily run picks it up again. It's short of a full week so a sync that
+// finished a few minutes after 3 AM doesn't push the next one out a day.
+const weeklySyncMinAge = 6*24*time.Hour + 12*time.Hour
+
+// libraryDue reports whether a library should sync in this run given its
+// schedule. Libraries with no explicit schedule sync daily.
+func (sch *Scheduler) libraryDue(ctx context.Context, serverID int64, libraryID string, schedule models.LibrarySyncSchedule, now time.Time) bool {
+	switch schedule {
+	case models.LibrarySyncOff:
+		return false
+	case models.LibrarySyncWeekly:
+		last, err := sch.store.GetLastSyncTime(ctx, serverID, libraryID)
+		if err != nil {
+			log.Printf("scheduler: last sync time for server %d library %s: %v", serverID, libraryID, err)
+			return true
+		}
+		return last == nil || now.Sub(*last) >= weeklySyncMinAge
+	default:
+		return true
+	}
+}
+
 func (sch *Scheduler) syncTVStatuses(ctx context.Context) {
 	if sch.tmdb == nil {
 		return
@@ -231,6 +351,55 @@ func (sch *Scheduler) syncTVStatuses(ctx context.Context) {
 	log.Printf("scheduler: updated %d TV series statuses", len(statuses))
 }
 
+func (sch *Scheduler) linkOrphanedHistory(ctx context.Context) {
+	res, err := sch.store.LinkOrphanedHistory(ctx)
+	if err != nil {
+		log.Printf("scheduler: link orphaned history: %v", err)
+		return
+	}
+	if res.Groups == 0 {
+		return
+	}
+	log.Printf("scheduler: linked %d orphaned history rows (%d titles matched, %d ambiguous, %d unmatched)",
+		res.Linked, res.Matched, res.Ambiguous, res.Unmatched)
+}
+
+func (sch *Scheduler) syncServerUsers(ctx context.Context) {
+	servers, err := sch.store.ListServers()
+	if err != nil {
+		log.Printf("scheduler: list servers: %v", err)
+		return
+	}
+	for _, srv := range servers {
+		if ctx.Err() != nil {
+			return
+		}
+		if !srv.Enabled {
+			continue
+		}
+		ms, ok := sch.poller.GetServer(srv.ID)
+		if !ok {
+			continue
+		}
+		users, err := ms.GetUsers(ctx)
+		if err != nil {
+			log.Printf("scheduler: get users for %s: %v", srv.Name, err)
+			continue
+		}
+		archived, restored, err := sch.store.ReconcileServerUsers(ctx, srv.ID, models.MediaUserNames(users), time.Now().UTC())
+		if err != nil {
+			log.Printf("scheduler: reconcile users for %s: %v", srv.Name, err)
+			continue
+		}
+		if len(archived) > 0 {
+			log.Printf("scheduler: archived %d users no longer on %s: %v", len(archived), srv.Name, archived)
+		}
+		if len(restored) > 0 {
+			log.Printf("scheduler: restored %d users back on %s: %v", len(restored), srv.Name, restored)
+		}
+	}
+}
+
 func (sch *Scheduler) evaluateAllRules(ctx context.Context) (totalCandidates, totalErrors int) {
 	rules, err := sch.store.ListAllMaintenanceRules(ctx)
 	if err != nil {
@@ -261,6 +430,9 @@ func (sch *Scheduler) evaluateAllRules(ctx context.Context) (totalCandidates, to
 			totalErrors++
 			continue
 		}
+		if sch.webhooks != nil {
+			sch.webhooks.MaintenanceEvaluated(&rule, len(candidates))
+		}
 
 		if len(candidates) > 0 {
 			log.Printf("scheduler: rule %d (%s): found %d candidates", rule.ID, rule.Name, len(candidates))
@@ -619,3 +619,56 @@ func TestEvaluateAllRulesIndependently(t *testing.T) {
 		t.Errorf("rule2: expected 1 candidate (low res), got %d", r2.Total)
 	}
 }
+
+func TestSyncAllRespectsLibrarySchedules(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	srv := seedServer(t, s, "test-server")
+	now := time.Now().UTC()
+
+	item := func(lib string) []models.LibraryItemCache {
+		return []models.LibraryItemCache{{
+			ServerID: srv.ID, LibraryID: lib, ItemID: lib + "-item", MediaType: models.MediaTypeMovie,
+			Title: "Movie", AddedAt: now, SyncedAt: now,
+		}}
+	}
+	var order []string
+	fake := &fakeMediaServer{
+		id:   srv.ID,
+		name: srv.Name,
+		libraries: []models.Library{
+			{ID: "daily", Name: "Daily", Type: models.LibraryTypeMovie},
+			{ID: "weekly", Name: "Weekly", Type: models.LibraryTypeMovie},
+			{ID: "weekly-new", Name: "Weekly New", Type: models.LibraryTypeMovie},
+			{ID: "off", Name: "Off", Type: models.LibraryTypeMovie},
+		},
+		items: map[string][]models.LibraryItemCache{
+			"daily": item("daily"), "weekly": item("weekly"), "weekly-new": item("weekly-new"), "off": item("off"),
+		},
+		syncOrder: &order,
+	}
+	p := poller.New(s, 5*time.Second)
+	p.AddServer(srv.ID, fake)
+	sch := New(s, p, nil, WithSyncTimeout(time.Minute))
+
+	// "weekly" has a fresh sync; "weekly-new" has never synced.
+	if _, _, err := s.SyncLibraryItems(ctx, srv.ID, "weekly", item("weekly")); err != nil {
+		t.Fatal(err)
+	}
+	for lib, schedule := range map[string]models.LibrarySyncSchedule{
+		"weekly":     models.LibrarySyncWeekly,
+		"weekly-new": models.LibrarySyncWeekly,
+		"off":        models.LibrarySyncOff,
+	} {
+		if err := s.SetLibrarySyncSchedule(ctx, srv.ID, lib, schedule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := sch.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "daily" || order[1] != "weekly-new" {
+		t.Errorf("synced libraries = %v, want [daily weekly-new]", order)
+	}
+}
@@ -102,8 +102,8 @@ func (s *Server) handleAdminUpdateUserRole(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if req.Role != models.RoleAdmin && req.Role != models.RoleViewer {
-		writeError(w, http.StatusBadRequest, "role must be 'admin' or 'viewer'")
+	if !req.Role.Valid() {
+		writeError(w, http.StatusBadRequest, "role must be 'admin', 'coadmin', or 'viewer'")
 		return
 	}
 
@@ -115,6 +115,21 @@ func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
 	p.HandleCallback(w, r)
 }
 
+// handleOIDCBackchannelLogout ends the sessions named by an IdP logout token
+func (s *Server) handleOIDCBackchannelLogout(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.providerHandler(auth.ProviderOIDC)
+	if !ok || !p.Enabled() {
+		writeError(w, http.StatusNotFound, "OIDC not configured")
+		return
+	}
+	op, ok := p.(*auth.OIDCProvider)
+	if !ok {
+		writeError(w, http.StatusNotFound, "OIDC not configured")
+		return
+	}
+	op.HandleBackchannelLogout(w, r)
+}
+
 // handleEmbyLogin handles Emby credential-based login
 func (s *Server) handleEmbyLogin(w http.ResponseWriter, r *http.Request) {
 	msp, ok := s.mediaServerHandler(auth.ProviderEmby)
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+// bandwidthDefaultDays is the range of a bandwidth request that gives none,
+// which would otherwise return every hourly bucket ever recorded.
+const bandwidthDefaultDays = 7
+
+func (s *Server) handleGetBandwidthStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Days == 0 && filter.StartDate.IsZero() {
+		filter.Days = bandwidthDefaultDays
+	}
+
+	interval := models.BandwidthHourly
+	if v := r.URL.Query().Get("interval"); v != "" {
+		interval = models.BandwidthInterval(v)
+		if !interval.Valid() {
+			writeError(w, http.StatusBadRequest, "interval must be hourly or daily")
+			return
+		}
+	}
+
+	stats, err := s.store.BandwidthStats(r.Context(), filter, interval)
+	if err != nil {
+		log.Printf("bandwidth stats: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
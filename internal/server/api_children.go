@@ -37,7 +37,7 @@ func (s *Server) handleGetChildren(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ms, ok := s.poller.GetServer(serverID)
-	if !ok {
+	if !ok || !visibleToWorkspace(r, serverID) {
 		writeError(w, http.StatusNotFound, "server not found")
 		return
 	}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type concurrentRecordSettingsPayload struct {
+	Notify bool `json:"notify"`
+}
+
+func (s *Server) handleGetConcurrentRecords(w http.ResponseWriter, r *http.Request) {
+	records, err := s.store.ConcurrentRecords(r.Context(), workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("concurrent records: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (s *Server) handleUpdateConcurrentRecordSettings(w http.ResponseWriter, r *http.Request) {
+	var req concurrentRecordSettingsPayload
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := s.store.SetConcurrentRecordNotify(req.Notify); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
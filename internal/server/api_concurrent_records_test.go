@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestConcurrentRecordsAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/settings/concurrent-records", strings.NewReader(`{"notify":true}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if on, _ := st.GetConcurrentRecordNotify(); !on {
+		t.Fatal("notify setting not persisted")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stats/concurrent-records", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.ConcurrentRecords
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.Notify || resp.AllTime.Count != 0 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
+
+func TestConcurrentRecordsAPI_ViewerForbidden(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/api/stats/concurrent-records", ""},
+		{http.MethodPut, "/api/settings/concurrent-records", `{"notify":true}`},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
@@ -12,7 +12,7 @@ func (s *Server) handleDashboardSessions(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	sessions := s.poller.CurrentSessions()
+	sessions := workspaceSessions(r, s.poller.CurrentSessions())
 
 	// Viewers can only see their own sessions
 	user := UserFromContext(r.Context())
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// dashboardOverviewResponse is everything the dashboard shows on load, so
+// the UI and third-party widgets need a single request.
+type dashboardOverviewResponse struct {
+	Sessions        []models.ActiveStream     `json:"sessions"`
+	Streams         dashboardSummaryResponse  `json:"streams"`
+	Today           models.LibraryStat        `json:"today"`
+	TopUserThisWeek *models.UserStat          `json:"top_user_this_week"`
+	Servers         []dashboardServerHealth   `json:"servers"`
+	Maintenance     models.MaintenancePending `json:"maintenance"`
+}
+
+type serverHealthStatus string
+
+const (
+	serverHealthOK       serverHealthStatus = "ok"
+	serverHealthError    serverHealthStatus = "error"
+	serverHealthUnknown  serverHealthStatus = "unknown"
+	serverHealthPaused   serverHealthStatus = "paused"
+	serverHealthDisabled serverHealthStatus = "disabled"
+)
+
+type dashboardServerHealth struct {
+	ID                  int64              `json:"id"`
+	Name                string             `json:"name"`
+	Type                models.ServerType  `json:"type"`
+	Status              serverHealthStatus `json:"status"`
+	LastPollAt          *time.Time         `json:"last_poll_at,omitempty"`
+	LastSuccessAt       *time.Time         `json:"last_success_at,omitempty"`
+	LastError           string             `json:"last_error,omitempty"`
+	ConsecutiveFailures int                `json:"consecutive_failures"`
+}
+
+func (s *Server) serverHealth(srv models.Server) dashboardServerHealth {
+	h := dashboardServerHealth{ID: srv.ID, Name: srv.Name, Type: srv.Type, Status: serverHealthUnknown}
+	switch {
+	case !srv.Enabled:
+		h.Status = serverHealthDisabled
+		return h
+	case srv.Paused:
+		h.Status = serverHealthPaused
+		return h
+	case s.poller == nil:
+		return h
+	}
+	ph, ok := s.poller.PollHealth(srv.ID)
+	if !ok {
+		return h
+	}
+	h.LastPollAt = &ph.LastPollAt
+	if !ph.LastSuccessAt.IsZero() {
+		h.LastSuccessAt = &ph.LastSuccessAt
+	}
+	if ph.LastError != nil {
+		h.LastError = sanitizeConnError(ph.LastError)
+	}
+	h.ConsecutiveFailures = ph.ConsecutiveFailures
+	h.Status = serverHealthOK
+	if ph.ConsecutiveFailures > 0 {
+		h.Status = serverHealthError
+	}
+	return h
+}
+
+// dashboardPeriods returns the start of today and of this week (Monday) in
+// the caller's timezone, as UTC.
+func dashboardPeriods(now time.Time, tzOffsetMinutes int) (today, week time.Time) {
+	local := now.In(time.FixedZone("", tzOffsetMinutes*60))
+	y, m, d := local.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, local.Location())
+	daysSinceMonday := (int(start.Weekday()) + 6) % 7
+	return start.UTC(), start.AddDate(0, 0, -daysSinceMonday).UTC()
+}
+
+func (s *Server) handleDashboardOverview(w http.ResponseWriter, r *http.Request) {
+	tzOffset, err := parseTZOffset(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tz_offset")
+		return
+	}
+	ctx := r.Context()
+
+	servers, err := s.store.ListServers()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	servers = workspaceServers(r, servers)
+	serverIDs := workspaceServerIDs(r)
+
+	resp := dashboardOverviewResponse{
+		Sessions: []models.ActiveStream{},
+		Servers:  make([]dashboardServerHealth, 0, len(servers)),
+	}
+	if s.poller != nil {
+		if sessions := s.poller.CurrentSessions(); sessions != nil {
+			resp.Sessions = workspaceSessions(r, sessions)
+		}
+	}
+	resp.Streams = summarizeSessions(resp.Sessions, len(servers))
+	for _, srv := range servers {
+		resp.Servers = append(resp.Servers, s.serverHealth(srv))
+	}
+
+	now := time.Now().UTC()
+	todayStart, weekStart := dashboardPeriods(now, tzOffset)
+	today, err := s.store.LibraryStats(ctx, store.StatsFilter{StartDate: todayStart, EndDate: now, ServerIDs: serverIDs})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	resp.Today = *today
+
+	top, err := s.store.TopUsers(ctx, 1, store.StatsFilter{StartDate: weekStart, EndDate: now, ServerIDs: serverIDs})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if len(top) > 0 {
+		resp.TopUserThisWeek = &top[0]
+	}
+
+	if resp.Maintenance, err = s.store.PendingMaintenance(ctx, serverIDs); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
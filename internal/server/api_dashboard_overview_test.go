@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+	"streammon/internal/poller"
+)
+
+func TestDashboardPeriods(t *testing.T) {
+	// Wednesday 01:30 UTC is still Tuesday evening at UTC-5.
+	now := time.Date(2026, 3, 4, 1, 30, 0, 0, time.UTC)
+	today, week := dashboardPeriods(now, -300)
+	if want := time.Date(2026, 3, 3, 5, 0, 0, 0, time.UTC); !today.Equal(want) {
+		t.Errorf("today = %v, want %v", today, want)
+	}
+	if want := time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC); !week.Equal(want) {
+		t.Errorf("week = %v, want %v", week, want)
+	}
+}
+
+func TestDashboardOverview(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	up := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://x", Enabled: true}
+	down := &models.Server{Name: "Jelly", Type: models.ServerTypeJellyfin, URL: "http://y", Enabled: true}
+	off := &models.Server{Name: "Old", Type: models.ServerTypeEmby, URL: "http://z"}
+	for _, srv := range []*models.Server{up, down, off} {
+		if err := st.CreateServer(srv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC()
+	ts.Server.SetPollerForTest(&fakePoller{
+		sessions: []models.ActiveStream{{ServerID: up.ID, UserName: "alice", Bandwidth: 1000}},
+		health: map[int64]poller.PollHealth{
+			up.ID:   {LastPollAt: now, LastSuccessAt: now},
+			down.ID: {LastPollAt: now, LastError: errors.New("unauthorized"), ConsecutiveFailures: 3},
+		},
+	})
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: up.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat",
+		DurationMs: 3600000, WatchedMs: 3600000, StartedAt: now.Add(-time.Minute), StoppedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dashboard", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp dashboardOverviewResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Sessions) != 1 || resp.Streams.StreamCount != 1 || resp.Streams.ServerCount != 3 {
+		t.Errorf("sessions = %d, streams = %+v", len(resp.Sessions), resp.Streams)
+	}
+	if resp.Today.TotalPlays != 1 || resp.Today.TotalHours != 1 {
+		t.Errorf("today = %+v", resp.Today)
+	}
+	if resp.TopUserThisWeek == nil || resp.TopUserThisWeek.UserName != "alice" {
+		t.Errorf("top user = %+v", resp.TopUserThisWeek)
+	}
+	status := map[int64]dashboardServerHealth{}
+	for _, h := range resp.Servers {
+		status[h.ID] = h
+	}
+	if status[up.ID].Status != serverHealthOK || status[off.ID].Status != serverHealthDisabled {
+		t.Errorf("servers = %+v", resp.Servers)
+	}
+	if h := status[down.ID]; h.Status != serverHealthError || h.ConsecutiveFailures != 3 || h.LastError == "" || h.LastError == "unauthorized" {
+		t.Errorf("failing server = %+v", h)
+	}
+	if resp.Maintenance.Items != 0 {
+		t.Errorf("maintenance = %+v", resp.Maintenance)
+	}
+}
+
+func TestDashboardOverview_ViewerForbidden(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+	req := httptest.NewRequest(http.MethodGet, "/api/dashboard", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer dashboard: %d", w.Code)
+	}
+}
@@ -42,21 +42,23 @@ func classifyDecision(s models.ActiveStream) models.TranscodeDecision {
 }
 
 func (s *Server) handleDashboardSummary(w http.ResponseWriter, r *http.Request) {
-	resp := dashboardSummaryResponse{}
-
 	servers, err := s.store.ListServers()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
-	resp.ServerCount = len(servers)
 
-	if s.poller == nil {
-		writeJSON(w, http.StatusOK, resp)
-		return
+	servers = workspaceServers(r, servers)
+
+	var sessions []models.ActiveStream
+	if s.poller != nil {
+		sessions = workspaceSessions(r, s.poller.CurrentSessions())
 	}
+	writeJSON(w, http.StatusOK, summarizeSessions(sessions, len(servers)))
+}
 
-	sessions := s.poller.CurrentSessions()
+func summarizeSessions(sessions []models.ActiveStream, serverCount int) dashboardSummaryResponse {
+	resp := dashboardSummaryResponse{ServerCount: serverCount}
 	users := map[userKey]struct{}{}
 	for _, sess := range sessions {
 		resp.StreamCount++
@@ -74,6 +76,5 @@ func (s *Server) handleDashboardSummary(w http.ResponseWriter, r *http.Request)
 		}
 	}
 	resp.ActiveUserCount = len(users)
-
-	writeJSON(w, http.StatusOK, resp)
+	return resp
 }
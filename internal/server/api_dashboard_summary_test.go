@@ -1,17 +1,23 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"streammon/internal/media"
 	"streammon/internal/models"
+	"streammon/internal/poller"
 )
 
 // fakePoller implements pollerIface for testing.
-type fakePoller struct{ sessions []models.ActiveStream }
+type fakePoller struct {
+	sessions []models.ActiveStream
+	health   map[int64]poller.PollHealth
+}
 
 func (f *fakePoller) CurrentSessions() []models.ActiveStream          { return f.sessions }
 func (f *fakePoller) Subscribe() chan []models.ActiveStream            { return nil }
@@ -20,6 +26,13 @@ func (f *fakePoller) AddServer(_ int64, _ media.MediaServer)          {}
 func (f *fakePoller) RemoveServer(_ int64)                            {}
 func (f *fakePoller) GetServer(_ int64) (media.MediaServer, bool)     { return nil, false }
 func (f *fakePoller) RefreshIdleTimeout()                             {}
+func (f *fakePoller) ApplyWebhookUpdate(_ context.Context, _ int64, _ models.SessionUpdate, _ string) {}
+func (f *fakePoller) ClearWebhook(_ int64)                            {}
+func (f *fakePoller) SetServerPolling(_ int64, _ time.Duration, _ bool) {}
+func (f *fakePoller) PollHealth(id int64) (poller.PollHealth, bool) {
+	h, ok := f.health[id]
+	return h, ok
+}
 
 func TestDashboardSummary_NoPoller_ReturnsZeros(t *testing.T) {
 	ts, _ := newTestServerWrapped(t)
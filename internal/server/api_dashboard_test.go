@@ -53,6 +53,26 @@ func TestDashboardSessionsWithPoller(t *testing.T) {
 	}
 }
 
+func TestDashboardSessionsIncludeProgressSnapshots(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+	srv.SetPollerForTest(&fakePoller{sessions: []models.ActiveStream{{
+		SessionID: "abc", ServerID: 1, UserName: "alice", Title: "Movie",
+		ProgressSnapshots: []models.ProgressSnapshot{{ProgressMs: 60000, ProgressPercent: 10}},
+	}}})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/dashboard/sessions", nil)
+	srv.ServeHTTP(rr, req)
+
+	var sessions []models.ActiveStream
+	if err := json.NewDecoder(rr.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || len(sessions[0].ProgressSnapshots) != 1 || sessions[0].ProgressSnapshots[0].ProgressPercent != 10 {
+		t.Errorf("expected progress snapshots in the live list, got %+v", sessions)
+	}
+}
+
 func TestSSEWithoutPoller(t *testing.T) {
 	srv, _ := newTestServerWrapped(t)
 	rr := httptest.NewRecorder()
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"streammon/internal/demo"
+)
+
+// handleGenerateDemoData fills the database with made-up servers, users and
+// history. The body is optional and sizes the data.
+func (s *Server) handleGenerateDemoData(w http.ResponseWriter, r *http.Request) {
+	var opts demo.Options
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := demo.Generate(r.Context(), s.store, opts)
+	if errors.Is(err, demo.ErrExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR generate demo data: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// handleClearDemoData removes the demo servers and their history.
+func (s *Server) handleClearDemoData(w http.ResponseWriter, r *http.Request) {
+	n, err := demo.Clear(s.store)
+	if err != nil {
+		log.Printf("ERROR clear demo data: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"servers_deleted": n})
+}
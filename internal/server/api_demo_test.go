@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/demo"
+)
+
+func TestDemoDataAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/demo-data", strings.NewReader(body)))
+		return w
+	}
+	if w := post(`{"days":-1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid days: status = %d, want 400", w.Code)
+	}
+
+	w := post(`{"days":7,"users":2,"seed":7}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("generate: status = %d: %s", w.Code, w.Body.String())
+	}
+	var result demo.Result
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Servers != 2 || result.Users != 2 {
+		t.Errorf("result = %+v", result)
+	}
+	if w := post(""); w.Code != http.StatusConflict {
+		t.Errorf("second generate: status = %d, want 409", w.Code)
+	}
+
+	t.Run("viewer forbidden", func(t *testing.T) {
+		tok := createViewerSession(t, st, "viewer")
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/demo-data", nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: tok})
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", w.Code)
+		}
+	})
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/admin/demo-data", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("clear: status = %d: %s", w.Code, w.Body.String())
+	}
+	servers, err := st.ListAllServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("servers after clear = %d, want 0", len(servers))
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// GET /api/digests
+func (s *Server) handleListDigests(w http.ResponseWriter, r *http.Request) {
+	digests, err := s.store.ListDigestSchedules()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, digests)
+}
+
+// decodeDigest reads and validates a digest schedule, checking its channels
+// exist. It writes the error response itself when it can't.
+func (s *Server) decodeDigest(w http.ResponseWriter, r *http.Request) (*models.DigestSchedule, bool) {
+	var d models.DigestSchedule
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return nil, false
+	}
+	if err := d.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	for _, id := range d.ChannelIDs {
+		_, err := s.store.GetNotificationChannel(id)
+		if errors.Is(err, models.ErrNotFound) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown channel id %d", id))
+			return nil, false
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return nil, false
+		}
+	}
+	return &d, true
+}
+
+// POST /api/digests
+func (s *Server) handleCreateDigest(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.decodeDigest(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.CreateDigestSchedule(d); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusCreated, d)
+}
+
+// GET /api/digests/{id}
+func (s *Server) handleGetDigest(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid digest id")
+		return
+	}
+	d, err := s.store.GetDigestSchedule(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// PUT /api/digests/{id}
+func (s *Server) handleUpdateDigest(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid digest id")
+		return
+	}
+	d, ok := s.decodeDigest(w, r)
+	if !ok {
+		return
+	}
+	d.ID = id
+	if err := s.store.UpdateDigestSchedule(d); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	updated, err := s.store.GetDigestSchedule(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DELETE /api/digests/{id}
+func (s *Server) handleDeleteDigest(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid digest id")
+		return
+	}
+	if err := s.store.DeleteDigestSchedule(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/digests/{id}/preview
+//
+// Returns the digest of the schedule's last complete period without
+// sending it.
+func (s *Server) handlePreviewDigest(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid digest id")
+		return
+	}
+	sched, err := s.store.GetDigestSchedule(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	start, end, _ := sched.Period.LastComplete(time.Now())
+	d, err := s.digests.Compile(r.Context(), sched, start, end)
+	if err != nil {
+		log.Printf("previewing digest %d: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// POST /api/digests/{id}/send
+//
+// Sends the digest of the schedule's last complete period now, even if
+// it's disabled. The scheduled send of that period still goes out.
+func (s *Server) handleSendDigest(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid digest id")
+		return
+	}
+	sched, err := s.store.GetDigestSchedule(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	start, end, _ := sched.Period.LastComplete(time.Now())
+	if err := s.digests.Send(r.Context(), sched, start, end); err != nil {
+		log.Printf("sending digest %d: %v", id, err)
+		writeError(w, http.StatusBadRequest, sanitizeConnError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
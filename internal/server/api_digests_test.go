@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestDigestsAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ch := &models.NotificationChannel{
+		Name: "hook", ChannelType: models.ChannelTypeWebhook, Enabled: true,
+		Config: json.RawMessage(`{"url":"https://example.com/hook","method":"POST"}`),
+	}
+	if err := st.CreateNotificationChannel(ch); err != nil {
+		t.Fatal(err)
+	}
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	for name, body := range map[string]string{
+		"no name":         `{"period":"weekly","channel_ids":[1]}`,
+		"bad period":      `{"name":"D","period":"daily","channel_ids":[1]}`,
+		"no channels":     `{"name":"D","period":"weekly","channel_ids":[]}`,
+		"unknown channel": `{"name":"D","period":"weekly","channel_ids":[999]}`,
+	} {
+		if w := do(http.MethodPost, "/api/digests", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d %s", name, w.Code, w.Body.String())
+		}
+	}
+
+	body := `{"name":"Weekly","period":"weekly","enabled":true,"channel_ids":[` + strconv.FormatInt(ch.ID, 10) + `]}`
+	w := do(http.MethodPost, "/api/digests", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var created models.DigestSchedule
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/digests/" + strconv.FormatInt(created.ID, 10)
+
+	w = do(http.MethodPut, path, strings.Replace(body, "weekly", "monthly", 1))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"period":"monthly"`) {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodGet, path+"/preview", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: %d %s", w.Code, w.Body.String())
+	}
+	var preview models.Digest
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatal(err)
+	}
+	if preview.Name != "Weekly" || preview.Period != models.DigestMonthly || preview.Start.Day() != 1 {
+		t.Errorf("preview = %+v", preview)
+	}
+
+	if w := do(http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := do(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("get deleted: %d", w.Code)
+	}
+}
+
+func TestDigestsAPI_ViewerForbidden(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+	req := httptest.NewRequest(http.MethodGet, "/api/digests", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer listing digests: %d", w.Code)
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"streammon/internal/store"
 	"streammon/internal/units"
@@ -11,30 +12,38 @@ import (
 type displaySettingsResponse struct {
 	UnitSystem     string `json:"unit_system"`
 	DiscoverRegion string `json:"discover_region"`
+	Timezone       string `json:"timezone"`
 }
 
 type displaySettingsRequest struct {
 	UnitSystem     string  `json:"unit_system"`
 	DiscoverRegion *string `json:"discover_region,omitempty"`
+	Timezone       *string `json:"timezone,omitempty"`
 }
 
-func (s *Server) handleGetDisplaySettings(w http.ResponseWriter, r *http.Request) {
+func (s *Server) displaySettings() (displaySettingsResponse, error) {
 	system, err := s.store.GetUnitSystem()
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "internal")
-		return
+		return displaySettingsResponse{}, err
 	}
-
 	region, err := s.store.GetDiscoverRegion()
+	if err != nil {
+		return displaySettingsResponse{}, err
+	}
+	tz, err := s.store.GetDisplayTimezone()
+	if err != nil {
+		return displaySettingsResponse{}, err
+	}
+	return displaySettingsResponse{UnitSystem: system, DiscoverRegion: region, Timezone: tz}, nil
+}
+
+func (s *Server) handleGetDisplaySettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.displaySettings()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
-
-	writeJSON(w, http.StatusOK, displaySettingsResponse{
-		UnitSystem:     system,
-		DiscoverRegion: region,
-	})
+	writeJSON(w, http.StatusOK, settings)
 }
 
 func (s *Server) handleUpdateDisplaySettings(w http.ResponseWriter, r *http.Request) {
@@ -67,19 +76,21 @@ func (s *Server) handleUpdateDisplaySettings(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	system, err := s.store.GetUnitSystem()
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "internal")
-		return
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			writeError(w, http.StatusBadRequest, "unknown timezone")
+			return
+		}
+		if err := s.store.SetDisplayTimezone(*req.Timezone); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
 	}
-	region, err := s.store.GetDiscoverRegion()
+
+	settings, err := s.displaySettings()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
-
-	writeJSON(w, http.StatusOK, displaySettingsResponse{
-		UnitSystem:     system,
-		DiscoverRegion: region,
-	})
+	writeJSON(w, http.StatusOK, settings)
 }
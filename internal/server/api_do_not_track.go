@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleListDoNotTrackRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.store.ListDoNotTrackRules()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list do-not-track rules")
+		return
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+func (s *Server) handleCreateDoNotTrackRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.DoNotTrackRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.CreateDoNotTrackRule(&rule); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+func (s *Server) handleUpdateDoNotTrackRule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid do-not-track rule id")
+		return
+	}
+	var rule models.DoNotTrackRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rule.ID = id
+	if err := s.store.UpdateDoNotTrackRule(&rule); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+func (s *Server) handleDeleteDoNotTrackRule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid do-not-track rule id")
+		return
+	}
+	if err := s.store.DeleteDoNotTrackRule(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestDoNotTrackAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/do-not-track",
+		strings.NewReader(`{"name":"Guest room","enabled":true,"ip_range":"10.0.0.0/8"}`))
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.DoNotTrackRule
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{
+		`{"name":"Everything","enabled":true}`,
+		`{"name":"Bad","ip_range":"nope"}`,
+		`{"name":"Library","library_id":"3"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/do-not-track", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	path := "/api/do-not-track/" + strconv.FormatInt(created.ID, 10)
+	req = httptest.NewRequest(http.MethodPut, path, strings.NewReader(`{"name":"Guest room","enabled":false,"user_name":"guest"}`))
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/do-not-track/999", strings.NewReader(`{"name":"Gone","user_name":"x"}`))
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("update missing: expected 404, got %d", w.Code)
+	}
+
+	viewer := createViewerSession(t, st, "alice")
+	req = httptest.NewRequest(http.MethodGet, "/api/do-not-track", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewer})
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer list: expected 403, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, path, nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+}
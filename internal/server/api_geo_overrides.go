@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// geoOverrideSetter is implemented by geo resolvers that consult the
+// admin's geo overrides before their database.
+type geoOverrideSetter interface {
+	SetOverrides([]models.GeoOverride)
+}
+
+func (s *Server) handleListGeoOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := s.store.ListGeoOverrides()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list geo overrides")
+		return
+	}
+	writeJSON(w, http.StatusOK, overrides)
+}
+
+func (s *Server) handleCreateGeoOverride(w http.ResponseWriter, r *http.Request) {
+	var o models.GeoOverride
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := o.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.CreateGeoOverride(&o); err != nil {
+		writeGeoOverrideError(w, err)
+		return
+	}
+	s.applyGeoOverrides(o.CIDR)
+	writeJSON(w, http.StatusCreated, o)
+}
+
+func (s *Server) handleUpdateGeoOverride(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid geo override id")
+		return
+	}
+	var o models.GeoOverride
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := o.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	existing, err := s.store.GetGeoOverride(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	o.ID = id
+	if err := s.store.UpdateGeoOverride(&o); err != nil {
+		writeGeoOverrideError(w, err)
+		return
+	}
+	s.applyGeoOverrides(existing.CIDR, o.CIDR)
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) handleDeleteGeoOverride(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid geo override id")
+		return
+	}
+	existing, err := s.store.GetGeoOverride(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := s.store.DeleteGeoOverride(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.applyGeoOverrides(existing.CIDR)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyGeoOverrides hands the stored overrides to the resolver and looks up
+// the cached addresses in the changed networks again, so history, stats and
+// rules see the new locations. Failures are logged; the override itself is
+// already saved.
+func (s *Server) applyGeoOverrides(changed ...string) {
+	setter, ok := s.geoResolver.(geoOverrideSetter)
+	if !ok {
+		return
+	}
+	overrides, err := s.store.ListGeoOverrides()
+	if err != nil {
+		log.Printf("loading geo overrides: %v", err)
+		return
+	}
+	setter.SetOverrides(overrides)
+
+	var prefixes []netip.Prefix
+	for _, c := range changed {
+		if p, err := netip.ParsePrefix(c); err == nil {
+			prefixes = append(prefixes, p)
+		}
+	}
+	ips, err := s.store.CachedGeoIPsIn(prefixes)
+	if err != nil {
+		log.Printf("geo overrides: %v", err)
+		return
+	}
+	for _, ip := range ips {
+		// A private address only had a location through the override.
+		if geo := s.geoResolver.Lookup(net.ParseIP(ip)); geo != nil {
+			err = s.store.SetCachedGeo(geo)
+		} else {
+			err = s.store.DeleteCachedGeo(ip)
+		}
+		if err != nil {
+			log.Printf("geo overrides: refreshing %s: %v", ip, err)
+		}
+	}
+}
+
+func writeGeoOverrideError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrGeoOverrideExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeStoreError(w, err)
+}
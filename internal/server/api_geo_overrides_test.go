@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/geoip"
+	"streammon/internal/models"
+)
+
+func TestGeoOverrideAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ts.geoResolver = geoip.NewResolver("")
+
+	// Looked up before the override existed, with the database's guess.
+	if err := st.SetCachedGeo(&models.GeoResult{IP: "100.70.0.9", City: "Wrong City", Country: "US"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/geo-overrides",
+		strings.NewReader(`{"cidr":"100.64.0.0/10","label":"Mom & Dad","country":"US","lat":40.7,"lng":-74}`))
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.GeoOverride
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if geo, _ := st.GetCachedGeo("100.70.0.9"); geo == nil || geo.City != "Mom & Dad" || geo.Label != "Mom & Dad" {
+		t.Errorf("cached geo after create = %+v", geo)
+	}
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"cidr":"100.64.0.0/10","label":"Dup"}`, http.StatusConflict},
+		{`{"cidr":"nope","label":"Bad"}`, http.StatusBadRequest},
+		{`{"cidr":"10.0.0.0/8"}`, http.StatusBadRequest},
+		{`{"cidr":"10.0.0.0/8","label":"Far","lat":91}`, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/geo-overrides", strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.body, tc.want, w.Code)
+		}
+	}
+
+	viewer := createViewerSession(t, st, "alice")
+	req = httptest.NewRequest(http.MethodGet, "/api/geo-overrides", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewer})
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer list: expected 403, got %d", w.Code)
+	}
+
+	// Without a database, deleting the override leaves nothing to place the
+	// address with.
+	req = httptest.NewRequest(http.MethodDelete, "/api/geo-overrides/"+strconv.FormatInt(created.ID, 10), nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if geo, _ := st.GetCachedGeo("100.70.0.9"); geo != nil {
+		t.Errorf("cached geo after delete = %+v", geo)
+	}
+}
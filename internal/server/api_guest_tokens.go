@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleCreateGuestToken(w http.ResponseWriter, r *http.Request) {
+	var input models.GuestTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, name := range input.UserNames {
+		exists, err := s.userDerivedExists(r.Context(), name)
+		if err != nil {
+			log.Printf("userDerivedExists error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if !exists {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown user %q", name))
+			return
+		}
+	}
+
+	var createdBy string
+	if user := UserFromContext(r.Context()); user != nil {
+		createdBy = user.Name
+	}
+	expiresAt := time.Now().UTC().Add(time.Duration(input.ExpiresInHours) * time.Hour)
+	token, err := s.store.CreateGuestToken(input, createdBy, expiresAt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create guest token")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, token)
+}
+
+func (s *Server) handleListGuestTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.store.ListGuestTokens()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list guest tokens")
+		return
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+func (s *Server) handleDeleteGuestToken(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid guest token id")
+		return
+	}
+	if err := s.store.DeleteGuestToken(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
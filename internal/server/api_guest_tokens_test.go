@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestGuestTokenAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, user := range []string{"kid", "parent"} {
+		entry := &models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: user, MediaType: models.MediaTypeMovie, Title: "Heat",
+			WatchedMs: 3600000, DurationMs: 3600000, StartedAt: now.Add(-time.Hour), StoppedAt: now,
+		}
+		if err := st.InsertHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/guest-tokens",
+		strings.NewReader(`{"name":"Kid report","user_names":["kid"],"expires_in_hours":168}`))
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.GuestToken
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Token == "" || created.CreatedBy != "test-admin" {
+		t.Fatalf("unexpected token %+v", created)
+	}
+
+	guest := func(method, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(guestTokenHeader, created.Token)
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := guest(http.MethodGet, "/api/users/kid/stats"); w.Code != http.StatusOK {
+		t.Fatalf("kid stats: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = guest(http.MethodGet, "/api/history?user=kid")
+	if w.Code != http.StatusOK {
+		t.Fatalf("kid history: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var history models.PaginatedResult[models.WatchHistoryEntry]
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if history.Total != 1 || history.Items[0].UserName != "kid" {
+		t.Fatalf("expected only kid's history, got %+v", history)
+	}
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/users/parent/stats"},
+		{http.MethodGet, "/api/history?user=parent"},
+		{http.MethodGet, "/api/history"},
+		{http.MethodGet, "/api/users/kid"},
+		{http.MethodGet, "/api/guest-tokens"},
+		{http.MethodPut, "/api/users/kid/notes"},
+	} {
+		if w := guest(tc.method, tc.path); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/guest-tokens/"+strconv.FormatInt(created.ID, 10), nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: expected 204, got %d", w.Code)
+	}
+	if w := guest(http.MethodGet, "/api/users/kid/stats"); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestGuestTokenCreateValidation(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+
+	for _, body := range []string{
+		`{"name":"","user_names":["kid"]}`,
+		`{"name":"x","user_names":[]}`,
+		`{"name":"x","user_names":["nobody"]}`,
+		`{"name":"x","user_names":["test-admin"],"expires_in_hours":100000}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/guest-tokens", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// statsLocation picks the timezone for stats bucketed by local day or hour:
+// the tz query parameter, then the caller's preferred timezone, then the
+// server's display timezone unless the request gives an explicit tz_offset.
+// A nil location with no error leaves the filter's offset, or UTC, to decide.
+func (s *Server) statsLocation(r *http.Request) (*time.Location, error) {
+	if name := r.URL.Query().Get("tz"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, errors.New("unknown tz")
+		}
+		return loc, nil
+	}
+	if user := UserFromContext(r.Context()); user != nil && user.ID != 0 {
+		if prefs, err := s.store.GetUserPreferences(user.ID); err == nil && prefs.Timezone != "" {
+			if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+				return loc, nil
+			}
+		}
+	}
+	if r.URL.Query().Has("tz_offset") {
+		return nil, nil
+	}
+	name, err := s.store.GetDisplayTimezone()
+	if err != nil || name == "" {
+		return nil, err
+	}
+	return time.LoadLocation(name)
+}
+
+func (s *Server) handleGetWatchHeatmap(w http.ResponseWriter, r *http.Request) {
+	loc, err := s.statsLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	r = s.withPreferenceDefaults(r)
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	heatmap, err := s.store.WatchHeatmap(r.Context(), filter, loc)
+	if err != nil {
+		log.Printf("watch heatmap: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, heatmap)
+}
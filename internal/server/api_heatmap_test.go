@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestGetWatchHeatmap(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	server := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	started := time.Date(2024, 7, 1, 21, 0, 0, 0, time.UTC)
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: server.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Dune",
+		DurationMs: 3600000, WatchedMs: 3600000, StartedAt: started, StoppedAt: started.Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(query string) (*httptest.ResponseRecorder, models.WatchHeatmap) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats/heatmap"+query, nil))
+		var hm models.WatchHeatmap
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&hm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, hm
+	}
+
+	if w, _ := get("?tz=Mars/Olympus"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown tz: status = %d, want 400", w.Code)
+	}
+
+	_, hm := get("")
+	if hm.Timezone != "UTC" || hm.Cells[int(time.Monday)*24+21].PlayCount != 1 {
+		t.Errorf("default heatmap in %s missing the UTC play", hm.Timezone)
+	}
+
+	if err := st.SetDisplayTimezone("Europe/Berlin"); err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	_, hm = get("")
+	if hm.Timezone != "Europe/Berlin" || hm.Cells[int(time.Monday)*24+23].HoursWatched != 1 {
+		t.Errorf("display timezone heatmap = %s, Mon 23:00 %+v", hm.Timezone, hm.Cells[int(time.Monday)*24+23])
+	}
+
+	_, hm = get("?tz=Asia/Tokyo")
+	if hm.Timezone != "Asia/Tokyo" || hm.Cells[int(time.Tuesday)*24+6].PlayCount != 1 {
+		t.Errorf("tz param heatmap = %s", hm.Timezone)
+	}
+}
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"errors"
 	"log"
 	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"streammon/internal/models"
+	"streammon/internal/store"
 )
 
 const maxPerPage = 100
@@ -36,6 +39,7 @@ var allowedSortColumns = map[string]string{
 }
 
 func (s *Server) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	r = s.withPreferenceDefaults(r)
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
 		page = 1
@@ -80,7 +84,20 @@ func (s *Server) handleListHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := s.store.SearchHistory(page, perPage, userFilter, search, sortColumn, sortOrder, serverIDs)
+	query := store.HistoryQuery{
+		UserName:     userFilter,
+		Search:       search,
+		SortColumn:   sortColumn,
+		SortOrder:    sortOrder,
+		ServerIDs:    serverIDs,
+		NetworkLabel: strings.TrimSpace(r.URL.Query().Get("network_label")),
+	}
+	if err := parseHistoryFilters(r, &query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.store.QueryHistory(page, perPage, query)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
@@ -111,6 +128,61 @@ func (s *Server) handleListHistory(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// parseHistoryFilters reads the history list's investigation filters:
+// media_type, transcode_decision, video_resolution, platform, player, ip,
+// watched, and a start_date and/or end_date (YYYY-MM-DD, end inclusive).
+// Errors are safe to show.
+func parseHistoryFilters(r *http.Request, q *store.HistoryQuery) error {
+	params := r.URL.Query()
+	q.MediaType = models.MediaType(strings.TrimSpace(params.Get("media_type")))
+	q.VideoResolution = strings.TrimSpace(params.Get("video_resolution"))
+	q.Platform = strings.TrimSpace(params.Get("platform"))
+	q.Player = strings.TrimSpace(params.Get("player"))
+
+	switch d := models.TranscodeDecision(params.Get("transcode_decision")); d {
+	case "", models.TranscodeDecisionDirectPlay, models.TranscodeDecisionCopy, models.TranscodeDecisionTranscode:
+		q.TranscodeDecision = d
+	default:
+		return errors.New("transcode_decision must be direct play, copy or transcode")
+	}
+
+	if ip := strings.TrimSpace(params.Get("ip")); ip != "" {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return errors.New("invalid ip")
+		}
+		q.IPAddress = addr.String()
+	}
+
+	switch params.Get("watched") {
+	case "":
+	case "true", "false":
+		watched := params.Get("watched") == "true"
+		q.Watched = &watched
+	default:
+		return errors.New("watched must be true or false")
+	}
+
+	if sd := params.Get("start_date"); sd != "" {
+		t, err := time.Parse(time.DateOnly, sd)
+		if err != nil {
+			return errors.New("invalid start_date, use YYYY-MM-DD")
+		}
+		q.StartedAfter = t
+	}
+	if ed := params.Get("end_date"); ed != "" {
+		t, err := time.Parse(time.DateOnly, ed)
+		if err != nil {
+			return errors.New("invalid end_date, use YYYY-MM-DD")
+		}
+		q.StartedBefore = t.AddDate(0, 0, 1)
+	}
+	if !q.StartedAfter.IsZero() && !q.StartedBefore.IsZero() && !q.StartedBefore.After(q.StartedAfter) {
+		return errors.New("end_date must not be before start_date")
+	}
+	return nil
+}
+
 func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
@@ -127,6 +199,13 @@ func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if WorkspaceFromContext(r.Context()) != nil {
+		entry, err := s.store.GetHistoryEntry(id)
+		if err != nil || !visibleToWorkspace(r, entry.ServerID) {
+			writeJSON(w, http.StatusOK, []models.WatchSession{})
+			return
+		}
+	}
 	sessions, err := s.store.ListSessionsForHistory(id)
 	if err != nil {
 		log.Printf("listing sessions for history %d: %v", id, err)
@@ -140,6 +219,7 @@ func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDailyHistory(w http.ResponseWriter, r *http.Request) {
+	r = s.withPreferenceDefaults(r)
 	startStr := r.URL.Query().Get("start")
 	endStr := r.URL.Query().Get("end")
 
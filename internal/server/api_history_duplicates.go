@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const (
+	defaultDuplicateWindowMinutes = 10
+	maxDuplicateWindowMinutes     = 24 * 60
+	defaultDuplicateClusterLimit  = 100
+	maxDuplicateClusterLimit      = 1000
+)
+
+type historyDuplicatesResponse struct {
+	WindowMinutes int                              `json:"window_minutes"`
+	Total         int                              `json:"total"`
+	Clusters      []models.HistoryDuplicateCluster `json:"clusters"`
+}
+
+// GET /api/history/duplicates?window_minutes=&server_id=&limit=
+//
+// Reports likely duplicate plays that insert-time dedup (60s) and
+// consolidation let through, with a preview of each merge. Read-only, so it
+// can be run before and after an import to compare.
+func (s *Server) handleHistoryDuplicates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	window := defaultDuplicateWindowMinutes
+	if v := q.Get("window_minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDuplicateWindowMinutes {
+			writeError(w, http.StatusBadRequest, "window_minutes must be between 1 and 1440")
+			return
+		}
+		window = n
+	}
+
+	var serverID int64
+	if v := q.Get("server_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			writeError(w, http.StatusBadRequest, "invalid server_id")
+			return
+		}
+		serverID = id
+	}
+
+	limit := defaultDuplicateClusterLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(n, maxDuplicateClusterLimit)
+	}
+
+	clusters, total, err := s.store.FindHistoryDuplicates(r.Context(), time.Duration(window)*time.Minute, serverID, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to scan history")
+		return
+	}
+	writeJSON(w, http.StatusOK, historyDuplicatesResponse{
+		WindowMinutes: window,
+		Total:         total,
+		Clusters:      clusters,
+	})
+}
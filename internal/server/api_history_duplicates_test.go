@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestHistoryDuplicatesAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	s := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(s); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC)
+	// Inserted latest first so neither dedup (60s) nor consolidation folds
+	// the earlier row into the later one.
+	for _, offset := range []time.Duration{5 * time.Minute, 0} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: s.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Movie",
+			DurationMs: 3600000, WatchedMs: 3000000,
+			StartedAt: start.Add(offset), StoppedAt: start.Add(offset + time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/history/duplicates", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp historyDuplicatesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.WindowMinutes != defaultDuplicateWindowMinutes || resp.Total != 1 || len(resp.Clusters[0].Entries) != 2 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/history/duplicates?window_minutes=2", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 0 {
+		t.Errorf("expected no clusters with a 2 minute window, got %d", resp.Total)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/history/duplicates?window_minutes=0", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid window, got %d", w.Code)
+	}
+
+	viewerToken := createViewerSession(t, st, "viewer")
+	req = httptest.NewRequest(http.MethodGet, "/api/history/duplicates", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewerToken})
+	w = httptest.NewRecorder()
+	srv.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for viewer, got %d", w.Code)
+	}
+}
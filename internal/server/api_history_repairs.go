@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+func decodeHistoryTimeShift(w http.ResponseWriter, r *http.Request) (*models.HistoryTimeShift, bool) {
+	var shift models.HistoryTimeShift
+	if err := json.NewDecoder(r.Body).Decode(&shift); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return nil, false
+	}
+	if err := shift.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return &shift, true
+}
+
+// POST /api/history/repairs/preview
+//
+// Reports how many rows a time shift would move, with a sample of before and
+// after start times. Changes nothing.
+func (s *Server) handlePreviewHistoryTimeShift(w http.ResponseWriter, r *http.Request) {
+	shift, ok := decodeHistoryTimeShift(w, r)
+	if !ok {
+		return
+	}
+	preview, err := s.store.PreviewHistoryTimeShift(r.Context(), shift)
+	if err != nil {
+		log.Printf("previewing history time shift: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, preview)
+}
+
+// POST /api/history/repairs
+func (s *Server) handleApplyHistoryTimeShift(w http.ResponseWriter, r *http.Request) {
+	shift, ok := decodeHistoryTimeShift(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.store.GetServer(shift.ServerID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var createdBy string
+	if user := UserFromContext(r.Context()); user != nil {
+		createdBy = user.Name
+	}
+	repair, err := s.store.ApplyHistoryTimeShift(r.Context(), shift, createdBy)
+	if err != nil {
+		log.Printf("applying history time shift: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	log.Printf("history time repair %d: shifted %d rows on server %d by %d minutes (by %s)",
+		repair.ID, repair.RowCount, repair.ServerID, repair.OffsetMinutes, createdBy)
+	writeJSON(w, http.StatusCreated, repair)
+}
+
+func (s *Server) handleListHistoryTimeRepairs(w http.ResponseWriter, r *http.Request) {
+	repairs, err := s.store.ListHistoryTimeRepairs(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, repairs)
+}
+
+// POST /api/history/repairs/{id}/undo
+func (s *Server) handleUndoHistoryTimeRepair(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid repair id")
+		return
+	}
+	repair, err := s.store.UndoHistoryTimeRepair(r.Context(), id)
+	if errors.Is(err, store.ErrRepairUndone) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, repair)
+}
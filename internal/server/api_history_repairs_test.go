@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestHistoryTimeRepairAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	entry := &models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat",
+		WatchedMs: 3600000, DurationMs: 3600000, StartedAt: base, StoppedAt: base.Add(time.Hour),
+		TautulliReferenceID: 7,
+	}
+	if err := st.InsertHistory(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		return w
+	}
+	body := fmt.Sprintf(`{"server_id":%d,"from":"2024-03-01T00:00:00Z","to":"2024-03-02T00:00:00Z","imported_only":true,"offset_minutes":120}`, srv.ID)
+
+	w := post("/api/history/repairs/preview", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var preview models.HistoryTimeShiftPreview
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatal(err)
+	}
+	if preview.Matched != 1 {
+		t.Fatalf("expected 1 matched row, got %+v", preview)
+	}
+	if got, _ := st.GetHistoryEntry(entry.ID); !got.StartedAt.Equal(base) {
+		t.Fatal("preview must not change history")
+	}
+
+	w = post("/api/history/repairs", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("apply: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var repair models.HistoryTimeRepair
+	if err := json.NewDecoder(w.Body).Decode(&repair); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.GetHistoryEntry(entry.ID); !got.StartedAt.Equal(base.Add(2 * time.Hour)) {
+		t.Fatalf("expected started_at shifted by 2h, got %v", got.StartedAt)
+	}
+
+	undoPath := fmt.Sprintf("/api/history/repairs/%d/undo", repair.ID)
+	if w := post(undoPath, ""); w.Code != http.StatusOK {
+		t.Fatalf("undo: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(undoPath, ""); w.Code != http.StatusConflict {
+		t.Fatalf("second undo: expected 409, got %d", w.Code)
+	}
+	if got, _ := st.GetHistoryEntry(entry.ID); !got.StartedAt.Equal(base) {
+		t.Fatalf("expected started_at restored, got %v", got.StartedAt)
+	}
+
+	for _, bad := range []string{
+		`{"server_id":1,"from":"2024-03-02T00:00:00Z","to":"2024-03-01T00:00:00Z","offset_minutes":60}`,
+		fmt.Sprintf(`{"server_id":%d,"from":"2024-03-01T00:00:00Z","to":"2024-03-02T00:00:00Z","offset_minutes":0}`, srv.ID),
+	} {
+		if w := post("/api/history/repairs", bad); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", bad, w.Code)
+		}
+	}
+	missing := `{"server_id":999,"from":"2024-03-01T00:00:00Z","to":"2024-03-02T00:00:00Z","offset_minutes":60}`
+	if w := post("/api/history/repairs", missing); w.Code != http.StatusNotFound {
+		t.Errorf("unknown server: expected 404, got %d", w.Code)
+	}
+}
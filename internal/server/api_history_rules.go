@@ -0,0 +1,36 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleGetHistoryRules(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetHistoryRuleSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// handleUpdateHistoryRules replaces the history rules. They apply to plays
+// written from now on; existing history is left as it is.
+func (s *Server) handleUpdateHistoryRules(w http.ResponseWriter, r *http.Request) {
+	var req models.HistoryRuleSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetHistoryRuleSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestHistoryRulesAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/history-rules", strings.NewReader(body)))
+		return w
+	}
+	for _, body := range []string{
+		`{"rules":[{"enabled":true,"action":"explode"}]}`,
+		`{"rules":[{"enabled":true,"action":"rewrite_title","pattern":"("}]}`,
+		`{"rules":[{"enabled":true,"action":"skip"}]}`,
+		`{"rules":[{"enabled":true,"action":"skip","library_id":"3"}]}`,
+	} {
+		if w := put(body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+	if w := put(`{"rules":[{"enabled":true,"action":"rename_user","user_name":"JDoe","replacement":"john"}]}`); w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/settings/history-rules", nil))
+	var got models.HistoryRuleSettings
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Rules) != 1 || got.Rules[0].Replacement != "john" {
+		t.Fatalf("rules = %+v", got.Rules)
+	}
+
+	srv := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	entry := &models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "jdoe", MediaType: models.MediaTypeMovie, Title: "Dune",
+		StartedAt: now, StoppedAt: now.Add(time.Hour),
+	}
+	if err := st.InsertHistory(entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.UserName != "john" {
+		t.Errorf("saved rule not applied: user = %q", entry.UserName)
+	}
+}
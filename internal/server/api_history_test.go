@@ -75,6 +75,45 @@ func TestListHistoryWithFilterAPI(t *testing.T) {
 	}
 }
 
+func TestListHistoryInvestigationFiltersAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	s := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	st.CreateServer(s)
+	now := time.Now().UTC()
+	st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: s.ID, UserName: "bob", MediaType: models.MediaTypeMovie, Title: "A",
+		TranscodeDecision: models.TranscodeDecisionTranscode, IPAddress: "203.0.113.7",
+		StartedAt: now.Add(-3 * time.Hour), StoppedAt: now.Add(-2 * time.Hour),
+	})
+	st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: s.ID, UserName: "bob", MediaType: models.MediaTypeMovie, Title: "B",
+		TranscodeDecision: models.TranscodeDecisionDirectPlay, IPAddress: "203.0.113.7",
+		StartedAt: now.Add(-time.Hour), StoppedAt: now,
+	})
+
+	start, end := now.AddDate(0, 0, -1).Format(time.DateOnly), now.Format(time.DateOnly)
+	req := httptest.NewRequest(http.MethodGet, "/api/history?user=bob&transcode_decision=transcode&ip=203.0.113.7&start_date="+start+"&end_date="+end, nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.PaginatedResult[models.WatchHistoryEntry]
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.Total != 1 || result.Items[0].Title != "A" {
+		t.Fatalf("expected only A, got %+v", result.Items)
+	}
+
+	for _, q := range []string{"transcode_decision=remux", "ip=not-an-ip", "watched=maybe", "start_date=yesterday", "start_date=2026-02-02&end_date=2026-02-01"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/history?"+q, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
 func TestDailyHistoryAPI(t *testing.T) {
 	srv, _ := newTestServerWrapped(t)
 
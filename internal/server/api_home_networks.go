@@ -0,0 +1,36 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleGetHomeNetworks(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetHomeNetworkSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// handleUpdateHomeNetworks replaces the home networks. Sessions are
+// classified as Local when read, so existing history follows the change.
+func (s *Server) handleUpdateHomeNetworks(w http.ResponseWriter, r *http.Request) {
+	var req models.HomeNetworkSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetHomeNetworkSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestHomeNetworksAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/home-networks", strings.NewReader(body)))
+		return w
+	}
+	if w := put(`{"cidrs":["not a cidr"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid CIDR: status = %d, want 400", w.Code)
+	}
+	if w := put(`{"cidrs":["203.0.113.0/24"]}`); w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/settings/home-networks", nil))
+	var got models.HomeNetworkSettings
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.CIDRs) != 1 || got.CIDRs[0] != "203.0.113.0/24" {
+		t.Fatalf("cidrs = %v", got.CIDRs)
+	}
+
+	srv := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, ip := range []string{"203.0.113.8", "8.8.8.8"} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Dune",
+			IPAddress: ip, StartedAt: now, StoppedAt: now.Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/history", nil))
+	var page models.PaginatedResult[models.WatchHistoryEntry]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	local := map[string]bool{}
+	for _, e := range page.Items {
+		local[e.IPAddress] = e.Local
+	}
+	if !local["203.0.113.8"] || local["8.8.8.8"] {
+		t.Errorf("local = %v, want only the home network session", local)
+	}
+}
+
+func TestHomeNetworksAPI_ViewerForbidden(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	token := createViewerSession(t, st, "viewer")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/settings/home-networks", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+	"streammon/internal/rules"
+	"streammon/internal/store"
+)
+
+// householdMember is one member's entry on the household map.
+type householdMember struct {
+	UserName  string                     `json:"user_name"`
+	Home      *models.HouseholdHome      `json:"home"`
+	Locations []models.HouseholdLocation `json:"locations"`
+}
+
+func (s *Server) handleHouseholdMap(w http.ResponseWriter, r *http.Request) {
+	locations, err := s.store.ListAllHouseholdLocations(workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("household map: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	manual, err := s.store.ListManualHouseholdHomes()
+	if err != nil {
+		log.Printf("household map: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	visible, err := s.workspaceUserNames(r)
+	if err != nil {
+		log.Printf("household map: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	names := make([]string, 0, len(locations)+len(manual))
+	for name := range locations {
+		names = append(names, name)
+	}
+	for name := range manual {
+		if _, ok := locations[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if visible != nil {
+		names = slices.DeleteFunc(names, func(name string) bool { return !visible[name] })
+	}
+	slices.Sort(names)
+
+	members := make([]householdMember, 0, len(names))
+	for _, name := range names {
+		m := householdMember{UserName: name, Locations: locations[name]}
+		if m.Locations == nil {
+			m.Locations = []models.HouseholdLocation{}
+		}
+		if home, ok := manual[name]; ok {
+			m.Home = &home
+		} else {
+			m.Home = rules.LearnHouseholdHome(name, m.Locations)
+		}
+		members = append(members, m)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"members": members})
+}
+
+// workspaceHomeQuerier learns homes only from the household locations a
+// workspace's servers have seen.
+type workspaceHomeQuerier struct {
+	*store.Store
+	serverIDs []int64
+}
+
+func (q workspaceHomeQuerier) ListHouseholdLocations(userName string) ([]models.HouseholdLocation, error) {
+	return q.ListHouseholdLocationsOnServers(userName, q.serverIDs)
+}
+
+func (s *Server) handleGetHouseholdHome(w http.ResponseWriter, r *http.Request) {
+	userName := chi.URLParam(r, "name")
+	if !s.requireGuestVisibility(w, r, userName, "visible_household") {
+		return
+	}
+	home, err := rules.ResolveHouseholdHome(workspaceHomeQuerier{s.store, workspaceServerIDs(r)}, userName)
+	if err != nil {
+		log.Printf("resolving household home for %s: %v", userName, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if home == nil {
+		writeError(w, http.StatusNotFound, "no household home")
+		return
+	}
+	writeJSON(w, http.StatusOK, home)
+}
+
+func (s *Server) handleSetHouseholdHome(w http.ResponseWriter, r *http.Request) {
+	var home models.HouseholdHome
+	if err := json.NewDecoder(r.Body).Decode(&home); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	home.UserName = chi.URLParam(r, "name")
+	if err := home.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetHouseholdHome(&home); err != nil {
+		log.Printf("setting household home for %s: %v", home.UserName, err)
+		writeError(w, http.StatusInternalServerError, "failed to save household home")
+		return
+	}
+	saved, err := s.store.GetManualHouseholdHome(home.UserName)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+func (s *Server) handleDeleteHouseholdHome(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteHouseholdHome(chi.URLParam(r, "name")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
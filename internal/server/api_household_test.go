@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestHouseholdHomeAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	now := time.Now().UTC()
+	if err := st.UpsertHouseholdLocation(&models.HouseholdLocation{
+		UserName: "alice", IPAddress: "10.0.0.1", City: "New York", Country: "US",
+		Latitude: 40.7128, Longitude: -74.0060, Trusted: true, SessionCount: 12, FirstSeen: now, LastSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/api/users/alice/household/home")
+	if w.Code != http.StatusOK {
+		t.Fatalf("learned home: status=%d body=%s", w.Code, w.Body.String())
+	}
+	var home models.HouseholdHome
+	json.Unmarshal(w.Body.Bytes(), &home)
+	if home.Source != models.HouseholdHomeLearned || home.Confidence != 100 {
+		t.Errorf("learned home = %+v", home)
+	}
+
+	if w := get("/api/users/bob/household/home"); w.Code != http.StatusNotFound {
+		t.Errorf("no home: status=%d, want 404", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/users/bob/household/home",
+		strings.NewReader(`{"latitude":51.5,"longitude":-0.12,"radius_km":10}`))
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("set home: status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/users/bob/household/home", strings.NewReader(`{"latitude":100,"longitude":0}`))
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid home: status=%d, want 400", w.Code)
+	}
+
+	w = get("/api/household/map")
+	if w.Code != http.StatusOK {
+		t.Fatalf("map: status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Members []householdMember `json:"members"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Members) != 2 || resp.Members[0].UserName != "alice" || resp.Members[1].UserName != "bob" {
+		t.Fatalf("members = %+v", resp.Members)
+	}
+	if len(resp.Members[0].Locations) != 1 || resp.Members[0].Home == nil {
+		t.Errorf("alice = %+v", resp.Members[0])
+	}
+	if h := resp.Members[1].Home; h == nil || h.Source != models.HouseholdHomeManual || h.RadiusKm != 10 {
+		t.Errorf("bob home = %+v", h)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/users/bob/household/home", nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("delete: status=%d, want 204", w.Code)
+	}
+}
+
+func TestHouseholdMap_ViewerForbidden(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	token := createViewerSession(t, st, "viewer")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/household/map", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status=%d, want 403", w.Code)
+	}
+}
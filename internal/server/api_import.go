@@ -3,10 +3,8 @@ package server
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
-	"sync"
 	"time"
 
 	"streammon/internal/models"
@@ -21,11 +19,13 @@ type importRequest struct {
 
 type importProgressEvent struct {
 	Type         string `json:"type"`
+	JobID        int64  `json:"job_id,omitempty"`
 	Processed    int    `json:"processed"`
 	Total        int    `json:"total"`
 	Inserted     int    `json:"inserted"`
 	Skipped      int    `json:"skipped"`
 	Consolidated int    `json:"consolidated"`
+	Errors       int    `json:"errors"`
 	Error        string `json:"error,omitempty"`
 }
 
@@ -53,96 +53,19 @@ func clampStoppedAt(startedAt, stoppedAt time.Time) time.Time {
 	return stoppedAt
 }
 
-type importTracker struct {
-	label        string
-	serverID     int64
-	send         func(importProgressEvent)
-	total        int
-	inserted     int
-	skipped      int
-	consolidated int
-	processed    int
-}
-
-func newImportTracker(label string, serverID int64, w http.ResponseWriter, flusher http.Flusher) *importTracker {
-	return &importTracker{
-		label:    label,
-		serverID: serverID,
-		send: func(event importProgressEvent) {
-			data, err := json.Marshal(event)
-			if err != nil {
-				return
-			}
-			fmt.Fprintf(w, "data: %s\n\n", data)
-			flusher.Flush()
-		},
-	}
-}
-
-func (t *importTracker) insertBatch(ctx context.Context, st *store.Store, entries []*models.WatchHistoryEntry, total int) error {
-	inserted, skipped, consolidated, err := st.InsertHistoryBatch(ctx, entries)
-	if err != nil {
-		return err
-	}
-	t.inserted += inserted
-	t.skipped += skipped
-	t.consolidated += consolidated
-	t.total = total
-	t.processed += len(entries)
-	t.send(importProgressEvent{
-		Type:         "progress",
-		Processed:    t.processed,
-		Total:        t.total,
-		Inserted:     t.inserted,
-		Skipped:      t.skipped,
-		Consolidated: t.consolidated,
-	})
-	return nil
-}
-
-func (t *importTracker) fail(err error) {
-	log.Printf("%s import error: %v (imported %d, skipped %d, consolidated %d)",
-		t.label, err, t.inserted, t.skipped, t.consolidated)
-	t.send(importProgressEvent{
-		Type:         "error",
-		Processed:    t.processed,
-		Total:        t.total,
-		Inserted:     t.inserted,
-		Skipped:      t.skipped,
-		Consolidated: t.consolidated,
-		Error:        "import failed, check server logs",
-	})
-}
-
-func (t *importTracker) complete() {
-	log.Printf("%s import completed: %d inserted, %d skipped, %d consolidated, server_id=%d",
-		t.label, t.inserted, t.skipped, t.consolidated, t.serverID)
-	t.send(importProgressEvent{
-		Type:         "complete",
-		Processed:    t.processed,
-		Total:        t.total,
-		Inserted:     t.inserted,
-		Skipped:      t.skipped,
-		Consolidated: t.consolidated,
-	})
-}
-
 type importStreamer func(ctx context.Context, serverID int64, pageSize int,
 	handler func(entries []*models.WatchHistoryEntry, total int) error) error
 
+// handleHistoryImport starts a background import from an API-backed source
+// and streams its progress on the request. The job carries on if the client
+// disconnects.
 func (s *Server) handleHistoryImport(
 	getConfig func() (store.IntegrationConfig, error),
 	makeStreamer func(cfg store.IntegrationConfig) (importStreamer, error),
-	label string,
+	source models.ImportSource,
 ) http.HandlerFunc {
-	var mu sync.Mutex
+	label := importSourceLabels[source]
 	return func(w http.ResponseWriter, r *http.Request) {
-		if !mu.TryLock() {
-			writeError(w, http.StatusConflict, label+" import already in progress")
-			return
-		}
-		defer mu.Unlock()
-
 		r.Body = http.MaxBytesReader(w, r.Body, maxSettingsBody)
 		var req importRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -184,26 +107,7 @@ func (s *Server) handleHistoryImport(
 			return
 		}
 
-		flusher, ok := sseFlusher(w)
-		if !ok {
-			writeError(w, http.StatusInternalServerError, "streaming not supported")
-			return
-		}
-
-		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
-		defer cancel()
-
-		tracker := newImportTracker(label, req.ServerID, w, flusher)
-
-		err = streamer(ctx, req.ServerID, 1000, func(entries []*models.WatchHistoryEntry, total int) error {
-			return tracker.insertBatch(ctx, s.store, entries, total)
-		})
-
-		if err != nil {
-			tracker.fail(err)
-			return
-		}
-
-		tracker.complete()
+		job := &models.ImportJob{Source: source, ServerID: req.ServerID}
+		s.startImportJobStream(w, r, job, streamerRun(streamer, req.ServerID))
 	}
 }
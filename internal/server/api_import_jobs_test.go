@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"streammon/internal/jellystat"
+	"streammon/internal/models"
+)
+
+func TestImportJob_ResumeSkipsImportedRecords(t *testing.T) {
+	records := []jellystat.HistoryRecord{
+		{UserName: "alice", NowPlayingItemName: "Movie A", NowPlayingItemId: "item-1", PlaybackDuration: 3600,
+			ActivityDateInserted: "2024-06-15T20:00:00.000Z", PlayMethod: "DirectPlay"},
+		{UserName: "bob", NowPlayingItemName: "Movie B", NowPlayingItemId: "item-2", PlaybackDuration: 1800,
+			ActivityDateInserted: "2024-06-15T21:00:00.000Z", PlayMethod: "Transcode"},
+	}
+	mockJS := mockJellystatServerWithHistory(t, records)
+	ts, st := newTestServerWrapped(t)
+	srv := ts.Unwrap()
+	ctx := context.Background()
+
+	jf := &models.Server{Name: "JF", Type: models.ServerTypeJellyfin, URL: "http://test", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(jf); err != nil {
+		t.Fatal(err)
+	}
+	configureJellystat(t, st, mockJS.URL)
+
+	// A job a shutdown interrupted after the first record.
+	job := &models.ImportJob{Source: models.ImportSourceJellystat, ServerID: jf.ID}
+	if err := st.CreateImportJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	job.Processed, job.Total, job.Inserted = 1, 2, 1
+	if err := st.SaveImportJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ResumeImportJobs()
+	srv.WaitImportJobs()
+
+	got, err := st.GetImportJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.ImportJobCompleted || got.Resumes != 1 || got.Processed != 2 || got.Inserted != 2 {
+		t.Fatalf("unexpected resumed job: %+v", got)
+	}
+	history, err := st.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Items) != 1 || history.Items[0].UserName != "bob" {
+		t.Fatalf("expected only the unprocessed record imported, got %+v", history.Items)
+	}
+}
+
+func TestImportJob_ResumeWithoutUploadFails(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ctx := context.Background()
+
+	job := &models.ImportJob{Source: models.ImportSourceTautulliDatabase, ServerID: 1, FilePath: t.TempDir() + "/gone.db"}
+	if err := st.CreateImportJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	ts.Unwrap().ResumeImportJobs()
+
+	got, err := st.GetImportJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.ImportJobFailed || got.Error != "uploaded file is no longer available" || got.FinishedAt == nil {
+		t.Fatalf("unexpected job: %+v", got)
+	}
+}
+
+func TestImportJob_ResumeRemovesStaleUploads(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := ts.Unwrap()
+	srv.importDir = t.TempDir()
+	ctx := context.Background()
+
+	orphan := filepath.Join(srv.importDir, "tautulli-orphan.db")
+	unresumable := filepath.Join(srv.importDir, "playback-reporting-1.tsv")
+	for _, p := range []string{orphan, unresumable} {
+		if err := os.WriteFile(p, []byte("data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Its server is gone, so the job can't resume.
+	job := &models.ImportJob{Source: models.ImportSourcePlaybackReporting, ServerID: 999, FilePath: unresumable}
+	if err := st.CreateImportJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ResumeImportJobs()
+	srv.WaitImportJobs()
+
+	for _, p := range []string{orphan, unresumable} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s not removed: %v", filepath.Base(p), err)
+		}
+	}
+	if got, err := st.GetImportJob(ctx, job.ID); err != nil || got.Status != models.ImportJobFailed {
+		t.Fatalf("job = %+v, %v", got, err)
+	}
+
+	kept := filepath.Join(srv.importDir, "tautulli-kept.db")
+	if err := os.WriteFile(kept, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	srv.removeStaleImportUploads(map[string]bool{kept: true})
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("resumed job's upload removed: %v", err)
+	}
+}
+
+func TestImportJob_CancelAndEvents(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := ts.Unwrap()
+
+	started := make(chan struct{})
+	job := &models.ImportJob{Source: models.ImportSourceJellystat, ServerID: 1}
+	_, err := srv.startImportJob(context.Background(), job, func(ctx context.Context, sink importSink) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	// A second job for the same source is refused while one runs.
+	if _, err := srv.startImportJob(context.Background(), &models.ImportJob{Source: models.ImportSourceJellystat, ServerID: 1},
+		func(context.Context, importSink) error { return nil }, false); err != errImportBusy {
+		t.Fatalf("expected errImportBusy, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/import-jobs/%d", job.ID), nil))
+	var live models.ImportJob
+	if err := json.NewDecoder(w.Body).Decode(&live); err != nil || live.Status != models.ImportJobRunning {
+		t.Fatalf("expected running job, got %d: %+v (%v)", w.Code, live, err)
+	}
+
+	path := fmt.Sprintf("/api/import-jobs/%d/cancel", job.ID)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("cancel: expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	srv.WaitImportJobs()
+
+	got, err := st.GetImportJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.ImportJobCancelled || got.FinishedAt == nil {
+		t.Fatalf("unexpected cancelled job: %+v", got)
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("cancelling a finished job: expected 409, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/import-jobs/999/cancel", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("cancelling an unknown job: expected 404, got %d", w.Code)
+	}
+
+	fw := &flushRecorder{httptest.NewRecorder()}
+	ts.ServeHTTP(fw, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/import-jobs/%d/events", job.ID), nil))
+	if !strings.Contains(fw.Body.String(), `"type":"error"`) || !strings.Contains(fw.Body.String(), "import cancelled") {
+		t.Errorf("expected final cancelled event, got: %s", fw.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/import-jobs", nil))
+	var jobs []models.ImportJob
+	if err := json.NewDecoder(w.Body).Decode(&jobs); err != nil || len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("expected the one job listed, got %d: %+v (%v)", w.Code, jobs, err)
+	}
+}
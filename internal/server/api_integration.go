@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"time"
 
+	"streammon/internal/httputil"
+	"streammon/internal/models"
 	"streammon/internal/store"
 )
 
@@ -16,6 +18,8 @@ type integrationSettings struct {
 	URL     string `json:"url"`
 	APIKey  string `json:"api_key"`
 	Enabled *bool  `json:"enabled,omitempty"`
+	// HTTP is left unchanged on update when omitted.
+	HTTP *httputil.ClientOptions `json:"http,omitempty"`
 }
 
 type integrationTestResponse struct {
@@ -25,6 +29,15 @@ type integrationTestResponse struct {
 
 type integrationClient interface {
 	TestConnection(ctx context.Context) error
+	Configure(opts httputil.ClientOptions) error
+}
+
+// warnInsecureTLS logs when certificate verification is turned off for
+// target, since it leaves the connection open to interception.
+func warnInsecureTLS(target string, opts httputil.ClientOptions) {
+	if opts.InsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification is disabled for %s", target)
+	}
 }
 
 const integrationTimeout = 15 * time.Second
@@ -56,6 +69,7 @@ func (s *Server) handleGetIntegrationSettings(d integrationDeps) http.HandlerFun
 			URL:     cfg.URL,
 			APIKey:  apiKey,
 			Enabled: &cfg.Enabled,
+			HTTP:    &cfg.HTTP,
 		})
 	}
 }
@@ -73,6 +87,13 @@ func (s *Server) handleUpdateIntegrationSettings(d integrationDeps) http.Handler
 			req.APIKey = ""
 		}
 
+		if req.HTTP != nil {
+			if err := req.HTTP.Validate(); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
 		if req.URL != "" {
 			if err := d.validateURL(req.URL); err != nil {
 				writeError(w, http.StatusBadRequest, err.Error())
@@ -104,16 +125,23 @@ func (s *Server) handleUpdateIntegrationSettings(d integrationDeps) http.Handler
 			enabled = *req.Enabled
 		}
 
+		httpOpts := existing.HTTP
+		if req.HTTP != nil {
+			httpOpts = *req.HTTP
+		}
+
 		storeCfg := store.IntegrationConfig{
 			URL:     req.URL,
 			APIKey:  req.APIKey,
 			Enabled: enabled,
+			HTTP:    httpOpts,
 		}
 
 		if err := d.setConfig(storeCfg); err != nil {
 			writeError(w, http.StatusInternalServerError, "internal")
 			return
 		}
+		warnInsecureTLS(r.URL.Path, httpOpts)
 
 		if d.onUpdate != nil {
 			d.onUpdate()
@@ -149,13 +177,19 @@ func (s *Server) handleTestIntegrationConnection(d integrationDeps) http.Handler
 			return
 		}
 
+		cfg, err := d.getConfig()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+
+		httpOpts := cfg.HTTP
+		if req.HTTP != nil {
+			httpOpts = *req.HTTP
+		}
+
 		apiKey := req.APIKey
 		if apiKey == "" || apiKey == maskedSecret {
-			cfg, err := d.getConfig()
-			if err != nil {
-				writeError(w, http.StatusInternalServerError, "internal")
-				return
-			}
 			if cfg.APIKey == store.EncryptedPlaceholder {
 				writeError(w, http.StatusBadRequest, "stored API key is encrypted; provide the key or set TOKEN_ENCRYPTION_KEY")
 				return
@@ -172,6 +206,9 @@ func (s *Server) handleTestIntegrationConnection(d integrationDeps) http.Handler
 		defer cancel()
 
 		client, err := d.newClient(req.URL, apiKey)
+		if err == nil {
+			err = client.Configure(httpOpts)
+		}
 		if err != nil {
 			log.Printf("test connection for %s failed: %v", r.URL.Path, err)
 			writeJSON(w, http.StatusOK, integrationTestResponse{
@@ -206,3 +243,36 @@ func (s *Server) handleIntegrationConfigured(d integrationDeps) http.HandlerFunc
 		})
 	}
 }
+
+// handleGetArrDeletePolicy returns what maintenance deletes do in Radarr or
+// Sonarr.
+func (s *Server) handleGetArrDeletePolicy(get func() (models.ArrDeletePolicy, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policy, err := get()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		writeJSON(w, http.StatusOK, policy)
+	}
+}
+
+func (s *Server) handleUpdateArrDeletePolicy(set func(models.ArrDeletePolicy) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSettingsBody)
+		var policy models.ArrDeletePolicy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		if err := policy.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := set(policy); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		writeJSON(w, http.StatusOK, policy)
+	}
+}
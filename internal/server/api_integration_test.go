@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"streammon/internal/auth"
+	"streammon/internal/models"
 	"streammon/internal/store"
 )
 
@@ -137,6 +138,36 @@ func testIntegrationSettingsCRUD(t *testing.T, cfg integrationTestConfig) {
 		}
 	})
 
+	t.Run("HTTPOptions", func(t *testing.T) {
+		mock := cfg.mockServer(t)
+		srv, st := newTestServerWrapped(t)
+
+		put := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, cfg.settingsPath, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			return w
+		}
+
+		w := put(`{"url":"` + mock.URL + `","api_key":"key","http":{"proxy_url":"http://proxy.lan:3128","timeout_seconds":20}}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		w = put(`{"url":"` + mock.URL + `","api_key":"` + maskedSecret + `"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		icfg, _ := cfg.getConfig(st)
+		if icfg.HTTP.ProxyURL != "http://proxy.lan:3128" || icfg.HTTP.TimeoutSeconds != 20 {
+			t.Fatalf("expected http options preserved, got %+v", icfg.HTTP)
+		}
+
+		w = put(`{"url":"` + mock.URL + `","api_key":"key","http":{"proxy_url":"ftp://proxy.lan"}}`)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for invalid proxy, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
 	t.Run("DeleteSettings", func(t *testing.T) {
 		mock := cfg.mockServer(t)
 		srv, st := newTestServerWrapped(t)
@@ -572,3 +603,38 @@ func testIntegrationSettingsCRUD(t *testing.T, cfg integrationTestConfig) {
 		})
 	}
 }
+
+func TestArrDeletePolicySettings(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/settings/radarr/delete-policy", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"action":"delete"`) {
+		t.Fatalf("expected default delete policy, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/settings/sonarr/delete-policy",
+		strings.NewReader(`{"action":"unmonitor","add_exclusion":true}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	policy, err := st.GetSonarrDeletePolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Action != models.ArrDeleteActionUnmonitor || !policy.AddExclusion {
+		t.Fatalf("policy = %+v", policy)
+	}
+
+	for _, body := range []string{`{"action":"archive"}`, `{"action":"none","add_exclusion":true}`} {
+		req = httptest.NewRequest(http.MethodPut, "/api/settings/radarr/delete-policy", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
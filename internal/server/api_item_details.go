@@ -42,7 +42,7 @@ func (s *Server) handleGetItemDetails(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ms, ok := s.poller.GetServer(serverID)
-	if !ok {
+	if !ok || !visibleToWorkspace(r, serverID) {
 		writeError(w, http.StatusNotFound, "server not found")
 		return
 	}
@@ -24,6 +24,9 @@ func (s *Server) jellystatDeps() integrationDeps {
 
 func jellystatStreamer(cfg store.IntegrationConfig) (importStreamer, error) {
 	client, err := jellystat.NewClient(cfg.URL, cfg.APIKey)
+	if err == nil {
+		err = client.Configure(cfg.HTTP)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -25,35 +26,44 @@ type libraryCache struct {
 const libraryCacheTTL = 5 * time.Minute
 
 func (s *Server) handleGetLibraries(w http.ResponseWriter, r *http.Request) {
-	if s.poller == nil {
-		writeJSON(w, http.StatusOK, LibrariesResponse{Libraries: []models.Library{}})
+	libraries, errors, err := s.libraries(r.Context())
+	if err != nil {
+		log.Printf("list servers: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list servers")
 		return
 	}
+	writeJSON(w, http.StatusOK, LibrariesResponse{
+		Libraries: libraries,
+		Errors:    errors,
+	})
+}
+
+// libraries returns the libraries of every enabled server, cached for
+// libraryCacheTTL. Servers that can't be reached are reported in errors
+// rather than failing the whole list.
+func (s *Server) libraries(ctx context.Context) ([]models.Library, []string, error) {
+	if s.poller == nil {
+		return []models.Library{}, nil, nil
+	}
 
 	s.libCache.mu.RLock()
 	if time.Now().UTC().Before(s.libCache.expiresAt) {
-		resp := LibrariesResponse{
-			Libraries: s.libCache.libraries,
-			Errors:    s.libCache.errors,
-		}
+		libraries, errors := s.libCache.libraries, s.libCache.errors
 		s.libCache.mu.RUnlock()
-		writeJSON(w, http.StatusOK, resp)
-		return
+		return libraries, errors, nil
 	}
 	s.libCache.mu.RUnlock()
 
 	servers, err := s.store.ListServers()
 	if err != nil {
-		log.Printf("list servers: %v", err)
-		writeError(w, http.StatusInternalServerError, "failed to list servers")
-		return
+		return nil, nil, err
 	}
 
 	var allLibraries []models.Library
 	var errors []string
 
 	for _, srv := range servers {
-		if r.Context().Err() != nil {
+		if ctx.Err() != nil {
 			break
 		}
 
@@ -66,7 +76,7 @@ func (s *Server) handleGetLibraries(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		libs, err := ms.GetLibraries(r.Context())
+		libs, err := ms.GetLibraries(ctx)
 		if err != nil {
 			log.Printf("libraries from %s: %v", ms.Name(), err)
 			errors = append(errors, ms.Name()+": "+err.Error())
@@ -85,7 +95,7 @@ func (s *Server) handleGetLibraries(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if needsSizeLookup {
-		librarySizes, err := s.store.GetAllLibraryTotalSizes(r.Context())
+		librarySizes, err := s.store.GetAllLibraryTotalSizes(ctx)
 		if err != nil {
 			log.Printf("get library sizes: %v", err)
 		} else {
@@ -104,10 +114,7 @@ func (s *Server) handleGetLibraries(w http.ResponseWriter, r *http.Request) {
 	s.libCache.expiresAt = time.Now().UTC().Add(libraryCacheTTL)
 	s.libCache.mu.Unlock()
 
-	writeJSON(w, http.StatusOK, LibrariesResponse{
-		Libraries: allLibraries,
-		Errors:    errors,
-	})
+	return allLibraries, errors, nil
 }
 
 func (s *Server) InvalidateLibraryCache() {
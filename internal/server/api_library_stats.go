@@ -0,0 +1,58 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+type libraryStatsResponse struct {
+	Libraries []models.LibraryWatchStat `json:"libraries"`
+	Errors    []string                  `json:"errors,omitempty"`
+}
+
+func (s *Server) handleGetLibraryStats(w http.ResponseWriter, r *http.Request) {
+	r = s.withPreferenceDefaults(r)
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := s.store.LibraryWatchStats(r.Context(), filter)
+	if err != nil {
+		log.Printf("library stats: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	// Names come from the media servers; a library they no longer list keeps
+	// its ID so its history still shows.
+	libraries, errors, err := s.libraries(r.Context())
+	if err != nil {
+		log.Printf("library stats: %v", err)
+	}
+	type libKey struct {
+		serverID int64
+		id       string
+	}
+	known := make(map[libKey]models.Library, len(libraries))
+	for _, lib := range libraries {
+		known[libKey{lib.ServerID, lib.ID}] = lib
+	}
+	for i := range stats {
+		if lib, ok := known[libKey{stats[i].ServerID, stats[i].LibraryID}]; ok {
+			stats[i].Name = lib.Name
+			stats[i].Type = lib.Type
+			if lib.TotalSize > 0 {
+				stats[i].TotalSize = lib.TotalSize
+			}
+		}
+		if stats[i].Name == "" {
+			stats[i].Name = stats[i].LibraryID
+		}
+	}
+
+	writeJSON(w, http.StatusOK, libraryStatsResponse{Libraries: stats, Errors: errors})
+}
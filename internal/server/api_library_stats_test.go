@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestGetLibraryStats(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	server := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://x", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	if _, _, err := st.SyncLibraryItems(context.Background(), server.ID, "1", []models.LibraryItemCache{
+		{ServerID: server.ID, LibraryID: "1", ItemID: "m1", MediaType: models.MediaTypeMovie, Title: "Heat", AddedAt: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: server.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat", ItemID: "m1",
+		DurationMs: 3600000, WatchedMs: 3600000, Watched: true, StartedAt: now.Add(-2 * time.Hour), StoppedAt: now.Add(-time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/libraries?days=7", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp libraryStatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Libraries) != 1 {
+		t.Fatalf("libraries = %+v", resp.Libraries)
+	}
+	// Without a reachable server the library is named by its ID.
+	if lib := resp.Libraries[0]; lib.Name != "1" || lib.Plays != 1 || lib.TotalHours != 1 || lib.CompletionRate != 1 {
+		t.Errorf("library = %+v", lib)
+	}
+}
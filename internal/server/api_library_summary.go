@@ -20,6 +20,9 @@ func (s *Server) handleLibrarySummary(w http.ResponseWriter, r *http.Request) {
 
 	resp := librarySummaryResponse{PerServer: make([]store.LibraryServerSummary, 0, len(entries))}
 	for _, e := range entries {
+		if !visibleToWorkspace(r, e.ServerID) {
+			continue
+		}
 		resp.TotalItems += e.TotalItems
 		resp.Movies += e.Movies
 		resp.Shows += e.Shows
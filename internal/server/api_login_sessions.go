@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+
+	"streammon/internal/auth"
+)
+
+// currentSessionToken returns the session cookie r was made with, or "" for
+// requests authenticated some other way.
+func currentSessionToken(r *http.Request) string {
+	if cookie, err := r.Cookie(auth.CookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (s *Server) handleListMySessions(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	sessions, err := s.store.ListLoginSessions(user.ID, currentSessionToken(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *Server) handleDeleteMySession(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	if err := s.store.DeleteLoginSession(user.ID, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteOtherSessions signs the caller out everywhere but here.
+func (s *Server) handleDeleteOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := s.store.DeleteUserSessionsExcept(user.ID, currentSessionToken(r)); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAdminListUserSessions(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	if _, err := s.store.GetAdminUserByID(id); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	sessions, err := s.store.ListLoginSessions(id, currentSessionToken(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *Server) handleAdminDeleteUserSession(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	sessionID, ok := parseIDParam(r, "sessionID")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	if err := s.store.DeleteLoginSession(id, sessionID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminDeleteUserSessions signs a user out of every session, for
+// when a cookie may have leaked.
+func (s *Server) handleAdminDeleteUserSessions(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	if _, err := s.store.GetAdminUserByID(id); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	n, err := s.store.DeleteUserSessions(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": n})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestLoginSessionsAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := ts.Unwrap()
+	token := createViewerSession(t, st, "viewer")
+	other, _ := st.GetUserByEmail("viewer@test.local")
+	second, err := st.CreateSession(other.ID, time.Now().UTC().Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asViewer := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := asViewer(http.MethodGet, "/api/me/sessions")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: got %d: %s", w.Code, w.Body.String())
+	}
+	var sessions []models.LoginSession
+	if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+	var otherID int64
+	for _, ls := range sessions {
+		if !ls.Current {
+			otherID = ls.ID
+		}
+	}
+
+	// Another user's session can't be revoked through /me.
+	admin, _ := st.GetUserByEmail("admin@test.local")
+	adminSessions, _ := st.ListLoginSessions(admin.ID, "")
+	if w := asViewer(http.MethodDelete, fmt.Sprintf("/api/me/sessions/%d", adminSessions[0].ID)); w.Code != http.StatusNotFound {
+		t.Errorf("revoking admin session as viewer: got %d, want 404", w.Code)
+	}
+	if w := asViewer(http.MethodDelete, fmt.Sprintf("/api/me/sessions/%d", otherID)); w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: got %d", w.Code)
+	}
+	if _, err := st.GetSessionUser(second); err == nil {
+		t.Error("revoked session still valid")
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/admin/users/%d/sessions", other.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin revoke all: got %d: %s", w.Code, w.Body.String())
+	}
+	if w := asViewer(http.MethodGet, "/api/me"); w.Code != http.StatusUnauthorized {
+		t.Errorf("after revoke all: got %d, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/users/99999/sessions", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: got %d, want 404", w.Code)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"sort"
@@ -18,6 +19,7 @@ import (
 	"streammon/internal/maintenance"
 	"streammon/internal/mediautil"
 	"streammon/internal/models"
+	"streammon/internal/store"
 )
 
 const maxBulkOperationSize = 500 // SQLite SQLITE_MAX_VARIABLE_NUMBER limit is 999
@@ -229,6 +231,16 @@ func (s *Server) recordDeleteAudit(candidate models.MaintenanceCandidate, delete
 	); err != nil {
 		log.Printf("record delete action: %v", err)
 	}
+	if success {
+		s.outboundWebhooks.Dispatch(models.WebhookEventMaintenanceDeleted, models.MaintenanceDeletedWebhook{
+			ServerID:  candidate.Item.ServerID,
+			ItemID:    candidate.Item.ItemID,
+			Title:     candidate.Item.Title,
+			MediaType: candidate.Item.MediaType,
+			FileSize:  candidate.Item.FileSize,
+			DeletedBy: deletedBy,
+		})
+	}
 }
 
 func parsePagination(r *http.Request, defaultPerPage, maxPerPage int) (page, perPage int) {
@@ -297,6 +309,11 @@ func (s *Server) handleGetMaintenanceDashboard(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	schedules, err := s.store.ListLibrarySyncSchedules(r.Context())
+	if err != nil {
+		log.Printf("maintenance dashboard: list sync schedules: %v", err)
+	}
+
 	var libraries []models.LibraryMaintenance
 
 	for _, srv := range servers {
@@ -335,6 +352,11 @@ func (s *Server) handleGetMaintenanceDashboard(w http.ResponseWriter, r *http.Re
 				log.Printf("maintenance dashboard: count items for %s/%s: %v", srv.Name, lib.Name, err)
 			}
 
+			schedule, ok := schedules[models.RuleLibrary{ServerID: srv.ID, LibraryID: lib.ID}]
+			if !ok {
+				schedule = models.LibrarySyncDaily
+			}
+
 			libraries = append(libraries, models.LibraryMaintenance{
 				ServerID:     srv.ID,
 				ServerName:   srv.Name,
@@ -343,6 +365,7 @@ func (s *Server) handleGetMaintenanceDashboard(w http.ResponseWriter, r *http.Re
 				LibraryType:  lib.Type,
 				TotalItems:   itemCount,
 				LastSyncedAt: lastSync,
+				SyncSchedule: schedule,
 				Rules:        rules,
 			})
 		}
@@ -351,6 +374,53 @@ func (s *Server) handleGetMaintenanceDashboard(w http.ResponseWriter, r *http.Re
 	writeJSON(w, http.StatusOK, models.MaintenanceDashboard{Libraries: libraries})
 }
 
+// PUT /api/maintenance/libraries/{serverID}/{libraryID}/sync-schedule
+func (s *Server) handleSetLibrarySyncSchedule(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(chi.URLParam(r, "serverID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid server id")
+		return
+	}
+	libraryID := chi.URLParam(r, "libraryID")
+	if libraryID == "" {
+		writeError(w, http.StatusBadRequest, "library id is required")
+		return
+	}
+
+	var req struct {
+		Schedule models.LibrarySyncSchedule `json:"schedule"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !req.Schedule.Valid() {
+		writeError(w, http.StatusBadRequest, "schedule must be daily, weekly, or off")
+		return
+	}
+
+	srv, err := s.store.GetServer(serverID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if srv.DeletedAt != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+
+	if err := s.store.SetLibrarySyncSchedule(r.Context(), serverID, libraryID, req.Schedule); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save sync schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"server_id":  serverID,
+		"library_id": libraryID,
+		"schedule":   req.Schedule,
+	})
+}
+
 // POST /api/maintenance/sync
 func (s *Server) handleSyncLibraryItems(w http.ResponseWriter, r *http.Request) {
 	if s.poller == nil {
@@ -407,6 +477,18 @@ func (s *Server) startBackgroundSync(key string, serverID int64, libraryID strin
 			s.librarySync.finish(key, count, int(deleted), err)
 		}()
 
+		limit, limitErr := s.store.GetLibrarySyncParallelism()
+		if limitErr != nil {
+			log.Printf("background sync %s: reading parallelism: %v", key, limitErr)
+			limit = store.DefaultLibrarySyncParallelism
+		}
+		if err = s.librarySync.acquireSlot(ctx, key, serverID, limit); err != nil {
+			mediautil.CloseProgress(progressCtx)
+			log.Printf("background sync %s: cancelled while queued: %v", key, err)
+			return
+		}
+		defer s.librarySync.releaseSlot(serverID)
+
 		log.Printf("background sync %s: started", key)
 
 		done := make(chan struct{})
@@ -515,7 +597,9 @@ func (s *Server) executeSyncLibrary(ctx context.Context, serverID int64, library
 			candidateCount += len(candidates)
 			if err := s.store.BatchUpsertCandidates(ctx, rule.ID, candidates); err != nil {
 				log.Printf("upsert candidates for rule %d: %v", rule.ID, err)
+				continue
 			}
+			s.outboundWebhooks.MaintenanceEvaluated(&rule, len(candidates))
 		}
 	}
 
@@ -581,6 +665,67 @@ func (s *Server) handleCreateMaintenanceRule(w http.ResponseWriter, r *http.Requ
 	writeJSON(w, http.StatusCreated, rule)
 }
 
+// POST /api/maintenance/rules/{id}/clone
+func (s *Server) handleCloneMaintenanceRule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid id parameter")
+		return
+	}
+
+	// The body is optional; without one the clone copies the source as is.
+	var clone models.MaintenanceRuleCloneInput
+	if err := json.NewDecoder(r.Body).Decode(&clone); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	src, err := s.store.GetMaintenanceRule(r.Context(), id)
+	if errors.Is(err, models.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "rule not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get rule")
+		return
+	}
+
+	input := models.MaintenanceRuleInput{
+		Name:          src.Name + " (copy)",
+		CriterionType: src.CriterionType,
+		MediaType:     src.MediaType,
+		Parameters:    src.Parameters,
+		Enabled:       src.Enabled,
+		Libraries:     src.Libraries,
+	}
+	if clone.Name != "" {
+		input.Name = clone.Name
+	}
+	if clone.Enabled != nil {
+		input.Enabled = *clone.Enabled
+	}
+	if len(clone.Libraries) > 0 {
+		input.Libraries = clone.Libraries
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := s.store.CreateMaintenanceRule(r.Context(), &input)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create rule")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// GET /api/maintenance/templates
+func (s *Server) handleGetRuleTemplates(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"templates": maintenance.GetRuleTemplates()})
+}
+
 // GET /api/maintenance/rules/{id}
 func (s *Server) handleGetMaintenanceRule(w http.ResponseWriter, r *http.Request) {
 	id, ok := parseIDParam(r, "id")
@@ -674,20 +819,95 @@ func (s *Server) handleEvaluateRule(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
 	defer cancel()
 
-	evaluator := maintenance.NewEvaluator(s.store, s.tmdbClient, s.poller)
-	candidates, err := evaluator.EvaluateRule(ctx, rule)
+	if r.Header.Get("Accept") == "text/event-stream" {
+		s.streamEvaluateRule(ctx, w, rule)
+		return
+	}
+
+	count, err := s.evaluateAndSaveRule(ctx, rule)
 	if err != nil {
-		log.Printf("evaluate rule %d: %v", id, err)
-		writeError(w, http.StatusInternalServerError, "failed to evaluate rule")
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if err := s.store.BatchUpsertCandidates(ctx, rule.ID, candidates); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to save candidates")
+	writeJSON(w, http.StatusOK, map[string]any{"candidates": count})
+}
+
+// evaluationProgressInterval throttles SSE progress events; evaluators report
+// once per item, which for large libraries is far more than a UI can draw.
+const evaluationProgressInterval = 250 * time.Millisecond
+
+// streamEvaluateRule runs the evaluation while streaming mediautil.SyncProgress
+// events, then a final "complete" event with the candidate count or an
+// "error" event.
+func (s *Server) streamEvaluateRule(ctx context.Context, w http.ResponseWriter, rule *models.MaintenanceRule) {
+	flusher, ok := sseFlusher(w)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	send := func(event string, v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		if event != "" {
+			fmt.Fprintf(w, "event: %s\n", event)
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	progressCtx, progressCh := mediautil.ContextWithProgress(ctx)
+
+	var count int
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer mediautil.CloseProgress(progressCtx)
+		count, err = s.evaluateAndSaveRule(progressCtx, rule)
+	}()
+
+	var last mediautil.SyncProgress
+	var lastSent time.Time
+	pending := false
+	for p := range progressCh {
+		last, pending = p, true
+		if time.Since(lastSent) >= evaluationProgressInterval {
+			send("", p)
+			lastSent, pending = time.Now(), false
+		}
+	}
+	<-done
+
+	if err != nil {
+		send("error", map[string]string{"error": err.Error()})
 		return
 	}
+	if pending {
+		send("", last)
+	}
+	send("complete", map[string]any{"candidates": count})
+}
 
-	writeJSON(w, http.StatusOK, map[string]any{"candidates": len(candidates)})
+// evaluateAndSaveRule evaluates rule and replaces its stored candidates.
+// Returned errors are safe to show to the client; details are logged.
+func (s *Server) evaluateAndSaveRule(ctx context.Context, rule *models.MaintenanceRule) (int, error) {
+	evaluator := maintenance.NewEvaluator(s.store, s.tmdbClient, s.poller)
+	candidates, err := evaluator.EvaluateRule(ctx, rule)
+	if err != nil {
+		log.Printf("evaluate rule %d: %v", rule.ID, err)
+		return 0, errors.New("failed to evaluate rule")
+	}
+
+	if err := s.store.BatchUpsertCandidates(ctx, rule.ID, candidates); err != nil {
+		log.Printf("save candidates for rule %d: %v", rule.ID, err)
+		return 0, errors.New("failed to save candidates")
+	}
+	s.outboundWebhooks.MaintenanceEvaluated(rule, len(candidates))
+	return len(candidates), nil
 }
 
 // GET /api/maintenance/rules/{id}/candidates
@@ -1210,6 +1430,100 @@ func (s *Server) handleBulkDeleteCandidates(w http.ResponseWriter, r *http.Reque
 	writeJSON(w, http.StatusOK, result)
 }
 
+// POST /api/maintenance/candidates/bulk-exclude
+func (s *Server) handleBulkExcludeCandidates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CandidateIDs []int64 `json:"candidate_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := validateBulkIDs(req.CandidateIDs); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	candidates, err := s.store.GetMaintenanceCandidates(r.Context(), req.CandidateIDs)
+	if err != nil {
+		log.Printf("get candidates for bulk exclude: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to get candidates")
+		return
+	}
+
+	// Exclusions are global, so candidates of different rules may share an item.
+	seen := make(map[int64]bool, len(candidates))
+	var libraryItemIDs []int64
+	for _, c := range candidates {
+		if !seen[c.LibraryItemID] {
+			seen[c.LibraryItemID] = true
+			libraryItemIDs = append(libraryItemIDs, c.LibraryItemID)
+		}
+	}
+
+	count, err := s.store.CreateExclusions(r.Context(), libraryItemIDs, getUserEmail(r))
+	if err != nil {
+		log.Printf("bulk exclude candidates: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to create exclusions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"excluded": count})
+}
+
+const maxSnoozeDays = 365
+
+// POST /api/maintenance/candidates/bulk-snooze
+func (s *Server) handleBulkSnoozeCandidates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CandidateIDs []int64    `json:"candidate_ids"`
+		Days         int        `json:"days"`
+		Until        *time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := validateBulkIDs(req.CandidateIDs); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	var until time.Time
+	switch {
+	case req.Until != nil && req.Days != 0:
+		writeError(w, http.StatusBadRequest, "specify either days or until, not both")
+		return
+	case req.Until != nil:
+		until = *req.Until
+	case req.Days > 0:
+		until = now.AddDate(0, 0, req.Days)
+	default:
+		writeError(w, http.StatusBadRequest, "days or until required")
+		return
+	}
+	if !until.After(now) {
+		writeError(w, http.StatusBadRequest, "until must be in the future")
+		return
+	}
+	if until.After(now.AddDate(0, 0, maxSnoozeDays)) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot snooze for more than %d days", maxSnoozeDays))
+		return
+	}
+
+	count, err := s.store.SnoozeCandidates(r.Context(), req.CandidateIDs, until, getUserEmail(r))
+	if err != nil {
+		log.Printf("bulk snooze candidates: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to snooze candidates")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"snoozed": count, "until": until.UTC()})
+}
+
 type BulkDeleteProgress struct {
 	Current   int    `json:"current"`
 	Total     int    `json:"total"`
@@ -1260,6 +1574,10 @@ func (s *Server) executeBulkDelete(ctx context.Context, candidateIDs []int64, ca
 	}
 
 	total := len(candidateIDs)
+	s.outboundWebhooks.BulkDeleteStarted(total, deletedBy)
+	// Every return hands back result, so this reports a cancelled or
+	// aborted delete as far as it got.
+	defer func() { s.outboundWebhooks.BulkDeleteFinished(total, deletedBy, result) }()
 
 	// Track library item IDs already deleted so that two candidates pointing
 	// at the same library item don't attempt a redundant media-server delete.
@@ -2,24 +2,66 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
 )
 
 type maintenanceSettingsResponse struct {
-	ResolutionWidthAware bool `json:"resolution_width_aware"`
+	ResolutionWidthAware      bool                        `json:"resolution_width_aware"`
+	SyncParallelismPerServer  int                         `json:"sync_parallelism_per_server"`
+	CollectionProtection      models.CollectionProtection `json:"collection_protection"`
+	ContinueWatchingDays      int                         `json:"continue_watching_days"`
+	CandidateWebhookThreshold int                         `json:"candidate_webhook_threshold"`
 }
 
 type maintenanceSettingsRequest struct {
-	ResolutionWidthAware *bool `json:"resolution_width_aware,omitempty"`
+	ResolutionWidthAware      *bool                        `json:"resolution_width_aware,omitempty"`
+	SyncParallelismPerServer  *int                         `json:"sync_parallelism_per_server,omitempty"`
+	CollectionProtection      *models.CollectionProtection `json:"collection_protection,omitempty"`
+	ContinueWatchingDays      *int                         `json:"continue_watching_days,omitempty"`
+	CandidateWebhookThreshold *int                         `json:"candidate_webhook_threshold,omitempty"`
 }
 
-func (s *Server) handleGetMaintenanceSettings(w http.ResponseWriter, r *http.Request) {
+func (s *Server) maintenanceSettings() (maintenanceSettingsResponse, error) {
 	widthAware, err := s.store.GetMaintenanceResolutionWidthAware()
+	if err != nil {
+		return maintenanceSettingsResponse{}, err
+	}
+	parallelism, err := s.store.GetLibrarySyncParallelism()
+	if err != nil {
+		return maintenanceSettingsResponse{}, err
+	}
+	protection, err := s.store.GetMaintenanceCollectionProtection()
+	if err != nil {
+		return maintenanceSettingsResponse{}, err
+	}
+	continueDays, err := s.store.GetMaintenanceContinueWatchingDays()
+	if err != nil {
+		return maintenanceSettingsResponse{}, err
+	}
+	threshold, err := s.store.GetMaintenanceCandidateWebhookThreshold()
+	if err != nil {
+		return maintenanceSettingsResponse{}, err
+	}
+	return maintenanceSettingsResponse{
+		ResolutionWidthAware:      widthAware,
+		SyncParallelismPerServer:  parallelism,
+		CollectionProtection:      protection,
+		ContinueWatchingDays:      continueDays,
+		CandidateWebhookThreshold: threshold,
+	}, nil
+}
+
+func (s *Server) handleGetMaintenanceSettings(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.maintenanceSettings()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
-	writeJSON(w, http.StatusOK, maintenanceSettingsResponse{ResolutionWidthAware: widthAware})
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleUpdateMaintenanceSettings(w http.ResponseWriter, r *http.Request) {
@@ -28,13 +70,63 @@ func (s *Server) handleUpdateMaintenanceSettings(w http.ResponseWriter, r *http.
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	if req.ResolutionWidthAware == nil {
-		writeError(w, http.StatusBadRequest, "resolution_width_aware is required")
+	if req.ResolutionWidthAware == nil && req.SyncParallelismPerServer == nil && req.CollectionProtection == nil &&
+		req.ContinueWatchingDays == nil && req.CandidateWebhookThreshold == nil {
+		writeError(w, http.StatusBadRequest, "resolution_width_aware, sync_parallelism_per_server, collection_protection, continue_watching_days, or candidate_webhook_threshold is required")
+		return
+	}
+	if n := req.CandidateWebhookThreshold; n != nil && (*n < 0 || *n > store.MaxCandidateWebhookThreshold) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("candidate_webhook_threshold must be between 0 and %d", store.MaxCandidateWebhookThreshold))
 		return
 	}
-	if err := s.store.SetMaintenanceResolutionWidthAware(*req.ResolutionWidthAware); err != nil {
+	if n := req.ContinueWatchingDays; n != nil && (*n < 0 || *n > store.MaxContinueWatchingDays) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("continue_watching_days must be between 0 and %d", store.MaxContinueWatchingDays))
+		return
+	}
+	if req.CollectionProtection != nil {
+		if err := req.CollectionProtection.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if n := req.SyncParallelismPerServer; n != nil {
+		if *n < 1 || *n > store.MaxLibrarySyncParallelism {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("sync_parallelism_per_server must be between 1 and %d", store.MaxLibrarySyncParallelism))
+			return
+		}
+		if err := s.store.SetLibrarySyncParallelism(*n); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+	}
+	if req.ResolutionWidthAware != nil {
+		if err := s.store.SetMaintenanceResolutionWidthAware(*req.ResolutionWidthAware); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+	}
+	if req.ContinueWatchingDays != nil {
+		if err := s.store.SetMaintenanceContinueWatchingDays(*req.ContinueWatchingDays); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+	}
+	if req.CollectionProtection != nil {
+		if err := s.store.SetMaintenanceCollectionProtection(*req.CollectionProtection); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+	}
+	if req.CandidateWebhookThreshold != nil {
+		if err := s.store.SetMaintenanceCandidateWebhookThreshold(*req.CandidateWebhookThreshold); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+	}
+	resp, err := s.maintenanceSettings()
+	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
-	writeJSON(w, http.StatusOK, maintenanceSettingsResponse{ResolutionWidthAware: *req.ResolutionWidthAware})
+	writeJSON(w, http.StatusOK, resp)
 }
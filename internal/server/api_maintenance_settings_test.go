@@ -102,3 +102,119 @@ func TestUpdateMaintenanceSettings_NonAdminForbidden(t *testing.T) {
 		t.Fatalf("expected 403 for non-admin PUT, got %d: %s", w.Code, w.Body.String())
 	}
 }
+
+func TestUpdateMaintenanceSettings_SyncParallelism(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(`{"sync_parallelism_per_server":3}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp maintenanceSettingsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.SyncParallelismPerServer != 3 || resp.ResolutionWidthAware {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if n, _ := st.GetLibrarySyncParallelism(); n != 3 {
+		t.Fatalf("stored parallelism = %d, want 3", n)
+	}
+
+	for _, body := range []string{`{"sync_parallelism_per_server":0}`, `{"sync_parallelism_per_server":99}`} {
+		req := httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestUpdateMaintenanceSettings_CollectionProtection(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	body := `{"collection_protection":{"all_collections":false,"names":[" Keep Forever ","keep forever",""]}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp maintenanceSettingsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	names := resp.CollectionProtection.Names
+	if len(names) != 1 || names[0] != "Keep Forever" {
+		t.Fatalf("names = %v, want [Keep Forever]", names)
+	}
+
+	stored, err := st.GetMaintenanceCollectionProtection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AllCollections || len(stored.Names) != 1 || stored.Names[0] != "Keep Forever" {
+		t.Fatalf("stored protection = %+v", stored)
+	}
+}
+
+func TestUpdateMaintenanceSettings_ContinueWatchingDays(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(`{"continue_watching_days":14}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp maintenanceSettingsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.ContinueWatchingDays != 14 {
+		t.Fatalf("continue_watching_days = %d, want 14", resp.ContinueWatchingDays)
+	}
+	if n, _ := st.GetMaintenanceContinueWatchingDays(); n != 14 {
+		t.Fatalf("stored days = %d, want 14", n)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(`{"continue_watching_days":-1}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for negative days, got %d", w.Code)
+	}
+}
+
+func TestUpdateMaintenanceSettings_CandidateWebhookThreshold(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(`{"candidate_webhook_threshold":50}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp maintenanceSettingsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.CandidateWebhookThreshold != 50 {
+		t.Fatalf("candidate_webhook_threshold = %d, want 50", resp.CandidateWebhookThreshold)
+	}
+	if n, _ := st.GetMaintenanceCandidateWebhookThreshold(); n != 50 {
+		t.Fatalf("stored threshold = %d, want 50", n)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/settings/maintenance", strings.NewReader(`{"candidate_webhook_threshold":-1}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for negative threshold, got %d", w.Code)
+	}
+}
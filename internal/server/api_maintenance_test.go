@@ -100,6 +100,103 @@ func TestCreateMaintenanceRuleAPIValidation(t *testing.T) {
 	}
 }
 
+func TestGetRuleTemplatesAPI(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/maintenance/templates", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Templates []models.MaintenanceRuleTemplate `json:"templates"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Templates) == 0 {
+		t.Error("expected at least one template")
+	}
+}
+
+func TestCloneMaintenanceRuleAPI(t *testing.T) {
+	srv, s := newTestServerWrapped(t)
+	ctx := context.Background()
+
+	server := &models.Server{Name: "Test", Type: models.ServerTypePlex, URL: "http://test", APIKey: "key", Enabled: true}
+	if err := s.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	src, err := s.CreateMaintenanceRule(ctx, &models.MaintenanceRuleInput{
+		Name:          "Old Movies",
+		CriterionType: models.CriterionUnwatchedMovie,
+		MediaType:     models.MediaTypeMovie,
+		Parameters:    json.RawMessage(`{"days":200}`),
+		Enabled:       true,
+		Libraries:     []models.RuleLibrary{{ServerID: server.ID, LibraryID: "lib1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("copy as is", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/maintenance/rules/%d/clone", src.ID), nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var rule models.MaintenanceRule
+		if err := json.NewDecoder(w.Body).Decode(&rule); err != nil {
+			t.Fatal(err)
+		}
+		if rule.ID == src.ID || rule.Name != "Old Movies (copy)" || !rule.Enabled {
+			t.Errorf("clone = %+v", rule)
+		}
+		if string(rule.Parameters) != `{"days":200}` {
+			t.Errorf("parameters = %s, want source parameters", rule.Parameters)
+		}
+		if len(rule.Libraries) != 1 || rule.Libraries[0].LibraryID != "lib1" {
+			t.Errorf("libraries = %+v, want source libraries", rule.Libraries)
+		}
+	})
+
+	t.Run("retargeted", func(t *testing.T) {
+		body := fmt.Sprintf(`{"name":"Old 4K Movies","enabled":false,"libraries":[{"server_id":%d,"library_id":"lib2"}]}`, server.ID)
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/maintenance/rules/%d/clone", src.ID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var rule models.MaintenanceRule
+		if err := json.NewDecoder(w.Body).Decode(&rule); err != nil {
+			t.Fatal(err)
+		}
+		if rule.Name != "Old 4K Movies" || rule.Enabled {
+			t.Errorf("clone = %+v", rule)
+		}
+		if len(rule.Libraries) != 1 || rule.Libraries[0].LibraryID != "lib2" {
+			t.Errorf("libraries = %+v, want lib2", rule.Libraries)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/maintenance/rules/99999/clone", nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
 func TestGetMaintenanceRuleAPI(t *testing.T) {
 	srv, s := newTestServerWrapped(t)
 	ctx := context.Background()
@@ -150,6 +247,42 @@ func TestGetMaintenanceRuleNotFoundAPI(t *testing.T) {
 	}
 }
 
+func TestSetLibrarySyncScheduleAPI(t *testing.T) {
+	srv, s := newTestServerWrapped(t)
+	server := &models.Server{Name: "Test", Type: models.ServerTypePlex, URL: "http://test", APIKey: "key", Enabled: true}
+	if err := s.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	path := fmt.Sprintf("/api/maintenance/libraries/%d/lib1/sync-schedule", server.ID)
+	w := put(path, `{"schedule":"weekly"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	schedules, err := s.ListLibrarySyncSchedules(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schedules[models.RuleLibrary{ServerID: server.ID, LibraryID: "lib1"}]; got != models.LibrarySyncWeekly {
+		t.Errorf("schedule = %q, want weekly", got)
+	}
+
+	if w := put(path, `{"schedule":"hourly"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid schedule: expected 400, got %d", w.Code)
+	}
+	if w := put("/api/maintenance/libraries/99999/lib1/sync-schedule", `{"schedule":"off"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown server: expected 404, got %d", w.Code)
+	}
+}
+
 func TestListMaintenanceRulesAPI(t *testing.T) {
 	srv, s := newTestServerWrapped(t)
 	ctx := context.Background()
@@ -704,6 +837,93 @@ func TestBulkRemoveExclusionsAPI(t *testing.T) {
 	}
 }
 
+func TestBulkExcludeCandidatesAPI(t *testing.T) {
+	srv, s := newTestServerWrapped(t)
+	ctx := context.Background()
+	ids := setupDeleteCandidateTest(t, s, "item1")
+
+	body := fmt.Sprintf(`{"candidate_ids":[%d]}`, ids.candidateID)
+	req := httptest.NewRequest(http.MethodPost, "/api/maintenance/candidates/bulk-exclude", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["excluded"].(float64) != 1 {
+		t.Errorf("excluded = %v, want 1", resp["excluded"])
+	}
+
+	excluded, err := s.IsItemExcluded(ctx, ids.libraryItemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !excluded {
+		t.Error("expected library item to be excluded")
+	}
+}
+
+func TestBulkSnoozeCandidatesAPI(t *testing.T) {
+	srv, s := newTestServerWrapped(t)
+	ctx := context.Background()
+	ids := setupDeleteCandidateTest(t, s, "item1")
+
+	body := fmt.Sprintf(`{"candidate_ids":[%d],"days":30}`, ids.candidateID)
+	req := httptest.NewRequest(http.MethodPost, "/api/maintenance/candidates/bulk-snooze", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["snoozed"].(float64) != 1 {
+		t.Errorf("snoozed = %v, want 1", resp["snoozed"])
+	}
+
+	count, err := s.CountCandidatesForRule(ctx, ids.ruleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("candidate count = %d, want 0 while snoozed", count)
+	}
+}
+
+func TestBulkSnoozeCandidatesValidationAPI(t *testing.T) {
+	srv, s := newTestServerWrapped(t)
+	ids := setupDeleteCandidateTest(t, s, "item1")
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	for _, body := range []string{
+		fmt.Sprintf(`{"candidate_ids":[%d]}`, ids.candidateID),
+		fmt.Sprintf(`{"candidate_ids":[%d],"days":400}`, ids.candidateID),
+		fmt.Sprintf(`{"candidate_ids":[%d],"until":%q}`, ids.candidateID, past),
+		fmt.Sprintf(`{"candidate_ids":[%d],"days":7,"until":%q}`, ids.candidateID, past),
+		`{"candidate_ids":[],"days":7}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/maintenance/candidates/bulk-snooze", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
 func TestBulkDeleteCandidatesAPI(t *testing.T) {
 	srv, s := newTestServerWrapped(t)
 	ctx := context.Background()
@@ -1454,3 +1674,65 @@ func TestExportCandidatesCSVSanitizesFormulas(t *testing.T) {
 		t.Errorf("reason not neutralized; body=%q", body)
 	}
 }
+
+func TestEvaluateRuleSSE_StreamsProgressAndComplete(t *testing.T) {
+	srv, s := newTestServerWrapped(t)
+	ids := setupDeleteCandidateTest(t, s, "item1")
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/maintenance/rules/%d/evaluate", ids.ruleID), nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := &flushRecorder{httptest.NewRecorder()}
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	var progress []mediautil.SyncProgress
+	var complete map[string]int
+	var event string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = line[len("event: "):]
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := []byte(line[len("data: "):])
+		switch event {
+		case "complete":
+			if err := json.Unmarshal(data, &complete); err != nil {
+				t.Fatalf("decoding complete event: %v", err)
+			}
+		case "":
+			var p mediautil.SyncProgress
+			if err := json.Unmarshal(data, &p); err != nil {
+				t.Fatalf("decoding progress event: %v", err)
+			}
+			progress = append(progress, p)
+		default:
+			t.Fatalf("unexpected event %q: %s", event, data)
+		}
+		event = ""
+	}
+
+	if len(progress) == 0 {
+		t.Fatal("expected at least one progress event")
+	}
+	last := progress[len(progress)-1]
+	if last.Phase != mediautil.PhaseEvaluating || last.Current != 1 || last.Total != 1 {
+		t.Errorf("last progress = %+v, want evaluating 1/1", last)
+	}
+	if complete == nil {
+		t.Fatal("expected a complete event")
+	}
+	if _, ok := complete["candidates"]; !ok {
+		t.Errorf("complete event missing candidates: %v", complete)
+	}
+}
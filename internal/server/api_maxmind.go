@@ -98,7 +98,8 @@ func (s *Server) handleDeleteMaxMindSettings(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	_ = s.store.SetSetting("maxmind.last_updated", "")
-	if s.geoUpdater != nil {
+	// Another provider's databases were put there by the admin.
+	if s.geoUpdater != nil && s.geoUpdater.ManagesDatabases() {
 		if err := os.Remove(s.geoUpdater.DBPath()); err != nil && !os.IsNotExist(err) {
 			log.Printf("removing geoip db: %v", err)
 		}
@@ -172,3 +173,51 @@ func (s *Server) handleGeoBackfill(w http.ResponseWriter, r *http.Request) {
 		Total:    len(ips),
 	})
 }
+
+type geoBackfillStartResponse struct {
+	Total  int    `json:"total"`
+	Status string `json:"status"` // "none", "started"
+}
+
+// handleStartGeoBackfillJob starts resolving every uncached history address
+// in the background. Progress is reported by handleGeoBackfillJobStatus.
+func (s *Server) handleStartGeoBackfillJob(w http.ResponseWriter, r *http.Request) {
+	if s.geoResolver == nil {
+		writeError(w, http.StatusServiceUnavailable, "GeoIP resolver not configured")
+		return
+	}
+
+	count, err := s.store.CountUncachedIPs(r.Context())
+	if err != nil {
+		log.Printf("ERROR geo backfill: CountUncachedIPs: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if count == 0 {
+		writeJSON(w, http.StatusOK, geoBackfillStartResponse{Total: 0, Status: "none"})
+		return
+	}
+
+	if !s.geoBackfill.start(s.appCtx, s.store, s.geoResolver, count) {
+		writeError(w, http.StatusConflict, "geo backfill already running")
+		return
+	}
+	writeJSON(w, http.StatusOK, geoBackfillStartResponse{Total: count, Status: "started"})
+}
+
+func (s *Server) handleStopGeoBackfillJob(w http.ResponseWriter, r *http.Request) {
+	s.geoBackfill.stop()
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleGeoBackfillJobStatus reports the running backfill's progress or, when
+// none is running, the last run's counts with Total set to what's left.
+func (s *Server) handleGeoBackfillJobStatus(w http.ResponseWriter, r *http.Request) {
+	st := s.geoBackfill.status()
+	if !st.Running {
+		if count, err := s.store.CountUncachedIPs(r.Context()); err == nil {
+			st.Total = count
+		}
+	}
+	writeJSON(w, http.StatusOK, st)
+}
@@ -247,3 +247,53 @@ func TestGeoBackfill_StopsOnContextCancellation(t *testing.T) {
 		t.Fatalf("expected no response body to be written after the client disconnected, got: %s", w.Body.String())
 	}
 }
+
+func TestGeoBackfillJob(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	start := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/settings/maxmind/backfill/start", nil))
+		return w
+	}
+	if w := start(); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without resolver: expected 503, got %d", w.Code)
+	}
+
+	seedUncachedIPs(t, st, []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "10.0.0.1"})
+	srv.Unwrap().geoResolver = &stubResolver{results: map[string]*models.GeoResult{
+		"1.1.1.1": {IP: "1.1.1.1", City: "A", Country: "US"},
+		"2.2.2.2": {IP: "2.2.2.2", City: "B", Country: "US"},
+		"3.3.3.3": {IP: "3.3.3.3", City: "C", Country: "US"},
+	}}
+
+	w := start()
+	if w.Code != http.StatusOK {
+		t.Fatalf("start: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var started geoBackfillStartResponse
+	if err := json.NewDecoder(w.Body).Decode(&started); err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != "started" || started.Total != 4 {
+		t.Fatalf("start response = %+v", started)
+	}
+	srv.Unwrap().WaitEnrichment()
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/settings/maxmind/backfill/status", nil))
+	var status geoBackfillStatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Running || status.Processed != 4 || status.Resolved != 3 || status.Unresolved != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	// Only the unresolvable address is left.
+	if status.Total != 1 {
+		t.Errorf("remaining = %d, want 1", status.Total)
+	}
+	if geo, err := st.GetCachedGeo("2.2.2.2"); err != nil || geo == nil || geo.City != "B" {
+		t.Errorf("cached geo = %+v, %v", geo, err)
+	}
+}
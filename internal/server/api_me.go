@@ -163,3 +163,87 @@ func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+type capabilitiesResponse struct {
+	Role        models.Role     `json:"role"`
+	Permissions map[string]bool `json:"permissions"`
+	Features    map[string]bool `json:"features"`
+	Visibility  map[string]bool `json:"visibility"`
+	Instance    instanceFlags   `json:"instance"`
+}
+
+type instanceFlags struct {
+	Version       string   `json:"version"`
+	GuestAccess   bool     `json:"guest_access"`
+	AuthProviders []string `json:"auth_providers"`
+}
+
+// GET /api/me/capabilities
+//
+// Reports what the caller can use, mirroring the role checks and guest
+// settings the routes enforce, so clients needn't probe endpoints for 403s.
+func (s *Server) handleMeCapabilities(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	gs, err := s.store.GetGuestSettings()
+	if err != nil {
+		log.Printf("getting guest settings: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	overseerr, err := s.overseerrDeps().getConfig()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	sonarr, err := s.sonarrDeps().getConfig()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	admin := user.Role == models.RoleAdmin
+	readAll := user.Role.CanReadAll()
+	discover := admin || gs["show_discover"]
+
+	visibility := make(map[string]bool)
+	for _, key := range []string{"profile", "trust_score", "violations", "watch_history", "household", "devices", "isps"} {
+		visibility[key] = readAll || gs["visible_"+key]
+	}
+
+	resp := capabilitiesResponse{
+		Role: user.Role,
+		Permissions: map[string]bool{
+			"read_all_users":     readAll,
+			"manage_servers":     admin,
+			"manage_settings":    admin,
+			"manage_users":       admin,
+			"terminate_sessions": admin,
+		},
+		Features: map[string]bool{
+			"maintenance":   admin,
+			"rules":         admin,
+			"notifications": admin,
+			"discover":      discover && s.tmdbClient != nil,
+			"requests":      discover && overseerr.IsUsable(),
+			"calendar":      (admin || gs["show_calendar"]) && sonarr.IsUsable(),
+		},
+		Visibility: visibility,
+		Instance: instanceFlags{
+			Version:       "unknown",
+			GuestAccess:   gs["access_enabled"],
+			AuthProviders: s.authManager.GetEnabledProviders(),
+		},
+	}
+	if resp.Instance.AuthProviders == nil {
+		resp.Instance.AuthProviders = []string{}
+	}
+	if s.version != nil {
+		resp.Instance.Version = s.version.Info().Current
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
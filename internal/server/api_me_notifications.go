@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+	"streammon/internal/webpush"
+)
+
+type myNotificationsResponse struct {
+	Subscription      models.UserNotificationSubscription `json:"subscription"`
+	EmailAvailable    bool                                `json:"email_available"`
+	VAPIDPublicKey    string                              `json:"vapid_public_key"`
+	PushSubscriptions []models.PushSubscription           `json:"push_subscriptions"`
+}
+
+// handleGetMyNotifications returns the caller's alert subscription along
+// with what the browser needs to offer it: whether email can be sent and
+// the key to pass to PushManager.subscribe.
+func (s *Server) handleGetMyNotifications(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	sub, err := s.store.GetUserNotificationSubscription(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	push, err := s.store.ListPushSubscriptions(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	settings, err := s.store.GetUserNotificationSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	keys, err := s.vapidKeys()
+	if err != nil {
+		log.Printf("loading vapid keys: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, myNotificationsResponse{
+		Subscription:      sub,
+		EmailAvailable:    settings.EmailChannelID != 0 && user.Email != "",
+		VAPIDPublicKey:    keys.PublicKey,
+		PushSubscriptions: push,
+	})
+}
+
+// vapidKeys returns the server's push signing keys, generating them the
+// first time anyone asks.
+func (s *Server) vapidKeys() (models.VAPIDKeys, error) {
+	keys, err := s.store.GetVAPIDKeys()
+	if !errors.Is(err, models.ErrNotFound) {
+		return keys, err
+	}
+	if keys, err = webpush.GenerateVAPIDKeys(); err != nil {
+		return keys, err
+	}
+	return s.store.SetVAPIDKeys(keys)
+}
+
+func (s *Server) handleUpdateMyNotifications(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var sub models.UserNotificationSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := sub.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetUserNotificationSubscription(user.ID, sub); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	sub, err := s.store.GetUserNotificationSubscription(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// pushSubscriptionRequest is the shape of the browser's
+// PushSubscription.toJSON().
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+func (s *Server) handleAddPushSubscription(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req pushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	p := models.PushSubscription{
+		UserID:    user.ID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: r.UserAgent(),
+	}
+	if err := p.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.AddPushSubscription(&p); err != nil {
+		if errors.Is(err, store.ErrTooManyPushSubscriptions) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) handleDeletePushSubscription(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid push subscription id")
+		return
+	}
+	if err := s.store.DeletePushSubscription(user.ID, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetUserNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetUserNotificationSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// handleUpdateUserNotificationSettings picks the email channel whose SMTP
+// server sends users their own alerts. Only its server settings are used;
+// its recipients and event filters don't apply.
+func (s *Server) handleUpdateUserNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	var req models.UserNotificationSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.EmailChannelID != 0 {
+		ch, err := s.store.GetNotificationChannel(req.EmailChannelID)
+		if errors.Is(err, models.ErrNotFound) {
+			writeError(w, http.StatusBadRequest, "email channel not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if ch.ChannelType != models.ChannelTypeEmail {
+			writeError(w, http.StatusBadRequest, "channel is not an email channel")
+			return
+		}
+	}
+	if err := s.store.SetUserNotificationSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestMyNotifications(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/me/notifications", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", w.Code, w.Body.String())
+	}
+	var got myNotificationsResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.EmailAvailable || got.VAPIDPublicKey == "" || len(got.PushSubscriptions) != 0 {
+		t.Errorf("initial = %+v", got)
+	}
+	// The key pair is generated once and then kept.
+	keys, err := st.GetVAPIDKeys()
+	if err != nil || keys.PublicKey != got.VAPIDPublicKey {
+		t.Errorf("stored keys = %+v, %v", keys, err)
+	}
+
+	w = do(http.MethodPut, "/api/me/notifications", `{"events":["new_device"],"email":true,"push":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("put: %d %s", w.Code, w.Body.String())
+	}
+	if w = do(http.MethodPut, "/api/me/notifications", `{"events":["server_down"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid event: %d", w.Code)
+	}
+
+	browser, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := fmt.Sprintf(`{"endpoint":"https://push.example.com/abc","keys":{"p256dh":%q,"auth":%q}}`,
+		base64.RawURLEncoding.EncodeToString(browser.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(make([]byte, 16)))
+	w = do(http.MethodPost, "/api/me/push-subscriptions", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("subscribe: %d %s", w.Code, w.Body.String())
+	}
+	var push models.PushSubscription
+	if err := json.NewDecoder(w.Body).Decode(&push); err != nil {
+		t.Fatal(err)
+	}
+	if w = do(http.MethodPost, "/api/me/push-subscriptions", `{"endpoint":"https://push.example.com/x","keys":{"p256dh":"AAAA","auth":"AAAA"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bad keys: %d", w.Code)
+	}
+
+	w = do(http.MethodGet, "/api/me/notifications", "")
+	got = myNotificationsResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Subscription.Email || len(got.Subscription.Events) != 1 || len(got.PushSubscriptions) != 1 {
+		t.Errorf("after subscribing = %+v", got)
+	}
+
+	if w = do(http.MethodDelete, fmt.Sprintf("/api/me/push-subscriptions/%d", push.ID), ""); w.Code != http.StatusNoContent {
+		t.Errorf("unsubscribe: %d", w.Code)
+	}
+	if w = do(http.MethodDelete, fmt.Sprintf("/api/me/push-subscriptions/%d", push.ID), ""); w.Code != http.StatusNotFound {
+		t.Errorf("unsubscribe again: %d", w.Code)
+	}
+
+	// Only admins choose how user alerts are emailed.
+	if w = do(http.MethodPut, "/api/settings/user-notifications", `{"email_channel_id":1}`); w.Code != http.StatusForbidden {
+		t.Errorf("viewer updating settings: %d", w.Code)
+	}
+}
+
+func TestUserNotificationSettings(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	discord := &models.NotificationChannel{Name: "Discord", ChannelType: models.ChannelTypeDiscord, Enabled: true,
+		Config: json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/1/abc"}`)}
+	email := &models.NotificationChannel{Name: "Email", ChannelType: models.ChannelTypeEmail, Enabled: true,
+		Config: json.RawMessage(`{"host":"smtp.example.com","from":"me@example.com","to":["me@example.com"]}`)}
+	for _, c := range []*models.NotificationChannel{discord, email} {
+		if err := st.CreateNotificationChannel(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put := func(body string) int {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/user-notifications", strings.NewReader(body)))
+		return w.Code
+	}
+	if code := put(fmt.Sprintf(`{"email_channel_id":%d}`, discord.ID)); code != http.StatusBadRequest {
+		t.Errorf("discord channel: %d", code)
+	}
+	if code := put(`{"email_channel_id":9999}`); code != http.StatusBadRequest {
+		t.Errorf("missing channel: %d", code)
+	}
+	if code := put(fmt.Sprintf(`{"email_channel_id":%d}`, email.ID)); code != http.StatusOK {
+		t.Fatalf("email channel: %d", code)
+	}
+	settings, err := st.GetUserNotificationSettings()
+	if err != nil || settings.EmailChannelID != email.ID {
+		t.Errorf("settings = %+v, %v", settings, err)
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// portalResponse is a signed-in user's own activity. Sections the guest
+// visibility settings hide from viewers are left null.
+type portalResponse struct {
+	UserName      string                                            `json:"user_name"`
+	Accounts      []models.UserAccount                              `json:"accounts"`
+	Stats         *models.UserDetailStats                           `json:"stats"`
+	History       *models.PaginatedResult[models.WatchHistoryEntry] `json:"history"`
+	ActiveStreams []models.ActiveStream                             `json:"active_streams"`
+}
+
+// handleGetPortal serves the self-service view of the caller's own history,
+// devices, and stats. A media-server login is scoped to the account it
+// signed in with (plus linked accounts), so a household member never sees
+// another member's activity even when names collide across servers.
+func (s *Server) handleGetPortal(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil || user.ID <= 0 {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	scope, err := s.store.SelfScope(r.Context(), user.ID, user.Name)
+	if err != nil {
+		log.Printf("SelfScope error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	gs, err := s.store.GetGuestSettings()
+	if err != nil {
+		log.Printf("getting guest settings: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	readAll := user.Role.CanReadAll()
+	visible := func(key string) bool {
+		return readAll || (gs["visible_profile"] && gs["visible_"+key])
+	}
+
+	resp := portalResponse{
+		UserName:      user.Name,
+		Accounts:      scope.Accounts,
+		ActiveStreams: []models.ActiveStream{},
+	}
+	if resp.Accounts == nil {
+		resp.Accounts = []models.UserAccount{}
+	}
+
+	if readAll || gs["visible_profile"] {
+		stats, err := s.store.UserDetailStatsScoped(r.Context(), scope)
+		if err != nil {
+			log.Printf("UserDetailStats error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if !visible("devices") {
+			stats.Devices = nil
+		}
+		if !visible("isps") {
+			stats.ISPs = nil
+			stats.NetworkLabels = nil
+		}
+		resp.Stats = stats
+	}
+
+	if visible("watch_history") {
+		page, perPage := parsePagination(r, 20, maxPerPage)
+		history, err := s.store.QueryHistory(page, perPage, store.HistoryQuery{Scope: &scope})
+		if err != nil {
+			log.Printf("QueryHistory error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		resp.History = history
+	}
+
+	if s.poller != nil {
+		for _, sess := range s.poller.CurrentSessions() {
+			if scope.Matches(sess.ServerID, sess.UserName) {
+				resp.ActiveStreams = append(resp.ActiveStreams, sess)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestPortal_ScopedToLoginAccount(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	var serverIDs []int64
+	for _, name := range []string{"Plex", "Jellyfin"} {
+		srv := &models.Server{Name: name, Type: models.ServerTypeJellyfin, URL: "http://" + name, APIKey: "k", Enabled: true}
+		if err := st.CreateServer(srv); err != nil {
+			t.Fatal(err)
+		}
+		serverIDs = append(serverIDs, srv.ID)
+	}
+	now := time.Now().UTC().Add(-time.Hour)
+	for _, h := range []struct {
+		serverID int64
+		user     string
+	}{{serverIDs[0], "alex"}, {serverIDs[1], "alex"}, {serverIDs[1], "jo"}} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: h.serverID, UserName: h.user, MediaType: models.MediaTypeMovie, Title: "Heat",
+			DurationMs: 1000, WatchedMs: 1000, StartedAt: now, StoppedAt: now.Add(time.Second),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user, err := st.GetOrLinkUserByEmail("", "alex", "jellyfin", strconv.FormatInt(serverIDs[1], 10)+":abc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := st.CreateSession(user.ID, time.Now().UTC().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Unwrap().SetPollerForTest(&fakePoller{sessions: []models.ActiveStream{
+		{ServerID: serverIDs[1], UserName: "alex", Title: "Mine"},
+		{ServerID: serverIDs[0], UserName: "alex", Title: "Other alex"},
+		{ServerID: serverIDs[1], UserName: "jo", Title: "Jo's"},
+	}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/portal", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp portalResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Stats == nil || resp.Stats.SessionCount != 1 {
+		t.Errorf("stats = %+v, want one session", resp.Stats)
+	}
+	if resp.History == nil || resp.History.Total != 1 || resp.History.Items[0].ServerID != serverIDs[1] {
+		t.Errorf("history = %+v, want the Jellyfin play only", resp.History)
+	}
+	if len(resp.ActiveStreams) != 1 || resp.ActiveStreams[0].Title != "Mine" {
+		t.Errorf("active streams = %+v, want only the caller's", resp.ActiveStreams)
+	}
+}
+
+func TestPortal_HonorsGuestVisibility(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	cookie := createViewerSession(t, st, "sam")
+	if err := st.SetGuestSettings(map[string]bool{"visible_watch_history": false, "visible_devices": false}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/portal", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: cookie})
+	w := httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp portalResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.History != nil {
+		t.Error("expected history hidden")
+	}
+	if resp.Stats == nil || resp.Stats.Devices != nil {
+		t.Errorf("expected stats without devices, got %+v", resp.Stats)
+	}
+}
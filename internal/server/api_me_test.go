@@ -422,3 +422,63 @@ func TestHandleChangePassword_EmptyBody(t *testing.T) {
 		t.Errorf("expected 400 for empty body, got %d", w.Code)
 	}
 }
+
+func TestHandleMeCapabilities(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	admin, err := st.CreateLocalUser("capadmin", "", "", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer, err := st.CreateLocalUser("capviewer", "", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetGuestSettings(map[string]bool{"visible_devices": false}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(user *models.User) capabilitiesResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/me/capabilities", nil)
+		req = req.WithContext(contextWithUser(req.Context(), user))
+		w := httptest.NewRecorder()
+		srv.handleMeCapabilities(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var got capabilitiesResponse
+		json.NewDecoder(w.Body).Decode(&got)
+		return got
+	}
+
+	got := get(admin)
+	if got.Role != models.RoleAdmin || !got.Features["maintenance"] || !got.Features["rules"] || !got.Permissions["manage_settings"] {
+		t.Errorf("admin capabilities = %+v", got)
+	}
+	if !got.Visibility["devices"] {
+		t.Error("admin should see devices regardless of guest settings")
+	}
+
+	got = get(viewer)
+	if got.Features["maintenance"] || got.Features["rules"] || got.Permissions["read_all_users"] {
+		t.Errorf("viewer capabilities = %+v", got)
+	}
+	if got.Visibility["devices"] || !got.Visibility["watch_history"] {
+		t.Errorf("viewer visibility = %+v", got.Visibility)
+	}
+	if got.Instance.AuthProviders == nil {
+		t.Error("expected auth_providers to be a list")
+	}
+}
+
+func TestHandleMeCapabilities_Unauthenticated(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+
+	req := httptest.NewRequest("GET", "/api/me/capabilities", nil)
+	w := httptest.NewRecorder()
+	srv.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var input models.APITokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Scope == models.APITokenScopeAdmin && user.Role != models.RoleAdmin {
+		writeError(w, http.StatusForbidden, "only admins can create admin-scoped tokens")
+		return
+	}
+
+	existing, err := s.store.ListAPITokens(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if len(existing) >= models.MaxAPITokensPerUser {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d API tokens per account", models.MaxAPITokensPerUser))
+		return
+	}
+
+	token, err := s.store.CreateAPIToken(user.ID, input)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create API token")
+		return
+	}
+	writeJSON(w, http.StatusCreated, token)
+}
+
+func (s *Server) handleListAPITokens(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	tokens, err := s.store.ListAPITokens(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list API tokens")
+		return
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+func (s *Server) handleDeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid API token id")
+		return
+	}
+	if err := s.store.DeleteAPIToken(user.ID, id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func createAPIToken(t *testing.T, h http.Handler, cookie, body string) (*httptest.ResponseRecorder, models.APIToken) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/me/api-tokens", strings.NewReader(body))
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: cookie})
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var tok models.APIToken
+	if w.Code == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w, tok
+}
+
+func bearerRequest(h http.Handler, token, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	h := ts.Unwrap()
+	viewerCookie := createViewerSession(t, st, "alice")
+
+	if w, _ := createAPIToken(t, h, viewerCookie, `{"name":"x","scope":"admin"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("viewer admin token: expected 403, got %d", w.Code)
+	}
+	w, stats := createAPIToken(t, h, viewerCookie, `{"name":"Home Assistant","scope":"stats_only"}`)
+	if w.Code != http.StatusCreated || stats.Token == "" {
+		t.Fatalf("create stats token: got %d: %s", w.Code, w.Body.String())
+	}
+	_, adminStats := createAPIToken(t, h, testSessionToken, `{"name":"grafana","scope":"stats_only"}`)
+	_, readOnly := createAPIToken(t, h, testSessionToken, `{"name":"dashboard","scope":"read_only"}`)
+	_, admin := createAPIToken(t, h, testSessionToken, `{"name":"automation","scope":"admin"}`)
+	if adminStats.Token == "" || readOnly.Token == "" || admin.Token == "" {
+		t.Fatal("expected admin to create tokens of every scope")
+	}
+
+	for _, tc := range []struct {
+		name, token, method, path string
+		want                      int
+	}{
+		{"stats own user", stats.Token, http.MethodGet, "/api/users/alice/stats", http.StatusOK},
+		{"stats global", adminStats.Token, http.MethodGet, "/api/stats", http.StatusOK},
+		{"stats history", adminStats.Token, http.MethodGet, "/api/history", http.StatusForbidden},
+		{"stats other user", stats.Token, http.MethodGet, "/api/users/test-admin/stats", http.StatusForbidden},
+		{"read_only get", readOnly.Token, http.MethodGet, "/api/admin/users", http.StatusOK},
+		{"read_only write", readOnly.Token, http.MethodDelete, "/api/servers/1", http.StatusForbidden},
+		{"admin get", admin.Token, http.MethodGet, "/api/admin/users", http.StatusOK},
+		{"admin create token", admin.Token, http.MethodPost, "/api/me/api-tokens", http.StatusForbidden},
+		{"malformed", "smt_short", http.MethodGet, "/api/stats", http.StatusUnauthorized},
+	} {
+		if w := bearerRequest(h, tc.token, tc.method, tc.path); w.Code != tc.want {
+			t.Errorf("%s: %s %s expected %d, got %d: %s", tc.name, tc.method, tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	// Demoting the owner disables admin-scoped tokens.
+	if err := st.UpdateUserRole("test-admin", models.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if w := bearerRequest(h, admin.Token, http.MethodGet, "/api/stats"); w.Code != http.StatusForbidden {
+		t.Errorf("demoted owner: expected 403, got %d", w.Code)
+	}
+}
+
+func TestAPITokenListAndRevoke(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	h := ts.Unwrap()
+	viewerCookie := createViewerSession(t, st, "alice")
+
+	_, tok := createAPIToken(t, h, testSessionToken, `{"name":"script","scope":"read_only"}`)
+	if tok.Token == "" {
+		t.Fatal("expected token")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/api-tokens", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	var listed []models.APIToken
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Token != "" || listed[0].LastUsedAt != nil {
+		t.Fatalf("unexpected list %+v", listed)
+	}
+
+	path := "/api/me/api-tokens/" + strconv.FormatInt(tok.ID, 10)
+	req = httptest.NewRequest(http.MethodDelete, path, nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewerCookie})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("revoking another user's token: expected 404, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, path, nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: expected 204, got %d", w.Code)
+	}
+	if w := bearerRequest(h, tok.Token, http.MethodGet, "/api/stats"); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestNonBearerAuthorizationFallsThroughToCookie(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected cookie auth to apply, got %d", w.Code)
+	}
+}
+
+func TestAPITokenCreateValidation(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+
+	for _, body := range []string{
+		`{"name":"","scope":"read_only"}`,
+		`{"name":"x","scope":"everything"}`,
+		`{"name":"x"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/me/api-tokens", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+const maxTitleHistoryEntries = 1000
+
+// overseerrRequestStatuses names Overseerr's numeric request statuses.
+var overseerrRequestStatuses = map[int]string{
+	1: "pending",
+	2: "approved",
+	3: "declined",
+	4: "failed",
+	5: "completed",
+}
+
+// handleGetMediaTitle returns everything known about one movie or show,
+// looked up by external ID. TMDB IDs are shared between a movie and a show,
+// so ?type=movie or ?type=show picks one when both are in the libraries.
+func (s *Server) handleGetMediaTitle(w http.ResponseWriter, r *http.Request) {
+	ext, err := models.ParseExternalID(chi.URLParam(r, "external_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var wantType models.MediaType
+	switch r.URL.Query().Get("type") {
+	case "":
+	case "movie":
+		wantType = models.MediaTypeMovie
+	case "show", "tv":
+		wantType = models.MediaTypeTV
+	default:
+		writeError(w, http.StatusBadRequest, "type must be movie or show")
+		return
+	}
+
+	all, err := s.store.ListLibraryCopies(r.Context(), ext)
+	if err != nil {
+		log.Printf("listing library copies for %s: %v", ext, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	var items []models.LibraryItemCache
+	for _, item := range all {
+		if wantType == "" || item.MediaType == wantType {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, "title not found in any library")
+		return
+	}
+	for _, item := range items[1:] {
+		if item.MediaType != items[0].MediaType {
+			writeError(w, http.StatusBadRequest, "id matches both a movie and a show; pass type=movie or type=show")
+			return
+		}
+	}
+
+	serverNames := make(map[int64]string)
+	if servers, err := s.store.ListServers(); err == nil {
+		for _, srv := range servers {
+			serverNames[srv.ID] = srv.Name
+		}
+	}
+
+	first := items[0]
+	title := &models.MediaTitle{
+		ExternalID: ext,
+		Title:      first.Title,
+		Year:       first.Year,
+		MediaType:  first.MediaType,
+		Copies:     make([]models.MediaTitleCopy, 0, len(items)),
+	}
+	itemIDs := make([]int64, 0, len(items))
+	matches := make([]store.LibraryMatch, 0, len(items))
+	for _, item := range items {
+		title.Copies = append(title.Copies, models.MediaTitleCopy{LibraryItemCache: item, ServerName: serverNames[item.ServerID]})
+		title.TotalSize += item.FileSize
+		if title.TMDBID == "" {
+			title.TMDBID = item.TMDBID
+		}
+		if title.TVDBID == "" {
+			title.TVDBID = item.TVDBID
+		}
+		if title.IMDBID == "" {
+			title.IMDBID = item.IMDBID
+		}
+		itemIDs = append(itemIDs, item.ID)
+		matches = append(matches, store.LibraryMatch{ServerID: item.ServerID, ServerName: serverNames[item.ServerID], ItemID: item.ItemID})
+	}
+
+	level := "movie"
+	if title.MediaType == models.MediaTypeTV {
+		level = "show"
+	}
+	if title.History, err = s.store.HistoryForItemAcrossServers(r.Context(), matches, level, 0, 0, "", maxTitleHistoryEntries); err != nil {
+		log.Printf("history for %s: %v", ext, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if title.Candidates, err = s.store.ListCandidatesForItems(r.Context(), itemIDs); err != nil {
+		log.Printf("candidates for %s: %v", ext, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if title.Exclusions, err = s.store.ListExclusionsForItems(r.Context(), itemIDs); err != nil {
+		log.Printf("exclusions for %s: %v", ext, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	// TMDB metadata and Overseerr requests are extras: a title still has a
+	// detail page when either service is down or not configured.
+	title.Requests = []models.MediaTitleRequest{}
+	if tmdbID, err := strconv.Atoi(title.TMDBID); err == nil {
+		movie := title.MediaType == models.MediaTypeMovie
+		title.TMDB = s.titleTMDBMetadata(r.Context(), tmdbID, movie)
+		title.Requests = s.titleRequests(r.Context(), tmdbID, movie)
+	}
+
+	writeJSON(w, http.StatusOK, title)
+}
+
+func (s *Server) titleTMDBMetadata(ctx context.Context, tmdbID int, movie bool) json.RawMessage {
+	if s.tmdbClient == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, tmdbTimeout)
+	defer cancel()
+	get := s.tmdbClient.GetTV
+	if movie {
+		get = s.tmdbClient.GetMovie
+	}
+	data, err := get(ctx, tmdbID)
+	if err != nil {
+		log.Printf("WARN: TMDB metadata tmdb=%d: %v", tmdbID, err)
+		return nil
+	}
+	return data
+}
+
+func (s *Server) titleRequests(ctx context.Context, tmdbID int, movie bool) []models.MediaTitleRequest {
+	requests := []models.MediaTitleRequest{}
+	client, err := s.newOverseerrClient()
+	if err != nil {
+		return requests
+	}
+	ctx, cancel := context.WithTimeout(ctx, integrationTimeout)
+	defer cancel()
+	get := client.GetTV
+	if movie {
+		get = client.GetMovie
+	}
+	raw, err := get(ctx, tmdbID)
+	if err != nil {
+		log.Printf("WARN: Overseerr requests tmdb=%d: %v", tmdbID, err)
+		return requests
+	}
+
+	var info struct {
+		MediaInfo *struct {
+			Requests []struct {
+				ID          int       `json:"id"`
+				Status      int       `json:"status"`
+				CreatedAt   time.Time `json:"createdAt"`
+				RequestedBy struct {
+					DisplayName string `json:"displayName"`
+					Email       string `json:"email"`
+				} `json:"requestedBy"`
+			} `json:"requests"`
+		} `json:"mediaInfo"`
+	}
+	if err := json.Unmarshal(raw, &info); err != nil || info.MediaInfo == nil {
+		return requests
+	}
+	for _, req := range info.MediaInfo.Requests {
+		mr := models.MediaTitleRequest{
+			ID:          req.ID,
+			Status:      overseerrRequestStatuses[req.Status],
+			RequestedBy: req.RequestedBy.DisplayName,
+			Email:       req.RequestedBy.Email,
+		}
+		if !req.CreatedAt.IsZero() {
+			createdAt := req.CreatedAt
+			mr.RequestedAt = &createdAt
+		}
+		requests = append(requests, mr)
+	}
+	return requests
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestGetMediaTitle(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	ctx := context.Background()
+
+	plex := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	jf := &models.Server{Name: "Jellyfin", Type: models.ServerTypeJellyfin, URL: "http://jf", APIKey: "k", Enabled: true}
+	for _, s := range []*models.Server{plex, jf} {
+		if err := st.CreateServer(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	items := []models.LibraryItemCache{
+		{ServerID: plex.ID, LibraryID: "lib1", ItemID: "p-603", MediaType: models.MediaTypeMovie, Title: "The Matrix", Year: 1999,
+			TMDBID: "603", IMDBID: "tt0133093", FileSize: 4 << 30, VideoResolution: "1080", AddedAt: now, SyncedAt: now},
+		{ServerID: jf.ID, LibraryID: "lib2", ItemID: "j-603", MediaType: models.MediaTypeMovie, Title: "The Matrix", Year: 1999,
+			TMDBID: "603", FileSize: 20 << 30, VideoResolution: "4k", AddedAt: now, SyncedAt: now},
+		{ServerID: plex.ID, LibraryID: "lib1", ItemID: "p-other", MediaType: models.MediaTypeMovie, Title: "Other",
+			TMDBID: "604", AddedAt: now, SyncedAt: now},
+	}
+	if _, err := st.UpsertLibraryItems(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	copies, err := st.ListLibraryCopies(ctx, models.ExternalID{Provider: models.ExternalIDTMDB, ID: "603"})
+	if err != nil || len(copies) != 2 {
+		t.Fatalf("expected 2 copies, got %d (%v)", len(copies), err)
+	}
+
+	for i, entry := range []*models.WatchHistoryEntry{
+		{ServerID: plex.ID, ItemID: "p-603", UserName: "alice", MediaType: models.MediaTypeMovie, Title: "The Matrix",
+			StartedAt: now.Add(-3 * time.Hour), StoppedAt: now.Add(-time.Hour)},
+		{ServerID: jf.ID, ItemID: "j-603", UserName: "bob", MediaType: models.MediaTypeMovie, Title: "The Matrix",
+			StartedAt: now.Add(-48 * time.Hour), StoppedAt: now.Add(-46 * time.Hour)},
+		{ServerID: plex.ID, ItemID: "p-other", UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Other",
+			StartedAt: now.Add(-5 * time.Hour), StoppedAt: now.Add(-4 * time.Hour)},
+	} {
+		if err := st.InsertHistory(entry); err != nil {
+			t.Fatalf("history %d: %v", i, err)
+		}
+	}
+
+	rule, err := st.CreateMaintenanceRule(ctx, &models.MaintenanceRuleInput{
+		Name:          "Unwatched",
+		CriterionType: models.CriterionUnwatchedMovie,
+		MediaType:     models.MediaTypeMovie,
+		Parameters:    json.RawMessage(`{}`),
+		Enabled:       true,
+		Libraries:     []models.RuleLibrary{{ServerID: jf.ID, LibraryID: "lib2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpsertMaintenanceCandidate(ctx, rule.ID, copies[1].ID, "not watched in 90 days"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.CreateExclusions(ctx, []int64{copies[0].ID}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/media/tmdb:603", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got models.MediaTitle
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "The Matrix" || got.IMDBID != "tt0133093" || got.TotalSize != 24<<30 {
+		t.Errorf("unexpected title summary: %+v", got)
+	}
+	if len(got.Copies) != 2 || got.Copies[0].ServerName != "Plex" || got.Copies[1].VideoResolution != "4k" {
+		t.Errorf("unexpected copies: %+v", got.Copies)
+	}
+	if len(got.History) != 2 || got.History[0].UserName != "alice" {
+		t.Errorf("expected both servers' plays newest first, got %+v", got.History)
+	}
+	if len(got.Candidates) != 1 || got.Candidates[0].RuleName != "Unwatched" || got.Candidates[0].LibraryItemID != copies[1].ID {
+		t.Errorf("unexpected candidates: %+v", got.Candidates)
+	}
+	if len(got.Exclusions) != 1 || got.Exclusions[0].LibraryItemID != copies[0].ID {
+		t.Errorf("unexpected exclusions: %+v", got.Exclusions)
+	}
+
+	// The IMDb ID only one copy carries finds that copy alone.
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/media/tt0133093", nil))
+	got = models.MediaTitle{}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || len(got.Copies) != 1 {
+		t.Errorf("imdb lookup: %d %+v (%v)", w.Code, got.Copies, err)
+	}
+
+	for path, want := range map[string]int{
+		"/api/media/tmdb:999":           http.StatusNotFound,
+		"/api/media/tmdb:603?type=show": http.StatusNotFound,
+		"/api/media/tmdb:abc":           http.StatusBadRequest,
+		"/api/media/foo:1":              http.StatusBadRequest,
+		"/api/media/tmdb:603?type=x":    http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"streammon/internal/metrics"
+	"streammon/internal/models"
+)
+
+// WithMetrics serves Prometheus metrics at /metrics. A non-empty token must
+// be sent as a bearer token by the scraper.
+func WithMetrics(token string) Option {
+	return func(s *Server) {
+		s.metricsEnabled = true
+		s.metricsToken = token
+	}
+}
+
+// GET /metrics
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metricsToken != "" {
+		got := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+s.metricsToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+	}
+
+	servers, err := s.store.ListServers()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	var sessions []models.ActiveStream
+	if s.poller != nil {
+		sessions = s.poller.CurrentSessions()
+	}
+
+	type serverStats struct {
+		name       string
+		streams    int
+		transcodes int
+		bandwidth  int64
+	}
+	byID := make(map[int64]*serverStats, len(servers))
+	order := make([]int64, 0, len(servers))
+	for _, srv := range servers {
+		byID[srv.ID] = &serverStats{name: srv.Name}
+		order = append(order, srv.ID)
+	}
+	var total serverStats
+	for _, sess := range sessions {
+		st, ok := byID[sess.ServerID]
+		if !ok {
+			st = &serverStats{name: sess.ServerName}
+			byID[sess.ServerID] = st
+			order = append(order, sess.ServerID)
+		}
+		transcode := 0
+		if classifyDecision(sess) == models.TranscodeDecisionTranscode {
+			transcode = 1
+		}
+		for _, agg := range []*serverStats{st, &total} {
+			agg.streams++
+			agg.transcodes += transcode
+			agg.bandwidth += sess.Bandwidth
+		}
+	}
+
+	var streams, transcodes, bandwidth []metrics.Sample
+	for _, id := range order {
+		st := byID[id]
+		labels := []string{"server_id", strconv.FormatInt(id, 10), "server", st.name}
+		streams = append(streams, metrics.Sample{Labels: labels, Value: float64(st.streams)})
+		transcodes = append(transcodes, metrics.Sample{Labels: labels, Value: float64(st.transcodes)})
+		bandwidth = append(bandwidth, metrics.Sample{Labels: labels, Value: float64(st.bandwidth)})
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.WriteGauge(w, "streammon_active_streams", "Active streams across all servers.",
+		metrics.Sample{Value: float64(total.streams)})
+	metrics.WriteGauge(w, "streammon_active_transcodes", "Active streams being transcoded across all servers.",
+		metrics.Sample{Value: float64(total.transcodes)})
+	metrics.WriteGauge(w, "streammon_bandwidth_bps", "Bandwidth of active streams across all servers, in bits per second.",
+		metrics.Sample{Value: float64(total.bandwidth)})
+	metrics.WriteGauge(w, "streammon_server_active_streams", "Active streams, by media server.", streams...)
+	metrics.WriteGauge(w, "streammon_server_active_transcodes", "Active streams being transcoded, by media server.", transcodes...)
+	metrics.WriteGauge(w, "streammon_server_bandwidth_bps", "Bandwidth of active streams by media server, in bits per second.", bandwidth...)
+	metrics.Default.Write(w)
+}
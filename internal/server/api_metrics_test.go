@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	_, st := newTestServer(t)
+	authMgr := auth.NewManager(st)
+	srv := NewServer(st, WithAuthManager(authMgr), WithMetrics("scrape-token"))
+
+	plex := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex.local", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(plex); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetPollerForTest(&fakePoller{sessions: []models.ActiveStream{
+		{ServerID: plex.ID, ServerName: "Plex", Bandwidth: 4000, VideoDecision: models.TranscodeDecisionTranscode},
+		{ServerID: plex.ID, ServerName: "Plex", Bandwidth: 1000, VideoDecision: models.TranscodeDecisionDirectPlay},
+	}})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-token")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"streammon_active_streams 2\n",
+		"streammon_active_transcodes 1\n",
+		"streammon_bandwidth_bps 5000\n",
+		fmt.Sprintf(`streammon_server_active_streams{server_id="%d",server="Plex"} 2`, plex.ID),
+		"# TYPE streammon_db_query_duration_seconds histogram",
+		"# TYPE streammon_rule_evaluations_total counter",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsEndpointDisabledByDefault(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "streammon_active_streams") {
+		t.Fatal("metrics served without WithMetrics")
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+func (s *Server) handleListNetworkLabels(w http.ResponseWriter, r *http.Request) {
+	labels, err := s.store.ListNetworkLabels()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list network labels")
+		return
+	}
+	writeJSON(w, http.StatusOK, labels)
+}
+
+func (s *Server) handleCreateNetworkLabel(w http.ResponseWriter, r *http.Request) {
+	var label models.NetworkLabel
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := label.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.CreateNetworkLabel(&label); err != nil {
+		writeNetworkLabelError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, label)
+}
+
+func (s *Server) handleUpdateNetworkLabel(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid network label id")
+		return
+	}
+	var label models.NetworkLabel
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := label.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	label.ID = id
+	if err := s.store.UpdateNetworkLabel(&label); err != nil {
+		writeNetworkLabelError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, label)
+}
+
+func (s *Server) handleDeleteNetworkLabel(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid network label id")
+		return
+	}
+	if err := s.store.DeleteNetworkLabel(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeNetworkLabelError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrNetworkLabelExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeStoreError(w, err)
+}
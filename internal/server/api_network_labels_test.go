@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestNetworkLabelAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/network-labels",
+		strings.NewReader(`{"name":"Home","cidrs":["192.168.1.0/24"]}`))
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.NetworkLabel
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"name":"home","asns":[7922]}`, http.StatusConflict},
+		{`{"name":"Bad","cidrs":["nope"]}`, http.StatusBadRequest},
+		{`{"name":"Empty"}`, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/network-labels", strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.body, tc.want, w.Code)
+		}
+	}
+
+	path := "/api/network-labels/" + strconv.FormatInt(created.ID, 10)
+	req = httptest.NewRequest(http.MethodPut, path, strings.NewReader(`{"name":"House","cidrs":["192.168.0.0/16"]}`))
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	viewer := createViewerSession(t, st, "alice")
+	req = httptest.NewRequest(http.MethodGet, "/api/network-labels", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewer})
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer list: expected 403, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, path, nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+}
+
+func TestHistoryNetworkLabelFilter(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i, label := range []string{"Home", "VPN"} {
+		started := now.Add(-time.Duration(i+1) * 3 * time.Hour)
+		entry := &models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: label + " movie",
+			WatchedMs: 3600000, DurationMs: 3600000, StartedAt: started, StoppedAt: started.Add(time.Hour),
+			NetworkLabel: label,
+		}
+		if err := st.InsertHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/history?network_label=VPN", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var history models.PaginatedResult[models.WatchHistoryEntry]
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if history.Total != 1 || history.Items[0].NetworkLabel != "VPN" {
+		t.Fatalf("expected only the VPN session, got %+v", history.Items)
+	}
+}
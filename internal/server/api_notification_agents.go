@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"streammon/internal/models"
+)
+
+// notificationAgent renders a channel as an agent: secrets masked, the event
+// matrix spelled out for every event, and the linked rule IDs.
+func notificationAgent(ch models.NotificationChannel, ruleIDs []int64) models.NotificationAgent {
+	ch = maskChannel(ch)
+	ch.Events = ch.Events.Complete()
+	if ruleIDs == nil {
+		ruleIDs = []int64{}
+	}
+	return models.NotificationAgent{NotificationChannel: ch, RuleIDs: ruleIDs}
+}
+
+// validateAgentRules deduplicates ruleIDs and checks every rule exists,
+// writing a 400 response and returning false otherwise.
+func (s *Server) validateAgentRules(w http.ResponseWriter, ruleIDs []int64) ([]int64, bool) {
+	ids := slices.Clone(ruleIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	for _, id := range ids {
+		if _, err := s.store.GetRule(id); err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("rule %d not found", id))
+			} else {
+				writeError(w, http.StatusInternalServerError, "failed to check rules")
+			}
+			return nil, false
+		}
+	}
+	return ids, true
+}
+
+func (s *Server) handleListNotificationAgents(w http.ResponseWriter, r *http.Request) {
+	channels, err := s.store.ListNotificationChannels()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list channels")
+		return
+	}
+	links, err := s.store.ListChannelRuleIDs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list channel rules")
+		return
+	}
+
+	agents := make([]models.NotificationAgent, len(channels))
+	for i, ch := range channels {
+		agents[i] = notificationAgent(ch, links[ch.ID])
+	}
+	writeJSON(w, http.StatusOK, agents)
+}
+
+func (s *Server) handleGetNotificationAgent(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid agent id")
+		return
+	}
+
+	channel, err := s.store.GetNotificationChannel(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	links, err := s.store.ListChannelRuleIDs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list channel rules")
+		return
+	}
+	writeJSON(w, http.StatusOK, notificationAgent(*channel, links[id]))
+}
+
+func (s *Server) handleCreateNotificationAgent(w http.ResponseWriter, r *http.Request) {
+	var agent models.NotificationAgent
+	if err := json.NewDecoder(r.Body).Decode(&agent); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if err := agent.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ruleIDs, ok := s.validateAgentRules(w, agent.RuleIDs)
+	if !ok {
+		return
+	}
+
+	channel := agent.NotificationChannel
+	if err := s.store.CreateNotificationChannel(&channel); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create agent")
+		return
+	}
+	if err := s.store.SetChannelRules(channel.ID, ruleIDs); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to link rules")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, notificationAgent(channel, ruleIDs))
+}
+
+// handleUpdateNotificationAgent replaces an agent. Omitting events or
+// rule_ids leaves the stored values unchanged.
+func (s *Server) handleUpdateNotificationAgent(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid agent id")
+		return
+	}
+
+	var agent models.NotificationAgent
+	if err := json.NewDecoder(r.Body).Decode(&agent); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	agent.ID = id
+	if err := agent.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := s.store.GetNotificationChannel(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var ruleIDs []int64
+	if agent.RuleIDs != nil {
+		if ruleIDs, ok = s.validateAgentRules(w, agent.RuleIDs); !ok {
+			return
+		}
+	}
+
+	channel := agent.NotificationChannel
+	channel.Config = restoreChannelSecrets(channel.ChannelType, channel.Config, existing.Config)
+	if err := s.store.UpdateNotificationChannel(&channel); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if agent.RuleIDs != nil {
+		if err := s.store.SetChannelRules(id, ruleIDs); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to link rules")
+			return
+		}
+	}
+
+	updated, err := s.store.GetNotificationChannel(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	links, err := s.store.ListChannelRuleIDs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list channel rules")
+		return
+	}
+	writeJSON(w, http.StatusOK, notificationAgent(*updated, links[id]))
+}
+
+func (s *Server) handleDeleteNotificationAgent(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid agent id")
+		return
+	}
+
+	if _, err := s.store.GetNotificationChannel(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := s.store.DeleteNotificationChannel(id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete agent")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestNotificationAgentsCRUD(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	rule := &models.Rule{Name: "Concurrent", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{"max_streams":2}`)}
+	if err := st.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf(`{"name":"Pushover","channel_type":"pushover","enabled":true,
+		"config":{"user_key":"userkey","api_token":"agentsecret"},
+		"events":{"rule_violation":{"enabled":true,"users":["alice"],"server_ids":[1]}},
+		"rule_ids":[%d,%d]}`, rule.ID, rule.ID)
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications/agents", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "agentsecret") {
+		t.Fatalf("response leaked secret: %s", w.Body.String())
+	}
+	var created models.NotificationAgent
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if len(created.RuleIDs) != 1 || created.RuleIDs[0] != rule.ID {
+		t.Fatalf("rule_ids = %v, want [%d]", created.RuleIDs, rule.ID)
+	}
+	if f, ok := created.Events[models.NotificationEventConcurrentRecord]; !ok || !f.Enabled {
+		t.Fatalf("events = %+v, want complete matrix", created.Events)
+	}
+
+	// Update without rule_ids or events keeps both, and the masked secret
+	// round-trips to the stored value.
+	body = `{"name":"Pushover 2","channel_type":"pushover","enabled":true,"config":{"user_key":"userkey","api_token":"********"}}`
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/notifications/agents/%d", created.ID), strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated models.NotificationAgent
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Name != "Pushover 2" || len(updated.RuleIDs) != 1 {
+		t.Fatalf("updated = %+v", updated)
+	}
+	if users := updated.Events[models.NotificationEventRuleViolation].Users; len(users) != 1 || users[0] != "alice" {
+		t.Fatalf("events not preserved: %+v", updated.Events)
+	}
+	stored, _ := st.GetNotificationChannel(created.ID)
+	var cfg models.PushoverConfig
+	json.Unmarshal(stored.Config, &cfg)
+	if cfg.APIToken != "agentsecret" {
+		t.Fatalf("api_token = %q, want preserved secret", cfg.APIToken)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/notifications/agents/%d", created.ID),
+		strings.NewReader(`{"name":"P","channel_type":"pushover","enabled":true,"config":{"user_key":"u","api_token":"t"},"rule_ids":[]}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("clear rules: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if len(updated.RuleIDs) != 0 {
+		t.Fatalf("rule_ids = %v, want none", updated.RuleIDs)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/notifications/agents", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	var agents []models.NotificationAgent
+	json.Unmarshal(w.Body.Bytes(), &agents)
+	if w.Code != http.StatusOK || len(agents) != 1 {
+		t.Fatalf("list: got %d with %d agents", w.Code, len(agents))
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/notifications/agents/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/notifications/agents/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: expected 404, got %d", w.Code)
+	}
+}
+
+func TestCreateNotificationAgent_Rejects(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+
+	for name, body := range map[string]string{
+		"unknown rule":  `{"name":"D","channel_type":"discord","enabled":true,"config":{"webhook_url":"https://discord.com/api/webhooks/1/a"},"rule_ids":[999]}`,
+		"unknown event": `{"name":"D","channel_type":"discord","enabled":true,"config":{"webhook_url":"https://discord.com/api/webhooks/1/a"},"events":{"bogus":{"enabled":true}}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/notifications/agents", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"streammon/internal/models"
+	"streammon/internal/notifier"
+	"streammon/internal/store"
+)
+
+// GET /api/notifications/history
+func (s *Server) handleListNotificationHistory(w http.ResponseWriter, r *http.Request) {
+	page, perPage := parsePagination(r, 50, 100)
+	q := r.URL.Query()
+
+	var filters store.NotificationHistoryFilters
+	filters.UserName = q.Get("user")
+	for param, dst := range map[string]*int64{"channel_id": &filters.ChannelID, "rule_id": &filters.RuleID} {
+		if v := q.Get(param); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid "+param+" value")
+				return
+			}
+			*dst = id
+		}
+	}
+	if status := q.Get("status"); status != "" {
+		switch st := models.NotificationStatus(status); st {
+		case models.NotificationSent, models.NotificationFailed, models.NotificationSuppressed:
+			filters.Status = st
+		default:
+			writeError(w, http.StatusBadRequest, "invalid status value")
+			return
+		}
+	}
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid since timestamp format")
+			return
+		}
+		filters.Since = t
+	}
+
+	result, err := s.store.ListNotificationHistory(r.Context(), page, perPage, filters)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GET /api/notifications/history/{id}
+func (s *Server) handleGetNotificationRecord(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	rec, err := s.store.GetNotificationRecord(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// POST /api/notifications/history/{id}/retry
+//
+// Sends a recorded notification again through its channel as the channel
+// is configured now. The attempt is recorded as a new history entry, which
+// is returned whether or not the send succeeded.
+func (s *Server) handleRetryNotification(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	rec, err := s.store.GetNotificationRecord(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	channel, err := s.store.GetNotificationChannel(rec.ChannelID)
+	if errors.Is(err, models.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "notification channel no longer exists")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	n := notifier.New(
+		notifier.WithPosterFetcher(NewPosterFetcher(s.store, s.posterCache)),
+		notifier.WithHistory(s.store),
+	)
+	retry, err := n.Redeliver(r.Context(), rec, *channel)
+	if errors.Is(err, notifier.ErrNoPayload) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("redelivering notification %d: %v", rec.ID, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, retry)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestNotificationHistoryAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ctx := context.Background()
+
+	ch := &models.NotificationChannel{Name: "Hook", ChannelType: models.ChannelTypeWebhook, Enabled: true,
+		Config: json.RawMessage(`{"url":"http://127.0.0.1:1/hook"}`)}
+	if err := st.CreateNotificationChannel(ch); err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(models.NewNotificationPayload(&models.RuleViolation{
+		RuleID: 1, RuleName: "Rule", UserName: "alice", Severity: models.SeverityWarning, Message: "m"}))
+	failed := &models.NotificationRecord{ChannelID: ch.ID, ChannelName: ch.Name, ChannelType: ch.ChannelType,
+		Event: models.NotificationEventRuleViolation, UserName: "alice", Status: models.NotificationFailed,
+		Error: "server returned status 502", Payload: payload}
+	bare := &models.NotificationRecord{ChannelID: ch.ID, ChannelType: ch.ChannelType,
+		Event: models.NotificationEventRuleViolation, Status: models.NotificationSent}
+	gone := &models.NotificationRecord{ChannelID: 999, ChannelType: models.ChannelTypeDiscord,
+		Event: models.NotificationEventRuleViolation, Status: models.NotificationFailed, Payload: payload}
+	for _, rec := range []*models.NotificationRecord{failed, bare, gone} {
+		if err := st.RecordNotification(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(http.MethodGet, fmt.Sprintf("/api/notifications/history?status=failed&channel_id=%d", ch.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+	var list models.PaginatedResult[models.NotificationRecord]
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 || list.Items[0].ID != failed.ID {
+		t.Errorf("list = %+v", list)
+	}
+	for _, q := range []string{"status=bogus", "since=yesterday", "rule_id=x"} {
+		if w := do(http.MethodGet, "/api/notifications/history?"+q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d", q, w.Code)
+		}
+	}
+
+	w = do(http.MethodGet, fmt.Sprintf("/api/notifications/history/%d", failed.ID))
+	var got models.NotificationRecord
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Payload) == 0 || got.Error != failed.Error {
+		t.Errorf("get = %+v", got)
+	}
+
+	// The safe client refuses loopback addresses, so the retry fails, but
+	// it is still recorded against the original.
+	w = do(http.MethodPost, fmt.Sprintf("/api/notifications/history/%d/retry", failed.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("retry: %d %s", w.Code, w.Body.String())
+	}
+	var retry models.NotificationRecord
+	if err := json.NewDecoder(w.Body).Decode(&retry); err != nil {
+		t.Fatal(err)
+	}
+	if retry.ID == 0 || retry.RetryOf != failed.ID || retry.Status != models.NotificationFailed || retry.UserName != "alice" {
+		t.Errorf("retry = %+v", retry)
+	}
+	stored, err := st.GetNotificationRecord(ctx, retry.ID)
+	if err != nil || stored.RetryOf != failed.ID {
+		t.Errorf("stored retry = %+v, %v", stored, err)
+	}
+
+	for name, tc := range map[string]struct {
+		id   int64
+		want int
+	}{
+		"no payload":   {bare.ID, http.StatusConflict},
+		"channel gone": {gone.ID, http.StatusNotFound},
+		"no record":    {9999, http.StatusNotFound},
+	} {
+		if w := do(http.MethodPost, fmt.Sprintf("/api/notifications/history/%d/retry", tc.id)); w.Code != tc.want {
+			t.Errorf("%s: %d, want %d", name, w.Code, tc.want)
+		}
+	}
+}
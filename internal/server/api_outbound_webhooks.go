@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+// GET /api/outbound-webhooks
+func (s *Server) handleListOutboundWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.store.ListOutboundWebhooks()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, hooks)
+}
+
+// POST /api/outbound-webhooks
+//
+// The response carries the generated signing secret; it isn't shown again.
+func (s *Server) handleCreateOutboundWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook models.OutboundWebhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := hook.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.CreateOutboundWebhook(&hook); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+// GET /api/outbound-webhooks/{id}
+func (s *Server) handleGetOutboundWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	hook, err := s.store.GetOutboundWebhook(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hook)
+}
+
+// PUT /api/outbound-webhooks/{id}
+func (s *Server) handleUpdateOutboundWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	var hook models.OutboundWebhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := hook.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	hook.ID = id
+	if err := s.store.UpdateOutboundWebhook(&hook); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	updated, err := s.store.GetOutboundWebhook(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DELETE /api/outbound-webhooks/{id}
+func (s *Server) handleDeleteOutboundWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	if err := s.store.DeleteOutboundWebhook(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/outbound-webhooks/{id}/secret/rotate
+func (s *Server) handleRotateOutboundWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	secret, err := s.store.RotateOutboundWebhookSecret(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, serverWebhookSecretResponse{Secret: secret})
+}
+
+// POST /api/outbound-webhooks/{id}/test
+//
+// Sends a signed webhook.test delivery now, even if the webhook is disabled.
+func (s *Server) handleTestOutboundWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	hook, err := s.store.GetOutboundWebhookWithSecret(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	data := map[string]string{"message": "Test delivery from StreamMon"}
+	if err := s.outboundWebhooks.Send(r.Context(), hook, models.WebhookEventTest, data); err != nil {
+		log.Printf("test webhook %s failed: %v", hook.Name, err)
+		writeError(w, http.StatusBadRequest, sanitizeConnError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
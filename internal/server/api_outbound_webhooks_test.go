@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+	"streammon/internal/webhooks"
+)
+
+func TestOutboundWebhooksAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	var received http.Header
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+	// The default client refuses loopback addresses.
+	ts.outboundWebhooks = webhooks.New(st, webhooks.WithClient(receiver.Client()))
+	url := strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)
+
+	do := func(method, path, reqBody string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(reqBody)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/outbound-webhooks", fmt.Sprintf(`{"name":"Automation","url":%q,"events":["session.started"],"enabled":true}`, url))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var created models.OutboundWebhook
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Secret == "" {
+		t.Fatal("create didn't return the secret")
+	}
+	if w := do(http.MethodPost, "/api/outbound-webhooks", `{"name":"Bad","url":"https://example.com","events":["bogus"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown event: %d", w.Code)
+	}
+
+	w = do(http.MethodGet, fmt.Sprintf("/api/outbound-webhooks/%d", created.ID), "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Secret) {
+		t.Errorf("get: %d %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodPost, fmt.Sprintf("/api/outbound-webhooks/%d/test", created.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("test: %d %s", w.Code, w.Body.String())
+	}
+	if received.Get(webhooks.EventHeader) != string(models.WebhookEventTest) {
+		t.Errorf("event header = %q", received.Get(webhooks.EventHeader))
+	}
+	want := webhooks.Sign(created.Secret, received.Get(webhooks.TimestampHeader), received.Get(webhooks.NonceHeader), body)
+	if received.Get(webhooks.SignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", received.Get(webhooks.SignatureHeader), want)
+	}
+
+	w = do(http.MethodPost, fmt.Sprintf("/api/outbound-webhooks/%d/secret/rotate", created.ID), "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("rotate: %d", w.Code)
+	}
+	var rotated serverWebhookSecretResponse
+	if err := json.NewDecoder(w.Body).Decode(&rotated); err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Secret == "" || rotated.Secret == created.Secret {
+		t.Errorf("rotated secret = %q", rotated.Secret)
+	}
+
+	w = do(http.MethodPut, fmt.Sprintf("/api/outbound-webhooks/%d", created.ID), fmt.Sprintf(`{"name":"Renamed","url":%q,"events":["rule.triggered"],"enabled":false}`, url))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	var updated models.OutboundWebhook
+	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Renamed" || updated.Enabled || updated.LastStatus != http.StatusOK {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/outbound-webhooks/%d", created.ID), ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d", w.Code)
+	}
+	if w := do(http.MethodPost, fmt.Sprintf("/api/outbound-webhooks/%d/test", created.ID), ""); w.Code != http.StatusNotFound {
+		t.Errorf("test after delete: %d", w.Code)
+	}
+}
@@ -83,7 +83,14 @@ func (s *Server) newOverseerrClient() (*overseerr.Client, error) {
 	if !cfg.IsUsable() {
 		return nil, errors.New("overseerr/seerr not configured")
 	}
-	return overseerr.NewClient(cfg.URL, cfg.APIKey)
+	client, err := overseerr.NewClient(cfg.URL, cfg.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Configure(cfg.HTTP); err != nil {
+		return nil, err
+	}
+	return client, nil
 }
 
 func (s *Server) overseerrClientWithTimeout(w http.ResponseWriter, r *http.Request) (*overseerr.Client, context.Context, context.CancelFunc, bool) {
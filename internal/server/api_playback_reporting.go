@@ -1,32 +1,84 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"streammon/internal/httputil"
 	"streammon/internal/models"
 )
 
+// parsePlaybackReportingExport parses an export from the Playback Reporting
+// plugin: either its native tab-separated backup or the same columns saved as
+// CSV from a spreadsheet. The format is picked from the first non-blank line.
+// rows is the number of records read, so callers can tell how many of them
+// were rejected.
+func parsePlaybackReportingExport(data []byte, userMap map[string]string, serverID int64) (entries []*models.WatchHistoryEntry, rows int) {
+	var records [][]string
+	if looksLikePlaybackReportingCSV(data) {
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Printf("WARN playback-reporting: skipping malformed CSV record: %v", err)
+				rows++
+				continue
+			}
+			records = append(records, rec)
+		}
+	} else {
+		records = splitPlaybackReportingTSV(data)
+	}
+	return parsePlaybackReportingRecords(records, userMap, serverID), rows + len(records)
+}
+
+func looksLikePlaybackReportingCSV(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		return !strings.Contains(line, "\t") && strings.Contains(line, ",")
+	}
+	return false
+}
+
 func parsePlaybackReportingTSV(data []byte, userMap map[string]string, serverID int64) []*models.WatchHistoryEntry {
-	lines := strings.Split(string(data), "\n")
-	var entries []*models.WatchHistoryEntry
+	return parsePlaybackReportingRecords(splitPlaybackReportingTSV(data), userMap, serverID)
+}
 
-	for _, line := range lines {
+func splitPlaybackReportingTSV(data []byte) [][]string {
+	var records [][]string
+	for _, line := range strings.Split(string(data), "\n") {
 		line = strings.TrimRight(line, "\r")
 		if line == "" {
 			continue
 		}
+		records = append(records, strings.Split(line, "\t"))
+	}
+	return records
+}
 
-		fields := strings.Split(line, "\t")
+func parsePlaybackReportingRecords(records [][]string, userMap map[string]string, serverID int64) []*models.WatchHistoryEntry {
+	var entries []*models.WatchHistoryEntry
+
+	for _, fields := range records {
 		nf := len(fields)
 		if nf != 9 && nf != 12 {
 			log.Printf("WARN playback-reporting: skipping line with %d fields", nf)
@@ -169,15 +221,11 @@ func fetchPlaybackReportingUsers(ctx context.Context, serverURL, apiKey string)
 	return m, nil
 }
 
+// handlePlaybackReportingImport imports an uploaded Playback Reporting
+// export as a background job, streaming its progress on the request. With
+// dry_run it only reports what the import would do.
 func (s *Server) handlePlaybackReportingImport() http.HandlerFunc {
-	var mu sync.Mutex
 	return func(w http.ResponseWriter, r *http.Request) {
-		if !mu.TryLock() {
-			writeError(w, http.StatusConflict, "playback reporting import already in progress")
-			return
-		}
-		defer mu.Unlock()
-
 		const maxUpload = 50 << 20     // 50 MiB file cap
 		const multipartSlack = 1 << 20 // 1 MiB headroom for form fields and multipart envelope
 		r.Body = http.MaxBytesReader(w, r.Body, maxUpload+multipartSlack)
@@ -186,18 +234,17 @@ func (s *Server) handlePlaybackReportingImport() http.HandlerFunc {
 			return
 		}
 
-		serverIDStr := r.FormValue("server_id")
-		serverID, err := strconv.ParseInt(serverIDStr, 10, 64)
-		if err != nil || serverID <= 0 {
-			writeError(w, http.StatusBadRequest, "server_id is required")
-			return
-		}
-
-		srv, err := s.store.GetServer(serverID)
+		srv, err := s.resolvePlaybackReportingServer(r.FormValue("server_id"), r.FormValue("machine_id"))
 		if err != nil {
+			var ie *importServerError
+			if errors.As(err, &ie) {
+				writeError(w, http.StatusBadRequest, ie.message)
+				return
+			}
 			writeStoreError(w, err)
 			return
 		}
+		serverID := srv.ID
 		if srv.DeletedAt != nil {
 			writeError(w, http.StatusBadRequest, "server has been deleted")
 			return
@@ -234,34 +281,131 @@ func (s *Server) handlePlaybackReportingImport() http.HandlerFunc {
 			return
 		}
 
-		entries := parsePlaybackReportingTSV(data, userMap, serverID)
+		entries, rows := parsePlaybackReportingExport(data, userMap, serverID)
+
+		if r.FormValue("dry_run") == "true" {
+			s.previewPlaybackReportingImport(ctx, w, serverID, entries, rows)
+			return
+		}
 
 		if len(entries) == 0 {
-			writeError(w, http.StatusBadRequest, "no valid records found in TSV file (check user IDs and format)")
+			writeError(w, http.StatusBadRequest, "no valid records found in file (check user IDs and format)")
 			return
 		}
 
-		flusher, ok := sseFlusher(w)
-		if !ok {
-			writeError(w, http.StatusInternalServerError, "streaming not supported")
+		path, err := s.spoolImportUpload("playback-reporting-*.tsv", func(f *os.File) error {
+			_, err := f.Write(data)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR playback-reporting import: spool upload: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
 			return
 		}
 
-		tracker := newImportTracker("playback-reporting", serverID, w, flusher)
+		job := &models.ImportJob{Source: models.ImportSourcePlaybackReporting, ServerID: serverID, FilePath: path}
+		s.startImportJobStream(w, r, job, playbackReportingRun(entries, rows))
+		if job.ID == 0 {
+			os.Remove(path)
+		}
+	}
+}
+
+// playbackReportingRun imports entries already parsed from an export of
+// rows records.
+func playbackReportingRun(entries []*models.WatchHistoryEntry, rows int) importJobRun {
+	return func(ctx context.Context, sink importSink) error {
+		sink.setErrors(rows - len(entries))
 		total := len(entries)
 		const batchSize = 1000
-
 		for i := 0; i < total; i += batchSize {
-			end := i + batchSize
-			if end > total {
-				end = total
-			}
-			if err := tracker.insertBatch(ctx, s.store, entries[i:end], total); err != nil {
-				tracker.fail(err)
-				return
+			end := min(i+batchSize, total)
+			if err := sink.batch(entries[i:end], total); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
+}
+
+// playbackReportingFileRun re-reads a spooled export for a resumed job. User
+// IDs are mapped again since the export only carries the server's IDs.
+func playbackReportingFileRun(srv *models.Server, path string) importJobRun {
+	return func(ctx context.Context, sink importSink) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		userMap, err := fetchPlaybackReportingUsers(ctx, srv.URL, srv.APIKey)
+		if err != nil {
+			return fmt.Errorf("fetch users: %w", err)
+		}
+		entries, rows := parsePlaybackReportingExport(data, userMap, srv.ID)
+		return playbackReportingRun(entries, rows)(ctx, sink)
+	}
+}
+
+type importServerError struct{ message string }
+
+func (e *importServerError) Error() string { return e.message }
+
+// resolvePlaybackReportingServer picks the target server by ID or by machine
+// ID, which is stable across URL changes and matches what the plugin's host
+// reports. When both are given they must agree.
+func (s *Server) resolvePlaybackReportingServer(serverIDStr, machineID string) (*models.Server, error) {
+	machineID = strings.TrimSpace(machineID)
+	if serverIDStr == "" && machineID == "" {
+		return nil, &importServerError{"server_id or machine_id is required"}
+	}
+
+	var srv *models.Server
+	if serverIDStr != "" {
+		serverID, err := strconv.ParseInt(serverIDStr, 10, 64)
+		if err != nil || serverID <= 0 {
+			return nil, &importServerError{"server_id is required"}
+		}
+		if srv, err = s.store.GetServer(serverID); err != nil {
+			return nil, err
+		}
+		if machineID != "" && !strings.EqualFold(srv.MachineID, machineID) {
+			return nil, &importServerError{"machine_id does not match server_id"}
+		}
+		return srv, nil
+	}
+
+	srv, err := s.store.GetServerByMachineID(machineID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, &importServerError{"no server with that machine_id"}
+	}
+	return srv, err
+}
+
+type playbackReportingPreview struct {
+	DryRun           bool  `json:"dry_run"`
+	ServerID         int64 `json:"server_id"`
+	Rows             int   `json:"rows"`
+	Invalid          int   `json:"invalid"`
+	WouldInsert      int   `json:"would_insert"`
+	WouldSkip        int   `json:"would_skip"`
+	WouldConsolidate int   `json:"would_consolidate"`
+}
 
-		tracker.complete()
+// previewPlaybackReportingImport reports how the parsed rows would land
+// without writing anything.
+func (s *Server) previewPlaybackReportingImport(ctx context.Context, w http.ResponseWriter, serverID int64, entries []*models.WatchHistoryEntry, rows int) {
+	inserted, skipped, consolidated, err := s.store.PreviewHistoryBatch(ctx, entries)
+	if err != nil {
+		log.Printf("ERROR playback-reporting import: preview: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
 	}
+	writeJSON(w, http.StatusOK, playbackReportingPreview{
+		DryRun:           true,
+		ServerID:         serverID,
+		Rows:             rows,
+		Invalid:          rows - len(entries),
+		WouldInsert:      inserted,
+		WouldSkip:        skipped,
+		WouldConsolidate: consolidated,
+	})
 }
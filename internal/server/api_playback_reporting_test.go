@@ -2,10 +2,12 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -333,3 +335,126 @@ func TestPlaybackReportingImport_RejectsOversizedUpload(t *testing.T) {
 		t.Errorf("oversized upload: status = %d, want 413", w.Code)
 	}
 }
+
+func TestParsePlaybackReportingExport_CSV(t *testing.T) {
+	userMap := map[string]string{"user1": "Alice"}
+	input := []byte(
+		"2024-03-15T20:30:00.0000000Z,user1,item123,Movie,\"Crouching Tiger, Hidden Dragon\",DirectPlay,VLC,Desktop,7200,120,192.168.1.100,\n" +
+			"not,enough,fields\n",
+	)
+
+	entries, rows := parsePlaybackReportingExport(input, userMap, 1)
+	if rows != 2 {
+		t.Errorf("rows = %d, want 2", rows)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Title != "Crouching Tiger, Hidden Dragon" || e.UserName != "Alice" {
+		t.Errorf("unexpected entry: title=%q user=%q", e.Title, e.UserName)
+	}
+	if e.PausedMs != 120*1000 || e.IPAddress != "192.168.1.100" {
+		t.Errorf("paused=%d ip=%q", e.PausedMs, e.IPAddress)
+	}
+}
+
+func TestParsePlaybackReportingExport_TSVWithCommas(t *testing.T) {
+	userMap := map[string]string{"u": "Alice"}
+	input := []byte("2024-06-01 14:00:00\tu\ti\tMovie\tOne, Two\tDirectPlay\tmpv\tLinux\t60\n")
+
+	entries, rows := parsePlaybackReportingExport(input, userMap, 1)
+	if rows != 1 || len(entries) != 1 || entries[0].Title != "One, Two" {
+		t.Fatalf("rows=%d entries=%+v", rows, entries)
+	}
+}
+
+func mockPlaybackReportingUsers(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Users" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[{"Id":"user1","Name":"Alice"}]`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postPlaybackReporting(t *testing.T, srv *testServer, fields map[string]string, data string) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	fw, _ := mw.CreateFormFile("file", "backup.tsv")
+	fw.Write([]byte(data))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/playback-reporting/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func TestPlaybackReportingImport_DryRunByMachineID(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	users := mockPlaybackReportingUsers(t)
+	emby := &models.Server{Name: "Emby", Type: models.ServerTypeEmby, URL: users.URL, APIKey: "k", MachineID: "emby-machine", Enabled: true}
+	if err := st.CreateServer(emby); err != nil {
+		t.Fatal(err)
+	}
+
+	tsv := "2024-03-15T20:30:00.0000000Z\tuser1\titem1\tMovie\tInception\tDirectPlay\tVLC\tDesktop\t7200\n" +
+		"2024-03-16T20:30:00.0000000Z\tuser1\titem2\tMovie\tTenet\tDirectPlay\tVLC\tDesktop\t7200\n" +
+		"2024-03-17T20:30:00.0000000Z\tghost\titem3\tMovie\tUnknown\tDirectPlay\tVLC\tDesktop\t7200\n"
+
+	w := postPlaybackReporting(t, srv, map[string]string{"machine_id": "EMBY-MACHINE", "dry_run": "true"}, tsv)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var preview playbackReportingPreview
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatal(err)
+	}
+	want := playbackReportingPreview{DryRun: true, ServerID: emby.ID, Rows: 3, Invalid: 1, WouldInsert: 2}
+	if preview != want {
+		t.Errorf("preview = %+v, want %+v", preview, want)
+	}
+
+	result, err := st.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 0 {
+		t.Fatalf("dry run wrote %d history rows", result.Total)
+	}
+}
+
+func TestPlaybackReportingImport_ServerResolution(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	emby := &models.Server{Name: "Emby", Type: models.ServerTypeEmby, URL: "http://emby", APIKey: "k", MachineID: "emby-machine", Enabled: true}
+	if err := st.CreateServer(emby); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"neither given", map[string]string{}},
+		{"unknown machine id", map[string]string{"machine_id": "nope"}},
+		{"mismatched ids", map[string]string{"server_id": strconv.FormatInt(emby.ID, 10), "machine_id": "other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postPlaybackReporting(t, srv, tt.fields, "x\n")
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	prefs, err := s.store.GetUserPreferences(user.ID)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		log.Printf("getting preferences for user %d: %v", user.ID, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+func (s *Server) handleUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var prefs models.UserPreferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := prefs.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetUserPreferences(user.ID, prefs); err != nil {
+		if !errors.Is(err, models.ErrNotFound) {
+			log.Printf("setting preferences for user %d: %v", user.ID, err)
+		}
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// withPreferenceDefaults fills server_ids, days, and tz_offset from the
+// caller's preferences where the request leaves them out, so stats and
+// history defaults match across clients. days is only filled when no
+// explicit date range was given either. Failing to read preferences just
+// leaves the request as it was.
+func (s *Server) withPreferenceDefaults(r *http.Request) *http.Request {
+	user := UserFromContext(r.Context())
+	if user == nil || user.ID == 0 {
+		return r
+	}
+	prefs, err := s.store.GetUserPreferences(user.ID)
+	if err != nil {
+		return r
+	}
+
+	q := r.URL.Query()
+	changed := false
+	if !q.Has("server_ids") && len(prefs.ServerIDs) > 0 {
+		ids := make([]string, len(prefs.ServerIDs))
+		for i, id := range prefs.ServerIDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		q.Set("server_ids", strings.Join(ids, ","))
+		changed = true
+	}
+	if prefs.Days != nil && !q.Has("days") && !q.Has("start_date") && !q.Has("end_date") {
+		q.Set("days", strconv.Itoa(*prefs.Days))
+		changed = true
+	}
+	if prefs.Timezone != "" && !q.Has("tz_offset") {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			_, offset := time.Now().In(loc).Zone()
+			q.Set("tz_offset", strconv.Itoa(offset/60))
+			changed = true
+		}
+	}
+	if !changed {
+		return r
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.RawQuery = q.Encode()
+	return r2
+}
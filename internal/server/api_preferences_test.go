@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestPreferences_RoundTrip(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	user, err := st.CreateLocalUser("alice", "alice@example.com", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/preferences", nil)
+	req = req.WithContext(contextWithUser(req.Context(), user))
+	w := httptest.NewRecorder()
+	srv.handleGetPreferences(w, req)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"server_ids":[],"days":null,"timezone":""}` {
+		t.Fatalf("default preferences: %d %s", w.Code, w.Body.String())
+	}
+
+	body := `{"server_ids":[2,1],"days":30,"timezone":"Europe/Oslo"}`
+	req = httptest.NewRequest(http.MethodPut, "/api/me/preferences", strings.NewReader(body))
+	req = req.WithContext(contextWithUser(req.Context(), user))
+	w = httptest.NewRecorder()
+	srv.handleUpdatePreferences(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+
+	prefs, err := st.GetUserPreferences(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefs.ServerIDs) != 2 || prefs.Days == nil || *prefs.Days != 30 || prefs.Timezone != "Europe/Oslo" {
+		t.Errorf("stored preferences = %+v", prefs)
+	}
+
+	for _, bad := range []string{
+		`{"timezone":"Mars/Olympus"}`,
+		`{"days":-1}`,
+		`{"server_ids":[1,1]}`,
+		`{"server_ids":[0]}`,
+	} {
+		req = httptest.NewRequest(http.MethodPut, "/api/me/preferences", strings.NewReader(bad))
+		req = req.WithContext(contextWithUser(req.Context(), user))
+		w = httptest.NewRecorder()
+		srv.handleUpdatePreferences(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, w.Code)
+		}
+	}
+}
+
+func TestWithPreferenceDefaults(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	user, err := st.CreateLocalUser("alice", "alice@example.com", "", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := 7
+	if err := st.SetUserPreferences(user.ID, models.UserPreferences{ServerIDs: []int64{3, 4}, Days: &days, Timezone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  map[string]string
+	}{
+		{"", map[string]string{"server_ids": "3,4", "days": "7", "tz_offset": "0"}},
+		{"?server_ids=1&days=30&tz_offset=60", map[string]string{"server_ids": "1", "days": "30", "tz_offset": "60"}},
+		{"?start_date=2024-01-01&end_date=2024-01-31", map[string]string{"server_ids": "3,4", "days": ""}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats"+tt.query, nil)
+		req = req.WithContext(contextWithUser(req.Context(), user))
+		q := srv.withPreferenceDefaults(req).URL.Query()
+		for k, v := range tt.want {
+			if got := q.Get(k); got != v {
+				t.Errorf("%q: %s = %q, want %q", tt.query, k, got, v)
+			}
+		}
+	}
+}
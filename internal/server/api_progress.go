@@ -0,0 +1,65 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultStaleProgressDays = 30
+	maxContinueWatching      = 200
+)
+
+// handleGetContinueWatching lists a user's unfinished items, most recently
+// played first. ?limit= caps the list (default and maximum 200).
+func (s *Server) handleGetContinueWatching(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !s.requireGuestVisibility(w, r, name, "visible_watch_history") {
+		return
+	}
+
+	limit := maxContinueWatching
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = min(parsed, maxContinueWatching)
+	}
+
+	items, err := s.store.ListContinueWatching(r.Context(), name, limit, workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("continue watching for %s: %v", name, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// handleGetStaleInProgress reports per-user counts of items started more
+// than ?days= (default 30) days ago and never finished.
+func (s *Server) handleGetStaleInProgress(w http.ResponseWriter, r *http.Request) {
+	days := defaultStaleProgressDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "days must be a positive number")
+			return
+		}
+		days = parsed
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -days)
+	users, err := s.store.StaleInProgressByUser(r.Context(), cutoff, workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("stale in-progress stats: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"days": days, "users": users})
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestContinueWatchingAndStaleProgressAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	st.CreateServer(&models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k"})
+
+	now := time.Now().UTC()
+	for _, e := range []models.WatchHistoryEntry{
+		{ItemID: "1", Title: "Old", StartedAt: now.AddDate(0, 0, -45)},
+		{ItemID: "2", Title: "Recent", StartedAt: now.AddDate(0, 0, -2)},
+	} {
+		e.ServerID, e.UserName, e.MediaType = 1, "alice", models.MediaTypeMovie
+		e.DurationMs, e.WatchedMs = 7_200_000, 1_800_000
+		e.StoppedAt = e.StartedAt.Add(30 * time.Minute)
+		if err := st.InsertHistory(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/alice/continue-watching?limit=1", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var items []models.PlaybackProgress
+	json.NewDecoder(w.Body).Decode(&items)
+	if len(items) != 1 || items[0].Title != "Recent" {
+		t.Fatalf("items = %+v, want only Recent", items)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stats/stale-in-progress", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Days  int                           `json:"days"`
+		Users []models.StaleProgressSummary `json:"users"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Days != 30 || len(resp.Users) != 1 || resp.Users[0].Count != 1 {
+		t.Fatalf("resp = %+v, want alice with 1 stale item", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stats/stale-in-progress?days=0", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for days=0, got %d", w.Code)
+	}
+}
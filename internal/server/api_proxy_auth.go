@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleGetProxyAuthSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetProxyAuthSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// handleUpdateProxyAuthSettings saves trusted header authentication
+// settings and applies them to the auth middleware at once.
+func (s *Server) handleUpdateProxyAuthSettings(w http.ResponseWriter, r *http.Request) {
+	var req models.ProxyAuthSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetProxyAuthSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if err := s.applyProxyAuthSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestProxyAuth(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := ts.Unwrap()
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/proxy-auth",
+		strings.NewReader(`{"enabled":true,"user_header":"x-authentik-username","trusted_proxies":["192.0.2.0/24"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := st.CreateLocalUser("alice", "alice@test.local", "", models.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	me := func(remoteAddr, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Authentik-Username", user)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w = me("192.0.2.1:1234", "alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("trusted proxy: got %d: %s", w.Code, w.Body.String())
+	}
+	var got models.User
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "alice" {
+		t.Errorf("name = %q, want alice", got.Name)
+	}
+
+	if w := me("198.51.100.9:1234", "alice"); w.Code != http.StatusUnauthorized {
+		t.Errorf("untrusted peer: expected 401, got %d", w.Code)
+	}
+	if w := me("192.0.2.1:1234", "mallory"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown user without auto-provision: expected 401, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/me/preferences", strings.NewReader(`{}`))
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Authentik-Username", "alice")
+	req.Header.Set("Origin", "https://evil.example")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("cross-site write: expected 403, got %d", w.Code)
+	}
+}
+
+func TestProxyAuth_AutoProvision(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/proxy-auth",
+		strings.NewReader(`{"enabled":true,"email_header":"Remote-Email","trusted_proxies":["192.0.2.1"],"auto_provision":true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: got %d: %s", w.Code, w.Body.String())
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("Remote-User", "bob")
+		req.Header.Set("Remote-Email", "bob@test.local")
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+
+	user, err := st.GetUserByProvider(string(auth.ProviderProxy), "bob")
+	if err != nil {
+		t.Fatalf("provisioned user: %v", err)
+	}
+	if user.Role != models.RoleViewer || user.Email != "bob@test.local" {
+		t.Errorf("provisioned %+v, want a viewer with the proxy's email", user)
+	}
+}
+
+func TestProxyAuthSettingsAPI_Invalid(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+
+	for _, body := range []string{
+		`{"enabled":true,"trusted_proxies":[]}`,
+		`{"enabled":true,"trusted_proxies":["not-an-ip"]}`,
+	} {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/proxy-auth", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if ts.Unwrap().proxyAuth.Load() != nil {
+		t.Error("invalid settings were applied")
+	}
+}
+
+func TestProxyAuth_PerServer(t *testing.T) {
+	other, otherStore := newTestServerWrapped(t)
+	ts, st := newTestServerWrapped(t) // last, so the test session cookie is ts's
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/proxy-auth",
+		strings.NewReader(`{"enabled":true,"trusted_proxies":["192.0.2.1"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := st.CreateLocalUser("alice", "alice@test.local", "", models.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := otherStore.CreateLocalUser("alice", "alice@test.local", "", models.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	me := func(srv *Server) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("Remote-User", "alice")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := me(ts.Unwrap()); code != http.StatusOK {
+		t.Fatalf("configured server: got %d", code)
+	}
+	if code := me(other.Unwrap()); code != http.StatusUnauthorized {
+		t.Errorf("proxy auth leaked to another server: got %d", code)
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streammon/internal/models"
+)
+
+type rateLimitStatusResponse struct {
+	Settings models.RateLimitSettings   `json:"settings"`
+	Limiters []models.RateLimiterStatus `json:"limiters"`
+}
+
+func (s *Server) handleGetRateLimitSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetRateLimitSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// handleUpdateRateLimitSettings saves new limits and applies them to the
+// running limiters, so clients already throttled feel the change at once.
+func (s *Server) handleUpdateRateLimitSettings(w http.ResponseWriter, r *http.Request) {
+	var req models.RateLimitSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetRateLimitSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	s.limits.apply(req)
+	writeJSON(w, http.StatusOK, req)
+}
+
+// handleGetRateLimitStatus shows which clients each limiter is rejecting
+// right now, alongside the limits in force.
+func (s *Server) handleGetRateLimitStatus(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetRateLimitSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, rateLimitStatusResponse{Settings: settings, Limiters: s.limits.status()})
+}
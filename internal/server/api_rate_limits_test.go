@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestRateLimitSettingsAPI(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/settings/rate-limits", nil))
+	var got models.RateLimitSettings
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != models.DefaultRateLimitSettings() {
+		t.Errorf("default = %+v", got)
+	}
+
+	put := func(body string) int {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/rate-limits", strings.NewReader(body)))
+		return w.Code
+	}
+	if code := put(`{"search":{"requests_per_minute":0},"auth":{"max_failures":10,"window_minutes":15}}`); code != http.StatusBadRequest {
+		t.Errorf("invalid settings: expected 400, got %d", code)
+	}
+	if code := put(`{"search":{"requests_per_minute":1,"burst":1,"exempt_api_keys":true},"auth":{"max_failures":10,"window_minutes":15}}`); code != http.StatusOK {
+		t.Fatalf("update: got %d", code)
+	}
+
+	// One per minute plus a burst of one: the third search is throttled.
+	const ip = "203.0.113.77"
+	search := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/maintenance/exclusions?search=x", nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		if w := search(); w.Code == http.StatusTooManyRequests {
+			t.Fatalf("search %d throttled early", i+1)
+		}
+	}
+	w = search()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if ra := w.Header().Get("Retry-After"); ra == "" || ra == "0" {
+		t.Errorf("Retry-After = %q, want seconds until the next token", ra)
+	}
+
+	// API tokens skip the search limit while exempt.
+	_, tok := createAPIToken(t, ts.Unwrap(), testSessionToken, `{"name":"dashboard","scope":"admin"}`)
+	req := httptest.NewRequest(http.MethodGet, "/api/maintenance/exclusions?search=x", nil)
+	req.RemoteAddr = ip + ":12345"
+	req.Header.Set("Authorization", "Bearer "+tok.Token)
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code == http.StatusTooManyRequests {
+		t.Error("expected API token requests to be exempt")
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/rate-limits/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d", w.Code)
+	}
+	var status rateLimitStatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Settings.Search.RequestsPerMinute != 1 || len(status.Limiters) != 2 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	search0 := status.Limiters[0]
+	if search0.Name != "search" || search0.Rejected == 0 {
+		t.Errorf("search limiter = %+v, want rejections counted", search0)
+	}
+	found := false
+	for _, c := range search0.Throttled {
+		found = found || c.Key == ip
+	}
+	if !found {
+		t.Errorf("expected %s among throttled clients, got %+v", ip, search0.Throttled)
+	}
+}
+
+func TestRateLimitSettingsAPI_AdminOnly(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	cookie := createViewerSession(t, st, "viewer")
+	for _, path := range []string{"/api/settings/rate-limits", "/api/rate-limits/status"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: cookie})
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 for viewer, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestRateLimitSettings_PerServer(t *testing.T) {
+	a, _ := newTestServer(t)
+	b, _ := newTestServer(t)
+
+	a.limits.apply(models.RateLimitSettings{
+		Search: models.SearchRateLimit{RequestsPerMinute: 1, ExemptAPIKeys: true},
+		Auth:   models.AuthRateLimit{MaxFailures: 1, WindowMinutes: 1},
+	})
+	const ip = "203.0.113.78"
+	a.limits.auth.record(ip)
+	if a.limits.auth.check(ip) {
+		t.Error("expected the first server to lock out after one failure")
+	}
+	if !b.limits.auth.check(ip) || b.limits.exemptAPIKeys.Load() {
+		t.Error("settings and failures on one server leaked into another")
+	}
+}
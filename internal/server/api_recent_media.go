@@ -23,7 +23,7 @@ func (s *Server) handleGetRecentMedia(w http.ResponseWriter, r *http.Request) {
 
 	var allItems []models.LibraryItem
 
-	for _, srv := range servers {
+	for _, srv := range workspaceServers(r, servers) {
 		if !srv.Enabled || !srv.ShowRecentMedia {
 			continue
 		}
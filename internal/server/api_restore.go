@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+type restoreResponse struct {
+	BackupVersion int    `json:"backup_version"`
+	SchemaVersion int    `json:"schema_version"`
+	Snapshot      string `json:"snapshot"`
+	// RestartRequired is set when some restored settings couldn't be applied
+	// to the running server; they take effect on the next start.
+	RestartRequired bool `json:"restart_required,omitempty"`
+}
+
+// handleAdminRestore replaces the database with an uploaded backup. The
+// backup is validated and migrated before anything changes, and the live
+// database is snapshotted to the backup directory first. Sessions come from
+// the backup too, so the caller may have to sign in again. Settings and
+// servers the running process keeps in memory are reloaded afterwards.
+func (s *Server) handleAdminRestore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const maxUpload = 2 << 30      // 2 GiB; years of history grow large
+		const multipartSlack = 1 << 20 // 1 MiB headroom for the multipart envelope
+		r.Body = http.MaxBytesReader(w, r.Body, maxUpload+multipartSlack)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "upload too large or invalid multipart form")
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+
+		// Restore migrates the backup in place, so it gets a scratch copy.
+		path, err := s.spoolImportUpload("restore-*.db", func(f *os.File) error {
+			_, err := io.Copy(f, file)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR restore: spool upload: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to read file")
+			return
+		}
+		defer func() {
+			for _, p := range []string{path, path + "-wal", path + "-shm"} {
+				os.Remove(p)
+			}
+		}()
+
+		// The poller has to drop servers the backup doesn't have, so note
+		// which ones it may be polling now.
+		before, err := s.store.ListAllServers()
+		if err != nil {
+			log.Printf("ERROR restore: listing servers: %v", err)
+			writeError(w, http.StatusInternalServerError, "restore failed, check server logs")
+			return
+		}
+
+		res, err := s.store.Restore(r.Context(), path)
+		var tooNew *store.SchemaTooNewError
+		switch {
+		case errors.Is(err, store.ErrInvalidBackup):
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		case errors.As(err, &tooNew):
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			log.Printf("ERROR restore: %v", err)
+			writeError(w, http.StatusInternalServerError, "restore failed, check server logs")
+			return
+		}
+
+		log.Printf("restored database from a schema version %d backup; previous database saved to %s", res.BackupVersion, res.SnapshotPath)
+		restartRequired := !s.reloadAfterRestore(r.Context(), before)
+		if restartRequired {
+			log.Printf("WARNING restore: some restored settings could not be applied; restart StreamMon to apply them")
+		}
+		writeJSON(w, http.StatusOK, restoreResponse{
+			BackupVersion:   res.BackupVersion,
+			SchemaVersion:   res.SchemaVersion,
+			Snapshot:        filepath.Base(res.SnapshotPath),
+			RestartRequired: restartRequired,
+		})
+	}
+}
+
+// reloadAfterRestore reapplies everything NewServer and startup read from
+// the database once, so the restored auth, rate limit, geo and poller
+// configuration is enforced without a restart. before is the server list
+// from ahead of the restore. It reports whether everything was applied.
+func (s *Server) reloadAfterRestore(ctx context.Context, before []models.Server) bool {
+	ok := true
+	fail := func(what string, err error) {
+		log.Printf("ERROR restore: reloading %s: %v", what, err)
+		ok = false
+	}
+
+	if limits, err := s.store.GetRateLimitSettings(); err != nil {
+		fail("rate limit settings", err)
+	} else {
+		s.limits.apply(limits)
+	}
+	if proxy, err := s.store.GetProxyAuthSettings(); err != nil {
+		fail("proxy auth settings", err)
+	} else if err := s.applyProxyAuthSettings(proxy); err != nil {
+		// Don't leave the pre-restore proxy trusted.
+		s.proxyAuth.Store(nil)
+		fail("proxy auth settings", err)
+	}
+	if err := s.reloadAuth(ctx); err != nil {
+		fail("OIDC settings", err)
+	}
+	s.applyGeoOverrides()
+	if s.rulesEngine != nil {
+		s.rulesEngine.InvalidateCache()
+	}
+
+	if s.poller != nil {
+		servers, err := s.store.ListServers()
+		if err != nil {
+			// Without the restored list, stop polling rather than keep
+			// using credentials the backup may have replaced.
+			for _, srv := range before {
+				s.poller.RemoveServer(srv.ID)
+			}
+			fail("servers", err)
+			return ok
+		}
+		restored := make(map[int64]bool, len(servers))
+		for i := range servers {
+			restored[servers[i].ID] = true
+			s.syncServerToPoller(&servers[i])
+		}
+		for _, srv := range before {
+			if !restored[srv.ID] {
+				s.poller.RemoveServer(srv.ID)
+			}
+		}
+		s.poller.RefreshIdleTimeout()
+	}
+	return ok
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+	"streammon/internal/poller"
+	"streammon/internal/store"
+)
+
+func postRestore(t *testing.T, srv http.Handler, data []byte, cookie *http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "streammon.db")
+	fw.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/restore", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminRestore(t *testing.T) {
+	backupDir := t.TempDir()
+	srv, st := newTestServerWrapped(t, store.WithBackupDir(backupDir))
+	if err := st.SetSetting("marker", "backed-up"); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	if err := st.BackupTo(context.Background(), backup); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetSetting("marker", "changed"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("junk upload", func(t *testing.T) {
+		w := postRestore(t, srv, []byte("not a database at all, only some text bytes"), nil)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("viewer forbidden", func(t *testing.T) {
+		tok := createViewerSession(t, st, "viewer")
+		w := postRestore(t, srv.Unwrap(), data, &http.Cookie{Name: auth.CookieName, Value: tok})
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	w := postRestore(t, srv, data, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp restoreResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Snapshot == "" || resp.BackupVersion != resp.SchemaVersion {
+		t.Errorf("response = %+v", resp)
+	}
+	if v, _ := st.GetSetting("marker"); v != "backed-up" {
+		t.Errorf("marker = %q after restore", v)
+	}
+	if _, err := os.Stat(filepath.Join(backupDir, resp.Snapshot)); err != nil {
+		t.Errorf("pre-restore snapshot: %v", err)
+	}
+}
+
+func TestAdminRestore_ReloadsRunningConfig(t *testing.T) {
+	srv, st := newTestServerWrapped(t, store.WithBackupDir(t.TempDir()))
+	p := poller.New(st, time.Hour)
+	srv.poller = p
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	if err := st.BackupTo(context.Background(), backup); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Configured after the backup, so the restore has to undo both.
+	added := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://x", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(added); err != nil {
+		t.Fatal(err)
+	}
+	p.AddServer(added.ID, &mockLibraryServer{})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/settings/proxy-auth",
+		strings.NewReader(`{"enabled":true,"trusted_proxies":["192.0.2.1"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("proxy auth update: got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = postRestore(t, srv, data, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp restoreResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.RestartRequired {
+		t.Error("restart_required set although every setting reloaded")
+	}
+	if srv.proxyAuth.Load() != nil {
+		t.Error("pre-restore proxy auth still enforced")
+	}
+	if _, ok := p.GetServer(added.ID); ok {
+		t.Error("poller still polls a server the backup doesn't have")
+	}
+}
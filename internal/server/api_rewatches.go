@@ -0,0 +1,44 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"streammon/internal/models"
+)
+
+const (
+	rewatchDefaultLimit = 25
+	rewatchMaxLimit     = 100
+)
+
+func (s *Server) handleGetMostRewatched(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(s.withPreferenceDefaults(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := rewatchDefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a number of at least 1")
+			return
+		}
+		limit = min(n, rewatchMaxLimit)
+	}
+	mediaType := models.MediaType(r.URL.Query().Get("media_type"))
+	if mediaType != "" && mediaType != models.MediaTypeMovie && mediaType != models.MediaTypeTV {
+		writeError(w, http.StatusBadRequest, "media_type must be movie or episode")
+		return
+	}
+
+	stats, err := s.store.MostRewatched(r.Context(), limit, filter, mediaType)
+	if err != nil {
+		log.Printf("most rewatched: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
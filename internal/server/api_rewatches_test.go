@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestMostRewatchedAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i := range 2 {
+		started := now.Add(-time.Duration(i+1) * 24 * time.Hour)
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Dune", Year: 2021,
+			WatchedMs: 3600000, DurationMs: 3600000, Watched: true, StartedAt: started, StoppedAt: started.Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/rewatched?media_type=movie", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var stats []models.RewatchStat
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Title != "Dune" || stats[0].RewatchCount != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	for _, q := range []string{"media_type=music", "limit=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats/rewatched?"+q, nil)
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
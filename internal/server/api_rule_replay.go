@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"streammon/internal/models"
+)
+
+// POST /api/rules/replay
+//
+// handleReplayRules runs the current rules over a window of stored history
+// and reports every decision, to debug why an alert did or didn't fire.
+// Nothing is recorded and no notification or action is sent.
+func (s *Server) handleReplayRules(w http.ResponseWriter, r *http.Request) {
+	if s.rulesEngine == nil {
+		writeError(w, http.StatusServiceUnavailable, "rules engine not available")
+		return
+	}
+	var req models.RuleReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	all, err := s.store.ListRules()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list rules")
+		return
+	}
+	var rules []models.Rule
+	for _, rule := range all {
+		if len(req.RuleIDs) == 0 && rule.Enabled || slices.Contains(req.RuleIDs, rule.ID) {
+			rules = append(rules, rule)
+		}
+	}
+	for _, id := range req.RuleIDs {
+		if !slices.ContainsFunc(rules, func(rule models.Rule) bool { return rule.ID == id }) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("rule %d not found", id))
+			return
+		}
+	}
+
+	history, err := s.store.ListHistoryOverlapping(r.Context(), req.From, req.To, req.UserName, models.MaxRuleReplaySessions+1)
+	if err != nil {
+		log.Printf("rule replay: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	truncated := len(history) > models.MaxRuleReplaySessions
+	if truncated {
+		history = history[:models.MaxRuleReplaySessions]
+	}
+
+	result := models.RuleReplayResult{
+		From:      req.From,
+		To:        req.To,
+		Rules:     len(rules),
+		Truncated: truncated,
+		Sessions:  s.rulesEngine.Replay(r.Context(), rules, history, req.From, req.To),
+	}
+	for _, session := range result.Sessions {
+		for _, d := range session.Decisions {
+			if d.Outcome == models.RuleReplayViolation {
+				result.Violations++
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+	"streammon/internal/rules"
+	"streammon/internal/store"
+)
+
+func TestReplayRules(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ts.Server.rulesEngine = rules.NewEngine(st, nil, rules.DefaultEngineConfig())
+
+	srv := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	concurrent := &models.Rule{Name: "One stream", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{"max_streams":1}`)}
+	disabled := &models.Rule{Name: "Geo", Type: models.RuleTypeGeoRestriction, Config: json.RawMessage(`{"allowed_countries":["NO"]}`)}
+	for _, rule := range []*models.Rule{concurrent, disabled} {
+		if err := st.CreateRule(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base := time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC)
+	for i, ip := range []string{"203.0.113.9", "198.51.100.1"} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: fmt.Sprintf("Movie %d", i),
+			IPAddress: ip, StartedAt: base.Add(time.Duration(i) * 10 * time.Minute), StoppedAt: base.Add(2 * time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay := func(body string) (int, models.RuleReplayResult) {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/rules/replay", strings.NewReader(body)))
+		var result models.RuleReplayResult
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, result
+	}
+
+	code, result := replay(`{"from":"2026-05-01T00:00:00Z","to":"2026-05-02T00:00:00Z"}`)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if result.Rules != 1 || len(result.Sessions) != 2 || result.Violations != 1 {
+		t.Fatalf("rules=%d sessions=%d violations=%d", result.Rules, len(result.Sessions), result.Violations)
+	}
+	first, second := result.Sessions[0], result.Sessions[1]
+	if first.Decisions[0].Outcome != models.RuleReplayPass || first.ConcurrentStreams != 1 {
+		t.Errorf("first session = %+v", first)
+	}
+	if second.Decisions[0].Outcome != models.RuleReplayViolation || second.ConcurrentStreams != 2 {
+		t.Errorf("second session = %+v", second)
+	}
+
+	// Naming a rule replays it even while disabled; the window starting
+	// after the first stream keeps it as context only.
+	body := fmt.Sprintf(`{"from":"2026-05-01T20:05:00Z","to":"2026-05-02T00:00:00Z","rule_ids":[%d,%d]}`, concurrent.ID, disabled.ID)
+	code, result = replay(body)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if result.Rules != 2 || len(result.Sessions) != 1 || result.Sessions[0].ConcurrentStreams != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// Nothing is recorded.
+	violations, err := st.ListViolations(1, 10, store.ViolationFilters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if violations.Total != 0 {
+		t.Errorf("replay recorded %d violations", violations.Total)
+	}
+
+	for _, bad := range []string{
+		`{"from":"2026-05-02T00:00:00Z","to":"2026-05-01T00:00:00Z"}`,
+		`{"from":"2026-01-01T00:00:00Z","to":"2026-05-01T00:00:00Z"}`,
+		`{"from":"2026-05-01T00:00:00Z","to":"2026-05-02T00:00:00Z","rule_ids":[9999]}`,
+	} {
+		if code, _ := replay(bad); code == http.StatusOK {
+			t.Errorf("%s: status = %d", bad, code)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,13 +17,13 @@ import (
 )
 
 func (s *Server) handleListRules(w http.ResponseWriter, r *http.Request) {
-	rules, err := s.store.ListRules()
+	rules, err := s.store.ListRulesWithCounts()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list rules")
 		return
 	}
-	if rules == nil {
-		rules = []models.Rule{}
+	if m := WorkspaceFromContext(r.Context()); m != nil {
+		rules = slices.DeleteFunc(rules, func(rc models.RuleWithCount) bool { return !rc.InWorkspace(m.WorkspaceID) })
 	}
 	writeJSON(w, http.StatusOK, rules)
 }
@@ -53,6 +54,10 @@ func (s *Server) handleCreateRule(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	rule.WorkspaceID = nil
+	if m := WorkspaceFromContext(r.Context()); m != nil {
+		rule.WorkspaceID = &m.WorkspaceID
+	}
 
 	if err := s.store.CreateRule(&rule); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create rule")
@@ -70,7 +75,8 @@ func (s *Server) handleUpdateRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := s.store.GetRule(id); err != nil {
+	existing, err := s.store.GetRule(id)
+	if err != nil {
 		writeStoreError(w, err)
 		return
 	}
@@ -82,6 +88,7 @@ func (s *Server) handleUpdateRule(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rule.ID = id
+	rule.WorkspaceID = existing.WorkspaceID
 	if err := rule.Validate(); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -96,6 +103,35 @@ func (s *Server) handleUpdateRule(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, rule)
 }
 
+func (s *Server) handleEnableRule(w http.ResponseWriter, r *http.Request) {
+	s.setRuleEnabled(w, r, true)
+}
+
+func (s *Server) handleDisableRule(w http.ResponseWriter, r *http.Request) {
+	s.setRuleEnabled(w, r, false)
+}
+
+func (s *Server) setRuleEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	if err := s.store.SetRuleEnabled(id, enabled); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.invalidateRulesCache()
+
+	rule, err := s.store.GetRule(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
 func (s *Server) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
 	id, ok := parseIDParam(r, "id")
 	if !ok {
@@ -188,6 +224,9 @@ func (s *Server) handleListNotificationChannels(w http.ResponseWriter, r *http.R
 	if channels == nil {
 		channels = []models.NotificationChannel{}
 	}
+	if m := WorkspaceFromContext(r.Context()); m != nil {
+		channels = slices.DeleteFunc(channels, func(c models.NotificationChannel) bool { return !c.InWorkspace(m.WorkspaceID) })
+	}
 	writeJSON(w, http.StatusOK, maskChannels(channels))
 }
 
@@ -217,6 +256,10 @@ func (s *Server) handleCreateNotificationChannel(w http.ResponseWriter, r *http.
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	channel.WorkspaceID = nil
+	if m := WorkspaceFromContext(r.Context()); m != nil {
+		channel.WorkspaceID = &m.WorkspaceID
+	}
 
 	if err := s.store.CreateNotificationChannel(&channel); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create channel")
@@ -250,6 +293,7 @@ func (s *Server) handleUpdateNotificationChannel(w http.ResponseWriter, r *http.
 	// persisting the literal "********" placeholder.
 	if existing, err := s.store.GetNotificationChannel(id); err == nil {
 		channel.Config = restoreChannelSecrets(channel.ChannelType, channel.Config, existing.Config)
+		channel.WorkspaceID = existing.WorkspaceID
 	}
 
 	if err := s.store.UpdateNotificationChannel(&channel); err != nil {
@@ -275,6 +319,37 @@ func (s *Server) handleDeleteNotificationChannel(w http.ResponseWriter, r *http.
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// testNotificationOptions returns the notification options for a test send:
+// those of the rule named by ?rule_id=, or the defaults.
+func (s *Server) testNotificationOptions(w http.ResponseWriter, r *http.Request) (models.RuleNotification, bool) {
+	raw := r.URL.Query().Get("rule_id")
+	if raw == "" {
+		return models.RuleNotification{}, true
+	}
+	ruleID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || ruleID <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid rule_id")
+		return models.RuleNotification{}, false
+	}
+	rule, err := s.store.GetRule(ruleID)
+	if err != nil {
+		writeStoreError(w, err)
+		return models.RuleNotification{}, false
+	}
+	return rule.Notification, true
+}
+
+func (s *Server) sendTestNotification(w http.ResponseWriter, r *http.Request, channel *models.NotificationChannel, opts models.RuleNotification) {
+	n := notifier.New()
+	if err := n.TestChannel(r.Context(), channel, opts); err != nil {
+		log.Printf("test notification channel %s failed: %v", channel.Name, err)
+		writeError(w, http.StatusBadRequest, sanitizeConnError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
 func (s *Server) handleTestNotificationChannel(w http.ResponseWriter, r *http.Request) {
 	id, ok := parseIDParam(r, "id")
 	if !ok {
@@ -288,14 +363,48 @@ func (s *Server) handleTestNotificationChannel(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	n := notifier.New()
-	if err := n.TestChannel(r.Context(), channel); err != nil {
-		log.Printf("test notification channel %s failed: %v", channel.Name, err)
-		writeError(w, http.StatusBadRequest, sanitizeConnError(err))
+	opts, ok := s.testNotificationOptions(w, r)
+	if !ok {
 		return
 	}
+	s.sendTestNotification(w, r, channel, opts)
+}
 
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+type testNotificationRequest struct {
+	Channel      models.NotificationChannel `json:"channel"`
+	Notification *models.RuleNotification   `json:"notification"`
+}
+
+// POST /api/notifications/test
+//
+// Sends a sample notification through an unsaved channel so a config can be
+// checked before it is stored. Embed options come from the body, else from
+// ?rule_id=.
+func (s *Server) handleTestNotificationConfig(w http.ResponseWriter, r *http.Request) {
+	var req testNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Channel.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var opts models.RuleNotification
+	if req.Notification != nil {
+		if err := req.Notification.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts = *req.Notification
+	} else {
+		var ok bool
+		if opts, ok = s.testNotificationOptions(w, r); !ok {
+			return
+		}
+	}
+	s.sendTestNotification(w, r, &req.Channel, opts)
 }
 
 func (s *Server) requireGuestVisibility(w http.ResponseWriter, r *http.Request, userName, settingKey string) bool {
@@ -304,7 +413,7 @@ func (s *Server) requireGuestVisibility(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusUnauthorized, "unauthorized")
 		return false
 	}
-	if user.Role == models.RoleAdmin {
+	if user.Role.CanReadAll() {
 		return true
 	}
 	if user.Name != userName {
@@ -342,7 +451,7 @@ func (s *Server) handleGetUserTrustScore(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	ts, err := s.store.GetUserTrustScore(userName)
+	ts, err := s.store.UserTrustScoreOnServers(userName, workspaceServerIDs(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to get trust score")
 		return
@@ -364,6 +473,7 @@ func (s *Server) handleGetUserViolations(w http.ResponseWriter, r *http.Request)
 
 	var filters store.ViolationFilters
 	filters.UserName = userName
+	filters.ServerIDs = workspaceServerIDs(r)
 
 	result, err := s.store.ListViolations(page, perPage, filters)
 	if err != nil {
@@ -386,7 +496,7 @@ func (s *Server) handleListHouseholdLocations(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	locations, err := s.store.ListHouseholdLocations(userName)
+	locations, err := s.store.ListHouseholdLocationsOnServers(userName, workspaceServerIDs(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list household locations")
 		return
@@ -501,6 +611,23 @@ func (s *Server) handleLinkRuleToChannel(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	rule, err := s.store.GetRule(ruleID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	channel, err := s.store.GetNotificationChannel(body.ChannelID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	// A rule can only notify through channels of its own workspace, or
+	// global channels when the rule is global.
+	if !sameWorkspace(rule.WorkspaceID, channel.WorkspaceID) {
+		writeError(w, http.StatusBadRequest, "channel belongs to a different workspace")
+		return
+	}
+
 	if err := s.store.LinkRuleToChannel(ruleID, body.ChannelID); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to link channel")
 		return
@@ -545,9 +672,19 @@ func (s *Server) handleGetRuleChannels(w http.ResponseWriter, r *http.Request) {
 	if channels == nil {
 		channels = []models.NotificationChannel{}
 	}
+	if m := WorkspaceFromContext(r.Context()); m != nil {
+		channels = slices.DeleteFunc(channels, func(c models.NotificationChannel) bool { return !c.InWorkspace(m.WorkspaceID) })
+	}
 	writeJSON(w, http.StatusOK, channels)
 }
 
+func sameWorkspace(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 func (s *Server) handleListRuleExemptions(w http.ResponseWriter, r *http.Request) {
 	id, ok := parseIDParam(r, "id")
 	if !ok {
@@ -619,3 +756,74 @@ func (s *Server) invalidateRulesCache() {
 		s.rulesEngine.InvalidateCache()
 	}
 }
+
+func (s *Server) handleListRuleUserLimits(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	if _, err := s.store.GetRule(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	limits, err := s.store.ListRuleUserLimits(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list user limits")
+		return
+	}
+	writeJSON(w, http.StatusOK, limits)
+}
+
+// handleSetRuleUserLimits replaces a concurrent streams rule's per-user
+// max_streams overrides. Users left out fall back to the rule's limit.
+func (s *Server) handleSetRuleUserLimits(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	rule, err := s.store.GetRule(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if rule.Type != models.RuleTypeConcurrentStreams {
+		writeError(w, http.StatusBadRequest, "user limits only apply to concurrent_streams rules")
+		return
+	}
+
+	var limits []models.RuleUserLimit
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: expected array of user limits")
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i := range limits {
+		if err := limits[i].Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		lower := strings.ToLower(limits[i].UserName)
+		if seen[lower] {
+			writeError(w, http.StatusBadRequest, "duplicate user "+limits[i].UserName)
+			return
+		}
+		seen[lower] = true
+	}
+	if limits == nil {
+		limits = []models.RuleUserLimit{}
+	}
+
+	if err := s.store.SetRuleUserLimits(id, limits); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to set user limits")
+		return
+	}
+
+	s.invalidateRulesCache()
+	writeJSON(w, http.StatusOK, limits)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"streammon/internal/models"
 )
@@ -27,6 +28,7 @@ func TestRuleAndChannelIDEndpointsRejectNonPositiveIDs(t *testing.T) {
 		{"get rule negative", http.MethodGet, "/api/rules/-1"},
 		{"update rule zero", http.MethodPut, "/api/rules/0"},
 		{"delete rule zero", http.MethodDelete, "/api/rules/0"},
+		{"enable rule zero", http.MethodPost, "/api/rules/0/enable"},
 		{"get channel zero", http.MethodGet, "/api/notifications/0"},
 		{"delete channel negative", http.MethodDelete, "/api/notifications/-5"},
 		{"test channel zero", http.MethodPost, "/api/notifications/0/test"},
@@ -76,6 +78,73 @@ func TestListViolations_ValidRuleID(t *testing.T) {
 	}
 }
 
+func TestEnableDisableRule(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	rule := &models.Rule{Name: "Toggle", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	if err := st.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		action string
+		want   bool
+	}{
+		{"disable", false},
+		{"enable", true},
+	} {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/rules/%d/%s", rule.ID, tc.action), nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tc.action, w.Code, w.Body.String())
+		}
+		var got models.Rule
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Enabled != tc.want {
+			t.Errorf("%s: enabled = %v, want %v", tc.action, got.Enabled, tc.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/rules/9999/enable", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestListRulesIncludesViolationCounts(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	rule := &models.Rule{Name: "Counted", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	if err := st.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	v := &models.RuleViolation{RuleID: rule.ID, UserName: "alice", Severity: models.SeverityWarning, Message: "x", OccurredAt: time.Now().UTC()}
+	if err := st.InsertViolation(v); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/rules", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var rules []models.RuleWithCount
+	if err := json.NewDecoder(w.Body).Decode(&rules); err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].ViolationCount != 1 || rules[0].LastViolationAt == nil {
+		t.Errorf("rules = %+v, want one rule with 1 violation", rules)
+	}
+}
+
 func TestListRuleExemptions_Empty(t *testing.T) {
 	srv, st := newTestServerWrapped(t)
 
@@ -134,6 +203,57 @@ func TestSetRuleExemptions(t *testing.T) {
 	}
 }
 
+func TestSetRuleUserLimits(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	rule := &models.Rule{
+		Name: "Test", Type: models.RuleTypeConcurrentStreams,
+		Enabled: true, Config: json.RawMessage(`{}`),
+	}
+	st.CreateRule(rule)
+	geo := &models.Rule{
+		Name: "Geo", Type: models.RuleTypeGeoRestriction,
+		Enabled: true, Config: json.RawMessage(`{}`),
+	}
+	st.CreateRule(geo)
+
+	put := func(id int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/rules/%d/user-limits", id), strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := put(rule.ID, `[{"user_name":" alice ","max_streams":3}]`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/rules/%d/user-limits", rule.ID), nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	var limits []models.RuleUserLimit
+	json.Unmarshal(w.Body.Bytes(), &limits)
+	if len(limits) != 1 || limits[0].UserName != "alice" || limits[0].MaxStreams != 3 {
+		t.Errorf("limits = %+v", limits)
+	}
+
+	bad := []struct {
+		name string
+		id   int64
+		body string
+	}{
+		{"zero limit", rule.ID, `[{"user_name":"alice","max_streams":0}]`},
+		{"missing user", rule.ID, `[{"max_streams":2}]`},
+		{"duplicate user", rule.ID, `[{"user_name":"alice","max_streams":2},{"user_name":"ALICE","max_streams":3}]`},
+		{"wrong rule type", geo.ID, `[{"user_name":"alice","max_streams":2}]`},
+	}
+	for _, tc := range bad {
+		if w := put(tc.id, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, w.Code)
+		}
+	}
+}
+
 func TestSetRuleExemptions_Replace(t *testing.T) {
 	srv, st := newTestServerWrapped(t)
 
@@ -200,7 +320,19 @@ func TestListNotificationChannels_MasksSecrets(t *testing.T) {
 		Name: "Webhook", ChannelType: models.ChannelTypeWebhook, Enabled: true,
 		Config: json.RawMessage(`{"url":"https://example.com/hook","method":"POST","headers":{"Authorization":"Bearer webhooksecrettoken"}}`),
 	}
-	for _, c := range []*models.NotificationChannel{discord, pushover, ntfy, webhook} {
+	apprise := &models.NotificationChannel{
+		Name: "Apprise", ChannelType: models.ChannelTypeApprise, Enabled: true,
+		Config: json.RawMessage(`{"server_url":"http://apprise:8000","urls":["tgram://appriseurlsecret/chat"]}`),
+	}
+	email := &models.NotificationChannel{
+		Name: "Email", ChannelType: models.ChannelTypeEmail, Enabled: true,
+		Config: json.RawMessage(`{"host":"smtp.example.com","username":"me","password":"smtppasswordsecret","from":"me@example.com","to":["me@example.com"]}`),
+	}
+	gotify := &models.NotificationChannel{
+		Name: "Gotify", ChannelType: models.ChannelTypeGotify, Enabled: true,
+		Config: json.RawMessage(`{"server_url":"https://gotify.example.com","token":"gotifytokensecret"}`),
+	}
+	for _, c := range []*models.NotificationChannel{discord, pushover, ntfy, webhook, apprise, email, gotify} {
 		if err := st.CreateNotificationChannel(c); err != nil {
 			t.Fatal(err)
 		}
@@ -215,7 +347,7 @@ func TestListNotificationChannels_MasksSecrets(t *testing.T) {
 	}
 
 	body := w.Body.String()
-	for _, secret := range []string{"supersecrettoken", "pushovertokensecret", "ntfytokensecret", "webhooksecrettoken"} {
+	for _, secret := range []string{"supersecrettoken", "pushovertokensecret", "ntfytokensecret", "webhooksecrettoken", "appriseurlsecret", "smtppasswordsecret", "gotifytokensecret"} {
 		if strings.Contains(body, secret) {
 			t.Fatalf("response leaked secret %q: %s", secret, body)
 		}
@@ -225,8 +357,8 @@ func TestListNotificationChannels_MasksSecrets(t *testing.T) {
 	if err := json.Unmarshal(w.Body.Bytes(), &channels); err != nil {
 		t.Fatalf("decode: %v", err)
 	}
-	if len(channels) != 4 {
-		t.Fatalf("expected 4 channels, got %d", len(channels))
+	if len(channels) != 7 {
+		t.Fatalf("expected 7 channels, got %d", len(channels))
 	}
 
 	for _, c := range channels {
@@ -258,6 +390,30 @@ func TestListNotificationChannels_MasksSecrets(t *testing.T) {
 			if cfg.Headers["Authorization"] != "********" {
 				t.Errorf("webhook auth header not masked: %q", cfg.Headers["Authorization"])
 			}
+		case models.ChannelTypeApprise:
+			var cfg models.AppriseConfig
+			json.Unmarshal(c.Config, &cfg)
+			if len(cfg.URLs) != 1 || cfg.URLs[0] != "********" {
+				t.Errorf("apprise urls not masked: %q", cfg.URLs)
+			}
+			if cfg.ServerURL != "http://apprise:8000" {
+				t.Errorf("apprise server_url should not be masked, got %q", cfg.ServerURL)
+			}
+		case models.ChannelTypeEmail:
+			var cfg models.EmailConfig
+			json.Unmarshal(c.Config, &cfg)
+			if cfg.Password != "********" {
+				t.Errorf("email password not masked: %q", cfg.Password)
+			}
+			if cfg.Username != "me" {
+				t.Errorf("email username should not be masked, got %q", cfg.Username)
+			}
+		case models.ChannelTypeGotify:
+			var cfg models.GotifyConfig
+			json.Unmarshal(c.Config, &cfg)
+			if cfg.Token != "********" {
+				t.Errorf("gotify token not masked: %q", cfg.Token)
+			}
 		}
 	}
 
@@ -397,3 +553,31 @@ func TestSetRuleExemptions_InvalidJSON(t *testing.T) {
 		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
 	}
 }
+
+func TestTestNotificationConfig_Validation(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+
+	channel := `{"name":"D","channel_type":"discord","config":{"webhook_url":"https://discord.com/api/webhooks/1/a"}}`
+	cases := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"invalid JSON", "/api/notifications/test", "{bad", http.StatusBadRequest},
+		{"invalid channel", "/api/notifications/test", `{"channel":{"name":"D","channel_type":"discord","config":{}}}`, http.StatusBadRequest},
+		{"invalid fields", "/api/notifications/test", `{"channel":` + channel + `,"notification":{"discord_fields":["weather"]}}`, http.StatusBadRequest},
+		{"invalid rule_id", "/api/notifications/test?rule_id=x", `{"channel":` + channel + `}`, http.StatusBadRequest},
+		{"unknown rule", "/api/notifications/test?rule_id=999", `{"channel":` + channel + `}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type serverHistoryMigrationRequest struct {
+	ToServerID int64 `json:"to_server_id"`
+}
+
+// historyMigrationServers reads the {id} source server and the body's
+// target, which must be a different, live server.
+func (s *Server) historyMigrationServers(w http.ResponseWriter, r *http.Request) (from, to int64, ok bool) {
+	from, err := parseServerID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, 0, false
+	}
+	var req serverHistoryMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return 0, 0, false
+	}
+	if req.ToServerID == 0 || req.ToServerID == from {
+		writeError(w, http.StatusBadRequest, "to_server_id must name a different server")
+		return 0, 0, false
+	}
+	if _, err := s.store.GetServer(from); err != nil {
+		writeStoreError(w, err)
+		return 0, 0, false
+	}
+	target, err := s.store.GetServer(req.ToServerID)
+	if err != nil {
+		writeStoreError(w, err)
+		return 0, 0, false
+	}
+	if target.DeletedAt != nil {
+		writeError(w, http.StatusBadRequest, "target server is deleted")
+		return 0, 0, false
+	}
+	return from, req.ToServerID, true
+}
+
+// POST /api/servers/{id}/history-migration/preview
+//
+// Reports how the server's history would map onto the target server's
+// library. Changes nothing.
+func (s *Server) handlePreviewServerHistoryMigration(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := s.historyMigrationServers(w, r)
+	if !ok {
+		return
+	}
+	plan, err := s.store.PreviewServerHistoryMigration(r.Context(), from, to)
+	if err != nil {
+		log.Printf("previewing history migration: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// POST /api/servers/{id}/history-migration
+//
+// Moves the server's matched history to the target server. The target's
+// library should be synced first, since matching uses its library cache.
+func (s *Server) handleMigrateServerHistory(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := s.historyMigrationServers(w, r)
+	if !ok {
+		return
+	}
+	result, err := s.store.MigrateServerHistory(r.Context(), from, to)
+	if err != nil {
+		log.Printf("migrating history from server %d to %d: %v", from, to, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	var by string
+	if user := UserFromContext(r.Context()); user != nil {
+		by = user.Name
+	}
+	log.Printf("history migration: moved %d rows (%d titles) from server %d to %d (by %s)",
+		result.Moved, result.Matched, from, to, by)
+	writeJSON(w, http.StatusOK, result)
+}
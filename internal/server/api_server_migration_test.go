@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+func TestServerHistoryMigrationAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	oldSrv := &models.Server{Name: "Old", Type: models.ServerTypePlex, URL: "http://old", APIKey: "k", Enabled: true}
+	newSrv := &models.Server{Name: "New", Type: models.ServerTypePlex, URL: "http://new", APIKey: "k", Enabled: true}
+	for _, srv := range []*models.Server{oldSrv, newSrv} {
+		if err := st.CreateServer(srv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.SeedLibraryItemsForTest(context.Background(), []store.LibraryItemSeed{
+		{ServerID: newSrv.ID, LibraryID: "1", ItemID: "n1", MediaType: "movie", Title: "Arrival", Year: 2016, AddedAt: "2024-01-01T00:00:00Z"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	if err := st.InsertHistory(&models.WatchHistoryEntry{ServerID: oldSrv.ID, ItemID: "o1", UserName: "alice",
+		MediaType: models.MediaTypeMovie, Title: "Arrival", Year: 2016, StartedAt: start, StoppedAt: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+	base := fmt.Sprintf("/api/servers/%d/history-migration", oldSrv.ID)
+	if w := post(base, fmt.Sprintf(`{"to_server_id":%d}`, oldSrv.ID)); w.Code != http.StatusBadRequest {
+		t.Errorf("same server: %d", w.Code)
+	}
+	if w := post(base, `{"to_server_id":9999}`); w.Code != http.StatusNotFound {
+		t.Errorf("missing target: %d", w.Code)
+	}
+
+	w := post(base+"/preview", fmt.Sprintf(`{"to_server_id":%d}`, newSrv.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: %d %s", w.Code, w.Body.String())
+	}
+	var preview store.ServerHistoryMigration
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatal(err)
+	}
+	if preview.Matched != 1 || preview.Moved != 0 {
+		t.Errorf("preview = %+v", preview)
+	}
+
+	w = post(base, fmt.Sprintf(`{"to_server_id":%d}`, newSrv.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("migrate: %d %s", w.Code, w.Body.String())
+	}
+	var result store.ServerHistoryMigration
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Moved != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
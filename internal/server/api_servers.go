@@ -14,6 +14,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"streammon/internal/httputil"
 	"streammon/internal/media"
 	"streammon/internal/media/plex"
 	"streammon/internal/models"
@@ -74,6 +75,7 @@ func (s *Server) syncServerToPoller(srv *models.Server) {
 			return
 		}
 		s.poller.AddServer(srv.ID, ms)
+		s.poller.SetServerPolling(srv.ID, time.Duration(srv.PollIntervalSeconds)*time.Second, srv.Paused)
 	}
 }
 
@@ -81,6 +83,8 @@ func (s *Server) syncServerToPoller(srv *models.Server) {
 func redactServerForViewer(srv models.Server) models.Server {
 	srv.URL = ""
 	srv.MachineID = ""
+	srv.OwnerUserName = ""
+	srv.HTTP = httputil.ClientOptions{}
 	return srv
 }
 
@@ -103,9 +107,13 @@ func (s *Server) handleListServers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !isAdmin {
-		for i := range servers {
-			servers[i] = redactServerForViewer(servers[i])
+		visible := servers[:0]
+		for _, srv := range servers {
+			if visibleToWorkspace(r, srv.ID) {
+				visible = append(visible, redactServerForViewer(srv))
+			}
 		}
+		servers = visible
 	}
 
 	writeJSON(w, http.StatusOK, servers)
@@ -127,6 +135,7 @@ func (s *Server) handleCreateServer(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
+	warnInsecureTLS("server "+srv.Name, srv.HTTP)
 	s.syncServerToPoller(srv)
 	s.InvalidateLibraryCache()
 	s.triggerServerSync(srv)
@@ -148,7 +157,7 @@ func (s *Server) handleGetServer(w http.ResponseWriter, r *http.Request) {
 	user := UserFromContext(r.Context())
 	isAdmin := user != nil && user.Role == models.RoleAdmin
 	if !isAdmin {
-		if srv.DeletedAt != nil {
+		if srv.DeletedAt != nil || !visibleToWorkspace(r, srv.ID) {
 			writeError(w, http.StatusNotFound, "not found")
 			return
 		}
@@ -192,6 +201,9 @@ func (s *Server) handleUpdateServer(w http.ResponseWriter, r *http.Request) {
 
 	srv := input.ToServer()
 	srv.ID = id
+	if input.HTTP == nil {
+		srv.HTTP = existing.HTTP
+	}
 	// Use ValidateForCreate to enforce machine_id for Plex servers on update too
 	// This forces remediation of legacy servers when they're edited
 	if err := srv.ValidateForCreate(); err != nil {
@@ -214,6 +226,7 @@ func (s *Server) handleUpdateServer(w http.ResponseWriter, r *http.Request) {
 		writeStoreError(w, err)
 		return
 	}
+	warnInsecureTLS("server "+srv.Name, srv.HTTP)
 
 	// Add new adapter after DB update succeeds
 	s.syncServerToPoller(srv)
@@ -222,6 +235,67 @@ func (s *Server) handleUpdateServer(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, srv)
 }
 
+// handleSetServerPolling changes a server's poll interval and paused flag
+// without re-adding it to the poller, so its tracked sessions survive.
+func (s *Server) handleSetServerPolling(w http.ResponseWriter, r *http.Request) {
+	id, err := parseServerID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var input models.ServerPolling
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetServerPolling(id, input); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	srv, err := s.store.GetServer(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if s.poller != nil {
+		s.poller.SetServerPolling(id, time.Duration(srv.PollIntervalSeconds)*time.Second, srv.Paused)
+	}
+	writeJSON(w, http.StatusOK, srv)
+}
+
+// handleSetServerLibraryFilter changes which libraries' plays a server
+// records to history. It takes effect for sessions that end afterwards.
+func (s *Server) handleSetServerLibraryFilter(w http.ResponseWriter, r *http.Request) {
+	id, err := parseServerID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var input models.ServerLibraryFilter
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetServerLibraryFilter(id, input); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	srv, err := s.store.GetServer(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, srv)
+}
+
 func (s *Server) handleDeleteServer(w http.ResponseWriter, r *http.Request) {
 	id, err := parseServerID(r)
 	if err != nil {
@@ -191,6 +191,84 @@ func TestUpdateServerPreservesAPIKeyWhenEmpty(t *testing.T) {
 	}
 }
 
+func TestUpdateServerOwnerStatsExclusion(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	st.CreateServer(&models.Server{Name: "Home", Type: models.ServerTypePlex, URL: "http://home", APIKey: "k", MachineID: "m123", Enabled: true})
+
+	body := `{"name":"Home","type":"plex","url":"http://home","machine_id":"m123","enabled":true,"exclude_owner_stats":true}`
+	req := httptest.NewRequest(http.MethodPut, "/api/servers/1", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without owner_user_name, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body = `{"name":"Home","type":"plex","url":"http://home","machine_id":"m123","enabled":true,"owner_user_name":" admin ","exclude_owner_stats":true}`
+	req = httptest.NewRequest(http.MethodPut, "/api/servers/1", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	got, err := st.GetServer(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OwnerUserName != "admin" || !got.ExcludeOwnerStats {
+		t.Fatalf("owner settings not saved: owner=%q exclude=%v", got.OwnerUserName, got.ExcludeOwnerStats)
+	}
+}
+
+func TestSetServerPollingAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	st.CreateServer(&models.Server{Name: "Remote", Type: models.ServerTypeEmby, URL: "http://remote", APIKey: "k", Enabled: true})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/servers/1/polling", strings.NewReader(`{"poll_interval_seconds":60,"paused":true}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	got, err := st.GetServer(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PollIntervalSeconds != 60 || !got.Paused {
+		t.Fatalf("polling not saved: interval=%d paused=%v", got.PollIntervalSeconds, got.Paused)
+	}
+
+	// A full server update keeps the polling schedule.
+	body := `{"name":"Remote 2","type":"emby","url":"http://remote","enabled":true}`
+	req = httptest.NewRequest(http.MethodPut, "/api/servers/1", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got, _ = st.GetServer(1)
+	if got.PollIntervalSeconds != 60 || !got.Paused {
+		t.Errorf("server update reset polling: interval=%d paused=%v", got.PollIntervalSeconds, got.Paused)
+	}
+
+	for _, tc := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/api/servers/1/polling", `{"poll_interval_seconds":-1}`, http.StatusBadRequest},
+		{"/api/servers/1/polling", `{"poll_interval_seconds":3601}`, http.StatusBadRequest},
+		{"/api/servers/999/polling", `{"poll_interval_seconds":5}`, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.path, tc.body, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
 func TestUpdateServerNotFoundAPI(t *testing.T) {
 	srv, _ := newTestServerWrapped(t)
 
@@ -776,3 +854,79 @@ func TestUpdateServerClearsMaintenanceDataOnMachineIDChange(t *testing.T) {
 		t.Fatalf("expected 0 items after machine_id change, got %d", count)
 	}
 }
+
+func TestUpdateServerHTTPOptionsAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	st.CreateServer(&models.Server{Name: "Home", Type: models.ServerTypePlex, URL: "https://home", APIKey: "k", MachineID: "m123", Enabled: true})
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/servers/1", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(`{"name":"Home","type":"plex","url":"https://home","machine_id":"m123","enabled":true,"http":{"insecure_skip_verify":true,"timeout_seconds":30}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Omitting http keeps the stored options.
+	w = put(`{"name":"Renamed","type":"plex","url":"https://home","machine_id":"m123","enabled":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got, err := st.GetServer(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.HTTP.InsecureSkipVerify || got.HTTP.TimeoutSeconds != 30 {
+		t.Fatalf("expected http options preserved, got %+v", got.HTTP)
+	}
+
+	w = put(`{"name":"Home","type":"plex","url":"https://home","machine_id":"m123","enabled":true,"http":{"proxy_url":"gopher://proxy"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid proxy, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSetServerLibraryFilterAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	st.CreateServer(&models.Server{Name: "Remote", Type: models.ServerTypeEmby, URL: "http://remote", APIKey: "k", Enabled: true})
+
+	body := `{"excluded_libraries":["home"," home ","fitness"]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/servers/1/library-filter", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	got, err := st.GetServer(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	excluded := got.LibraryFilter.ExcludedLibraries
+	if len(excluded) != 2 || excluded[0] != "home" || excluded[1] != "fitness" {
+		t.Fatalf("excluded libraries = %v, want [home fitness]", excluded)
+	}
+	if got.LibraryFilter.Records("home") || !got.LibraryFilter.Records("movies") {
+		t.Errorf("filter records the wrong libraries: %+v", got.LibraryFilter)
+	}
+
+	for _, tc := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/api/servers/1/library-filter", `{"included_libraries":["a"],"excluded_libraries":["a"]}`, http.StatusBadRequest},
+		{"/api/servers/1/library-filter", `not json`, http.StatusBadRequest},
+		{"/api/servers/999/library-filter", `{}`, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.path, tc.body, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+)
+
+const sessionDetailHistoryLimit = 5
+
+type sessionStreamPart struct {
+	Decision         models.TranscodeDecision `json:"decision,omitempty"`
+	SourceCodec      string                   `json:"source_codec,omitempty"`
+	StreamCodec      string                   `json:"stream_codec,omitempty"`
+	SourceResolution string                   `json:"source_resolution,omitempty"`
+	StreamResolution string                   `json:"stream_resolution,omitempty"`
+	DynamicRange     string                   `json:"dynamic_range,omitempty"`
+	Channels         int                      `json:"channels,omitempty"`
+}
+
+type sessionContainer struct {
+	Source     string  `json:"source,omitempty"`
+	Stream     string  `json:"stream,omitempty"`
+	HWDecode   bool    `json:"hw_decode"`
+	HWEncode   bool    `json:"hw_encode"`
+	Progress   float64 `json:"progress,omitempty"`
+	Bitrate    int64   `json:"bitrate,omitempty"`
+	Bandwidth  int64   `json:"bandwidth,omitempty"`
+	Transcoded bool    `json:"transcoded"`
+}
+
+type sessionStreams struct {
+	Video     *sessionStreamPart `json:"video,omitempty"`
+	Audio     *sessionStreamPart `json:"audio,omitempty"`
+	Subtitle  *sessionStreamPart `json:"subtitle,omitempty"`
+	Container sessionContainer   `json:"container"`
+}
+
+type sessionDetailResponse struct {
+	Session       models.ActiveStream        `json:"session"`
+	Streams       sessionStreams             `json:"streams"`
+	Geo           *models.GeoResult          `json:"geo"`
+	RecentHistory []models.WatchHistoryEntry `json:"recent_history"`
+	// ProgressSnapshots is the session's progress timeline, oldest first.
+	ProgressSnapshots []models.ProgressSnapshot `json:"progress_snapshots"`
+}
+
+// sessionStreamsOf splits a session's flat media fields into per-stream
+// source and delivered details.
+func sessionStreamsOf(as models.ActiveStream) sessionStreams {
+	var out sessionStreams
+	if as.VideoCodec != "" || as.VideoDecision != "" {
+		v := &sessionStreamPart{
+			Decision:         as.VideoDecision,
+			SourceCodec:      as.VideoCodec,
+			StreamCodec:      as.VideoCodec,
+			SourceResolution: as.VideoResolution,
+			StreamResolution: as.VideoResolution,
+			DynamicRange:     as.DynamicRange,
+		}
+		if as.VideoDecision == models.TranscodeDecisionTranscode {
+			if as.TranscodeVideoCodec != "" {
+				v.StreamCodec = as.TranscodeVideoCodec
+			}
+			if as.TranscodeVideoResolution != "" {
+				v.StreamResolution = as.TranscodeVideoResolution
+			}
+		}
+		out.Video = v
+	}
+	if as.AudioCodec != "" || as.AudioDecision != "" {
+		a := &sessionStreamPart{
+			Decision:    as.AudioDecision,
+			SourceCodec: as.AudioCodec,
+			StreamCodec: as.AudioCodec,
+			Channels:    as.AudioChannels,
+		}
+		if as.AudioDecision == models.TranscodeDecisionTranscode && as.TranscodeAudioCodec != "" {
+			a.StreamCodec = as.TranscodeAudioCodec
+		}
+		out.Audio = a
+	}
+	if as.SubtitleCodec != "" {
+		out.Subtitle = &sessionStreamPart{SourceCodec: as.SubtitleCodec, StreamCodec: as.SubtitleCodec}
+	}
+
+	c := sessionContainer{
+		Source:    as.Container,
+		Stream:    as.Container,
+		HWDecode:  as.TranscodeHWDecode,
+		HWEncode:  as.TranscodeHWEncode,
+		Progress:  as.TranscodeProgress,
+		Bitrate:   as.Bitrate,
+		Bandwidth: as.Bandwidth,
+	}
+	c.Transcoded = as.VideoDecision == models.TranscodeDecisionTranscode || as.AudioDecision == models.TranscodeDecisionTranscode
+	if as.TranscodeContainer != "" {
+		c.Stream = as.TranscodeContainer
+	}
+	out.Container = c
+	return out
+}
+
+// GET /api/sessions/{key}?server_id=
+//
+// key is the session ID reported in the live sessions list. server_id is
+// required only when the same ID is active on more than one server.
+func (s *Server) handleGetSessionDetail(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		writeError(w, http.StatusBadRequest, "invalid session key")
+		return
+	}
+	var serverID int64
+	if raw := r.URL.Query().Get("server_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid server_id")
+			return
+		}
+		serverID = id
+	}
+	if s.poller == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	var matches []models.ActiveStream
+	for _, as := range workspaceSessions(r, s.poller.CurrentSessions()) {
+		if as.SessionID != key || (serverID > 0 && as.ServerID != serverID) {
+			continue
+		}
+		// Viewers can only see their own sessions, as on the dashboard.
+		if user.Role == models.RoleViewer && as.UserName != user.Name {
+			continue
+		}
+		matches = append(matches, as)
+	}
+	switch len(matches) {
+	case 0:
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	case 1:
+	default:
+		writeError(w, http.StatusBadRequest, "session key is active on several servers, server_id is required")
+		return
+	}
+	as := matches[0]
+
+	resp := sessionDetailResponse{
+		Session:           as,
+		Streams:           sessionStreamsOf(as),
+		Geo:               s.sessionGeo(as.IPAddress),
+		RecentHistory:     []models.WatchHistoryEntry{},
+		ProgressSnapshots: as.ProgressSnapshots,
+	}
+	if resp.ProgressSnapshots == nil {
+		resp.ProgressSnapshots = []models.ProgressSnapshot{}
+	}
+
+	historyVisible := user.Role.CanReadAll()
+	if !historyVisible {
+		gs, err := s.store.GetGuestSettings()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		historyVisible = gs["visible_profile"] && gs["visible_watch_history"]
+		if resp.Geo != nil && !gs["visible_isps"] {
+			geo := *resp.Geo
+			geo.ISP = ""
+			resp.Geo = &geo
+		}
+	}
+	if historyVisible {
+		result, err := s.store.ListHistory(1, sessionDetailHistoryLimit, as.UserName, "", "", workspaceServerIDs(r))
+		if err != nil {
+			log.Printf("session detail history for %q: %v", as.UserName, err)
+		} else {
+			resp.RecentHistory = result.Items
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// sessionGeo resolves ip from the geo cache, falling back to the resolver.
+func (s *Server) sessionGeo(ip string) *models.GeoResult {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil
+	}
+	if geo, err := s.store.GetCachedGeo(ip); err == nil && geo != nil {
+		return geo
+	}
+	if s.geoResolver == nil {
+		return nil
+	}
+	geo := s.geoResolver.Lookup(parsed)
+	if geo != nil {
+		if err := s.store.SetCachedGeo(geo); err != nil {
+			log.Printf("caching geo for %s: %v", ip, err)
+		}
+	}
+	return geo
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestSessionDetail(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ts.Server.SetPollerForTest(&fakePoller{sessions: []models.ActiveStream{
+		{
+			SessionID: "abc", ServerID: 1, UserName: "alice", Title: "Movie",
+			Container: "mkv", VideoCodec: "hevc", VideoResolution: "4k", AudioCodec: "truehd", AudioChannels: 8,
+			VideoDecision: models.TranscodeDecisionTranscode, TranscodeVideoCodec: "h264", TranscodeVideoResolution: "1080",
+			AudioDecision: models.TranscodeDecisionCopy, TranscodeContainer: "mpegts", IPAddress: "203.0.113.9",
+			StartedAt: time.Now().UTC(), ProgressSnapshots: []models.ProgressSnapshot{{ProgressMs: 60000, ProgressPercent: 10}},
+		},
+		{SessionID: "dup", ServerID: 1, UserName: "bob"},
+		{SessionID: "dup", ServerID: 2, UserName: "bob"},
+	}})
+	if err := st.SetCachedGeo(&models.GeoResult{IP: "203.0.113.9", City: "Oslo", Country: "NO", ISP: "Telenor"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions/abc", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp sessionDetailResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Session.Title != "Movie" {
+		t.Errorf("session = %+v", resp.Session)
+	}
+	v := resp.Streams.Video
+	if v == nil || v.SourceCodec != "hevc" || v.StreamCodec != "h264" || v.StreamResolution != "1080" {
+		t.Errorf("video = %+v", v)
+	}
+	if a := resp.Streams.Audio; a == nil || a.StreamCodec != "truehd" || a.Channels != 8 {
+		t.Errorf("audio = %+v", a)
+	}
+	if c := resp.Streams.Container; c.Source != "mkv" || c.Stream != "mpegts" || !c.Transcoded {
+		t.Errorf("container = %+v", c)
+	}
+	if resp.Geo == nil || resp.Geo.City != "Oslo" || resp.Geo.ISP != "Telenor" {
+		t.Errorf("geo = %+v", resp.Geo)
+	}
+	if resp.RecentHistory == nil {
+		t.Error("expected recent_history list")
+	}
+	if len(resp.ProgressSnapshots) != 1 || resp.ProgressSnapshots[0].ProgressPercent != 10 {
+		t.Errorf("progress_snapshots = %+v", resp.ProgressSnapshots)
+	}
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/api/sessions/missing", http.StatusNotFound},
+		{"/api/sessions/dup", http.StatusBadRequest},
+		{"/api/sessions/dup?server_id=2", http.StatusOK},
+		{"/api/sessions/dup?server_id=x", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: status=%d, want %d", tc.path, w.Code, tc.want)
+		}
+	}
+}
+
+func TestSessionDetail_ViewerOwnSessionsOnly(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	ts.Server.SetPollerForTest(&fakePoller{sessions: []models.ActiveStream{
+		{SessionID: "mine", ServerID: 1, UserName: "viewer"},
+		{SessionID: "theirs", ServerID: 1, UserName: "other"},
+	}})
+	token := createViewerSession(t, st, "viewer")
+
+	for path, want := range map[string]int{
+		"/api/sessions/mine":   http.StatusOK,
+		"/api/sessions/theirs": http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: status=%d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+)
+
+// sharedPathPrefix is where share link tokens resolve without a login.
+const sharedPathPrefix = "/api/shared/"
+
+type shareLinkResponse struct {
+	models.ShareLink
+	Path string `json:"path,omitempty"`
+}
+
+func (s *Server) handleCreateShareLink(w http.ResponseWriter, r *http.Request) {
+	var input models.ShareLinkInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var err error
+	switch input.TargetType {
+	case models.ShareTargetHistory:
+		_, err = s.store.GetHistoryEntry(input.TargetID)
+	case models.ShareTargetViolation:
+		_, err = s.store.GetViolation(input.TargetID)
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var createdBy string
+	if user := UserFromContext(r.Context()); user != nil {
+		createdBy = user.Name
+	}
+	expiresAt := time.Now().UTC().Add(time.Duration(input.ExpiresInHours) * time.Hour)
+	link, err := s.store.CreateShareLink(input, createdBy, expiresAt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create share link")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, shareLinkResponse{ShareLink: *link, Path: sharedPathPrefix + link.Token})
+}
+
+func (s *Server) handleListShareLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := s.store.ListShareLinks()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list share links")
+		return
+	}
+	writeJSON(w, http.StatusOK, links)
+}
+
+func (s *Server) handleDeleteShareLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid share link id")
+		return
+	}
+	if err := s.store.DeleteShareLink(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetSharedRecord serves the record behind a share link to anyone
+// holding the token, until the link expires or is revoked.
+func (s *Server) handleGetSharedRecord(w http.ResponseWriter, r *http.Request) {
+	link, err := s.store.ResolveShareLink(chi.URLParam(r, "token"))
+	if err != nil {
+		if !errors.Is(err, models.ErrNotFound) {
+			log.Printf("resolving share link: %v", err)
+		}
+		writeError(w, http.StatusNotFound, "link not found or expired")
+		return
+	}
+
+	record := models.SharedRecord{TargetType: link.TargetType, ExpiresAt: link.ExpiresAt}
+	switch link.TargetType {
+	case models.ShareTargetHistory:
+		entry, err := s.store.GetHistoryEntry(link.TargetID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		sessions, err := s.store.ListSessionsForHistory(entry.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		record.History = entry
+		record.Sessions = sessions
+		if entry.IPAddress != "" {
+			if geo, err := s.store.GetCachedGeo(entry.IPAddress); err == nil && geo != nil {
+				record.Location = geo
+			}
+		}
+	case models.ShareTargetViolation:
+		violation, err := s.store.GetViolation(link.TargetID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		record.Violation = violation
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, record)
+}
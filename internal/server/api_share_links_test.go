@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestShareLinkHistoryAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	entry := &models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat",
+		IPAddress: "203.0.113.5", DurationMs: 1000, StartedAt: now.Add(-time.Hour), StoppedAt: now,
+	}
+	if err := st.InsertHistory(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/share-links",
+		strings.NewReader(`{"target_type":"history","target_id":`+strconv.FormatInt(entry.ID, 10)+`,"expires_in_hours":2}`))
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created shareLinkResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Token == "" || created.Path != sharedPathPrefix+created.Token {
+		t.Fatalf("unexpected response %+v", created)
+	}
+	if d := time.Until(created.ExpiresAt); d < 119*time.Minute || d > 2*time.Hour {
+		t.Fatalf("expected expiry about 2h out, got %v", d)
+	}
+
+	// The link works without a session.
+	req = httptest.NewRequest(http.MethodGet, created.Path, nil)
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var record models.SharedRecord
+	if err := json.NewDecoder(w.Body).Decode(&record); err != nil {
+		t.Fatal(err)
+	}
+	if record.History == nil || record.History.Title != "Heat" || record.Violation != nil {
+		t.Fatalf("unexpected shared record %+v", record)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/share-links/"+strconv.FormatInt(created.ID, 10), nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, created.Path, nil)
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after revoke, got %d", w.Code)
+	}
+}
+
+func TestShareLinkViolationAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	rule := &models.Rule{Name: "Streams", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	if err := st.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	v := &models.RuleViolation{RuleID: rule.ID, UserName: "bob", Severity: models.SeverityWarning, Message: "too many", OccurredAt: time.Now().UTC()}
+	if err := st.InsertViolation(v); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/share-links",
+		strings.NewReader(`{"target_type":"violation","target_id":`+strconv.FormatInt(v.ID, 10)+`}`))
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created shareLinkResponse
+	json.NewDecoder(w.Body).Decode(&created)
+
+	req = httptest.NewRequest(http.MethodGet, created.Path, nil)
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var record models.SharedRecord
+	json.NewDecoder(w.Body).Decode(&record)
+	if record.Violation == nil || record.Violation.RuleName != "Streams" || record.History != nil {
+		t.Fatalf("unexpected shared record %+v", record)
+	}
+}
+
+func TestCreateShareLinkValidation(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"target_type":"user","target_id":1}`, http.StatusBadRequest},
+		{`{"target_type":"history"}`, http.StatusBadRequest},
+		{`{"target_type":"history","target_id":1,"expires_in_hours":1000}`, http.StatusBadRequest},
+		{`{"target_type":"history","target_id":999}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/share-links", strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.body, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"streammon/internal/store"
+)
+
+const (
+	sharedIPDefaultDays     = 30
+	sharedIPDefaultMinUsers = 2
+	sharedIPDefaultMinIPs   = 5
+	sharedIPDefaultLimit    = 50
+	sharedIPMaxLimit        = 500
+)
+
+func (s *Server) handleGetSharedIPReport(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Days == 0 && filter.StartDate.IsZero() {
+		filter.Days = sharedIPDefaultDays
+	}
+	opts := store.SharedIPOptions{
+		Filter:         filter,
+		MinUsers:       sharedIPDefaultMinUsers,
+		MinIPs:         sharedIPDefaultMinIPs,
+		Limit:          sharedIPDefaultLimit,
+		IncludePrivate: r.URL.Query().Get("include_private") == "true",
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+		min  int
+	}{
+		{"min_users", &opts.MinUsers, 2},
+		{"min_ips", &opts.MinIPs, 2},
+		{"limit", &opts.Limit, 1},
+	} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < p.min {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be a number of at least %d", p.name, p.min))
+			return
+		}
+		*p.dst = n
+	}
+	opts.Limit = min(opts.Limit, sharedIPMaxLimit)
+
+	report, err := s.store.SharedIPReport(r.Context(), opts)
+	if err != nil {
+		log.Printf("shared ip report: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	ips := make([]string, len(report.SharedIPs))
+	for i, st := range report.SharedIPs {
+		ips[i] = st.IPAddress
+	}
+	geos, err := s.store.GetCachedGeos(ips)
+	if err != nil {
+		log.Printf("shared ip report: %v", err)
+	}
+	for i := range report.SharedIPs {
+		report.SharedIPs[i].Location = geos[report.SharedIPs[i].IPAddress]
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
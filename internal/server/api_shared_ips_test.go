@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestGetSharedIPReport(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	server := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://x", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().UTC().Add(-time.Hour)
+	for _, user := range []string{"alice", "bob"} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: server.ID, UserName: user, MediaType: models.MediaTypeMovie, Title: "Heat " + user,
+			IPAddress: "203.0.113.5", StartedAt: at, StoppedAt: at.Add(30 * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/shared-ips", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report models.SharedIPReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.SharedIPs) != 1 || report.SharedIPs[0].PeakConcurrent != 2 || len(report.MultiIPUsers) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	for _, q := range []string{"min_users=1", "min_ips=x", "limit=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats/shared-ips?"+q, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestGetSharedIPReportMasksIPsForCoAdmin(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createCoAdminSession(t, st, "helper")
+	server := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://x", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().UTC().Add(-time.Hour)
+	for _, e := range []struct{ user, title, ip string }{
+		{"alice", "Heat", "203.0.113.5"},
+		{"bob", "Heat", "203.0.113.5"},
+		{"alice", "Ronin", "198.51.100.9"},
+	} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: server.ID, UserName: e.user, MediaType: models.MediaTypeMovie, Title: e.title,
+			IPAddress: e.ip, StartedAt: at, StoppedAt: at.Add(30 * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/shared-ips?min_ips=2", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, ip := range []string{"203.0.113.5", "198.51.100.9"} {
+		if strings.Contains(body, ip) {
+			t.Errorf("raw ip %s leaked to co-admin: %s", ip, body)
+		}
+	}
+	var report models.SharedIPReport
+	if err := json.Unmarshal([]byte(body), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.SharedIPs) != 1 || len(report.MultiIPUsers) != 1 || len(report.MultiIPUsers[0].IPs) != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -43,7 +43,14 @@ func (s *Server) newSonarrClient() (*sonarr.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return sonarr.NewClient(cfg.URL, cfg.APIKey)
+	client, err := sonarr.NewClient(cfg.URL, cfg.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Configure(cfg.HTTP); err != nil {
+		return nil, err
+	}
+	return client, nil
 }
 
 func (s *Server) sonarrClientWithTimeout(w http.ResponseWriter, r *http.Request) (*sonarr.Client, context.Context, context.CancelFunc, bool) {
@@ -147,6 +154,11 @@ func (s *Server) handleSonarrPoster(w http.ResponseWriter, r *http.Request) {
 
 	imgURL := fmt.Sprintf("%s/api/v3/mediacover/%s/poster-250.jpg",
 		strings.TrimRight(cfg.URL, "/"), seriesID)
+	cacheKey := "sonarr/" + seriesID
+	if data, ct, ok := s.cachedPoster(cacheKey); ok {
+		writeImage(w, ct, data, "public, max-age=14400")
+		return
+	}
 
 	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, imgURL, nil)
 	if err != nil {
@@ -172,7 +184,17 @@ func (s *Server) handleSonarrPoster(w http.ResponseWriter, r *http.Request) {
 	if !strings.HasPrefix(ct, "image/") {
 		ct = "image/jpeg"
 	}
-	w.Header().Set("Content-Type", ct)
-	w.Header().Set("Cache-Control", "public, max-age=14400")
-	_, _ = io.Copy(w, io.LimitReader(resp.Body, 5<<20))
+	if s.posterCache == nil {
+		w.Header().Set("Content-Type", ct)
+		w.Header().Set("Cache-Control", "public, max-age=14400")
+		_, _ = io.Copy(w, io.LimitReader(resp.Body, maxPosterBytes))
+		return
+	}
+	data, err := readPoster(resp.Body)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "upstream error")
+		return
+	}
+	s.cachePoster(cacheKey, ct, data)
+	writeImage(w, ct, data, "public, max-age=14400")
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -15,6 +16,9 @@ import (
 type StatsResponse struct {
 	TopMovies            []models.MediaStat           `json:"top_movies"`
 	TopTVShows           []models.MediaStat           `json:"top_tv_shows"`
+	TopArtists           []models.MediaStat           `json:"top_artists"`
+	TopAlbums            []models.MediaStat           `json:"top_albums"`
+	TopTracks            []models.MediaStat           `json:"top_tracks"`
 	TopUsers             []models.UserStat            `json:"top_users"`
 	Library              *models.LibraryStat          `json:"library"`
 	Locations            []models.GeoResult           `json:"locations"`
@@ -25,65 +29,84 @@ type StatsResponse struct {
 	QualityDistribution  []models.DistributionStat    `json:"quality_distribution"`
 	ConcurrentTimeSeries []models.ConcurrentTimePoint `json:"concurrent_time_series"`
 	ConcurrentPeaks      models.ConcurrentPeaks       `json:"concurrent_peaks"`
+	WatchParties         []models.WatchParty          `json:"watch_parties"`
 }
 
-func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
-	if viewerName(r) != "" {
-		writeError(w, http.StatusForbidden, "forbidden")
-		return
-	}
-
+// parseStatsFilter reads the date range (days, or start_date and end_date),
+// server_ids, tz_offset, include_all_media, include_owner (admins only) and
+// compare query parameters shared by the stats endpoints. compare=previous
+// asks for comparisons against the previous period. Errors are safe to show.
+func parseStatsFilter(r *http.Request) (store.StatsFilter, error) {
 	var filter store.StatsFilter
+	q := r.URL.Query()
 
-	if d := r.URL.Query().Get("days"); d != "" {
+	if d := q.Get("days"); d != "" {
 		parsed, err := strconv.Atoi(d)
 		if err != nil || parsed < 0 {
-			writeError(w, http.StatusBadRequest, "days must be a non-negative number")
-			return
+			return filter, errors.New("days must be a non-negative number")
 		}
 		filter.Days = parsed
 	} else {
-		if sd := r.URL.Query().Get("start_date"); sd != "" {
+		if sd := q.Get("start_date"); sd != "" {
 			t, err := time.Parse("2006-01-02", sd)
 			if err != nil {
-				writeError(w, http.StatusBadRequest, "invalid start_date, use YYYY-MM-DD")
-				return
+				return filter, errors.New("invalid start_date, use YYYY-MM-DD")
 			}
 			filter.StartDate = t
 		}
-		if ed := r.URL.Query().Get("end_date"); ed != "" {
+		if ed := q.Get("end_date"); ed != "" {
 			t, err := time.Parse("2006-01-02", ed)
 			if err != nil {
-				writeError(w, http.StatusBadRequest, "invalid end_date, use YYYY-MM-DD")
-				return
+				return filter, errors.New("invalid end_date, use YYYY-MM-DD")
 			}
 			filter.EndDate = t.AddDate(0, 0, 1)
 		}
 		hasStart := !filter.StartDate.IsZero()
 		hasEnd := !filter.EndDate.IsZero()
 		if hasStart != hasEnd {
-			writeError(w, http.StatusBadRequest, "both start_date and end_date are required")
-			return
+			return filter, errors.New("both start_date and end_date are required")
 		}
 		if hasStart && hasEnd && !filter.EndDate.After(filter.StartDate) {
-			writeError(w, http.StatusBadRequest, "end_date must be after start_date")
-			return
+			return filter, errors.New("end_date must be after start_date")
 		}
 	}
 
-	sids, err := parseServerIDs(r.URL.Query().Get("server_ids"))
+	sids, err := parseServerIDs(q.Get("server_ids"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid server_ids")
-		return
+		return filter, errors.New("invalid server_ids")
 	}
 	filter.ServerIDs = sids
 
 	tzOffset, err := parseTZOffset(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid tz_offset")
-		return
+		return filter, errors.New("invalid tz_offset")
 	}
 	filter.TZOffsetMinutes = tzOffset
+	filter.IncludeAllMediaTypes = q.Get("include_all_media") == "true"
+	// Owner exclusion is the admin's call, so only an admin can lift it.
+	filter.IncludeOwnerPlays = q.Get("include_owner") == "true" && isAdmin(r)
+	switch q.Get("compare") {
+	case "":
+	case "previous":
+		filter.ComparePrevious = true
+	default:
+		return filter, errors.New("compare must be previous")
+	}
+	return filter, nil
+}
+
+func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	if viewerName(r) != "" {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	r = s.withPreferenceDefaults(r)
+
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	var resp StatsResponse
 	g, ctx := errgroup.WithContext(r.Context())
@@ -98,6 +121,21 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 		resp.TopTVShows, err = s.store.TopTVShows(ctx, 10, filter)
 		return err
 	})
+	g.Go(func() error {
+		var err error
+		resp.TopArtists, err = s.store.TopArtists(ctx, 10, filter)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		resp.TopAlbums, err = s.store.TopAlbums(ctx, 10, filter)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		resp.TopTracks, err = s.store.TopTracks(ctx, 10, filter)
+		return err
+	})
 	g.Go(func() error {
 		var err error
 		resp.TopUsers, err = s.store.TopUsers(ctx, 10, filter)
@@ -143,6 +181,11 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 		resp.ConcurrentTimeSeries, resp.ConcurrentPeaks, err = s.store.ConcurrentStats(ctx, filter)
 		return err
 	})
+	g.Go(func() error {
+		var err error
+		resp.WatchParties, err = s.store.ListWatchParties(ctx, filter, 10)
+		return err
+	})
 
 	if err := g.Wait(); err != nil {
 		log.Printf("stats error: %v", err)
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -259,6 +260,7 @@ func TestGetStatsAPI_InvalidDateRange(t *testing.T) {
 		{"end before start", "?start_date=2024-03-01&end_date=2024-01-01"},
 		{"start_date only", "?start_date=2024-01-01"},
 		{"end_date only", "?end_date=2024-02-01"},
+		{"unknown compare", "?days=30&compare=lastyear"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -272,6 +274,24 @@ func TestGetStatsAPI_InvalidDateRange(t *testing.T) {
 	}
 }
 
+func TestGetStatsAPI_ComparePrevious(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+
+	req := httptest.NewRequest("GET", "/api/stats?start_date=2024-10-01&end_date=2024-10-31&compare=previous", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp StatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Library == nil || resp.Library.Comparison == nil {
+		t.Fatal("expected library comparison with compare=previous")
+	}
+}
+
 func TestGetStatsAPI_WithServerIDs(t *testing.T) {
 	srv, _ := newTestServerWrapped(t)
 
@@ -293,3 +313,59 @@ func TestGetStatsAPI_InvalidServerIDs(t *testing.T) {
 		t.Fatalf("status = %d, want 400", w.Code)
 	}
 }
+
+func TestGetBandwidthStats(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	server := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://x", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RecordBandwidth(context.Background(), time.Now().UTC(), []models.BandwidthSample{
+		{ServerID: server.ID, UserName: "alice", Network: models.BandwidthWAN, Bytes: 1000, PeakBps: 8000},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/bandwidth?interval=daily", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var stats models.BandwidthStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Interval != models.BandwidthDaily || stats.WANBytes != 1000 || len(stats.Users) != 1 || len(stats.Servers) != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stats/bandwidth?interval=weekly", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad interval, got %d", w.Code)
+	}
+}
+
+func TestParseStatsFilter_IncludeOwnerAdminOnly(t *testing.T) {
+	tests := []struct {
+		role models.Role
+		want bool
+	}{
+		{models.RoleAdmin, true},
+		{models.RoleCoAdmin, false},
+		{models.RoleViewer, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats?include_owner=true", nil)
+		req = req.WithContext(contextWithUser(req.Context(), &models.User{Name: "u", Role: tt.role}))
+		filter, err := parseStatsFilter(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.role, err)
+		}
+		if filter.IncludeOwnerPlays != tt.want {
+			t.Errorf("%s: IncludeOwnerPlays = %v, want %v", tt.role, filter.IncludeOwnerPlays, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) handleGetUserStreaks(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !viewerCanAccessUser(r, name) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	user := UserFromContext(r.Context())
+	if user != nil && !user.Role.CanReadAll() {
+		profileVisible, err := s.store.GetGuestSetting("visible_profile")
+		if err != nil {
+			log.Printf("GetGuestSetting error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if !profileVisible {
+			writeError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+	}
+
+	loc, err := s.statsLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if loc == nil {
+		filter, err := parseStatsFilter(s.withPreferenceDefaults(r))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		loc = filter.Location()
+	}
+
+	streaks, err := s.store.UserStreaks(r.Context(), name, time.Now().In(loc), workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("user streaks: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, streaks)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestUserStreaksAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now().UTC().Add(-time.Hour)
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Movie",
+		WatchedMs: 3600000, DurationMs: 3600000, StartedAt: started, StoppedAt: started.Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/users/alice/streaks?tz=UTC", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var streaks models.UserStreaks
+	if err := json.NewDecoder(w.Body).Decode(&streaks); err != nil {
+		t.Fatal(err)
+	}
+	if streaks.UserName != "alice" || streaks.TotalHours != 1 || streaks.LongestStreakDays != 1 || streaks.Timezone != "UTC" {
+		t.Errorf("streaks = %+v", streaks)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stats/users/alice/streaks?tz=Nowhere/Special", nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown tz: expected 400, got %d", w.Code)
+	}
+
+	viewer := createViewerSession(t, st, "bob")
+	req = httptest.NewRequest(http.MethodGet, "/api/stats/users/alice/streaks", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewer})
+	w = httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("another user's streaks as viewer: expected 403, got %d", w.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"streammon/internal/models"
+	"streammon/internal/rules"
+)
+
+// streamMapPriorIPs bounds the earlier addresses checked when deciding
+// whether a stream comes from a first-time location.
+const streamMapPriorIPs = 100
+
+// streamMapEntry is one live session placed on the map. DistanceFromHomeKm
+// is nil when either the session or the member's home has no coordinates.
+// FirstTimeIP and FirstTimeCity are set when the member's earlier history
+// has no stream from the address or from the city.
+type streamMapEntry struct {
+	Session            models.ActiveStream   `json:"session"`
+	Geo                *models.GeoResult     `json:"geo"`
+	Home               *models.HouseholdHome `json:"home"`
+	DistanceFromHomeKm *float64              `json:"distance_from_home_km"`
+	AwayFromHome       bool                  `json:"away_from_home"`
+	Household          bool                  `json:"household"`
+	FirstTimeIP        bool                  `json:"first_time_ip"`
+	FirstTimeCity      bool                  `json:"first_time_city"`
+}
+
+// GET /api/dashboard/map
+//
+// handleStreamMap joins the live sessions with their location and each
+// member's household, so a map view needs one request per refresh.
+func (s *Server) handleStreamMap(w http.ResponseWriter, r *http.Request) {
+	entries := []streamMapEntry{}
+	if s.poller == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"sessions": entries})
+		return
+	}
+	sessions := workspaceSessions(r, s.poller.CurrentSessions())
+	if len(sessions) == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"sessions": entries})
+		return
+	}
+
+	locations, err := s.store.ListAllHouseholdLocations(workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("stream map: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	manual, err := s.store.ListManualHouseholdHomes()
+	if err != nil {
+		log.Printf("stream map: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	homes := make(map[string]*models.HouseholdHome)
+	for _, as := range sessions {
+		e := streamMapEntry{Session: as, Geo: s.sessionGeo(as.IPAddress)}
+
+		home, ok := homes[as.UserName]
+		if !ok {
+			if h, found := manual[as.UserName]; found {
+				home = &h
+			} else {
+				home = rules.LearnHouseholdHome(as.UserName, locations[as.UserName])
+			}
+			homes[as.UserName] = home
+		}
+		e.Home = home
+		if home != nil && e.Geo != nil && (e.Geo.Lat != 0 || e.Geo.Lng != 0) {
+			km := rules.HaversineDistance(home.Latitude, home.Longitude, e.Geo.Lat, e.Geo.Lng)
+			e.DistanceFromHomeKm = &km
+			e.AwayFromHome = km > home.RadiusKm
+		}
+		for _, l := range locations[as.UserName] {
+			if l.Trusted && l.IPAddress != "" && l.IPAddress == as.IPAddress {
+				e.Household = true
+				break
+			}
+		}
+		if as.IPAddress != "" {
+			e.FirstTimeIP, e.FirstTimeCity = s.firstTimeLocation(as, e.Geo)
+		}
+		entries = append(entries, e)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sessions": entries})
+}
+
+// firstTimeLocation reports whether the member's history before the session
+// has no stream from its address, and none from its city. Earlier addresses
+// are placed using the geo cache only, so an address never looked up doesn't
+// count toward the city.
+func (s *Server) firstTimeLocation(as models.ActiveStream, geo *models.GeoResult) (newIP, newCity bool) {
+	prior, err := s.store.GetUserDistinctIPs(as.UserName, as.StartedAt, streamMapPriorIPs)
+	if err != nil {
+		log.Printf("stream map prior IPs for %s: %v", as.UserName, err)
+		return false, false
+	}
+	newIP = true
+	for _, ip := range prior {
+		if ip == as.IPAddress {
+			newIP = false
+			break
+		}
+	}
+	if !newIP || geo == nil || geo.City == "" {
+		return newIP, false
+	}
+	cached, err := s.store.GetCachedGeos(prior)
+	if err != nil {
+		log.Printf("stream map cached geo for %s: %v", as.UserName, err)
+		return newIP, false
+	}
+	for _, g := range cached {
+		if strings.EqualFold(g.City, geo.City) && strings.EqualFold(g.Country, geo.Country) {
+			return newIP, false
+		}
+	}
+	return newIP, true
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestStreamMap(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := &models.Server{Name: "S", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	ts.Server.SetPollerForTest(&fakePoller{sessions: []models.ActiveStream{
+		{SessionID: "a", ServerID: srv.ID, UserName: "alice", IPAddress: "203.0.113.9", StartedAt: now},
+		{SessionID: "b", ServerID: srv.ID, UserName: "bob", IPAddress: "192.0.2.5", StartedAt: now},
+	}})
+
+	for _, geo := range []*models.GeoResult{
+		{IP: "203.0.113.9", Lat: 59.91, Lng: 10.75, City: "Oslo", Country: "NO"},
+		{IP: "198.51.100.1", Lat: 59.92, Lng: 10.74, City: "Oslo", Country: "NO"},
+		{IP: "192.0.2.5", Lat: 51.5, Lng: -0.12, City: "London", Country: "GB"},
+	} {
+		if err := st.SetCachedGeo(geo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// alice has watched from another address in Oslo, and lives in Bergen.
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Movie",
+		IPAddress: "198.51.100.1", StartedAt: now.Add(-48 * time.Hour), StoppedAt: now.Add(-47 * time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetHouseholdHome(&models.HouseholdHome{UserName: "alice", Latitude: 60.39, Longitude: 5.32}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpsertHouseholdLocation(&models.HouseholdLocation{
+		UserName: "bob", IPAddress: "192.0.2.5", City: "London", Country: "GB",
+		Latitude: 51.5, Longitude: -0.12, Trusted: true, SessionCount: 5, FirstSeen: now, LastSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/dashboard/map", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Sessions []streamMapEntry `json:"sessions"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("got %d sessions", len(resp.Sessions))
+	}
+
+	alice := resp.Sessions[0]
+	if alice.Geo == nil || alice.Geo.City != "Oslo" {
+		t.Errorf("alice geo = %+v", alice.Geo)
+	}
+	if alice.DistanceFromHomeKm == nil || *alice.DistanceFromHomeKm < 250 || !alice.AwayFromHome {
+		t.Errorf("alice distance = %v, away = %v", alice.DistanceFromHomeKm, alice.AwayFromHome)
+	}
+	if !alice.FirstTimeIP || alice.FirstTimeCity || alice.Household {
+		t.Errorf("alice flags = %+v", alice)
+	}
+
+	bob := resp.Sessions[1]
+	if !bob.Household || bob.AwayFromHome || bob.Home == nil {
+		t.Errorf("bob = %+v", bob)
+	}
+	if !bob.FirstTimeIP || !bob.FirstTimeCity {
+		t.Errorf("bob has no history, want first-time flags: %+v", bob)
+	}
+}
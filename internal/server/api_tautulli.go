@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
@@ -26,6 +29,9 @@ func (s *Server) tautulliDeps() integrationDeps {
 
 func tautulliStreamer(cfg store.IntegrationConfig) (importStreamer, error) {
 	client, err := tautulli.NewClient(cfg.URL, cfg.APIKey)
+	if err == nil {
+		err = client.Configure(cfg.HTTP)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +70,7 @@ func convertTautulliRecord(rec tautulli.HistoryRecord, serverID int64) *models.W
 
 	durationMs := clampMs(rec.Duration*1000, maxDurationMs)
 	watchedMs := clampMs(rec.PlayDuration*1000, maxDurationMs)
+	pausedMs := clampMs(int64(rec.PausedCounter)*1000, maxDurationMs)
 
 	return &models.WatchHistoryEntry{
 		ServerID:          serverID,
@@ -77,6 +84,7 @@ func convertTautulliRecord(rec tautulli.HistoryRecord, serverID int64) *models.W
 		Year:              int(rec.Year),
 		DurationMs:        durationMs,
 		WatchedMs:         watchedMs,
+		PausedMs:          pausedMs,
 		Player:            rec.Player,
 		Platform:          rec.Platform,
 		IPAddress:         rec.IPAddress,
@@ -90,6 +98,95 @@ func convertTautulliRecord(rec tautulli.HistoryRecord, serverID int64) *models.W
 	}
 }
 
+// convertTautulliDBRecord converts a row read from tautulli.db. Unlike the
+// API import, the database already carries stream details, so rows arrive
+// fully enriched.
+func convertTautulliDBRecord(rec tautulli.DBRecord, serverID int64) *models.WatchHistoryEntry {
+	entry := convertTautulliRecord(rec.HistoryRecord, serverID)
+	entry.TautulliReferenceID = int64(rec.ReferenceID)
+	enrichEntryFromStreamData(entry, &rec.Stream)
+	return entry
+}
+
+// tautulliDatabaseRun imports the tautulli.db at path.
+func tautulliDatabaseRun(serverID int64, path string) importJobRun {
+	return func(ctx context.Context, sink importSink) error {
+		err := tautulli.ReadDatabase(ctx, path, 1000, func(records []tautulli.DBRecord, total int) error {
+			entries := make([]*models.WatchHistoryEntry, 0, len(records))
+			for _, rec := range records {
+				entries = append(entries, convertTautulliDBRecord(rec, serverID))
+			}
+			return sink.batch(entries, total)
+		})
+		if errors.Is(err, tautulli.ErrNotTautulliDatabase) {
+			return &importFailure{"file is not a Tautulli database"}
+		}
+		return err
+	}
+}
+
+// handleTautulliDatabaseImport backfills history from an uploaded tautulli.db
+// as a background import job, streaming the same progress events as the API
+// import. Rows go through InsertHistoryBatch, so re-importing the same file
+// is a no-op.
+func (s *Server) handleTautulliDatabaseImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const maxUpload = 2 << 30      // 2 GiB; long-lived Tautulli installs grow large
+		const multipartSlack = 1 << 20 // 1 MiB headroom for form fields and multipart envelope
+		r.Body = http.MaxBytesReader(w, r.Body, maxUpload+multipartSlack)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "upload too large or invalid multipart form")
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		serverID, err := strconv.ParseInt(r.FormValue("server_id"), 10, 64)
+		if err != nil || serverID <= 0 {
+			writeError(w, http.StatusBadRequest, "server_id is required")
+			return
+		}
+
+		srv, err := s.store.GetServer(serverID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if srv.DeletedAt != nil {
+			writeError(w, http.StatusBadRequest, "server has been deleted")
+			return
+		}
+		if srv.Type != models.ServerTypePlex {
+			writeError(w, http.StatusBadRequest, "server must be Plex type")
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+
+		// SQLite needs a real path, so spool the upload even when the
+		// multipart reader kept it in memory. The job removes it when done.
+		path, err := s.spoolImportUpload("tautulli-*.db", func(f *os.File) error {
+			_, err := io.Copy(f, file)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR Tautulli database import: spool upload: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to read file")
+			return
+		}
+
+		job := &models.ImportJob{Source: models.ImportSourceTautulliDatabase, ServerID: serverID, FilePath: path}
+		s.startImportJobStream(w, r, job, tautulliDatabaseRun(serverID, path))
+		if job.ID == 0 {
+			os.Remove(path)
+		}
+	}
+}
+
 func convertTranscodeDecision(decision string) models.TranscodeDecision {
 	switch decision {
 	case "transcode":
@@ -156,6 +253,9 @@ func (s *Server) handleStartEnrichment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client, err := tautulli.NewClient(cfg.URL, cfg.APIKey)
+	if err == nil {
+		err = client.Configure(cfg.HTTP)
+	}
 	if err != nil {
 		log.Printf("ERROR enrichment: NewClient: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal")
@@ -1,10 +1,16 @@
 package server
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -328,3 +334,100 @@ func TestEnrichStatus_WithServerIDParam(t *testing.T) {
 		t.Fatalf("expected server_id 1, got %d", resp.ServerID)
 	}
 }
+
+func postTautulliDatabase(t *testing.T, srv *testServer, serverID int64, dbPath string) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("server_id", strconv.FormatInt(serverID, 10))
+	fw, _ := mw.CreateFormFile("file", "tautulli.db")
+	fw.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/tautulli/import-db", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func TestTautulliDatabaseImport(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	plex := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(plex); err != nil {
+		t.Fatal(err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "tautulli.db")
+	db, err := sql.Open("sqlite", "file:"+dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE session_history (id INTEGER PRIMARY KEY, reference_id INTEGER, started INTEGER, stopped INTEGER,
+			rating_key INTEGER, user TEXT, ip_address TEXT, paused_counter INTEGER, player TEXT, platform TEXT, media_type TEXT)`,
+		`CREATE TABLE session_history_metadata (id INTEGER PRIMARY KEY, title TEXT, year INTEGER, duration INTEGER)`,
+		`CREATE TABLE session_history_media_info (id INTEGER PRIMARY KEY, transcode_decision TEXT, video_codec TEXT)`,
+		`INSERT INTO session_history VALUES (1, 1, 1700000000, 1700003600, 100, 'alice', '10.0.0.1', 600, 'TV', 'Roku', 'movie')`,
+		`INSERT INTO session_history_metadata VALUES (1, 'The Movie', 2021, 7200000)`,
+		`INSERT INTO session_history_media_info VALUES (1, 'transcode', 'h264')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	w := postTautulliDatabase(t, srv, plex.ID, dbPath)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"type":"complete"`) || !strings.Contains(w.Body.String(), `"inserted":1`) {
+		t.Fatalf("expected complete event with 1 insert, got: %s", w.Body.String())
+	}
+
+	result, err := st.ListHistory(1, 10, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 {
+		t.Fatalf("expected 1 history row, got %d", len(result.Items))
+	}
+	got := result.Items[0]
+	if got.UserName != "alice" || got.Title != "The Movie" || got.IPAddress != "10.0.0.1" || got.Player != "TV" {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+	if got.WatchedMs != 3000*1000 || got.PausedMs != 600*1000 {
+		t.Errorf("watched=%d paused=%d, want 3000000/600000", got.WatchedMs, got.PausedMs)
+	}
+	if got.TranscodeDecision != models.TranscodeDecisionTranscode || got.VideoCodec != "h264" {
+		t.Errorf("transcode=%q codec=%q", got.TranscodeDecision, got.VideoCodec)
+	}
+
+	// Importing the same file again is deduplicated.
+	w = postTautulliDatabase(t, srv, plex.ID, dbPath)
+	if !strings.Contains(w.Body.String(), `"inserted":0`) {
+		t.Fatalf("expected re-import to insert nothing, got: %s", w.Body.String())
+	}
+}
+
+func TestTautulliDatabaseImport_NotTautulli(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	plex := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(plex); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("definitely not a sqlite database file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := postTautulliDatabase(t, srv, plex.ID, path)
+	if !strings.Contains(w.Body.String(), "not a Tautulli database") {
+		t.Fatalf("expected not-a-database error event, got %d: %s", w.Code, w.Body.String())
+	}
+}
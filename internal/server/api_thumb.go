@@ -1,8 +1,11 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -11,7 +14,10 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"streammon/internal/diskcache"
+	"streammon/internal/httputil"
 	"streammon/internal/models"
+	"streammon/internal/store"
 )
 
 var (
@@ -33,6 +39,112 @@ func escapePathSegments(path string) string {
 	return strings.Join(segments, "/")
 }
 
+// thumbImageURL maps a thumb path, as stored on sessions and history rows, to
+// the image URL on srv. Errors are safe to show to clients.
+func thumbImageURL(srv *models.Server, thumbPath string) (string, error) {
+	baseURL := strings.TrimRight(srv.URL, "/")
+	switch srv.Type {
+	case models.ServerTypePlex:
+		if strings.Contains(thumbPath, "/") {
+			if !validPlexThumbPath.MatchString(thumbPath) {
+				return "", errors.New("invalid plex thumb path")
+			}
+			return fmt.Sprintf("%s/%s?X-Plex-Token=%s", baseURL, escapePathSegments(thumbPath), srv.APIKey), nil
+		}
+		if !validPlexIDPattern.MatchString(thumbPath) {
+			return "", errors.New("invalid plex id format")
+		}
+		return fmt.Sprintf("%s/library/metadata/%s/thumb?X-Plex-Token=%s", baseURL, thumbPath, srv.APIKey), nil
+	case models.ServerTypeEmby, models.ServerTypeJellyfin:
+		if strings.HasPrefix(thumbPath, "user/") {
+			userID := strings.TrimPrefix(thumbPath, "user/")
+			if !validUserIDPattern.MatchString(userID) {
+				return "", errors.New("invalid user id format")
+			}
+			return fmt.Sprintf("%s/Users/%s/Images/Primary?maxHeight=300", baseURL, userID), nil
+		}
+		if !validItemIDPattern.MatchString(thumbPath) {
+			return "", errors.New("invalid item id format")
+		}
+		return fmt.Sprintf("%s/Items/%s/Images/Primary?maxHeight=300", baseURL, url.PathEscape(thumbPath)), nil
+	}
+	return "", errors.New("unsupported server type")
+}
+
+func thumbImageRequest(ctx context.Context, srv *models.Server, imgURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imgURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if srv.Type == models.ServerTypeEmby || srv.Type == models.ServerTypeJellyfin {
+		req.Header.Set("X-Emby-Token", srv.APIKey)
+	}
+	return req, nil
+}
+
+// PosterFetcher loads artwork from media servers for notifications, using the
+// same URL rules as the thumb proxy.
+type PosterFetcher struct {
+	store  *store.Store
+	client *http.Client
+	cache  *diskcache.Cache
+}
+
+// NewPosterFetcher returns a fetcher that shares cache, which may be nil,
+// with the thumb proxy.
+func NewPosterFetcher(st *store.Store, cache *diskcache.Cache) *PosterFetcher {
+	return &PosterFetcher{store: st, client: httputil.NewClient(), cache: cache}
+}
+
+// FetchPoster implements notifier.PosterFetcher.
+func (f *PosterFetcher) FetchPoster(ctx context.Context, serverID int64, thumb string) ([]byte, string, error) {
+	thumb = strings.TrimLeft(thumb, "/")
+	if thumb == "" || !isValidPathSegment(thumb) {
+		return nil, "", errors.New("invalid thumb path")
+	}
+	srv, err := f.store.GetServer(serverID)
+	if err != nil {
+		return nil, "", err
+	}
+	imgURL, err := thumbImageURL(srv, thumb)
+	if err != nil {
+		return nil, "", err
+	}
+	cacheKey := thumbCacheKey(serverID, thumb)
+	if f.cache != nil {
+		if data, ct, ok := f.cache.Get(cacheKey); ok {
+			return data, ct, nil
+		}
+	}
+	req, err := thumbImageRequest(ctx, srv, imgURL)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetching poster: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, "", fmt.Errorf("fetching poster: status %d", resp.StatusCode)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "image/") {
+		return nil, "", fmt.Errorf("fetching poster: unexpected content type %q", ct)
+	}
+	data, err := readPoster(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading poster: %w", err)
+	}
+	if f.cache != nil {
+		if err := f.cache.Put(cacheKey, ct, data); err != nil {
+			log.Printf("caching poster: %v", err)
+		}
+	}
+	return data, ct, nil
+}
+
 func (s *Server) handleThumbProxy(w http.ResponseWriter, r *http.Request) {
 	serverID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
@@ -52,60 +164,29 @@ func (s *Server) handleThumbProxy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	srv, err := s.store.GetServer(serverID)
-	if err != nil {
+	if err != nil || !visibleToWorkspace(r, serverID) {
 		writeError(w, http.StatusNotFound, "server not found")
 		return
 	}
 
-	baseURL := strings.TrimRight(srv.URL, "/")
-	var imgURL string
+	imgURL, err := thumbImageURL(srv, thumbPath)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	switch srv.Type {
-	case models.ServerTypePlex:
-		if strings.Contains(thumbPath, "/") {
-			if !validPlexThumbPath.MatchString(thumbPath) {
-				writeError(w, http.StatusBadRequest, "invalid plex thumb path")
-				return
-			}
-			imgURL = fmt.Sprintf("%s/%s?X-Plex-Token=%s", baseURL, escapePathSegments(thumbPath), srv.APIKey)
-		} else {
-			if !validPlexIDPattern.MatchString(thumbPath) {
-				writeError(w, http.StatusBadRequest, "invalid plex id format")
-				return
-			}
-			imgURL = fmt.Sprintf("%s/library/metadata/%s/thumb?X-Plex-Token=%s", baseURL, thumbPath, srv.APIKey)
-		}
-	case models.ServerTypeEmby, models.ServerTypeJellyfin:
-		if strings.HasPrefix(thumbPath, "user/") {
-			userID := strings.TrimPrefix(thumbPath, "user/")
-			if !validUserIDPattern.MatchString(userID) {
-				writeError(w, http.StatusBadRequest, "invalid user id format")
-				return
-			}
-			imgURL = fmt.Sprintf("%s/Users/%s/Images/Primary?maxHeight=300", baseURL, userID)
-		} else {
-			if !validItemIDPattern.MatchString(thumbPath) {
-				writeError(w, http.StatusBadRequest, "invalid item id format")
-				return
-			}
-			imgURL = fmt.Sprintf("%s/Items/%s/Images/Primary?maxHeight=300", baseURL, url.PathEscape(thumbPath))
-		}
-	default:
-		writeError(w, http.StatusBadRequest, "unsupported server type")
+	cacheKey := thumbCacheKey(serverID, thumbPath)
+	if data, ct, ok := s.cachedPoster(cacheKey); ok {
+		writeImage(w, ct, data, "public, max-age=3600")
 		return
 	}
 
-	ctx := r.Context()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imgURL, nil)
+	req, err := thumbImageRequest(r.Context(), srv, imgURL)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "bad request")
 		return
 	}
 
-	if srv.Type == models.ServerTypeEmby || srv.Type == models.ServerTypeJellyfin {
-		req.Header.Set("X-Emby-Token", srv.APIKey)
-	}
-
 	resp, err := s.thumbProxyHTTP.Do(req)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, "upstream error")
@@ -123,7 +204,62 @@ func (s *Server) handleThumbProxy(w http.ResponseWriter, r *http.Request) {
 	if !strings.HasPrefix(ct, "image/") {
 		ct = "image/jpeg"
 	}
-	w.Header().Set("Content-Type", ct)
-	w.Header().Set("Cache-Control", "public, max-age=3600")
-	_, _ = io.Copy(w, io.LimitReader(resp.Body, 5<<20))
+	if s.posterCache == nil {
+		w.Header().Set("Content-Type", ct)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_, _ = io.Copy(w, io.LimitReader(resp.Body, maxPosterBytes))
+		return
+	}
+	data, err := readPoster(resp.Body)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "upstream error")
+		return
+	}
+	s.cachePoster(cacheKey, ct, data)
+	writeImage(w, ct, data, "public, max-age=3600")
+}
+
+// maxPosterBytes caps a proxied poster.
+const maxPosterBytes = 5 << 20
+
+var errPosterTooLarge = errors.New("poster exceeds 5 MB")
+
+// readPoster reads a whole poster body, failing instead of returning a
+// truncated image when it's larger than maxPosterBytes, so a partial image
+// never lands in the poster cache.
+func readPoster(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPosterBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPosterBytes {
+		return nil, errPosterTooLarge
+	}
+	return data, nil
+}
+
+func thumbCacheKey(serverID int64, thumbPath string) string {
+	return fmt.Sprintf("thumb/%d/%s", serverID, thumbPath)
+}
+
+func (s *Server) cachedPoster(key string) ([]byte, string, bool) {
+	if s.posterCache == nil {
+		return nil, "", false
+	}
+	return s.posterCache.Get(key)
+}
+
+func (s *Server) cachePoster(key, contentType string, data []byte) {
+	if s.posterCache == nil {
+		return
+	}
+	if err := s.posterCache.Put(key, contentType, data); err != nil {
+		log.Printf("caching poster: %v", err)
+	}
+}
+
+func writeImage(w http.ResponseWriter, contentType string, data []byte, cacheControl string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	_, _ = w.Write(data)
 }
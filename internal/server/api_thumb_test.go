@@ -3,8 +3,10 @@ package server
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
+	"streammon/internal/diskcache"
 	"streammon/internal/models"
 )
 
@@ -193,3 +195,67 @@ func TestValidUserIDPattern(t *testing.T) {
 		}
 	}
 }
+
+func TestThumbProxy_DiskCache(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png-bytes"))
+	}))
+	defer upstream.Close()
+
+	srv, st := newTestServerWrapped(t)
+	cache, err := diskcache.Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.posterCache = cache
+	st.CreateServer(&models.Server{
+		Name: "Plex", Type: models.ServerTypePlex,
+		URL: upstream.URL, APIKey: "k", Enabled: true,
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/servers/1/thumb/123", nil))
+		if w.Code != http.StatusOK || w.Body.String() != "png-bytes" || w.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("request %d: status=%d type=%q body=%q", i, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("upstream hit %d times, want 1", hits.Load())
+	}
+}
+
+func TestThumbProxy_OversizedPosterNotCached(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, maxPosterBytes+1))
+	}))
+	defer upstream.Close()
+
+	srv, st := newTestServerWrapped(t)
+	cache, err := diskcache.Open(t.TempDir(), 16<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.posterCache = cache
+	st.CreateServer(&models.Server{
+		Name: "Plex", Type: models.ServerTypePlex,
+		URL: upstream.URL, APIKey: "k", Enabled: true,
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/servers/1/thumb/123", nil))
+		if w.Code != http.StatusBadGateway {
+			t.Fatalf("request %d: expected 502 for an oversized poster, got %d", i, w.Code)
+		}
+	}
+	if hits.Load() != 2 {
+		t.Errorf("upstream hit %d times, want 2 (truncated poster must not be cached)", hits.Load())
+	}
+}
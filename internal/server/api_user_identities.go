@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+)
+
+func (s *Server) handleListDuplicateUserNames(w http.ResponseWriter, r *http.Request) {
+	dups, err := s.store.ListDuplicateUserNames(r.Context())
+	if err != nil {
+		log.Printf("ListDuplicateUserNames error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if WorkspaceFromContext(r.Context()) != nil {
+		// Only accounts on the workspace's servers are shown, so a name is a
+		// duplicate there only if two of them remain.
+		scoped := dups[:0]
+		for _, d := range dups {
+			accounts := make([]models.UserAccount, 0, len(d.Accounts))
+			for _, a := range d.Accounts {
+				if visibleToWorkspace(r, a.ServerID) {
+					accounts = append(accounts, a)
+				}
+			}
+			if len(accounts) > 1 {
+				d.Accounts = accounts
+				scoped = append(scoped, d)
+			}
+		}
+		dups = scoped
+	}
+	writeJSON(w, http.StatusOK, dups)
+}
+
+func (s *Server) handleListUserIdentityLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := s.store.ListUserIdentityLinks()
+	if err != nil {
+		log.Printf("ListUserIdentityLinks error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, links)
+}
+
+// handleLinkUserIdentity merges a server account into a named identity.
+// Accounts sharing an identity are reported as one person.
+func (s *Server) handleLinkUserIdentity(w http.ResponseWriter, r *http.Request) {
+	var link models.UserAccount
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := link.ValidateLink(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	srv, err := s.store.GetServer(link.ServerID)
+	if errors.Is(err, models.ErrNotFound) {
+		writeError(w, http.StatusBadRequest, "server not found")
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := s.store.LinkUserIdentity(&link); err != nil {
+		log.Printf("LinkUserIdentity error: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to link identity")
+		return
+	}
+	link.ServerName = srv.Name
+	writeJSON(w, http.StatusOK, link)
+}
+
+func (s *Server) handleUnlinkUserIdentity(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := parseIDParam(r, "serverID")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid server id")
+		return
+	}
+	if err := s.store.UnlinkUserIdentity(serverID, chi.URLParam(r, "name")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
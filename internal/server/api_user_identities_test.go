@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestUserIdentityAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	var serverIDs []int64
+	for _, name := range []string{"Plex", "Emby"} {
+		srv := &models.Server{Name: name, Type: models.ServerTypePlex, URL: "http://" + name, APIKey: "k", Enabled: true}
+		if err := st.CreateServer(srv); err != nil {
+			t.Fatal(err)
+		}
+		serverIDs = append(serverIDs, srv.ID)
+	}
+	now := time.Now().UTC()
+	for _, id := range []int64{serverIDs[0], serverIDs[1], serverIDs[1]} {
+		entry := &models.WatchHistoryEntry{
+			ServerID: id, UserName: "alex", MediaType: models.MediaTypeMovie, Title: "Heat",
+			WatchedMs: 3600000, DurationMs: 3600000, StartedAt: now.Add(-time.Hour), StoppedAt: now,
+		}
+		if err := st.InsertHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(-2 * time.Hour)
+	}
+
+	sessionCount := func(query string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/users/alex/stats"+query, nil)
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("stats%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var stats models.UserDetailStats
+		if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+			t.Fatal(err)
+		}
+		return stats.SessionCount
+	}
+
+	if n := sessionCount(""); n != 3 {
+		t.Errorf("unscoped: expected 3 sessions, got %d", n)
+	}
+	if n := sessionCount("?server_id=" + strconv.FormatInt(serverIDs[0], 10)); n != 1 {
+		t.Errorf("server scoped: expected 1 session, got %d", n)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/alex/stats?server_id=abc", nil)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid server_id: expected 400, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users/duplicates", nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("duplicates: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var dups []models.DuplicateUserName
+	if err := json.NewDecoder(w.Body).Decode(&dups); err != nil {
+		t.Fatal(err)
+	}
+	if len(dups) != 1 || dups[0].UserName != "alex" || dups[0].Merged {
+		t.Fatalf("unexpected duplicates: %+v", dups)
+	}
+
+	for _, id := range serverIDs {
+		body := `{"server_id":` + strconv.FormatInt(id, 10) + `,"user_name":"alex","identity":"Alex"}`
+		req = httptest.NewRequest(http.MethodPut, "/api/user-identities", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("link: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if n := sessionCount("?server_id=" + strconv.FormatInt(serverIDs[0], 10)); n != 3 {
+		t.Errorf("linked: expected 3 sessions, got %d", n)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/user-identities",
+		strings.NewReader(`{"server_id":9999,"user_name":"alex","identity":"Alex"}`))
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown server: expected 400, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/user-identities/"+strconv.FormatInt(serverIDs[1], 10)+"/alex", nil)
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unlink: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := sessionCount("?server_id=" + strconv.FormatInt(serverIDs[0], 10)); n != 1 {
+		t.Errorf("after unlink: expected 1 session, got %d", n)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -21,7 +22,7 @@ func viewerCanAccessUser(r *http.Request, targetName string) bool {
 	if user == nil {
 		return false
 	}
-	if user.Role == models.RoleAdmin {
+	if user.Role.CanReadAll() {
 		return true
 	}
 	return user.Name == targetName
@@ -74,11 +75,26 @@ func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal")
 		return
 	}
+	visible, err := s.workspaceUserNames(r)
+	if err != nil {
+		log.Printf("workspace users: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if visible != nil {
+		scoped := make([]models.User, 0, len(users))
+		for _, u := range users {
+			if visible[u.Name] {
+				scoped = append(scoped, u)
+			}
+		}
+		users = scoped
+	}
 	writeJSON(w, http.StatusOK, users)
 }
 
-func (s *Server) handleListUserSummaries(w http.ResponseWriter, _ *http.Request) {
-	summaries, err := s.store.ListUserSummaries()
+func (s *Server) handleListUserSummaries(w http.ResponseWriter, r *http.Request) {
+	summaries, err := s.store.ListUserSummaries(workspaceServerIDs(r))
 	if err != nil {
 		log.Printf("ListUserSummaries error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal")
@@ -87,6 +103,25 @@ func (s *Server) handleListUserSummaries(w http.ResponseWriter, _ *http.Request)
 	writeJSON(w, http.StatusOK, summaries)
 }
 
+func (s *Server) handleListArchivedUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.store.ListArchivedUsers(r.Context())
+	if err != nil {
+		log.Printf("ListArchivedUsers error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if WorkspaceFromContext(r.Context()) != nil {
+		scoped := users[:0]
+		for _, u := range users {
+			if visibleToWorkspace(r, u.ServerID) {
+				scoped = append(scoped, u)
+			}
+		}
+		users = scoped
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
 func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 
@@ -126,7 +161,7 @@ func (s *Server) handleGetUserLocations(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	ipResults, err := s.store.DistinctIPsForUser(name)
+	ipResults, err := s.store.DistinctIPsForUser(name, workspaceServerIDs(r))
 	if err != nil {
 		log.Printf("DistinctIPsForUser error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal")
@@ -187,7 +222,7 @@ func (s *Server) handleGetUserStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := UserFromContext(r.Context())
-	if user != nil && user.Role != models.RoleAdmin {
+	if user != nil && !user.Role.CanReadAll() {
 		profileVisible, err := s.store.GetGuestSetting("visible_profile")
 		if err != nil {
 			log.Printf("GetGuestSetting error: %v", err)
@@ -200,7 +235,26 @@ func (s *Server) handleGetUserStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := s.store.UserDetailStats(r.Context(), name)
+	// server_id narrows the stats to that server's account and the accounts
+	// linked to it, so two different people sharing a name don't merge.
+	var serverID int64
+	if raw := r.URL.Query().Get("server_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid server_id")
+			return
+		}
+		serverID = id
+	}
+	scope, err := s.store.ResolveUserScope(r.Context(), serverID, name)
+	if err != nil {
+		log.Printf("ResolveUserScope error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	scope.ServerIDs = workspaceServerIDs(r)
+
+	stats, err := s.store.UserDetailStatsScoped(r.Context(), scope)
 	if err != nil {
 		log.Printf("UserDetailStats error: %v", err)
 		writeError(w, http.StatusInternalServerError, "internal")
@@ -229,7 +283,7 @@ func (s *Server) handleGetUserStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if user != nil && user.Role != models.RoleAdmin {
+	if user != nil && !user.Role.CanReadAll() {
 		devicesVisible, err := s.store.GetGuestSetting("visible_devices")
 		if err != nil {
 			log.Printf("GetGuestSetting error: %v", err)
@@ -247,6 +301,7 @@ func (s *Server) handleGetUserStats(w http.ResponseWriter, r *http.Request) {
 		}
 		if !ispsVisible {
 			stats.ISPs = nil
+			stats.NetworkLabels = nil
 		}
 	}
 
@@ -286,6 +341,10 @@ func (s *Server) handleSyncUserAvatars(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		if _, _, err := s.store.ReconcileServerUsers(r.Context(), srv.ID, models.MediaUserNames(users), time.Now().UTC()); err != nil {
+			log.Printf("ReconcileServerUsers %s: %v", srv.Name, err)
+		}
+
 		result, err := s.store.SyncUsersFromServer(srv.ID, string(srv.Type), users)
 		if err != nil {
 			log.Printf("SyncUsersFromServer %s: %v", srv.Name, err)
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -287,6 +288,44 @@ func TestListUserSummaries_AdminAllowed(t *testing.T) {
 	}
 }
 
+func TestListArchivedUsersAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+	viewerToken := createViewerSession(t, st, "viewer")
+	server := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for i, present := range [][]string{{"owner", "alice"}, {"owner"}, {"owner"}} {
+		if _, _, err := st.ReconcileServerUsers(ctx, server.ID, present, now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/archived", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var users []models.ArchivedUser
+	if err := json.NewDecoder(w.Body).Decode(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].UserName != "alice" || users[0].ServerName != "Plex" {
+		t.Errorf("archived users = %+v, want alice on Plex", users)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users/archived", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: viewerToken})
+	w = httptest.NewRecorder()
+	srv.Server.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer status = %d, want 403", w.Code)
+	}
+}
+
 func TestUserNotesAPI_RoundTrip(t *testing.T) {
 	srv, st := newTestServerWrapped(t)
 	if _, err := st.GetOrCreateUser("alice"); err != nil {
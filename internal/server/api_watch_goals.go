@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+)
+
+const maxDailyWatchTimeDays = 366
+
+// watchGoalNow is the current time in the zone weeks and days are split in:
+// ?tz_offset= when given, else the server's, which limit notifications use.
+func watchGoalNow(r *http.Request) (time.Time, error) {
+	now := time.Now()
+	if r.URL.Query().Get("tz_offset") == "" {
+		return now, nil
+	}
+	offset, err := parseTZOffset(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.In(time.FixedZone("", offset*60)), nil
+}
+
+// GET /api/watch-goals
+//
+// Reports this week's progress for every user with a goal.
+func (s *Server) handleListWatchGoals(w http.ResponseWriter, r *http.Request) {
+	now, err := watchGoalNow(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tz_offset")
+		return
+	}
+	goals, err := s.store.ListWatchGoals()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	visible, err := s.workspaceUserNames(r)
+	if err != nil {
+		log.Printf("workspace users: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	progress := make([]*models.WatchGoalProgress, 0, len(goals))
+	for _, g := range goals {
+		if visible != nil && !visible[g.UserName] {
+			continue
+		}
+		p, err := s.store.WatchGoalProgress(r.Context(), g, now, workspaceServerIDs(r))
+		if err != nil {
+			log.Printf("watch goal progress for %s: %v", g.UserName, err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		progress = append(progress, p)
+	}
+	writeJSON(w, http.StatusOK, progress)
+}
+
+// GET /api/users/{name}/watch-goal
+func (s *Server) handleGetWatchGoal(w http.ResponseWriter, r *http.Request) {
+	userName := chi.URLParam(r, "name")
+	if !s.requireGuestVisibility(w, r, userName, "visible_watch_history") {
+		return
+	}
+	now, err := watchGoalNow(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tz_offset")
+		return
+	}
+	goal, err := s.store.GetWatchGoal(userName)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	p, err := s.store.WatchGoalProgress(r.Context(), *goal, now, workspaceServerIDs(r))
+	if err != nil {
+		log.Printf("watch goal progress for %s: %v", userName, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// PUT /api/users/{name}/watch-goal
+func (s *Server) handleSetWatchGoal(w http.ResponseWriter, r *http.Request) {
+	var goal models.WatchGoal
+	if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	goal.UserName = chi.URLParam(r, "name")
+	if err := goal.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetWatchGoal(&goal); err != nil {
+		log.Printf("setting watch goal for %s: %v", goal.UserName, err)
+		writeError(w, http.StatusInternalServerError, "failed to save watch goal")
+		return
+	}
+	s.invalidateRulesCache()
+	writeJSON(w, http.StatusOK, goal)
+}
+
+// DELETE /api/users/{name}/watch-goal
+func (s *Server) handleDeleteWatchGoal(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteWatchGoal(chi.URLParam(r, "name")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.invalidateRulesCache()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/users/{name}/watch-time/daily?days=N
+//
+// Returns the user's watch time per day for the last N days, today
+// included, oldest first. Days without watching are left out.
+func (s *Server) handleUserDailyWatchTime(w http.ResponseWriter, r *http.Request) {
+	userName := chi.URLParam(r, "name")
+	if !s.requireGuestVisibility(w, r, userName, "visible_watch_history") {
+		return
+	}
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDailyWatchTimeDays {
+			writeError(w, http.StatusBadRequest, "days must be between 1 and 366")
+			return
+		}
+		days = n
+	}
+	now, err := watchGoalNow(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tz_offset")
+		return
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	_, offset := now.Zone()
+	result, err := s.store.DailyWatchTimeForUser(r.Context(), userName, today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1), offset/60, workspaceServerIDs(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestWatchGoalsAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	if w := do(http.MethodGet, "/api/users/alice/watch-goal", ""); w.Code != http.StatusNotFound {
+		t.Errorf("before setting: %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/api/users/alice/watch-goal", `{"weekly_target_minutes":900,"weekly_limit_minutes":600}`); w.Code != http.StatusBadRequest {
+		t.Errorf("target above limit: %d", w.Code)
+	}
+	w := do(http.MethodPut, "/api/users/alice/watch-goal", `{"user_name":"mallory","weekly_target_minutes":300,"weekly_limit_minutes":600}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("put: %d %s", w.Code, w.Body.String())
+	}
+	if g, err := st.GetWatchGoal("alice"); err != nil || g.WeeklyLimitMinutes != 600 {
+		t.Errorf("stored goal = %+v, %v", g, err)
+	}
+
+	w = do(http.MethodGet, "/api/users/alice/watch-goal?tz_offset=60", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", w.Code, w.Body.String())
+	}
+	var p models.WatchGoalProgress
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.UserName != "alice" || len(p.Days) != 7 || p.RemainingMinutes == nil || *p.RemainingMinutes != 600 {
+		t.Errorf("progress = %+v", p)
+	}
+
+	w = do(http.MethodGet, "/api/watch-goals", "")
+	var list []models.WatchGoalProgress
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].UserName != "alice" {
+		t.Errorf("list = %+v", list)
+	}
+
+	w = do(http.MethodGet, "/api/users/alice/watch-time/daily?days=30", "")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("daily: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/users/alice/watch-time/daily?days=0", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("days=0: %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, "/api/users/alice/watch-goal", ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/users/alice/watch-goal", ""); w.Code != http.StatusNotFound {
+		t.Errorf("delete again: %d", w.Code)
+	}
+}
+
+func TestWatchGoalsAPI_Viewer(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+	if err := st.SetWatchGoal(&models.WatchGoal{UserName: "viewer", WeeklyLimitMinutes: 600}); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(http.MethodGet, "/api/users/viewer/watch-goal", ""); code != http.StatusOK {
+		t.Errorf("own goal: %d", code)
+	}
+	if code := do(http.MethodGet, "/api/users/someone/watch-goal", ""); code != http.StatusForbidden {
+		t.Errorf("another user's goal: %d", code)
+	}
+	if code := do(http.MethodPut, "/api/users/viewer/watch-goal", `{"weekly_limit_minutes":6000}`); code != http.StatusForbidden {
+		t.Errorf("raising own limit: %d", code)
+	}
+	if code := do(http.MethodGet, "/api/watch-goals", ""); code != http.StatusForbidden {
+		t.Errorf("listing: %d", code)
+	}
+}
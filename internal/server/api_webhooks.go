@@ -0,0 +1,208 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/media/embybase"
+	"streammon/internal/models"
+)
+
+const mediaWebhookPathPrefix = "/api/webhooks/media/"
+
+type serverWebhookRotateResponse struct {
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// webhookServer loads the server for the {id} route param and checks it is an
+// active Emby or Jellyfin server, the only types that push webhooks.
+func (s *Server) webhookServer(w http.ResponseWriter, r *http.Request) (*models.Server, bool) {
+	id, err := parseServerID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return nil, false
+	}
+	srv, err := s.store.GetServer(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return nil, false
+	}
+	if srv.DeletedAt != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	if srv.Type != models.ServerTypeEmby && srv.Type != models.ServerTypeJellyfin {
+		writeError(w, http.StatusBadRequest, "webhooks are only supported for Emby and Jellyfin servers")
+		return nil, false
+	}
+	return srv, true
+}
+
+// GET /api/servers/{id}/webhook
+func (s *Server) handleGetServerWebhook(w http.ResponseWriter, r *http.Request) {
+	srv, ok := s.webhookServer(w, r)
+	if !ok {
+		return
+	}
+	hook, err := s.store.GetServerWebhook(srv.ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hook)
+}
+
+// POST /api/servers/{id}/webhook/rotate
+func (s *Server) handleRotateServerWebhook(w http.ResponseWriter, r *http.Request) {
+	srv, ok := s.webhookServer(w, r)
+	if !ok {
+		return
+	}
+	token, err := s.store.RotateServerWebhook(srv.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	hook, err := s.store.GetServerWebhook(srv.ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, serverWebhookRotateResponse{
+		Token:     token,
+		Path:      mediaWebhookPathPrefix + token,
+		CreatedAt: hook.CreatedAt,
+	})
+}
+
+// DELETE /api/servers/{id}/webhook
+func (s *Server) handleDeleteServerWebhook(w http.ResponseWriter, r *http.Request) {
+	srv, ok := s.webhookServer(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.DeleteServerWebhook(srv.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if s.poller != nil {
+		s.poller.ClearWebhook(srv.ID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type serverWebhookSecretResponse struct {
+	Secret string `json:"secret"`
+}
+
+// POST /api/servers/{id}/webhook/secret/rotate
+func (s *Server) handleRotateServerWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	srv, ok := s.webhookServer(w, r)
+	if !ok {
+		return
+	}
+	secret, err := s.store.RotateServerWebhookSecret(srv.ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, serverWebhookSecretResponse{Secret: secret})
+}
+
+// DELETE /api/servers/{id}/webhook/secret
+func (s *Server) handleDeleteServerWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	srv, ok := s.webhookServer(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.ClearServerWebhookSecret(srv.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/webhooks/media/{token}
+//
+// Receives playback events from Emby notification webhooks and the Jellyfin
+// Webhook plugin. The URL token is the credential since neither sender can
+// attach custom auth headers reliably. Senders that can, such as a relay in
+// front of StreamMon, may additionally be required to sign each delivery
+// (see verifyWebhookSignature) by rotating a signing secret.
+func (s *Server) handleMediaWebhook(w http.ResponseWriter, r *http.Request) {
+	serverID, secret, err := s.store.ResolveWebhookToken(chi.URLParam(r, "token"))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			writeError(w, http.StatusUnauthorized, "unknown webhook")
+			return
+		}
+		log.Printf("webhook token: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+
+	if secret != "" {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if err := verifyWebhookSignature(r.Header, raw, secret, serverID, s.webhookNonces, time.Now()); err != nil {
+			log.Printf("webhook for server %d rejected: %v", serverID, err)
+			writeError(w, http.StatusUnauthorized, "invalid webhook signature")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+	}
+	if err := s.store.MarkWebhookReceived(serverID); err != nil {
+		log.Printf("webhook for server %d: %v", serverID, err)
+	}
+
+	body, err := readWebhookBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	ev, ok, err := embybase.ParseWebhook(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unrecognized webhook payload")
+		return
+	}
+	if !ok || s.poller == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if _, tracked := s.poller.GetServer(serverID); !tracked {
+		log.Printf("webhook for server %d ignored: server not polled", serverID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.poller.ApplyWebhookUpdate(r.Context(), serverID, ev.Update, ev.UserName)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readWebhookBody returns the JSON payload, which Emby sends either as the raw
+// body or as the "data" field of a multipart form.
+func readWebhookBody(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxBodySize); err != nil {
+			return nil, err
+		}
+		data := r.FormValue("data")
+		if data == "" {
+			return nil, errors.New("missing data field")
+		}
+		return []byte(data), nil
+	}
+	return io.ReadAll(r.Body)
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestServerWebhookLifecycle(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := &models.Server{Name: "Jelly", Type: models.ServerTypeJellyfin, URL: "http://jf", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	p := setupTestPoller(t, ts.Unwrap(), st)
+	p.AddServer(srv.ID, &mockLibraryServer{name: srv.Name, srvType: srv.Type})
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/servers/%d/webhook", srv.ID), nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("get before rotate: expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/servers/%d/webhook/rotate", srv.ID), nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("rotate: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var rotated serverWebhookRotateResponse
+	if err := json.NewDecoder(w.Body).Decode(&rotated); err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Token == "" || rotated.Path != mediaWebhookPathPrefix+rotated.Token {
+		t.Fatalf("unexpected rotate response %+v", rotated)
+	}
+
+	deliver := func(path, contentType string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req) // no session cookie: the token is the credential
+		return w.Code
+	}
+
+	jellyfin := []byte(`{"NotificationType":"PlaybackStart","ItemId":"abc","NotificationUsername":"alice"}`)
+	if code := deliver(rotated.Path, "application/json", jellyfin); code != http.StatusNoContent {
+		t.Errorf("jellyfin webhook: expected 204, got %d", code)
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	_ = mw.WriteField("data", `{"Event":"playback.pause","Item":{"Id":"abc"},"Session":{"Id":"s1"}}`)
+	mw.Close()
+	if code := deliver(rotated.Path, mw.FormDataContentType(), form.Bytes()); code != http.StatusNoContent {
+		t.Errorf("emby multipart webhook: expected 204, got %d", code)
+	}
+
+	if code := deliver(rotated.Path, "application/json", []byte(`{"hello":"world"}`)); code != http.StatusBadRequest {
+		t.Errorf("unknown payload: expected 400, got %d", code)
+	}
+	if code := deliver(mediaWebhookPathPrefix+"wrong", "application/json", jellyfin); code != http.StatusUnauthorized {
+		t.Errorf("bad token: expected 401, got %d", code)
+	}
+
+	hook, err := st.GetServerWebhook(srv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.LastReceivedAt == nil {
+		t.Error("expected last_received_at to be recorded")
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%d/webhook", srv.ID), nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	if code := deliver(rotated.Path, "application/json", jellyfin); code != http.StatusUnauthorized {
+		t.Errorf("after delete: expected 401, got %d", code)
+	}
+}
+
+func TestServerWebhookRejectsPlex(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/servers/%d/webhook/rotate", srv.ID), nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Emby and Jellyfin") {
+		t.Errorf("expected 400 for plex server, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServerWebhookSignedDeliveries(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := &models.Server{Name: "Jelly", Type: models.ServerTypeJellyfin, URL: "http://jf", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	token, err := st.RotateServerWebhook(srv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/servers/%d/webhook/secret/rotate", srv.ID), nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("rotate secret: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp serverWebhookSecretResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Secret == "" {
+		t.Fatal("expected a secret")
+	}
+	if hook, _ := st.GetServerWebhook(srv.ID); !hook.Signed {
+		t.Fatal("expected webhook to be marked signed")
+	}
+
+	body := []byte(`{"NotificationType":"PlaybackStart","ItemId":"abc","NotificationUsername":"alice"}`)
+	deliver := func(timestamp, nonce, sig string) int {
+		req := httptest.NewRequest(http.MethodPost, mediaWebhookPathPrefix+token, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if timestamp != "" {
+			req.Header.Set(webhookTimestampHeader, timestamp)
+			req.Header.Set(webhookNonceHeader, nonce)
+			req.Header.Set(webhookSignatureHeader, sig)
+		}
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	now := fmt.Sprint(time.Now().Unix())
+	stale := fmt.Sprint(time.Now().Add(-10 * time.Minute).Unix())
+	if code := deliver("", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("unsigned: expected 401, got %d", code)
+	}
+	if code := deliver(now, "n1", webhookSignature("wrong", now, "n1", body)); code != http.StatusUnauthorized {
+		t.Errorf("bad signature: expected 401, got %d", code)
+	}
+	if code := deliver(stale, "n2", webhookSignature(resp.Secret, stale, "n2", body)); code != http.StatusUnauthorized {
+		t.Errorf("stale timestamp: expected 401, got %d", code)
+	}
+	if code := deliver(now, "n3", webhookSignature(resp.Secret, now, "n3", body)); code != http.StatusNoContent {
+		t.Errorf("signed: expected 204, got %d", code)
+	}
+	if code := deliver(now, "n3", webhookSignature(resp.Secret, now, "n3", body)); code != http.StatusUnauthorized {
+		t.Errorf("replay: expected 401, got %d", code)
+	}
+
+	w = httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%d/webhook/secret", srv.ID), nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("clear secret: expected 204, got %d", w.Code)
+	}
+	if code := deliver("", "", ""); code != http.StatusNoContent {
+		t.Errorf("unsigned after clearing secret: expected 204, got %d", code)
+	}
+}
+
+func TestNonceCacheExpires(t *testing.T) {
+	c := newNonceCache()
+	now := time.Now()
+	if !c.claim("a", now) || c.claim("a", now.Add(time.Minute)) {
+		t.Fatal("expected first claim to succeed and repeat to fail")
+	}
+	if !c.claim("a", now.Add(3*webhookTimestampTolerance)) {
+		t.Fatal("expected nonce to be claimable after it expires")
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// canManageWorkspace reports whether the caller may manage id's members:
+// admins may manage any workspace, workspace admins only their own.
+func canManageWorkspace(r *http.Request, id int64) bool {
+	if user := UserFromContext(r.Context()); user != nil && user.Role == models.RoleAdmin {
+		return true
+	}
+	m := WorkspaceFromContext(r.Context())
+	return m != nil && m.WorkspaceID == id && m.Role == models.WorkspaceRoleAdmin
+}
+
+// handleListWorkspaces is the overview of every workspace for admins, and
+// of their own workspace for workspace admins.
+func (s *Server) handleListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	m := WorkspaceFromContext(r.Context())
+	isAdmin := user != nil && user.Role == models.RoleAdmin
+	if !isAdmin && (m == nil || m.Role != models.WorkspaceRoleAdmin) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	overviews, err := s.store.ListWorkspaceOverviews(r.Context(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	if !isAdmin {
+		own := []models.WorkspaceOverview{}
+		for _, o := range overviews {
+			if o.ID == m.WorkspaceID {
+				own = append(own, o)
+			}
+		}
+		overviews = own
+	}
+
+	if s.poller != nil {
+		active := make(map[int64]int)
+		for _, session := range s.poller.CurrentSessions() {
+			active[session.ServerID]++
+		}
+		for i := range overviews {
+			for _, id := range overviews[i].ServerIDs {
+				overviews[i].ActiveStreams += active[id]
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, overviews)
+}
+
+func (s *Server) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var ws models.Workspace
+	if err := json.NewDecoder(r.Body).Decode(&ws); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := ws.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.CreateWorkspace(&ws); err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, ws)
+}
+
+func (s *Server) handleRenameWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid workspace id")
+		return
+	}
+	var ws models.Workspace
+	if err := json.NewDecoder(r.Body).Decode(&ws); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := ws.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ws.ID = id
+	if err := s.store.RenameWorkspace(&ws); err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws)
+}
+
+func (s *Server) handleDeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid workspace id")
+		return
+	}
+	if err := s.store.DeleteWorkspace(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type workspaceServersRequest struct {
+	ServerIDs []int64 `json:"server_ids"`
+}
+
+func (s *Server) handleSetWorkspaceServers(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid workspace id")
+		return
+	}
+	var req workspaceServersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := s.store.SetWorkspaceServers(r.Context(), id, req.ServerIDs); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type workspaceMemberRequest struct {
+	Role models.WorkspaceRole `json:"role"`
+}
+
+// handleSetWorkspaceMember adds a user to a workspace or changes their
+// role. Only admins can enroll users or make workspace admins; workspace
+// admins can only demote existing members of their own workspace.
+func (s *Server) handleSetWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	id, target, ok := s.workspaceMemberTarget(w, r)
+	if !ok {
+		return
+	}
+	var req workspaceMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.WorkspaceRoleMember
+	}
+	if !req.Role.Valid() {
+		writeError(w, http.StatusBadRequest, "role must be admin or member")
+		return
+	}
+
+	if user := UserFromContext(r.Context()); user.Role != models.RoleAdmin {
+		if req.Role != models.WorkspaceRoleMember {
+			writeError(w, http.StatusForbidden, "only admins can make workspace admins")
+			return
+		}
+		current, err := s.store.WorkspaceMembership(r.Context(), target.ID)
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if current == nil || current.WorkspaceID != id {
+			writeError(w, http.StatusForbidden, "only admins can add users to a workspace")
+			return
+		}
+	}
+
+	if err := s.store.SetWorkspaceMember(r.Context(), id, target.ID, req.Role); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, models.WorkspaceMember{UserID: target.ID, Name: target.Name, Role: req.Role})
+}
+
+func (s *Server) handleRemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	id, target, ok := s.workspaceMemberTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.RemoveWorkspaceMember(r.Context(), id, target.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// workspaceMemberTarget resolves the workspace and user a membership
+// request is about, writing the error response when the caller can't
+// manage it.
+func (s *Server) workspaceMemberTarget(w http.ResponseWriter, r *http.Request) (int64, *models.User, bool) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid workspace id")
+		return 0, nil, false
+	}
+	if !canManageWorkspace(r, id) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return 0, nil, false
+	}
+	target, err := s.store.GetUser(chi.URLParam(r, "name"))
+	if err != nil {
+		writeStoreError(w, err)
+		return 0, nil, false
+	}
+	if target.Role == models.RoleAdmin {
+		writeError(w, http.StatusBadRequest, "admins see every workspace and can't be members")
+		return 0, nil, false
+	}
+	if user := UserFromContext(r.Context()); user.ID == target.ID {
+		writeError(w, http.StatusBadRequest, "cannot change your own workspace membership")
+		return 0, nil, false
+	}
+	return id, target, true
+}
+
+func writeWorkspaceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrWorkspaceExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeStoreError(w, err)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestWorkspaceIsolation(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+
+	var serverIDs []int64
+	for _, name := range []string{"Friends", "Family"} {
+		srv := &models.Server{Name: name, Type: models.ServerTypePlex, URL: "http://" + name, APIKey: "k", Enabled: true}
+		if err := st.CreateServer(srv); err != nil {
+			t.Fatal(err)
+		}
+		serverIDs = append(serverIDs, srv.ID)
+		started := time.Now().UTC().Add(-2 * time.Hour)
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: name + " movie",
+			DurationMs: 3600000, WatchedMs: 3600000, StartedAt: started, StoppedAt: started.Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	friends, family := serverIDs[0], serverIDs[1]
+	wsAdmin := createCoAdminSession(t, st, "hoster")
+	createViewerSession(t, st, "carol")
+
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, req)
+		return w
+	}
+	asWorkspaceAdmin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: wsAdmin})
+		w := httptest.NewRecorder()
+		ts.Unwrap().ServeHTTP(w, req)
+		return w
+	}
+
+	w := admin(http.MethodPost, "/api/workspaces", `{"name":"Friends"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var ws models.Workspace
+	if err := json.NewDecoder(w.Body).Decode(&ws); err != nil {
+		t.Fatal(err)
+	}
+	base := fmt.Sprintf("/api/workspaces/%d", ws.ID)
+	if w := admin(http.MethodPost, "/api/workspaces", `{"name":"friends"}`); w.Code != http.StatusConflict {
+		t.Errorf("duplicate name: expected 409, got %d", w.Code)
+	}
+	if w := admin(http.MethodPut, base+"/servers", fmt.Sprintf(`{"server_ids":[%d]}`, friends)); w.Code != http.StatusNoContent {
+		t.Fatalf("set servers: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := admin(http.MethodPut, base+"/members/hoster", `{"role":"admin"}`); w.Code != http.StatusOK {
+		t.Fatalf("add member: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = asWorkspaceAdmin(http.MethodGet, "/api/history", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("history: expected 200, got %d", w.Code)
+	}
+	var history models.PaginatedResult[models.WatchHistoryEntry]
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Items) != 1 || history.Items[0].ServerID != friends {
+		t.Errorf("expected only the workspace's history, got %+v", history.Items)
+	}
+
+	w = asWorkspaceAdmin(http.MethodGet, fmt.Sprintf("/api/history?server_ids=%d", family), "")
+	history = models.PaginatedResult[models.WatchHistoryEntry]{}
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Items) != 0 {
+		t.Errorf("expected no history from another workspace's server, got %+v", history.Items)
+	}
+
+	w = asWorkspaceAdmin(http.MethodGet, "/api/servers", "")
+	var servers []models.Server
+	if err := json.NewDecoder(w.Body).Decode(&servers); err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers[0].ID != friends {
+		t.Errorf("expected only the workspace's server, got %+v", servers)
+	}
+	if w := asWorkspaceAdmin(http.MethodGet, fmt.Sprintf("/api/servers/%d", family), ""); w.Code != http.StatusNotFound {
+		t.Errorf("other server: expected 404, got %d", w.Code)
+	}
+
+	w = asWorkspaceAdmin(http.MethodGet, "/api/workspaces", "")
+	var overviews []models.WorkspaceOverview
+	if err := json.NewDecoder(w.Body).Decode(&overviews); err != nil {
+		t.Fatal(err)
+	}
+	if len(overviews) != 1 || overviews[0].TotalPlays != 1 || len(overviews[0].Members) != 1 {
+		t.Errorf("overview = %+v", overviews)
+	}
+
+	if w := asWorkspaceAdmin(http.MethodPut, base+"/members/carol", `{}`); w.Code != http.StatusForbidden {
+		t.Errorf("workspace admin enrolling an unaffiliated user: expected 403, got %d", w.Code)
+	}
+	if w := admin(http.MethodPut, base+"/members/carol", `{}`); w.Code != http.StatusOK {
+		t.Fatalf("admin adding member: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := asWorkspaceAdmin(http.MethodPut, base+"/members/carol", `{"role":"admin"}`); w.Code != http.StatusForbidden {
+		t.Errorf("workspace admin granting admin: expected 403, got %d", w.Code)
+	}
+	if w := asWorkspaceAdmin(http.MethodPut, base+"/members/carol", `{"role":"member"}`); w.Code != http.StatusOK {
+		t.Errorf("workspace admin keeping a member: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, name := range []string{"hoster", "carol"} {
+		if w := asWorkspaceAdmin(http.MethodDelete, base+"/members/"+name, ""); w.Code != http.StatusForbidden {
+			t.Errorf("workspace admin removing %s: expected 403, got %d", name, w.Code)
+		}
+	}
+	if w := asWorkspaceAdmin(http.MethodPost, "/api/workspaces", `{"name":"Mine"}`); w.Code != http.StatusForbidden {
+		t.Errorf("workspace admin creating workspace: expected 403, got %d", w.Code)
+	}
+
+	w = admin(http.MethodPost, "/api/workspaces", `{"name":"Family"}`)
+	var other models.Workspace
+	if err := json.NewDecoder(w.Body).Decode(&other); err != nil {
+		t.Fatal(err)
+	}
+	if w := asWorkspaceAdmin(http.MethodPut, fmt.Sprintf("/api/workspaces/%d/members/carol", other.ID), `{}`); w.Code != http.StatusForbidden {
+		t.Errorf("managing another workspace: expected 403, got %d", w.Code)
+	}
+
+	w = admin(http.MethodGet, "/api/workspaces", "")
+	overviews = nil
+	if err := json.NewDecoder(w.Body).Decode(&overviews); err != nil {
+		t.Fatal(err)
+	}
+	if len(overviews) != 2 {
+		t.Errorf("admin overview: expected 2 workspaces, got %d", len(overviews))
+	}
+}
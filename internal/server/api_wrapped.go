@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// minWrappedYear is the earliest year a recap can be asked for.
+const minWrappedYear = 2000
+
+type wrappedOptOutRequest struct {
+	OptOut bool `json:"opt_out"`
+}
+
+// loadWrapped checks the caller may see {name}'s recap of {year} and builds
+// it, writing the error response itself when it can't.
+func (s *Server) loadWrapped(w http.ResponseWriter, r *http.Request) (*models.Wrapped, bool) {
+	name := chi.URLParam(r, "name")
+	if !s.requireGuestVisibility(w, r, name, "visible_watch_history") {
+		return nil, false
+	}
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil || year < minWrappedYear || year > time.Now().Year() {
+		writeError(w, http.StatusBadRequest, "invalid year")
+		return nil, false
+	}
+
+	caller := UserFromContext(r.Context())
+	if caller.Name != name {
+		optedOut, err := s.store.IsWrappedOptedOut(r.Context(), name)
+		if err != nil {
+			log.Printf("wrapped opt-out for %s: %v", name, err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return nil, false
+		}
+		if optedOut {
+			writeError(w, http.StatusForbidden, "user has opted out of wrapped")
+			return nil, false
+		}
+	}
+
+	r = s.withPreferenceDefaults(r)
+	tzOffset, err := parseTZOffset(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tz_offset")
+		return nil, false
+	}
+
+	scope := store.NameScope(name)
+	scope.ServerIDs = workspaceServerIDs(r)
+	wrapped, err := s.store.UserWrapped(r.Context(), scope, year, tzOffset)
+	if err != nil {
+		log.Printf("wrapped for %s: %v", name, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return nil, false
+	}
+
+	if !caller.Role.CanReadAll() {
+		devicesVisible, err := s.store.GetGuestSetting("visible_devices")
+		if err != nil {
+			log.Printf("GetGuestSetting error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return nil, false
+		}
+		if !devicesVisible {
+			wrapped.TopDevice = nil
+		}
+	}
+	return wrapped, true
+}
+
+// GET /api/users/{name}/wrapped/{year}?tz_offset=
+func (s *Server) handleGetWrapped(w http.ResponseWriter, r *http.Request) {
+	wrapped, ok := s.loadWrapped(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, wrapped)
+}
+
+// GET /api/users/{name}/wrapped/{year}/card.svg?tz_offset=
+//
+// Renders the recap as a standalone image for sharing.
+func (s *Server) handleGetWrappedCard(w http.ResponseWriter, r *http.Request) {
+	wrapped, ok := s.loadWrapped(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if _, err := w.Write(renderWrappedCard(wrapped)); err != nil {
+		log.Printf("writing wrapped card: %v", err)
+	}
+}
+
+// GET /api/users/{name}/wrapped/opt-out
+func (s *Server) handleGetWrappedOptOut(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !viewerCanAccessUser(r, name) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	optedOut, err := s.store.IsWrappedOptedOut(r.Context(), name)
+	if err != nil {
+		log.Printf("wrapped opt-out for %s: %v", name, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, wrappedOptOutRequest{OptOut: optedOut})
+}
+
+// PUT /api/users/{name}/wrapped/opt-out
+//
+// Users set their own; admins may set anyone's.
+func (s *Server) handleSetWrappedOptOut(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	caller := UserFromContext(r.Context())
+	if caller == nil || (caller.Name != name && caller.Role != models.RoleAdmin) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	var req wrappedOptOutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := s.store.SetWrappedOptOut(r.Context(), name, req.OptOut); err != nil {
+		log.Printf("setting wrapped opt-out for %s: %v", name, err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
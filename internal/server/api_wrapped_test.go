@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestWrappedAPI(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "http://plex", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+	year := time.Now().Year()
+	start := time.Date(year, 1, 2, 20, 0, 0, 0, time.UTC)
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: srv.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat <1995>",
+		DurationMs: 7200000, WatchedMs: 7200000, StartedAt: start, StoppedAt: start.Add(2 * time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ts.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	path := "/api/users/alice/wrapped/" + strconv.Itoa(year)
+
+	w := do(http.MethodGet, path, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", w.Code, w.Body.String())
+	}
+	var wrapped models.Wrapped
+	if err := json.NewDecoder(w.Body).Decode(&wrapped); err != nil {
+		t.Fatal(err)
+	}
+	if wrapped.Plays != 1 || wrapped.TotalHours != 2 || len(wrapped.TopMovies) != 1 {
+		t.Errorf("wrapped = %+v", wrapped)
+	}
+
+	w = do(http.MethodGet, path+"/card.svg", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("card: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Heat &lt;1995&gt;") || strings.Contains(body, "<1995>") {
+		t.Errorf("card does not escape titles:\n%s", body)
+	}
+
+	for _, bad := range []string{"1999", strconv.Itoa(year + 1), "abc"} {
+		if w := do(http.MethodGet, "/api/users/alice/wrapped/"+bad, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("year %s: %d", bad, w.Code)
+		}
+	}
+
+	if w := do(http.MethodPut, "/api/users/alice/wrapped/opt-out", `{"opt_out":true}`); w.Code != http.StatusOK {
+		t.Fatalf("opt out: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/users/alice/wrapped/opt-out", ""); !strings.Contains(w.Body.String(), `"opt_out":true`) {
+		t.Errorf("opt-out status: %s", w.Body.String())
+	}
+	if w := do(http.MethodGet, path, ""); w.Code != http.StatusForbidden {
+		t.Errorf("admin reading opted-out recap: %d", w.Code)
+	}
+	if w := do(http.MethodGet, path+"/card.svg", ""); w.Code != http.StatusForbidden {
+		t.Errorf("admin rendering opted-out card: %d", w.Code)
+	}
+}
+
+func TestWrappedAPI_Viewer(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+	do := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	year := strconv.Itoa(time.Now().Year())
+
+	if code := do(http.MethodGet, "/api/users/someone/wrapped/"+year, ""); code != http.StatusForbidden {
+		t.Errorf("another user's recap: %d", code)
+	}
+	if code := do(http.MethodPut, "/api/users/someone/wrapped/opt-out", `{"opt_out":false}`); code != http.StatusForbidden {
+		t.Errorf("opting someone else in: %d", code)
+	}
+	// Opting out hides the recap from others, not from its owner.
+	if code := do(http.MethodPut, "/api/users/viewer/wrapped/opt-out", `{"opt_out":true}`); code != http.StatusOK {
+		t.Fatalf("own opt-out: %d", code)
+	}
+	if code := do(http.MethodGet, "/api/users/viewer/wrapped/"+year, ""); code != http.StatusOK {
+		t.Errorf("own recap after opting out: %d", code)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streammon/internal/store"
+)
+
+type zombieSessionsResponse struct {
+	store.ZombieSettings
+	LastReport *store.ZombieCleanupReport `json:"last_report"`
+}
+
+func (s *Server) handleGetZombieSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.GetZombieSettings()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	report, err := s.store.GetLastZombieReport()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, zombieSessionsResponse{ZombieSettings: settings, LastReport: report})
+}
+
+func (s *Server) handleUpdateZombieSettings(w http.ResponseWriter, r *http.Request) {
+	var req store.ZombieSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.SetZombieSettings(req); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
+
+// handleRunZombieCleanup runs the cleanup immediately, whether or not the
+// scheduler's hourly pass is enabled, e.g. after tightening the thresholds.
+func (s *Server) handleRunZombieCleanup(w http.ResponseWriter, r *http.Request) {
+	report, err := s.store.CleanupZombieSessions(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
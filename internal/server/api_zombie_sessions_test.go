@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+func TestZombieSessionSettingsAPI(t *testing.T) {
+	srv, st := newTestServerWrapped(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/settings/zombie-sessions", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp zombieSessionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.MaxSessionAgeHours != store.DefaultZombieMaxSessionAgeHours || resp.LastReport != nil {
+		t.Fatalf("unexpected defaults: %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/settings/zombie-sessions",
+		strings.NewReader(`{"max_session_age_hours":1000,"max_silence_minutes":60}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for out-of-range age, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/settings/zombie-sessions",
+		strings.NewReader(`{"max_session_age_hours":6,"max_silence_minutes":60}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	server := &models.Server{Name: "s", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().UTC().Add(-12 * time.Hour)
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: server.ID, UserName: "alice", Title: "Stuck", MediaType: models.MediaTypeMovie,
+		StartedAt: start, StoppedAt: start.Add(10 * time.Hour), WatchedMs: 1800000,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/settings/zombie-sessions/run", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report store.ZombieCleanupReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Closed != 1 || report.Sessions[0].UserName != "alice" || report.Sessions[0].Reason != store.ZombieReasonMaxAge {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestZombieSessionSettingsRequiresAdmin(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createViewerSession(t, st, "viewer")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/zombie-sessions/run", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
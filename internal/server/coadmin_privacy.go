@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+// Co-admins see the same titles, users, and stats as admins, but not where
+// members connect from: IP addresses and ISPs are blanked and coordinates
+// are rounded to roughly city level (one decimal place, ~10 km).
+//
+// Fields are matched on the words of their JSON key rather than exact names,
+// so "last_ip", "shared_ips", or "home_latitude" are masked without being
+// listed here. Any string value that parses as an IP address is blanked too,
+// whatever its key.
+var (
+	coAdminBlankedWords = map[string]bool{
+		"ip":   true,
+		"ips":  true,
+		"isp":  true,
+		"isps": true,
+	}
+	coAdminRoundedWords = map[string]bool{
+		"lat":       true,
+		"lng":       true,
+		"lon":       true,
+		"latitude":  true,
+		"longitude": true,
+	}
+)
+
+// networkKeyKind reports whether a JSON key names a network field to blank
+// or a coordinate to round.
+func networkKeyKind(key string) (blank, round bool) {
+	for _, word := range strings.Split(strings.ToLower(key), "_") {
+		if coAdminBlankedWords[word] {
+			blank = true
+		}
+		if coAdminRoundedWords[word] {
+			round = true
+		}
+	}
+	return blank, round
+}
+
+func isCoAdmin(r *http.Request) bool {
+	user := UserFromContext(r.Context())
+	return user != nil && user.Role == models.RoleCoAdmin
+}
+
+// maskNetworkForCoAdmin buffers JSON responses to co-admins and strips
+// network details before they're sent. Responses to other roles pass through
+// untouched. The buffered writer deliberately doesn't implement http.Flusher,
+// so streaming handlers refuse co-admins rather than leak unmasked events.
+func maskNetworkForCoAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isCoAdmin(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buf := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		body := buf.body.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && len(body) > 0 {
+			body = maskNetworkJSON(body)
+			w.Header().Del("Content-Length")
+		}
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	})
+}
+
+type bufferedResponseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (b *bufferedResponseWriter) Header() http.Header { return b.header }
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.status = status
+	b.wroteHeader = true
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// maskNetworkJSON rewrites a JSON document with network fields masked. Bodies
+// that don't parse are returned unchanged; they can't carry masked fields in
+// a form a client would read as JSON anyway.
+func maskNetworkJSON(data []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+	out, err := json.Marshal(maskNetworkValue(v))
+	if err != nil {
+		return data
+	}
+	return append(out, '\n')
+}
+
+func maskNetworkValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			switch blank, round := networkKeyKind(k); {
+			case blank:
+				t[k] = blankNetworkValue(val)
+			case round:
+				t[k] = roundCoordinate(val)
+			default:
+				t[k] = maskNetworkValue(val)
+			}
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = maskNetworkValue(t[i])
+		}
+		return t
+	case string:
+		if _, err := netip.ParseAddr(t); err == nil {
+			return ""
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// blankNetworkValue empties a string, or every string in a list, keeping the
+// shape so clients still see how many addresses there were. Objects under a
+// network key are masked like any other.
+func blankNetworkValue(v any) any {
+	switch t := v.(type) {
+	case string:
+		return ""
+	case []any:
+		for i := range t {
+			t[i] = blankNetworkValue(t[i])
+		}
+		return t
+	default:
+		return maskNetworkValue(v)
+	}
+}
+
+// roundCoordinate rounds a number, or every number in a list, to one decimal
+// place.
+func roundCoordinate(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if f, err := t.Float64(); err == nil {
+			return math.Round(f*10) / 10
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = roundCoordinate(t[i])
+		}
+		return t
+	default:
+		return maskNetworkValue(v)
+	}
+}
+
+// maskStreamsJSONForCoAdmin masks an encoded dashboard SSE snapshot, which
+// bypasses the buffering middleware. It goes through the same JSON masking
+// so fields added to ActiveStream are covered without a typed copy here.
+func maskStreamsJSONForCoAdmin(data []byte) []byte {
+	return bytes.TrimSuffix(maskNetworkJSON(data), []byte("\n"))
+}
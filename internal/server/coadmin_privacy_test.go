@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+func createCoAdminSession(t *testing.T, st *store.Store, name string) string {
+	t.Helper()
+	user, err := st.CreateLocalUser(name, name+"@test.local", "", models.RoleCoAdmin)
+	if err != nil {
+		t.Fatalf("creating co-admin user: %v", err)
+	}
+	token, err := st.CreateSession(user.ID, time.Now().UTC().Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("creating co-admin session: %v", err)
+	}
+	return token
+}
+
+func TestMaskNetworkJSON(t *testing.T) {
+	in := `{"items":[{"title":"Heat","ip_address":"203.0.113.7","city":"Berlin","isp":"Telekom"}],
+		"locations":[{"ip":"203.0.113.7","lat":52.5200,"lng":13.4050,"city":"Berlin"}],"total":1}`
+	var got map[string]any
+	if err := json.Unmarshal(maskNetworkJSON([]byte(in)), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	item := got["items"].([]any)[0].(map[string]any)
+	if item["ip_address"] != "" || item["isp"] != "" {
+		t.Errorf("network fields not masked: %v", item)
+	}
+	if item["title"] != "Heat" || item["city"] != "Berlin" {
+		t.Errorf("non-network fields changed: %v", item)
+	}
+	loc := got["locations"].([]any)[0].(map[string]any)
+	if loc["ip"] != "" || loc["lat"] != 52.5 || loc["lng"] != 13.4 {
+		t.Errorf("location not coarsened: %v", loc)
+	}
+	if got["total"] != float64(1) {
+		t.Errorf("total = %v, want 1", got["total"])
+	}
+
+	if out := maskNetworkJSON([]byte("not json")); string(out) != "not json" {
+		t.Errorf("invalid JSON should pass through, got %q", out)
+	}
+}
+
+func TestCoAdminHistoryMasksNetwork(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createCoAdminSession(t, st, "helper")
+
+	server := &models.Server{Name: "s", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: server.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat",
+		IPAddress: "203.0.113.7", StartedAt: now.Add(-time.Hour), StoppedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var page models.PaginatedResult[models.WatchHistoryEntry]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Title != "Heat" {
+		t.Fatalf("co-admin should see other members' history, got %+v", page.Items)
+	}
+	if page.Items[0].IPAddress != "" {
+		t.Errorf("ip_address leaked to co-admin: %q", page.Items[0].IPAddress)
+	}
+}
+
+func TestCoAdminAccess(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createCoAdminSession(t, st, "helper")
+
+	for _, tc := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/users/summary", "", http.StatusOK},
+		{http.MethodGet, "/api/stats", "", http.StatusOK},
+		{http.MethodGet, "/api/dashboard/summary", "", http.StatusOK},
+		{http.MethodPost, "/api/servers", `{"name":"x","type":"plex","url":"http://x","api_key":"k"}`, http.StatusForbidden},
+		{http.MethodGet, "/api/admin/users", "", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.method, tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestCoAdminReadableRoutesMaskNetwork walks every GET route a co-admin can
+// reach and checks that none of them returns a member's IP address, ISP, or
+// unrounded coordinates, so new routes and fields are covered by default.
+func TestCoAdminReadableRoutesMaskNetwork(t *testing.T) {
+	srv, st := newTestServer(t)
+	token := createCoAdminSession(t, st, "helper")
+
+	server := &models.Server{Name: "s", Type: models.ServerTypePlex, URL: "http://s", APIKey: "k", Enabled: true}
+	if err := st.CreateServer(server); err != nil {
+		t.Fatal(err)
+	}
+	const ip, isp = "203.0.113.7", "Examplenet"
+	now := time.Now().UTC()
+	if err := st.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: server.ID, UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Heat",
+		IPAddress: ip, StartedAt: now.Add(-time.Hour), StoppedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetCachedGeo(&models.GeoResult{IP: ip, Lat: 52.5234, Lng: 13.4115, City: "Berlin", Country: "DE", ISP: isp}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpsertHouseholdLocation(&models.HouseholdLocation{
+		UserName: "alice", IPAddress: ip, City: "Berlin", Country: "DE", Latitude: 52.5234, Longitude: 13.4115, Trusted: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetHouseholdHome(&models.HouseholdHome{
+		UserName: "alice", Latitude: 48.1374, Longitude: 11.5755, RadiusKm: 25, Source: models.HouseholdHomeManual,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rule := &models.Rule{Name: "Hosting", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	if err := st.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertViolation(&models.RuleViolation{
+		RuleID: rule.ID, UserName: "alice", Severity: models.SeverityWarning, Message: "x", OccurredAt: now,
+		Details: map[string]interface{}{"ip": ip, "isp": isp, "previous_ips": []string{ip}, "home_lat": 48.1374},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	matrix, err := routePermissions(srv.router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := strings.NewReplacer(
+		"{id}", "1", "{name}", "alice", "{ip}", ip, "{year}", strconv.Itoa(now.Year()),
+		"{key}", "k", "{seasonNumber}", "1", "{seriesId}", "1", "*", "x",
+	)
+	leaks := []string{ip, isp, "52.5234", "13.4115", "48.1374", "11.5755"}
+	checked := 0
+	for _, a := range matrix {
+		if a.Method != http.MethodGet || !slices.Contains(a.Roles, models.RoleCoAdmin) {
+			continue
+		}
+		path := params.Replace(a.Pattern)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		cancel()
+		if w.Code == http.StatusOK {
+			checked++
+		}
+		body := w.Body.String()
+		for _, leak := range leaks {
+			if strings.Contains(body, leak) {
+				t.Errorf("GET %s (%d) leaked %q to co-admin: %s", path, w.Code, leak, body)
+			}
+		}
+	}
+	if checked < 20 {
+		t.Errorf("only %d co-admin GET routes answered 200; fixtures or parameters are off", checked)
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"streammon/internal/store"
+)
+
+const (
+	// geoBackfillBatchSize is how many addresses the background geo backfill
+	// resolves between pauses.
+	geoBackfillBatchSize = 100
+	// geoBackfillBatchInterval is the pause between batches, which keeps a
+	// large backfill from monopolising the database's write lock.
+	geoBackfillBatchInterval = 250 * time.Millisecond
+)
+
+// geoBackfillState runs the background geo backfill: it walks every history
+// address missing from the geo cache, not just the first few thousand the
+// synchronous backfill handles, so imported history shows up on the maps.
+type geoBackfillState struct {
+	mu         sync.RWMutex
+	wg         sync.WaitGroup
+	running    bool
+	processed  int
+	total      int
+	resolved   int
+	unresolved int
+	cancel     context.CancelFunc
+}
+
+type geoBackfillStatusResponse struct {
+	Running    bool `json:"running"`
+	Processed  int  `json:"processed"`
+	Total      int  `json:"total"`
+	Resolved   int  `json:"resolved"`
+	Unresolved int  `json:"unresolved"`
+}
+
+func (g *geoBackfillState) status() geoBackfillStatusResponse {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return geoBackfillStatusResponse{
+		Running:    g.running,
+		Processed:  g.processed,
+		Total:      g.total,
+		Resolved:   g.resolved,
+		Unresolved: g.unresolved,
+	}
+}
+
+// start atomically checks if a backfill is already running and, if not,
+// starts one in the background. Returns false if one was already running.
+func (g *geoBackfillState) start(ctx context.Context, st *store.Store, resolver GeoLookup, total int) bool {
+	g.mu.Lock()
+	if g.running {
+		g.mu.Unlock()
+		return false
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	g.running = true
+	g.processed, g.resolved, g.unresolved = 0, 0, 0
+	g.total = total
+	g.cancel = cancel
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go g.run(runCtx, st, resolver)
+	return true
+}
+
+// stop cancels a running backfill. No-op if not running.
+func (g *geoBackfillState) stop() {
+	g.mu.RLock()
+	cancel := g.cancel
+	g.mu.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Wait blocks until any running backfill goroutine finishes.
+func (g *geoBackfillState) Wait() {
+	g.wg.Wait()
+}
+
+func (g *geoBackfillState) run(ctx context.Context, st *store.Store, resolver GeoLookup) {
+	defer g.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("geo backfill: panic recovered: %v", r)
+		}
+		g.mu.Lock()
+		if g.cancel != nil {
+			g.cancel()
+			g.cancel = nil
+		}
+		g.running = false
+		g.mu.Unlock()
+	}()
+
+	limiter := time.NewTicker(geoBackfillBatchInterval)
+	defer limiter.Stop()
+
+	after := ""
+	for {
+		ips, err := st.ListUncachedIPsAfter(ctx, after, geoBackfillBatchSize)
+		if err != nil {
+			log.Printf("geo backfill: list error: %v", err)
+			return
+		}
+		if len(ips) == 0 {
+			break
+		}
+		after = ips[len(ips)-1]
+
+		for _, ipStr := range ips {
+			if ctx.Err() != nil {
+				return
+			}
+			ok := false
+			if ip := net.ParseIP(ipStr); ip != nil {
+				if geo := resolver.Lookup(ip); geo != nil {
+					if err := st.SetCachedGeo(geo); err != nil {
+						log.Printf("geo backfill cache %s: %v", ipStr, err)
+					} else {
+						ok = true
+					}
+				}
+			}
+
+			g.mu.Lock()
+			g.processed++
+			if ok {
+				g.resolved++
+			} else {
+				g.unresolved++
+			}
+			g.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-limiter.C:
+		}
+	}
+
+	done := g.status()
+	if done.Resolved > 0 {
+		st.BackfillHouseholdGeo()
+	}
+	log.Printf("geo backfill: resolved %d, unresolved %d, total %d", done.Resolved, done.Unresolved, done.Processed)
+}
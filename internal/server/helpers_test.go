@@ -49,6 +49,7 @@ func newTestServer(t *testing.T, storeOpts ...store.Option) (*Server, *store.Sto
 	authMgr := auth.NewManager(s)
 	authMgr.RegisterProvider(auth.NewLocalProvider(s, authMgr))
 	srv := NewServer(s, WithAuthManager(authMgr))
+	t.Cleanup(srv.StopRateLimiters)
 	return srv, s
 }
 
@@ -0,0 +1,586 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const (
+	importEventBuffer  = 64
+	importJobListLimit = 50
+)
+
+var errImportBusy = errors.New("import already in progress")
+
+var importSourceLabels = map[models.ImportSource]string{
+	models.ImportSourceTautulli:          "Tautulli",
+	models.ImportSourceTautulliDatabase:  "Tautulli database",
+	models.ImportSourceJellystat:         "Jellystat",
+	models.ImportSourcePlaybackReporting: "playback-reporting",
+}
+
+// importTimeouts bound each run of a job. A resumed run gets a fresh budget.
+var importTimeouts = map[models.ImportSource]time.Duration{
+	models.ImportSourceTautulli:          10 * time.Minute,
+	models.ImportSourceTautulliDatabase:  30 * time.Minute,
+	models.ImportSourceJellystat:         10 * time.Minute,
+	models.ImportSourcePlaybackReporting: 10 * time.Minute,
+}
+
+// importFailure is a job error whose message is safe to show the user.
+// Anything else fails the job with a pointer to the server logs.
+type importFailure struct{ message string }
+
+func (e *importFailure) Error() string { return e.message }
+
+// importSink receives a job's records as its source produces them. batch
+// takes the next run of entries and the source's total record count;
+// setErrors records how many source records couldn't be converted.
+type importSink struct {
+	batch     func(entries []*models.WatchHistoryEntry, total int) error
+	setErrors func(n int)
+}
+
+// importJobRun reads one job's source into sink from the beginning. Records
+// an earlier run already imported are skipped by the sink, not the source.
+type importJobRun func(ctx context.Context, sink importSink) error
+
+func streamerRun(stream importStreamer, serverID int64) importJobRun {
+	return func(ctx context.Context, sink importSink) error {
+		return stream(ctx, serverID, 1000, sink.batch)
+	}
+}
+
+// importJobManager tracks the imports running in the background. Jobs
+// outlive the request that started them, and only one job per source runs
+// at a time.
+type importJobManager struct {
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	active map[int64]*activeImport
+}
+
+type activeImport struct {
+	job       models.ImportJob
+	cancel    context.CancelFunc
+	cancelled bool
+	subs      map[chan importProgressEvent]struct{}
+}
+
+func newImportJobManager() *importJobManager {
+	return &importJobManager{active: make(map[int64]*activeImport)}
+}
+
+// Wait blocks until every running import has stopped.
+func (m *importJobManager) Wait() {
+	m.wg.Wait()
+}
+
+func (m *importJobManager) update(a *activeImport, fn func(j *models.ImportJob)) models.ImportJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(&a.job)
+	return a.job
+}
+
+// publish hands ev to every subscriber that has room for it. Slow
+// subscribers miss intermediate progress, never the final state.
+func (m *importJobManager) publish(a *activeImport, ev importProgressEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch := range a.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// subscribe returns a running job's current state and a channel of its
+// progress, closed when the job finishes. ok is false when the job isn't
+// running.
+func (m *importJobManager) subscribe(id int64) (job models.ImportJob, ch chan importProgressEvent, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.active[id]
+	if !ok {
+		return models.ImportJob{}, nil, false
+	}
+	ch = make(chan importProgressEvent, importEventBuffer)
+	a.subs[ch] = struct{}{}
+	return a.job, ch, true
+}
+
+func (m *importJobManager) unsubscribe(id int64, ch chan importProgressEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.active[id]; ok {
+		delete(a.subs, ch)
+	}
+}
+
+func (m *importJobManager) get(id int64) (models.ImportJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.active[id]
+	if !ok {
+		return models.ImportJob{}, false
+	}
+	return a.job, true
+}
+
+func (m *importJobManager) cancel(id int64) (models.ImportJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.active[id]
+	if !ok {
+		return models.ImportJob{}, false
+	}
+	a.cancelled = true
+	a.cancel()
+	return a.job, true
+}
+
+// startImportJob records job, unless it is a resumed job that already has
+// an ID, and runs it in the background. With subscribe set, the returned
+// channel receives the job's progress and is closed once it finishes.
+func (s *Server) startImportJob(ctx context.Context, job *models.ImportJob, run importJobRun, subscribe bool) (chan importProgressEvent, error) {
+	m := s.importJobs
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.active {
+		if a.job.Source == job.Source {
+			return nil, errImportBusy
+		}
+	}
+	if job.ID == 0 {
+		if err := s.store.CreateImportJob(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(s.appCtx, importTimeouts[job.Source])
+	a := &activeImport{job: *job, cancel: cancel, subs: make(map[chan importProgressEvent]struct{})}
+	var ch chan importProgressEvent
+	if subscribe {
+		ch = make(chan importProgressEvent, importEventBuffer)
+		a.subs[ch] = struct{}{}
+	}
+	m.active[job.ID] = a
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		s.runImportJob(runCtx, a, run)
+	}()
+	return ch, nil
+}
+
+func (s *Server) runImportJob(ctx context.Context, a *activeImport, run importJobRun) {
+	m := s.importJobs
+	skip := m.update(a, func(*models.ImportJob) {}).Processed
+	seen := 0
+
+	err := run(ctx, importSink{
+		batch: func(entries []*models.WatchHistoryEntry, total int) error {
+			if seen+len(entries) <= skip {
+				seen += len(entries)
+				return nil
+			}
+			if seen < skip {
+				entries = entries[skip-seen:]
+				seen = skip
+			}
+			inserted, skipped, consolidated, err := s.store.InsertHistoryBatch(ctx, entries)
+			if err != nil {
+				return err
+			}
+			seen += len(entries)
+			job := m.update(a, func(j *models.ImportJob) {
+				j.Processed = seen
+				j.Total = total
+				j.Inserted += inserted
+				j.Skipped += skipped
+				j.Consolidated += consolidated
+			})
+			if err := s.store.SaveImportJob(ctx, &job); err != nil {
+				log.Printf("saving import job %d progress: %v", job.ID, err)
+			}
+			m.publish(a, importJobEvent("progress", &job))
+			return nil
+		},
+		setErrors: func(n int) {
+			m.update(a, func(j *models.ImportJob) { j.Errors = n })
+		},
+	})
+
+	label := importSourceLabels[a.job.Source]
+	m.mu.Lock()
+	switch {
+	case err == nil:
+		a.job.Status = models.ImportJobCompleted
+	case a.cancelled:
+		a.job.Status = models.ImportJobCancelled
+		a.job.Error = "import cancelled"
+	case s.appCtx.Err() != nil:
+		// Shutting down: leave the job running so the next start resumes it.
+	default:
+		a.job.Status = models.ImportJobFailed
+		a.job.Error = "import failed, check server logs"
+		var f *importFailure
+		if errors.As(err, &f) {
+			a.job.Error = f.message
+		}
+	}
+	if a.job.Finished() {
+		now := time.Now().UTC()
+		a.job.FinishedAt = &now
+	}
+	job := a.job
+	m.mu.Unlock()
+
+	// Save before releasing subscribers: they read the final state back.
+	if err := s.store.SaveImportJob(context.Background(), &job); err != nil {
+		log.Printf("saving import job %d: %v", job.ID, err)
+	}
+	m.mu.Lock()
+	delete(m.active, job.ID)
+	for ch := range a.subs {
+		close(ch)
+	}
+	m.mu.Unlock()
+	if job.Finished() && job.FilePath != "" {
+		os.Remove(job.FilePath)
+	}
+
+	switch job.Status {
+	case models.ImportJobCompleted:
+		log.Printf("%s import completed: %d inserted, %d skipped, %d consolidated, server_id=%d",
+			label, job.Inserted, job.Skipped, job.Consolidated, job.ServerID)
+	case models.ImportJobRunning:
+		log.Printf("%s import interrupted by shutdown after %d records, will resume", label, job.Processed)
+	default:
+		log.Printf("%s import error: %v (imported %d, skipped %d, consolidated %d)",
+			label, err, job.Inserted, job.Skipped, job.Consolidated)
+	}
+}
+
+// ResumeImportJobs picks up the imports a shutdown interrupted. Records the
+// interrupted run already imported are skipped, and InsertHistoryBatch's
+// dedup covers any a source returns in a different order. Uploads no resumed
+// job needs are then removed from the import directory. Call it before
+// serving requests, so no upload is being spooled while it sweeps.
+func (s *Server) ResumeImportJobs() {
+	jobs, err := s.store.ListRunningImportJobs(s.appCtx)
+	if err != nil {
+		log.Printf("listing interrupted import jobs: %v", err)
+		return
+	}
+	keep := make(map[string]bool)
+	defer func() { s.removeStaleImportUploads(keep) }()
+	for i := range jobs {
+		job := &jobs[i]
+		run, err := s.resumeImportRun(job)
+		if err == nil {
+			job.Resumes++
+			_, err = s.startImportJob(s.appCtx, job, run, false)
+		}
+		if err != nil {
+			log.Printf("resuming %s import job %d: %v", importSourceLabels[job.Source], job.ID, err)
+			s.failImportJob(job, err)
+			continue
+		}
+		if job.FilePath != "" {
+			keep[job.FilePath] = true
+		}
+		log.Printf("resumed %s import job %d after %d records", importSourceLabels[job.Source], job.ID, job.Processed)
+	}
+}
+
+// removeStaleImportUploads deletes the files in the import directory other
+// than those in keep: uploads of jobs that failed to resume, or that a crash
+// left behind before their job was recorded or after it finished. Without
+// an import directory uploads go to the system temp dir, which isn't ours
+// to sweep.
+func (s *Server) removeStaleImportUploads(keep map[string]bool) {
+	if s.importDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(s.importDir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("listing import uploads: %v", err)
+		}
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(s.importDir, e.Name())
+		if !e.Type().IsRegular() || keep[path] {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("removing stale import upload %s: %v", e.Name(), err)
+			continue
+		}
+		log.Printf("removed stale import upload %s", e.Name())
+	}
+}
+
+func (s *Server) resumeImportRun(job *models.ImportJob) (importJobRun, error) {
+	switch job.Source {
+	case models.ImportSourceTautulli, models.ImportSourceJellystat:
+		getConfig, makeStreamer := s.store.GetTautulliConfig, tautulliStreamer
+		if job.Source == models.ImportSourceJellystat {
+			getConfig, makeStreamer = s.store.GetJellystatConfig, jellystatStreamer
+		}
+		cfg, err := getConfig()
+		if err != nil {
+			return nil, err
+		}
+		if !cfg.HasCredentials() {
+			return nil, &importFailure{importSourceLabels[job.Source] + " settings not configured"}
+		}
+		stream, err := makeStreamer(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return streamerRun(stream, job.ServerID), nil
+	case models.ImportSourceTautulliDatabase, models.ImportSourcePlaybackReporting:
+		if _, err := os.Stat(job.FilePath); err != nil {
+			return nil, &importFailure{"uploaded file is no longer available"}
+		}
+		if job.Source == models.ImportSourceTautulliDatabase {
+			return tautulliDatabaseRun(job.ServerID, job.FilePath), nil
+		}
+		srv, err := s.store.GetServer(job.ServerID)
+		if err != nil {
+			return nil, err
+		}
+		return playbackReportingFileRun(srv, job.FilePath), nil
+	}
+	return nil, fmt.Errorf("unknown import source %q", job.Source)
+}
+
+func (s *Server) failImportJob(job *models.ImportJob, err error) {
+	now := time.Now().UTC()
+	job.Status = models.ImportJobFailed
+	job.FinishedAt = &now
+	job.Error = "import failed, check server logs"
+	var f *importFailure
+	if errors.As(err, &f) {
+		job.Error = f.message
+	}
+	if err := s.store.SaveImportJob(context.Background(), job); err != nil {
+		log.Printf("saving import job %d: %v", job.ID, err)
+	}
+	if job.FilePath != "" {
+		os.Remove(job.FilePath)
+	}
+}
+
+// spoolImportUpload copies an uploaded file into the import directory, where
+// it stays until its job finishes so a restart can resume from it.
+func (s *Server) spoolImportUpload(pattern string, write func(f *os.File) error) (string, error) {
+	dir := s.importDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func importJobEvent(typ string, j *models.ImportJob) importProgressEvent {
+	return importProgressEvent{
+		Type:         typ,
+		JobID:        j.ID,
+		Processed:    j.Processed,
+		Total:        j.Total,
+		Inserted:     j.Inserted,
+		Skipped:      j.Skipped,
+		Consolidated: j.Consolidated,
+		Errors:       j.Errors,
+	}
+}
+
+// finalImportEvent is the event describing where a job ended up.
+func finalImportEvent(j *models.ImportJob) importProgressEvent {
+	switch j.Status {
+	case models.ImportJobCompleted:
+		return importJobEvent("complete", j)
+	case models.ImportJobRunning:
+		return importJobEvent("progress", j)
+	}
+	ev := importJobEvent("error", j)
+	ev.Error = j.Error
+	return ev
+}
+
+// streamImportJob relays a job's progress as server-sent events until the
+// job finishes or the client goes away. The job keeps running either way.
+// With no channel, only first is sent.
+func (s *Server) streamImportJob(w http.ResponseWriter, r *http.Request, flusher http.Flusher, id int64, first *importProgressEvent, ch chan importProgressEvent) {
+	send := func(ev importProgressEvent) {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+	if first != nil {
+		send(*first)
+	}
+	if ch == nil {
+		return
+	}
+	for {
+		select {
+		case ev, open := <-ch:
+			if !open {
+				job, err := s.store.GetImportJob(context.Background(), id)
+				if err != nil {
+					log.Printf("getting import job %d: %v", id, err)
+					return
+				}
+				send(finalImportEvent(job))
+				return
+			}
+			send(ev)
+		case <-r.Context().Done():
+			s.importJobs.unsubscribe(id, ch)
+			return
+		}
+	}
+}
+
+// startImportJobStream starts job and streams its progress on the request
+// that started it.
+func (s *Server) startImportJobStream(w http.ResponseWriter, r *http.Request, job *models.ImportJob, run importJobRun) {
+	flusher, ok := sseFlusher(w)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	if user := UserFromContext(r.Context()); user != nil {
+		job.CreatedBy = user.Name
+	}
+	ch, err := s.startImportJob(r.Context(), job, run, true)
+	if errors.Is(err, errImportBusy) {
+		writeError(w, http.StatusConflict, importSourceLabels[job.Source]+" import already in progress")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR %s import: start job: %v", importSourceLabels[job.Source], err)
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	s.streamImportJob(w, r, flusher, job.ID, nil, ch)
+}
+
+func (s *Server) handleListImportJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.store.ListImportJobs(r.Context(), importJobListLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list import jobs")
+		return
+	}
+	for i := range jobs {
+		if live, ok := s.importJobs.get(jobs[i].ID); ok {
+			jobs[i] = live
+		}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleGetImportJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid import job id")
+		return
+	}
+	if live, ok := s.importJobs.get(id); ok {
+		writeJSON(w, http.StatusOK, live)
+		return
+	}
+	job, err := s.store.GetImportJob(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleImportJobEvents streams a job's progress, starting with its current
+// state. A finished job gets its final state and the stream ends.
+func (s *Server) handleImportJobEvents(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid import job id")
+		return
+	}
+	job, ch, running := s.importJobs.subscribe(id)
+	if !running {
+		stored, err := s.store.GetImportJob(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		job = *stored
+	}
+	flusher, ok := sseFlusher(w)
+	if !ok {
+		if running {
+			s.importJobs.unsubscribe(id, ch)
+		}
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	if !running {
+		ev := finalImportEvent(&job)
+		s.streamImportJob(w, r, flusher, id, &ev, nil)
+		return
+	}
+	ev := importJobEvent("progress", &job)
+	s.streamImportJob(w, r, flusher, id, &ev, ch)
+}
+
+func (s *Server) handleCancelImportJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(r, "id")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid import job id")
+		return
+	}
+	if job, ok := s.importJobs.cancel(id); ok {
+		writeJSON(w, http.StatusAccepted, job)
+		return
+	}
+	if _, err := s.store.GetImportJob(r.Context(), id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeError(w, http.StatusConflict, "import job is not running")
+}
@@ -82,6 +82,13 @@ func viewerName(r *http.Request) string {
 	return ""
 }
 
+// isAdmin reports whether the caller is a full admin. Co-admins read what
+// admins read but don't get admin-only switches.
+func isAdmin(r *http.Request) bool {
+	user := UserFromContext(r.Context())
+	return user != nil && user.Role == models.RoleAdmin
+}
+
 func isValidPathSegment(s string) bool {
 	return !strings.Contains(s, "..") && !strings.Contains(s, "?") && !strings.Contains(s, "#")
 }
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"sync"
 	"time"
 
@@ -14,6 +16,18 @@ type librarySyncManager struct {
 	mu     sync.Mutex
 	wg     sync.WaitGroup
 	active map[string]*librarySyncJob
+
+	// Manual syncs queue globally in request order and run with bounded
+	// parallelism per media server. wake is closed and replaced whenever a
+	// slot frees up or the queue changes, waking every waiter to recheck.
+	queue   []queuedSync
+	running map[int64]int
+	wake    chan struct{}
+}
+
+type queuedSync struct {
+	key      string
+	serverID int64
 }
 
 type librarySyncJob struct {
@@ -46,6 +60,89 @@ func (m *librarySyncManager) tryStart(key, libraryID string) bool {
 	return true
 }
 
+// acquireSlot queues the job for key and blocks until fewer than limit syncs
+// are running against serverID and no earlier request for the same server is
+// still waiting. Callers must releaseSlot once the sync finishes. Returns the
+// context's error if it ends while the job is still queued.
+func (m *librarySyncManager) acquireSlot(ctx context.Context, key string, serverID int64, limit int) error {
+	limit = max(limit, 1)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running == nil {
+		m.running = make(map[int64]int)
+	}
+	m.queue = append(m.queue, queuedSync{key: key, serverID: serverID})
+	if job, ok := m.active[key]; ok {
+		job.progress.Phase = mediautil.PhaseQueued
+	}
+
+	for {
+		if m.canStartLocked(key, serverID, limit) {
+			m.dequeueLocked(key)
+			m.running[serverID]++
+			if job, ok := m.active[key]; ok {
+				job.progress.Phase = mediautil.PhaseItems
+			}
+			return nil
+		}
+		wake := m.wakeLocked()
+		m.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			m.mu.Lock()
+			m.dequeueLocked(key)
+			return ctx.Err()
+		case <-wake:
+		}
+		m.mu.Lock()
+	}
+}
+
+// releaseSlot frees the slot taken by acquireSlot.
+func (m *librarySyncManager) releaseSlot(serverID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running[serverID] > 0 {
+		m.running[serverID]--
+	}
+	m.broadcastLocked()
+}
+
+func (m *librarySyncManager) canStartLocked(key string, serverID int64, limit int) bool {
+	if m.running[serverID] >= limit {
+		return false
+	}
+	for _, q := range m.queue {
+		if q.key == key {
+			return true
+		}
+		if q.serverID == serverID {
+			return false
+		}
+	}
+	return false
+}
+
+func (m *librarySyncManager) dequeueLocked(key string) {
+	m.queue = slices.DeleteFunc(m.queue, func(q queuedSync) bool { return q.key == key })
+	m.broadcastLocked()
+}
+
+func (m *librarySyncManager) wakeLocked() <-chan struct{} {
+	if m.wake == nil {
+		m.wake = make(chan struct{})
+	}
+	return m.wake
+}
+
+func (m *librarySyncManager) broadcastLocked() {
+	if m.wake != nil {
+		close(m.wake)
+		m.wake = nil
+	}
+}
+
 // updateProgress atomically replaces the job's progress snapshot.
 func (m *librarySyncManager) updateProgress(key string, p mediautil.SyncProgress) {
 	m.mu.Lock()
@@ -110,6 +207,13 @@ func (m *librarySyncManager) status() map[string]mediautil.SyncProgress {
 		}
 	}
 
+	for i, q := range m.queue {
+		if p, ok := result[q.key]; ok {
+			p.QueuePosition = i + 1
+			result[q.key] = p
+		}
+	}
+
 	return result
 }
 
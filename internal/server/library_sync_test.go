@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -228,3 +229,85 @@ func TestSyncErrorFallback(t *testing.T) {
 		t.Errorf("Error() = %q, want %q", se2.Error(), "user message")
 	}
 }
+
+func TestLibrarySyncQueuePerServerParallelism(t *testing.T) {
+	m := newTestSyncManager()
+	ctx := context.Background()
+	for _, key := range []string{"1-a", "1-b", "2-c"} {
+		m.tryStart(key, key[2:])
+	}
+
+	if err := m.acquireSlot(ctx, "1-a", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if err := m.acquireSlot(ctx, "1-b", 1, 1); err != nil {
+			t.Error(err)
+		}
+		close(acquired)
+	}()
+
+	// Another server has its own slots.
+	if err := m.acquireSlot(ctx, "2-c", 2, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for m.status()["1-b"].Phase != mediautil.PhaseQueued {
+		if time.Now().After(deadline) {
+			t.Fatalf("1-b never queued: %+v", m.status()["1-b"])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := m.status()["1-b"]; got.QueuePosition != 1 {
+		t.Errorf("queue position = %d, want 1", got.QueuePosition)
+	}
+	select {
+	case <-acquired:
+		t.Fatal("1-b started while server 1 was at its limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	m.releaseSlot(1)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("1-b did not start after a slot freed up")
+	}
+	if got := m.status()["1-b"]; got.Phase != mediautil.PhaseItems || got.QueuePosition != 0 {
+		t.Errorf("1-b status = %+v, want running", got)
+	}
+
+	m.releaseSlot(1)
+	m.releaseSlot(2)
+	for _, key := range []string{"1-a", "1-b", "2-c"} {
+		m.finish(key, 0, 0, nil)
+	}
+}
+
+func TestLibrarySyncQueueCancelledWhileWaiting(t *testing.T) {
+	m := newTestSyncManager()
+	m.tryStart("1-a", "a")
+	m.tryStart("1-b", "b")
+	if err := m.acquireSlot(context.Background(), "1-a", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.acquireSlot(ctx, "1-b", 1, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	m.mu.Lock()
+	queued := len(m.queue)
+	m.mu.Unlock()
+	if queued != 0 {
+		t.Errorf("expected cancelled job to leave the queue, %d still queued", queued)
+	}
+
+	m.releaseSlot(1)
+	m.finish("1-a", 0, 0, nil)
+	m.finish("1-b", 0, 0, nil)
+}
@@ -1,12 +1,21 @@
 package server
 
 import (
+	"cmp"
 	"context"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"streammon/internal/metrics"
+	"streammon/internal/models"
 )
 
 const maxBodySize = 1 << 20 // 1MB
@@ -38,21 +47,33 @@ func rawClientIP(r *http.Request) string {
 	return addr
 }
 
+// rateLimiter is a per-key token bucket: each key may make burst requests at
+// once, refilled at perMinute requests per minute.
 type rateLimiter struct {
-	mu       sync.Mutex
-	requests map[string][]time.Time
-	limit    int
-	window   time.Duration
-	done     chan struct{}
-	stopOnce sync.Once
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	perMinute int
+	burst     int
+	allowed   int64
+	rejected  int64
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
 }
 
-func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+// newRateLimiter allows perMinute requests per minute per key, plus burst
+// extra requests at once. A full bucket holds perMinute+burst tokens, so a
+// burst of zero reproduces a plain requests-per-minute limit.
+func newRateLimiter(perMinute, burst int) *rateLimiter {
 	rl := &rateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
-		done:     make(chan struct{}),
+		buckets:   make(map[string]*tokenBucket),
+		perMinute: perMinute,
+		burst:     burst,
+		done:      make(chan struct{}),
 	}
 	go rl.cleanup()
 	return rl
@@ -64,6 +85,28 @@ func (rl *rateLimiter) stop() {
 	})
 }
 
+// setLimits changes the budget without forgetting what clients have spent.
+func (rl *rateLimiter) setLimits(perMinute, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.perMinute = perMinute
+	rl.burst = burst
+	capacity := rl.capacity()
+	for _, b := range rl.buckets {
+		b.tokens = min(b.tokens, capacity)
+	}
+}
+
+func (rl *rateLimiter) capacity() float64 {
+	return float64(rl.perMinute + rl.burst)
+}
+
+// refill tops up b for the time since it was last touched. Caller holds mu.
+func (rl *rateLimiter) refill(b *tokenBucket, now time.Time) {
+	b.tokens = min(rl.capacity(), b.tokens+now.Sub(b.last).Minutes()*float64(rl.perMinute))
+	b.last = now
+}
+
 func (rl *rateLimiter) cleanup() {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
@@ -74,17 +117,9 @@ func (rl *rateLimiter) cleanup() {
 		case <-ticker.C:
 			rl.mu.Lock()
 			now := time.Now().UTC()
-			for ip, times := range rl.requests {
-				valid := times[:0]
-				for _, t := range times {
-					if now.Sub(t) <= rl.window {
-						valid = append(valid, t)
-					}
-				}
-				if len(valid) == 0 {
-					delete(rl.requests, ip)
-				} else {
-					rl.requests[ip] = valid
+			for key, b := range rl.buckets {
+				if rl.refill(b, now); b.tokens >= rl.capacity() {
+					delete(rl.buckets, key)
 				}
 			}
 			rl.mu.Unlock()
@@ -92,45 +127,127 @@ func (rl *rateLimiter) cleanup() {
 	}
 }
 
-func (rl *rateLimiter) allow(ip string) bool {
+func (rl *rateLimiter) allow(key string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	now := time.Now().UTC()
-	times := rl.requests[ip]
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rl.capacity(), last: now}
+		rl.buckets[key] = b
+	}
+	rl.refill(b, now)
+	if b.tokens < 1 {
+		rl.rejected++
+		return false
+	}
+	b.tokens--
+	rl.allowed++
+	return true
+}
+
+// retryAfter returns how long until key may make another request.
+func (rl *rateLimiter) retryAfter(key string) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b, ok := rl.buckets[key]
+	if !ok {
+		return 0
+	}
+	return rl.wait(b, time.Now().UTC())
+}
 
-	valid := times[:0]
-	for _, t := range times {
-		if now.Sub(t) <= rl.window {
-			valid = append(valid, t)
+// wait is the time until b holds a whole token. Caller holds mu.
+func (rl *rateLimiter) wait(b *tokenBucket, now time.Time) time.Duration {
+	rl.refill(b, now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / float64(rl.perMinute) * float64(time.Minute))
+}
+
+func (rl *rateLimiter) status(name string) models.RateLimiterStatus {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	st := models.RateLimiterStatus{
+		Name:           name,
+		TrackedClients: len(rl.buckets),
+		Throttled:      []models.ThrottledClient{},
+		Allowed:        rl.allowed,
+		Rejected:       rl.rejected,
+	}
+	now := time.Now().UTC()
+	for key, b := range rl.buckets {
+		if d := rl.wait(b, now); d > 0 {
+			st.Throttled = append(st.Throttled, models.ThrottledClient{Key: key, RetryAfterSeconds: retryAfterSeconds(d)})
 		}
 	}
+	sortThrottled(st.Throttled)
+	return st
+}
 
-	if len(valid) >= rl.limit {
-		rl.requests[ip] = valid
-		return false
+// retryAfterSeconds rounds d up to whole seconds for a Retry-After header.
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
+
+// sortThrottled orders the clients that will wait longest first.
+func sortThrottled(clients []models.ThrottledClient) {
+	slices.SortFunc(clients, func(a, b models.ThrottledClient) int {
+		if c := cmp.Compare(b.RetryAfterSeconds, a.RetryAfterSeconds); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+}
+
+// rateLimiters are a server's request limiters, configured from the rate
+// limit settings; see apply. ExemptAPIKeys only affects the search limiter;
+// models.AuthRateLimit explains why the auth limiter needs no exemption.
+type rateLimiters struct {
+	search        *rateLimiter
+	auth          *authRateLimiter
+	exemptAPIKeys atomic.Bool
+}
+
+func newRateLimiters() *rateLimiters {
+	return &rateLimiters{
+		search: newRateLimiter(models.DefaultSearchRequestsPerMinute, 0),
+		auth:   newAuthRateLimiter(models.DefaultAuthMaxFailures, models.DefaultAuthWindowMinutes*time.Minute),
 	}
+}
 
-	rl.requests[ip] = append(valid, now)
-	return true
+// stop ends the limiters' cleanup goroutines.
+func (l *rateLimiters) stop() {
+	l.search.stop()
+	l.auth.Stop()
 }
 
-// Global rate limiter for search endpoints: 30 requests per minute per IP
-var searchRateLimiter = newRateLimiter(30, time.Minute)
+// apply points the limiters at settings.
+func (l *rateLimiters) apply(settings models.RateLimitSettings) {
+	l.search.setLimits(settings.Search.RequestsPerMinute, settings.Search.Burst)
+	l.exemptAPIKeys.Store(settings.Search.ExemptAPIKeys)
+	l.auth.setLimits(settings.Auth.MaxFailures, time.Duration(settings.Auth.WindowMinutes)*time.Minute)
+}
 
-// StopRateLimiter stops the global rate limiter's cleanup goroutine.
-// Call this during server shutdown.
-func StopRateLimiter() {
-	searchRateLimiter.stop()
+// status reports the limiters, for the admin status endpoint.
+func (l *rateLimiters) status() []models.RateLimiterStatus {
+	return []models.RateLimiterStatus{
+		l.search.status("search"),
+		l.auth.status("auth"),
+	}
 }
 
-func rateLimit(next http.Handler) http.Handler {
+// limitSearch applies the search limiter to GET requests with a search query.
+func (l *rateLimiters) limitSearch(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet && r.URL.Query().Get("search") != "" {
+		if r.Method == http.MethodGet && r.URL.Query().Get("search") != "" && !l.searchExempt(r) {
 			ip := rawClientIP(r)
-			if !searchRateLimiter.allow(ip) {
+			if !l.search.allow(ip) {
 				log.Printf("search rate limit: ip=%s path=%s", ip, r.URL.Path)
-				w.Header().Set("Retry-After", "60")
+				metrics.RateLimited.Inc("search")
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(l.search.retryAfter(ip))))
 				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
 				return
 			}
@@ -139,6 +256,16 @@ func rateLimit(next http.Handler) http.Handler {
 	})
 }
 
+// searchExempt reports whether r authenticated with the API key or a
+// personal API token while those are exempt from the search limit.
+func (l *rateLimiters) searchExempt(r *http.Request) bool {
+	if !l.exemptAPIKeys.Load() {
+		return false
+	}
+	user := UserFromContext(r.Context())
+	return user != nil && (user.APIKeyAuth || user.APITokenID != 0)
+}
+
 func limitBody(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
@@ -159,7 +286,7 @@ func corsMiddleware(allowedOrigin string) func(http.Handler) http.Handler {
 			if allowedOrigin != "" {
 				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Guest-Token")
 				if r.Method == http.MethodOptions {
 					w.WriteHeader(http.StatusNoContent)
 					return
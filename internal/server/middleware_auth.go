@@ -7,11 +7,16 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"streammon/internal/auth"
+	"streammon/internal/metrics"
 	"streammon/internal/models"
 )
 
@@ -28,32 +33,41 @@ func UserFromContext(ctx context.Context) *models.User {
 // the prefix plus 32 bytes hex-encoded.
 const expectedAPIKeyLength = len(auth.APIKeyPrefix) + 64
 
-// RequireAuthManager creates auth middleware using the auth.Manager.
-// Two paths:
+// guestTokenHeader carries a guest token in place of a session cookie.
+const guestTokenHeader = "X-Guest-Token"
+
+// requireAuth creates this server's auth middleware. Five paths:
 //  1. X-API-Key header → hash-compared against the stored API key. On match,
 //     a synthetic admin user is injected (no DB lookup). Mismatch is 401 and
-//     bumps the global auth rate limiter — does not fall through to cookies.
-//  2. No header → existing session-cookie path.
+//     bumps the auth rate limiter — does not fall through to cookies.
+//  2. X-Guest-Token header → looked up like a session; see serveGuestToken.
+//  3. Authorization: Bearer header → personal API token; see serveAPIToken.
+//  4. Trusted proxy header, when enabled; see serveProxyAuth.
+//  5. No header → existing session-cookie path.
 //
 // SECURITY: No fallback to default admin - auth is always required.
-func RequireAuthManager(mgr *auth.Manager) func(http.Handler) http.Handler {
+func (s *Server) requireAuth() func(http.Handler) http.Handler {
+	return authMiddleware(s.authManager, &s.proxyAuth, s.limits.auth)
+}
+
+func authMiddleware(mgr *auth.Manager, proxy *atomic.Pointer[proxyAuthConfig], limiter *authRateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		return authGate{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Use Values, not Get: an explicitly-empty header (e.g. "X-API-Key: ")
 			// must reject + rate-limit, not silently fall through to cookie auth.
 			if vals := r.Header.Values("X-API-Key"); len(vals) > 0 {
 				ip := rawClientIP(r)
 
 				// Rate-limit before doing any work on attacker-controlled input.
-				if !globalAuthRateLimiter.check(ip) {
-					w.Header().Set("Retry-After", "900")
+				if !limiter.check(ip) {
+					w.Header().Set("Retry-After", limiter.retryAfter())
 					writeError(w, http.StatusTooManyRequests, "too many auth attempts, try again later")
 					return
 				}
 
 				// Defense-in-depth: API-key acceptance only after setup is complete.
 				if required, err := mgr.IsSetupRequired(); err != nil || required {
-					globalAuthRateLimiter.record(ip)
+					limiter.record(ip)
 					writeError(w, http.StatusUnauthorized, "unauthorized")
 					return
 				}
@@ -61,7 +75,7 @@ func RequireAuthManager(mgr *auth.Manager) func(http.Handler) http.Handler {
 				// Reject duplicates and malformed inputs before hashing — bounds work
 				// on attacker input and disambiguates intent.
 				if len(vals) > 1 || !validAPIKeyShape(vals[0]) {
-					globalAuthRateLimiter.record(ip)
+					limiter.record(ip)
 					writeError(w, http.StatusUnauthorized, "unauthorized")
 					return
 				}
@@ -69,7 +83,7 @@ func RequireAuthManager(mgr *auth.Manager) func(http.Handler) http.Handler {
 				apiKey := vals[0]
 				stored, err := mgr.Store().GetAPIKey()
 				if err != nil || !auth.CompareAPIKey(stored, apiKey) {
-					globalAuthRateLimiter.record(ip)
+					limiter.record(ip)
 					writeError(w, http.StatusUnauthorized, "unauthorized")
 					return
 				}
@@ -78,6 +92,25 @@ func RequireAuthManager(mgr *auth.Manager) func(http.Handler) http.Handler {
 				return
 			}
 
+			if vals := r.Header.Values(guestTokenHeader); len(vals) > 0 {
+				serveGuestToken(mgr, limiter, w, r, vals, next)
+				return
+			}
+
+			if vals, ok := bearerTokens(r); ok {
+				serveAPIToken(mgr, limiter, w, r, vals, next)
+				return
+			}
+
+			if proxy != nil {
+				if cfg := proxy.Load(); cfg != nil {
+					if vals, ok := cfg.userHeader(r); ok {
+						serveProxyAuth(mgr, cfg, w, r, vals, next)
+						return
+					}
+				}
+			}
+
 			cookie, err := r.Cookie(auth.CookieName)
 			if err != nil {
 				writeError(w, http.StatusUnauthorized, "unauthorized")
@@ -94,7 +127,7 @@ func RequireAuthManager(mgr *auth.Manager) func(http.Handler) http.Handler {
 
 			ctx := context.WithValue(r.Context(), userContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
-		})
+		})}
 	}
 }
 
@@ -120,18 +153,185 @@ func syntheticAPIKeyUser() *models.User {
 	}
 }
 
-// RequireInteractiveSession rejects requests authenticated via X-API-Key.
-// Apply to handlers that mutate the caller's own user record, manage the API
-// key itself, or otherwise only make sense for a real human session.
+// serveGuestToken authenticates a guest token. A valid token only reaches
+// the read-only endpoints guestTokenTarget allows, and only for the users it
+// was issued for; the request then runs as a viewer named after that user,
+// so the handlers' existing viewer scoping applies. Bad tokens count toward
+// the auth rate limit like bad API keys.
+func serveGuestToken(mgr *auth.Manager, limiter *authRateLimiter, w http.ResponseWriter, r *http.Request, vals []string, next http.Handler) {
+	ip := rawClientIP(r)
+	if !limiter.check(ip) {
+		w.Header().Set("Retry-After", limiter.retryAfter())
+		writeError(w, http.StatusTooManyRequests, "too many auth attempts, try again later")
+		return
+	}
+	if len(vals) > 1 || !validGuestTokenShape(vals[0]) {
+		limiter.record(ip)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	token, err := mgr.Store().ResolveGuestToken(vals[0])
+	if err != nil {
+		limiter.record(ip)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	target, ok := guestTokenTarget(r)
+	if !ok || !token.AllowsUser(target) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	if err := mgr.Store().TouchGuestToken(token.ID); err != nil {
+		log.Printf("touching guest token %d: %v", token.ID, err)
+	}
+
+	guest := &models.User{
+		Name:         target,
+		Role:         models.RoleViewer,
+		GuestTokenID: token.ID,
+	}
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, guest)))
+}
+
+// expectedGuestTokenLength is the prefix plus 32 bytes hex-encoded.
+const expectedGuestTokenLength = len(models.GuestTokenPrefix) + 64
+
+func validGuestTokenShape(s string) bool {
+	return len(s) == expectedGuestTokenLength && strings.HasPrefix(s, models.GuestTokenPrefix)
+}
+
+// guestTokenTarget returns the user a guest-token request reads, for the
+// only requests a guest token may make: a user's stats, or the history list
+// filtered to one user.
+func guestTokenTarget(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet {
+		return "", false
+	}
+	if r.URL.Path == "/api/history" {
+		user := r.URL.Query().Get("user")
+		return user, user != ""
+	}
+	rest, ok := strings.CutPrefix(r.URL.EscapedPath(), "/api/users/")
+	if !ok {
+		return "", false
+	}
+	escaped, ok := strings.CutSuffix(rest, "/stats")
+	if !ok || escaped == "" || strings.Contains(escaped, "/") {
+		return "", false
+	}
+	name, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// bearerTokens returns the credentials of any Bearer Authorization headers.
+// Other schemes are left alone so a reverse proxy's Basic auth doesn't
+// shadow the session cookie.
+func bearerTokens(r *http.Request) ([]string, bool) {
+	var tokens []string
+	for _, v := range r.Header.Values("Authorization") {
+		scheme, token, _ := strings.Cut(v, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			tokens = append(tokens, strings.TrimSpace(token))
+		}
+	}
+	return tokens, len(tokens) > 0
+}
+
+// serveAPIToken authenticates a personal API token. The request runs as the
+// token's owner, limited to what the token's scope allows; see
+// apiTokenAllows. Bad tokens count toward the auth rate limit like bad API
+// keys.
+func serveAPIToken(mgr *auth.Manager, limiter *authRateLimiter, w http.ResponseWriter, r *http.Request, vals []string, next http.Handler) {
+	ip := rawClientIP(r)
+	if !limiter.check(ip) {
+		w.Header().Set("Retry-After", limiter.retryAfter())
+		writeError(w, http.StatusTooManyRequests, "too many auth attempts, try again later")
+		return
+	}
+	if len(vals) > 1 || !validAPITokenShape(vals[0]) {
+		limiter.record(ip)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	token, owner, err := mgr.Store().ResolveAPIToken(vals[0])
+	if err != nil {
+		limiter.record(ip)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if !apiTokenAllows(token.Scope, owner.Role, r) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	if err := mgr.Store().TouchAPIToken(token.ID); err != nil {
+		log.Printf("touching api token %d: %v", token.ID, err)
+	}
+
+	owner.APITokenID = token.ID
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, owner)))
+}
+
+// expectedAPITokenLength is the prefix plus 32 bytes hex-encoded.
+const expectedAPITokenLength = len(models.APITokenPrefix) + 64
+
+func validAPITokenShape(s string) bool {
+	return len(s) == expectedAPITokenLength && strings.HasPrefix(s, models.APITokenPrefix)
+}
+
+// apiTokenAllows reports whether a token with scope may make r. Role checks
+// further down still apply, so a token never reaches more than its owner
+// could. An admin-scoped token stops working if its owner loses the admin
+// role.
+func apiTokenAllows(scope models.APITokenScope, role models.Role, r *http.Request) bool {
+	switch scope {
+	case models.APITokenScopeAdmin:
+		return role == models.RoleAdmin
+	case models.APITokenScopeReadOnly:
+		return r.Method == http.MethodGet || r.Method == http.MethodHead
+	case models.APITokenScopeStatsOnly:
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return false
+		}
+		return isStatsPath(r.URL.EscapedPath())
+	}
+	return false
+}
+
+// isStatsPath matches the global stats endpoints and a user's stats page.
+func isStatsPath(path string) bool {
+	if path == "/api/stats" || strings.HasPrefix(path, "/api/stats/") {
+		return true
+	}
+	rest, ok := strings.CutPrefix(path, "/api/users/")
+	if !ok {
+		return false
+	}
+	name, ok := strings.CutSuffix(rest, "/stats")
+	return ok && name != "" && !strings.Contains(name, "/")
+}
+
+// RequireInteractiveSession rejects requests authenticated via X-API-Key, a
+// guest token, or a personal API token. Apply to handlers that mutate the
+// caller's own user record, manage the API key itself, or otherwise only make
+// sense for a real human session.
 func RequireInteractiveSession(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return interactiveGate{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := UserFromContext(r.Context())
-		if user == nil || user.APIKeyAuth {
+		if user == nil || user.APIKeyAuth || user.GuestTokenID != 0 || user.APITokenID != 0 {
 			writeError(w, http.StatusForbidden, "interactive session required")
 			return
 		}
 		next.ServeHTTP(w, r)
-	})
+	})}
 }
 
 // setupCheck creates middleware that checks setup status.
@@ -169,16 +369,17 @@ func RequireSetupComplete(mgr *auth.Manager) func(http.Handler) http.Handler {
 	return setupCheck(mgr, false)
 }
 
-func RequireRole(role models.Role) func(http.Handler) http.Handler {
+// RequireRole rejects requests from users whose role isn't one of roles.
+func RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		return roleGate{roles, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user := UserFromContext(r.Context())
-			if user == nil || user.Role != role {
+			if user == nil || !slices.Contains(roles, user.Role) {
 				writeError(w, http.StatusForbidden, "forbidden")
 				return
 			}
 			next.ServeHTTP(w, r)
-		})
+		})}
 	}
 }
 
@@ -188,6 +389,8 @@ type authRateLimiter struct {
 	attempts map[string][]time.Time
 	limit    int
 	window   time.Duration
+	allowed  int64
+	rejected int64
 	stopOnce sync.Once
 	stopCh   chan struct{}
 }
@@ -257,7 +460,13 @@ func (l *authRateLimiter) check(ip string) bool {
 	valid := filterValid(l.attempts[ip], cutoff)
 	l.attempts[ip] = valid
 
-	return len(valid) < l.limit
+	if len(valid) >= l.limit {
+		l.rejected++
+		metrics.RateLimited.Inc("auth")
+		return false
+	}
+	l.allowed++
+	return true
 }
 
 func (l *authRateLimiter) record(ip string) {
@@ -267,13 +476,47 @@ func (l *authRateLimiter) record(ip string) {
 	l.attempts[ip] = append(l.attempts[ip], time.Now().UTC())
 }
 
-// Global rate limiter for auth endpoints: 10 failed attempts per 15 minutes
-var globalAuthRateLimiter = newAuthRateLimiter(10, 15*time.Minute)
+// setLimits changes the budget without forgetting recorded failures.
+func (l *authRateLimiter) setLimits(limit int, window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.window = window
+}
 
-// StopAuthRateLimiter stops the background cleanup goroutine for the auth rate limiter.
-// Call this during graceful shutdown.
-func StopAuthRateLimiter() {
-	globalAuthRateLimiter.Stop()
+// retryAfter is the Retry-After value, in seconds, for a locked-out client:
+// the whole window, since one more failure restarts it.
+func (l *authRateLimiter) retryAfter() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strconv.Itoa(retryAfterSeconds(l.window))
+}
+
+func (l *authRateLimiter) status(name string) models.RateLimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st := models.RateLimiterStatus{
+		Name:      name,
+		Throttled: []models.ThrottledClient{},
+		Allowed:   l.allowed,
+		Rejected:  l.rejected,
+	}
+	now := time.Now().UTC()
+	cutoff := now.Add(-l.window)
+	for key, attempts := range l.attempts {
+		valid := filterValid(attempts, cutoff)
+		if len(valid) == 0 {
+			continue
+		}
+		st.TrackedClients++
+		if len(valid) >= l.limit {
+			// Locked out until enough failures age out to drop below the limit.
+			until := valid[len(valid)-l.limit].Add(l.window)
+			st.Throttled = append(st.Throttled, models.ThrottledClient{Key: key, RetryAfterSeconds: retryAfterSeconds(until.Sub(now))})
+		}
+	}
+	sortThrottled(st.Throttled)
+	return st
 }
 
 // statusRecorder wraps ResponseWriter to capture the status code
@@ -287,17 +530,17 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
-// rateLimitAuthWith applies the failed-attempt limiter keyed by keyFn(r).
+// limitAuthWith applies the failed-attempt limiter keyed by keyFn(r).
 // Only failed attempts (4xx/5xx responses) count toward the limit.
-func rateLimitAuthWith(keyFn func(*http.Request) string, next http.Handler) http.Handler {
+func (l *rateLimiters) limitAuthWith(keyFn func(*http.Request) string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		key := keyFn(r)
 
-		if !globalAuthRateLimiter.check(key) {
+		if !l.auth.check(key) {
 			// Log the raw peer + path only — never the attacker-controlled
 			// username portion of the key, to avoid log injection.
 			log.Printf("auth rate limit: ip=%s path=%s", rawClientIP(r), r.URL.Path)
-			w.Header().Set("Retry-After", "900") // 15 minutes
+			w.Header().Set("Retry-After", l.auth.retryAfter())
 			writeError(w, http.StatusTooManyRequests, "too many login attempts, try again later")
 			return
 		}
@@ -307,29 +550,29 @@ func rateLimitAuthWith(keyFn func(*http.Request) string, next http.Handler) http
 
 		// Only count failed attempts (4xx/5xx)
 		if rec.status >= 400 {
-			globalAuthRateLimiter.record(key)
+			l.auth.record(key)
 		}
 	})
 }
 
-// RateLimitAuth applies IP-only rate limiting to authentication endpoints that
+// limitAuth applies IP-only rate limiting to authentication endpoints that
 // carry no login username (setup, OIDC, password-change, API-key rotate).
 // Keys on the raw socket peer captured by CaptureRawRemoteAddr so a spoofed
 // X-Forwarded-For (already applied by middleware.RealIP) cannot rotate the
 // limiter's bucket.
-func RateLimitAuth(next http.Handler) http.Handler {
-	return rateLimitAuthWith(func(r *http.Request) string { return rawClientIP(r) }, next)
+func (l *rateLimiters) limitAuth(next http.Handler) http.Handler {
+	return l.limitAuthWith(func(r *http.Request) string { return rawClientIP(r) }, next)
 }
 
-// RateLimitLogin applies rate limiting to credential-login endpoints, scoped by
+// limitLogin applies rate limiting to credential-login endpoints, scoped by
 // "<rawClientIP>|<username>". Behind a reverse proxy every user shares one
 // socket peer, so IP-only keying lets one bad actor's failed logins lock out
 // all users. Scoping by the submitted account identifier confines a flood to
 // the targeted account while still keying on the raw socket peer (not a
 // spoofable X-Forwarded-For) for the IP portion. Falls back to IP-only when no
 // username is present in the body (e.g. Plex token login).
-func RateLimitLogin(next http.Handler) http.Handler {
-	return rateLimitAuthWith(loginRateLimitKey, next)
+func (l *rateLimiters) limitLogin(next http.Handler) http.Handler {
+	return l.limitAuthWith(loginRateLimitKey, next)
 }
 
 // loginRateLimitKey builds the per-account limiter bucket key for a login
@@ -18,11 +18,13 @@ import (
 	"streammon/internal/models"
 )
 
-func resetAuthRateLimiter(t *testing.T) {
+// newTestRateLimiters returns limiters with the default limits, stopped when
+// the test ends.
+func newTestRateLimiters(t *testing.T) *rateLimiters {
 	t.Helper()
-	globalAuthRateLimiter.mu.Lock()
-	defer globalAuthRateLimiter.mu.Unlock()
-	globalAuthRateLimiter.attempts = make(map[string][]time.Time)
+	l := newRateLimiters()
+	t.Cleanup(l.stop)
+	return l
 }
 
 func contextWithUser(ctx context.Context, u *models.User) context.Context {
@@ -30,10 +32,11 @@ func contextWithUser(ctx context.Context, u *models.User) context.Context {
 }
 
 func TestRequireAuthManager_NoCookie_Returns401(t *testing.T) {
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -60,13 +63,14 @@ func TestRequireAuthManager_NoCookie_Returns401(t *testing.T) {
 }
 
 func TestRequireAuthManager_ValidSession(t *testing.T) {
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
 	user, _ := st.GetOrCreateUser("testuser")
 	token, _ := st.CreateSession(user.ID, time.Now().UTC().Add(24*time.Hour))
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	var gotUser *models.User
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gotUser = UserFromContext(r.Context())
@@ -90,7 +94,7 @@ func TestRequireAuthManager_ValidSession(t *testing.T) {
 }
 
 func TestRequireAuthManager_ValidAPIKey(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
@@ -102,7 +106,7 @@ func TestRequireAuthManager_ValidAPIKey(t *testing.T) {
 		t.Fatalf("SetAPIKey: %v", err)
 	}
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	var gotUser *models.User
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gotUser = UserFromContext(r.Context())
@@ -129,7 +133,7 @@ func TestRequireAuthManager_ValidAPIKey(t *testing.T) {
 }
 
 func TestRequireAuthManager_InvalidAPIKey_ReturnsAuthError_AndRateLimits(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
@@ -138,7 +142,7 @@ func TestRequireAuthManager_InvalidAPIKey_ReturnsAuthError_AndRateLimits(t *test
 		t.Fatalf("SetAPIKey: %v", err)
 	}
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("handler should not run on bad key")
 	}))
@@ -154,23 +158,23 @@ func TestRequireAuthManager_InvalidAPIKey_ReturnsAuthError_AndRateLimits(t *test
 		t.Errorf("expected 401, got %d", w.Code)
 	}
 
-	globalAuthRateLimiter.mu.Lock()
-	count := len(globalAuthRateLimiter.attempts["10.0.0.1"])
-	globalAuthRateLimiter.mu.Unlock()
+	limits.auth.mu.Lock()
+	count := len(limits.auth.attempts["10.0.0.1"])
+	limits.auth.mu.Unlock()
 	if count != 1 {
 		t.Errorf("expected rate-limit counter incremented once, got %d", count)
 	}
 }
 
 func TestRequireAuthManager_EmptyAPIKeyHeader_Rejects(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
 	user, _ := st.GetOrCreateUser("testuser")
 	token, _ := st.CreateSession(user.ID, time.Now().UTC().Add(24*time.Hour))
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("handler should not run on empty API key header")
 	}))
@@ -187,7 +191,7 @@ func TestRequireAuthManager_EmptyAPIKeyHeader_Rejects(t *testing.T) {
 }
 
 func TestRequireAuthManager_APIKeyRateLimitBlocksAfterThreshold(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
@@ -196,7 +200,7 @@ func TestRequireAuthManager_APIKeyRateLimitBlocksAfterThreshold(t *testing.T) {
 		t.Fatalf("SetAPIKey: %v", err)
 	}
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -230,7 +234,7 @@ func TestRequireAuthManager_APIKeyRateLimitBlocksAfterThreshold(t *testing.T) {
 }
 
 func TestRequireAuthManager_APIKey_RejectsMalformedShape(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
@@ -239,7 +243,7 @@ func TestRequireAuthManager_APIKey_RejectsMalformedShape(t *testing.T) {
 		t.Fatalf("SetAPIKey: %v", err)
 	}
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("handler should not run on bad shape")
 	}))
@@ -256,7 +260,7 @@ func TestRequireAuthManager_APIKey_RejectsMalformedShape(t *testing.T) {
 }
 
 func TestRequireAuthManager_APIKey_RejectsMultipleHeaders(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
@@ -265,7 +269,7 @@ func TestRequireAuthManager_APIKey_RejectsMultipleHeaders(t *testing.T) {
 		t.Fatalf("SetAPIKey: %v", err)
 	}
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("handler should not run when duplicates are sent")
 	}))
@@ -281,7 +285,7 @@ func TestRequireAuthManager_APIKey_RejectsMultipleHeaders(t *testing.T) {
 }
 
 func TestRequireAuthManager_APIKey_RejectedBeforeSetup(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	st := newEmptyStore(t)
 	mgr := auth.NewManager(st)
 
@@ -291,7 +295,7 @@ func TestRequireAuthManager_APIKey_RejectedBeforeSetup(t *testing.T) {
 		t.Fatalf("SetAPIKey: %v", err)
 	}
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("handler must not run when setup is required")
 	}))
@@ -332,14 +336,14 @@ func TestRequireInteractiveSession_RejectsAPIKey(t *testing.T) {
 }
 
 func TestRequireAuthManager_BadAPIKey_DoesNotFallThroughToCookie(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 	_, st := newTestServer(t)
 	mgr := auth.NewManager(st)
 
 	user, _ := st.GetOrCreateUser("testuser")
 	token, _ := st.CreateSession(user.ID, time.Now().UTC().Add(24*time.Hour))
 
-	mw := RequireAuthManager(mgr)
+	mw := authMiddleware(mgr, nil, limits.auth)
 	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Fatal("handler should not run when API key is invalid")
 	}))
@@ -470,12 +474,12 @@ func TestRequireSetupComplete_AllowsWhenUsersExist(t *testing.T) {
 // land in fresh limiter buckets and never hit the cap. With the raw-socket
 // capture in place, all attempts key on the actual peer.
 func TestRateLimitAuth_IgnoresSpoofedXForwardedFor(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 
 	r := chi.NewRouter()
 	r.Use(CaptureRawRemoteAddr)
 	r.Use(middleware.RealIP)
-	r.With(RateLimitAuth).Post("/login", func(w http.ResponseWriter, _ *http.Request) {
+	r.With(limits.limitAuth).Post("/login", func(w http.ResponseWriter, _ *http.Request) {
 		http.Error(w, `{"error":"bad creds"}`, http.StatusUnauthorized)
 	})
 
@@ -507,10 +511,10 @@ func TestRateLimitAuth_IgnoresSpoofedXForwardedFor(t *testing.T) {
 // still locked, and the limiter falls back to IP-only when no username is
 // present in the body.
 func TestRateLimitLogin_PerAccountScoping(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 
 	var lastSeenBody string
-	handler := RateLimitLogin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := limits.limitLogin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Prove the peeked body was restored for the downstream handler.
 		b, _ := io.ReadAll(r.Body)
 		lastSeenBody = string(b)
@@ -558,12 +562,12 @@ func TestRateLimitLogin_PerAccountScoping(t *testing.T) {
 	}
 }
 
-// When the login body carries no username, RateLimitLogin degrades to IP-only
+// When the login body carries no username, limitLogin degrades to IP-only
 // scoping so the endpoint is still protected against blind floods.
 func TestRateLimitLogin_NoUsername_FallsBackToIP(t *testing.T) {
-	resetAuthRateLimiter(t)
+	limits := newTestRateLimiters(t)
 
-	handler := RateLimitLogin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := limits.limitLogin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"bad"}`, http.StatusUnauthorized)
 	}))
 
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+	"streammon/internal/store"
+)
+
+// proxyAuthConfig is the parsed form of the trusted header authentication
+// settings the auth middleware consults on every request.
+type proxyAuthConfig struct {
+	settings models.ProxyAuthSettings
+	trusted  []netip.Prefix
+}
+
+// applyProxyAuthSettings points the server's auth middleware at settings.
+func (s *Server) applyProxyAuthSettings(settings models.ProxyAuthSettings) error {
+	if !settings.Enabled {
+		s.proxyAuth.Store(nil)
+		return nil
+	}
+	trusted, err := settings.ParseTrustedProxies()
+	if err != nil {
+		return err
+	}
+	s.proxyAuth.Store(&proxyAuthConfig{settings: settings, trusted: trusted})
+	return nil
+}
+
+// fromTrustedProxy reports whether the socket peer, not any forwarded
+// address, is one of the trusted proxies.
+func (c *proxyAuthConfig) fromTrustedProxy(r *http.Request) bool {
+	addr, err := netip.ParseAddr(rawClientIP(r))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, p := range c.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// userHeader returns the user header's values when r came from a trusted
+// proxy and carries it. ok is false when header authentication doesn't
+// apply to r and other credentials should be tried.
+func (c *proxyAuthConfig) userHeader(r *http.Request) (vals []string, ok bool) {
+	vals = r.Header.Values(c.settings.UserHeader)
+	if len(vals) == 0 || !c.fromTrustedProxy(r) {
+		return nil, false
+	}
+	return vals, true
+}
+
+// serveProxyAuth signs r in as the user its trusted proxy named.
+func serveProxyAuth(mgr *auth.Manager, cfg *proxyAuthConfig, w http.ResponseWriter, r *http.Request, vals []string, next http.Handler) {
+	name := strings.TrimSpace(vals[0])
+	if len(vals) > 1 || name == "" {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if required, err := mgr.IsSetupRequired(); err != nil || required {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	// The proxy vouches for every request the browser sends through it,
+	// including ones another site triggers, so cross-site writes are refused.
+	if crossSiteWrite(r) {
+		writeError(w, http.StatusForbidden, "cross-site request refused")
+		return
+	}
+
+	var email string
+	if h := cfg.settings.EmailHeader; h != "" {
+		email = strings.TrimSpace(r.Header.Get(h))
+	}
+	user, err := resolveProxyUser(mgr.Store(), name, email, cfg.settings.AutoProvision)
+	if err != nil {
+		if !errors.Is(err, models.ErrNotFound) {
+			log.Printf("proxy auth for %q: %v", name, err)
+		}
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+}
+
+// resolveProxyUser maps a proxy-asserted user name to an account: one the
+// proxy provisioned before, then an account of that name, then one with
+// the proxy-asserted email. Unknown users are created only with
+// autoProvision.
+func resolveProxyUser(st *store.Store, name, email string, autoProvision bool) (*models.User, error) {
+	if user, err := st.GetUserByProvider(string(auth.ProviderProxy), name); err == nil {
+		return user, nil
+	}
+	if user, _, err := st.GetUserByUsername(name); err == nil {
+		return user, nil
+	} else if !errors.Is(err, models.ErrNotFound) {
+		return nil, err
+	}
+	if email != "" {
+		if user, err := st.GetUserByEmail(email); err == nil {
+			return user, nil
+		}
+	}
+	if !autoProvision {
+		return nil, models.ErrNotFound
+	}
+	return st.GetOrLinkUser(email, []string{name}, name, string(auth.ProviderProxy), name, "")
+}
+
+func crossSiteWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || !strings.EqualFold(u.Host, r.Host)
+}
@@ -41,14 +41,13 @@ func TestSecurityHeaders_CSPPresent(t *testing.T) {
 // TestRateLimit_ExceededReturnsJSONError verifies the search rate limiter's
 // 429 body is JSON (via writeError), not http.Error's default text/plain.
 // The bucket for a test-only IP is pre-filled directly rather than firing 30
-// real requests, and rate-limited via a dedicated fake IP so it can't be
-// polluted by (or pollute) other tests sharing the global searchRateLimiter.
+// real requests.
 func TestRateLimit_ExceededReturnsJSONError(t *testing.T) {
 	srv, _ := newTestServerWrapped(t)
 
 	const ip = "203.0.113.99" // TEST-NET-3 (RFC 5737): reserved for docs/testing
 	for i := 0; i < 30; i++ {
-		searchRateLimiter.allow(ip)
+		srv.limits.search.allow(ip)
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/maintenance/exclusions?search=x", nil)
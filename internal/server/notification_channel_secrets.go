@@ -26,8 +26,9 @@ func unmaskSecret(v, stored string) string {
 }
 
 // maskChannelConfig returns a copy of raw with secret fields (Discord
-// webhook URL, webhook auth headers, Pushover API token, Ntfy token)
-// replaced by maskedSecret, so secrets never leave the server in cleartext.
+// webhook URL, webhook auth headers, Pushover API token, Ntfy token, Apprise
+// service URLs, SMTP password, Gotify token) replaced by maskedSecret, so secrets never leave the server
+// in cleartext.
 // raw itself is never mutated; on any decode error raw is returned unchanged
 // so callers still see a validation error rather than a silently-empty config.
 func maskChannelConfig(ct models.ChannelType, raw json.RawMessage) json.RawMessage {
@@ -74,6 +75,32 @@ func maskChannelConfig(ct models.ChannelType, raw json.RawMessage) json.RawMessa
 		cfg.Token = maskSecret(cfg.Token)
 		return marshalOrFallback(cfg, raw)
 
+	case models.ChannelTypeApprise:
+		var cfg models.AppriseConfig
+		if json.Unmarshal(raw, &cfg) != nil {
+			return raw
+		}
+		for i, u := range cfg.URLs {
+			cfg.URLs[i] = maskSecret(u)
+		}
+		return marshalOrFallback(cfg, raw)
+
+	case models.ChannelTypeEmail:
+		var cfg models.EmailConfig
+		if json.Unmarshal(raw, &cfg) != nil {
+			return raw
+		}
+		cfg.Password = maskSecret(cfg.Password)
+		return marshalOrFallback(cfg, raw)
+
+	case models.ChannelTypeGotify:
+		var cfg models.GotifyConfig
+		if json.Unmarshal(raw, &cfg) != nil {
+			return raw
+		}
+		cfg.Token = maskSecret(cfg.Token)
+		return marshalOrFallback(cfg, raw)
+
 	default:
 		return raw
 	}
@@ -127,6 +154,41 @@ func restoreChannelSecrets(ct models.ChannelType, newRaw, existingRaw json.RawMe
 		newCfg.Token = unmaskSecret(newCfg.Token, oldCfg.Token)
 		return marshalOrFallback(newCfg, newRaw)
 
+	case models.ChannelTypeApprise:
+		var newCfg, oldCfg models.AppriseConfig
+		if json.Unmarshal(newRaw, &newCfg) != nil {
+			return newRaw
+		}
+		_ = json.Unmarshal(existingRaw, &oldCfg)
+		// URLs are matched by position: a masked entry keeps the URL that
+		// was stored at the same index.
+		for i, u := range newCfg.URLs {
+			var stored string
+			if i < len(oldCfg.URLs) {
+				stored = oldCfg.URLs[i]
+			}
+			newCfg.URLs[i] = unmaskSecret(u, stored)
+		}
+		return marshalOrFallback(newCfg, newRaw)
+
+	case models.ChannelTypeEmail:
+		var newCfg, oldCfg models.EmailConfig
+		if json.Unmarshal(newRaw, &newCfg) != nil {
+			return newRaw
+		}
+		_ = json.Unmarshal(existingRaw, &oldCfg)
+		newCfg.Password = unmaskSecret(newCfg.Password, oldCfg.Password)
+		return marshalOrFallback(newCfg, newRaw)
+
+	case models.ChannelTypeGotify:
+		var newCfg, oldCfg models.GotifyConfig
+		if json.Unmarshal(newRaw, &newCfg) != nil {
+			return newRaw
+		}
+		_ = json.Unmarshal(existingRaw, &oldCfg)
+		newCfg.Token = unmaskSecret(newCfg.Token, oldCfg.Token)
+		return marshalOrFallback(newCfg, newRaw)
+
 	default:
 		return newRaw
 	}
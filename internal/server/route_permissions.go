@@ -0,0 +1,99 @@
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+)
+
+// Route access levels in the permission matrix.
+const (
+	routeAuthPublic  = "public"  // no login; the handler checks any token itself
+	routeAuthSession = "session" // a session cookie, API key, or API token
+)
+
+// routeAccess is one row of the permission matrix: what a caller needs to
+// reach a route. Handlers may narrow further -- viewers only ever see their
+// own records -- but never past what's listed here.
+type routeAccess struct {
+	Method          string        `json:"method"`
+	Pattern         string        `json:"pattern"`
+	Auth            string        `json:"auth"`
+	Roles           []models.Role `json:"roles"`
+	InteractiveOnly bool          `json:"interactive_only"`
+}
+
+// accessGate is implemented by the handlers the auth middlewares return, so
+// a route's requirements can be read off its middleware chain.
+type accessGate interface {
+	describeAccess(a *routeAccess)
+}
+
+type authGate struct{ http.Handler }
+
+func (authGate) describeAccess(a *routeAccess) {
+	a.Auth = routeAuthSession
+	if a.Roles == nil {
+		a.Roles = []models.Role{models.RoleViewer, models.RoleCoAdmin, models.RoleAdmin}
+	}
+}
+
+type roleGate struct {
+	roles []models.Role
+	http.Handler
+}
+
+// describeAccess intersects with any role gate further out, since a request
+// has to pass all of them.
+func (g roleGate) describeAccess(a *routeAccess) {
+	if a.Roles == nil {
+		a.Roles = slices.Clone(g.roles)
+		return
+	}
+	a.Roles = slices.DeleteFunc(a.Roles, func(r models.Role) bool { return !slices.Contains(g.roles, r) })
+}
+
+type interactiveGate struct{ http.Handler }
+
+func (interactiveGate) describeAccess(a *routeAccess) {
+	a.InteractiveOnly = true
+}
+
+// routePermissions lists every route on router with the access its
+// middleware enforces, ordered by pattern then method.
+func routePermissions(router chi.Routes) ([]routeAccess, error) {
+	probe := http.NotFoundHandler()
+	matrix := []routeAccess{}
+	err := chi.Walk(router, func(method, pattern string, _ http.Handler, mws ...func(http.Handler) http.Handler) error {
+		a := routeAccess{Method: method, Pattern: pattern, Auth: routeAuthPublic}
+		for _, mw := range mws {
+			if g, ok := mw(probe).(accessGate); ok {
+				g.describeAccess(&a)
+			}
+		}
+		if a.Roles == nil {
+			a.Roles = []models.Role{}
+		}
+		matrix = append(matrix, a)
+		return nil
+	})
+	slices.SortFunc(matrix, func(x, y routeAccess) int {
+		return cmp.Or(cmp.Compare(x.Pattern, y.Pattern), cmp.Compare(x.Method, y.Method))
+	})
+	return matrix, err
+}
+
+// handleGetRoutePermissions exports the permission matrix so a forward-auth
+// proxy can mirror streamMon's authorization decisions.
+func (s *Server) handleGetRoutePermissions(w http.ResponseWriter, r *http.Request) {
+	matrix, err := routePermissions(s.router)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal")
+		return
+	}
+	writeJSON(w, http.StatusOK, matrix)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+func TestRoutePermissions(t *testing.T) {
+	ts, _ := newTestServerWrapped(t)
+	w := httptest.NewRecorder()
+	ts.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/route-permissions", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var matrix []routeAccess
+	if err := json.NewDecoder(w.Body).Decode(&matrix); err != nil {
+		t.Fatal(err)
+	}
+	find := func(method, pattern string) routeAccess {
+		t.Helper()
+		i := slices.IndexFunc(matrix, func(a routeAccess) bool { return a.Method == method && a.Pattern == pattern })
+		if i < 0 {
+			t.Fatalf("%s %s missing from matrix", method, pattern)
+		}
+		return matrix[i]
+	}
+	all := []models.Role{models.RoleViewer, models.RoleCoAdmin, models.RoleAdmin}
+
+	for _, tc := range []struct {
+		method, pattern string
+		auth            string
+		roles           []models.Role
+		interactive     bool
+	}{
+		{"GET", "/api/health", routeAuthPublic, []models.Role{}, false},
+		{"GET", "/api/users/{name}", routeAuthSession, all, false},
+		{"GET", "/api/users/summary", routeAuthSession, []models.Role{models.RoleCoAdmin, models.RoleAdmin}, false},
+		{"PUT", "/api/rules/{id}", routeAuthSession, []models.Role{models.RoleCoAdmin, models.RoleAdmin}, false},
+		{"POST", "/api/rules/replay", routeAuthSession, []models.Role{models.RoleAdmin}, false},
+		{"POST", "/api/me/api-tokens", routeAuthSession, all, true},
+		{"POST", "/api/admin/api-key/rotate", routeAuthSession, []models.Role{models.RoleAdmin}, true},
+		{"GET", "/api/dashboard/sse", routeAuthSession, all, false},
+	} {
+		a := find(tc.method, tc.pattern)
+		if a.Auth != tc.auth || !slices.Equal(a.Roles, tc.roles) || a.InteractiveOnly != tc.interactive {
+			t.Errorf("%s %s = %+v, want auth=%s roles=%v interactive=%v", tc.method, tc.pattern, a, tc.auth, tc.roles, tc.interactive)
+		}
+	}
+}
+
+func TestRoutePermissions_AdminOnly(t *testing.T) {
+	ts, st := newTestServerWrapped(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/route-permissions", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: createViewerSession(t, st, "viewer")})
+	w := httptest.NewRecorder()
+	ts.Unwrap().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for viewer, got %d", w.Code)
+	}
+}
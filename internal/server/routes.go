@@ -20,10 +20,10 @@ func (s *Server) routes() {
 		r.Use(limitBody)
 		r.Use(corsMiddleware(s.corsOrigin))
 		r.Get("/status", s.authManager.HandleGetStatus)
-		r.With(RequireSetup(s.authManager), RateLimitAuth).Post("/local", s.handleSetupLocal)
-		r.With(RequireSetup(s.authManager), RateLimitAuth).Post("/plex", s.handleSetupPlex)
-		r.With(RequireSetup(s.authManager), RateLimitAuth).Post("/emby", s.handleSetupEmby)
-		r.With(RequireSetup(s.authManager), RateLimitAuth).Post("/jellyfin", s.handleSetupJellyfin)
+		r.With(RequireSetup(s.authManager), s.limits.limitAuth).Post("/local", s.handleSetupLocal)
+		r.With(RequireSetup(s.authManager), s.limits.limitAuth).Post("/plex", s.handleSetupPlex)
+		r.With(RequireSetup(s.authManager), s.limits.limitAuth).Post("/emby", s.handleSetupEmby)
+		r.With(RequireSetup(s.authManager), s.limits.limitAuth).Post("/jellyfin", s.handleSetupJellyfin)
 	})
 
 	// Auth endpoints
@@ -34,14 +34,15 @@ func (s *Server) routes() {
 		r.Post("/logout", s.authManager.HandleLogout)
 
 		// Login endpoints require setup to be complete (prevents creating users before admin exists)
-		r.With(RequireSetupComplete(s.authManager), RateLimitLogin).Post("/local/login", s.handleLocalLogin)
-		r.With(RequireSetupComplete(s.authManager), RateLimitAuth).Post("/plex/login", s.handlePlexLogin)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitLogin).Post("/local/login", s.handleLocalLogin)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitAuth).Post("/plex/login", s.handlePlexLogin)
 		r.With(RequireSetupComplete(s.authManager)).Get("/emby/servers", s.handleEmbyServers)
-		r.With(RequireSetupComplete(s.authManager), RateLimitLogin).Post("/emby/login", s.handleEmbyLogin)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitLogin).Post("/emby/login", s.handleEmbyLogin)
 		r.With(RequireSetupComplete(s.authManager)).Get("/jellyfin/servers", s.handleJellyfinServers)
-		r.With(RequireSetupComplete(s.authManager), RateLimitLogin).Post("/jellyfin/login", s.handleJellyfinLogin)
-		r.With(RequireSetupComplete(s.authManager), RateLimitAuth).Get("/oidc/login", s.handleOIDCLogin)
-		r.With(RequireSetupComplete(s.authManager), RateLimitAuth).Get("/oidc/callback", s.handleOIDCCallback)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitLogin).Post("/jellyfin/login", s.handleJellyfinLogin)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitAuth).Get("/oidc/login", s.handleOIDCLogin)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitAuth).Get("/oidc/callback", s.handleOIDCCallback)
+		r.With(RequireSetupComplete(s.authManager), s.limits.limitAuth).Post("/oidc/backchannel-logout", s.handleOIDCBackchannelLogout)
 	})
 
 	s.router.Route("/api", func(r chi.Router) {
@@ -49,11 +50,27 @@ func (s *Server) routes() {
 		r.Use(jsonContentType)
 		r.Use(corsMiddleware(s.corsOrigin))
 
-		r.Use(RequireAuthManager(s.authManager))
+		r.Use(s.requireAuth())
+		r.Use(maskNetworkForCoAdmin)
+		r.Use(s.scopeToWorkspace)
 
 		r.Get("/me", s.handleMe)
+		r.Get("/me/capabilities", s.handleMeCapabilities)
+		r.Get("/me/preferences", s.handleGetPreferences)
+		r.Put("/me/preferences", s.handleUpdatePreferences)
 		r.With(RequireInteractiveSession).Put("/me", s.handleUpdateProfile)
-		r.With(RequireInteractiveSession, RateLimitAuth).Post("/me/password", s.handleChangePassword)
+		r.With(RequireInteractiveSession, s.limits.limitAuth).Post("/me/password", s.handleChangePassword)
+		r.Get("/me/portal", s.handleGetPortal)
+		r.Get("/me/api-tokens", s.handleListAPITokens)
+		r.With(RequireInteractiveSession).Post("/me/api-tokens", s.handleCreateAPIToken)
+		r.Delete("/me/api-tokens/{id}", s.handleDeleteAPIToken)
+		r.Get("/me/sessions", s.handleListMySessions)
+		r.With(RequireInteractiveSession).Delete("/me/sessions", s.handleDeleteOtherSessions)
+		r.With(RequireInteractiveSession).Delete("/me/sessions/{id}", s.handleDeleteMySession)
+		r.Get("/me/notifications", s.handleGetMyNotifications)
+		r.With(RequireInteractiveSession).Put("/me/notifications", s.handleUpdateMyNotifications)
+		r.With(RequireInteractiveSession).Post("/me/push-subscriptions", s.handleAddPushSubscription)
+		r.Delete("/me/push-subscriptions/{id}", s.handleDeletePushSubscription)
 
 		r.Get("/servers", s.handleListServers)
 		r.With(RequireRole(models.RoleAdmin)).Post("/servers", s.handleCreateServer)
@@ -62,31 +79,71 @@ func (s *Server) routes() {
 		r.With(RequireRole(models.RoleAdmin)).Put("/servers/{id}", s.handleUpdateServer)
 		r.With(RequireRole(models.RoleAdmin)).Delete("/servers/{id}", s.handleDeleteServer)
 		r.With(RequireRole(models.RoleAdmin)).Post("/servers/{id}/restore", s.handleRestoreServer)
+		r.With(RequireRole(models.RoleAdmin)).Put("/servers/{id}/polling", s.handleSetServerPolling)
+		r.With(RequireRole(models.RoleAdmin)).Put("/servers/{id}/library-filter", s.handleSetServerLibraryFilter)
 		r.With(RequireRole(models.RoleAdmin)).Post("/servers/{id}/test", s.handleTestServer)
+		r.With(RequireRole(models.RoleAdmin)).Get("/servers/{id}/webhook", s.handleGetServerWebhook)
+		r.With(RequireRole(models.RoleAdmin), RequireInteractiveSession).Post("/servers/{id}/webhook/rotate", s.handleRotateServerWebhook)
+		r.With(RequireRole(models.RoleAdmin)).Delete("/servers/{id}/webhook", s.handleDeleteServerWebhook)
+		r.With(RequireRole(models.RoleAdmin), RequireInteractiveSession).Post("/servers/{id}/webhook/secret/rotate", s.handleRotateServerWebhookSecret)
+		r.With(RequireRole(models.RoleAdmin)).Delete("/servers/{id}/webhook/secret", s.handleDeleteServerWebhookSecret)
+		r.With(RequireRole(models.RoleAdmin)).Post("/servers/{id}/history-migration/preview", s.handlePreviewServerHistoryMigration)
+		r.With(RequireRole(models.RoleAdmin)).Post("/servers/{id}/history-migration", s.handleMigrateServerHistory)
 
 		r.Get("/history", s.handleListHistory)
 		r.Get("/history/daily", s.handleDailyHistory)
+		r.With(RequireRole(models.RoleAdmin)).Get("/history/duplicates", s.handleHistoryDuplicates)
 		r.Get("/history/{id}/sessions", s.handleListSessions)
+		r.Route("/history/repairs", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListHistoryTimeRepairs)
+			sr.Post("/", s.handleApplyHistoryTimeShift)
+			sr.Post("/preview", s.handlePreviewHistoryTimeShift)
+			sr.Post("/{id}/undo", s.handleUndoHistoryTimeRepair)
+		})
 
 		r.Get("/users", s.handleListUsers)
-		r.With(RequireRole(models.RoleAdmin)).Get("/users/summary", s.handleListUserSummaries)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/users/summary", s.handleListUserSummaries)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/users/archived", s.handleListArchivedUsers)
 		r.With(RequireRole(models.RoleAdmin)).Post("/users/sync-avatars", s.handleSyncUserAvatars)
-		r.Get("/users/{name}", s.handleGetUser)
-		r.Get("/users/{name}/locations", s.handleGetUserLocations)
-		r.Get("/users/{name}/stats", s.handleGetUserStats)
-		r.With(RequireRole(models.RoleAdmin)).Get("/users/{name}/notes", s.handleGetUserNotes)
-		r.With(RequireRole(models.RoleAdmin)).Put("/users/{name}/notes", s.handleUpdateUserNotes)
-
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/users/duplicates", s.handleListDuplicateUserNames)
+		r.Route("/user-identities", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListUserIdentityLinks)
+			sr.Put("/", s.handleLinkUserIdentity)
+			sr.Delete("/{serverID}/{name}", s.handleUnlinkUserIdentity)
+		})
+		// Workspace members only see the users of their workspace.
+		wu := r.With(s.requireWorkspaceUser)
+		wu.Get("/users/{name}", s.handleGetUser)
+		wu.Get("/users/{name}/locations", s.handleGetUserLocations)
+		wu.Get("/users/{name}/stats", s.handleGetUserStats)
+		wu.Get("/users/{name}/continue-watching", s.handleGetContinueWatching)
+		wu.With(RequireRole(models.RoleAdmin)).Get("/users/{name}/notes", s.handleGetUserNotes)
+		wu.With(RequireRole(models.RoleAdmin)).Put("/users/{name}/notes", s.handleUpdateUserNotes)
+
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassOverview)).Get("/dashboard", s.handleDashboardOverview)
 		r.Get("/dashboard/sessions", s.handleDashboardSessions)
-		r.With(RequireRole(models.RoleAdmin)).Get("/dashboard/summary", s.handleDashboardSummary)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/dashboard/summary", s.handleDashboardSummary)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/dashboard/map", s.handleStreamMap)
 		r.Get("/dashboard/recent-media", s.handleGetRecentMedia)
 		r.With(RequireRole(models.RoleAdmin)).Post("/sessions/terminate", s.handleTerminateSession)
+		r.Get("/sessions/{key}", s.handleGetSessionDetail)
 
-		r.With(RequireRole(models.RoleAdmin)).Get("/library/summary", s.handleLibrarySummary)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/library/summary", s.handleLibrarySummary)
+		r.With(RequireRole(models.RoleAdmin)).Get("/media/{external_id}", s.handleGetMediaTitle)
 
 		r.Get("/geoip/{ip}", s.handleGeoIPLookup)
 
-		r.Get("/stats", s.handleGetStats)
+		r.With(s.statsShed.limit(statsClassOverview)).Get("/stats", s.handleGetStats)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/concurrent-records", s.handleGetConcurrentRecords)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/bandwidth", s.handleGetBandwidthStats)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/shared-ips", s.handleGetSharedIPReport)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/libraries", s.handleGetLibraryStats)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/stale-in-progress", s.handleGetStaleInProgress)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/heatmap", s.handleGetWatchHeatmap)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin), s.statsShed.limit(statsClassReports)).Get("/stats/rewatched", s.handleGetMostRewatched)
+		wu.With(s.statsShed.limit(statsClassReports)).Get("/stats/users/{name}/streaks", s.handleGetUserStreaks)
 		r.With(RequireRole(models.RoleAdmin)).Get("/libraries", s.handleGetLibraries)
 		r.With(RequireRole(models.RoleAdmin)).Get("/libraries/{serverID}/{libraryID}/items", s.handleListLibraryItems)
 		r.With(RequireRole(models.RoleAdmin)).Get("/libraries/{serverID}/{libraryID}/summary", s.handleLibraryItemSummary)
@@ -105,6 +162,9 @@ func (s *Server) routes() {
 			sr.Put("/", s.handleUpdateMaxMindSettings)
 			sr.Delete("/", s.handleDeleteMaxMindSettings)
 			sr.Post("/backfill", s.handleGeoBackfill)
+			sr.Post("/backfill/start", s.handleStartGeoBackfillJob)
+			sr.Post("/backfill/stop", s.handleStopGeoBackfillJob)
+			sr.Get("/backfill/status", s.handleGeoBackfillJobStatus)
 		})
 
 		r.Route("/settings/tautulli", func(sr chi.Router) {
@@ -114,7 +174,7 @@ func (s *Server) routes() {
 			sr.Put("/", s.handleUpdateIntegrationSettings(td))
 			sr.Delete("/", s.handleDeleteIntegrationSettings(td))
 			sr.Post("/test", s.handleTestIntegrationConnection(td))
-			sr.Post("/import", s.handleHistoryImport(s.store.GetTautulliConfig, tautulliStreamer, "Tautulli"))
+			sr.Post("/import", s.handleHistoryImport(s.store.GetTautulliConfig, tautulliStreamer, models.ImportSourceTautulli))
 			sr.Post("/enrich", s.handleStartEnrichment)
 			sr.Post("/enrich/stop", s.handleStopEnrichment)
 			sr.Get("/enrich/status", s.handleEnrichmentStatus)
@@ -127,7 +187,15 @@ func (s *Server) routes() {
 			sr.Put("/", s.handleUpdateIntegrationSettings(jd))
 			sr.Delete("/", s.handleDeleteIntegrationSettings(jd))
 			sr.Post("/test", s.handleTestIntegrationConnection(jd))
-			sr.Post("/import", s.handleHistoryImport(s.store.GetJellystatConfig, jellystatStreamer, "Jellystat"))
+			sr.Post("/import", s.handleHistoryImport(s.store.GetJellystatConfig, jellystatStreamer, models.ImportSourceJellystat))
+		})
+
+		r.Route("/import-jobs", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListImportJobs)
+			sr.Get("/{id}", s.handleGetImportJob)
+			sr.Get("/{id}/events", s.handleImportJobEvents)
+			sr.Post("/{id}/cancel", s.handleCancelImportJob)
 		})
 
 		r.Route("/settings/overseerr", func(sr chi.Router) {
@@ -146,6 +214,8 @@ func (s *Server) routes() {
 			sr.Put("/", s.handleUpdateIntegrationSettings(sd))
 			sr.Delete("/", s.handleDeleteIntegrationSettings(sd))
 			sr.Post("/test", s.handleTestIntegrationConnection(sd))
+			sr.Get("/delete-policy", s.handleGetArrDeletePolicy(s.store.GetSonarrDeletePolicy))
+			sr.Put("/delete-policy", s.handleUpdateArrDeletePolicy(s.store.SetSonarrDeletePolicy))
 		})
 
 		r.Route("/settings/radarr", func(sr chi.Router) {
@@ -155,6 +225,8 @@ func (s *Server) routes() {
 			sr.Put("/", s.handleUpdateIntegrationSettings(rd))
 			sr.Delete("/", s.handleDeleteIntegrationSettings(rd))
 			sr.Post("/test", s.handleTestIntegrationConnection(rd))
+			sr.Get("/delete-policy", s.handleGetArrDeletePolicy(s.store.GetRadarrDeletePolicy))
+			sr.Put("/delete-policy", s.handleUpdateArrDeletePolicy(s.store.SetRadarrDeletePolicy))
 		})
 
 		r.Route("/sonarr", func(sr chi.Router) {
@@ -216,23 +288,72 @@ func (s *Server) routes() {
 			sr.Put("/", s.handleUpdateIdleTimeout)
 		})
 
+		r.With(RequireRole(models.RoleAdmin)).Put("/settings/concurrent-records", s.handleUpdateConcurrentRecordSettings)
+
+		r.Route("/settings/zombie-sessions", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleGetZombieSettings)
+			sr.Put("/", s.handleUpdateZombieSettings)
+			sr.Post("/run", s.handleRunZombieCleanup)
+		})
+
+		r.Route("/settings/proxy-auth", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleGetProxyAuthSettings)
+			sr.With(RequireInteractiveSession).Put("/", s.handleUpdateProxyAuthSettings)
+		})
+
+		r.Route("/settings/rate-limits", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleGetRateLimitSettings)
+			sr.Put("/", s.handleUpdateRateLimitSettings)
+		})
+
+		r.With(RequireRole(models.RoleAdmin)).Get("/rate-limits/status", s.handleGetRateLimitStatus)
+
+		r.Route("/settings/history-rules", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleGetHistoryRules)
+			sr.Put("/", s.handleUpdateHistoryRules)
+		})
+
+		r.Route("/settings/home-networks", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleGetHomeNetworks)
+			sr.Put("/", s.handleUpdateHomeNetworks)
+		})
+
+		r.Route("/settings/user-notifications", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleGetUserNotificationSettings)
+			sr.Put("/", s.handleUpdateUserNotificationSettings)
+		})
+
 		r.Route("/settings/guest", func(sr chi.Router) {
 			sr.Get("/", s.handleGetGuestSettings)
 			sr.With(RequireRole(models.RoleAdmin)).Put("/", s.handleUpdateGuestSettings)
 		})
 
+		// Workspace admins manage their own workspace's rules and channels.
 		r.Route("/rules", func(sr chi.Router) {
-			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Use(RequireRole(models.RoleAdmin, models.RoleCoAdmin))
+			sr.Use(requireRuleManager)
 			sr.Get("/", s.handleListRules)
 			sr.Post("/", s.handleCreateRule)
-			sr.Get("/{id}", s.handleGetRule)
-			sr.Put("/{id}", s.handleUpdateRule)
-			sr.Delete("/{id}", s.handleDeleteRule)
-			sr.Post("/{id}/channels", s.handleLinkRuleToChannel)
-			sr.Delete("/{id}/channels/{channelId}", s.handleUnlinkRuleFromChannel)
-			sr.Get("/{id}/channels", s.handleGetRuleChannels)
-			sr.Get("/{id}/exemptions", s.handleListRuleExemptions)
-			sr.Put("/{id}/exemptions", s.handleSetRuleExemptions)
+			sr.With(RequireRole(models.RoleAdmin)).Post("/replay", s.handleReplayRules)
+			wr := sr.With(s.requireWorkspaceRule)
+			wr.Get("/{id}", s.handleGetRule)
+			wr.Put("/{id}", s.handleUpdateRule)
+			wr.Delete("/{id}", s.handleDeleteRule)
+			wr.Post("/{id}/enable", s.handleEnableRule)
+			wr.Post("/{id}/disable", s.handleDisableRule)
+			wr.Post("/{id}/channels", s.handleLinkRuleToChannel)
+			wr.Delete("/{id}/channels/{channelId}", s.handleUnlinkRuleFromChannel)
+			wr.Get("/{id}/channels", s.handleGetRuleChannels)
+			wr.Get("/{id}/exemptions", s.handleListRuleExemptions)
+			wr.Put("/{id}/exemptions", s.handleSetRuleExemptions)
+			wr.Get("/{id}/user-limits", s.handleListRuleUserLimits)
+			wr.Put("/{id}/user-limits", s.handleSetRuleUserLimits)
 		})
 
 		r.Route("/violations", func(sr chi.Router) {
@@ -240,29 +361,118 @@ func (s *Server) routes() {
 			sr.Get("/", s.handleListViolations)
 		})
 
-		r.Route("/notifications", func(sr chi.Router) {
+		r.Route("/share-links", func(sr chi.Router) {
 			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListShareLinks)
+			sr.With(RequireInteractiveSession).Post("/", s.handleCreateShareLink)
+			sr.Delete("/{id}", s.handleDeleteShareLink)
+		})
+
+		r.Route("/guest-tokens", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListGuestTokens)
+			sr.With(RequireInteractiveSession).Post("/", s.handleCreateGuestToken)
+			sr.Delete("/{id}", s.handleDeleteGuestToken)
+		})
+
+		r.Route("/network-labels", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListNetworkLabels)
+			sr.Post("/", s.handleCreateNetworkLabel)
+			sr.Put("/{id}", s.handleUpdateNetworkLabel)
+			sr.Delete("/{id}", s.handleDeleteNetworkLabel)
+		})
+
+		r.Route("/geo-overrides", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListGeoOverrides)
+			sr.Post("/", s.handleCreateGeoOverride)
+			sr.Put("/{id}", s.handleUpdateGeoOverride)
+			sr.Delete("/{id}", s.handleDeleteGeoOverride)
+		})
+
+		r.Route("/do-not-track", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListDoNotTrackRules)
+			sr.Post("/", s.handleCreateDoNotTrackRule)
+			sr.Put("/{id}", s.handleUpdateDoNotTrackRule)
+			sr.Delete("/{id}", s.handleDeleteDoNotTrackRule)
+		})
+
+		r.Route("/workspaces", func(sr chi.Router) {
+			sr.Get("/", s.handleListWorkspaces)
+			sr.With(RequireRole(models.RoleAdmin)).Post("/", s.handleCreateWorkspace)
+			sr.With(RequireRole(models.RoleAdmin)).Put("/{id}", s.handleRenameWorkspace)
+			sr.With(RequireRole(models.RoleAdmin)).Delete("/{id}", s.handleDeleteWorkspace)
+			sr.With(RequireRole(models.RoleAdmin)).Put("/{id}/servers", s.handleSetWorkspaceServers)
+			sr.Put("/{id}/members/{name}", s.handleSetWorkspaceMember)
+			// Removing a member releases them from the workspace, so only
+			// admins may do it.
+			sr.With(RequireRole(models.RoleAdmin)).Delete("/{id}/members/{name}", s.handleRemoveWorkspaceMember)
+		})
+
+		r.Route("/outbound-webhooks", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListOutboundWebhooks)
+			sr.Post("/", s.handleCreateOutboundWebhook)
+			sr.Get("/{id}", s.handleGetOutboundWebhook)
+			sr.Put("/{id}", s.handleUpdateOutboundWebhook)
+			sr.Delete("/{id}", s.handleDeleteOutboundWebhook)
+			sr.Post("/{id}/secret/rotate", s.handleRotateOutboundWebhookSecret)
+			sr.Post("/{id}/test", s.handleTestOutboundWebhook)
+		})
+
+		r.Route("/digests", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Get("/", s.handleListDigests)
+			sr.Post("/", s.handleCreateDigest)
+			sr.Get("/{id}", s.handleGetDigest)
+			sr.Put("/{id}", s.handleUpdateDigest)
+			sr.Delete("/{id}", s.handleDeleteDigest)
+			sr.Get("/{id}/preview", s.handlePreviewDigest)
+			sr.Post("/{id}/send", s.handleSendDigest)
+		})
+
+		r.Route("/notifications", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin, models.RoleCoAdmin))
+			sr.Use(requireRuleManager)
 			sr.Get("/", s.handleListNotificationChannels)
 			sr.Post("/", s.handleCreateNotificationChannel)
-			sr.Get("/{id}", s.handleGetNotificationChannel)
-			sr.Put("/{id}", s.handleUpdateNotificationChannel)
-			sr.Delete("/{id}", s.handleDeleteNotificationChannel)
-			sr.Post("/{id}/test", s.handleTestNotificationChannel)
+			sr.Group(func(ar chi.Router) {
+				ar.Use(RequireRole(models.RoleAdmin))
+				ar.Post("/test", s.handleTestNotificationConfig)
+				ar.Get("/agents", s.handleListNotificationAgents)
+				ar.Post("/agents", s.handleCreateNotificationAgent)
+				ar.Get("/agents/{id}", s.handleGetNotificationAgent)
+				ar.Put("/agents/{id}", s.handleUpdateNotificationAgent)
+				ar.Delete("/agents/{id}", s.handleDeleteNotificationAgent)
+				ar.Get("/history", s.handleListNotificationHistory)
+				ar.Get("/history/{id}", s.handleGetNotificationRecord)
+				ar.Post("/history/{id}/retry", s.handleRetryNotification)
+			})
+			wc := sr.With(s.requireWorkspaceChannel)
+			wc.Get("/{id}", s.handleGetNotificationChannel)
+			wc.Put("/{id}", s.handleUpdateNotificationChannel)
+			wc.Delete("/{id}", s.handleDeleteNotificationChannel)
+			wc.Post("/{id}/test", s.handleTestNotificationChannel)
 		})
 
 		// Maintenance routes (admin only)
 		r.Route("/maintenance", func(mr chi.Router) {
 			mr.Use(RequireRole(models.RoleAdmin))
-			mr.Use(rateLimit)
+			mr.Use(s.limits.limitSearch)
 			mr.Get("/criterion-types", s.handleGetCriterionTypes)
+			mr.Get("/templates", s.handleGetRuleTemplates)
 			mr.Get("/dashboard", s.handleGetMaintenanceDashboard)
 			mr.Post("/sync", s.handleSyncLibraryItems)
 			mr.Get("/sync/status", s.handleSyncStatus)
+			mr.Put("/libraries/{serverID}/{libraryID}/sync-schedule", s.handleSetLibrarySyncSchedule)
 			mr.Get("/rules", s.handleListMaintenanceRules)
 			mr.Post("/rules", s.handleCreateMaintenanceRule)
 			mr.Get("/rules/{id}", s.handleGetMaintenanceRule)
 			mr.Put("/rules/{id}", s.handleUpdateMaintenanceRule)
 			mr.Delete("/rules/{id}", s.handleDeleteMaintenanceRule)
+			mr.Post("/rules/{id}/clone", s.handleCloneMaintenanceRule)
 			mr.Post("/rules/{id}/evaluate", s.handleEvaluateRule)
 			mr.Get("/rules/{id}/candidates", s.handleListCandidates)
 			mr.Get("/rules/{id}/candidates/export", s.handleExportCandidates)
@@ -275,18 +485,33 @@ func (s *Server) routes() {
 			mr.Delete("/library-items/{id}", s.handleDeleteLibraryItem)
 			mr.Get("/candidates/{id}/cross-server", s.handleCrossServerItems)
 			mr.Post("/candidates/bulk-delete", s.handleBulkDeleteCandidates)
+			mr.Post("/candidates/bulk-exclude", s.handleBulkExcludeCandidates)
+			mr.Post("/candidates/bulk-snooze", s.handleBulkSnoozeCandidates)
 		})
 
-		r.Get("/users/{name}/trust", s.handleGetUserTrustScore)
-		r.Get("/users/{name}/violations", s.handleGetUserViolations)
-		r.Route("/users/{name}/household", func(sr chi.Router) {
+		wu.Get("/users/{name}/trust", s.handleGetUserTrustScore)
+		wu.Get("/users/{name}/violations", s.handleGetUserViolations)
+		wu.Get("/users/{name}/watch-time/daily", s.handleUserDailyWatchTime)
+		wu.Get("/users/{name}/wrapped/opt-out", s.handleGetWrappedOptOut)
+		wu.Put("/users/{name}/wrapped/opt-out", s.handleSetWrappedOptOut)
+		wu.Get("/users/{name}/wrapped/{year}", s.handleGetWrapped)
+		wu.Get("/users/{name}/wrapped/{year}/card.svg", s.handleGetWrappedCard)
+		wu.Get("/users/{name}/watch-goal", s.handleGetWatchGoal)
+		wu.With(RequireRole(models.RoleAdmin)).Put("/users/{name}/watch-goal", s.handleSetWatchGoal)
+		wu.With(RequireRole(models.RoleAdmin)).Delete("/users/{name}/watch-goal", s.handleDeleteWatchGoal)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/watch-goals", s.handleListWatchGoals)
+		wu.Route("/users/{name}/household", func(sr chi.Router) {
 			sr.Get("/", s.handleListHouseholdLocations)
 			sr.With(RequireRole(models.RoleAdmin)).Post("/", s.handleCreateHouseholdLocation)
 			sr.With(RequireRole(models.RoleAdmin)).Put("/{id}", s.handleUpdateHouseholdTrusted)
 			sr.With(RequireRole(models.RoleAdmin)).Delete("/{id}", s.handleDeleteHouseholdLocation)
+			sr.Get("/home", s.handleGetHouseholdHome)
+			sr.With(RequireRole(models.RoleAdmin)).Put("/home", s.handleSetHouseholdHome)
+			sr.With(RequireRole(models.RoleAdmin)).Delete("/home", s.handleDeleteHouseholdHome)
 		})
 
 		r.With(RequireRole(models.RoleAdmin)).Post("/household/calculate", s.handleCalculateHouseholdLocations)
+		r.With(RequireRole(models.RoleAdmin, models.RoleCoAdmin)).Get("/household/map", s.handleHouseholdMap)
 
 		// Admin user management
 		r.Route("/admin/users", func(sr chi.Router) {
@@ -297,6 +522,18 @@ func (s *Server) routes() {
 			sr.Put("/{id}/role", s.handleAdminUpdateUserRole)
 			sr.Post("/{id}/unlink", s.handleAdminUnlinkUser)
 			sr.Delete("/{id}", s.handleAdminDeleteUser)
+			sr.Get("/{id}/sessions", s.handleAdminListUserSessions)
+			sr.With(RequireInteractiveSession).Delete("/{id}/sessions", s.handleAdminDeleteUserSessions)
+			sr.With(RequireInteractiveSession).Delete("/{id}/sessions/{sessionID}", s.handleAdminDeleteUserSession)
+		})
+
+		r.With(RequireRole(models.RoleAdmin)).Get("/admin/route-permissions", s.handleGetRoutePermissions)
+
+		r.Route("/admin/demo-data", func(sr chi.Router) {
+			sr.Use(RequireRole(models.RoleAdmin))
+			sr.Use(RequireInteractiveSession)
+			sr.Post("/", s.handleGenerateDemoData)
+			sr.Delete("/", s.handleClearDemoData)
 		})
 
 		// Programmatic API key (synthetic-admin, single key, header-only).
@@ -306,20 +543,36 @@ func (s *Server) routes() {
 			sr.Use(RequireRole(models.RoleAdmin))
 			sr.Use(RequireInteractiveSession)
 			sr.Get("/", s.handleGetAPIKeyStatus)
-			sr.With(RateLimitAuth).Post("/rotate", s.handleRotateAPIKey)
+			sr.With(s.limits.limitAuth).Post("/rotate", s.handleRotateAPIKey)
 			sr.Delete("/", s.handleRevokeAPIKey)
 		})
 	})
 
+	// Media server webhooks authenticate with the token in the URL.
+	s.router.With(limitBody).Post(mediaWebhookPathPrefix+"{token}", s.handleMediaWebhook)
+
+	// Share links authenticate with the token in the URL. Failed lookups
+	// count toward the auth limiter to slow token guessing.
+	s.router.With(s.limits.limitAuth).Get(sharedPathPrefix+"{token}", s.handleGetSharedRecord)
+
+	// Prometheus scrapers can't log in, so /metrics is opt-in and guarded
+	// by its own optional bearer token.
+	if s.metricsEnabled {
+		s.router.Get("/metrics", s.handleMetrics)
+	}
+
 	s.router.Group(func(r chi.Router) {
 		r.Use(corsMiddleware(s.corsOrigin))
-		r.Use(RequireAuthManager(s.authManager))
+		r.Use(s.requireAuth())
+		r.Use(s.attachWorkspace)
 		r.Get("/api/servers/{id}/thumb/*", s.handleThumbProxy)
 		r.Get("/api/servers/{id}/items/*", s.handleGetItemDetails)
 		r.Get("/api/servers/{id}/children/*", s.handleGetChildren)
 		r.Get("/api/sonarr/poster/{seriesId}", s.handleSonarrPoster)
 		r.Get("/api/dashboard/sse", s.handleDashboardSSE)
 		r.With(RequireRole(models.RoleAdmin)).Post("/api/settings/playback-reporting/import", s.handlePlaybackReportingImport())
+		r.With(RequireRole(models.RoleAdmin)).Post("/api/settings/tautulli/import-db", s.handleTautulliDatabaseImport())
+		r.With(RequireRole(models.RoleAdmin), RequireInteractiveSession).Post("/api/admin/restore", s.handleAdminRestore())
 	})
 
 	s.serveSPA()
@@ -2,22 +2,29 @@ package server
 
 import (
 	"context"
+	"log"
 	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
 	"streammon/internal/auth"
+	"streammon/internal/digest"
+	"streammon/internal/diskcache"
 	"streammon/internal/geoip"
 	"streammon/internal/httputil"
 	"streammon/internal/maintenance"
 	"streammon/internal/media"
 	"streammon/internal/models"
+	"streammon/internal/notifier"
 	"streammon/internal/poller"
 	"streammon/internal/store"
 	"streammon/internal/tmdb"
 	"streammon/internal/version"
+	"streammon/internal/webhooks"
 )
 
 // pollerIface covers all poller methods used by the server package.
@@ -31,6 +38,10 @@ type pollerIface interface {
 	RemoveServer(id int64)
 	GetServer(id int64) (media.MediaServer, bool)
 	RefreshIdleTimeout()
+	ApplyWebhookUpdate(ctx context.Context, serverID int64, u models.SessionUpdate, userName string)
+	ClearWebhook(serverID int64)
+	SetServerPolling(serverID int64, interval time.Duration, paused bool)
+	PollHealth(serverID int64) (poller.PollHealth, bool)
 }
 
 // Ensure *poller.Poller satisfies pollerIface at compile time.
@@ -42,6 +53,7 @@ type GeoLookup interface {
 
 type RulesEngine interface {
 	InvalidateCache()
+	Replay(ctx context.Context, rules []models.Rule, history []models.WatchHistoryEntry, from, to time.Time) []models.RuleReplaySession
 }
 
 type Server struct {
@@ -56,9 +68,12 @@ type Server struct {
 	rulesEngine      RulesEngine
 	version          *version.Checker
 	enrichment       *enrichmentState
+	geoBackfill      *geoBackfillState
 	autoSync         autoSyncState
 	sseConns         sseConnLimiter
 	librarySync      *librarySyncManager
+	importJobs       *importJobManager
+	importDir        string
 	appCtx           context.Context
 	cascadeDeleter   *maintenance.CascadeDeleter
 	overseerrUsers   *overseerrUserCache
@@ -66,6 +81,16 @@ type Server struct {
 	tmdbClient       *tmdb.Client
 	thumbProxyHTTP   *http.Client
 	sonarrPosterHTTP *http.Client
+	posterCache      *diskcache.Cache
+	metricsEnabled   bool
+	metricsToken     string
+	webhookNonces    *nonceCache
+	outboundWebhooks *webhooks.Dispatcher
+	digests          *digest.Sender
+	statsShed        *statsShedder
+	limits           *rateLimiters
+	// proxyAuth is nil while trusted header authentication is disabled.
+	proxyAuth atomic.Pointer[proxyAuthConfig]
 }
 
 func NewServer(s *store.Store, opts ...Option) *Server {
@@ -74,20 +99,41 @@ func NewServer(s *store.Store, opts ...Option) *Server {
 		store:            s,
 		libCache:         &libraryCache{},
 		enrichment:       &enrichmentState{},
+		geoBackfill:      &geoBackfillState{},
 		librarySync:      &librarySyncManager{active: make(map[string]*librarySyncJob)},
+		importJobs:       newImportJobManager(),
 		appCtx:           context.Background(),
 		cascadeDeleter:   maintenance.NewCascadeDeleter(s),
 		overseerrUsers:   &overseerrUserCache{},
 		overseerrMedia:   &overseerrMediaCache{},
 		thumbProxyHTTP:   httputil.NewClient(),
 		sonarrPosterHTTP: httputil.NewClient(),
+		webhookNonces:    newNonceCache(),
+		statsShed:        newStatsShedder(),
+		limits:           newRateLimiters(),
 	}
 	for _, o := range opts {
 		o(srv)
 	}
+	if srv.outboundWebhooks == nil {
+		srv.outboundWebhooks = webhooks.New(s)
+	}
+	if srv.digests == nil {
+		srv.digests = digest.New(s, notifier.New(notifier.WithHistory(s)))
+	}
 	if srv.authManager == nil {
 		panic("server: authManager is required — use WithAuthManager")
 	}
+	if limits, err := s.GetRateLimitSettings(); err != nil {
+		log.Printf("loading rate limit settings: %v", err)
+	} else {
+		srv.limits.apply(limits)
+	}
+	if proxy, err := s.GetProxyAuthSettings(); err != nil {
+		log.Printf("loading proxy auth settings: %v", err)
+	} else if err := srv.applyProxyAuthSettings(proxy); err != nil {
+		log.Printf("applying proxy auth settings: %v", err)
+	}
 	srv.router.Use(CaptureRawRemoteAddr)
 	srv.router.Use(middleware.RealIP)
 	srv.router.Use(middleware.Recoverer)
@@ -130,6 +176,29 @@ func WithAppContext(ctx context.Context) Option {
 	return func(s *Server) { s.appCtx = ctx }
 }
 
+// WithImportDir sets where uploaded import files are kept until their job
+// finishes. It should survive restarts so interrupted jobs can resume.
+func WithImportDir(dir string) Option {
+	return func(s *Server) { s.importDir = dir }
+}
+
+// WithPosterCache keeps proxied posters on disk, so repeat views don't go
+// back to the media server.
+func WithPosterCache(c *diskcache.Cache) Option {
+	return func(s *Server) { s.posterCache = c }
+}
+
+// WithWebhooks sets the dispatcher outbound webhooks are sent through, so
+// the server shares it with the poller and rules engine.
+func WithWebhooks(d *webhooks.Dispatcher) Option {
+	return func(s *Server) { s.outboundWebhooks = d }
+}
+
+// WithDigests sets the sender digests are sent through on demand.
+func WithDigests(d *digest.Sender) Option {
+	return func(s *Server) { s.digests = d }
+}
+
 func WithTMDBClient(c *tmdb.Client) Option {
 	return func(s *Server) { s.tmdbClient = c }
 }
@@ -138,9 +207,11 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
 
-// WaitEnrichment blocks until any running background enrichment finishes.
+// WaitEnrichment blocks until any running background enrichment or geo
+// backfill finishes.
 func (s *Server) WaitEnrichment() {
 	s.enrichment.Wait()
+	s.geoBackfill.Wait()
 }
 
 // WaitAutoSync blocks until any running server auto-syncs (on add/update) finish.
@@ -148,7 +219,18 @@ func (s *Server) WaitAutoSync() {
 	s.autoSync.Wait()
 }
 
+// WaitImportJobs blocks until any running history imports stop.
+func (s *Server) WaitImportJobs() {
+	s.importJobs.Wait()
+}
+
 // WaitLibrarySync blocks until any running on-demand library syncs finish.
 func (s *Server) WaitLibrarySync() {
 	s.librarySync.Wait()
 }
+
+// StopRateLimiters stops the rate limiters' cleanup goroutines.
+// Call this during server shutdown.
+func (s *Server) StopRateLimiters() {
+	s.limits.stop()
+}
@@ -52,11 +52,16 @@ func (s *Server) handleDashboardSSE(w http.ResponseWriter, r *http.Request) {
 	defer s.poller.Unsubscribe(ch)
 
 	// Send initial snapshot (filtered for viewers)
-	sessions := s.poller.CurrentSessions()
+	coAdmin := isCoAdmin(r)
+
+	sessions := workspaceSessions(r, s.poller.CurrentSessions())
 	if isViewer {
 		sessions = filterSessionsForUser(sessions, viewerName)
 	}
 	if data, err := json.Marshal(sessions); err == nil {
+		if coAdmin {
+			data = maskStreamsJSONForCoAdmin(data)
+		}
 		fmt.Fprintf(w, "data: %s\n\n", data)
 		flusher.Flush()
 	}
@@ -69,6 +74,7 @@ func (s *Server) handleDashboardSSE(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
+			snapshot = workspaceSessions(r, snapshot)
 			// Filter for viewers
 			if isViewer {
 				snapshot = filterSessionsForUser(snapshot, viewerName)
@@ -77,6 +83,9 @@ func (s *Server) handleDashboardSSE(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				continue
 			}
+			if coAdmin {
+				data = maskStreamsJSONForCoAdmin(data)
+			}
 			fmt.Fprintf(w, "data: %s\n\n", data)
 			flusher.Flush()
 		}
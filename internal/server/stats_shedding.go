@@ -0,0 +1,172 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"streammon/internal/metrics"
+)
+
+// Stats endpoints each run a handful of heavy aggregate queries. While an
+// import or library sync is holding the database, letting them pile up only
+// deepens the SQLite lock contention, so each endpoint class admits a few
+// requests at a time and answers the rest with its last good response.
+type statsClass string
+
+const (
+	// statsClassOverview is the stats page and dashboard overview.
+	statsClassOverview statsClass = "overview"
+	// statsClassReports is the narrower /stats/* reports.
+	statsClassReports statsClass = "reports"
+)
+
+var statsClassLimits = map[statsClass]int{
+	statsClassOverview: 4,
+	statsClassReports:  2,
+}
+
+const (
+	// statsShedWait is how long a request waits for a free slot before it's
+	// shed.
+	statsShedWait = 500 * time.Millisecond
+	// statsStaleMaxAge bounds how old a cached response can be and still be
+	// served in place of a fresh one.
+	statsStaleMaxAge = time.Hour
+	// statsCacheMaxEntries caps the cached responses across all classes.
+	statsCacheMaxEntries = 256
+	// statsShedRetryAfter is the Retry-After, in seconds, when there's no
+	// cached response to fall back on.
+	statsShedRetryAfter = 5
+)
+
+type statsCacheEntry struct {
+	body        []byte
+	contentType string
+	at          time.Time
+}
+
+// statsShedder holds the per-class concurrency slots and the last successful
+// response for each caller and URL.
+type statsShedder struct {
+	slots map[statsClass]chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]statsCacheEntry
+}
+
+func newStatsShedder() *statsShedder {
+	sh := &statsShedder{
+		slots: make(map[statsClass]chan struct{}, len(statsClassLimits)),
+		cache: make(map[string]statsCacheEntry),
+	}
+	for class, limit := range statsClassLimits {
+		sh.slots[class] = make(chan struct{}, limit)
+	}
+	return sh
+}
+
+// statsCacheKey scopes cached responses to the caller, since preference
+// defaults and role checks make the same URL answer differently per user.
+func statsCacheKey(r *http.Request) string {
+	principal := "anon"
+	if user := UserFromContext(r.Context()); user != nil {
+		principal = strconv.FormatInt(user.ID, 10)
+	}
+	return principal + " " + r.URL.RequestURI()
+}
+
+// limit caps concurrent requests for class. A request that can't get a slot
+// within statsShedWait is served the cached response, marked with X-Stats-Stale
+// and its Age in seconds, or a 503 if there is none.
+func (sh *statsShedder) limit(class statsClass) func(http.Handler) http.Handler {
+	slots := sh.slots[class]
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := statsCacheKey(r)
+			if !sh.acquire(r, slots) {
+				sh.shed(w, r, class, key)
+				return
+			}
+			defer func() { <-slots }()
+
+			buf := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(buf, r)
+			if buf.status == http.StatusOK {
+				sh.store(key, statsCacheEntry{
+					body:        buf.body.Bytes(),
+					contentType: w.Header().Get("Content-Type"),
+					at:          time.Now(),
+				})
+			}
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+		})
+	}
+}
+
+func (sh *statsShedder) acquire(r *http.Request, slots chan struct{}) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	timer := time.NewTimer(statsShedWait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (sh *statsShedder) shed(w http.ResponseWriter, r *http.Request, class statsClass, key string) {
+	entry, ok := sh.lookup(key)
+	if !ok {
+		log.Printf("stats load shedding: class=%s path=%s rejected", class, r.URL.Path)
+		metrics.StatsShed.Inc(string(class), "rejected")
+		w.Header().Set("Retry-After", strconv.Itoa(statsShedRetryAfter))
+		writeError(w, http.StatusServiceUnavailable, "stats are busy, try again shortly")
+		return
+	}
+	metrics.StatsShed.Inc(string(class), "stale")
+	age := int(time.Since(entry.at).Seconds())
+	w.Header().Set("Content-Type", entry.contentType)
+	w.Header().Set("Age", strconv.Itoa(age))
+	w.Header().Set("X-Stats-Stale", "true")
+	w.WriteHeader(http.StatusOK)
+	w.Write(entry.body)
+}
+
+func (sh *statsShedder) lookup(key string) (statsCacheEntry, bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	entry, ok := sh.cache[key]
+	if !ok || time.Since(entry.at) > statsStaleMaxAge {
+		return statsCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store keeps entry for key, evicting the oldest response once the cache is
+// full.
+func (sh *statsShedder) store(key string, entry statsCacheEntry) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, exists := sh.cache[key]; !exists && len(sh.cache) >= statsCacheMaxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range sh.cache {
+			if oldestKey == "" || e.at.Before(oldest) {
+				oldestKey, oldest = k, e.at
+			}
+		}
+		delete(sh.cache, oldestKey)
+	}
+	sh.cache[key] = entry
+}
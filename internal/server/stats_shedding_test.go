@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsShedding(t *testing.T) {
+	srv, _ := newTestServerWrapped(t)
+	slots := srv.Server.statsShed.slots[statsClassReports]
+	fill := func() {
+		for range cap(slots) {
+			slots <- struct{}{}
+		}
+	}
+	drain := func() {
+		for range cap(slots) {
+			<-slots
+		}
+	}
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	fill()
+	w := get("/api/stats/concurrent-records")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("at capacity with no cache: status = %d, want 503", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After on shed request")
+	}
+	drain()
+
+	fresh := get("/api/stats/concurrent-records")
+	if fresh.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", fresh.Code, fresh.Body.String())
+	}
+	if fresh.Header().Get("X-Stats-Stale") != "" {
+		t.Error("fresh response marked stale")
+	}
+
+	fill()
+	defer drain()
+	stale := get("/api/stats/concurrent-records")
+	if stale.Code != http.StatusOK {
+		t.Fatalf("at capacity with cache: status = %d, want 200", stale.Code)
+	}
+	if stale.Header().Get("X-Stats-Stale") != "true" || stale.Header().Get("Age") == "" {
+		t.Errorf("stale headers = %v", stale.Header())
+	}
+	if stale.Body.String() != fresh.Body.String() {
+		t.Errorf("stale body = %s, want %s", stale.Body.String(), fresh.Body.String())
+	}
+
+	// Other URLs aren't served another query's cached response.
+	if w := get("/api/stats/concurrent-records?days=7"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("uncached URL at capacity: status = %d, want 503", w.Code)
+	}
+	// Other classes have their own slots.
+	if w := get("/api/stats"); w.Code != http.StatusOK {
+		t.Errorf("overview class: status = %d, want 200", w.Code)
+	}
+}
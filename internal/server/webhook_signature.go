@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/hmac"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"streammon/internal/webhooks"
+)
+
+// Signed webhook deliveries carry a Unix timestamp, a unique nonce, and
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<nonce>.<body>"
+// keyed with the webhook's signing secret.
+const (
+	webhookTimestampHeader = webhooks.TimestampHeader
+	webhookNonceHeader     = webhooks.NonceHeader
+	webhookSignatureHeader = webhooks.SignatureHeader
+
+	webhookTimestampTolerance = 5 * time.Minute
+	maxWebhookNonceLen        = 128
+)
+
+var (
+	errWebhookUnsigned  = errors.New("missing signature headers")
+	errWebhookStale     = errors.New("timestamp outside tolerance")
+	errWebhookSignature = errors.New("signature mismatch")
+	errWebhookReplay    = errors.New("nonce already used")
+)
+
+// webhookSignature returns the expected signature header value.
+func webhookSignature(secret, timestamp, nonce string, body []byte) string {
+	return webhooks.Sign(secret, timestamp, nonce, body)
+}
+
+// verifyWebhookSignature checks a signed delivery's headers against body,
+// then claims its nonce so the same request can't be replayed.
+func verifyWebhookSignature(h http.Header, body []byte, secret string, serverID int64, nonces *nonceCache, now time.Time) error {
+	timestamp := h.Get(webhookTimestampHeader)
+	nonce := h.Get(webhookNonceHeader)
+	sig := h.Get(webhookSignatureHeader)
+	if timestamp == "" || nonce == "" || sig == "" || len(nonce) > maxWebhookNonceLen {
+		return errWebhookUnsigned
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errWebhookStale
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > webhookTimestampTolerance || d < -webhookTimestampTolerance {
+		return errWebhookStale
+	}
+
+	if !strings.HasPrefix(sig, "sha256=") {
+		sig = "sha256=" + sig
+	}
+	if !hmac.Equal([]byte(strings.ToLower(sig)), []byte(webhookSignature(secret, timestamp, nonce, body))) {
+		return errWebhookSignature
+	}
+
+	if !nonces.claim(strconv.FormatInt(serverID, 10)+":"+nonce, now) {
+		return errWebhookReplay
+	}
+	return nil
+}
+
+// nonceCache remembers nonces for twice the timestamp tolerance, long enough
+// that any replay still inside the tolerance window is caught.
+type nonceCache struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time)}
+}
+
+// claim records key and reports whether it was unseen.
+func (c *nonceCache) claim(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := 2 * webhookTimestampTolerance
+	if now.Sub(c.lastPrune) > time.Minute {
+		for k, at := range c.seen {
+			if now.Sub(at) > ttl {
+				delete(c.seen, k)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	if at, ok := c.seen[key]; ok && now.Sub(at) <= ttl {
+		return false
+	}
+	c.seen[key] = now
+	return true
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"streammon/internal/models"
+)
+
+const workspaceContextKey contextKey = "workspace"
+
+// WorkspaceFromContext returns the workspace the caller is confined to, or
+// nil when they're unconfined. A caller outside every workspace gets one
+// with no servers and a zero WorkspaceID; see workspaceFor.
+func WorkspaceFromContext(ctx context.Context) *models.WorkspaceMembership {
+	m, _ := ctx.Value(workspaceContextKey).(*models.WorkspaceMembership)
+	return m
+}
+
+// scopeToWorkspace confines workspace members to their workspace's servers.
+// server_ids is rewritten to the servers the caller asked for, or their
+// preferred servers, that are in the workspace, so every handler reading
+// server_ids only sees the workspace's history and stats. Admins pass
+// through untouched, as does everyone until the first workspace exists.
+func (s *Server) scopeToWorkspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := UserFromContext(r.Context())
+		m, err := s.workspaceFor(r.Context(), user)
+		if err != nil {
+			log.Printf("getting workspace for user %d: %v", user.ID, err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if m == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+		var requested []int64
+		if q.Has("server_ids") {
+			requested, err = parseServerIDs(q.Get("server_ids"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid server_ids")
+				return
+			}
+		} else if prefs, err := s.store.GetUserPreferences(user.ID); err == nil {
+			requested = prefs.ServerIDs
+		}
+		scoped := m.ScopeServerIDs(requested)
+		ids := make([]string, len(scoped))
+		for i, id := range scoped {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		if len(ids) == 0 {
+			// Server IDs start at 1, so this matches nothing rather than
+			// falling back to every server.
+			ids = []string{"0"}
+		}
+		q.Set("server_ids", strings.Join(ids, ","))
+
+		r2 := r.Clone(context.WithValue(r.Context(), workspaceContextKey, m))
+		r2.URL.RawQuery = q.Encode()
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// workspaceFor returns the workspace user is confined to, or nil for admins
+// and admin-issued guest tokens. Once any workspace exists, a user in none
+// of them is confined to an empty workspace instead of seeing every tenant.
+func (s *Server) workspaceFor(ctx context.Context, user *models.User) (*models.WorkspaceMembership, error) {
+	if user == nil || user.ID == 0 || user.Role == models.RoleAdmin {
+		return nil, nil
+	}
+	m, err := s.store.WorkspaceMembership(ctx, user.ID)
+	if !errors.Is(err, models.ErrNotFound) {
+		return m, err
+	}
+	exists, err := s.store.HasWorkspaces(ctx)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return &models.WorkspaceMembership{Role: models.WorkspaceRoleMember, ServerIDs: []int64{}}, nil
+}
+
+// attachWorkspace puts the caller's workspace in the request context
+// without touching server_ids, for routes outside /api's middleware stack.
+func (s *Server) attachWorkspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := UserFromContext(r.Context())
+		m, err := s.workspaceFor(r.Context(), user)
+		if err != nil {
+			log.Printf("getting workspace for user %d: %v", user.ID, err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if m != nil {
+			r = r.WithContext(context.WithValue(r.Context(), workspaceContextKey, m))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireWorkspaceUser answers 404 for /users/{name} routes when the user
+// never played on, and isn't a member of, the caller's workspace.
+func (s *Server) requireWorkspaceUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, err := s.workspaceCanSeeUser(r, chi.URLParam(r, "name"))
+		if err != nil {
+			log.Printf("checking workspace user: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// visibleToWorkspace reports whether the caller may see serverID: always,
+// unless they're confined to a workspace without it.
+func visibleToWorkspace(r *http.Request, serverID int64) bool {
+	m := WorkspaceFromContext(r.Context())
+	return m == nil || m.HasServer(serverID)
+}
+
+// workspaceServerIDs returns the servers the caller is confined to, or nil
+// when they aren't in a workspace. A workspace without servers yields an ID
+// that matches nothing, so it never reads as "every server".
+func workspaceServerIDs(r *http.Request) []int64 {
+	m := WorkspaceFromContext(r.Context())
+	if m == nil {
+		return nil
+	}
+	if len(m.ServerIDs) == 0 {
+		return []int64{0}
+	}
+	return m.ServerIDs
+}
+
+// workspaceSessions drops the sessions on servers outside the caller's
+// workspace.
+func workspaceSessions(r *http.Request, sessions []models.ActiveStream) []models.ActiveStream {
+	m := WorkspaceFromContext(r.Context())
+	if m == nil {
+		return sessions
+	}
+	scoped := make([]models.ActiveStream, 0, len(sessions))
+	for _, session := range sessions {
+		if m.HasServer(session.ServerID) {
+			scoped = append(scoped, session)
+		}
+	}
+	return scoped
+}
+
+// workspaceServers drops the servers outside the caller's workspace.
+func workspaceServers(r *http.Request, servers []models.Server) []models.Server {
+	m := WorkspaceFromContext(r.Context())
+	if m == nil {
+		return servers
+	}
+	scoped := make([]models.Server, 0, len(servers))
+	for _, srv := range servers {
+		if m.HasServer(srv.ID) {
+			scoped = append(scoped, srv)
+		}
+	}
+	return scoped
+}
+
+// workspaceUserNames returns the users the caller's workspace can see:
+// anyone who played on, or is streaming on, one of its servers, plus its
+// members. It returns nil when the caller isn't in a workspace.
+func (s *Server) workspaceUserNames(r *http.Request) (map[string]bool, error) {
+	m := WorkspaceFromContext(r.Context())
+	if m == nil {
+		return nil, nil
+	}
+	names, err := s.store.WorkspaceUserNames(r.Context(), m.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if s.poller != nil {
+		for _, session := range workspaceSessions(r, s.poller.CurrentSessions()) {
+			names[session.UserName] = true
+		}
+	}
+	return names, nil
+}
+
+// workspaceCanSeeUser reports whether name is visible to the caller's
+// workspace; see workspaceUserNames.
+func (s *Server) workspaceCanSeeUser(r *http.Request, name string) (bool, error) {
+	m := WorkspaceFromContext(r.Context())
+	if m == nil {
+		return true, nil
+	}
+	if s.poller != nil {
+		for _, session := range workspaceSessions(r, s.poller.CurrentSessions()) {
+			if session.UserName == name {
+				return true, nil
+			}
+		}
+	}
+	return s.store.WorkspaceHasUser(r.Context(), m.WorkspaceID, name)
+}
+
+// requireRuleManager lets admins and workspace admins through to the rules
+// and notification channel routes. Workspace admins only ever see their own
+// workspace's rules and channels; see requireWorkspaceRule.
+func requireRuleManager(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := UserFromContext(r.Context()); user != nil && user.Role == models.RoleAdmin {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if m := WorkspaceFromContext(r.Context()); m == nil || m.Role != models.WorkspaceRoleAdmin {
+			writeError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireWorkspaceRule answers 404 for /rules/{id} routes when the rule
+// isn't in the caller's workspace.
+func (s *Server) requireWorkspaceRule(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := WorkspaceFromContext(r.Context())
+		if m == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := parseIDParam(r, "id")
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid rule id")
+			return
+		}
+		rule, err := s.store.GetRule(id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if !rule.InWorkspace(m.WorkspaceID) {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireWorkspaceChannel answers 404 for /notifications/{id} routes when
+// the channel isn't in the caller's workspace.
+func (s *Server) requireWorkspaceChannel(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := WorkspaceFromContext(r.Context())
+		if m == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := parseIDParam(r, "id")
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid channel id")
+			return
+		}
+		channel, err := s.store.GetNotificationChannel(id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if !channel.InWorkspace(m.WorkspaceID) {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
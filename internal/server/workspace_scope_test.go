@@ -0,0 +1,587 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/auth"
+	"streammon/internal/models"
+)
+
+// workspaceScopeFixture has two servers, "Friends" and "Family", with amy
+// playing on Friends and bob on Family. The co-admin "hoster" and the viewer
+// "carol" are confined to a workspace holding only Friends.
+type workspaceScopeFixture struct {
+	srv          *testServer
+	friends      int64
+	family       int64
+	bobHistoryID int64
+	workspace    int64
+	hoster       string
+	carol        string
+}
+
+func newWorkspaceScopeFixture(t *testing.T) *workspaceScopeFixture {
+	t.Helper()
+	srv, st := newTestServerWrapped(t)
+	ctx := context.Background()
+	f := &workspaceScopeFixture{srv: srv}
+
+	started := time.Now().UTC().Add(-2 * time.Hour)
+	for _, seed := range []struct {
+		server string
+		users  []string
+		id     *int64
+	}{
+		{"Friends", []string{"amy"}, &f.friends},
+		{"Family", []string{"bob", "bob"}, &f.family},
+	} {
+		s := &models.Server{Name: seed.server, Type: models.ServerTypePlex, URL: "http://" + seed.server, APIKey: "k", Enabled: true}
+		if err := st.CreateServer(s); err != nil {
+			t.Fatal(err)
+		}
+		*seed.id = s.ID
+		for i, user := range seed.users {
+			entry := &models.WatchHistoryEntry{
+				ServerID: s.ID, UserName: user, MediaType: models.MediaTypeMovie,
+				Title: fmt.Sprintf("%s movie %d", seed.server, i), IPAddress: "203.0.113.5",
+				DurationMs: 3600000, WatchedMs: 3600000, StartedAt: started, StoppedAt: started.Add(time.Hour),
+			}
+			if err := st.InsertHistory(entry); err != nil {
+				t.Fatal(err)
+			}
+			if user == "bob" {
+				f.bobHistoryID = entry.ID
+			}
+		}
+	}
+	for _, name := range []string{"amy", "bob"} {
+		if err := st.UpdateUserAvatar(name, "", "plex"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.UpsertHouseholdLocation(&models.HouseholdLocation{
+			UserName: name, IPAddress: "203.0.113.5", City: "Berlin", Latitude: 52.52, Longitude: 13.4,
+			Trusted: true, SessionCount: 5, FirstSeen: started, LastSeen: started,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.poller = &fakePoller{sessions: []models.ActiveStream{
+		{SessionID: "amy-1", ServerID: f.friends, UserName: "amy", Title: "Friends live"},
+		{SessionID: "bob-1", ServerID: f.family, UserName: "bob", Title: "Family live"},
+	}}
+
+	f.hoster = createCoAdminSession(t, st, "hoster")
+	f.carol = createViewerSession(t, st, "carol")
+	ws := &models.Workspace{Name: "Friends"}
+	if err := st.CreateWorkspace(ws); err != nil {
+		t.Fatal(err)
+	}
+	f.workspace = ws.ID
+	if err := st.SetWorkspaceServers(ctx, ws.ID, []int64{f.friends}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"hoster", "carol"} {
+		u, err := st.GetUser(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SetWorkspaceMember(ctx, ws.ID, u.ID, models.WorkspaceRoleMember); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func (f *workspaceScopeFixture) get(t *testing.T, token, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	return f.do(t, token, http.MethodGet, path, "")
+}
+
+func (f *workspaceScopeFixture) do(t *testing.T, token, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	f.srv.Unwrap().ServeHTTP(w, req)
+	return w
+}
+
+func TestWorkspaceScopesLiveSessions(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+
+	for _, path := range []string{"/api/dashboard/sessions", "/api/dashboard", "/api/dashboard/map"} {
+		w := f.get(t, f.hoster, path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); !strings.Contains(body, "Friends live") || strings.Contains(body, "Family live") {
+			t.Errorf("%s: expected only the workspace's sessions, got %s", path, body)
+		}
+	}
+
+	w := f.get(t, f.hoster, "/api/dashboard")
+	var overview dashboardOverviewResponse
+	if err := json.NewDecoder(w.Body).Decode(&overview); err != nil {
+		t.Fatal(err)
+	}
+	if len(overview.Servers) != 1 || overview.Servers[0].ID != f.friends || overview.Streams.StreamCount != 1 {
+		t.Errorf("overview servers = %+v, streams = %+v", overview.Servers, overview.Streams)
+	}
+
+	w = f.get(t, f.hoster, "/api/dashboard/summary")
+	var summary dashboardSummaryResponse
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.StreamCount != 1 || summary.ServerCount != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	if w := f.get(t, f.hoster, "/api/sessions/bob-1"); w.Code != http.StatusNotFound {
+		t.Errorf("other workspace's session: expected 404, got %d", w.Code)
+	}
+	if w := f.get(t, f.hoster, "/api/sessions/amy-1"); w.Code != http.StatusOK {
+		t.Errorf("workspace session: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWorkspaceScopesSSE(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dashboard/sse", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: f.hoster})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	f.srv.Unwrap().ServeHTTP(w, req.WithContext(ctx))
+
+	if body := w.Body.String(); !strings.Contains(body, "Friends live") || strings.Contains(body, "Family live") {
+		t.Errorf("expected only the workspace's sessions, got %s", body)
+	}
+}
+
+func TestWorkspaceScopesUsers(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+
+	for _, path := range []string{"/api/users", "/api/users/summary", "/api/household/map"} {
+		w := f.get(t, f.hoster, path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); !strings.Contains(body, `"amy"`) || strings.Contains(body, `"bob"`) {
+			t.Errorf("%s: expected only the workspace's users, got %s", path, body)
+		}
+	}
+
+	for _, path := range []string{"/api/users/bob", "/api/users/bob/stats", "/api/users/bob/locations", "/api/stats/users/bob/streaks"} {
+		if w := f.get(t, f.hoster, path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if w := f.get(t, f.hoster, "/api/users/amy"); w.Code != http.StatusOK {
+		t.Errorf("workspace user: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := f.get(t, f.carol, "/api/users/carol"); w.Code != http.StatusOK {
+		t.Errorf("member's own profile: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWorkspaceScopesHistoryDetail(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+	path := fmt.Sprintf("/api/history/%d/sessions", f.bobHistoryID)
+
+	admin := httptest.NewRecorder()
+	f.srv.ServeHTTP(admin, httptest.NewRequest(http.MethodGet, path, nil))
+	var sessions []models.WatchSession
+	if err := json.NewDecoder(admin.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("admin: expected 1 session, got %d", len(sessions))
+	}
+
+	w := f.get(t, f.hoster, path)
+	sessions = nil
+	if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("other workspace's history: expected no sessions, got %+v", sessions)
+	}
+
+	if w := f.get(t, f.hoster, fmt.Sprintf("/api/servers/%d/thumb/library/metadata/1/thumb", f.family)); w.Code != http.StatusNotFound {
+		t.Errorf("other workspace's thumb: expected 404, got %d", w.Code)
+	}
+}
+
+func TestWorkspaceScopesConcurrentRecords(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+
+	w := f.get(t, f.hoster, "/api/stats/concurrent-records")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var records models.ConcurrentRecords
+	if err := json.NewDecoder(w.Body).Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+	// bob's two overlapping plays on Family would make the record 2.
+	if records.AllTime.Count != 1 {
+		t.Errorf("all-time record = %+v, want 1 from the workspace's server", records.AllTime)
+	}
+}
+
+func TestWorkspaceScopesRulesAndChannels(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+	st := f.srv.store
+	ctx := context.Background()
+
+	hoster, err := st.GetUser("hoster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetWorkspaceMember(ctx, f.workspace, hoster.ID, models.WorkspaceRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	other := &models.Workspace{Name: "Family"}
+	if err := st.CreateWorkspace(other); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := map[string]*models.Rule{"mine": nil, "theirs": nil, "global": nil}
+	channels := map[string]*models.NotificationChannel{"mine": nil, "theirs": nil, "global": nil}
+	owners := map[string]*int64{"mine": &f.workspace, "theirs": &other.ID, "global": nil}
+	for key, owner := range owners {
+		rule := &models.Rule{Name: key + " rule", Type: models.RuleTypeConcurrentStreams, Enabled: true,
+			Config: json.RawMessage(`{"max_streams":2}`), WorkspaceID: owner}
+		if err := st.CreateRule(rule); err != nil {
+			t.Fatal(err)
+		}
+		rules[key] = rule
+		channel := &models.NotificationChannel{Name: key + " channel", ChannelType: models.ChannelTypeDiscord, Enabled: true,
+			Config: json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/1/abc"}`), WorkspaceID: owner}
+		if err := st.CreateNotificationChannel(channel); err != nil {
+			t.Fatal(err)
+		}
+		channels[key] = channel
+	}
+
+	for _, path := range []string{"/api/rules", "/api/notifications"} {
+		w := f.get(t, f.hoster, path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, `"mine `) || strings.Contains(body, `"theirs `) || strings.Contains(body, `"global `) {
+			t.Errorf("%s: expected only the workspace's entries, got %s", path, body)
+		}
+	}
+
+	for _, key := range []string{"theirs", "global"} {
+		for _, req := range []struct{ method, path, body string }{
+			{http.MethodGet, fmt.Sprintf("/api/rules/%d", rules[key].ID), ""},
+			{http.MethodPut, fmt.Sprintf("/api/rules/%d", rules[key].ID), `{"name":"x","type":"concurrent_streams","enabled":true,"config":{"max_streams":1}}`},
+			{http.MethodDelete, fmt.Sprintf("/api/rules/%d", rules[key].ID), ""},
+			{http.MethodGet, fmt.Sprintf("/api/rules/%d/channels", rules[key].ID), ""},
+			{http.MethodGet, fmt.Sprintf("/api/notifications/%d", channels[key].ID), ""},
+			{http.MethodDelete, fmt.Sprintf("/api/notifications/%d", channels[key].ID), ""},
+		} {
+			if w := f.do(t, f.hoster, req.method, req.path, req.body); w.Code != http.StatusNotFound {
+				t.Errorf("%s %s: expected 404, got %d", req.method, req.path, w.Code)
+			}
+		}
+	}
+
+	link := fmt.Sprintf("/api/rules/%d/channels", rules["mine"].ID)
+	if w := f.do(t, f.hoster, http.MethodPost, link, fmt.Sprintf(`{"channel_id":%d}`, channels["global"].ID)); w.Code != http.StatusBadRequest {
+		t.Errorf("linking a global channel: expected 400, got %d", w.Code)
+	}
+	if w := f.do(t, f.hoster, http.MethodPost, link, fmt.Sprintf(`{"channel_id":%d}`, channels["mine"].ID)); w.Code != http.StatusOK {
+		t.Errorf("linking the workspace's channel: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := f.do(t, f.hoster, http.MethodPost, "/api/rules", `{"name":"new","type":"concurrent_streams","enabled":true,"config":{"max_streams":3}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create rule: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Rule
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if !created.InWorkspace(f.workspace) {
+		t.Errorf("created rule workspace = %v, want %d", created.WorkspaceID, f.workspace)
+	}
+
+	w = f.do(t, f.hoster, http.MethodPost, "/api/notifications",
+		`{"name":"new","channel_type":"discord","enabled":true,"config":{"webhook_url":"https://discord.com/api/webhooks/2/def"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create channel: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var channel models.NotificationChannel
+	if err := json.NewDecoder(w.Body).Decode(&channel); err != nil {
+		t.Fatal(err)
+	}
+	if !channel.InWorkspace(f.workspace) {
+		t.Errorf("created channel workspace = %v, want %d", channel.WorkspaceID, f.workspace)
+	}
+
+	helper := createCoAdminSession(t, st, "helper")
+	u, err := st.GetUser("helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetWorkspaceMember(ctx, f.workspace, u.ID, models.WorkspaceRoleMember); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/api/rules", "/api/notifications"} {
+		if w := f.get(t, helper, path); w.Code != http.StatusForbidden {
+			t.Errorf("%s: workspace member expected 403, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestWorkspaceScopesReports(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+	st := f.srv.store
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	stale := now.AddDate(0, 0, -60)
+	for _, seed := range []struct {
+		server int64
+		user   string
+	}{{f.friends, "amy"}, {f.family, "bob"}, {f.friends, "dan"}, {f.family, "dan"}} {
+		if err := st.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: seed.server, UserName: seed.user, MediaType: models.MediaTypeMovie, ItemID: "half-" + seed.user,
+			Title: "Half watched", DurationMs: 3600000, WatchedMs: 600000, StartedAt: stale, StoppedAt: stale.Add(10 * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SetWatchGoal(&models.WatchGoal{UserName: seed.user, WeeklyTargetMinutes: 60}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, server := range []int64{f.friends, f.family} {
+		if _, err := st.UpsertLibraryItems(ctx, []models.LibraryItemCache{
+			{ServerID: server, LibraryID: "lib", ItemID: "item", MediaType: models.MediaTypeMovie, Title: "Movie", AddedAt: now, SyncedAt: now},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two syncs without amy and bob archive them.
+	for _, seed := range []struct {
+		server int64
+		user   string
+	}{{f.friends, "amy"}, {f.family, "bob"}} {
+		for _, present := range [][]string{{seed.user, "keep"}, {"keep"}, {"keep"}} {
+			if _, _, err := st.ReconcileServerUsers(ctx, seed.server, present, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		path, visible, hidden string
+	}{
+		{"/api/watch-goals", `"amy"`, `"bob"`},
+		{"/api/stats/stale-in-progress", `"amy"`, `"bob"`},
+		{"/api/users/archived", `"amy"`, `"bob"`},
+		{"/api/library/summary", `"Friends"`, `"Family"`},
+	} {
+		admin := httptest.NewRecorder()
+		f.srv.ServeHTTP(admin, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if body := admin.Body.String(); !strings.Contains(body, tc.hidden) {
+			t.Fatalf("%s: admin should see %s, got %s", tc.path, tc.hidden, body)
+		}
+
+		w := f.get(t, f.hoster, tc.path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tc.path, w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); !strings.Contains(body, tc.visible) || strings.Contains(body, tc.hidden) {
+			t.Errorf("%s: expected %s but not %s, got %s", tc.path, tc.visible, tc.hidden, body)
+		}
+	}
+
+	// dan has accounts on both servers, but only one in the workspace.
+	admin := httptest.NewRecorder()
+	f.srv.ServeHTTP(admin, httptest.NewRequest(http.MethodGet, "/api/users/duplicates", nil))
+	if body := admin.Body.String(); !strings.Contains(body, `"dan"`) {
+		t.Fatalf("admin should see dan's duplicate accounts, got %s", body)
+	}
+	if body := f.get(t, f.hoster, "/api/users/duplicates").Body.String(); strings.Contains(body, `"dan"`) {
+		t.Errorf("expected no duplicates within the workspace, got %s", body)
+	}
+}
+
+// TestWorkspaceScopesSharedUser covers a user who streams on servers in two
+// workspaces: each workspace only sees what happened on its own servers.
+func TestWorkspaceScopesSharedUser(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+	st := f.srv.store
+	ctx := context.Background()
+
+	started := time.Now().UTC().Add(-3 * time.Hour)
+	for _, seed := range []struct {
+		server     int64
+		name, ip   string
+		city       string
+		lat, lng   float64
+		sessions   int
+		decrement  int
+		watchedMs  int64
+		durationMs int64
+	}{
+		{f.friends, "Friends", "198.51.100.7", "Berlin", 52.52, 13.4, 2, 10, 3600000, 3600000},
+		{f.family, "Family", "192.0.2.44", "Tokyo", 35.68, 139.69, 5, 20, 7200000, 7200000},
+	} {
+		for _, entry := range []*models.WatchHistoryEntry{
+			{ServerID: seed.server, UserName: "eve", MediaType: models.MediaTypeMovie, Title: "Eve " + seed.name + " film",
+				IPAddress: seed.ip, DurationMs: seed.durationMs, WatchedMs: seed.watchedMs, StartedAt: started, StoppedAt: started.Add(time.Hour)},
+			{ServerID: seed.server, UserName: "eve", MediaType: models.MediaTypeMovie, Title: "Eve " + seed.name + " half", ItemID: seed.name + "-half",
+				IPAddress: seed.ip, DurationMs: 3600000, WatchedMs: 600000, StartedAt: started, StoppedAt: started.Add(10 * time.Minute)},
+		} {
+			if err := st.InsertHistory(entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := st.SetCachedGeo(&models.GeoResult{IP: seed.ip, City: seed.city, Country: "XX", Lat: seed.lat, Lng: seed.lng}); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.UpsertHouseholdLocation(&models.HouseholdLocation{
+			UserName: "eve", IPAddress: seed.ip, City: seed.city, Latitude: seed.lat, Longitude: seed.lng,
+			Trusted: true, SessionCount: seed.sessions, FirstSeen: started, LastSeen: started,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		rule := &models.Rule{Name: seed.name + " limit", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{"max_streams":1}`)}
+		if err := st.CreateRule(rule); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.InsertViolationWithTx(ctx, &models.RuleViolation{
+			RuleID: rule.ID, UserName: "eve", Severity: models.SeverityWarning, Message: "eve broke the " + seed.name + " limit",
+			ServerID: seed.server, OccurredAt: started,
+		}, seed.decrement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.SetWatchGoal(&models.WatchGoal{UserName: "eve", WeeklyTargetMinutes: 600}); err != nil {
+		t.Fatal(err)
+	}
+
+	adminGet := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		f.srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("admin %s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		return w
+	}
+	hosterGet := func(path string) *httptest.ResponseRecorder {
+		w := f.get(t, f.hoster, path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	for _, tc := range []struct{ path, marker string }{
+		{"/api/users/eve/locations", "Tokyo"},
+		{"/api/users/eve/stats", "Tokyo"},
+		{"/api/users/eve/continue-watching", "Eve Family half"},
+		{fmt.Sprintf("/api/users/eve/wrapped/%d", started.Year()), "Eve Family film"},
+		{"/api/users/eve/violations", "Family limit"},
+		{"/api/users/eve/household", "Tokyo"},
+		{"/api/household/map", "Tokyo"},
+	} {
+		if body := adminGet(tc.path).Body.String(); !strings.Contains(body, tc.marker) {
+			t.Fatalf("admin %s: expected %q, got %s", tc.path, tc.marker, body)
+		}
+		if body := hosterGet(tc.path).Body.String(); strings.Contains(body, tc.marker) {
+			t.Errorf("%s: Family data %q leaked: %s", tc.path, tc.marker, body)
+		}
+	}
+
+	field := func(w *httptest.ResponseRecorder, key string) float64 {
+		t.Helper()
+		var body map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		v, _ := body[key].(float64)
+		return v
+	}
+	for _, tc := range []struct {
+		path, key  string
+		admin, own float64
+	}{
+		{"/api/users/eve/stats", "session_count", 4, 2},
+		{"/api/users/eve/watch-goal", "watched_ms", 3600000 + 7200000 + 2*600000, 3600000 + 600000},
+		{"/api/stats/users/eve/streaks", "total_hours", 3.33, 1.17},
+		{"/api/users/eve/trust", "score", 70, 90},
+		{"/api/users/eve/household/home", "latitude", 35.68, 52.5}, // co-admins get rounded coordinates
+	} {
+		if got := field(adminGet(tc.path), tc.key); got != tc.admin {
+			t.Fatalf("admin %s: %s = %v, want %v", tc.path, tc.key, got, tc.admin)
+		}
+		if got := field(hosterGet(tc.path), tc.key); got != tc.own {
+			t.Errorf("%s: %s = %v, want %v from Friends only", tc.path, tc.key, got, tc.own)
+		}
+	}
+
+	var days []models.DailyWatchTime
+	if err := json.NewDecoder(hosterGet("/api/users/eve/watch-time/daily").Body).Decode(&days); err != nil {
+		t.Fatal(err)
+	}
+	var plays int
+	for _, d := range days {
+		plays += d.Plays
+	}
+	if plays != 2 {
+		t.Errorf("daily watch time: %d plays, want 2 from Friends only", plays)
+	}
+}
+
+func TestWorkspaceScopesUnaffiliatedUsers(t *testing.T) {
+	f := newWorkspaceScopeFixture(t)
+	st := f.srv.store
+	drifter := createCoAdminSession(t, st, "drifter")
+
+	w := f.get(t, drifter, "/api/history")
+	var history models.PaginatedResult[models.WatchHistoryEntry]
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Items) != 0 {
+		t.Errorf("history: expected nothing outside every workspace, got %+v", history.Items)
+	}
+	for _, path := range []string{"/api/dashboard/sessions", "/api/users", "/api/servers"} {
+		w := f.get(t, drifter, path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); strings.Contains(body, "Friends") || strings.Contains(body, "Family") || strings.Contains(body, `"amy"`) {
+			t.Errorf("%s: expected nothing outside every workspace, got %s", path, body)
+		}
+	}
+	if w := f.get(t, drifter, "/api/users/amy"); w.Code != http.StatusNotFound {
+		t.Errorf("user profile: expected 404, got %d", w.Code)
+	}
+
+	// Removing a member drops them to nothing, not to every tenant.
+	admin := httptest.NewRecorder()
+	f.srv.ServeHTTP(admin, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/workspaces/%d/members/hoster", f.workspace), nil))
+	if admin.Code != http.StatusNoContent {
+		t.Fatalf("remove member: expected 204, got %d: %s", admin.Code, admin.Body.String())
+	}
+	if body := f.get(t, f.hoster, "/api/dashboard/sessions").Body.String(); strings.Contains(body, "live") {
+		t.Errorf("removed member: expected no sessions, got %s", body)
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"streammon/internal/models"
+)
+
+// Wrapped card layout, in SVG user units.
+const (
+	wrappedCardWidth  = 1080
+	wrappedCardHeight = 1350
+	wrappedMapX       = 80
+	wrappedMapY       = 930
+	wrappedMapWidth   = 920
+	wrappedMapHeight  = 340
+)
+
+// wrappedCardTopN is how many shows and movies the card lists.
+const wrappedCardTopN = 3
+
+// renderWrappedCard draws w as a self-contained SVG, with the places
+// watched from plotted on a plain longitude/latitude grid.
+func renderWrappedCard(w *models.Wrapped) []byte {
+	var b bytes.Buffer
+	text := func(x, y, size int, weight, fill, s string) {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%d" font-weight="%s" fill="%s">%s</text>`+"\n",
+			x, y, size, weight, fill, html.EscapeString(s))
+	}
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		wrappedCardWidth, wrappedCardHeight, wrappedCardWidth, wrappedCardHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#111827"/>`+"\n", wrappedCardWidth, wrappedCardHeight)
+
+	text(80, 120, 44, "bold", "#f9fafb", fmt.Sprintf("%s's %d Wrapped", w.UserName, w.Year))
+	text(80, 250, 120, "bold", "#34d399", fmt.Sprintf("%.0f", w.TotalHours))
+	text(80, 300, 34, "normal", "#d1d5db", fmt.Sprintf("hours watched · %d plays · %d days", w.Plays, w.DaysWatched))
+
+	list := func(x, y int, heading string, items []models.MediaStat) {
+		text(x, y, 30, "bold", "#f9fafb", heading)
+		if len(items) == 0 {
+			text(x, y+50, 28, "normal", "#6b7280", "Nothing this year")
+		}
+		for i, m := range items {
+			if i == wrappedCardTopN {
+				break
+			}
+			label := m.Title
+			if m.Year > 0 {
+				label = fmt.Sprintf("%s (%d)", m.Title, m.Year)
+			}
+			text(x, y+50*(i+1), 28, "normal", "#d1d5db", fmt.Sprintf("%d. %s", i+1, truncateRunes(label, 26)))
+		}
+	}
+	list(80, 400, "Top shows", w.TopShows)
+	list(560, 400, "Top movies", w.TopMovies)
+
+	y := 640
+	fact := func(label, value string) {
+		text(80, y, 26, "normal", "#9ca3af", label)
+		text(380, y, 28, "bold", "#f9fafb", truncateRunes(value, 40))
+		y += 60
+	}
+	if d := w.BusiestDay; d != nil {
+		fact("Busiest day", fmt.Sprintf("%s · %.1f h", d.Date, d.TotalHours))
+	}
+	if bg := w.LongestBinge; bg != nil {
+		fact("Longest binge", fmt.Sprintf("%s · %d episodes", bg.Show, bg.Episodes))
+	}
+	if dev := w.TopDevice; dev != nil {
+		name := dev.Player
+		if name == "" {
+			name = dev.Platform
+		}
+		fact("Favourite device", name)
+	}
+	fact("Places watched from", fmt.Sprintf("%d", len(w.Locations)))
+
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="16" fill="#1f2937"/>`+"\n",
+		wrappedMapX, wrappedMapY, wrappedMapWidth, wrappedMapHeight)
+	for _, loc := range w.Locations {
+		cx := wrappedMapX + (loc.Lng+180)/360*wrappedMapWidth
+		cy := wrappedMapY + (90-loc.Lat)/180*wrappedMapHeight
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%d" fill="#34d399" fill-opacity="0.8"><title>%s</title></circle>`+"\n",
+			cx, cy, locationDotRadius(loc.Plays), html.EscapeString(locationLabel(loc)))
+	}
+
+	text(80, 1320, 22, "normal", "#6b7280", "StreamMon")
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// locationDotRadius grows a map dot with the plays from that place, up to a
+// cap so one home city doesn't cover the map.
+func locationDotRadius(plays int) int {
+	return min(6+plays/5, 20)
+}
+
+func locationLabel(loc models.WrappedLocation) string {
+	if loc.City == "" {
+		return loc.Country
+	}
+	return loc.City + ", " + loc.Country
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -47,15 +47,50 @@ func (c *Client) LookupSeriesByTVDB(ctx context.Context, tvdbID string) (int, er
 	return series[0].ID, nil
 }
 
-// DeleteSeries removes a series from Sonarr, optionally deleting files.
-func (c *Client) DeleteSeries(ctx context.Context, seriesID int, deleteFiles bool) error {
+// DeleteSeries removes a series from Sonarr, optionally deleting files and
+// adding it to the import list exclusions so lists don't re-add it.
+func (c *Client) DeleteSeries(ctx context.Context, seriesID int, deleteFiles, addExclusion bool) error {
 	q := url.Values{}
 	if deleteFiles {
 		q.Set("deleteFiles", "true")
 	}
+	if addExclusion {
+		q.Set("addImportListExclusion", "true")
+	}
 	return c.DoDelete(ctx, fmt.Sprintf("/series/%d", seriesID), q)
 }
 
+type seriesEditorRequest struct {
+	SeriesIDs []int `json:"seriesIds"`
+	Monitored bool  `json:"monitored"`
+}
+
+// UnmonitorSeries keeps a series in Sonarr but stops it searching for or
+// grabbing any episode.
+func (c *Client) UnmonitorSeries(ctx context.Context, seriesID int) error {
+	data, err := json.Marshal(seriesEditorRequest{SeriesIDs: []int{seriesID}})
+	if err != nil {
+		return fmt.Errorf("marshal series editor: %w", err)
+	}
+	_, err = c.DoPut(ctx, "/series/editor", data)
+	return err
+}
+
+type seriesExclusion struct {
+	TVDBID int    `json:"tvdbId"`
+	Title  string `json:"title"`
+}
+
+// AddExclusion adds a series to Sonarr's import list exclusions.
+func (c *Client) AddExclusion(ctx context.Context, tvdbID int, title string) error {
+	data, err := json.Marshal(seriesExclusion{TVDBID: tvdbID, Title: title})
+	if err != nil {
+		return fmt.Errorf("marshal exclusion: %w", err)
+	}
+	_, err = c.DoPost(ctx, "/importlistexclusion", data)
+	return err
+}
+
 func (c *Client) GetSeries(ctx context.Context, seriesID int) (json.RawMessage, error) {
 	return c.DoGet(ctx, fmt.Sprintf("/series/%d", seriesID), nil)
 }
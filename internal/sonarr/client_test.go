@@ -216,7 +216,7 @@ func TestDeleteSeries(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient(ts.URL, "test-key")
-	if err := c.DeleteSeries(context.Background(), 77, true); err != nil {
+	if err := c.DeleteSeries(context.Background(), 77, true, false); err != nil {
 		t.Fatalf("DeleteSeries: %v", err)
 	}
 }
@@ -229,7 +229,7 @@ func TestDeleteSeriesFailure(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient(ts.URL, "test-key")
-	if err := c.DeleteSeries(context.Background(), 999, true); err == nil {
+	if err := c.DeleteSeries(context.Background(), 999, true, false); err == nil {
 		t.Fatal("expected error for 404 response")
 	}
 }
@@ -347,3 +347,23 @@ func TestGetCalendar(t *testing.T) {
 		t.Fatalf("expected Test Episode, got %s", episodes[0].Title)
 	}
 }
+
+func TestUnmonitorSeries(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v3/series/editor" {
+			t.Errorf("expected PUT /api/v3/series/editor, got %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["monitored"] != false {
+			t.Errorf("expected monitored=false, got %v", body["monitored"])
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient(ts.URL, "test-key")
+	if err := c.UnmonitorSeries(context.Background(), 77); err != nil {
+		t.Fatalf("UnmonitorSeries: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const apiTokenColumns = `id, user_id, name, scope, last_used_at, created_at`
+
+func scanAPIToken(scanner interface{ Scan(...any) error }) (models.APIToken, error) {
+	var t models.APIToken
+	var lastUsed sql.NullTime
+	if err := scanner.Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &lastUsed, &t.CreatedAt); err != nil {
+		return t, err
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = &lastUsed.Time
+	}
+	return t, nil
+}
+
+// CreateAPIToken stores a new token owned by userID and returns it with the
+// plaintext token set.
+func (s *Store) CreateAPIToken(userID int64, in models.APITokenInput) (*models.APIToken, error) {
+	raw, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating api token: %w", err)
+	}
+	token := models.APITokenPrefix + raw
+	t, err := scanAPIToken(s.db.QueryRow(
+		`INSERT INTO api_tokens (token_hash, user_id, name, scope)
+		VALUES (?, ?, ?, ?) RETURNING `+apiTokenColumns,
+		hashToken(token), userID, in.Name, in.Scope,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating api token: %w", err)
+	}
+	t.Token = token
+	return &t, nil
+}
+
+// ListAPITokens returns userID's tokens, newest first.
+func (s *Store) ListAPITokens(userID int64) ([]models.APIToken, error) {
+	rows, err := s.db.Query(`SELECT `+apiTokenColumns+` FROM api_tokens
+		WHERE user_id = ? ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning api token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// ResolveAPIToken returns the token a plaintext token belongs to and its
+// owner as currently stored, so role changes apply to existing tokens.
+func (s *Store) ResolveAPIToken(token string) (*models.APIToken, *models.User, error) {
+	var t models.APIToken
+	var u models.User
+	var lastUsed sql.NullTime
+	err := s.db.QueryRow(
+		`SELECT t.id, t.user_id, t.name, t.scope, t.last_used_at, t.created_at,
+			u.id, u.name, u.email, u.role, u.thumb_url, u.created_at, u.updated_at
+		FROM api_tokens t INNER JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ?`,
+		hashToken(token),
+	).Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &lastUsed, &t.CreatedAt,
+		&u.ID, &u.Name, &u.Email, &u.Role, &u.ThumbURL, &u.CreatedAt, &u.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("api token: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving api token: %w", err)
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = &lastUsed.Time
+	}
+	return &t, &u, nil
+}
+
+// TouchAPIToken records that an API token was just used.
+func (s *Store) TouchAPIToken(id int64) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("touching api token: %w", err)
+	}
+	return nil
+}
+
+// DeleteAPIToken revokes one of userID's tokens. Another user's token
+// reports ErrNotFound.
+func (s *Store) DeleteAPIToken(userID, id int64) error {
+	result, err := s.db.Exec(`DELETE FROM api_tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("deleting api token: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("api token %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestAPITokenLifecycle(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	owner, err := s.CreateLocalUser("alice", "alice@test.local", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.CreateLocalUser("bob", "bob@test.local", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := s.CreateAPIToken(owner.ID, models.APITokenInput{Name: "Home Assistant", Scope: models.APITokenScopeStatsOnly})
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	if !strings.HasPrefix(tok.Token, models.APITokenPrefix) {
+		t.Fatalf("expected %q prefix, got %q", models.APITokenPrefix, tok.Token)
+	}
+
+	got, user, err := s.ResolveAPIToken(tok.Token)
+	if err != nil {
+		t.Fatalf("ResolveAPIToken: %v", err)
+	}
+	if got.ID != tok.ID || got.Scope != models.APITokenScopeStatsOnly || got.Token != "" {
+		t.Fatalf("unexpected token %+v", got)
+	}
+	if user.ID != owner.ID || user.Name != "alice" || user.Role != models.RoleViewer {
+		t.Fatalf("unexpected owner %+v", user)
+	}
+
+	if err := s.TouchAPIToken(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := s.ListAPITokens(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Fatalf("unexpected list %+v", tokens)
+	}
+	if tokens, _ := s.ListAPITokens(other.ID); len(tokens) != 0 {
+		t.Fatalf("expected bob to see no tokens, got %d", len(tokens))
+	}
+
+	if _, _, err := s.ResolveAPIToken(models.APITokenPrefix + "nope"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown token, got %v", err)
+	}
+	if err := s.DeleteAPIToken(other.ID, tok.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound revoking another user's token, got %v", err)
+	}
+	if err := s.DeleteAPIToken(owner.ID, tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.ResolveAPIToken(tok.Token); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after revoke, got %v", err)
+	}
+}
+
+func TestAPITokensDeletedWithUser(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	if _, err := s.CreateLocalUser("admin", "admin@test.local", "", models.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	owner, err := s.CreateLocalUser("alice", "alice@test.local", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := s.CreateAPIToken(owner.ID, models.APITokenInput{Name: "script", Scope: models.APITokenScopeReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteUser(owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.ResolveAPIToken(tok.Token); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected token to go with its owner, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// preMigrationBackupPrefix names the backups Migrate takes before applying
+// migrations to an existing database. The newest keepBackups of each kind
+// are kept.
+const (
+	preMigrationBackupPrefix = "pre-migrate-"
+	keepBackups              = 5
+)
+
+// WithBackupDir sets where Migrate writes a copy of the database before
+// applying migrations to it. Without one, Migrate takes no backup.
+func WithBackupDir(dir string) Option {
+	return func(s *Store) { s.backupDir = dir }
+}
+
+// BackupTo writes a consistent copy of the database to path. VACUUM INTO
+// reads in a single transaction, so it's safe while the database is in use.
+func (s *Store) BackupTo(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup %s already exists", path)
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("backing up database: %w", err)
+	}
+	return nil
+}
+
+// backupBeforeMigrating copies the database at schema version from into the
+// backup directory, so a failed upgrade or a later rollback to the previous
+// release has something to restore. It returns the backup's path.
+func (s *Store) backupBeforeMigrating(from, to int) (string, error) {
+	if err := os.MkdirAll(s.backupDir, 0700); err != nil {
+		return "", fmt.Errorf("creating backup dir: %w", err)
+	}
+	name := fmt.Sprintf("%sv%03d-to-v%03d-%s.db", preMigrationBackupPrefix, from, to, time.Now().UTC().Format("20060102T150405Z"))
+	path := filepath.Join(s.backupDir, name)
+	if err := s.BackupTo(context.Background(), path); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", fmt.Errorf("restricting backup permissions: %w", err)
+	}
+	s.pruneBackups(preMigrationBackupPrefix)
+	return path, nil
+}
+
+// pruneBackups removes all but the newest backups named with prefix.
+// Names sort by time, and failures only cost disk space.
+func (s *Store) pruneBackups(prefix string) {
+	entries, err := os.ReadDir(s.backupDir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(backupTimestamp(a), backupTimestamp(b))
+	})
+	for len(names) > keepBackups {
+		if err := os.Remove(filepath.Join(s.backupDir, names[0])); err != nil {
+			log.Printf("removing old backup %s: %v", names[0], err)
+		}
+		names = names[1:]
+	}
+}
+
+func backupTimestamp(name string) string {
+	name = strings.TrimSuffix(name, ".db")
+	return name[strings.LastIndex(name, "-")+1:]
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// bandwidthBytesExpr estimates bytes transferred by a history row: bandwidth
+// is stored in bits per second and watched_ms is actual play time, so the
+// product over 8000 is bytes. Rows without a recorded bitrate count as zero.
+const bandwidthBytesExpr = `bandwidth * watched_ms / 8000`
+
+// MonthStart returns midnight UTC on the first day of t's month, the
+// boundary used for monthly bandwidth accounting.
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetUserBandwidthSince returns the estimated bytes a user has transferred
+// in history rows started at or after since.
+func (s *Store) GetUserBandwidthSince(userName string, since time.Time) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(`+bandwidthBytesExpr+`), 0) FROM watch_history
+		 WHERE user_name = ? AND started_at >= ? AND bandwidth > 0`,
+		userName, since.UTC(),
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("user bandwidth: %w", err)
+	}
+	return total, nil
+}
+
+// UserActivitySince returns a user's history rows started at or after
+// since, oldest first, with the country their address resolved to and
+// their estimated transfer.
+func (s *Store) UserActivitySince(ctx context.Context, userName string, since time.Time) ([]models.UserActivity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT h.started_at, COALESCE(g.country, ''), CASE WHEN h.bandwidth > 0 THEN h.bandwidth * h.watched_ms / 8000 ELSE 0 END
+		 FROM watch_history h
+		 LEFT JOIN ip_geo_cache g ON h.ip_address = g.ip
+		 WHERE h.user_name = ? AND h.started_at >= ?
+		 ORDER BY h.started_at`,
+		userName, since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("user activity: %w", err)
+	}
+	defer rows.Close()
+	var activity []models.UserActivity
+	for rows.Next() {
+		var a models.UserActivity
+		if err := rows.Scan(&a.StartedAt, &a.Country, &a.Bytes); err != nil {
+			return nil, fmt.Errorf("scanning user activity: %w", err)
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// UserMonthlyBandwidth returns a user's estimated transfer per calendar month
+// for the last `months` months, oldest first. Months without any recorded
+// bitrate are omitted.
+func (s *Store) UserMonthlyBandwidth(ctx context.Context, userName string, months int) ([]models.MonthlyBandwidth, error) {
+	return s.userMonthlyBandwidth(ctx, NameScope(userName), months)
+}
+
+func (s *Store) userMonthlyBandwidth(ctx context.Context, scope UserScope, months int) ([]models.MonthlyBandwidth, error) {
+	since := MonthStart(time.Now().UTC()).AddDate(0, -(months - 1), 0)
+	userCond, args := scope.condition("")
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT strftime('%Y-%m', started_at) AS month, SUM(`+bandwidthBytesExpr+`) AS bytes
+		 FROM watch_history
+		 WHERE `+userCond+` AND started_at >= ? AND bandwidth > 0
+		 GROUP BY month
+		 ORDER BY month`,
+		append(args, since)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("user monthly bandwidth: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.MonthlyBandwidth{}
+	for rows.Next() {
+		var mb models.MonthlyBandwidth
+		if err := rows.Scan(&mb.Month, &mb.Bytes); err != nil {
+			return nil, fmt.Errorf("scanning monthly bandwidth: %w", err)
+		}
+		result = append(result, mb)
+	}
+	return result, rows.Err()
+}
+
+// RecordBandwidth adds samples taken at at to their hourly buckets.
+func (s *Store) RecordBandwidth(ctx context.Context, at time.Time, samples []models.BandwidthSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	bucket := at.UTC().Truncate(time.Hour)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO bandwidth_samples (bucket, server_id, user_name, network, bytes, peak_bps)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bucket, server_id, user_name, network) DO UPDATE SET
+			bytes = bytes + excluded.bytes,
+			peak_bps = MAX(peak_bps, excluded.peak_bps)`)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, b := range samples {
+		if _, err := stmt.ExecContext(ctx, bucket, b.ServerID, b.UserName, b.Network, b.Bytes, b.PeakBps); err != nil {
+			return fmt.Errorf("record bandwidth: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// bandwidthConditions applies the filter's range and servers to the
+// bandwidth_samples hour buckets.
+func bandwidthConditions(f StatsFilter, alias string) (string, []any) {
+	col := "bucket"
+	if alias != "" {
+		col = alias + ".bucket"
+	}
+	var clauses []string
+	var args []any
+	if !f.StartDate.IsZero() && !f.EndDate.IsZero() {
+		clauses = append(clauses, col+" >= ? AND "+col+" < ?")
+		args = append(args, f.StartDate.UTC(), f.EndDate.UTC())
+	} else if cutoff := cutoffTime(f.Days); !cutoff.IsZero() {
+		clauses = append(clauses, col+" >= ?")
+		args = append(args, cutoff.Truncate(time.Hour))
+	}
+	if cond, sargs := f.serverConditionWith(alias); cond != "" {
+		clauses = append(clauses, cond)
+		args = append(args, sargs...)
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// BandwidthStats totals recorded bandwidth over the filter's range as a
+// series of hourly or daily buckets in the filter's timezone, and per server
+// and per user. Users are ordered by internet traffic.
+func (s *Store) BandwidthStats(ctx context.Context, filter StatsFilter, interval models.BandwidthInterval) (*models.BandwidthStats, error) {
+	format, seconds := "%Y-%m-%d %H:00", int64(3600)
+	if interval == models.BandwidthDaily {
+		format, seconds = "%Y-%m-%d", 86400
+	}
+	stats := &models.BandwidthStats{
+		Interval: interval,
+		Series:   []models.BandwidthPoint{},
+		Servers:  []models.BandwidthServerStat{},
+		Users:    []models.BandwidthUserStat{},
+	}
+
+	where, filterArgs := bandwidthConditions(filter, "")
+	bucketExpr := "strftime('" + format + "', bucket)"
+	var args []any
+	if mod, ok := tzModifier(filter.TZOffsetMinutes); ok {
+		bucketExpr = "strftime('" + format + "', bucket, ?)"
+		args = append(args, mod)
+	}
+	args = append(args, filterArgs...)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+bucketExpr+` AS b, SUM(bytes),
+			SUM(CASE WHEN network = 'lan' THEN bytes ELSE 0 END),
+			SUM(CASE WHEN network = 'wan' THEN bytes ELSE 0 END)
+		FROM bandwidth_samples`+where+`
+		GROUP BY b ORDER BY b`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("bandwidth series: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.BandwidthPoint
+		if err := rows.Scan(&p.Bucket, &p.Bytes, &p.LANBytes, &p.WANBytes); err != nil {
+			return nil, fmt.Errorf("scanning bandwidth series: %w", err)
+		}
+		p.AvgBps = p.Bytes * 8 / seconds
+		stats.LANBytes += p.LANBytes
+		stats.WANBytes += p.WANBytes
+		stats.Series = append(stats.Series, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating bandwidth series: %w", err)
+	}
+
+	where, filterArgs = bandwidthConditions(filter, "b")
+	rows, err = s.db.QueryContext(ctx,
+		`SELECT b.server_id, COALESCE(s.name, ''), SUM(b.bytes),
+			SUM(CASE WHEN b.network = 'wan' THEN b.bytes ELSE 0 END)
+		FROM bandwidth_samples b
+		LEFT JOIN servers s ON s.id = b.server_id`+where+`
+		GROUP BY b.server_id ORDER BY SUM(b.bytes) DESC`, filterArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("bandwidth by server: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var st models.BandwidthServerStat
+		if err := rows.Scan(&st.ServerID, &st.ServerName, &st.Bytes, &st.WANBytes); err != nil {
+			return nil, fmt.Errorf("scanning bandwidth by server: %w", err)
+		}
+		stats.Servers = append(stats.Servers, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating bandwidth by server: %w", err)
+	}
+
+	where, filterArgs = bandwidthConditions(filter, "")
+	rows, err = s.db.QueryContext(ctx,
+		`SELECT user_name, SUM(bytes),
+			SUM(CASE WHEN network = 'wan' THEN bytes ELSE 0 END) AS wan,
+			MAX(CASE WHEN network = 'wan' THEN peak_bps ELSE 0 END)
+		FROM bandwidth_samples`+where+`
+		GROUP BY user_name ORDER BY wan DESC, SUM(bytes) DESC`, filterArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("bandwidth by user: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u models.BandwidthUserStat
+		if err := rows.Scan(&u.UserName, &u.Bytes, &u.WANBytes, &u.PeakWANBps); err != nil {
+			return nil, fmt.Errorf("scanning bandwidth by user: %w", err)
+		}
+		stats.Users = append(stats.Users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating bandwidth by user: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestUserBandwidth(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+
+	thisMonth := MonthStart(time.Now().UTC()).Add(time.Hour)
+	lastMonth := MonthStart(time.Now().UTC()).AddDate(0, -1, 0).Add(time.Hour)
+
+	// 8 Mbps for one hour = 3.6 GB.
+	for _, tc := range []struct {
+		user  string
+		title string
+		at    time.Time
+		bw    int64
+	}{
+		{"alice", "A", thisMonth, 8_000_000},
+		{"alice", "B", thisMonth.Add(3 * time.Hour), 8_000_000},
+		{"alice", "C", lastMonth, 8_000_000},
+		{"alice", "D", thisMonth.Add(6 * time.Hour), 0},
+		{"bob", "E", thisMonth, 8_000_000},
+	} {
+		e := makeHistoryEntry(serverID, tc.user, tc.title, tc.at)
+		e.StoppedAt = tc.at.Add(time.Hour)
+		e.WatchedMs = int64(time.Hour / time.Millisecond)
+		e.Bandwidth = tc.bw
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.GetUserBandwidthSince("alice", MonthStart(time.Now().UTC()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 7_200_000_000 {
+		t.Errorf("this month = %d, want 7.2e9", got)
+	}
+
+	months, err := s.UserMonthlyBandwidth(context.Background(), "alice", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 2 {
+		t.Fatalf("months = %+v, want 2 entries", months)
+	}
+	if months[0].Month != lastMonth.Format("2006-01") || months[0].Bytes != 3_600_000_000 {
+		t.Errorf("first month = %+v", months[0])
+	}
+	if months[1].Month != thisMonth.Format("2006-01") || months[1].Bytes != 7_200_000_000 {
+		t.Errorf("second month = %+v", months[1])
+	}
+
+	stats, err := s.UserDetailStats(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Bandwidth) != 2 {
+		t.Errorf("UserDetailStats bandwidth = %+v, want 2 months", stats.Bandwidth)
+	}
+}
+
+func TestMonthStart(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*3600)
+	// 2026-03-01 05:00 in UTC+10 is still February in UTC.
+	got := MonthStart(time.Date(2026, 3, 1, 5, 0, 0, 0, loc))
+	want := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("MonthStart = %v, want %v", got, want)
+	}
+}
+
+func TestBandwidthStats(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	at := time.Date(2026, 3, 2, 22, 10, 0, 0, time.UTC)
+	record := func(at time.Time, samples ...models.BandwidthSample) {
+		t.Helper()
+		if err := s.RecordBandwidth(ctx, at, samples); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(at,
+		models.BandwidthSample{ServerID: serverID, UserName: "alice", Network: models.BandwidthWAN, Bytes: 1000, PeakBps: 8000},
+		models.BandwidthSample{ServerID: serverID, UserName: "bob", Network: models.BandwidthLAN, Bytes: 5000, PeakBps: 20000})
+	record(at.Add(20*time.Minute),
+		models.BandwidthSample{ServerID: serverID, UserName: "alice", Network: models.BandwidthWAN, Bytes: 2000, PeakBps: 16000})
+	record(at.Add(3*time.Hour),
+		models.BandwidthSample{ServerID: serverID, UserName: "alice", Network: models.BandwidthWAN, Bytes: 400, PeakBps: 4000})
+
+	filter := StatsFilter{StartDate: at.Add(-time.Hour), EndDate: at.Add(4 * time.Hour)}
+	hourly, err := s.BandwidthStats(ctx, filter, models.BandwidthHourly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly.Series) != 2 || hourly.Series[0].Bucket != "2026-03-02 22:00" ||
+		hourly.Series[0].Bytes != 8000 || hourly.Series[0].WANBytes != 3000 || hourly.Series[0].AvgBps != 8000*8/3600 {
+		t.Errorf("hourly series = %+v", hourly.Series)
+	}
+	if hourly.LANBytes != 5000 || hourly.WANBytes != 3400 {
+		t.Errorf("totals = %d lan, %d wan", hourly.LANBytes, hourly.WANBytes)
+	}
+	if len(hourly.Users) != 2 || hourly.Users[0].UserName != "alice" || hourly.Users[0].PeakWANBps != 16000 || hourly.Users[1].PeakWANBps != 0 {
+		t.Errorf("users = %+v", hourly.Users)
+	}
+	if len(hourly.Servers) != 1 || hourly.Servers[0].ServerName != "Test" || hourly.Servers[0].Bytes != 8400 {
+		t.Errorf("servers = %+v", hourly.Servers)
+	}
+
+	// At UTC+3 the 22:00 bucket falls on the next day, the later one too.
+	filter.TZOffsetMinutes = 180
+	daily, err := s.BandwidthStats(ctx, filter, models.BandwidthDaily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily.Series) != 1 || daily.Series[0].Bucket != "2026-03-03" || daily.Series[0].Bytes != 8400 {
+		t.Errorf("daily series = %+v", daily.Series)
+	}
+}
+
+func TestUserActivitySince(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	if err := s.SetCachedGeo(&models.GeoResult{IP: "203.0.113.5", Country: "US"}); err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		at time.Time
+		ip string
+		bw int64
+	}{
+		{now.Add(-48 * time.Hour), "203.0.113.5", 8_000_000},
+		{now.Add(-3 * time.Hour), "203.0.113.5", 8_000_000},
+		{now.Add(-time.Hour), "198.51.100.1", 0},
+	} {
+		e := makeHistoryEntry(serverID, "alice", string(rune('A'+i)), tc.at)
+		e.WatchedMs = int64(time.Hour / time.Millisecond)
+		e.IPAddress = tc.ip
+		e.Bandwidth = tc.bw
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	activity, err := s.UserActivitySince(context.Background(), "alice", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 2 {
+		t.Fatalf("expected 2 sessions in the last day, got %+v", activity)
+	}
+	if activity[0].Country != "US" || activity[0].Bytes != 3_600_000_000 {
+		t.Errorf("first = %+v, want US and 3.6e9 bytes", activity[0])
+	}
+	if activity[1].Country != "" || activity[1].Bytes != 0 {
+		t.Errorf("second = %+v, want no country and no transfer", activity[1])
+	}
+}
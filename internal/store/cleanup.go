@@ -1,49 +1,259 @@
 package store
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"strconv"
+	"time"
 )
 
-const zombieCleanupKey = "migration.zombie_cleanup_done"
+const (
+	zombieEnabledKey    = "zombie.enabled"
+	zombieMaxAgeKey     = "zombie.max_session_age_hours"
+	zombieMaxSilenceKey = "zombie.max_silence_minutes"
+	zombieLastReportKey = "zombie.last_report"
 
-// CleanupZombieSessions caps stopped_at for zombie sessions where wall time
-// vastly exceeds actual watched time. Runs once, guarded by a setting flag.
-func (s *Store) CleanupZombieSessions() error {
-	done, err := s.GetSetting(zombieCleanupKey)
+	DefaultZombieMaxSessionAgeHours = 24
+	DefaultZombieMaxSilenceMinutes  = 240
+
+	MaxZombieSessionAgeHours = 24 * 7
+	MaxZombieSilenceMinutes  = 24 * 60
+
+	// MinZombieSilenceMinutes keeps the silence threshold above the grace
+	// period a capped session is given, so a fixed row never matches again.
+	MinZombieSilenceMinutes = 10
+
+	zombieStopGrace = 5 * time.Minute
+
+	// zombieReportLimit bounds how many fixed sessions are kept in the
+	// stored report; Closed still reflects the full count.
+	zombieReportLimit = 100
+)
+
+const (
+	ZombieReasonMaxAge  = "max_age"
+	ZombieReasonSilence = "silence"
+)
+
+// ZombieSettings are the thresholds a history row is checked against.
+// A zero value disables that criterion.
+type ZombieSettings struct {
+	// Enabled turns on the scheduler's hourly cleanup. It's off by default
+	// since the cleanup rewrites history; a manual run works either way.
+	Enabled bool `json:"enabled"`
+	// MaxSessionAgeHours caps total wall time from start to stop.
+	MaxSessionAgeHours int `json:"max_session_age_hours"`
+	// MaxSilenceMinutes caps wall time not accounted for by watched or
+	// reported paused time, i.e. how long a session may go unreported.
+	MaxSilenceMinutes int `json:"max_silence_minutes"`
+}
+
+func (z ZombieSettings) Validate() error {
+	if z.MaxSessionAgeHours < 0 || z.MaxSessionAgeHours > MaxZombieSessionAgeHours {
+		return fmt.Errorf("max_session_age_hours must be between 0 and %d", MaxZombieSessionAgeHours)
+	}
+	if z.MaxSilenceMinutes != 0 && (z.MaxSilenceMinutes < MinZombieSilenceMinutes || z.MaxSilenceMinutes > MaxZombieSilenceMinutes) {
+		return fmt.Errorf("max_silence_minutes must be 0 or between %d and %d", MinZombieSilenceMinutes, MaxZombieSilenceMinutes)
+	}
+	return nil
+}
+
+// ZombieSessionFix describes one history row whose stop time was pulled in.
+type ZombieSessionFix struct {
+	HistoryID         int64     `json:"history_id"`
+	UserName          string    `json:"user_name"`
+	Title             string    `json:"title"`
+	StartedAt         time.Time `json:"started_at"`
+	PreviousStoppedAt time.Time `json:"previous_stopped_at"`
+	StoppedAt         time.Time `json:"stopped_at"`
+	Reason            string    `json:"reason"`
+}
+
+// ZombieCleanupReport summarises a cleanup run.
+type ZombieCleanupReport struct {
+	RanAt    time.Time          `json:"ran_at"`
+	Settings ZombieSettings     `json:"settings"`
+	Closed   int                `json:"closed"`
+	ByReason map[string]int     `json:"by_reason"`
+	Sessions []ZombieSessionFix `json:"sessions"`
+}
+
+func (s *Store) getIntSetting(key string, def int) (int, error) {
+	val, err := s.GetSetting(key)
 	if err != nil {
-		return fmt.Errorf("checking zombie cleanup flag: %w", err)
+		return 0, err
+	}
+	if val == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return def, nil
+	}
+	return n, nil
+}
+
+func (s *Store) GetZombieSettings() (ZombieSettings, error) {
+	var z ZombieSettings
+	enabled, err := s.GetSetting(zombieEnabledKey)
+	if err != nil {
+		return z, err
+	}
+	z.Enabled = enabled == "true"
+	if z.MaxSessionAgeHours, err = s.getIntSetting(zombieMaxAgeKey, DefaultZombieMaxSessionAgeHours); err != nil {
+		return z, err
 	}
-	if done == "1" {
-		return nil
+	if z.MaxSilenceMinutes, err = s.getIntSetting(zombieMaxSilenceKey, DefaultZombieMaxSilenceMinutes); err != nil {
+		return z, err
 	}
+	return z, nil
+}
 
-	result, err := s.db.Exec(`
-		UPDATE watch_history
-		SET stopped_at = datetime(started_at, '+' || (watched_ms / 1000 + 300) || ' seconds')
-		WHERE (julianday(stopped_at) - julianday(started_at)) * 86400 > watched_ms / 1000 * 5 + 3600
-		  AND watched_ms > 0
-	`)
+func (s *Store) SetZombieSettings(z ZombieSettings) error {
+	if err := z.Validate(); err != nil {
+		return err
+	}
+	if err := s.SetSetting(zombieEnabledKey, strconv.FormatBool(z.Enabled)); err != nil {
+		return err
+	}
+	if err := s.SetSetting(zombieMaxAgeKey, strconv.Itoa(z.MaxSessionAgeHours)); err != nil {
+		return err
+	}
+	return s.SetSetting(zombieMaxSilenceKey, strconv.Itoa(z.MaxSilenceMinutes))
+}
+
+// GetLastZombieReport returns the most recent cleanup report, or nil if
+// cleanup has never run.
+func (s *Store) GetLastZombieReport() (*ZombieCleanupReport, error) {
+	val, err := s.GetSetting(zombieLastReportKey)
+	if err != nil || val == "" {
+		return nil, err
+	}
+	var report ZombieCleanupReport
+	if err := json.Unmarshal([]byte(val), &report); err != nil {
+		return nil, fmt.Errorf("parsing zombie report: %w", err)
+	}
+	return &report, nil
+}
+
+// CleanupZombieSessions pulls in stopped_at for history rows whose wall time
+// exceeds the configured thresholds -- typically sessions the media server
+// never reported as stopped. Reported paused time counts as activity, so a
+// long pause isn't mistaken for silence. A row is capped at its watched and
+// paused time plus a short grace period, and never beyond the max session
+// age. The report is stored
+// and returned; rows already within the thresholds are left untouched, so
+// repeated runs are no-ops.
+func (s *Store) CleanupZombieSessions(ctx context.Context) (*ZombieCleanupReport, error) {
+	settings, err := s.GetZombieSettings()
 	if err != nil {
-		return fmt.Errorf("cleaning zombie sessions: %w", err)
+		return nil, err
+	}
+	report := &ZombieCleanupReport{
+		RanAt:    time.Now().UTC(),
+		Settings: settings,
+		ByReason: map[string]int{},
+		Sessions: []ZombieSessionFix{},
+	}
+
+	maxAge := time.Duration(settings.MaxSessionAgeHours) * time.Hour
+	maxSilence := time.Duration(settings.MaxSilenceMinutes) * time.Minute
+	if maxAge == 0 && maxSilence == 0 {
+		return report, s.saveZombieReport(report)
 	}
 
-	// Also fix zero-progress zombies: sessions with no watched time but lingering for > 1 hour
-	resultZero, err := s.db.Exec(`
-		UPDATE watch_history
-		SET stopped_at = started_at
-		WHERE watched_ms = 0
-		  AND (julianday(stopped_at) - julianday(started_at)) * 86400 > 3600
-	`)
+	const wallSeconds = `(julianday(stopped_at) - julianday(started_at)) * 86400`
+	var conds []string
+	var args []any
+	if maxAge > 0 {
+		conds = append(conds, wallSeconds+` > ?`)
+		args = append(args, maxAge.Seconds())
+	}
+	if maxSilence > 0 {
+		conds = append(conds, wallSeconds+` - (watched_ms + paused_ms) / 1000.0 > ?`)
+		args = append(args, maxSilence.Seconds())
+	}
+	where := conds[0]
+	if len(conds) == 2 {
+		where = conds[0] + ` OR ` + conds[1]
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, user_name, title, started_at, stopped_at, watched_ms, paused_ms
+		 FROM watch_history WHERE `+where+` ORDER BY id`, args...)
 	if err != nil {
-		return fmt.Errorf("cleaning zero-progress zombie sessions: %w", err)
+		return nil, fmt.Errorf("finding zombie sessions: %w", err)
+	}
+	var fixes []ZombieSessionFix
+	for rows.Next() {
+		var f ZombieSessionFix
+		var watchedMs, pausedMs int64
+		if err := rows.Scan(&f.HistoryID, &f.UserName, &f.Title, &f.StartedAt, &f.PreviousStoppedAt, &watchedMs, &pausedMs); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning zombie session: %w", err)
+		}
+		active := time.Duration(watchedMs+pausedMs) * time.Millisecond
+		newStop := f.StartedAt.Add(active)
+		if active > 0 {
+			newStop = newStop.Add(zombieStopGrace)
+		}
+		f.Reason = ZombieReasonSilence
+		if maxAge > 0 && f.PreviousStoppedAt.Sub(f.StartedAt) > maxAge {
+			f.Reason = ZombieReasonMaxAge
+			if limit := f.StartedAt.Add(maxAge); newStop.After(limit) {
+				newStop = limit
+			}
+		}
+		if !newStop.Before(f.PreviousStoppedAt) {
+			continue
+		}
+		f.StoppedAt = newStop
+		fixes = append(fixes, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating zombie sessions: %w", err)
 	}
 
-	fixed, _ := result.RowsAffected()
-	fixedZero, _ := resultZero.RowsAffected()
-	if total := fixed + fixedZero; total > 0 {
-		log.Printf("zombie cleanup: fixed %d sessions with inflated wall times (%d zero-progress)", total, fixedZero)
+	if len(fixes) > 0 {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("begin tx: %w", err)
+		}
+		defer tx.Rollback()
+		stmt, err := tx.PrepareContext(ctx, `UPDATE watch_history SET stopped_at = ? WHERE id = ?`)
+		if err != nil {
+			return nil, fmt.Errorf("preparing zombie update: %w", err)
+		}
+		defer stmt.Close()
+		for _, f := range fixes {
+			if _, err := stmt.ExecContext(ctx, f.StoppedAt, f.HistoryID); err != nil {
+				return nil, fmt.Errorf("closing zombie session %d: %w", f.HistoryID, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit: %w", err)
+		}
 	}
 
-	return s.SetSetting(zombieCleanupKey, "1")
+	report.Closed = len(fixes)
+	for _, f := range fixes {
+		report.ByReason[f.Reason]++
+	}
+	if len(fixes) > zombieReportLimit {
+		fixes = fixes[:zombieReportLimit]
+	}
+	if fixes != nil {
+		report.Sessions = fixes
+	}
+	return report, s.saveZombieReport(report)
+}
+
+func (s *Store) saveZombieReport(report *ZombieCleanupReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("marshaling zombie report: %w", err)
+	}
+	return s.SetSetting(zombieLastReportKey, string(data))
 }
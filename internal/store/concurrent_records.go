@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const (
+	concurrentRecordKey        = "concurrent_record.all_time"
+	concurrentRollingRecordKey = "concurrent_record.last_30_days"
+	concurrentRecordNotifyKey  = "concurrent_record.notify"
+
+	concurrentRecordRollingDays = 30
+)
+
+// ConcurrentRecords returns the all-time and rolling-30-day concurrent stream
+// records. Both are derived from watch_history and then raised to the live
+// records the poller has observed, which also cover sessions that have not
+// been written to history yet. When serverIDs isn't empty the records only
+// cover those servers and come from history alone, since the live records
+// span every server.
+func (s *Store) ConcurrentRecords(ctx context.Context, serverIDs []int64) (models.ConcurrentRecords, error) {
+	var res models.ConcurrentRecords
+	now := time.Now().UTC()
+
+	allTime, err := s.historyConcurrentRecord(ctx, StatsFilter{
+		StartDate: time.Unix(0, 0).UTC(),
+		EndDate:   now.Add(24 * time.Hour),
+		ServerIDs: serverIDs,
+	})
+	if err != nil {
+		return res, err
+	}
+	rolling, err := s.historyConcurrentRecord(ctx, StatsFilter{Days: concurrentRecordRollingDays, ServerIDs: serverIDs})
+	if err != nil {
+		return res, err
+	}
+	if len(serverIDs) > 0 {
+		res.AllTime = allTime
+		res.Last30Days = rolling
+		res.Notify, err = s.GetConcurrentRecordNotify()
+		return res, err
+	}
+
+	live, err := s.getLiveConcurrentRecord(concurrentRecordKey)
+	if err != nil {
+		return res, err
+	}
+	liveRolling, err := s.getLiveConcurrentRecord(concurrentRollingRecordKey)
+	if err != nil {
+		return res, err
+	}
+	if live.Count > allTime.Count {
+		allTime = live
+	}
+	for _, rec := range []models.ConcurrentRecord{live, liveRolling} {
+		if rec.Count > rolling.Count && inRollingWindow(rec, now) {
+			rolling = rec
+		}
+	}
+
+	res.AllTime = allTime
+	res.Last30Days = rolling
+	res.Notify, err = s.GetConcurrentRecordNotify()
+	return res, err
+}
+
+// ObserveConcurrentStreams compares a live concurrent stream count against
+// the stored all-time and rolling 30-day records and persists whichever it
+// beats. A record only counts as new when a previous one existed; the first
+// observation is seeded from history so upgrading installs don't announce a
+// "record" that history already exceeds. The rolling record is re-derived
+// from history once it ages out of the window.
+func (s *Store) ObserveConcurrentStreams(ctx context.Context, count int, at time.Time) (models.ConcurrentRecordUpdate, error) {
+	var upd models.ConcurrentRecordUpdate
+	rec := models.ConcurrentRecord{Count: count, At: at.UTC().Format(time.RFC3339)}
+
+	val, err := s.GetSetting(concurrentRecordKey)
+	if err != nil {
+		return upd, err
+	}
+	if val == "" {
+		upd.AllTime, err = s.historyConcurrentRecord(ctx, StatsFilter{
+			StartDate: time.Unix(0, 0).UTC(),
+			EndDate:   at.Add(24 * time.Hour),
+		})
+		if err != nil {
+			return upd, err
+		}
+		if err := s.setLiveConcurrentRecord(concurrentRecordKey, upd.AllTime); err != nil {
+			return upd, err
+		}
+	} else if err := json.Unmarshal([]byte(val), &upd.AllTime); err != nil {
+		return upd, fmt.Errorf("parsing concurrent record: %w", err)
+	}
+
+	upd.Last30Days, err = s.getLiveConcurrentRecord(concurrentRollingRecordKey)
+	if err != nil {
+		return upd, err
+	}
+	if !inRollingWindow(upd.Last30Days, at) {
+		upd.Last30Days, err = s.historyConcurrentRecord(ctx, StatsFilter{
+			StartDate: at.AddDate(0, 0, -concurrentRecordRollingDays),
+			EndDate:   at.Add(24 * time.Hour),
+		})
+		if err != nil {
+			return upd, err
+		}
+		// An empty window still gets a timestamp, so it isn't re-derived
+		// on every poll.
+		if upd.Last30Days.At == "" {
+			upd.Last30Days.At = rec.At
+		}
+		if err := s.setLiveConcurrentRecord(concurrentRollingRecordKey, upd.Last30Days); err != nil {
+			return upd, err
+		}
+	}
+
+	if count > upd.AllTime.Count {
+		if err := s.setLiveConcurrentRecord(concurrentRecordKey, rec); err != nil {
+			return upd, err
+		}
+		upd.NewAllTime = upd.AllTime.Count > 0
+	}
+	if count > upd.Last30Days.Count {
+		if err := s.setLiveConcurrentRecord(concurrentRollingRecordKey, rec); err != nil {
+			return upd, err
+		}
+		upd.NewLast30Days = upd.Last30Days.Count > 0
+	}
+	return upd, nil
+}
+
+// inRollingWindow reports whether rec was set within the rolling window
+// ending at now.
+func inRollingWindow(rec models.ConcurrentRecord, now time.Time) bool {
+	at, err := time.Parse(time.RFC3339, rec.At)
+	return err == nil && !at.Before(now.AddDate(0, 0, -concurrentRecordRollingDays))
+}
+
+func (s *Store) historyConcurrentRecord(ctx context.Context, filter StatsFilter) (models.ConcurrentRecord, error) {
+	_, peaks, err := s.ConcurrentStats(ctx, filter)
+	if err != nil {
+		return models.ConcurrentRecord{}, err
+	}
+	return models.ConcurrentRecord{Count: peaks.Total, At: peaks.PeakAt}, nil
+}
+
+func (s *Store) getLiveConcurrentRecord(key string) (models.ConcurrentRecord, error) {
+	var rec models.ConcurrentRecord
+	val, err := s.GetSetting(key)
+	if err != nil || val == "" {
+		return rec, err
+	}
+	if err := json.Unmarshal([]byte(val), &rec); err != nil {
+		return rec, fmt.Errorf("parsing concurrent record: %w", err)
+	}
+	return rec, nil
+}
+
+func (s *Store) setLiveConcurrentRecord(key string, rec models.ConcurrentRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshaling concurrent record: %w", err)
+	}
+	return s.SetSetting(key, string(data))
+}
+
+func (s *Store) GetConcurrentRecordNotify() (bool, error) {
+	val, err := s.GetSetting(concurrentRecordNotifyKey)
+	if err != nil {
+		return false, err
+	}
+	return val == "true", nil
+}
+
+func (s *Store) SetConcurrentRecordNotify(enabled bool) error {
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return s.SetSetting(concurrentRecordNotifyKey, val)
+}
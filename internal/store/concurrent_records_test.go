@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestObserveConcurrentStreams(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	// History already holds a peak of 2 concurrent streams.
+	start := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Second)
+	for i, user := range []string{"alice", "bob"} {
+		e := makeHistoryEntry(serverID, user, "Movie", start.Add(time.Duration(i)*time.Minute))
+		e.StoppedAt = start.Add(time.Hour)
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+
+	// First observation seeds from history; 1 is below the seeded record.
+	upd, err := s.ObserveConcurrentStreams(ctx, 1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upd.NewAllTime || upd.NewLast30Days || upd.AllTime.Count != 2 || upd.Last30Days.Count != 2 {
+		t.Fatalf("seed: %+v, want no new records against 2/2", upd)
+	}
+
+	upd, err = s.ObserveConcurrentStreams(ctx, 3, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upd.NewAllTime || !upd.NewLast30Days || upd.AllTime.Count != 2 {
+		t.Fatalf("record: %+v, want both new over 2", upd)
+	}
+
+	if upd, _ = s.ObserveConcurrentStreams(ctx, 3, now); upd.NewAllTime || upd.NewLast30Days {
+		t.Fatal("tying the record should not count as new")
+	}
+
+	recs, err := s.ConcurrentRecords(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs.AllTime.Count != 3 || recs.Last30Days.Count != 3 {
+		t.Fatalf("records = %+v, want 3/3", recs)
+	}
+	if recs.AllTime.At != now.Format(time.RFC3339) {
+		t.Errorf("all-time at = %q, want %q", recs.AllTime.At, now.Format(time.RFC3339))
+	}
+}
+
+func TestObserveConcurrentStreams_EmptyHistoryNotNew(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	upd, err := s.ObserveConcurrentStreams(ctx, 1, time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upd.NewAllTime || upd.NewLast30Days {
+		t.Fatal("first ever stream should not be announced as a record")
+	}
+	upd, err = s.ObserveConcurrentStreams(ctx, 2, time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upd.NewAllTime || !upd.NewLast30Days {
+		t.Fatal("beating an established record should be new")
+	}
+}
+
+func TestConcurrentRecords_RollingWindow(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	old := time.Now().UTC().AddDate(0, 0, -60)
+	for i, user := range []string{"alice", "bob", "carol"} {
+		e := makeHistoryEntry(serverID, user, "Old", old.Add(time.Duration(i)*time.Minute))
+		e.StoppedAt = old.Add(time.Hour)
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recent := time.Now().UTC().AddDate(0, 0, -2)
+	e := makeHistoryEntry(serverID, "alice", "Recent", recent)
+	e.StoppedAt = recent.Add(time.Hour)
+	e.WatchedMs = e.DurationMs
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err := s.ConcurrentRecords(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs.AllTime.Count != 3 {
+		t.Errorf("all-time = %d, want 3", recs.AllTime.Count)
+	}
+	if recs.Last30Days.Count != 1 {
+		t.Errorf("last 30 days = %d, want 1", recs.Last30Days.Count)
+	}
+	if recs.Notify {
+		t.Error("notify should default to false")
+	}
+
+	if err := s.SetConcurrentRecordNotify(true); err != nil {
+		t.Fatal(err)
+	}
+	if on, _ := s.GetConcurrentRecordNotify(); !on {
+		t.Error("notify not persisted")
+	}
+}
+
+func TestObserveConcurrentStreams_RollingRecord(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	// Three at once two months ago, one stream last week.
+	old := time.Now().UTC().AddDate(0, 0, -60)
+	for i, user := range []string{"alice", "bob", "carol"} {
+		e := makeHistoryEntry(serverID, user, "Old", old.Add(time.Duration(i)*time.Minute))
+		e.StoppedAt = old.Add(time.Hour)
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recent := time.Now().UTC().AddDate(0, 0, -7)
+	e := makeHistoryEntry(serverID, "alice", "Recent", recent)
+	e.StoppedAt = recent.Add(time.Hour)
+	e.WatchedMs = e.DurationMs
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+
+	upd, err := s.ObserveConcurrentStreams(ctx, 2, time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upd.NewAllTime || !upd.NewLast30Days || upd.Last30Days.Count != 1 {
+		t.Fatalf("got %+v, want only a new 30-day record over 1", upd)
+	}
+
+	recs, err := s.ConcurrentRecords(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs.AllTime.Count != 3 || recs.Last30Days.Count != 2 {
+		t.Errorf("records = %+v, want 3/2", recs)
+	}
+
+	// A stale rolling record is re-derived from history.
+	stale := models.ConcurrentRecord{Count: 5, At: old.Format(time.RFC3339)}
+	if err := s.setLiveConcurrentRecord(concurrentRollingRecordKey, stale); err != nil {
+		t.Fatal(err)
+	}
+	upd, err = s.ObserveConcurrentStreams(ctx, 2, time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upd.NewLast30Days || upd.Last30Days.Count != 1 {
+		t.Errorf("got %+v, want the expired record replaced by history's 1", upd)
+	}
+}
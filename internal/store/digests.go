@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// digestTopLimit is how many movies, shows, and users a digest lists.
+const digestTopLimit = 5
+
+// digestNewContentLimit is how many newly added titles a digest lists; the
+// rest only count towards NewContentCount.
+const digestNewContentLimit = 10
+
+const digestScheduleColumns = `id, name, period, channel_ids, personalized, enabled, last_sent_period, last_sent_at, created_at, updated_at`
+
+func scanDigestSchedule(scanner interface{ Scan(...any) error }) (models.DigestSchedule, error) {
+	var d models.DigestSchedule
+	var channelIDs string
+	var lastSent sql.NullTime
+	err := scanner.Scan(&d.ID, &d.Name, &d.Period, &channelIDs, &d.Personalized, &d.Enabled,
+		&d.LastSentPeriod, &lastSent, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal([]byte(channelIDs), &d.ChannelIDs); err != nil {
+		return d, fmt.Errorf("decoding digest channels: %w", err)
+	}
+	if lastSent.Valid {
+		d.LastSentAt = &lastSent.Time
+	}
+	return d, nil
+}
+
+func (s *Store) CreateDigestSchedule(d *models.DigestSchedule) error {
+	if err := d.Validate(); err != nil {
+		return fmt.Errorf("invalid digest: %w", err)
+	}
+	channelIDs, err := json.Marshal(d.ChannelIDs)
+	if err != nil {
+		return fmt.Errorf("encoding digest channels: %w", err)
+	}
+	err = s.db.QueryRow(`INSERT INTO digest_schedules (name, period, channel_ids, personalized, enabled) VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at, updated_at`,
+		d.Name, d.Period, string(channelIDs), d.Personalized, d.Enabled).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("creating digest: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetDigestSchedule(id int64) (*models.DigestSchedule, error) {
+	d, err := scanDigestSchedule(s.db.QueryRow(`SELECT `+digestScheduleColumns+` FROM digest_schedules WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting digest: %w", err)
+	}
+	return &d, nil
+}
+
+func (s *Store) ListDigestSchedules() ([]models.DigestSchedule, error) {
+	rows, err := s.db.Query(`SELECT ` + digestScheduleColumns + ` FROM digest_schedules ORDER BY name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing digests: %w", err)
+	}
+	defer rows.Close()
+	digests := []models.DigestSchedule{}
+	for rows.Next() {
+		d, err := scanDigestSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning digest: %w", err)
+		}
+		digests = append(digests, d)
+	}
+	return digests, rows.Err()
+}
+
+// UpdateDigestSchedule saves d's settings. The record of the last period
+// sent is kept, so changing a schedule doesn't resend a digest.
+func (s *Store) UpdateDigestSchedule(d *models.DigestSchedule) error {
+	if err := d.Validate(); err != nil {
+		return fmt.Errorf("invalid digest: %w", err)
+	}
+	channelIDs, err := json.Marshal(d.ChannelIDs)
+	if err != nil {
+		return fmt.Errorf("encoding digest channels: %w", err)
+	}
+	res, err := s.db.Exec(`UPDATE digest_schedules SET name = ?, period = ?, channel_ids = ?, personalized = ?, enabled = ?,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		d.Name, d.Period, string(channelIDs), d.Personalized, d.Enabled, d.ID)
+	if err != nil {
+		return fmt.Errorf("updating digest: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DeleteDigestSchedule(id int64) error {
+	res, err := s.db.Exec(`DELETE FROM digest_schedules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting digest: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDigestPeriod records that the digest for periodKey is going out. It
+// returns false when it already went, so a digest is sent once per period
+// even if the scheduler runs twice.
+func (s *Store) ClaimDigestPeriod(ctx context.Context, id int64, periodKey string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE digest_schedules SET last_sent_period = ?, last_sent_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND last_sent_period != ?`, periodKey, id, periodKey)
+	if err != nil {
+		return false, fmt.Errorf("claiming digest period: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claiming digest period: %w", err)
+	}
+	return n == 1, nil
+}
+
+// CompileDigest summarizes watching and library additions from start up to
+// end across all servers, with the same exclusions as the dashboard stats.
+// Start and End keep the zone they were given in, for display.
+func (s *Store) CompileDigest(ctx context.Context, period models.DigestPeriod, start, end time.Time) (*models.Digest, error) {
+	filter := StatsFilter{StartDate: start.UTC(), EndDate: end.UTC()}
+	d := &models.Digest{
+		Period:     period,
+		Start:      start,
+		End:        end,
+		NewContent: []models.DigestNewItem{},
+	}
+
+	where, args := filter.conditions()
+	var watchedMs int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(watched_ms), 0), COUNT(DISTINCT user_name) FROM watch_history`+where,
+		args...,
+	).Scan(&d.Plays, &watchedMs, &d.ActiveUsers)
+	if err != nil {
+		return nil, fmt.Errorf("digest totals: %w", err)
+	}
+	d.TotalHours = msToHours(watchedMs)
+
+	if d.TopMovies, err = s.TopMovies(ctx, digestTopLimit, filter); err != nil {
+		return nil, err
+	}
+	if d.TopShows, err = s.TopTVShows(ctx, digestTopLimit, filter); err != nil {
+		return nil, err
+	}
+	if d.TopUsers, err = s.TopUsers(ctx, digestTopLimit, filter); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM library_items WHERE added_at >= ? AND added_at < ?`, filter.StartDate, filter.EndDate,
+	).Scan(&d.NewContentCount)
+	if err != nil {
+		return nil, fmt.Errorf("digest new content count: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT li.title, li.year, li.media_type, s.name, li.added_at
+		FROM library_items li
+		JOIN servers s ON s.id = li.server_id
+		WHERE li.added_at >= ? AND li.added_at < ?
+		ORDER BY li.added_at DESC, li.id DESC
+		LIMIT ?`, filter.StartDate, filter.EndDate, digestNewContentLimit)
+	if err != nil {
+		return nil, fmt.Errorf("digest new content: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.DigestNewItem
+		if err := rows.Scan(&item.Title, &item.Year, &item.MediaType, &item.ServerName, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("scanning digest new content: %w", err)
+		}
+		d.NewContent = append(d.NewContent, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating digest new content: %w", err)
+	}
+	return d, nil
+}
+
+// ListDigestRecipients returns the media server users with an email address
+// who watched something from start up to end.
+func (s *Store) ListDigestRecipients(ctx context.Context, start, end time.Time) ([]models.DigestRecipient, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT u.name, u.email FROM users u
+		WHERE u.email != '' AND EXISTS (
+			SELECT 1 FROM watch_history h
+			WHERE h.user_name = u.name AND h.started_at >= ? AND h.started_at < ?
+		)
+		ORDER BY u.name`, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("listing digest recipients: %w", err)
+	}
+	defer rows.Close()
+	recipients := []models.DigestRecipient{}
+	for rows.Next() {
+		var r models.DigestRecipient
+		if err := rows.Scan(&r.UserName, &r.Email); err != nil {
+			return nil, fmt.Errorf("scanning digest recipient: %w", err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+// PersonalDigest is userName's own watching from start up to end, for a
+// personalized copy of a digest. Shows are listed by show, not episode.
+func (s *Store) PersonalDigest(ctx context.Context, userName string, start, end time.Time) (*models.DigestPersonal, error) {
+	filter := StatsFilter{StartDate: start.UTC(), EndDate: end.UTC(), IncludeOwnerPlays: true}
+	filterCond, filterArgs := filter.andConditionsWith("h")
+	where := ` WHERE h.user_name = ?` + filterCond
+	args := append([]any{userName}, filterArgs...)
+
+	p := &models.DigestPersonal{UserName: userName, TopTitles: []models.MediaStat{}}
+	var watchedMs int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(h.watched_ms), 0) FROM watch_history h`+where, args...,
+	).Scan(&p.Plays, &watchedMs)
+	if err != nil {
+		return nil, fmt.Errorf("personal digest totals: %w", err)
+	}
+	p.TotalHours = msToHours(watchedMs)
+	if p.Plays == 0 {
+		return p, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT COALESCE(NULLIF(h.grandparent_title, ''), h.title) AS t,
+			CASE WHEN h.grandparent_title != '' THEN 0 ELSE h.year END AS y,
+			COUNT(*), COALESCE(SUM(h.watched_ms), 0) AS ms
+		FROM watch_history h`+where+`
+		GROUP BY t, y
+		ORDER BY ms DESC, COUNT(*) DESC
+		LIMIT ?`,
+		append(args, digestTopLimit)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("personal digest titles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m models.MediaStat
+		var ms int64
+		if err := rows.Scan(&m.Title, &m.Year, &m.PlayCount, &ms); err != nil {
+			return nil, fmt.Errorf("scanning personal digest title: %w", err)
+		}
+		m.TotalHours = msToHours(ms)
+		p.TopTitles = append(p.TopTitles, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating personal digest titles: %w", err)
+	}
+	return p, nil
+}
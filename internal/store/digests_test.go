@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestDigestScheduleCRUD(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	d := &models.DigestSchedule{Name: " Weekly ", Period: models.DigestWeekly, ChannelIDs: []int64{3, 1}, Enabled: true}
+	if err := s.CreateDigestSchedule(d); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetDigestSchedule(d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Weekly" || len(got.ChannelIDs) != 2 || got.ChannelIDs[0] != 3 || got.LastSentAt != nil {
+		t.Errorf("got %+v", got)
+	}
+
+	for i, want := range []bool{true, false, true} {
+		key := "2026-01-05"
+		if i == 2 {
+			key = "2026-01-12"
+		}
+		claimed, err := s.ClaimDigestPeriod(ctx, d.ID, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed != want {
+			t.Errorf("claim %d (%s) = %v, want %v", i, key, claimed, want)
+		}
+	}
+
+	got.Period = models.DigestMonthly
+	got.Personalized = true
+	if err := s.UpdateDigestSchedule(got); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.ListDigestSchedules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Period != models.DigestMonthly || !list[0].Personalized ||
+		list[0].LastSentPeriod != "2026-01-12" || list[0].LastSentAt == nil {
+		t.Errorf("list = %+v", list)
+	}
+
+	if err := s.DeleteDigestSchedule(d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetDigestSchedule(d.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("after delete: %v", err)
+	}
+	if err := s.UpdateDigestSchedule(got); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("update deleted: %v", err)
+	}
+}
+
+func TestCompileDigest(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	play := func(user, title string, at time.Time) {
+		e := makeHistoryEntry(serverID, user, title, at)
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	play("alice", "Heat", start.Add(20*time.Hour))
+	play("alice", "Alien", start.Add(44*time.Hour))
+	play("bob", "Heat", start.Add(68*time.Hour))
+	play("bob", "Old", start.Add(-time.Hour))
+
+	_, _, err := s.SyncLibraryItems(ctx, serverID, "1", []models.LibraryItemCache{
+		{ServerID: serverID, LibraryID: "1", ItemID: "a", MediaType: models.MediaTypeMovie, Title: "New", Year: 2026, AddedAt: start.Add(time.Hour)},
+		{ServerID: serverID, LibraryID: "1", ItemID: "b", MediaType: models.MediaTypeMovie, Title: "Older", AddedAt: start.AddDate(0, 0, -3)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := s.CompileDigest(ctx, models.DigestWeekly, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Plays != 3 || d.ActiveUsers != 2 || d.TotalHours != 6 {
+		t.Errorf("totals = %d plays, %d users, %.1f h", d.Plays, d.ActiveUsers, d.TotalHours)
+	}
+	if d.NewContentCount != 1 || len(d.NewContent) != 1 || d.NewContent[0].Title != "New" || d.NewContent[0].ServerName != "Test" {
+		t.Errorf("new content = %d %+v", d.NewContentCount, d.NewContent)
+	}
+	if len(d.TopMovies) != 2 || d.TopMovies[0].Title != "Heat" || d.TopMovies[0].PlayCount != 2 {
+		t.Errorf("top movies = %+v", d.TopMovies)
+	}
+	if len(d.TopUsers) != 2 {
+		t.Errorf("top users = %+v", d.TopUsers)
+	}
+
+	if _, err := s.CreateLocalUser("alice", "alice@example.com", "", models.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateLocalUser("carol", "carol@example.com", "", models.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := s.ListDigestRecipients(ctx, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 1 || recipients[0].UserName != "alice" || recipients[0].Email != "alice@example.com" {
+		t.Errorf("recipients = %+v", recipients)
+	}
+
+	p, err := s.PersonalDigest(ctx, "alice", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Plays != 2 || p.TotalHours != 4 || len(p.TopTitles) != 2 {
+		t.Errorf("personal = %+v", p)
+	}
+}
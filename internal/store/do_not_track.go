@@ -0,0 +1,102 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const doNotTrackColumns = `id, name, enabled, server_id, user_name, device, library_id, ip_range,
+	suppressed_count, last_suppressed_at, created_at, updated_at`
+
+func scanDoNotTrackRule(scanner interface{ Scan(...any) error }) (models.DoNotTrackRule, error) {
+	var r models.DoNotTrackRule
+	var lastSuppressed sql.NullTime
+	if err := scanner.Scan(&r.ID, &r.Name, &r.Enabled, &r.ServerID, &r.UserName, &r.Device, &r.LibraryID, &r.IPRange,
+		&r.SuppressedCount, &lastSuppressed, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return r, err
+	}
+	if lastSuppressed.Valid {
+		r.LastSuppressedAt = &lastSuppressed.Time
+	}
+	return r, nil
+}
+
+// ListDoNotTrackRules returns every do-not-track rule in name order.
+func (s *Store) ListDoNotTrackRules() ([]models.DoNotTrackRule, error) {
+	rows, err := s.db.Query(`SELECT ` + doNotTrackColumns + ` FROM do_not_track_rules ORDER BY name COLLATE NOCASE, id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing do-not-track rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.DoNotTrackRule{}
+	for rows.Next() {
+		r, err := scanDoNotTrackRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning do-not-track rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+func (s *Store) CreateDoNotTrackRule(r *models.DoNotTrackRule) error {
+	if err := r.Validate(); err != nil {
+		return fmt.Errorf("invalid do-not-track rule: %w", err)
+	}
+	created, err := scanDoNotTrackRule(s.db.QueryRow(
+		`INSERT INTO do_not_track_rules (name, enabled, server_id, user_name, device, library_id, ip_range)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING `+doNotTrackColumns,
+		r.Name, r.Enabled, r.ServerID, r.UserName, r.Device, r.LibraryID, r.IPRange))
+	if err != nil {
+		return fmt.Errorf("creating do-not-track rule: %w", err)
+	}
+	*r = created
+	return nil
+}
+
+// UpdateDoNotTrackRule replaces a rule's conditions, keeping its suppressed
+// session count.
+func (s *Store) UpdateDoNotTrackRule(r *models.DoNotTrackRule) error {
+	if err := r.Validate(); err != nil {
+		return fmt.Errorf("invalid do-not-track rule: %w", err)
+	}
+	updated, err := scanDoNotTrackRule(s.db.QueryRow(
+		`UPDATE do_not_track_rules SET name = ?, enabled = ?, server_id = ?, user_name = ?, device = ?,
+			library_id = ?, ip_range = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? RETURNING `+doNotTrackColumns,
+		r.Name, r.Enabled, r.ServerID, r.UserName, r.Device, r.LibraryID, r.IPRange, r.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("do-not-track rule %d: %w", r.ID, models.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("updating do-not-track rule: %w", err)
+	}
+	*r = updated
+	return nil
+}
+
+func (s *Store) DeleteDoNotTrackRule(id int64) error {
+	res, err := s.db.Exec(`DELETE FROM do_not_track_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting do-not-track rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("do-not-track rule %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+// RecordDoNotTrackSuppression counts a session the rule kept out of history.
+func (s *Store) RecordDoNotTrackSuppression(id int64, at time.Time) error {
+	if _, err := s.db.Exec(
+		`UPDATE do_not_track_rules SET suppressed_count = suppressed_count + 1, last_suppressed_at = ? WHERE id = ?`,
+		at.UTC(), id); err != nil {
+		return fmt.Errorf("recording do-not-track suppression: %w", err)
+	}
+	return nil
+}
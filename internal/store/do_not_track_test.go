@@ -0,0 +1,57 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestDoNotTrackRuleCRUD(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	rule := &models.DoNotTrackRule{Name: "Guest room", Enabled: true, IPRange: "192.168.1.20"}
+	if err := s.CreateDoNotTrackRule(rule); err != nil {
+		t.Fatalf("CreateDoNotTrackRule: %v", err)
+	}
+	if rule.ID == 0 || rule.IPRange != "192.168.1.20/32" || rule.LastSuppressedAt != nil {
+		t.Fatalf("unexpected created rule %+v", rule)
+	}
+	if err := s.CreateDoNotTrackRule(&models.DoNotTrackRule{Name: "Everything", Enabled: true}); err == nil {
+		t.Fatal("expected a rule without conditions to be rejected")
+	}
+
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for range 2 {
+		if err := s.RecordDoNotTrackSuppression(rule.ID, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rule.UserName = "alice"
+	if err := s.UpdateDoNotTrackRule(rule); err != nil {
+		t.Fatalf("UpdateDoNotTrackRule: %v", err)
+	}
+	if rule.SuppressedCount != 2 || rule.LastSuppressedAt == nil || !rule.LastSuppressedAt.Equal(at) {
+		t.Fatalf("update should keep the suppression count, got %+v", rule)
+	}
+
+	rules, err := s.ListDoNotTrackRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].UserName != "alice" {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+
+	if err := s.UpdateDoNotTrackRule(&models.DoNotTrackRule{ID: 999, Name: "Gone", UserName: "bob"}); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating a missing rule, got %v", err)
+	}
+	if err := s.DeleteDoNotTrackRule(rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteDoNotTrackRule(rule.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// uncachedIPsCond matches history addresses with no fresh geo cache entry,
+// the same set GetUncachedIPs returns.
+const uncachedIPsCond = `FROM watch_history h
+		LEFT JOIN ip_geo_cache g ON h.ip_address = g.ip AND g.cached_at > ?
+		WHERE h.ip_address != '' AND g.ip IS NULL`
+
+// CountUncachedIPs counts the distinct history addresses with no fresh geo
+// cache entry.
+func (s *Store) CountUncachedIPs(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT h.ip_address) `+uncachedIPsCond,
+		time.Now().UTC().Add(-geoCacheTTL),
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count uncached ips: %w", err)
+	}
+	return n, nil
+}
+
+// ListUncachedIPsAfter returns up to limit uncached history addresses that
+// sort after the given one, in order. Paging by address rather than by
+// offset lets a backfill walk past addresses that can't be resolved, which
+// stay uncached.
+func (s *Store) ListUncachedIPsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT h.ip_address `+uncachedIPsCond+` AND h.ip_address > ?
+		ORDER BY h.ip_address
+		LIMIT ?`,
+		time.Now().UTC().Add(-geoCacheTTL), after, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list uncached ips: %w", err)
+	}
+	defer rows.Close()
+
+	var ips []string
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			return nil, err
+		}
+		ips = append(ips, ip)
+	}
+	return ips, rows.Err()
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestListUncachedIPsAfter(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for i, ip := range []string{"5.5.5.5", "1.1.1.1", "3.3.3.3", "1.1.1.1", "4.4.4.4", ""} {
+		if err := s.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: serverID, UserName: "alice", MediaType: models.MediaTypeMovie,
+			Title: fmt.Sprintf("M%d", i), IPAddress: ip, StartedAt: now, StoppedAt: now,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetCachedGeo(&models.GeoResult{IP: "4.4.4.4", City: "X"}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.CountUncachedIPs(ctx)
+	if err != nil {
+		t.Fatalf("CountUncachedIPs: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("count = %d, want 3", n)
+	}
+
+	first, err := s.ListUncachedIPsAfter(ctx, "", 2)
+	if err != nil {
+		t.Fatalf("ListUncachedIPsAfter: %v", err)
+	}
+	if want := []string{"1.1.1.1", "3.3.3.3"}; !slices.Equal(first, want) {
+		t.Errorf("first page = %v, want %v", first, want)
+	}
+	rest, err := s.ListUncachedIPsAfter(ctx, first[len(first)-1], 2)
+	if err != nil {
+		t.Fatalf("ListUncachedIPsAfter: %v", err)
+	}
+	if want := []string{"5.5.5.5"}; !slices.Equal(rest, want) {
+		t.Errorf("second page = %v, want %v", rest, want)
+	}
+}
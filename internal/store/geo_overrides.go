@@ -0,0 +1,100 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+// ErrGeoOverrideExists is returned when a network already has an override.
+var ErrGeoOverrideExists = errors.New("that network already has a geo override")
+
+const geoOverrideColumns = `id, cidr, label, city, country, lat, lng, created_at, updated_at`
+
+func scanGeoOverride(scanner interface{ Scan(...any) error }) (models.GeoOverride, error) {
+	var o models.GeoOverride
+	err := scanner.Scan(&o.ID, &o.CIDR, &o.Label, &o.City, &o.Country, &o.Lat, &o.Lng, &o.CreatedAt, &o.UpdatedAt)
+	return o, err
+}
+
+// ListGeoOverrides returns every geo override in CIDR order.
+func (s *Store) ListGeoOverrides() ([]models.GeoOverride, error) {
+	rows, err := s.db.Query(`SELECT ` + geoOverrideColumns + ` FROM geo_overrides ORDER BY cidr`)
+	if err != nil {
+		return nil, fmt.Errorf("listing geo overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []models.GeoOverride{}
+	for rows.Next() {
+		o, err := scanGeoOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning geo override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+func (s *Store) GetGeoOverride(id int64) (*models.GeoOverride, error) {
+	o, err := scanGeoOverride(s.db.QueryRow(`SELECT `+geoOverrideColumns+` FROM geo_overrides WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("geo override %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting geo override: %w", err)
+	}
+	return &o, nil
+}
+
+func (s *Store) CreateGeoOverride(o *models.GeoOverride) error {
+	if err := o.Validate(); err != nil {
+		return fmt.Errorf("invalid geo override: %w", err)
+	}
+	created, err := scanGeoOverride(s.db.QueryRow(
+		`INSERT INTO geo_overrides (cidr, label, city, country, lat, lng) VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING `+geoOverrideColumns,
+		o.CIDR, o.Label, o.City, o.Country, o.Lat, o.Lng))
+	if isUniqueConstraintError(err) {
+		return ErrGeoOverrideExists
+	}
+	if err != nil {
+		return fmt.Errorf("creating geo override: %w", err)
+	}
+	*o = created
+	return nil
+}
+
+func (s *Store) UpdateGeoOverride(o *models.GeoOverride) error {
+	if err := o.Validate(); err != nil {
+		return fmt.Errorf("invalid geo override: %w", err)
+	}
+	updated, err := scanGeoOverride(s.db.QueryRow(
+		`UPDATE geo_overrides SET cidr = ?, label = ?, city = ?, country = ?, lat = ?, lng = ?,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING `+geoOverrideColumns,
+		o.CIDR, o.Label, o.City, o.Country, o.Lat, o.Lng, o.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("geo override %d: %w", o.ID, models.ErrNotFound)
+	}
+	if isUniqueConstraintError(err) {
+		return ErrGeoOverrideExists
+	}
+	if err != nil {
+		return fmt.Errorf("updating geo override: %w", err)
+	}
+	*o = updated
+	return nil
+}
+
+func (s *Store) DeleteGeoOverride(id int64) error {
+	res, err := s.db.Exec(`DELETE FROM geo_overrides WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting geo override: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("geo override %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestGeoOverrideCRUD(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	parents := &models.GeoOverride{CIDR: "100.64.0.0/10", Label: "Mom & Dad", Country: "US", Lat: 40.7, Lng: -74}
+	if err := s.CreateGeoOverride(parents); err != nil {
+		t.Fatalf("CreateGeoOverride: %v", err)
+	}
+	if parents.ID == 0 || parents.CreatedAt.IsZero() {
+		t.Fatalf("expected created override to be populated, got %+v", parents)
+	}
+	if err := s.CreateGeoOverride(&models.GeoOverride{CIDR: "100.64.0.0/10", Label: "Again"}); !errors.Is(err, ErrGeoOverrideExists) {
+		t.Fatalf("expected ErrGeoOverrideExists for a duplicate network, got %v", err)
+	}
+	office := &models.GeoOverride{CIDR: "203.0.113.7", Label: "Office"}
+	if err := s.CreateGeoOverride(office); err != nil {
+		t.Fatal(err)
+	}
+	if office.CIDR != "203.0.113.7/32" {
+		t.Errorf("expected a bare address stored as a /32, got %q", office.CIDR)
+	}
+
+	parents.City = "Newark"
+	if err := s.UpdateGeoOverride(parents); err != nil {
+		t.Fatalf("UpdateGeoOverride: %v", err)
+	}
+	got, err := s.GetGeoOverride(parents.ID)
+	if err != nil || got.City != "Newark" || got.Label != "Mom & Dad" {
+		t.Fatalf("GetGeoOverride = %+v, %v", got, err)
+	}
+
+	if err := s.DeleteGeoOverride(office.ID); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := s.ListGeoOverrides()
+	if err != nil || len(overrides) != 1 {
+		t.Fatalf("ListGeoOverrides = %+v, %v", overrides, err)
+	}
+	if err := s.DeleteGeoOverride(office.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestCachedGeoIPsIn(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	for _, geo := range []*models.GeoResult{
+		{IP: "100.70.0.9", City: "Mom & Dad", Label: "Mom & Dad"},
+		{IP: "8.8.8.8", City: "Mountain View"},
+	} {
+		if err := s.SetCachedGeo(geo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if geo, _ := s.GetCachedGeo("100.70.0.9"); geo == nil || geo.Label != "Mom & Dad" {
+		t.Errorf("cached label = %+v", geo)
+	}
+
+	ips, err := s.CachedGeoIPsIn([]netip.Prefix{netip.MustParsePrefix("100.64.0.0/10")})
+	if err != nil || len(ips) != 1 || ips[0] != "100.70.0.9" {
+		t.Fatalf("CachedGeoIPsIn = %v, %v", ips, err)
+	}
+	if err := s.DeleteCachedGeo("100.70.0.9"); err != nil {
+		t.Fatal(err)
+	}
+	if geo, _ := s.GetCachedGeo("100.70.0.9"); geo != nil {
+		t.Errorf("expected cached geo deleted, got %+v", geo)
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -11,12 +12,12 @@ import (
 
 const (
 	geoCacheTTL = 30 * 24 * time.Hour
-	geoColumns  = `ip, lat, lng, city, country, isp`
+	geoColumns  = `ip, lat, lng, city, country, isp, label`
 )
 
 func scanGeoResult(scanner interface{ Scan(...any) error }) (models.GeoResult, error) {
 	var geo models.GeoResult
-	err := scanner.Scan(&geo.IP, &geo.Lat, &geo.Lng, &geo.City, &geo.Country, &geo.ISP)
+	err := scanner.Scan(&geo.IP, &geo.Lat, &geo.Lng, &geo.City, &geo.Country, &geo.ISP, &geo.Label)
 	return geo, err
 }
 
@@ -37,11 +38,11 @@ func (s *Store) GetCachedGeo(ip string) (*models.GeoResult, error) {
 func (s *Store) SetCachedGeo(geo *models.GeoResult) error {
 	_, err := s.db.Exec(
 		`INSERT INTO ip_geo_cache (`+geoColumns+`, cached_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(ip) DO UPDATE SET
 			lat=excluded.lat, lng=excluded.lng, city=excluded.city,
-			country=excluded.country, isp=excluded.isp, cached_at=excluded.cached_at`,
-		geo.IP, geo.Lat, geo.Lng, geo.City, geo.Country, geo.ISP, time.Now().UTC(),
+			country=excluded.country, isp=excluded.isp, label=excluded.label, cached_at=excluded.cached_at`,
+		geo.IP, geo.Lat, geo.Lng, geo.City, geo.Country, geo.ISP, geo.Label, time.Now().UTC(),
 	)
 	if err != nil {
 		return fmt.Errorf("set cached geo: %w", err)
@@ -81,20 +82,69 @@ func (s *Store) GetCachedGeos(ips []string) (map[string]*models.GeoResult, error
 	return result, rows.Err()
 }
 
+// CachedGeoIPsIn returns the cached addresses inside any of prefixes, so
+// their locations can be looked up again after a geo override changes.
+func (s *Store) CachedGeoIPsIn(prefixes []netip.Prefix) ([]string, error) {
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT ip FROM ip_geo_cache`)
+	if err != nil {
+		return nil, fmt.Errorf("listing cached geos: %w", err)
+	}
+	defer rows.Close()
+
+	var ips []string
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			return nil, err
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		for _, p := range prefixes {
+			if p.Contains(addr) {
+				ips = append(ips, ip)
+				break
+			}
+		}
+	}
+	return ips, rows.Err()
+}
+
+// DeleteCachedGeo forgets ip's cached location.
+func (s *Store) DeleteCachedGeo(ip string) error {
+	if _, err := s.db.Exec(`DELETE FROM ip_geo_cache WHERE ip = ?`, ip); err != nil {
+		return fmt.Errorf("delete cached geo: %w", err)
+	}
+	return nil
+}
+
 type IPWithLastSeen struct {
 	IP       string
 	LastSeen time.Time
 }
 
-func (s *Store) DistinctIPsForUser(userName string) ([]IPWithLastSeen, error) {
+// DistinctIPsForUser returns the addresses userName streamed from, most
+// recent first. A non-empty serverIDs only counts plays on those servers.
+func (s *Store) DistinctIPsForUser(userName string, serverIDs []int64) ([]IPWithLastSeen, error) {
+	args := []any{userName}
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		serverCond = " AND " + serverCond
+		args = append(args, serverArgs...)
+	}
 	rows, err := s.db.Query(
 		`SELECT ip_address, COALESCE(MAX(stopped_at), MAX(started_at)) as last_seen
 		FROM watch_history
-		WHERE user_name = ? AND ip_address != ''
+		WHERE user_name = ? AND ip_address != ''`+serverCond+`
 		GROUP BY ip_address
 		ORDER BY last_seen DESC
 		LIMIT 500`,
-		userName,
+		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("distinct ips: %w", err)
@@ -146,7 +146,7 @@ func TestDistinctIPsForUser(t *testing.T) {
 		}
 	}
 
-	results, err := s.DistinctIPsForUser("alice")
+	results, err := s.DistinctIPsForUser("alice", nil)
 	if err != nil {
 		t.Fatalf("DistinctIPsForUser: %v", err)
 	}
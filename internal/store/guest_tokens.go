@@ -0,0 +1,121 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const guestTokenColumns = `id, name, user_names, created_by, expires_at, last_used_at, created_at`
+
+func scanGuestToken(scanner interface{ Scan(...any) error }) (models.GuestToken, error) {
+	var t models.GuestToken
+	var userNames string
+	var lastUsed sql.NullTime
+	if err := scanner.Scan(&t.ID, &t.Name, &userNames, &t.CreatedBy, &t.ExpiresAt, &lastUsed, &t.CreatedAt); err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal([]byte(userNames), &t.UserNames); err != nil {
+		return t, fmt.Errorf("decoding guest token users: %w", err)
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = &lastUsed.Time
+	}
+	return t, nil
+}
+
+// CreateGuestToken stores a new token scoped to in's users, valid until
+// expiresAt, and returns it with the plaintext token set.
+func (s *Store) CreateGuestToken(in models.GuestTokenInput, createdBy string, expiresAt time.Time) (*models.GuestToken, error) {
+	raw, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating guest token: %w", err)
+	}
+	token := models.GuestTokenPrefix + raw
+	userNames, err := json.Marshal(in.UserNames)
+	if err != nil {
+		return nil, fmt.Errorf("encoding guest token users: %w", err)
+	}
+	t, err := scanGuestToken(s.db.QueryRow(
+		`INSERT INTO guest_tokens (token_hash, name, user_names, created_by, expires_at)
+		VALUES (?, ?, ?, ?, ?) RETURNING `+guestTokenColumns,
+		hashToken(token), in.Name, string(userNames), createdBy, expiresAt.UTC(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating guest token: %w", err)
+	}
+	t.Token = token
+	return &t, nil
+}
+
+// ListGuestTokens returns the tokens that haven't expired, newest first.
+func (s *Store) ListGuestTokens() ([]models.GuestToken, error) {
+	rows, err := s.db.Query(`SELECT `+guestTokenColumns+` FROM guest_tokens
+		WHERE expires_at > ? ORDER BY created_at DESC, id DESC`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("listing guest tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.GuestToken{}
+	for rows.Next() {
+		t, err := scanGuestToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning guest token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// ResolveGuestToken returns the guest token a plaintext token belongs to.
+// Unknown and expired tokens both report ErrNotFound.
+func (s *Store) ResolveGuestToken(token string) (*models.GuestToken, error) {
+	t, err := scanGuestToken(s.db.QueryRow(
+		`SELECT `+guestTokenColumns+` FROM guest_tokens WHERE token_hash = ? AND expires_at > ?`,
+		hashToken(token), time.Now().UTC(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("guest token: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving guest token: %w", err)
+	}
+	return &t, nil
+}
+
+// TouchGuestToken records that a guest token was just used.
+func (s *Store) TouchGuestToken(id int64) error {
+	_, err := s.db.Exec(`UPDATE guest_tokens SET last_used_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("touching guest token: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteGuestToken(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM guest_tokens WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting guest token: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("guest token %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) DeleteExpiredGuestTokens() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM guest_tokens WHERE expires_at <= ?`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("deleting expired guest tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestGuestTokenLifecycle(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	in := models.GuestTokenInput{Name: "Kid report", UserNames: []string{"kid"}}
+	tok, err := s.CreateGuestToken(in, "admin", time.Now().UTC().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CreateGuestToken: %v", err)
+	}
+	if !strings.HasPrefix(tok.Token, models.GuestTokenPrefix) {
+		t.Fatalf("expected %q prefix, got %q", models.GuestTokenPrefix, tok.Token)
+	}
+
+	got, err := s.ResolveGuestToken(tok.Token)
+	if err != nil {
+		t.Fatalf("ResolveGuestToken: %v", err)
+	}
+	if got.ID != tok.ID || !got.AllowsUser("kid") || got.AllowsUser("parent") || got.Token != "" {
+		t.Fatalf("unexpected token %+v", got)
+	}
+	if got.LastUsedAt != nil {
+		t.Fatal("expected no last_used_at before first use")
+	}
+
+	if err := s.TouchGuestToken(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := s.ListGuestTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil || tokens[0].CreatedBy != "admin" {
+		t.Fatalf("unexpected list %+v", tokens)
+	}
+
+	if _, err := s.ResolveGuestToken(models.GuestTokenPrefix + "nope"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown token, got %v", err)
+	}
+
+	if err := s.DeleteGuestToken(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveGuestToken(tok.Token); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := s.DeleteGuestToken(tok.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestGuestTokenExpiry(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	in := models.GuestTokenInput{Name: "Old", UserNames: []string{"kid"}}
+	tok, err := s.CreateGuestToken(in, "admin", time.Now().UTC().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveGuestToken(tok.Token); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected expired token to be ErrNotFound, got %v", err)
+	}
+	tokens, err := s.ListGuestTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 0 {
+		t.Fatalf("expected expired token to be hidden, got %d", len(tokens))
+	}
+	n, err := s.DeleteExpiredGuestTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 expired token deleted, got %d", n)
+	}
+}
@@ -16,22 +16,24 @@ const historyColumns = `id, server_id, item_id, grandparent_item_id, user_name,
 	year, duration_ms, watched_ms, player, platform, ip_address, started_at, stopped_at, created_at,
 	season_number, episode_number, thumb_url, video_resolution, transcode_decision,
 	video_codec, audio_codec, audio_channels, bandwidth, video_decision, audio_decision,
-	transcode_hw_decode, transcode_hw_encode, dynamic_range, paused_ms, watched, session_count`
+	transcode_hw_decode, transcode_hw_encode, dynamic_range, paused_ms, watched, session_count, watch_party_id,
+	original_title, language, content_rating, network_label`
 
 const historyColumnsWithGeo = `h.id, h.server_id, h.item_id, h.grandparent_item_id, h.user_name, h.media_type, h.extra_type, h.title, h.parent_title,
 	h.grandparent_title, h.year, h.duration_ms, h.watched_ms, h.player, h.platform, h.ip_address,
 	h.started_at, h.stopped_at, h.created_at, h.season_number, h.episode_number, h.thumb_url,
 	h.video_resolution, h.transcode_decision,
 	h.video_codec, h.audio_codec, h.audio_channels, h.bandwidth, h.video_decision, h.audio_decision,
-	h.transcode_hw_decode, h.transcode_hw_encode, h.dynamic_range, h.paused_ms, h.watched, h.session_count,
-	COALESCE(g.city, ''), COALESCE(g.country, ''), COALESCE(g.isp, '')`
+	h.transcode_hw_decode, h.transcode_hw_encode, h.dynamic_range, h.paused_ms, h.watched, h.session_count, h.watch_party_id,
+	h.original_title, h.language, h.content_rating, h.network_label, COALESCE(g.city, ''), COALESCE(g.country, ''), COALESCE(g.isp, '')`
 
 const historyInsertSQL = `INSERT INTO watch_history (server_id, item_id, grandparent_item_id, user_name, media_type, extra_type, title, parent_title, grandparent_title,
 	year, duration_ms, watched_ms, player, platform, ip_address, started_at, stopped_at,
 	season_number, episode_number, thumb_url, video_resolution, transcode_decision,
 	video_codec, audio_codec, audio_channels, bandwidth, video_decision, audio_decision,
-	transcode_hw_decode, transcode_hw_encode, dynamic_range, paused_ms, watched, tautulli_reference_id)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	transcode_hw_decode, transcode_hw_encode, dynamic_range, paused_ms, watched, tautulli_reference_id,
+	original_title, language, content_rating, network_label)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 func scanHistoryEntry(scanner interface{ Scan(...any) error }) (models.WatchHistoryEntry, error) {
 	var e models.WatchHistoryEntry
@@ -41,7 +43,8 @@ func scanHistoryEntry(scanner interface{ Scan(...any) error }) (models.WatchHist
 		&e.Player, &e.Platform, &e.IPAddress, &e.StartedAt, &e.StoppedAt, &e.CreatedAt,
 		&e.SeasonNumber, &e.EpisodeNumber, &e.ThumbURL, &e.VideoResolution, &e.TranscodeDecision,
 		&e.VideoCodec, &e.AudioCodec, &e.AudioChannels, &e.Bandwidth, &e.VideoDecision, &e.AudioDecision,
-		&hwDecode, &hwEncode, &e.DynamicRange, &e.PausedMs, &watched, &e.SessionCount)
+		&hwDecode, &hwEncode, &e.DynamicRange, &e.PausedMs, &watched, &e.SessionCount, &e.WatchPartyID,
+		&e.OriginalTitle, &e.Language, &e.ContentRating, &e.NetworkLabel)
 	e.TranscodeHWDecode = hwDecode != 0
 	e.TranscodeHWEncode = hwEncode != 0
 	e.Watched = watched != 0
@@ -56,8 +59,8 @@ func scanHistoryEntryWithGeo(scanner interface{ Scan(...any) error }) (models.Wa
 		&e.Player, &e.Platform, &e.IPAddress, &e.StartedAt, &e.StoppedAt, &e.CreatedAt,
 		&e.SeasonNumber, &e.EpisodeNumber, &e.ThumbURL, &e.VideoResolution, &e.TranscodeDecision,
 		&e.VideoCodec, &e.AudioCodec, &e.AudioChannels, &e.Bandwidth, &e.VideoDecision, &e.AudioDecision,
-		&hwDecode, &hwEncode, &e.DynamicRange, &e.PausedMs, &watched, &e.SessionCount,
-		&e.City, &e.Country, &e.ISP)
+		&hwDecode, &hwEncode, &e.DynamicRange, &e.PausedMs, &watched, &e.SessionCount, &e.WatchPartyID,
+		&e.OriginalTitle, &e.Language, &e.ContentRating, &e.NetworkLabel, &e.City, &e.Country, &e.ISP)
 	e.TranscodeHWDecode = hwDecode != 0
 	e.TranscodeHWEncode = hwEncode != 0
 	e.Watched = watched != 0
@@ -184,6 +187,7 @@ func historyInsertArgs(entry *models.WatchHistoryEntry) []any {
 		entry.VideoDecision, entry.AudioDecision,
 		boolToInt(entry.TranscodeHWDecode), boolToInt(entry.TranscodeHWEncode),
 		entry.DynamicRange, entry.PausedMs, boolToInt(entry.Watched), entry.TautulliReferenceID,
+		entry.OriginalTitle, entry.Language, entry.ContentRating, entry.NetworkLabel,
 	}
 }
 
@@ -197,6 +201,9 @@ func (s *Store) insertHistoryTx(ctx context.Context, entry *models.WatchHistoryE
 	if err := ctx.Err(); err != nil {
 		return err
 	}
+	if !s.applyHistoryHooks(ctx, entry) {
+		return nil
+	}
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -217,6 +224,9 @@ func (s *Store) insertHistoryTx(ctx context.Context, entry *models.WatchHistoryE
 	if err != nil {
 		return err
 	}
+	if err := upsertPlaybackProgress(ctx, tx, entry); err != nil {
+		return err
+	}
 	if consolidatedID > 0 {
 		if err := insertSession(ctx, tx, consolidatedID, entry); err != nil {
 			return err
@@ -236,6 +246,9 @@ func (s *Store) insertHistoryTx(ctx context.Context, entry *models.WatchHistoryE
 	if err := insertSession(ctx, tx, id, entry); err != nil {
 		return err
 	}
+	if err := assignWatchParty(ctx, tx, id, entry); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
@@ -275,28 +288,112 @@ func (s *Store) ListHistory(page, perPage int, userFilter, sortColumn, sortOrder
 }
 
 func (s *Store) SearchHistory(page, perPage int, userFilter, search, sortColumn, sortOrder string, serverIDs []int64) (*models.PaginatedResult[models.WatchHistoryEntry], error) {
+	return s.QueryHistory(page, perPage, HistoryQuery{
+		UserName:   userFilter,
+		Search:     search,
+		SortColumn: sortColumn,
+		SortOrder:  sortOrder,
+		ServerIDs:  serverIDs,
+	})
+}
+
+// HistoryQuery selects and orders the history list. Zero fields don't
+// filter.
+type HistoryQuery struct {
+	UserName     string
+	Search       string
+	SortColumn   string
+	SortOrder    string
+	ServerIDs    []int64
+	NetworkLabel string
+	// Scope, when set, limits the list to one person's accounts; see
+	// UserScope.
+	Scope *UserScope
+
+	MediaType         models.MediaType
+	TranscodeDecision models.TranscodeDecision
+	VideoResolution   string
+	// Platform and Player match case-insensitively.
+	Platform  string
+	Player    string
+	IPAddress string
+	// Watched, when set, keeps only plays that were (or weren't) watched to
+	// completion.
+	Watched *bool
+	// StartedAfter and StartedBefore bound started_at, inclusive and
+	// exclusive respectively.
+	StartedAfter  time.Time
+	StartedBefore time.Time
+}
+
+func (s *Store) QueryHistory(page, perPage int, q HistoryQuery) (*models.PaginatedResult[models.WatchHistoryEntry], error) {
 	var countConds []string
 	var joinConds []string
 	var args []any
-	if userFilter != "" {
+	if q.UserName != "" {
 		countConds = append(countConds, "user_name = ?")
 		joinConds = append(joinConds, "h.user_name = ?")
-		args = append(args, userFilter)
+		args = append(args, q.UserName)
 	}
-	if len(serverIDs) > 0 {
-		placeholders := strings.Repeat(",?", len(serverIDs))[1:]
+	if q.Scope != nil {
+		cond, scopeArgs := q.Scope.condition("")
+		aliased, _ := q.Scope.condition("h")
+		countConds = append(countConds, cond)
+		joinConds = append(joinConds, aliased)
+		args = append(args, scopeArgs...)
+	}
+	if len(q.ServerIDs) > 0 {
+		placeholders := strings.Repeat(",?", len(q.ServerIDs))[1:]
 		countConds = append(countConds, fmt.Sprintf("server_id IN (%s)", placeholders))
 		joinConds = append(joinConds, fmt.Sprintf("h.server_id IN (%s)", placeholders))
-		for _, id := range serverIDs {
+		for _, id := range q.ServerIDs {
 			args = append(args, id)
 		}
 	}
-	if search != "" {
-		pattern := "%" + escapeLikePattern(search) + "%"
+	if q.Search != "" {
+		pattern := "%" + escapeLikePattern(q.Search) + "%"
 		countConds = append(countConds, `(title LIKE ? ESCAPE '\' OR grandparent_title LIKE ? ESCAPE '\' OR user_name LIKE ? ESCAPE '\')`)
 		joinConds = append(joinConds, `(h.title LIKE ? ESCAPE '\' OR h.grandparent_title LIKE ? ESCAPE '\' OR h.user_name LIKE ? ESCAPE '\')`)
 		args = append(args, pattern, pattern, pattern)
 	}
+	if q.NetworkLabel != "" {
+		countConds = append(countConds, "network_label = ? COLLATE NOCASE")
+		joinConds = append(joinConds, "h.network_label = ? COLLATE NOCASE")
+		args = append(args, q.NetworkLabel)
+	}
+	// filterOn adds "<column> <cond>" to both the count and the list query.
+	filterOn := func(column, cond string, arg any) {
+		countConds = append(countConds, column+" "+cond)
+		joinConds = append(joinConds, "h."+column+" "+cond)
+		args = append(args, arg)
+	}
+	if q.MediaType != "" {
+		filterOn("media_type", "= ?", q.MediaType)
+	}
+	if q.TranscodeDecision != "" {
+		filterOn("transcode_decision", "= ?", q.TranscodeDecision)
+	}
+	if q.VideoResolution != "" {
+		filterOn("video_resolution", "= ?", q.VideoResolution)
+	}
+	if q.Platform != "" {
+		filterOn("platform", "= ? COLLATE NOCASE", q.Platform)
+	}
+	if q.Player != "" {
+		filterOn("player", "= ? COLLATE NOCASE", q.Player)
+	}
+	if q.IPAddress != "" {
+		filterOn("ip_address", "= ?", q.IPAddress)
+	}
+	if q.Watched != nil {
+		filterOn("watched", "= ?", *q.Watched)
+	}
+	if !q.StartedAfter.IsZero() {
+		filterOn("started_at", ">= ?", q.StartedAfter.UTC())
+	}
+	if !q.StartedBefore.IsZero() {
+		filterOn("started_at", "< ?", q.StartedBefore.UTC())
+	}
 
 	countWhere := ""
 	if len(countConds) > 0 {
@@ -309,12 +406,12 @@ func (s *Store) SearchHistory(page, perPage int, userFilter, search, sortColumn,
 	}
 
 	orderBy := "h.started_at DESC"
-	if sortColumn != "" && validHistorySortColumns[sortColumn] {
+	if q.SortColumn != "" && validHistorySortColumns[q.SortColumn] {
 		order := "DESC"
-		if sortOrder == "asc" {
+		if q.SortOrder == "asc" {
 			order = "ASC"
 		}
-		orderBy = sortColumn + " " + order
+		orderBy = q.SortColumn + " " + order
 	}
 
 	where := ""
@@ -347,6 +444,14 @@ func (s *Store) SearchHistory(page, perPage int, userFilter, search, sortColumn,
 		return nil, err
 	}
 
+	home, err := s.GetHomeNetworkSettings()
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Local = home.IsLocal(items[i].IPAddress)
+	}
+
 	return &models.PaginatedResult[models.WatchHistoryEntry]{
 		Items:   items,
 		Total:   total,
@@ -753,8 +858,10 @@ func (s *Store) InsertHistoryBatch(ctx context.Context, entries []*models.WatchH
 
 	thresholdPct, _ := s.GetWatchedThreshold()
 
+	kept, skipped := s.applyHistoryHooksAll(ctx, entries)
+
 	var firstErr error
-	for i, chunk := range chunkSlice(entries, writeChunkSize) {
+	for i, chunk := range chunkSlice(kept, writeChunkSize) {
 		if ctx.Err() != nil {
 			if firstErr == nil {
 				firstErr = ctx.Err()
@@ -790,6 +897,48 @@ func (s *Store) insertHistoryChunk(ctx context.Context, entries []*models.WatchH
 	}
 	defer tx.Rollback()
 
+	ins, skip, cons, err := applyHistoryEntries(ctx, tx, entries, thresholdPct)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return ins, skip, cons, nil
+}
+
+// PreviewHistoryBatch reports what InsertHistoryBatch would do with entries
+// without keeping any of it: the same dedup and consolidation run inside a
+// single transaction that is always rolled back. Using one transaction means
+// duplicates within entries are counted as skipped, as a real import would.
+func (s *Store) PreviewHistoryBatch(ctx context.Context, entries []*models.WatchHistoryEntry) (inserted, skipped, consolidated int, err error) {
+	if len(entries) == 0 {
+		return 0, 0, 0, nil
+	}
+	thresholdPct, _ := s.GetWatchedThreshold()
+
+	// The hooks rewrite entries in place, so preview on copies to leave the
+	// caller's entries as they were for the real import.
+	copies := make([]*models.WatchHistoryEntry, len(entries))
+	for i, entry := range entries {
+		e := *entry
+		copies[i] = &e
+	}
+	kept, dropped := s.applyHistoryHooksAll(ctx, copies)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted, skipped, consolidated, err = applyHistoryEntries(ctx, tx, kept, thresholdPct)
+	return inserted, skipped + dropped, consolidated, err
+}
+
+// applyHistoryEntries dedups, consolidates, and inserts entries within tx.
+// The caller decides whether to commit.
+func applyHistoryEntries(ctx context.Context, tx *sql.Tx, entries []*models.WatchHistoryEntry, thresholdPct int) (inserted, skipped, consolidated int, err error) {
 	insertStmt, err := tx.PrepareContext(ctx, historyInsertSQL)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("prepare insert: %w", err)
@@ -849,13 +998,12 @@ func (s *Store) insertHistoryChunk(ctx context.Context, entries []*models.WatchH
 		if _, err := sessionStmt.ExecContext(ctx, sessionInsertArgs(newID, entry)...); err != nil {
 			return 0, 0, 0, fmt.Errorf("inserting session: %w", err)
 		}
+		if err := assignWatchParty(ctx, tx, newID, entry); err != nil {
+			return 0, 0, 0, err
+		}
 		ins++
 	}
 
-	if err := tx.Commit(); err != nil {
-		return 0, 0, 0, fmt.Errorf("commit tx: %w", err)
-	}
-
 	return ins, skip, cons, nil
 }
 
@@ -928,6 +1076,21 @@ func (s *Store) MarkHistoryEnriched(ctx context.Context, id int64) error {
 	return nil
 }
 
+// GetHistoryEntry returns a history entry with its cached geo fields.
+func (s *Store) GetHistoryEntry(id int64) (*models.WatchHistoryEntry, error) {
+	entry, err := scanHistoryEntryWithGeo(s.db.QueryRow(`SELECT `+historyColumnsWithGeo+`
+		FROM watch_history h
+		LEFT JOIN ip_geo_cache g ON h.ip_address = g.ip
+		WHERE h.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("history %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting history entry: %w", err)
+	}
+	return &entry, nil
+}
+
 func (s *Store) GetHistoryOwner(historyID int64) (string, error) {
 	var userName string
 	err := s.db.QueryRow(`SELECT user_name FROM watch_history WHERE id = ?`, historyID).Scan(&userName)
@@ -958,3 +1121,33 @@ func (s *Store) ListSessionsForHistory(historyID int64) ([]models.WatchSession,
 	}
 	return sessions, rows.Err()
 }
+
+// ListHistoryOverlapping returns up to limit history rows that were playing
+// at some point in [from, to), oldest first, optionally for one user.
+func (s *Store) ListHistoryOverlapping(ctx context.Context, from, to time.Time, userName string, limit int) ([]models.WatchHistoryEntry, error) {
+	query := `SELECT ` + historyColumns + ` FROM watch_history
+		WHERE started_at < ? AND stopped_at > ?`
+	args := []any{to.UTC(), from.UTC()}
+	if userName != "" {
+		query += ` AND user_name = ?`
+		args = append(args, userName)
+	}
+	query += ` ORDER BY started_at, id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing overlapping history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.WatchHistoryEntry
+	for rows.Next() {
+		e, err := scanHistoryEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// FindHistoryDuplicates reports clusters of history rows with the same
+// server, user, title, and show (grandparent title, so "Pilot" episodes of
+// different shows stay apart) whose start times each fall within window of the
+// previous row. Nothing is modified: each cluster carries a preview of the
+// merge. serverID 0 covers all servers. At most limit clusters are returned
+// (0 means no limit), total counts every cluster found.
+func (s *Store) FindHistoryDuplicates(ctx context.Context, window time.Duration, serverID int64, limit int) (clusters []models.HistoryDuplicateCluster, total int, err error) {
+	thresholdPct, _ := s.GetWatchedThreshold()
+
+	query := `SELECT id, server_id, user_name, title, COALESCE(grandparent_title, ''), started_at, stopped_at,
+		COALESCE(duration_ms, 0), COALESCE(watched_ms, 0), COALESCE(paused_ms, 0),
+		COALESCE(player, ''), COALESCE(platform, ''), COALESCE(session_count, 1),
+		COALESCE(tautulli_reference_id, 0) > 0
+		FROM watch_history`
+	var args []any
+	if serverID > 0 {
+		query += ` WHERE server_id = ?`
+		args = append(args, serverID)
+	}
+	query += ` ORDER BY server_id, user_name, title, COALESCE(grandparent_title, ''), started_at, id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("scanning history for duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	clusters = []models.HistoryDuplicateCluster{}
+	var cur *models.HistoryDuplicateCluster
+	flush := func() {
+		if cur == nil || len(cur.Entries) < 2 {
+			return
+		}
+		total++
+		if limit <= 0 || len(clusters) < limit {
+			previewHistoryMerge(cur, thresholdPct)
+			clusters = append(clusters, *cur)
+		}
+	}
+
+	for rows.Next() {
+		var c models.HistoryDuplicateCluster
+		var e models.HistoryDuplicateEntry
+		if err := rows.Scan(&e.ID, &c.ServerID, &c.UserName, &c.Title, &c.GrandparentTitle,
+			&e.StartedAt, &e.StoppedAt, &e.DurationMs, &e.WatchedMs, &e.PausedMs,
+			&e.Player, &e.Platform, &e.SessionCount, &e.FromTautulli); err != nil {
+			return nil, 0, fmt.Errorf("scanning history row: %w", err)
+		}
+		if cur != nil && cur.ServerID == c.ServerID && cur.UserName == c.UserName && cur.Title == c.Title &&
+			cur.GrandparentTitle == c.GrandparentTitle &&
+			e.StartedAt.Sub(cur.Entries[len(cur.Entries)-1].StartedAt) <= window {
+			cur.Entries = append(cur.Entries, e)
+			continue
+		}
+		flush()
+		c.Entries = []models.HistoryDuplicateEntry{e}
+		cur = &c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterating history rows: %w", err)
+	}
+	flush()
+	return clusters, total, nil
+}
+
+// previewHistoryMerge fills KeepID and Merged for a cluster whose entries are
+// ordered by start time.
+func previewHistoryMerge(c *models.HistoryDuplicateCluster, thresholdPct int) {
+	first := c.Entries[0]
+	m := models.HistoryMergePreview{StartedAt: first.StartedAt, StoppedAt: first.StoppedAt}
+	keep := first
+
+	// Rows overlapping in time are the same play recorded twice, so within
+	// each overlapping segment only the longest watch counts.
+	var seg models.HistoryDuplicateEntry
+	addSegment := func() {
+		m.WatchedMs += seg.WatchedMs
+		m.PausedMs += seg.PausedMs
+		m.SessionCount += seg.SessionCount
+	}
+	segEnd := first.StoppedAt
+	seg = first
+	for _, e := range c.Entries[1:] {
+		if e.WatchedMs > keep.WatchedMs {
+			keep = e
+		}
+		if e.DurationMs > m.DurationMs {
+			m.DurationMs = e.DurationMs
+		}
+		if e.StoppedAt.After(m.StoppedAt) {
+			m.StoppedAt = e.StoppedAt
+		}
+		if e.StartedAt.Before(segEnd) {
+			if e.WatchedMs > seg.WatchedMs {
+				seg = e
+			}
+			if e.StoppedAt.After(segEnd) {
+				segEnd = e.StoppedAt
+			}
+			continue
+		}
+		addSegment()
+		seg = e
+		segEnd = e.StoppedAt
+	}
+	addSegment()
+
+	if first.DurationMs > m.DurationMs {
+		m.DurationMs = first.DurationMs
+	}
+	m.Watched = m.DurationMs > 0 && float64(m.WatchedMs)/float64(m.DurationMs)*100 >= float64(thresholdPct)
+	c.KeepID = keep.ID
+	c.Merged = m
+}
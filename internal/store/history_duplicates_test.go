@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// insertRawHistory bypasses dedup and consolidation to plant duplicates.
+func insertRawHistory(t *testing.T, s *Store, e *models.WatchHistoryEntry) int64 {
+	t.Helper()
+	res, err := s.db.Exec(historyInsertSQL, historyInsertArgs(e)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+func TestFindHistoryDuplicates(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	t0 := time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	entry := func(user string, start, stop time.Duration, watched time.Duration) *models.WatchHistoryEntry {
+		e := makeHistoryEntry(serverID, user, "Movie", t0.Add(start))
+		e.StoppedAt = t0.Add(stop)
+		e.WatchedMs = watched.Milliseconds()
+		return e
+	}
+	imported := entry("alice", 0, 60*time.Minute, 50*time.Minute)
+	imported.TautulliReferenceID = 7
+	idA := insertRawHistory(t, s, imported)
+	idB := insertRawHistory(t, s, entry("alice", 3*time.Minute, 62*time.Minute, 55*time.Minute))
+	insertRawHistory(t, s, entry("alice", 63*time.Minute, 90*time.Minute, 20*time.Minute))
+	insertRawHistory(t, s, entry("bob", 0, 60*time.Minute, 50*time.Minute))
+	insertRawHistory(t, s, entry("alice", 5*time.Hour, 6*time.Hour, 60*time.Minute))
+
+	clusters, total, err := s.FindHistoryDuplicates(ctx, 10*time.Minute, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got total=%d clusters=%+v", total, clusters)
+	}
+	c := clusters[0]
+	if len(c.Entries) != 2 || c.Entries[0].ID != idA || c.Entries[1].ID != idB {
+		t.Fatalf("unexpected cluster entries %+v", c.Entries)
+	}
+	if !c.Entries[0].FromTautulli || c.Entries[1].FromTautulli {
+		t.Error("expected only the first entry flagged as a Tautulli import")
+	}
+	if c.KeepID != idB {
+		t.Errorf("keep_id = %d, want %d", c.KeepID, idB)
+	}
+	// Overlapping rows are one play: the longer watch wins.
+	if c.Merged.WatchedMs != (55*time.Minute).Milliseconds() || !c.Merged.StoppedAt.Equal(t0.Add(62*time.Minute)) {
+		t.Errorf("unexpected merge preview %+v", c.Merged)
+	}
+
+	// A wider window also pulls in the later, disjoint session, which adds up.
+	clusters, _, err = s.FindHistoryDuplicates(ctx, time.Hour, serverID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || len(clusters[0].Entries) != 3 {
+		t.Fatalf("expected one 3-entry cluster, got %+v", clusters)
+	}
+	if m := clusters[0].Merged; m.WatchedMs != (75*time.Minute).Milliseconds() || m.SessionCount != 2 {
+		t.Errorf("unexpected merge preview %+v", m)
+	}
+
+	clusters, total, err = s.FindHistoryDuplicates(ctx, 10*time.Minute, serverID+1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || len(clusters) != 0 {
+		t.Errorf("expected no clusters for other server, got %d", total)
+	}
+}
+
+func TestFindHistoryDuplicatesKeepsShowsApart(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	t0 := time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	pilot := func(show string, start time.Duration) int64 {
+		e := makeHistoryEntry(serverID, "alice", "Pilot", t0.Add(start))
+		e.MediaType = models.MediaTypeTV
+		e.GrandparentTitle = show
+		e.StoppedAt = e.StartedAt.Add(45 * time.Minute)
+		e.WatchedMs = (40 * time.Minute).Milliseconds()
+		return insertRawHistory(t, s, e)
+	}
+	idA1 := pilot("Show A", 0)
+	pilot("Show B", 2*time.Minute)
+	idA2 := pilot("Show A", 4*time.Minute)
+
+	clusters, total, err := s.FindHistoryDuplicates(ctx, 10*time.Minute, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got total=%d clusters=%+v", total, clusters)
+	}
+	c := clusters[0]
+	if c.GrandparentTitle != "Show A" || len(c.Entries) != 2 || c.Entries[0].ID != idA1 || c.Entries[1].ID != idA2 {
+		t.Errorf("expected only Show A's pilots clustered, got %+v", c)
+	}
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sync"
+
+	"streammon/internal/models"
+)
+
+// HistoryHook can rewrite a play before it's written to history, or drop it
+// by returning false. Hooks run on every insert path, in the order they
+// were added, ahead of the history rules from settings.
+type HistoryHook func(ctx context.Context, entry *models.WatchHistoryEntry) bool
+
+// historyPipeline holds the pre-insert hooks. rules are compiled from the
+// history rule settings on first use and whenever they're saved.
+type historyPipeline struct {
+	mu     sync.RWMutex
+	hooks  []HistoryHook
+	rules  []HistoryHook
+	loaded bool
+}
+
+// AddHistoryHook appends hook to the history write pipeline.
+func (s *Store) AddHistoryHook(hook HistoryHook) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	s.history.hooks = append(s.history.hooks, hook)
+}
+
+// applyHistoryHooks runs entry through the pipeline, reporting whether it
+// should still be written.
+func (s *Store) applyHistoryHooks(ctx context.Context, entry *models.WatchHistoryEntry) bool {
+	for _, hook := range s.historyHooks() {
+		if !hook(ctx, entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyHistoryHooksAll runs each of entries through the pipeline, returning
+// the ones still to be written and how many were dropped.
+func (s *Store) applyHistoryHooksAll(ctx context.Context, entries []*models.WatchHistoryEntry) (kept []*models.WatchHistoryEntry, dropped int) {
+	kept = make([]*models.WatchHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if s.applyHistoryHooks(ctx, entry) {
+			kept = append(kept, entry)
+		} else {
+			dropped++
+		}
+	}
+	return kept, dropped
+}
+
+func (s *Store) historyHooks() []HistoryHook {
+	s.history.mu.RLock()
+	if s.history.loaded {
+		hooks := slices.Concat(s.history.hooks, s.history.rules)
+		s.history.mu.RUnlock()
+		return hooks
+	}
+	s.history.mu.RUnlock()
+
+	settings, err := s.GetHistoryRuleSettings()
+	if err != nil {
+		// Write the play unrewritten rather than lose it; the next insert
+		// tries loading the rules again.
+		log.Printf("loading history rules: %v", err)
+	}
+	rules := s.compileHistoryRules(settings.Rules)
+
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	if err == nil && !s.history.loaded {
+		s.history.rules, s.history.loaded = rules, true
+	}
+	return slices.Concat(s.history.hooks, rules)
+}
+
+func (s *Store) compileHistoryRules(rules []models.HistoryRule) []HistoryHook {
+	hooks := make([]HistoryHook, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		var pattern *regexp.Regexp
+		if rule.Action == models.HistoryRuleRewriteTitle {
+			var err error
+			if pattern, err = regexp.Compile(rule.Pattern); err != nil {
+				log.Printf("history rule %q: %v", rule.Name, err)
+				continue
+			}
+		}
+		hooks = append(hooks, func(ctx context.Context, entry *models.WatchHistoryEntry) bool {
+			if !rule.Matches(entry) || !s.historyRuleLibraryMatches(ctx, rule, entry) {
+				return true
+			}
+			switch rule.Action {
+			case models.HistoryRuleRenameUser:
+				entry.UserName = rule.Replacement
+			case models.HistoryRuleRewriteTitle:
+				entry.Title = pattern.ReplaceAllString(entry.Title, rule.Replacement)
+			case models.HistoryRuleSkip:
+				return false
+			}
+			return true
+		})
+	}
+	return hooks
+}
+
+// historyRuleLibraryMatches resolves the play's library through the synced
+// library cache. A play whose library can't be found doesn't match.
+func (s *Store) historyRuleLibraryMatches(ctx context.Context, rule models.HistoryRule, entry *models.WatchHistoryEntry) bool {
+	if rule.LibraryID == "" {
+		return true
+	}
+	libraryID, err := s.LibraryIDForItem(ctx, entry.ServerID, entry.ItemID, entry.GrandparentItemID)
+	if err != nil {
+		log.Printf("history rule %q library lookup for %s: %v", rule.Name, entry.Title, err)
+		return false
+	}
+	return libraryID == rule.LibraryID
+}
+
+const historyRulesKey = "history_rules"
+
+// GetHistoryRuleSettings returns the history rules, none by default.
+func (s *Store) GetHistoryRuleSettings() (models.HistoryRuleSettings, error) {
+	settings := models.HistoryRuleSettings{Rules: []models.HistoryRule{}}
+	val, err := s.GetSetting(historyRulesKey)
+	if err != nil || val == "" {
+		return settings, err
+	}
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return models.HistoryRuleSettings{Rules: []models.HistoryRule{}}, fmt.Errorf("parsing history rules: %w", err)
+	}
+	return settings, nil
+}
+
+// SetHistoryRuleSettings saves the history rules and applies them to the
+// next write.
+func (s *Store) SetHistoryRuleSettings(settings models.HistoryRuleSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	val, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encoding history rules: %w", err)
+	}
+	if err := s.SetSetting(historyRulesKey, string(val)); err != nil {
+		return err
+	}
+	rules := s.compileHistoryRules(settings.Rules)
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	s.history.rules, s.history.loaded = rules, true
+	return nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestHistoryRules(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	if _, _, err := s.SyncLibraryItems(ctx, serverID, "kids", []models.LibraryItemCache{{
+		ServerID: serverID, LibraryID: "kids", ItemID: "cartoon", MediaType: models.MediaTypeMovie,
+		Title: "Cartoon", AddedAt: time.Now().UTC(),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetHistoryRuleSettings(models.HistoryRuleSettings{Rules: []models.HistoryRule{
+		{Enabled: true, Action: models.HistoryRuleRenameUser, UserName: "JDoe", Replacement: "john"},
+		{Enabled: true, Action: models.HistoryRuleRewriteTitle, Pattern: `\s*\(\d{4}\)$`},
+		{Enabled: true, Action: models.HistoryRuleSkip, ServerID: serverID, LibraryID: "kids"},
+		{Enabled: true, Action: models.HistoryRuleSkip, UserName: "john", MediaType: models.MediaTypeLiveTV},
+		{Enabled: false, Action: models.HistoryRuleSkip, UserName: "john"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(user, title, itemID string, mt models.MediaType, offset int) *models.WatchHistoryEntry {
+		e := makeHistoryEntry(serverID, user, title, base.Add(time.Duration(offset)*3*time.Hour))
+		e.ItemID, e.MediaType = itemID, mt
+		return e
+	}
+
+	renamed := entry("jdoe", "Dune (2021)", "dune", models.MediaTypeMovie, 0)
+	if err := s.InsertHistory(renamed); err != nil {
+		t.Fatal(err)
+	}
+	if renamed.UserName != "john" || renamed.Title != "Dune" || renamed.ID == 0 {
+		t.Errorf("rewritten entry = %q %q id=%d", renamed.UserName, renamed.Title, renamed.ID)
+	}
+
+	inserted, skipped, _, err := s.InsertHistoryBatch(ctx, []*models.WatchHistoryEntry{
+		entry("alice", "Cartoon", "cartoon", models.MediaTypeMovie, 1),
+		entry("JDOE", "News", "news", models.MediaTypeLiveTV, 2),
+		entry("alice", "Arrival", "arrival", models.MediaTypeMovie, 3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted != 1 || skipped != 2 {
+		t.Errorf("batch inserted=%d skipped=%d, want 1 and 2", inserted, skipped)
+	}
+
+	preview := entry("jdoe", "Heat (1995)", "heat", models.MediaTypeMovie, 4)
+	if _, _, _, err := s.PreviewHistoryBatch(ctx, []*models.WatchHistoryEntry{preview}); err != nil {
+		t.Fatal(err)
+	}
+	if preview.UserName != "jdoe" || preview.Title != "Heat (1995)" {
+		t.Errorf("preview rewrote the caller's entry: %q %q", preview.UserName, preview.Title)
+	}
+}
+
+func TestAddHistoryHookRunsBeforeRules(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	s.AddHistoryHook(func(_ context.Context, e *models.WatchHistoryEntry) bool {
+		e.UserName = strings.TrimSpace(e.UserName)
+		return true
+	})
+	if err := s.SetHistoryRuleSettings(models.HistoryRuleSettings{Rules: []models.HistoryRule{
+		{Enabled: true, Action: models.HistoryRuleSkip, UserName: "bot"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := makeHistoryEntry(serverID, "  bot ", "Movie", time.Now().UTC())
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != 0 {
+		t.Error("hook ran after the rules: entry was written")
+	}
+}
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+// OrphanLinkResult summarizes one LinkOrphanedHistory pass.
+type OrphanLinkResult struct {
+	Groups    int `json:"groups"`
+	Matched   int `json:"matched"`
+	Ambiguous int `json:"ambiguous"`
+	Unmatched int `json:"unmatched"`
+	Linked    int `json:"linked"`
+}
+
+// orphanGroup is one (server, title, year) bucket of history rows that share
+// the same missing library key. Movies key on title+year and backfill
+// item_id; episodes key on the series title and backfill grandparent_item_id
+// (episode rows carry the episode's year, not the show's, so year is ignored).
+type orphanGroup struct {
+	serverID  int64
+	mediaType models.MediaType
+	title     string
+	year      int
+}
+
+type orphanCandidate struct {
+	itemID string
+	year   int
+	extKey string
+}
+
+// LinkOrphanedHistory backfills item_id (movies) and grandparent_item_id
+// (episodes) on legacy watch_history rows that predate ID capture, by
+// matching them to the current library_items cache on the same server.
+//
+// A group is only linked when it resolves to exactly one title: several
+// library rows are accepted only when they share an external ID (the same
+// film in a 4K and a 1080p library), in which case the first-cached copy
+// wins. Anything else is counted as ambiguous and left alone, since a wrong
+// link would misattribute plays in stats and maintenance.
+func (s *Store) LinkOrphanedHistory(ctx context.Context) (OrphanLinkResult, error) {
+	var res OrphanLinkResult
+
+	groups, err := s.listOrphanGroups(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.Groups = len(groups)
+
+	type link struct {
+		group  orphanGroup
+		itemID string
+	}
+	var links []link
+	for _, g := range groups {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		cands, err := s.orphanCandidates(ctx, g)
+		if err != nil {
+			return res, err
+		}
+		itemID, found, ok := resolveOrphanCandidates(cands, g)
+		switch {
+		case !found:
+			res.Unmatched++
+		case !ok:
+			res.Ambiguous++
+		default:
+			res.Matched++
+			links = append(links, link{group: g, itemID: itemID})
+		}
+	}
+
+	for _, chunk := range chunkSlice(links, writeChunkSize) {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return res, fmt.Errorf("begin tx: %w", err)
+		}
+		n := 0
+		for _, l := range chunk {
+			var result sql.Result
+			if l.group.mediaType == models.MediaTypeMovie {
+				result, err = tx.ExecContext(ctx,
+					`UPDATE watch_history SET item_id = ?
+					 WHERE server_id = ? AND media_type = ? AND item_id = '' AND title = ? AND year = ?`,
+					l.itemID, l.group.serverID, l.group.mediaType, l.group.title, l.group.year)
+			} else {
+				result, err = tx.ExecContext(ctx,
+					`UPDATE watch_history SET grandparent_item_id = ?
+					 WHERE server_id = ? AND media_type = ? AND grandparent_item_id = '' AND grandparent_title = ?`,
+					l.itemID, l.group.serverID, l.group.mediaType, l.group.title)
+			}
+			if err != nil {
+				tx.Rollback()
+				return res, fmt.Errorf("linking history %q: %w", l.group.title, err)
+			}
+			affected, _ := result.RowsAffected()
+			n += int(affected)
+		}
+		if err := tx.Commit(); err != nil {
+			return res, fmt.Errorf("commit tx: %w", err)
+		}
+		res.Linked += n
+	}
+
+	return res, nil
+}
+
+func (s *Store) listOrphanGroups(ctx context.Context) ([]orphanGroup, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT server_id, media_type, title, year FROM watch_history
+		 WHERE media_type = ? AND item_id = '' AND title != ''
+		 GROUP BY server_id, title, year
+		 UNION ALL
+		 SELECT server_id, media_type, grandparent_title, 0 FROM watch_history
+		 WHERE media_type = ? AND grandparent_item_id = '' AND grandparent_title != ''
+		 GROUP BY server_id, grandparent_title`,
+		models.MediaTypeMovie, models.MediaTypeTV)
+	if err != nil {
+		return nil, fmt.Errorf("listing orphaned history: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []orphanGroup
+	for rows.Next() {
+		var g orphanGroup
+		if err := rows.Scan(&g.serverID, &g.mediaType, &g.title, &g.year); err != nil {
+			return nil, fmt.Errorf("scanning orphaned history: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func (s *Store) orphanCandidates(ctx context.Context, g orphanGroup) ([]orphanCandidate, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT item_id, year, tmdb_id, tvdb_id, imdb_id FROM library_items
+		 WHERE server_id = ? AND media_type = ? AND title = ? COLLATE NOCASE
+		 ORDER BY id`,
+		g.serverID, g.mediaType, g.title)
+	if err != nil {
+		return nil, fmt.Errorf("orphan candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var cands []orphanCandidate
+	for rows.Next() {
+		var c orphanCandidate
+		var ids models.ExternalIDs
+		if err := rows.Scan(&c.itemID, &c.year, &ids.TMDB, &ids.TVDB, &ids.IMDB); err != nil {
+			return nil, fmt.Errorf("scanning orphan candidate: %w", err)
+		}
+		c.extKey = ids.DedupeKey()
+		cands = append(cands, c)
+	}
+	return cands, rows.Err()
+}
+
+// resolveOrphanCandidates picks the library item a group should link to.
+// Movie groups with a known year drop candidates whose (known) year differs.
+// The remainder must all be one title: a single row, or several rows sharing
+// the same non-empty external ID. found reports whether any candidate
+// survived the year filter; ok whether they resolved to a single title.
+func resolveOrphanCandidates(cands []orphanCandidate, g orphanGroup) (itemID string, found, ok bool) {
+	if g.mediaType == models.MediaTypeMovie && g.year > 0 {
+		var filtered []orphanCandidate
+		for _, c := range cands {
+			if c.year == 0 || c.year == g.year {
+				filtered = append(filtered, c)
+			}
+		}
+		cands = filtered
+	}
+	switch len(cands) {
+	case 0:
+		return "", false, false
+	case 1:
+		return cands[0].itemID, true, true
+	}
+	key := cands[0].extKey
+	if key == "" {
+		return "", true, false
+	}
+	for _, c := range cands[1:] {
+		if !strings.EqualFold(c.extKey, key) {
+			return "", true, false
+		}
+	}
+	return cands[0].itemID, true, true
+}
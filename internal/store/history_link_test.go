@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestLinkOrphanedHistory(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	if err := s.SeedLibraryItemsForTest(ctx, []LibraryItemSeed{
+		{ServerID: serverID, LibraryID: "1", ItemID: "m1", MediaType: "movie", Title: "The Matrix", Year: 1999, AddedAt: "2024-01-01T00:00:00Z"},
+		{ServerID: serverID, LibraryID: "1", ItemID: "m2", MediaType: "movie", Title: "Dune", Year: 1984, AddedAt: "2024-01-01T00:00:00Z"},
+		{ServerID: serverID, LibraryID: "1", ItemID: "m3", MediaType: "movie", Title: "Dune", Year: 2021, AddedAt: "2024-01-01T00:00:00Z"},
+		{ServerID: serverID, LibraryID: "1", ItemID: "m4", MediaType: "movie", Title: "Heat", Year: 0, AddedAt: "2024-01-01T00:00:00Z"},
+		{ServerID: serverID, LibraryID: "1", ItemID: "m5", MediaType: "movie", Title: "Heat", Year: 0, AddedAt: "2024-01-01T00:00:00Z"},
+		{ServerID: serverID, LibraryID: "2", ItemID: "s1", MediaType: "episode", Title: "Severance", AddedAt: "2024-01-01T00:00:00Z"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2023, 6, 1, 20, 0, 0, 0, time.UTC)
+	insert := func(e *models.WatchHistoryEntry) {
+		t.Helper()
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matrix := makeHistoryEntry(serverID, "alice", "the matrix", base)
+	matrix.Year = 1999
+	insert(matrix)
+
+	dune := makeHistoryEntry(serverID, "alice", "Dune", base.Add(24*time.Hour))
+	dune.Year = 2021
+	insert(dune)
+
+	heat := makeHistoryEntry(serverID, "alice", "Heat", base.Add(48*time.Hour))
+	insert(heat)
+
+	unknown := makeHistoryEntry(serverID, "alice", "Not In Library", base.Add(72*time.Hour))
+	insert(unknown)
+
+	ep := makeHistoryEntry(serverID, "bob", "Good News About Hell", base.Add(96*time.Hour))
+	ep.MediaType = models.MediaTypeTV
+	ep.GrandparentTitle = "Severance"
+	ep.ItemID = "e1"
+	insert(ep)
+
+	linked := makeHistoryEntry(serverID, "bob", "The Matrix", base.Add(120*time.Hour))
+	linked.Year = 1999
+	linked.ItemID = "already"
+	insert(linked)
+
+	res, err := s.LinkOrphanedHistory(ctx)
+	if err != nil {
+		t.Fatalf("LinkOrphanedHistory: %v", err)
+	}
+	// "The Matrix" title casing differs but still matches (NOCASE); "Heat"
+	// has two copies with no shared external ID and must stay unlinked.
+	if res.Matched != 3 || res.Ambiguous != 1 || res.Unmatched != 1 || res.Linked != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	itemIDs := map[int64]string{}
+	gpIDs := map[int64]string{}
+	rows, err := s.db.Query(`SELECT id, item_id, grandparent_item_id FROM watch_history`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var item, gp string
+		if err := rows.Scan(&id, &item, &gp); err != nil {
+			t.Fatal(err)
+		}
+		itemIDs[id] = item
+		gpIDs[id] = gp
+	}
+
+	if itemIDs[matrix.ID] != "m1" {
+		t.Errorf("matrix item_id = %q, want m1", itemIDs[matrix.ID])
+	}
+	if itemIDs[dune.ID] != "m3" {
+		t.Errorf("dune item_id = %q, want m3 (year match)", itemIDs[dune.ID])
+	}
+	if itemIDs[heat.ID] != "" {
+		t.Errorf("heat should remain unlinked, got %q", itemIDs[heat.ID])
+	}
+	if gpIDs[ep.ID] != "s1" {
+		t.Errorf("episode grandparent_item_id = %q, want s1", gpIDs[ep.ID])
+	}
+	if itemIDs[linked.ID] != "already" {
+		t.Errorf("existing item_id overwritten: %q", itemIDs[linked.ID])
+	}
+
+	again, err := s.LinkOrphanedHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Linked != 0 {
+		t.Errorf("second pass linked %d rows, want 0", again.Linked)
+	}
+}
+
+func TestLinkOrphanedHistory_SharedExternalID(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	for _, id := range []string{"4k", "hd"} {
+		if err := s.SeedLibraryItemsForTest(ctx, []LibraryItemSeed{
+			{ServerID: serverID, LibraryID: id, ItemID: id, MediaType: "movie", Title: "Alien", Year: 1979, AddedAt: "2024-01-01T00:00:00Z"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.db.Exec(`UPDATE library_items SET tmdb_id = '348'`); err != nil {
+		t.Fatal(err)
+	}
+
+	e := makeHistoryEntry(serverID, "alice", "Alien", time.Now().UTC().Add(-time.Hour))
+	e.Year = 1979
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.LinkOrphanedHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Linked != 1 || res.Ambiguous != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	var itemID string
+	if err := s.db.QueryRow(`SELECT item_id FROM watch_history WHERE id = ?`, e.ID).Scan(&itemID); err != nil {
+		t.Fatal(err)
+	}
+	if itemID != "4k" {
+		t.Errorf("item_id = %q, want first cached copy 4k", itemID)
+	}
+}
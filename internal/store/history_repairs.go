@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// ErrRepairUndone is returned when undoing a repair that was already undone.
+var ErrRepairUndone = errors.New("repair has already been undone")
+
+const historyShiftSampleLimit = 10
+
+func historyShiftWhere(shift *models.HistoryTimeShift) (string, []any) {
+	where := `server_id = ? AND started_at >= ? AND started_at < ?`
+	args := []any{shift.ServerID, shift.From.UTC(), shift.To.UTC()}
+	if shift.CreatedFrom != nil {
+		where += ` AND created_at >= ?`
+		args = append(args, shift.CreatedFrom.UTC())
+	}
+	if shift.CreatedTo != nil {
+		where += ` AND created_at < ?`
+		args = append(args, shift.CreatedTo.UTC())
+	}
+	if shift.ImportedOnly {
+		where += ` AND tautulli_reference_id > 0`
+	}
+	return where, args
+}
+
+// PreviewHistoryTimeShift counts the rows a shift would move and returns the
+// earliest few with their shifted start times.
+func (s *Store) PreviewHistoryTimeShift(ctx context.Context, shift *models.HistoryTimeShift) (*models.HistoryTimeShiftPreview, error) {
+	where, args := historyShiftWhere(shift)
+
+	preview := &models.HistoryTimeShiftPreview{Samples: []models.HistoryTimeShiftSample{}}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM watch_history WHERE `+where, args...).Scan(&preview.Matched); err != nil {
+		return nil, fmt.Errorf("counting shifted history: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_name, title, started_at FROM watch_history
+		WHERE `+where+` ORDER BY started_at, id LIMIT ?`, append(args, historyShiftSampleLimit)...)
+	if err != nil {
+		return nil, fmt.Errorf("sampling shifted history: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sample models.HistoryTimeShiftSample
+		if err := rows.Scan(&sample.HistoryID, &sample.UserName, &sample.Title, &sample.StartedAt); err != nil {
+			return nil, fmt.Errorf("scanning shifted history: %w", err)
+		}
+		sample.ShiftedStartedAt = sample.StartedAt.Add(shift.Offset())
+		preview.Samples = append(preview.Samples, sample)
+	}
+	return preview, rows.Err()
+}
+
+// ApplyHistoryTimeShift moves the selected rows and their sessions by the
+// shift's offset and records which rows moved, so the repair can be undone.
+func (s *Store) ApplyHistoryTimeShift(ctx context.Context, shift *models.HistoryTimeShift, createdBy string) (*models.HistoryTimeRepair, error) {
+	if err := shift.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid time shift: %w", err)
+	}
+	where, args := historyShiftWhere(shift)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var repairID int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO history_time_repairs
+		(server_id, range_from, range_to, created_from, created_to, imported_only, offset_minutes, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		shift.ServerID, shift.From.UTC(), shift.To.UTC(), utcOrNil(shift.CreatedFrom), utcOrNil(shift.CreatedTo),
+		boolToInt(shift.ImportedOnly), shift.OffsetMinutes, createdBy,
+	).Scan(&repairID)
+	if err != nil {
+		return nil, fmt.Errorf("recording time repair: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO history_time_repair_rows (repair_id, history_id)
+		SELECT ?, id FROM watch_history WHERE `+where, append([]any{repairID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("recording repaired rows: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("checking rows affected: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE history_time_repairs SET row_count = ? WHERE id = ?`, n, repairID); err != nil {
+		return nil, fmt.Errorf("recording repaired row count: %w", err)
+	}
+
+	if err := shiftRepairRows(ctx, tx, repairID, shift.Offset()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing time repair: %w", err)
+	}
+	return s.GetHistoryTimeRepair(ctx, repairID)
+}
+
+// UndoHistoryTimeRepair moves a repair's rows back by its offset.
+func (s *Store) UndoHistoryTimeRepair(ctx context.Context, id int64) (*models.HistoryTimeRepair, error) {
+	repair, err := s.GetHistoryTimeRepair(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if repair.UndoneAt != nil {
+		return nil, ErrRepairUndone
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE history_time_repairs SET undone_at = ? WHERE id = ? AND undone_at IS NULL`,
+		time.Now().UTC(), id)
+	if err != nil {
+		return nil, fmt.Errorf("marking time repair undone: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrRepairUndone
+	}
+	if err := shiftRepairRows(ctx, tx, id, -repair.Offset()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing time repair undo: %w", err)
+	}
+	return s.GetHistoryTimeRepair(ctx, id)
+}
+
+type shiftedRow struct {
+	id                   int64
+	startedAt, stoppedAt time.Time
+}
+
+// shiftRepairRows moves the history rows recorded for a repair, and the
+// sessions under them, by offset. Times are shifted in Go rather than with
+// SQLite date functions so they keep the driver's storage format.
+func shiftRepairRows(ctx context.Context, tx *sql.Tx, repairID int64, offset time.Duration) error {
+	for _, table := range []struct{ name, selectSQL string }{
+		{"watch_history", `SELECT h.id, h.started_at, h.stopped_at FROM watch_history h
+			JOIN history_time_repair_rows r ON r.history_id = h.id WHERE r.repair_id = ?`},
+		{"watch_sessions", `SELECT ws.id, ws.started_at, ws.stopped_at FROM watch_sessions ws
+			JOIN history_time_repair_rows r ON r.history_id = ws.history_id WHERE r.repair_id = ?`},
+	} {
+		rows, err := tx.QueryContext(ctx, table.selectSQL, repairID)
+		if err != nil {
+			return fmt.Errorf("listing %s to shift: %w", table.name, err)
+		}
+		var shifted []shiftedRow
+		for rows.Next() {
+			var r shiftedRow
+			if err := rows.Scan(&r.id, &r.startedAt, &r.stoppedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("scanning %s to shift: %w", table.name, err)
+			}
+			shifted = append(shifted, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterating %s to shift: %w", table.name, err)
+		}
+
+		stmt, err := tx.PrepareContext(ctx, `UPDATE `+table.name+` SET started_at = ?, stopped_at = ? WHERE id = ?`)
+		if err != nil {
+			return fmt.Errorf("preparing %s shift: %w", table.name, err)
+		}
+		for _, r := range shifted {
+			if _, err := stmt.ExecContext(ctx, r.startedAt.Add(offset).UTC(), r.stoppedAt.Add(offset).UTC(), r.id); err != nil {
+				stmt.Close()
+				return fmt.Errorf("shifting %s %d: %w", table.name, r.id, err)
+			}
+		}
+		stmt.Close()
+	}
+	return nil
+}
+
+const historyTimeRepairColumns = `id, server_id, range_from, range_to, created_from, created_to, imported_only,
+	offset_minutes, row_count, created_by, created_at, undone_at`
+
+func scanHistoryTimeRepair(scanner interface{ Scan(...any) error }) (models.HistoryTimeRepair, error) {
+	var r models.HistoryTimeRepair
+	var createdFrom, createdTo, undoneAt sql.NullTime
+	var importedOnly int
+	err := scanner.Scan(&r.ID, &r.ServerID, &r.From, &r.To, &createdFrom, &createdTo, &importedOnly,
+		&r.OffsetMinutes, &r.RowCount, &r.CreatedBy, &r.CreatedAt, &undoneAt)
+	if err != nil {
+		return r, err
+	}
+	r.ImportedOnly = importedOnly != 0
+	if createdFrom.Valid {
+		r.CreatedFrom = &createdFrom.Time
+	}
+	if createdTo.Valid {
+		r.CreatedTo = &createdTo.Time
+	}
+	if undoneAt.Valid {
+		r.UndoneAt = &undoneAt.Time
+	}
+	return r, nil
+}
+
+func (s *Store) GetHistoryTimeRepair(ctx context.Context, id int64) (*models.HistoryTimeRepair, error) {
+	r, err := scanHistoryTimeRepair(s.db.QueryRowContext(ctx,
+		`SELECT `+historyTimeRepairColumns+` FROM history_time_repairs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("time repair %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting time repair: %w", err)
+	}
+	return &r, nil
+}
+
+// ListHistoryTimeRepairs returns every repair, newest first.
+func (s *Store) ListHistoryTimeRepairs(ctx context.Context) ([]models.HistoryTimeRepair, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+historyTimeRepairColumns+` FROM history_time_repairs ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("listing time repairs: %w", err)
+	}
+	defer rows.Close()
+
+	repairs := []models.HistoryTimeRepair{}
+	for rows.Next() {
+		r, err := scanHistoryTimeRepair(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning time repair: %w", err)
+		}
+		repairs = append(repairs, r)
+	}
+	return repairs, rows.Err()
+}
+
+func utcOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestHistoryTimeRepair(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	base := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	imported := makeHistoryEntry(serverID, "alice", "Imported", base)
+	imported.TautulliReferenceID = 42
+	live := makeHistoryEntry(serverID, "alice", "Live", base.Add(6*time.Hour))
+	outside := makeHistoryEntry(serverID, "alice", "Outside", base.Add(-48*time.Hour))
+	outside.TautulliReferenceID = 43
+	for _, e := range []*models.WatchHistoryEntry{imported, live, outside} {
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shift := &models.HistoryTimeShift{
+		ServerID:      serverID,
+		From:          base.Add(-time.Hour),
+		To:            base.Add(24 * time.Hour),
+		ImportedOnly:  true,
+		OffsetMinutes: -300,
+	}
+	preview, err := s.PreviewHistoryTimeShift(ctx, shift)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Matched != 1 || len(preview.Samples) != 1 || !preview.Samples[0].ShiftedStartedAt.Equal(base.Add(-5*time.Hour)) {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+
+	repair, err := s.ApplyHistoryTimeShift(ctx, shift, "admin")
+	if err != nil {
+		t.Fatalf("ApplyHistoryTimeShift: %v", err)
+	}
+	if repair.RowCount != 1 || repair.CreatedBy != "admin" || !repair.ImportedOnly || repair.UndoneAt != nil {
+		t.Fatalf("unexpected repair %+v", repair)
+	}
+
+	startedAt := func(id int64) time.Time {
+		t.Helper()
+		e, err := s.GetHistoryEntry(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.StartedAt
+	}
+	if got := startedAt(imported.ID); !got.Equal(base.Add(-5 * time.Hour)) {
+		t.Errorf("imported row started_at = %v, want shifted by -5h", got)
+	}
+	if got := startedAt(live.ID); !got.Equal(base.Add(6 * time.Hour)) {
+		t.Errorf("live row moved to %v", got)
+	}
+	sessions, err := s.ListSessionsForHistory(imported.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) == 0 {
+		t.Fatal("expected the imported row to have a session")
+	}
+	for _, ws := range sessions {
+		if !ws.StartedAt.Equal(base.Add(-5 * time.Hour)) {
+			t.Errorf("session started_at = %v, want shifted with its history row", ws.StartedAt)
+		}
+	}
+
+	undone, err := s.UndoHistoryTimeRepair(ctx, repair.ID)
+	if err != nil {
+		t.Fatalf("UndoHistoryTimeRepair: %v", err)
+	}
+	if undone.UndoneAt == nil {
+		t.Error("expected undone_at to be set")
+	}
+	if got := startedAt(imported.ID); !got.Equal(base) {
+		t.Errorf("after undo started_at = %v, want %v", got, base)
+	}
+	if _, err := s.UndoHistoryTimeRepair(ctx, repair.ID); !errors.Is(err, ErrRepairUndone) {
+		t.Fatalf("expected ErrRepairUndone undoing twice, got %v", err)
+	}
+	if _, err := s.UndoHistoryTimeRepair(ctx, 999); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing repair, got %v", err)
+	}
+
+	repairs, err := s.ListHistoryTimeRepairs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repairs) != 1 || repairs[0].ID != repair.ID {
+		t.Fatalf("unexpected repairs %+v", repairs)
+	}
+}
+
+func TestHistoryTimeShiftValidate(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, shift := range []models.HistoryTimeShift{
+		{From: from, To: from.Add(time.Hour), OffsetMinutes: 60},
+		{ServerID: 1, From: from, To: from, OffsetMinutes: 60},
+		{ServerID: 1, From: from, To: from.Add(time.Hour)},
+		{ServerID: 1, From: from, To: from.Add(time.Hour), OffsetMinutes: models.MaxTimeShiftMinutes + 1},
+	} {
+		if err := shift.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", shift)
+		}
+	}
+}
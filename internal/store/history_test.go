@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -61,6 +62,28 @@ func TestInsertAndListHistory(t *testing.T) {
 	}
 }
 
+func TestHistoryOriginalTitleRoundTrip(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+
+	entry := &models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "alice", MediaType: models.MediaTypeMovie,
+		Title: "The Boat", OriginalTitle: "Das Boot", Language: "ger", ContentRating: "R", Year: 1981,
+		StartedAt: time.Now().UTC().Add(-2 * time.Hour), StoppedAt: time.Now().UTC().Add(-1 * time.Hour),
+	}
+	if err := s.InsertHistory(entry); err != nil {
+		t.Fatalf("InsertHistory: %v", err)
+	}
+
+	got, err := s.GetHistoryEntry(entry.ID)
+	if err != nil {
+		t.Fatalf("GetHistoryEntry: %v", err)
+	}
+	if got.OriginalTitle != "Das Boot" || got.Language != "ger" || got.ContentRating != "R" {
+		t.Errorf("OriginalTitle/Language/ContentRating = %q/%q/%q, want Das Boot/ger/R", got.OriginalTitle, got.Language, got.ContentRating)
+	}
+}
+
 // TestInsertHistoryContextRespectsCancelledContext ensures a caller with an
 // already-cancelled context (e.g. a shutdown deadline that has passed) gets
 // a prompt error instead of blocking for the SQLite busy_timeout (5s in
@@ -201,6 +224,62 @@ func TestListHistoryWithServerIDs(t *testing.T) {
 	}
 }
 
+func TestQueryHistoryFilters(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	sid := seedServer(t, s)
+	now := time.Now().UTC().Truncate(time.Hour)
+
+	entries := []*models.WatchHistoryEntry{
+		{Title: "Heat", UserName: "bob", TranscodeDecision: models.TranscodeDecisionTranscode, VideoResolution: "4k",
+			Platform: "Roku", Player: "Living Room", IPAddress: "203.0.113.7", Watched: true, StartedAt: now.Add(-40 * 24 * time.Hour)},
+		{Title: "Ronin", UserName: "bob", TranscodeDecision: models.TranscodeDecisionTranscode, VideoResolution: "1080",
+			Platform: "Chrome", Player: "Laptop", IPAddress: "203.0.113.7", StartedAt: now.Add(-10 * 24 * time.Hour)},
+		{Title: "Thief", UserName: "bob", TranscodeDecision: models.TranscodeDecisionDirectPlay, VideoResolution: "1080",
+			Platform: "roku", Player: "Bedroom", IPAddress: "192.168.1.5", Watched: true, StartedAt: now.Add(-5 * 24 * time.Hour)},
+		{Title: "Collateral", UserName: "alice", TranscodeDecision: models.TranscodeDecisionTranscode, VideoResolution: "1080",
+			Platform: "Roku", Player: "Living Room", IPAddress: "198.51.100.2", StartedAt: now.Add(-2 * 24 * time.Hour)},
+	}
+	for _, e := range entries {
+		e.ServerID, e.MediaType, e.StoppedAt = sid, models.MediaTypeMovie, e.StartedAt.Add(time.Hour)
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		query HistoryQuery
+		want  []string
+	}{
+		{"transcodes by bob", HistoryQuery{UserName: "bob", TranscodeDecision: models.TranscodeDecisionTranscode}, []string{"Ronin", "Heat"}},
+		{"last month", HistoryQuery{UserName: "bob", TranscodeDecision: models.TranscodeDecisionTranscode,
+			StartedAfter: now.Add(-30 * 24 * time.Hour), StartedBefore: now}, []string{"Ronin"}},
+		{"resolution", HistoryQuery{VideoResolution: "4k"}, []string{"Heat"}},
+		{"platform ignores case", HistoryQuery{Platform: "ROKU"}, []string{"Collateral", "Thief", "Heat"}},
+		{"player", HistoryQuery{Player: "living room"}, []string{"Collateral", "Heat"}},
+		{"ip", HistoryQuery{IPAddress: "203.0.113.7"}, []string{"Ronin", "Heat"}},
+		{"watched", HistoryQuery{Watched: &yes}, []string{"Thief", "Heat"}},
+		{"unwatched", HistoryQuery{Watched: &no, UserName: "bob"}, []string{"Ronin"}},
+		{"media type", HistoryQuery{MediaType: models.MediaTypeTV}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.QueryHistory(1, 10, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range result.Items {
+				got = append(got, item.Title)
+			}
+			if !slices.Equal(got, tt.want) || result.Total != len(tt.want) {
+				t.Errorf("got %v (total %d), want %v", got, result.Total, tt.want)
+			}
+		})
+	}
+}
+
 func TestListHistoryPagination(t *testing.T) {
 	s := newTestStoreWithMigrations(t)
 
@@ -493,6 +572,35 @@ func TestInsertHistoryBatchSkipsDuplicates(t *testing.T) {
 	}
 }
 
+func TestPreviewHistoryBatchWritesNothing(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+
+	startedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := s.InsertHistory(makeHistoryEntry(serverID, "alice", "Existing Movie", startedAt)); err != nil {
+		t.Fatalf("pre-insert: %v", err)
+	}
+
+	entries := []*models.WatchHistoryEntry{
+		makeHistoryEntry(serverID, "alice", "Existing Movie", startedAt),
+		makeHistoryEntry(serverID, "bob", "New Episode", startedAt.Add(time.Hour)),
+		makeHistoryEntry(serverID, "bob", "New Episode", startedAt.Add(time.Hour)),
+	}
+
+	inserted, skipped, consolidated, err := s.PreviewHistoryBatch(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("PreviewHistoryBatch: %v", err)
+	}
+	if inserted != 1 || skipped != 2 || consolidated != 0 {
+		t.Errorf("preview = %d inserted, %d skipped, %d consolidated; want 1/2/0", inserted, skipped, consolidated)
+	}
+
+	result, _ := s.ListHistory(1, 10, "", "", "", nil)
+	if result.Total != 1 {
+		t.Fatalf("expected preview to leave history untouched, got %d entries", result.Total)
+	}
+}
+
 func TestInsertHistoryDedupWithinWindow(t *testing.T) {
 	s := newTestStoreWithMigrations(t)
 	serverID := seedServer(t, s)
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+const homeNetworksKey = "home_networks"
+
+// GetHomeNetworkSettings returns the admin-defined home networks, none by
+// default.
+func (s *Store) GetHomeNetworkSettings() (models.HomeNetworkSettings, error) {
+	settings := models.HomeNetworkSettings{CIDRs: []string{}}
+	val, err := s.GetSetting(homeNetworksKey)
+	if err != nil || val == "" {
+		return settings, err
+	}
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return models.HomeNetworkSettings{CIDRs: []string{}}, fmt.Errorf("parsing home networks: %w", err)
+	}
+	return settings, nil
+}
+
+// SetHomeNetworkSettings saves the home networks. Sessions are classified
+// when read, so the change applies to existing history too.
+func (s *Store) SetHomeNetworkSettings(settings models.HomeNetworkSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	val, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encoding home networks: %w", err)
+	}
+	return s.SetSetting(homeNetworksKey, string(val))
+}
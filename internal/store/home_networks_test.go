@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestHomeNetworkSettings(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	got, err := s.GetHomeNetworkSettings()
+	if err != nil {
+		t.Fatalf("GetHomeNetworkSettings: %v", err)
+	}
+	if got.CIDRs == nil || len(got.CIDRs) != 0 {
+		t.Errorf("default CIDRs = %v, want empty", got.CIDRs)
+	}
+
+	if err := s.SetHomeNetworkSettings(models.HomeNetworkSettings{CIDRs: []string{"bad"}}); err == nil {
+		t.Error("expected error for an invalid CIDR")
+	}
+	if err := s.SetHomeNetworkSettings(models.HomeNetworkSettings{CIDRs: []string{"203.0.113.9/24", "198.51.100.4"}}); err != nil {
+		t.Fatalf("SetHomeNetworkSettings: %v", err)
+	}
+	got, err = s.GetHomeNetworkSettings()
+	if err != nil {
+		t.Fatalf("GetHomeNetworkSettings: %v", err)
+	}
+	if want := []string{"203.0.113.0/24", "198.51.100.4/32"}; !slices.Equal(got.CIDRs, want) {
+		t.Errorf("CIDRs = %v, want %v", got.CIDRs, want)
+	}
+}
+
+func TestUserDetailStatsLocalSessions(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	for i, ip := range []string{"192.168.1.10", "203.0.113.5", "1.2.3.4", "1.2.3.4"} {
+		if err := s.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: serverID, UserName: "alice", MediaType: models.MediaTypeMovie,
+			Title: "M", WatchedMs: 3600000, IPAddress: ip,
+			StartedAt: now.Add(time.Duration(-i) * time.Hour), StoppedAt: now.Add(time.Duration(-i)*time.Hour + time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.SetCachedGeo(&models.GeoResult{IP: "203.0.113.5", City: "Home Town", Country: "US"})
+	s.SetCachedGeo(&models.GeoResult{IP: "1.2.3.4", City: "NYC", Country: "US"})
+
+	ctx := context.Background()
+	stats, err := s.UserDetailStats(ctx, "alice")
+	if err != nil {
+		t.Fatalf("UserDetailStats: %v", err)
+	}
+	if stats.LocalSessions != 1 {
+		t.Errorf("local_sessions = %d, want 1 (private address)", stats.LocalSessions)
+	}
+	if len(stats.Locations) != 2 || stats.Locations[0].City != "NYC" || stats.Locations[0].SessionCount != 2 {
+		t.Fatalf("locations = %+v, want NYC x2 then Home Town", stats.Locations)
+	}
+
+	if err := s.SetHomeNetworkSettings(models.HomeNetworkSettings{CIDRs: []string{"203.0.113.0/24"}}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = s.UserDetailStats(ctx, "alice")
+	if err != nil {
+		t.Fatalf("UserDetailStats: %v", err)
+	}
+	if stats.LocalSessions != 2 {
+		t.Errorf("local_sessions = %d, want 2", stats.LocalSessions)
+	}
+	if len(stats.Locations) != 1 || stats.Locations[0].City != "NYC" || stats.Locations[0].Percentage != 100 {
+		t.Errorf("locations = %+v, want only NYC at 100%%", stats.Locations)
+	}
+}
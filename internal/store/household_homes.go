@@ -0,0 +1,106 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+// GetManualHouseholdHome returns the home an admin assigned to userName, or
+// models.ErrNotFound when the member's home is learned.
+func (s *Store) GetManualHouseholdHome(userName string) (*models.HouseholdHome, error) {
+	h := models.HouseholdHome{UserName: userName, Confidence: 100, Source: models.HouseholdHomeManual}
+	var updatedAt string
+	err := s.db.QueryRow(`SELECT latitude, longitude, radius_km, updated_at FROM household_homes WHERE user_name = ?`,
+		userName).Scan(&h.Latitude, &h.Longitude, &h.RadiusKm, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting household home: %w", err)
+	}
+	if t, err := parseSQLiteTime(updatedAt); err == nil {
+		h.UpdatedAt = &t
+	}
+	return &h, nil
+}
+
+// ListManualHouseholdHomes returns every admin-assigned home keyed by user.
+func (s *Store) ListManualHouseholdHomes() (map[string]models.HouseholdHome, error) {
+	rows, err := s.db.Query(`SELECT user_name, latitude, longitude, radius_km, updated_at FROM household_homes`)
+	if err != nil {
+		return nil, fmt.Errorf("listing household homes: %w", err)
+	}
+	defer rows.Close()
+
+	homes := make(map[string]models.HouseholdHome)
+	for rows.Next() {
+		h := models.HouseholdHome{Confidence: 100, Source: models.HouseholdHomeManual}
+		var updatedAt string
+		if err := rows.Scan(&h.UserName, &h.Latitude, &h.Longitude, &h.RadiusKm, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scanning household home: %w", err)
+		}
+		if t, err := parseSQLiteTime(updatedAt); err == nil {
+			h.UpdatedAt = &t
+		}
+		homes[h.UserName] = h
+	}
+	return homes, rows.Err()
+}
+
+// SetHouseholdHome assigns a member's home, overriding the learned one.
+func (s *Store) SetHouseholdHome(h *models.HouseholdHome) error {
+	if err := h.Validate(); err != nil {
+		return fmt.Errorf("invalid household home: %w", err)
+	}
+	_, err := s.db.Exec(`INSERT INTO household_homes (user_name, latitude, longitude, radius_km) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_name) DO UPDATE SET latitude = excluded.latitude, longitude = excluded.longitude,
+		radius_km = excluded.radius_km, updated_at = CURRENT_TIMESTAMP`,
+		h.UserName, h.Latitude, h.Longitude, h.RadiusKm)
+	if err != nil {
+		return fmt.Errorf("setting household home: %w", err)
+	}
+	return nil
+}
+
+// DeleteHouseholdHome removes an assigned home so the learned one applies
+// again. It returns models.ErrNotFound when none was assigned.
+func (s *Store) DeleteHouseholdHome(userName string) error {
+	res, err := s.db.Exec(`DELETE FROM household_homes WHERE user_name = ?`, userName)
+	if err != nil {
+		return fmt.Errorf("deleting household home: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ListAllHouseholdLocations returns every member's household locations keyed
+// by user, most recently seen first. A non-empty serverIDs keeps only the
+// addresses each member streamed from on those servers.
+func (s *Store) ListAllHouseholdLocations(serverIDs []int64) (map[string][]models.HouseholdLocation, error) {
+	query := `SELECT ` + householdColumns + ` FROM household_locations`
+	serverCond, args := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		query += ` WHERE ip_address IN (SELECT ip_address FROM watch_history
+			WHERE user_name = household_locations.user_name AND ` + serverCond + `)`
+	}
+	rows, err := s.db.Query(query+` ORDER BY user_name, last_seen DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing household locations: %w", err)
+	}
+	defer rows.Close()
+
+	locations := make(map[string][]models.HouseholdLocation)
+	for rows.Next() {
+		h, err := scanHousehold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning household location: %w", err)
+		}
+		locations[h.UserName] = append(locations[h.UserName], h)
+	}
+	return locations, rows.Err()
+}
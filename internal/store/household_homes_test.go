@@ -0,0 +1,76 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestHouseholdHomes(t *testing.T) {
+	s := setupTestStore(t)
+
+	if _, err := s.GetManualHouseholdHome("alice"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	home := &models.HouseholdHome{UserName: "alice", Latitude: 40.7128, Longitude: -74.0060}
+	if err := s.SetHouseholdHome(home); err != nil {
+		t.Fatalf("SetHouseholdHome: %v", err)
+	}
+	got, err := s.GetManualHouseholdHome("alice")
+	if err != nil {
+		t.Fatalf("GetManualHouseholdHome: %v", err)
+	}
+	if got.Latitude != 40.7128 || got.RadiusKm != models.DefaultHouseholdHomeRadiusKm || got.Source != models.HouseholdHomeManual || got.Confidence != 100 {
+		t.Errorf("home = %+v", got)
+	}
+	if got.UpdatedAt == nil {
+		t.Error("expected updated_at")
+	}
+
+	home.RadiusKm = 5
+	if err := s.SetHouseholdHome(home); err != nil {
+		t.Fatalf("SetHouseholdHome update: %v", err)
+	}
+	homes, err := s.ListManualHouseholdHomes()
+	if err != nil {
+		t.Fatalf("ListManualHouseholdHomes: %v", err)
+	}
+	if len(homes) != 1 || homes["alice"].RadiusKm != 5 {
+		t.Errorf("homes = %+v", homes)
+	}
+
+	if err := s.SetHouseholdHome(&models.HouseholdHome{UserName: "bob", Latitude: 91, Longitude: 0}); err == nil {
+		t.Error("expected invalid latitude to be rejected")
+	}
+
+	if err := s.DeleteHouseholdHome("alice"); err != nil {
+		t.Fatalf("DeleteHouseholdHome: %v", err)
+	}
+	if err := s.DeleteHouseholdHome("alice"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("second delete: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListAllHouseholdLocations(t *testing.T) {
+	s := setupTestStore(t)
+	now := time.Now().UTC()
+	for _, loc := range []models.HouseholdLocation{
+		{UserName: "alice", IPAddress: "10.0.0.1", Trusted: true, FirstSeen: now, LastSeen: now},
+		{UserName: "alice", IPAddress: "10.0.0.2", Trusted: true, FirstSeen: now, LastSeen: now},
+		{UserName: "bob", IPAddress: "10.0.0.3", Trusted: true, FirstSeen: now, LastSeen: now},
+	} {
+		if err := s.UpsertHouseholdLocation(&loc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, err := s.ListAllHouseholdLocations(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all["alice"]) != 2 || len(all["bob"]) != 1 {
+		t.Errorf("locations = %+v", all)
+	}
+}
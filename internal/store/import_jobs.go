@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+const importJobColumns = `id, source, server_id, status, file_path, processed, total, inserted, skipped,
+	consolidated, errors, error, resumes, created_by, started_at, finished_at`
+
+func scanImportJob(scanner interface{ Scan(...any) error }) (models.ImportJob, error) {
+	var j models.ImportJob
+	var finishedAt sql.NullTime
+	err := scanner.Scan(&j.ID, &j.Source, &j.ServerID, &j.Status, &j.FilePath, &j.Processed, &j.Total,
+		&j.Inserted, &j.Skipped, &j.Consolidated, &j.Errors, &j.Error, &j.Resumes, &j.CreatedBy,
+		&j.StartedAt, &finishedAt)
+	if err != nil {
+		return j, err
+	}
+	if finishedAt.Valid {
+		j.FinishedAt = &finishedAt.Time
+	}
+	return j, nil
+}
+
+// CreateImportJob records a new running job and fills in its ID and start
+// time.
+func (s *Store) CreateImportJob(ctx context.Context, j *models.ImportJob) error {
+	j.Status = models.ImportJobRunning
+	created, err := scanImportJob(s.db.QueryRowContext(ctx,
+		`INSERT INTO import_jobs (source, server_id, status, file_path, created_by)
+		VALUES (?, ?, ?, ?, ?) RETURNING `+importJobColumns,
+		j.Source, j.ServerID, j.Status, j.FilePath, j.CreatedBy))
+	if err != nil {
+		return fmt.Errorf("creating import job: %w", err)
+	}
+	*j = created
+	return nil
+}
+
+// SaveImportJob writes a job's status, counters and error.
+func (s *Store) SaveImportJob(ctx context.Context, j *models.ImportJob) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE import_jobs SET status = ?, processed = ?, total = ?,
+		inserted = ?, skipped = ?, consolidated = ?, errors = ?, error = ?, resumes = ?, finished_at = ?
+		WHERE id = ?`,
+		j.Status, j.Processed, j.Total, j.Inserted, j.Skipped, j.Consolidated, j.Errors, j.Error,
+		j.Resumes, utcOrNil(j.FinishedAt), j.ID)
+	if err != nil {
+		return fmt.Errorf("saving import job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("import job %d: %w", j.ID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) GetImportJob(ctx context.Context, id int64) (*models.ImportJob, error) {
+	j, err := scanImportJob(s.db.QueryRowContext(ctx,
+		`SELECT `+importJobColumns+` FROM import_jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("import job %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting import job: %w", err)
+	}
+	return &j, nil
+}
+
+// ListImportJobs returns the most recent jobs, newest first.
+func (s *Store) ListImportJobs(ctx context.Context, limit int) ([]models.ImportJob, error) {
+	return s.queryImportJobs(ctx, `SELECT `+importJobColumns+` FROM import_jobs ORDER BY id DESC LIMIT ?`, limit)
+}
+
+// ListRunningImportJobs returns the jobs still marked running, oldest first.
+// At startup these are the jobs a restart interrupted.
+func (s *Store) ListRunningImportJobs(ctx context.Context) ([]models.ImportJob, error) {
+	return s.queryImportJobs(ctx, `SELECT `+importJobColumns+` FROM import_jobs WHERE status = ? ORDER BY id`,
+		models.ImportJobRunning)
+}
+
+func (s *Store) queryImportJobs(ctx context.Context, query string, args ...any) ([]models.ImportJob, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing import jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.ImportJob{}
+	for rows.Next() {
+		j, err := scanImportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning import job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
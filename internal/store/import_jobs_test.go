@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestImportJobs(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	running := &models.ImportJob{Source: models.ImportSourceJellystat, ServerID: serverID, CreatedBy: "admin"}
+	if err := s.CreateImportJob(ctx, running); err != nil {
+		t.Fatal(err)
+	}
+	if running.ID == 0 || running.Status != models.ImportJobRunning || running.StartedAt.IsZero() {
+		t.Fatalf("unexpected created job: %+v", running)
+	}
+
+	done := &models.ImportJob{Source: models.ImportSourceTautulliDatabase, ServerID: serverID, FilePath: "/tmp/x.db"}
+	if err := s.CreateImportJob(ctx, done); err != nil {
+		t.Fatal(err)
+	}
+	finished := time.Now().UTC()
+	done.Status = models.ImportJobCompleted
+	done.Processed, done.Total, done.Inserted, done.Skipped, done.Consolidated, done.Errors = 10, 10, 7, 2, 1, 3
+	done.FinishedAt = &finished
+	if err := s.SaveImportJob(ctx, done); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetImportJob(ctx, done.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.ImportJobCompleted || got.Inserted != 7 || got.Skipped != 2 || got.Consolidated != 1 ||
+		got.Errors != 3 || got.FilePath != "/tmp/x.db" || got.FinishedAt == nil {
+		t.Errorf("unexpected saved job: %+v", got)
+	}
+
+	jobs, err := s.ListImportJobs(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != done.ID {
+		t.Fatalf("expected both jobs newest first, got %+v", jobs)
+	}
+
+	pending, err := s.ListRunningImportJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != running.ID {
+		t.Fatalf("expected only the running job, got %+v", pending)
+	}
+
+	if _, err := s.GetImportJob(ctx, 999); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.SaveImportJob(ctx, &models.ImportJob{ID: 999}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected ErrNotFound saving unknown job, got %v", err)
+	}
+}
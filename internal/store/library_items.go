@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -13,13 +14,13 @@ import (
 
 const libraryItemColumns = `id, server_id, library_id, item_id, media_type, title, year,
 	added_at, last_watched_at, video_resolution, video_width, video_height, file_size, episode_count, thumb_url,
-	tmdb_id, tvdb_id, imdb_id, tmdb_status, synced_at`
+	tmdb_id, tvdb_id, imdb_id, content_rating, tmdb_status, collections, synced_at`
 
 const libraryItemUpsertSQL = `
 	INSERT INTO library_items (server_id, library_id, item_id, media_type, title, year,
 		added_at, last_watched_at, video_resolution, video_width, video_height, file_size, episode_count, thumb_url,
-		tmdb_id, tvdb_id, imdb_id, tmdb_status, synced_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		tmdb_id, tvdb_id, imdb_id, content_rating, tmdb_status, collections, synced_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(server_id, item_id) DO UPDATE SET
 		library_id = excluded.library_id,
 		media_type = excluded.media_type,
@@ -40,30 +41,44 @@ const libraryItemUpsertSQL = `
 		tmdb_id = excluded.tmdb_id,
 		tvdb_id = excluded.tvdb_id,
 		imdb_id = excluded.imdb_id,
+		content_rating = excluded.content_rating,
 		tmdb_status = CASE
 			WHEN excluded.tmdb_status != '' THEN excluded.tmdb_status
 			ELSE library_items.tmdb_status
 		END,
+		collections = excluded.collections,
 		synced_at = excluded.synced_at`
 
 func execLibraryItemUpsert(ctx context.Context, stmt *sql.Stmt, item models.LibraryItemCache, syncTime time.Time) error {
-	_, err := stmt.ExecContext(ctx, item.ServerID, item.LibraryID, item.ItemID,
+	collections := item.Collections
+	if collections == nil {
+		collections = []string{}
+	}
+	collectionsJSON, err := json.Marshal(collections)
+	if err != nil {
+		return fmt.Errorf("encoding collections: %w", err)
+	}
+	_, err = stmt.ExecContext(ctx, item.ServerID, item.LibraryID, item.ItemID,
 		item.MediaType, item.Title, item.Year, item.AddedAt, item.LastWatchedAt,
 		item.VideoResolution, item.VideoWidth, item.VideoHeight, item.FileSize, item.EpisodeCount, normalizeThumbURL(item.ThumbURL),
-		item.TMDBID, item.TVDBID, item.IMDBID, item.TMDBStatus, syncTime)
+		item.TMDBID, item.TVDBID, item.IMDBID, item.ContentRating, item.TMDBStatus, string(collectionsJSON), syncTime)
 	return err
 }
 
 func scanLibraryItem(scanner interface{ Scan(...any) error }) (models.LibraryItemCache, error) {
 	var item models.LibraryItemCache
 	var lastWatchedAt sql.NullString
+	var collections string
 	err := scanner.Scan(&item.ID, &item.ServerID, &item.LibraryID, &item.ItemID,
 		&item.MediaType, &item.Title, &item.Year, &item.AddedAt, &lastWatchedAt,
 		&item.VideoResolution, &item.VideoWidth, &item.VideoHeight, &item.FileSize, &item.EpisodeCount, &item.ThumbURL,
-		&item.TMDBID, &item.TVDBID, &item.IMDBID, &item.TMDBStatus, &item.SyncedAt)
+		&item.TMDBID, &item.TVDBID, &item.IMDBID, &item.ContentRating, &item.TMDBStatus, &collections, &item.SyncedAt)
 	if err != nil {
 		return item, err
 	}
+	if err := json.Unmarshal([]byte(collections), &item.Collections); err != nil {
+		return item, fmt.Errorf("decoding collections: %w", err)
+	}
 	if lastWatchedAt.Valid && lastWatchedAt.String != "" {
 		t, parseErr := parseSQLiteTime(lastWatchedAt.String)
 		if parseErr == nil {
@@ -105,6 +120,28 @@ func (s *Store) UpsertLibraryItems(ctx context.Context, items []models.LibraryIt
 	return count, tx.Commit()
 }
 
+// LibraryIDForItem returns the library holding the first of itemIDs found in
+// serverID's library cache, or "" when none of them have been synced.
+func (s *Store) LibraryIDForItem(ctx context.Context, serverID int64, itemIDs ...string) (string, error) {
+	for _, itemID := range itemIDs {
+		if itemID == "" {
+			continue
+		}
+		var libraryID string
+		err := s.db.QueryRowContext(ctx,
+			`SELECT library_id FROM library_items WHERE server_id = ? AND item_id = ?`,
+			serverID, itemID).Scan(&libraryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("looking up item library: %w", err)
+		}
+		return libraryID, nil
+	}
+	return "", nil
+}
+
 func (s *Store) GetLibraryItem(ctx context.Context, id int64) (*models.LibraryItemCache, error) {
 	item, err := scanLibraryItem(s.db.QueryRowContext(ctx,
 		`SELECT `+libraryItemColumns+` FROM library_items WHERE id = ?`, id))
@@ -373,7 +410,9 @@ func (s *Store) ListItemsForLibraries(ctx context.Context, libraries []models.Ru
 	return items, nil
 }
 
-func (s *Store) batchQueryTimes(ctx context.Context, itemIDs []int64, queryFn func(placeholders string) string, errLabel string, result map[int64]*time.Time) error {
+// batchQueryTimes runs queryFn over itemIDs in batches. leadingArgs bind to
+// any placeholders the query has before the item ID list.
+func (s *Store) batchQueryTimes(ctx context.Context, itemIDs []int64, queryFn func(placeholders string) string, errLabel string, result map[int64]*time.Time, leadingArgs ...any) error {
 	const batchSize = 200
 	for i := 0; i < len(itemIDs); i += batchSize {
 		end := i + batchSize
@@ -383,10 +422,11 @@ func (s *Store) batchQueryTimes(ctx context.Context, itemIDs []int64, queryFn fu
 		batch := itemIDs[i:end]
 
 		placeholders := make([]string, len(batch))
-		args := make([]any, len(batch))
+		args := make([]any, 0, len(leadingArgs)+len(batch))
+		args = append(args, leadingArgs...)
 		for j, id := range batch {
 			placeholders[j] = "?"
-			args[j] = id
+			args = append(args, id)
 		}
 
 		rows, err := s.db.QueryContext(ctx, queryFn(strings.Join(placeholders, ",")), args...)
@@ -487,6 +527,41 @@ func (s *Store) GetStreamMonWatchTimes(ctx context.Context, itemIDs []int64) (ma
 	return result, nil
 }
 
+// inProgressCTE aggregates recent partial plays (started but not counted as
+// watched) per item the same way watchAggCTE does, keyed by series for TV.
+const inProgressCTE = `
+WITH wh_partial AS MATERIALIZED (
+	SELECT server_id,
+	       COALESCE(NULLIF(grandparent_item_id, ''), item_id) AS k,
+	       MAX(started_at) AS last_played_at
+	FROM watch_history
+	WHERE watched = 0 AND watched_ms > 0 AND started_at >= ?
+	  AND COALESCE(NULLIF(grandparent_item_id, ''), item_id) != ''
+	GROUP BY server_id, k
+)`
+
+// GetInProgressWatchTimes returns, for each of the library items someone has
+// partially watched since the given time, when that last happened. A TV
+// show counts when any of its episodes was partially watched.
+func (s *Store) GetInProgressWatchTimes(ctx context.Context, itemIDs []int64, since time.Time) (map[int64]*time.Time, error) {
+	result := make(map[int64]*time.Time)
+	if len(itemIDs) == 0 {
+		return result, nil
+	}
+
+	err := s.batchQueryTimes(ctx, itemIDs, func(ph string) string {
+		return inProgressCTE + `
+			SELECT li.id, p.last_played_at
+			FROM library_items li
+			JOIN wh_partial p ON p.server_id = li.server_id AND p.k = li.item_id
+			WHERE li.id IN (` + ph + `)`
+	}, "in-progress watch times", result, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (s *Store) FindMatchingItems(ctx context.Context, item *models.LibraryItemCache) ([]models.LibraryItemCache, error) {
 	var clauses []string
 	var args []any
@@ -1452,3 +1452,108 @@ func TestGetAllLibraryTotalSizes(t *testing.T) {
 		t.Errorf("lib2 size = %d, want 5000", sizes[key2])
 	}
 }
+
+func TestLibrarySyncSchedules(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	if err := s.SetLibrarySyncSchedule(ctx, serverID, "lib1", models.LibrarySyncWeekly); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetLibrarySyncSchedule(ctx, serverID, "lib2", models.LibrarySyncOff); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetLibrarySyncSchedule(ctx, serverID, "lib1", models.LibrarySyncDaily); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetLibrarySyncSchedule(ctx, serverID, "lib3", "hourly"); err == nil {
+		t.Error("expected error for invalid schedule")
+	}
+
+	got, err := s.ListLibrarySyncSchedules(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[models.RuleLibrary]models.LibrarySyncSchedule{
+		{ServerID: serverID, LibraryID: "lib2"}: models.LibrarySyncOff,
+	}
+	if len(got) != len(want) || got[models.RuleLibrary{ServerID: serverID, LibraryID: "lib2"}] != models.LibrarySyncOff {
+		t.Errorf("schedules = %v, want %v", got, want)
+	}
+}
+
+func TestUpsertLibraryItemsCollections(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	srv := &models.Server{Name: "Test", Type: models.ServerTypePlex, URL: "http://test", APIKey: "key", Enabled: true}
+	if err := s.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	items := []models.LibraryItemCache{{
+		ServerID: srv.ID, LibraryID: "lib1", ItemID: "item1",
+		MediaType: models.MediaTypeMovie, Title: "Movie",
+		AddedAt: now, SyncedAt: now,
+		Collections: []string{"Keep Forever", "Marvel"},
+	}}
+	if _, err := s.UpsertLibraryItems(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.ListLibraryItems(ctx, srv.ID, "lib1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got[0].Collections) != 2 || got[0].Collections[1] != "Marvel" {
+		t.Fatalf("collections = %+v, want [Keep Forever Marvel]", got)
+	}
+
+	// Leaving a collection on the server drops it on the next sync.
+	items[0].Collections = nil
+	if _, err := s.UpsertLibraryItems(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.ListLibraryItems(ctx, srv.ID, "lib1")
+	if len(got[0].Collections) != 0 {
+		t.Errorf("collections after removal = %v, want none", got[0].Collections)
+	}
+}
+
+func TestGetInProgressWatchTimes(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	srvPlex, _ := seedMultiServerItems(t, s)
+
+	plexLib1, _ := s.ListLibraryItems(ctx, srvPlex.ID, "lib1")
+	inception := findByItemID(t, plexLib1, "plex-1")
+	matrix := findByItemID(t, plexLib1, "plex-2")
+
+	now := time.Now().UTC()
+	// Inception: a partial play last week. Matrix: finished last week and a
+	// partial play long ago.
+	for _, h := range []*models.WatchHistoryEntry{
+		{ServerID: srvPlex.ID, ItemID: "plex-1", UserName: "alice", MediaType: models.MediaTypeMovie, Title: "Inception",
+			DurationMs: 7200000, WatchedMs: 1800000, StartedAt: now.AddDate(0, 0, -7), StoppedAt: now.AddDate(0, 0, -7).Add(30 * time.Minute)},
+		{ServerID: srvPlex.ID, ItemID: "plex-2", UserName: "alice", MediaType: models.MediaTypeMovie, Title: "The Matrix",
+			DurationMs: 7200000, WatchedMs: 7200000, Watched: true, StartedAt: now.AddDate(0, 0, -7), StoppedAt: now.AddDate(0, 0, -7).Add(2 * time.Hour)},
+		{ServerID: srvPlex.ID, ItemID: "plex-2", UserName: "bob", MediaType: models.MediaTypeMovie, Title: "The Matrix",
+			DurationMs: 7200000, WatchedMs: 600000, StartedAt: now.AddDate(0, 0, -90), StoppedAt: now.AddDate(0, 0, -90).Add(10 * time.Minute)},
+	} {
+		if err := s.InsertHistory(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := s.GetInProgressWatchTimes(ctx, []int64{inception.ID, matrix.ID}, now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[inception.ID] == nil {
+		t.Error("expected Inception to be in progress")
+	}
+	if result[matrix.ID] != nil {
+		t.Errorf("expected Matrix not in progress, got %v", result[matrix.ID])
+	}
+}
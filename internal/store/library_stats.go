@@ -0,0 +1,95 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+// LibraryWatchStats returns watch stats for every library with cached items,
+// matching plays to items by server and item ID (episodes through their
+// series). Libraries are ordered by hours watched. Name and Type are left
+// for the caller, since only the media server knows them.
+func (s *Store) LibraryWatchStats(ctx context.Context, filter StatsFilter) ([]models.LibraryWatchStat, error) {
+	type libKey struct {
+		serverID  int64
+		libraryID string
+	}
+	byLib := make(map[libKey]*models.LibraryWatchStat)
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT li.server_id, COALESCE(sv.name, ''), li.library_id, COUNT(*), COALESCE(SUM(li.file_size), 0)
+		FROM library_items li
+		LEFT JOIN servers sv ON sv.id = li.server_id
+		GROUP BY li.server_id, li.library_id`)
+	if err != nil {
+		return nil, fmt.Errorf("library item counts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var st models.LibraryWatchStat
+		if err := rows.Scan(&st.ServerID, &st.ServerName, &st.LibraryID, &st.ItemCount, &st.TotalSize); err != nil {
+			return nil, fmt.Errorf("scanning library item counts: %w", err)
+		}
+		byLib[libKey{st.ServerID, st.LibraryID}] = &st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating library item counts: %w", err)
+	}
+
+	where, args := filter.conditionsWithPrefix(" WHERE ", "h")
+	rows, err = s.db.QueryContext(ctx,
+		`WITH wh AS (
+			SELECT h.server_id, COALESCE(NULLIF(h.grandparent_item_id, ''), h.item_id) AS k,
+				h.user_name, h.watched_ms, h.watched
+			FROM watch_history h`+where+`
+		)
+		SELECT li.server_id, li.library_id, COUNT(*), COALESCE(SUM(wh.watched_ms), 0),
+			COUNT(DISTINCT wh.user_name), COALESCE(SUM(wh.watched), 0), COUNT(DISTINCT li.id)
+		FROM wh
+		JOIN library_items li ON li.server_id = wh.server_id AND li.item_id = wh.k
+		GROUP BY li.server_id, li.library_id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("library watch stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key libKey
+		var plays, users, completed, items int
+		var watchedMs int64
+		if err := rows.Scan(&key.serverID, &key.libraryID, &plays, &watchedMs, &users, &completed, &items); err != nil {
+			return nil, fmt.Errorf("scanning library watch stats: %w", err)
+		}
+		st, ok := byLib[key]
+		if !ok {
+			continue
+		}
+		st.Plays = plays
+		st.TotalHours = msToHours(watchedMs)
+		st.UniqueUsers = users
+		st.ItemsPlayed = items
+		if plays > 0 {
+			st.CompletionRate = float64(completed) / float64(plays)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating library watch stats: %w", err)
+	}
+
+	stats := make([]models.LibraryWatchStat, 0, len(byLib))
+	for _, st := range byLib {
+		stats = append(stats, *st)
+	}
+	slices.SortFunc(stats, func(a, b models.LibraryWatchStat) int {
+		return cmp.Or(
+			cmp.Compare(b.TotalHours, a.TotalHours),
+			cmp.Compare(a.ServerID, b.ServerID),
+			strings.Compare(a.LibraryID, b.LibraryID),
+		)
+	})
+	return stats, nil
+}
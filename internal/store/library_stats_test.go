@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestLibraryWatchStats(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	now := time.Now().UTC()
+	if _, _, err := s.SyncLibraryItems(ctx, serverID, "movies", []models.LibraryItemCache{
+		{ServerID: serverID, LibraryID: "movies", ItemID: "m1", MediaType: models.MediaTypeMovie, Title: "Heat", FileSize: 100, AddedAt: now},
+		{ServerID: serverID, LibraryID: "movies", ItemID: "m2", MediaType: models.MediaTypeMovie, Title: "Alien", FileSize: 50, AddedAt: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.SyncLibraryItems(ctx, serverID, "anime", []models.LibraryItemCache{
+		{ServerID: serverID, LibraryID: "anime", ItemID: "s1", MediaType: models.MediaTypeTV, Title: "Show", FileSize: 800, AddedAt: now},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	play := func(user, itemID, seriesID string, watched bool) {
+		t.Helper()
+		e := makeHistoryEntry(serverID, user, "Play "+itemID, now.Add(-time.Hour))
+		e.ItemID, e.GrandparentItemID = itemID, seriesID
+		e.WatchedMs = e.DurationMs / 2
+		if watched {
+			e.WatchedMs = e.DurationMs
+			e.Watched = true
+		}
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	play("alice", "m1", "", true)
+	play("bob", "m1", "", false)
+	play("alice", "e1", "s1", true)
+	play("alice", "unknown", "", true)
+
+	stats, err := s.LibraryWatchStats(ctx, StatsFilter{Days: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	movies := stats[0]
+	if movies.LibraryID != "movies" || movies.Plays != 2 || movies.UniqueUsers != 2 || movies.TotalHours != 3 ||
+		movies.CompletionRate != 0.5 || movies.ItemsPlayed != 1 || movies.ItemCount != 2 || movies.TotalSize != 150 {
+		t.Errorf("movies = %+v", movies)
+	}
+	anime := stats[1]
+	if anime.LibraryID != "anime" || anime.Plays != 1 || anime.CompletionRate != 1 || anime.ServerName != "Test" {
+		t.Errorf("anime = %+v", anime)
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+// ListLibrarySyncSchedules returns every library with a non-default schedule.
+// Libraries missing from the result sync daily.
+func (s *Store) ListLibrarySyncSchedules(ctx context.Context) (map[models.RuleLibrary]models.LibrarySyncSchedule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT server_id, library_id, schedule FROM library_sync_schedules`)
+	if err != nil {
+		return nil, fmt.Errorf("listing library sync schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make(map[models.RuleLibrary]models.LibrarySyncSchedule)
+	for rows.Next() {
+		var lib models.RuleLibrary
+		var schedule models.LibrarySyncSchedule
+		if err := rows.Scan(&lib.ServerID, &lib.LibraryID, &schedule); err != nil {
+			return nil, fmt.Errorf("scanning library sync schedule: %w", err)
+		}
+		schedules[lib] = schedule
+	}
+	return schedules, rows.Err()
+}
+
+// SetLibrarySyncSchedule stores the schedule for one library. Setting it back
+// to daily removes the override.
+func (s *Store) SetLibrarySyncSchedule(ctx context.Context, serverID int64, libraryID string, schedule models.LibrarySyncSchedule) error {
+	if !schedule.Valid() {
+		return fmt.Errorf("invalid sync schedule %q", schedule)
+	}
+	if schedule == models.LibrarySyncDaily {
+		_, err := s.db.ExecContext(ctx,
+			`DELETE FROM library_sync_schedules WHERE server_id = ? AND library_id = ?`, serverID, libraryID)
+		if err != nil {
+			return fmt.Errorf("clearing library sync schedule: %w", err)
+		}
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO library_sync_schedules (server_id, library_id, schedule) VALUES (?, ?, ?)
+		 ON CONFLICT(server_id, library_id) DO UPDATE SET schedule = excluded.schedule, updated_at = CURRENT_TIMESTAMP`,
+		serverID, libraryID, schedule)
+	if err != nil {
+		return fmt.Errorf("setting library sync schedule: %w", err)
+	}
+	return nil
+}
@@ -132,13 +132,13 @@ var validCandidateSortColumns = map[string]string{
 	"status":     "i.tmdb_status",
 }
 
-// ListCandidatesForRule returns candidates with their library items, excluding excluded items.
+// ListCandidatesForRule returns candidates with their library items, excluding excluded and snoozed items.
 func (s *Store) ListCandidatesForRule(ctx context.Context, ruleID int64, opts models.CandidateListOptions) (*models.CandidatesResponse, error) {
 	var total int
 	var totalSize int64
 	var args []any
 
-	baseWhere := `c.rule_id = ? AND e.id IS NULL`
+	baseWhere := `c.rule_id = ? AND e.id IS NULL AND ` + candidateNotSnoozedSQL
 	args = append(args, ruleID)
 
 	if opts.ServerID > 0 && opts.LibraryID != "" {
@@ -232,7 +232,7 @@ func (s *Store) ListCandidatesForRule(ctx context.Context, ruleID int64, opts mo
 	}
 
 	var statuses []string
-	statusWhere := `c.rule_id = ? AND e.id IS NULL AND i.tmdb_status != ''`
+	statusWhere := `c.rule_id = ? AND e.id IS NULL AND i.tmdb_status != '' AND ` + candidateNotSnoozedSQL
 	statusArgs := []any{ruleID}
 	if opts.ServerID > 0 && opts.LibraryID != "" {
 		statusWhere += ` AND i.server_id = ? AND i.library_id = ?`
@@ -387,13 +387,36 @@ func (s *Store) populateOtherCopies(ctx context.Context, candidates []models.Mai
 	return nil
 }
 
-// CountCandidatesForRule returns the count of candidates for a rule, excluding excluded items
+// PendingMaintenance counts the candidates of enabled rules that are neither
+// excluded nor snoozed, limited to items on serverIDs when it isn't empty.
+func (s *Store) PendingMaintenance(ctx context.Context, serverIDs []int64) (models.MaintenancePending, error) {
+	var p models.MaintenancePending
+	serverCond, args := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		serverCond = " AND c.library_item_id IN (SELECT id FROM library_items WHERE " + serverCond + ")"
+	}
+	err := s.db.QueryRowContext(ctx, `
+		WITH pending AS (
+			SELECT c.rule_id, c.library_item_id FROM maintenance_candidates c
+			JOIN maintenance_rules r ON r.id = c.rule_id
+			LEFT JOIN maintenance_exclusions e ON c.library_item_id = e.library_item_id
+			WHERE r.enabled = 1 AND e.id IS NULL AND `+candidateNotSnoozedSQL+serverCond+`
+		)
+		SELECT COUNT(*), COALESCE(SUM(file_size), 0), (SELECT COUNT(DISTINCT rule_id) FROM pending)
+		FROM library_items WHERE id IN (SELECT library_item_id FROM pending)`, args...).Scan(&p.Items, &p.TotalSize, &p.Rules)
+	if err != nil {
+		return p, fmt.Errorf("pending maintenance: %w", err)
+	}
+	return p, nil
+}
+
+// CountCandidatesForRule returns the count of candidates for a rule, excluding excluded and snoozed items
 func (s *Store) CountCandidatesForRule(ctx context.Context, ruleID int64) (int, error) {
 	var count int
 	err := s.db.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM maintenance_candidates c
 		LEFT JOIN maintenance_exclusions e ON c.library_item_id = e.library_item_id
-		WHERE c.rule_id = ? AND e.id IS NULL`, ruleID).Scan(&count)
+		WHERE c.rule_id = ? AND e.id IS NULL AND `+candidateNotSnoozedSQL, ruleID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("count candidates for rule: %w", err)
 	}
@@ -571,7 +594,7 @@ func (s *Store) RecordDeleteAction(ctx context.Context, serverID int64, itemID,
 	return nil
 }
 
-// ListAllCandidatesForRule returns all candidates without pagination, excluding excluded items
+// ListAllCandidatesForRule returns all candidates without pagination, excluding excluded and snoozed items
 func (s *Store) ListAllCandidatesForRule(ctx context.Context, ruleID int64) ([]models.MaintenanceCandidate, error) {
 	rows, err := s.db.QueryContext(ctx, candidatePlayCountCTE+`
 		SELECT `+candidateSelectColumnsAgg+`
@@ -579,7 +602,7 @@ func (s *Store) ListAllCandidatesForRule(ctx context.Context, ruleID int64) ([]m
 		JOIN library_items i ON c.library_item_id = i.id
 		LEFT JOIN maintenance_exclusions e ON c.library_item_id = e.library_item_id
 		LEFT JOIN play_counts pc ON pc.server_id = i.server_id AND pc.k = i.item_id
-		WHERE c.rule_id = ? AND e.id IS NULL
+		WHERE c.rule_id = ? AND e.id IS NULL AND `+candidateNotSnoozedSQL+`
 		ORDER BY i.added_at DESC`, ruleID)
 	if err != nil {
 		return nil, fmt.Errorf("list all candidates: %w", err)
@@ -1521,3 +1521,43 @@ func TestListCandidatesForRuleSearchEscapesWildcards(t *testing.T) {
 		t.Errorf("search with _: total = %d, want 0 (should not match single char)", result.Total)
 	}
 }
+
+func TestPendingMaintenance(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	serverID, ruleID, itemID := seedMaintenanceTestData(t, s)
+	second, err := s.CreateMaintenanceRule(ctx, createTestRuleInput(models.RuleLibrary{ServerID: serverID, LibraryID: "lib1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{ruleID, second.ID} {
+		if err := s.BatchUpsertCandidates(ctx, id, []models.BatchCandidate{{LibraryItemID: itemID, Reason: "Test"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := s.PendingMaintenance(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Items != 1 || p.Rules != 2 || p.TotalSize != 1024*1024*1024 {
+		t.Errorf("pending = %+v, want the item once across 2 rules", p)
+	}
+	if p, err = s.PendingMaintenance(ctx, []int64{serverID + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if p.Items != 0 || p.Rules != 0 {
+		t.Errorf("pending on another server = %+v", p)
+	}
+
+	if _, err := s.CreateExclusions(ctx, []int64{itemID}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = s.PendingMaintenance(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if p.Items != 0 || p.Rules != 0 || p.TotalSize != 0 {
+		t.Errorf("pending after exclusion = %+v", p)
+	}
+}
@@ -290,7 +290,8 @@ func (s *Store) ListMaintenanceRulesWithCounts(ctx context.Context, serverID int
 			AND NOT EXISTS (
 				SELECT 1 FROM maintenance_exclusions e
 				WHERE e.library_item_id = c.library_item_id
-			)`
+			)
+			AND `+candidateNotSnoozedSQL
 
 	var args []any
 	if serverID > 0 || libraryID != "" {
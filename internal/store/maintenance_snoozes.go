@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// candidateNotSnoozedSQL filters out candidates (aliased c) with an active
+// snooze. snoozed_until is written in UTC at second precision so it compares
+// correctly against datetime('now').
+const candidateNotSnoozedSQL = `NOT EXISTS (
+	SELECT 1 FROM maintenance_snoozes sn
+	WHERE sn.rule_id = c.rule_id AND sn.library_item_id = c.library_item_id
+	AND sn.snoozed_until > datetime('now'))`
+
+// SnoozeCandidates hides the given candidates from their rule's candidate list
+// until until, replacing any existing snooze. Returns the number of candidates
+// snoozed; ids that no longer exist are ignored.
+func (s *Store) SnoozeCandidates(ctx context.Context, candidateIDs []int64, until time.Time, snoozedBy string) (int, error) {
+	if len(candidateIDs) == 0 {
+		return 0, nil
+	}
+
+	placeholders := make([]string, len(candidateIDs))
+	args := []any{until.UTC().Truncate(time.Second), snoozedBy}
+	for i, id := range candidateIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM maintenance_snoozes WHERE snoozed_until <= datetime('now')`); err != nil {
+		return 0, fmt.Errorf("purge expired snoozes: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO maintenance_snoozes (rule_id, library_item_id, snoozed_until, snoozed_by)
+		SELECT rule_id, library_item_id, ?, ? FROM maintenance_candidates
+		WHERE id IN (`+strings.Join(placeholders, ",")+`)
+		ON CONFLICT(rule_id, library_item_id) DO UPDATE SET
+			snoozed_until = excluded.snoozed_until,
+			snoozed_by = excluded.snoozed_by`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("snooze candidates: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("check rows affected: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestSnoozeCandidates(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	_, ruleID, itemID := seedMaintenanceTestData(t, s)
+	if err := s.BatchUpsertCandidates(ctx, ruleID, []models.BatchCandidate{{LibraryItemID: itemID, Reason: "Test"}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.ListCandidatesForRule(ctx, ruleID, models.CandidateListOptions{Page: 1, PerPage: 10})
+	if err != nil || len(resp.Items) != 1 {
+		t.Fatalf("ListCandidatesForRule: err=%v, resp=%+v", err, resp)
+	}
+	candidateID := resp.Items[0].ID
+
+	n, err := s.SnoozeCandidates(ctx, []int64{candidateID, 99999}, time.Now().Add(7*24*time.Hour), "admin@test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("snoozed = %d, want 1", n)
+	}
+
+	count, err := s.CountCandidatesForRule(ctx, ruleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("count while snoozed = %d, want 0", count)
+	}
+	resp, err = s.ListCandidatesForRule(ctx, ruleID, models.CandidateListOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 0 || len(resp.Items) != 0 {
+		t.Errorf("list while snoozed: total=%d items=%d, want 0", resp.Total, len(resp.Items))
+	}
+
+	// Re-evaluation replaces candidate rows but the snooze still applies.
+	if err := s.BatchUpsertCandidates(ctx, ruleID, []models.BatchCandidate{{LibraryItemID: itemID, Reason: "Again"}}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.ListAllCandidatesForRule(ctx, ruleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 0 {
+		t.Errorf("ListAllCandidatesForRule after re-evaluation = %d, want 0", len(all))
+	}
+
+	// An expired snooze no longer hides the candidate.
+	if _, err := s.db.ExecContext(ctx, `UPDATE maintenance_snoozes SET snoozed_until = ?`,
+		time.Now().UTC().Add(-time.Hour).Truncate(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	count, err = s.CountCandidatesForRule(ctx, ruleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("count after snooze expired = %d, want 1", count)
+	}
+}
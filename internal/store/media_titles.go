@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+var externalIDColumns = map[string]string{
+	models.ExternalIDTMDB: "tmdb_id",
+	models.ExternalIDTVDB: "tvdb_id",
+	models.ExternalIDIMDB: "imdb_id",
+}
+
+// ListLibraryCopies returns every library item on a live server carrying
+// the external ID, ordered by server.
+func (s *Store) ListLibraryCopies(ctx context.Context, ext models.ExternalID) ([]models.LibraryItemCache, error) {
+	column, ok := externalIDColumns[ext.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown external id provider %q", ext.Provider)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+libraryItemColumns+` FROM library_items
+		WHERE `+column+` = ? AND server_id IN (SELECT id FROM servers WHERE deleted_at IS NULL)
+		ORDER BY server_id, id`, ext.ID)
+	if err != nil {
+		return nil, fmt.Errorf("listing library copies: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.LibraryItemCache{}
+	for rows.Next() {
+		item, err := scanLibraryItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning library copy: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListCandidatesForItems returns the maintenance candidates flagging any of
+// the library items, with their rule names.
+func (s *Store) ListCandidatesForItems(ctx context.Context, itemIDs []int64) ([]models.MediaTitleCandidate, error) {
+	candidates := []models.MediaTitleCandidate{}
+	if len(itemIDs) == 0 {
+		return candidates, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT c.id, c.rule_id, r.name, c.library_item_id, c.reason, c.computed_at
+		FROM maintenance_candidates c
+		JOIN maintenance_rules r ON r.id = c.rule_id
+		WHERE c.library_item_id IN (`+strings.Repeat(",?", len(itemIDs))[1:]+`)
+		ORDER BY c.library_item_id, r.name`, int64Args(itemIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing candidates for items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.MediaTitleCandidate
+		if err := rows.Scan(&c.ID, &c.RuleID, &c.RuleName, &c.LibraryItemID, &c.Reason, &c.ComputedAt); err != nil {
+			return nil, fmt.Errorf("scanning candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// ListExclusionsForItems returns the maintenance exclusions covering any of
+// the library items.
+func (s *Store) ListExclusionsForItems(ctx context.Context, itemIDs []int64) ([]models.MaintenanceExclusion, error) {
+	exclusions := []models.MaintenanceExclusion{}
+	if len(itemIDs) == 0 {
+		return exclusions, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, library_item_id, excluded_by, excluded_at
+		FROM maintenance_exclusions
+		WHERE library_item_id IN (`+strings.Repeat(",?", len(itemIDs))[1:]+`)
+		ORDER BY library_item_id`, int64Args(itemIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing exclusions for items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.MaintenanceExclusion
+		if err := rows.Scan(&e.ID, &e.LibraryItemID, &e.ExcludedBy, &e.ExcludedAt); err != nil {
+			return nil, fmt.Errorf("scanning exclusion: %w", err)
+		}
+		exclusions = append(exclusions, e)
+	}
+	return exclusions, rows.Err()
+}
+
+func int64Args(ids []int64) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
@@ -34,6 +35,30 @@ func splitStatements(content string) []string {
 	return stmts
 }
 
+// SchemaTooNewError is returned by Migrate when the database has migrations
+// this build doesn't know about, usually after rolling back to an older
+// release. Running against a newer schema risks writing rows the newer
+// release can't read, so Migrate refuses.
+type SchemaTooNewError struct {
+	DatabaseVersion int
+	LatestKnown     int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("database schema is at version %d but this build only knows migrations up to %d; "+
+		"it was last run by a newer release of StreamMon. Run that release again, or restore a backup taken before upgrading",
+		e.DatabaseVersion, e.LatestKnown)
+}
+
+type migrationFile struct {
+	version int
+	name    string
+}
+
+// Migrate applies the migrations in migrationsDir that the database hasn't
+// had yet, in version order. It refuses a database whose schema is newer
+// than any migration here, and backs up an existing database before
+// changing it when the store has a backup directory.
 func (s *Store) Migrate(migrationsDir string) error {
 	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
 		version INTEGER PRIMARY KEY,
@@ -43,20 +68,44 @@ func (s *Store) Migrate(migrationsDir string) error {
 		return fmt.Errorf("creating migrations table: %w", err)
 	}
 
-	entries, err := os.ReadDir(migrationsDir)
+	files, err := readMigrationFiles(migrationsDir)
 	if err != nil {
-		return fmt.Errorf("reading migrations dir: %w", err)
+		return err
 	}
 
-	var files []string
-	for _, e := range entries {
-		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
-			files = append(files, e.Name())
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	current, latest := 0, 0
+	for v := range applied {
+		current = max(current, v)
+	}
+	var pending []migrationFile
+	for _, f := range files {
+		latest = max(latest, f.version)
+		if !applied[f.version] {
+			pending = append(pending, f)
+			applied[f.version] = true
 		}
 	}
-	sort.Strings(files)
+	if current > latest {
+		return &SchemaTooNewError{DatabaseVersion: current, LatestKnown: latest}
+	}
+	s.migrationsDir = migrationsDir
+	if len(pending) == 0 {
+		return nil
+	}
 
-	for _, f := range files {
+	if current > 0 && s.backupDir != "" {
+		path, err := s.backupBeforeMigrating(current, latest)
+		if err != nil {
+			return fmt.Errorf("backing up before migrating (not migrating): %w", err)
+		}
+		log.Printf("backed up database at schema version %d to %s", current, path)
+	}
+
+	for _, f := range pending {
 		if err := s.applyMigration(migrationsDir, f); err != nil {
 			return err
 		}
@@ -65,21 +114,57 @@ func (s *Store) Migrate(migrationsDir string) error {
 	return nil
 }
 
-func (s *Store) applyMigration(dir, f string) error {
-	parts := strings.SplitN(f, "_", 2)
-	version, err := strconv.Atoi(parts[0])
+// SchemaVersion returns the newest migration applied to the database, or 0
+// before any have been.
+func (s *Store) SchemaVersion() (int, error) {
+	var v sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return int(v.Int64), nil
+}
+
+func readMigrationFiles(dir string) ([]migrationFile, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("invalid migration filename %q: expected numeric prefix", f)
+		return nil, fmt.Errorf("reading migrations dir: %w", err)
 	}
 
-	var count int
-	if err := s.db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&count); err != nil {
-		return err
+	var files []migrationFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		parts := strings.SplitN(e.Name(), "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration filename %q: expected numeric prefix", e.Name())
+		}
+		files = append(files, migrationFile{version: version, name: e.Name()})
 	}
-	if count > 0 {
-		return nil
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+func (s *Store) appliedMigrations() (map[int]bool, error) {
+	rows, err := s.db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("reading applied migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("scanning applied migration: %w", err)
+		}
+		applied[v] = true
 	}
+	return applied, rows.Err()
+}
 
+func (s *Store) applyMigration(dir string, m migrationFile) error {
+	f, version := m.name, m.version
 	log.Printf("applying migration %s", f)
 	start := time.Now()
 
@@ -1,10 +1,12 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -83,6 +85,128 @@ func TestMigrateInvalidFilename(t *testing.T) {
 	}
 }
 
+func writeTestFile(t *testing.T, dir, name, sql string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "001_a.sql", "CREATE TABLE a (id INTEGER PRIMARY KEY);")
+	writeTestFile(t, dir, "002_b.sql", "CREATE TABLE b (id INTEGER PRIMARY KEY);")
+	if err := s.Migrate(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	older := t.TempDir()
+	writeTestFile(t, older, "001_a.sql", "CREATE TABLE a (id INTEGER PRIMARY KEY);")
+	err := s.Migrate(older)
+	var tooNew *SchemaTooNewError
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("expected SchemaTooNewError, got %v", err)
+	}
+	if tooNew.DatabaseVersion != 2 || tooNew.LatestKnown != 1 {
+		t.Errorf("got %+v", tooNew)
+	}
+
+	v, err := s.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 {
+		t.Errorf("SchemaVersion = %d, want 2", v)
+	}
+}
+
+func TestMigrateBacksUpExistingDatabase(t *testing.T) {
+	dataDir := t.TempDir()
+	backupDir := filepath.Join(dataDir, "backups")
+	s, err := New(filepath.Join(dataDir, "streammon.db"), WithBackupDir(backupDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "001_a.sql", "CREATE TABLE a (id INTEGER PRIMARY KEY);")
+	if err := s.Migrate(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupDir); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup of a new database, stat err = %v", err)
+	}
+
+	if _, err := s.db.Exec(`INSERT INTO a (id) VALUES (42)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Migrate(dir); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(backupDir); len(entries) != 0 {
+		t.Fatalf("expected no backup without pending migrations, got %d", len(entries))
+	}
+
+	writeTestFile(t, dir, "002_b.sql", "CREATE TABLE b (id INTEGER PRIMARY KEY);")
+	if err := s.Migrate(dir); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 backup, got %d", len(entries))
+	}
+
+	backup, err := New(filepath.Join(backupDir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+	if v, err := backup.SchemaVersion(); err != nil || v != 1 {
+		t.Errorf("backup SchemaVersion = %d, %v; want 1", v, err)
+	}
+	var id int
+	if err := backup.db.QueryRow(`SELECT id FROM a`).Scan(&id); err != nil || id != 42 {
+		t.Errorf("backup row = %d, %v; want 42", id, err)
+	}
+}
+
+func TestPrunePreMigrationBackups(t *testing.T) {
+	s := &Store{backupDir: t.TempDir()}
+	for i := range keepBackups + 2 {
+		name := fmt.Sprintf("%sv%03d-to-v%03d-2026010%dT000000Z.db", preMigrationBackupPrefix, 9-i, 10, i+1)
+		writeTestFile(t, s.backupDir, name, "")
+	}
+	writeTestFile(t, s.backupDir, "manual.db", "")
+
+	s.pruneBackups(preMigrationBackupPrefix)
+
+	entries, err := os.ReadDir(s.backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != keepBackups+1 {
+		t.Fatalf("got %v", names)
+	}
+	for _, gone := range []string{"20260101", "20260102"} {
+		for _, n := range names {
+			if strings.Contains(n, gone) {
+				t.Errorf("expected oldest backups removed, still have %s", n)
+			}
+		}
+	}
+}
+
 func TestSplitStatements(t *testing.T) {
 	tests := []struct {
 		name  string
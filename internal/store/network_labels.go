@@ -0,0 +1,120 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+// ErrNetworkLabelExists is returned when a label's name is already taken.
+var ErrNetworkLabelExists = errors.New("a network label with that name already exists")
+
+const networkLabelColumns = `id, name, cidrs, asns, created_at, updated_at`
+
+func scanNetworkLabel(scanner interface{ Scan(...any) error }) (models.NetworkLabel, error) {
+	var l models.NetworkLabel
+	var cidrs, asns string
+	if err := scanner.Scan(&l.ID, &l.Name, &cidrs, &asns, &l.CreatedAt, &l.UpdatedAt); err != nil {
+		return l, err
+	}
+	if err := json.Unmarshal([]byte(cidrs), &l.CIDRs); err != nil {
+		return l, fmt.Errorf("decoding network label cidrs: %w", err)
+	}
+	if err := json.Unmarshal([]byte(asns), &l.ASNs); err != nil {
+		return l, fmt.Errorf("decoding network label asns: %w", err)
+	}
+	return l, nil
+}
+
+func encodeNetworkLabel(l *models.NetworkLabel) (string, string, error) {
+	cidrs, err := json.Marshal(l.CIDRs)
+	if err != nil {
+		return "", "", fmt.Errorf("encoding network label cidrs: %w", err)
+	}
+	asns, err := json.Marshal(l.ASNs)
+	if err != nil {
+		return "", "", fmt.Errorf("encoding network label asns: %w", err)
+	}
+	return string(cidrs), string(asns), nil
+}
+
+// ListNetworkLabels returns every network label in name order.
+func (s *Store) ListNetworkLabels() ([]models.NetworkLabel, error) {
+	rows, err := s.db.Query(`SELECT ` + networkLabelColumns + ` FROM network_labels ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing network labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []models.NetworkLabel{}
+	for rows.Next() {
+		l, err := scanNetworkLabel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning network label: %w", err)
+		}
+		labels = append(labels, l)
+	}
+	return labels, rows.Err()
+}
+
+func (s *Store) CreateNetworkLabel(l *models.NetworkLabel) error {
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid network label: %w", err)
+	}
+	cidrs, asns, err := encodeNetworkLabel(l)
+	if err != nil {
+		return err
+	}
+	created, err := scanNetworkLabel(s.db.QueryRow(
+		`INSERT INTO network_labels (name, cidrs, asns) VALUES (?, ?, ?) RETURNING `+networkLabelColumns,
+		l.Name, cidrs, asns))
+	if isUniqueConstraintError(err) {
+		return ErrNetworkLabelExists
+	}
+	if err != nil {
+		return fmt.Errorf("creating network label: %w", err)
+	}
+	*l = created
+	return nil
+}
+
+// UpdateNetworkLabel replaces a label's name and networks. Sessions already
+// recorded keep the label they were tagged with.
+func (s *Store) UpdateNetworkLabel(l *models.NetworkLabel) error {
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid network label: %w", err)
+	}
+	cidrs, asns, err := encodeNetworkLabel(l)
+	if err != nil {
+		return err
+	}
+	updated, err := scanNetworkLabel(s.db.QueryRow(
+		`UPDATE network_labels SET name = ?, cidrs = ?, asns = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? RETURNING `+networkLabelColumns,
+		l.Name, cidrs, asns, l.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("network label %d: %w", l.ID, models.ErrNotFound)
+	}
+	if isUniqueConstraintError(err) {
+		return ErrNetworkLabelExists
+	}
+	if err != nil {
+		return fmt.Errorf("updating network label: %w", err)
+	}
+	*l = updated
+	return nil
+}
+
+func (s *Store) DeleteNetworkLabel(id int64) error {
+	res, err := s.db.Exec(`DELETE FROM network_labels WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting network label: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("network label %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
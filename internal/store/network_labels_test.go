@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestNetworkLabelCRUD(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	home := &models.NetworkLabel{Name: "Home", CIDRs: []string{"192.168.1.0/24"}}
+	if err := s.CreateNetworkLabel(home); err != nil {
+		t.Fatalf("CreateNetworkLabel: %v", err)
+	}
+	if home.ID == 0 || home.CreatedAt.IsZero() {
+		t.Fatalf("expected created label to be populated, got %+v", home)
+	}
+	if err := s.CreateNetworkLabel(&models.NetworkLabel{Name: "home", ASNs: []uint{1}}); !errors.Is(err, ErrNetworkLabelExists) {
+		t.Fatalf("expected ErrNetworkLabelExists for a duplicate name, got %v", err)
+	}
+	if err := s.CreateNetworkLabel(&models.NetworkLabel{Name: "Cellular", ASNs: []uint{21928}}); err != nil {
+		t.Fatal(err)
+	}
+
+	home.CIDRs = []string{"192.168.0.0/16"}
+	home.ASNs = []uint{7922}
+	if err := s.UpdateNetworkLabel(home); err != nil {
+		t.Fatalf("UpdateNetworkLabel: %v", err)
+	}
+
+	labels, err := s.ListNetworkLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[0].Name != "Cellular" || labels[1].CIDRs[0] != "192.168.0.0/16" || labels[1].ASNs[0] != 7922 {
+		t.Fatalf("unexpected labels %+v", labels)
+	}
+
+	if err := s.UpdateNetworkLabel(&models.NetworkLabel{ID: 999, Name: "Gone", ASNs: []uint{1}}); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating a missing label, got %v", err)
+	}
+	if err := s.DeleteNetworkLabel(home.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteNetworkLabel(home.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestNetworkLabelHistoryFilterAndStats(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+
+	now := time.Now().UTC()
+	for i, label := range []string{"Home", "Home", "VPN", ""} {
+		e := makeHistoryEntry(serverID, "alice", fmt.Sprintf("Movie %d", i), now.Add(-time.Duration(i+1)*3*time.Hour))
+		e.WatchedMs = e.DurationMs
+		e.NetworkLabel = label
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := s.QueryHistory(1, 10, HistoryQuery{NetworkLabel: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || len(result.Items) != 2 || result.Items[0].NetworkLabel != "Home" {
+		t.Fatalf("expected two Home sessions, got %+v", result)
+	}
+
+	stats, err := s.UserDetailStatsScoped(context.Background(), NameScope("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.NetworkLabels) != 2 {
+		t.Fatalf("expected two labelled networks, got %+v", stats.NetworkLabels)
+	}
+	top := stats.NetworkLabels[0]
+	if top.Label != "Home" || top.SessionCount != 2 || top.Percentage < 66 || top.Percentage > 67 {
+		t.Errorf("unexpected top network %+v", top)
+	}
+}
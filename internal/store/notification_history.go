@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// notificationHistoryRetention is how long notification history is kept.
+const notificationHistoryRetention = 90 * 24 * time.Hour
+
+// notificationHistoryPruneEvery spaces out the sweeps of expired history:
+// one every this many records.
+const notificationHistoryPruneEvery = 500
+
+const notificationRecordColumns = `id, COALESCE(rule_id, 0), rule_name, channel_id, channel_name, channel_type,
+	event, user_name, status, reason, error, COALESCE(retry_of, 0), created_at`
+
+func scanNotificationRecord(scanner interface{ Scan(...any) error }, extra ...any) (models.NotificationRecord, error) {
+	var rec models.NotificationRecord
+	dest := []any{&rec.ID, &rec.RuleID, &rec.RuleName, &rec.ChannelID, &rec.ChannelName, &rec.ChannelType,
+		&rec.Event, &rec.UserName, &rec.Status, &rec.Reason, &rec.Error, &rec.RetryOf, &rec.CreatedAt}
+	err := scanner.Scan(append(dest, extra...)...)
+	return rec, err
+}
+
+func nullableID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// RecordNotification adds rec to the notification history. Records older
+// than the retention period are swept out as new ones arrive.
+func (s *Store) RecordNotification(ctx context.Context, rec *models.NotificationRecord) error {
+	err := s.db.QueryRowContext(ctx, `INSERT INTO notification_history
+		(rule_id, rule_name, channel_id, channel_name, channel_type, event, user_name, status, reason, error, payload, retry_of)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at`,
+		nullableID(rec.RuleID), rec.RuleName, rec.ChannelID, rec.ChannelName, rec.ChannelType, rec.Event, rec.UserName,
+		rec.Status, rec.Reason, rec.Error, string(rec.Payload), nullableID(rec.RetryOf)).Scan(&rec.ID, &rec.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("recording notification: %w", err)
+	}
+	if rec.ID%notificationHistoryPruneEvery == 0 {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM notification_history WHERE created_at < ?`,
+			time.Now().UTC().Add(-notificationHistoryRetention)); err != nil {
+			return fmt.Errorf("pruning notification history: %w", err)
+		}
+	}
+	return nil
+}
+
+// NotificationHistoryFilters narrows ListNotificationHistory. Zero values
+// match everything.
+type NotificationHistoryFilters struct {
+	ChannelID int64
+	RuleID    int64
+	UserName  string
+	Status    models.NotificationStatus
+	Since     time.Time
+}
+
+// ListNotificationHistory returns a page of history, newest first, without
+// payloads.
+func (s *Store) ListNotificationHistory(ctx context.Context, page, perPage int, filters NotificationHistoryFilters) (*models.PaginatedResult[models.NotificationRecord], error) {
+	where := " WHERE 1=1"
+	var args []any
+	if filters.ChannelID > 0 {
+		where += " AND channel_id = ?"
+		args = append(args, filters.ChannelID)
+	}
+	if filters.RuleID > 0 {
+		where += " AND rule_id = ?"
+		args = append(args, filters.RuleID)
+	}
+	if filters.UserName != "" {
+		where += " AND user_name = ?"
+		args = append(args, filters.UserName)
+	}
+	if filters.Status != "" {
+		where += " AND status = ?"
+		args = append(args, filters.Status)
+	}
+	if !filters.Since.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, filters.Since.UTC())
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notification_history`+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("counting notification history: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+notificationRecordColumns+` FROM notification_history`+where+
+		` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing notification history: %w", err)
+	}
+	defer rows.Close()
+	items := []models.NotificationRecord{}
+	for rows.Next() {
+		rec, err := scanNotificationRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning notification history: %w", err)
+		}
+		items = append(items, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &models.PaginatedResult[models.NotificationRecord]{Items: items, Total: total, Page: page, PerPage: perPage}, nil
+}
+
+// GetNotificationRecord returns one history entry with its payload.
+func (s *Store) GetNotificationRecord(ctx context.Context, id int64) (*models.NotificationRecord, error) {
+	var payload string
+	rec, err := scanNotificationRecord(s.db.QueryRowContext(ctx,
+		`SELECT `+notificationRecordColumns+`, payload FROM notification_history WHERE id = ?`, id), &payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting notification record: %w", err)
+	}
+	if payload != "" {
+		rec.Payload = []byte(payload)
+	}
+	return &rec, nil
+}
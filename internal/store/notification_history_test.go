@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestRecordNotification(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	for _, rec := range []*models.NotificationRecord{
+		{RuleID: 3, RuleName: "Rule", ChannelID: 1, ChannelName: "Discord", ChannelType: models.ChannelTypeDiscord,
+			Event: models.NotificationEventRuleViolation, UserName: "alice", Status: models.NotificationSent},
+		{ChannelID: 1, ChannelName: "Discord", ChannelType: models.ChannelTypeDiscord,
+			Event: models.NotificationEventConcurrentRecord, Status: models.NotificationSuppressed, Reason: models.SuppressedQuietHours},
+	} {
+		if err := s.RecordNotification(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.ID == 0 || rec.CreatedAt.IsZero() {
+			t.Errorf("record not filled in: %+v", rec)
+		}
+	}
+
+	var suppressed int
+	var ruleID *int64
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM notification_history WHERE status = 'suppressed' AND reason = 'quiet_hours'`).
+		Scan(&suppressed); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.QueryRow(`SELECT rule_id FROM notification_history WHERE event = 'concurrent_record'`).Scan(&ruleID); err != nil {
+		t.Fatal(err)
+	}
+	if suppressed != 1 || ruleID != nil {
+		t.Errorf("suppressed = %d, rule_id = %v", suppressed, ruleID)
+	}
+}
+
+func TestListNotificationHistory(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	records := []*models.NotificationRecord{
+		{RuleID: 1, ChannelID: 1, ChannelType: models.ChannelTypeDiscord, UserName: "alice", Status: models.NotificationSent,
+			Event: models.NotificationEventRuleViolation, Payload: json.RawMessage(`{"event":"rule_violation"}`)},
+		{RuleID: 1, ChannelID: 2, ChannelType: models.ChannelTypeWebhook, UserName: "alice", Status: models.NotificationFailed,
+			Event: models.NotificationEventRuleViolation, Error: "server returned status 502"},
+		{RuleID: 2, ChannelID: 2, ChannelType: models.ChannelTypeWebhook, UserName: "bob", Status: models.NotificationFailed,
+			Event: models.NotificationEventRuleViolation},
+	}
+	for _, rec := range records {
+		if err := s.RecordNotification(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	retry := &models.NotificationRecord{RuleID: 1, ChannelID: 2, ChannelType: models.ChannelTypeWebhook, UserName: "alice",
+		Status: models.NotificationSent, Event: models.NotificationEventRuleViolation, RetryOf: records[1].ID}
+	if err := s.RecordNotification(ctx, retry); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := s.ListNotificationHistory(ctx, 1, 10, NotificationHistoryFilters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Total != 4 || all.Items[0].ID != retry.ID || all.Items[0].RetryOf != records[1].ID {
+		t.Errorf("all = %+v", all)
+	}
+	for _, item := range all.Items {
+		if item.Payload != nil {
+			t.Errorf("listing included payload for %d", item.ID)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		filters NotificationHistoryFilters
+		want    int
+	}{
+		"failed":         {NotificationHistoryFilters{Status: models.NotificationFailed}, 2},
+		"channel":        {NotificationHistoryFilters{ChannelID: 2}, 3},
+		"rule and user":  {NotificationHistoryFilters{RuleID: 1, UserName: "alice"}, 3},
+		"future since":   {NotificationHistoryFilters{Since: time.Now().Add(time.Hour)}, 0},
+		"recent entries": {NotificationHistoryFilters{Since: time.Now().Add(-time.Hour)}, 4},
+	} {
+		got, err := s.ListNotificationHistory(ctx, 1, 10, tc.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Total != tc.want || len(got.Items) != tc.want {
+			t.Errorf("%s: total = %d, items = %d, want %d", name, got.Total, len(got.Items), tc.want)
+		}
+	}
+
+	page, _ := s.ListNotificationHistory(ctx, 2, 3, NotificationHistoryFilters{})
+	if page.Total != 4 || len(page.Items) != 1 || page.Items[0].ID != records[0].ID {
+		t.Errorf("second page = %+v", page)
+	}
+
+	got, err := s.GetNotificationRecord(ctx, records[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Payload) != `{"event":"rule_violation"}` {
+		t.Errorf("payload = %s", got.Payload)
+	}
+	if _, err := s.GetNotificationRecord(ctx, 999); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("missing record: err = %v", err)
+	}
+}
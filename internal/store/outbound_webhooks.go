@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const outboundWebhookColumns = `id, name, url, events, enabled, last_delivery_at, last_status, last_error, created_at, updated_at`
+
+func scanOutboundWebhook(scanner interface{ Scan(...any) error }, extra ...any) (models.OutboundWebhook, error) {
+	var w models.OutboundWebhook
+	var events string
+	var lastDelivery sql.NullTime
+	dest := []any{&w.ID, &w.Name, &w.URL, &events, &w.Enabled, &lastDelivery, &w.LastStatus, &w.LastError, &w.CreatedAt, &w.UpdatedAt}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return w, err
+	}
+	if err := json.Unmarshal([]byte(events), &w.Events); err != nil {
+		return w, fmt.Errorf("decoding webhook events: %w", err)
+	}
+	if lastDelivery.Valid {
+		w.LastDeliveryAt = &lastDelivery.Time
+	}
+	return w, nil
+}
+
+// CreateOutboundWebhook stores w with a newly generated signing secret,
+// which is left in w.Secret.
+func (s *Store) CreateOutboundWebhook(w *models.OutboundWebhook) error {
+	if err := w.Validate(); err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+	secret, stored, err := s.newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return fmt.Errorf("encoding webhook events: %w", err)
+	}
+	err = s.db.QueryRow(`INSERT INTO outbound_webhooks (name, url, secret, events, enabled) VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at, updated_at`,
+		w.Name, w.URL, stored, string(events), w.Enabled).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("creating webhook: %w", err)
+	}
+	w.Secret = secret
+	return nil
+}
+
+func (s *Store) newWebhookSecret() (secret, stored string, err error) {
+	secret, err = generateToken()
+	if err != nil {
+		return "", "", fmt.Errorf("generating webhook secret: %w", err)
+	}
+	stored, err = s.encryptValue(secret)
+	if err != nil {
+		return "", "", fmt.Errorf("encrypting webhook secret: %w", err)
+	}
+	return secret, stored, nil
+}
+
+func (s *Store) GetOutboundWebhook(id int64) (*models.OutboundWebhook, error) {
+	w, err := scanOutboundWebhook(s.db.QueryRow(`SELECT `+outboundWebhookColumns+` FROM outbound_webhooks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook: %w", err)
+	}
+	return &w, nil
+}
+
+func (s *Store) ListOutboundWebhooks() ([]models.OutboundWebhook, error) {
+	rows, err := s.db.Query(`SELECT ` + outboundWebhookColumns + ` FROM outbound_webhooks ORDER BY name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks: %w", err)
+	}
+	defer rows.Close()
+	hooks := []models.OutboundWebhook{}
+	for rows.Next() {
+		w, err := scanOutboundWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning webhook: %w", err)
+		}
+		hooks = append(hooks, w)
+	}
+	return hooks, rows.Err()
+}
+
+// UpdateOutboundWebhook saves w's name, URL, events, and enabled flag. The
+// secret is only changed by RotateOutboundWebhookSecret.
+func (s *Store) UpdateOutboundWebhook(w *models.OutboundWebhook) error {
+	if err := w.Validate(); err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return fmt.Errorf("encoding webhook events: %w", err)
+	}
+	res, err := s.db.Exec(`UPDATE outbound_webhooks SET name = ?, url = ?, events = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, w.Name, w.URL, string(events), w.Enabled, w.ID)
+	if err != nil {
+		return fmt.Errorf("updating webhook: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DeleteOutboundWebhook(id int64) error {
+	res, err := s.db.Exec(`DELETE FROM outbound_webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// RotateOutboundWebhookSecret replaces the webhook's signing secret and
+// returns the new one in plaintext.
+func (s *Store) RotateOutboundWebhookSecret(id int64) (string, error) {
+	secret, stored, err := s.newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	res, err := s.db.Exec(`UPDATE outbound_webhooks SET secret = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, stored, id)
+	if err != nil {
+		return "", fmt.Errorf("saving webhook secret: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", models.ErrNotFound
+	}
+	return secret, nil
+}
+
+// GetOutboundWebhookWithSecret returns the webhook with its decrypted
+// signing secret, for sending.
+func (s *Store) GetOutboundWebhookWithSecret(id int64) (*models.OutboundWebhook, error) {
+	var stored string
+	w, err := scanOutboundWebhook(s.db.QueryRow(`SELECT `+outboundWebhookColumns+`, secret FROM outbound_webhooks WHERE id = ?`, id), &stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook: %w", err)
+	}
+	if w.Secret, err = s.decryptValue(stored); err != nil {
+		return nil, fmt.Errorf("decrypting webhook secret: %w", err)
+	}
+	return &w, nil
+}
+
+// ListWebhooksForEvent returns the enabled webhooks subscribed to event,
+// with their decrypted signing secrets. Webhooks whose secret can't be
+// decrypted are skipped.
+func (s *Store) ListWebhooksForEvent(ctx context.Context, event models.WebhookEvent) ([]models.OutboundWebhook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+outboundWebhookColumns+`, secret FROM outbound_webhooks
+		WHERE enabled = 1 AND EXISTS (SELECT 1 FROM json_each(outbound_webhooks.events) WHERE value = ?)
+		ORDER BY id`, event)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks for %s: %w", event, err)
+	}
+	defer rows.Close()
+	var hooks []models.OutboundWebhook
+	for rows.Next() {
+		var stored string
+		w, err := scanOutboundWebhook(rows, &stored)
+		if err != nil {
+			return nil, fmt.Errorf("scanning webhook: %w", err)
+		}
+		if w.Secret, err = s.decryptValue(stored); err != nil {
+			continue
+		}
+		hooks = append(hooks, w)
+	}
+	return hooks, rows.Err()
+}
+
+// RecordWebhookDelivery notes the outcome of the webhook's latest delivery.
+func (s *Store) RecordWebhookDelivery(ctx context.Context, id int64, status int, errMsg string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE outbound_webhooks SET last_delivery_at = ?, last_status = ?, last_error = ? WHERE id = ?`,
+		time.Now().UTC(), status, errMsg, id)
+	if err != nil {
+		return fmt.Errorf("recording webhook delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestOutboundWebhooks(t *testing.T) {
+	s := testStoreWithEncryptor(t)
+	ctx := context.Background()
+
+	sessions := &models.OutboundWebhook{Name: "Sessions", URL: "https://hooks.example.com/a", Enabled: true,
+		Events: []models.WebhookEvent{models.WebhookEventSessionStarted, models.WebhookEventSessionStopped}}
+	deletes := &models.OutboundWebhook{Name: "Deletes", URL: "https://hooks.example.com/b", Enabled: true,
+		Events: []models.WebhookEvent{models.WebhookEventMaintenanceDeleted}}
+	for _, w := range []*models.OutboundWebhook{sessions, deletes} {
+		if err := s.CreateOutboundWebhook(w); err != nil {
+			t.Fatal(err)
+		}
+		if w.Secret == "" {
+			t.Fatalf("%s: no secret returned", w.Name)
+		}
+	}
+	if err := s.CreateOutboundWebhook(&models.OutboundWebhook{Name: "Bad", URL: "https://hooks.example.com",
+		Events: []models.WebhookEvent{models.WebhookEventTest}}); err == nil {
+		t.Error("expected the test event to be rejected")
+	}
+
+	var raw string
+	if err := s.db.QueryRow(`SELECT secret FROM outbound_webhooks WHERE id = ?`, sessions.ID).Scan(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, encryptedPrefix) {
+		t.Errorf("secret stored in plaintext: %q", raw)
+	}
+
+	got, err := s.GetOutboundWebhook(sessions.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Secret != "" || len(got.Events) != 2 {
+		t.Errorf("get = %+v", got)
+	}
+
+	hooks, err := s.ListWebhooksForEvent(ctx, models.WebhookEventSessionStopped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || hooks[0].ID != sessions.ID || hooks[0].Secret != sessions.Secret {
+		t.Errorf("session.stopped hooks = %+v", hooks)
+	}
+
+	secret, err := s.RotateOutboundWebhookSecret(sessions.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == sessions.Secret {
+		t.Error("rotating kept the old secret")
+	}
+	withSecret, err := s.GetOutboundWebhookWithSecret(sessions.ID)
+	if err != nil || withSecret.Secret != secret {
+		t.Errorf("after rotating = %+v, %v", withSecret, err)
+	}
+
+	sessions.Enabled = false
+	if err := s.UpdateOutboundWebhook(sessions); err != nil {
+		t.Fatal(err)
+	}
+	if hooks, _ := s.ListWebhooksForEvent(ctx, models.WebhookEventSessionStarted); len(hooks) != 0 {
+		t.Errorf("disabled webhook still listed: %+v", hooks)
+	}
+
+	if err := s.RecordWebhookDelivery(ctx, deletes.ID, 500, "unexpected status 500"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.GetOutboundWebhook(deletes.ID)
+	if got.LastDeliveryAt == nil || got.LastStatus != 500 || got.LastError == "" {
+		t.Errorf("after delivery = %+v", got)
+	}
+
+	if err := s.DeleteOutboundWebhook(deletes.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetOutboundWebhook(deletes.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("after delete: err = %v", err)
+	}
+	if list, _ := s.ListOutboundWebhooks(); len(list) != 1 {
+		t.Errorf("list = %+v", list)
+	}
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// minProgressMs is how far into an item a user must get before it counts as
+// in progress, so accidental starts don't fill continue-watching lists.
+const minProgressMs = 60_000
+
+const progressColumns = `server_id, user_name, item_id, grandparent_item_id, media_type, title, parent_title,
+	grandparent_title, year, season_number, episode_number, thumb_url, position_ms, duration_ms,
+	finished, first_started_at, last_played_at`
+
+const progressIsNewer = `excluded.last_played_at >= playback_progress.last_played_at`
+
+// progressUpsertSQL takes position, finished state, and metadata from the
+// newer of the stored row and the incoming session, so a rewatch reopens a
+// finished item but a late retry of an older session can't rewind it.
+// first_started_at keeps the earliest start either way.
+const progressUpsertSQL = `INSERT INTO playback_progress (` + progressColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (server_id, user_name, item_id) DO UPDATE SET
+		grandparent_item_id = CASE WHEN ` + progressIsNewer + ` THEN excluded.grandparent_item_id ELSE playback_progress.grandparent_item_id END,
+		title = CASE WHEN ` + progressIsNewer + ` THEN excluded.title ELSE playback_progress.title END,
+		parent_title = CASE WHEN ` + progressIsNewer + ` THEN excluded.parent_title ELSE playback_progress.parent_title END,
+		grandparent_title = CASE WHEN ` + progressIsNewer + ` THEN excluded.grandparent_title ELSE playback_progress.grandparent_title END,
+		thumb_url = CASE WHEN ` + progressIsNewer + ` THEN excluded.thumb_url ELSE playback_progress.thumb_url END,
+		position_ms = CASE WHEN ` + progressIsNewer + ` THEN excluded.position_ms ELSE playback_progress.position_ms END,
+		duration_ms = CASE WHEN ` + progressIsNewer + ` THEN excluded.duration_ms ELSE playback_progress.duration_ms END,
+		finished = CASE WHEN ` + progressIsNewer + ` THEN excluded.finished ELSE playback_progress.finished END,
+		first_started_at = MIN(playback_progress.first_started_at, excluded.first_started_at),
+		last_played_at = MAX(playback_progress.last_played_at, excluded.last_played_at)`
+
+// tracksProgress reports whether entry is a resumable item whose position
+// is worth recording.
+func tracksProgress(entry *models.WatchHistoryEntry) bool {
+	if entry.ItemID == "" || entry.DurationMs <= 0 || entry.TautulliReferenceID != 0 {
+		return false
+	}
+	switch entry.MediaType {
+	case models.MediaTypeMovie, models.MediaTypeTV, models.MediaTypeAudiobook:
+		return true
+	}
+	return false
+}
+
+// upsertPlaybackProgress records the position a live session ended at.
+func upsertPlaybackProgress(ctx context.Context, qe queryExecer, entry *models.WatchHistoryEntry) error {
+	if !tracksProgress(entry) {
+		return nil
+	}
+	_, err := qe.ExecContext(ctx, progressUpsertSQL,
+		entry.ServerID, entry.UserName, entry.ItemID, entry.GrandparentItemID, entry.MediaType,
+		entry.Title, entry.ParentTitle, entry.GrandparentTitle, entry.Year,
+		entry.SeasonNumber, entry.EpisodeNumber, normalizeThumbURL(entry.ThumbURL),
+		entry.WatchedMs, entry.DurationMs, boolToInt(entry.Watched),
+		entry.StartedAt.UTC(), entry.StoppedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("recording playback progress: %w", err)
+	}
+	return nil
+}
+
+func scanPlaybackProgress(scanner interface{ Scan(...any) error }) (models.PlaybackProgress, error) {
+	var p models.PlaybackProgress
+	var finished int
+	err := scanner.Scan(&p.ServerID, &p.UserName, &p.ItemID, &p.GrandparentItemID, &p.MediaType,
+		&p.Title, &p.ParentTitle, &p.GrandparentTitle, &p.Year, &p.SeasonNumber, &p.EpisodeNumber,
+		&p.ThumbURL, &p.PositionMs, &p.DurationMs, &finished, &p.FirstStartedAt, &p.LastPlayedAt)
+	p.Finished = finished != 0
+	return p, err
+}
+
+// ListContinueWatching returns a user's unfinished items, most recently
+// played first. limit <= 0 returns them all; a non-empty serverIDs keeps
+// only items on those servers.
+func (s *Store) ListContinueWatching(ctx context.Context, userName string, limit int, serverIDs []int64) ([]models.PlaybackProgress, error) {
+	args := []any{userName, minProgressMs}
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		serverCond = " AND " + serverCond
+		args = append(args, serverArgs...)
+	}
+	query := `SELECT ` + progressColumns + ` FROM playback_progress
+		WHERE user_name = ? AND finished = 0 AND position_ms >= ?` + serverCond + `
+		ORDER BY last_played_at DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing continue watching: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.PlaybackProgress{}
+	for rows.Next() {
+		p, err := scanPlaybackProgress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning playback progress: %w", err)
+		}
+		items = append(items, p)
+	}
+	return items, rows.Err()
+}
+
+// StaleInProgressByUser counts, per user, the items first started before
+// startedBefore that were never finished, users with the most first. A
+// non-empty serverIDs limits the count to items on those servers.
+func (s *Store) StaleInProgressByUser(ctx context.Context, startedBefore time.Time, serverIDs []int64) ([]models.StaleProgressSummary, error) {
+	args := []any{minProgressMs, startedBefore.UTC()}
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		serverCond = " AND " + serverCond
+		args = append(args, serverArgs...)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT user_name, COUNT(*), MIN(first_started_at), MAX(last_played_at)
+		FROM playback_progress
+		WHERE finished = 0 AND position_ms >= ? AND first_started_at < ?`+serverCond+`
+		GROUP BY user_name
+		ORDER BY COUNT(*) DESC, user_name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("counting stale progress: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []models.StaleProgressSummary{}
+	for rows.Next() {
+		var sum models.StaleProgressSummary
+		var oldest, latest string
+		if err := rows.Scan(&sum.UserName, &sum.Count, &oldest, &latest); err != nil {
+			return nil, fmt.Errorf("scanning stale progress: %w", err)
+		}
+		sum.OldestStarted, _ = parseSQLiteTime(oldest)
+		sum.LatestPlayedAt, _ = parseSQLiteTime(latest)
+		summaries = append(summaries, sum)
+	}
+	return summaries, rows.Err()
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func insertProgressEntry(t *testing.T, s *Store, serverID int64, user, itemID string, startedAt time.Time, watchedMs int64, watched bool) {
+	t.Helper()
+	e := makeHistoryEntry(serverID, user, "Title "+itemID, startedAt)
+	e.ItemID = itemID
+	e.WatchedMs = watchedMs
+	e.Watched = watched
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatalf("InsertHistory: %v", err)
+	}
+}
+
+func TestPlaybackProgressFromHistory(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	insertProgressEntry(t, s, serverID, "alice", "1", now.Add(-48*time.Hour), 30*60_000, false)
+	insertProgressEntry(t, s, serverID, "alice", "2", now.Add(-24*time.Hour), 20*60_000, false)
+	insertProgressEntry(t, s, serverID, "alice", "3", now.Add(-12*time.Hour), 10_000, false) // accidental start
+	insertProgressEntry(t, s, serverID, "alice", "4", now.Add(-6*time.Hour), 120*60_000, true)
+
+	items, err := s.ListContinueWatching(ctx, "alice", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ItemID != "2" || items[1].ItemID != "1" {
+		t.Fatalf("continue watching = %+v, want items 2 then 1", items)
+	}
+	if items[0].PositionMs != 20*60_000 {
+		t.Errorf("position = %d, want %d", items[0].PositionMs, 20*60_000)
+	}
+
+	// Finishing item 1 in a later session drops it from the list.
+	insertProgressEntry(t, s, serverID, "alice", "1", now.Add(-2*time.Hour), 120*60_000, true)
+	items, err = s.ListContinueWatching(ctx, "alice", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ItemID != "2" {
+		t.Fatalf("continue watching = %+v, want only item 2", items)
+	}
+}
+
+func TestPlaybackProgressIgnoresOlderSessions(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	insertProgressEntry(t, s, serverID, "bob", "1", now.Add(-3*time.Hour), 50*60_000, false)
+	// A late-arriving retry for an earlier session must not rewind progress.
+	insertProgressEntry(t, s, serverID, "bob", "1", now.Add(-30*time.Hour), 5*60_000, false)
+
+	items, err := s.ListContinueWatching(ctx, "bob", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].PositionMs != 50*60_000 {
+		t.Fatalf("continue watching = %+v, want position from latest session", items)
+	}
+	if !items[0].FirstStartedAt.Before(now.Add(-29 * time.Hour)) {
+		t.Errorf("first_started_at = %v, want earliest start", items[0].FirstStartedAt)
+	}
+}
+
+func TestStaleInProgressByUser(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	insertProgressEntry(t, s, serverID, "alice", "1", now.AddDate(0, 0, -40), 30*60_000, false)
+	insertProgressEntry(t, s, serverID, "alice", "2", now.AddDate(0, 0, -35), 30*60_000, false)
+	insertProgressEntry(t, s, serverID, "alice", "3", now.AddDate(0, 0, -5), 30*60_000, false)
+	insertProgressEntry(t, s, serverID, "bob", "1", now.AddDate(0, 0, -60), 120*60_000, true)
+
+	stale, err := s.StaleInProgressByUser(ctx, now.AddDate(0, 0, -30), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].UserName != "alice" || stale[0].Count != 2 {
+		t.Fatalf("stale = %+v, want alice with 2", stale)
+	}
+	if stale[0].OldestStarted.IsZero() || !stale[0].OldestStarted.Before(now.AddDate(0, 0, -39)) {
+		t.Errorf("oldest_started_at = %v", stale[0].OldestStarted)
+	}
+}
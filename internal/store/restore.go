@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// preRestoreBackupPrefix names the snapshot Restore takes of the live
+// database before replacing it.
+const preRestoreBackupPrefix = "pre-restore-"
+
+// ErrInvalidBackup is returned by Restore for a file that isn't a readable
+// StreamMon database.
+var ErrInvalidBackup = errors.New("not a valid StreamMon backup")
+
+// RestoreResult describes a completed restore.
+type RestoreResult struct {
+	// BackupVersion is the schema version the backup was taken at; it was
+	// migrated up to SchemaVersion before being restored.
+	BackupVersion int
+	SchemaVersion int
+	// SnapshotPath is the copy of the database as it was before the restore.
+	SnapshotPath string
+}
+
+// Restore replaces the database's contents with the backup at path. The
+// backup is checked and migrated to the current schema in place, so path
+// should be a scratch copy; a backup from a newer release is refused with a
+// *SchemaTooNewError. The live database is snapshotted to the backup
+// directory first, then every table is replaced in one transaction, so a
+// failure leaves it as it was.
+func (s *Store) Restore(ctx context.Context, path string) (*RestoreResult, error) {
+	if s.backupDir == "" {
+		return nil, errors.New("restoring needs a backup directory for the pre-restore snapshot")
+	}
+	if s.migrationsDir == "" {
+		return nil, errors.New("restoring needs the database to have been migrated first")
+	}
+
+	backupVersion, err := prepareRestore(path, s.migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.backupDir, 0700); err != nil {
+		return nil, fmt.Errorf("creating backup dir: %w", err)
+	}
+	snapshot := filepath.Join(s.backupDir, fmt.Sprintf("%sv%03d-%s.db", preRestoreBackupPrefix, version, time.Now().UTC().Format("20060102T150405Z")))
+	if err := s.BackupTo(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("taking pre-restore snapshot (not restoring): %w", err)
+	}
+	if err := os.Chmod(snapshot, 0600); err != nil {
+		return nil, fmt.Errorf("restricting snapshot permissions: %w", err)
+	}
+	s.pruneBackups(preRestoreBackupPrefix)
+
+	if err := s.replaceContents(ctx, path); err != nil {
+		return nil, err
+	}
+	return &RestoreResult{BackupVersion: backupVersion, SchemaVersion: version, SnapshotPath: snapshot}, nil
+}
+
+// prepareRestore checks that path holds an intact StreamMon database and
+// migrates it to the schema in migrationsDir, returning the version it was
+// at beforehand.
+func prepareRestore(path, migrationsDir string) (int, error) {
+	src, err := New(path)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer src.Close()
+
+	var check string
+	if err := src.db.QueryRow(`PRAGMA quick_check`).Scan(&check); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if check != "ok" {
+		return 0, fmt.Errorf("%w: integrity check failed: %s", ErrInvalidBackup, check)
+	}
+	var n int
+	if err := src.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	version := 0
+	if n > 0 {
+		if version, err = src.SchemaVersion(); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("%w: no schema version", ErrInvalidBackup)
+	}
+
+	if err := src.Migrate(migrationsDir); err != nil {
+		var tooNew *SchemaTooNewError
+		if errors.As(err, &tooNew) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("migrating backup: %w", err)
+	}
+	return version, nil
+}
+
+// replaceContents swaps every table's rows for those in the database at
+// path, which has the same schema. All rows are deleted before any are
+// copied so ON DELETE CASCADE can't reach rows already restored, and
+// foreign keys are checked once at commit.
+func (s *Store) replaceContents(ctx context.Context, path string) (err error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("restoring: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS restored`, path); err != nil {
+		return fmt.Errorf("attaching backup: %w", err)
+	}
+	defer func() {
+		if _, detachErr := conn.ExecContext(context.Background(), `DETACH DATABASE restored`); detachErr != nil && err == nil {
+			err = fmt.Errorf("detaching backup: %w", detachErr)
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("restoring: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `PRAGMA defer_foreign_keys = ON`); err != nil {
+		return fmt.Errorf("restoring: %w", err)
+	}
+	tables, err := restoreTables(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM main.`+quoteIdent(t)); err != nil {
+			return fmt.Errorf("clearing %s: %w", t, err)
+		}
+	}
+	for _, t := range tables {
+		cols, err := tableColumns(ctx, tx, "main", t)
+		if err != nil {
+			return err
+		}
+		backupCols, err := tableColumns(ctx, tx, "restored", t)
+		if err != nil {
+			return err
+		}
+		if len(backupCols) == 0 {
+			continue
+		}
+		if len(backupCols) != len(cols) {
+			return fmt.Errorf("restoring %s: backup has %d columns, database has %d", t, len(backupCols), len(cols))
+		}
+		list := strings.Join(cols, ", ")
+		if _, err := tx.ExecContext(ctx, `INSERT INTO main.`+quoteIdent(t)+` (`+list+`) SELECT `+list+` FROM restored.`+quoteIdent(t)); err != nil {
+			return fmt.Errorf("restoring %s: %w", t, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing restore: %w", err)
+	}
+	return nil
+}
+
+// restoreTables lists the database's tables, with sqlite_sequence (the
+// AUTOINCREMENT counters) last so it picks up the backup's values.
+func restoreTables(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM main.sqlite_master
+		WHERE type = 'table' AND (name NOT LIKE 'sqlite_%' OR name = 'sqlite_sequence')
+		ORDER BY name = 'sqlite_sequence', name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing tables: %w", err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning table: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// tableColumns returns table's quoted column names in schema, or none when
+// the schema has no such table.
+func tableColumns(ctx context.Context, tx *sql.Tx, schema, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(?, ?)`, table, schema)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s columns: %w", table, err)
+	}
+	defer rows.Close()
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning %s column: %w", table, err)
+		}
+		cols = append(cols, quoteIdent(name))
+	}
+	return cols, rows.Err()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
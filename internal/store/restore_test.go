@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	s, err := New(filepath.Join(dataDir, "streammon.db"), WithBackupDir(filepath.Join(dataDir, "backups")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "001_a.sql", `CREATE TABLE parent (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id) ON DELETE CASCADE);`)
+	if err := s.Migrate(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO parent (name) VALUES ('kept'); INSERT INTO child (id, parent_id) VALUES (1, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	if err := s.BackupTo(ctx, backup); err != nil {
+		t.Fatal(err)
+	}
+
+	// The live database moves on: a new migration and different rows.
+	writeTestFile(t, dir, "002_b.sql", `ALTER TABLE parent ADD COLUMN note TEXT NOT NULL DEFAULT 'none'`)
+	if err := s.Migrate(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec(`DELETE FROM parent; INSERT INTO parent (name) VALUES ('lost'), ('lost too')`); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Restore(ctx, backup)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if res.BackupVersion != 1 || res.SchemaVersion != 2 {
+		t.Errorf("result = %+v", res)
+	}
+
+	var name, note string
+	if err := s.db.QueryRow(`SELECT name, note FROM parent`).Scan(&name, &note); err != nil || name != "kept" || note != "none" {
+		t.Errorf("parent = %q, %q, %v", name, note, err)
+	}
+	var children int
+	s.db.QueryRow(`SELECT COUNT(*) FROM child`).Scan(&children)
+	if children != 1 {
+		t.Errorf("children = %d, want 1", children)
+	}
+	var id int64
+	if err := s.db.QueryRow(`INSERT INTO parent (name) VALUES ('next') RETURNING id`).Scan(&id); err != nil || id != 2 {
+		t.Errorf("next id = %d, %v; want the backup's sequence", id, err)
+	}
+
+	snapshot, err := New(res.SnapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Close()
+	var lost int
+	snapshot.db.QueryRow(`SELECT COUNT(*) FROM parent WHERE name LIKE 'lost%'`).Scan(&lost)
+	if lost != 2 {
+		t.Errorf("snapshot has %d pre-restore rows, want 2", lost)
+	}
+}
+
+func TestRestoreRejectsBadBackups(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStoreWithMigrations(t, WithBackupDir(t.TempDir()))
+	if err := s.SetSetting("marker", "live"); err != nil {
+		t.Fatal(err)
+	}
+
+	junk := filepath.Join(t.TempDir(), "junk.db")
+	if err := os.WriteFile(junk, []byte("definitely not sqlite, just some bytes padding it out"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restore(ctx, junk); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("junk file: err = %v, want ErrInvalidBackup", err)
+	}
+
+	newer := filepath.Join(t.TempDir(), "newer.db")
+	if err := s.BackupTo(ctx, newer); err != nil {
+		t.Fatal(err)
+	}
+	ns, err := New(newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ns.db.Exec(`INSERT INTO schema_migrations (version) VALUES (9999)`); err != nil {
+		t.Fatal(err)
+	}
+	ns.Close()
+	var tooNew *SchemaTooNewError
+	if _, err := s.Restore(ctx, newer); !errors.As(err, &tooNew) {
+		t.Errorf("newer schema: err = %v, want SchemaTooNewError", err)
+	}
+
+	if v, _ := s.GetSetting("marker"); v != "live" {
+		t.Errorf("marker = %q after refused restores", v)
+	}
+}
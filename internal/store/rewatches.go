@@ -0,0 +1,118 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"streammon/internal/models"
+)
+
+// MostRewatched ranks titles that users have watched to the end more than
+// once, most rewatched first. mediaType picks movies or series; empty
+// returns both. Only plays marked watched count as completions.
+func (s *Store) MostRewatched(ctx context.Context, limit int, filter StatsFilter, mediaType models.MediaType) ([]models.RewatchStat, error) {
+	stats := []models.RewatchStat{}
+	if mediaType == "" || mediaType == models.MediaTypeMovie {
+		movies, err := s.rewatchedMovies(ctx, limit, filter)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, movies...)
+	}
+	if mediaType == "" || mediaType == models.MediaTypeTV {
+		series, err := s.rewatchedSeries(ctx, limit, filter)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, series...)
+	}
+	slices.SortStableFunc(stats, func(a, b models.RewatchStat) int {
+		if c := cmp.Compare(b.Completions, a.Completions); c != 0 {
+			return c
+		}
+		return b.LastWatchedAt.Compare(a.LastWatchedAt)
+	})
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats, nil
+}
+
+func (s *Store) rewatchedMovies(ctx context.Context, limit int, filter StatsFilter) ([]models.RewatchStat, error) {
+	filterClause, filterArgs := filter.andConditions()
+	args := append([]any{models.MediaTypeMovie}, filterArgs...)
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, `SELECT user_name, MAX(title), year, COUNT(*) AS completions, MAX(stopped_at)
+		FROM watch_history
+		WHERE media_type = ? AND watched = 1`+filterClause+`
+		GROUP BY user_name, `+movieTitleKey+`, year
+		HAVING completions > 1
+		ORDER BY completions DESC, MAX(stopped_at) DESC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rewatched movies: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.RewatchStat{}
+	for rows.Next() {
+		stat := models.RewatchStat{MediaType: models.MediaTypeMovie}
+		var last sql.NullString
+		if err := rows.Scan(&stat.UserName, &stat.Title, &stat.Year, &stat.Completions, &last); err != nil {
+			return nil, fmt.Errorf("scanning rewatched movies: %w", err)
+		}
+		if stat.LastWatchedAt, err = parseSQLiteTime(last.String); err != nil {
+			return nil, fmt.Errorf("parsing rewatched movie time: %w", err)
+		}
+		stat.RewatchCount = stat.Completions - 1
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// rewatchedSeries counts a user's full passes through each series: how many
+// times every episode they've finished has been finished, skipping series
+// where they've finished fewer episodes than the library holds.
+func (s *Store) rewatchedSeries(ctx context.Context, limit int, filter StatsFilter) ([]models.RewatchStat, error) {
+	filterClause, filterArgs := filter.andConditions()
+	args := append([]any{models.MediaTypeTV}, filterArgs...)
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, `WITH episodes AS (
+			SELECT user_name, grandparent_title, MAX(server_id) AS server_id,
+				MAX(grandparent_item_id) AS series_id, COUNT(*) AS completions, MAX(stopped_at) AS last_stopped
+			FROM watch_history
+			WHERE media_type = ? AND watched = 1 AND grandparent_title != ''`+filterClause+`
+			GROUP BY user_name, grandparent_title, season_number, episode_number
+		)
+		SELECT e.user_name, e.grandparent_title, COUNT(*) AS finished_episodes, MIN(e.completions) AS passes,
+			MAX(e.last_stopped), COALESCE(MAX(li.episode_count), 0) AS library_episodes
+		FROM episodes e
+		LEFT JOIN library_items li ON li.server_id = e.server_id AND li.item_id = e.series_id AND e.series_id != ''
+		GROUP BY e.user_name, e.grandparent_title
+		HAVING passes > 1 AND finished_episodes >= library_episodes
+		ORDER BY passes DESC, MAX(e.last_stopped) DESC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rewatched series: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.RewatchStat{}
+	for rows.Next() {
+		stat := models.RewatchStat{MediaType: models.MediaTypeTV}
+		var last sql.NullString
+		var libraryEpisodes int
+		if err := rows.Scan(&stat.UserName, &stat.Title, &stat.Episodes, &stat.Completions, &last, &libraryEpisodes); err != nil {
+			return nil, fmt.Errorf("scanning rewatched series: %w", err)
+		}
+		if stat.LastWatchedAt, err = parseSQLiteTime(last.String); err != nil {
+			return nil, fmt.Errorf("parsing rewatched series time: %w", err)
+		}
+		stat.RewatchCount = stat.Completions - 1
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
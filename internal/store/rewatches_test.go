@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestMostRewatched(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	n := 0
+	insert := func(user string, e *models.WatchHistoryEntry, watched bool) {
+		t.Helper()
+		at := base.Add(time.Duration(n) * 24 * time.Hour)
+		n++
+		e.ServerID, e.UserName = serverID, user
+		e.StartedAt, e.StoppedAt = at, at.Add(time.Hour)
+		e.DurationMs, e.WatchedMs, e.Watched = 3600000, 3600000, watched
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	movie := func(title string) *models.WatchHistoryEntry {
+		return &models.WatchHistoryEntry{MediaType: models.MediaTypeMovie, Title: title, Year: 2020}
+	}
+	episode := func(series, seriesID string, ep int) *models.WatchHistoryEntry {
+		return &models.WatchHistoryEntry{MediaType: models.MediaTypeTV, Title: fmt.Sprintf("Episode %d", ep),
+			GrandparentTitle: series, GrandparentItemID: seriesID, SeasonNumber: 1, EpisodeNumber: ep}
+	}
+
+	for range 3 {
+		insert("alice", movie("Dune"), true)
+	}
+	insert("alice", movie("Dune"), false)
+	insert("bob", movie("Dune"), true)
+	insert("bob", movie("Arrival"), true)
+	insert("bob", movie("Arrival"), true)
+
+	// Two full passes of a two-episode series, and a third of one episode.
+	for range 2 {
+		insert("alice", episode("Severance", "sev", 1), true)
+		insert("alice", episode("Severance", "sev", 2), true)
+	}
+	insert("alice", episode("Severance", "sev", 1), true)
+	// Twice through the only episodes bob has seen of a series the
+	// library says is longer.
+	if _, err := s.UpsertLibraryItems(ctx, []models.LibraryItemCache{{
+		ServerID: serverID, LibraryID: "tv", ItemID: "lost", MediaType: models.MediaTypeTV,
+		Title: "Lost", EpisodeCount: 20, AddedAt: base, SyncedAt: base,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		insert("bob", episode("Lost", "lost", 1), true)
+	}
+
+	stats, err := s.MostRewatched(ctx, 10, StatsFilter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 rewatched titles, got %+v", stats)
+	}
+	if stats[0].UserName != "alice" || stats[0].Title != "Dune" || stats[0].Completions != 3 || stats[0].RewatchCount != 2 {
+		t.Errorf("first = %+v, want alice's 3 completions of Dune", stats[0])
+	}
+	series := stats[1]
+	if series.Title != "Severance" || series.MediaType != models.MediaTypeTV || series.Completions != 2 || series.Episodes != 2 {
+		t.Errorf("second = %+v, want alice's 2 passes of Severance", series)
+	}
+	if stats[2].UserName != "bob" || stats[2].Title != "Arrival" {
+		t.Errorf("third = %+v, want bob's Arrival", stats[2])
+	}
+
+	movies, err := s.MostRewatched(ctx, 1, StatsFilter{}, models.MediaTypeMovie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movies) != 1 || movies[0].Title != "Dune" {
+		t.Errorf("movies limited to 1 = %+v", movies)
+	}
+}
@@ -13,7 +13,7 @@ import (
 	"streammon/internal/models"
 )
 
-const ruleColumns = `id, name, type, enabled, config, created_at, updated_at`
+const ruleColumns = `id, name, type, enabled, config, actions, notification, workspace_id, created_at, updated_at`
 
 func boolToInt(b bool) int {
 	if b {
@@ -29,13 +29,20 @@ func intToBool(i int) bool {
 func scanRule(scanner interface{ Scan(...any) error }) (models.Rule, error) {
 	var r models.Rule
 	var enabled int
-	var configJSON string
-	err := scanner.Scan(&r.ID, &r.Name, &r.Type, &enabled, &configJSON, &r.CreatedAt, &r.UpdatedAt)
+	var configJSON, actionsJSON, notificationJSON string
+	err := scanner.Scan(&r.ID, &r.Name, &r.Type, &enabled, &configJSON, &actionsJSON, &notificationJSON, &r.WorkspaceID, &r.CreatedAt, &r.UpdatedAt)
 	if err != nil {
 		return r, err
 	}
 	r.Enabled = enabled != 0
 	r.Config = json.RawMessage(configJSON)
+	r.Actions = []models.RuleAction{}
+	if err := json.Unmarshal([]byte(actionsJSON), &r.Actions); err != nil {
+		return r, fmt.Errorf("parsing rule %d actions: %w", r.ID, err)
+	}
+	if err := json.Unmarshal([]byte(notificationJSON), &r.Notification); err != nil {
+		return r, fmt.Errorf("parsing rule %d notification options: %w", r.ID, err)
+	}
 	return r, nil
 }
 
@@ -61,8 +68,16 @@ func (s *Store) CreateRule(rule *models.Rule) error {
 	if len(rule.Config) > 0 {
 		configJSON = string(rule.Config)
 	}
-	result, err := s.db.Exec(`INSERT INTO rules (name, type, enabled, config) VALUES (?, ?, ?, ?)`,
-		rule.Name, rule.Type, boolToInt(rule.Enabled), configJSON)
+	actionsJSON, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return fmt.Errorf("marshaling rule actions: %w", err)
+	}
+	notificationJSON, err := json.Marshal(rule.Notification)
+	if err != nil {
+		return fmt.Errorf("marshaling rule notification options: %w", err)
+	}
+	result, err := s.db.Exec(`INSERT INTO rules (name, type, enabled, config, actions, notification, workspace_id) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rule.Name, rule.Type, boolToInt(rule.Enabled), configJSON, string(actionsJSON), string(notificationJSON), rule.WorkspaceID)
 	if err != nil {
 		return fmt.Errorf("creating rule: %w", err)
 	}
@@ -90,8 +105,16 @@ func (s *Store) UpdateRule(rule *models.Rule) error {
 	if len(rule.Config) > 0 {
 		configJSON = string(rule.Config)
 	}
-	result, err := s.db.Exec(`UPDATE rules SET name = ?, type = ?, enabled = ?, config = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
-		rule.Name, rule.Type, boolToInt(rule.Enabled), configJSON, rule.ID)
+	actionsJSON, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return fmt.Errorf("marshaling rule actions: %w", err)
+	}
+	notificationJSON, err := json.Marshal(rule.Notification)
+	if err != nil {
+		return fmt.Errorf("marshaling rule notification options: %w", err)
+	}
+	result, err := s.db.Exec(`UPDATE rules SET name = ?, type = ?, enabled = ?, config = ?, actions = ?, notification = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		rule.Name, rule.Type, boolToInt(rule.Enabled), configJSON, string(actionsJSON), string(notificationJSON), rule.ID)
 	if err != nil {
 		return fmt.Errorf("updating rule: %w", err)
 	}
@@ -121,6 +144,67 @@ func (s *Store) ListRules() ([]models.Rule, error) {
 	return scanRuleRows(rows)
 }
 
+// ListRulesWithCounts returns all rules with how many violations each has
+// raised and when the latest occurred.
+func (s *Store) ListRulesWithCounts() ([]models.RuleWithCount, error) {
+	rows, err := s.db.Query(`SELECT r.id, r.name, r.type, r.enabled, r.config, r.actions, r.notification,
+		r.workspace_id, r.created_at, r.updated_at, COUNT(v.id), MAX(v.occurred_at)
+		FROM rules r
+		LEFT JOIN rule_violations v ON v.rule_id = r.id
+		GROUP BY r.id
+		ORDER BY r.name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing rules with counts: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.RuleWithCount{}
+	for rows.Next() {
+		var rc models.RuleWithCount
+		var lastViolation sql.NullString
+		rule, err := scanRule(scannerWithExtra{rows, []any{&rc.ViolationCount, &lastViolation}})
+		if err != nil {
+			return nil, fmt.Errorf("scanning rule: %w", err)
+		}
+		rc.Rule = rule
+		if lastViolation.Valid {
+			if t, err := parseSQLiteTime(lastViolation.String); err == nil {
+				rc.LastViolationAt = &t
+			}
+		}
+		rules = append(rules, rc)
+	}
+	return rules, rows.Err()
+}
+
+// scannerWithExtra appends extra destinations after the ones a scan
+// function supplies, for queries selecting more than its columns.
+type scannerWithExtra struct {
+	scanner interface{ Scan(...any) error }
+	extra   []any
+}
+
+func (s scannerWithExtra) Scan(dest ...any) error {
+	return s.scanner.Scan(append(dest, s.extra...)...)
+}
+
+// SetRuleEnabled enables or disables a rule without touching its config.
+func (s *Store) SetRuleEnabled(id int64, enabled bool) error {
+	result, err := s.db.Exec(`UPDATE rules SET enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		boolToInt(enabled), id)
+	if err != nil {
+		return fmt.Errorf("setting rule enabled: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("rule %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
 func (s *Store) ListEnabledRules() ([]models.Rule, error) {
 	rows, err := s.db.Query(`SELECT ` + ruleColumns + ` FROM rules WHERE enabled = 1 ORDER BY name`)
 	if err != nil {
@@ -137,7 +221,7 @@ func (s *Store) ListRulesByType(ruleType models.RuleType) ([]models.Rule, error)
 	return scanRuleRows(rows)
 }
 
-const violationColumnsWithRule = `v.id, v.rule_id, r.name, r.type, v.user_name, v.severity, v.message, v.details, v.confidence_score, v.session_key, v.occurred_at, v.created_at, v.action_taken`
+const violationColumnsWithRule = `v.id, v.rule_id, r.name, r.type, v.user_name, v.severity, v.message, v.details, v.confidence_score, v.session_key, COALESCE(v.server_id, 0), v.occurred_at, v.created_at, v.action_taken`
 
 func unmarshalViolationDetails(v *models.RuleViolation, detailsJSON string) {
 	if detailsJSON != "" && detailsJSON != "{}" {
@@ -150,7 +234,7 @@ func unmarshalViolationDetails(v *models.RuleViolation, detailsJSON string) {
 func scanViolationWithRule(scanner interface{ Scan(...any) error }) (models.RuleViolation, error) {
 	var v models.RuleViolation
 	var detailsJSON string
-	err := scanner.Scan(&v.ID, &v.RuleID, &v.RuleName, &v.RuleType, &v.UserName, &v.Severity, &v.Message, &detailsJSON, &v.ConfidenceScore, &v.SessionKey, &v.OccurredAt, &v.CreatedAt, &v.ActionTaken)
+	err := scanner.Scan(&v.ID, &v.RuleID, &v.RuleName, &v.RuleType, &v.UserName, &v.Severity, &v.Message, &detailsJSON, &v.ConfidenceScore, &v.SessionKey, &v.ServerID, &v.OccurredAt, &v.CreatedAt, &v.ActionTaken)
 	if err != nil {
 		return v, err
 	}
@@ -158,6 +242,18 @@ func scanViolationWithRule(scanner interface{ Scan(...any) error }) (models.Rule
 	return v, nil
 }
 
+func (s *Store) GetViolation(id int64) (*models.RuleViolation, error) {
+	v, err := scanViolationWithRule(s.db.QueryRow(`SELECT `+violationColumnsWithRule+` FROM rule_violations v
+		JOIN rules r ON v.rule_id = r.id WHERE v.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("violation %d: %w", id, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting violation: %w", err)
+	}
+	return &v, nil
+}
+
 func (s *Store) InsertViolation(v *models.RuleViolation) error {
 	if err := v.Validate(); err != nil {
 		return fmt.Errorf("invalid violation: %w", err)
@@ -167,9 +263,9 @@ func (s *Store) InsertViolation(v *models.RuleViolation) error {
 		b, _ := json.Marshal(v.Details)
 		detailsJSON = string(b)
 	}
-	result, err := s.db.Exec(`INSERT INTO rule_violations (rule_id, user_name, severity, message, details, confidence_score, session_key, occurred_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		v.RuleID, v.UserName, v.Severity, v.Message, detailsJSON, v.ConfidenceScore, v.SessionKey, v.OccurredAt)
+	result, err := s.db.Exec(`INSERT INTO rule_violations (rule_id, user_name, severity, message, details, confidence_score, session_key, server_id, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		v.RuleID, v.UserName, v.Severity, v.Message, detailsJSON, v.ConfidenceScore, v.SessionKey, nullableID(v.ServerID), v.OccurredAt)
 	if err != nil {
 		return fmt.Errorf("inserting violation: %w", err)
 	}
@@ -185,6 +281,8 @@ type ViolationFilters struct {
 	Severity      models.Severity
 	MinConfidence float64
 	Since         time.Time
+	// ServerIDs, when non-empty, keeps only violations on those servers.
+	ServerIDs []int64
 }
 
 func (s *Store) ListViolations(page, perPage int, filters ViolationFilters) (*models.PaginatedResult[models.RuleViolation], error) {
@@ -215,6 +313,10 @@ func (s *Store) ListViolations(page, perPage int, filters ViolationFilters) (*mo
 		where += " AND v.occurred_at >= ?"
 		args = append(args, filters.Since)
 	}
+	if cond, serverArgs := (StatsFilter{ServerIDs: filters.ServerIDs}).serverConditionWith("v"); cond != "" {
+		where += " AND " + cond
+		args = append(args, serverArgs...)
+	}
 
 	var total int
 	countQuery := `SELECT COUNT(*) FROM rule_violations v JOIN rules r ON v.rule_id = r.id` + where
@@ -313,8 +415,19 @@ func (s *Store) UpsertHouseholdLocation(h *models.HouseholdLocation) error {
 }
 
 func (s *Store) ListHouseholdLocations(userName string) ([]models.HouseholdLocation, error) {
-	rows, err := s.db.Query(`SELECT `+householdColumns+` FROM household_locations
-		WHERE user_name = ? ORDER BY last_seen DESC`, userName)
+	return s.ListHouseholdLocationsOnServers(userName, nil)
+}
+
+// ListHouseholdLocationsOnServers is ListHouseholdLocations keeping only the
+// addresses the user streamed from on serverIDs. Empty serverIDs keeps all.
+func (s *Store) ListHouseholdLocationsOnServers(userName string, serverIDs []int64) ([]models.HouseholdLocation, error) {
+	query := `SELECT ` + householdColumns + ` FROM household_locations WHERE user_name = ?`
+	args := []any{userName}
+	if serverCond, serverArgs := (StatsFilter{ServerIDs: serverIDs}).serverConditionWith(""); serverCond != "" {
+		query += ` AND ip_address IN (SELECT ip_address FROM watch_history WHERE user_name = ? AND ` + serverCond + `)`
+		args = append(append(args, userName), serverArgs...)
+	}
+	rows, err := s.db.Query(query+` ORDER BY last_seen DESC`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("listing household locations: %w", err)
 	}
@@ -623,6 +736,34 @@ func (s *Store) GetUserTrustScore(userName string) (*models.UserTrustScore, erro
 	return &ts, nil
 }
 
+// UserTrustScoreOnServers is GetUserTrustScore counting only the violations
+// on serverIDs. Scores only ever drop by each violation's decrement, so the
+// score is rebuilt from those violations alone. Empty serverIDs returns the
+// stored score.
+func (s *Store) UserTrustScoreOnServers(userName string, serverIDs []int64) (*models.UserTrustScore, error) {
+	if len(serverIDs) == 0 {
+		return s.GetUserTrustScore(userName)
+	}
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	ts := models.UserTrustScore{UserName: userName, UpdatedAt: time.Now().UTC()}
+	var decrement int
+	var last sql.NullString
+	err := s.db.QueryRow(`SELECT COALESCE(SUM(trust_decrement), 0), COUNT(*), MAX(occurred_at)
+		FROM rule_violations WHERE user_name = ? AND trust_decrement > 0 AND `+serverCond,
+		append([]any{userName}, serverArgs...)...).Scan(&decrement, &ts.ViolationCount, &last)
+	if err != nil {
+		return nil, fmt.Errorf("getting trust score: %w", err)
+	}
+	ts.Score = max(0, 100-decrement)
+	if last.Valid {
+		if t, err := parseSQLiteTime(last.String); err == nil {
+			ts.LastViolationAt = &t
+			ts.UpdatedAt = t
+		}
+	}
+	return &ts, nil
+}
+
 func (s *Store) UpsertTrustScore(ts *models.UserTrustScore) error {
 	_, err := s.db.Exec(`INSERT INTO user_trust_scores (user_name, score, violation_count, last_violation_at, updated_at)
 		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
@@ -649,27 +790,74 @@ func (s *Store) DecrementTrustScore(userName string, amount int, violationTime t
 	return nil
 }
 
-const channelColumns = `id, name, channel_type, config, enabled, created_at, updated_at`
+const channelColumns = `id, name, channel_type, config, enabled, language, events, quiet_hours, workspace_id, created_at, updated_at`
 
 func scanChannel(scanner interface{ Scan(...any) error }) (models.NotificationChannel, error) {
 	var c models.NotificationChannel
 	var enabled int
-	var configJSON string
-	err := scanner.Scan(&c.ID, &c.Name, &c.ChannelType, &configJSON, &enabled, &c.CreatedAt, &c.UpdatedAt)
+	var configJSON, eventsJSON, quietJSON string
+	err := scanner.Scan(&c.ID, &c.Name, &c.ChannelType, &configJSON, &enabled, &c.Language, &eventsJSON, &quietJSON, &c.WorkspaceID, &c.CreatedAt, &c.UpdatedAt)
 	if err != nil {
 		return c, err
 	}
 	c.Enabled = enabled != 0
 	c.Config = json.RawMessage(configJSON)
+	if err := json.Unmarshal([]byte(eventsJSON), &c.Events); err != nil {
+		return c, fmt.Errorf("parsing events for channel %d: %w", c.ID, err)
+	}
+	if quietJSON != "" {
+		c.QuietHours = &models.QuietHours{}
+		if err := json.Unmarshal([]byte(quietJSON), c.QuietHours); err != nil {
+			return c, fmt.Errorf("parsing quiet hours for channel %d: %w", c.ID, err)
+		}
+	}
 	return c, nil
 }
 
+// channelEventsJSON encodes a channel's event matrix for storage. A nil
+// matrix encodes as SQL NULL so updates can keep the stored value.
+func channelEventsJSON(m models.NotificationEventMatrix) (any, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("encoding events: %w", err)
+	}
+	return string(b), nil
+}
+
+// channelQuietHoursJSON encodes q for the quiet_hours column: nil keeps the
+// stored value on update, an empty window stores none.
+func channelQuietHoursJSON(q *models.QuietHours) (any, error) {
+	if q == nil {
+		return nil, nil
+	}
+	if q.IsZero() {
+		return "", nil
+	}
+	b, err := json.Marshal(q)
+	if err != nil {
+		return nil, fmt.Errorf("encoding quiet hours: %w", err)
+	}
+	return string(b), nil
+}
+
 func (s *Store) CreateNotificationChannel(c *models.NotificationChannel) error {
 	if err := c.Validate(); err != nil {
 		return fmt.Errorf("invalid channel: %w", err)
 	}
-	result, err := s.db.Exec(`INSERT INTO notification_channels (name, channel_type, config, enabled) VALUES (?, ?, ?, ?)`,
-		c.Name, c.ChannelType, string(c.Config), boolToInt(c.Enabled))
+	events, err := channelEventsJSON(c.Events)
+	if err != nil {
+		return err
+	}
+	quiet, err := channelQuietHoursJSON(c.QuietHours)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(`INSERT INTO notification_channels (name, channel_type, config, enabled, language, events, quiet_hours, workspace_id)
+		VALUES (?, ?, ?, ?, ?, COALESCE(?, '{}'), COALESCE(?, ''), ?)`,
+		c.Name, c.ChannelType, string(c.Config), boolToInt(c.Enabled), c.Language, events, quiet, c.WorkspaceID)
 	if err != nil {
 		return fmt.Errorf("creating channel: %w", err)
 	}
@@ -693,8 +881,18 @@ func (s *Store) UpdateNotificationChannel(c *models.NotificationChannel) error {
 	if err := c.Validate(); err != nil {
 		return fmt.Errorf("invalid channel: %w", err)
 	}
-	result, err := s.db.Exec(`UPDATE notification_channels SET name = ?, channel_type = ?, config = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
-		c.Name, c.ChannelType, string(c.Config), boolToInt(c.Enabled), c.ID)
+	events, err := channelEventsJSON(c.Events)
+	if err != nil {
+		return err
+	}
+	quiet, err := channelQuietHoursJSON(c.QuietHours)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(`UPDATE notification_channels SET name = ?, channel_type = ?, config = ?, enabled = ?,
+		language = COALESCE(NULLIF(?, ''), language), events = COALESCE(?, events), quiet_hours = COALESCE(?, quiet_hours),
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		c.Name, c.ChannelType, string(c.Config), boolToInt(c.Enabled), c.Language, events, quiet, c.ID)
 	if err != nil {
 		return fmt.Errorf("updating channel: %w", err)
 	}
@@ -768,6 +966,45 @@ func (s *Store) UnlinkRuleFromChannel(ruleID, channelID int64) error {
 	return nil
 }
 
+// ListChannelRuleIDs returns the IDs of the rules linked to each channel,
+// keyed by channel ID.
+func (s *Store) ListChannelRuleIDs() (map[int64][]int64, error) {
+	rows, err := s.db.Query(`SELECT channel_id, rule_id FROM rule_notifications ORDER BY channel_id, rule_id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing channel rules: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[int64][]int64)
+	for rows.Next() {
+		var channelID, ruleID int64
+		if err := rows.Scan(&channelID, &ruleID); err != nil {
+			return nil, fmt.Errorf("scanning channel rule: %w", err)
+		}
+		out[channelID] = append(out[channelID], ruleID)
+	}
+	return out, rows.Err()
+}
+
+// SetChannelRules replaces the set of rules linked to a channel.
+func (s *Store) SetChannelRules(channelID int64, ruleIDs []int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM rule_notifications WHERE channel_id = ?`, channelID); err != nil {
+		return fmt.Errorf("clearing channel rules: %w", err)
+	}
+	for _, ruleID := range ruleIDs {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO rule_notifications (rule_id, channel_id) VALUES (?, ?)`, ruleID, channelID); err != nil {
+			return fmt.Errorf("linking rule %d: %w", ruleID, err)
+		}
+	}
+	return tx.Commit()
+}
+
 func (s *Store) GetChannelsForRule(ruleID int64) ([]models.NotificationChannel, error) {
 	rows, err := s.db.Query(`SELECT `+channelColumns+` FROM notification_channels c
 		JOIN rule_notifications rn ON c.id = rn.channel_id
@@ -828,9 +1065,9 @@ func (s *Store) InsertViolationWithTx(ctx context.Context, v *models.RuleViolati
 		b, _ := json.Marshal(v.Details)
 		detailsJSON = string(b)
 	}
-	result, err := tx.ExecContext(ctx, `INSERT INTO rule_violations (rule_id, user_name, severity, message, details, confidence_score, session_key, occurred_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		v.RuleID, v.UserName, v.Severity, v.Message, detailsJSON, v.ConfidenceScore, v.SessionKey, v.OccurredAt)
+	result, err := tx.ExecContext(ctx, `INSERT INTO rule_violations (rule_id, user_name, severity, message, details, confidence_score, session_key, server_id, trust_decrement, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		v.RuleID, v.UserName, v.Severity, v.Message, detailsJSON, v.ConfidenceScore, v.SessionKey, nullableID(v.ServerID), trustDecrement, v.OccurredAt)
 	if err != nil {
 		if isUniqueConstraintError(err) {
 			return nil
@@ -931,3 +1168,67 @@ func (s *Store) ListAllRuleExemptions() (map[int64][]string, error) {
 	}
 	return result, rows.Err()
 }
+
+func (s *Store) ListRuleUserLimits(ruleID int64) ([]models.RuleUserLimit, error) {
+	rows, err := s.db.Query(`SELECT user_name, max_streams FROM rule_user_limits WHERE rule_id = ? ORDER BY user_name`, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("listing rule user limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := []models.RuleUserLimit{}
+	for rows.Next() {
+		var l models.RuleUserLimit
+		if err := rows.Scan(&l.UserName, &l.MaxStreams); err != nil {
+			return nil, err
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
+
+// SetRuleUserLimits replaces every per-user limit of a rule.
+func (s *Store) SetRuleUserLimits(ruleID int64, limits []models.RuleUserLimit) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM rule_user_limits WHERE rule_id = ?`, ruleID); err != nil {
+		return fmt.Errorf("clearing user limits: %w", err)
+	}
+
+	for _, l := range limits {
+		if _, err := tx.Exec(`INSERT INTO rule_user_limits (rule_id, user_name, max_streams) VALUES (?, ?, ?)`,
+			ruleID, l.UserName, l.MaxStreams); err != nil {
+			return fmt.Errorf("inserting user limit: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListAllRuleUserLimits returns every per-user limit as ruleID → user → max.
+func (s *Store) ListAllRuleUserLimits() (map[int64]map[string]int, error) {
+	rows, err := s.db.Query(`SELECT rule_id, user_name, max_streams FROM rule_user_limits`)
+	if err != nil {
+		return nil, fmt.Errorf("listing all user limits: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int64]map[string]int)
+	for rows.Next() {
+		var ruleID int64
+		var name string
+		var maxStreams int
+		if err := rows.Scan(&ruleID, &name, &maxStreams); err != nil {
+			return nil, err
+		}
+		if result[ruleID] == nil {
+			result[ruleID] = make(map[string]int)
+		}
+		result[ruleID][name] = maxStreams
+	}
+	return result, rows.Err()
+}
@@ -56,8 +56,13 @@ func TestRuleCRUD(t *testing.T) {
 		t.Error("expected Enabled = true")
 	}
 
+	if got.Notification.DiscordFields != nil {
+		t.Errorf("DiscordFields = %v, want nil (defaults)", got.Notification.DiscordFields)
+	}
+
 	got.Name = "Updated Rule"
 	got.Enabled = false
+	got.Notification.DiscordFields = []models.DiscordEmbedField{models.DiscordFieldPlayer}
 	if err := s.UpdateRule(got); err != nil {
 		t.Fatalf("UpdateRule: %v", err)
 	}
@@ -72,6 +77,9 @@ func TestRuleCRUD(t *testing.T) {
 	if got.Enabled {
 		t.Error("expected Enabled = false")
 	}
+	if !got.Notification.HasDiscordField(models.DiscordFieldPlayer) || got.Notification.HasDiscordField(models.DiscordFieldPoster) {
+		t.Errorf("DiscordFields = %v, want [player]", got.Notification.DiscordFields)
+	}
 
 	if err := s.DeleteRule(rule.ID); err != nil {
 		t.Fatalf("DeleteRule: %v", err)
@@ -139,6 +147,71 @@ func TestListRules(t *testing.T) {
 	}
 }
 
+func TestListRulesWithCounts(t *testing.T) {
+	s := setupTestStore(t)
+
+	quiet := &models.Rule{Name: "Quiet", Type: models.RuleTypeGeoRestriction, Enabled: true, Config: json.RawMessage(`{}`)}
+	noisy := &models.Rule{Name: "Noisy", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	for _, r := range []*models.Rule{quiet, noisy} {
+		if err := s.CreateRule(r); err != nil {
+			t.Fatalf("CreateRule: %v", err)
+		}
+	}
+
+	latest := time.Now().UTC().Truncate(time.Second)
+	for _, at := range []time.Time{latest.Add(-time.Hour), latest} {
+		v := &models.RuleViolation{RuleID: noisy.ID, UserName: "alice", Severity: models.SeverityWarning, Message: "x", OccurredAt: at}
+		if err := s.InsertViolation(v); err != nil {
+			t.Fatalf("InsertViolation: %v", err)
+		}
+	}
+
+	rules, err := s.ListRulesWithCounts()
+	if err != nil {
+		t.Fatalf("ListRulesWithCounts: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	// Ordered by name.
+	if rules[0].Name != "Noisy" || rules[0].ViolationCount != 2 {
+		t.Errorf("rules[0] = %s with %d violations, want Noisy with 2", rules[0].Name, rules[0].ViolationCount)
+	}
+	if rules[0].LastViolationAt == nil || !rules[0].LastViolationAt.Equal(latest) {
+		t.Errorf("LastViolationAt = %v, want %v", rules[0].LastViolationAt, latest)
+	}
+	if rules[1].ViolationCount != 0 || rules[1].LastViolationAt != nil {
+		t.Errorf("rules[1] = %+v, want no violations", rules[1])
+	}
+}
+
+func TestSetRuleEnabled(t *testing.T) {
+	s := setupTestStore(t)
+
+	rule := &models.Rule{Name: "Toggle", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{"max_streams":3}`)}
+	if err := s.CreateRule(rule); err != nil {
+		t.Fatalf("CreateRule: %v", err)
+	}
+
+	if err := s.SetRuleEnabled(rule.ID, false); err != nil {
+		t.Fatalf("SetRuleEnabled: %v", err)
+	}
+	got, err := s.GetRule(rule.ID)
+	if err != nil {
+		t.Fatalf("GetRule: %v", err)
+	}
+	if got.Enabled {
+		t.Error("expected rule to be disabled")
+	}
+	if string(got.Config) != `{"max_streams":3}` {
+		t.Errorf("config = %s, want unchanged", got.Config)
+	}
+
+	if err := s.SetRuleEnabled(9999, true); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestViolationCRUD(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -629,6 +702,57 @@ func TestInsertViolationWithTx(t *testing.T) {
 	}
 }
 
+func TestUserTrustScoreOnServers(t *testing.T) {
+	s := setupTestStore(t)
+	ctx := context.Background()
+
+	rule := &models.Rule{Name: "Test", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	if err := s.CreateRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, seed := range []struct {
+		serverID  int64
+		decrement int
+	}{{1, 10}, {2, 20}, {2, 0}} {
+		v := &models.RuleViolation{RuleID: rule.ID, UserName: "testuser", Severity: models.SeverityWarning,
+			Message: "test violation", ServerID: seed.serverID, OccurredAt: now}
+		if err := s.InsertViolationWithTx(ctx, v, seed.decrement); err != nil {
+			t.Fatalf("InsertViolationWithTx: %v", err)
+		}
+	}
+
+	all, err := s.UserTrustScoreOnServers("testuser", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Score != 70 || all.ViolationCount != 2 {
+		t.Errorf("unscoped = %+v, want the stored score 70 from 2 violations", all)
+	}
+	one, err := s.UserTrustScoreOnServers("testuser", []int64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one.Score != 90 || one.ViolationCount != 1 || one.LastViolationAt == nil {
+		t.Errorf("server 1 = %+v, want 90 from 1 violation", one)
+	}
+	none, err := s.UserTrustScoreOnServers("testuser", []int64{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if none.Score != 100 || none.ViolationCount != 0 {
+		t.Errorf("server 3 = %+v, want a clean 100", none)
+	}
+
+	result, err := s.ListViolations(1, 50, ViolationFilters{UserName: "testuser", ServerIDs: []int64{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Items[0].ServerID != 1 {
+		t.Errorf("violations on server 1 = %+v", result.Items)
+	}
+}
+
 func TestAutoLearnHouseholdLocation(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -1088,6 +1212,41 @@ func TestListAllRuleExemptions(t *testing.T) {
 	}
 }
 
+func TestRuleUserLimits(t *testing.T) {
+	s := setupTestStore(t)
+
+	r1 := &models.Rule{Name: "R1", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	r2 := &models.Rule{Name: "R2", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	s.CreateRule(r1)
+	s.CreateRule(r2)
+
+	if err := s.SetRuleUserLimits(r1.ID, []models.RuleUserLimit{{UserName: "alice", MaxStreams: 4}, {UserName: "bob", MaxStreams: 1}}); err != nil {
+		t.Fatalf("SetRuleUserLimits: %v", err)
+	}
+	if err := s.SetRuleUserLimits(r2.ID, []models.RuleUserLimit{{UserName: "carol", MaxStreams: 2}}); err != nil {
+		t.Fatalf("SetRuleUserLimits: %v", err)
+	}
+	if err := s.SetRuleUserLimits(r1.ID, []models.RuleUserLimit{{UserName: "alice", MaxStreams: 5}}); err != nil {
+		t.Fatalf("SetRuleUserLimits replace: %v", err)
+	}
+
+	limits, err := s.ListRuleUserLimits(r1.ID)
+	if err != nil {
+		t.Fatalf("ListRuleUserLimits: %v", err)
+	}
+	if len(limits) != 1 || limits[0] != (models.RuleUserLimit{UserName: "alice", MaxStreams: 5}) {
+		t.Errorf("r1 limits = %+v, want only alice=5", limits)
+	}
+
+	all, err := s.ListAllRuleUserLimits()
+	if err != nil {
+		t.Fatalf("ListAllRuleUserLimits: %v", err)
+	}
+	if all[r1.ID]["alice"] != 5 || all[r2.ID]["carol"] != 2 || len(all) != 2 {
+		t.Errorf("all limits = %v", all)
+	}
+}
+
 func TestUpdateViolationAction(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -1149,3 +1308,139 @@ func seedTestServer(t *testing.T, s *Store) int64 {
 	}
 	return srv.ID
 }
+
+func TestNotificationChannelEventsAndRules(t *testing.T) {
+	s := setupTestStore(t)
+
+	rule1 := &models.Rule{Name: "R1", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	rule2 := &models.Rule{Name: "R2", Type: models.RuleTypeConcurrentStreams, Enabled: true, Config: json.RawMessage(`{}`)}
+	for _, r := range []*models.Rule{rule1, rule2} {
+		if err := s.CreateRule(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	channel := &models.NotificationChannel{
+		Name: "Discord", ChannelType: models.ChannelTypeDiscord, Enabled: true,
+		Config: json.RawMessage(`{"webhook_url":"https://discord.com/api/webhooks/1/a"}`),
+		Events: models.NotificationEventMatrix{
+			models.NotificationEventRuleViolation: {Enabled: true, Users: []string{"alice"}},
+		},
+	}
+	if err := s.CreateNotificationChannel(channel); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetNotificationChannel(channel.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := got.Events[models.NotificationEventRuleViolation]; !f.Enabled || len(f.Users) != 1 {
+		t.Fatalf("events = %+v, want stored matrix", got.Events)
+	}
+
+	// A nil matrix on update keeps the stored one.
+	got.Events = nil
+	got.Name = "Renamed"
+	if err := s.UpdateNotificationChannel(got); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.GetNotificationChannel(channel.ID)
+	if len(got.Events) != 1 {
+		t.Fatalf("events = %+v, want matrix preserved", got.Events)
+	}
+
+	if err := s.SetChannelRules(channel.ID, []int64{rule1.ID, rule2.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetChannelRules(channel.ID, []int64{rule2.ID}); err != nil {
+		t.Fatal(err)
+	}
+	links, err := s.ListChannelRuleIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := links[channel.ID]; len(ids) != 1 || ids[0] != rule2.ID {
+		t.Fatalf("rule ids = %v, want [%d]", ids, rule2.ID)
+	}
+}
+
+func TestNotificationChannel_Language(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	c := &models.NotificationChannel{
+		Name: "Casa", ChannelType: models.ChannelTypeWebhook, Enabled: true,
+		Config:   json.RawMessage(`{"url":"https://example.com/hook"}`),
+		Language: models.LanguageSpanish,
+	}
+	if err := s.CreateNotificationChannel(c); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetNotificationChannel(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Language != models.LanguageSpanish {
+		t.Fatalf("language = %q", got.Language)
+	}
+
+	// Omitting the language on update keeps it.
+	got.Language = ""
+	if err := s.UpdateNotificationChannel(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.GetNotificationChannel(c.ID); got.Language != models.LanguageSpanish {
+		t.Fatalf("language after update = %q", got.Language)
+	}
+	got.Language = models.LanguageEnglish
+	if err := s.UpdateNotificationChannel(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.GetNotificationChannel(c.ID); got.Language != models.LanguageEnglish {
+		t.Errorf("language = %q, want en", got.Language)
+	}
+
+	c.Language = "xx"
+	if err := s.CreateNotificationChannel(c); err == nil {
+		t.Error("expected an unsupported language to be rejected")
+	}
+}
+
+func TestNotificationChannel_QuietHours(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	c := &models.NotificationChannel{
+		Name: "Night", ChannelType: models.ChannelTypeWebhook, Enabled: true,
+		Config:     json.RawMessage(`{"url":"https://example.com/hook"}`),
+		QuietHours: &models.QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Oslo"},
+	}
+	if err := s.CreateNotificationChannel(c); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetNotificationChannel(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.QuietHours == nil || *got.QuietHours != *c.QuietHours {
+		t.Fatalf("quiet hours = %+v", got.QuietHours)
+	}
+
+	// Omitting quiet hours on update keeps them; an empty window clears them.
+	got.QuietHours = nil
+	if err := s.UpdateNotificationChannel(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.GetNotificationChannel(c.ID); got.QuietHours == nil {
+		t.Fatal("update without quiet_hours cleared them")
+	}
+	got.QuietHours = &models.QuietHours{}
+	if err := s.UpdateNotificationChannel(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.GetNotificationChannel(c.ID); got.QuietHours != nil {
+		t.Errorf("quiet hours = %+v, want cleared", got.QuietHours)
+	}
+
+	c.QuietHours = &models.QuietHours{Start: "22:00", End: "22:00"}
+	if err := s.CreateNotificationChannel(c); err == nil {
+		t.Error("expected an empty window to be rejected")
+	}
+}
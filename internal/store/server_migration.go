@@ -0,0 +1,278 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+// Ways a title in the old server's history was matched on the new one.
+const (
+	MatchedByExternalID = "external_id"
+	MatchedByTitle      = "title"
+)
+
+// ServerHistoryMapping is one title in the old server's history and the
+// library item it maps to on the new server, if any.
+type ServerHistoryMapping struct {
+	MediaType models.MediaType `json:"media_type"`
+	Title     string           `json:"title"`
+	Year      int              `json:"year,omitempty"`
+	OldItemID string           `json:"old_item_id,omitempty"`
+	NewItemID string           `json:"new_item_id,omitempty"`
+	MatchedBy string           `json:"matched_by,omitempty"`
+	Ambiguous bool             `json:"ambiguous,omitempty"`
+	Rows      int              `json:"rows"`
+}
+
+// ServerHistoryMigration summarizes mapping one server's history onto
+// another. Moved and Exclusions stay zero in a preview.
+type ServerHistoryMigration struct {
+	FromServerID int64                  `json:"from_server_id"`
+	ToServerID   int64                  `json:"to_server_id"`
+	Titles       int                    `json:"titles"`
+	Matched      int                    `json:"matched"`
+	Ambiguous    int                    `json:"ambiguous"`
+	Unmatched    int                    `json:"unmatched"`
+	MatchedRows  int                    `json:"matched_rows"`
+	Moved        int                    `json:"moved"`
+	Exclusions   int                    `json:"exclusions"`
+	Mappings     []ServerHistoryMapping `json:"mappings"`
+}
+
+// PreviewServerHistoryMigration reports how from's movie and TV history
+// would map onto to's library. Changes nothing.
+func (s *Store) PreviewServerHistoryMigration(ctx context.Context, from, to int64) (*ServerHistoryMigration, error) {
+	return s.planServerHistoryMigration(ctx, from, to)
+}
+
+// MigrateServerHistory moves from's movie and TV history to to, for a server
+// that was rebuilt and came back with new item IDs. Each title is matched to
+// to's library cache by external ID, via from's cached copy of the item,
+// then by title as LinkOrphanedHistory does. Matched rows move to to with
+// their item_id (movies) or grandparent_item_id (episodes) rewritten;
+// episode item IDs can't be mapped and are cleared. Maintenance exclusions
+// on matched titles are copied over. Unmatched and ambiguous titles stay on
+// from untouched.
+func (s *Store) MigrateServerHistory(ctx context.Context, from, to int64) (*ServerHistoryMigration, error) {
+	plan, err := s.planServerHistoryMigration(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []ServerHistoryMapping
+	for _, m := range plan.Mappings {
+		if m.NewItemID != "" {
+			matched = append(matched, m)
+		}
+	}
+	for _, chunk := range chunkSlice(matched, writeChunkSize) {
+		if err := ctx.Err(); err != nil {
+			return plan, err
+		}
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return plan, fmt.Errorf("begin tx: %w", err)
+		}
+		moved, exclusions := 0, 0
+		for _, m := range chunk {
+			n, err := migrateHistoryTitle(ctx, tx, from, to, m)
+			if err != nil {
+				tx.Rollback()
+				return plan, fmt.Errorf("migrating history %q: %w", m.Title, err)
+			}
+			moved += n
+			if m.OldItemID == "" {
+				continue
+			}
+			res, err := tx.ExecContext(ctx,
+				`INSERT OR IGNORE INTO maintenance_exclusions (library_item_id, excluded_by, excluded_at)
+				 SELECT n.id, e.excluded_by, e.excluded_at
+				 FROM maintenance_exclusions e
+				 JOIN library_items o ON o.id = e.library_item_id
+				 JOIN library_items n ON n.server_id = ? AND n.item_id = ?
+				 WHERE o.server_id = ? AND o.item_id = ?`,
+				to, m.NewItemID, from, m.OldItemID)
+			if err != nil {
+				tx.Rollback()
+				return plan, fmt.Errorf("copying exclusions for %q: %w", m.Title, err)
+			}
+			n64, _ := res.RowsAffected()
+			exclusions += int(n64)
+		}
+		if err := tx.Commit(); err != nil {
+			return plan, fmt.Errorf("commit tx: %w", err)
+		}
+		plan.Moved += moved
+		plan.Exclusions += exclusions
+	}
+	return plan, nil
+}
+
+func migrateHistoryTitle(ctx context.Context, tx *sql.Tx, from, to int64, m ServerHistoryMapping) (int, error) {
+	var res sql.Result
+	var err error
+	switch {
+	case m.MediaType == models.MediaTypeMovie && m.OldItemID != "":
+		res, err = tx.ExecContext(ctx,
+			`UPDATE watch_history SET server_id = ?, item_id = ?
+			 WHERE server_id = ? AND media_type = ? AND item_id = ?`,
+			to, m.NewItemID, from, m.MediaType, m.OldItemID)
+	case m.MediaType == models.MediaTypeMovie:
+		res, err = tx.ExecContext(ctx,
+			`UPDATE watch_history SET server_id = ?, item_id = ?
+			 WHERE server_id = ? AND media_type = ? AND item_id = '' AND title = ? AND year = ?`,
+			to, m.NewItemID, from, m.MediaType, m.Title, m.Year)
+	case m.OldItemID != "":
+		res, err = tx.ExecContext(ctx,
+			`UPDATE watch_history SET server_id = ?, grandparent_item_id = ?, item_id = ''
+			 WHERE server_id = ? AND media_type = ? AND grandparent_item_id = ?`,
+			to, m.NewItemID, from, m.MediaType, m.OldItemID)
+	default:
+		res, err = tx.ExecContext(ctx,
+			`UPDATE watch_history SET server_id = ?, grandparent_item_id = ?, item_id = ''
+			 WHERE server_id = ? AND media_type = ? AND grandparent_item_id = '' AND grandparent_title = ?`,
+			to, m.NewItemID, from, m.MediaType, m.Title)
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *Store) planServerHistoryMigration(ctx context.Context, from, to int64) (*ServerHistoryMigration, error) {
+	if from == to {
+		return nil, errors.New("source and target server must differ")
+	}
+	groups, err := s.listServerHistoryTitles(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ServerHistoryMigration{FromServerID: from, ToServerID: to, Titles: len(groups), Mappings: groups}
+	for i := range plan.Mappings {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		m := &plan.Mappings[i]
+		if err := s.mapHistoryTitle(ctx, from, to, m); err != nil {
+			return nil, err
+		}
+		switch {
+		case m.NewItemID != "":
+			plan.Matched++
+			plan.MatchedRows += m.Rows
+		case m.Ambiguous:
+			plan.Ambiguous++
+		default:
+			plan.Unmatched++
+		}
+	}
+	return plan, nil
+}
+
+// listServerHistoryTitles groups a server's movie history by item_id and
+// its episode history by grandparent_item_id, falling back to the title for
+// rows recorded without one.
+func (s *Store) listServerHistoryTitles(ctx context.Context, serverID int64) ([]ServerHistoryMapping, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT media_type, item_id, MAX(title), MAX(year), COUNT(*)
+		 FROM watch_history WHERE server_id = ? AND media_type = ? AND (item_id != '' OR title != '')
+		 GROUP BY item_id, CASE WHEN item_id = '' THEN title END, CASE WHEN item_id = '' THEN year END
+		 UNION ALL
+		 SELECT media_type, grandparent_item_id, MAX(grandparent_title), 0, COUNT(*)
+		 FROM watch_history WHERE server_id = ? AND media_type = ? AND (grandparent_item_id != '' OR grandparent_title != '')
+		 GROUP BY grandparent_item_id, CASE WHEN grandparent_item_id = '' THEN grandparent_title END`,
+		serverID, models.MediaTypeMovie, serverID, models.MediaTypeTV)
+	if err != nil {
+		return nil, fmt.Errorf("listing server history: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []ServerHistoryMapping{}
+	for rows.Next() {
+		var m ServerHistoryMapping
+		if err := rows.Scan(&m.MediaType, &m.OldItemID, &m.Title, &m.Year, &m.Rows); err != nil {
+			return nil, fmt.Errorf("scanning server history: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// mapHistoryTitle fills in m's match on the new server, if there is exactly
+// one.
+func (s *Store) mapHistoryTitle(ctx context.Context, from, to int64, m *ServerHistoryMapping) error {
+	if m.OldItemID != "" {
+		var ids models.ExternalIDs
+		err := s.db.QueryRowContext(ctx,
+			`SELECT COALESCE(tmdb_id, ''), COALESCE(tvdb_id, ''), COALESCE(imdb_id, '') FROM library_items
+			 WHERE server_id = ? AND item_id = ?`, from, m.OldItemID).Scan(&ids.TMDB, &ids.TVDB, &ids.IMDB)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("looking up %q: %w", m.Title, err)
+		}
+		if ids.DedupeKey() != "" {
+			cands, err := s.externalIDCandidates(ctx, to, m.MediaType, ids)
+			if err != nil {
+				return err
+			}
+			if itemID, found, ok := resolveOrphanCandidates(cands, orphanGroup{}); found {
+				if ok {
+					m.NewItemID, m.MatchedBy = itemID, MatchedByExternalID
+				} else {
+					m.Ambiguous = true
+				}
+				return nil
+			}
+		}
+	}
+
+	g := orphanGroup{serverID: to, mediaType: m.MediaType, title: m.Title, year: m.Year}
+	cands, err := s.orphanCandidates(ctx, g)
+	if err != nil {
+		return err
+	}
+	itemID, found, ok := resolveOrphanCandidates(cands, g)
+	switch {
+	case ok:
+		m.NewItemID, m.MatchedBy = itemID, MatchedByTitle
+	case found:
+		m.Ambiguous = true
+	}
+	return nil
+}
+
+func (s *Store) externalIDCandidates(ctx context.Context, serverID int64, mediaType models.MediaType, ids models.ExternalIDs) ([]orphanCandidate, error) {
+	var conds []string
+	args := []any{serverID, mediaType}
+	for col, v := range map[string]string{"tmdb_id": ids.TMDB, "tvdb_id": ids.TVDB, "imdb_id": ids.IMDB} {
+		if v != "" {
+			conds = append(conds, col+" = ?")
+			args = append(args, v)
+		}
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT item_id, year, COALESCE(tmdb_id, ''), COALESCE(tvdb_id, ''), COALESCE(imdb_id, '') FROM library_items
+		 WHERE server_id = ? AND media_type = ? AND (`+strings.Join(conds, " OR ")+`)
+		 ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("external id candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var cands []orphanCandidate
+	for rows.Next() {
+		var c orphanCandidate
+		var cid models.ExternalIDs
+		if err := rows.Scan(&c.itemID, &c.year, &cid.TMDB, &cid.TVDB, &cid.IMDB); err != nil {
+			return nil, fmt.Errorf("scanning external id candidate: %w", err)
+		}
+		c.extKey = cid.DedupeKey()
+		cands = append(cands, c)
+	}
+	return cands, rows.Err()
+}
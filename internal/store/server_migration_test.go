@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestMigrateServerHistory(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	oldID := seedServer(t, s)
+	newID := seedServer(t, s)
+
+	if err := s.SeedLibraryItemsForTest(ctx, []LibraryItemSeed{
+		{ServerID: oldID, LibraryID: "1", ItemID: "100", MediaType: "movie", Title: "Arrival", Year: 2016, AddedAt: "2024-01-01T00:00:00Z"},
+		{ServerID: oldID, LibraryID: "2", ItemID: "200", MediaType: "episode", Title: "Severance", AddedAt: "2024-01-01T00:00:00Z"},
+		// Renamed on the new server; only the external ID links them.
+		{ServerID: newID, LibraryID: "1", ItemID: "9100", MediaType: "movie", Title: "Arrival (2016)", Year: 2016, AddedAt: "2024-06-01T00:00:00Z"},
+		{ServerID: newID, LibraryID: "2", ItemID: "9200", MediaType: "episode", Title: "Severance", AddedAt: "2024-06-01T00:00:00Z"},
+		{ServerID: newID, LibraryID: "1", ItemID: "9300", MediaType: "movie", Title: "Heat", AddedAt: "2024-06-01T00:00:00Z"},
+		{ServerID: newID, LibraryID: "1", ItemID: "9301", MediaType: "movie", Title: "Heat", AddedAt: "2024-06-01T00:00:00Z"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		`UPDATE library_items SET tmdb_id = '329865' WHERE item_id IN ('100', '9100')`,
+		`UPDATE library_items SET tvdb_id = '371980' WHERE item_id IN ('200', '9200')`,
+	} {
+		if _, err := s.db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var oldArrival int64
+	if err := s.db.QueryRow(`SELECT id FROM library_items WHERE server_id = ? AND item_id = '100'`, oldID).Scan(&oldArrival); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateExclusions(ctx, []int64{oldArrival}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	insert := func(e *models.WatchHistoryEntry) {
+		t.Helper()
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, user := range []string{"alice", "bob"} {
+		e := makeHistoryEntry(oldID, user, "Arrival", base.Add(time.Duration(i)*24*time.Hour))
+		e.Year, e.ItemID = 2016, "100"
+		insert(e)
+	}
+	ep := makeHistoryEntry(oldID, "alice", "Good News About Hell", base.Add(72*time.Hour))
+	ep.MediaType, ep.ItemID, ep.GrandparentItemID, ep.GrandparentTitle = models.MediaTypeTV, "201", "200", "Severance"
+	insert(ep)
+	heat := makeHistoryEntry(oldID, "alice", "Heat", base.Add(96*time.Hour))
+	heat.ItemID = "300"
+	insert(heat)
+	gone := makeHistoryEntry(oldID, "alice", "Not On New Server", base.Add(120*time.Hour))
+	insert(gone)
+
+	preview, err := s.PreviewServerHistoryMigration(ctx, oldID, newID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Titles != 4 || preview.Matched != 2 || preview.Ambiguous != 1 || preview.Unmatched != 1 || preview.MatchedRows != 3 {
+		t.Fatalf("preview = %+v", preview)
+	}
+	var onOld int
+	s.db.QueryRow(`SELECT COUNT(*) FROM watch_history WHERE server_id = ?`, oldID).Scan(&onOld)
+	if onOld != 5 {
+		t.Fatalf("preview moved rows: %d left on old server", onOld)
+	}
+
+	res, err := s.MigrateServerHistory(ctx, oldID, newID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Moved != 3 || res.Exclusions != 1 {
+		t.Errorf("result = %+v", res)
+	}
+	for _, m := range res.Mappings {
+		if m.Title == "Arrival" && (m.NewItemID != "9100" || m.MatchedBy != MatchedByExternalID) {
+			t.Errorf("arrival mapping = %+v", m)
+		}
+	}
+
+	var n int
+	s.db.QueryRow(`SELECT COUNT(*) FROM watch_history WHERE server_id = ? AND item_id = '9100'`, newID).Scan(&n)
+	if n != 2 {
+		t.Errorf("arrival rows on new server = %d, want 2", n)
+	}
+	var item, gp string
+	s.db.QueryRow(`SELECT item_id, grandparent_item_id FROM watch_history WHERE server_id = ? AND media_type = ?`,
+		newID, models.MediaTypeTV).Scan(&item, &gp)
+	if item != "" || gp != "9200" {
+		t.Errorf("episode ids = %q, %q", item, gp)
+	}
+	s.db.QueryRow(`SELECT COUNT(*) FROM watch_history WHERE server_id = ?`, oldID).Scan(&onOld)
+	if onOld != 2 {
+		t.Errorf("%d rows left on old server, want 2", onOld)
+	}
+	s.db.QueryRow(`SELECT COUNT(*) FROM maintenance_exclusions e JOIN library_items li ON li.id = e.library_item_id
+		WHERE li.server_id = ? AND li.item_id = '9100'`, newID).Scan(&n)
+	if n != 1 {
+		t.Errorf("exclusion not copied")
+	}
+
+	if _, err := s.MigrateServerHistory(ctx, oldID, oldID); err == nil {
+		t.Error("expected migrating a server onto itself to fail")
+	}
+}
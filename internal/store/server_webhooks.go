@@ -0,0 +1,127 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// RotateServerWebhook generates a new webhook token for the server, replacing
+// any previous one, and returns the plaintext token.
+func (s *Store) RotateServerWebhook(serverID int64) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("generating webhook token: %w", err)
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO server_webhooks (server_id, token_hash, created_at) VALUES (?, ?, ?)
+		 ON CONFLICT(server_id) DO UPDATE SET token_hash = excluded.token_hash, created_at = excluded.created_at, last_received_at = NULL`,
+		serverID, hashToken(token), time.Now().UTC(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("saving webhook token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *Store) GetServerWebhook(serverID int64) (*models.ServerWebhook, error) {
+	var hook models.ServerWebhook
+	var lastReceived sql.NullTime
+	err := s.db.QueryRow(
+		`SELECT server_id, created_at, last_received_at, signing_secret != '' FROM server_webhooks WHERE server_id = ?`, serverID,
+	).Scan(&hook.ServerID, &hook.CreatedAt, &lastReceived, &hook.Signed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("server %d webhook: %w", serverID, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting server webhook: %w", err)
+	}
+	if lastReceived.Valid {
+		hook.LastReceivedAt = &lastReceived.Time
+	}
+	return &hook, nil
+}
+
+// ResolveWebhookToken returns the server a webhook token belongs to and its
+// decrypted signing secret, empty when deliveries aren't signed.
+func (s *Store) ResolveWebhookToken(token string) (serverID int64, secret string, err error) {
+	var stored string
+	err = s.db.QueryRow(
+		`SELECT server_id, signing_secret FROM server_webhooks WHERE token_hash = ?`, hashToken(token),
+	).Scan(&serverID, &stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", fmt.Errorf("webhook token: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("resolving webhook token: %w", err)
+	}
+	if secret, err = s.decryptValue(stored); err != nil {
+		return 0, "", fmt.Errorf("decrypting webhook signing secret: %w", err)
+	}
+	return serverID, secret, nil
+}
+
+// MarkWebhookReceived records the time of an accepted delivery.
+func (s *Store) MarkWebhookReceived(serverID int64) error {
+	_, err := s.db.Exec(`UPDATE server_webhooks SET last_received_at = ? WHERE server_id = ?`, time.Now().UTC(), serverID)
+	if err != nil {
+		return fmt.Errorf("recording webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// RotateServerWebhookSecret generates a new HMAC signing secret for the
+// server's webhook and returns it in plaintext. From then on deliveries
+// must be signed.
+func (s *Store) RotateServerWebhookSecret(serverID int64) (string, error) {
+	secret, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("generating webhook secret: %w", err)
+	}
+	stored, err := s.encryptValue(secret)
+	if err != nil {
+		return "", fmt.Errorf("encrypting webhook secret: %w", err)
+	}
+	if err := s.setWebhookSecret(serverID, stored); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// ClearServerWebhookSecret stops requiring signed deliveries.
+func (s *Store) ClearServerWebhookSecret(serverID int64) error {
+	return s.setWebhookSecret(serverID, "")
+}
+
+func (s *Store) setWebhookSecret(serverID int64, stored string) error {
+	result, err := s.db.Exec(`UPDATE server_webhooks SET signing_secret = ? WHERE server_id = ?`, stored, serverID)
+	if err != nil {
+		return fmt.Errorf("saving webhook secret: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("server %d webhook: %w", serverID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) DeleteServerWebhook(serverID int64) error {
+	result, err := s.db.Exec(`DELETE FROM server_webhooks WHERE server_id = ?`, serverID)
+	if err != nil {
+		return fmt.Errorf("deleting server webhook: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("server %d webhook: %w", serverID, models.ErrNotFound)
+	}
+	return nil
+}
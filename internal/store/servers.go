@@ -2,22 +2,33 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"streammon/internal/models"
 )
 
-const serverColumns = `id, name, type, url, api_key, machine_id, enabled, show_recent_media, created_at, updated_at, deleted_at`
+const serverColumns = `id, name, type, url, api_key, machine_id, enabled, show_recent_media, owner_user_name, exclude_owner_stats, poll_interval_seconds, paused, library_filter, http_options, created_at, updated_at, deleted_at`
 
 func scanServer(scanner interface{ Scan(...any) error }) (models.Server, error) {
 	var srv models.Server
 	var deletedAt sql.NullTime
-	err := scanner.Scan(&srv.ID, &srv.Name, &srv.Type, &srv.URL, &srv.APIKey, &srv.MachineID, &srv.Enabled, &srv.ShowRecentMedia, &srv.CreatedAt, &srv.UpdatedAt, &deletedAt)
+	var libraryFilter, httpOptions string
+	err := scanner.Scan(&srv.ID, &srv.Name, &srv.Type, &srv.URL, &srv.APIKey, &srv.MachineID, &srv.Enabled, &srv.ShowRecentMedia, &srv.OwnerUserName, &srv.ExcludeOwnerStats, &srv.PollIntervalSeconds, &srv.Paused, &libraryFilter, &httpOptions, &srv.CreatedAt, &srv.UpdatedAt, &deletedAt)
+	if err != nil {
+		return srv, err
+	}
 	if deletedAt.Valid {
 		srv.DeletedAt = &deletedAt.Time
 	}
-	return srv, err
+	if err := json.Unmarshal([]byte(libraryFilter), &srv.LibraryFilter); err != nil {
+		return srv, fmt.Errorf("parsing library filter: %w", err)
+	}
+	if err := json.Unmarshal([]byte(httpOptions), &srv.HTTP); err != nil {
+		return srv, fmt.Errorf("parsing http options: %w", err)
+	}
+	return srv, nil
 }
 
 func (s *Store) decryptServerKey(srv *models.Server) error {
@@ -34,9 +45,13 @@ func (s *Store) CreateServer(srv *models.Server) error {
 	if err != nil {
 		return fmt.Errorf("encrypting api key: %w", err)
 	}
+	httpOptions, err := json.Marshal(srv.HTTP)
+	if err != nil {
+		return fmt.Errorf("encoding http options: %w", err)
+	}
 	created, err := scanServer(s.db.QueryRow(
-		`INSERT INTO servers (name, type, url, api_key, machine_id, enabled, show_recent_media) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING `+serverColumns,
-		srv.Name, srv.Type, srv.URL, encKey, srv.MachineID, srv.Enabled, srv.ShowRecentMedia,
+		`INSERT INTO servers (name, type, url, api_key, machine_id, enabled, show_recent_media, owner_user_name, exclude_owner_stats, http_options) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING `+serverColumns,
+		srv.Name, srv.Type, srv.URL, encKey, srv.MachineID, srv.Enabled, srv.ShowRecentMedia, srv.OwnerUserName, srv.ExcludeOwnerStats, string(httpOptions),
 	))
 	if err != nil {
 		return fmt.Errorf("creating server: %w", err)
@@ -94,15 +109,42 @@ func (s *Store) ListAllServers() ([]models.Server, error) {
 	return s.listServers(`SELECT ` + serverColumns + ` FROM servers ORDER BY id`)
 }
 
+// GetServerByMachineID returns the active (non-deleted) server with the given
+// machine ID, compared case-insensitively.
+func (s *Store) GetServerByMachineID(machineID string) (*models.Server, error) {
+	if machineID == "" {
+		return nil, fmt.Errorf("server machine_id %q: %w", machineID, models.ErrNotFound)
+	}
+	srv, err := scanServer(s.db.QueryRow(
+		`SELECT `+serverColumns+` FROM servers
+		 WHERE machine_id = ? COLLATE NOCASE AND deleted_at IS NULL
+		 ORDER BY id LIMIT 1`, machineID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("server machine_id %q: %w", machineID, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting server: %w", err)
+	}
+	if err := s.decryptServerKey(&srv); err != nil {
+		return nil, err
+	}
+	return &srv, nil
+}
+
 func (s *Store) UpdateServer(srv *models.Server) error {
 	encKey, err := s.encryptValue(srv.APIKey)
 	if err != nil {
 		return fmt.Errorf("encrypting api key: %w", err)
 	}
+	httpOptions, err := json.Marshal(srv.HTTP)
+	if err != nil {
+		return fmt.Errorf("encoding http options: %w", err)
+	}
 	updated, err := scanServer(s.db.QueryRow(
-		`UPDATE servers SET name = ?, type = ?, url = ?, api_key = ?, machine_id = ?, enabled = ?, show_recent_media = ?, updated_at = CURRENT_TIMESTAMP
+		`UPDATE servers SET name = ?, type = ?, url = ?, api_key = ?, machine_id = ?, enabled = ?, show_recent_media = ?, owner_user_name = ?, exclude_owner_stats = ?, http_options = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? RETURNING `+serverColumns,
-		srv.Name, srv.Type, srv.URL, encKey, srv.MachineID, srv.Enabled, srv.ShowRecentMedia, srv.ID,
+		srv.Name, srv.Type, srv.URL, encKey, srv.MachineID, srv.Enabled, srv.ShowRecentMedia, srv.OwnerUserName, srv.ExcludeOwnerStats, string(httpOptions), srv.ID,
 	))
 	if errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("server %d: %w", srv.ID, models.ErrNotFound)
@@ -145,11 +187,15 @@ func (s *Store) UpdateServerAtomic(existing, srv *models.Server) error {
 	if err != nil {
 		return fmt.Errorf("encrypting api key: %w", err)
 	}
+	httpOptions, err := json.Marshal(srv.HTTP)
+	if err != nil {
+		return fmt.Errorf("encoding http options: %w", err)
+	}
 
 	updated, err := scanServer(tx.QueryRow(
-		`UPDATE servers SET name = ?, type = ?, url = ?, api_key = ?, machine_id = ?, enabled = ?, show_recent_media = ?, updated_at = CURRENT_TIMESTAMP
+		`UPDATE servers SET name = ?, type = ?, url = ?, api_key = ?, machine_id = ?, enabled = ?, show_recent_media = ?, owner_user_name = ?, exclude_owner_stats = ?, http_options = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? RETURNING `+serverColumns,
-		srv.Name, srv.Type, srv.URL, encKey, srv.MachineID, srv.Enabled, srv.ShowRecentMedia, srv.ID,
+		srv.Name, srv.Type, srv.URL, encKey, srv.MachineID, srv.Enabled, srv.ShowRecentMedia, srv.OwnerUserName, srv.ExcludeOwnerStats, string(httpOptions), srv.ID,
 	))
 	if errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("server %d: %w", srv.ID, models.ErrNotFound)
@@ -166,6 +212,47 @@ func (s *Store) UpdateServerAtomic(existing, srv *models.Server) error {
 	return s.decryptServerKey(srv)
 }
 
+// SetServerPolling updates a server's poll interval override and paused flag.
+func (s *Store) SetServerPolling(id int64, p models.ServerPolling) error {
+	result, err := s.db.Exec(`UPDATE servers SET poll_interval_seconds = ?, paused = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND deleted_at IS NULL`, p.PollIntervalSeconds, p.Paused, id)
+	if err != nil {
+		return fmt.Errorf("updating server polling: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("server %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+// SetServerLibraryFilter replaces the libraries a server records plays from.
+func (s *Store) SetServerLibraryFilter(id int64, f models.ServerLibraryFilter) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	filter, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encoding library filter: %w", err)
+	}
+	result, err := s.db.Exec(`UPDATE servers SET library_filter = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND deleted_at IS NULL`, string(filter), id)
+	if err != nil {
+		return fmt.Errorf("updating server library filter: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("server %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
 // DeleteServer permanently removes a server and all its watch history.
 // Works on both active and soft-deleted servers.
 func (s *Store) DeleteServer(id int64) error {
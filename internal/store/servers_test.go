@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"streammon/internal/httputil"
 	"streammon/internal/models"
 )
 
@@ -321,3 +322,52 @@ func TestDoubleSoftDelete(t *testing.T) {
 		t.Fatalf("expected ErrNotFound on double soft-delete, got %v", err)
 	}
 }
+
+func TestGetServerByMachineID(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	srv := &models.Server{Name: "Emby", Type: models.ServerTypeEmby, URL: "http://emby", APIKey: "k", MachineID: "ABC123", Enabled: true}
+	if err := s.CreateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetServerByMachineID("abc123")
+	if err != nil {
+		t.Fatalf("GetServerByMachineID: %v", err)
+	}
+	if got.ID != srv.ID {
+		t.Errorf("got server %d, want %d", got.ID, srv.ID)
+	}
+
+	if err := s.SoftDeleteServer(srv.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetServerByMachineID("ABC123"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("deleted server: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestServerHTTPOptions(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	srv := &models.Server{Name: "Plex", Type: models.ServerTypePlex, URL: "https://plex:32400", APIKey: "k",
+		HTTP: httputil.ClientOptions{ProxyURL: "socks5://proxy:1080", TimeoutSeconds: 30}}
+	if err := s.CreateServer(srv); err != nil {
+		t.Fatalf("CreateServer: %v", err)
+	}
+	got, err := s.GetServer(srv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HTTP != srv.HTTP {
+		t.Fatalf("expected %+v, got %+v", srv.HTTP, got.HTTP)
+	}
+
+	srv.HTTP = httputil.ClientOptions{InsecureSkipVerify: true}
+	if err := s.UpdateServer(srv); err != nil {
+		t.Fatalf("UpdateServer: %v", err)
+	}
+	got, _ = s.GetServer(srv.ID)
+	if got.HTTP != (httputil.ClientOptions{InsecureSkipVerify: true}) {
+		t.Fatalf("expected insecure-only options, got %+v", got.HTTP)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"streammon/internal/models"
@@ -28,13 +29,44 @@ func hashToken(token string) string {
 }
 
 func (s *Store) CreateSession(userID int64, expiresAt time.Time) (string, error) {
+	return s.CreateLoginSession(userID, expiresAt, SessionClient{}, OIDCSession{})
+}
+
+// SessionClient is the client a session signed in from.
+type SessionClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// OIDCSession identifies the identity provider login a session came from:
+// the user's subject, the IdP's session ID (the "sid" claim, when sent), and
+// the ID token, kept as the hint for RP-initiated logout.
+type OIDCSession struct {
+	Subject   string
+	SessionID string
+	IDToken   string
+}
+
+// CreateOIDCSession is CreateSession for an OIDC login.
+func (s *Store) CreateOIDCSession(userID int64, expiresAt time.Time, o OIDCSession) (string, error) {
+	return s.CreateLoginSession(userID, expiresAt, SessionClient{}, o)
+}
+
+// CreateLoginSession creates a session recording the client it signed in
+// from. o is zero for logins that didn't come from OIDC.
+func (s *Store) CreateLoginSession(userID int64, expiresAt time.Time, client SessionClient, o OIDCSession) (string, error) {
 	token, err := generateToken()
 	if err != nil {
 		return "", fmt.Errorf("generating session token: %w", err)
 	}
+	userAgent := client.UserAgent
+	if len(userAgent) > models.MaxSessionUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:models.MaxSessionUserAgentLength], "")
+	}
 	_, err = s.db.Exec(
-		`INSERT INTO sessions (id, user_id, expires_at) VALUES (?, ?, ?)`,
-		hashToken(token), userID, expiresAt.UTC(),
+		`INSERT INTO sessions (id, user_id, expires_at, oidc_sub, oidc_sid, oidc_id_token, ip_address, user_agent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		hashToken(token), userID, expiresAt.UTC(), o.Subject, o.SessionID, o.IDToken, client.IPAddress, userAgent,
 	)
 	if err != nil {
 		return "", fmt.Errorf("creating session: %w", err)
@@ -42,6 +74,42 @@ func (s *Store) CreateSession(userID int64, expiresAt time.Time) (string, error)
 	return token, nil
 }
 
+// GetSessionOIDC returns the OIDC login behind a session; it is zero for
+// sessions that didn't come from OIDC.
+func (s *Store) GetSessionOIDC(token string) (OIDCSession, error) {
+	var o OIDCSession
+	err := s.db.QueryRow(`SELECT oidc_sub, oidc_sid, oidc_id_token FROM sessions WHERE id = ?`,
+		hashToken(token)).Scan(&o.Subject, &o.SessionID, &o.IDToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return o, fmt.Errorf("session: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return o, fmt.Errorf("getting session oidc login: %w", err)
+	}
+	return o, nil
+}
+
+// DeleteOIDCSessions ends the sessions an IdP logout names: the one IdP
+// session sid when given (and, with subject, only if it belongs to that
+// user), otherwise every session of subject.
+func (s *Store) DeleteOIDCSessions(subject, sid string) (int64, error) {
+	var result sql.Result
+	var err error
+	switch {
+	case sid != "":
+		result, err = s.db.Exec(`DELETE FROM sessions WHERE oidc_sid = ? AND (? = '' OR oidc_sub = ?)`,
+			sid, subject, subject)
+	case subject != "":
+		result, err = s.db.Exec(`DELETE FROM sessions WHERE oidc_sub = ?`, subject)
+	default:
+		return 0, errors.New("subject or session id is required")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("deleting oidc sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 func (s *Store) GetSessionUser(token string) (*models.User, error) {
 	u, err := scanUser(s.db.QueryRow(
 		`SELECT u.id, u.name, u.email, u.role, u.thumb_url, u.created_at, u.updated_at FROM users u
@@ -84,3 +152,59 @@ func (s *Store) DeleteExpiredSessions() (int64, error) {
 	}
 	return result.RowsAffected()
 }
+
+// ListLoginSessions returns userID's unexpired sessions, most recently used
+// first, marking the one currentToken belongs to.
+func (s *Store) ListLoginSessions(userID int64, currentToken string) ([]models.LoginSession, error) {
+	rows, err := s.db.Query(`SELECT rowid, user_id, ip_address, user_agent, created_at, last_used_at, expires_at,
+		id = ? FROM sessions
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY COALESCE(last_used_at, created_at) DESC, rowid DESC`,
+		hashToken(currentToken), userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.LoginSession{}
+	for rows.Next() {
+		var ls models.LoginSession
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&ls.ID, &ls.UserID, &ls.IPAddress, &ls.UserAgent, &ls.CreatedAt, &lastUsed,
+			&ls.ExpiresAt, &ls.Current); err != nil {
+			return nil, fmt.Errorf("scanning session: %w", err)
+		}
+		if lastUsed.Valid {
+			ls.LastSeenAt = &lastUsed.Time
+		}
+		sessions = append(sessions, ls)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteLoginSession revokes one of userID's sessions by its listed ID.
+// Another user's session reports ErrNotFound.
+func (s *Store) DeleteLoginSession(userID, id int64) error {
+	result, err := s.db.Exec(`DELETE FROM sessions WHERE rowid = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("session %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteUserSessions signs userID out everywhere, returning how many
+// sessions ended.
+func (s *Store) DeleteUserSessions(userID int64) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("deleting user sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -127,3 +128,106 @@ func TestSessionTokenIsHashedInDB(t *testing.T) {
 		t.Fatalf("expected hashed token in DB, found %d rows", count)
 	}
 }
+
+func TestDeleteOIDCSessions(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	user, _ := s.GetOrCreateUser("frank")
+	expires := time.Now().UTC().Add(24 * time.Hour)
+
+	laptop, _ := s.CreateOIDCSession(user.ID, expires, OIDCSession{Subject: "sub-1", SessionID: "sid-a", IDToken: "id.token.a"})
+	phone, _ := s.CreateOIDCSession(user.ID, expires, OIDCSession{Subject: "sub-1", SessionID: "sid-b"})
+	local, _ := s.CreateSession(user.ID, expires)
+
+	o, err := s.GetSessionOIDC(laptop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Subject != "sub-1" || o.SessionID != "sid-a" || o.IDToken != "id.token.a" {
+		t.Errorf("GetSessionOIDC = %+v", o)
+	}
+	if o, _ := s.GetSessionOIDC(local); o != (OIDCSession{}) {
+		t.Errorf("local session reported oidc login %+v", o)
+	}
+
+	// A sid belonging to another subject is left alone.
+	if n, _ := s.DeleteOIDCSessions("sub-2", "sid-a"); n != 0 {
+		t.Errorf("mismatched subject deleted %d sessions", n)
+	}
+	if n, _ := s.DeleteOIDCSessions("", "sid-a"); n != 1 {
+		t.Errorf("sid logout deleted %d sessions, want 1", n)
+	}
+	if _, err := s.GetSessionUser(phone); err != nil {
+		t.Error("other IdP session should survive a sid logout")
+	}
+	if n, _ := s.DeleteOIDCSessions("sub-1", ""); n != 1 {
+		t.Errorf("subject logout deleted %d sessions, want 1", n)
+	}
+	if _, err := s.GetSessionUser(local); err != nil {
+		t.Error("non-OIDC session should survive")
+	}
+	if _, err := s.DeleteOIDCSessions("", ""); err == nil {
+		t.Error("expected error without subject or sid")
+	}
+}
+
+func TestLoginSessions(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	user, _ := s.GetOrCreateUser("grace")
+	other, _ := s.GetOrCreateUser("heidi")
+	expires := time.Now().UTC().Add(24 * time.Hour)
+
+	laptop, err := s.CreateLoginSession(user.ID, expires,
+		SessionClient{IPAddress: "192.0.2.10", UserAgent: strings.Repeat("x", 600)}, OIDCSession{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, _ := s.CreateLoginSession(user.ID, expires, SessionClient{IPAddress: "198.51.100.4", UserAgent: "Phone"}, OIDCSession{})
+	if _, err := s.CreateSession(user.ID, time.Now().UTC().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	otherToken, _ := s.CreateSession(other.ID, expires)
+
+	sessions, err := s.ListLoginSessions(user.ID, laptop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2 unexpired", len(sessions))
+	}
+	var current, phoneSession models.LoginSession
+	for _, ls := range sessions {
+		if ls.Current {
+			current = ls
+		} else {
+			phoneSession = ls
+		}
+	}
+	if current.IPAddress != "192.0.2.10" || len(current.UserAgent) != models.MaxSessionUserAgentLength {
+		t.Errorf("current session = %+v", current)
+	}
+	if phoneSession.UserAgent != "Phone" || phoneSession.CreatedAt.IsZero() {
+		t.Errorf("phone session = %+v", phoneSession)
+	}
+
+	others, _ := s.ListLoginSessions(other.ID, "")
+	if err := s.DeleteLoginSession(user.ID, others[0].ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("deleting another user's session: err = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteLoginSession(user.ID, phoneSession.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSessionUser(phone); err == nil {
+		t.Error("revoked session still valid")
+	}
+
+	n, err := s.DeleteUserSessions(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("revoked %d sessions, want 2 including the expired one", n)
+	}
+	if _, err := s.GetSessionUser(otherToken); err != nil {
+		t.Error("another user's session should survive")
+	}
+}
@@ -2,11 +2,15 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"streammon/internal/httputil"
+	"streammon/internal/models"
 	"streammon/internal/units"
 )
 
@@ -172,6 +176,7 @@ type IntegrationConfig struct {
 	URL     string
 	APIKey  string
 	Enabled bool
+	HTTP    httputil.ClientOptions
 }
 
 // HasCredentials reports whether the integration has a URL and a real
@@ -207,6 +212,15 @@ func (s *Store) getIntegrationConfig(prefix string) (IntegrationConfig, error) {
 		return cfg, err
 	}
 	cfg.Enabled = enabled != "0"
+	rawHTTP, err := s.GetSetting(prefix + ".http")
+	if err != nil {
+		return cfg, err
+	}
+	if rawHTTP != "" {
+		if err := json.Unmarshal([]byte(rawHTTP), &cfg.HTTP); err != nil {
+			return cfg, fmt.Errorf("parsing %s http options: %w", prefix, err)
+		}
+	}
 	return cfg, nil
 }
 
@@ -236,13 +250,20 @@ func (s *Store) setIntegrationConfig(prefix string, cfg IntegrationConfig) error
 	if _, err := tx.Exec(settingUpsert, prefix+".enabled", enabledVal); err != nil {
 		return fmt.Errorf("setting %q: %w", prefix+".enabled", err)
 	}
+	httpVal, err := json.Marshal(cfg.HTTP)
+	if err != nil {
+		return fmt.Errorf("encoding %s http options: %w", prefix, err)
+	}
+	if _, err := tx.Exec(settingUpsert, prefix+".http", string(httpVal)); err != nil {
+		return fmt.Errorf("setting %q: %w", prefix+".http", err)
+	}
 
 	return tx.Commit()
 }
 
 func (s *Store) deleteIntegrationConfig(prefix string) error {
-	_, err := s.db.Exec(`DELETE FROM settings WHERE key IN (?, ?, ?)`,
-		prefix+".url", prefix+".api_key", prefix+".enabled")
+	_, err := s.db.Exec(`DELETE FROM settings WHERE key IN (?, ?, ?, ?)`,
+		prefix+".url", prefix+".api_key", prefix+".enabled", prefix+".http")
 	if err != nil {
 		return fmt.Errorf("deleting %s config: %w", prefix, err)
 	}
@@ -267,10 +288,70 @@ func (s *Store) GetRadarrConfig() (RadarrConfig, error) { return s.getIntegratio
 func (s *Store) SetRadarrConfig(cfg RadarrConfig) error { return s.setIntegrationConfig("radarr", cfg) }
 func (s *Store) DeleteRadarrConfig() error              { return s.deleteIntegrationConfig("radarr") }
 
+func (s *Store) getArrDeletePolicy(prefix string) (models.ArrDeletePolicy, error) {
+	var p models.ArrDeletePolicy
+	action, err := s.GetSetting(prefix + ".delete_action")
+	if err != nil {
+		return p, err
+	}
+	exclusion, err := s.GetSetting(prefix + ".add_exclusion")
+	if err != nil {
+		return p, err
+	}
+	p.Action = models.ArrDeleteAction(action)
+	p.AddExclusion = exclusion == "1"
+	if err := p.Validate(); err != nil {
+		return models.ArrDeletePolicy{Action: models.ArrDeleteActionDelete}, nil
+	}
+	return p, nil
+}
+
+func (s *Store) setArrDeletePolicy(prefix string, p models.ArrDeletePolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(settingUpsert, prefix+".delete_action", string(p.Action)); err != nil {
+		return fmt.Errorf("setting %q: %w", prefix+".delete_action", err)
+	}
+	exclusion := "0"
+	if p.AddExclusion {
+		exclusion = "1"
+	}
+	if _, err := tx.Exec(settingUpsert, prefix+".add_exclusion", exclusion); err != nil {
+		return fmt.Errorf("setting %q: %w", prefix+".add_exclusion", err)
+	}
+	return tx.Commit()
+}
+
+// GetRadarrDeletePolicy returns what maintenance deletes do in Radarr. An
+// unset or invalid policy deletes the movie and its files.
+func (s *Store) GetRadarrDeletePolicy() (models.ArrDeletePolicy, error) {
+	return s.getArrDeletePolicy("radarr")
+}
+func (s *Store) SetRadarrDeletePolicy(p models.ArrDeletePolicy) error {
+	return s.setArrDeletePolicy("radarr", p)
+}
+
+// GetSonarrDeletePolicy returns what maintenance deletes do in Sonarr. An
+// unset or invalid policy deletes the series and its files.
+func (s *Store) GetSonarrDeletePolicy() (models.ArrDeletePolicy, error) {
+	return s.getArrDeletePolicy("sonarr")
+}
+func (s *Store) SetSonarrDeletePolicy(p models.ArrDeletePolicy) error {
+	return s.setArrDeletePolicy("sonarr", p)
+}
+
 // plaintextSecretKeys lists all settings keys that should be encrypted at rest.
 var plaintextSecretKeys = []string{
 	"overseerr.api_key", "sonarr.api_key", "radarr.api_key", "tautulli.api_key",
 	"jellystat.api_key", "oidc.client_secret", "maxmind.license_key",
+	"vapid.private_key",
 }
 
 // GetMaxMindLicenseKey returns the decrypted MaxMind license key, or
@@ -417,6 +498,21 @@ func (s *Store) SetDiscoverRegion(region string) error {
 	return s.SetSetting(discoverRegionKey, region)
 }
 
+const displayTimezoneKey = "display.timezone"
+
+// GetDisplayTimezone returns the IANA timezone charts bucket by when the
+// caller has none of their own, or "" for UTC.
+func (s *Store) GetDisplayTimezone() (string, error) {
+	return s.GetSetting(displayTimezoneKey)
+}
+
+func (s *Store) SetDisplayTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q", name)
+	}
+	return s.SetSetting(displayTimezoneKey, name)
+}
+
 // IsValidRegionCode reports whether code is a well-formed two-letter,
 // uppercase ISO-3166 region code. Exported so callers (e.g. HTTP handlers)
 // can validate input before calling SetDiscoverRegion, letting them
@@ -590,3 +686,172 @@ func (s *Store) SetMaintenanceResolutionWidthAware(enabled bool) error {
 	}
 	return s.SetSetting(maintenanceResolutionWidthAwareKey, val)
 }
+
+const maintenanceContinueWatchingDaysKey = "maintenance.continue_watching_days"
+
+const (
+	DefaultContinueWatchingDays = 30
+	MaxContinueWatchingDays     = 365
+)
+
+// GetMaintenanceContinueWatchingDays returns how far back a partial play
+// keeps an item out of maintenance candidates. 0 disables the protection.
+func (s *Store) GetMaintenanceContinueWatchingDays() (int, error) {
+	n, err := s.getIntSetting(maintenanceContinueWatchingDaysKey, DefaultContinueWatchingDays)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > MaxContinueWatchingDays {
+		return DefaultContinueWatchingDays, nil
+	}
+	return n, nil
+}
+
+func (s *Store) SetMaintenanceContinueWatchingDays(days int) error {
+	if days < 0 || days > MaxContinueWatchingDays {
+		return fmt.Errorf("continue watching days must be between 0 and %d, got %d", MaxContinueWatchingDays, days)
+	}
+	return s.SetSetting(maintenanceContinueWatchingDaysKey, strconv.Itoa(days))
+}
+
+const maintenanceCandidateWebhookThresholdKey = "maintenance.candidate_webhook_threshold"
+
+// MaxCandidateWebhookThreshold bounds the candidate count that triggers a
+// maintenance.threshold_exceeded webhook.
+const MaxCandidateWebhookThreshold = 1000000
+
+// GetMaintenanceCandidateWebhookThreshold returns how many candidates a rule
+// evaluation must find to send maintenance.threshold_exceeded. 0, the
+// default, never sends it.
+func (s *Store) GetMaintenanceCandidateWebhookThreshold() (int, error) {
+	n, err := s.getIntSetting(maintenanceCandidateWebhookThresholdKey, 0)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > MaxCandidateWebhookThreshold {
+		return 0, nil
+	}
+	return n, nil
+}
+
+func (s *Store) SetMaintenanceCandidateWebhookThreshold(n int) error {
+	if n < 0 || n > MaxCandidateWebhookThreshold {
+		return fmt.Errorf("candidate webhook threshold must be between 0 and %d, got %d", MaxCandidateWebhookThreshold, n)
+	}
+	return s.SetSetting(maintenanceCandidateWebhookThresholdKey, strconv.Itoa(n))
+}
+
+const maintenanceCollectionProtectionKey = "maintenance.collection_protection"
+
+// GetMaintenanceCollectionProtection returns which collections keep their
+// members out of maintenance candidates. Nothing is protected by default.
+func (s *Store) GetMaintenanceCollectionProtection() (models.CollectionProtection, error) {
+	p := models.CollectionProtection{Names: []string{}}
+	val, err := s.GetSetting(maintenanceCollectionProtectionKey)
+	if err != nil {
+		return p, err
+	}
+	if val == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(val), &p); err != nil {
+		return p, fmt.Errorf("parsing collection protection: %w", err)
+	}
+	if p.Names == nil {
+		p.Names = []string{}
+	}
+	return p, nil
+}
+
+func (s *Store) SetMaintenanceCollectionProtection(p models.CollectionProtection) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	val, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encoding collection protection: %w", err)
+	}
+	return s.SetSetting(maintenanceCollectionProtectionKey, string(val))
+}
+
+const librarySyncParallelismKey = "maintenance.sync_parallelism_per_server"
+
+const (
+	DefaultLibrarySyncParallelism = 1
+	MaxLibrarySyncParallelism     = 8
+)
+
+// GetLibrarySyncParallelism returns how many manual library syncs may run at
+// once against a single media server.
+func (s *Store) GetLibrarySyncParallelism() (int, error) {
+	n, err := s.getIntSetting(librarySyncParallelismKey, DefaultLibrarySyncParallelism)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 || n > MaxLibrarySyncParallelism {
+		return DefaultLibrarySyncParallelism, nil
+	}
+	return n, nil
+}
+
+func (s *Store) SetLibrarySyncParallelism(n int) error {
+	if n < 1 || n > MaxLibrarySyncParallelism {
+		return fmt.Errorf("sync parallelism must be between 1 and %d, got %d", MaxLibrarySyncParallelism, n)
+	}
+	return s.SetSetting(librarySyncParallelismKey, strconv.Itoa(n))
+}
+
+const rateLimitsKey = "rate_limits"
+
+// GetRateLimitSettings returns the configured request limits, or the
+// defaults when none have been saved.
+func (s *Store) GetRateLimitSettings() (models.RateLimitSettings, error) {
+	settings := models.DefaultRateLimitSettings()
+	val, err := s.GetSetting(rateLimitsKey)
+	if err != nil || val == "" {
+		return settings, err
+	}
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return models.DefaultRateLimitSettings(), fmt.Errorf("parsing rate limits: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Store) SetRateLimitSettings(settings models.RateLimitSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	val, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encoding rate limits: %w", err)
+	}
+	return s.SetSetting(rateLimitsKey, string(val))
+}
+
+const proxyAuthKey = "proxy_auth"
+
+// GetProxyAuthSettings returns the trusted header authentication settings,
+// disabled by default.
+func (s *Store) GetProxyAuthSettings() (models.ProxyAuthSettings, error) {
+	settings := models.DefaultProxyAuthSettings()
+	val, err := s.GetSetting(proxyAuthKey)
+	if err != nil || val == "" {
+		return settings, err
+	}
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return models.DefaultProxyAuthSettings(), fmt.Errorf("parsing proxy auth settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Store) SetProxyAuthSettings(settings models.ProxyAuthSettings) error {
+	settings.Normalize()
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	val, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encoding proxy auth settings: %w", err)
+	}
+	return s.SetSetting(proxyAuthKey, string(val))
+}
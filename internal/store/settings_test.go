@@ -1,9 +1,11 @@
 package store
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"streammon/internal/httputil"
 	"streammon/internal/models"
 )
 
@@ -194,7 +196,12 @@ func TestIntegrationConfigs(t *testing.T) {
 	for _, ic := range integrations {
 		t.Run(ic.name+"/round trip", func(t *testing.T) {
 			s := newTestStoreWithMigrations(t)
-			cfg := IntegrationConfig{URL: ic.url, APIKey: "my-" + ic.name + "-key", Enabled: true}
+			cfg := IntegrationConfig{
+				URL:     ic.url,
+				APIKey:  "my-" + ic.name + "-key",
+				Enabled: true,
+				HTTP:    httputil.ClientOptions{ProxyURL: "http://proxy.lan:3128", TimeoutSeconds: 20},
+			}
 			if err := ic.ops.set(s, cfg); err != nil {
 				t.Fatalf("Set: %v", err)
 			}
@@ -231,7 +238,7 @@ func TestIntegrationConfigs(t *testing.T) {
 
 		t.Run(ic.name+"/delete", func(t *testing.T) {
 			s := newTestStoreWithMigrations(t)
-			if err := ic.ops.set(s, IntegrationConfig{URL: ic.url, APIKey: "my-key", Enabled: true}); err != nil {
+			if err := ic.ops.set(s, IntegrationConfig{URL: ic.url, APIKey: "my-key", Enabled: true, HTTP: httputil.ClientOptions{InsecureSkipVerify: true}}); err != nil {
 				t.Fatalf("Set: %v", err)
 			}
 			if err := ic.ops.delete(s); err != nil {
@@ -244,6 +251,9 @@ func TestIntegrationConfigs(t *testing.T) {
 			if got.URL != "" || got.APIKey != "" {
 				t.Fatalf("expected empty URL/APIKey after delete, got %+v", got)
 			}
+			if !got.HTTP.IsZero() {
+				t.Fatalf("expected http options cleared after delete, got %+v", got.HTTP)
+			}
 		})
 
 		t.Run(ic.name+"/empty defaults", func(t *testing.T) {
@@ -826,9 +836,13 @@ func TestCleanupZombieSessions(t *testing.T) {
 		t.Fatalf("InsertHistory zero-progress: %v", err)
 	}
 
-	if err := s.CleanupZombieSessions(); err != nil {
+	report, err := s.CleanupZombieSessions(context.Background())
+	if err != nil {
 		t.Fatalf("CleanupZombieSessions: %v", err)
 	}
+	if report.Closed != 2 || report.ByReason[ZombieReasonMaxAge] != 2 {
+		t.Fatalf("expected 2 sessions closed for max age, got %+v", report)
+	}
 
 	result, err := s.ListHistory(1, 10, "", "", "", nil)
 	if err != nil {
@@ -854,9 +868,117 @@ func TestCleanupZombieSessions(t *testing.T) {
 		}
 	}
 
-	if err := s.CleanupZombieSessions(); err != nil {
+	report, err = s.CleanupZombieSessions(context.Background())
+	if err != nil {
 		t.Fatalf("second CleanupZombieSessions: %v", err)
 	}
+	if report.Closed != 0 {
+		t.Fatalf("second run should be a no-op, closed %d", report.Closed)
+	}
+}
+
+func TestCleanupZombieSessionsSilenceThreshold(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	ctx := context.Background()
+
+	// Three hours paused on a 1h watch: within the 24h age limit and the
+	// default 4h silence limit, but over a tightened 2h one.
+	start := time.Now().UTC().Add(-5 * time.Hour)
+	e := &models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "alice", Title: "Paused", MediaType: models.MediaTypeMovie,
+		StartedAt: start, StoppedAt: start.Add(4 * time.Hour), WatchedMs: int64(time.Hour / time.Millisecond),
+	}
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.CleanupZombieSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Closed != 0 {
+		t.Fatalf("expected nothing closed with defaults, got %d", report.Closed)
+	}
+
+	if err := s.SetZombieSettings(ZombieSettings{MaxSilenceMinutes: 5}); err == nil {
+		t.Fatal("expected silence threshold below the minimum to be rejected")
+	}
+	if err := s.SetZombieSettings(ZombieSettings{MaxSilenceMinutes: 120}); err != nil {
+		t.Fatal(err)
+	}
+	report, err = s.CleanupZombieSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Closed != 1 || report.Sessions[0].Reason != ZombieReasonSilence {
+		t.Fatalf("expected 1 silence closure, got %+v", report)
+	}
+	if got := report.Sessions[0].StoppedAt.Sub(start); got != time.Hour+5*time.Minute {
+		t.Errorf("capped wall time = %v, want 1h5m", got)
+	}
+
+	last, err := s.GetLastZombieReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.Closed != 1 || last.Settings.MaxSilenceMinutes != 120 {
+		t.Fatalf("stored report mismatch: %+v", last)
+	}
+}
+
+func TestZombieSettingsOptIn(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	z, err := s.GetZombieSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.Enabled {
+		t.Error("scheduled zombie cleanup should be off by default")
+	}
+	z.Enabled = true
+	if err := s.SetZombieSettings(z); err != nil {
+		t.Fatal(err)
+	}
+	if z, err = s.GetZombieSettings(); err != nil || !z.Enabled {
+		t.Fatalf("enabled not saved: %+v, %v", z, err)
+	}
+}
+
+func TestCleanupZombieSessionsKeepsReportedPauses(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	if err := s.SetZombieSettings(ZombieSettings{MaxSessionAgeHours: 24, MaxSilenceMinutes: 120}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A 1h watch with a reported 3h pause: 4h of wall time, but none of it
+	// silent, so it must survive the 2h silence limit untouched.
+	start := time.Now().UTC().Add(-5 * time.Hour)
+	e := &models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "alice", Title: "Long Pause", MediaType: models.MediaTypeMovie,
+		StartedAt: start, StoppedAt: start.Add(4 * time.Hour),
+		WatchedMs: int64(time.Hour / time.Millisecond), PausedMs: int64(3 * time.Hour / time.Millisecond),
+	}
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.CleanupZombieSessions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Closed != 0 {
+		t.Fatalf("paused session was closed: %+v", report.Sessions)
+	}
+	got, err := s.GetHistoryEntry(e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.StoppedAt.Equal(start.Add(4 * time.Hour)) {
+		t.Errorf("stopped_at = %v, want unchanged %v", got.StoppedAt, start.Add(4*time.Hour))
+	}
 }
 
 func TestIntegrationConfigEncryptedWithoutEncryptor(t *testing.T) {
@@ -1136,3 +1258,111 @@ func TestMaintenanceResolutionWidthAware_RoundTrip(t *testing.T) {
 		t.Errorf("after set(false), got true")
 	}
 }
+
+func TestMaintenanceCollectionProtection_RoundTrip(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	got, err := s.GetMaintenanceCollectionProtection()
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Enabled() {
+		t.Fatalf("default = %+v, want nothing protected", got)
+	}
+
+	in := models.CollectionProtection{Names: []string{"Keep Forever", " keep forever", "  "}}
+	if err := s.SetMaintenanceCollectionProtection(in); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	got, err = s.GetMaintenanceCollectionProtection()
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.AllCollections || len(got.Names) != 1 || got.Names[0] != "Keep Forever" {
+		t.Errorf("got %+v, want names [Keep Forever]", got)
+	}
+	if !got.Protects([]string{"KEEP FOREVER"}) || got.Protects([]string{"Other"}) {
+		t.Errorf("Protects matched the wrong collections")
+	}
+
+	tooMany := models.CollectionProtection{Names: make([]string, models.MaxProtectedCollections+1)}
+	if err := s.SetMaintenanceCollectionProtection(tooMany); err == nil {
+		t.Error("expected error for too many names")
+	}
+}
+
+func TestMaintenanceContinueWatchingDays(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	got, err := s.GetMaintenanceContinueWatchingDays()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != DefaultContinueWatchingDays {
+		t.Errorf("default = %d, want %d", got, DefaultContinueWatchingDays)
+	}
+	if err := s.SetMaintenanceContinueWatchingDays(0); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetMaintenanceContinueWatchingDays(); got != 0 {
+		t.Errorf("after set(0) got %d", got)
+	}
+	if err := s.SetMaintenanceContinueWatchingDays(MaxContinueWatchingDays + 1); err == nil {
+		t.Error("expected error above the maximum")
+	}
+}
+
+func TestRateLimitSettings_RoundTrip(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	got, err := s.GetRateLimitSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != models.DefaultRateLimitSettings() {
+		t.Errorf("default = %+v, want %+v", got, models.DefaultRateLimitSettings())
+	}
+
+	in := models.RateLimitSettings{
+		Search: models.SearchRateLimit{RequestsPerMinute: 120, Burst: 40, ExemptAPIKeys: true},
+		Auth:   models.AuthRateLimit{MaxFailures: 5, WindowMinutes: 30},
+	}
+	if err := s.SetRateLimitSettings(in); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetRateLimitSettings(); got != in {
+		t.Errorf("got %+v, want %+v", got, in)
+	}
+
+	bad := in
+	bad.Search.RequestsPerMinute = 0
+	if err := s.SetRateLimitSettings(bad); err == nil {
+		t.Error("expected error for zero requests per minute")
+	}
+}
+
+func TestProxyAuthSettings_RoundTrip(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	got, err := s.GetProxyAuthSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Enabled || got.UserHeader != models.DefaultProxyAuthUserHeader {
+		t.Errorf("default = %+v", got)
+	}
+
+	in := models.ProxyAuthSettings{
+		Enabled:        true,
+		UserHeader:     "x-authentik-username",
+		TrustedProxies: []string{" 10.0.0.0/8 ", "172.18.0.2"},
+	}
+	if err := s.SetProxyAuthSettings(in); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.GetProxyAuthSettings()
+	if got.UserHeader != "X-Authentik-Username" || len(got.TrustedProxies) != 2 || got.TrustedProxies[0] != "10.0.0.0/8" {
+		t.Errorf("got %+v", got)
+	}
+
+	in.TrustedProxies = nil
+	if err := s.SetProxyAuthSettings(in); err == nil {
+		t.Error("expected error enabling without trusted proxies")
+	}
+}
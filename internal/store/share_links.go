@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const shareLinkColumns = `id, target_type, target_id, created_by, expires_at, created_at`
+
+func scanShareLink(scanner interface{ Scan(...any) error }) (models.ShareLink, error) {
+	var l models.ShareLink
+	err := scanner.Scan(&l.ID, &l.TargetType, &l.TargetID, &l.CreatedBy, &l.ExpiresAt, &l.CreatedAt)
+	return l, err
+}
+
+// CreateShareLink stores a new link for in's target, valid until expiresAt,
+// and returns it with the plaintext token set.
+func (s *Store) CreateShareLink(in models.ShareLinkInput, createdBy string, expiresAt time.Time) (*models.ShareLink, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating share token: %w", err)
+	}
+	link, err := scanShareLink(s.db.QueryRow(
+		`INSERT INTO share_links (token_hash, target_type, target_id, created_by, expires_at)
+		VALUES (?, ?, ?, ?, ?) RETURNING `+shareLinkColumns,
+		hashToken(token), in.TargetType, in.TargetID, createdBy, expiresAt.UTC(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating share link: %w", err)
+	}
+	link.Token = token
+	return &link, nil
+}
+
+// ListShareLinks returns the links that haven't expired, newest first.
+func (s *Store) ListShareLinks() ([]models.ShareLink, error) {
+	rows, err := s.db.Query(`SELECT `+shareLinkColumns+` FROM share_links
+		WHERE expires_at > ? ORDER BY created_at DESC, id DESC`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("listing share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []models.ShareLink{}
+	for rows.Next() {
+		l, err := scanShareLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning share link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// ResolveShareLink returns the link a token belongs to. Unknown and expired
+// tokens both report ErrNotFound.
+func (s *Store) ResolveShareLink(token string) (*models.ShareLink, error) {
+	link, err := scanShareLink(s.db.QueryRow(
+		`SELECT `+shareLinkColumns+` FROM share_links WHERE token_hash = ? AND expires_at > ?`,
+		hashToken(token), time.Now().UTC(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("share link: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving share link: %w", err)
+	}
+	return &link, nil
+}
+
+func (s *Store) DeleteShareLink(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM share_links WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting share link: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("share link %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) DeleteExpiredShareLinks() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM share_links WHERE expires_at <= ?`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("deleting expired share links: %w", err)
+	}
+	return result.RowsAffected()
+}
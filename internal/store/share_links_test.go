@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestShareLinkLifecycle(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	in := models.ShareLinkInput{TargetType: models.ShareTargetHistory, TargetID: 42}
+	link, err := s.CreateShareLink(in, "admin", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+	if link.Token == "" || link.ID == 0 {
+		t.Fatalf("expected token and id, got %+v", link)
+	}
+
+	got, err := s.ResolveShareLink(link.Token)
+	if err != nil {
+		t.Fatalf("ResolveShareLink: %v", err)
+	}
+	if got.TargetType != models.ShareTargetHistory || got.TargetID != 42 || got.CreatedBy != "admin" {
+		t.Fatalf("unexpected link %+v", got)
+	}
+	if got.Token != "" {
+		t.Fatal("resolved link should not carry the token")
+	}
+
+	if _, err := s.ResolveShareLink("wrong"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown token, got %v", err)
+	}
+
+	links, err := s.ListShareLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(links))
+	}
+
+	if err := s.DeleteShareLink(link.ID); err != nil {
+		t.Fatalf("DeleteShareLink: %v", err)
+	}
+	if _, err := s.ResolveShareLink(link.Token); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected revoked link to be gone, got %v", err)
+	}
+	if err := s.DeleteShareLink(link.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestShareLinkExpiry(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+
+	in := models.ShareLinkInput{TargetType: models.ShareTargetViolation, TargetID: 1}
+	expired, err := s.CreateShareLink(in, "admin", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateShareLink(in, "admin", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ResolveShareLink(expired.Token); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected expired link to be rejected, got %v", err)
+	}
+	links, _ := s.ListShareLinks()
+	if len(links) != 1 {
+		t.Fatalf("expected only the active link listed, got %d", len(links))
+	}
+
+	n, err := s.DeleteExpiredShareLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 expired link deleted, got %d", n)
+	}
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// SharedIPOptions bounds the shared-IP report. Filter supplies the window
+// and servers; private and loopback addresses are left out unless
+// IncludePrivate is set, since everyone at home shares those.
+type SharedIPOptions struct {
+	Filter         StatsFilter
+	MinUsers       int
+	MinIPs         int
+	Limit          int
+	IncludePrivate bool
+}
+
+type userIPPlays struct {
+	user, ip  string
+	plays     int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+func isPrivateAddress(ip string) bool {
+	addr := net.ParseIP(ip)
+	return addr == nil || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast()
+}
+
+// SharedIPReport lists the addresses at least MinUsers distinct users played
+// from, and the users who played from at least MinIPs addresses, within the
+// filter's window. Each list is ordered by its count and capped at Limit.
+func (s *Store) SharedIPReport(ctx context.Context, opts SharedIPOptions) (*models.SharedIPReport, error) {
+	pairs, err := s.userIPPlays(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	byIP := make(map[string][]userIPPlays)
+	byUser := make(map[string][]userIPPlays)
+	for _, p := range pairs {
+		byIP[p.ip] = append(byIP[p.ip], p)
+		byUser[p.user] = append(byUser[p.user], p)
+	}
+
+	report := &models.SharedIPReport{
+		SharedIPs:    []models.SharedIPStat{},
+		MultiIPUsers: []models.MultiIPUserStat{},
+	}
+	for ip, ps := range byIP {
+		if len(ps) < opts.MinUsers {
+			continue
+		}
+		stat := models.SharedIPStat{IPAddress: ip, FirstSeen: ps[0].firstSeen, LastSeen: ps[0].lastSeen}
+		for _, p := range ps {
+			stat.Users = append(stat.Users, p.user)
+			stat.Plays += p.plays
+			if p.firstSeen.Before(stat.FirstSeen) {
+				stat.FirstSeen = p.firstSeen
+			}
+			if p.lastSeen.After(stat.LastSeen) {
+				stat.LastSeen = p.lastSeen
+			}
+		}
+		slices.Sort(stat.Users)
+		report.SharedIPs = append(report.SharedIPs, stat)
+	}
+	slices.SortFunc(report.SharedIPs, func(a, b models.SharedIPStat) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Users), len(a.Users)),
+			cmp.Compare(b.Plays, a.Plays),
+			strings.Compare(a.IPAddress, b.IPAddress),
+		)
+	})
+	if opts.Limit > 0 && len(report.SharedIPs) > opts.Limit {
+		report.SharedIPs = report.SharedIPs[:opts.Limit]
+	}
+
+	for user, ps := range byUser {
+		if len(ps) < opts.MinIPs {
+			continue
+		}
+		slices.SortFunc(ps, func(a, b userIPPlays) int {
+			return cmp.Or(cmp.Compare(b.plays, a.plays), strings.Compare(a.ip, b.ip))
+		})
+		stat := models.MultiIPUserStat{UserName: user, IPCount: len(ps)}
+		for i, p := range ps {
+			if i < models.MaxReportedIPs {
+				stat.IPs = append(stat.IPs, p.ip)
+			}
+			stat.Plays += p.plays
+			if p.lastSeen.After(stat.LastSeen) {
+				stat.LastSeen = p.lastSeen
+			}
+		}
+		report.MultiIPUsers = append(report.MultiIPUsers, stat)
+	}
+	slices.SortFunc(report.MultiIPUsers, func(a, b models.MultiIPUserStat) int {
+		return cmp.Or(
+			cmp.Compare(b.IPCount, a.IPCount),
+			cmp.Compare(b.Plays, a.Plays),
+			strings.Compare(a.UserName, b.UserName),
+		)
+	})
+	if opts.Limit > 0 && len(report.MultiIPUsers) > opts.Limit {
+		report.MultiIPUsers = report.MultiIPUsers[:opts.Limit]
+	}
+
+	if err := s.fillPeakConcurrent(ctx, opts.Filter, report.SharedIPs); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *Store) userIPPlays(ctx context.Context, opts SharedIPOptions) ([]userIPPlays, error) {
+	var clauses []string
+	var args []any
+	if cond, targs := opts.Filter.timeConditionWith(""); cond != "" {
+		clauses = append(clauses, cond)
+		args = append(args, targs...)
+	}
+	if cond, sargs := opts.Filter.serverConditionWith(""); cond != "" {
+		clauses = append(clauses, cond)
+		args = append(args, sargs...)
+	}
+	clauses = append(clauses, "ip_address != ''")
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_name, ip_address, COUNT(*), MIN(started_at), MAX(started_at)
+		FROM watch_history
+		WHERE `+strings.Join(clauses, " AND ")+`
+		GROUP BY user_name, ip_address`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("user ip plays: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []userIPPlays
+	for rows.Next() {
+		var p userIPPlays
+		var first, last string
+		if err := rows.Scan(&p.user, &p.ip, &p.plays, &first, &last); err != nil {
+			return nil, fmt.Errorf("scanning user ip plays: %w", err)
+		}
+		if !opts.IncludePrivate && isPrivateAddress(p.ip) {
+			continue
+		}
+		p.firstSeen, _ = parseSQLiteTime(first)
+		p.lastSeen, _ = parseSQLiteTime(last)
+		pairs = append(pairs, p)
+	}
+	return pairs, rows.Err()
+}
+
+// fillPeakConcurrent sets each stat's PeakConcurrent from the plays from its
+// address within the filter's window.
+func (s *Store) fillPeakConcurrent(ctx context.Context, filter StatsFilter, stats []models.SharedIPStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(stats))
+	args := make([]any, 0, len(stats))
+	for i, st := range stats {
+		index[st.IPAddress] = i
+		args = append(args, st.IPAddress)
+	}
+	query := `SELECT ip_address, started_at, stopped_at FROM watch_history
+		WHERE ip_address IN (` + strings.Repeat(",?", len(stats))[1:] + `)`
+	if cond, targs := filter.timeConditionWith(""); cond != "" {
+		query += " AND " + cond
+		args = append(args, targs...)
+	}
+	if cond, sargs := filter.serverConditionWith(""); cond != "" {
+		query += " AND " + cond
+		args = append(args, sargs...)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("shared ip plays: %w", err)
+	}
+	defer rows.Close()
+
+	events := make(map[string][]concurrentEvent, len(stats))
+	for rows.Next() {
+		var ip string
+		var started, stopped time.Time
+		if err := rows.Scan(&ip, &started, &stopped); err != nil {
+			return fmt.Errorf("scanning shared ip plays: %w", err)
+		}
+		if stopped.IsZero() || stopped.Before(started) {
+			continue
+		}
+		events[ip] = append(events[ip],
+			concurrentEvent{t: started, delta: 1},
+			concurrentEvent{t: stopped, delta: -1})
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating shared ip plays: %w", err)
+	}
+
+	for ip, evs := range events {
+		// Stops sort before starts at the same instant, so back-to-back
+		// plays don't count as overlapping.
+		slices.SortFunc(evs, func(a, b concurrentEvent) int {
+			return cmp.Or(a.t.Compare(b.t), cmp.Compare(a.delta, b.delta))
+		})
+		cur, peak := 0, 0
+		for _, e := range evs {
+			cur += e.delta
+			peak = max(peak, cur)
+		}
+		stats[index[ip]].PeakConcurrent = peak
+	}
+	return nil
+}
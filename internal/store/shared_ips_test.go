@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSharedIPReport(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	base := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Hour)
+	play := func(user, ip, title string, at time.Time) {
+		t.Helper()
+		e := makeHistoryEntry(serverID, user, title, at)
+		e.IPAddress = ip
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// alice and bob overlap on one public address, carol uses it later.
+	play("alice", "203.0.113.5", "A", base)
+	play("bob", "203.0.113.5", "B", base.Add(time.Hour))
+	play("carol", "203.0.113.5", "C", base.Add(10*time.Hour))
+	// Everyone at home shares the LAN address.
+	play("alice", "192.168.1.2", "D", base.Add(20*time.Hour))
+	play("bob", "192.168.1.2", "E", base.Add(20*time.Hour))
+	// dave hops between addresses, one of them outside the window.
+	for i := range 3 {
+		play("dave", fmt.Sprintf("198.51.100.%d", i+1), fmt.Sprintf("F%d", i), base.Add(time.Duration(i)*time.Hour))
+	}
+	play("dave", "198.51.100.9", "G", base.AddDate(0, 0, -40))
+
+	report, err := s.SharedIPReport(ctx, SharedIPOptions{Filter: StatsFilter{Days: 30}, MinUsers: 2, MinIPs: 3, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.SharedIPs) != 1 {
+		t.Fatalf("shared ips = %+v", report.SharedIPs)
+	}
+	ip := report.SharedIPs[0]
+	if ip.IPAddress != "203.0.113.5" || !slices.Equal(ip.Users, []string{"alice", "bob", "carol"}) ||
+		ip.Plays != 3 || ip.PeakConcurrent != 2 || !ip.FirstSeen.Equal(base) {
+		t.Errorf("shared ip = %+v", ip)
+	}
+	if len(report.MultiIPUsers) != 1 || report.MultiIPUsers[0].UserName != "dave" || report.MultiIPUsers[0].IPCount != 3 {
+		t.Errorf("multi ip users = %+v", report.MultiIPUsers)
+	}
+
+	report, err = s.SharedIPReport(ctx, SharedIPOptions{Filter: StatsFilter{Days: 30}, MinUsers: 2, MinIPs: 3, IncludePrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.SharedIPs) != 2 || report.SharedIPs[1].IPAddress != "192.168.1.2" {
+		t.Errorf("shared ips with private = %+v", report.SharedIPs)
+	}
+}
@@ -59,6 +59,17 @@ type StatsFilter struct {
 	// TZOffsetMinutes is the caller's timezone offset in minutes east of UTC,
 	// used only for day/hour bucketing. Zero (the default) buckets in UTC.
 	TZOffsetMinutes int
+	// IncludeAllMediaTypes keeps music video and photo plays in the results.
+	// They are excluded by default since a slideshow or a run of short clips
+	// would otherwise swamp play counts and concurrency figures.
+	IncludeAllMediaTypes bool
+	// IncludeOwnerPlays keeps plays by a server's owner account even when
+	// that server is configured to exclude them from shared stats.
+	IncludeOwnerPlays bool
+	// ComparePrevious has TopMovies, TopTVShows, TopUsers and LibraryStats
+	// fill in each result's Comparison against the previous period. It has
+	// no effect on all-time filters.
+	ComparePrevious bool
 }
 
 // tzModifier builds a SQLite '±HH:MM' datetime modifier from an offset in
@@ -77,6 +88,15 @@ func tzModifier(offsetMinutes int) (string, bool) {
 	return fmt.Sprintf("%s%02d:%02d", sign, m/60, m%60), true
 }
 
+// Location is the fixed zone the filter's TZOffsetMinutes describes, or UTC.
+func (f StatsFilter) Location() *time.Location {
+	mod, ok := tzModifier(f.TZOffsetMinutes)
+	if !ok {
+		return time.UTC
+	}
+	return time.FixedZone("UTC"+mod, f.TZOffsetMinutes*60)
+}
+
 func (f StatsFilter) timeConditionWith(alias string) (string, []any) {
 	col := "started_at"
 	if alias != "" {
@@ -108,6 +128,41 @@ func (f StatsFilter) serverConditionWith(alias string) (string, []any) {
 	return fmt.Sprintf("%s IN (%s)", col, placeholders), args
 }
 
+// statsExcludedMediaTypes are left out of stats unless
+// StatsFilter.IncludeAllMediaTypes is set.
+var statsExcludedMediaTypes = []models.MediaType{models.MediaTypeMusicVideo, models.MediaTypePhoto}
+
+func (f StatsFilter) mediaTypeConditionWith(alias string) (string, []any) {
+	if f.IncludeAllMediaTypes {
+		return "", nil
+	}
+	col := "media_type"
+	if alias != "" {
+		col = alias + ".media_type"
+	}
+	placeholders := strings.Repeat(",?", len(statsExcludedMediaTypes))[1:]
+	args := make([]any, len(statsExcludedMediaTypes))
+	for i, mt := range statsExcludedMediaTypes {
+		args[i] = mt
+	}
+	return fmt.Sprintf("%s NOT IN (%s)", col, placeholders), args
+}
+
+// ownerConditionWith drops plays by the owner account of servers that have
+// ExcludeOwnerStats enabled.
+func (f StatsFilter) ownerConditionWith(alias string) string {
+	if f.IncludeOwnerPlays {
+		return ""
+	}
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	return fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM servers o WHERE o.id = %sserver_id
+		AND o.exclude_owner_stats = 1 AND o.owner_user_name != ''
+		AND o.owner_user_name = %suser_name COLLATE NOCASE)`, prefix, prefix)
+}
+
 func buildConditions(alias string, f StatsFilter) (conds []string, args []any) {
 	conds = append(conds, minPlayCond(alias))
 	if tc, ta := f.timeConditionWith(alias); tc != "" {
@@ -118,6 +173,13 @@ func buildConditions(alias string, f StatsFilter) (conds []string, args []any) {
 		conds = append(conds, sc)
 		args = append(args, sa...)
 	}
+	if mc, ma := f.mediaTypeConditionWith(alias); mc != "" {
+		conds = append(conds, mc)
+		args = append(args, ma...)
+	}
+	if oc := f.ownerConditionWith(alias); oc != "" {
+		conds = append(conds, oc)
+	}
 	return
 }
 
@@ -142,24 +204,91 @@ type topMediaConfig struct {
 	itemIDCol  string
 	metaWhere  string
 	metaArgs   func(stat models.MediaStat) []any
+	// artistCol, when set, is grouped alongside selectCol and fills Artist.
+	artistCol string
 }
 
 func (s *Store) topMedia(ctx context.Context, limit int, filter StatsFilter, cfg topMediaConfig) ([]models.MediaStat, error) {
-	filterClause, filterArgs := filter.andConditions()
+	stats, err := s.topMediaTotals(ctx, limit, filter, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return stats, nil
+	}
+
+	if prevFilter, ok := filter.previousPeriod(); ok && filter.ComparePrevious {
+		prev, err := s.topMediaTotals(ctx, -1, prevFilter, cfg)
+		if err != nil {
+			return nil, err
+		}
+		prevByKey := make(map[string]models.MediaStat, len(prev))
+		for _, p := range prev {
+			prevByKey[mediaStatKey(p)] = p
+		}
+		for i := range stats {
+			p := prevByKey[mediaStatKey(stats[i])]
+			stats[i].Comparison = comparePlays(stats[i].PlayCount, stats[i].TotalHours, p.PlayCount, p.TotalHours)
+		}
+	}
 
 	itemIDCol := cfg.itemIDCol
 	if itemIDCol == "" {
 		itemIDCol = "item_id"
 	}
 
-	query := fmt.Sprintf(`SELECT %s, %s, COUNT(*) as play_count,
+	metaQuery := fmt.Sprintf(`SELECT thumb_url, server_id, %s
+		FROM watch_history
+		WHERE media_type = ? AND %s AND thumb_url != ''
+		ORDER BY started_at DESC LIMIT 1`,
+		itemIDCol, cfg.metaWhere)
+
+	for i := range stats {
+		var thumbURL sql.NullString
+		var serverID sql.NullInt64
+		var itemID sql.NullString
+		metaArgs := append([]any{cfg.mediaType}, cfg.metaArgs(stats[i])...)
+		err := s.db.QueryRowContext(ctx, metaQuery, metaArgs...).Scan(&thumbURL, &serverID, &itemID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("%s metadata: %w", cfg.errMsg, err)
+		}
+		if thumbURL.Valid {
+			stats[i].ThumbURL = thumbURL.String
+		}
+		if serverID.Valid {
+			stats[i].ServerID = serverID.Int64
+		}
+		if itemID.Valid {
+			stats[i].ItemID = itemID.String
+		}
+	}
+
+	return stats, nil
+}
+
+func mediaStatKey(stat models.MediaStat) string {
+	return fmt.Sprintf("%s|%s|%d", stat.Title, stat.Artist, stat.Year)
+}
+
+// topMediaTotals runs the grouped play count and hours query behind
+// topMedia, without the display metadata. A negative limit returns every
+// group.
+func (s *Store) topMediaTotals(ctx context.Context, limit int, filter StatsFilter, cfg topMediaConfig) ([]models.MediaStat, error) {
+	filterClause, filterArgs := filter.andConditions()
+
+	artistCol := cfg.artistCol
+	if artistCol == "" {
+		artistCol = "''"
+	}
+
+	query := fmt.Sprintf(`SELECT %s, %s, %s, `+partyPlayCountExpr+` as play_count,
 		SUM(watched_ms) / 3600000.0 as total_hours
 	FROM watch_history
 	WHERE media_type = ?%s%s
 	GROUP BY %s
 	ORDER BY play_count DESC
 	LIMIT ?`,
-		cfg.selectCol, cfg.yearExpr,
+		cfg.selectCol, artistCol, cfg.yearExpr,
 		cfg.extraWhere, filterClause,
 		cfg.groupBy)
 
@@ -178,7 +307,7 @@ func (s *Store) topMedia(ctx context.Context, limit int, filter StatsFilter, cfg
 	for rows.Next() {
 		var stat models.MediaStat
 		var totalHours sql.NullFloat64
-		if err := rows.Scan(&stat.Title, &stat.Year, &stat.PlayCount, &totalHours); err != nil {
+		if err := rows.Scan(&stat.Title, &stat.Artist, &stat.Year, &stat.PlayCount, &totalHours); err != nil {
 			return nil, fmt.Errorf("scanning %s: %w", cfg.errMsg, err)
 		}
 		if totalHours.Valid {
@@ -189,49 +318,24 @@ func (s *Store) topMedia(ctx context.Context, limit int, filter StatsFilter, cfg
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating %s: %w", cfg.errMsg, err)
 	}
-
-	if len(stats) == 0 {
-		return stats, nil
-	}
-
-	metaQuery := fmt.Sprintf(`SELECT thumb_url, server_id, %s
-		FROM watch_history
-		WHERE media_type = ? AND %s AND thumb_url != ''
-		ORDER BY started_at DESC LIMIT 1`,
-		itemIDCol, cfg.metaWhere)
-
-	for i := range stats {
-		var thumbURL sql.NullString
-		var serverID sql.NullInt64
-		var itemID sql.NullString
-		metaArgs := append([]any{cfg.mediaType}, cfg.metaArgs(stats[i])...)
-		err := s.db.QueryRowContext(ctx, metaQuery, metaArgs...).Scan(&thumbURL, &serverID, &itemID)
-		if err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("%s metadata: %w", cfg.errMsg, err)
-		}
-		if thumbURL.Valid {
-			stats[i].ThumbURL = thumbURL.String
-		}
-		if serverID.Valid {
-			stats[i].ServerID = serverID.Int64
-		}
-		if itemID.Valid {
-			stats[i].ItemID = itemID.String
-		}
-	}
-
 	return stats, nil
 }
 
+// movieTitleKey groups plays of the same film from libraries in different
+// languages, e.g. "Das Boot" and "The Boat", under their shared original title.
+// The key is also the title shown, so a group reads the same however its
+// plays are split between libraries.
+const movieTitleKey = "COALESCE(NULLIF(original_title, ''), title)"
+
 func (s *Store) TopMovies(ctx context.Context, limit int, filter StatsFilter) ([]models.MediaStat, error) {
 	return s.topMedia(ctx, limit, filter, topMediaConfig{
-		selectCol:  "title",
+		selectCol:  movieTitleKey,
 		yearExpr:   "year",
 		extraWhere: "",
-		groupBy:    "title, year",
+		groupBy:    movieTitleKey + ", year",
 		mediaType:  models.MediaTypeMovie,
 		errMsg:     "top movies",
-		metaWhere:  "title = ? AND year = ?",
+		metaWhere:  movieTitleKey + " = ? AND year = ?",
 		metaArgs:   func(s models.MediaStat) []any { return []any{s.Title, s.Year} },
 	})
 }
@@ -250,7 +354,78 @@ func (s *Store) TopTVShows(ctx context.Context, limit int, filter StatsFilter) (
 	})
 }
 
+// TopArtists ranks music artists by track plays.
+func (s *Store) TopArtists(ctx context.Context, limit int, filter StatsFilter) ([]models.MediaStat, error) {
+	return s.topMedia(ctx, limit, filter, topMediaConfig{
+		selectCol:  "grandparent_title",
+		yearExpr:   "0 as year",
+		extraWhere: " AND grandparent_title != ''",
+		groupBy:    "grandparent_title",
+		mediaType:  models.MediaTypeMusic,
+		errMsg:     "top artists",
+		itemIDCol:  "grandparent_item_id",
+		metaWhere:  "grandparent_title = ?",
+		metaArgs:   func(s models.MediaStat) []any { return []any{s.Title} },
+	})
+}
+
+// TopAlbums ranks albums by track plays. Albums have no item ID of their
+// own in history, so ItemID is left empty.
+func (s *Store) TopAlbums(ctx context.Context, limit int, filter StatsFilter) ([]models.MediaStat, error) {
+	return s.topMedia(ctx, limit, filter, topMediaConfig{
+		selectCol:  "parent_title",
+		artistCol:  "grandparent_title",
+		yearExpr:   "MAX(year) as year",
+		extraWhere: " AND parent_title != ''",
+		groupBy:    "grandparent_title, parent_title",
+		mediaType:  models.MediaTypeMusic,
+		errMsg:     "top albums",
+		itemIDCol:  "''",
+		metaWhere:  "parent_title = ? AND grandparent_title = ?",
+		metaArgs:   func(s models.MediaStat) []any { return []any{s.Title, s.Artist} },
+	})
+}
+
+// TopTracks ranks tracks by plays, keeping same-named tracks by different
+// artists apart.
+func (s *Store) TopTracks(ctx context.Context, limit int, filter StatsFilter) ([]models.MediaStat, error) {
+	return s.topMedia(ctx, limit, filter, topMediaConfig{
+		selectCol: "title",
+		artistCol: "grandparent_title",
+		yearExpr:  "0 as year",
+		groupBy:   "grandparent_title, title",
+		mediaType: models.MediaTypeMusic,
+		errMsg:    "top tracks",
+		metaWhere: "title = ? AND grandparent_title = ?",
+		metaArgs:  func(s models.MediaStat) []any { return []any{s.Title, s.Artist} },
+	})
+}
+
 func (s *Store) TopUsers(ctx context.Context, limit int, filter StatsFilter) ([]models.UserStat, error) {
+	stats, err := s.userTotals(ctx, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+	if prevFilter, ok := filter.previousPeriod(); ok && filter.ComparePrevious && len(stats) > 0 {
+		prev, err := s.userTotals(ctx, -1, prevFilter)
+		if err != nil {
+			return nil, err
+		}
+		prevByUser := make(map[string]models.UserStat, len(prev))
+		for _, p := range prev {
+			prevByUser[p.UserName] = p
+		}
+		for i := range stats {
+			p := prevByUser[stats[i].UserName]
+			stats[i].Comparison = comparePlays(stats[i].PlayCount, stats[i].TotalHours, p.PlayCount, p.TotalHours)
+		}
+	}
+	return stats, nil
+}
+
+// userTotals is the per-user play count and hours behind TopUsers, heaviest
+// watchers first. A negative limit returns every user.
+func (s *Store) userTotals(ctx context.Context, limit int, filter StatsFilter) ([]models.UserStat, error) {
 	whereClause, filterArgs := filter.conditions()
 
 	query := `SELECT user_name, COUNT(*) as play_count,
@@ -284,6 +459,21 @@ func (s *Store) TopUsers(ctx context.Context, limit int, filter StatsFilter) ([]
 }
 
 func (s *Store) LibraryStats(ctx context.Context, filter StatsFilter) (*models.LibraryStat, error) {
+	stats, err := s.libraryTotals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if prevFilter, ok := filter.previousPeriod(); ok && filter.ComparePrevious {
+		prev, err := s.libraryTotals(ctx, prevFilter)
+		if err != nil {
+			return nil, err
+		}
+		stats.Comparison = compareLibrary(*stats, *prev)
+	}
+	return stats, nil
+}
+
+func (s *Store) libraryTotals(ctx context.Context, filter StatsFilter) (*models.LibraryStat, error) {
 	var stats models.LibraryStat
 	var totalHours sql.NullFloat64
 
@@ -293,14 +483,25 @@ func (s *Store) LibraryStats(ctx context.Context, filter StatsFilter) (*models.L
 		SUM(watched_ms) / 3600000.0 as total_hours,
 		COUNT(DISTINCT user_name) as unique_users,
 		COUNT(DISTINCT CASE WHEN media_type = ? THEN title || '|' || COALESCE(year, 0) END) as unique_movies,
-		COUNT(DISTINCT CASE WHEN media_type = ? AND grandparent_title != '' THEN grandparent_title END) as unique_tv_shows
+		COUNT(DISTINCT CASE WHEN media_type = ? AND grandparent_title != '' THEN grandparent_title END) as unique_tv_shows,
+		COALESCE(SUM(CASE WHEN media_type = ? THEN 1 ELSE 0 END), 0) as music_plays,
+		COALESCE(SUM(CASE WHEN media_type = ? THEN watched_ms ELSE 0 END), 0) / 3600000.0 as music_hours,
+		COUNT(DISTINCT CASE WHEN media_type = ? THEN user_name END) as music_users,
+		COUNT(DISTINCT CASE WHEN media_type = ? AND grandparent_title != '' THEN grandparent_title END) as unique_artists,
+		COUNT(DISTINCT CASE WHEN media_type = ? AND parent_title != '' THEN grandparent_title || '|' || parent_title END) as unique_albums,
+		COUNT(DISTINCT CASE WHEN media_type = ? THEN grandparent_title || '|' || title END) as unique_tracks
 	FROM watch_history` + whereClause
 
 	args := []any{models.MediaTypeMovie, models.MediaTypeTV}
+	for range 6 {
+		args = append(args, models.MediaTypeMusic)
+	}
 	args = append(args, filterArgs...)
 	if err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&stats.TotalPlays, &totalHours, &stats.UniqueUsers,
 		&stats.UniqueMovies, &stats.UniqueTVShows,
+		&stats.Music.TotalPlays, &stats.Music.TotalHours, &stats.Music.UniqueUsers,
+		&stats.Music.UniqueArtists, &stats.Music.UniqueAlbums, &stats.Music.UniqueTracks,
 	); err != nil {
 		return nil, fmt.Errorf("library stats: %w", err)
 	}
@@ -481,11 +682,112 @@ func (s *Store) AllWatchLocations(ctx context.Context, filter StatsFilter) ([]mo
 	return results, nil
 }
 
+// userLocationStats returns the top ten cities for the plays matching cond,
+// and separately the count of Local plays: those from home networks or
+// private addresses, which have no geo location to report. Plays from IPs
+// not yet in the geo cache are left out of both.
+func (s *Store) userLocationStats(ctx context.Context, cond string, args []any) ([]models.LocationStat, int, error) {
+	home, err := s.GetHomeNetworkSettings()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT h.ip_address, g.city, g.country, COUNT(*) as session_count,
+			MAX(COALESCE(h.stopped_at, h.started_at)) as last_seen
+		FROM watch_history h
+		LEFT JOIN ip_geo_cache g ON h.ip_address = g.ip
+		WHERE `+cond+` AND `+minPlayCond("h")+`
+		GROUP BY h.ip_address`,
+		args...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("user location stats: %w", err)
+	}
+	defer rows.Close()
+
+	type cityKey struct{ city, country string }
+	byCity := make(map[cityKey]*models.LocationStat)
+	lastSeen := make(map[cityKey]time.Time)
+	var local int
+	for rows.Next() {
+		var ip string
+		var city, country, lastSeenStr sql.NullString
+		var count int
+		if err := rows.Scan(&ip, &city, &country, &count, &lastSeenStr); err != nil {
+			return nil, 0, fmt.Errorf("scanning location stat: %w", err)
+		}
+		if home.IsLocal(ip) {
+			local += count
+			continue
+		}
+		if !city.Valid {
+			continue
+		}
+		key := cityKey{city.String, country.String}
+		loc, ok := byCity[key]
+		if !ok {
+			loc = &models.LocationStat{City: city.String, Country: country.String}
+			byCity[key] = loc
+		}
+		loc.SessionCount += count
+		if lastSeenStr.Valid {
+			if t, _ := parseSQLiteTime(lastSeenStr.String); t.After(lastSeen[key]) {
+				lastSeen[key] = t
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterating location stats: %w", err)
+	}
+
+	locations := make([]models.LocationStat, 0, len(byCity))
+	for key, loc := range byCity {
+		if t := lastSeen[key]; !t.IsZero() {
+			loc.LastSeen = t.Format(time.RFC3339)
+		}
+		locations = append(locations, *loc)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.SessionCount != b.SessionCount {
+			return a.SessionCount > b.SessionCount
+		}
+		if a.Country != b.Country {
+			return a.Country < b.Country
+		}
+		return a.City < b.City
+	})
+	if len(locations) > 10 {
+		locations = locations[:10]
+	}
+
+	var total int
+	for _, loc := range locations {
+		total += loc.SessionCount
+	}
+	for i := range locations {
+		locations[i].Percentage = calcPercentage(locations[i].SessionCount, total)
+	}
+	return locations, local, nil
+}
+
 func (s *Store) UserDetailStats(ctx context.Context, userName string) (*models.UserDetailStats, error) {
+	return s.UserDetailStatsScoped(ctx, NameScope(userName))
+}
+
+// UserDetailStatsScoped is UserDetailStats for the accounts in scope.
+func (s *Store) UserDetailStatsScoped(ctx context.Context, scope UserScope) (*models.UserDetailStats, error) {
+	userCond, userArgs := scope.condition("")
+	aliasedCond, aliasedArgs := scope.condition("h")
+
 	stats := &models.UserDetailStats{
-		Locations: []models.LocationStat{},
-		Devices:   []models.DeviceStat{},
-		ISPs:      []models.ISPStat{},
+		Locations:      []models.LocationStat{},
+		Devices:        []models.DeviceStat{},
+		ISPs:           []models.ISPStat{},
+		Bandwidth:      []models.MonthlyBandwidth{},
+		ContentRatings: []models.ContentRatingStat{},
+		NetworkLabels:  []models.NetworkLabelStat{},
 	}
 
 	var totalHours sql.NullFloat64
@@ -493,8 +795,8 @@ func (s *Store) UserDetailStats(ctx context.Context, userName string) (*models.U
 		`SELECT COUNT(*) as session_count,
 			SUM(watched_ms) / 3600000.0 as total_hours
 		FROM watch_history
-		WHERE user_name = ? AND `+minPlayCond(""),
-		userName,
+		WHERE `+userCond+` AND `+minPlayCond(""),
+		userArgs...,
 	).Scan(&stats.SessionCount, &totalHours)
 	if err != nil {
 		return nil, fmt.Errorf("user stats totals: %w", err)
@@ -503,49 +805,22 @@ func (s *Store) UserDetailStats(ctx context.Context, userName string) (*models.U
 		stats.TotalHours = totalHours.Float64
 	}
 
-	locRows, err := s.db.QueryContext(ctx,
-		`SELECT g.city, g.country, COUNT(*) as session_count,
-			MAX(COALESCE(h.stopped_at, h.started_at)) as last_seen
-		FROM watch_history h
-		JOIN ip_geo_cache g ON h.ip_address = g.ip
-		WHERE h.user_name = ? AND `+minPlayCond("h")+`
-		GROUP BY g.city, g.country
-		ORDER BY session_count DESC
-		LIMIT 10`,
-		userName,
-	)
+	locations, local, err := s.userLocationStats(ctx, aliasedCond, aliasedArgs)
 	if err != nil {
-		return nil, fmt.Errorf("user location stats: %w", err)
-	}
-	defer locRows.Close()
-
-	var totalLocSessions int
-	for locRows.Next() {
-		var loc models.LocationStat
-		var lastSeenStr sql.NullString
-		if err := locRows.Scan(&loc.City, &loc.Country, &loc.SessionCount, &lastSeenStr); err != nil {
-			return nil, fmt.Errorf("scanning location stat: %w", err)
-		}
-		loc.LastSeen = formatLastSeen(lastSeenStr)
-		totalLocSessions += loc.SessionCount
-		stats.Locations = append(stats.Locations, loc)
-	}
-	if err := locRows.Err(); err != nil {
-		return nil, fmt.Errorf("iterating location stats: %w", err)
-	}
-	for i := range stats.Locations {
-		stats.Locations[i].Percentage = calcPercentage(stats.Locations[i].SessionCount, totalLocSessions)
+		return nil, err
 	}
+	stats.Locations = append(stats.Locations, locations...)
+	stats.LocalSessions = local
 
 	devRows, err := s.db.QueryContext(ctx,
 		`SELECT player, platform, COUNT(*) as session_count,
 			MAX(COALESCE(stopped_at, started_at)) as last_seen
 		FROM watch_history
-		WHERE user_name = ? AND `+minPlayCond("")+`
+		WHERE `+userCond+` AND `+minPlayCond("")+`
 		GROUP BY player, platform
 		ORDER BY session_count DESC
 		LIMIT 10`,
-		userName,
+		userArgs...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("user device stats: %w", err)
@@ -575,11 +850,11 @@ func (s *Store) UserDetailStats(ctx context.Context, userName string) (*models.U
 			MAX(COALESCE(h.stopped_at, h.started_at)) as last_seen
 		FROM watch_history h
 		JOIN ip_geo_cache g ON h.ip_address = g.ip
-		WHERE h.user_name = ? AND g.isp != '' AND `+minPlayCond("h")+`
+		WHERE `+aliasedCond+` AND g.isp != '' AND `+minPlayCond("h")+`
 		GROUP BY g.isp
 		ORDER BY session_count DESC
 		LIMIT 10`,
-		userName,
+		aliasedArgs...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("user isp stats: %w", err)
@@ -604,9 +879,76 @@ func (s *Store) UserDetailStats(ctx context.Context, userName string) (*models.U
 		stats.ISPs[i].Percentage = calcPercentage(stats.ISPs[i].SessionCount, totalISPSessions)
 	}
 
+	ratingRows, err := s.db.QueryContext(ctx,
+		`SELECT content_rating, COUNT(*) as session_count
+		FROM watch_history
+		WHERE `+userCond+` AND content_rating != '' AND `+minPlayCond("")+`
+		GROUP BY content_rating
+		ORDER BY session_count DESC, content_rating`,
+		userArgs...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("user content rating stats: %w", err)
+	}
+	defer ratingRows.Close()
+
+	var totalRatedSessions int
+	for ratingRows.Next() {
+		var rs models.ContentRatingStat
+		if err := ratingRows.Scan(&rs.Rating, &rs.SessionCount); err != nil {
+			return nil, fmt.Errorf("scanning content rating stat: %w", err)
+		}
+		totalRatedSessions += rs.SessionCount
+		stats.ContentRatings = append(stats.ContentRatings, rs)
+	}
+	if err := ratingRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating content rating stats: %w", err)
+	}
+	for i := range stats.ContentRatings {
+		stats.ContentRatings[i].Percentage = calcPercentage(stats.ContentRatings[i].SessionCount, totalRatedSessions)
+	}
+
+	labelRows, err := s.db.QueryContext(ctx,
+		`SELECT network_label, COUNT(*) as session_count
+		FROM watch_history
+		WHERE `+userCond+` AND network_label != '' AND `+minPlayCond("")+`
+		GROUP BY network_label
+		ORDER BY session_count DESC, network_label`,
+		userArgs...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("user network label stats: %w", err)
+	}
+	defer labelRows.Close()
+
+	var totalLabelledSessions int
+	for labelRows.Next() {
+		var ls models.NetworkLabelStat
+		if err := labelRows.Scan(&ls.Label, &ls.SessionCount); err != nil {
+			return nil, fmt.Errorf("scanning network label stat: %w", err)
+		}
+		totalLabelledSessions += ls.SessionCount
+		stats.NetworkLabels = append(stats.NetworkLabels, ls)
+	}
+	if err := labelRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating network label stats: %w", err)
+	}
+	for i := range stats.NetworkLabels {
+		stats.NetworkLabels[i].Percentage = calcPercentage(stats.NetworkLabels[i].SessionCount, totalLabelledSessions)
+	}
+
+	stats.Bandwidth, err = s.userMonthlyBandwidth(ctx, scope, userBandwidthMonths)
+	if err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
+// userBandwidthMonths is how many calendar months of estimated transfer
+// UserDetailStats reports.
+const userBandwidthMonths = 12
+
 var dayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
 
 var allowedStrftimeFormats = map[string]bool{
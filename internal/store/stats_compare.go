@@ -0,0 +1,84 @@
+package store
+
+import (
+	"math"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// previousPeriod returns f moved back to the window just before it, for
+// period comparisons. Ranges starting on the first of a month step back by
+// whole months, so this month is compared with the same days of last month;
+// other ranges step back by their own length. All-time filters have no
+// previous period.
+func (f StatsFilter) previousPeriod() (StatsFilter, bool) {
+	prev := f
+	prev.ComparePrevious = false
+	switch {
+	case !f.StartDate.IsZero() && !f.EndDate.IsZero():
+		if months := calendarMonths(f.StartDate, f.EndDate); months > 0 {
+			prev.StartDate = f.StartDate.AddDate(0, -months, 0)
+			prev.EndDate = f.EndDate.AddDate(0, -months, 0)
+			if prev.EndDate.After(f.StartDate) {
+				prev.EndDate = f.StartDate
+			}
+			return prev, true
+		}
+		length := f.EndDate.Sub(f.StartDate)
+		prev.StartDate, prev.EndDate = f.StartDate.Add(-length), f.StartDate
+		return prev, true
+	case f.Days > 0:
+		end := cutoffTime(f.Days)
+		prev.Days = 0
+		prev.StartDate, prev.EndDate = end.AddDate(0, 0, -f.Days), end
+		return prev, true
+	default:
+		return f, false
+	}
+}
+
+// calendarMonths is how many calendar months [start, end) touches when start
+// is midnight on the first of a month, or zero otherwise.
+func calendarMonths(start, end time.Time) int {
+	if start.Day() != 1 || !start.Equal(start.Truncate(24*time.Hour)) {
+		return 0
+	}
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	if end.Day() > 1 || !end.Equal(end.Truncate(24*time.Hour)) {
+		months++
+	}
+	return max(months, 1)
+}
+
+// changePct is the percentage change from prev to cur, to one decimal
+// place, or nil when prev is zero.
+func changePct(cur, prev float64) *float64 {
+	if prev == 0 {
+		return nil
+	}
+	pct := math.Round((cur-prev)/prev*1000) / 10
+	return &pct
+}
+
+func comparePlays(plays int, hours float64, prevPlays int, prevHours float64) *models.PlayComparison {
+	return &models.PlayComparison{
+		PreviousPlayCount:   prevPlays,
+		PreviousTotalHours:  prevHours,
+		PlayCountChangePct:  changePct(float64(plays), float64(prevPlays)),
+		TotalHoursChangePct: changePct(hours, prevHours),
+	}
+}
+
+func compareLibrary(cur, prev models.LibraryStat) *models.LibraryComparison {
+	return &models.LibraryComparison{
+		Previous:               prev,
+		TotalPlaysChangePct:    changePct(float64(cur.TotalPlays), float64(prev.TotalPlays)),
+		TotalHoursChangePct:    changePct(cur.TotalHours, prev.TotalHours),
+		UniqueUsersChangePct:   changePct(float64(cur.UniqueUsers), float64(prev.UniqueUsers)),
+		UniqueMoviesChangePct:  changePct(float64(cur.UniqueMovies), float64(prev.UniqueMovies)),
+		UniqueTVShowsChangePct: changePct(float64(cur.UniqueTVShows), float64(prev.UniqueTVShows)),
+		MusicPlaysChangePct:    changePct(float64(cur.Music.TotalPlays), float64(prev.Music.TotalPlays)),
+		MusicHoursChangePct:    changePct(cur.Music.TotalHours, prev.Music.TotalHours),
+	}
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name               string
+		start, end         time.Time
+		wantStart, wantEnd time.Time
+	}{
+		{"whole month", date(2024, 10, 1), date(2024, 11, 1), date(2024, 9, 1), date(2024, 10, 1)},
+		{"month to date", date(2024, 10, 1), date(2024, 10, 17), date(2024, 9, 1), date(2024, 9, 17)},
+		{"quarter", date(2024, 1, 1), date(2024, 4, 1), date(2023, 10, 1), date(2024, 1, 1)},
+		{"clamped to start", date(2024, 3, 1), date(2024, 3, 31), date(2024, 2, 1), date(2024, 3, 1)},
+		{"arbitrary range", date(2024, 10, 10), date(2024, 10, 17), date(2024, 10, 3), date(2024, 10, 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, ok := StatsFilter{StartDate: tt.start, EndDate: tt.end, ComparePrevious: true}.previousPeriod()
+			if !ok {
+				t.Fatal("expected a previous period")
+			}
+			if !prev.StartDate.Equal(tt.wantStart) || !prev.EndDate.Equal(tt.wantEnd) {
+				t.Errorf("previous = [%v, %v), want [%v, %v)", prev.StartDate, prev.EndDate, tt.wantStart, tt.wantEnd)
+			}
+			if prev.ComparePrevious {
+				t.Error("previous period should not compare again")
+			}
+		})
+	}
+
+	prev, ok := StatsFilter{Days: 7}.previousPeriod()
+	if !ok || prev.Days != 0 || prev.EndDate.Sub(prev.StartDate) != 7*24*time.Hour {
+		t.Errorf("days previous = %+v, %v", prev, ok)
+	}
+	if _, ok := (StatsFilter{}).previousPeriod(); ok {
+		t.Error("all-time filter should have no previous period")
+	}
+}
+
+func TestStatsComparePrevious(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	ctx := context.Background()
+	cur := time.Date(2024, 10, 5, 20, 0, 0, 0, time.UTC)
+	prev := time.Date(2024, 9, 5, 20, 0, 0, 0, time.UTC)
+
+	insert := func(user, title string, at time.Time) {
+		t.Helper()
+		if err := s.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: serverID, UserName: user, MediaType: models.MediaTypeMovie,
+			Title: title, Year: 2020, WatchedMs: 3600000, DurationMs: 3600000,
+			StartedAt: at, StoppedAt: at.Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("alice", "Dune", cur)
+	insert("alice", "Arrival", cur.Add(2*time.Hour))
+	insert("alice", "Arrival", cur.Add(24*time.Hour))
+	insert("bob", "Dune", cur)
+	insert("alice", "Dune", prev)
+	insert("carol", "Dune", prev.Add(2*time.Hour))
+
+	filter := StatsFilter{
+		StartDate:       time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+		ComparePrevious: true,
+	}
+
+	users, err := s.TopUsers(ctx, 10, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byUser := map[string]models.UserStat{}
+	for _, u := range users {
+		byUser[u.UserName] = u
+	}
+	alice := byUser["alice"].Comparison
+	if alice == nil || alice.PreviousPlayCount != 1 || alice.PlayCountChangePct == nil || *alice.PlayCountChangePct != 200 {
+		t.Errorf("alice comparison = %+v", alice)
+	}
+	bob := byUser["bob"].Comparison
+	if bob == nil || bob.PreviousPlayCount != 0 || bob.PlayCountChangePct != nil {
+		t.Errorf("bob comparison = %+v, want no previous plays and no percentage", bob)
+	}
+
+	movies, err := s.TopMovies(ctx, 10, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range movies {
+		if m.Title != "Dune" {
+			continue
+		}
+		if m.Comparison == nil || m.Comparison.PreviousPlayCount != 2 || *m.Comparison.PlayCountChangePct != 0 {
+			t.Errorf("Dune comparison = %+v", m.Comparison)
+		}
+	}
+
+	lib, err := s.LibraryStats(ctx, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lib.Comparison == nil || lib.Comparison.Previous.TotalPlays != 2 || *lib.Comparison.TotalPlaysChangePct != 100 {
+		t.Errorf("library comparison = %+v", lib.Comparison)
+	}
+
+	filter.ComparePrevious = false
+	lib, err = s.LibraryStats(ctx, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lib.Comparison != nil {
+		t.Error("comparison filled in without ComparePrevious")
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// WatchHeatmap buckets plays by local weekday and hour in loc, which follows
+// daylight saving unlike the fixed TZOffsetMinutes. A nil loc falls back to
+// the filter's offset.
+func (s *Store) WatchHeatmap(ctx context.Context, filter StatsFilter, loc *time.Location) (*models.WatchHeatmap, error) {
+	if loc == nil {
+		loc = filter.Location()
+	}
+
+	whereClause, filterArgs := filter.conditions()
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT started_at, watched_ms FROM watch_history`+whereClause, filterArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("watch heatmap: %w", err)
+	}
+	defer rows.Close()
+
+	var plays [7][24]int
+	var watchedMs [7][24]int64
+	for rows.Next() {
+		var started time.Time
+		var watched int64
+		if err := rows.Scan(&started, &watched); err != nil {
+			return nil, fmt.Errorf("scanning watch heatmap: %w", err)
+		}
+		local := started.In(loc)
+		plays[local.Weekday()][local.Hour()]++
+		spreadWatchTime(&watchedMs, local, watched)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating watch heatmap: %w", err)
+	}
+
+	heatmap := &models.WatchHeatmap{Timezone: loc.String(), Cells: make([]models.HeatmapCell, 0, 7*24)}
+	for day := range 7 {
+		for hour := range 24 {
+			cell := models.HeatmapCell{
+				DayOfWeek:    day,
+				Hour:         hour,
+				PlayCount:    plays[day][hour],
+				HoursWatched: math.Round(msToHours(watchedMs[day][hour])*100) / 100,
+			}
+			heatmap.MaxHoursWatched = max(heatmap.MaxHoursWatched, cell.HoursWatched)
+			heatmap.Cells = append(heatmap.Cells, cell)
+		}
+	}
+	return heatmap, nil
+}
+
+// heatmapMaxPlay caps the watch time spread from one play; anything longer
+// is a session left running or a bad import, not a day of viewing.
+const heatmapMaxPlay = 24 * time.Hour
+
+// spreadWatchTime adds watchedMs to the cells from start onward, filling
+// each local hour to its end before moving to the next.
+func spreadWatchTime(cells *[7][24]int64, start time.Time, watchedMs int64) {
+	t := start
+	remaining := min(watchedMs, heatmapMaxPlay.Milliseconds())
+	for remaining > 0 {
+		hourEnd := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(time.Hour)
+		chunk := min(remaining, hourEnd.Sub(t).Milliseconds())
+		if chunk <= 0 {
+			// A repeated hour at a DST fall-back can leave hourEnd at t;
+			// step past it rather than loop.
+			chunk = min(remaining, time.Hour.Milliseconds())
+		}
+		cells[t.Weekday()][t.Hour()] += chunk
+		remaining -= chunk
+		t = t.Add(time.Duration(chunk) * time.Millisecond)
+	}
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestWatchHeatmap(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	// Monday 2024-07-01 21:30 UTC is 17:30 EDT in New York. Ninety minutes
+	// watched fills half of 17:00 and all of 18:00.
+	e := makeHistoryEntry(serverID, "alice", "Movie", time.Date(2024, 7, 1, 21, 30, 0, 0, time.UTC))
+	e.WatchedMs = (90 * time.Minute).Milliseconds()
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+	// In January New York is on EST, five hours behind.
+	winter := makeHistoryEntry(serverID, "bob", "Other", time.Date(2024, 1, 6, 15, 0, 0, 0, time.UTC))
+	winter.WatchedMs = winter.DurationMs
+	if err := s.InsertHistory(winter); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	heatmap, err := s.WatchHeatmap(ctx, StatsFilter{}, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heatmap.Cells) != 7*24 || heatmap.Timezone != "America/New_York" {
+		t.Fatalf("got %d cells in %s", len(heatmap.Cells), heatmap.Timezone)
+	}
+	cell := func(day time.Weekday, hour int) models.HeatmapCell {
+		return heatmap.Cells[int(day)*24+hour]
+	}
+	if c := cell(time.Monday, 17); c.PlayCount != 1 || c.HoursWatched != 0.5 {
+		t.Errorf("Mon 17:00 = %+v, want 1 play and 0.5h", c)
+	}
+	if c := cell(time.Monday, 18); c.PlayCount != 0 || c.HoursWatched != 1 {
+		t.Errorf("Mon 18:00 = %+v, want 0 plays and 1h", c)
+	}
+	if c := cell(time.Saturday, 10); c.PlayCount != 1 || c.HoursWatched != 1 {
+		t.Errorf("Sat 10:00 EST = %+v, want 1 play and 1h", c)
+	}
+	if heatmap.MaxHoursWatched != 1 {
+		t.Errorf("max = %v, want 1", heatmap.MaxHoursWatched)
+	}
+
+	utc, err := s.WatchHeatmap(ctx, StatsFilter{TZOffsetMinutes: 60}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := utc.Cells[int(time.Monday)*24+22]; c.PlayCount != 1 {
+		t.Errorf("fixed +01:00 Mon 22:00 = %+v, want the play", c)
+	}
+	if utc.Timezone != "UTC+01:00" {
+		t.Errorf("fixed offset timezone = %q", utc.Timezone)
+	}
+}
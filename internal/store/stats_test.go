@@ -52,6 +52,49 @@ func TestTopMovies(t *testing.T) {
 	}
 }
 
+func TestTopMoviesGroupsByOriginalTitle(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "alice", MediaType: models.MediaTypeMovie,
+		Title: "Das Boot", OriginalTitle: "Das Boot", Language: "ger", Year: 1981,
+		WatchedMs: 3600000, ThumbURL: "thumb/1",
+		StartedAt: now.Add(-2 * time.Hour), StoppedAt: now.Add(-time.Hour),
+	})
+	s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "bob", MediaType: models.MediaTypeMovie,
+		Title: "The Boat", OriginalTitle: "Das Boot", Language: "eng", Year: 1981,
+		WatchedMs: 3600000, ThumbURL: "thumb/2",
+		StartedAt: now, StoppedAt: now.Add(time.Hour),
+	})
+	s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "bob", MediaType: models.MediaTypeMovie,
+		Title: "Inception", Year: 2010, WatchedMs: 5400000,
+		StartedAt: now, StoppedAt: now.Add(90 * time.Minute),
+	})
+
+	stats, err := s.TopMovies(context.Background(), 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopMovies: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 movies, got %d", len(stats))
+	}
+	if stats[0].PlayCount != 2 {
+		t.Fatalf("expected localized plays to group, got %d plays", stats[0].PlayCount)
+	}
+	// The most recent play was titled "The Boat", but the group shows the
+	// key it was grouped under.
+	if stats[0].Title != "Das Boot" {
+		t.Errorf("expected the grouping key as title, got %q", stats[0].Title)
+	}
+	if stats[0].ThumbURL != "thumb/2" {
+		t.Errorf("expected most recent thumb, got %q", stats[0].ThumbURL)
+	}
+}
+
 func TestTopMoviesEmpty(t *testing.T) {
 	s := newTestStoreWithMigrations(t)
 
@@ -144,6 +187,91 @@ func TestTopUsers(t *testing.T) {
 	}
 }
 
+func TestTopUsersExcludesMusicVideoAndPhotoByDefault(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "alice", MediaType: models.MediaTypeMovie,
+		Title: "M1", WatchedMs: 3600000, StartedAt: now, StoppedAt: now.Add(time.Hour),
+	})
+	s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "bob", MediaType: models.MediaTypeMusicVideo,
+		Title: "Clip", WatchedMs: 240000, StartedAt: now, StoppedAt: now.Add(4 * time.Minute),
+	})
+	s.InsertHistory(&models.WatchHistoryEntry{
+		ServerID: serverID, UserName: "carol", MediaType: models.MediaTypePhoto,
+		Title: "Beach.jpg", StartedAt: now, StoppedAt: now.Add(10 * time.Second),
+	})
+
+	ctx := context.Background()
+	stats, err := s.TopUsers(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopUsers: %v", err)
+	}
+	if len(stats) != 1 || stats[0].UserName != "alice" {
+		t.Fatalf("expected only alice by default, got %+v", stats)
+	}
+
+	stats, err = s.TopUsers(ctx, 10, StatsFilter{IncludeAllMediaTypes: true})
+	if err != nil {
+		t.Fatalf("TopUsers: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 users with IncludeAllMediaTypes, got %d", len(stats))
+	}
+}
+
+func TestTopUsersExcludesServerOwner(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	now := time.Now().UTC()
+
+	srv, err := s.GetServer(serverID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.OwnerUserName = "Owner"
+	srv.ExcludeOwnerStats = true
+	if err := s.UpdateServer(srv); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []string{"owner", "alice"} {
+		s.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: serverID, UserName: user, MediaType: models.MediaTypeMovie,
+			Title: "M1", WatchedMs: 3600000, StartedAt: now, StoppedAt: now.Add(time.Hour),
+		})
+	}
+
+	ctx := context.Background()
+	stats, err := s.TopUsers(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopUsers: %v", err)
+	}
+	if len(stats) != 1 || stats[0].UserName != "alice" {
+		t.Fatalf("expected owner excluded, got %+v", stats)
+	}
+
+	stats, err = s.TopUsers(ctx, 10, StatsFilter{IncludeOwnerPlays: true})
+	if err != nil {
+		t.Fatalf("TopUsers: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 users with IncludeOwnerPlays, got %d", len(stats))
+	}
+
+	// Owner plays stay in history regardless of the stats setting.
+	page, err := s.ListHistory(1, 10, "owner", "", "", nil)
+	if err != nil {
+		t.Fatalf("ListHistory: %v", err)
+	}
+	if page.Total != 1 {
+		t.Fatalf("expected owner play in history, got %d", page.Total)
+	}
+}
+
 func TestLibraryStats(t *testing.T) {
 	s := newTestStoreWithMigrations(t)
 	serverID := seedServer(t, s)
@@ -1609,7 +1737,7 @@ func TestMinPlayDurationFilter(t *testing.T) {
 	})
 
 	t.Run("ListUserSummaries", func(t *testing.T) {
-		summaries, err := s.ListUserSummaries()
+		summaries, err := s.ListUserSummaries(nil)
 		if err != nil {
 			t.Fatalf("ListUserSummaries: %v", err)
 		}
@@ -1653,3 +1781,111 @@ func TestMinPlayDurationBoundary(t *testing.T) {
 		t.Errorf("TotalPlays = %d, want 1 (boundary included, just-under excluded)", lib.TotalPlays)
 	}
 }
+
+func TestUserDetailStatsContentRatings(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	base := time.Now().UTC().Add(-24 * time.Hour)
+
+	for i, rating := range []string{"PG", "PG", "TV-MA", ""} {
+		e := makeHistoryEntry(serverID, "alice", fmt.Sprintf("T%d", i), base.Add(time.Duration(i)*3*time.Hour))
+		e.WatchedMs = 3600000
+		e.ContentRating = rating
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := s.UserDetailStats(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := stats.ContentRatings
+	if len(got) != 2 {
+		t.Fatalf("content ratings = %+v, want 2 entries", got)
+	}
+	if got[0].Rating != "PG" || got[0].SessionCount != 2 || got[1].Rating != "TV-MA" {
+		t.Errorf("content ratings = %+v", got)
+	}
+	if got[0].Percentage < 66 || got[0].Percentage > 67 {
+		t.Errorf("PG percentage = %f, want ~66.67", got[0].Percentage)
+	}
+}
+
+func TestMusicStats(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	now := time.Now().UTC().Add(-24 * time.Hour)
+
+	plays := []struct {
+		user, artist, album, track string
+		watchedMs                  int64
+	}{
+		{"alice", "Daft Punk", "Discovery", "One More Time", 240000},
+		{"alice", "Daft Punk", "Discovery", "Aerodynamic", 200000},
+		{"bob", "Daft Punk", "Homework", "Da Funk", 300000},
+		{"bob", "Daft Punk", "Discovery", "One More Time", 240000},
+		{"bob", "Air", "Moon Safari", "La femme d'argent", 420000},
+		{"alice", "Air", "Moon Safari", "One More Time", 240000},
+		// Skipped after a few seconds, so it isn't a play.
+		{"alice", "Air", "Moon Safari", "Sexy Boy", 5000},
+	}
+	for i, p := range plays {
+		started := now.Add(time.Duration(i) * 10 * time.Minute)
+		if err := s.InsertHistory(&models.WatchHistoryEntry{
+			ServerID: serverID, UserName: p.user, MediaType: models.MediaTypeMusic,
+			Title: p.track, ParentTitle: p.album, GrandparentTitle: p.artist, Year: 2001,
+			DurationMs: 300000, WatchedMs: p.watchedMs, StartedAt: started, StoppedAt: started.Add(5 * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	artists, err := s.TopArtists(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopArtists: %v", err)
+	}
+	if len(artists) != 2 || artists[0].Title != "Daft Punk" || artists[0].PlayCount != 4 || artists[1].PlayCount != 2 {
+		t.Errorf("artists = %+v", artists)
+	}
+
+	albums, err := s.TopAlbums(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopAlbums: %v", err)
+	}
+	if len(albums) != 3 || albums[0].Title != "Discovery" || albums[0].Artist != "Daft Punk" || albums[0].PlayCount != 3 {
+		t.Errorf("albums = %+v", albums)
+	}
+
+	tracks, err := s.TopTracks(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopTracks: %v", err)
+	}
+	if len(tracks) != 5 || tracks[0].Title != "One More Time" || tracks[0].Artist != "Daft Punk" || tracks[0].PlayCount != 2 {
+		t.Errorf("tracks = %+v", tracks)
+	}
+
+	movies, err := s.TopMovies(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatalf("TopMovies: %v", err)
+	}
+	if len(movies) != 0 {
+		t.Errorf("expected no movies from music plays, got %+v", movies)
+	}
+
+	lib, err := s.LibraryStats(ctx, StatsFilter{})
+	if err != nil {
+		t.Fatalf("LibraryStats: %v", err)
+	}
+	want := models.MusicLibraryStat{TotalPlays: 6, UniqueUsers: 2, UniqueArtists: 2, UniqueAlbums: 3, UniqueTracks: 5}
+	got := lib.Music
+	got.TotalHours = 0
+	if got != want {
+		t.Errorf("music = %+v, want %+v", got, want)
+	}
+	// 240000 + 200000 + 300000 + 240000 + 420000 + 240000 = 1640000ms
+	if h := lib.Music.TotalHours; h < 0.45 || h > 0.46 {
+		t.Errorf("music hours = %f", h)
+	}
+}
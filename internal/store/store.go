@@ -11,6 +11,11 @@ import (
 type Store struct {
 	db        *sql.DB
 	encryptor *crypto.Encryptor
+	history   historyPipeline
+	backupDir string
+	// migrationsDir is where Migrate last found the migrations, so a
+	// restored backup can be brought up to the same schema.
+	migrationsDir string
 }
 
 type Option func(*Store)
@@ -20,10 +25,16 @@ func WithEncryptor(e *crypto.Encryptor) Option {
 }
 
 func New(dbPath string, opts ...Option) (*Store, error) {
-	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=journal_mode(wal)&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite")
+	dsn := "file:" + dbPath + "?_pragma=journal_mode(wal)&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+	// sql.Open doesn't connect; it's only used here to look up the
+	// registered driver, which timedConnector wraps for query metrics.
+	base, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
 	}
+	drv := base.Driver()
+	base.Close()
+	db := sql.OpenDB(&timedConnector{dsn: dsn, driver: drv})
 	if dbPath == ":memory:" {
 		db.SetMaxOpenConns(1)
 	}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// UserStreaks computes userName's watch streaks and milestones as of now,
+// with days taken in now's location. Plays too short to count as a play
+// are left out. A non-empty serverIDs only counts plays on those servers.
+func (s *Store) UserStreaks(ctx context.Context, userName string, now time.Time, serverIDs []int64) (*models.UserStreaks, error) {
+	args := []any{userName}
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		serverCond = " AND " + serverCond
+		args = append(args, serverArgs...)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT started_at, watched_ms FROM watch_history
+		WHERE user_name = ? AND `+minPlayCond("")+serverCond+` ORDER BY started_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("user streaks: %w", err)
+	}
+	defer rows.Close()
+
+	loc := now.Location()
+	today := now.Format(time.DateOnly)
+	var days []string
+	var first *time.Time
+	var totalMs, todayMs int64
+	for rows.Next() {
+		var started time.Time
+		var watched int64
+		if err := rows.Scan(&started, &watched); err != nil {
+			return nil, fmt.Errorf("scanning user streaks: %w", err)
+		}
+		if first == nil {
+			first = &started
+		}
+		day := started.In(loc).Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1] != day {
+			days = append(days, day)
+		}
+		totalMs += watched
+		if day == today {
+			todayMs += watched
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating user streaks: %w", err)
+	}
+	return models.NewUserStreaks(userName, days, first, totalMs, todayMs, now), nil
+}
+
+// ClaimUserMilestone marks the milestone as announced for userName,
+// reporting false when it already was.
+func (s *Store) ClaimUserMilestone(ctx context.Context, userName string, m models.UserMilestone) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO user_milestones (user_name, milestone) VALUES (?, ?)`,
+		userName, m.Key())
+	if err != nil {
+		return false, fmt.Errorf("claiming user milestone: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claiming user milestone: %w", err)
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestUserStreaks(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	ctx := context.Background()
+
+	loc := time.FixedZone("UTC-5", -5*3600)
+	now := time.Date(2024, 6, 10, 20, 0, 0, 0, loc)
+	// 02:00 UTC on the 10th is still the 9th at UTC-5.
+	for i, at := range []time.Time{
+		time.Date(2024, 6, 8, 18, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 10, 2, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 10, 22, 0, 0, 0, time.UTC),
+	} {
+		e := makeHistoryEntry(serverID, "alice", fmt.Sprintf("Movie %d", i), at)
+		e.WatchedMs = 3600000
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	short := makeHistoryEntry(serverID, "alice", "Trailer", time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC))
+	short.WatchedMs = 1000
+	if err := s.InsertHistory(short); err != nil {
+		t.Fatal(err)
+	}
+
+	streaks, err := s.UserStreaks(ctx, "alice", now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streaks.CurrentStreakDays != 3 || streaks.CurrentStreakStart != "2024-06-08" {
+		t.Errorf("current streak = %d from %s, want 3 from 2024-06-08", streaks.CurrentStreakDays, streaks.CurrentStreakStart)
+	}
+	if streaks.TotalHours != 3 || streaks.TodayHours != 1 || streaks.Timezone != "UTC-5" {
+		t.Errorf("streaks = %+v", streaks)
+	}
+	if streaks.FirstWatchAt == nil || streaks.FirstWatchAt.Day() != 8 {
+		t.Errorf("first watch = %v, want the 8th (too-short plays don't count)", streaks.FirstWatchAt)
+	}
+
+	empty, err := s.UserStreaks(ctx, "nobody", now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.CurrentStreakDays != 0 || empty.FirstWatchAt != nil || empty.NextHoursMilestone != 10 {
+		t.Errorf("empty streaks = %+v", empty)
+	}
+}
+
+func TestClaimUserMilestone(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	m := models.UserMilestone{Kind: models.MilestoneHours, Value: 100}
+
+	for i, want := range []bool{true, false} {
+		claimed, err := s.ClaimUserMilestone(ctx, "alice", m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed != want {
+			t.Errorf("claim %d = %v, want %v", i+1, claimed, want)
+		}
+	}
+	if claimed, err := s.ClaimUserMilestone(ctx, "bob", m); err != nil || !claimed {
+		t.Errorf("another user's claim = %v, %v", claimed, err)
+	}
+}
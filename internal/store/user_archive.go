@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// archiveAfterMisses is how many consecutive user list syncs a user must be
+// missing from before they're archived. One miss isn't enough: Plex returns
+// just the owner when the friends endpoint fails.
+const archiveAfterMisses = 2
+
+// ReconcileServerUsers records the users currently on a server's user list.
+// Users seen before but missing from archiveAfterMisses syncs in a row are
+// archived with their final stats; archived users who reappear are restored.
+// An empty list is ignored so a failed fetch can't archive everyone.
+func (s *Store) ReconcileServerUsers(ctx context.Context, serverID int64, present []string, now time.Time) (archived, restored []string, err error) {
+	if len(present) == 0 {
+		return nil, nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	type member struct {
+		missed   int
+		archived bool
+	}
+	rows, err := tx.QueryContext(ctx,
+		`SELECT user_name, missed_syncs, archived_at IS NOT NULL FROM server_users WHERE server_id = ?`, serverID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing server users: %w", err)
+	}
+	known := make(map[string]member)
+	for rows.Next() {
+		var name string
+		var m member
+		if err := rows.Scan(&name, &m.missed, &m.archived); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scanning server user: %w", err)
+		}
+		known[name] = m
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterating server users: %w", err)
+	}
+
+	upsert, err := tx.PrepareContext(ctx,
+		`INSERT INTO server_users (server_id, user_name, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(server_id, user_name) DO UPDATE SET
+			last_seen_at = excluded.last_seen_at, missed_syncs = 0, archived_at = NULL`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("prepare upsert: %w", err)
+	}
+	defer upsert.Close()
+
+	seen := make(map[string]bool, len(present))
+	for _, name := range present {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if _, err := upsert.ExecContext(ctx, serverID, name, now, now); err != nil {
+			return nil, nil, fmt.Errorf("upserting server user %s: %w", name, err)
+		}
+		if known[name].archived {
+			restored = append(restored, name)
+		}
+	}
+
+	missStmt, err := tx.PrepareContext(ctx,
+		`UPDATE server_users SET missed_syncs = missed_syncs + 1 WHERE server_id = ? AND user_name = ?`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("prepare miss: %w", err)
+	}
+	defer missStmt.Close()
+
+	archiveStmt, err := tx.PrepareContext(ctx,
+		`UPDATE server_users SET archived_at = ?,
+			final_plays = (SELECT COUNT(*) FROM watch_history h
+				WHERE h.server_id = server_users.server_id AND h.user_name = server_users.user_name AND `+minPlayCond("h")+`),
+			final_watched_ms = (SELECT COALESCE(SUM(h.watched_ms), 0) FROM watch_history h
+				WHERE h.server_id = server_users.server_id AND h.user_name = server_users.user_name AND `+minPlayCond("h")+`),
+			final_last_streamed_at = (SELECT MAX(h.started_at) FROM watch_history h
+				WHERE h.server_id = server_users.server_id AND h.user_name = server_users.user_name)
+		WHERE server_id = ? AND user_name = ?`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("prepare archive: %w", err)
+	}
+	defer archiveStmt.Close()
+
+	for name, m := range known {
+		if seen[name] || m.archived {
+			continue
+		}
+		if _, err := missStmt.ExecContext(ctx, serverID, name); err != nil {
+			return nil, nil, fmt.Errorf("recording missed sync for %s: %w", name, err)
+		}
+		if m.missed+1 < archiveAfterMisses {
+			continue
+		}
+		if _, err := archiveStmt.ExecContext(ctx, now, serverID, name); err != nil {
+			return nil, nil, fmt.Errorf("archiving server user %s: %w", name, err)
+		}
+		archived = append(archived, name)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit: %w", err)
+	}
+	slices.Sort(archived)
+	return archived, restored, nil
+}
+
+// ListArchivedUsers returns the archived server users, most recently
+// archived first.
+func (s *Store) ListArchivedUsers(ctx context.Context) ([]models.ArchivedUser, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT su.server_id, COALESCE(srv.name, ''), su.user_name, su.first_seen_at, su.archived_at,
+			su.final_plays, su.final_watched_ms, su.final_last_streamed_at
+		FROM server_users su
+		LEFT JOIN servers srv ON srv.id = su.server_id
+		WHERE su.archived_at IS NOT NULL
+		ORDER BY su.archived_at DESC, su.user_name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing archived users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.ArchivedUser{}
+	for rows.Next() {
+		var u models.ArchivedUser
+		var lastStreamed sql.NullString
+		if err := rows.Scan(&u.ServerID, &u.ServerName, &u.UserName, &u.FirstSeenAt, &u.ArchivedAt,
+			&u.TotalPlays, &u.TotalWatchedMs, &lastStreamed); err != nil {
+			return nil, fmt.Errorf("scanning archived user: %w", err)
+		}
+		if lastStreamed.Valid {
+			if t, err := parseSQLiteTime(lastStreamed.String); err == nil {
+				u.LastStreamedAt = &t
+			}
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
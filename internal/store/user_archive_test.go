@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReconcileServerUsers(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range 3 {
+		e := makeHistoryEntry(serverID, "alice", fmt.Sprintf("Movie %d", i), base.Add(time.Duration(i)*time.Hour))
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// bob only appears in history, never on the server's user list.
+	if err := s.InsertHistory(makeHistoryEntry(serverID, "bob", "Movie", base)); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile := func(at time.Time, present ...string) (archived, restored []string) {
+		t.Helper()
+		archived, restored, err := s.ReconcileServerUsers(ctx, serverID, present, at)
+		if err != nil {
+			t.Fatalf("ReconcileServerUsers: %v", err)
+		}
+		return archived, restored
+	}
+
+	reconcile(base, "owner", "alice")
+	if archived, _ := reconcile(base.Add(24*time.Hour), "owner"); len(archived) != 0 {
+		t.Fatalf("archived after one miss: %v", archived)
+	}
+	if archived, _ := reconcile(base.Add(48 * time.Hour)); len(archived) != 0 {
+		t.Fatalf("empty list archived users: %v", archived)
+	}
+	archivedAt := base.Add(72 * time.Hour)
+	if archived, _ := reconcile(archivedAt, "owner"); !slices.Equal(archived, []string{"alice"}) {
+		t.Fatalf("archived = %v, want [alice]", archived)
+	}
+
+	users, err := s.ListArchivedUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("got %d archived users, want 1", len(users))
+	}
+	u := users[0]
+	if u.UserName != "alice" || u.ServerName != "Test" || !u.ArchivedAt.Equal(archivedAt) {
+		t.Errorf("archived user = %+v", u)
+	}
+	if u.TotalPlays != 3 || u.TotalWatchedMs != 3*2*time.Hour.Milliseconds() {
+		t.Errorf("final stats = %d plays, %d ms", u.TotalPlays, u.TotalWatchedMs)
+	}
+	if u.LastStreamedAt == nil || !u.LastStreamedAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("last streamed = %v, want %v", u.LastStreamedAt, base.Add(2*time.Hour))
+	}
+
+	summaries, err := s.ListUserSummaries(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sum := range summaries {
+		switch sum.Name {
+		case "alice":
+			if sum.ArchivedAt == nil {
+				t.Error("alice summary not marked archived")
+			}
+		case "bob":
+			if sum.ArchivedAt != nil {
+				t.Error("history-only user marked archived")
+			}
+		}
+	}
+
+	if _, restored := reconcile(base.Add(96*time.Hour), "owner", "alice"); !slices.Equal(restored, []string{"alice"}) {
+		t.Fatalf("restored = %v, want [alice]", restored)
+	}
+	users, err = s.ListArchivedUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("archived users after restore = %+v", users)
+	}
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"streammon/internal/models"
+)
+
+// UserScope selects the history that belongs to one person. A scope with no
+// accounts matches the user name on every server, which is how per-user
+// queries behaved before accounts were keyed by server.
+type UserScope struct {
+	UserName string
+	Accounts []models.UserAccount
+	// ServerIDs, when non-empty, further limits the scope to those servers,
+	// so a workspace never sees the person's plays elsewhere.
+	ServerIDs []int64
+}
+
+// NameScope matches userName on every server.
+func NameScope(userName string) UserScope {
+	return UserScope{UserName: userName}
+}
+
+// condition returns a WHERE fragment matching the scope's rows, with alias
+// qualifying the watch_history columns when non-empty.
+func (sc UserScope) condition(alias string) (string, []any) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	var cond string
+	var args []any
+	if len(sc.Accounts) == 0 {
+		cond, args = prefix+"user_name = ?", []any{sc.UserName}
+	} else {
+		parts := make([]string, len(sc.Accounts))
+		args = make([]any, 0, 2*len(sc.Accounts))
+		for i, a := range sc.Accounts {
+			parts[i] = "(" + prefix + "server_id = ? AND " + prefix + "user_name = ?)"
+			args = append(args, a.ServerID, a.UserName)
+		}
+		cond = "(" + strings.Join(parts, " OR ") + ")"
+	}
+	if len(sc.ServerIDs) > 0 {
+		cond += " AND " + prefix + "server_id IN (" + strings.Repeat(",?", len(sc.ServerIDs))[1:] + ")"
+		for _, id := range sc.ServerIDs {
+			args = append(args, id)
+		}
+	}
+	return cond, args
+}
+
+// Matches reports whether the scope covers userName's account on serverID.
+func (sc UserScope) Matches(serverID int64, userName string) bool {
+	if len(sc.ServerIDs) > 0 && !slices.Contains(sc.ServerIDs, serverID) {
+		return false
+	}
+	if len(sc.Accounts) == 0 {
+		return userName == sc.UserName
+	}
+	for _, a := range sc.Accounts {
+		if a.ServerID == serverID && a.UserName == userName {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveUserScope returns the scope for userName on serverID: that account
+// plus every account an admin linked to the same identity. serverID 0 keeps
+// the name-only scope.
+func (s *Store) ResolveUserScope(ctx context.Context, serverID int64, userName string) (UserScope, error) {
+	if serverID == 0 {
+		return NameScope(userName), nil
+	}
+	scope := UserScope{
+		UserName: userName,
+		Accounts: []models.UserAccount{{ServerID: serverID, UserName: userName}},
+	}
+
+	var identity string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT identity FROM user_identity_links WHERE server_id = ? AND user_name = ?`,
+		serverID, userName).Scan(&identity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return scope, nil
+	}
+	if err != nil {
+		return UserScope{}, fmt.Errorf("resolving user identity: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT server_id, user_name FROM user_identity_links
+		WHERE identity = ? AND NOT (server_id = ? AND user_name = ?)
+		ORDER BY server_id, user_name`,
+		identity, serverID, userName)
+	if err != nil {
+		return UserScope{}, fmt.Errorf("listing linked accounts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.UserAccount
+		if err := rows.Scan(&a.ServerID, &a.UserName); err != nil {
+			return UserScope{}, fmt.Errorf("scanning linked account: %w", err)
+		}
+		scope.Accounts = append(scope.Accounts, a)
+	}
+	return scope, rows.Err()
+}
+
+// SelfScope returns the scope of a streamMon account's own history. An Emby
+// or Jellyfin login records the server it signed in to, so its scope is that
+// server's account plus any linked to it; other logins match their name on
+// every server.
+func (s *Store) SelfScope(ctx context.Context, userID int64, userName string) (UserScope, error) {
+	var provider, providerID string
+	err := s.db.QueryRowContext(ctx, `SELECT provider, provider_id FROM users WHERE id = ?`, userID).
+		Scan(&provider, &providerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return NameScope(userName), nil
+	}
+	if err != nil {
+		return UserScope{}, fmt.Errorf("getting user provider: %w", err)
+	}
+	if provider != string(models.ServerTypeEmby) && provider != string(models.ServerTypeJellyfin) {
+		return NameScope(userName), nil
+	}
+	// Media-server provider IDs are "<server id>:<media user id>".
+	rawServerID, _, ok := strings.Cut(providerID, ":")
+	serverID, err := strconv.ParseInt(rawServerID, 10, 64)
+	if !ok || err != nil || serverID <= 0 {
+		return NameScope(userName), nil
+	}
+	return s.ResolveUserScope(ctx, serverID, userName)
+}
+
+// LinkUserIdentity links an account to an identity, replacing any identity
+// it was linked to before.
+func (s *Store) LinkUserIdentity(a *models.UserAccount) error {
+	if err := a.ValidateLink(); err != nil {
+		return fmt.Errorf("invalid identity link: %w", err)
+	}
+	_, err := s.db.Exec(`INSERT INTO user_identity_links (server_id, user_name, identity) VALUES (?, ?, ?)
+		ON CONFLICT(server_id, user_name) DO UPDATE SET identity = excluded.identity, created_at = CURRENT_TIMESTAMP`,
+		a.ServerID, a.UserName, a.Identity)
+	if err != nil {
+		return fmt.Errorf("linking user identity: %w", err)
+	}
+	return nil
+}
+
+// UnlinkUserIdentity removes an account's identity link. It returns
+// models.ErrNotFound when the account wasn't linked.
+func (s *Store) UnlinkUserIdentity(serverID int64, userName string) error {
+	res, err := s.db.Exec(`DELETE FROM user_identity_links WHERE server_id = ? AND user_name = ?`,
+		serverID, userName)
+	if err != nil {
+		return fmt.Errorf("unlinking user identity: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ListUserIdentityLinks returns every linked account, grouped by identity.
+func (s *Store) ListUserIdentityLinks() ([]models.UserAccount, error) {
+	rows, err := s.db.Query(`SELECT l.server_id, COALESCE(sv.name, ''), l.user_name, l.identity, l.created_at
+		FROM user_identity_links l
+		LEFT JOIN servers sv ON sv.id = l.server_id
+		ORDER BY l.identity, l.server_id, l.user_name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing identity links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []models.UserAccount{}
+	for rows.Next() {
+		var a models.UserAccount
+		var linkedAt string
+		if err := rows.Scan(&a.ServerID, &a.ServerName, &a.UserName, &a.Identity, &linkedAt); err != nil {
+			return nil, fmt.Errorf("scanning identity link: %w", err)
+		}
+		if t, err := parseSQLiteTime(linkedAt); err == nil {
+			a.LinkedAt = &t
+		}
+		links = append(links, a)
+	}
+	return links, rows.Err()
+}
+
+// ListDuplicateUserNames returns the user names with history on more than
+// one server, with each account's identity link.
+func (s *Store) ListDuplicateUserNames(ctx context.Context) ([]models.DuplicateUserName, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT h.user_name, h.server_id, COALESCE(sv.name, ''), COALESCE(l.identity, '')
+		FROM (SELECT DISTINCT user_name, server_id FROM watch_history) h
+		LEFT JOIN servers sv ON sv.id = h.server_id
+		LEFT JOIN user_identity_links l ON l.server_id = h.server_id AND l.user_name = h.user_name
+		WHERE h.user_name IN (
+			SELECT user_name FROM watch_history GROUP BY user_name HAVING COUNT(DISTINCT server_id) > 1
+		)
+		ORDER BY h.user_name, h.server_id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing duplicate user names: %w", err)
+	}
+	defer rows.Close()
+
+	dups := []models.DuplicateUserName{}
+	for rows.Next() {
+		var a models.UserAccount
+		if err := rows.Scan(&a.UserName, &a.ServerID, &a.ServerName, &a.Identity); err != nil {
+			return nil, fmt.Errorf("scanning duplicate user name: %w", err)
+		}
+		if n := len(dups); n == 0 || dups[n-1].UserName != a.UserName {
+			dups = append(dups, models.DuplicateUserName{UserName: a.UserName})
+		}
+		d := &dups[len(dups)-1]
+		d.Accounts = append(d.Accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating duplicate user names: %w", err)
+	}
+
+	for i := range dups {
+		identity := dups[i].Accounts[0].Identity
+		merged := identity != ""
+		for _, a := range dups[i].Accounts[1:] {
+			if a.Identity != identity {
+				merged = false
+				break
+			}
+		}
+		dups[i].Merged = merged
+	}
+	return dups, nil
+}
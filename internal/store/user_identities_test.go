@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func seedDuplicateAlex(t *testing.T, s *Store) (int64, int64) {
+	t.Helper()
+	server1 := seedServer(t, s)
+	server2 := seedServer(t, s)
+	now := time.Now().UTC().Add(-24 * time.Hour)
+	for i, serverID := range []int64{server1, server2, server2} {
+		e := makeHistoryEntry(serverID, "alex", "Heat", now.Add(time.Duration(i)*3*time.Hour))
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatalf("InsertHistory: %v", err)
+		}
+	}
+	return server1, server2
+}
+
+func TestUserDetailStatsScopedByServer(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	server1, server2 := seedDuplicateAlex(t, s)
+	ctx := context.Background()
+
+	merged, err := s.UserDetailStats(ctx, "alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.SessionCount != 3 {
+		t.Fatalf("name-only scope: expected 3 sessions, got %d", merged.SessionCount)
+	}
+
+	scope, err := s.ResolveUserScope(ctx, server1, "alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.UserDetailStatsScoped(ctx, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.SessionCount != 1 {
+		t.Fatalf("server scope: expected 1 session, got %d", stats.SessionCount)
+	}
+
+	// Linking both accounts to one identity merges them again.
+	for _, id := range []int64{server1, server2} {
+		if err := s.LinkUserIdentity(&models.UserAccount{ServerID: id, UserName: "alex", Identity: "Alex"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scope, err = s.ResolveUserScope(ctx, server1, "alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err = s.UserDetailStatsScoped(ctx, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.SessionCount != 3 {
+		t.Fatalf("linked scope: expected 3 sessions, got %d", stats.SessionCount)
+	}
+}
+
+func TestListDuplicateUserNames(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	server1, server2 := seedDuplicateAlex(t, s)
+	e := makeHistoryEntry(server1, "bea", "Heat", time.Now().UTC().Add(-time.Hour))
+	if err := s.InsertHistory(e); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	dups, err := s.ListDuplicateUserNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dups) != 1 || dups[0].UserName != "alex" || len(dups[0].Accounts) != 2 || dups[0].Merged {
+		t.Fatalf("unexpected duplicates: %+v", dups)
+	}
+
+	for _, id := range []int64{server1, server2} {
+		if err := s.LinkUserIdentity(&models.UserAccount{ServerID: id, UserName: "alex", Identity: "Alex"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dups, err = s.ListDuplicateUserNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dups[0].Merged || dups[0].Accounts[1].Identity != "Alex" {
+		t.Fatalf("expected merged duplicate, got %+v", dups[0])
+	}
+}
+
+func TestUserIdentityLinkCRUD(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+
+	if err := s.LinkUserIdentity(&models.UserAccount{ServerID: serverID, UserName: "alex"}); err == nil {
+		t.Fatal("expected error for missing identity")
+	}
+	if err := s.LinkUserIdentity(&models.UserAccount{ServerID: serverID, UserName: "alex", Identity: "Alex"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LinkUserIdentity(&models.UserAccount{ServerID: serverID, UserName: "alex", Identity: "Alex R"}); err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := s.ListUserIdentityLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Identity != "Alex R" || links[0].ServerName != "Test" || links[0].LinkedAt == nil {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+
+	if err := s.UnlinkUserIdentity(serverID, "alex"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnlinkUserIdentity(serverID, "alex"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSelfScope(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	server1, server2 := seedDuplicateAlex(t, s)
+
+	jellyfin, err := s.GetOrLinkUserByEmail("", "alex", "jellyfin", strconv.FormatInt(server2, 10)+":abc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope, err := s.SelfScope(ctx, jellyfin.ID, jellyfin.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scope.Accounts) != 1 || scope.Accounts[0].ServerID != server2 {
+		t.Fatalf("scope = %+v, want alex on server %d only", scope, server2)
+	}
+	if scope.Matches(server1, "alex") || !scope.Matches(server2, "alex") {
+		t.Error("Matches disagreed with the scope's accounts")
+	}
+	got, err := s.QueryHistory(1, 10, HistoryQuery{Scope: &scope})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 2 {
+		t.Errorf("scoped history total = %d, want 2", got.Total)
+	}
+	for _, e := range got.Items {
+		if e.ServerID != server2 {
+			t.Errorf("scoped history included server %d", e.ServerID)
+		}
+	}
+
+	local, err := s.CreateLocalUser("sam", "", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope, err = s.SelfScope(ctx, local.ID, local.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scope.Accounts) != 0 || scope.UserName != "sam" {
+		t.Errorf("local login scope = %+v, want name-only", scope)
+	}
+}
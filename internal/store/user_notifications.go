@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"streammon/internal/models"
+)
+
+// GetUserNotificationSubscription returns what userID subscribed to, or an
+// empty subscription when they never have.
+func (s *Store) GetUserNotificationSubscription(userID int64) (models.UserNotificationSubscription, error) {
+	sub := models.UserNotificationSubscription{Events: []models.UserAlertEvent{}}
+	var events string
+	err := s.db.QueryRow(`SELECT events, email, push FROM user_notification_subscriptions WHERE user_id = ?`, userID).
+		Scan(&events, &sub.Email, &sub.Push)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sub, nil
+	}
+	if err != nil {
+		return sub, fmt.Errorf("getting notification subscription: %w", err)
+	}
+	if err := json.Unmarshal([]byte(events), &sub.Events); err != nil {
+		return sub, fmt.Errorf("parsing notification subscription: %w", err)
+	}
+	if sub.Events == nil {
+		sub.Events = []models.UserAlertEvent{}
+	}
+	return sub, nil
+}
+
+func (s *Store) SetUserNotificationSubscription(userID int64, sub models.UserNotificationSubscription) error {
+	if err := sub.Validate(); err != nil {
+		return fmt.Errorf("invalid notification subscription: %w", err)
+	}
+	if sub.Events == nil {
+		sub.Events = []models.UserAlertEvent{}
+	}
+	events, err := json.Marshal(sub.Events)
+	if err != nil {
+		return fmt.Errorf("encoding notification subscription: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO user_notification_subscriptions (user_id, events, email, push) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET events = excluded.events, email = excluded.email,
+			push = excluded.push, updated_at = CURRENT_TIMESTAMP`,
+		userID, string(events), sub.Email, sub.Push)
+	if err != nil {
+		return fmt.Errorf("setting notification subscription: %w", err)
+	}
+	return nil
+}
+
+// ListUserAlertSubscribers returns the users subscribed to event on at
+// least one delivery.
+func (s *Store) ListUserAlertSubscribers(ctx context.Context, event models.UserAlertEvent) ([]models.UserAlertSubscriber, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT u.id, u.name, u.email, n.events, n.email, n.push
+		FROM user_notification_subscriptions n JOIN users u ON u.id = n.user_id
+		WHERE (n.email = 1 OR n.push = 1)
+			AND EXISTS (SELECT 1 FROM json_each(n.events) WHERE value = ?)
+		ORDER BY u.id`, string(event))
+	if err != nil {
+		return nil, fmt.Errorf("listing alert subscribers: %w", err)
+	}
+	defer rows.Close()
+	var out []models.UserAlertSubscriber
+	for rows.Next() {
+		var sub models.UserAlertSubscriber
+		var events string
+		if err := rows.Scan(&sub.UserID, &sub.Name, &sub.Email, &events, &sub.Subscription.Email, &sub.Subscription.Push); err != nil {
+			return nil, fmt.Errorf("scanning alert subscriber: %w", err)
+		}
+		if err := json.Unmarshal([]byte(events), &sub.Subscription.Events); err != nil {
+			return nil, fmt.Errorf("parsing alert subscriber events: %w", err)
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// ErrTooManyPushSubscriptions is returned when a user registers more
+// browsers than models.MaxPushSubscriptionsPerUser.
+var ErrTooManyPushSubscriptions = fmt.Errorf("at most %d browsers can receive push notifications", models.MaxPushSubscriptionsPerUser)
+
+const pushSubscriptionColumns = `id, user_id, endpoint, p256dh, auth, user_agent, created_at`
+
+func scanPushSubscription(sc interface{ Scan(...any) error }) (models.PushSubscription, error) {
+	var p models.PushSubscription
+	err := sc.Scan(&p.ID, &p.UserID, &p.Endpoint, &p.P256dh, &p.Auth, &p.UserAgent, &p.CreatedAt)
+	return p, err
+}
+
+// AddPushSubscription registers a browser for userID. Registering an
+// endpoint again refreshes its keys, moving it to userID if another account
+// had it: one browser signed in to a new account alerts only that account.
+func (s *Store) AddPushSubscription(p *models.PushSubscription) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM push_subscriptions WHERE user_id = ? AND endpoint != ?`,
+		p.UserID, p.Endpoint).Scan(&count); err != nil {
+		return fmt.Errorf("counting push subscriptions: %w", err)
+	}
+	if count >= models.MaxPushSubscriptionsPerUser {
+		return ErrTooManyPushSubscriptions
+	}
+	err = tx.QueryRow(`INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(endpoint) DO UPDATE SET user_id = excluded.user_id, p256dh = excluded.p256dh,
+			auth = excluded.auth, user_agent = excluded.user_agent
+		RETURNING id, created_at`,
+		p.UserID, p.Endpoint, p.P256dh, p.Auth, p.UserAgent).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("adding push subscription: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListPushSubscriptions(ctx context.Context, userID int64) ([]models.PushSubscription, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+pushSubscriptionColumns+` FROM push_subscriptions WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing push subscriptions: %w", err)
+	}
+	defer rows.Close()
+	out := []models.PushSubscription{}
+	for rows.Next() {
+		p, err := scanPushSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning push subscription: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeletePushSubscription removes one of userID's browsers.
+func (s *Store) DeletePushSubscription(userID, id int64) error {
+	res, err := s.db.Exec(`DELETE FROM push_subscriptions WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return fmt.Errorf("deleting push subscription: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+const userNotificationsKey = "user_notifications"
+
+// GetUserNotificationSettings returns how user alerts are delivered. Email
+// alerts are off until an admin picks a channel to send them through.
+func (s *Store) GetUserNotificationSettings() (models.UserNotificationSettings, error) {
+	var settings models.UserNotificationSettings
+	val, err := s.GetSetting(userNotificationsKey)
+	if err != nil || val == "" {
+		return settings, err
+	}
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return models.UserNotificationSettings{}, fmt.Errorf("parsing user notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Store) SetUserNotificationSettings(settings models.UserNotificationSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	val, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encoding user notification settings: %w", err)
+	}
+	return s.SetSetting(userNotificationsKey, string(val))
+}
+
+// GetVAPIDKeys returns the key pair push requests are signed with, or
+// models.ErrNotFound before one has been generated.
+func (s *Store) GetVAPIDKeys() (models.VAPIDKeys, error) {
+	var keys models.VAPIDKeys
+	var err error
+	if keys.PublicKey, err = s.GetSetting("vapid.public_key"); err != nil {
+		return keys, err
+	}
+	raw, err := s.GetSetting("vapid.private_key")
+	if err != nil {
+		return keys, err
+	}
+	if keys.PublicKey == "" || raw == "" {
+		return models.VAPIDKeys{}, models.ErrNotFound
+	}
+	if keys.PrivateKey, err = s.decryptValue(raw); err != nil {
+		return models.VAPIDKeys{}, fmt.Errorf("decrypting vapid key: %w", err)
+	}
+	return keys, nil
+}
+
+// SetVAPIDKeys stores keys unless a pair already exists, and returns the
+// pair in effect. Replacing the keys would orphan every browser
+// subscription, so a pair is kept once stored.
+func (s *Store) SetVAPIDKeys(keys models.VAPIDKeys) (models.VAPIDKeys, error) {
+	private, err := s.encryptValue(keys.PrivateKey)
+	if err != nil {
+		return keys, fmt.Errorf("encrypting vapid key: %w", err)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return keys, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`INSERT INTO settings (key, value) VALUES ('vapid.public_key', ?) ON CONFLICT(key) DO NOTHING`, keys.PublicKey)
+	if err != nil {
+		return keys, fmt.Errorf("storing vapid keys: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		if _, err := tx.Exec(settingUpsert, "vapid.private_key", private); err != nil {
+			return keys, fmt.Errorf("storing vapid keys: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return keys, fmt.Errorf("storing vapid keys: %w", err)
+	}
+	return s.GetVAPIDKeys()
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+func TestUserNotificationSubscription_RoundTrip(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	alice := createTestUser(t, s, "alice", "alice@example.com")
+	bob := createTestUser(t, s, "bob", "bob@example.com")
+
+	got, err := s.GetUserNotificationSubscription(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Email || got.Push || len(got.Events) != 0 || got.Events == nil {
+		t.Errorf("default = %+v", got)
+	}
+
+	if err := s.SetUserNotificationSubscription(alice.ID, models.UserNotificationSubscription{
+		Events: []models.UserAlertEvent{models.UserAlertNewDevice, models.UserAlertNewLocation}, Push: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Subscribed to the event but with no delivery chosen.
+	if err := s.SetUserNotificationSubscription(bob.ID, models.UserNotificationSubscription{
+		Events: []models.UserAlertEvent{models.UserAlertNewDevice},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserNotificationSubscription(bob.ID, models.UserNotificationSubscription{
+		Events: []models.UserAlertEvent{"bogus"},
+	}); err == nil {
+		t.Error("expected an invalid event to be rejected")
+	}
+
+	got, _ = s.GetUserNotificationSubscription(alice.ID)
+	if !got.Push || got.Email || len(got.Events) != 2 {
+		t.Errorf("alice = %+v", got)
+	}
+	subs, err := s.ListUserAlertSubscribers(ctx, models.UserAlertNewLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].UserID != alice.ID || subs[0].Email != "alice@example.com" {
+		t.Errorf("new_location subscribers = %+v", subs)
+	}
+	subs, _ = s.ListUserAlertSubscribers(ctx, models.UserAlertNewDevice)
+	if len(subs) != 1 || subs[0].Name != "alice" {
+		t.Errorf("new_device subscribers = %+v", subs)
+	}
+}
+
+func testPushSubscription(t *testing.T, userID int64, endpoint string) *models.PushSubscription {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+	}
+}
+
+func TestPushSubscriptions(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	alice := createTestUser(t, s, "alice", "alice@example.com")
+	bob := createTestUser(t, s, "bob", "bob@example.com")
+
+	p := testPushSubscription(t, alice.ID, "https://push.example.com/1")
+	if err := s.AddPushSubscription(p); err != nil {
+		t.Fatal(err)
+	}
+	bad := testPushSubscription(t, alice.ID, "http://push.example.com/2")
+	if err := s.AddPushSubscription(bad); err == nil {
+		t.Error("expected a non-https endpoint to be rejected")
+	}
+
+	// The same browser signing in as bob moves to bob.
+	moved := testPushSubscription(t, bob.ID, p.Endpoint)
+	if err := s.AddPushSubscription(moved); err != nil {
+		t.Fatal(err)
+	}
+	if moved.ID != p.ID {
+		t.Errorf("re-registering got id %d, want %d", moved.ID, p.ID)
+	}
+	if list, _ := s.ListPushSubscriptions(ctx, alice.ID); len(list) != 0 {
+		t.Errorf("alice still has %d subscriptions", len(list))
+	}
+	list, err := s.ListPushSubscriptions(ctx, bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].P256dh != moved.P256dh {
+		t.Errorf("bob = %+v", list)
+	}
+
+	if err := s.DeletePushSubscription(alice.ID, moved.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("deleting another user's subscription: err = %v", err)
+	}
+	if err := s.DeletePushSubscription(bob.ID, moved.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range models.MaxPushSubscriptionsPerUser {
+		if err := s.AddPushSubscription(testPushSubscription(t, alice.ID, fmt.Sprintf("https://push.example.com/a%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = s.AddPushSubscription(testPushSubscription(t, alice.ID, "https://push.example.com/one-too-many"))
+	if !errors.Is(err, ErrTooManyPushSubscriptions) {
+		t.Errorf("over the limit: err = %v", err)
+	}
+}
+
+func TestVAPIDKeys_KeptOnceStored(t *testing.T) {
+	s := testStoreWithEncryptor(t)
+	if _, err := s.GetVAPIDKeys(); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("before generating: err = %v", err)
+	}
+	first := models.VAPIDKeys{PublicKey: "pub1", PrivateKey: "priv1"}
+	got, err := s.SetVAPIDKeys(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != first {
+		t.Errorf("got %+v", got)
+	}
+	got, err = s.SetVAPIDKeys(models.VAPIDKeys{PublicKey: "pub2", PrivateKey: "priv2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != first {
+		t.Errorf("second pair replaced the first: %+v", got)
+	}
+	raw, _ := s.GetSetting("vapid.private_key")
+	if !strings.HasPrefix(raw, encryptedPrefix) {
+		t.Errorf("private key stored in plaintext: %q", raw)
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -127,9 +128,31 @@ type UserSummary struct {
 	LastPlayedServerID         int     `json:"last_played_server_id"`
 	LastPlayedItemID           string  `json:"last_played_item_id"`
 	LastPlayedGrandparentID    string  `json:"last_played_grandparent_item_id"`
+	// ArchivedAt is set once the user has been archived on every server
+	// they were seen on.
+	ArchivedAt *string `json:"archived_at,omitempty"`
 }
 
-func (s *Store) ListUserSummaries() ([]UserSummary, error) {
+// ListUserSummaries summarizes every user who played on one of serverIDs,
+// or on any server when serverIDs is empty.
+func (s *Store) ListUserSummaries(serverIDs []int64) ([]UserSummary, error) {
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	serverWhere, statsCond := "", minPlayCond("")
+	// Trust scores only ever drop by each violation's decrement, so a scoped
+	// score is rebuilt from the violations on serverIDs.
+	trustScores := "user_trust_scores"
+	if serverCond != "" {
+		serverWhere = " WHERE " + serverCond
+		statsCond += " AND " + serverCond
+		trustScores = `(SELECT user_name, MAX(0, 100 - SUM(trust_decrement)) AS score
+			FROM rule_violations WHERE trust_decrement > 0 AND ` + serverCond + ` GROUP BY user_name)`
+	}
+	var args []any
+	args = append(args, serverArgs...)
+	args = append(args, serverArgs...)
+	args = append(args, serverArgs...)
+	args = append(args, serverArgs...)
+
 	// Users derived from watch_history (source of truth); users table only has OIDC logins
 	rows, err := s.db.Query(`
 		WITH ranked AS (
@@ -144,7 +167,7 @@ func (s *Store) ListUserSummaries() ([]UserSummary, error) {
 				grandparent_item_id,
 				started_at,
 				ROW_NUMBER() OVER (PARTITION BY user_name ORDER BY started_at DESC) as rn
-			FROM watch_history
+			FROM watch_history`+serverWhere+`
 		),
 		stats AS (
 			SELECT
@@ -152,7 +175,7 @@ func (s *Store) ListUserSummaries() ([]UserSummary, error) {
 				COUNT(*) as total_plays,
 				SUM(watched_ms) as total_watched_ms
 			FROM watch_history
-			WHERE ` + minPlayCond("") + `
+			WHERE `+statsCond+`
 			GROUP BY user_name
 		),
 		last_entry AS (
@@ -173,12 +196,19 @@ func (s *Store) ListUserSummaries() ([]UserSummary, error) {
 			COALESCE(le.media_type, '') as last_played_media_type,
 			COALESCE(le.server_id, 0) as last_played_server_id,
 			COALESCE(le.item_id, '') as last_played_item_id,
-			COALESCE(le.grandparent_item_id, '') as last_played_grandparent_item_id
+			COALESCE(le.grandparent_item_id, '') as last_played_grandparent_item_id,
+			ar.archived_at
 		FROM last_entry le
 		LEFT JOIN stats s ON le.user_name = s.user_name
 		LEFT JOIN users u ON le.user_name = u.name
-		LEFT JOIN user_trust_scores t ON le.user_name = t.user_name
-		ORDER BY le.user_name`)
+		LEFT JOIN `+trustScores+` t ON le.user_name = t.user_name
+		LEFT JOIN (
+			SELECT user_name, MAX(archived_at) as archived_at
+			FROM server_users`+serverWhere+`
+			GROUP BY user_name
+			HAVING COUNT(archived_at) = COUNT(*)
+		) ar ON le.user_name = ar.user_name
+		ORDER BY le.user_name`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("listing user summaries: %w", err)
 	}
@@ -192,6 +222,7 @@ func (s *Store) ListUserSummaries() ([]UserSummary, error) {
 			&s.TotalPlays, &s.TotalWatchedMs, &s.TrustScore,
 			&s.LastPlayedTitle, &s.LastPlayedGrandparentTitle, &s.LastPlayedMediaType,
 			&s.LastPlayedServerID, &s.LastPlayedItemID, &s.LastPlayedGrandparentID,
+			&s.ArchivedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scanning user summary: %w", err)
 		}
@@ -630,3 +661,42 @@ func (s *Store) SetUserNotes(name, notes string) error {
 	}
 	return nil
 }
+
+// GetUserPreferences returns a user's stored preferences, empty when none
+// have been saved.
+func (s *Store) GetUserPreferences(userID int64) (models.UserPreferences, error) {
+	prefs := models.UserPreferences{ServerIDs: []int64{}}
+	var raw string
+	err := s.db.QueryRow(`SELECT preferences FROM users WHERE id = ?`, userID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return prefs, models.ErrNotFound
+	}
+	if err != nil {
+		return prefs, fmt.Errorf("getting user preferences: %w", err)
+	}
+	if err := json.Unmarshal([]byte(raw), &prefs); err != nil {
+		return prefs, fmt.Errorf("parsing user preferences: %w", err)
+	}
+	if prefs.ServerIDs == nil {
+		prefs.ServerIDs = []int64{}
+	}
+	return prefs, nil
+}
+
+func (s *Store) SetUserPreferences(userID int64, prefs models.UserPreferences) error {
+	if err := prefs.Validate(); err != nil {
+		return fmt.Errorf("invalid preferences: %w", err)
+	}
+	raw, err := json.Marshal(prefs)
+	if err != nil {
+		return fmt.Errorf("marshaling preferences: %w", err)
+	}
+	res, err := s.db.Exec(`UPDATE users SET preferences = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, string(raw), userID)
+	if err != nil {
+		return fmt.Errorf("setting user preferences: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
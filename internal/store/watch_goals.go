@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+const watchGoalColumns = `user_name, weekly_target_minutes, weekly_limit_minutes, updated_at`
+
+func scanWatchGoal(scanner interface{ Scan(...any) error }) (models.WatchGoal, error) {
+	var g models.WatchGoal
+	err := scanner.Scan(&g.UserName, &g.WeeklyTargetMinutes, &g.WeeklyLimitMinutes, &g.UpdatedAt)
+	return g, err
+}
+
+func (s *Store) GetWatchGoal(userName string) (*models.WatchGoal, error) {
+	g, err := scanWatchGoal(s.db.QueryRow(`SELECT `+watchGoalColumns+` FROM watch_goals WHERE user_name = ?`, userName))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting watch goal: %w", err)
+	}
+	return &g, nil
+}
+
+func (s *Store) ListWatchGoals() ([]models.WatchGoal, error) {
+	rows, err := s.db.Query(`SELECT ` + watchGoalColumns + ` FROM watch_goals ORDER BY user_name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing watch goals: %w", err)
+	}
+	defer rows.Close()
+	goals := []models.WatchGoal{}
+	for rows.Next() {
+		g, err := scanWatchGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning watch goal: %w", err)
+		}
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+// SetWatchGoal creates or replaces a user's goal. Changing it lets a limit
+// already passed this week be announced again.
+func (s *Store) SetWatchGoal(g *models.WatchGoal) error {
+	if err := g.Validate(); err != nil {
+		return fmt.Errorf("invalid watch goal: %w", err)
+	}
+	err := s.db.QueryRow(`INSERT INTO watch_goals (user_name, weekly_target_minutes, weekly_limit_minutes) VALUES (?, ?, ?)
+		ON CONFLICT(user_name) DO UPDATE SET weekly_target_minutes = excluded.weekly_target_minutes,
+		weekly_limit_minutes = excluded.weekly_limit_minutes, limit_notified_week = '', updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		g.UserName, g.WeeklyTargetMinutes, g.WeeklyLimitMinutes).Scan(&g.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("setting watch goal: %w", err)
+	}
+	return nil
+}
+
+// DeleteWatchGoal returns models.ErrNotFound when the user has no goal.
+func (s *Store) DeleteWatchGoal(userName string) error {
+	res, err := s.db.Exec(`DELETE FROM watch_goals WHERE user_name = ?`, userName)
+	if err != nil {
+		return fmt.Errorf("deleting watch goal: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimWatchLimitNotice marks the user's limit as announced for week,
+// reporting false when it already was, so each week's overrun is announced
+// once across restarts.
+func (s *Store) ClaimWatchLimitNotice(ctx context.Context, userName, week string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE watch_goals SET limit_notified_week = ?
+		WHERE user_name = ? AND limit_notified_week != ?`, week, userName, week)
+	if err != nil {
+		return false, fmt.Errorf("claiming watch limit notice: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claiming watch limit notice: %w", err)
+	}
+	return n == 1, nil
+}
+
+// DailyWatchTimeForUser totals a user's watching per day for sessions
+// started in [start, end), with days split at midnight tzOffsetMinutes east
+// of UTC. Days without watching are left out. A non-empty serverIDs only
+// counts plays on those servers.
+func (s *Store) DailyWatchTimeForUser(ctx context.Context, userName string, start, end time.Time, tzOffsetMinutes int, serverIDs []int64) ([]models.DailyWatchTime, error) {
+	dayExpr := "date(started_at)"
+	var args []any
+	if mod, ok := tzModifier(tzOffsetMinutes); ok {
+		dayExpr = "date(started_at, ?)"
+		args = append(args, mod)
+	}
+	args = append(args, userName, start.UTC(), end.UTC())
+	serverCond, serverArgs := StatsFilter{ServerIDs: serverIDs}.serverConditionWith("")
+	if serverCond != "" {
+		serverCond = " AND " + serverCond
+		args = append(args, serverArgs...)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+dayExpr+` AS day, COALESCE(SUM(watched_ms), 0), COUNT(*)
+		FROM watch_history
+		WHERE user_name = ? AND started_at >= ? AND started_at < ?`+serverCond+`
+		GROUP BY day
+		ORDER BY day`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("daily watch time: %w", err)
+	}
+	defer rows.Close()
+	days := []models.DailyWatchTime{}
+	for rows.Next() {
+		var d models.DailyWatchTime
+		if err := rows.Scan(&d.Date, &d.WatchedMs, &d.Plays); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// WatchGoalProgress reports goal's user's week up to now, with the week and
+// its days taken in now's location. A non-empty serverIDs only counts plays
+// on those servers.
+func (s *Store) WatchGoalProgress(ctx context.Context, goal models.WatchGoal, now time.Time, serverIDs []int64) (*models.WatchGoalProgress, error) {
+	weekStart := models.WeekStart(now)
+	_, offset := now.Zone()
+	days, err := s.DailyWatchTimeForUser(ctx, goal.UserName, weekStart, weekStart.AddDate(0, 0, 7), offset/60, serverIDs)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewWatchGoalProgress(goal, weekStart, days), nil
+}
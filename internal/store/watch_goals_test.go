@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestWatchGoals_CRUD(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	if _, err := s.GetWatchGoal("alice"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("before setting: err = %v", err)
+	}
+	goal := &models.WatchGoal{UserName: "alice", WeeklyLimitMinutes: 600}
+	if err := s.SetWatchGoal(goal); err != nil {
+		t.Fatal(err)
+	}
+	if goal.UpdatedAt.IsZero() {
+		t.Error("updated_at not filled in")
+	}
+	if err := s.SetWatchGoal(&models.WatchGoal{UserName: "bob"}); err == nil {
+		t.Error("expected an empty goal to be rejected")
+	}
+
+	claimed, err := s.ClaimWatchLimitNotice(ctx, "alice", "2026-03-02")
+	if err != nil || !claimed {
+		t.Fatalf("first claim = %v, %v", claimed, err)
+	}
+	if claimed, _ := s.ClaimWatchLimitNotice(ctx, "alice", "2026-03-02"); claimed {
+		t.Error("same week claimed twice")
+	}
+	if claimed, _ := s.ClaimWatchLimitNotice(ctx, "alice", "2026-03-09"); !claimed {
+		t.Error("next week not claimable")
+	}
+	if claimed, _ := s.ClaimWatchLimitNotice(ctx, "bob", "2026-03-09"); claimed {
+		t.Error("claimed for a user without a goal")
+	}
+
+	// Raising the limit lets the new one be announced this week.
+	if err := s.SetWatchGoal(&models.WatchGoal{UserName: "alice", WeeklyTargetMinutes: 120, WeeklyLimitMinutes: 900}); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, _ := s.ClaimWatchLimitNotice(ctx, "alice", "2026-03-09"); !claimed {
+		t.Error("not claimable after the goal changed")
+	}
+
+	got, err := s.GetWatchGoal("alice")
+	if err != nil || got.WeeklyTargetMinutes != 120 || got.WeeklyLimitMinutes != 900 {
+		t.Errorf("get = %+v, %v", got, err)
+	}
+	list, err := s.ListWatchGoals()
+	if err != nil || len(list) != 1 {
+		t.Errorf("list = %+v, %v", list, err)
+	}
+
+	if err := s.DeleteWatchGoal("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteWatchGoal("alice"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("deleting twice: err = %v", err)
+	}
+}
+
+func TestWatchGoalProgress(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	ctx := context.Background()
+
+	// Wednesday 10:00 in UTC-5.
+	loc := time.FixedZone("", -5*3600)
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, loc)
+	for _, tc := range []struct {
+		user    string
+		title   string
+		at      time.Time
+		watched time.Duration
+	}{
+		{"alice", "A", time.Date(2026, 3, 2, 20, 0, 0, 0, loc), 90 * time.Minute},
+		// 23:30 local is already Tuesday in UTC; it counts on Monday here.
+		{"alice", "B", time.Date(2026, 3, 2, 23, 30, 0, 0, loc), 30 * time.Minute},
+		{"alice", "C", time.Date(2026, 3, 4, 8, 0, 0, 0, loc), 45 * time.Minute},
+		{"alice", "D", time.Date(2026, 3, 1, 20, 0, 0, 0, loc), 5 * time.Hour}, // last week
+		{"bob", "E", time.Date(2026, 3, 3, 20, 0, 0, 0, loc), time.Hour},
+	} {
+		e := makeHistoryEntry(serverID, tc.user, tc.title, tc.at)
+		e.WatchedMs = tc.watched.Milliseconds()
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := s.WatchGoalProgress(ctx, models.WatchGoal{UserName: "alice", WeeklyLimitMinutes: 150}, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.WeekStart.Format("2006-01-02") != "2026-03-02" || p.WatchedMinutes != 165 || !p.LimitExceeded {
+		t.Errorf("progress = %+v", p)
+	}
+	if p.Days[0].WatchedMs != (2*time.Hour).Milliseconds() || p.Days[0].Plays != 2 || p.Days[2].Plays != 1 || p.Days[1].Plays != 0 {
+		t.Errorf("days = %+v", p.Days)
+	}
+
+	days, err := s.DailyWatchTimeForUser(ctx, "alice", p.WeekStart, p.WeekStart.AddDate(0, 0, 7), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Split at UTC midnight, Monday evening's sessions land on Tuesday.
+	if len(days) != 2 || days[0].Date != "2026-03-03" || days[0].Plays != 2 {
+		t.Errorf("utc days = %+v", days)
+	}
+}
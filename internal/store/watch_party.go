@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// watchPartyWindow is how close together two plays of the same item must
+// start to be treated as one watch party.
+const watchPartyWindow = 60 * time.Second
+
+// partyPlayCountExpr counts plays with every watch party collapsed to one,
+// so a group watching together isn't ranked as several separate plays.
+const partyPlayCountExpr = `COUNT(DISTINCT CASE WHEN watch_party_id > 0 THEN -watch_party_id ELSE id END)`
+
+// watchPartyMatchCond selects other rows that belong in the same party as a
+// new row: same server and item, started within watchPartyWindow, and from a
+// different user or device (one user on one device is just a resume).
+const watchPartyMatchCond = `server_id = ? AND item_id = ? AND id != ?
+	AND started_at BETWEEN ? AND ?
+	AND (user_name != ? OR player != ?)`
+
+func watchPartyMatchArgs(id int64, entry *models.WatchHistoryEntry) []any {
+	return []any{
+		entry.ServerID, entry.ItemID, id,
+		entry.StartedAt.Add(-watchPartyWindow), entry.StartedAt.Add(watchPartyWindow),
+		entry.UserName, entry.Player,
+	}
+}
+
+// assignWatchParty links a freshly inserted history row to any rows it
+// started alongside. A party is keyed by its lowest row id; when the new row
+// joins an existing party it adopts that party's id, and any matching rows
+// not yet in a party are pulled in with it.
+func assignWatchParty(ctx context.Context, qe queryExecer, id int64, entry *models.WatchHistoryEntry) error {
+	if entry.ItemID == "" {
+		return nil
+	}
+
+	var existingParty, minID sql.NullInt64
+	err := qe.QueryRowContext(ctx,
+		`SELECT MIN(CASE WHEN watch_party_id > 0 THEN watch_party_id END), MIN(id)
+		 FROM watch_history WHERE `+watchPartyMatchCond,
+		watchPartyMatchArgs(id, entry)...,
+	).Scan(&existingParty, &minID)
+	if err != nil {
+		return fmt.Errorf("checking watch party: %w", err)
+	}
+	if !minID.Valid {
+		return nil
+	}
+
+	partyID := min(id, minID.Int64)
+	if existingParty.Valid {
+		partyID = existingParty.Int64
+	}
+
+	args := append([]any{partyID, id}, watchPartyMatchArgs(id, entry)...)
+	if _, err := qe.ExecContext(ctx,
+		`UPDATE watch_history SET watch_party_id = ?
+		 WHERE id = ? OR (watch_party_id = 0 AND `+watchPartyMatchCond+`)`,
+		args...,
+	); err != nil {
+		return fmt.Errorf("assigning watch party: %w", err)
+	}
+	return nil
+}
+
+// ListWatchParties returns detected watch parties in the filter window,
+// newest first, with the users and devices that took part.
+func (s *Store) ListWatchParties(ctx context.Context, filter StatsFilter, limit int) ([]models.WatchParty, error) {
+	filterClause, filterArgs := filter.andConditions()
+	args := append(filterArgs, limit)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT watch_party_id, server_id, item_id, MAX(media_type), MAX(title),
+			MAX(grandparent_title), MIN(started_at), COUNT(*),
+			GROUP_CONCAT(DISTINCT user_name)
+		 FROM watch_history
+		 WHERE watch_party_id > 0`+filterClause+`
+		 GROUP BY watch_party_id
+		 ORDER BY MIN(started_at) DESC
+		 LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing watch parties: %w", err)
+	}
+	defer rows.Close()
+
+	parties := []models.WatchParty{}
+	for rows.Next() {
+		var p models.WatchParty
+		var startedAt, users string
+		if err := rows.Scan(&p.ID, &p.ServerID, &p.ItemID, &p.MediaType, &p.Title,
+			&p.GrandparentTitle, &startedAt, &p.SessionCount, &users); err != nil {
+			return nil, fmt.Errorf("scanning watch party: %w", err)
+		}
+		if t, err := parseSQLiteTime(startedAt); err == nil {
+			p.StartedAt = t
+		}
+		p.Users = strings.Split(users, ",")
+		parties = append(parties, p)
+	}
+	return parties, rows.Err()
+}
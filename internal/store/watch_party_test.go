@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestWatchPartyDetection(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+	base := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+
+	entry := func(user, player, itemID string, offset time.Duration) *models.WatchHistoryEntry {
+		e := makeHistoryEntry(serverID, user, "Movie Night", base.Add(offset))
+		e.ItemID = itemID
+		e.Player = player
+		e.WatchedMs = e.DurationMs
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	alice := entry("alice", "TV", "42", 0)
+	bob := entry("bob", "Phone", "42", 30*time.Second)
+	carol := entry("carol", "Tablet", "42", 80*time.Second) // within a minute of bob
+	late := entry("dave", "TV", "42", 10*time.Minute)
+	other := entry("erin", "TV", "99", 10*time.Second)
+
+	party := func(id int64) int64 {
+		t.Helper()
+		var p int64
+		if err := s.db.QueryRow(`SELECT watch_party_id FROM watch_history WHERE id = ?`, id).Scan(&p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	for _, e := range []*models.WatchHistoryEntry{alice, bob, carol} {
+		if got := party(e.ID); got != alice.ID {
+			t.Errorf("%s watch_party_id = %d, want %d", e.UserName, got, alice.ID)
+		}
+	}
+	if got := party(late.ID); got != 0 {
+		t.Errorf("late start grouped into party %d", got)
+	}
+	if got := party(other.ID); got != 0 {
+		t.Errorf("different item grouped into party %d", got)
+	}
+
+	parties, err := s.ListWatchParties(ctx, StatsFilter{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parties) != 1 {
+		t.Fatalf("parties = %+v, want 1", parties)
+	}
+	if parties[0].SessionCount != 3 || len(parties[0].Users) != 3 || parties[0].ItemID != "42" {
+		t.Errorf("unexpected party %+v", parties[0])
+	}
+
+	// Five rows share the title: the three-member party counts once.
+	movies, err := s.TopMovies(ctx, 10, StatsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movies) != 1 || movies[0].PlayCount != 3 {
+		t.Errorf("top movies = %+v, want 1 entry with 3 plays", movies)
+	}
+}
+
+func TestWatchPartyDetection_SameUserSameDeviceIgnored(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	serverID := seedServer(t, s)
+	base := time.Now().UTC().Add(-2 * time.Hour)
+
+	// Same user on two devices counts (synced playback); both rows land in
+	// one batch to exercise the import path.
+	a := makeHistoryEntry(serverID, "alice", "Show", base)
+	a.ItemID, a.Player = "7", "TV"
+	b := makeHistoryEntry(serverID, "alice", "Show Again", base.Add(20*time.Second))
+	b.ItemID, b.Player = "7", "Phone"
+	if _, _, _, err := s.InsertHistoryBatch(context.Background(), []*models.WatchHistoryEntry{a, b}); err != nil {
+		t.Fatal(err)
+	}
+
+	var grouped int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM watch_history WHERE watch_party_id > 0`).Scan(&grouped); err != nil {
+		t.Fatal(err)
+	}
+	if grouped != 2 {
+		t.Errorf("grouped rows = %d, want 2 (same user, different devices)", grouped)
+	}
+
+	c := makeHistoryEntry(serverID, "bob", "Solo", base.Add(time.Hour))
+	c.ItemID, c.Player = "8", "TV"
+	d := makeHistoryEntry(serverID, "bob", "Solo Again", base.Add(time.Hour+20*time.Second))
+	d.ItemID, d.Player = "8", "TV"
+	for _, e := range []*models.WatchHistoryEntry{c, d} {
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM watch_history WHERE watch_party_id > 0`).Scan(&grouped); err != nil {
+		t.Fatal(err)
+	}
+	if grouped != 2 {
+		t.Errorf("grouped rows = %d, want 2 (same user and device is not a party)", grouped)
+	}
+}
@@ -0,0 +1,357 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// ErrWorkspaceExists is returned when a workspace's name is already taken.
+var ErrWorkspaceExists = errors.New("a workspace with that name already exists")
+
+const workspaceColumns = `id, name, created_at, updated_at`
+
+func scanWorkspace(scanner interface{ Scan(...any) error }) (models.Workspace, error) {
+	var w models.Workspace
+	err := scanner.Scan(&w.ID, &w.Name, &w.CreatedAt, &w.UpdatedAt)
+	return w, err
+}
+
+func (s *Store) CreateWorkspace(w *models.Workspace) error {
+	if err := w.Validate(); err != nil {
+		return fmt.Errorf("invalid workspace: %w", err)
+	}
+	created, err := scanWorkspace(s.db.QueryRow(
+		`INSERT INTO workspaces (name) VALUES (?) RETURNING `+workspaceColumns, w.Name))
+	if isUniqueConstraintError(err) {
+		return ErrWorkspaceExists
+	}
+	if err != nil {
+		return fmt.Errorf("creating workspace: %w", err)
+	}
+	*w = created
+	return nil
+}
+
+func (s *Store) RenameWorkspace(w *models.Workspace) error {
+	if err := w.Validate(); err != nil {
+		return fmt.Errorf("invalid workspace: %w", err)
+	}
+	updated, err := scanWorkspace(s.db.QueryRow(
+		`UPDATE workspaces SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING `+workspaceColumns,
+		w.Name, w.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("workspace %d: %w", w.ID, models.ErrNotFound)
+	}
+	if isUniqueConstraintError(err) {
+		return ErrWorkspaceExists
+	}
+	if err != nil {
+		return fmt.Errorf("renaming workspace: %w", err)
+	}
+	*w = updated
+	return nil
+}
+
+// DeleteWorkspace removes a workspace. Its servers and members go back to
+// being unscoped; no history is deleted.
+func (s *Store) DeleteWorkspace(id int64) error {
+	res, err := s.db.Exec(`DELETE FROM workspaces WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting workspace: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("workspace %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+// SetWorkspaceServers replaces a workspace's servers. A server already in
+// another workspace moves to this one.
+func (s *Store) SetWorkspaceServers(ctx context.Context, workspaceID int64, serverIDs []int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := workspaceExists(ctx, tx, workspaceID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workspace_servers WHERE workspace_id = ?`, workspaceID); err != nil {
+		return fmt.Errorf("clearing workspace servers: %w", err)
+	}
+	for _, id := range serverIDs {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM servers WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("checking server %d: %w", id, err)
+		}
+		if !exists {
+			return fmt.Errorf("server %d: %w", id, models.ErrNotFound)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO workspace_servers (server_id, workspace_id) VALUES (?, ?)
+			ON CONFLICT(server_id) DO UPDATE SET workspace_id = excluded.workspace_id`,
+			id, workspaceID); err != nil {
+			return fmt.Errorf("adding server %d to workspace: %w", id, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE workspaces SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, workspaceID); err != nil {
+		return fmt.Errorf("touching workspace: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SetWorkspaceMember adds a user to a workspace with role, or changes their
+// role. A user in another workspace moves to this one.
+func (s *Store) SetWorkspaceMember(ctx context.Context, workspaceID, userID int64, role models.WorkspaceRole) error {
+	if !role.Valid() {
+		return fmt.Errorf("invalid workspace role %q", role)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := workspaceExists(ctx, tx, workspaceID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO workspace_members (user_id, workspace_id, role) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET workspace_id = excluded.workspace_id, role = excluded.role`,
+		userID, workspaceID, role); err != nil {
+		return fmt.Errorf("setting workspace member: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Store) RemoveWorkspaceMember(ctx context.Context, workspaceID, userID int64) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?`, workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("removing workspace member: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("workspace %d member %d: %w", workspaceID, userID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func workspaceExists(ctx context.Context, tx *sql.Tx, id int64) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM workspaces WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("checking workspace: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("workspace %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+// HasWorkspaces reports whether any workspace exists.
+func (s *Store) HasWorkspaces(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM workspaces)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking for workspaces: %w", err)
+	}
+	return exists, nil
+}
+
+// WorkspaceMembership returns the workspace userID belongs to, or
+// models.ErrNotFound when they're in none.
+func (s *Store) WorkspaceMembership(ctx context.Context, userID int64) (*models.WorkspaceMembership, error) {
+	var m models.WorkspaceMembership
+	err := s.db.QueryRowContext(ctx,
+		`SELECT w.id, w.name, wm.role FROM workspace_members wm
+		JOIN workspaces w ON w.id = wm.workspace_id
+		WHERE wm.user_id = ?`, userID).Scan(&m.WorkspaceID, &m.Name, &m.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("workspace membership for user %d: %w", userID, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting workspace membership: %w", err)
+	}
+	servers, err := s.workspaceServerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.ServerIDs = servers[m.WorkspaceID]
+	if m.ServerIDs == nil {
+		m.ServerIDs = []int64{}
+	}
+	return &m, nil
+}
+
+func (s *Store) workspaceServerIDs(ctx context.Context) (map[int64][]int64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT workspace_id, server_id FROM workspace_servers ORDER BY workspace_id, server_id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing workspace servers: %w", err)
+	}
+	defer rows.Close()
+	servers := make(map[int64][]int64)
+	for rows.Next() {
+		var wid, sid int64
+		if err := rows.Scan(&wid, &sid); err != nil {
+			return nil, fmt.Errorf("scanning workspace server: %w", err)
+		}
+		servers[wid] = append(servers[wid], sid)
+	}
+	return servers, rows.Err()
+}
+
+func (s *Store) workspaceMembers(ctx context.Context) (map[int64][]models.WorkspaceMember, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT wm.workspace_id, u.id, u.name, wm.role FROM workspace_members wm
+		JOIN users u ON u.id = wm.user_id
+		ORDER BY wm.workspace_id, u.name COLLATE NOCASE`)
+	if err != nil {
+		return nil, fmt.Errorf("listing workspace members: %w", err)
+	}
+	defer rows.Close()
+	members := make(map[int64][]models.WorkspaceMember)
+	for rows.Next() {
+		var wid int64
+		var m models.WorkspaceMember
+		if err := rows.Scan(&wid, &m.UserID, &m.Name, &m.Role); err != nil {
+			return nil, fmt.Errorf("scanning workspace member: %w", err)
+		}
+		members[wid] = append(members[wid], m)
+	}
+	return members, rows.Err()
+}
+
+// ListWorkspaceOverviews returns every workspace in name order with its
+// servers, members and play totals as of now. ActiveStreams is left for
+// the caller, which knows what's playing.
+func (s *Store) ListWorkspaceOverviews(ctx context.Context, now time.Time) ([]models.WorkspaceOverview, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT w.id, w.name, w.created_at, w.updated_at,
+			COUNT(h.id), COALESCE(SUM(h.watched_ms), 0),
+			COALESCE(SUM(CASE WHEN h.started_at >= ? THEN 1 ELSE 0 END), 0),
+			MAX(h.started_at)
+		FROM workspaces w
+		LEFT JOIN workspace_servers ws ON ws.workspace_id = w.id
+		LEFT JOIN watch_history h ON h.server_id = ws.server_id
+		GROUP BY w.id
+		ORDER BY w.name COLLATE NOCASE, w.id`,
+		now.UTC().AddDate(0, 0, -30))
+	if err != nil {
+		return nil, fmt.Errorf("listing workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	overviews := []models.WorkspaceOverview{}
+	for rows.Next() {
+		var o models.WorkspaceOverview
+		var lastPlayed sql.NullString
+		if err := rows.Scan(&o.ID, &o.Name, &o.CreatedAt, &o.UpdatedAt,
+			&o.TotalPlays, &o.TotalWatchedMs, &o.PlaysLast30Days, &lastPlayed); err != nil {
+			return nil, fmt.Errorf("scanning workspace: %w", err)
+		}
+		if lastPlayed.Valid {
+			t, err := parseSQLiteTime(lastPlayed.String)
+			if err != nil {
+				return nil, fmt.Errorf("parsing last played: %w", err)
+			}
+			o.LastPlayedAt = &t
+		}
+		overviews = append(overviews, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	servers, err := s.workspaceServerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.workspaceMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range overviews {
+		o := &overviews[i]
+		o.ServerIDs = servers[o.ID]
+		if o.ServerIDs == nil {
+			o.ServerIDs = []int64{}
+		}
+		o.Members = members[o.ID]
+		if o.Members == nil {
+			o.Members = []models.WorkspaceMember{}
+		}
+	}
+	return overviews, nil
+}
+
+// workspaceUsersSQL selects the names of the users a workspace can see:
+// those who played on, or are listed by, one of its servers, and its
+// members. It takes the workspace ID three times.
+const workspaceUsersSQL = `
+	SELECT user_name FROM watch_history
+	WHERE server_id IN (SELECT server_id FROM workspace_servers WHERE workspace_id = ?)
+	UNION
+	SELECT user_name FROM server_users
+	WHERE server_id IN (SELECT server_id FROM workspace_servers WHERE workspace_id = ?)
+	UNION
+	SELECT u.name FROM workspace_members wm JOIN users u ON u.id = wm.user_id
+	WHERE wm.workspace_id = ?`
+
+// WorkspaceUserNames returns the names of the users workspaceID can see.
+func (s *Store) WorkspaceUserNames(ctx context.Context, workspaceID int64) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, workspaceUsersSQL, workspaceID, workspaceID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing workspace users: %w", err)
+	}
+	defer rows.Close()
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning workspace user: %w", err)
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
+
+// WorkspaceHasUser reports whether workspaceID can see the user name.
+func (s *Store) WorkspaceHasUser(ctx context.Context, workspaceID int64, name string) (bool, error) {
+	var ok bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM watch_history
+			WHERE user_name = ? AND server_id IN (SELECT server_id FROM workspace_servers WHERE workspace_id = ?))
+		OR EXISTS(SELECT 1 FROM server_users
+			WHERE user_name = ? AND server_id IN (SELECT server_id FROM workspace_servers WHERE workspace_id = ?))
+		OR EXISTS(SELECT 1 FROM workspace_members wm JOIN users u ON u.id = wm.user_id
+			WHERE u.name = ? AND wm.workspace_id = ?)`,
+		name, workspaceID, name, workspaceID, name, workspaceID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("checking workspace user: %w", err)
+	}
+	return ok, nil
+}
+
+// ServerWorkspaces maps each server in a workspace to that workspace's ID.
+// Servers in no workspace are left out.
+func (s *Store) ServerWorkspaces() (map[int64]int64, error) {
+	rows, err := s.db.Query(`SELECT server_id, workspace_id FROM workspace_servers`)
+	if err != nil {
+		return nil, fmt.Errorf("listing workspace servers: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[int64]int64)
+	for rows.Next() {
+		var serverID, workspaceID int64
+		if err := rows.Scan(&serverID, &workspaceID); err != nil {
+			return nil, fmt.Errorf("scanning workspace server: %w", err)
+		}
+		out[serverID] = workspaceID
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestWorkspaces(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	serverA := seedServer(t, s)
+	serverB := seedServer(t, s)
+	now := time.Now().UTC()
+	if err := s.InsertHistory(makeHistoryEntry(serverA, "alice", "Alien", now.Add(-48*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertHistory(makeHistoryEntry(serverA, "alice", "Aliens", now.Add(-60*24*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertHistory(makeHistoryEntry(serverB, "bob", "Heat", now.Add(-time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.CreateLocalUser("carol", "carol@test.local", "", models.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	friends := &models.Workspace{Name: " Friends "}
+	if err := s.CreateWorkspace(friends); err != nil {
+		t.Fatal(err)
+	}
+	if friends.Name != "Friends" {
+		t.Errorf("name = %q", friends.Name)
+	}
+	if err := s.CreateWorkspace(&models.Workspace{Name: "FRIENDS"}); !errors.Is(err, ErrWorkspaceExists) {
+		t.Errorf("expected ErrWorkspaceExists, got %v", err)
+	}
+	family := &models.Workspace{Name: "Family"}
+	if err := s.CreateWorkspace(family); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.WorkspaceMembership(ctx, user.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected ErrNotFound before joining, got %v", err)
+	}
+	if err := s.SetWorkspaceServers(ctx, friends.ID, []int64{serverA, 9999}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("unknown server: expected ErrNotFound, got %v", err)
+	}
+	if err := s.SetWorkspaceServers(ctx, family.ID, []int64{serverA}); err != nil {
+		t.Fatal(err)
+	}
+	// Assigning a server to another workspace moves it.
+	if err := s.SetWorkspaceServers(ctx, friends.ID, []int64{serverA}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetWorkspaceMember(ctx, friends.ID, user.ID, models.WorkspaceRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := s.WorkspaceMembership(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.WorkspaceID != friends.ID || m.Role != models.WorkspaceRoleAdmin || len(m.ServerIDs) != 1 || m.ServerIDs[0] != serverA {
+		t.Errorf("membership = %+v", m)
+	}
+
+	overviews, err := s.ListWorkspaceOverviews(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overviews) != 2 || overviews[0].Name != "Family" || overviews[1].Name != "Friends" {
+		t.Fatalf("overviews = %+v", overviews)
+	}
+	if o := overviews[0]; o.TotalPlays != 0 || len(o.ServerIDs) != 0 || len(o.Members) != 0 || o.LastPlayedAt != nil {
+		t.Errorf("empty workspace overview = %+v", o)
+	}
+	o := overviews[1]
+	if o.TotalPlays != 2 || o.PlaysLast30Days != 1 || len(o.Members) != 1 || o.Members[0].Name != "carol" || o.LastPlayedAt == nil {
+		t.Errorf("friends overview = %+v", o)
+	}
+
+	if err := s.RemoveWorkspaceMember(ctx, family.ID, user.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("removing from the wrong workspace: expected ErrNotFound, got %v", err)
+	}
+	if err := s.DeleteWorkspace(friends.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.WorkspaceMembership(ctx, user.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected membership gone with its workspace, got %v", err)
+	}
+}
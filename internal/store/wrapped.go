@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// wrappedTopLimit is how many shows and movies a recap lists.
+const wrappedTopLimit = 5
+
+// wrappedBingeGap is the longest break between two episodes that still
+// counts as one binge.
+const wrappedBingeGap = 30 * time.Minute
+
+// WrappedYearRange is year's first instant and the next year's, in the zone
+// tzOffsetMinutes east of UTC.
+func WrappedYearRange(year, tzOffsetMinutes int) (start, end time.Time) {
+	loc := time.FixedZone("", tzOffsetMinutes*60)
+	start = time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	return start.UTC(), start.AddDate(1, 0, 0).UTC()
+}
+
+// UserWrapped builds scope's recap of year, with days split at midnight
+// tzOffsetMinutes east of UTC. Plays by server owners count, since the recap
+// is the user's own; the usual minimum play length and media type
+// exclusions apply.
+func (s *Store) UserWrapped(ctx context.Context, scope UserScope, year, tzOffsetMinutes int) (*models.Wrapped, error) {
+	start, end := WrappedYearRange(year, tzOffsetMinutes)
+	filter := StatsFilter{StartDate: start, EndDate: end, TZOffsetMinutes: tzOffsetMinutes, IncludeOwnerPlays: true}
+	userCond, userArgs := scope.condition("h")
+	filterCond, filterArgs := filter.andConditionsWith("h")
+	where := ` WHERE ` + userCond + filterCond
+	whereArgs := append(userArgs, filterArgs...)
+
+	dayExpr := "date(h.started_at)"
+	var dayArgs []any
+	if mod, ok := tzModifier(tzOffsetMinutes); ok {
+		dayExpr = "date(h.started_at, ?)"
+		dayArgs = []any{mod}
+	}
+
+	w := &models.Wrapped{
+		UserName:  scope.UserName,
+		Year:      year,
+		TopShows:  []models.MediaStat{},
+		TopMovies: []models.MediaStat{},
+		Locations: []models.WrappedLocation{},
+	}
+
+	var watchedMs int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(h.watched_ms), 0), COUNT(DISTINCT `+dayExpr+`)
+		FROM watch_history h`+where,
+		append(append([]any{}, dayArgs...), whereArgs...)...,
+	).Scan(&w.Plays, &watchedMs, &w.DaysWatched)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped totals: %w", err)
+	}
+	w.TotalHours = msToHours(watchedMs)
+	if w.Plays == 0 {
+		return w, nil
+	}
+
+	if w.TopShows, err = s.wrappedTop(ctx, "h.grandparent_title", "", models.MediaTypeTV, where, whereArgs); err != nil {
+		return nil, fmt.Errorf("wrapped top shows: %w", err)
+	}
+	if w.TopMovies, err = s.wrappedTop(ctx, "h.title", "h.year", models.MediaTypeMovie, where, whereArgs); err != nil {
+		return nil, fmt.Errorf("wrapped top movies: %w", err)
+	}
+
+	var day models.WrappedDay
+	err = s.db.QueryRowContext(ctx,
+		`SELECT `+dayExpr+` AS day, COUNT(*), COALESCE(SUM(h.watched_ms), 0) AS ms
+		FROM watch_history h`+where+`
+		GROUP BY day ORDER BY ms DESC, day LIMIT 1`,
+		append(append([]any{}, dayArgs...), whereArgs...)...,
+	).Scan(&day.Date, &day.Plays, &watchedMs)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped busiest day: %w", err)
+	}
+	day.TotalHours = msToHours(watchedMs)
+	w.BusiestDay = &day
+
+	var dev models.DeviceStat
+	var lastSeen sql.NullString
+	err = s.db.QueryRowContext(ctx,
+		`SELECT h.player, h.platform, COUNT(*), MAX(h.stopped_at)
+		FROM watch_history h`+where+`
+		GROUP BY h.player, h.platform ORDER BY SUM(h.watched_ms) DESC, COUNT(*) DESC LIMIT 1`,
+		whereArgs...,
+	).Scan(&dev.Player, &dev.Platform, &dev.SessionCount, &lastSeen)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped top device: %w", err)
+	}
+	dev.LastSeen = formatLastSeen(lastSeen)
+	dev.Percentage = calcPercentage(dev.SessionCount, w.Plays)
+	w.TopDevice = &dev
+
+	if w.LongestBinge, err = s.wrappedLongestBinge(ctx, where, whereArgs); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT g.city, g.country, g.lat, g.lng, COUNT(*) AS plays
+		FROM watch_history h
+		JOIN ip_geo_cache g ON h.ip_address = g.ip`+where+` AND h.ip_address != ''
+		GROUP BY g.city, g.country, g.lat, g.lng
+		ORDER BY plays DESC, g.country, g.city`,
+		whereArgs...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped locations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var loc models.WrappedLocation
+		if err := rows.Scan(&loc.City, &loc.Country, &loc.Lat, &loc.Lng, &loc.Plays); err != nil {
+			return nil, fmt.Errorf("scanning wrapped location: %w", err)
+		}
+		w.Locations = append(w.Locations, loc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating wrapped locations: %w", err)
+	}
+	return w, nil
+}
+
+func msToHours(ms int64) float64 {
+	return float64(ms) / 3600000.0
+}
+
+// wrappedTop ranks titles of one media type by time watched, grouped by
+// titleCol and yearCol when it's set.
+func (s *Store) wrappedTop(ctx context.Context, titleCol, yearCol string, mediaType models.MediaType, where string, args []any) ([]models.MediaStat, error) {
+	cols, groupBy := titleCol+", 0", titleCol
+	if yearCol != "" {
+		cols, groupBy = titleCol+", "+yearCol, titleCol+", "+yearCol
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+cols+`, COUNT(*), COALESCE(SUM(h.watched_ms), 0) AS ms
+		FROM watch_history h`+where+` AND h.media_type = ? AND `+titleCol+` != ''
+		GROUP BY `+groupBy+`
+		ORDER BY ms DESC, COUNT(*) DESC
+		LIMIT ?`,
+		append(append([]any{}, args...), mediaType, wrappedTopLimit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stats := []models.MediaStat{}
+	for rows.Next() {
+		var m models.MediaStat
+		var ms int64
+		if err := rows.Scan(&m.Title, &m.Year, &m.PlayCount, &ms); err != nil {
+			return nil, err
+		}
+		m.TotalHours = msToHours(ms)
+		stats = append(stats, m)
+	}
+	return stats, rows.Err()
+}
+
+// wrappedLongestBinge finds the longest run of episodes of one show, each
+// started within wrappedBingeGap of the previous one stopping. Ties go to
+// the run with more time watched. Nil when no show had two in a row.
+func (s *Store) wrappedLongestBinge(ctx context.Context, where string, args []any) (*models.WrappedBinge, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT h.grandparent_title, h.started_at, h.stopped_at, h.watched_ms
+		FROM watch_history h`+where+` AND h.media_type = ? AND h.grandparent_title != ''
+		ORDER BY h.started_at, h.id`,
+		append(append([]any{}, args...), models.MediaTypeTV)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped binges: %w", err)
+	}
+	defer rows.Close()
+
+	var best, cur *models.WrappedBinge
+	var curMs, bestMs int64
+	for rows.Next() {
+		var show string
+		var started, stopped time.Time
+		var ms int64
+		if err := rows.Scan(&show, &started, &stopped, &ms); err != nil {
+			return nil, fmt.Errorf("scanning wrapped binge: %w", err)
+		}
+		if cur != nil && cur.Show == show && started.Sub(cur.EndedAt) <= wrappedBingeGap {
+			cur.Episodes++
+			curMs += ms
+			if stopped.After(cur.EndedAt) {
+				cur.EndedAt = stopped
+			}
+		} else {
+			cur = &models.WrappedBinge{Show: show, Episodes: 1, StartedAt: started, EndedAt: stopped}
+			curMs = ms
+		}
+		if cur.Episodes >= 2 && (best == nil || cur.Episodes > best.Episodes ||
+			(cur.Episodes == best.Episodes && curMs > bestMs)) {
+			b := *cur
+			best, bestMs = &b, curMs
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating wrapped binges: %w", err)
+	}
+	if best != nil {
+		best.TotalHours = msToHours(bestMs)
+	}
+	return best, nil
+}
+
+// IsWrappedOptedOut reports whether userName keeps their recap private.
+func (s *Store) IsWrappedOptedOut(ctx context.Context, userName string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM wrapped_opt_outs WHERE user_name = ?`, userName).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking wrapped opt-out: %w", err)
+	}
+	return true, nil
+}
+
+// SetWrappedOptOut records whether userName keeps their recap private.
+func (s *Store) SetWrappedOptOut(ctx context.Context, userName string, optOut bool) error {
+	var err error
+	if optOut {
+		_, err = s.db.ExecContext(ctx, `INSERT OR IGNORE INTO wrapped_opt_outs (user_name) VALUES (?)`, userName)
+	} else {
+		_, err = s.db.ExecContext(ctx, `DELETE FROM wrapped_opt_outs WHERE user_name = ?`, userName)
+	}
+	if err != nil {
+		return fmt.Errorf("setting wrapped opt-out: %w", err)
+	}
+	return nil
+}
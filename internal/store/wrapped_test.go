@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func TestUserWrapped(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+	serverID := seedServer(t, s)
+
+	if err := s.SetCachedGeo(&models.GeoResult{IP: "1.2.3.4", City: "Oslo", Country: "NO", Lat: 59.9, Lng: 10.7}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC)
+	episode := func(start time.Time, ep int) *models.WatchHistoryEntry {
+		e := makeHistoryEntry(serverID, "alice", fmt.Sprintf("Episode %d", ep), start)
+		e.MediaType = models.MediaTypeTV
+		e.GrandparentTitle = "The Show"
+		e.EpisodeNumber = ep
+		e.StoppedAt = start.Add(45 * time.Minute)
+		e.WatchedMs = (45 * time.Minute).Milliseconds()
+		e.Player = "Living Room TV"
+		e.IPAddress = "1.2.3.4"
+		return e
+	}
+	movie := makeHistoryEntry(serverID, "alice", "Heat", time.Date(2025, 7, 1, 18, 0, 0, 0, time.UTC))
+	movie.Year = 1995
+	movie.WatchedMs = (2 * time.Hour).Milliseconds()
+	movie.Player = "Phone"
+
+	entries := []*models.WatchHistoryEntry{
+		// Three back to back, then one after a long break.
+		episode(day, 1),
+		episode(day.Add(50*time.Minute), 2),
+		episode(day.Add(100*time.Minute), 3),
+		episode(day.Add(24*time.Hour), 4),
+		movie,
+		// Outside the year, and someone else.
+		episode(time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC), 9),
+		makeHistoryEntry(serverID, "bob", "Heat", day),
+	}
+	for _, e := range entries {
+		if err := s.InsertHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := s.UserWrapped(ctx, NameScope("alice"), 2025, 0)
+	if err != nil {
+		t.Fatalf("UserWrapped: %v", err)
+	}
+	if w.Plays != 5 || w.DaysWatched != 3 || w.TotalHours != 5 {
+		t.Errorf("totals = %d plays, %d days, %.2f h", w.Plays, w.DaysWatched, w.TotalHours)
+	}
+	if len(w.TopShows) != 1 || w.TopShows[0].Title != "The Show" || w.TopShows[0].PlayCount != 4 {
+		t.Errorf("top shows = %+v", w.TopShows)
+	}
+	if len(w.TopMovies) != 1 || w.TopMovies[0].Title != "Heat" || w.TopMovies[0].Year != 1995 {
+		t.Errorf("top movies = %+v", w.TopMovies)
+	}
+	if w.BusiestDay == nil || w.BusiestDay.Date != "2025-03-14" || w.BusiestDay.Plays != 3 {
+		t.Errorf("busiest day = %+v", w.BusiestDay)
+	}
+	if b := w.LongestBinge; b == nil || b.Show != "The Show" || b.Episodes != 3 || b.TotalHours != 2.25 {
+		t.Errorf("longest binge = %+v", b)
+	}
+	if w.TopDevice == nil || w.TopDevice.Player != "Living Room TV" {
+		t.Errorf("top device = %+v", w.TopDevice)
+	}
+	if len(w.Locations) != 1 || w.Locations[0].City != "Oslo" || w.Locations[0].Plays != 4 {
+		t.Errorf("locations = %+v", w.Locations)
+	}
+
+	// Shifted a day east, the New Year's Eve episode falls into 2025.
+	w, err = s.UserWrapped(ctx, NameScope("alice"), 2025, 14*60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Plays != 6 {
+		t.Errorf("plays with +14:00 = %d, want 6", w.Plays)
+	}
+
+	empty, err := s.UserWrapped(ctx, NameScope("alice"), 2023, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Plays != 0 || empty.BusiestDay != nil || empty.TopShows == nil || empty.Locations == nil {
+		t.Errorf("empty year = %+v", empty)
+	}
+}
+
+func TestWrappedOptOut(t *testing.T) {
+	s := newTestStoreWithMigrations(t)
+	ctx := context.Background()
+
+	for _, step := range []bool{true, true, false, false} {
+		if err := s.SetWrappedOptOut(ctx, "alice", step); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.IsWrappedOptedOut(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step {
+			t.Errorf("opted out = %v, want %v", got, step)
+		}
+	}
+}
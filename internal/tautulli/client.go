@@ -89,6 +89,7 @@ type HistoryRecord struct {
 	Stopped              int64      `json:"stopped"`
 	Duration             int64      `json:"duration"`
 	PlayDuration         int64      `json:"play_duration"`
+	PausedCounter        FlexInt    `json:"paused_counter"`
 	Player               string     `json:"player"`
 	Platform             string     `json:"platform"`
 	IPAddress            string     `json:"ip_address"`
@@ -154,6 +155,11 @@ func NewClient(baseURL, apiKey string) (*Client, error) {
 	}, nil
 }
 
+// Configure applies per-integration proxy, TLS, and timeout settings.
+func (c *Client) Configure(opts httputil.ClientOptions) error {
+	return opts.Apply(c.http)
+}
+
 func ValidateURL(rawURL string) error {
 	return httputil.ValidateIntegrationURL(rawURL)
 }
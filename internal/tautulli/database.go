@@ -0,0 +1,215 @@
+package tautulli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotTautulliDatabase is returned when the file isn't SQLite or has no
+// session_history table.
+var ErrNotTautulliDatabase = errors.New("not a Tautulli database")
+
+// DBRecord is one session_history row read straight from tautulli.db. The
+// embedded HistoryRecord mirrors what get_history returns; Stream carries the
+// media info the API only exposes per row through get_stream_data.
+type DBRecord struct {
+	HistoryRecord
+	Stream StreamData
+}
+
+// DBBatchHandler receives each batch of records along with the total row
+// count, matching the API's StreamHistory paging.
+type DBBatchHandler func(records []DBRecord, total int) error
+
+// ReadDatabase streams every session_history row from the tautulli.db at
+// path, oldest first. The file is opened read-only. Columns that older
+// Tautulli schemas lack read as zero values rather than failing the import.
+func ReadDatabase(ctx context.Context, path string, batchSize int, handler DBBatchHandler) error {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?mode=ro&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("opening tautulli database: %w", err)
+	}
+	defer db.Close()
+
+	cols := map[string]map[string]bool{}
+	for _, table := range []string{"session_history", "session_history_metadata", "session_history_media_info"} {
+		c, err := tableColumns(ctx, db, table)
+		if err != nil {
+			// SQLite only reads the header on first query, so a file that
+			// isn't a database at all surfaces here.
+			return fmt.Errorf("%w: %v", ErrNotTautulliDatabase, err)
+		}
+		cols[table] = c
+	}
+	if len(cols["session_history"]) == 0 {
+		return ErrNotTautulliDatabase
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM session_history`).Scan(&total); err != nil {
+		return fmt.Errorf("counting tautulli history: %w", err)
+	}
+
+	text := func(table, alias, col string) string {
+		if !cols[table][col] {
+			return `''`
+		}
+		return fmt.Sprintf(`COALESCE(CAST(%s.%s AS TEXT), '')`, alias, col)
+	}
+	num := func(table, alias, col string) string {
+		if !cols[table][col] {
+			return `0`
+		}
+		return fmt.Sprintf(`COALESCE(CAST(%s.%s AS INTEGER), 0)`, alias, col)
+	}
+	const (
+		sh   = "session_history"
+		shm  = "session_history_metadata"
+		shmi = "session_history_media_info"
+	)
+	selects := []string{
+		"sh.id",
+		num(sh, "sh", "reference_id"),
+		num(sh, "sh", "started"),
+		num(sh, "sh", "stopped"),
+		num(sh, "sh", "paused_counter"),
+		text(sh, "sh", "rating_key"),
+		text(sh, "sh", "grandparent_rating_key"),
+		text(sh, "sh", "user"),
+		text(sh, "sh", "ip_address"),
+		text(sh, "sh", "player"),
+		text(sh, "sh", "platform"),
+		text(sh, "sh", "media_type"),
+		num(sh, "sh", "bandwidth"),
+		text(shm, "shm", "title"),
+		text(shm, "shm", "parent_title"),
+		text(shm, "shm", "grandparent_title"),
+		num(shm, "shm", "year"),
+		num(shm, "shm", "media_index"),
+		num(shm, "shm", "parent_media_index"),
+		text(shm, "shm", "thumb"),
+		num(shm, "shm", "duration"),
+		text(shmi, "shmi", "transcode_decision"),
+		text(shmi, "shmi", "video_decision"),
+		text(shmi, "shmi", "audio_decision"),
+		text(shmi, "shmi", "video_codec"),
+		text(shmi, "shmi", "audio_codec"),
+		num(shmi, "shmi", "audio_channels"),
+		text(shmi, "shmi", "video_full_resolution"),
+		num(shmi, "shmi", "video_height"),
+		text(shmi, "shmi", "video_dynamic_range"),
+		num(shmi, "shmi", "transcode_hw_decoding"),
+		num(shmi, "shmi", "transcode_hw_encoding"),
+	}
+	query := `SELECT ` + strings.Join(selects, ", ") + `
+		FROM session_history sh`
+	if len(cols[shm]) > 0 {
+		query += ` LEFT JOIN session_history_metadata shm ON shm.id = sh.id`
+	}
+	if len(cols[shmi]) > 0 {
+		query += ` LEFT JOIN session_history_media_info shmi ON shmi.id = sh.id`
+	}
+	query += ` WHERE sh.id > ? ORDER BY sh.id LIMIT ?`
+
+	var lastID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		records, last, err := readDBBatch(ctx, db, query, lastID, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := handler(records, total); err != nil {
+			return err
+		}
+		if len(records) < batchSize {
+			return nil
+		}
+		lastID = last
+	}
+}
+
+func readDBBatch(ctx context.Context, db *sql.DB, query string, afterID int64, limit int) ([]DBRecord, int64, error) {
+	rows, err := db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("querying tautulli history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []DBRecord
+	var lastID int64
+	for rows.Next() {
+		var rec DBRecord
+		var referenceID, paused, year, mediaIndex, parentMediaIndex, durationMs int64
+		var ratingKey, grandparentRatingKey string
+		var audioChannels, videoHeight, hwDecode, hwEncode int
+		if err := rows.Scan(
+			&lastID, &referenceID, &rec.Started, &rec.Stopped, &paused,
+			&ratingKey, &grandparentRatingKey, &rec.User, &rec.IPAddress,
+			&rec.Player, &rec.Platform, &rec.MediaType, &rec.Stream.Bandwidth,
+			&rec.Title, &rec.ParentTitle, &rec.GrandparentTitle, &year,
+			&mediaIndex, &parentMediaIndex, &rec.Thumb, &durationMs,
+			&rec.Stream.TranscodeDecision, &rec.Stream.VideoDecision, &rec.Stream.AudioDecision,
+			&rec.Stream.VideoCodec, &rec.Stream.AudioCodec, &audioChannels,
+			&rec.VideoFullResolution, &videoHeight, &rec.Stream.VideoDynamicRange,
+			&hwDecode, &hwEncode,
+		); err != nil {
+			return nil, 0, fmt.Errorf("scanning tautulli history: %w", err)
+		}
+		rec.ReferenceID = FlexInt(referenceID)
+		rec.RatingKey = FlexString(ratingKey)
+		rec.GrandparentRatingKey = FlexString(grandparentRatingKey)
+		rec.Year = FlexInt(year)
+		rec.MediaIndex = FlexInt(mediaIndex)
+		rec.ParentMediaIndex = FlexInt(parentMediaIndex)
+		rec.PausedCounter = FlexInt(paused)
+		rec.TranscodeDecision = rec.Stream.TranscodeDecision
+		// Tautulli stores metadata duration in milliseconds, while get_history
+		// reports seconds; normalise to the API's units.
+		rec.Duration = durationMs / 1000
+		if rec.Stopped > rec.Started {
+			rec.PlayDuration = max(rec.Stopped-rec.Started-paused, 0)
+		}
+		rec.Stream.AudioChannels = audioChannels
+		rec.Stream.VideoHeight = videoHeight
+		rec.Stream.TranscodeHWDecode = hwDecode == 1
+		rec.Stream.TranscodeHWEncode = hwEncode == 1
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterating tautulli history: %w", err)
+	}
+	return records, lastID, nil
+}
+
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
@@ -0,0 +1,128 @@
+package tautulli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createTestDatabase(t *testing.T, stmts ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tautulli.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return path
+}
+
+func TestReadDatabase(t *testing.T) {
+	path := createTestDatabase(t,
+		`CREATE TABLE session_history (id INTEGER PRIMARY KEY, reference_id INTEGER, started INTEGER, stopped INTEGER,
+			rating_key INTEGER, grandparent_rating_key INTEGER, user TEXT, ip_address TEXT, paused_counter INTEGER,
+			player TEXT, platform TEXT, media_type TEXT, bandwidth INTEGER)`,
+		`CREATE TABLE session_history_metadata (id INTEGER PRIMARY KEY, title TEXT, parent_title TEXT,
+			grandparent_title TEXT, year INTEGER, media_index INTEGER, parent_media_index INTEGER, thumb TEXT, duration INTEGER)`,
+		`CREATE TABLE session_history_media_info (id INTEGER PRIMARY KEY, transcode_decision TEXT, video_decision TEXT,
+			audio_decision TEXT, video_codec TEXT, audio_codec TEXT, audio_channels TEXT, video_height INTEGER,
+			transcode_hw_decoding INTEGER, transcode_hw_encoding INTEGER)`,
+		`INSERT INTO session_history VALUES
+			(1, 1, 1700000000, 1700003600, 100, NULL, 'alice', '10.0.0.1', 600, 'Living Room', 'Roku', 'movie', 8000),
+			(2, 2, 1700010000, 1700011000, 201, 200, 'bob', '10.0.0.2', 0, 'iPhone', 'iOS', 'episode', 4000),
+			(3, 3, 1700020000, 1700021000, 300, NULL, 'carol', '10.0.0.3', 0, 'Web', 'Chrome', 'movie', 0)`,
+		`INSERT INTO session_history_metadata VALUES
+			(1, 'The Movie', '', '', 2021, 0, 0, '/thumb/100', 7200000),
+			(2, 'Pilot', 'Season 1', 'The Show', 2019, 1, 1, '/thumb/201', 3000000)`,
+		`INSERT INTO session_history_media_info VALUES
+			(1, 'transcode', 'transcode', 'copy', 'h264', 'aac', '6', 1080, 1, 0),
+			(2, 'direct play', 'direct play', 'direct play', 'hevc', 'eac3', '2', 2160, 0, 0)`,
+	)
+
+	var got []DBRecord
+	var batches, total int
+	err := ReadDatabase(context.Background(), path, 2, func(records []DBRecord, n int) error {
+		batches++
+		total = n
+		got = append(got, records...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadDatabase: %v", err)
+	}
+	if batches != 2 || total != 3 || len(got) != 3 {
+		t.Fatalf("batches=%d total=%d records=%d, want 2/3/3", batches, total, len(got))
+	}
+
+	movie := got[0]
+	if movie.User != "alice" || movie.Title != "The Movie" || string(movie.RatingKey) != "100" {
+		t.Errorf("unexpected movie record: %+v", movie.HistoryRecord)
+	}
+	if movie.PausedCounter != 600 || movie.PlayDuration != 3000 {
+		t.Errorf("paused=%d play=%d, want 600/3000", movie.PausedCounter, movie.PlayDuration)
+	}
+	if movie.Duration != 7200 {
+		t.Errorf("Duration = %d, want 7200 seconds", movie.Duration)
+	}
+	if movie.Stream.TranscodeDecision != "transcode" || movie.Stream.AudioChannels != 6 || !movie.Stream.TranscodeHWDecode {
+		t.Errorf("unexpected stream data: %+v", movie.Stream)
+	}
+	if movie.Stream.Bandwidth != 8000 || movie.IPAddress != "10.0.0.1" {
+		t.Errorf("bandwidth=%d ip=%q", movie.Stream.Bandwidth, movie.IPAddress)
+	}
+
+	episode := got[1]
+	if episode.GrandparentTitle != "The Show" || string(episode.GrandparentRatingKey) != "200" || episode.MediaIndex != 1 {
+		t.Errorf("unexpected episode record: %+v", episode.HistoryRecord)
+	}
+
+	// Rows without metadata or media info still import with zero values.
+	if got[2].User != "carol" || got[2].Title != "" || got[2].Stream.VideoCodec != "" {
+		t.Errorf("unexpected bare record: %+v", got[2])
+	}
+}
+
+func TestReadDatabase_MissingOptionalTables(t *testing.T) {
+	path := createTestDatabase(t,
+		`CREATE TABLE session_history (id INTEGER PRIMARY KEY, started INTEGER, stopped INTEGER, user TEXT)`,
+		`INSERT INTO session_history VALUES (1, 1700000000, 1700000600, 'alice')`,
+	)
+
+	var got []DBRecord
+	err := ReadDatabase(context.Background(), path, 0, func(records []DBRecord, _ int) error {
+		got = append(got, records...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadDatabase: %v", err)
+	}
+	if len(got) != 1 || got[0].User != "alice" || got[0].PlayDuration != 600 {
+		t.Fatalf("unexpected records: %+v", got)
+	}
+}
+
+func TestReadDatabase_NotTautulli(t *testing.T) {
+	other := createTestDatabase(t, `CREATE TABLE something (id INTEGER)`)
+
+	garbage := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(garbage, []byte("this is not sqlite, just some text padding it out"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{"other schema": other, "not sqlite": garbage} {
+		t.Run(name, func(t *testing.T) {
+			err := ReadDatabase(context.Background(), path, 0, func([]DBRecord, int) error { return nil })
+			if !errors.Is(err, ErrNotTautulliDatabase) {
+				t.Fatalf("err = %v, want ErrNotTautulliDatabase", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Package webhooks sends outbound webhooks: JSON deliveries POSTed to
+// user-configured URLs and signed with each webhook's shared secret.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"streammon/internal/httputil"
+	"streammon/internal/models"
+)
+
+// Deliveries carry a Unix timestamp, a unique nonce, and "sha256=" followed
+// by the hex HMAC-SHA256 of "<timestamp>.<nonce>.<body>" keyed with the
+// webhook's secret, the same scheme StreamMon checks on inbound webhooks.
+const (
+	TimestampHeader = "X-StreamMon-Timestamp"
+	NonceHeader     = "X-StreamMon-Nonce"
+	SignatureHeader = "X-StreamMon-Signature"
+	EventHeader     = "X-StreamMon-Event"
+)
+
+// sendTimeout bounds one delivery, including reading the response.
+const sendTimeout = 15 * time.Second
+
+// Sign returns the signature header value for a delivery.
+func Sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Store is what the dispatcher needs from storage.
+type Store interface {
+	ListWebhooksForEvent(ctx context.Context, event models.WebhookEvent) ([]models.OutboundWebhook, error)
+	RecordWebhookDelivery(ctx context.Context, id int64, status int, errMsg string) error
+	GetMaintenanceCandidateWebhookThreshold() (int, error)
+}
+
+// Dispatcher fans events out to the webhooks subscribed to them.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	now    func() time.Time
+	wg     sync.WaitGroup
+}
+
+type Option func(*Dispatcher)
+
+// WithClient replaces the default client, which refuses loopback and
+// link-local addresses.
+func WithClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+func New(s Store, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		store:  s,
+		client: httputil.NewSafeClient(httputil.IntegrationTimeout),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Dispatch sends event to every enabled webhook subscribed to it, in the
+// background. Failures are recorded on the webhook and not retried.
+func (d *Dispatcher) Dispatch(event models.WebhookEvent, data any) {
+	occurred := d.now().UTC()
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ctx := context.Background()
+		hooks, err := d.store.ListWebhooksForEvent(ctx, event)
+		if err != nil {
+			log.Printf("webhooks: listing webhooks for %s: %v", event, err)
+			return
+		}
+		for i := range hooks {
+			if err := d.send(ctx, &hooks[i], event, occurred, data); err != nil {
+				log.Printf("webhooks: delivering %s to %q: %v", event, hooks[i].Name, err)
+			}
+		}
+	}()
+}
+
+// Send delivers event to hook now and records the outcome on it.
+func (d *Dispatcher) Send(ctx context.Context, hook *models.OutboundWebhook, event models.WebhookEvent, data any) error {
+	return d.send(ctx, hook, event, d.now().UTC(), data)
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook *models.OutboundWebhook, event models.WebhookEvent, occurred time.Time, data any) error {
+	status, err := d.post(ctx, hook, event, occurred, data)
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if rerr := d.store.RecordWebhookDelivery(context.WithoutCancel(ctx), hook.ID, status, errMsg); rerr != nil {
+		log.Printf("webhooks: %v", rerr)
+	}
+	return err
+}
+
+func (d *Dispatcher) post(ctx context.Context, hook *models.OutboundWebhook, event models.WebhookEvent, occurred time.Time, data any) (int, error) {
+	id, err := newDeliveryID()
+	if err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(models.WebhookDelivery{ID: id, Event: event, OccurredAt: occurred, Data: data})
+	if err != nil {
+		return 0, fmt.Errorf("encoding delivery: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(NonceHeader, id)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, id, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func newDeliveryID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating delivery id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Wait blocks until background deliveries finish. Call it during shutdown.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// SessionStarted sends session.started for s.
+func (d *Dispatcher) SessionStarted(s models.ActiveStream) {
+	d.Dispatch(models.WebhookEventSessionStarted, s)
+}
+
+// SessionEnded sends session.stopped for s.
+func (d *Dispatcher) SessionEnded(s models.ActiveStream) {
+	d.Dispatch(models.WebhookEventSessionStopped, s)
+}
+
+// MaintenanceEvaluated sends maintenance.evaluated for rule's latest
+// evaluation, and maintenance.threshold_exceeded too when it found at least
+// the configured number of candidates.
+func (d *Dispatcher) MaintenanceEvaluated(rule *models.MaintenanceRule, candidates int) {
+	data := models.MaintenanceEvaluatedWebhook{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		Criterion:  rule.CriterionType,
+		MediaType:  rule.MediaType,
+		Candidates: candidates,
+	}
+	d.Dispatch(models.WebhookEventMaintenanceEvaluated, data)
+
+	threshold, err := d.store.GetMaintenanceCandidateWebhookThreshold()
+	if err != nil {
+		log.Printf("webhooks: candidate threshold: %v", err)
+		return
+	}
+	if threshold > 0 && candidates >= threshold {
+		data.Threshold = threshold
+		d.Dispatch(models.WebhookEventMaintenanceThreshold, data)
+	}
+}
+
+// BulkDeleteStarted sends maintenance.bulk_delete_started for a delete of
+// requested candidates.
+func (d *Dispatcher) BulkDeleteStarted(requested int, deletedBy string) {
+	d.Dispatch(models.WebhookEventMaintenanceBulkStarted, models.MaintenanceBulkDeleteWebhook{
+		Requested: requested,
+		DeletedBy: deletedBy,
+	})
+}
+
+// BulkDeleteFinished sends maintenance.bulk_delete_finished with the
+// outcome of a bulk delete, including one cut short.
+func (d *Dispatcher) BulkDeleteFinished(requested int, deletedBy string, result models.BulkDeleteResult) {
+	d.Dispatch(models.WebhookEventMaintenanceBulkFinished, models.MaintenanceBulkDeleteWebhook{
+		Requested:  requested,
+		Deleted:    result.Deleted,
+		Failed:     result.Failed,
+		Skipped:    result.Skipped,
+		BytesFreed: result.TotalSize,
+		DeletedBy:  deletedBy,
+	})
+}
@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"streammon/internal/models"
+)
+
+type fakeStore struct {
+	mu    sync.Mutex
+	hooks []models.OutboundWebhook
+	// statuses records each delivery's outcome by webhook ID.
+	statuses  map[int64]int
+	errs      map[int64]string
+	threshold int
+}
+
+func (f *fakeStore) ListWebhooksForEvent(_ context.Context, event models.WebhookEvent) ([]models.OutboundWebhook, error) {
+	var out []models.OutboundWebhook
+	for _, h := range f.hooks {
+		for _, e := range h.Events {
+			if e == event {
+				out = append(out, h)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) RecordWebhookDelivery(_ context.Context, id int64, status int, errMsg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[id] = status
+	f.errs[id] = errMsg
+	return nil
+}
+
+func (f *fakeStore) GetMaintenanceCandidateWebhookThreshold() (int, error) {
+	return f.threshold, nil
+}
+
+func TestDispatch_SignsDeliveries(t *testing.T) {
+	var mu sync.Mutex
+	var got []*http.Request
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	st := &fakeStore{
+		hooks: []models.OutboundWebhook{
+			{ID: 1, Name: "ok", URL: srv.URL + "/ok", Secret: "s1", Events: []models.WebhookEvent{models.WebhookEventSessionStarted}},
+			{ID: 2, Name: "fail", URL: srv.URL + "/fail", Secret: "s2", Events: []models.WebhookEvent{models.WebhookEventSessionStarted}},
+			{ID: 3, Name: "other", URL: srv.URL + "/other", Secret: "s3", Events: []models.WebhookEvent{models.WebhookEventRuleTriggered}},
+		},
+		statuses: make(map[int64]int),
+		errs:     make(map[int64]string),
+	}
+	d := New(st, WithClient(srv.Client()))
+	d.SessionStarted(models.ActiveStream{UserName: "alice", Title: "Movie"})
+	d.Wait()
+
+	if len(got) != 2 {
+		t.Fatalf("got %d deliveries, want 2", len(got))
+	}
+	for i, r := range got {
+		secret := map[string]string{"/ok": "s1", "/fail": "s2"}[r.URL.Path]
+		want := Sign(secret, r.Header.Get(TimestampHeader), r.Header.Get(NonceHeader), bodies[i])
+		if r.Header.Get(SignatureHeader) != want {
+			t.Errorf("%s: signature %q, want %q", r.URL.Path, r.Header.Get(SignatureHeader), want)
+		}
+		if r.Header.Get(EventHeader) != string(models.WebhookEventSessionStarted) {
+			t.Errorf("%s: event header %q", r.URL.Path, r.Header.Get(EventHeader))
+		}
+		var delivery struct {
+			ID    string              `json:"id"`
+			Event models.WebhookEvent `json:"event"`
+			Data  models.ActiveStream `json:"data"`
+		}
+		if err := json.Unmarshal(bodies[i], &delivery); err != nil {
+			t.Fatal(err)
+		}
+		if delivery.ID != r.Header.Get(NonceHeader) || delivery.Data.UserName != "alice" {
+			t.Errorf("%s: body = %s", r.URL.Path, bodies[i])
+		}
+	}
+	if st.statuses[1] != http.StatusOK || st.errs[1] != "" {
+		t.Errorf("ok webhook recorded %d %q", st.statuses[1], st.errs[1])
+	}
+	if st.statuses[2] != http.StatusInternalServerError || st.errs[2] == "" {
+		t.Errorf("failing webhook recorded %d %q", st.statuses[2], st.errs[2])
+	}
+	if _, ok := st.statuses[3]; ok {
+		t.Error("webhook not subscribed to session.started was sent it")
+	}
+}
+
+func TestMaintenanceEvaluated_Threshold(t *testing.T) {
+	var mu sync.Mutex
+	events := map[string][]models.MaintenanceEvaluatedWebhook{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var delivery struct {
+			Data models.MaintenanceEvaluatedWebhook `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&delivery)
+		mu.Lock()
+		events[r.Header.Get(EventHeader)] = append(events[r.Header.Get(EventHeader)], delivery.Data)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	st := &fakeStore{
+		hooks: []models.OutboundWebhook{{ID: 1, URL: srv.URL, Secret: "s", Events: []models.WebhookEvent{
+			models.WebhookEventMaintenanceEvaluated, models.WebhookEventMaintenanceThreshold,
+		}}},
+		statuses:  make(map[int64]int),
+		errs:      make(map[int64]string),
+		threshold: 10,
+	}
+	d := New(st, WithClient(srv.Client()))
+	rule := &models.MaintenanceRule{ID: 7, Name: "Unwatched", MediaType: models.MediaTypeMovie}
+	d.MaintenanceEvaluated(rule, 9)
+	d.MaintenanceEvaluated(rule, 10)
+	d.Wait()
+
+	if got := events[string(models.WebhookEventMaintenanceEvaluated)]; len(got) != 2 {
+		t.Errorf("evaluated deliveries = %+v", got)
+	}
+	got := events[string(models.WebhookEventMaintenanceThreshold)]
+	if len(got) != 1 || got[0].RuleID != 7 || got[0].Candidates != 10 || got[0].Threshold != 10 {
+		t.Errorf("threshold deliveries = %+v", got)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// printf '1700000000.abc.{}' | openssl dgst -sha256 -hmac secret
+	const want = "sha256=c298f98d541d2a5fa6efc81e6cfe35504abeb3847802a5791eeac7a19a12361b"
+	if got := Sign("secret", "1700000000", "abc", []byte("{}")); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+// Package webpush sends Web Push messages: payloads encrypted to the browser
+// with aes128gcm (RFC 8291) and requests signed with VAPID (RFC 8292).
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"streammon/internal/models"
+)
+
+// ErrGone means the push service no longer knows the subscription, so it
+// should be forgotten.
+var ErrGone = errors.New("push subscription expired")
+
+// recordSize is the aes128gcm record size. Payloads are sent as a single
+// record, so anything up to this size less overhead fits.
+const recordSize = 4096
+
+// MaxPayload is the largest plaintext Send accepts. Push services must
+// accept 4096-byte bodies; this leaves room for the header and GCM tag.
+const MaxPayload = recordSize - 16 - 1 - 86
+
+// GenerateVAPIDKeys returns a new P-256 key pair for signing push requests.
+func GenerateVAPIDKeys() (models.VAPIDKeys, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return models.VAPIDKeys{}, fmt.Errorf("generating vapid key: %w", err)
+	}
+	priv, err := key.Bytes()
+	if err != nil {
+		return models.VAPIDKeys{}, err
+	}
+	pub, err := key.PublicKey.Bytes()
+	if err != nil {
+		return models.VAPIDKeys{}, err
+	}
+	return models.VAPIDKeys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(pub),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(priv),
+	}, nil
+}
+
+// Message is one push request.
+type Message struct {
+	Payload []byte
+	// Subject is the VAPID contact, a mailto: or https: URL push services
+	// can use to reach the sender.
+	Subject string
+	TTL     time.Duration
+}
+
+// Send encrypts msg to sub and posts it to the subscription's push service.
+func Send(ctx context.Context, client *http.Client, keys models.VAPIDKeys, sub models.PushSubscription, msg Message) error {
+	if len(msg.Payload) > MaxPayload {
+		return fmt.Errorf("payload is %d bytes, limit is %d", len(msg.Payload), MaxPayload)
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" {
+		return errors.New("invalid push endpoint")
+	}
+	body, err := encrypt(sub, msg.Payload)
+	if err != nil {
+		return err
+	}
+	token, err := vapidToken(keys, endpoint.Scheme+"://"+endpoint.Host, msg.Subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ttl := int(msg.TTL / time.Second)
+	if ttl <= 0 {
+		ttl = int((24 * time.Hour) / time.Second)
+	}
+	req.Header.Set("Authorization", "vapid t="+token+", k="+keys.PublicKey)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(ttl))
+	req.Header.Set("Urgency", "high")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending push: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encrypt builds an aes128gcm body for sub as RFC 8291 describes: a fresh
+// ECDH key pair and salt per message, keyed with the subscription's auth
+// secret.
+func encrypt(sub models.PushSubscription, payload []byte) ([]byte, error) {
+	uaPublicRaw, err := decodeKey(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	authSecret, err := decodeKey(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptWith(asPrivate, uaPublic, authSecret, salt, payload)
+}
+
+func encryptWith(asPrivate *ecdh.PrivateKey, uaPublic *ecdh.PublicKey, authSecret, salt, payload []byte) ([]byte, error) {
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	keyInfo := "WebPush: info\x00" + string(uaPublic.Bytes()) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// A single, final record: the payload followed by the 0x02 delimiter.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// vapidToken signs the ES256 JWT push services check against the public
+// key in the Authorization header.
+func vapidToken(keys models.VAPIDKeys, audience, subject string, now time.Time) (string, error) {
+	raw, err := decodeKey(keys.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid vapid key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid vapid key: %w", err)
+	}
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing vapid token: %w", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
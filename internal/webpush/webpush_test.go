@@ -0,0 +1,127 @@
+package webpush
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streammon/internal/models"
+)
+
+func b64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestEncrypt_RFC8291Example checks the worked example in RFC 8291 section 5.
+func TestEncrypt_RFC8291Example(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(b64(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(b64(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := encryptWith(asPrivate, uaPublic,
+		b64(t, "BTBZMqHH6r4Tts7J_aSIgg"),
+		b64(t, "DGv6ra1nlYgDCS1FRnbzlw"),
+		[]byte("When I grow up, I want to be a watermelon"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := base64.RawURLEncoding.EncodeToString(body); got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSend(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser, err := ecdh.P256().GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotAuth, gotEncoding, gotTTL string
+	var gotLen int
+	status := http.StatusCreated
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotEncoding = r.Header.Get("Content-Encoding")
+		gotTTL = r.Header.Get("TTL")
+		gotLen = int(r.ContentLength)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	sub := models.PushSubscription{
+		Endpoint: ts.URL + "/push/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(browser.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+	}
+	msg := Message{Payload: []byte(`{"title":"hi"}`), Subject: "mailto:admin@example.com", TTL: time.Hour}
+	if err := Send(context.Background(), ts.Client(), keys, sub, msg); err != nil {
+		t.Fatal(err)
+	}
+	if gotEncoding != "aes128gcm" || gotTTL != "3600" {
+		t.Errorf("encoding=%q ttl=%q", gotEncoding, gotTTL)
+	}
+	// Header (16+4+1+65) plus payload, delimiter and tag.
+	if want := 86 + len(msg.Payload) + 1 + 16; gotLen != want {
+		t.Errorf("body length = %d, want %d", gotLen, want)
+	}
+
+	token, ok := strings.CutPrefix(gotAuth, "vapid t=")
+	token, pub, ok2 := strings.Cut(token, ", k=")
+	if !ok || !ok2 || pub != keys.PublicKey {
+		t.Fatalf("authorization = %q", gotAuth)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token = %q", token)
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(b64(t, parts[1]), &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Aud != ts.URL || claims.Sub != msg.Subject || claims.Exp <= time.Now().Unix() {
+		t.Errorf("claims = %+v", claims)
+	}
+	pubKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), b64(t, keys.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := b64(t, parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(pubKey, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Error("vapid signature does not verify")
+	}
+
+	status = http.StatusGone
+	if err := Send(context.Background(), ts.Client(), keys, sub, msg); !errors.Is(err, ErrGone) {
+		t.Errorf("410: err = %v, want ErrGone", err)
+	}
+}
@@ -0,0 +1,8 @@
+-- Groups history rows for the same item started within a minute of each
+-- other by different users or devices (watch parties / synced playback).
+-- 0 means the row is not part of a party, otherwise it holds the lowest
+-- history id in the group.
+ALTER TABLE watch_history ADD COLUMN watch_party_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_watch_history_watch_party
+    ON watch_history(watch_party_id)
+    WHERE watch_party_id > 0;
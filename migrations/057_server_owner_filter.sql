@@ -0,0 +1,4 @@
+-- Optional per-server owner account whose plays can be left out of shared
+-- stats (top lists, activity charts) while still appearing in history.
+ALTER TABLE servers ADD COLUMN owner_user_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE servers ADD COLUMN exclude_owner_stats BOOLEAN NOT NULL DEFAULT 0;
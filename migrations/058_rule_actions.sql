@@ -0,0 +1,4 @@
+-- Actions a rule runs when it records a violation, as a JSON array of
+-- {"type": ..., "message": ...} objects. Legacy auto_terminate config flags
+-- keep working alongside this column.
+ALTER TABLE rules ADD COLUMN actions TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,9 @@
+-- Per-library cadence for scheduler-driven syncs. Libraries without a row
+-- follow the default daily 3 AM sync.
+CREATE TABLE IF NOT EXISTS library_sync_schedules (
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    library_id TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (server_id, library_id)
+);
@@ -0,0 +1,8 @@
+-- Inbound playback webhooks from Emby and Jellyfin. Only a SHA-256 hash of
+-- the URL token is kept, the plaintext is shown once when generated.
+CREATE TABLE IF NOT EXISTS server_webhooks (
+    server_id INTEGER PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_received_at DATETIME
+);
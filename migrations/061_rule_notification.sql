@@ -0,0 +1,3 @@
+-- Per-rule notification options as JSON, such as which Discord embed fields
+-- to include. An empty object means defaults.
+ALTER TABLE rules ADD COLUMN notification TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,9 @@
+-- Home locations assigned by an admin. Members without a row fall back to a
+-- home learned from their household locations.
+CREATE TABLE IF NOT EXISTS household_homes (
+    user_name TEXT PRIMARY KEY,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    radius_km REAL NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Per-user overrides of a concurrent streams rule's max_streams. Users
+-- without a row get the rule's own limit.
+CREATE TABLE IF NOT EXISTS rule_user_limits (
+    rule_id     INTEGER NOT NULL REFERENCES rules(id) ON DELETE CASCADE,
+    user_name   TEXT NOT NULL,
+    max_streams INTEGER NOT NULL,
+    PRIMARY KEY (rule_id, user_name)
+);
@@ -0,0 +1,2 @@
+-- Per-account defaults for stats and history requests, as JSON.
+ALTER TABLE users ADD COLUMN preferences TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,10 @@
+-- Snoozed maintenance candidates are hidden from a rule's candidate list
+-- until snoozed_until passes. Snoozes are keyed by rule and library item
+-- rather than candidate id so they survive re-evaluation.
+CREATE TABLE IF NOT EXISTS maintenance_snoozes (
+    rule_id         INTEGER NOT NULL REFERENCES maintenance_rules(id) ON DELETE CASCADE,
+    library_item_id INTEGER NOT NULL REFERENCES library_items(id) ON DELETE CASCADE,
+    snoozed_until   DATETIME NOT NULL,
+    snoozed_by      TEXT NOT NULL,
+    PRIMARY KEY (rule_id, library_item_id)
+);
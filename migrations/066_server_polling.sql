@@ -0,0 +1,4 @@
+-- Per-server poll interval in seconds (0 uses the global POLL_INTERVAL) and
+-- a paused flag that stops polling without disabling the server.
+ALTER TABLE servers ADD COLUMN poll_interval_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE servers ADD COLUMN paused BOOLEAN NOT NULL DEFAULT 0;
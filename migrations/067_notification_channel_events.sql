@@ -0,0 +1,3 @@
+-- Per-channel event matrix as JSON, keyed by event type. An empty object
+-- delivers every event unfiltered.
+ALTER TABLE notification_channels ADD COLUMN events TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,25 @@
+-- Last playback position per user and item, updated from live sessions as
+-- they end. Rows stay after the item is finished (finished = 1) so a later
+-- rewatch can reopen them.
+CREATE TABLE IF NOT EXISTS playback_progress (
+    server_id           INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    user_name           TEXT NOT NULL,
+    item_id             TEXT NOT NULL,
+    grandparent_item_id TEXT NOT NULL DEFAULT '',
+    media_type          TEXT NOT NULL,
+    title               TEXT NOT NULL,
+    parent_title        TEXT NOT NULL DEFAULT '',
+    grandparent_title   TEXT NOT NULL DEFAULT '',
+    year                INTEGER NOT NULL DEFAULT 0,
+    season_number       INTEGER NOT NULL DEFAULT 0,
+    episode_number      INTEGER NOT NULL DEFAULT 0,
+    thumb_url           TEXT NOT NULL DEFAULT '',
+    position_ms         INTEGER NOT NULL,
+    duration_ms         INTEGER NOT NULL,
+    finished            INTEGER NOT NULL DEFAULT 0,
+    first_started_at    DATETIME NOT NULL,
+    last_played_at      DATETIME NOT NULL,
+    PRIMARY KEY (server_id, user_name, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_playback_progress_user ON playback_progress(user_name, finished, last_played_at);
@@ -0,0 +1,3 @@
+-- Optional HMAC signing secret for inbound webhooks, encrypted like other
+-- secrets. Empty means deliveries are authenticated by the URL token alone.
+ALTER TABLE server_webhooks ADD COLUMN signing_secret TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Per-server outbound HTTP settings (proxy, custom CA, TLS verification,
+-- timeout) stored as JSON. An empty object keeps the defaults.
+ALTER TABLE servers ADD COLUMN http_options TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,13 @@
+-- Time-limited links that expose one history entry or rule violation
+-- without a login. Only the token hash is stored.
+CREATE TABLE share_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    target_type TEXT NOT NULL,
+    target_id INTEGER NOT NULL,
+    created_by TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_share_links_expires_at ON share_links(expires_at);
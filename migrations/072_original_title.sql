@@ -0,0 +1,4 @@
+-- Original-language title and played audio language, so plays of one item
+-- from differently localized libraries can be grouped together.
+ALTER TABLE watch_history ADD COLUMN original_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE watch_history ADD COLUMN language TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Media-server accounts an admin has merged into one person. Accounts
+-- without a row are kept apart by server even when their names match.
+CREATE TABLE IF NOT EXISTS user_identity_links (
+    server_id INTEGER NOT NULL REFERENCES servers(id),
+    user_name TEXT NOT NULL,
+    identity TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (server_id, user_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identity_links_identity ON user_identity_links(identity);
@@ -0,0 +1,14 @@
+-- Expiring tokens that give read-only API access to the stats and history
+-- of the listed users. Only the token hash is stored.
+CREATE TABLE guest_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    user_names TEXT NOT NULL DEFAULT '[]',
+    created_by TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    last_used_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_guest_tokens_expires_at ON guest_tokens(expires_at);
@@ -0,0 +1,3 @@
+-- Names of the Plex/Jellyfin/Emby collections each library item belongs to,
+-- as a JSON array, so maintenance rules can protect collection members.
+ALTER TABLE library_items ADD COLUMN collections TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- Per-server lists of library IDs whose plays are recorded (included) or
+-- never recorded (excluded), stored as JSON.
+ALTER TABLE servers ADD COLUMN library_filter TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+-- Content rating (e.g. PG-13, TV-MA, gb/15) as reported by the media server,
+-- for parental analytics and rules.
+ALTER TABLE watch_history ADD COLUMN content_rating TEXT NOT NULL DEFAULT '';
+ALTER TABLE library_items ADD COLUMN content_rating TEXT NOT NULL DEFAULT '';
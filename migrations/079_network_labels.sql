@@ -0,0 +1,14 @@
+-- Named networks matched by CIDR or ASN, and the label each recorded
+-- session was tagged with when it ended.
+CREATE TABLE network_labels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    cidrs TEXT NOT NULL DEFAULT '[]',
+    asns TEXT NOT NULL DEFAULT '[]',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE watch_history ADD COLUMN network_label TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_watch_history_network_label ON watch_history(network_label COLLATE NOCASE);
@@ -0,0 +1,24 @@
+-- Time shifts applied to history rows to repair timezone-skewed imports.
+-- The shifted rows are kept so a repair can be undone exactly.
+CREATE TABLE history_time_repairs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id INTEGER NOT NULL,
+    range_from DATETIME NOT NULL,
+    range_to DATETIME NOT NULL,
+    created_from DATETIME,
+    created_to DATETIME,
+    imported_only INTEGER NOT NULL DEFAULT 0,
+    offset_minutes INTEGER NOT NULL,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    undone_at DATETIME
+);
+
+CREATE TABLE history_time_repair_rows (
+    repair_id INTEGER NOT NULL REFERENCES history_time_repairs(id) ON DELETE CASCADE,
+    history_id INTEGER NOT NULL REFERENCES watch_history(id) ON DELETE CASCADE,
+    PRIMARY KEY (repair_id, history_id)
+);
+
+CREATE INDEX idx_history_time_repair_rows_history ON history_time_repair_rows(history_id);
@@ -0,0 +1,6 @@
+-- Remember the OIDC login behind each session so IdP logout can end it
+ALTER TABLE sessions ADD COLUMN oidc_sub TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN oidc_sid TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN oidc_id_token TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_sessions_oidc_sub ON sessions(oidc_sub) WHERE oidc_sub != '';
+CREATE INDEX IF NOT EXISTS idx_sessions_oidc_sid ON sessions(oidc_sid) WHERE oidc_sid != '';
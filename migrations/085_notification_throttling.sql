@@ -0,0 +1,20 @@
+-- Channel quiet hours, and a history of notifications sent, failed, or held back
+ALTER TABLE notification_channels ADD COLUMN quiet_hours TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE notification_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id INTEGER,
+    rule_name TEXT NOT NULL DEFAULT '',
+    channel_id INTEGER NOT NULL,
+    channel_name TEXT NOT NULL,
+    channel_type TEXT NOT NULL,
+    event TEXT NOT NULL,
+    user_name TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_history_created_at ON notification_history(created_at);
+CREATE INDEX idx_notification_history_channel ON notification_history(channel_id, created_at);
@@ -0,0 +1,4 @@
+-- Keep what each notification was built from so it can be sent again
+ALTER TABLE notification_history ADD COLUMN payload TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_history ADD COLUMN retry_of INTEGER;
+CREATE INDEX idx_notification_history_status ON notification_history(status, created_at);
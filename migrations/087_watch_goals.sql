@@ -0,0 +1,9 @@
+-- Per-user weekly watch-time targets and limits
+CREATE TABLE watch_goals (
+    user_name TEXT PRIMARY KEY,
+    weekly_target_minutes INTEGER NOT NULL DEFAULT 0,
+    weekly_limit_minutes INTEGER NOT NULL DEFAULT 0,
+    limit_notified_week TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
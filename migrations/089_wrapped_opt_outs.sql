@@ -0,0 +1,5 @@
+-- Users who keep their yearly recap private
+CREATE TABLE wrapped_opt_outs (
+    user_name TEXT PRIMARY KEY,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- Weekly and monthly activity digests sent to notification channels
+CREATE TABLE digest_schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    period TEXT NOT NULL,
+    channel_ids TEXT NOT NULL DEFAULT '[]',
+    personalized INTEGER NOT NULL DEFAULT 0,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_sent_period TEXT NOT NULL DEFAULT '',
+    last_sent_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- Hourly bandwidth per server, user and network, accumulated by the poller from live sessions.
+CREATE TABLE IF NOT EXISTS bandwidth_samples (
+    bucket DATETIME NOT NULL,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    user_name TEXT NOT NULL,
+    network TEXT NOT NULL,
+    bytes INTEGER NOT NULL DEFAULT 0,
+    peak_bps INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket, server_id, user_name, network)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bandwidth_samples_user ON bandwidth_samples(user_name, bucket);
@@ -0,0 +1,2 @@
+-- Covers the shared-IP report's grouping of a time window by user and IP
+CREATE INDEX IF NOT EXISTS idx_watch_history_started_ip_user ON watch_history(started_at, ip_address, user_name);
@@ -0,0 +1,15 @@
+-- Media server user membership, archived with final stats when a user drops off a server's user list
+CREATE TABLE IF NOT EXISTS server_users (
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    user_name TEXT NOT NULL,
+    first_seen_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    missed_syncs INTEGER NOT NULL DEFAULT 0,
+    archived_at DATETIME,
+    final_plays INTEGER NOT NULL DEFAULT 0,
+    final_watched_ms INTEGER NOT NULL DEFAULT 0,
+    final_last_streamed_at DATETIME,
+    PRIMARY KEY (server_id, user_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_users_archived ON server_users(archived_at);
@@ -0,0 +1,16 @@
+-- Do-not-track rules: sessions matching an enabled rule are never written to
+-- watch history. suppressed_count tallies the sessions each rule dropped.
+CREATE TABLE do_not_track_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    server_id INTEGER NOT NULL DEFAULT 0,
+    user_name TEXT NOT NULL DEFAULT '',
+    device TEXT NOT NULL DEFAULT '',
+    library_id TEXT NOT NULL DEFAULT '',
+    ip_range TEXT NOT NULL DEFAULT '',
+    suppressed_count INTEGER NOT NULL DEFAULT 0,
+    last_suppressed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- Milestones already announced for each user, so each is announced once.
+CREATE TABLE user_milestones (
+    user_name TEXT NOT NULL,
+    milestone TEXT NOT NULL,
+    reached_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_name, milestone)
+);
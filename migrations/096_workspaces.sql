@@ -0,0 +1,22 @@
+-- Workspaces isolate groups of servers, and the StreamMon users who may see them, from each other.
+CREATE TABLE workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE workspace_servers (
+    server_id INTEGER PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_workspace_servers_workspace ON workspace_servers(workspace_id);
+
+CREATE TABLE workspace_members (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member'
+);
+
+CREATE INDEX idx_workspace_members_workspace ON workspace_members(workspace_id);
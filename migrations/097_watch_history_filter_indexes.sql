@@ -0,0 +1,9 @@
+-- Composite indexes for the history list's investigation filters, each ending in started_at for the default sort
+DROP INDEX IF EXISTS idx_watch_history_transcode;
+DROP INDEX IF EXISTS idx_watch_history_resolution;
+CREATE INDEX IF NOT EXISTS idx_watch_history_transcode_started ON watch_history(transcode_decision, started_at);
+CREATE INDEX IF NOT EXISTS idx_watch_history_user_transcode_started ON watch_history(user_name, transcode_decision, started_at);
+CREATE INDEX IF NOT EXISTS idx_watch_history_resolution_started ON watch_history(video_resolution, started_at);
+CREATE INDEX IF NOT EXISTS idx_watch_history_platform_started ON watch_history(platform COLLATE NOCASE, started_at);
+CREATE INDEX IF NOT EXISTS idx_watch_history_player_started ON watch_history(player COLLATE NOCASE, started_at);
+CREATE INDEX IF NOT EXISTS idx_watch_history_ip_started ON watch_history(ip_address, started_at);
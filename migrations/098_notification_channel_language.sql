@@ -0,0 +1,2 @@
+-- Per-channel notification language, empty meaning English
+ALTER TABLE notification_channels ADD COLUMN language TEXT NOT NULL DEFAULT '';
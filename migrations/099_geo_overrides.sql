@@ -0,0 +1,13 @@
+-- Admin-managed locations for known networks, consulted before the geolocation database
+CREATE TABLE IF NOT EXISTS geo_overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cidr TEXT NOT NULL UNIQUE,
+    label TEXT NOT NULL,
+    city TEXT NOT NULL DEFAULT '',
+    country TEXT NOT NULL DEFAULT '',
+    lat REAL NOT NULL DEFAULT 0,
+    lng REAL NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE ip_geo_cache ADD COLUMN label TEXT NOT NULL DEFAULT '';
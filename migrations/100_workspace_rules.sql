@@ -0,0 +1,6 @@
+-- Rules and notification channels can belong to a workspace. NULL keeps them global.
+ALTER TABLE rules ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE notification_channels ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_rules_workspace ON rules(workspace_id);
+CREATE INDEX idx_notification_channels_workspace ON notification_channels(workspace_id);
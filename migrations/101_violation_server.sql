@@ -0,0 +1,5 @@
+-- The server a violation happened on and what it cost the user's trust score,
+-- so a workspace sees only its own servers' violations and score.
+ALTER TABLE rule_violations ADD COLUMN server_id INTEGER;
+ALTER TABLE rule_violations ADD COLUMN trust_decrement INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_violations_server ON rule_violations(server_id);